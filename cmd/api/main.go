@@ -2,24 +2,58 @@ package main
 
 import (
 	"context"
+	"expvar"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	expvarmw "github.com/gofiber/fiber/v2/middleware/expvar"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/accesslog"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/adaptivelimit"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/adminui"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/antireplay"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apikey"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/cache"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/captcha"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/claimbuffer"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/claimrecord"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/claimsim"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/config"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/enumguard"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/eventlog"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/failover"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/grant"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/handler"
-	"github.com/fairyhunter13/scalable-coupon-system/internal/repository"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/hedge"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/killswitch"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/metaschema"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/pacing"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/poolwatch"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/runtimelimits"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/seed"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/stockwait"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/store"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/webhook"
 	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/lifecycle"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/objstore"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/workpool"
 )
 
 func main() {
@@ -32,6 +66,16 @@ func main() {
 	// Initialize zerolog based on configuration
 	initLogger(cfg)
 
+	// Fit the runtime to the container's CPU and memory limits
+	runtimeLimits := runtimelimits.Apply(cfg.Runtime.MemoryLimitRatio)
+	log.Info().
+		Int("gomaxprocs", runtimeLimits.GOMAXPROCS).
+		Str("gomaxprocs_source", runtimeLimits.GOMAXPROCSSource).
+		Int("num_cpu", runtimeLimits.NumCPU).
+		Int64("memory_limit", runtimeLimits.MemoryLimit).
+		Str("memory_limit_source", runtimeLimits.MemoryLimitSource).
+		Msg("runtime limits")
+
 	// Log security warnings for default credentials
 	for _, warning := range cfg.WarnIfDefaultCredentials() {
 		log.Warn().Msg(warning)
@@ -40,52 +84,747 @@ func main() {
 	// Create context for startup
 	ctx := context.Background()
 
-	// Initialize database pool with retry
-	pool, err := database.NewPool(ctx, cfg.DB.DSN(), 5)
+	// Open the storage backend selected by DB_DRIVER (connects with retry, or in the
+	// background with DB_DEGRADED_START)
+	st, err := store.Open(ctx, cfg.DB)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to connect to database")
 	}
+	expvar.Publish("db_pools", expvar.Func(func() any { return st.PoolStats() }))
+	expvar.Publish("db_statements", expvar.Func(func() any { return st.StatementMetrics().Stats() }))
+	if retrier := st.ReadRetrier(); retrier != nil {
+		expvar.Publish("read_retry", expvar.Func(func() any { return retrier.Stats() }))
+		log.Info().Msg("read retry enabled")
+	}
+
+	// Subsystems are stopped in reverse dependency order: the HTTP server first, then
+	// background workers, then what they use (claim buffer, database pool)
+	components := lifecycle.New()
+	addComponent := func(c lifecycle.Component) {
+		if err := components.Add(c); err != nil {
+			log.Fatal().Err(err).Msg("failed to register component")
+		}
+	}
+	addComponent(lifecycle.Component{
+		Name: "database",
+		Stop: func(context.Context) error { st.Close(); return nil },
+	})
+	if cfg.DB.DegradedStart {
+		// Readiness fails until the database is reachable; requests needing it fail
+		addComponent(lifecycle.Component{
+			Name:      "database_connect",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { _ = database.AwaitConnection(ctx, st.Ping) },
+		})
+		log.Info().Msg("degraded start enabled: connecting to the database in the background")
+	}
+
+	// Access log lines go to stdout unless ACCESS_LOG_SINK names a file or syslog
+	var accessLog io.Writer
+	if cfg.Access.Sink != accesslog.Stdout {
+		sink, err := accesslog.Open(cfg.Access.Options())
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open access log")
+		}
+		accessLog = sink
+		addComponent(lifecycle.Component{
+			Name: "access_log",
+			Stop: func(context.Context) error { return sink.Close() },
+		})
+		log.Info().Str("sink", cfg.Access.Sink).Msg("access log sink enabled")
+	}
 
 	// Initialize Fiber with production-ready configuration
 	app := fiber.New(fiber.Config{
 		AppName:      "Scalable Coupon System",
-		ReadTimeout:  30 * time.Second,  // Max time to read request
-		WriteTimeout: 30 * time.Second,  // Max time to write response
-		IdleTimeout:  120 * time.Second, // Max time for keep-alive connections
-		BodyLimit:    1 * 1024 * 1024,   // 1MB body limit (explicit, prevents large payloads)
+		ReadTimeout:  cfg.Server.ReadTimeout,    // Max time to read request
+		WriteTimeout: cfg.Server.WriteTimeout,   // Max time to write response
+		IdleTimeout:  cfg.Server.IdleTimeout,    // Max time for keep-alive connections
+		BodyLimit:    cfg.Server.MaxBodyLimit(), // Routes enforce their own (smaller) limits
 	})
+	log.Info().
+		Dur("read_timeout", cfg.Server.ReadTimeout).
+		Dur("write_timeout", cfg.Server.WriteTimeout).
+		Dur("idle_timeout", cfg.Server.IdleTimeout).
+		Int("body_limit", cfg.Server.BodyLimit).
+		Int("default_page_size", cfg.Server.DefaultPageSize).
+		Int("max_page_size", cfg.Server.MaxPageSize).
+		Msg("server limits")
+	handler.SetPageSizes(cfg.Server.DefaultPageSize, cfg.Server.MaxPageSize)
+
+	// limits bounds the body size and handling time of the named route
+	limits := func(route string) fiber.Handler {
+		timeout, bodyLimit := cfg.Server.Route(route)
+		_, hasTimeout := cfg.Server.RouteTimeouts[route]
+		_, hasBodyLimit := cfg.Server.RouteBodyLimits[route]
+		if hasTimeout || hasBodyLimit {
+			log.Info().Str("route", route).Dur("timeout", timeout).Int("body_limit", bodyLimit).Msg("route limits")
+		}
+		return handler.RouteLimits(timeout, bodyLimit)
+	}
 
 	// Middleware
 	app.Use(recover.New())
 	app.Use(requestid.New()) // Adds X-Request-ID header to all requests
-	app.Use(logger.New())
+	app.Use(logger.New(accessLogConfig(accessLog)))
+	// Answers OPTIONS for every route; CORS headers only for CORS_ALLOW_ORIGINS
+	app.Use(handler.CORS(handler.CORSConfig{
+		AllowOrigins: cfg.CORS.AllowOrigins,
+		AllowHeaders: cfg.CORS.AllowHeaders,
+		MaxAge:       cfg.CORS.MaxAge,
+	}))
+	if len(cfg.CORS.AllowOrigins) > 0 {
+		log.Info().Strs("origins", cfg.CORS.AllowOrigins).Dur("max_age", cfg.CORS.MaxAge).Msg("cors enabled")
+	}
+	app.Use(expvarmw.New()) // Serves /debug/vars (runtime and cache metrics)
+	if cfg.Server.MaxRequestDeadline > 0 {
+		// Gateways pass their callers' deadlines on, so work stops when nobody waits for it
+		app.Use(handler.RequestDeadline(cfg.Server.MaxRequestDeadline))
+		log.Info().Dur("max", cfg.Server.MaxRequestDeadline).Msg("request deadline header enabled")
+	}
+
+	// Global kill switch: while engaged, writes get 503 but reads and health checks pass
+	var killStore killswitch.Store
+	if cfg.Kill.RedisURL != "" {
+		redisStore, err := killswitch.NewRedisStore(cfg.Kill.RedisURL, cfg.Kill.RedisTimeout)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid kill switch Redis URL")
+		}
+		addComponent(lifecycle.Component{
+			Name: "kill_switch_redis",
+			Stop: func(context.Context) error { return redisStore.Close() },
+		})
+		killStore = redisStore
+	}
+	killSwitch := killswitch.New(cfg.Kill.Engaged, cfg.Kill.Message, killStore)
+	if killStore != nil {
+		// Picks up the switch toggled on any replica
+		addComponent(lifecycle.Component{
+			Name:      "kill_switch",
+			DependsOn: []string{"kill_switch_redis"},
+			Run:       func(ctx context.Context) { killSwitch.Run(ctx, cfg.Kill.PollInterval) },
+		})
+	}
+	app.Use(killSwitch.Handler("/api/admin/killswitch"))
+	expvar.Publish("kill_switch", expvar.Func(func() any { return killSwitch.Stats() }))
+	if cfg.Kill.Engaged {
+		log.Warn().Str("message", cfg.Kill.Message).Msg("kill switch engaged, writes are paused")
+	}
 
 	// Initialize validator with custom validations
 	validate := validator.New()
 
 	// Initialize coupon components (layered architecture)
-	couponRepo := repository.NewCouponRepository(pool)
-	claimRepo := repository.NewClaimRepository(pool)
-	couponService := service.NewCouponService(pool, couponRepo, claimRepo)
+	couponService := service.NewCouponServiceWithTransactor(st, st.Coupons(), st.Claims())
+	if cfg.Cache.CouponTTL > 0 {
+		couponCache := cache.NewLRU[string, *model.CouponResponse](cfg.Cache.CouponSize, cfg.Cache.CouponTTL)
+		couponService.SetCache(couponCache)
+		expvar.Publish("coupon_cache", expvar.Func(func() any { return couponCache.Stats() }))
+		log.Info().Dur("ttl", cfg.Cache.CouponTTL).Int("size", cfg.Cache.CouponSize).Msg("coupon cache enabled")
+	}
+	if cfg.Dedup.Window > 0 {
+		couponService.SetClaimDedup(cfg.Dedup.Window, cfg.Dedup.Size)
+		expvar.Publish("claim_dedup", expvar.Func(func() any { return couponService.ClaimDedupStats() }))
+		log.Info().Dur("window", cfg.Dedup.Window).Int("size", cfg.Dedup.Size).Msg("claim deduplication enabled")
+	}
+	if cfg.Filter.Capacity > 0 {
+		couponService.SetClaimFilter(cfg.Filter.Capacity, cfg.Filter.FPRate)
+		expvar.Publish("claim_filter", expvar.Func(func() any { return couponService.ClaimFilterStats() }))
+		log.Info().Int("capacity", cfg.Filter.Capacity).Float64("fp_rate", cfg.Filter.FPRate).Msg("claimed filter enabled")
+	}
+	if cfg.Shadow.Strategy != "" {
+		strategy := service.NewOptimisticClaimStrategy(st.Coupons(), st.Claims())
+		couponService.SetClaimShadow(strategy, cfg.Shadow.SampleRate, cfg.Shadow.MaxInFlight)
+		addComponent(lifecycle.Component{
+			Name:      "claim_shadow",
+			DependsOn: []string{"database"},
+			Stop:      couponService.DrainClaimShadow,
+		})
+		expvar.Publish("claim_shadow", expvar.Func(func() any { return couponService.ClaimShadowStats() }))
+		log.Info().
+			Str("strategy", strategy.Name()).
+			Float64("sample_rate", cfg.Shadow.SampleRate).
+			Msg("claim shadow mode enabled")
+	}
+	if cfg.Events.Sink != "" {
+		sink, err := eventlog.Open(cfg.Events.Options())
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open domain event log")
+		}
+		addComponent(lifecycle.Component{
+			Name: "event_log",
+			Stop: func(context.Context) error { return sink.Close() },
+		})
+		eventLog := eventlog.New(sink)
+		couponService.SetDomainEventLog(eventLog)
+		expvar.Publish("event_log", expvar.Func(func() any { return eventLog.Stats() }))
+		log.Info().Str("sink", cfg.Events.Sink).Msg("domain event log enabled")
+	}
+	if cfg.Budget.MinBudget > 0 {
+		couponService.SetMinClaimBudget(cfg.Budget.MinBudget)
+		expvar.Publish("claim_budget", expvar.Func(func() any { return couponService.ClaimBudgetStats() }))
+		log.Info().Dur("min_budget", cfg.Budget.MinBudget).Msg("claim deadline budget enabled")
+	}
+	// Claims sent with an API key are limited and prioritized by its tier when enabled
+	var keys *apikey.Keys
+	var apiKeyHandler *handler.APIKeyHandler
+	if cfg.Keys.KeysFile != "" {
+		var err error
+		keys, err = apikey.Load(cfg.Keys.KeysFile, apikey.Config{
+			StandardRate: cfg.Keys.StandardRate,
+			PremiumRate:  cfg.Keys.PremiumRate,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Str("path", cfg.Keys.KeysFile).Msg("failed to load API keys")
+		}
+		expvar.Publish("api_keys", expvar.Func(func() any { return keys.Stats() }))
+		log.Info().
+			Str("path", cfg.Keys.KeysFile).
+			Int("keys", keys.Len()).
+			Float64("standard_rate", cfg.Keys.StandardRate).
+			Float64("premium_rate", cfg.Keys.PremiumRate).
+			Msg("API keys enabled")
+		if cfg.Keys.UsageEnabled {
+			// Claims count against the keys' monthly quotas, shared through the database
+			keys.SetUsageStore(st.APIKeyUsage())
+			addComponent(lifecycle.Component{
+				Name:      "api_key_usage",
+				DependsOn: []string{"database"},
+				Run:       func(ctx context.Context) { keys.RunUsage(ctx, cfg.Keys.UsageFlushInterval) },
+				Stop:      keys.Flush, // The claims counted since the last flush
+			})
+			apiKeyHandler = handler.NewAPIKeyHandler(keys)
+			log.Info().Dur("flush_interval", cfg.Keys.UsageFlushInterval).Msg("API key usage enabled")
+		} else if keys.HasQuotas() {
+			log.Fatal().Str("path", cfg.Keys.KeysFile).Msg("API keys set monthly_quota, which requires API_KEY_USAGE_ENABLED")
+		}
+	}
+	if cfg.Quota.PoolShare > 0 {
+		slots := cfg.Quota.Slots(cfg.DB.MaxConns)
+		couponService.SetHighProfileQuota(slots)
+		expvar.Publish("high_profile_quota", expvar.Func(func() any { return couponService.HighProfileStats() }))
+		log.Info().
+			Float64("pool_share", cfg.Quota.PoolShare).
+			Int("slots", slots).
+			Msg("high-profile coupon quota enabled")
+	}
+	if cfg.Pace.Rate > 0 {
+		var store pacing.Store = pacing.NewMemoryStore()
+		if cfg.Pace.RedisURL != "" {
+			redisStore, err := pacing.NewRedisStore(cfg.Pace.RedisURL, cfg.Pace.RedisTimeout)
+			if err != nil {
+				log.Fatal().Err(err).Msg("invalid claim pacing Redis URL")
+			}
+			addComponent(lifecycle.Component{
+				Name: "claim_pacing_redis",
+				Stop: func(context.Context) error { return redisStore.Close() },
+			})
+			store = redisStore
+		}
+		paceCfg := pacing.Config{Rate: cfg.Pace.Rate, MaxQueue: cfg.Pace.MaxQueue}
+		if keys != nil {
+			paceCfg.PriorityMaxQueue = cfg.Keys.PremiumMaxQueue
+			paceCfg.Priority = apikey.Priority
+		}
+		pacer := pacing.New(store, paceCfg)
+		couponService.SetClaimPacer(pacer)
+		expvar.Publish("claim_pacing", expvar.Func(func() any {
+			return map[string]any{"coupons": pacer.Stats(), "store_errors": pacer.Errors()}
+		}))
+		log.Info().
+			Float64("rate", cfg.Pace.Rate).
+			Int("max_queue", cfg.Pace.MaxQueue).
+			Bool("redis", cfg.Pace.RedisURL != "").
+			Msg("claim pacing enabled")
+	}
+	if cfg.Meta.SchemaPath != "" {
+		schema, err := metaschema.Load(cfg.Meta.SchemaPath)
+		if err != nil {
+			log.Fatal().Err(err).Str("path", cfg.Meta.SchemaPath).Msg("failed to load coupon metadata schema")
+		}
+		couponService.SetMetadataSchema(schema)
+		log.Info().Str("path", cfg.Meta.SchemaPath).Msg("coupon metadata schema enabled")
+	}
+	if cfg.Hedge.Enabled {
+		hedger := hedge.New(cfg.Hedge.MinDelay)
+		couponService.SetHedger(hedger)
+		expvar.Publish("read_hedging", expvar.Func(func() any { return hedger.Stats() }))
+		log.Info().Dur("min_delay", cfg.Hedge.MinDelay).Msg("hedged reads enabled")
+	}
+	var webhookHandler *handler.WebhookHandler
+	if cfg.Webhook.Enabled {
+		sender := webhook.NewSender(cfg.Webhook.Timeout)
+		webhookService := service.NewWebhookService(st.Webhooks(), st.Jobs(), sender)
+		couponService.SetEventPublisher(webhookService)
+		// Deliveries to external endpoints run on one bounded pool, however many are due
+		deliveries, err := workpool.New(workpool.Config{
+			Size:       cfg.Deliver.Workers,
+			QueueDepth: cfg.Deliver.QueueDepth,
+			Overflow:   cfg.Deliver.Overflow,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create delivery pool")
+		}
+		addComponent(lifecycle.Component{
+			Name:      "delivery_pool",
+			DependsOn: []string{"database"},
+			Run:       deliveries.Run,
+		})
+		expvar.Publish("delivery_pool", expvar.Func(func() any { return deliveries.Stats() }))
+		log.Info().
+			Int("workers", cfg.Deliver.Workers).
+			Int("queue_depth", cfg.Deliver.QueueDepth).
+			Str("overflow", cfg.Deliver.Overflow).
+			Msg("delivery pool enabled")
+
+		worker := jobs.NewWorker(st.Jobs(), service.WebhookQueue, webhookService.Deliver, jobs.WorkerConfig{Pool: deliveries})
+		addComponent(lifecycle.Component{
+			Name:      "webhook_worker",
+			DependsOn: []string{"database", "delivery_pool"},
+			Run:       worker.Run,
+		})
+		webhookHandler = handler.NewWebhookHandler(webhookService, validate)
+		expvar.Publish("webhooks", expvar.Func(func() any {
+			return map[string]any{"deliveries": sender.Stats(), "jobs": worker.Stats()}
+		}))
+		log.Info().Dur("timeout", cfg.Webhook.Timeout).Msg("coupon webhooks enabled")
+	}
+	couponService.SetImportChunkSize(cfg.Import.ChunkSize)
+	couponService.SetAuditLog(st.Audit())
+	expvar.Publish("claim_import", expvar.Func(func() any { return couponService.ClaimImportStats() }))
+	if cfg.Names.Interval > 0 {
+		couponService.SetCouponNameFilter(cfg.Names.Capacity, cfg.Names.FPRate)
+		addComponent(lifecycle.Component{
+			Name:      "coupon_name_filter",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { couponService.RunCouponNameFilter(ctx, cfg.Names.Interval) },
+		})
+		expvar.Publish("coupon_name_filter", expvar.Func(func() any { return couponService.CouponNameFilterStats() }))
+		log.Info().Dur("interval", cfg.Names.Interval).Int("capacity", cfg.Names.Capacity).Msg("coupon name filter enabled")
+	}
+	var campaignHandler *handler.CampaignHandler
+	if cfg.Caps.Enabled {
+		couponService.SetCampaignCaps(st.CampaignCaps())
+		campaignHandler = handler.NewCampaignHandler(couponService, validate)
+		log.Info().Msg("campaign claim caps enabled")
+	}
+	var allowlistHandler *handler.AllowlistHandler
+	if cfg.Allow.Enabled {
+		couponService.SetAllowlists(st.Allowlists())
+		allowlistHandler = handler.NewAllowlistHandler(couponService, validate)
+		log.Info().Msg("coupon allowlists enabled")
+	}
+	// Heavy periodic jobs keep to the maintenance windows and stand back under load
+	var heavyJobs service.JobGate
+	if cfg.Heavy.Scheduled() {
+		windows, _ := jobs.ParseWindows(cfg.Heavy.Windows) // Validated by config
+		schedule := jobs.NewSchedule(jobs.ScheduleConfig{
+			Windows:           windows,
+			MaxReplicationLag: cfg.Heavy.MaxReplicationLag,
+			MaxActiveConns:    cfg.Heavy.MaxActiveConns,
+			Load:              st.ServerLoad,
+		})
+		heavyJobs = schedule
+		couponService.SetJobGate(schedule)
+		expvar.Publish("heavy_job_schedule", expvar.Func(func() any { return schedule.Stats() }))
+		log.Info().
+			Str("windows", cfg.Heavy.Windows).
+			Dur("max_replication_lag", cfg.Heavy.MaxReplicationLag).
+			Int64("max_active_conns", cfg.Heavy.MaxActiveConns).
+			Msg("heavy job schedule enabled")
+	}
+	var couponDeleteHandler *handler.CouponDeleteHandler
+	if cfg.Delete.UndoWindow > 0 {
+		couponService.SetCouponDeletion(st.Tombstones(), cfg.Delete.UndoWindow)
+		addComponent(lifecycle.Component{
+			Name:      "coupon_purge",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { couponService.RunCouponPurge(ctx, cfg.Delete.PurgeInterval) },
+		})
+		couponDeleteHandler = handler.NewCouponDeleteHandler(couponService)
+		expvar.Publish("coupon_purge", expvar.Func(func() any { return couponService.CouponPurgeStats() }))
+		log.Info().
+			Dur("undo_window", cfg.Delete.UndoWindow).
+			Dur("purge_interval", cfg.Delete.PurgeInterval).
+			Msg("coupon deletion enabled")
+	}
+	var archiveHandler *handler.ArchiveHandler
+	if cfg.Archive.Enabled {
+		// Purged coupons stay readable from the archive
+		couponService.SetCouponArchive(st.Archive())
+		archiveHandler = handler.NewArchiveHandler(couponService, cfg.Archive.CacheMaxAge)
+		log.Info().Dur("cache_max_age", cfg.Archive.CacheMaxAge).Msg("coupon archive enabled")
+	}
+	if cfg.Retain.Enabled {
+		couponService.SetClaimRetention(st.ClaimRetention())
+		addComponent(lifecycle.Component{
+			Name:      "claim_retention",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { couponService.RunClaimRetention(ctx, cfg.Retain.Interval) },
+		})
+		expvar.Publish("claim_retention", expvar.Func(func() any { return couponService.ClaimRetentionStats() }))
+		log.Info().Dur("interval", cfg.Retain.Interval).Msg("claim retention enabled")
+	}
+	if cfg.Export.Store != "" {
+		exportStore, err := analyticsExportStore(cfg.Export)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to configure analytics export store")
+		}
+		exporter := service.NewAnalyticsExporter(st.AnalyticsExport(), exportStore, cfg.Export.Prefix, cfg.Export.LookbackDays)
+		exporter.SetJobGate(heavyJobs)
+		addComponent(lifecycle.Component{
+			Name:      "analytics_export",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { exporter.Run(ctx, cfg.Export.Interval) },
+		})
+		expvar.Publish("analytics_export", expvar.Func(func() any { return exporter.Stats() }))
+		log.Info().
+			Str("store", cfg.Export.Store).
+			Str("prefix", cfg.Export.Prefix).
+			Dur("interval", cfg.Export.Interval).
+			Msg("analytics export enabled")
+	}
+	var loadTestHandler *handler.LoadTestHandler
+	if cfg.Load.Enabled {
+		couponService.SetLoadTests(st.LoadTests())
+		addComponent(lifecycle.Component{
+			Name:      "loadtest_cleanup",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { couponService.RunLoadTestCleanup(ctx, cfg.Load.CleanupInterval) },
+		})
+		loadTestHandler = handler.NewLoadTestHandler(couponService, validate)
+		expvar.Publish("loadtest", expvar.Func(func() any { return couponService.LoadTestStats() }))
+		log.Warn().Dur("cleanup_interval", cfg.Load.CleanupInterval).Msg("load test API enabled; do not enable in production")
+	}
+	// Requests waiting for stock are woken by notifications of the coupons' top-ups
+	var stockWaiters *stockwait.Hub
+	var stockWaitHandler *handler.StockWaitHandler
+	if cfg.Wait.Enabled {
+		stockWaiters = stockwait.New(cfg.Wait.MaxWaiters)
+		listener := st.StockListener() // Not nil on PostgreSQL, validated by config.Load
+		couponService.SetStockWaiter(stockWaiters)
+		addComponent(lifecycle.Component{
+			Name:      "stock_listener",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { listener.Run(ctx, stockWaiters.Notify) },
+		})
+		stockWaitHandler = handler.NewStockWaitHandler(couponService, cfg.Wait.MaxTimeout)
+		expvar.Publish("stock_wait", expvar.Func(func() any {
+			return map[string]any{"waiters": stockWaiters.Stats(), "listener": listener.Stats()}
+		}))
+		log.Info().
+			Dur("max_timeout", cfg.Wait.MaxTimeout).
+			Int("max_waiters", cfg.Wait.MaxWaiters).
+			Msg("waiting for stock enabled")
+	}
+	couponService.SetClaimedByLimit(cfg.Privacy.Max)
+	if cfg.Seed.File != "" {
+		seedCoupons(ctx, couponService, cfg.Seed.File)
+	}
 	couponHandler := handler.NewCouponHandler(couponService, validate)
-	claimHandler := handler.NewClaimHandler(couponService, validate)
+	if err := couponHandler.SetClaimedByPrivacy(cfg.Privacy.Mode, cfg.Privacy.HashKey); err != nil {
+		log.Fatal().Err(err).Msg("failed to configure claimed_by privacy")
+	}
+	if cfg.Privacy.Mode != handler.ClaimedByFull {
+		log.Info().Str("mode", cfg.Privacy.Mode).Msg("claimed_by privacy enabled")
+	}
+	var claimService handler.ClaimServiceInterface = couponService
+	if cfg.Buffer.Path != "" {
+		claimBuffer, err := claimbuffer.Open(cfg.Buffer.Path, cfg.Buffer.MaxEntries)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open claim buffer")
+		}
+		storeForward := service.NewStoreAndForward(couponService, claimBuffer, cfg.Buffer.MaxAge)
+		claimService = storeForward
+		addComponent(lifecycle.Component{
+			Name: "claim_buffer",
+			Stop: func(context.Context) error { return claimBuffer.Close() },
+		})
+		// Unreplayed claims stay in the buffer file
+		addComponent(lifecycle.Component{
+			Name:      "claim_replay",
+			DependsOn: []string{"database", "claim_buffer"},
+			Run:       func(ctx context.Context) { storeForward.Run(ctx, cfg.Buffer.ReplayInterval) },
+		})
+		log.Info().
+			Str("path", cfg.Buffer.Path).
+			Int("pending", claimBuffer.Len()).
+			Msg("claim store-and-forward enabled")
+	}
+	claimHandler := handler.NewClaimHandler(claimService, validate)
+	if err := claimHandler.SetClaimedByPrivacy(cfg.Privacy.Mode, cfg.Privacy.HashKey); err != nil {
+		log.Fatal().Err(err).Msg("failed to configure claimed_by privacy")
+	}
+	if cfg.Captcha.Provider != "" {
+		verifier, err := captcha.New(cfg.Captcha.Provider, cfg.Captcha.Secret, cfg.Captcha.Timeout)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to configure captcha verification")
+		}
+		claimHandler.SetCaptchaVerifier(verifier)
+		expvar.Publish("captcha", expvar.Func(func() any { return verifier.Stats() }))
+		log.Info().Str("provider", cfg.Captcha.Provider).Msg("captcha verification enabled")
+	}
+	if cfg.Grant.Secret != "" {
+		signer := grant.New([]byte(cfg.Grant.Secret))
+		claimHandler.SetGrantSigner(signer)
+		expvar.Publish("claim_grants", expvar.Func(func() any { return signer.Stats() }))
+		log.Info().Msg("claim grants enabled")
+	}
+	// Routes revealing whether a coupon exists are guarded against enumeration when enabled
+	guard := func(c *fiber.Ctx) error { return c.Next() }
+	if cfg.Enum.Enabled {
+		enumGuard := enumguard.New(enumguard.Config{
+			MinLatency: cfg.Enum.MinLatency,
+			Jitter:     cfg.Enum.Jitter,
+			Threshold:  cfg.Enum.Threshold,
+			Window:     cfg.Enum.Window,
+			Block:      cfg.Enum.Block,
+			MaxBlock:   cfg.Enum.MaxBlock,
+			MaxIPs:     cfg.Enum.MaxIPs,
+			Headers:    cfg.Enum.RateLimitHeaders,
+		})
+		guard = enumGuard.Handler()
+		expvar.Publish("enumeration_guard", expvar.Func(func() any { return enumGuard.Stats() }))
+		log.Info().
+			Dur("min_latency", cfg.Enum.MinLatency).
+			Int("threshold", cfg.Enum.Threshold).
+			Dur("window", cfg.Enum.Window).
+			Bool("rate_limit_headers", cfg.Enum.RateLimitHeaders).
+			Msg("enumeration guard enabled")
+	}
+	// Claims resent with the same X-Request-ID get the first response when enabled
+	antiReplay := func(c *fiber.Ctx) error { return c.Next() }
+	if cfg.Replay.Window > 0 {
+		replayGuard := antireplay.New(cfg.Replay.Window, cfg.Replay.Size)
+		antiReplay = replayGuard.Handler()
+		expvar.Publish("claim_anti_replay", expvar.Func(func() any { return replayGuard.Stats() }))
+		log.Info().Dur("window", cfg.Replay.Window).Int("size", cfg.Replay.Size).Msg("claim anti-replay enabled")
+	}
+	// Failing claims sent with X-Debug-Record: true are kept for debugging when enabled
+	claimRecord := func(c *fiber.Ctx) error { return c.Next() }
+	var recordingHandler *handler.RecordingHandler
+	if cfg.Record.Size > 0 {
+		recorder := claimrecord.New(cfg.Record.Size)
+		claimRecord = recorder.Handler()
+		recordingHandler = handler.NewRecordingHandler(recorder)
+		expvar.Publish("claim_recording", expvar.Func(func() any { return recorder.Stats() }))
+		log.Info().Int("size", cfg.Record.Size).Msg("claim recording enabled")
+	}
+	// Claims waiting long for a coupon row lock have its lock state dumped when enabled
+	lockDiag := func(c *fiber.Ctx) error { return c.Next() }
+	var lockDiagHandler *handler.LockDiagnosticsHandler
+	if diag := st.LockDiagnostics(); diag != nil {
+		lockDiag = diag.Handler()
+		lockDiagHandler = handler.NewLockDiagnosticsHandler(diag)
+		expvar.Publish("lock_diagnostics", expvar.Func(func() any { return diag.Stats() }))
+		log.Info().
+			Dur("threshold", cfg.DB.LockDiagnosticsThreshold).
+			Int("size", cfg.DB.LockDiagnosticsSize).
+			Msg("lock diagnostics enabled")
+	}
+	apiKeys := func(c *fiber.Ctx) error { return c.Next() }
+	if keys != nil {
+		apiKeys = keys.Handler()
+	}
+	// Claims are shed with 429 over a limit that adapts to claim latency when enabled
+	claimLimit := func(c *fiber.Ctx) error { return c.Next() }
+	if cfg.Shed.Enabled {
+		limitCfg := adaptivelimit.Config{
+			Initial:   cfg.Shed.Initial,
+			Min:       cfg.Shed.Min,
+			Max:       cfg.Shed.Max,
+			Tolerance: cfg.Shed.Tolerance,
+		}
+		if keys != nil {
+			limitCfg.Reserve = cfg.Keys.PremiumReserve
+			limitCfg.Priority = apikey.Priority
+		}
+		limiter := adaptivelimit.New(limitCfg)
+		claimLimit = limiter.Handler()
+		expvar.Publish("claim_adaptive_limit", expvar.Func(func() any { return limiter.Stats() }))
+		log.Info().
+			Int("initial", cfg.Shed.Initial).
+			Int("min", cfg.Shed.Min).
+			Int("max", cfg.Shed.Max).
+			Float64("tolerance", cfg.Shed.Tolerance).
+			Msg("claim adaptive limit enabled")
+	}
+	adminHandler := handler.NewAdminHandler(couponService, validate)
+	killSwitchHandler := handler.NewKillSwitchHandler(killSwitch, validate)
+	simulationHandler := handler.NewSimulationHandler(claimsim.New(st.StatementMetrics().Stats, cfg.DB.LockPolicy()), validate)
+
+	// Initialize user data components
+	userService := service.NewUserServiceWithTransactor(st, st.Claims(), st.Audit())
+	userHandler := handler.NewUserHandler(userService)
+	// Leaderboard entries keep user IDs, so erasure renames them too, counted or not
+	if st.Leaderboard() != nil {
+		userService.AddDerivedData(st.Leaderboard())
+	}
+	// So do allowlist entries, which stay in place if allowlists are disabled later
+	if st.Allowlists() != nil {
+		userService.AddDerivedData(st.Allowlists())
+	}
+
+	// Initialize campaign leaderboards
+	var leaderboardHandler *handler.LeaderboardHandler
+	if cfg.Board.Interval > 0 {
+		leaderboardService := service.NewLeaderboardService(st, st.Leaderboard())
+		addComponent(lifecycle.Component{
+			Name:      "leaderboard_refresh",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { leaderboardService.Run(ctx, cfg.Board.Interval) },
+		})
+		leaderboardHandler = handler.NewLeaderboardHandler(leaderboardService)
+		if err := leaderboardHandler.SetClaimedByPrivacy(cfg.Privacy.Mode, cfg.Privacy.HashKey); err != nil {
+			log.Fatal().Err(err).Msg("failed to configure claimed_by privacy")
+		}
+		expvar.Publish("leaderboard", expvar.Func(func() any { return leaderboardService.Stats() }))
+		log.Info().Dur("interval", cfg.Board.Interval).Msg("campaign leaderboards enabled")
+	}
+
+	// Verifying a table move compares both tables, so it is only served while both are
+	// written; validated by config.Load
+	var migrationHandler *handler.MigrationHandler
+	moves, _ := database.TableMovesWithPhases(cfg.DB.MigrationPhases)
+	if claimsV2 := moves[database.ClaimsV2.Name()]; st.ClaimMoves() != nil && claimsV2.WritesOld() && claimsV2.WritesNew() {
+		migrationHandler = handler.NewMigrationHandler(service.NewClaimMoveService(st.ClaimMoves(), claimsV2.Phase))
+	}
 
 	// Health handler
-	healthHandler := handler.NewHealthHandler(pool)
+	healthHandler := handler.NewHealthHandler(st)
+	healthHandler.SetReadinessDetail(st, migrationPhases(cfg))
+	if cfg.Watch.Interval > 0 {
+		// Warns while a pool nears exhaustion, before acquires start timing out
+		watchdog := poolwatch.New(st.PoolStats, poolwatch.Thresholds{
+			Saturation:     cfg.Watch.SaturationWarn,
+			AcquireWaitP95: cfg.Watch.AcquireWaitWarn,
+		})
+		healthHandler.SetPoolStatus(watchdog)
+		addComponent(lifecycle.Component{
+			Name:      "db_pool_watch",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { watchdog.Run(ctx, cfg.Watch.Interval) },
+		})
+		expvar.Publish("db_pool_watch", expvar.Func(func() any { return watchdog.Stats() }))
+		log.Info().
+			Dur("interval", cfg.Watch.Interval).
+			Float64("saturation_warn", cfg.Watch.SaturationWarn).
+			Dur("acquire_wait_p95_warn", cfg.Watch.AcquireWaitWarn).
+			Msg("connection pool watchdog enabled")
+	}
+	if cfg.Failover.Interval > 0 {
+		// Logs a failover or restart of the database server, explaining the errors and
+		// latency around it after the incident
+		failoverWatch := failover.New(st.ServerIdentity, cfg.Failover.StatusHold)
+		healthHandler.SetFailoverStatus(failoverWatch)
+		addComponent(lifecycle.Component{
+			Name:      "db_failover_watch",
+			DependsOn: []string{"database"},
+			Run:       func(ctx context.Context) { failoverWatch.Run(ctx, cfg.Failover.Interval) },
+		})
+		expvar.Publish("db_failover_watch", expvar.Func(func() any { return failoverWatch.Stats() }))
+		log.Info().
+			Dur("interval", cfg.Failover.Interval).
+			Dur("status_hold", cfg.Failover.StatusHold).
+			Msg("database failover detection enabled")
+	}
 	app.Get("/health", healthHandler.Check)
+	// Kubernetes-style probes: liveness checks only the process, readiness the database
+	// and that shutdown has not begun
+	app.Get("/livez", healthHandler.Livez)
+	app.Get("/readyz", healthHandler.Readyz)
+	app.Get("/healthz", healthHandler.Healthz)
+
+	app.Get("/api/version", limits("version"), handler.Version())
 
 	// Coupon routes
-	app.Post("/api/coupons", couponHandler.CreateCoupon)
-	app.Get("/api/coupons/:name", couponHandler.GetCoupon)
-	app.Post("/api/coupons/claim", claimHandler.ClaimCoupon)
+	app.Post("/api/coupons", limits("create"), couponHandler.CreateCoupon)
+	app.Get("/api/coupons", limits("list"), couponHandler.ListCoupons)
+	app.Get("/api/coupons/:name", limits("get"), guard, couponHandler.GetCoupon)
+	app.Patch("/api/coupons/:name", limits("update"), couponHandler.UpdateCoupon)
+	app.Put("/api/coupons/:name", limits("put"), couponHandler.PutCoupon)
+	app.Post("/api/coupons/:name/top-up", limits("top_up"), couponHandler.TopUpCoupon)
+	if couponDeleteHandler != nil {
+		app.Delete("/api/coupons/:name", limits("delete"), couponDeleteHandler.DeleteCoupon)
+		app.Post("/api/coupons/:name/restore", limits("restore"), couponDeleteHandler.RestoreCoupon)
+	}
+	if archiveHandler != nil {
+		app.Get("/api/archive/coupons/:name", limits("archive"), guard, archiveHandler.GetArchivedCoupon)
+	}
+	app.Post("/api/coupons/claim", limits("claim"), claimRecord, lockDiag, apiKeys, guard, antiReplay, claimLimit, claimHandler.ClaimCoupon)
+	app.Get("/api/coupons/:name/claims", limits("claims"), guard, claimHandler.ListClaims)
+	app.Get("/api/coupons/:name/claims/sample", limits("claims"), guard, claimHandler.SampleClaims)
+	if stockWaitHandler != nil {
+		app.Get("/api/coupons/:name/wait-for-stock", limits("wait_for_stock"), guard, stockWaitHandler.WaitForStock)
+	}
+	app.Post("/api/admin/apply", limits("apply"), adminHandler.ApplyManifest)
+	app.Post("/api/admin/claims/import", limits("import"), adminHandler.ImportClaims)
+	app.Post("/api/admin/coupons/:name/terminate", limits("terminate"), adminHandler.TerminateCoupon)
+	app.Post("/api/coupons/:name/notes", limits("notes"), adminHandler.AddCouponNote)
+	app.Get("/api/admin/coupons/:name/changelog", limits("notes"), adminHandler.CouponChangelog)
+	app.Post("/api/admin/simulate", limits("simulate"), simulationHandler.Simulate)
+	app.Get("/api/admin/killswitch", limits("killswitch"), killSwitchHandler.GetKillSwitch)
+	app.Put("/api/admin/killswitch", limits("killswitch"), killSwitchHandler.SetKillSwitch)
+	if webhookHandler != nil {
+		app.Post("/api/admin/webhooks", limits("webhooks"), webhookHandler.CreateWebhook)
+		app.Get("/api/admin/webhooks", limits("webhooks"), webhookHandler.ListWebhooks)
+		app.Delete("/api/admin/webhooks/:id", limits("webhooks"), webhookHandler.DeleteWebhook)
+	}
+	if loadTestHandler != nil {
+		app.Post("/api/admin/loadtest/prewarm", limits("loadtest"), loadTestHandler.PrewarmLoadTest)
+		app.Delete("/api/admin/loadtest/:namespace", limits("loadtest"), loadTestHandler.CleanupLoadTest)
+	}
+	if campaignHandler != nil {
+		app.Put("/api/admin/campaigns/:id/cap", limits("campaign_cap"), campaignHandler.SetCampaignCap)
+		app.Get("/api/admin/campaigns/:id/cap", limits("campaign_cap"), campaignHandler.GetCampaignCap)
+		app.Delete("/api/admin/campaigns/:id/cap", limits("campaign_cap"), campaignHandler.DeleteCampaignCap)
+	}
+	if allowlistHandler != nil {
+		app.Put("/api/admin/coupons/:name/allowlist", limits("allowlist"), allowlistHandler.SetAllowlist)
+		app.Get("/api/admin/coupons/:name/allowlist", limits("allowlist"), allowlistHandler.GetAllowlist)
+		app.Delete("/api/admin/coupons/:name/allowlist", limits("allowlist"), allowlistHandler.DeleteAllowlist)
+	}
+	if leaderboardHandler != nil {
+		app.Get("/api/campaigns/:id/leaderboard", limits("leaderboard"), leaderboardHandler.GetLeaderboard)
+	}
+	if migrationHandler != nil {
+		app.Get("/api/admin/migrations/claims_v2/verify", limits("migrations"), migrationHandler.VerifyClaimsV2)
+	}
+	if apiKeyHandler != nil {
+		app.Get("/api/admin/keys/:id/usage", limits("api_keys"), apiKeyHandler.GetUsage)
+	}
+	if recordingHandler != nil {
+		app.Get("/api/admin/claims/recordings", limits("recordings"), recordingHandler.ListRecordings)
+		app.Delete("/api/admin/claims/recordings", limits("recordings"), recordingHandler.ClearRecordings)
+	}
+	if lockDiagHandler != nil {
+		app.Get("/api/admin/diagnostics/locks", limits("diagnostics"), lockDiagHandler.ListLockDumps)
+	}
 
-	// Start server with graceful shutdown
-	go func() {
-		log.Info().Str("port", cfg.Server.Port).Msg("starting server")
-		if err := app.Listen(":" + cfg.Server.Port); err != nil {
-			log.Fatal().Err(err).Msg("failed to start server")
-		}
-	}()
+	// Admin UI (static, calls the JSON API above)
+	app.Use(adminui.Prefix, adminui.Handler())
+
+	// User data routes
+	app.Delete("/api/users/:user_id/data", limits("erase"), userHandler.EraseUserData)
+
+	// The HTTP server depends on every other component, so it stops accepting requests
+	// (and finishes in-flight ones) before anything it calls into is stopped
+	addComponent(lifecycle.Component{
+		Name:      "http",
+		DependsOn: components.Names(),
+		Run: func(context.Context) {
+			log.Info().Str("port", cfg.Server.Port).Msg("starting server")
+			if err := app.Listen(":" + cfg.Server.Port); err != nil {
+				log.Fatal().Err(err).Msg("failed to start server")
+			}
+		},
+		Stop: func(ctx context.Context) error {
+			log.Info().Msg("waiting for in-flight requests to complete...")
+			return app.ShutdownWithContext(ctx)
+		},
+	})
+	logStartup(ctx, cfg, st, components.Names())
+	components.Start()
 
 	// Wait for interrupt signal for graceful shutdown
 	quit := make(chan os.Signal, 1)
@@ -102,19 +841,137 @@ func main() {
 	)
 	defer shutdownCancel()
 
-	// Shutdown server (waits for in-flight requests)
-	log.Info().Msg("waiting for in-flight requests to complete...")
-	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
-		log.Error().Err(err).Msg("error during server shutdown")
+	// Fail readiness first, so probes stop routing here while in-flight requests finish
+	healthHandler.Drain()
+	// End waits for stock, which would otherwise hold the server up to their timeout
+	if stockWaiters != nil {
+		stockWaiters.Close()
 	}
 
-	// Close database pool AFTER server shutdown (even if shutdown timed out)
-	log.Info().Msg("closing database connections...")
-	pool.Close()
-	log.Info().Msg("database connections closed")
+	// Stop the components; the database pool is closed last, even if the server or a
+	// worker did not stop in time
+	if err := components.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("error during shutdown")
+	}
 	log.Info().Msg("server stopped")
 }
 
+// accessLogConfig returns the request logger configuration, writing to out (stdout when
+// nil). With redaction enabled the route pattern is logged instead of the path, which
+// embeds coupon names and user IDs.
+func accessLogConfig(out io.Writer) logger.Config {
+	cfg := logger.ConfigDefault
+	if out != nil {
+		cfg.Output = out
+		cfg.DisableColors = true
+	}
+	if redact.Enabled() {
+		cfg.Format = "${time} | ${status} | ${latency} | ${ip} | ${method} | ${route} | ${error}\n"
+	}
+	return cfg
+}
+
+// analyticsExportUploadTimeout bounds each upload of the analytics export.
+const analyticsExportUploadTimeout = 10 * time.Minute
+
+// analyticsExportStore returns the object store the analytics export writes to.
+func analyticsExportStore(cfg config.AnalyticsExportConfig) (objstore.Store, error) {
+	if cfg.Store == config.ExportStoreLocal {
+		return objstore.NewLocal(cfg.LocalDir), nil
+	}
+	return objstore.NewS3(objstore.S3Config{
+		Endpoint:        cfg.S3Endpoint,
+		Region:          cfg.S3Region,
+		Bucket:          cfg.S3Bucket,
+		AccessKeyID:     cfg.S3AccessKeyID,
+		SecretAccessKey: cfg.S3SecretAccessKey,
+		PathStyle:       cfg.S3PathStyle,
+	}, &http.Client{Timeout: analyticsExportUploadTimeout})
+}
+
+// migrationPhases returns the phase of every column rename and table move by name.
+func migrationPhases(cfg *config.Config) map[string]string {
+	// Validated by config.Load
+	renames, _ := database.RenamesWithPhases(cfg.DB.MigrationPhases)
+	moves, _ := database.TableMovesWithPhases(cfg.DB.MigrationPhases)
+	phases := make(map[string]string, len(renames)+len(moves))
+	for name, r := range renames {
+		phases[name] = string(r.Phase)
+	}
+	for name, m := range moves {
+		phases[name] = string(m.Phase)
+	}
+	return phases
+}
+
+// seedCoupons applies the seed manifest in the file at path, exiting if it cannot be
+// loaded or conflicts with the stored coupons.
+func seedCoupons(ctx context.Context, svc *service.CouponService, path string) {
+	manifest, err := seed.Load(path)
+	if err != nil {
+		log.Fatal().Err(err).Str("path", path).Msg("failed to load seed manifest")
+	}
+	report, err := svc.Seed(ctx, manifest)
+	if err != nil {
+		event := log.Fatal().Err(err).Str("path", path)
+		if report != nil { // The changes explain the conflict
+			event = event.Interface("changes", report.Changes)
+		}
+		event.Msg("failed to seed coupons")
+	}
+
+	actions := zerolog.Dict()
+	counts := map[string]int{}
+	for _, change := range report.Changes {
+		counts[change.Action]++
+	}
+	for action, n := range counts {
+		actions = actions.Int(action, n)
+	}
+	log.Info().Str("path", path).Dict("coupons", actions).Msg("coupons seeded")
+}
+
+// logStartup logs one event describing the process: build and database server versions,
+// column rename phases, pool settings, enabled subsystems and the configuration with
+// its secrets redacted.
+func logStartup(ctx context.Context, cfg *config.Config, st store.Store, components []string) {
+	event := log.Info().Str("driver", st.Dialect().Name)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		event = event.Str("go_version", info.GoVersion)
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				event = event.Str("revision", setting.Value)
+			case "vcs.modified":
+				event = event.Str("modified", setting.Value)
+			}
+		}
+	}
+
+	versionCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if version, err := st.ServerVersion(versionCtx); err != nil {
+		log.Warn().Err(err).Msg("failed to get database server version")
+	} else {
+		event = event.Str("db_version", version)
+	}
+
+	migrations := zerolog.Dict()
+	for name, phase := range migrationPhases(cfg) {
+		migrations = migrations.Str(name, phase)
+	}
+
+	event.
+		Dict("migrations", migrations).
+		Dict("pool", zerolog.Dict().
+			Int("max_conns", cfg.DB.MaxConns).Int("min_conns", cfg.DB.MinConns).
+			Int("read_max_conns", cfg.DB.ReadMaxConns).Int("read_min_conns", cfg.DB.ReadMinConns)).
+		Strs("subsystems", cfg.Subsystems()).
+		Strs("components", components).
+		Interface("config", cfg.Redacted()).
+		Msg("startup")
+}
+
 // initLogger configures zerolog based on the application configuration.
 func initLogger(cfg *config.Config) {
 	// Set log level
@@ -134,4 +991,11 @@ func initLogger(cfg *config.Config) {
 		zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 		log.Logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
 	}
+
+	// Configure redaction of user IDs and coupon names (validated by config.Load)
+	redactor, err := redact.New(redact.Mode(cfg.Log.Redact), cfg.Log.RedactKey)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to configure log redaction")
+	}
+	redact.SetDefault(redactor)
 }
@@ -0,0 +1,55 @@
+// Package accesslog opens the sink HTTP access log lines are written to: stdout, a
+// size-rotated file, or syslog, for environments without a log shipper collecting
+// stdout.
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Sink kinds.
+const (
+	Stdout = "stdout"
+	File   = "file"
+	Syslog = "syslog"
+)
+
+// Options configures a sink.
+type Options struct {
+	Sink string // Stdout, File or Syslog
+
+	// File sink: Path is rotated once writing to it would exceed MaxSize bytes, keeping
+	// MaxBackups older files as Path.1 (newest) to Path.<MaxBackups>.
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+
+	// Syslog sink: Network ("udp" or "tcp") and Addr of the syslog server, or both empty
+	// for the local syslog daemon. Tag identifies the process in each message.
+	Network string
+	Addr    string
+	Tag     string
+}
+
+// Open opens the sink described by opts. Close flushes and releases it; closing the
+// stdout sink leaves stdout open.
+func Open(opts Options) (io.WriteCloser, error) {
+	switch opts.Sink {
+	case Stdout:
+		return nopCloser{os.Stdout}, nil
+	case File:
+		return OpenRotatingFile(opts.Path, opts.MaxSize, opts.MaxBackups)
+	case Syslog:
+		return dialSyslog(opts.Network, opts.Addr, opts.Tag)
+	default:
+		return nil, fmt.Errorf("unknown access log sink %q", opts.Sink)
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
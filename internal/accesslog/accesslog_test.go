@@ -0,0 +1,113 @@
+package accesslog
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestOpen_UnknownSink(t *testing.T) {
+	_, err := Open(Options{Sink: "kafka"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown access log sink "kafka"`)
+}
+
+func TestOpen_StdoutCloseKeepsStdout(t *testing.T) {
+	w, err := Open(Options{Sink: Stdout})
+	require.NoError(t, err)
+
+	require.NoError(t, w.Close())
+	_, err = os.Stdout.Stat()
+	assert.NoError(t, err)
+}
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := OpenRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "fourth\n", readFile(t, path))
+	assert.Equal(t, "third\n", readFile(t, path+".1"))
+	assert.Equal(t, "second\n", readFile(t, path+".2"))
+	assert.NoFileExists(t, path+".3", "only MaxBackups backups are kept")
+}
+
+func TestRotatingFile_NoBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := OpenRotatingFile(path, 10, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("first\n"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "second\n", readFile(t, path))
+	assert.NoFileExists(t, path+".1")
+}
+
+func TestRotatingFile_AppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(path, []byte("earlier\n"), 0o644))
+
+	f, err := OpenRotatingFile(path, 10, 1)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write([]byte("later\n"))
+	require.NoError(t, err)
+
+	// The existing size counts toward the limit
+	assert.Equal(t, "later\n", readFile(t, path))
+	assert.Equal(t, "earlier\n", readFile(t, path+".1"))
+}
+
+func TestRotatingFile_WriteAfterClose(t *testing.T) {
+	f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "access.log"), 10, 1)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = f.Write([]byte("line\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestOpen_SyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := Open(Options{Sink: Syslog, Network: "udp", Addr: conn.LocalAddr().String(), Tag: "coupon-api"})
+	require.NoError(t, err)
+	defer w.Close()
+	_, err = w.Write([]byte("200 | GET | /api/coupons\n"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<134>"), "LOCAL0.INFO priority, got %q", msg)
+	assert.Contains(t, msg, "coupon-api")
+	assert.Contains(t, msg, "200 | GET | /api/coupons")
+}
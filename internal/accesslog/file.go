@@ -0,0 +1,105 @@
+package accesslog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is a log file that is rotated once writing to it would exceed a size
+// limit. It is safe for concurrent use.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens path for appending, creating it if needed. Once writing to it
+// would exceed maxSize bytes it is renamed to path.1, older backups shift up to
+// path.<maxBackups> (the oldest is removed), and a new file is started. With maxBackups
+// 0 the old file is removed. A single write larger than maxSize is not split.
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open access log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat access log: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends p, rotating the file first if p would take it past the size limit.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate moves the current file to the first backup and opens a new one.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("close access log: %w", err)
+	}
+	f.file = nil
+
+	if f.maxBackups == 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove access log: %w", err)
+		}
+	} else {
+		for i := f.maxBackups - 1; i >= 1; i-- {
+			err := os.Rename(f.backup(i), f.backup(i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("rotate access log: %w", err)
+			}
+		}
+		if err := os.Rename(f.path, f.backup(1)); err != nil {
+			return fmt.Errorf("rotate access log: %w", err)
+		}
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
+
+// Close closes the file. Later writes fail with os.ErrClosed.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
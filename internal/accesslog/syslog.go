@@ -0,0 +1,20 @@
+//go:build !windows && !plan9
+
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+)
+
+// dialSyslog connects to the syslog server at addr, or the local daemon when network
+// and addr are empty. Lines are sent with facility LOCAL0 and severity INFO; the
+// writer reconnects after a failed write.
+func dialSyslog(network, addr, tag string) (io.WriteCloser, error) {
+	w, err := syslog.Dial(network, addr, syslog.LOG_LOCAL0|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, fmt.Errorf("connect to syslog: %w", err)
+	}
+	return w, nil
+}
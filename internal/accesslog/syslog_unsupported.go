@@ -0,0 +1,12 @@
+//go:build windows || plan9
+
+package accesslog
+
+import (
+	"errors"
+	"io"
+)
+
+func dialSyslog(network, addr, tag string) (io.WriteCloser, error) {
+	return nil, errors.New("the syslog access log sink is not supported on this platform")
+}
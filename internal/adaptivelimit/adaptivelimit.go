@@ -0,0 +1,162 @@
+// Package adaptivelimit bounds how many requests a route handles at once with a limit
+// that follows the route's latency: while recent latency stays near its long-term
+// average the limit grows, and once recent latency rises above it (the database
+// queueing work during an incident) the limit shrinks in proportion. Requests over
+// the limit are shed with 429, so throughput stays near the knee of the latency curve
+// instead of every request slowing down together.
+package adaptivelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// shortAlpha and longAlpha weight each latency sample in the short- and long-term
+	// averages; the long-term one spans roughly the last 600 requests.
+	shortAlpha = 0.1
+	longAlpha  = 2.0 / 601
+	// smoothing is how much of the newly computed limit each sample applies.
+	smoothing = 0.2
+	// minGradient caps how much a single sample shrinks the limit.
+	minGradient = 0.5
+)
+
+// Config configures a Limiter.
+type Config struct {
+	Initial   int     // Limit before any latency has been observed
+	Min       int     // The limit never drops below Min...
+	Max       int     // ...nor grows above Max
+	Tolerance float64 // Recent latency up to Tolerance times the long-term average does not shrink the limit
+	// Reserve is the share of the limit only priority requests may take, so they are
+	// still handled while the others are shed. 0 reserves nothing.
+	Reserve float64
+	// Priority reports whether the request of a user context is a priority one; nil
+	// for none.
+	Priority func(context.Context) bool
+}
+
+// Stats is a snapshot of Limiter state and counters.
+type Stats struct {
+	Limit        int     `json:"limit"`
+	InFlight     int     `json:"in_flight"`
+	Accepted     int64   `json:"accepted"`
+	Shed         int64   `json:"shed"`          // Requests answered 429 over the limit
+	ShedPriority int64   `json:"shed_priority"` // Priority requests among Shed
+	ShortRTTMs   float64 `json:"short_rtt_ms"`
+	LongRTTMs    float64 `json:"long_rtt_ms"`
+}
+
+// Limiter is an adaptive concurrency limit. It is safe for concurrent use.
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex // Guards the fields below
+	limit    float64
+	inFlight int
+	shortRTT float64 // Seconds; 0 until the first sample
+	longRTT  float64
+
+	accepted     atomic.Int64
+	shed         atomic.Int64
+	shedPriority atomic.Int64
+}
+
+// New creates a Limiter starting at cfg.Initial.
+func New(cfg Config) *Limiter {
+	return &Limiter{cfg: cfg, now: time.Now, limit: float64(cfg.Initial)}
+}
+
+// Stats returns the current limit and counters.
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Stats{
+		Limit:        int(l.limit),
+		InFlight:     l.inFlight,
+		Accepted:     l.accepted.Load(),
+		Shed:         l.shed.Load(),
+		ShedPriority: l.shedPriority.Load(),
+		ShortRTTMs:   l.shortRTT * 1000,
+		LongRTTMs:    l.longRTT * 1000,
+	}
+}
+
+// Handler returns middleware limiting the routes it is mounted on. Requests over the
+// limit get 429 with Retry-After; the others are handled and their latency adjusts the
+// limit. Mount it right before the handler so the latency is the handler's own, and
+// after whatever marks priority requests.
+func (l *Limiter) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		priority := l.cfg.Priority != nil && l.cfg.Priority(c.UserContext())
+		if !l.acquire(priority) {
+			l.shed.Add(1)
+			if priority {
+				l.shedPriority.Add(1)
+			}
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "server busy, retry later"})
+		}
+		l.accepted.Add(1)
+
+		start := l.now()
+		err := c.Next()
+		l.release(l.now().Sub(start))
+		return err
+	}
+}
+
+// acquire takes a slot if fewer than limit requests are in flight, or fewer than the
+// limit less its reserve for a request without priority.
+func (l *Limiter) acquire(priority bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit := l.limit
+	if !priority {
+		limit = math.Max(1, limit*(1-l.cfg.Reserve))
+	}
+	if l.inFlight >= int(limit) {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+// release frees a slot and updates the limit with the request's latency, following
+// the gradient of Netflix's concurrency-limits: the limit is scaled by how far recent
+// latency (short-term average) is above the long-term average, beyond Tolerance, and
+// then allowed a queue of sqrt(limit) extra requests to probe for more capacity.
+// Samples taken while less than half the limit was used leave it unchanged, since they
+// say nothing about the load the route can take.
+func (l *Limiter) release(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	inFlight := l.inFlight
+	l.inFlight--
+
+	rtt := latency.Seconds()
+	if l.shortRTT == 0 {
+		l.shortRTT, l.longRTT = rtt, rtt
+	} else {
+		l.shortRTT += shortAlpha * (rtt - l.shortRTT)
+		l.longRTT += longAlpha * (rtt - l.longRTT)
+	}
+	// Let the long-term average recover quickly after latency dropped for good
+	if l.longRTT > 2*l.shortRTT {
+		l.longRTT *= 0.95
+	}
+	if l.shortRTT <= 0 || float64(inFlight) < l.limit/2 {
+		return
+	}
+
+	gradient := math.Max(minGradient, math.Min(1, l.cfg.Tolerance*l.longRTT/l.shortRTT))
+	target := l.limit*gradient + math.Sqrt(l.limit)
+	limit := l.limit*(1-smoothing) + target*smoothing
+	l.limit = math.Max(float64(l.cfg.Min), math.Min(float64(l.cfg.Max), limit))
+}
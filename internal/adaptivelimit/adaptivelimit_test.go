@@ -0,0 +1,128 @@
+package adaptivelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = Config{Initial: 20, Min: 4, Max: 100, Tolerance: 1.5}
+
+// saturate fills every slot of l, then releases them all with latency, rounds times.
+func saturate(t *testing.T, l *Limiter, latency time.Duration, rounds int) {
+	t.Helper()
+	for range rounds {
+		n := 0
+		for l.acquire(false) {
+			n++
+		}
+		require.Positive(t, n)
+		for range n {
+			l.release(latency)
+		}
+	}
+}
+
+func TestLimiter_ShedsOverLimit(t *testing.T) {
+	l := New(Config{Initial: 2, Min: 1, Max: 10, Tolerance: 1.5})
+
+	assert.True(t, l.acquire(false))
+	assert.True(t, l.acquire(false))
+	assert.False(t, l.acquire(false), "the limit is reached")
+
+	l.release(10 * time.Millisecond)
+	assert.True(t, l.acquire(false), "a released slot can be taken again")
+}
+
+func TestLimiter_GrowsWhileLatencyIsSteady(t *testing.T) {
+	l := New(testConfig)
+
+	saturate(t, l, 10*time.Millisecond, 50)
+
+	assert.Equal(t, testConfig.Max, l.Stats().Limit)
+	assert.InDelta(t, 10, l.Stats().ShortRTTMs, 0.01)
+}
+
+func TestLimiter_ShrinksWhenLatencyRises(t *testing.T) {
+	l := New(testConfig)
+	saturate(t, l, 10*time.Millisecond, 50)
+	before := l.Stats().Limit
+
+	saturate(t, l, 100*time.Millisecond, 3)
+
+	stats := l.Stats()
+	assert.Less(t, stats.Limit, before/2)
+	assert.GreaterOrEqual(t, stats.Limit, testConfig.Min)
+	assert.Greater(t, stats.ShortRTTMs, stats.LongRTTMs)
+}
+
+func TestLimiter_TolerantOfSmallLatencyIncrease(t *testing.T) {
+	l := New(testConfig)
+	saturate(t, l, 10*time.Millisecond, 50)
+
+	saturate(t, l, 13*time.Millisecond, 5)
+
+	assert.Equal(t, testConfig.Max, l.Stats().Limit)
+}
+
+func TestLimiter_UnchangedWhenUnderused(t *testing.T) {
+	l := New(testConfig)
+
+	for range 100 {
+		require.True(t, l.acquire(false))
+		l.release(time.Second)
+	}
+
+	assert.Equal(t, testConfig.Initial, l.Stats().Limit, "one request at a time says nothing about capacity")
+}
+
+func TestLimiter_Handler(t *testing.T) {
+	l := New(Config{Initial: 1, Min: 1, Max: 1, Tolerance: 1.5})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	app := fiber.New()
+	app.Post("/api/coupons/claim", l.Handler(), func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	done := make(chan int)
+	go func() {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/api/coupons/claim", nil), -1)
+		if err != nil {
+			done <- 0
+			return
+		}
+		done <- resp.StatusCode
+	}()
+	<-started
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/api/coupons/claim", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(fiber.HeaderRetryAfter))
+
+	close(release)
+	assert.Equal(t, fiber.StatusOK, <-done)
+	stats := l.Stats()
+	assert.Equal(t, int64(1), stats.Accepted)
+	assert.Equal(t, int64(1), stats.Shed)
+	assert.Equal(t, 0, stats.InFlight)
+}
+
+func TestLimiter_ReservesSlotsForPriority(t *testing.T) {
+	l := New(Config{Initial: 10, Min: 1, Max: 10, Tolerance: 1.5, Reserve: 0.2})
+
+	for range 8 {
+		require.True(t, l.acquire(false))
+	}
+	assert.False(t, l.acquire(false), "the rest of the limit is reserved")
+	assert.True(t, l.acquire(true))
+	assert.True(t, l.acquire(true))
+	assert.False(t, l.acquire(true), "the limit is reached")
+}
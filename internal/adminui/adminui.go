@@ -0,0 +1,33 @@
+// Package adminui embeds the single-page admin UI served at /admin.
+//
+// The UI is plain HTML and JavaScript with no build step. It only calls the public
+// JSON endpoints (listing, coupon details, claims export, top-up), so it needs no
+// server-side support beyond serving these files.
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+// Prefix is the URL path the admin UI is mounted at.
+const Prefix = "/admin"
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the embedded admin UI. Mount it with app.Use(Prefix, Handler()).
+func Handler() fiber.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // The embedded directory is fixed at compile time
+	}
+	return filesystem.New(filesystem.Config{
+		Root:  http.FS(root),
+		Index: "index.html",
+	})
+}
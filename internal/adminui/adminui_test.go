@@ -0,0 +1,54 @@
+package adminui
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestApp() *fiber.App {
+	app := fiber.New()
+	app.Use(Prefix, Handler())
+	return app
+}
+
+func TestHandler_ServesAssets(t *testing.T) {
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/admin", "text/html", "<title>Coupon Admin</title>"},
+		{"/admin/", "text/html", "/admin/app.js"},
+		{"/admin/app.js", "javascript", "/api/coupons"},
+		{"/admin/style.css", "text/css", "table"},
+	}
+
+	app := setupTestApp()
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.Contains(t, resp.Header.Get("Content-Type"), tt.contentType)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.contains)
+		})
+	}
+}
+
+func TestHandler_UnknownFile(t *testing.T) {
+	app := setupTestApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/admin/missing.js", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,250 @@
+// Coupon admin UI. Talks only to the public JSON API; every value from the API is
+// rendered with textContent, never innerHTML.
+(function () {
+  'use strict';
+
+  const listLimit = 1000;
+  let selected = null;
+
+  function $(id) { return document.getElementById(id); }
+
+  function el(tag, text, className) {
+    const node = document.createElement(tag);
+    if (text !== undefined) node.textContent = String(text);
+    if (className) node.className = className;
+    return node;
+  }
+
+  function setStatus(id, message, ok) {
+    const node = $(id);
+    node.textContent = message || '';
+    node.classList.toggle('ok', Boolean(ok));
+  }
+
+  async function api(path, options) {
+    const resp = await fetch(path, options);
+    const body = await resp.json().catch(function () { return {}; });
+    if (!resp.ok) throw new Error(body.error || resp.statusText);
+    return body;
+  }
+
+  function couponPath(name) {
+    return '/api/coupons/' + encodeURIComponent(name);
+  }
+
+  function changelogPath(name) {
+    return '/api/admin/coupons/' + encodeURIComponent(name) + '/changelog';
+  }
+
+  async function loadCoupons() {
+    const tag = $('tag').value.trim();
+    const query = new URLSearchParams({ limit: String(listLimit) });
+    if (tag) query.set('tag', tag);
+
+    try {
+      const data = await api('/api/coupons?' + query.toString());
+      renderCoupons(data.items);
+      const more = data.next_cursor ? ' (first ' + listLimit + ' shown)' : '';
+      setStatus('list-status', data.items.length + ' coupon(s)' + more, true);
+    } catch (err) {
+      setStatus('list-status', 'Failed to load coupons: ' + err.message);
+    }
+  }
+
+  // Reads every page of a list endpoint, following next_cursor.
+  async function listAll(path) {
+    const items = [];
+    let cursor = '';
+    do {
+      const query = new URLSearchParams({ limit: String(listLimit) });
+      if (cursor) query.set('cursor', cursor);
+      const page = await api(path + '?' + query.toString());
+      items.push.apply(items, page.items);
+      cursor = page.next_cursor;
+    } while (cursor);
+    return items;
+  }
+
+  function renderCoupons(coupons) {
+    const body = $('coupons');
+    body.replaceChildren();
+    coupons.forEach(function (c) {
+      const row = el('tr', undefined, c.disabled ? 'disabled' : '');
+      row.append(
+        el('td', c.name),
+        el('td', c.unlimited ? 'unlimited' : c.amount, 'num'),
+        el('td', c.unlimited ? '-' : c.remaining_amount, 'num'),
+        el('td', claimedOf(c), 'num'),
+        el('td', c.tags.join(', ')),
+        el('td', c.status));
+      if (c.name === selected) row.classList.add('selected');
+      row.addEventListener('click', function () { showCoupon(c.name); });
+      body.append(row);
+    });
+  }
+
+  // Unlimited coupons report their claims, having no stock to subtract.
+  function claimedOf(coupon) {
+    return coupon.unlimited ? coupon.claimed : coupon.amount - coupon.remaining_amount;
+  }
+
+  function countBy(claims, key) {
+    const counts = new Map();
+    claims.forEach(function (claim) {
+      const value = claim[key] || '(none)';
+      counts.set(value, (counts.get(value) || 0) + 1);
+    });
+    return counts;
+  }
+
+  function breakdownTable(title, counts, extra) {
+    const wrapper = el('div');
+    wrapper.append(el('h3', title));
+    const table = el('table');
+    counts.forEach(function (count, name) {
+      const row = el('tr');
+      row.append(el('td', name), el('td', count + ' claimed', 'num'));
+      if (extra) row.append(el('td', extra(name), 'num'));
+      table.append(row);
+    });
+    wrapper.append(table);
+    return wrapper;
+  }
+
+  async function showCoupon(name) {
+    selected = name;
+    $('detail').hidden = false;
+    $('detail-name').textContent = name;
+    setStatus('detail-status', '');
+    document.querySelectorAll('#coupons tr').forEach(function (row) {
+      row.classList.toggle('selected', row.firstChild.textContent === name);
+    });
+
+    try {
+      const results = await Promise.all([
+        api(couponPath(name)),
+        listAll(couponPath(name) + '/claims'),
+      ]);
+      renderDetail(results[0], results[1]);
+    } catch (err) {
+      setStatus('detail-status', 'Failed to load coupon: ' + err.message);
+    }
+    loadChangelog(name);
+  }
+
+  async function loadChangelog(name) {
+    setStatus('changelog-status', '');
+    try {
+      renderChangelog((await api(changelogPath(name))).items);
+    } catch (err) {
+      $('changelog').replaceChildren();
+      setStatus('changelog-status', 'Failed to load changelog: ' + err.message);
+    }
+  }
+
+  // Notes show their text and author; other entries (terminations) their reason.
+  function renderChangelog(entries) {
+    const list = $('changelog');
+    list.replaceChildren();
+    entries.forEach(function (entry) {
+      const d = entry.details || {};
+      const item = el('li');
+      item.append(el('time', new Date(entry.created_at).toLocaleString()), ' ');
+      if (entry.action === 'coupon_note') {
+        item.append(el('span', d.note), el('span', d.author ? ' (' + d.author + ')' : '', 'author'));
+      } else {
+        item.append(el('span', entry.action.replace('coupon_', '') + (d.reason ? ': ' + d.reason : ''), 'change'));
+      }
+      list.append(item);
+    });
+    if (!entries.length) setStatus('changelog-status', 'No notes yet', true);
+  }
+
+  function renderDetail(coupon, claims) {
+    const claimed = claimedOf(coupon);
+    const stats = $('detail-stats');
+    stats.replaceChildren();
+    [
+      ['Amount', coupon.unlimited ? 'unlimited' : coupon.amount],
+      ['Remaining', coupon.unlimited ? '-' : coupon.remaining_amount + (coupon.low_stock ? ' (low stock)' : '')],
+      ['Claimed', coupon.unlimited ? claimed : claimed + ' (' + (coupon.amount ? Math.round(100 * claimed / coupon.amount) : 0) + '%)'],
+      ['Tags', coupon.tags.join(', ') || '-'],
+      ['Parent', coupon.parent || '-'],
+      ['Requires', coupon.prerequisite || '-'],
+      ['Exclusion group', coupon.exclusion_group || '-'],
+      ['Regions', (coupon.regions || []).map(function (r) {
+        return r.region + ' ' + r.claimed + (r.quota ? '/' + r.quota : '');
+      }).join(', ') || '-'],
+      ['Status', coupon.status],
+      ['Last claim', claims.length ? new Date(claims[claims.length - 1].claimed_at).toLocaleString() : '-'],
+    ].forEach(function (pair) {
+      stats.append(el('dt', pair[0]), el('dd', pair[1]));
+    });
+
+    const breakdown = $('detail-breakdown');
+    breakdown.replaceChildren();
+    if (coupon.channels && coupon.channels.length) {
+      const remaining = new Map(coupon.channels.map(function (q) { return [q.channel, q]; }));
+      const counts = new Map(coupon.channels.map(function (q) { return [q.channel, 0]; }));
+      countBy(claims, 'channel').forEach(function (count, channel) { counts.set(channel, count); });
+      breakdown.append(breakdownTable('Channels', counts, function (channel) {
+        const q = remaining.get(channel);
+        return q ? q.remaining + ' / ' + q.quota + ' left' : '';
+      }));
+    }
+    if (coupon.tiers && coupon.tiers.length) {
+      breakdown.append(breakdownTable('Tiers', countBy(claims, 'tier')));
+    }
+
+    // Partitioned coupons cannot be topped up (their quotas must keep summing to the amount).
+    $('topup').hidden = Boolean(coupon.channels && coupon.channels.length);
+  }
+
+  async function topUp(event) {
+    event.preventDefault();
+    if (!selected) return;
+    const amount = Number($('topup-amount').value);
+
+    try {
+      await api(couponPath(selected) + '/top-up', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ amount: amount }),
+      });
+      $('topup-amount').value = '';
+      await Promise.all([loadCoupons(), showCoupon(selected)]);
+      setStatus('detail-status', 'Added ' + amount + ' to ' + selected, true);
+    } catch (err) {
+      setStatus('detail-status', 'Top-up failed: ' + err.message);
+    }
+  }
+
+  async function addNote(event) {
+    event.preventDefault();
+    if (!selected) return;
+    const body = { note: $('note-text').value.trim() };
+    const author = $('note-author').value.trim();
+    if (author) body.author = author;
+
+    try {
+      const changelog = await api(couponPath(selected) + '/notes', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body),
+      });
+      $('note-text').value = '';
+      renderChangelog(changelog.items);
+      setStatus('changelog-status', 'Note added', true);
+    } catch (err) {
+      setStatus('changelog-status', 'Adding note failed: ' + err.message);
+    }
+  }
+
+  $('filter').addEventListener('submit', function (event) {
+    event.preventDefault();
+    loadCoupons();
+  });
+  $('topup').addEventListener('submit', topUp);
+  $('note').addEventListener('submit', addNote);
+  loadCoupons();
+})();
@@ -0,0 +1,132 @@
+// Package antireplay answers a request resent with the same X-Request-ID within a
+// window with the response to the first one, instead of running it again. It is a
+// lightweight idempotency layer: request IDs are remembered in process, per client IP,
+// only for requests that carry one.
+package antireplay
+
+import (
+	"crypto/sha256"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/cache"
+)
+
+// HeaderReplayed is set to "true" on replayed responses.
+const HeaderReplayed = "Idempotent-Replayed"
+
+// Stats is a snapshot of Guard counters.
+type Stats struct {
+	Replayed   int64 `json:"replayed"`   // Requests answered with an earlier response
+	Mismatched int64 `json:"mismatched"` // Request IDs reused with a different body (422)
+	Size       int   `json:"size"`       // Request IDs remembered
+}
+
+// response is the outcome of the first request with a request ID.
+type response struct {
+	bodyHash [sha256.Size]byte
+	done     chan struct{} // Closed once the fields below are set
+
+	status      int
+	contentType string
+	body        []byte
+	ok          bool // Whether the response may be replayed
+}
+
+// Guard remembers responses by client IP and request ID. It is safe for concurrent use.
+type Guard struct {
+	mu        sync.Mutex // Makes looking up and adding a request ID atomic
+	responses *cache.LRU[string, *response]
+
+	replayed   atomic.Int64
+	mismatched atomic.Int64
+}
+
+// New creates a Guard remembering up to size request IDs for window each.
+func New(window time.Duration, size int) *Guard {
+	return &Guard{responses: cache.NewLRU[string, *response](size, window)}
+}
+
+// Stats returns the guard's counters.
+func (g *Guard) Stats() Stats {
+	return Stats{
+		Replayed:   g.replayed.Load(),
+		Mismatched: g.mismatched.Load(),
+		Size:       g.responses.Stats().Size,
+	}
+}
+
+// Handler returns middleware replaying responses to requests whose X-Request-ID was
+// seen within the window. A duplicate of a request still in flight waits for it. A
+// request ID reused with a different body gets 422. Server errors and 429s are not
+// remembered, so those requests can be retried with the same ID.
+func (g *Guard) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if id == "" {
+			return c.Next()
+		}
+		key := c.IP() + "\x00" + id
+		hash := sha256.Sum256(c.Body())
+
+		g.mu.Lock()
+		prior, seen := g.responses.Get(key)
+		if !seen {
+			prior = &response{bodyHash: hash, done: make(chan struct{})}
+			g.responses.Add(key, prior)
+		}
+		g.mu.Unlock()
+
+		if seen {
+			return g.replay(c, prior, hash)
+		}
+		return g.record(c, key, prior)
+	}
+}
+
+// replay answers c with prior, waiting for it if it is still in flight.
+func (g *Guard) replay(c *fiber.Ctx, prior *response, hash [sha256.Size]byte) error {
+	if prior.bodyHash != hash {
+		g.mismatched.Add(1)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "request ID reused with a different body"})
+	}
+	select {
+	case <-prior.done:
+	case <-c.UserContext().Done():
+		return c.UserContext().Err()
+	}
+	if !prior.ok {
+		// The first request failed in a way worth retrying
+		return c.Next()
+	}
+
+	g.replayed.Add(1)
+	c.Set(HeaderReplayed, "true")
+	c.Set(fiber.HeaderContentType, prior.contentType)
+	return c.Status(prior.status).Send(prior.body)
+}
+
+// record runs the request and fills in r, remembered under key, with its response.
+func (g *Guard) record(c *fiber.Ctx, key string, r *response) error {
+	defer close(r.done)
+
+	err := c.Next()
+	status := c.Response().StatusCode()
+	if err == nil && status < fiber.StatusInternalServerError && status != fiber.StatusTooManyRequests {
+		r.status = status
+		r.contentType = string(c.Response().Header.ContentType())
+		r.body = append([]byte(nil), c.Response().Body()...)
+		r.ok = true
+		return nil
+	}
+
+	g.mu.Lock()
+	if current, ok := g.responses.Get(key); ok && current == r {
+		g.responses.Remove(key)
+	}
+	g.mu.Unlock()
+	return err
+}
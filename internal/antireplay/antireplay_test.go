@@ -0,0 +1,162 @@
+package antireplay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// app serves POST /claim, answering with status and counting the requests it runs.
+func app(g *Guard, status *atomic.Int32, runs *atomic.Int32) *fiber.App {
+	app := fiber.New()
+	app.Post("/claim", g.Handler(), func(c *fiber.Ctx) error {
+		n := runs.Add(1)
+		return c.Status(int(status.Load())).JSON(fiber.Map{"run": n})
+	})
+	return app
+}
+
+func post(t *testing.T, app *fiber.App, requestID, body string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/claim", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if requestID != "" {
+		req.Header.Set(fiber.HeaderXRequestID, requestID)
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+func TestGuard_ReplaysDuplicateRequestID(t *testing.T) {
+	g := New(time.Minute, 100)
+	var status, runs atomic.Int32
+	status.Store(fiber.StatusOK)
+	a := app(g, &status, &runs)
+
+	first, firstBody := post(t, a, "req-1", `{"user_id":"u1"}`)
+	status.Store(fiber.StatusConflict)
+	second, secondBody := post(t, a, "req-1", `{"user_id":"u1"}`)
+
+	assert.Equal(t, int32(1), runs.Load(), "the duplicate is not run")
+	assert.Equal(t, fiber.StatusOK, second.StatusCode)
+	assert.Equal(t, firstBody, secondBody)
+	assert.Equal(t, first.Header.Get("Content-Type"), second.Header.Get("Content-Type"))
+	assert.Empty(t, first.Header.Get(HeaderReplayed))
+	assert.Equal(t, "true", second.Header.Get(HeaderReplayed))
+	assert.Equal(t, Stats{Replayed: 1, Size: 1}, g.Stats())
+}
+
+func TestGuard_RemembersClientErrors(t *testing.T) {
+	g := New(time.Minute, 100)
+	var status, runs atomic.Int32
+	status.Store(fiber.StatusConflict)
+	a := app(g, &status, &runs)
+
+	post(t, a, "req-1", `{}`)
+	status.Store(fiber.StatusOK)
+	resp, _ := post(t, a, "req-1", `{}`)
+
+	assert.Equal(t, int32(1), runs.Load())
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+func TestGuard_DoesNotRememberRetryableResponses(t *testing.T) {
+	for _, code := range []int{fiber.StatusInternalServerError, fiber.StatusServiceUnavailable, fiber.StatusTooManyRequests} {
+		g := New(time.Minute, 100)
+		var status, runs atomic.Int32
+		status.Store(int32(code))
+		a := app(g, &status, &runs)
+
+		post(t, a, "req-1", `{}`)
+		status.Store(fiber.StatusOK)
+		resp, _ := post(t, a, "req-1", `{}`)
+
+		assert.Equal(t, int32(2), runs.Load(), "status %d is retried", code)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get(HeaderReplayed))
+	}
+}
+
+func TestGuard_RejectsRequestIDReusedWithDifferentBody(t *testing.T) {
+	g := New(time.Minute, 100)
+	var status, runs atomic.Int32
+	status.Store(fiber.StatusOK)
+	a := app(g, &status, &runs)
+
+	post(t, a, "req-1", `{"user_id":"u1"}`)
+	resp, body := post(t, a, "req-1", `{"user_id":"u2"}`)
+
+	assert.Equal(t, int32(1), runs.Load())
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	assert.JSONEq(t, `{"error":"request ID reused with a different body"}`, body)
+	assert.Equal(t, int64(1), g.Stats().Mismatched)
+}
+
+func TestGuard_IgnoresRequestsWithoutID(t *testing.T) {
+	g := New(time.Minute, 100)
+	var status, runs atomic.Int32
+	status.Store(fiber.StatusOK)
+	a := app(g, &status, &runs)
+
+	post(t, a, "", `{}`)
+	post(t, a, "", `{}`)
+
+	assert.Equal(t, int32(2), runs.Load())
+	assert.Equal(t, 0, g.Stats().Size)
+}
+
+func TestGuard_ForgetsAfterWindow(t *testing.T) {
+	g := New(10*time.Millisecond, 100)
+	var status, runs atomic.Int32
+	status.Store(fiber.StatusOK)
+	a := app(g, &status, &runs)
+
+	post(t, a, "req-1", `{}`)
+	time.Sleep(20 * time.Millisecond)
+	post(t, a, "req-1", `{}`)
+
+	assert.Equal(t, int32(2), runs.Load())
+}
+
+func TestGuard_DuplicateWaitsForInFlightRequest(t *testing.T) {
+	g := New(time.Minute, 100)
+	var runs atomic.Int32
+	release := make(chan struct{})
+	a := fiber.New()
+	a.Post("/claim", g.Handler(), func(c *fiber.Ctx) error {
+		runs.Add(1)
+		<-release
+		return c.JSON(fiber.Map{"claimed": true})
+	})
+
+	first := make(chan *http.Response)
+	go func() {
+		resp, _ := post(t, a, "req-1", `{}`)
+		first <- resp
+	}()
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+
+	second := make(chan *http.Response)
+	go func() {
+		resp, _ := post(t, a, "req-1", `{}`)
+		second <- resp
+	}()
+	close(release)
+
+	assert.Equal(t, fiber.StatusOK, (<-first).StatusCode)
+	resp := <-second
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get(HeaderReplayed))
+	assert.Equal(t, int32(1), runs.Load())
+}
@@ -0,0 +1,288 @@
+// Package apikey identifies the partners making claims by tiered API keys, sent in the
+// X-API-Key header, and enforces their tier in one place: each key's claims are rate
+// limited to its tier's rate, and premium claims are marked as priority for the claim
+// limiter and pacing queues (see Priority). Requests without a key are anonymous and
+// pass through unchanged: keys identify partners, they do not gate access. Keys may
+// also carry a contracted monthly claim quota, counted across replicas once a
+// UsageStore is set (see SetUsageStore).
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Header carries a request's API key.
+const Header = "X-API-Key"
+
+// Tiers. Requests without a key are counted as TierAnonymous.
+const (
+	TierStandard  = "standard"
+	TierPremium   = "premium"
+	TierAnonymous = "anonymous"
+)
+
+// Key is an API key as listed in the keys file. Only the key's SHA-256 is kept, so the
+// file is no secret.
+type Key struct {
+	ID           string `json:"id"`            // Partner the key was issued to, e.g. "acme"
+	Tier         string `json:"tier"`          // TierStandard or TierPremium
+	SHA256       string `json:"sha256"`        // Hex SHA-256 of the key
+	MonthlyQuota int64  `json:"monthly_quota"` // Claims allowed per UTC month; 0 for no quota
+}
+
+// Config sets the rate limit of each tier: claims per second per key, with a burst of
+// one second's worth. 0 does not limit the tier.
+type Config struct {
+	StandardRate float64
+	PremiumRate  float64
+}
+
+// TierStats is a snapshot of one tier's counters.
+type TierStats struct {
+	Requests      int64 `json:"requests"`       // Requests with a key of the tier
+	RateLimited   int64 `json:"rate_limited"`   // Refused with 429 over their key's rate
+	Throttled     int64 `json:"throttled"`      // Answered 429 further on (adaptive limit, pacing)
+	ServerErrors  int64 `json:"server_errors"`  // Answered 5xx
+	QuotaExceeded int64 `json:"quota_exceeded"` // Refused with 429 over their key's monthly quota
+}
+
+// Stats is a snapshot of Keys counters.
+type Stats struct {
+	Tiers       map[string]TierStats `json:"tiers"`
+	InvalidKeys int64                `json:"invalid_keys"`       // Requests refused with 401 for an unknown key
+	Flushes     int64                `json:"usage_flushes"`      // Claim counts flushed to the UsageStore
+	FlushErrors int64                `json:"usage_flush_errors"` // Flushes to the UsageStore that failed
+}
+
+// tierCounters counts one tier's requests.
+type tierCounters struct {
+	requests      atomic.Int64
+	rateLimited   atomic.Int64
+	throttled     atomic.Int64
+	serverErrors  atomic.Int64
+	quotaExceeded atomic.Int64
+}
+
+// bucket is the token bucket of one key.
+type bucket struct {
+	key  Key
+	rate float64 // Tokens per second; 0 for no limit
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// take takes a token if one is left at now; otherwise returns how long until one is.
+func (b *bucket) take(now time.Time) (bool, time.Duration) {
+	if b.rate == 0 {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	burst := math.Max(1, b.rate)
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Keys is a set of API keys. It is safe for concurrent use.
+type Keys struct {
+	byHash map[[sha256.Size]byte]*bucket
+	tiers  map[string]*tierCounters
+	now    func() time.Time
+
+	invalid atomic.Int64
+
+	usage       UsageStore // nil unless SetUsageStore is called
+	usageMu     sync.Mutex
+	month       string             // Month of totals, UTC
+	totals      map[string]int64   // Claims of each key in month, as of the last flush
+	pending     map[usageKey]int64 // Claims counted since the last flush
+	flushes     atomic.Int64
+	flushErrors atomic.Int64
+}
+
+// New returns the set of keys, rate limited as cfg sets. Returns an error if an ID or
+// hash repeats, a tier is unknown or a hash is not a hex SHA-256.
+func New(keys []Key, cfg Config) (*Keys, error) {
+	rates := map[string]float64{TierStandard: cfg.StandardRate, TierPremium: cfg.PremiumRate}
+	k := &Keys{
+		byHash: make(map[[sha256.Size]byte]*bucket, len(keys)),
+		tiers: map[string]*tierCounters{
+			TierStandard:  {},
+			TierPremium:   {},
+			TierAnonymous: {},
+		},
+		now:     time.Now,
+		pending: make(map[usageKey]int64),
+	}
+	ids := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.ID == "" || ids[key.ID] {
+			return nil, fmt.Errorf("API key id %q is empty or repeated", key.ID)
+		}
+		rate, ok := rates[key.Tier]
+		if !ok {
+			return nil, fmt.Errorf("API key %s: tier must be one of: %s, %s; got %q", key.ID, TierStandard, TierPremium, key.Tier)
+		}
+		if key.MonthlyQuota < 0 {
+			return nil, fmt.Errorf("API key %s: monthly_quota must not be negative", key.ID)
+		}
+		sum, err := hex.DecodeString(key.SHA256)
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("API key %s: sha256 must be 64 hex digits", key.ID)
+		}
+		hash := [sha256.Size]byte(sum)
+		if _, ok := k.byHash[hash]; ok {
+			return nil, fmt.Errorf("API key %s: sha256 repeats another key's", key.ID)
+		}
+		ids[key.ID] = true
+		k.byHash[hash] = &bucket{key: key, rate: rate}
+	}
+	return k, nil
+}
+
+// Load returns the keys listed in the JSON file at path, an array of Key.
+func Load(path string, cfg Config) (*Keys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New(path + " lists no API keys")
+	}
+	return New(keys, cfg)
+}
+
+// Len returns how many keys the set holds.
+func (k *Keys) Len() int {
+	return len(k.byHash)
+}
+
+// Stats returns the counters of every tier.
+func (k *Keys) Stats() Stats {
+	stats := Stats{
+		Tiers:       make(map[string]TierStats, len(k.tiers)),
+		InvalidKeys: k.invalid.Load(),
+		Flushes:     k.flushes.Load(),
+		FlushErrors: k.flushErrors.Load(),
+	}
+	for tier, c := range k.tiers {
+		stats.Tiers[tier] = TierStats{
+			Requests:      c.requests.Load(),
+			RateLimited:   c.rateLimited.Load(),
+			Throttled:     c.throttled.Load(),
+			ServerErrors:  c.serverErrors.Load(),
+			QuotaExceeded: c.quotaExceeded.Load(),
+		}
+	}
+	return stats
+}
+
+// tierKey is the context key of a request's tier.
+type tierKey struct{}
+
+// Tier returns the tier of the request of ctx, TierAnonymous when it had no key.
+func Tier(ctx context.Context) string {
+	if tier, ok := ctx.Value(tierKey{}).(string); ok {
+		return tier
+	}
+	return TierAnonymous
+}
+
+// Priority reports whether the request of ctx is a premium one.
+func Priority(ctx context.Context) bool {
+	return Tier(ctx) == TierPremium
+}
+
+// Handler returns middleware identifying the key of each request. A request with an
+// unknown key gets 401, and one over its key's rate or monthly quota 429 with
+// Retry-After; the others are handled with their tier in their user context. Mount it
+// before the subsystems reading Priority. With a UsageStore, each request with a key
+// counts against its quota unless it is answered other than 2xx.
+func (k *Keys) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tier := TierAnonymous
+		var reserved *bucket
+		var month string
+		if raw := c.Get(Header); raw != "" {
+			b, ok := k.byHash[sha256.Sum256([]byte(raw))]
+			if !ok {
+				k.invalid.Add(1)
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid API key"})
+			}
+			tier = b.key.Tier
+			if ok, wait := b.take(k.now()); !ok {
+				counters := k.tiers[tier]
+				counters.requests.Add(1)
+				counters.rateLimited.Add(1)
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "API key rate limit exceeded, retry later"})
+			}
+			if k.usage != nil {
+				now := k.now()
+				var ok bool
+				var usage Usage
+				if month, ok, usage = k.reserve(b.key, now); !ok {
+					counters := k.tiers[tier]
+					counters.requests.Add(1)
+					counters.quotaExceeded.Add(1)
+					c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(usage.ResetsAt.Sub(now).Seconds()))))
+					return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+						"error": "API key monthly claim quota exceeded",
+						"usage": usage,
+					})
+				}
+				reserved = b
+			}
+			c.SetUserContext(context.WithValue(c.UserContext(), tierKey{}, tier))
+		}
+		counters := k.tiers[tier]
+		counters.requests.Add(1)
+
+		err := c.Next()
+		status := c.Response().StatusCode()
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			status = fe.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+		switch {
+		case status == fiber.StatusTooManyRequests:
+			counters.throttled.Add(1)
+		case status >= fiber.StatusInternalServerError:
+			counters.serverErrors.Add(1)
+		}
+		if reserved != nil && (status < 200 || status > 299) {
+			k.release(reserved.key.ID, month)
+		}
+		return err
+	}
+}
@@ -0,0 +1,136 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hash returns the hex SHA-256 of key.
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// newApp returns an app claiming through k, answering each claim with status and
+// recording the tier the handler saw.
+func newApp(k *Keys, status int, tiers *[]string) *fiber.App {
+	app := fiber.New()
+	app.Post("/api/coupons/claim", k.Handler(), func(c *fiber.Ctx) error {
+		*tiers = append(*tiers, Tier(c.UserContext()))
+		return c.SendStatus(status)
+	})
+	return app
+}
+
+// claim posts a claim with key, none when empty.
+func claim(t *testing.T, app *fiber.App, key string) int {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/api/coupons/claim", nil)
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestKeys_Handler(t *testing.T) {
+	k, err := New([]Key{
+		{ID: "acme", Tier: TierPremium, SHA256: hash("acme-secret")},
+		{ID: "shop", Tier: TierStandard, SHA256: hash("shop-secret")},
+	}, Config{StandardRate: 1})
+	require.NoError(t, err)
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	k.now = func() time.Time { return now }
+	var tiers []string
+	app := newApp(k, fiber.StatusCreated, &tiers)
+
+	assert.Equal(t, fiber.StatusCreated, claim(t, app, ""))
+	assert.Equal(t, fiber.StatusCreated, claim(t, app, "acme-secret"))
+	assert.Equal(t, fiber.StatusCreated, claim(t, app, "shop-secret"))
+	assert.Equal(t, fiber.StatusTooManyRequests, claim(t, app, "shop-secret"), "over the standard rate")
+	assert.Equal(t, fiber.StatusCreated, claim(t, app, "acme-secret"), "premium is not limited")
+	assert.Equal(t, fiber.StatusUnauthorized, claim(t, app, "guessed"))
+	now = now.Add(time.Second)
+	assert.Equal(t, fiber.StatusCreated, claim(t, app, "shop-secret"), "the bucket refilled")
+
+	assert.Equal(t, []string{TierAnonymous, TierPremium, TierStandard, TierPremium, TierStandard}, tiers)
+	stats := k.Stats()
+	assert.Equal(t, int64(1), stats.InvalidKeys)
+	assert.Equal(t, TierStats{Requests: 2}, stats.Tiers[TierPremium])
+	assert.Equal(t, TierStats{Requests: 3, RateLimited: 1}, stats.Tiers[TierStandard])
+	assert.Equal(t, TierStats{Requests: 1}, stats.Tiers[TierAnonymous])
+}
+
+func TestKeys_Handler_CountsDownstreamOutcomes(t *testing.T) {
+	k, err := New([]Key{{ID: "acme", Tier: TierPremium, SHA256: hash("acme-secret")}}, Config{})
+	require.NoError(t, err)
+	var tiers []string
+
+	claim(t, newApp(k, fiber.StatusTooManyRequests, &tiers), "acme-secret")
+	claim(t, newApp(k, fiber.StatusServiceUnavailable, &tiers), "acme-secret")
+
+	assert.Equal(t, TierStats{Requests: 2, Throttled: 1, ServerErrors: 1}, k.Stats().Tiers[TierPremium])
+}
+
+func TestKeys_RetryAfter(t *testing.T) {
+	k, err := New([]Key{{ID: "shop", Tier: TierStandard, SHA256: hash("shop-secret")}}, Config{StandardRate: 0.1})
+	require.NoError(t, err)
+	app := newApp(k, fiber.StatusCreated, new([]string))
+	claim(t, app, "shop-secret")
+
+	req := httptest.NewRequest(fiber.MethodPost, "/api/coupons/claim", nil)
+	req.Header.Set(Header, "shop-secret")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "10", resp.Header.Get(fiber.HeaderRetryAfter))
+}
+
+func TestPriority(t *testing.T) {
+	assert.False(t, Priority(context.Background()))
+	assert.True(t, Priority(context.WithValue(context.Background(), tierKey{}, TierPremium)))
+	assert.False(t, Priority(context.WithValue(context.Background(), tierKey{}, TierStandard)))
+}
+
+func TestNew_InvalidKeys(t *testing.T) {
+	tests := map[string][]Key{
+		"empty id":       {{Tier: TierStandard, SHA256: hash("a")}},
+		"repeated id":    {{ID: "a", Tier: TierStandard, SHA256: hash("a")}, {ID: "a", Tier: TierStandard, SHA256: hash("b")}},
+		"unknown tier":   {{ID: "a", Tier: "gold", SHA256: hash("a")}},
+		"invalid hash":   {{ID: "a", Tier: TierStandard, SHA256: "abc"}},
+		"repeated hash":  {{ID: "a", Tier: TierStandard, SHA256: hash("a")}, {ID: "b", Tier: TierPremium, SHA256: hash("a")}},
+		"negative quota": {{ID: "a", Tier: TierStandard, SHA256: hash("a"), MonthlyQuota: -1}},
+	}
+	for name, keys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(keys, Config{})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id":"acme","tier":"premium","sha256":"`+hash("acme-secret")+`"}]`), 0o600))
+
+	k, err := Load(path, Config{})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, k.Len())
+
+	require.NoError(t, os.WriteFile(path, []byte(`[]`), 0o600))
+	_, err = Load(path, Config{})
+	assert.Error(t, err)
+}
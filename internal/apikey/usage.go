@@ -0,0 +1,193 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// UsageStore persists the claims counted against each key's monthly quota, shared by
+// every replica (implemented by ports.APIKeyUsageRepository).
+type UsageStore interface {
+	// AddUsage adds the claims of deltas, by key ID, to the keys' counts for month
+	// ("2006-01", UTC) and returns the count of every key with claims in month.
+	AddUsage(ctx context.Context, month string, deltas map[string]int64) (map[string]int64, error)
+}
+
+// Usage is a key's claim volume in the current month.
+type Usage struct {
+	ID           string    `json:"id"`
+	Tier         string    `json:"tier"`
+	Month        string    `json:"month"`                   // UTC, e.g. "2026-10"
+	Claims       int64     `json:"claims"`                  // Counted by every replica as of the last flush, plus this one's since
+	MonthlyQuota int64     `json:"monthly_quota,omitempty"` // Omitted when the key has none
+	Remaining    *int64    `json:"remaining,omitempty"`     // Claims left this month; omitted without a quota
+	ResetsAt     time.Time `json:"resets_at"`               // When the next month begins
+}
+
+// usageKey identifies the claims of one key in one month.
+type usageKey struct {
+	id    string
+	month string
+}
+
+// monthOf returns the UTC month of t, as counts are kept.
+func monthOf(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// nextMonth returns when the UTC month after the one of t begins.
+func nextMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// SetUsageStore counts each key's claims in store, flushed by RunUsage, and enforces
+// the keys' monthly quotas against the counts. Without it claims are not counted.
+func (k *Keys) SetUsageStore(store UsageStore) {
+	k.usage = store
+}
+
+// HasQuotas reports whether a key has a monthly quota.
+func (k *Keys) HasQuotas() bool {
+	for _, b := range k.byHash {
+		if b.key.MonthlyQuota > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// rollover starts counting month if it is not the current one. The caller holds k.usageMu.
+func (k *Keys) rollover(month string) {
+	if month != k.month {
+		k.month = month
+		k.totals = make(map[string]int64)
+	}
+}
+
+// usedLocked returns the claims of the key id this month. The caller holds k.usageMu.
+func (k *Keys) usedLocked(id string) int64 {
+	return k.totals[id] + k.pending[usageKey{id, k.month}]
+}
+
+// reserve counts a claim of key at now against its quota, and returns the month it
+// counts in, or false with the key's usage when the quota is used up.
+func (k *Keys) reserve(key Key, now time.Time) (string, bool, Usage) {
+	month := monthOf(now)
+	k.usageMu.Lock()
+	defer k.usageMu.Unlock()
+	k.rollover(month)
+	if key.MonthlyQuota > 0 && k.usedLocked(key.ID) >= key.MonthlyQuota {
+		return month, false, k.usageLocked(key, now)
+	}
+	k.pending[usageKey{key.ID, month}]++
+	return month, true, Usage{}
+}
+
+// release takes back a claim reserved in month whose request failed.
+func (k *Keys) release(id, month string) {
+	k.usageMu.Lock()
+	defer k.usageMu.Unlock()
+	k.pending[usageKey{id, month}]--
+}
+
+// Usage returns the usage of the key id this month, or false if there is no such key.
+func (k *Keys) Usage(id string) (Usage, bool) {
+	for _, b := range k.byHash {
+		if b.key.ID == id {
+			now := k.now()
+			k.usageMu.Lock()
+			defer k.usageMu.Unlock()
+			k.rollover(monthOf(now))
+			return k.usageLocked(b.key, now), true
+		}
+	}
+	return Usage{}, false
+}
+
+// usageLocked returns the usage of key at now. The caller holds k.usageMu.
+func (k *Keys) usageLocked(key Key, now time.Time) Usage {
+	u := Usage{
+		ID:           key.ID,
+		Tier:         key.Tier,
+		Month:        k.month,
+		Claims:       k.usedLocked(key.ID),
+		MonthlyQuota: key.MonthlyQuota,
+		ResetsAt:     nextMonth(now),
+	}
+	if key.MonthlyQuota > 0 {
+		remaining := max(0, key.MonthlyQuota-u.Claims)
+		u.Remaining = &remaining
+	}
+	return u
+}
+
+// Flush adds the claims counted since the last flush to the store, and takes the
+// current month's counts of every replica from it. Claims that could not be flushed
+// are kept for the next one.
+func (k *Keys) Flush(ctx context.Context) error {
+	if k.usage == nil {
+		return nil
+	}
+	month := monthOf(k.now())
+	k.usageMu.Lock()
+	k.rollover(month)
+	byMonth := map[string]map[string]int64{month: {}} // Always read the current month
+	for key, n := range k.pending {
+		if n == 0 {
+			delete(k.pending, key)
+			continue
+		}
+		if byMonth[key.month] == nil {
+			byMonth[key.month] = make(map[string]int64)
+		}
+		byMonth[key.month][key.id] = n
+	}
+	k.usageMu.Unlock()
+
+	var errs []error
+	for m, deltas := range byMonth {
+		totals, err := k.usage.AddUsage(ctx, m, deltas)
+		if err != nil {
+			k.flushErrors.Add(1)
+			errs = append(errs, err)
+			continue
+		}
+		// The flushed claims move from pending to the totals at once, so that claims
+		// counted meanwhile stay pending and none is counted twice
+		k.usageMu.Lock()
+		for id, n := range deltas {
+			k.pending[usageKey{id, m}] -= n
+		}
+		if m == k.month {
+			k.totals = totals
+		}
+		k.usageMu.Unlock()
+	}
+	if len(errs) == 0 {
+		k.flushes.Add(1)
+	}
+	return errors.Join(errs...)
+}
+
+// RunUsage flushes the claim counts now and then every interval until ctx is
+// cancelled, so that quotas hold across replicas within interval. Flush once more at
+// shutdown so the last claims are not lost.
+func (k *Keys) RunUsage(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := k.Flush(ctx); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("API key usage flush failed, retrying with the next")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memUsage is a UsageStore in memory, shared by the Keys of several "replicas".
+type memUsage struct {
+	mu     sync.Mutex
+	claims map[string]map[string]int64 // By month, then key ID
+	err    error
+}
+
+func (m *memUsage) AddUsage(_ context.Context, month string, deltas map[string]int64) (map[string]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.claims == nil {
+		m.claims = make(map[string]map[string]int64)
+	}
+	if m.claims[month] == nil {
+		m.claims[month] = make(map[string]int64)
+	}
+	for id, n := range deltas {
+		m.claims[month][id] += n
+	}
+	totals := make(map[string]int64, len(m.claims[month]))
+	for id, n := range m.claims[month] {
+		totals[id] = n
+	}
+	return totals, nil
+}
+
+// newQuotaKeys returns keys counting their usage in store at *now, acme with a quota
+// of quota claims a month.
+func newQuotaKeys(t *testing.T, store UsageStore, now *time.Time, quota int64) *Keys {
+	t.Helper()
+	k, err := New([]Key{
+		{ID: "acme", Tier: TierPremium, SHA256: hash("acme-secret"), MonthlyQuota: quota},
+		{ID: "shop", Tier: TierStandard, SHA256: hash("shop-secret")},
+	}, Config{})
+	require.NoError(t, err)
+	k.now = func() time.Time { return *now }
+	k.SetUsageStore(store)
+	return k
+}
+
+func TestKeys_Handler_MonthlyQuota(t *testing.T) {
+	now := time.Date(2026, 10, 31, 23, 0, 0, 0, time.UTC)
+	k := newQuotaKeys(t, &memUsage{}, &now, 2)
+	app := newApp(k, fiber.StatusCreated, new([]string))
+
+	assert.Equal(t, fiber.StatusCreated, claim(t, app, "acme-secret"))
+	assert.Equal(t, fiber.StatusConflict, claim(t, newApp(k, fiber.StatusConflict, new([]string)), "acme-secret"),
+		"refused claims are not counted")
+	assert.Equal(t, fiber.StatusCreated, claim(t, app, "acme-secret"))
+
+	req := httptest.NewRequest(fiber.MethodPost, "/api/coupons/claim", nil)
+	req.Header.Set(Header, "acme-secret")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "3600", resp.Header.Get(fiber.HeaderRetryAfter), "until the next month")
+	var body struct {
+		Error string `json:"error"`
+		Usage Usage  `json:"usage"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "API key monthly claim quota exceeded", body.Error)
+	assert.Equal(t, "2026-10", body.Usage.Month)
+	assert.Equal(t, int64(2), body.Usage.Claims)
+	require.NotNil(t, body.Usage.Remaining)
+	assert.Zero(t, *body.Usage.Remaining)
+
+	assert.Equal(t, fiber.StatusCreated, claim(t, app, "shop-secret"), "keys without a quota are only counted")
+	assert.Equal(t, TierStats{Requests: 4, QuotaExceeded: 1}, k.Stats().Tiers[TierPremium])
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, fiber.StatusCreated, claim(t, app, "acme-secret"), "the quota resets with the month")
+}
+
+func TestKeys_Flush_SharesUsageAcrossReplicas(t *testing.T) {
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	store := &memUsage{}
+	a := newQuotaKeys(t, store, &now, 3)
+	b := newQuotaKeys(t, store, &now, 3)
+	appA := newApp(a, fiber.StatusCreated, new([]string))
+	appB := newApp(b, fiber.StatusCreated, new([]string))
+
+	claim(t, appA, "acme-secret")
+	claim(t, appA, "acme-secret")
+	claim(t, appB, "acme-secret")
+	require.NoError(t, a.Flush(context.Background()))
+	require.NoError(t, b.Flush(context.Background()))
+
+	assert.Equal(t, fiber.StatusTooManyRequests, claim(t, appB, "acme-secret"), "b sees a's claims once flushed")
+	usage, ok := b.Usage("acme")
+	require.True(t, ok)
+	assert.Equal(t, int64(3), usage.Claims)
+	assert.Equal(t, map[string]int64{"acme": 3}, store.claims["2026-10"], "each claim is stored once")
+	assert.Equal(t, int64(1), b.Stats().Flushes)
+}
+
+func TestKeys_Flush_KeepsClaimsOnError(t *testing.T) {
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	store := &memUsage{err: errors.New("connection refused")}
+	k := newQuotaKeys(t, store, &now, 0)
+	claim(t, newApp(k, fiber.StatusCreated, new([]string)), "shop-secret")
+
+	assert.Error(t, k.Flush(context.Background()))
+	assert.Equal(t, int64(1), k.Stats().FlushErrors)
+
+	store.err = nil
+	require.NoError(t, k.Flush(context.Background()))
+	assert.Equal(t, map[string]int64{"shop": 1}, store.claims["2026-10"])
+	require.NoError(t, k.Flush(context.Background()))
+	assert.Equal(t, map[string]int64{"shop": 1}, store.claims["2026-10"], "flushed claims are not flushed again")
+}
+
+func TestKeys_Usage(t *testing.T) {
+	now := time.Date(2026, 12, 5, 0, 0, 0, 0, time.UTC)
+	k := newQuotaKeys(t, &memUsage{}, &now, 10)
+	claim(t, newApp(k, fiber.StatusCreated, new([]string)), "acme-secret")
+
+	usage, ok := k.Usage("acme")
+	require.True(t, ok)
+	remaining := int64(9)
+	assert.Equal(t, Usage{
+		ID: "acme", Tier: TierPremium, Month: "2026-12", Claims: 1, MonthlyQuota: 10,
+		Remaining: &remaining, ResetsAt: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, usage)
+
+	usage, ok = k.Usage("shop")
+	require.True(t, ok)
+	assert.Nil(t, usage.Remaining, "no quota")
+
+	_, ok = k.Usage("nobody")
+	assert.False(t, ok)
+	assert.True(t, k.HasQuotas())
+}
@@ -0,0 +1,246 @@
+// Package apperr defines the errors the coupon system reports to its callers, each with
+// a stable code and the HTTP status it maps to. Repositories return them to the
+// service layer and handlers match on them, so neither depends on the service package.
+// Match with errors.Is against the sentinels below; wrapped errors keep matching.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// Error is an error with a stable code and the HTTP status it maps to.
+// The sentinels below are its only instances; compare them with errors.Is.
+type Error struct {
+	Code   string // Stable machine-readable identifier, e.g. "coupon_not_found"
+	Status int    // HTTP status the error maps to
+	msg    string
+}
+
+func newError(code string, status int, msg string) *Error {
+	return &Error{Code: code, Status: status, msg: msg}
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+var (
+	// ErrCouponExists is returned when attempting to create a coupon that already exists
+	ErrCouponExists = newError("coupon_exists", http.StatusConflict, "coupon already exists")
+
+	// ErrCouponNotFound is returned when a coupon cannot be found
+	ErrCouponNotFound = newError("coupon_not_found", http.StatusNotFound, "coupon not found")
+
+	// ErrInvalidRequest is returned when request data is invalid or incomplete
+	ErrInvalidRequest = newError("invalid_request", http.StatusBadRequest, "invalid request")
+
+	// ErrAlreadyClaimed is returned when a user attempts to claim a coupon they already claimed
+	ErrAlreadyClaimed = newError("already_claimed", http.StatusConflict, "coupon already claimed by user")
+
+	// ErrNoStock is returned when a coupon has no remaining stock
+	ErrNoStock = newError("no_stock", http.StatusBadRequest, "coupon out of stock")
+
+	// ErrChannelRequired is returned when claiming a channel-partitioned coupon without a channel
+	ErrChannelRequired = newError("channel_required", http.StatusBadRequest, "channel is required for this coupon")
+
+	// ErrUnknownChannel is returned when a claim names a channel the coupon has no partition for
+	ErrUnknownChannel = newError("unknown_channel", http.StatusBadRequest, "unknown channel for this coupon")
+
+	// ErrInvalidChannelQuotas is returned when channel percentages do not sum to 100
+	ErrInvalidChannelQuotas = newError("invalid_channel_quotas", http.StatusBadRequest, "channel percentages must sum to 100")
+
+	// ErrCouponDisabled is returned when claiming a coupon that has been disabled
+	ErrCouponDisabled = newError("coupon_disabled", http.StatusBadRequest, "coupon is disabled")
+
+	// ErrCaptchaRequired is returned when claiming a coupon that requires a captcha
+	// without a verified captcha token
+	ErrCaptchaRequired = newError("captcha_required", http.StatusForbidden, "captcha required")
+
+	// ErrGrantRequired is returned when claiming a private coupon without a verified
+	// claim grant
+	ErrGrantRequired = newError("grant_required", http.StatusForbidden, "claim grant required")
+
+	// ErrClaimQueued is returned when the database was unreachable and the claim was
+	// buffered for later replay (store-and-forward mode). The claim is accepted, not granted.
+	ErrClaimQueued = newError("claim_queued", http.StatusAccepted, "claim queued for processing")
+
+	// ErrPartitionedTopUp is returned when topping up a channel-partitioned coupon,
+	// whose partitions must keep summing to its amount
+	ErrPartitionedTopUp = newError("partitioned_top_up", http.StatusConflict, "channel-partitioned coupons cannot be topped up")
+
+	// ErrUnlimitedTopUp is returned when topping up an unlimited coupon, which has no stock
+	ErrUnlimitedTopUp = newError("unlimited_top_up", http.StatusConflict, "unlimited coupons cannot be topped up")
+
+	// ErrManifestConflict is returned when a manifest contains changes that cannot be reconciled
+	ErrManifestConflict = newError("manifest_conflict", http.StatusConflict, "manifest conflicts with existing coupons")
+
+	// ErrInvalidTiers is returned when tier sizes add up to more than the coupon amount
+	ErrInvalidTiers = newError("invalid_tiers", http.StatusBadRequest, "tier sizes exceed coupon amount")
+
+	// ErrInvalidParent is returned when a coupon's parent does not exist, is itself the
+	// child of another coupon, or is unlimited (and so has no budget to share)
+	ErrInvalidParent = newError("invalid_parent", http.StatusBadRequest, "parent must be an existing coupon without a parent")
+
+	// ErrInvalidPrerequisite is returned when a coupon's prerequisite does not exist or
+	// is the coupon itself
+	ErrInvalidPrerequisite = newError("invalid_prerequisite", http.StatusBadRequest, "prerequisite must be an existing coupon")
+
+	// ErrPrerequisiteNotClaimed is returned when claiming a coupon whose prerequisite
+	// coupon the user has not claimed
+	ErrPrerequisiteNotClaimed = newError("prerequisite_not_claimed", http.StatusForbidden, "prerequisite coupon not claimed")
+
+	// ErrExclusionGroupClaimed is returned when claiming a coupon of an exclusion group
+	// the user already claimed another coupon of
+	ErrExclusionGroupClaimed = newError("exclusion_group_claimed", http.StatusConflict, "user already claimed a coupon of this exclusion group")
+
+	// ErrWebhookNotFound is returned when a webhook subscription cannot be found
+	ErrWebhookNotFound = newError("webhook_not_found", http.StatusNotFound, "webhook subscription not found")
+
+	// ErrInvalidWebhookTemplate is returned when a webhook payload template does not
+	// parse, uses a forbidden construct or does not render valid JSON
+	ErrInvalidWebhookTemplate = newError("invalid_webhook_template", http.StatusBadRequest, "invalid payload template")
+
+	// ErrCampaignCapReached is returned when a claim would exceed the claim cap of a
+	// campaign (tag) of the coupon
+	ErrCampaignCapReached = newError("campaign_cap_reached", http.StatusBadRequest, "campaign claim cap reached")
+
+	// ErrCampaignCapNotFound is returned when a campaign has no claim cap
+	ErrCampaignCapNotFound = newError("campaign_cap_not_found", http.StatusNotFound, "campaign claim cap not found")
+
+	// ErrRegionQuotaReached is returned when a claim's region has used up its quota
+	// of the coupon
+	ErrRegionQuotaReached = newError("region_quota_reached", http.StatusBadRequest, "region quota reached")
+
+	// ErrCouponBusy is returned when a coupon's row lock is held by another transaction
+	// and the lock policy (COUPON_LOCK_POLICY) does not wait for it. Retrying may succeed
+	ErrCouponBusy = newError("coupon_busy", http.StatusServiceUnavailable, "coupon is busy")
+
+	// ErrDeadlineTooShort is returned when a claim's deadline leaves less than the minimum
+	// claim budget (CLAIM_MIN_BUDGET), so its transaction was not begun
+	ErrDeadlineTooShort = newError("deadline_too_short", http.StatusServiceUnavailable, "not enough time left to claim")
+
+	// ErrClaimPaced is returned when a coupon's claim pacing queue (CLAIM_PACING_RATE) is
+	// full, or the claim's turn would come after its deadline. Retrying later may succeed
+	ErrClaimPaced = newError("claim_paced", http.StatusTooManyRequests, "too many claims on this coupon")
+
+	// ErrInvalidMetadata is returned when coupon metadata is not a JSON object or
+	// violates the metadata schema (COUPON_METADATA_SCHEMA)
+	ErrInvalidMetadata = newError("invalid_metadata", http.StatusBadRequest, "invalid coupon metadata")
+
+	// ErrNotAllowlisted is returned when a coupon has an allowlist the user is not on
+	ErrNotAllowlisted = newError("not_allowlisted", http.StatusForbidden, "user is not allowlisted for this coupon")
+
+	// ErrClaimDeadlinePassed is returned when the claim-by deadline of the user's
+	// allowlist entry has passed
+	ErrClaimDeadlinePassed = newError("claim_deadline_passed", http.StatusForbidden, "claim deadline has passed")
+
+	// ErrAllowlistNotFound is returned when a coupon has no allowlist
+	ErrAllowlistNotFound = newError("allowlist_not_found", http.StatusNotFound, "coupon allowlist not found")
+
+	// ErrCouponDeleted is returned when a coupon name is held by a deleted coupon that
+	// has not been purged yet
+	ErrCouponDeleted = newError("coupon_deleted", http.StatusConflict, "coupon is deleted")
+
+	// ErrDeletedCouponNotFound is returned when no coupon of a name was deleted within
+	// the undo window (COUPON_UNDO_WINDOW)
+	ErrDeletedCouponNotFound = newError("deleted_coupon_not_found", http.StatusNotFound, "deleted coupon not found")
+
+	// ErrArchivedCouponNotFound is returned when the coupon archive holds no listed
+	// coupon of a name
+	ErrArchivedCouponNotFound = newError("archived_coupon_not_found", http.StatusNotFound, "archived coupon not found")
+
+	// ErrStockWaitUnavailable is returned when a request cannot wait for stock: the
+	// requests waiting are at their maximum (STOCK_WAIT_MAX_WAITERS), or the server is
+	// shutting down. Retrying later may succeed
+	ErrStockWaitUnavailable = newError("stock_wait_unavailable", http.StatusServiceUnavailable, "cannot wait for stock right now")
+
+	// ErrClaimRetentionDisabled is returned when creating a coupon with a claim retention
+	// while nothing enforces it (CLAIM_RETENTION_ENABLED)
+	ErrClaimRetentionDisabled = newError("claim_retention_disabled", http.StatusBadRequest, "claim retention is not enabled")
+
+	// ErrLoadTestNamespaceExists is returned when prewarming a load test namespace that
+	// has not been cleaned up yet
+	ErrLoadTestNamespaceExists = newError("load_test_namespace_exists", http.StatusConflict, "load test namespace already exists")
+
+	// ErrLoadTestNamespaceNotFound is returned when cleaning up a load test namespace that
+	// does not exist or was already cleaned up
+	ErrLoadTestNamespaceNotFound = newError("load_test_namespace_not_found", http.StatusNotFound, "load test namespace not found")
+
+	// ErrLoadTestTooLarge is returned when a prewarm asks for more claims in total than
+	// one request may create
+	ErrLoadTestTooLarge = newError("load_test_too_large", http.StatusBadRequest, "load test prewarm asks for too many claims")
+)
+
+// As returns the first *Error in err's chain.
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// Code returns the code of the first *Error in err's chain, or "internal" if there is none.
+func Code(err error) string {
+	if e, ok := As(err); ok {
+		return e.Code
+	}
+	return "internal"
+}
+
+// HTTPStatus returns the HTTP status of the first *Error in err's chain, or 500 if
+// there is none. Handlers may still answer differently where a route needs to.
+func HTTPStatus(err error) int {
+	if e, ok := As(err); ok {
+		return e.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// ConflictError is returned when a coupon already exists with a configuration
+// different from the one requested. It matches ErrCouponExists via errors.Is.
+type ConflictError struct {
+	Diffs []model.FieldDiff
+}
+
+func (e *ConflictError) Error() string {
+	return "coupon already exists with different configuration"
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrCouponExists
+}
+
+// AlreadyClaimedError is returned for a repeat claim when the user's earlier claim could
+// be read, so callers can tell the user when they claimed. It matches ErrAlreadyClaimed
+// via errors.Is.
+type AlreadyClaimedError struct {
+	ClaimedAt     time.Time
+	ClaimSequence int // The earlier claim's receipt number
+}
+
+func (e *AlreadyClaimedError) Error() string {
+	return ErrAlreadyClaimed.Error()
+}
+
+func (e *AlreadyClaimedError) Unwrap() error {
+	return ErrAlreadyClaimed
+}
+
+// MetadataError is returned when coupon metadata is rejected. It matches
+// ErrInvalidMetadata via errors.Is.
+type MetadataError struct {
+	Reason string // Why, e.g. "must be a JSON object" or the schema violations
+}
+
+func (e *MetadataError) Error() string {
+	return "invalid coupon metadata: " + e.Reason
+}
+
+func (e *MetadataError) Unwrap() error {
+	return ErrInvalidMetadata
+}
@@ -0,0 +1,40 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, HTTPStatus(ErrCouponNotFound))
+	assert.Equal(t, http.StatusConflict, HTTPStatus(fmt.Errorf("insert coupon: %w", ErrCouponExists)), "wrapped errors keep their status")
+	assert.Equal(t, http.StatusConflict, HTTPStatus(&ConflictError{}))
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(&MetadataError{Reason: "must be a JSON object"}))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(errors.New("connection reset")))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(nil))
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, "no_stock", Code(fmt.Errorf("claim: %w", ErrNoStock)))
+	assert.Equal(t, "internal", Code(errors.New("connection reset")))
+}
+
+func TestAs(t *testing.T) {
+	e, ok := As(fmt.Errorf("lock coupon: %w", ErrCouponBusy))
+	assert.True(t, ok)
+	assert.Same(t, ErrCouponBusy, e)
+	assert.Equal(t, "coupon is busy", e.Error())
+
+	_, ok = As(errors.New("connection reset"))
+	assert.False(t, ok)
+}
+
+func TestErrorsIs(t *testing.T) {
+	assert.ErrorIs(t, &ConflictError{}, ErrCouponExists)
+	assert.ErrorIs(t, &MetadataError{}, ErrInvalidMetadata)
+	assert.NotErrorIs(t, ErrCouponExists, ErrCouponNotFound)
+}
@@ -0,0 +1,91 @@
+// Package bloom provides an in-process Bloom filter over strings.
+package bloom
+
+import (
+	"hash/maphash"
+	"math"
+	"sync/atomic"
+)
+
+// Filter is a fixed-size Bloom filter: MayContain never misses a key added since the
+// last Reset, but may report keys that were never added (false positives). The
+// false-positive rate stays near the one the filter was sized for until more than
+// its capacity keys have been added. It is safe for concurrent use.
+type Filter struct {
+	bits   []atomic.Uint64
+	m      uint64 // Number of bits
+	k      int    // Number of hash functions
+	seed1  maphash.Seed
+	seed2  maphash.Seed
+	added  atomic.Int64
+	target int
+}
+
+// New creates a filter sized to hold capacity keys at false-positive rate fpRate.
+func New(capacity int, fpRate float64) *Filter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	// Optimal size and hash count: m = -n·ln(p)/ln(2)², k = m/n·ln(2).
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) / 64 * 64
+	k := max(1, int(math.Round(float64(m)/float64(capacity)*math.Ln2)))
+	return &Filter{
+		bits:   make([]atomic.Uint64, m/64),
+		m:      m,
+		k:      k,
+		seed1:  maphash.MakeSeed(),
+		seed2:  maphash.MakeSeed(),
+		target: capacity,
+	}
+}
+
+// Add records key.
+func (f *Filter) Add(key string) {
+	h1, h2 := f.hash(key)
+	for i := range f.k {
+		bit := (h1 + uint64(i)*h2) % f.m
+		f.bits[bit/64].Or(1 << (bit % 64))
+	}
+	f.added.Add(1)
+}
+
+// MayContain reports whether key may have been added. False means it was not.
+func (f *Filter) MayContain(key string) bool {
+	h1, h2 := f.hash(key)
+	for i := range f.k {
+		bit := (h1 + uint64(i)*h2) % f.m
+		if f.bits[bit/64].Load()&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns how many keys were added since the last Reset, counting repeats.
+func (f *Filter) Len() int {
+	return int(f.added.Load())
+}
+
+// Full reports whether the filter holds more keys than it was sized for, so its
+// false-positive rate is above the one requested.
+func (f *Filter) Full() bool {
+	return f.Len() > f.target
+}
+
+// Reset removes all keys. Keys added concurrently with Reset may or may not survive it.
+func (f *Filter) Reset() {
+	for i := range f.bits {
+		f.bits[i].Store(0)
+	}
+	f.added.Store(0)
+}
+
+// hash returns the two hashes combined into the k bit positions of key
+// (Kirsch-Mitzenmacher double hashing). h2 is odd, so never 0.
+func (f *Filter) hash(key string) (uint64, uint64) {
+	return maphash.String(f.seed1, key), maphash.String(f.seed2, key) | 1
+}
@@ -0,0 +1,69 @@
+package bloom
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_NoFalseNegatives(t *testing.T) {
+	f := New(1000, 0.01)
+	for i := range 1000 {
+		f.Add("key-" + strconv.Itoa(i))
+	}
+
+	for i := range 1000 {
+		assert.True(t, f.MayContain("key-"+strconv.Itoa(i)), "key-%d", i)
+	}
+	assert.Equal(t, 1000, f.Len())
+	assert.False(t, f.Full())
+}
+
+func TestFilter_FalsePositiveRate(t *testing.T) {
+	f := New(10000, 0.01)
+	for i := range 10000 {
+		f.Add("added-" + strconv.Itoa(i))
+	}
+
+	positives := 0
+	for i := range 10000 {
+		if f.MayContain("other-" + strconv.Itoa(i)) {
+			positives++
+		}
+	}
+	assert.Less(t, positives, 200, "false-positive rate near the requested 1%")
+}
+
+func TestFilter_Reset(t *testing.T) {
+	f := New(1, 0.01)
+	f.Add("a")
+	f.Add("b")
+	assert.True(t, f.Full())
+
+	f.Reset()
+
+	assert.False(t, f.MayContain("a"))
+	assert.Zero(t, f.Len())
+	assert.False(t, f.Full())
+}
+
+func TestFilter_Concurrent(t *testing.T) {
+	f := New(1000, 0.01)
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				key := strconv.Itoa(w) + "-" + strconv.Itoa(i)
+				f.Add(key)
+				assert.True(t, f.MayContain(key))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 800, f.Len())
+}
@@ -0,0 +1,126 @@
+// Package cache provides a small in-process LRU cache with per-entry TTL.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of cache counters.
+type Stats struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+	Size     int     `json:"size"`
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// LRU is a size-bounded, least-recently-used cache whose entries expire after a TTL.
+// It is safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List // Front is most recently used
+	items    map[K]*list.Element
+	now      func() time.Time
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewLRU creates a cache holding at most capacity entries, each for at most ttl.
+func NewLRU[K comparable, V any](capacity int, ttl time.Duration) *LRU[K, V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRU[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[K]*list.Element, capacity),
+		now:      time.Now,
+	}
+}
+
+// Get returns the cached value for key if present and not expired.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		if c.now().Before(e.expiresAt) {
+			c.order.MoveToFront(el)
+			c.hits.Add(1)
+			return e.value, true
+		}
+		c.removeElement(el)
+	}
+	c.misses.Add(1)
+	var zero V
+	return zero, false
+}
+
+// Add stores value for key, evicting the least recently used entry when full.
+func (c *LRU[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	if c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Remove drops key from the cache.
+func (c *LRU[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// Purge drops every entry. Counters are kept.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	clear(c.items)
+}
+
+// Stats returns the current counters.
+func (c *LRU[K, V]) Stats() Stats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	s := Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Size: size}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRatio = float64(s.Hits) / float64(total)
+	}
+	return s
+}
+
+func (c *LRU[K, V]) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
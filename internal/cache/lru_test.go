@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_GetAdd(t *testing.T) {
+	c := NewLRU[string, int](2, time.Minute)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	c.Add("a", 1)
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	c.Add("a", 2)
+	v, _ = c.Get("a")
+	assert.Equal(t, 2, v, "Add replaces existing values")
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](2, time.Minute)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Get("a") // b is now least recently used
+	c.Add("c", 3)
+
+	_, ok := c.Get("b")
+	assert.False(t, ok, "b should be evicted")
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Stats().Size)
+}
+
+func TestLRU_Expires(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := NewLRU[string, int](2, 200*time.Millisecond)
+	c.now = func() time.Time { return now }
+
+	c.Add("a", 1)
+	now = now.Add(199 * time.Millisecond)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Millisecond)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "entries expire after the TTL")
+	assert.Equal(t, 0, c.Stats().Size, "expired entries are dropped")
+}
+
+func TestLRU_RemoveAndPurge(t *testing.T) {
+	c := NewLRU[string, int](4, time.Minute)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("c", 3)
+
+	c.Remove("a")
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	c.Purge()
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Stats().Size)
+}
+
+func TestLRU_Stats(t *testing.T) {
+	c := NewLRU[string, int](4, time.Minute)
+	assert.Equal(t, Stats{}, c.Stats(), "no division by zero before any lookups")
+
+	c.Add("a", 1)
+	c.Get("a")
+	c.Get("a")
+	c.Get("a")
+	c.Get("missing")
+
+	assert.Equal(t, Stats{Hits: 3, Misses: 1, HitRatio: 0.75, Size: 1}, c.Stats())
+}
+
+func TestLRU_Concurrent(t *testing.T) {
+	c := NewLRU[int, int](16, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Add(j%32, i)
+				c.Get(j % 32)
+				if j%100 == 0 {
+					c.Remove(j % 32)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.Stats().Size, 16)
+}
@@ -0,0 +1,72 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// provider serves siteverify, accepting only the token "good".
+func provider(t *testing.T, status int) (*httptest.Server, *http.Request) {
+	t.Helper()
+	var last http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		last = *r
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		if r.PostForm.Get("response") == "good" {
+			_, _ = w.Write([]byte(`{"success": true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &last
+}
+
+func TestSiteVerify_Verify(t *testing.T) {
+	srv, last := provider(t, http.StatusOK)
+	v := NewSiteVerify(srv.URL, "s3cret", time.Second)
+
+	require.NoError(t, v.Verify(context.Background(), "good", "203.0.113.7"))
+	assert.Equal(t, "s3cret", last.PostForm.Get("secret"))
+	assert.Equal(t, "203.0.113.7", last.PostForm.Get("remoteip"))
+
+	err := v.Verify(context.Background(), "forged", "")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorContains(t, err, "invalid-input-response")
+
+	assert.ErrorIs(t, v.Verify(context.Background(), "", ""), ErrInvalidToken, "no token is not sent")
+
+	assert.Equal(t, Stats{Passed: 1, Rejected: 2}, v.Stats())
+}
+
+func TestSiteVerify_ProviderError(t *testing.T) {
+	srv, _ := provider(t, http.StatusInternalServerError)
+	v := NewSiteVerify(srv.URL, "s3cret", time.Second)
+
+	err := v.Verify(context.Background(), "good", "")
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidToken, "an unreachable provider is not a rejected token")
+	assert.Equal(t, int64(1), v.Stats().Errors)
+}
+
+func TestNew(t *testing.T) {
+	for provider, url := range map[string]string{"hcaptcha": HCaptchaURL, "turnstile": TurnstileURL} {
+		v, err := New(provider, "s3cret", time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, url, v.url)
+	}
+
+	_, err := New("recaptcha", "s3cret", time.Second)
+	assert.ErrorContains(t, err, `unknown captcha provider "recaptcha"`)
+}
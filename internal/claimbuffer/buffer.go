@@ -0,0 +1,235 @@
+// Package claimbuffer is a local write-ahead log of claims accepted while the
+// database was unreachable (store-and-forward mode).
+//
+// Entries are appended as JSON lines and fsynced before Append returns, so an
+// accepted claim survives a process crash. The file is per instance: claims
+// buffered by one instance are only replayed by that instance, and are lost
+// if its disk is lost.
+package claimbuffer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// ErrFull is returned when the buffer already holds its maximum number of entries.
+var ErrFull = errors.New("claim buffer is full")
+
+// Entry is one buffered claim. The request's captcha token and claim grant are not
+// kept, only whether they passed verification.
+type Entry struct {
+	Request         model.ClaimCouponRequest `json:"request"`
+	AcceptedAt      time.Time                `json:"accepted_at"`
+	CaptchaVerified bool                     `json:"captcha_verified,omitempty"`
+	GrantVerified   bool                     `json:"grant_verified,omitempty"`
+}
+
+// Buffer is an append-only file of pending claims. It is safe for concurrent use.
+type Buffer struct {
+	mu         sync.Mutex
+	path       string
+	file       *os.File
+	count      int
+	maxEntries int
+	now        func() time.Time
+}
+
+// Open opens (or creates) the buffer file at path, recovering entries left by a
+// previous run. maxEntries bounds how many claims may be pending at once.
+func Open(path string, maxEntries int) (*Buffer, error) {
+	if maxEntries < 1 {
+		return nil, fmt.Errorf("claim buffer max entries must be at least 1, got %d", maxEntries)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("create claim buffer dir: %w", err)
+	}
+	entries, err := readEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open claim buffer: %w", err)
+	}
+	if err := terminateTornLine(file); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &Buffer{
+		path:       path,
+		file:       file,
+		count:      len(entries),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}, nil
+}
+
+// Append durably records req. Returns ErrFull when the buffer is at capacity.
+func (b *Buffer) Append(req *model.ClaimCouponRequest) error {
+	e := Entry{Request: *req, AcceptedAt: b.now().UTC(), CaptchaVerified: req.CaptchaVerified, GrantVerified: req.GrantVerified}
+	e.Request.CaptchaToken, e.Request.Grant = "", "" // Credentials, already verified
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode buffered claim: %w", err)
+	}
+	line = append(line, '\n')
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.count >= b.maxEntries {
+		return ErrFull
+	}
+	if _, err := b.file.Write(line); err != nil {
+		return fmt.Errorf("write claim buffer: %w", err)
+	}
+	if err := b.file.Sync(); err != nil {
+		return fmt.Errorf("sync claim buffer: %w", err)
+	}
+	b.count++
+	return nil
+}
+
+// Len returns the number of pending entries.
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// Pending returns all pending entries in acceptance order.
+func (b *Buffer) Pending() ([]Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return readEntries(b.path)
+}
+
+// Ack removes the first n pending entries (those returned first by Pending), after
+// they have been replayed. Entries appended since Pending was called are kept.
+func (b *Buffer) Ack(n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries, err := readEntries(b.path)
+	if err != nil {
+		return err
+	}
+	if n > len(entries) {
+		n = len(entries)
+	}
+	rest := entries[n:]
+
+	// Rewrite through a temp file and rename so a crash leaves either the old or the new file.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range rest {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("encode buffered claim: %w", err)
+		}
+	}
+	tmp := b.path + ".tmp"
+	if err := writeFileSync(tmp, buf.Bytes()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("replace claim buffer: %w", err)
+	}
+
+	file, err := os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("reopen claim buffer: %w", err)
+	}
+	_ = b.file.Close()
+	b.file = file
+	b.count = len(rest)
+	return nil
+}
+
+// Close closes the buffer file. Pending entries stay on disk for the next Open.
+func (b *Buffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.file.Close()
+}
+
+// readEntries parses the buffer file. A torn final line (crash mid-append, before
+// the fsync returned and so before the caller saw 202) is ignored.
+func readEntries(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read claim buffer: %w", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		e.Request.CaptchaVerified = e.CaptchaVerified
+		e.Request.GrantVerified = e.GrantVerified
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan claim buffer: %w", err)
+	}
+	return entries, nil
+}
+
+// terminateTornLine appends a newline if the file ends mid-line, so the next entry
+// starts on its own line and the torn fragment is skipped by readEntries.
+func terminateTornLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat claim buffer: %w", err)
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return fmt.Errorf("read claim buffer: %w", err)
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	if _, err := f.Write([]byte{'\n'}); err != nil {
+		return fmt.Errorf("write claim buffer: %w", err)
+	}
+	return f.Sync()
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("write claim buffer: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write claim buffer: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("sync claim buffer: %w", err)
+	}
+	return f.Close()
+}
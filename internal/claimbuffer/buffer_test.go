@@ -0,0 +1,121 @@
+package claimbuffer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func claimReq(user string) *model.ClaimCouponRequest {
+	return &model.ClaimCouponRequest{UserID: user, CouponName: "PROMO"}
+}
+
+func users(entries []Entry) []string {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.Request.UserID)
+	}
+	return out
+}
+
+func TestBuffer_AppendPendingAck(t *testing.T) {
+	b, err := Open(filepath.Join(t.TempDir(), "claims.wal"), 10)
+	require.NoError(t, err)
+	defer b.Close()
+
+	for _, u := range []string{"u1", "u2", "u3"} {
+		require.NoError(t, b.Append(claimReq(u)))
+	}
+	assert.Equal(t, 3, b.Len())
+
+	pending, err := b.Pending()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u1", "u2", "u3"}, users(pending), "entries keep acceptance order")
+	assert.False(t, pending[0].AcceptedAt.IsZero())
+
+	require.NoError(t, b.Append(claimReq("u4"))) // Appended while replaying
+	require.NoError(t, b.Ack(2))
+
+	pending, err = b.Pending()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u3", "u4"}, users(pending))
+	assert.Equal(t, 2, b.Len())
+
+	require.NoError(t, b.Append(claimReq("u5")), "appends keep working after Ack")
+	pending, err = b.Pending()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u3", "u4", "u5"}, users(pending))
+}
+
+func TestBuffer_Full(t *testing.T) {
+	b, err := Open(filepath.Join(t.TempDir(), "claims.wal"), 2)
+	require.NoError(t, err)
+	defer b.Close()
+
+	require.NoError(t, b.Append(claimReq("u1")))
+	require.NoError(t, b.Append(claimReq("u2")))
+	assert.ErrorIs(t, b.Append(claimReq("u3")), ErrFull)
+
+	require.NoError(t, b.Ack(1))
+	assert.NoError(t, b.Append(claimReq("u3")), "acked entries free capacity")
+}
+
+func TestBuffer_KeepsCaptchaVerificationNotToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claims.wal")
+	b, err := Open(path, 10)
+	require.NoError(t, err)
+	defer b.Close()
+
+	req := claimReq("u1")
+	req.CaptchaToken, req.CaptchaVerified = "10000000-aaaa-bbbb", true
+	req.Grant, req.GrantVerified = "eyJhbGciOiJIUzI1NiJ9.grant.sig", true
+	require.NoError(t, b.Append(req))
+
+	pending, err := b.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.True(t, pending[0].Request.CaptchaVerified)
+	assert.True(t, pending[0].Request.GrantVerified)
+	assert.Empty(t, pending[0].Request.CaptchaToken)
+	assert.Empty(t, pending[0].Request.Grant)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "10000000-aaaa-bbbb", "tokens are not written to disk")
+	assert.NotContains(t, string(data), "eyJhbGciOiJIUzI1NiJ9", "grants are not written to disk")
+}
+
+func TestBuffer_RecoversAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "claims.wal")
+	b, err := Open(path, 10)
+	require.NoError(t, err)
+	require.NoError(t, b.Append(claimReq("u1")))
+	require.NoError(t, b.Close())
+
+	// Simulate a crash mid-append: a torn, unterminated line at the end.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"request":{"user_id":"tor`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	b, err = Open(path, 10)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 1, b.Len())
+
+	require.NoError(t, b.Append(claimReq("u2")))
+	pending, err := b.Pending()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u1", "u2"}, users(pending), "torn line is skipped, later entries intact")
+}
+
+func TestOpen_InvalidMaxEntries(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "claims.wal"), 0)
+	assert.ErrorContains(t, err, "at least 1")
+}
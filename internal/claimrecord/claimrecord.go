@@ -0,0 +1,224 @@
+// Package claimrecord keeps the most recent failing claims, request and response, in a
+// ring buffer, answering "what exactly did the client send" without turning on request
+// logging for everyone. Recording is opt-in per request: only claims sent with the
+// X-Debug-Record header are recorded. Recordings are sanitized: captcha tokens, claim
+// grants and credentials are removed, user IDs and coupon names are redacted like in
+// the logs, and request bodies that are not JSON objects are not recorded.
+package claimrecord
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// Header opts a request in to recording when set to "true".
+const Header = "X-Debug-Record"
+
+// Bodies are cut to maxBody bytes; a cut body is recorded as a string.
+const maxBody = 4096
+
+// removed replaces sanitized values.
+const removed = "[removed]"
+
+// sensitiveFields are request body fields whose values are removed.
+var sensitiveFields = []string{"captcha_token", "grant"}
+
+// redactedFields are request body fields whose values are redacted like in the logs.
+var redactedFields = []string{"user_id", "coupon_name"}
+
+// unparsedBody replaces request bodies that are not JSON objects.
+var unparsedBody = json.RawMessage(`"[removed: not a JSON object]"`)
+
+// Recording is a failing claim as the client sent it and as it was answered.
+type Recording struct {
+	Time           time.Time         `json:"time"`
+	RequestID      string            `json:"request_id"`
+	Method         string            `json:"method"`
+	Path           string            `json:"path"`
+	RequestHeaders map[string]string `json:"request_headers"`
+	Request        json.RawMessage   `json:"request"`
+	Status         int               `json:"status"`
+	Response       json.RawMessage   `json:"response,omitempty"`
+	Error          string            `json:"error,omitempty"` // Set when the handler returned an error instead of a response
+}
+
+// Stats is a snapshot of Recorder counters.
+type Stats struct {
+	Size     int   `json:"size"` // Recordings held
+	Capacity int   `json:"capacity"`
+	Recorded int64 `json:"recorded"` // Failing claims recorded since startup, including overwritten ones
+}
+
+// Recorder records failing claims opted in to recording, keeping the most recent ones.
+// It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Recording // Ring buffer; next is the oldest once full
+	next    int
+	full    bool
+
+	recorded atomic.Int64
+}
+
+// New creates a Recorder keeping the size most recent recordings.
+func New(size int) *Recorder {
+	return &Recorder{entries: make([]Recording, size)}
+}
+
+// Stats returns the recorder's counters.
+func (r *Recorder) Stats() Stats {
+	r.mu.Lock()
+	size := r.next
+	if r.full {
+		size = len(r.entries)
+	}
+	r.mu.Unlock()
+	return Stats{Size: size, Capacity: len(r.entries), Recorded: r.recorded.Load()}
+}
+
+// Recordings returns the recordings held, newest first.
+func (r *Recorder) Recordings() []Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.next
+	if r.full {
+		n = len(r.entries)
+	}
+	out := make([]Recording, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, r.entries[(r.next-i+len(r.entries))%len(r.entries)])
+	}
+	return out
+}
+
+// Clear drops all recordings.
+func (r *Recorder) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.entries)
+	r.next = 0
+	r.full = false
+}
+
+func (r *Recorder) add(rec Recording) {
+	r.recorded.Add(1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = rec
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Handler returns middleware recording requests sent with Header: true that fail,
+// i.e. are answered with a status of 400 or more or return an error.
+func (r *Recorder) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get(Header) != "true" || len(r.entries) == 0 {
+			return c.Next()
+		}
+		err := c.Next()
+		status := c.Response().StatusCode()
+		if err == nil && status < fiber.StatusBadRequest {
+			return nil
+		}
+
+		rec := Recording{
+			Time:           time.Now().UTC(),
+			RequestID:      strings.Clone(c.GetRespHeader(fiber.HeaderXRequestID)),
+			Method:         strings.Clone(c.Method()),
+			Path:           strings.Clone(c.Path()),
+			RequestHeaders: requestHeaders(c),
+			Request:        sanitizeBody(c.Body()),
+			Status:         status,
+		}
+		if err != nil {
+			rec.Status = fiber.StatusInternalServerError
+			var fe *fiber.Error
+			if errors.As(err, &fe) {
+				rec.Status = fe.Code
+			}
+			rec.Error = redact.Error(err)
+		} else {
+			rec.Response = rawBody(c.Response().Body())
+		}
+		r.add(rec)
+		return err
+	}
+}
+
+// requestHeaders returns the request's headers, with the values of those carrying
+// credentials removed.
+func requestHeaders(c *fiber.Ctx) map[string]string {
+	headers := make(map[string]string)
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		name := http.CanonicalHeaderKey(string(key))
+		if sensitiveHeader(name) {
+			headers[name] = removed
+			return
+		}
+		headers[name] = string(value)
+	})
+	return headers
+}
+
+func sensitiveHeader(name string) bool {
+	switch name {
+	case fiber.HeaderAuthorization, fiber.HeaderProxyAuthorization, fiber.HeaderCookie:
+		return true
+	}
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "token") || strings.Contains(lower, "secret") ||
+		strings.Contains(lower, "signature") || strings.Contains(lower, "api-key")
+}
+
+// sanitizeBody returns a claim request body with sensitive fields removed and the user
+// ID and coupon name redacted. A body that is not a JSON object, e.g. a form-encoded
+// claim, is replaced with unparsed: its sensitive fields cannot be found to remove them.
+func sanitizeBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var fields map[string]any
+	if json.Unmarshal(body, &fields) != nil {
+		return unparsedBody
+	}
+	for _, name := range sensitiveFields {
+		if _, ok := fields[name]; ok {
+			fields[name] = removed
+		}
+	}
+	for _, name := range redactedFields {
+		if value, ok := fields[name].(string); ok {
+			fields[name] = redact.Value(value)
+		}
+	}
+	sanitized, err := json.Marshal(fields)
+	if err != nil {
+		return unparsedBody
+	}
+	return rawBody(sanitized)
+}
+
+// rawBody returns body as JSON: itself if it is valid JSON of at most maxBody bytes,
+// otherwise a string of its first maxBody bytes.
+func rawBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if len(body) <= maxBody && json.Valid(body) {
+		return append(json.RawMessage(nil), body...)
+	}
+	s, _ := json.Marshal(string(body[:min(len(body), maxBody)]))
+	return s
+}
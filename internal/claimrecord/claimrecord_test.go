@@ -0,0 +1,144 @@
+package claimrecord
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+func newApp(r *Recorder, status int) *fiber.App {
+	app := fiber.New()
+	app.Post("/claim", r.Handler(), func(c *fiber.Ctx) error {
+		if status == 0 {
+			return errors.New("boom")
+		}
+		return c.Status(status).JSON(fiber.Map{"error": "coupon out of stock"})
+	})
+	return app
+}
+
+func post(t *testing.T, app *fiber.App, body string, headers map[string]string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/claim", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestRecorder_RecordsOptedInFailures(t *testing.T) {
+	r := New(10)
+	app := newApp(r, fiber.StatusBadRequest)
+
+	post(t, app, `{"user_id": "u1", "coupon_name": "PROMO"}`, nil) // Not opted in
+	post(t, app, `{"user_id": "u1", "coupon_name": "PROMO", "captcha_token": "t0k", "grant": "g"}`, map[string]string{
+		Header:            "true",
+		"Authorization":   "Bearer secret",
+		"X-Captcha-Token": "t0k",
+		"User-Agent":      "shop-app/1.2",
+	})
+
+	recs := r.Recordings()
+	require.Len(t, recs, 1)
+	rec := recs[0]
+	assert.Equal(t, http.MethodPost, rec.Method)
+	assert.Equal(t, "/claim", rec.Path)
+	assert.Equal(t, fiber.StatusBadRequest, rec.Status)
+	assert.JSONEq(t, `{"user_id": "u1", "coupon_name": "PROMO", "captcha_token": "[removed]", "grant": "[removed]"}`, string(rec.Request))
+	assert.JSONEq(t, `{"error": "coupon out of stock"}`, string(rec.Response))
+	assert.Equal(t, "[removed]", rec.RequestHeaders["Authorization"])
+	assert.Equal(t, "[removed]", rec.RequestHeaders["X-Captcha-Token"])
+	assert.Equal(t, "shop-app/1.2", rec.RequestHeaders["User-Agent"])
+	assert.Equal(t, Stats{Size: 1, Capacity: 10, Recorded: 1}, r.Stats())
+}
+
+func TestRecorder_SkipsSuccesses(t *testing.T) {
+	r := New(10)
+	post(t, newApp(r, fiber.StatusOK), `{"user_id": "u1"}`, map[string]string{Header: "true"})
+
+	assert.Empty(t, r.Recordings())
+}
+
+func TestRecorder_RecordsErrors(t *testing.T) {
+	r := New(10)
+	post(t, newApp(r, 0), `{"user_id": "u1"}`, map[string]string{Header: "true"})
+
+	recs := r.Recordings()
+	require.Len(t, recs, 1)
+	assert.Equal(t, fiber.StatusInternalServerError, recs[0].Status)
+	assert.Equal(t, "boom", recs[0].Error)
+	assert.Nil(t, recs[0].Response)
+}
+
+func TestRecorder_RedactsUserID(t *testing.T) {
+	redactor, err := redact.New(redact.ModeTruncate, "")
+	require.NoError(t, err)
+	redact.SetDefault(redactor)
+	defer func() {
+		off, _ := redact.New(redact.ModeOff, "")
+		redact.SetDefault(off)
+	}()
+
+	r := New(10)
+	post(t, newApp(r, fiber.StatusConflict), `{"user_id": "customer_42", "coupon_name": "SECRET_SALE"}`, map[string]string{Header: "true"})
+
+	assert.JSONEq(t, `{"user_id": "cust***", "coupon_name": "SECR***"}`, string(r.Recordings()[0].Request))
+}
+
+func TestRecorder_ReplacesUnparsedBodies(t *testing.T) {
+	r := New(10)
+	app := newApp(r, fiber.StatusBadRequest)
+	for _, body := range []string{
+		"user_id=u1&coupon_name=PROMO&grant=g&captcha_token=t0k", // Form-encoded
+		`["u1", "g"]`,
+		`{"user_id": "u1", "grant": "g"`, // Truncated
+	} {
+		post(t, app, body, map[string]string{Header: "true"})
+	}
+
+	recs := r.Recordings()
+	require.Len(t, recs, 3)
+	for _, rec := range recs {
+		assert.Equal(t, `"[removed: not a JSON object]"`, string(rec.Request))
+	}
+}
+
+func TestRecorder_KeepsMostRecent(t *testing.T) {
+	r := New(3)
+	app := newApp(r, fiber.StatusBadRequest)
+	for i := range 5 {
+		post(t, app, `{"user_id": "u`+strconv.Itoa(i)+`"}`, map[string]string{Header: "true"})
+	}
+
+	var users []string
+	for _, rec := range r.Recordings() {
+		users = append(users, string(rec.Request))
+	}
+	assert.Equal(t, []string{`{"user_id":"u4"}`, `{"user_id":"u3"}`, `{"user_id":"u2"}`}, users)
+	assert.Equal(t, Stats{Size: 3, Capacity: 3, Recorded: 5}, r.Stats())
+
+	r.Clear()
+	assert.Empty(t, r.Recordings())
+	assert.Equal(t, 0, r.Stats().Size)
+}
+
+func TestRawBody(t *testing.T) {
+	assert.Nil(t, rawBody(nil))
+	assert.Equal(t, `"not json"`, string(rawBody([]byte("not json"))))
+
+	long := `{"x": "` + strings.Repeat("a", maxBody) + `"}`
+	cut := rawBody([]byte(long))
+	assert.True(t, strings.HasPrefix(string(cut), `"{\"x\": \"aaa`), "cut bodies are recorded as strings")
+}
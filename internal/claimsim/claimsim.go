@@ -0,0 +1,178 @@
+// Package claimsim simulates a coupon launch in memory, for capacity planning: every
+// claim on a coupon takes the coupon's row lock, so its claims are served one at a
+// time, each holding the lock for as long as its statements take. The statements'
+// latencies are drawn from the histograms this instance recorded (db_statements), and
+// claims queue on the lock, or are refused it, as the coupon lock policy says.
+package claimsim
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// MaxClaims bounds how many claims one simulation runs.
+const MaxClaims = 1_000_000
+
+var (
+	// ErrNoSamples is returned when no claim statements were recorded yet to draw
+	// latencies from.
+	ErrNoSamples = errors.New("no claim statements recorded yet")
+	// ErrTooManyClaims is returned when a simulation would run more than MaxClaims claims.
+	ErrTooManyClaims = errors.New("arrival_rate times duration_seconds exceeds the simulated claims limit")
+)
+
+// Simulator simulates launches with the latencies of the statements in stats. It is
+// safe for concurrent use.
+type Simulator struct {
+	stats  func() map[string]database.StatementStats
+	policy database.LockPolicy
+}
+
+// New creates a Simulator drawing latencies from stats (StatementMetrics.Stats) and
+// acquiring row locks with policy.
+func New(stats func() map[string]database.StatementStats, policy database.LockPolicy) *Simulator {
+	return &Simulator{stats: stats, policy: policy}
+}
+
+// distribution samples latencies from a cumulative statement latency histogram.
+type distribution struct {
+	bounds []float64 // Upper bound of each bucket, in seconds; +Inf for the last
+	counts []int64   // Cumulative
+}
+
+// newDistribution returns the distribution of buckets, or false if it holds no runs.
+func newDistribution(buckets []database.LatencyBucket) (distribution, bool) {
+	var d distribution
+	for _, b := range buckets {
+		bound := math.Inf(1)
+		if le, err := time.ParseDuration(b.Le); err == nil {
+			bound = le.Seconds()
+		}
+		d.bounds = append(d.bounds, bound)
+		d.counts = append(d.counts, b.Count)
+	}
+	return d, len(d.counts) > 0 && d.counts[len(d.counts)-1] > 0
+}
+
+// sample returns a latency in seconds, uniform within a bucket picked by its count.
+// Runs over the last finite bound are taken to have lasted up to twice it.
+func (d distribution) sample(rng *rand.Rand) float64 {
+	n := rng.Int64N(d.counts[len(d.counts)-1])
+	i, _ := slices.BinarySearch(d.counts, n+1)
+	lo := 0.0
+	if i > 0 {
+		lo = d.bounds[i-1]
+	}
+	hi := d.bounds[i]
+	if math.IsInf(hi, 1) {
+		hi = 2 * lo
+	}
+	return lo + rng.Float64()*(hi-lo)
+}
+
+// finishes is a min-heap of the times claims release their connections.
+type finishes []float64
+
+func (f finishes) Len() int           { return len(f) }
+func (f finishes) Less(i, j int) bool { return f[i] < f[j] }
+func (f finishes) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f *finishes) Push(x any)        { *f = append(*f, x.(float64)) }
+func (f *finishes) Pop() any {
+	old := *f
+	x := old[len(old)-1]
+	*f = old[:len(old)-1]
+	return x
+}
+
+// Simulate runs the launch of req. Claims arrive as a Poisson process; each takes the
+// row lock in arrival order and holds it for a lock-and-read, plus an insert and a
+// stock decrement while stock is left. Commits and time outside the transaction are
+// not modeled, so latencies are a lower bound.
+func (s *Simulator) Simulate(req *model.ClaimSimulationRequest) (*model.ClaimSimulationResult, error) {
+	if req.ArrivalRate*float64(req.DurationSeconds) > MaxClaims {
+		return nil, ErrTooManyClaims
+	}
+	stats := s.stats()
+	var (
+		dists   = make(map[string]distribution, 3)
+		samples int64
+	)
+	for _, name := range []string{database.StatementGetForUpdate, database.StatementInsertClaim, database.StatementDecrementStock} {
+		d, ok := newDistribution(stats[name].Latency)
+		if !ok {
+			return nil, ErrNoSamples
+		}
+		dists[name] = d
+		samples += stats[name].Count
+	}
+
+	rng := rand.New(rand.NewPCG(req.Seed, req.Seed))
+	timeout := s.policy.Timeout.Seconds()
+	stock := req.Stock
+	res := &model.ClaimSimulationResult{LockPolicy: string(s.policy.Mode), Samples: samples}
+	var (
+		lockFree  float64 // When the row lock is next released
+		held      float64 // Total time granted claims held the lock
+		latencies []float64
+		inFlight  finishes
+	)
+	for at := rng.ExpFloat64() / req.ArrivalRate; at < float64(req.DurationSeconds); at += rng.ExpFloat64() / req.ArrivalRate {
+		res.Claims++
+		for inFlight.Len() > 0 && inFlight[0] <= at {
+			heap.Pop(&inFlight)
+		}
+
+		wait := math.Max(0, lockFree-at)
+		var finish float64
+		switch {
+		case wait > 0 && s.policy.Mode == database.LockNoWait:
+			res.Busy++
+			finish = at
+		case wait > timeout && s.policy.Mode == database.LockTimeout:
+			res.Busy++
+			finish = at + timeout
+		default:
+			hold := dists[database.StatementGetForUpdate].sample(rng)
+			if stock > 0 {
+				hold += dists[database.StatementInsertClaim].sample(rng) + dists[database.StatementDecrementStock].sample(rng)
+				held += hold
+				stock--
+				res.Granted++
+			} else {
+				res.OutOfStock++
+			}
+			finish = at + wait + hold
+			lockFree = finish
+			if stock == 0 && res.ExhaustedAfterMs == nil {
+				exhausted := finish * 1000
+				res.ExhaustedAfterMs = &exhausted
+			}
+		}
+		latencies = append(latencies, finish-at)
+		heap.Push(&inFlight, finish)
+		res.PeakConnections = max(res.PeakConnections, inFlight.Len())
+	}
+
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		res.P50LatencyMs = percentile(latencies, 0.50) * 1000
+		res.P99LatencyMs = percentile(latencies, 0.99) * 1000
+		res.MaxLatencyMs = latencies[len(latencies)-1] * 1000
+	}
+	if res.Granted > 0 {
+		res.LockHoldMs = held / float64(res.Granted) * 1000
+	}
+	return res, nil
+}
+
+// percentile returns the q-th percentile of sorted, nearest rank.
+func percentile(sorted []float64, q float64) float64 {
+	return sorted[max(0, int(math.Ceil(q*float64(len(sorted))))-1)]
+}
@@ -0,0 +1,108 @@
+package claimsim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// histogram returns cumulative latency buckets with n runs, all in the bucket up to le.
+func histogram(le string, n int64) []database.LatencyBucket {
+	var buckets []database.LatencyBucket
+	var count int64
+	for _, bound := range []string{"1ms", "2ms", "5ms", "10ms", "25ms", "50ms", "+Inf"} {
+		if bound == le {
+			count = n
+		}
+		buckets = append(buckets, database.LatencyBucket{Le: bound, Count: count})
+	}
+	return buckets
+}
+
+// statements returns statement stats with every claim statement taking up to le.
+func statements(le string) func() map[string]database.StatementStats {
+	return func() map[string]database.StatementStats {
+		stats := map[string]database.StatementStats{}
+		for _, name := range database.Statements {
+			stats[name] = database.StatementStats{Count: 100, Latency: histogram(le, 100)}
+		}
+		return stats
+	}
+}
+
+func TestSimulator_Simulate(t *testing.T) {
+	sim := New(statements("1ms"), database.LockPolicy{Mode: database.LockWait})
+
+	res, err := sim.Simulate(&model.ClaimSimulationRequest{Stock: 500, ArrivalRate: 100, DurationSeconds: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, "wait", res.LockPolicy)
+	assert.InDelta(t, 1000, res.Claims, 100)
+	assert.Equal(t, 500, res.Granted)
+	assert.Equal(t, res.Claims-500, res.OutOfStock)
+	assert.Zero(t, res.Busy)
+	require.NotNil(t, res.ExhaustedAfterMs)
+	assert.InDelta(t, 5000, *res.ExhaustedAfterMs, 750)
+	assert.LessOrEqual(t, res.P99LatencyMs, 10.0, "the lock is rarely contended")
+	assert.LessOrEqual(t, res.LockHoldMs, 3.0)
+	assert.Equal(t, int64(300), res.Samples)
+
+	again, err := sim.Simulate(&model.ClaimSimulationRequest{Stock: 500, ArrivalRate: 100, DurationSeconds: 10})
+	require.NoError(t, err)
+	assert.Equal(t, res, again, "the same seed gives the same result")
+}
+
+func TestSimulator_Simulate_StockLasts(t *testing.T) {
+	sim := New(statements("1ms"), database.LockPolicy{Mode: database.LockWait})
+
+	res, err := sim.Simulate(&model.ClaimSimulationRequest{Stock: 10000, ArrivalRate: 10, DurationSeconds: 5})
+
+	require.NoError(t, err)
+	assert.Nil(t, res.ExhaustedAfterMs)
+	assert.Equal(t, res.Claims, res.Granted)
+}
+
+func TestSimulator_Simulate_Overloaded(t *testing.T) {
+	// Each claim holds the lock 15-30ms, so the lock serves at most ~65 claims a second
+	req := &model.ClaimSimulationRequest{Stock: 100000, ArrivalRate: 200, DurationSeconds: 2}
+
+	t.Run("wait queues claims", func(t *testing.T) {
+		res, err := New(statements("10ms"), database.LockPolicy{Mode: database.LockWait}).Simulate(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, res.Claims, res.Granted)
+		assert.Greater(t, res.P99LatencyMs, 1000.0)
+		assert.Greater(t, res.PeakConnections, 100)
+	})
+
+	t.Run("nowait refuses contended claims", func(t *testing.T) {
+		res, err := New(statements("10ms"), database.LockPolicy{Mode: database.LockNoWait}).Simulate(req)
+
+		require.NoError(t, err)
+		assert.Greater(t, res.Busy, res.Granted)
+		assert.Equal(t, res.Claims, res.Granted+res.Busy)
+		assert.LessOrEqual(t, res.MaxLatencyMs, 30.0)
+	})
+
+	t.Run("timeout refuses claims waiting too long", func(t *testing.T) {
+		res, err := New(statements("10ms"), database.LockPolicy{Mode: database.LockTimeout, Timeout: 100 * time.Millisecond}).Simulate(req)
+
+		require.NoError(t, err)
+		assert.Positive(t, res.Busy)
+		assert.LessOrEqual(t, res.MaxLatencyMs, 130.0)
+	})
+}
+
+func TestSimulator_Simulate_Errors(t *testing.T) {
+	empty := func() map[string]database.StatementStats { return map[string]database.StatementStats{} }
+	_, err := New(empty, database.LockPolicy{}).Simulate(&model.ClaimSimulationRequest{Stock: 1, ArrivalRate: 1, DurationSeconds: 1})
+	assert.ErrorIs(t, err, ErrNoSamples)
+
+	_, err = New(statements("1ms"), database.LockPolicy{}).Simulate(&model.ClaimSimulationRequest{Stock: 1, ArrivalRate: 100000, DurationSeconds: 3600})
+	assert.ErrorIs(t, err, ErrTooManyClaims)
+}
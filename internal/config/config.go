@@ -2,29 +2,186 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/kelseyhightower/envconfig"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/accesslog"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/eventlog"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/killswitch"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/pacing"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/workpool"
 )
 
 // Config holds all configuration for the application.
 type Config struct {
-	Server ServerConfig
-	DB     DBConfig
-	Log    LogConfig
+	Server   ServerConfig
+	CORS     CORSConfig
+	DB       DBConfig
+	Log      LogConfig
+	Access   AccessLogConfig
+	Events   EventLogConfig
+	Cache    CacheConfig
+	Buffer   ClaimBufferConfig
+	Dedup    ClaimDedupConfig
+	Replay   ClaimAntiReplayConfig
+	Record   ClaimRecordConfig
+	Filter   ClaimFilterConfig
+	Shadow   ClaimShadowConfig
+	Names    CouponNameFilterConfig
+	Enum     EnumGuardConfig
+	Captcha  CaptchaConfig
+	Grant    ClaimGrantConfig
+	Webhook  WebhookConfig
+	Deliver  DeliveryPoolConfig
+	Board    LeaderboardConfig
+	Caps     CampaignCapConfig
+	Hedge    ReadHedgeConfig
+	Import   ClaimImportConfig
+	Budget   ClaimBudgetConfig
+	Shed     ClaimLimitConfig
+	Pace     ClaimPacingConfig
+	Keys     APIKeyConfig
+	Quota    HighProfileConfig
+	Meta     CouponMetadataConfig
+	Seed     SeedConfig
+	Allow    AllowlistConfig
+	Delete   CouponDeleteConfig
+	Archive  CouponArchiveConfig
+	Wait     StockWaitConfig
+	Retain   ClaimRetentionConfig
+	Export   AnalyticsExportConfig
+	Kill     KillSwitchConfig
+	Load     LoadTestConfig
+	Watch    PoolWatchConfig
+	Failover FailoverWatchConfig
+	Heavy    HeavyJobConfig
+	Privacy  ClaimedByConfig
+	Runtime  RuntimeConfig
 }
 
 // ServerConfig holds server-related configuration.
+// ReadTimeout, WriteTimeout and IdleTimeout bound connections; BodyLimit bounds request
+// bodies. RouteTimeouts and RouteBodyLimits override them per route (see Routes), e.g.
+// SERVER_ROUTE_TIMEOUTS=claim:10s,import:2m. A route timeout is a deadline on handling
+// the request, so database work is cancelled when it passes; routes without one only
+// have the connection timeouts. With MaxRequestDeadline set, requests may also bring
+// their own deadline in X-Request-Deadline, capped at MaxRequestDeadline from arrival.
+// DefaultPageSize and MaxPageSize bound ?limit= of every list endpoint.
 type ServerConfig struct {
 	Port            string `envconfig:"SERVER_PORT" default:"3000"`
 	ShutdownTimeout int    `envconfig:"SHUTDOWN_TIMEOUT" default:"30"` // seconds
+
+	ReadTimeout  time.Duration `envconfig:"SERVER_READ_TIMEOUT" default:"30s"`
+	WriteTimeout time.Duration `envconfig:"SERVER_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout  time.Duration `envconfig:"SERVER_IDLE_TIMEOUT" default:"120s"`
+	BodyLimit    int           `envconfig:"SERVER_BODY_LIMIT" default:"1048576"` // bytes
+
+	RouteTimeouts   map[string]time.Duration `envconfig:"SERVER_ROUTE_TIMEOUTS" default:"claim:10s,import:2m"`
+	RouteBodyLimits map[string]int           `envconfig:"SERVER_ROUTE_BODY_LIMITS" default:"claim:16384"`
+
+	MaxRequestDeadline time.Duration `envconfig:"SERVER_MAX_REQUEST_DEADLINE" default:"0s"` // 0 ignores X-Request-Deadline
+
+	DefaultPageSize int `envconfig:"SERVER_DEFAULT_PAGE_SIZE" default:"100"`
+	MaxPageSize     int `envconfig:"SERVER_MAX_PAGE_SIZE" default:"1000"`
+}
+
+// Routes names the routes SERVER_ROUTE_TIMEOUTS and SERVER_ROUTE_BODY_LIMITS may override.
+var Routes = []string{
+	"create", "list", "get", "update", "put", "top_up", "delete", "restore", // /api/coupons
+	"claim", "claims", "wait_for_stock", // /api/coupons/claim, /api/coupons/{name}/claims(/sample) and /wait-for-stock
+	"apply", "import", "webhooks", "terminate", "migrations", "killswitch", "recordings", "simulate", "diagnostics", // /api/admin
+	"erase",        // /api/users/{user_id}/data
+	"leaderboard",  // /api/campaigns/{id}/leaderboard
+	"campaign_cap", // /api/admin/campaigns/{id}/cap
+	"allowlist",    // /api/admin/coupons/{name}/allowlist
+	"notes",        // /api/coupons/{name}/notes, /api/admin/coupons/{name}/changelog
+	"loadtest",     // /api/admin/loadtest
+	"api_keys",     // /api/admin/keys/{id}/usage
+	"archive",      // /api/archive/coupons/{name}
+	"version",      // /api/version
+}
+
+// Route returns the handling timeout (0 for none) and body limit of the named route.
+func (c ServerConfig) Route(name string) (time.Duration, int) {
+	bodyLimit, ok := c.RouteBodyLimits[name]
+	if !ok {
+		bodyLimit = c.BodyLimit
+	}
+	return c.RouteTimeouts[name], bodyLimit
+}
+
+// MaxBodyLimit returns the largest body limit of any route, which the server enforces
+// before routing.
+func (c ServerConfig) MaxBodyLimit() int {
+	limit := c.BodyLimit
+	for _, l := range c.RouteBodyLimits {
+		limit = max(limit, l)
+	}
+	return limit
+}
+
+// CORSConfig holds cross-origin configuration for browser clients on other origins,
+// e.g. third-party storefronts. AllowOrigins lists the origins allowed to call the API
+// ("*" for any); empty disables cross-origin access. OPTIONS requests are answered for
+// every route regardless, with the route's methods. MaxAge is how long browsers may
+// cache a preflight answer.
+type CORSConfig struct {
+	AllowOrigins []string      `envconfig:"CORS_ALLOW_ORIGINS"`
+	AllowHeaders []string      `envconfig:"CORS_ALLOW_HEADERS" default:"Content-Type,X-Request-ID"`
+	MaxAge       time.Duration `envconfig:"CORS_MAX_AGE" default:"10m"`
 }
 
 // DBConfig holds database-related configuration.
 // WARNING: Default password is for local development only.
 // In production, always set DB_PASSWORD via environment variable.
 // In production, set DB_SSLMODE to "require" or "verify-full".
+// Driver selects the backend: "postgres", "cockroachdb" (default port 26257),
+// "mysql" (MySQL/MariaDB, default port 3306) or "memory" (process memory, for demos and
+// tests; the connection settings are ignored).
+// MigrationPhases sets the rollout phase of column renames (see database.Rename) and
+// table moves (see database.TableMove), e.g.
+// DB_MIGRATION_PHASES=claims.claimed_at:dual_write,claims_v2:read_new.
+// QueryTimeouts bounds individual queries (see database.Queries) under the route
+// timeout, so a slow database fails a request with 503 instead of holding it until
+// the route's deadline (504), e.g. DB_QUERY_TIMEOUTS=get_coupon:500ms,lock_coupon:2s.
+// ReadMaxConns, when set, opens a second pool of that size for reads outside
+// transactions (coupon and claim lists, leaderboards), so heavy read traffic cannot
+// take the connections claims need; MaxConns and MinConns then size the claim pool.
+// CouponLockPolicy sets how claims and updates acquire a coupon's row lock while another
+// transaction holds it (see database.LockMode): "wait" (default), "nowait" to fail at
+// once, or "timeout" to wait up to CouponLockTimeout (PostgreSQL wire-compatible
+// backends only). Requests that give up get a retryable 503.
+// DegradedStart starts the server without waiting for the database: instead of
+// retrying five times and exiting, the pools connect in the background and readiness
+// fails until they do.
+// ReadRetry retries coupon and claim reads once when their connection is lost, e.g.
+// one the server or a proxy closed while it was idle in the pool (PostgreSQL
+// wire-compatible backends only; database/sql already does this for MySQL).
+// Host may be a Unix socket: an absolute path to the socket directory for PostgreSQL
+// (e.g. /var/run/postgresql, where Port picks .s.PGSQL.<port>) or to the socket file
+// for MySQL (e.g. /var/run/mysqld/mysqld.sock).
+// PoolMode "transaction" runs behind a pooler that hands out a server connection per
+// transaction, such as PgBouncer in transaction pooling mode: statements are described
+// once and cached client-side instead of being prepared by name, which the pooler
+// could route to a connection that never saw them (PostgreSQL wire-compatible
+// backends only). The repositories keep no session state, so nothing else changes.
+// LockDiagnosticsThreshold dumps the lock state of claims waiting longer than it for a
+// coupon's row lock: the pg_stat_activity and pg_locks rows of the waiting backend and
+// of those blocking it, logged and kept (the LockDiagnosticsSize most recent) for GET
+// /api/admin/diagnostics/locks (DB_DRIVER=postgres in session pool mode only). 0
+// disables it.
 type DBConfig struct {
+	Driver   string `envconfig:"DB_DRIVER" default:"postgres"`
 	Host     string `envconfig:"DB_HOST" default:"localhost"`
 	Port     int    `envconfig:"DB_PORT" default:"5432"`
 	User     string `envconfig:"DB_USER" default:"postgres"`
@@ -33,18 +190,564 @@ type DBConfig struct {
 	SSLMode  string `envconfig:"DB_SSLMODE" default:"disable"` // Use "require" in production
 	MaxConns int    `envconfig:"DB_MAX_CONNS" default:"25"`
 	MinConns int    `envconfig:"DB_MIN_CONNS" default:"5"`
+
+	ReadMaxConns int `envconfig:"DB_READ_MAX_CONNS" default:"0"` // 0 shares the claim pool
+	ReadMinConns int `envconfig:"DB_READ_MIN_CONNS" default:"0"`
+
+	MigrationPhases map[string]string      `envconfig:"DB_MIGRATION_PHASES"`
+	QueryTimeouts   database.QueryTimeouts `envconfig:"DB_QUERY_TIMEOUTS" default:"get_coupon:500ms,lock_coupon:2s"`
+
+	CouponLockPolicy  string        `envconfig:"DB_COUPON_LOCK_POLICY" default:"wait"`
+	CouponLockTimeout time.Duration `envconfig:"DB_COUPON_LOCK_TIMEOUT" default:"200ms"`
+
+	DegradedStart bool `envconfig:"DB_DEGRADED_START" default:"false"`
+	ReadRetry     bool `envconfig:"DB_READ_RETRY_ENABLED" default:"false"`
+
+	PoolMode string `envconfig:"DB_POOL_MODE" default:"session"`
+
+	LockDiagnosticsThreshold time.Duration `envconfig:"DB_LOCK_DIAGNOSTICS_THRESHOLD" default:"0"`
+	LockDiagnosticsSize      int           `envconfig:"DB_LOCK_DIAGNOSTICS_SIZE" default:"20"`
+}
+
+// DB pool modes (DB_POOL_MODE).
+const (
+	PoolModeSession     = "session"
+	PoolModeTransaction = "transaction"
+)
+
+// unixSocket reports whether Host is a Unix socket path.
+func (c DBConfig) unixSocket() bool {
+	return strings.HasPrefix(c.Host, "/")
+}
+
+// postgresWire reports whether Driver selects a PostgreSQL wire-compatible backend,
+// which the pgx repositories behind most optional features require.
+func (c DBConfig) postgresWire() bool {
+	return c.Driver == database.Postgres.Name || c.Driver == database.CockroachDB.Name
+}
+
+// couponFeatures reports whether Driver selects a backend with the repositories of
+// campaign caps, allowlists, coupon deletion, claim retention and API key usage: the
+// PostgreSQL wire-compatible ones and memory.
+func (c DBConfig) couponFeatures() bool {
+	return c.postgresWire() || c.Driver == database.Memory.Name
+}
+
+// LockPolicy returns the coupon row lock policy. CouponLockPolicy must be valid.
+func (c DBConfig) LockPolicy() database.LockPolicy {
+	return database.LockPolicy{Mode: database.LockMode(c.CouponLockPolicy), Timeout: c.CouponLockTimeout}
 }
 
 // DSN returns the PostgreSQL connection string.
 func (c DBConfig) DSN() string {
-	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s&pool_max_conns=%d&pool_min_conns=%d",
-		c.User, c.Password, c.Host, c.Port, c.Name, c.SSLMode, c.MaxConns, c.MinConns)
+	return c.dsn(c.MaxConns, c.MinConns)
+}
+
+// ReadDSN returns the PostgreSQL connection string of the read pool.
+func (c DBConfig) ReadDSN() string {
+	return c.dsn(c.ReadMaxConns, c.ReadMinConns)
+}
+
+func (c DBConfig) dsn(maxConns, minConns int) string {
+	u := url.URL{Scheme: "postgres", User: url.UserPassword(c.User, c.Password), Path: "/" + c.Name}
+	query := fmt.Sprintf("sslmode=%s&pool_max_conns=%d&pool_min_conns=%d", c.SSLMode, maxConns, minConns)
+	if c.unixSocket() {
+		query = fmt.Sprintf("host=%s&port=%d&", url.QueryEscape(c.Host), c.Port) + query
+	} else {
+		u.Host = net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	}
+	if c.PoolMode == PoolModeTransaction {
+		query += "&default_query_exec_mode=cache_describe"
+	}
+	u.RawQuery = query
+	return u.String()
+}
+
+// mysqlTLSModes maps DB_SSLMODE values to the MySQL driver's tls parameter.
+var mysqlTLSModes = map[string]string{
+	"disable": "false", "allow": "preferred", "prefer": "preferred",
+	"require": "skip-verify", "verify-ca": "true", "verify-full": "true",
+}
+
+// MySQLDSN returns the MySQL/MariaDB connection string. Pool sizes are not part of it;
+// they are applied to the *sql.DB. Sessions run in UTC with times parsed into time.Time,
+// and UPDATEs report matched rather than changed rows, as PostgreSQL does.
+func (c DBConfig) MySQLDSN() string {
+	cfg := mysql.NewConfig()
+	cfg.User = c.User
+	cfg.Passwd = c.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	cfg.DBName = c.Name
+	cfg.TLSConfig = mysqlTLSModes[c.SSLMode]
+	if c.unixSocket() {
+		cfg.Net = "unix"
+		cfg.Addr = c.Host
+		cfg.TLSConfig = "false" // Like libpq, TLS settings are ignored on Unix sockets
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	cfg.ClientFoundRows = true
+	cfg.Params = map[string]string{"time_zone": "'+00:00'"}
+	return cfg.FormatDSN()
 }
 
 // LogConfig holds logging configuration.
+// Redact controls how user IDs and coupon names appear in logs: "off", "hash" (keyed
+// HMAC, requires RedactKey) or "truncate". Keep RedactKey stable so hashes correlate
+// across restarts and instances.
 type LogConfig struct {
-	Level  string `envconfig:"LOG_LEVEL" default:"info"`
-	Pretty bool   `envconfig:"LOG_PRETTY" default:"false"`
+	Level     string `envconfig:"LOG_LEVEL" default:"info"`
+	Pretty    bool   `envconfig:"LOG_PRETTY" default:"false"`
+	Redact    string `envconfig:"LOG_REDACT" default:"off"`
+	RedactKey string `envconfig:"LOG_REDACT_KEY"`
+}
+
+// AccessLogConfig holds HTTP access log configuration. Sink is "stdout" (default),
+// "file" (File, rotated at MaxSizeMB keeping MaxBackups old files) or "syslog"
+// (SyslogNetwork "udp" or "tcp" to SyslogAddr, or the local daemon when both are empty).
+type AccessLogConfig struct {
+	Sink          string `envconfig:"ACCESS_LOG_SINK" default:"stdout"`
+	File          string `envconfig:"ACCESS_LOG_FILE"`
+	MaxSizeMB     int    `envconfig:"ACCESS_LOG_MAX_SIZE_MB" default:"100"`
+	MaxBackups    int    `envconfig:"ACCESS_LOG_MAX_BACKUPS" default:"5"`
+	SyslogNetwork string `envconfig:"ACCESS_LOG_SYSLOG_NETWORK"`
+	SyslogAddr    string `envconfig:"ACCESS_LOG_SYSLOG_ADDR"`
+	SyslogTag     string `envconfig:"ACCESS_LOG_SYSLOG_TAG" default:"coupon-api"`
+}
+
+// Options returns the access log sink options.
+func (c AccessLogConfig) Options() accesslog.Options {
+	return accesslog.Options{
+		Sink:       c.Sink,
+		Path:       c.File,
+		MaxSize:    int64(c.MaxSizeMB) << 20,
+		MaxBackups: c.MaxBackups,
+		Network:    c.SyslogNetwork,
+		Addr:       c.SyslogAddr,
+		Tag:        c.SyslogTag,
+	}
+}
+
+// EventLogConfig holds domain event log configuration. With Sink set, every coupon
+// created and claim made is appended as a JSON line to stdout (each line marked with
+// "stream":"domain_event") or to File, rotated at MaxSizeMB keeping MaxBackups old files.
+// Empty disables it.
+type EventLogConfig struct {
+	Sink       string `envconfig:"EVENT_LOG_SINK"`
+	File       string `envconfig:"EVENT_LOG_FILE"`
+	MaxSizeMB  int    `envconfig:"EVENT_LOG_MAX_SIZE_MB" default:"100"`
+	MaxBackups int    `envconfig:"EVENT_LOG_MAX_BACKUPS" default:"5"`
+}
+
+// Options returns the event log sink options.
+func (c EventLogConfig) Options() eventlog.Options {
+	return eventlog.Options{
+		Sink:       c.Sink,
+		Path:       c.File,
+		MaxSize:    int64(c.MaxSizeMB) << 20,
+		MaxBackups: c.MaxBackups,
+	}
+}
+
+// CacheConfig holds response cache configuration.
+// A CouponTTL of 0 disables the GET /api/coupons/:name cache.
+type CacheConfig struct {
+	CouponTTL  time.Duration `envconfig:"CACHE_COUPON_TTL" default:"0s"` // e.g. 200ms
+	CouponSize int           `envconfig:"CACHE_COUPON_SIZE" default:"1024"`
+}
+
+// ClaimBufferConfig holds store-and-forward configuration for claims.
+// An empty Path disables it: claims fail with 500 while the database is unreachable.
+// When set, such claims are appended to the file at Path, answered with 202 and
+// replayed once the database is back (see service.StoreAndForward for the trade-offs).
+type ClaimBufferConfig struct {
+	Path           string        `envconfig:"CLAIM_BUFFER_PATH"`
+	MaxEntries     int           `envconfig:"CLAIM_BUFFER_MAX_ENTRIES" default:"10000"`
+	MaxAge         time.Duration `envconfig:"CLAIM_BUFFER_MAX_AGE" default:"5m"`
+	ReplayInterval time.Duration `envconfig:"CLAIM_BUFFER_REPLAY_INTERVAL" default:"1s"`
+}
+
+// ClaimDedupConfig holds claim deduplication configuration.
+// A Window of 0 disables it. Otherwise identical claims (same user, coupon and channel)
+// share one transaction while in flight, and retries within Window of a successful
+// claim get its receipt instead of 409.
+type ClaimDedupConfig struct {
+	Window time.Duration `envconfig:"CLAIM_DEDUP_WINDOW" default:"0s"` // e.g. 5s
+	Size   int           `envconfig:"CLAIM_DEDUP_SIZE" default:"10000"`
+}
+
+// ClaimAntiReplayConfig holds claim anti-replay configuration. A Window of 0 disables
+// it. Otherwise a claim resent with the same X-Request-ID and body within Window gets
+// the first one's response without being run again.
+type ClaimAntiReplayConfig struct {
+	Window time.Duration `envconfig:"CLAIM_ANTI_REPLAY_WINDOW" default:"0s"` // e.g. 30s
+	Size   int           `envconfig:"CLAIM_ANTI_REPLAY_SIZE" default:"10000"`
+}
+
+// ClaimRecordConfig holds claim recording configuration. A Size of 0 disables it.
+// Otherwise the last Size failing claims sent with X-Debug-Record: true are kept,
+// sanitized, for GET /api/admin/claims/recordings.
+type ClaimRecordConfig struct {
+	Size int `envconfig:"CLAIM_RECORD_SIZE" default:"0"` // e.g. 100
+}
+
+// ClaimFilterConfig holds configuration of the claimed filter, a Bloom filter of
+// successful claims. A Capacity of 0 disables it. Otherwise repeat claims of a user
+// are answered 409 after a lock-free read instead of a transaction on the coupon row.
+// The filter takes about 1.2 bytes per claim of Capacity at the default FPRate and is
+// cleared when more claims than Capacity were added.
+type ClaimFilterConfig struct {
+	Capacity int     `envconfig:"CLAIM_FILTER_CAPACITY" default:"0"` // e.g. 1000000
+	FPRate   float64 `envconfig:"CLAIM_FILTER_FP_RATE" default:"0.01"`
+}
+
+// ClaimShadowConfig holds shadow mode configuration: a candidate claim strategy predicts
+// the outcome of a SampleRate fraction of claims alongside the current one, and
+// divergences are logged. Strategy is "optimistic" or empty (disabled). At most
+// MaxInFlight predictions run at once; claims beyond that are not shadowed.
+type ClaimShadowConfig struct {
+	Strategy    string  `envconfig:"CLAIM_SHADOW_STRATEGY"`
+	SampleRate  float64 `envconfig:"CLAIM_SHADOW_SAMPLE_RATE" default:"1"`
+	MaxInFlight int     `envconfig:"CLAIM_SHADOW_MAX_IN_FLIGHT" default:"32"`
+}
+
+// CouponNameFilterConfig holds configuration of the coupon name filter, a Bloom filter
+// of coupon names rebuilt every Interval. An Interval of 0 disables it. Otherwise
+// claims and reads of coupons not in the filter get 404 without a database read.
+// Coupons created through other instances are 404 on this one until its next rebuild.
+type CouponNameFilterConfig struct {
+	Interval time.Duration `envconfig:"COUPON_NAME_FILTER_INTERVAL" default:"0s"` // e.g. 30s
+	Capacity int           `envconfig:"COUPON_NAME_FILTER_CAPACITY" default:"100000"`
+	FPRate   float64       `envconfig:"COUPON_NAME_FILTER_FP_RATE" default:"0.01"`
+}
+
+// EnumGuardConfig holds anti-enumeration configuration for the routes that reveal
+// whether a coupon exists (GET /api/coupons/{name}, its /claims and claims).
+// When Enabled, their responses take at least MinLatency plus up to Jitter, so 404s
+// cannot be told from hits by timing, and an IP getting more than Threshold 404s per
+// Window is answered 429 for Block, doubling on each repeat up to MaxBlock.
+// RateLimitHeaders adds X-RateLimit-* headers with the IP's remaining 404 budget.
+type EnumGuardConfig struct {
+	Enabled    bool          `envconfig:"ENUM_GUARD_ENABLED" default:"false"`
+	MinLatency time.Duration `envconfig:"ENUM_GUARD_MIN_LATENCY" default:"50ms"`
+	Jitter     time.Duration `envconfig:"ENUM_GUARD_JITTER" default:"25ms"`
+	Threshold  int           `envconfig:"ENUM_GUARD_THRESHOLD" default:"20"`
+	Window     time.Duration `envconfig:"ENUM_GUARD_WINDOW" default:"1m"`
+	Block      time.Duration `envconfig:"ENUM_GUARD_BLOCK" default:"1m"`
+	MaxBlock   time.Duration `envconfig:"ENUM_GUARD_MAX_BLOCK" default:"1h"`
+	MaxIPs     int           `envconfig:"ENUM_GUARD_MAX_IPS" default:"100000"`
+
+	RateLimitHeaders bool `envconfig:"ENUM_GUARD_RATE_LIMIT_HEADERS" default:"false"`
+}
+
+// CaptchaConfig holds the captcha provider verifying claims of coupons created with
+// captcha_required. An empty Provider disables verification, so claims of such
+// coupons are rejected. Provider is "hcaptcha" or "turnstile"; Secret is its secret key.
+type CaptchaConfig struct {
+	Provider string        `envconfig:"CAPTCHA_PROVIDER"`
+	Secret   string        `envconfig:"CAPTCHA_SECRET"`
+	Timeout  time.Duration `envconfig:"CAPTCHA_TIMEOUT" default:"3s"`
+}
+
+// ClaimGrantConfig holds the shared secret of claim grants: HS256 JWTs an upstream
+// system signs to pre-authorize one user's claim of one coupon. An empty Secret
+// disables grants.
+type ClaimGrantConfig struct {
+	Secret string `envconfig:"CLAIM_GRANT_SECRET"`
+}
+
+// WebhookConfig holds coupon lifecycle webhook configuration. When Enabled, the
+// /api/admin/webhooks endpoints manage subscriptions and deliveries are sent as jobs
+// (pkg/jobs), giving up on an endpoint after Timeout. Requires a PostgreSQL wire-
+// compatible DB_DRIVER, as MySQL has no job queue.
+type WebhookConfig struct {
+	Enabled bool          `envconfig:"WEBHOOKS_ENABLED" default:"false"`
+	Timeout time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"5s"`
+}
+
+// DeliveryPoolConfig sizes the work pool (pkg/workpool) shared by the subsystems
+// delivering to external endpoints, currently webhooks: at most Workers deliveries run
+// at once, QueueDepth more wait for a worker, and Overflow ("block" or "reject") sets
+// what happens beyond that. Job queue deliveries stay in the job queue while the pool
+// is full under either policy.
+type DeliveryPoolConfig struct {
+	Workers    int    `envconfig:"DELIVERY_WORKERS" default:"8"`
+	QueueDepth int    `envconfig:"DELIVERY_QUEUE_DEPTH" default:"64"`
+	Overflow   string `envconfig:"DELIVERY_OVERFLOW" default:"block"`
+}
+
+// LeaderboardConfig holds campaign leaderboard configuration: claims per user across
+// the coupons tagged with a campaign, refreshed every Interval from the claims made
+// since the previous refresh. An Interval of 0 disables GET /api/campaigns/:id/leaderboard
+// and the refresh. Requires a PostgreSQL wire-compatible DB_DRIVER.
+type LeaderboardConfig struct {
+	Interval time.Duration `envconfig:"LEADERBOARD_REFRESH_INTERVAL" default:"0s"`
+}
+
+// CampaignCapConfig holds campaign claim cap configuration. When Enabled, the
+// /api/admin/campaigns/:id/cap endpoints manage claim budgets across the coupons tagged
+// with a campaign, and claims check them. Requires DB_DRIVER postgres, cockroachdb or
+// memory.
+type CampaignCapConfig struct {
+	Enabled bool `envconfig:"CAMPAIGN_CAPS_ENABLED" default:"false"`
+}
+
+// AllowlistConfig holds coupon allowlist configuration. When Enabled, the
+// /api/admin/coupons/:name/allowlist endpoints manage the users allowed to claim a
+// coupon, each until an optional claim-by deadline, and claims check them. Requires
+// DB_DRIVER postgres, cockroachdb or memory.
+type AllowlistConfig struct {
+	Enabled bool `envconfig:"COUPON_ALLOWLISTS_ENABLED" default:"false"`
+}
+
+// CouponDeleteConfig holds coupon deletion configuration. A deleted coupon is kept as
+// a tombstone for UndoWindow, restorable with POST /api/coupons/:name/restore, and then
+// purged with its claims by a sweep every PurgeInterval. An UndoWindow of 0 disables
+// DELETE /api/coupons/:name. Requires DB_DRIVER postgres, cockroachdb or memory.
+type CouponDeleteConfig struct {
+	UndoWindow    time.Duration `envconfig:"COUPON_UNDO_WINDOW" default:"0s"`
+	PurgeInterval time.Duration `envconfig:"COUPON_PURGE_INTERVAL" default:"1m"`
+}
+
+// CouponArchiveConfig holds coupon archive configuration. With Enabled, deleted coupons
+// are copied to coupon_archive as they are purged (requires COUPON_UNDO_WINDOW) and
+// served read-only by GET /api/archive/coupons/:name, cacheable for CacheMaxAge: an
+// archived coupon does not change.
+type CouponArchiveConfig struct {
+	Enabled     bool          `envconfig:"COUPON_ARCHIVE_ENABLED" default:"false"`
+	CacheMaxAge time.Duration `envconfig:"COUPON_ARCHIVE_CACHE_MAX_AGE" default:"24h"`
+}
+
+// StockWaitConfig holds configuration of GET /api/coupons/:name/wait-for-stock, which
+// holds a request until the coupon is claimable or its ?timeout= (at most MaxTimeout)
+// passes. Waiters are woken by PostgreSQL notifications sent when stock is topped up or
+// a coupon re-enabled or restored, so Enabled requires DB_DRIVER=postgres and
+// DB_POOL_MODE=session. At most MaxWaiters requests wait at once; more get 503.
+type StockWaitConfig struct {
+	Enabled    bool          `envconfig:"STOCK_WAIT_ENABLED" default:"false"`
+	MaxTimeout time.Duration `envconfig:"STOCK_WAIT_MAX_TIMEOUT" default:"60s"`
+	MaxWaiters int           `envconfig:"STOCK_WAIT_MAX_WAITERS" default:"10000"`
+}
+
+// ClaimRetentionConfig holds claim retention configuration. When Enabled, coupons may be
+// created with a claim_retention, and a sweep run every Interval purges or anonymizes
+// their claims once older than its days. Requires DB_DRIVER postgres, cockroachdb or
+// memory.
+type ClaimRetentionConfig struct {
+	Enabled  bool          `envconfig:"CLAIM_RETENTION_ENABLED" default:"false"`
+	Interval time.Duration `envconfig:"CLAIM_RETENTION_INTERVAL" default:"1h"`
+}
+
+// AnalyticsExportConfig holds the analytics export configuration. With Store set, the
+// coupons and the claims of each day are exported as Parquet files under Prefix (e.g.
+// the environment's name), a pass every Interval exporting the days of the last
+// LookbackDays not exported yet. Store "local" writes them under LocalDir; "s3" puts them
+// to S3Bucket of any service speaking the S3 API: Amazon S3 in S3Region when S3Endpoint
+// is empty, MinIO (with S3PathStyle), or GCS at https://storage.googleapis.com with HMAC
+// keys. Requires a PostgreSQL wire-compatible DB_DRIVER.
+type AnalyticsExportConfig struct {
+	Store        string        `envconfig:"ANALYTICS_EXPORT_STORE"` // local or s3; empty disables the export
+	Prefix       string        `envconfig:"ANALYTICS_EXPORT_PREFIX"`
+	Interval     time.Duration `envconfig:"ANALYTICS_EXPORT_INTERVAL" default:"1h"`
+	LookbackDays int           `envconfig:"ANALYTICS_EXPORT_LOOKBACK_DAYS" default:"7"`
+	LocalDir     string        `envconfig:"ANALYTICS_EXPORT_LOCAL_DIR"`
+
+	S3Endpoint        string `envconfig:"ANALYTICS_EXPORT_S3_ENDPOINT"`
+	S3Region          string `envconfig:"ANALYTICS_EXPORT_S3_REGION" default:"us-east-1"`
+	S3Bucket          string `envconfig:"ANALYTICS_EXPORT_S3_BUCKET"`
+	S3AccessKeyID     string `envconfig:"ANALYTICS_EXPORT_S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string `envconfig:"ANALYTICS_EXPORT_S3_SECRET_ACCESS_KEY"`
+	S3PathStyle       bool   `envconfig:"ANALYTICS_EXPORT_S3_PATH_STYLE" default:"false"`
+}
+
+// Analytics export stores.
+const (
+	ExportStoreLocal = "local"
+	ExportStoreS3    = "s3"
+)
+
+// KillSwitchConfig holds the global kill switch configuration. While the switch is
+// engaged, every write but PUT /api/admin/killswitch gets 503 with Message; reads and
+// health checks keep working. Engaged starts the instance with the switch engaged. With
+// RedisURL (redis://[user:password@]host:port[/db]) the switch is shared by every replica:
+// each polls it every PollInterval, keeping the last state it saw while Redis is
+// unreachable, and Engaged cannot be set as the state in Redis rules.
+type KillSwitchConfig struct {
+	Engaged      bool          `envconfig:"KILL_SWITCH_ENGAGED" default:"false"`
+	Message      string        `envconfig:"KILL_SWITCH_MESSAGE" default:"writes are paused for maintenance"`
+	RedisURL     string        `envconfig:"KILL_SWITCH_REDIS_URL"`
+	RedisTimeout time.Duration `envconfig:"KILL_SWITCH_REDIS_TIMEOUT" default:"500ms"`
+	PollInterval time.Duration `envconfig:"KILL_SWITCH_POLL_INTERVAL" default:"1s"`
+}
+
+// LoadTestConfig holds configuration for the load test prewarm API. When Enabled,
+// POST /api/admin/loadtest/prewarm creates disposable coupons and fake claims under a
+// namespace, DELETE /api/admin/loadtest/{namespace} removes them, and a sweep run every
+// CleanupInterval removes those of namespaces past their expiry. Meant for load-test
+// environments only. Requires a PostgreSQL wire-compatible DB_DRIVER.
+type LoadTestConfig struct {
+	Enabled         bool          `envconfig:"LOADTEST_ENABLED" default:"false"`
+	CleanupInterval time.Duration `envconfig:"LOADTEST_CLEANUP_INTERVAL" default:"1m"`
+}
+
+// PoolWatchConfig holds configuration for the connection pool watchdog. With Interval
+// set, every pool is sampled that often and a warning logged (and /health reporting
+// "pools": "warning") while its saturation, the share of its connections in use, is
+// at least SaturationWarn or the p95 of its recent acquire waits at least
+// AcquireWaitWarn. A zero threshold is not checked.
+type PoolWatchConfig struct {
+	Interval        time.Duration `envconfig:"DB_POOL_WATCH_INTERVAL" default:"0s"`
+	SaturationWarn  float64       `envconfig:"DB_POOL_SATURATION_WARN" default:"0.9"`
+	AcquireWaitWarn time.Duration `envconfig:"DB_POOL_ACQUIRE_WAIT_P95_WARN" default:"100ms"`
+}
+
+// FailoverWatchConfig holds configuration for database failover detection. With
+// Interval set, the database server is identified that often, and a "database failover
+// detected" event logged (and /health reporting "database_server": "failover_detected"
+// for StatusHold) when another server answers or the server has restarted. Not
+// supported on CockroachDB, whose connections land on any node behind the load balancer.
+type FailoverWatchConfig struct {
+	Interval   time.Duration `envconfig:"DB_FAILOVER_WATCH_INTERVAL" default:"0s"`
+	StatusHold time.Duration `envconfig:"DB_FAILOVER_STATUS_HOLD" default:"15m"`
+}
+
+// HeavyJobConfig schedules the heavy periodic jobs: the coupon purge (which archives),
+// claim retention and the analytics export. With Windows set (see jobs.ParseWindows),
+// they only run within those daily UTC windows; with MaxReplicationLag or
+// MaxActiveConns set, they skip their pass while the database is over either, e.g.
+// while a backup streams from a replica. A skipped pass runs on the job's next tick.
+// The load limits require DB_DRIVER postgres.
+type HeavyJobConfig struct {
+	Windows           string        `envconfig:"HEAVY_JOB_WINDOWS" default:""`
+	MaxReplicationLag time.Duration `envconfig:"HEAVY_JOB_MAX_REPLICATION_LAG" default:"0s"`
+	MaxActiveConns    int64         `envconfig:"HEAVY_JOB_MAX_ACTIVE_CONNS" default:"0"`
+}
+
+// Scheduled reports whether the heavy jobs are held to windows or load limits.
+func (c HeavyJobConfig) Scheduled() bool {
+	return c.Windows != "" || c.MaxReplicationLag > 0 || c.MaxActiveConns > 0
+}
+
+// RuntimeConfig fits the Go runtime to the container. Unless GOMEMLIMIT is set, the
+// runtime memory limit is MemoryLimitRatio of the cgroup memory limit, leaving the rest
+// for memory the runtime does not manage; 0 leaves it unset. GOMAXPROCS already follows
+// the cgroup CPU limit (Go 1.25).
+type RuntimeConfig struct {
+	MemoryLimitRatio float64 `envconfig:"MEMORY_LIMIT_RATIO" default:"0.9"`
+}
+
+// ReadHedgeConfig holds hedged read configuration for the GET endpoints.
+// Enabled is the kill switch. When on, a read still running after its recent p95
+// latency (but at least MinDelay) is issued a second time and the first answer wins.
+type ReadHedgeConfig struct {
+	Enabled  bool          `envconfig:"READ_HEDGE_ENABLED" default:"false"`
+	MinDelay time.Duration `envconfig:"READ_HEDGE_MIN_DELAY" default:"10ms"`
+}
+
+// ClaimImportConfig holds configuration for POST /api/admin/claims/import.
+// ChunkSize is how many claims are committed per transaction: larger chunks import
+// faster but hold coupon row locks longer, delaying live claims on the same coupons.
+type ClaimImportConfig struct {
+	ChunkSize int `envconfig:"CLAIM_IMPORT_CHUNK_SIZE" default:"500"`
+}
+
+// ClaimBudgetConfig holds configuration for aborting claims early. With MinBudget set,
+// a claim whose deadline (the claim route timeout) leaves less than MinBudget gets 503
+// before its transaction begins, rather than queueing on the coupon's row lock only to
+// be cancelled mid-flight. 0 disables it.
+type ClaimBudgetConfig struct {
+	MinBudget time.Duration `envconfig:"CLAIM_MIN_BUDGET" default:"0s"`
+}
+
+// ClaimLimitConfig holds configuration for shedding claims when the database slows down.
+// When Enabled, the claim route admits at most an adaptive number of claims at once:
+// the limit starts at Initial and moves between Min and Max, shrinking while recent
+// claim latency exceeds Tolerance times its long-term average. Claims over it get 429.
+type ClaimLimitConfig struct {
+	Enabled   bool    `envconfig:"CLAIM_ADAPTIVE_LIMIT_ENABLED" default:"false"`
+	Initial   int     `envconfig:"CLAIM_ADAPTIVE_LIMIT_INITIAL" default:"20"`
+	Min       int     `envconfig:"CLAIM_ADAPTIVE_LIMIT_MIN" default:"5"`
+	Max       int     `envconfig:"CLAIM_ADAPTIVE_LIMIT_MAX" default:"500"`
+	Tolerance float64 `envconfig:"CLAIM_ADAPTIVE_LIMIT_TOLERANCE" default:"1.5"`
+}
+
+// ClaimPacingConfig holds configuration for smoothing claims per coupon. With Rate set,
+// each coupon's claims begin at most Rate per second: claims arriving faster wait their
+// turn, and once MaxQueue claims are waiting on a coupon further ones get 429. The
+// queues live in Redis at RedisURL (redis://[user:password@]host:port[/db]), shared by
+// every replica and kept across restarts, or in process when it is empty. Redis
+// commands taking longer than RedisTimeout let the claim through unpaced. 0 disables it.
+type ClaimPacingConfig struct {
+	Rate         float64       `envconfig:"CLAIM_PACING_RATE" default:"0"`
+	MaxQueue     int           `envconfig:"CLAIM_PACING_MAX_QUEUE" default:"50"`
+	RedisURL     string        `envconfig:"CLAIM_PACING_REDIS_URL"`
+	RedisTimeout time.Duration `envconfig:"CLAIM_PACING_REDIS_TIMEOUT" default:"100ms"`
+}
+
+// APIKeyConfig holds the tiered API keys identifying partners on the claim route, sent
+// in X-API-Key. KeysFile is a JSON array of {"id", "tier", "sha256"} objects, tier
+// "standard" or "premium" and sha256 the hex SHA-256 of the key, loaded at startup;
+// empty disables keys. Keys do not gate access: claims without one are handled as
+// before, and claims with an unknown one get 401. Each key's claims are limited to
+// StandardRate or PremiumRate per second (0 does not limit them), and premium claims
+// may take the PremiumReserve share of the adaptive claim limit the others are shed
+// from, and queue up to PremiumMaxQueue claims deep in claim pacing. With UsageEnabled,
+// each key's claims are counted per UTC month in api_key_usage, flushed every
+// UsageFlushInterval, and keys with a "monthly_quota" get 429 once it is used up;
+// replicas may overshoot a quota by the claims they make within one interval.
+type APIKeyConfig struct {
+	KeysFile        string  `envconfig:"API_KEYS_FILE"`
+	StandardRate    float64 `envconfig:"API_KEY_STANDARD_RATE" default:"50"`
+	PremiumRate     float64 `envconfig:"API_KEY_PREMIUM_RATE" default:"500"`
+	PremiumReserve  float64 `envconfig:"API_KEY_PREMIUM_RESERVE" default:"0.2"`
+	PremiumMaxQueue int     `envconfig:"API_KEY_PREMIUM_MAX_QUEUE" default:"200"`
+
+	UsageEnabled       bool          `envconfig:"API_KEY_USAGE_ENABLED" default:"false"`
+	UsageFlushInterval time.Duration `envconfig:"API_KEY_USAGE_FLUSH_INTERVAL" default:"10s"`
+}
+
+// HighProfileConfig holds the share of the claim pool's connections reserved for the
+// claims of high-profile coupons (marked with high_profile): PoolShare of DB_MAX_CONNS,
+// at least one connection, taken by all high-profile coupons together. Their claims
+// beyond it wait for a connection of the share to free up, so a viral coupon cannot
+// starve the rest of the catalog. 0 disables it.
+type HighProfileConfig struct {
+	PoolShare float64 `envconfig:"COUPON_HIGH_PROFILE_POOL_SHARE" default:"0"`
+}
+
+// Slots returns how many connections the claims of high-profile coupons may hold at once.
+func (c HighProfileConfig) Slots(maxConns int) int {
+	return max(1, int(c.PoolShare*float64(maxConns)))
+}
+
+// CouponMetadataConfig holds the JSON Schema (drafts 4, 6 or 7) that coupon metadata
+// must conform to when coupons are created. SchemaPath is the schema file, loaded at
+// startup; empty accepts any JSON object.
+type CouponMetadataConfig struct {
+	SchemaPath string `envconfig:"COUPON_METADATA_SCHEMA"`
+}
+
+// SeedConfig holds the manifest of coupons applied when the server starts, e.g. to
+// give a demo environment or preview deployment its coupons. File is a JSON file, or
+// YAML when it ends in .yaml or .yml, in the format of POST /api/admin/apply. Missing
+// coupons are created and listed ones reconciled as the apply does, but coupons absent
+// from the file are left alone, so restarting with the same file changes nothing. A
+// manifest that conflicts with the stored coupons stops the server. Empty disables it.
+type SeedConfig struct {
+	File string `envconfig:"SEED_FILE"`
+}
+
+// ClaimedByConfig sets how GET /api/coupons/{name} and the claim export and sample,
+// which anyone may call, report who claimed a coupon: Mode "full" lists user IDs, "hash"
+// replaces each with a keyed HMAC-SHA256 (requires HashKey; keep it stable so hashes
+// stay comparable) and "omit" leaves them out; only "full" answers claimed_by_contains.
+// stats.total_claims counts the claims in every mode. Max caps claimed_by at the first
+// Max claimants, reporting truncated when more claimed.
+type ClaimedByConfig struct {
+	Mode    string `envconfig:"COUPON_CLAIMED_BY" default:"full"`
+	HashKey string `envconfig:"COUPON_CLAIMED_BY_HASH_KEY"`
+	Max     int    `envconfig:"COUPON_CLAIMED_BY_MAX" default:"10000"`
 }
 
 // Load parses environment variables into the Config struct and validates them.
@@ -77,6 +780,91 @@ func (c *Config) WarnIfDefaultCredentials() []string {
 	return warnings
 }
 
+// redacted replaces a secret in logged configuration; unset secrets stay empty.
+const redacted = "[REDACTED]"
+
+// Redacted returns a copy of c with its secrets (DB_PASSWORD, LOG_REDACT_KEY,
+// CAPTCHA_SECRET, CLAIM_GRANT_SECRET, COUPON_CLAIMED_BY_HASH_KEY and
+// ANALYTICS_EXPORT_S3_SECRET_ACCESS_KEY) masked, for logging.
+func (c *Config) Redacted() Config {
+	r := *c
+	for _, secret := range []*string{&r.DB.Password, &r.Log.RedactKey, &r.Captcha.Secret, &r.Grant.Secret, &r.Privacy.HashKey, &r.Export.S3SecretAccessKey} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return r
+}
+
+// Subsystems returns the optional subsystems c enables, named as in /debug/vars.
+func (c *Config) Subsystems() []string {
+	enabled := []string{}
+	for _, s := range []struct {
+		name string
+		on   bool
+	}{
+		{"cors", len(c.CORS.AllowOrigins) > 0},
+		{"request_deadline", c.Server.MaxRequestDeadline > 0},
+		{"coupon_cache", c.Cache.CouponTTL > 0},
+		{"claim_buffer", c.Buffer.Path != ""},
+		{"claim_dedup", c.Dedup.Window > 0},
+		{"claim_anti_replay", c.Replay.Window > 0},
+		{"claim_recording", c.Record.Size > 0},
+		{"claim_filter", c.Filter.Capacity > 0},
+		{"claim_shadow", c.Shadow.Strategy != ""},
+		{"coupon_name_filter", c.Names.Interval > 0},
+		{"enumeration_guard", c.Enum.Enabled},
+		{"captcha", c.Captcha.Provider != ""},
+		{"claim_grants", c.Grant.Secret != ""},
+		{"webhooks", c.Webhook.Enabled},
+		{"leaderboard", c.Board.Interval > 0},
+		{"campaign_caps", c.Caps.Enabled},
+		{"read_hedging", c.Hedge.Enabled},
+		{"claim_budget", c.Budget.MinBudget > 0},
+		{"claim_adaptive_limit", c.Shed.Enabled},
+		{"claim_pacing", c.Pace.Rate > 0},
+		{"api_keys", c.Keys.KeysFile != ""},
+		{"api_key_usage", c.Keys.UsageEnabled},
+		{"high_profile_quota", c.Quota.PoolShare > 0},
+		{"read_pool", c.DB.ReadMaxConns > 0},
+		{"db_degraded_start", c.DB.DegradedStart},
+		{"read_retry", c.DB.ReadRetry},
+		{"transaction_pooling", c.DB.PoolMode == PoolModeTransaction},
+		{"coupon_lock_policy", c.DB.CouponLockPolicy != string(database.LockWait)},
+		{"lock_diagnostics", c.DB.LockDiagnosticsThreshold > 0},
+		{"coupon_metadata_schema", c.Meta.SchemaPath != ""},
+		{"seed", c.Seed.File != ""},
+		{"coupon_allowlists", c.Allow.Enabled},
+		{"coupon_deletion", c.Delete.UndoWindow > 0},
+		{"coupon_archive", c.Archive.Enabled},
+		{"stock_wait", c.Wait.Enabled},
+		{"claim_retention", c.Retain.Enabled},
+		{"analytics_export", c.Export.Store != ""},
+		{"kill_switch_engaged", c.Kill.Engaged},
+		{"kill_switch_redis", c.Kill.RedisURL != ""},
+		{"loadtest", c.Load.Enabled},
+		{"db_pool_watch", c.Watch.Interval > 0},
+		{"db_failover_watch", c.Failover.Interval > 0},
+		{"heavy_job_schedule", c.Heavy.Scheduled()},
+		{"claimed_by_" + c.Privacy.Mode, c.Privacy.Mode != "full"},
+		{"access_log_" + c.Access.Sink, c.Access.Sink != accesslog.Stdout},
+		{"event_log_" + c.Events.Sink, c.Events.Sink != ""},
+	} {
+		if s.on {
+			enabled = append(enabled, s.name)
+		}
+	}
+	return enabled
+}
+
+// validateBodyLimit checks a body limit is between 1KB and 64MB.
+func validateBodyLimit(name string, limit int) error {
+	if limit < 1<<10 || limit > 64<<20 {
+		return fmt.Errorf("%s must be between 1024 and 67108864 bytes, got %d", name, limit)
+	}
+	return nil
+}
+
 // Validate checks that all configuration values are valid.
 func (c *Config) Validate() error {
 	// Validate server port
@@ -96,6 +884,89 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("SHUTDOWN_TIMEOUT must not exceed 300 seconds, got %d", c.Server.ShutdownTimeout)
 	}
 
+	// Validate server limits
+	for name, d := range map[string]time.Duration{
+		"SERVER_READ_TIMEOUT":  c.Server.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT": c.Server.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":  c.Server.IdleTimeout,
+	} {
+		if d < time.Second || d > 10*time.Minute {
+			return fmt.Errorf("%s must be between 1s and 10m, got %s", name, d)
+		}
+	}
+	if err := validateBodyLimit("SERVER_BODY_LIMIT", c.Server.BodyLimit); err != nil {
+		return err
+	}
+	for route, d := range c.Server.RouteTimeouts {
+		if !slices.Contains(Routes, route) {
+			return fmt.Errorf("SERVER_ROUTE_TIMEOUTS has unknown route %q (routes: %s)", route, strings.Join(Routes, ", "))
+		}
+		if d < 100*time.Millisecond || d > 10*time.Minute {
+			return fmt.Errorf("SERVER_ROUTE_TIMEOUTS for %s must be between 100ms and 10m, got %s", route, d)
+		}
+	}
+	if d := c.Server.MaxRequestDeadline; d != 0 && (d < 100*time.Millisecond || d > 10*time.Minute) {
+		return fmt.Errorf("SERVER_MAX_REQUEST_DEADLINE must be 0 (disabled) or between 100ms and 10m, got %s", d)
+	}
+	for route, limit := range c.Server.RouteBodyLimits {
+		if !slices.Contains(Routes, route) {
+			return fmt.Errorf("SERVER_ROUTE_BODY_LIMITS has unknown route %q (routes: %s)", route, strings.Join(Routes, ", "))
+		}
+		if err := validateBodyLimit("SERVER_ROUTE_BODY_LIMITS for "+route, limit); err != nil {
+			return err
+		}
+	}
+
+	// Validate page sizes (a page is read and encoded in one go, so it is capped)
+	if c.Server.MaxPageSize < 1 || c.Server.MaxPageSize > 10000 {
+		return fmt.Errorf("SERVER_MAX_PAGE_SIZE must be between 1 and 10000, got %d", c.Server.MaxPageSize)
+	}
+	if c.Server.DefaultPageSize < 1 || c.Server.DefaultPageSize > c.Server.MaxPageSize {
+		return fmt.Errorf("SERVER_DEFAULT_PAGE_SIZE must be between 1 and SERVER_MAX_PAGE_SIZE (%d), got %d",
+			c.Server.MaxPageSize, c.Server.DefaultPageSize)
+	}
+
+	// Validate CORS
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return fmt.Errorf("CORS_ALLOW_ORIGINS must hold * or origins like https://shop.example.com, got %q", origin)
+		}
+	}
+	if c.CORS.MaxAge < 0 || c.CORS.MaxAge > 24*time.Hour {
+		return fmt.Errorf("CORS_MAX_AGE must be between 0 and 24h, got %s", c.CORS.MaxAge)
+	}
+
+	// Validate database driver
+	if _, err := database.DialectByName(c.DB.Driver); err != nil {
+		return fmt.Errorf("DB_DRIVER must be one of: postgres, cockroachdb, mysql, memory; got %q", c.DB.Driver)
+	}
+
+	// Validate column rename and table move phases
+	if _, err := database.RenamesWithPhases(c.DB.MigrationPhases); err != nil {
+		return fmt.Errorf("DB_MIGRATION_PHASES is invalid: %w", err)
+	}
+	moves, err := database.TableMovesWithPhases(c.DB.MigrationPhases)
+	if err != nil {
+		return fmt.Errorf("DB_MIGRATION_PHASES is invalid: %w", err)
+	}
+	if moves[database.ClaimsV2.Name()].Phase != database.PhaseOld && c.DB.Driver != database.Postgres.Name {
+		return fmt.Errorf("DB_MIGRATION_PHASES: claims_v2 requires DB_DRIVER=postgres (hash partitioning), got %q", c.DB.Driver)
+	}
+
+	// Validate query timeouts
+	for query, d := range c.DB.QueryTimeouts {
+		if !slices.Contains(database.Queries, query) {
+			return fmt.Errorf("DB_QUERY_TIMEOUTS has unknown query %q (queries: %s)", query, strings.Join(database.Queries, ", "))
+		}
+		if d < 10*time.Millisecond || d > time.Minute {
+			return fmt.Errorf("DB_QUERY_TIMEOUTS for %s must be between 10ms and 1m, got %s", query, d)
+		}
+	}
+
 	// Validate required string fields
 	if c.DB.Host == "" {
 		return fmt.Errorf("DB_HOST cannot be empty")
@@ -123,6 +994,54 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("DB_MIN_CONNS (%d) cannot exceed DB_MAX_CONNS (%d)", c.DB.MinConns, c.DB.MaxConns)
 	}
 
+	// Validate the read pool
+	if c.DB.ReadMaxConns < 0 {
+		return fmt.Errorf("DB_READ_MAX_CONNS must be at least 0, got %d", c.DB.ReadMaxConns)
+	}
+	if c.DB.ReadMaxConns > 0 && !c.DB.postgresWire() {
+		return fmt.Errorf("DB_READ_MAX_CONNS requires a PostgreSQL wire-compatible DB_DRIVER (postgres or cockroachdb), got %q", c.DB.Driver)
+	}
+	if c.DB.ReadMinConns < 0 || c.DB.ReadMinConns > c.DB.ReadMaxConns {
+		return fmt.Errorf("DB_READ_MIN_CONNS must be between 0 and DB_READ_MAX_CONNS (%d), got %d", c.DB.ReadMaxConns, c.DB.ReadMinConns)
+	}
+
+	if c.DB.ReadRetry && !c.DB.postgresWire() {
+		return fmt.Errorf("DB_READ_RETRY_ENABLED requires a PostgreSQL wire-compatible DB_DRIVER (postgres or cockroachdb), got %q", c.DB.Driver)
+	}
+
+	// Validate the coupon lock policy
+	mode, err := database.ParseLockMode(c.DB.CouponLockPolicy)
+	if err != nil {
+		return fmt.Errorf("DB_COUPON_LOCK_POLICY must be one of: wait, nowait, timeout; got %q", c.DB.CouponLockPolicy)
+	}
+	if mode == database.LockTimeout {
+		if !c.DB.postgresWire() {
+			return fmt.Errorf("DB_COUPON_LOCK_POLICY=timeout requires a PostgreSQL wire-compatible DB_DRIVER (postgres or cockroachdb), got %q", c.DB.Driver)
+		}
+		if c.DB.CouponLockTimeout < time.Millisecond || c.DB.CouponLockTimeout > 10*time.Second {
+			return fmt.Errorf("DB_COUPON_LOCK_TIMEOUT must be between 1ms and 10s, got %s", c.DB.CouponLockTimeout)
+		}
+	}
+
+	// Validate lock diagnostics
+	if d := c.DB.LockDiagnosticsThreshold; d != 0 {
+		if d < 10*time.Millisecond || d > time.Minute {
+			return fmt.Errorf("DB_LOCK_DIAGNOSTICS_THRESHOLD must be 0 (disabled) or between 10ms and 1m, got %s", d)
+		}
+		if c.DB.Driver != database.Postgres.Name {
+			return fmt.Errorf("DB_LOCK_DIAGNOSTICS_THRESHOLD requires DB_DRIVER=postgres (pg_locks), got %q", c.DB.Driver)
+		}
+		if c.DB.PoolMode != PoolModeSession {
+			return fmt.Errorf("DB_LOCK_DIAGNOSTICS_THRESHOLD requires DB_POOL_MODE=session (a pooler hides the backends), got %q", c.DB.PoolMode)
+		}
+		if mode == database.LockNoWait || (mode == database.LockTimeout && d >= c.DB.CouponLockTimeout) {
+			return fmt.Errorf("DB_LOCK_DIAGNOSTICS_THRESHOLD must be below how long claims wait for a coupon lock (DB_COUPON_LOCK_POLICY=%s)", c.DB.CouponLockPolicy)
+		}
+		if c.DB.LockDiagnosticsSize < 1 || c.DB.LockDiagnosticsSize > 1000 {
+			return fmt.Errorf("DB_LOCK_DIAGNOSTICS_SIZE must be between 1 and 1000, got %d", c.DB.LockDiagnosticsSize)
+		}
+	}
+
 	// Validate SSL mode
 	validSSLModes := map[string]bool{
 		"disable": true, "allow": true, "prefer": true,
@@ -132,5 +1051,470 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("DB_SSLMODE must be one of: disable, allow, prefer, require, verify-ca, verify-full; got %q", c.DB.SSLMode)
 	}
 
+	// Validate pool mode
+	switch c.DB.PoolMode {
+	case PoolModeSession:
+	case PoolModeTransaction:
+		if !c.DB.postgresWire() {
+			return fmt.Errorf("DB_POOL_MODE=transaction requires a PostgreSQL wire-compatible DB_DRIVER (postgres or cockroachdb), got %q", c.DB.Driver)
+		}
+	default:
+		return fmt.Errorf("DB_POOL_MODE must be one of: session, transaction; got %q", c.DB.PoolMode)
+	}
+
+	// Validate response cache (TTL capped at 1 minute: the cache trades freshness for read load)
+	if c.Cache.CouponTTL < 0 || c.Cache.CouponTTL > time.Minute {
+		return fmt.Errorf("CACHE_COUPON_TTL must be between 0 and 1m, got %s", c.Cache.CouponTTL)
+	}
+	if c.Cache.CouponSize < 1 {
+		return fmt.Errorf("CACHE_COUPON_SIZE must be at least 1, got %d", c.Cache.CouponSize)
+	}
+
+	// Validate claim buffer (only used when a path is set)
+	if c.Buffer.MaxEntries < 1 {
+		return fmt.Errorf("CLAIM_BUFFER_MAX_ENTRIES must be at least 1, got %d", c.Buffer.MaxEntries)
+	}
+	if c.Buffer.MaxAge <= 0 {
+		return fmt.Errorf("CLAIM_BUFFER_MAX_AGE must be positive, got %s", c.Buffer.MaxAge)
+	}
+	if c.Buffer.ReplayInterval <= 0 {
+		return fmt.Errorf("CLAIM_BUFFER_REPLAY_INTERVAL must be positive, got %s", c.Buffer.ReplayInterval)
+	}
+
+	// Validate claim deduplication (window capped at 1 minute: it only absorbs retry storms)
+	if c.Dedup.Window < 0 || c.Dedup.Window > time.Minute {
+		return fmt.Errorf("CLAIM_DEDUP_WINDOW must be between 0 and 1m, got %s", c.Dedup.Window)
+	}
+	if c.Dedup.Size < 1 {
+		return fmt.Errorf("CLAIM_DEDUP_SIZE must be at least 1, got %d", c.Dedup.Size)
+	}
+
+	// Validate claim anti-replay
+	if c.Replay.Window < 0 || c.Replay.Window > 10*time.Minute {
+		return fmt.Errorf("CLAIM_ANTI_REPLAY_WINDOW must be between 0 and 10m, got %s", c.Replay.Window)
+	}
+	if c.Replay.Size < 1 {
+		return fmt.Errorf("CLAIM_ANTI_REPLAY_SIZE must be at least 1, got %d", c.Replay.Size)
+	}
+
+	// Validate claim recording (recordings hold up to 4 KiB bodies each)
+	if c.Record.Size < 0 || c.Record.Size > 1000 {
+		return fmt.Errorf("CLAIM_RECORD_SIZE must be between 0 and 1000, got %d", c.Record.Size)
+	}
+
+	// Validate the claimed filter (capped at 100M claims, about 120MB at a 1% FP rate)
+	if c.Filter.Capacity < 0 || c.Filter.Capacity > 100_000_000 {
+		return fmt.Errorf("CLAIM_FILTER_CAPACITY must be between 0 and 100000000, got %d", c.Filter.Capacity)
+	}
+	if c.Filter.FPRate <= 0 || c.Filter.FPRate > 0.5 {
+		return fmt.Errorf("CLAIM_FILTER_FP_RATE must be greater than 0 and at most 0.5, got %g", c.Filter.FPRate)
+	}
+
+	// Validate the coupon name filter (interval capped: it bounds how long coupons created
+	// through other instances are 404 here)
+	if c.Names.Interval != 0 && (c.Names.Interval < time.Second || c.Names.Interval > 10*time.Minute) {
+		return fmt.Errorf("COUPON_NAME_FILTER_INTERVAL must be 0 or between 1s and 10m, got %s", c.Names.Interval)
+	}
+	if c.Names.Capacity < 1 || c.Names.Capacity > 10_000_000 {
+		return fmt.Errorf("COUPON_NAME_FILTER_CAPACITY must be between 1 and 10000000, got %d", c.Names.Capacity)
+	}
+	if c.Names.FPRate <= 0 || c.Names.FPRate > 0.5 {
+		return fmt.Errorf("COUPON_NAME_FILTER_FP_RATE must be greater than 0 and at most 0.5, got %g", c.Names.FPRate)
+	}
+
+	// Validate anti-enumeration (delays capped: they apply to every guarded request)
+	if c.Enum.MinLatency < 0 || c.Enum.MinLatency > time.Second {
+		return fmt.Errorf("ENUM_GUARD_MIN_LATENCY must be between 0 and 1s, got %s", c.Enum.MinLatency)
+	}
+	if c.Enum.Jitter < 0 || c.Enum.Jitter > time.Second {
+		return fmt.Errorf("ENUM_GUARD_JITTER must be between 0 and 1s, got %s", c.Enum.Jitter)
+	}
+	if c.Enum.Threshold < 1 {
+		return fmt.Errorf("ENUM_GUARD_THRESHOLD must be at least 1, got %d", c.Enum.Threshold)
+	}
+	if c.Enum.Window <= 0 {
+		return fmt.Errorf("ENUM_GUARD_WINDOW must be positive, got %s", c.Enum.Window)
+	}
+	if c.Enum.Block <= 0 {
+		return fmt.Errorf("ENUM_GUARD_BLOCK must be positive, got %s", c.Enum.Block)
+	}
+	if c.Enum.MaxBlock < c.Enum.Block {
+		return fmt.Errorf("ENUM_GUARD_MAX_BLOCK must be at least ENUM_GUARD_BLOCK, got %s", c.Enum.MaxBlock)
+	}
+	if c.Enum.MaxIPs < 1 {
+		return fmt.Errorf("ENUM_GUARD_MAX_IPS must be at least 1, got %d", c.Enum.MaxIPs)
+	}
+
+	// Validate claim shadow mode
+	if c.Shadow.Strategy != "" && c.Shadow.Strategy != "optimistic" {
+		return fmt.Errorf("CLAIM_SHADOW_STRATEGY must be empty or optimistic, got %q", c.Shadow.Strategy)
+	}
+	if c.Shadow.SampleRate <= 0 || c.Shadow.SampleRate > 1 {
+		return fmt.Errorf("CLAIM_SHADOW_SAMPLE_RATE must be greater than 0 and at most 1, got %g", c.Shadow.SampleRate)
+	}
+	if c.Shadow.MaxInFlight < 1 || c.Shadow.MaxInFlight > 1024 {
+		return fmt.Errorf("CLAIM_SHADOW_MAX_IN_FLIGHT must be between 1 and 1024, got %d", c.Shadow.MaxInFlight)
+	}
+
+	// Validate captcha verification
+	switch c.Captcha.Provider {
+	case "", "hcaptcha", "turnstile":
+	default:
+		return fmt.Errorf("CAPTCHA_PROVIDER must be empty, hcaptcha or turnstile, got %q", c.Captcha.Provider)
+	}
+	if c.Captcha.Provider != "" && c.Captcha.Secret == "" {
+		return fmt.Errorf("CAPTCHA_SECRET is required when CAPTCHA_PROVIDER is set")
+	}
+	if c.Captcha.Timeout < 100*time.Millisecond || c.Captcha.Timeout > 10*time.Second {
+		return fmt.Errorf("CAPTCHA_TIMEOUT must be between 100ms and 10s, got %s", c.Captcha.Timeout)
+	}
+
+	// Validate claim grants
+	if c.Grant.Secret != "" && len(c.Grant.Secret) < 32 {
+		return fmt.Errorf("CLAIM_GRANT_SECRET must be at least 32 bytes, got %d", len(c.Grant.Secret))
+	}
+
+	// Validate claimed_by privacy
+	switch c.Privacy.Mode {
+	case "full", "omit":
+	case "hash":
+		if len(c.Privacy.HashKey) < redact.MinKeyLength {
+			return fmt.Errorf("COUPON_CLAIMED_BY_HASH_KEY must be at least %d bytes when COUPON_CLAIMED_BY is hash", redact.MinKeyLength)
+		}
+	default:
+		return fmt.Errorf("COUPON_CLAIMED_BY must be one of: full, hash, omit; got %q", c.Privacy.Mode)
+	}
+	if c.Privacy.Max < 1 || c.Privacy.Max > 1000000 {
+		return fmt.Errorf("COUPON_CLAIMED_BY_MAX must be between 1 and 1000000, got %d", c.Privacy.Max)
+	}
+
+	// Validate webhooks
+	if c.Webhook.Enabled && !c.DB.postgresWire() {
+		return fmt.Errorf("WEBHOOKS_ENABLED requires a DB_DRIVER with a job queue (postgres or cockroachdb), got %q", c.DB.Driver)
+	}
+	if c.Webhook.Timeout < 100*time.Millisecond || c.Webhook.Timeout > time.Minute {
+		return fmt.Errorf("WEBHOOK_TIMEOUT must be between 100ms and 1m, got %s", c.Webhook.Timeout)
+	}
+	if c.Deliver.Workers < 1 || c.Deliver.Workers > 256 {
+		return fmt.Errorf("DELIVERY_WORKERS must be between 1 and 256, got %d", c.Deliver.Workers)
+	}
+	if c.Deliver.QueueDepth < 0 || c.Deliver.QueueDepth > 10000 {
+		return fmt.Errorf("DELIVERY_QUEUE_DEPTH must be between 0 and 10000, got %d", c.Deliver.QueueDepth)
+	}
+	if c.Deliver.Overflow != workpool.Block && c.Deliver.Overflow != workpool.Reject {
+		return fmt.Errorf("DELIVERY_OVERFLOW must be one of: block, reject; got %q", c.Deliver.Overflow)
+	}
+
+	// Validate campaign leaderboards
+	if c.Board.Interval != 0 && !c.DB.postgresWire() {
+		return fmt.Errorf("LEADERBOARD_REFRESH_INTERVAL requires a PostgreSQL wire-compatible DB_DRIVER (postgres or cockroachdb), got %q", c.DB.Driver)
+	}
+	if c.Board.Interval != 0 && (c.Board.Interval < time.Second || c.Board.Interval > time.Hour) {
+		return fmt.Errorf("LEADERBOARD_REFRESH_INTERVAL must be 0 or between 1s and 1h, got %s", c.Board.Interval)
+	}
+
+	// Validate campaign claim caps
+	if c.Caps.Enabled && !c.DB.couponFeatures() {
+		return fmt.Errorf("CAMPAIGN_CAPS_ENABLED requires DB_DRIVER postgres, cockroachdb or memory, got %q", c.DB.Driver)
+	}
+
+	// Validate coupon allowlists
+	if c.Allow.Enabled && !c.DB.couponFeatures() {
+		return fmt.Errorf("COUPON_ALLOWLISTS_ENABLED requires DB_DRIVER postgres, cockroachdb or memory, got %q", c.DB.Driver)
+	}
+
+	// Validate coupon deletion
+	if c.Delete.UndoWindow != 0 && !c.DB.couponFeatures() {
+		return fmt.Errorf("COUPON_UNDO_WINDOW requires DB_DRIVER postgres, cockroachdb or memory, got %q", c.DB.Driver)
+	}
+	if c.Delete.UndoWindow != 0 && (c.Delete.UndoWindow < time.Minute || c.Delete.UndoWindow > 720*time.Hour) {
+		return fmt.Errorf("COUPON_UNDO_WINDOW must be 0 or between 1m and 720h, got %s", c.Delete.UndoWindow)
+	}
+	if c.Delete.PurgeInterval < time.Second || c.Delete.PurgeInterval > time.Hour {
+		return fmt.Errorf("COUPON_PURGE_INTERVAL must be between 1s and 1h, got %s", c.Delete.PurgeInterval)
+	}
+
+	// Validate the coupon archive
+	if c.Archive.Enabled && c.Delete.UndoWindow == 0 {
+		return fmt.Errorf("COUPON_UNDO_WINDOW is required when COUPON_ARCHIVE_ENABLED is set")
+	}
+	if c.Archive.Enabled && !c.DB.postgresWire() {
+		return fmt.Errorf("COUPON_ARCHIVE_ENABLED requires a PostgreSQL wire-compatible DB_DRIVER (postgres or cockroachdb), got %q", c.DB.Driver)
+	}
+	if c.Archive.CacheMaxAge < time.Minute || c.Archive.CacheMaxAge > 8760*time.Hour {
+		return fmt.Errorf("COUPON_ARCHIVE_CACHE_MAX_AGE must be between 1m and 8760h, got %s", c.Archive.CacheMaxAge)
+	}
+
+	// Validate waiting for stock
+	if c.Wait.Enabled && c.DB.Driver != database.Postgres.Name {
+		return fmt.Errorf("STOCK_WAIT_ENABLED requires DB_DRIVER=postgres (LISTEN/NOTIFY), got %q", c.DB.Driver)
+	}
+	if c.Wait.Enabled && c.DB.PoolMode == PoolModeTransaction {
+		return fmt.Errorf("STOCK_WAIT_ENABLED requires DB_POOL_MODE=session (LISTEN needs a session of its own), got %q", c.DB.PoolMode)
+	}
+	if c.Wait.MaxTimeout < time.Second || c.Wait.MaxTimeout > 10*time.Minute {
+		return fmt.Errorf("STOCK_WAIT_MAX_TIMEOUT must be between 1s and 10m, got %s", c.Wait.MaxTimeout)
+	}
+	if c.Wait.MaxWaiters < 1 || c.Wait.MaxWaiters > 1000000 {
+		return fmt.Errorf("STOCK_WAIT_MAX_WAITERS must be between 1 and 1000000, got %d", c.Wait.MaxWaiters)
+	}
+
+	// Validate claim retention
+	if c.Retain.Enabled && !c.DB.couponFeatures() {
+		return fmt.Errorf("CLAIM_RETENTION_ENABLED requires DB_DRIVER postgres, cockroachdb or memory, got %q", c.DB.Driver)
+	}
+	if c.Retain.Interval < time.Minute || c.Retain.Interval > 24*time.Hour {
+		return fmt.Errorf("CLAIM_RETENTION_INTERVAL must be between 1m and 24h, got %s", c.Retain.Interval)
+	}
+
+	// Validate analytics export
+	switch c.Export.Store {
+	case "":
+	case ExportStoreLocal:
+		if c.Export.LocalDir == "" {
+			return fmt.Errorf("ANALYTICS_EXPORT_LOCAL_DIR is required when ANALYTICS_EXPORT_STORE is local")
+		}
+	case ExportStoreS3:
+		if c.Export.S3Bucket == "" || c.Export.S3Region == "" {
+			return fmt.Errorf("ANALYTICS_EXPORT_S3_BUCKET and ANALYTICS_EXPORT_S3_REGION are required when ANALYTICS_EXPORT_STORE is s3")
+		}
+		if c.Export.S3AccessKeyID == "" || c.Export.S3SecretAccessKey == "" {
+			return fmt.Errorf("ANALYTICS_EXPORT_S3_ACCESS_KEY_ID and ANALYTICS_EXPORT_S3_SECRET_ACCESS_KEY are required when ANALYTICS_EXPORT_STORE is s3")
+		}
+		if c.Export.S3Endpoint != "" {
+			u, err := url.Parse(c.Export.S3Endpoint)
+			if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("ANALYTICS_EXPORT_S3_ENDPOINT must be an http or https URL, got %q", c.Export.S3Endpoint)
+			}
+		}
+	default:
+		return fmt.Errorf("ANALYTICS_EXPORT_STORE must be one of: local, s3; got %q", c.Export.Store)
+	}
+	if c.Export.Store != "" && !c.DB.postgresWire() {
+		return fmt.Errorf("ANALYTICS_EXPORT_STORE requires a PostgreSQL wire-compatible DB_DRIVER (postgres or cockroachdb), got %q", c.DB.Driver)
+	}
+	if strings.HasPrefix(c.Export.Prefix, "/") || strings.HasSuffix(c.Export.Prefix, "/") {
+		return fmt.Errorf("ANALYTICS_EXPORT_PREFIX must not start or end with /, got %q", c.Export.Prefix)
+	}
+	if c.Export.Interval < time.Minute || c.Export.Interval > 24*time.Hour {
+		return fmt.Errorf("ANALYTICS_EXPORT_INTERVAL must be between 1m and 24h, got %s", c.Export.Interval)
+	}
+	if c.Export.LookbackDays < 1 || c.Export.LookbackDays > 90 {
+		return fmt.Errorf("ANALYTICS_EXPORT_LOOKBACK_DAYS must be between 1 and 90, got %d", c.Export.LookbackDays)
+	}
+
+	// Validate kill switch
+	if c.Kill.Message == "" || len(c.Kill.Message) > 255 {
+		return fmt.Errorf("KILL_SWITCH_MESSAGE must be between 1 and 255 characters, got %d", len(c.Kill.Message))
+	}
+	if c.Kill.RedisURL != "" {
+		if c.Kill.Engaged {
+			return fmt.Errorf("KILL_SWITCH_ENGAGED cannot be combined with KILL_SWITCH_REDIS_URL; engage it with PUT /api/admin/killswitch instead")
+		}
+		if c.Kill.RedisTimeout <= 0 {
+			return fmt.Errorf("KILL_SWITCH_REDIS_TIMEOUT must be positive, got %s", c.Kill.RedisTimeout)
+		}
+		if c.Kill.PollInterval < 100*time.Millisecond || c.Kill.PollInterval > time.Minute {
+			return fmt.Errorf("KILL_SWITCH_POLL_INTERVAL must be between 100ms and 1m, got %s", c.Kill.PollInterval)
+		}
+		if _, err := killswitch.NewRedisStore(c.Kill.RedisURL, c.Kill.RedisTimeout); err != nil {
+			return fmt.Errorf("KILL_SWITCH_REDIS_URL: %w", err)
+		}
+	}
+
+	// Validate the load test API
+	if c.Load.Enabled && !c.DB.postgresWire() {
+		return fmt.Errorf("LOADTEST_ENABLED requires a PostgreSQL wire-compatible DB_DRIVER (postgres or cockroachdb), got %q", c.DB.Driver)
+	}
+	if c.Load.CleanupInterval < time.Second || c.Load.CleanupInterval > time.Hour {
+		return fmt.Errorf("LOADTEST_CLEANUP_INTERVAL must be between 1s and 1h, got %s", c.Load.CleanupInterval)
+	}
+
+	// Validate the connection pool watchdog
+	if c.Watch.Interval != 0 && (c.Watch.Interval < time.Second || c.Watch.Interval > time.Minute) {
+		return fmt.Errorf("DB_POOL_WATCH_INTERVAL must be 0 (disabled) or between 1s and 1m, got %s", c.Watch.Interval)
+	}
+	if c.Runtime.MemoryLimitRatio < 0 || c.Runtime.MemoryLimitRatio > 1 {
+		return fmt.Errorf("MEMORY_LIMIT_RATIO must be between 0 and 1, got %g", c.Runtime.MemoryLimitRatio)
+	}
+	if c.Watch.SaturationWarn < 0 || c.Watch.SaturationWarn > 1 {
+		return fmt.Errorf("DB_POOL_SATURATION_WARN must be between 0 and 1, got %g", c.Watch.SaturationWarn)
+	}
+	if c.Watch.AcquireWaitWarn < 0 || c.Watch.AcquireWaitWarn > time.Minute {
+		return fmt.Errorf("DB_POOL_ACQUIRE_WAIT_P95_WARN must be between 0 and 1m, got %s", c.Watch.AcquireWaitWarn)
+	}
+
+	// Validate failover detection
+	if c.Failover.Interval != 0 && (c.Failover.Interval < time.Second || c.Failover.Interval > time.Minute) {
+		return fmt.Errorf("DB_FAILOVER_WATCH_INTERVAL must be 0 (disabled) or between 1s and 1m, got %s", c.Failover.Interval)
+	}
+	if c.Failover.Interval > 0 && c.DB.Driver == database.CockroachDB.Name {
+		return fmt.Errorf("DB_FAILOVER_WATCH_INTERVAL is not supported with DB_DRIVER cockroachdb")
+	}
+	if c.Failover.StatusHold < time.Minute || c.Failover.StatusHold > 24*time.Hour {
+		return fmt.Errorf("DB_FAILOVER_STATUS_HOLD must be between 1m and 24h, got %s", c.Failover.StatusHold)
+	}
+
+	// Validate heavy job scheduling
+	if _, err := jobs.ParseWindows(c.Heavy.Windows); err != nil {
+		return fmt.Errorf("HEAVY_JOB_WINDOWS is invalid: %w", err)
+	}
+	if c.Heavy.MaxReplicationLag < 0 {
+		return fmt.Errorf("HEAVY_JOB_MAX_REPLICATION_LAG must not be negative, got %s", c.Heavy.MaxReplicationLag)
+	}
+	if c.Heavy.MaxActiveConns < 0 {
+		return fmt.Errorf("HEAVY_JOB_MAX_ACTIVE_CONNS must not be negative, got %d", c.Heavy.MaxActiveConns)
+	}
+	if (c.Heavy.MaxReplicationLag > 0 || c.Heavy.MaxActiveConns > 0) && c.DB.Driver != database.Postgres.Name {
+		return fmt.Errorf("HEAVY_JOB_MAX_REPLICATION_LAG and HEAVY_JOB_MAX_ACTIVE_CONNS require DB_DRIVER=postgres, got %q", c.DB.Driver)
+	}
+
+	// Validate read hedging
+	if c.Hedge.MinDelay < time.Millisecond || c.Hedge.MinDelay > time.Second {
+		return fmt.Errorf("READ_HEDGE_MIN_DELAY must be between 1ms and 1s, got %s", c.Hedge.MinDelay)
+	}
+
+	// Validate claim import
+	if c.Import.ChunkSize < 1 || c.Import.ChunkSize > 10000 {
+		return fmt.Errorf("CLAIM_IMPORT_CHUNK_SIZE must be between 1 and 10000, got %d", c.Import.ChunkSize)
+	}
+
+	// Validate the claim budget (it must leave the claim route time to run)
+	if c.Budget.MinBudget < 0 || c.Budget.MinBudget > 10*time.Second {
+		return fmt.Errorf("CLAIM_MIN_BUDGET must be between 0 and 10s, got %s", c.Budget.MinBudget)
+	}
+	if timeout, _ := c.Server.Route("claim"); c.Budget.MinBudget > 0 && timeout > 0 && c.Budget.MinBudget >= timeout {
+		return fmt.Errorf("CLAIM_MIN_BUDGET (%s) must be less than the claim route timeout (%s)", c.Budget.MinBudget, timeout)
+	}
+
+	// Validate the adaptive claim limit
+	if c.Shed.Enabled {
+		if c.Shed.Min < 1 {
+			return fmt.Errorf("CLAIM_ADAPTIVE_LIMIT_MIN must be at least 1, got %d", c.Shed.Min)
+		}
+		if c.Shed.Max < c.Shed.Min {
+			return fmt.Errorf("CLAIM_ADAPTIVE_LIMIT_MAX must be at least CLAIM_ADAPTIVE_LIMIT_MIN (%d), got %d", c.Shed.Min, c.Shed.Max)
+		}
+		if c.Shed.Initial < c.Shed.Min || c.Shed.Initial > c.Shed.Max {
+			return fmt.Errorf("CLAIM_ADAPTIVE_LIMIT_INITIAL must be between CLAIM_ADAPTIVE_LIMIT_MIN and CLAIM_ADAPTIVE_LIMIT_MAX, got %d", c.Shed.Initial)
+		}
+		if c.Shed.Tolerance < 1 || c.Shed.Tolerance > 10 {
+			return fmt.Errorf("CLAIM_ADAPTIVE_LIMIT_TOLERANCE must be between 1 and 10, got %g", c.Shed.Tolerance)
+		}
+	}
+
+	// Validate claim pacing
+	if c.Pace.Rate < 0 || c.Pace.Rate > 100000 {
+		return fmt.Errorf("CLAIM_PACING_RATE must be between 0 and 100000, got %g", c.Pace.Rate)
+	}
+	if c.Pace.Rate > 0 {
+		if c.Pace.MaxQueue < 1 || c.Pace.MaxQueue > 100000 {
+			return fmt.Errorf("CLAIM_PACING_MAX_QUEUE must be between 1 and 100000, got %d", c.Pace.MaxQueue)
+		}
+		if c.Pace.RedisTimeout <= 0 {
+			return fmt.Errorf("CLAIM_PACING_REDIS_TIMEOUT must be positive, got %s", c.Pace.RedisTimeout)
+		}
+		if c.Pace.RedisURL != "" {
+			if _, err := pacing.NewRedisStore(c.Pace.RedisURL, c.Pace.RedisTimeout); err != nil {
+				return fmt.Errorf("CLAIM_PACING_REDIS_URL: %w", err)
+			}
+		}
+	}
+
+	// Validate API keys
+	if c.Keys.KeysFile != "" {
+		if c.Keys.StandardRate < 0 || c.Keys.StandardRate > 100000 {
+			return fmt.Errorf("API_KEY_STANDARD_RATE must be between 0 and 100000, got %g", c.Keys.StandardRate)
+		}
+		if c.Keys.PremiumRate < 0 || c.Keys.PremiumRate > 100000 {
+			return fmt.Errorf("API_KEY_PREMIUM_RATE must be between 0 and 100000, got %g", c.Keys.PremiumRate)
+		}
+		if c.Keys.PremiumReserve < 0 || c.Keys.PremiumReserve >= 1 {
+			return fmt.Errorf("API_KEY_PREMIUM_RESERVE must be at least 0 and less than 1, got %g", c.Keys.PremiumReserve)
+		}
+		if c.Pace.Rate > 0 && (c.Keys.PremiumMaxQueue < c.Pace.MaxQueue || c.Keys.PremiumMaxQueue > 100000) {
+			return fmt.Errorf("API_KEY_PREMIUM_MAX_QUEUE must be between CLAIM_PACING_MAX_QUEUE (%d) and 100000, got %d", c.Pace.MaxQueue, c.Keys.PremiumMaxQueue)
+		}
+	}
+	if c.Keys.UsageEnabled {
+		if c.Keys.KeysFile == "" {
+			return fmt.Errorf("API_KEYS_FILE is required when API_KEY_USAGE_ENABLED is set")
+		}
+		if !c.DB.couponFeatures() {
+			return fmt.Errorf("API_KEY_USAGE_ENABLED requires DB_DRIVER postgres, cockroachdb or memory, got %q", c.DB.Driver)
+		}
+		if c.Keys.UsageFlushInterval < time.Second || c.Keys.UsageFlushInterval > 5*time.Minute {
+			return fmt.Errorf("API_KEY_USAGE_FLUSH_INTERVAL must be between 1s and 5m, got %s", c.Keys.UsageFlushInterval)
+		}
+	}
+
+	// Validate the high-profile pool share
+	if c.Quota.PoolShare < 0 || c.Quota.PoolShare >= 1 {
+		return fmt.Errorf("COUPON_HIGH_PROFILE_POOL_SHARE must be at least 0 and less than 1, got %g", c.Quota.PoolShare)
+	}
+
+	// Validate the startup seed
+	if c.Seed.File != "" && c.DB.DegradedStart {
+		return fmt.Errorf("SEED_FILE cannot be combined with DB_DEGRADED_START; seeding needs the database at startup")
+	}
+
+	// Validate log redaction
+	switch redact.Mode(c.Log.Redact) {
+	case redact.ModeOff, redact.ModeTruncate:
+	case redact.ModeHash:
+		if len(c.Log.RedactKey) < redact.MinKeyLength {
+			return fmt.Errorf("LOG_REDACT_KEY must be at least %d bytes when LOG_REDACT is hash", redact.MinKeyLength)
+		}
+	default:
+		return fmt.Errorf("LOG_REDACT must be one of: off, hash, truncate; got %q", c.Log.Redact)
+	}
+
+	// Validate the access log sink
+	switch c.Access.Sink {
+	case accesslog.Stdout:
+	case accesslog.File:
+		if c.Access.File == "" {
+			return fmt.Errorf("ACCESS_LOG_FILE is required when ACCESS_LOG_SINK is file")
+		}
+		if c.Access.MaxSizeMB < 1 || c.Access.MaxSizeMB > 10240 {
+			return fmt.Errorf("ACCESS_LOG_MAX_SIZE_MB must be between 1 and 10240, got %d", c.Access.MaxSizeMB)
+		}
+		if c.Access.MaxBackups < 0 || c.Access.MaxBackups > 100 {
+			return fmt.Errorf("ACCESS_LOG_MAX_BACKUPS must be between 0 and 100, got %d", c.Access.MaxBackups)
+		}
+	case accesslog.Syslog:
+		switch c.Access.SyslogNetwork {
+		case "":
+			if c.Access.SyslogAddr != "" {
+				return fmt.Errorf("ACCESS_LOG_SYSLOG_NETWORK is required with ACCESS_LOG_SYSLOG_ADDR")
+			}
+		case "udp", "tcp":
+			if _, _, err := net.SplitHostPort(c.Access.SyslogAddr); err != nil {
+				return fmt.Errorf("ACCESS_LOG_SYSLOG_ADDR must be host:port, got %q", c.Access.SyslogAddr)
+			}
+		default:
+			return fmt.Errorf("ACCESS_LOG_SYSLOG_NETWORK must be one of: udp, tcp; got %q", c.Access.SyslogNetwork)
+		}
+	default:
+		return fmt.Errorf("ACCESS_LOG_SINK must be one of: stdout, file, syslog; got %q", c.Access.Sink)
+	}
+
+	// Validate the domain event log sink
+	switch c.Events.Sink {
+	case "", accesslog.Stdout:
+	case accesslog.File:
+		if c.Events.File == "" {
+			return fmt.Errorf("EVENT_LOG_FILE is required when EVENT_LOG_SINK is file")
+		}
+		if c.Events.MaxSizeMB < 1 || c.Events.MaxSizeMB > 10240 {
+			return fmt.Errorf("EVENT_LOG_MAX_SIZE_MB must be between 1 and 10240, got %d", c.Events.MaxSizeMB)
+		}
+		if c.Events.MaxBackups < 0 || c.Events.MaxBackups > 100 {
+			return fmt.Errorf("EVENT_LOG_MAX_BACKUPS must be between 0 and 100, got %d", c.Events.MaxBackups)
+		}
+	default:
+		return fmt.Errorf("EVENT_LOG_SINK must be one of: stdout, file; got %q", c.Events.Sink)
+	}
+
 	return nil
 }
@@ -2,15 +2,23 @@ package config
 
 import (
 	"testing"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/accesslog"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
 func TestLoad_CustomValues(t *testing.T) {
 	// Use t.Setenv which auto-restores after test
 	t.Setenv("SERVER_PORT", "8080")
 	t.Setenv("SHUTDOWN_TIMEOUT", "60")
+	t.Setenv("DB_DRIVER", "cockroachdb")
 	t.Setenv("DB_HOST", "db.example.com")
 	t.Setenv("DB_PORT", "5433")
 	t.Setenv("DB_USER", "myuser")
@@ -31,6 +39,7 @@ func TestLoad_CustomValues(t *testing.T) {
 	assert.Equal(t, 60, cfg.Server.ShutdownTimeout)
 
 	// DB custom values
+	assert.Equal(t, "cockroachdb", cfg.DB.Driver)
 	assert.Equal(t, "db.example.com", cfg.DB.Host)
 	assert.Equal(t, 5433, cfg.DB.Port)
 	assert.Equal(t, "myuser", cfg.DB.User)
@@ -60,6 +69,7 @@ func TestLoad_PartialOverride(t *testing.T) {
 
 	// Default values should still work
 	assert.Equal(t, 30, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, "postgres", cfg.DB.Driver)
 	assert.Equal(t, "localhost", cfg.DB.Host)
 	assert.Equal(t, 5432, cfg.DB.Port)
 	assert.Equal(t, "disable", cfg.DB.SSLMode)
@@ -105,6 +115,81 @@ func TestDBConfig_DSN_CustomPort(t *testing.T) {
 	assert.Contains(t, dsn, "pool_min_conns=10")
 }
 
+func TestDBConfig_MySQLDSN(t *testing.T) {
+	dbCfg := DBConfig{
+		Host:     "mysql.example.com",
+		Port:     3306,
+		User:     "coupon",
+		Password: "p@ss/word",
+		Name:     "coupon_db",
+		SSLMode:  "require",
+	}
+
+	dsn := dbCfg.MySQLDSN()
+	cfg, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "coupon", cfg.User)
+	assert.Equal(t, "p@ss/word", cfg.Passwd)
+	assert.Equal(t, "mysql.example.com:3306", cfg.Addr)
+	assert.Equal(t, "coupon_db", cfg.DBName)
+	assert.Equal(t, "skip-verify", cfg.TLSConfig)
+	assert.True(t, cfg.ParseTime, "repositories scan DATETIME into time.Time")
+	assert.True(t, cfg.ClientFoundRows, "UpdateTags relies on matched-row counts")
+	assert.Equal(t, "'+00:00'", cfg.Params["time_zone"])
+}
+
+func TestDBConfig_DSN_EscapesCredentials(t *testing.T) {
+	dbCfg := DBConfig{Host: "localhost", Port: 5432, User: "coupon", Password: "p@ss/word", Name: "coupon_db", SSLMode: "disable", MaxConns: 10}
+
+	cfg, err := pgxpool.ParseConfig(dbCfg.DSN())
+	require.NoError(t, err)
+	assert.Equal(t, "p@ss/word", cfg.ConnConfig.Password)
+	assert.Equal(t, "localhost", cfg.ConnConfig.Host)
+}
+
+func TestDBConfig_DSN_UnixSocket(t *testing.T) {
+	dbCfg := DBConfig{
+		Host: "/var/run/postgresql", Port: 6432, User: "coupon", Password: "secret", Name: "coupon_db",
+		SSLMode: "disable", MaxConns: 10, MinConns: 1,
+	}
+
+	cfg, err := pgxpool.ParseConfig(dbCfg.DSN())
+	require.NoError(t, err)
+	assert.Equal(t, "/var/run/postgresql", cfg.ConnConfig.Host)
+	assert.Equal(t, uint16(6432), cfg.ConnConfig.Port)
+	assert.Equal(t, "coupon_db", cfg.ConnConfig.Database)
+	assert.Equal(t, int32(10), cfg.MaxConns)
+}
+
+func TestDBConfig_DSN_PoolMode(t *testing.T) {
+	dbCfg := DBConfig{
+		Host: "pgbouncer", Port: 6432, User: "coupon", Name: "coupon_db", SSLMode: "disable",
+		MaxConns: 10, ReadMaxConns: 5,
+	}
+
+	cfg, err := pgxpool.ParseConfig(dbCfg.DSN())
+	require.NoError(t, err)
+	assert.Equal(t, pgx.QueryExecModeCacheStatement, cfg.ConnConfig.DefaultQueryExecMode)
+
+	dbCfg.PoolMode = PoolModeTransaction
+	for _, dsn := range []string{dbCfg.DSN(), dbCfg.ReadDSN()} {
+		cfg, err := pgxpool.ParseConfig(dsn)
+		require.NoError(t, err)
+		assert.Equal(t, pgx.QueryExecModeCacheDescribe, cfg.ConnConfig.DefaultQueryExecMode,
+			"no named prepared statements behind a transaction pooler")
+	}
+}
+
+func TestDBConfig_MySQLDSN_UnixSocket(t *testing.T) {
+	dbCfg := DBConfig{Host: "/var/run/mysqld/mysqld.sock", Port: 3306, User: "coupon", Name: "coupon_db", SSLMode: "require"}
+
+	cfg, err := mysql.ParseDSN(dbCfg.MySQLDSN())
+	require.NoError(t, err)
+	assert.Equal(t, "unix", cfg.Net)
+	assert.Equal(t, "/var/run/mysqld/mysqld.sock", cfg.Addr)
+	assert.Equal(t, "false", cfg.TLSConfig)
+}
+
 // TestConfig_Validate tests the validation logic for configuration.
 func TestConfig_Validate(t *testing.T) {
 	// Each subtest runs in isolation with t.Setenv auto-cleanup
@@ -158,6 +243,13 @@ func TestConfig_Validate(t *testing.T) {
 		assert.Contains(t, err.Error(), "DB_MIN_CONNS (10) cannot exceed DB_MAX_CONNS (5)")
 	})
 
+	t.Run("invalid_db_driver", func(t *testing.T) {
+		t.Setenv("DB_DRIVER", "oracle")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_DRIVER must be one of")
+	})
+
 	t.Run("invalid_ssl_mode", func(t *testing.T) {
 		t.Setenv("DB_SSLMODE", "invalid")
 		_, err := Load()
@@ -206,161 +298,1852 @@ func TestConfig_Validate(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "DB_NAME cannot be empty")
 	})
-}
 
-// TestConfig_Validate_ValidSSLModes tests all valid SSL modes.
-func TestConfig_Validate_ValidSSLModes(t *testing.T) {
-	validModes := []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	t.Run("invalid_cache_ttl_too_high", func(t *testing.T) {
+		t.Setenv("CACHE_COUPON_TTL", "2m")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CACHE_COUPON_TTL must be between 0 and 1m")
+	})
 
-	for _, mode := range validModes {
-		t.Run(mode, func(t *testing.T) {
-			t.Setenv("DB_SSLMODE", mode)
-			cfg, err := Load()
-			require.NoError(t, err)
-			assert.Equal(t, mode, cfg.DB.SSLMode)
-		})
-	}
-}
+	t.Run("invalid_cache_size_zero", func(t *testing.T) {
+		t.Setenv("CACHE_COUPON_SIZE", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CACHE_COUPON_SIZE must be at least 1")
+	})
 
-// TestLoad_DefaultValues verifies all default values when no environment variables are set.
-// This ensures the config layer works correctly with zero configuration.
-// Note: envconfig uses defaults when env vars are UNSET, not when set to empty string.
-func TestLoad_DefaultValues(t *testing.T) {
-	// The TestLoad_PartialOverride test already validates default behavior
-	// for unset variables. This test documents the expected default values
-	// by checking the struct tags directly.
-	//
-	// Default values per struct definition:
-	// - SERVER_PORT: "3000"
-	// - SHUTDOWN_TIMEOUT: 30
-	// - DB_HOST: "localhost"
-	// - DB_PORT: 5432
-	// - DB_USER: "postgres"
-	// - DB_PASSWORD: "postgres" (WARNING: Change in production!)
-	// - DB_NAME: "coupon_db"
-	// - DB_SSLMODE: "disable" (Use "require" in production!)
-	// - DB_MAX_CONNS: 25
-	// - DB_MIN_CONNS: 5
-	// - LOG_LEVEL: "info"
-	// - LOG_PRETTY: false
-	//
-	// Rather than unsetting env vars (which is complex in test isolation),
-	// we verify the behavior through TestLoad_PartialOverride which confirms
-	// defaults are used for unset variables.
+	t.Run("invalid_claim_dedup_window_too_high", func(t *testing.T) {
+		t.Setenv("CLAIM_DEDUP_WINDOW", "2m")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_DEDUP_WINDOW must be between 0 and 1m")
+	})
 
-	// Verify Load works and produces valid config
-	cfg, err := Load()
-	require.NoError(t, err)
-	require.NotNil(t, cfg)
+	t.Run("invalid_claim_dedup_size_zero", func(t *testing.T) {
+		t.Setenv("CLAIM_DEDUP_SIZE", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_DEDUP_SIZE must be at least 1")
+	})
 
-	// Verify struct is populated (may have overrides from other tests but validates loading works)
-	assert.NotEmpty(t, cfg.Server.Port, "Server port should be set")
-	assert.NotZero(t, cfg.Server.ShutdownTimeout, "Shutdown timeout should be set")
-	assert.NotEmpty(t, cfg.DB.Host, "DB host should be set")
-	assert.NotZero(t, cfg.DB.Port, "DB port should be set")
-	assert.NotEmpty(t, cfg.Log.Level, "Log level should be set")
-}
+	t.Run("invalid_claim_anti_replay_window_too_high", func(t *testing.T) {
+		t.Setenv("CLAIM_ANTI_REPLAY_WINDOW", "11m")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_ANTI_REPLAY_WINDOW must be between 0 and 10m")
+	})
 
-// TestConfig_WarnIfDefaultCredentials tests the security warning function.
-func TestConfig_WarnIfDefaultCredentials(t *testing.T) {
-	t.Run("all_defaults_returns_all_warnings", func(t *testing.T) {
-		cfg := &Config{
-			DB: DBConfig{
-				User:     "postgres",
-				Password: "postgres",
-				SSLMode:  "disable",
-			},
-		}
+	t.Run("invalid_claim_anti_replay_size_zero", func(t *testing.T) {
+		t.Setenv("CLAIM_ANTI_REPLAY_SIZE", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_ANTI_REPLAY_SIZE must be at least 1")
+	})
 
-		warnings := cfg.WarnIfDefaultCredentials()
-		assert.Len(t, warnings, 3, "Should return 3 warnings for all defaults")
-		assert.Contains(t, warnings[0], "DB_PASSWORD")
-		assert.Contains(t, warnings[1], "DB_USER")
-		assert.Contains(t, warnings[2], "DB_SSLMODE")
+	t.Run("invalid_claim_record_size_too_high", func(t *testing.T) {
+		t.Setenv("CLAIM_RECORD_SIZE", "1001")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_RECORD_SIZE must be between 0 and 1000")
 	})
 
-	t.Run("custom_password_only", func(t *testing.T) {
-		cfg := &Config{
-			DB: DBConfig{
-				User:     "postgres",
-				Password: "secure_password_123",
-				SSLMode:  "disable",
-			},
-		}
+	t.Run("invalid_claim_filter_capacity_negative", func(t *testing.T) {
+		t.Setenv("CLAIM_FILTER_CAPACITY", "-1")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_FILTER_CAPACITY must be between 0 and 100000000")
+	})
 
-		warnings := cfg.WarnIfDefaultCredentials()
-		assert.Len(t, warnings, 2, "Should return 2 warnings (user and SSL)")
+	t.Run("invalid_claim_filter_fp_rate", func(t *testing.T) {
+		t.Setenv("CLAIM_FILTER_FP_RATE", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_FILTER_FP_RATE must be greater than 0 and at most 0.5")
+	})
 
-		// Should not contain password warning
-		for _, w := range warnings {
-			assert.NotContains(t, w, "DB_PASSWORD")
-		}
+	t.Run("invalid_coupon_name_filter_interval", func(t *testing.T) {
+		t.Setenv("COUPON_NAME_FILTER_INTERVAL", "100ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_NAME_FILTER_INTERVAL must be 0 or between 1s and 10m")
 	})
 
-	t.Run("custom_user_only", func(t *testing.T) {
-		cfg := &Config{
-			DB: DBConfig{
-				User:     "custom_user",
-				Password: "postgres",
-				SSLMode:  "disable",
-			},
-		}
+	t.Run("invalid_coupon_name_filter_capacity", func(t *testing.T) {
+		t.Setenv("COUPON_NAME_FILTER_CAPACITY", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_NAME_FILTER_CAPACITY must be between 1 and 10000000")
+	})
 
-		warnings := cfg.WarnIfDefaultCredentials()
-		assert.Len(t, warnings, 2, "Should return 2 warnings (password and SSL)")
+	t.Run("invalid_coupon_name_filter_fp_rate", func(t *testing.T) {
+		t.Setenv("COUPON_NAME_FILTER_FP_RATE", "0.9")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_NAME_FILTER_FP_RATE must be greater than 0 and at most 0.5")
+	})
 
-		// Should not contain user warning
-		for _, w := range warnings {
-			assert.NotContains(t, w, "DB_USER")
-		}
+	t.Run("invalid_enum_guard_min_latency", func(t *testing.T) {
+		t.Setenv("ENUM_GUARD_MIN_LATENCY", "2s")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ENUM_GUARD_MIN_LATENCY must be between 0 and 1s")
 	})
 
-	t.Run("ssl_mode_require", func(t *testing.T) {
-		cfg := &Config{
-			DB: DBConfig{
-				User:     "postgres",
-				Password: "postgres",
-				SSLMode:  "require",
-			},
-		}
+	t.Run("invalid_enum_guard_threshold", func(t *testing.T) {
+		t.Setenv("ENUM_GUARD_THRESHOLD", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ENUM_GUARD_THRESHOLD must be at least 1")
+	})
 
-		warnings := cfg.WarnIfDefaultCredentials()
-		assert.Len(t, warnings, 2, "Should return 2 warnings (password and user)")
+	t.Run("invalid_enum_guard_max_block_below_block", func(t *testing.T) {
+		t.Setenv("ENUM_GUARD_BLOCK", "10m")
+		t.Setenv("ENUM_GUARD_MAX_BLOCK", "5m")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ENUM_GUARD_MAX_BLOCK must be at least ENUM_GUARD_BLOCK")
+	})
 
-		// Should not contain SSL warning
-		for _, w := range warnings {
-			assert.NotContains(t, w, "DB_SSLMODE")
-		}
+	t.Run("invalid_claim_shadow_strategy", func(t *testing.T) {
+		t.Setenv("CLAIM_SHADOW_STRATEGY", "sharded")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_SHADOW_STRATEGY must be empty or optimistic")
 	})
 
-	t.Run("all_custom_returns_empty", func(t *testing.T) {
-		cfg := &Config{
-			DB: DBConfig{
-				User:     "production_user",
-				Password: "super_secure_password",
-				SSLMode:  "verify-full",
-			},
-		}
+	t.Run("invalid_claim_shadow_sample_rate", func(t *testing.T) {
+		t.Setenv("CLAIM_SHADOW_SAMPLE_RATE", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_SHADOW_SAMPLE_RATE must be greater than 0 and at most 1")
+	})
 
-		warnings := cfg.WarnIfDefaultCredentials()
-		assert.Empty(t, warnings, "Should return no warnings when all values are custom")
+	t.Run("invalid_claim_shadow_max_in_flight", func(t *testing.T) {
+		t.Setenv("CLAIM_SHADOW_MAX_IN_FLIGHT", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_SHADOW_MAX_IN_FLIGHT must be between 1 and 1024")
 	})
 
-	t.Run("verify_ca_ssl_mode_no_warning", func(t *testing.T) {
-		cfg := &Config{
-			DB: DBConfig{
-				User:     "postgres",
-				Password: "postgres",
-				SSLMode:  "verify-ca",
-			},
-		}
+	t.Run("invalid_captcha_provider", func(t *testing.T) {
+		t.Setenv("CAPTCHA_PROVIDER", "recaptcha")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CAPTCHA_PROVIDER must be empty, hcaptcha or turnstile")
+	})
 
-		warnings := cfg.WarnIfDefaultCredentials()
-		assert.Len(t, warnings, 2, "Should return 2 warnings (only user and password)")
+	t.Run("invalid_captcha_missing_secret", func(t *testing.T) {
+		t.Setenv("CAPTCHA_PROVIDER", "turnstile")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CAPTCHA_SECRET is required when CAPTCHA_PROVIDER is set")
+	})
 
-		// Should not contain SSL warning
-		for _, w := range warnings {
-			assert.NotContains(t, w, "DB_SSLMODE")
-		}
+	t.Run("invalid_captcha_timeout", func(t *testing.T) {
+		t.Setenv("CAPTCHA_TIMEOUT", "1m")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CAPTCHA_TIMEOUT must be between 100ms and 10s")
+	})
+
+	t.Run("invalid_claimed_by_mode", func(t *testing.T) {
+		t.Setenv("COUPON_CLAIMED_BY", "hidden")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_CLAIMED_BY must be one of: full, hash, omit")
+	})
+
+	t.Run("invalid_claimed_by_hash_key", func(t *testing.T) {
+		t.Setenv("COUPON_CLAIMED_BY", "hash")
+		t.Setenv("COUPON_CLAIMED_BY_HASH_KEY", "short")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_CLAIMED_BY_HASH_KEY must be at least 16 bytes")
+	})
+
+	t.Run("invalid_claimed_by_max", func(t *testing.T) {
+		t.Setenv("COUPON_CLAIMED_BY_MAX", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_CLAIMED_BY_MAX must be between 1 and 1000000")
+	})
+
+	t.Run("invalid_claim_grant_secret", func(t *testing.T) {
+		t.Setenv("CLAIM_GRANT_SECRET", "short")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_GRANT_SECRET must be at least 32 bytes")
+	})
+
+	t.Run("invalid_webhooks_on_mysql", func(t *testing.T) {
+		t.Setenv("WEBHOOKS_ENABLED", "true")
+		t.Setenv("DB_DRIVER", "mysql")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "WEBHOOKS_ENABLED requires a DB_DRIVER with a job queue")
+	})
+
+	t.Run("invalid_webhooks_on_memory", func(t *testing.T) {
+		t.Setenv("WEBHOOKS_ENABLED", "true")
+		t.Setenv("DB_DRIVER", "memory")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "WEBHOOKS_ENABLED requires a DB_DRIVER with a job queue")
+	})
+
+	t.Run("invalid_webhook_timeout", func(t *testing.T) {
+		t.Setenv("WEBHOOK_TIMEOUT", "10ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "WEBHOOK_TIMEOUT must be between 100ms and 1m")
+	})
+
+	t.Run("invalid_leaderboard_on_mysql", func(t *testing.T) {
+		t.Setenv("LEADERBOARD_REFRESH_INTERVAL", "30s")
+		t.Setenv("DB_DRIVER", "mysql")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "LEADERBOARD_REFRESH_INTERVAL requires a PostgreSQL wire-compatible DB_DRIVER")
 	})
+
+	t.Run("invalid_leaderboard_interval", func(t *testing.T) {
+		t.Setenv("LEADERBOARD_REFRESH_INTERVAL", "2h")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "LEADERBOARD_REFRESH_INTERVAL must be 0 or between 1s and 1h")
+	})
+
+	t.Run("invalid_campaign_caps_on_mysql", func(t *testing.T) {
+		t.Setenv("CAMPAIGN_CAPS_ENABLED", "true")
+		t.Setenv("DB_DRIVER", "mysql")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CAMPAIGN_CAPS_ENABLED requires DB_DRIVER postgres, cockroachdb or memory")
+	})
+
+	t.Run("invalid_coupon_allowlists_on_mysql", func(t *testing.T) {
+		t.Setenv("COUPON_ALLOWLISTS_ENABLED", "true")
+		t.Setenv("DB_DRIVER", "mysql")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_ALLOWLISTS_ENABLED requires DB_DRIVER postgres, cockroachdb or memory")
+	})
+
+	t.Run("invalid_coupon_deletion_on_mysql", func(t *testing.T) {
+		t.Setenv("COUPON_UNDO_WINDOW", "24h")
+		t.Setenv("DB_DRIVER", "mysql")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_UNDO_WINDOW requires DB_DRIVER postgres, cockroachdb or memory")
+	})
+
+	t.Run("valid_coupon_features_on_memory", func(t *testing.T) {
+		t.Setenv("DB_DRIVER", "memory")
+		t.Setenv("CAMPAIGN_CAPS_ENABLED", "true")
+		t.Setenv("COUPON_ALLOWLISTS_ENABLED", "true")
+		t.Setenv("COUPON_UNDO_WINDOW", "24h")
+		t.Setenv("CLAIM_RETENTION_ENABLED", "true")
+		_, err := Load()
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid_coupon_undo_window", func(t *testing.T) {
+		t.Setenv("COUPON_UNDO_WINDOW", "30s")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_UNDO_WINDOW must be 0 or between 1m and 720h")
+	})
+
+	t.Run("invalid_coupon_purge_interval", func(t *testing.T) {
+		t.Setenv("COUPON_PURGE_INTERVAL", "2h")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "COUPON_PURGE_INTERVAL must be between 1s and 1h")
+	})
+
+	t.Run("invalid_stock_wait_driver", func(t *testing.T) {
+		t.Setenv("STOCK_WAIT_ENABLED", "true")
+		t.Setenv("DB_DRIVER", "cockroachdb")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "STOCK_WAIT_ENABLED requires DB_DRIVER=postgres")
+	})
+
+	t.Run("invalid_stock_wait_pool_mode", func(t *testing.T) {
+		t.Setenv("STOCK_WAIT_ENABLED", "true")
+		t.Setenv("DB_POOL_MODE", "transaction")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "STOCK_WAIT_ENABLED requires DB_POOL_MODE=session")
+	})
+
+	t.Run("invalid_stock_wait_max_timeout", func(t *testing.T) {
+		t.Setenv("STOCK_WAIT_MAX_TIMEOUT", "500ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "STOCK_WAIT_MAX_TIMEOUT must be between 1s and 10m")
+	})
+
+	t.Run("invalid_stock_wait_max_waiters", func(t *testing.T) {
+		t.Setenv("STOCK_WAIT_MAX_WAITERS", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "STOCK_WAIT_MAX_WAITERS must be between 1 and 1000000")
+	})
+
+	t.Run("invalid_claim_retention_driver", func(t *testing.T) {
+		t.Setenv("CLAIM_RETENTION_ENABLED", "true")
+		t.Setenv("DB_DRIVER", "mysql")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_RETENTION_ENABLED requires DB_DRIVER postgres, cockroachdb or memory")
+	})
+
+	t.Run("invalid_loadtest_driver", func(t *testing.T) {
+		t.Setenv("LOADTEST_ENABLED", "true")
+		t.Setenv("DB_DRIVER", "mysql")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "LOADTEST_ENABLED requires a PostgreSQL wire-compatible DB_DRIVER")
+	})
+
+	t.Run("invalid_loadtest_cleanup_interval", func(t *testing.T) {
+		t.Setenv("LOADTEST_CLEANUP_INTERVAL", "2h")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "LOADTEST_CLEANUP_INTERVAL must be between 1s and 1h")
+	})
+
+	t.Run("invalid_pool_watch_interval", func(t *testing.T) {
+		t.Setenv("DB_POOL_WATCH_INTERVAL", "500ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_POOL_WATCH_INTERVAL must be 0 (disabled) or between 1s and 1m")
+	})
+
+	t.Run("invalid_delivery_workers", func(t *testing.T) {
+		t.Setenv("DELIVERY_WORKERS", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DELIVERY_WORKERS must be between 1 and 256")
+	})
+
+	t.Run("invalid_delivery_overflow", func(t *testing.T) {
+		t.Setenv("DELIVERY_OVERFLOW", "drop")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DELIVERY_OVERFLOW must be one of: block, reject")
+	})
+
+	t.Run("invalid_memory_limit_ratio", func(t *testing.T) {
+		t.Setenv("MEMORY_LIMIT_RATIO", "1.2")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "MEMORY_LIMIT_RATIO must be between 0 and 1")
+	})
+
+	t.Run("invalid_pool_saturation_warn", func(t *testing.T) {
+		t.Setenv("DB_POOL_SATURATION_WARN", "1.5")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_POOL_SATURATION_WARN must be between 0 and 1")
+	})
+
+	t.Run("invalid_claim_retention_interval", func(t *testing.T) {
+		t.Setenv("CLAIM_RETENTION_INTERVAL", "30s")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_RETENTION_INTERVAL must be between 1m and 24h")
+	})
+
+	t.Run("invalid_analytics_export_store", func(t *testing.T) {
+		t.Setenv("ANALYTICS_EXPORT_STORE", "ftp")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ANALYTICS_EXPORT_STORE must be one of: local, s3")
+	})
+
+	t.Run("invalid_analytics_export_local_dir", func(t *testing.T) {
+		t.Setenv("ANALYTICS_EXPORT_STORE", "local")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ANALYTICS_EXPORT_LOCAL_DIR is required")
+	})
+
+	t.Run("invalid_analytics_export_s3_credentials", func(t *testing.T) {
+		t.Setenv("ANALYTICS_EXPORT_STORE", "s3")
+		t.Setenv("ANALYTICS_EXPORT_S3_BUCKET", "analytics")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ANALYTICS_EXPORT_S3_ACCESS_KEY_ID and ANALYTICS_EXPORT_S3_SECRET_ACCESS_KEY are required")
+	})
+
+	t.Run("invalid_analytics_export_s3_endpoint", func(t *testing.T) {
+		t.Setenv("ANALYTICS_EXPORT_STORE", "s3")
+		t.Setenv("ANALYTICS_EXPORT_S3_BUCKET", "analytics")
+		t.Setenv("ANALYTICS_EXPORT_S3_ACCESS_KEY_ID", "key")
+		t.Setenv("ANALYTICS_EXPORT_S3_SECRET_ACCESS_KEY", "secret")
+		t.Setenv("ANALYTICS_EXPORT_S3_ENDPOINT", "minio:9000")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ANALYTICS_EXPORT_S3_ENDPOINT must be an http or https URL")
+	})
+
+	t.Run("invalid_analytics_export_driver", func(t *testing.T) {
+		t.Setenv("ANALYTICS_EXPORT_STORE", "local")
+		t.Setenv("ANALYTICS_EXPORT_LOCAL_DIR", "/var/lib/exports")
+		t.Setenv("DB_DRIVER", "mysql")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ANALYTICS_EXPORT_STORE requires a PostgreSQL wire-compatible DB_DRIVER")
+	})
+
+	t.Run("invalid_analytics_export_prefix", func(t *testing.T) {
+		t.Setenv("ANALYTICS_EXPORT_PREFIX", "production/")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ANALYTICS_EXPORT_PREFIX must not start or end with /")
+	})
+
+	t.Run("invalid_analytics_export_interval", func(t *testing.T) {
+		t.Setenv("ANALYTICS_EXPORT_INTERVAL", "30s")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ANALYTICS_EXPORT_INTERVAL must be between 1m and 24h")
+	})
+
+	t.Run("invalid_analytics_export_lookback_days", func(t *testing.T) {
+		t.Setenv("ANALYTICS_EXPORT_LOOKBACK_DAYS", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ANALYTICS_EXPORT_LOOKBACK_DAYS must be between 1 and 90")
+	})
+
+	t.Run("invalid_kill_switch_engaged_with_redis", func(t *testing.T) {
+		t.Setenv("KILL_SWITCH_ENGAGED", "true")
+		t.Setenv("KILL_SWITCH_REDIS_URL", "redis://localhost:6379")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "KILL_SWITCH_ENGAGED cannot be combined with KILL_SWITCH_REDIS_URL")
+	})
+
+	t.Run("invalid_kill_switch_redis_url", func(t *testing.T) {
+		t.Setenv("KILL_SWITCH_REDIS_URL", "http://localhost:6379")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "KILL_SWITCH_REDIS_URL")
+	})
+
+	t.Run("invalid_kill_switch_poll_interval", func(t *testing.T) {
+		t.Setenv("KILL_SWITCH_REDIS_URL", "redis://localhost:6379")
+		t.Setenv("KILL_SWITCH_POLL_INTERVAL", "10ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "KILL_SWITCH_POLL_INTERVAL must be between 100ms and 1m")
+	})
+
+	t.Run("invalid_server_read_timeout", func(t *testing.T) {
+		t.Setenv("SERVER_READ_TIMEOUT", "500ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_READ_TIMEOUT must be between 1s and 10m")
+	})
+
+	t.Run("invalid_server_body_limit", func(t *testing.T) {
+		t.Setenv("SERVER_BODY_LIMIT", "100")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_BODY_LIMIT must be between 1024 and 67108864 bytes")
+	})
+
+	t.Run("invalid_server_route_timeouts_unknown_route", func(t *testing.T) {
+		t.Setenv("SERVER_ROUTE_TIMEOUTS", "claims_import:1m")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `SERVER_ROUTE_TIMEOUTS has unknown route "claims_import"`)
+	})
+
+	t.Run("invalid_server_route_timeouts_range", func(t *testing.T) {
+		t.Setenv("SERVER_ROUTE_TIMEOUTS", "claim:1h")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_ROUTE_TIMEOUTS for claim must be between 100ms and 10m")
+	})
+
+	t.Run("invalid_server_max_request_deadline", func(t *testing.T) {
+		t.Setenv("SERVER_MAX_REQUEST_DEADLINE", "1h")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_MAX_REQUEST_DEADLINE must be 0 (disabled) or between 100ms and 10m")
+	})
+
+	t.Run("invalid_server_max_page_size_too_high", func(t *testing.T) {
+		t.Setenv("SERVER_MAX_PAGE_SIZE", "10000000")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_MAX_PAGE_SIZE must be between 1 and 10000")
+	})
+
+	t.Run("invalid_server_default_page_size_above_max", func(t *testing.T) {
+		t.Setenv("SERVER_DEFAULT_PAGE_SIZE", "500")
+		t.Setenv("SERVER_MAX_PAGE_SIZE", "200")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_DEFAULT_PAGE_SIZE must be between 1 and SERVER_MAX_PAGE_SIZE (200)")
+	})
+
+	t.Run("invalid_server_default_page_size_zero", func(t *testing.T) {
+		t.Setenv("SERVER_DEFAULT_PAGE_SIZE", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_DEFAULT_PAGE_SIZE must be between 1 and SERVER_MAX_PAGE_SIZE")
+	})
+
+	t.Run("invalid_server_route_body_limits", func(t *testing.T) {
+		t.Setenv("SERVER_ROUTE_BODY_LIMITS", "import:128MB")
+		_, err := Load()
+		require.Error(t, err)
+	})
+
+	t.Run("invalid_server_route_body_limits_range", func(t *testing.T) {
+		t.Setenv("SERVER_ROUTE_BODY_LIMITS", "import:134217728")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_ROUTE_BODY_LIMITS for import must be between 1024 and 67108864 bytes")
+	})
+
+	t.Run("invalid_read_hedge_min_delay", func(t *testing.T) {
+		t.Setenv("READ_HEDGE_MIN_DELAY", "0s")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "READ_HEDGE_MIN_DELAY must be between 1ms and 1s")
+	})
+
+	t.Run("invalid_migration_phase", func(t *testing.T) {
+		t.Setenv("DB_MIGRATION_PHASES", "claims.claimed_at:halfway")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `DB_MIGRATION_PHASES is invalid: claims.claimed_at: unknown migration phase "halfway"`)
+	})
+
+	t.Run("invalid_migration_rename", func(t *testing.T) {
+		t.Setenv("DB_MIGRATION_PHASES", "coupons.quantity:new")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown column rename "coupons.quantity"`)
+	})
+
+	t.Run("invalid_table_move_phase", func(t *testing.T) {
+		t.Setenv("DB_MIGRATION_PHASES", "claims_v2:halfway")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `DB_MIGRATION_PHASES is invalid: claims_v2: unknown migration phase "halfway"`)
+	})
+
+	t.Run("invalid_table_move_driver", func(t *testing.T) {
+		for _, driver := range []string{"mysql", "cockroachdb"} {
+			t.Setenv("DB_DRIVER", driver)
+			t.Setenv("DB_MIGRATION_PHASES", "claims_v2:dual_write")
+			_, err := Load()
+			require.Error(t, err, driver)
+			assert.Contains(t, err.Error(), "claims_v2 requires DB_DRIVER=postgres", driver)
+		}
+	})
+
+	t.Run("invalid_query_timeout_query", func(t *testing.T) {
+		t.Setenv("DB_QUERY_TIMEOUTS", "list_coupons:1s")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `DB_QUERY_TIMEOUTS has unknown query "list_coupons"`)
+	})
+
+	t.Run("invalid_query_timeout", func(t *testing.T) {
+		t.Setenv("DB_QUERY_TIMEOUTS", "lock_coupon:1ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_QUERY_TIMEOUTS for lock_coupon must be between 10ms and 1m")
+	})
+
+	t.Run("invalid_read_min_conns", func(t *testing.T) {
+		t.Setenv("DB_READ_MAX_CONNS", "4")
+		t.Setenv("DB_READ_MIN_CONNS", "5")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_READ_MIN_CONNS must be between 0 and DB_READ_MAX_CONNS (4), got 5")
+	})
+
+	t.Run("read_pool_mysql", func(t *testing.T) {
+		t.Setenv("DB_DRIVER", "mysql")
+		t.Setenv("DB_READ_MAX_CONNS", "10")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_READ_MAX_CONNS requires a PostgreSQL wire-compatible DB_DRIVER")
+	})
+
+	t.Run("read_retry_mysql", func(t *testing.T) {
+		t.Setenv("DB_DRIVER", "mysql")
+		t.Setenv("DB_READ_RETRY_ENABLED", "true")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_READ_RETRY_ENABLED requires a PostgreSQL wire-compatible DB_DRIVER")
+	})
+
+	t.Run("invalid_coupon_lock_policy", func(t *testing.T) {
+		t.Setenv("DB_COUPON_LOCK_POLICY", "skip_locked")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `DB_COUPON_LOCK_POLICY must be one of: wait, nowait, timeout; got "skip_locked"`)
+	})
+
+	t.Run("invalid_coupon_lock_timeout", func(t *testing.T) {
+		t.Setenv("DB_COUPON_LOCK_POLICY", "timeout")
+		t.Setenv("DB_COUPON_LOCK_TIMEOUT", "30s")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_COUPON_LOCK_TIMEOUT must be between 1ms and 10s")
+	})
+
+	t.Run("coupon_lock_timeout_mysql", func(t *testing.T) {
+		t.Setenv("DB_DRIVER", "mysql")
+		t.Setenv("DB_COUPON_LOCK_POLICY", "timeout")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_COUPON_LOCK_POLICY=timeout requires a PostgreSQL wire-compatible DB_DRIVER")
+	})
+
+	t.Run("invalid_lock_diagnostics_threshold", func(t *testing.T) {
+		t.Setenv("DB_LOCK_DIAGNOSTICS_THRESHOLD", "1ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_LOCK_DIAGNOSTICS_THRESHOLD must be 0 (disabled) or between 10ms and 1m")
+	})
+
+	t.Run("lock_diagnostics_cockroachdb", func(t *testing.T) {
+		t.Setenv("DB_DRIVER", "cockroachdb")
+		t.Setenv("DB_LOCK_DIAGNOSTICS_THRESHOLD", "500ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_LOCK_DIAGNOSTICS_THRESHOLD requires DB_DRIVER=postgres")
+	})
+
+	t.Run("lock_diagnostics_transaction_pooling", func(t *testing.T) {
+		t.Setenv("DB_POOL_MODE", "transaction")
+		t.Setenv("DB_LOCK_DIAGNOSTICS_THRESHOLD", "500ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_LOCK_DIAGNOSTICS_THRESHOLD requires DB_POOL_MODE=session")
+	})
+
+	t.Run("lock_diagnostics_beyond_lock_timeout", func(t *testing.T) {
+		t.Setenv("DB_COUPON_LOCK_POLICY", "timeout")
+		t.Setenv("DB_COUPON_LOCK_TIMEOUT", "200ms")
+		t.Setenv("DB_LOCK_DIAGNOSTICS_THRESHOLD", "500ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_LOCK_DIAGNOSTICS_THRESHOLD must be below how long claims wait for a coupon lock")
+	})
+
+	t.Run("invalid_lock_diagnostics_size", func(t *testing.T) {
+		t.Setenv("DB_LOCK_DIAGNOSTICS_THRESHOLD", "500ms")
+		t.Setenv("DB_LOCK_DIAGNOSTICS_SIZE", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_LOCK_DIAGNOSTICS_SIZE must be between 1 and 1000")
+	})
+
+	t.Run("invalid_claim_min_budget", func(t *testing.T) {
+		t.Setenv("CLAIM_MIN_BUDGET", "-1ms")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_MIN_BUDGET must be between 0 and 10s")
+	})
+
+	t.Run("claim_min_budget_exceeds_route_timeout", func(t *testing.T) {
+		t.Setenv("CLAIM_MIN_BUDGET", "2s")
+		t.Setenv("SERVER_ROUTE_TIMEOUTS", "claim:1s")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_MIN_BUDGET (2s) must be less than the claim route timeout (1s)")
+	})
+
+	t.Run("invalid_claim_import_chunk_size", func(t *testing.T) {
+		t.Setenv("CLAIM_IMPORT_CHUNK_SIZE", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_IMPORT_CHUNK_SIZE must be between 1 and 10000")
+	})
+
+	t.Run("invalid_claim_buffer_max_entries", func(t *testing.T) {
+		t.Setenv("CLAIM_BUFFER_MAX_ENTRIES", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_BUFFER_MAX_ENTRIES must be at least 1")
+	})
+
+	t.Run("invalid_claim_buffer_max_age", func(t *testing.T) {
+		t.Setenv("CLAIM_BUFFER_MAX_AGE", "0s")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAIM_BUFFER_MAX_AGE must be positive")
+	})
+
+	t.Run("invalid_log_redact", func(t *testing.T) {
+		t.Setenv("LOG_REDACT", "mask")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "LOG_REDACT must be one of: off, hash, truncate")
+	})
+
+	t.Run("log_redact_hash_without_key", func(t *testing.T) {
+		t.Setenv("LOG_REDACT", "hash")
+		t.Setenv("LOG_REDACT_KEY", "short")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "LOG_REDACT_KEY must be at least 16 bytes")
+	})
+
+	t.Run("invalid_access_log_sink", func(t *testing.T) {
+		t.Setenv("ACCESS_LOG_SINK", "kafka")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `ACCESS_LOG_SINK must be one of: stdout, file, syslog; got "kafka"`)
+	})
+
+	t.Run("access_log_file_without_path", func(t *testing.T) {
+		t.Setenv("ACCESS_LOG_SINK", "file")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ACCESS_LOG_FILE is required when ACCESS_LOG_SINK is file")
+	})
+
+	t.Run("invalid_access_log_max_size", func(t *testing.T) {
+		t.Setenv("ACCESS_LOG_SINK", "file")
+		t.Setenv("ACCESS_LOG_FILE", "/var/log/coupon/access.log")
+		t.Setenv("ACCESS_LOG_MAX_SIZE_MB", "0")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ACCESS_LOG_MAX_SIZE_MB must be between 1 and 10240, got 0")
+	})
+
+	t.Run("invalid_access_log_syslog_network", func(t *testing.T) {
+		t.Setenv("ACCESS_LOG_SINK", "syslog")
+		t.Setenv("ACCESS_LOG_SYSLOG_NETWORK", "unix")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `ACCESS_LOG_SYSLOG_NETWORK must be one of: udp, tcp; got "unix"`)
+	})
+
+	t.Run("invalid_access_log_syslog_addr", func(t *testing.T) {
+		t.Setenv("ACCESS_LOG_SINK", "syslog")
+		t.Setenv("ACCESS_LOG_SYSLOG_NETWORK", "udp")
+		t.Setenv("ACCESS_LOG_SYSLOG_ADDR", "syslog")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `ACCESS_LOG_SYSLOG_ADDR must be host:port, got "syslog"`)
+	})
+
+	t.Run("invalid_event_log_sink", func(t *testing.T) {
+		t.Setenv("EVENT_LOG_SINK", "syslog")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `EVENT_LOG_SINK must be one of: stdout, file; got "syslog"`)
+	})
+
+	t.Run("missing_event_log_file", func(t *testing.T) {
+		t.Setenv("EVENT_LOG_SINK", "file")
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "EVENT_LOG_FILE is required when EVENT_LOG_SINK is file")
+	})
+}
+
+// TestLoad_Cache verifies cache settings are loaded and disabled by default.
+func TestLoad_Cache(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Cache.CouponTTL)
+	assert.Equal(t, 1024, cfg.Cache.CouponSize)
+
+	t.Setenv("CACHE_COUPON_TTL", "200ms")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 200*time.Millisecond, cfg.Cache.CouponTTL)
+}
+
+// TestLoad_ClaimBuffer verifies store-and-forward settings are loaded and disabled by default.
+func TestLoad_ClaimBuffer(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Buffer.Path)
+	assert.Equal(t, 10000, cfg.Buffer.MaxEntries)
+	assert.Equal(t, 5*time.Minute, cfg.Buffer.MaxAge)
+	assert.Equal(t, time.Second, cfg.Buffer.ReplayInterval)
+
+	t.Setenv("CLAIM_BUFFER_PATH", "/var/lib/coupon/claims.log")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/var/lib/coupon/claims.log", cfg.Buffer.Path)
+}
+
+// TestLoad_ClaimDedup verifies claim deduplication settings are loaded and disabled by default.
+func TestLoad_ClaimDedup(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Dedup.Window)
+	assert.Equal(t, 10000, cfg.Dedup.Size)
+
+	t.Setenv("CLAIM_DEDUP_WINDOW", "5s")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, cfg.Dedup.Window)
+}
+
+// TestLoad_ClaimAntiReplay verifies claim anti-replay settings are loaded and disabled by default.
+func TestLoad_ClaimAntiReplay(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Replay.Window)
+	assert.Equal(t, 10000, cfg.Replay.Size)
+
+	t.Setenv("CLAIM_ANTI_REPLAY_WINDOW", "30s")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.Replay.Window)
+	assert.Contains(t, cfg.Subsystems(), "claim_anti_replay")
+}
+
+// TestLoad_ClaimRecord verifies claim recording settings are loaded and disabled by default.
+func TestLoad_ClaimRecord(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Record.Size)
+	assert.NotContains(t, cfg.Subsystems(), "claim_recording")
+
+	t.Setenv("CLAIM_RECORD_SIZE", "100")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Record.Size)
+	assert.Contains(t, cfg.Subsystems(), "claim_recording")
+}
+
+// TestLoad_ClaimFilter verifies claimed filter settings are loaded and disabled by default.
+func TestLoad_ClaimFilter(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Filter.Capacity)
+	assert.InDelta(t, 0.01, cfg.Filter.FPRate, 1e-9)
+
+	t.Setenv("CLAIM_FILTER_CAPACITY", "1000000")
+	t.Setenv("CLAIM_FILTER_FP_RATE", "0.001")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 1000000, cfg.Filter.Capacity)
+	assert.InDelta(t, 0.001, cfg.Filter.FPRate, 1e-9)
+}
+
+// TestLoad_CouponNameFilter verifies coupon name filter settings are loaded and disabled by default.
+func TestLoad_CouponNameFilter(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Names.Interval)
+	assert.Equal(t, 100000, cfg.Names.Capacity)
+	assert.InDelta(t, 0.01, cfg.Names.FPRate, 1e-9)
+
+	t.Setenv("COUPON_NAME_FILTER_INTERVAL", "30s")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.Names.Interval)
+}
+
+// TestLoad_EnumGuard verifies anti-enumeration settings are loaded and disabled by default.
+func TestLoad_EnumGuard(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Enum.Enabled)
+	assert.Equal(t, 50*time.Millisecond, cfg.Enum.MinLatency)
+	assert.Equal(t, 20, cfg.Enum.Threshold)
+	assert.Equal(t, time.Hour, cfg.Enum.MaxBlock)
+	assert.False(t, cfg.Enum.RateLimitHeaders)
+
+	t.Setenv("ENUM_GUARD_ENABLED", "true")
+	t.Setenv("ENUM_GUARD_THRESHOLD", "5")
+	t.Setenv("ENUM_GUARD_RATE_LIMIT_HEADERS", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Enum.Enabled)
+	assert.Equal(t, 5, cfg.Enum.Threshold)
+	assert.True(t, cfg.Enum.RateLimitHeaders)
+}
+
+func TestLoad_CORS(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.CORS.AllowOrigins)
+	assert.Equal(t, []string{"Content-Type", "X-Request-ID"}, cfg.CORS.AllowHeaders)
+	assert.Equal(t, 10*time.Minute, cfg.CORS.MaxAge)
+
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://shop.example.com,http://localhost:5173")
+	t.Setenv("CORS_MAX_AGE", "1h")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://shop.example.com", "http://localhost:5173"}, cfg.CORS.AllowOrigins)
+	assert.Equal(t, time.Hour, cfg.CORS.MaxAge)
+	assert.Contains(t, cfg.Subsystems(), "cors")
+
+	for _, origin := range []string{"shop.example.com", "https://shop.example.com/", "ftp://shop.example.com"} {
+		t.Setenv("CORS_ALLOW_ORIGINS", origin)
+		_, err = Load()
+		assert.ErrorContains(t, err, "CORS_ALLOW_ORIGINS", origin)
+	}
+
+	t.Setenv("CORS_ALLOW_ORIGINS", "*")
+	t.Setenv("CORS_MAX_AGE", "48h")
+	_, err = Load()
+	assert.ErrorContains(t, err, "CORS_MAX_AGE must be between 0 and 24h")
+}
+
+// TestLoad_ClaimShadow verifies shadow mode is disabled by default.
+func TestLoad_ClaimShadow(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Shadow.Strategy)
+	assert.Equal(t, 1.0, cfg.Shadow.SampleRate)
+	assert.Equal(t, 32, cfg.Shadow.MaxInFlight)
+
+	t.Setenv("CLAIM_SHADOW_STRATEGY", "optimistic")
+	t.Setenv("CLAIM_SHADOW_SAMPLE_RATE", "0.1")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "optimistic", cfg.Shadow.Strategy)
+	assert.Equal(t, 0.1, cfg.Shadow.SampleRate)
+}
+
+// TestLoad_Captcha verifies captcha settings are loaded and disabled by default.
+func TestLoad_Captcha(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Captcha.Provider)
+	assert.Equal(t, 3*time.Second, cfg.Captcha.Timeout)
+
+	t.Setenv("CAPTCHA_PROVIDER", "hcaptcha")
+	t.Setenv("CAPTCHA_SECRET", "0x0000000000000000000000000000000000000000")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "hcaptcha", cfg.Captcha.Provider)
+	assert.Equal(t, "0x0000000000000000000000000000000000000000", cfg.Captcha.Secret)
+}
+
+// TestLoad_ClaimGrant verifies claim grants are disabled by default.
+func TestLoad_ClaimGrant(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Grant.Secret)
+
+	t.Setenv("CLAIM_GRANT_SECRET", "0123456789abcdef0123456789abcdef")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", cfg.Grant.Secret)
+}
+
+// TestLoad_ClaimedBy verifies claimed_by lists up to 10000 user IDs by default.
+func TestLoad_ClaimedBy(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "full", cfg.Privacy.Mode)
+	assert.Equal(t, 10000, cfg.Privacy.Max)
+
+	t.Setenv("COUPON_CLAIMED_BY", "hash")
+	t.Setenv("COUPON_CLAIMED_BY_MAX", "500")
+	t.Setenv("COUPON_CLAIMED_BY_HASH_KEY", "0123456789abcdef")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "hash", cfg.Privacy.Mode)
+	assert.Equal(t, "0123456789abcdef", cfg.Privacy.HashKey)
+	assert.Equal(t, 500, cfg.Privacy.Max)
+	assert.Contains(t, cfg.Subsystems(), "claimed_by_hash")
+}
+
+// TestLoad_Webhook verifies webhooks are disabled by default, delivered by 8 workers.
+func TestLoad_Webhook(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Webhook.Enabled)
+	assert.Equal(t, 5*time.Second, cfg.Webhook.Timeout)
+	assert.Equal(t, DeliveryPoolConfig{Workers: 8, QueueDepth: 64, Overflow: "block"}, cfg.Deliver)
+
+	t.Setenv("WEBHOOKS_ENABLED", "true")
+	t.Setenv("DB_DRIVER", "cockroachdb")
+	t.Setenv("DELIVERY_WORKERS", "2")
+	t.Setenv("DELIVERY_QUEUE_DEPTH", "0")
+	t.Setenv("DELIVERY_OVERFLOW", "reject")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Webhook.Enabled)
+	assert.Equal(t, DeliveryPoolConfig{Workers: 2, Overflow: "reject"}, cfg.Deliver)
+}
+
+// TestLoad_Leaderboard verifies campaign leaderboards are disabled by default.
+func TestLoad_Leaderboard(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Board.Interval)
+
+	t.Setenv("LEADERBOARD_REFRESH_INTERVAL", "30s")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.Board.Interval)
+}
+
+// TestLoad_CampaignCaps verifies campaign claim caps are disabled by default.
+func TestLoad_CampaignCaps(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Caps.Enabled)
+
+	t.Setenv("CAMPAIGN_CAPS_ENABLED", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Caps.Enabled)
+}
+
+// TestLoad_ServerLimits verifies server limits and their per-route overrides.
+func TestLoad_ServerLimits(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 30*time.Second, cfg.Server.WriteTimeout)
+	assert.Equal(t, 120*time.Second, cfg.Server.IdleTimeout)
+	assert.Equal(t, 1<<20, cfg.Server.BodyLimit)
+
+	timeout, bodyLimit := cfg.Server.Route("claim")
+	assert.Equal(t, 10*time.Second, timeout)
+	assert.Equal(t, 16384, bodyLimit)
+	timeout, bodyLimit = cfg.Server.Route("import")
+	assert.Equal(t, 2*time.Minute, timeout)
+	assert.Equal(t, 1<<20, bodyLimit, "routes without an override get SERVER_BODY_LIMIT")
+	timeout, _ = cfg.Server.Route("get")
+	assert.Zero(t, timeout)
+
+	t.Setenv("SERVER_ROUTE_BODY_LIMITS", "claim:4096,import:8388608")
+	cfg, err = Load()
+	require.NoError(t, err)
+	_, bodyLimit = cfg.Server.Route("import")
+	assert.Equal(t, 8<<20, bodyLimit)
+	assert.Equal(t, 8<<20, cfg.Server.MaxBodyLimit())
+}
+
+// TestLoad_PageSizes verifies the page size bounds of list endpoints.
+func TestLoad_PageSizes(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Server.DefaultPageSize)
+	assert.Equal(t, 1000, cfg.Server.MaxPageSize)
+
+	t.Setenv("SERVER_DEFAULT_PAGE_SIZE", "20")
+	t.Setenv("SERVER_MAX_PAGE_SIZE", "200")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 20, cfg.Server.DefaultPageSize)
+	assert.Equal(t, 200, cfg.Server.MaxPageSize)
+}
+
+// TestLoad_ReadHedge verifies hedged read settings are loaded and disabled by default.
+func TestLoad_ReadHedge(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Hedge.Enabled)
+	assert.Equal(t, 10*time.Millisecond, cfg.Hedge.MinDelay)
+
+	t.Setenv("READ_HEDGE_ENABLED", "true")
+	t.Setenv("READ_HEDGE_MIN_DELAY", "25ms")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Hedge.Enabled)
+	assert.Equal(t, 25*time.Millisecond, cfg.Hedge.MinDelay)
+}
+
+// TestLoad_MigrationPhases verifies column rename phases are loaded.
+func TestLoad_MigrationPhases(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.DB.MigrationPhases)
+
+	t.Setenv("DB_MIGRATION_PHASES", "claims.claimed_at:dual_write")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"claims.claimed_at": "dual_write"}, cfg.DB.MigrationPhases)
+}
+
+// TestLoad_TableMovePhases verifies table move phases are loaded alongside column renames.
+func TestLoad_TableMovePhases(t *testing.T) {
+	t.Setenv("DB_MIGRATION_PHASES", "claims.claimed_at:new,claims_v2:read_new")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"claims.claimed_at": "new", "claims_v2": "read_new"}, cfg.DB.MigrationPhases)
+}
+
+// TestLoad_QueryTimeouts verifies per-query timeouts are loaded.
+func TestLoad_QueryTimeouts(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, database.QueryTimeouts{"get_coupon": 500 * time.Millisecond, "lock_coupon": 2 * time.Second},
+		cfg.DB.QueryTimeouts)
+
+	t.Setenv("DB_QUERY_TIMEOUTS", "get_coupon:250ms")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, database.QueryTimeouts{"get_coupon": 250 * time.Millisecond}, cfg.DB.QueryTimeouts)
+}
+
+// TestLoad_ReadPool verifies the read pool is disabled by default and sized separately.
+func TestLoad_ReadPool(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.DB.ReadMaxConns)
+
+	t.Setenv("DB_READ_MAX_CONNS", "10")
+	t.Setenv("DB_READ_MIN_CONNS", "2")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Contains(t, cfg.DB.DSN(), "pool_max_conns=25&pool_min_conns=5")
+	assert.Contains(t, cfg.DB.ReadDSN(), "pool_max_conns=10&pool_min_conns=2")
+	assert.Contains(t, cfg.Subsystems(), "read_pool")
+}
+
+// TestLoad_DegradedStart verifies the server waits for the database by default.
+func TestLoad_DegradedStart(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.DB.DegradedStart)
+
+	t.Setenv("DB_DEGRADED_START", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.DB.DegradedStart)
+	assert.Contains(t, cfg.Subsystems(), "db_degraded_start")
+}
+
+// TestLoad_ReadRetry verifies reads are not retried by default.
+func TestLoad_ReadRetry(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.DB.ReadRetry)
+
+	t.Setenv("DB_READ_RETRY_ENABLED", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.DB.ReadRetry)
+	assert.Contains(t, cfg.Subsystems(), "read_retry")
+}
+
+// TestLoad_PoolMode verifies the pools assume session pooling by default.
+func TestLoad_PoolMode(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, PoolModeSession, cfg.DB.PoolMode)
+
+	t.Setenv("DB_POOL_MODE", "transaction")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Contains(t, cfg.Subsystems(), "transaction_pooling")
+
+	t.Setenv("DB_DRIVER", "mysql")
+	_, err = Load()
+	assert.ErrorContains(t, err, "DB_POOL_MODE=transaction requires a PostgreSQL wire-compatible DB_DRIVER")
+
+	t.Setenv("DB_DRIVER", "postgres")
+	t.Setenv("DB_POOL_MODE", "statement")
+	_, err = Load()
+	assert.ErrorContains(t, err, "DB_POOL_MODE must be one of: session, transaction")
+}
+
+// TestLoad_CouponLockPolicy verifies coupon row locks wait by default.
+func TestLoad_CouponLockPolicy(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, database.LockWait, cfg.DB.LockPolicy().Mode)
+
+	t.Setenv("DB_COUPON_LOCK_POLICY", "timeout")
+	t.Setenv("DB_COUPON_LOCK_TIMEOUT", "50ms")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, database.LockPolicy{Mode: database.LockTimeout, Timeout: 50 * time.Millisecond}, cfg.DB.LockPolicy())
+	assert.Contains(t, cfg.Subsystems(), "coupon_lock_policy")
+}
+
+// TestLoad_LockDiagnostics verifies lock diagnostics are off by default.
+func TestLoad_LockDiagnostics(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.DB.LockDiagnosticsThreshold)
+	assert.Equal(t, 20, cfg.DB.LockDiagnosticsSize)
+
+	t.Setenv("DB_LOCK_DIAGNOSTICS_THRESHOLD", "500ms")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, cfg.DB.LockDiagnosticsThreshold)
+	assert.Contains(t, cfg.Subsystems(), "lock_diagnostics")
+}
+
+// TestLoad_ClaimBudget verifies the minimum claim budget is loaded and disabled by default.
+func TestLoad_ClaimBudget(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Budget.MinBudget)
+
+	t.Setenv("CLAIM_MIN_BUDGET", "100ms")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, cfg.Budget.MinBudget)
+}
+
+// TestLoad_ClaimAdaptiveLimit verifies claims are not shed by default.
+func TestLoad_ClaimAdaptiveLimit(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Shed.Enabled)
+	assert.NotContains(t, cfg.Subsystems(), "claim_adaptive_limit")
+
+	t.Setenv("CLAIM_ADAPTIVE_LIMIT_ENABLED", "true")
+	t.Setenv("CLAIM_ADAPTIVE_LIMIT_MAX", "200")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, ClaimLimitConfig{Enabled: true, Initial: 20, Min: 5, Max: 200, Tolerance: 1.5}, cfg.Shed)
+	assert.Contains(t, cfg.Subsystems(), "claim_adaptive_limit")
+
+	t.Setenv("CLAIM_ADAPTIVE_LIMIT_INITIAL", "300")
+	_, err = Load()
+	assert.ErrorContains(t, err, "CLAIM_ADAPTIVE_LIMIT_INITIAL must be between")
+
+	t.Setenv("CLAIM_ADAPTIVE_LIMIT_INITIAL", "20")
+	t.Setenv("CLAIM_ADAPTIVE_LIMIT_TOLERANCE", "0.8")
+	_, err = Load()
+	assert.ErrorContains(t, err, "CLAIM_ADAPTIVE_LIMIT_TOLERANCE must be between 1 and 10")
+}
+
+// TestLoad_ClaimPacing verifies claim pacing is off by default and validated when on.
+func TestLoad_ClaimPacing(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Pace.Rate)
+	assert.NotContains(t, cfg.Subsystems(), "claim_pacing")
+
+	t.Setenv("CLAIM_PACING_RATE", "25")
+	t.Setenv("CLAIM_PACING_REDIS_URL", "redis://:secret@redis:6379/1")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, ClaimPacingConfig{
+		Rate: 25, MaxQueue: 50, RedisURL: "redis://:secret@redis:6379/1", RedisTimeout: 100 * time.Millisecond,
+	}, cfg.Pace)
+	assert.Contains(t, cfg.Subsystems(), "claim_pacing")
+
+	t.Setenv("CLAIM_PACING_REDIS_URL", "localhost:6379")
+	_, err = Load()
+	assert.ErrorContains(t, err, "CLAIM_PACING_REDIS_URL")
+
+	t.Setenv("CLAIM_PACING_REDIS_URL", "")
+	t.Setenv("CLAIM_PACING_MAX_QUEUE", "0")
+	_, err = Load()
+	assert.ErrorContains(t, err, "CLAIM_PACING_MAX_QUEUE must be between 1 and 100000")
+}
+
+// TestLoad_APIKeys verifies API keys are off by default and validated when on.
+func TestLoad_APIKeys(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Keys.KeysFile)
+	assert.NotContains(t, cfg.Subsystems(), "api_keys")
+
+	t.Setenv("API_KEYS_FILE", "/etc/coupons/api-keys.json")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, APIKeyConfig{
+		KeysFile: "/etc/coupons/api-keys.json", StandardRate: 50, PremiumRate: 500, PremiumReserve: 0.2, PremiumMaxQueue: 200,
+		UsageFlushInterval: 10 * time.Second,
+	}, cfg.Keys)
+	assert.Contains(t, cfg.Subsystems(), "api_keys")
+
+	t.Setenv("API_KEY_PREMIUM_RESERVE", "1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "API_KEY_PREMIUM_RESERVE must be at least 0 and less than 1")
+
+	t.Setenv("API_KEY_PREMIUM_RESERVE", "0.2")
+	t.Setenv("API_KEY_STANDARD_RATE", "-1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "API_KEY_STANDARD_RATE must be between 0 and 100000")
+
+	t.Setenv("API_KEY_STANDARD_RATE", "50")
+	t.Setenv("CLAIM_PACING_RATE", "25")
+	t.Setenv("API_KEY_PREMIUM_MAX_QUEUE", "10")
+	_, err = Load()
+	assert.ErrorContains(t, err, "API_KEY_PREMIUM_MAX_QUEUE must be between CLAIM_PACING_MAX_QUEUE (50) and 100000")
+}
+
+// TestLoad_APIKeyUsage verifies API key usage counting is off by default and validated when on.
+func TestLoad_APIKeyUsage(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Keys.UsageEnabled)
+	assert.NotContains(t, cfg.Subsystems(), "api_key_usage")
+
+	t.Setenv("API_KEY_USAGE_ENABLED", "true")
+	_, err = Load()
+	assert.ErrorContains(t, err, "API_KEYS_FILE is required when API_KEY_USAGE_ENABLED is set")
+
+	t.Setenv("API_KEYS_FILE", "/etc/coupons/api-keys.json")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.Keys.UsageFlushInterval)
+	assert.Contains(t, cfg.Subsystems(), "api_key_usage")
+
+	t.Setenv("API_KEY_USAGE_FLUSH_INTERVAL", "10m")
+	_, err = Load()
+	assert.ErrorContains(t, err, "API_KEY_USAGE_FLUSH_INTERVAL must be between 1s and 5m")
+
+	t.Setenv("API_KEY_USAGE_FLUSH_INTERVAL", "10s")
+	t.Setenv("DB_DRIVER", "mysql")
+	_, err = Load()
+	assert.ErrorContains(t, err, "API_KEY_USAGE_ENABLED requires DB_DRIVER postgres, cockroachdb or memory")
+}
+
+// TestLoad_HighProfileQuota verifies the high-profile pool share is off by default and validated.
+func TestLoad_HighProfileQuota(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Quota.PoolShare)
+	assert.NotContains(t, cfg.Subsystems(), "high_profile_quota")
+
+	t.Setenv("COUPON_HIGH_PROFILE_POOL_SHARE", "0.3")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Contains(t, cfg.Subsystems(), "high_profile_quota")
+	assert.Equal(t, 7, cfg.Quota.Slots(cfg.DB.MaxConns), "30% of 25 connections")
+	assert.Equal(t, 1, cfg.Quota.Slots(2), "at least one connection")
+
+	t.Setenv("COUPON_HIGH_PROFILE_POOL_SHARE", "1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "COUPON_HIGH_PROFILE_POOL_SHARE must be at least 0 and less than 1")
+}
+
+// TestLoad_CouponMetadataSchema verifies the metadata schema path is loaded and unset by default.
+func TestLoad_CouponMetadataSchema(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Meta.SchemaPath)
+
+	t.Setenv("COUPON_METADATA_SCHEMA", "/etc/coupons/metadata.schema.json")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/coupons/metadata.schema.json", cfg.Meta.SchemaPath)
+	assert.Contains(t, cfg.Subsystems(), "coupon_metadata_schema")
+}
+
+// TestLoad_Seed verifies the seed file is unset by default and needs the database at startup.
+func TestLoad_Seed(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Seed.File)
+
+	t.Setenv("SEED_FILE", "/etc/coupons/seed.yaml")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/coupons/seed.yaml", cfg.Seed.File)
+	assert.Contains(t, cfg.Subsystems(), "seed")
+
+	t.Setenv("DB_DEGRADED_START", "true")
+	_, err = Load()
+	assert.ErrorContains(t, err, "SEED_FILE cannot be combined with DB_DEGRADED_START")
+}
+
+// TestLoad_CouponAllowlists verifies coupon allowlists are off by default.
+func TestLoad_CouponAllowlists(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Allow.Enabled)
+
+	t.Setenv("COUPON_ALLOWLISTS_ENABLED", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Allow.Enabled)
+	assert.Contains(t, cfg.Subsystems(), "coupon_allowlists")
+}
+
+// TestLoad_CouponDeletion verifies coupon deletion is disabled by default.
+func TestLoad_CouponDeletion(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Delete.UndoWindow)
+	assert.Equal(t, time.Minute, cfg.Delete.PurgeInterval)
+
+	t.Setenv("COUPON_UNDO_WINDOW", "48h")
+	t.Setenv("COUPON_PURGE_INTERVAL", "5m")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 48*time.Hour, cfg.Delete.UndoWindow)
+	assert.Equal(t, 5*time.Minute, cfg.Delete.PurgeInterval)
+	assert.Contains(t, cfg.Subsystems(), "coupon_deletion")
+}
+
+// TestLoad_CouponArchive verifies the coupon archive is off by default and requires deletion.
+func TestLoad_CouponArchive(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, CouponArchiveConfig{CacheMaxAge: 24 * time.Hour}, cfg.Archive)
+	assert.NotContains(t, cfg.Subsystems(), "coupon_archive")
+
+	t.Setenv("COUPON_ARCHIVE_ENABLED", "true")
+	_, err = Load()
+	assert.ErrorContains(t, err, "COUPON_UNDO_WINDOW is required when COUPON_ARCHIVE_ENABLED is set")
+
+	t.Setenv("COUPON_UNDO_WINDOW", "48h")
+	t.Setenv("COUPON_ARCHIVE_CACHE_MAX_AGE", "720h")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, CouponArchiveConfig{Enabled: true, CacheMaxAge: 720 * time.Hour}, cfg.Archive)
+	assert.Contains(t, cfg.Subsystems(), "coupon_archive")
+
+	t.Setenv("COUPON_ARCHIVE_CACHE_MAX_AGE", "10s")
+	_, err = Load()
+	assert.ErrorContains(t, err, "COUPON_ARCHIVE_CACHE_MAX_AGE must be between 1m and 8760h")
+
+	t.Setenv("COUPON_ARCHIVE_CACHE_MAX_AGE", "720h")
+	t.Setenv("DB_DRIVER", "memory")
+	_, err = Load()
+	assert.ErrorContains(t, err, "COUPON_ARCHIVE_ENABLED requires a PostgreSQL wire-compatible DB_DRIVER")
+}
+
+// TestLoad_StockWait verifies waiting for stock is disabled by default.
+func TestLoad_StockWait(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, StockWaitConfig{MaxTimeout: time.Minute, MaxWaiters: 10000}, cfg.Wait)
+
+	t.Setenv("STOCK_WAIT_ENABLED", "true")
+	t.Setenv("STOCK_WAIT_MAX_TIMEOUT", "2m")
+	t.Setenv("STOCK_WAIT_MAX_WAITERS", "500")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, StockWaitConfig{Enabled: true, MaxTimeout: 2 * time.Minute, MaxWaiters: 500}, cfg.Wait)
+	assert.Contains(t, cfg.Subsystems(), "stock_wait")
+}
+
+// TestLoad_ClaimRetention verifies claim retention is disabled by default.
+func TestLoad_ClaimRetention(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, ClaimRetentionConfig{Interval: time.Hour}, cfg.Retain)
+
+	t.Setenv("CLAIM_RETENTION_ENABLED", "true")
+	t.Setenv("CLAIM_RETENTION_INTERVAL", "6h")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, ClaimRetentionConfig{Enabled: true, Interval: 6 * time.Hour}, cfg.Retain)
+	assert.Contains(t, cfg.Subsystems(), "claim_retention")
+}
+
+// TestLoad_AnalyticsExport verifies the analytics export is disabled by default.
+func TestLoad_AnalyticsExport(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Export.Store)
+	assert.Equal(t, time.Hour, cfg.Export.Interval)
+	assert.Equal(t, 7, cfg.Export.LookbackDays)
+
+	t.Setenv("ANALYTICS_EXPORT_STORE", "s3")
+	t.Setenv("ANALYTICS_EXPORT_PREFIX", "production")
+	t.Setenv("ANALYTICS_EXPORT_S3_ENDPOINT", "https://storage.googleapis.com")
+	t.Setenv("ANALYTICS_EXPORT_S3_REGION", "auto")
+	t.Setenv("ANALYTICS_EXPORT_S3_BUCKET", "analytics")
+	t.Setenv("ANALYTICS_EXPORT_S3_ACCESS_KEY_ID", "GOOG1EXAMPLE")
+	t.Setenv("ANALYTICS_EXPORT_S3_SECRET_ACCESS_KEY", "secret")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, AnalyticsExportConfig{
+		Store:             ExportStoreS3,
+		Prefix:            "production",
+		Interval:          time.Hour,
+		LookbackDays:      7,
+		S3Endpoint:        "https://storage.googleapis.com",
+		S3Region:          "auto",
+		S3Bucket:          "analytics",
+		S3AccessKeyID:     "GOOG1EXAMPLE",
+		S3SecretAccessKey: "secret",
+	}, cfg.Export)
+	assert.Contains(t, cfg.Subsystems(), "analytics_export")
+}
+
+// TestLoad_LoadTest verifies the load test API is disabled by default.
+func TestLoad_LoadTest(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, LoadTestConfig{CleanupInterval: time.Minute}, cfg.Load)
+
+	t.Setenv("LOADTEST_ENABLED", "true")
+	t.Setenv("LOADTEST_CLEANUP_INTERVAL", "10s")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, LoadTestConfig{Enabled: true, CleanupInterval: 10 * time.Second}, cfg.Load)
+	assert.Contains(t, cfg.Subsystems(), "loadtest")
+}
+
+// TestLoad_RequestDeadline verifies X-Request-Deadline is ignored by default.
+func TestLoad_RequestDeadline(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Server.MaxRequestDeadline)
+	assert.NotContains(t, cfg.Subsystems(), "request_deadline")
+
+	t.Setenv("SERVER_MAX_REQUEST_DEADLINE", "30s")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.Server.MaxRequestDeadline)
+	assert.Contains(t, cfg.Subsystems(), "request_deadline")
+}
+
+// TestLoad_PoolWatch verifies the connection pool watchdog is disabled by default.
+func TestLoad_PoolWatch(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, PoolWatchConfig{SaturationWarn: 0.9, AcquireWaitWarn: 100 * time.Millisecond}, cfg.Watch)
+	assert.NotContains(t, cfg.Subsystems(), "db_pool_watch")
+
+	t.Setenv("DB_POOL_WATCH_INTERVAL", "5s")
+	t.Setenv("DB_POOL_SATURATION_WARN", "0.8")
+	t.Setenv("DB_POOL_ACQUIRE_WAIT_P95_WARN", "0s")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, PoolWatchConfig{Interval: 5 * time.Second, SaturationWarn: 0.8}, cfg.Watch)
+	assert.Contains(t, cfg.Subsystems(), "db_pool_watch")
+}
+
+// TestLoad_FailoverWatch verifies failover detection is disabled by default and not
+// supported on CockroachDB.
+func TestLoad_FailoverWatch(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, FailoverWatchConfig{StatusHold: 15 * time.Minute}, cfg.Failover)
+	assert.NotContains(t, cfg.Subsystems(), "db_failover_watch")
+
+	t.Setenv("DB_FAILOVER_WATCH_INTERVAL", "10s")
+	t.Setenv("DB_FAILOVER_STATUS_HOLD", "1h")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, FailoverWatchConfig{Interval: 10 * time.Second, StatusHold: time.Hour}, cfg.Failover)
+	assert.Contains(t, cfg.Subsystems(), "db_failover_watch")
+
+	t.Setenv("DB_FAILOVER_STATUS_HOLD", "10s")
+	_, err = Load()
+	assert.ErrorContains(t, err, "DB_FAILOVER_STATUS_HOLD")
+
+	t.Setenv("DB_FAILOVER_STATUS_HOLD", "1h")
+	t.Setenv("DB_DRIVER", "cockroachdb")
+	_, err = Load()
+	assert.ErrorContains(t, err, "not supported with DB_DRIVER cockroachdb")
+}
+
+// TestLoad_HeavyJobs verifies heavy jobs run at any time by default, and their load
+// limits require PostgreSQL.
+func TestLoad_HeavyJobs(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, HeavyJobConfig{}, cfg.Heavy)
+	assert.NotContains(t, cfg.Subsystems(), "heavy_job_schedule")
+
+	t.Setenv("HEAVY_JOB_WINDOWS", "01:00-05:00,22:00-23:00")
+	t.Setenv("HEAVY_JOB_MAX_REPLICATION_LAG", "30s")
+	t.Setenv("HEAVY_JOB_MAX_ACTIVE_CONNS", "80")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, HeavyJobConfig{Windows: "01:00-05:00,22:00-23:00", MaxReplicationLag: 30 * time.Second, MaxActiveConns: 80}, cfg.Heavy)
+	assert.Contains(t, cfg.Subsystems(), "heavy_job_schedule")
+
+	t.Setenv("DB_DRIVER", "mysql")
+	_, err = Load()
+	assert.ErrorContains(t, err, "require DB_DRIVER=postgres")
+
+	t.Setenv("DB_DRIVER", "postgres")
+	t.Setenv("HEAVY_JOB_WINDOWS", "1am-5am")
+	_, err = Load()
+	assert.ErrorContains(t, err, "HEAVY_JOB_WINDOWS is invalid")
+}
+
+// TestLoad_Runtime verifies the memory limit defaults to 90% of the cgroup's.
+func TestLoad_Runtime(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 0.9, cfg.Runtime.MemoryLimitRatio)
+
+	t.Setenv("MEMORY_LIMIT_RATIO", "0")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Runtime.MemoryLimitRatio)
+}
+
+// TestLoad_KillSwitch verifies the kill switch starts disengaged and in process.
+func TestLoad_KillSwitch(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, KillSwitchConfig{
+		Message:      "writes are paused for maintenance",
+		RedisTimeout: 500 * time.Millisecond,
+		PollInterval: time.Second,
+	}, cfg.Kill)
+
+	t.Setenv("KILL_SWITCH_ENGAGED", "true")
+	t.Setenv("KILL_SWITCH_MESSAGE", "back at 10:00 UTC")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Kill.Engaged)
+	assert.Equal(t, "back at 10:00 UTC", cfg.Kill.Message)
+	assert.Contains(t, cfg.Subsystems(), "kill_switch_engaged")
+}
+
+// TestLoad_ClaimImport verifies the claim import chunk size is loaded.
+func TestLoad_ClaimImport(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 500, cfg.Import.ChunkSize)
+
+	t.Setenv("CLAIM_IMPORT_CHUNK_SIZE", "100")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Import.ChunkSize)
+}
+
+// TestLoad_LogRedaction verifies redaction settings are loaded.
+func TestLoad_LogRedaction(t *testing.T) {
+	t.Setenv("LOG_REDACT", "hash")
+	t.Setenv("LOG_REDACT_KEY", "0123456789abcdef")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "hash", cfg.Log.Redact)
+	assert.Equal(t, "0123456789abcdef", cfg.Log.RedactKey)
+}
+
+// TestLoad_AccessLog verifies the access log goes to stdout by default.
+func TestLoad_AccessLog(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "stdout", cfg.Access.Sink)
+	assert.NotContains(t, cfg.Subsystems(), "access_log_stdout")
+
+	t.Setenv("ACCESS_LOG_SINK", "file")
+	t.Setenv("ACCESS_LOG_FILE", "/var/log/coupon/access.log")
+	t.Setenv("ACCESS_LOG_MAX_SIZE_MB", "10")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, accesslog.Options{
+		Sink: "file", Path: "/var/log/coupon/access.log", MaxSize: 10 << 20, MaxBackups: 5, Tag: "coupon-api",
+	}, cfg.Access.Options())
+	assert.Contains(t, cfg.Subsystems(), "access_log_file")
+}
+
+// TestLoad_EventLog verifies the domain event log is disabled by default.
+func TestLoad_EventLog(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Events.Sink)
+
+	t.Setenv("EVENT_LOG_SINK", "file")
+	t.Setenv("EVENT_LOG_FILE", "/var/log/coupon/events.jsonl")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, accesslog.Options{
+		Sink: "file", Path: "/var/log/coupon/events.jsonl", MaxSize: 100 << 20, MaxBackups: 5,
+	}, cfg.Events.Options())
+	assert.Contains(t, cfg.Subsystems(), "event_log_file")
+}
+
+// TestConfig_Validate_ValidSSLModes tests all valid SSL modes.
+func TestConfig_Validate_ValidSSLModes(t *testing.T) {
+	validModes := []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+	for _, mode := range validModes {
+		t.Run(mode, func(t *testing.T) {
+			t.Setenv("DB_SSLMODE", mode)
+			cfg, err := Load()
+			require.NoError(t, err)
+			assert.Equal(t, mode, cfg.DB.SSLMode)
+		})
+	}
+}
+
+// TestLoad_DefaultValues verifies all default values when no environment variables are set.
+// This ensures the config layer works correctly with zero configuration.
+// Note: envconfig uses defaults when env vars are UNSET, not when set to empty string.
+func TestLoad_DefaultValues(t *testing.T) {
+	// The TestLoad_PartialOverride test already validates default behavior
+	// for unset variables. This test documents the expected default values
+	// by checking the struct tags directly.
+	//
+	// Default values per struct definition:
+	// - SERVER_PORT: "3000"
+	// - SHUTDOWN_TIMEOUT: 30
+	// - DB_DRIVER: "postgres"
+	// - DB_HOST: "localhost"
+	// - DB_PORT: 5432
+	// - DB_USER: "postgres"
+	// - DB_PASSWORD: "postgres" (WARNING: Change in production!)
+	// - DB_NAME: "coupon_db"
+	// - DB_SSLMODE: "disable" (Use "require" in production!)
+	// - DB_MAX_CONNS: 25
+	// - DB_MIN_CONNS: 5
+	// - LOG_LEVEL: "info"
+	// - LOG_PRETTY: false
+	//
+	// Rather than unsetting env vars (which is complex in test isolation),
+	// we verify the behavior through TestLoad_PartialOverride which confirms
+	// defaults are used for unset variables.
+
+	// Verify Load works and produces valid config
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+
+	// Verify struct is populated (may have overrides from other tests but validates loading works)
+	assert.NotEmpty(t, cfg.Server.Port, "Server port should be set")
+	assert.NotZero(t, cfg.Server.ShutdownTimeout, "Shutdown timeout should be set")
+	assert.NotEmpty(t, cfg.DB.Host, "DB host should be set")
+	assert.NotZero(t, cfg.DB.Port, "DB port should be set")
+	assert.NotEmpty(t, cfg.Log.Level, "Log level should be set")
+}
+
+// TestConfig_WarnIfDefaultCredentials tests the security warning function.
+func TestConfig_WarnIfDefaultCredentials(t *testing.T) {
+	t.Run("all_defaults_returns_all_warnings", func(t *testing.T) {
+		cfg := &Config{
+			DB: DBConfig{
+				User:     "postgres",
+				Password: "postgres",
+				SSLMode:  "disable",
+			},
+		}
+
+		warnings := cfg.WarnIfDefaultCredentials()
+		assert.Len(t, warnings, 3, "Should return 3 warnings for all defaults")
+		assert.Contains(t, warnings[0], "DB_PASSWORD")
+		assert.Contains(t, warnings[1], "DB_USER")
+		assert.Contains(t, warnings[2], "DB_SSLMODE")
+	})
+
+	t.Run("custom_password_only", func(t *testing.T) {
+		cfg := &Config{
+			DB: DBConfig{
+				User:     "postgres",
+				Password: "secure_password_123",
+				SSLMode:  "disable",
+			},
+		}
+
+		warnings := cfg.WarnIfDefaultCredentials()
+		assert.Len(t, warnings, 2, "Should return 2 warnings (user and SSL)")
+
+		// Should not contain password warning
+		for _, w := range warnings {
+			assert.NotContains(t, w, "DB_PASSWORD")
+		}
+	})
+
+	t.Run("custom_user_only", func(t *testing.T) {
+		cfg := &Config{
+			DB: DBConfig{
+				User:     "custom_user",
+				Password: "postgres",
+				SSLMode:  "disable",
+			},
+		}
+
+		warnings := cfg.WarnIfDefaultCredentials()
+		assert.Len(t, warnings, 2, "Should return 2 warnings (password and SSL)")
+
+		// Should not contain user warning
+		for _, w := range warnings {
+			assert.NotContains(t, w, "DB_USER")
+		}
+	})
+
+	t.Run("ssl_mode_require", func(t *testing.T) {
+		cfg := &Config{
+			DB: DBConfig{
+				User:     "postgres",
+				Password: "postgres",
+				SSLMode:  "require",
+			},
+		}
+
+		warnings := cfg.WarnIfDefaultCredentials()
+		assert.Len(t, warnings, 2, "Should return 2 warnings (password and user)")
+
+		// Should not contain SSL warning
+		for _, w := range warnings {
+			assert.NotContains(t, w, "DB_SSLMODE")
+		}
+	})
+
+	t.Run("all_custom_returns_empty", func(t *testing.T) {
+		cfg := &Config{
+			DB: DBConfig{
+				User:     "production_user",
+				Password: "super_secure_password",
+				SSLMode:  "verify-full",
+			},
+		}
+
+		warnings := cfg.WarnIfDefaultCredentials()
+		assert.Empty(t, warnings, "Should return no warnings when all values are custom")
+	})
+
+	t.Run("verify_ca_ssl_mode_no_warning", func(t *testing.T) {
+		cfg := &Config{
+			DB: DBConfig{
+				User:     "postgres",
+				Password: "postgres",
+				SSLMode:  "verify-ca",
+			},
+		}
+
+		warnings := cfg.WarnIfDefaultCredentials()
+		assert.Len(t, warnings, 2, "Should return 2 warnings (only user and password)")
+
+		// Should not contain SSL warning
+		for _, w := range warnings {
+			assert.NotContains(t, w, "DB_SSLMODE")
+		}
+	})
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := &Config{
+		DB:      DBConfig{User: "coupon", Password: "hunter2"},
+		Log:     LogConfig{Redact: "hash", RedactKey: "0123456789abcdef0123456789abcdef"},
+		Grant:   ClaimGrantConfig{Secret: "0123456789abcdef0123456789abcdef"},
+		Captcha: CaptchaConfig{Provider: "hcaptcha"},
+		Privacy: ClaimedByConfig{Mode: "hash", HashKey: "0123456789abcdef"},
+		Export:  AnalyticsExportConfig{Store: ExportStoreS3, S3SecretAccessKey: "secret"},
+	}
+
+	r := cfg.Redacted()
+
+	assert.Equal(t, "[REDACTED]", r.DB.Password)
+	assert.Equal(t, "[REDACTED]", r.Log.RedactKey)
+	assert.Equal(t, "[REDACTED]", r.Grant.Secret)
+	assert.Equal(t, "[REDACTED]", r.Privacy.HashKey)
+	assert.Equal(t, "[REDACTED]", r.Export.S3SecretAccessKey)
+	assert.Empty(t, r.Captcha.Secret, "unset secrets stay empty")
+	assert.Equal(t, "coupon", r.DB.User)
+	assert.Equal(t, "hunter2", cfg.DB.Password, "the config itself is unchanged")
+}
+
+func TestConfig_Subsystems(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Subsystems(), "optional subsystems are disabled by default")
+
+	t.Setenv("CACHE_COUPON_TTL", "200ms")
+	t.Setenv("READ_HEDGE_ENABLED", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"coupon_cache", "read_hedging"}, cfg.Subsystems())
 }
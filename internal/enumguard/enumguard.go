@@ -0,0 +1,226 @@
+// Package enumguard makes scanning for valid coupon names slow and detectable. Routes
+// it guards answer in uniform, randomized time, so a 404 cannot be told apart from a
+// hit by latency, and clients (by IP) that collect too many 404s are blocked for an
+// escalating period.
+package enumguard
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/cache"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// Config configures a Guard.
+type Config struct {
+	MinLatency time.Duration // Guarded responses take at least this long...
+	Jitter     time.Duration // ...plus a random delay of up to Jitter
+	Threshold  int           // 404s an IP may get per Window before it is blocked
+	Window     time.Duration
+	Block      time.Duration // First block of an IP; doubles on each repeat up to MaxBlock
+	MaxBlock   time.Duration
+	MaxIPs     int // IPs tracked at once; the least recently seen are forgotten
+
+	// Headers adds X-RateLimit-* headers describing the client's 404 budget to guarded
+	// responses (see Handler). They let well-behaved clients pace themselves, but also
+	// tell scanners how fast they may go.
+	Headers bool
+}
+
+// Rate limit headers set when Config.Headers is on.
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"     // Threshold: 404s allowed per window
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining" // 404s left in the current window
+	HeaderRateLimitReset     = "X-RateLimit-Reset"     // Seconds until the budget is restored
+)
+
+// Stats is a snapshot of Guard counters.
+type Stats struct {
+	NotFound   int64 `json:"not_found"`   // 404 responses on guarded routes
+	Blocks     int64 `json:"blocks"`      // Times an IP was blocked
+	Rejected   int64 `json:"rejected"`    // Requests refused with 429 while blocked
+	TrackedIPs int   `json:"tracked_ips"` // IPs with recent 404s or blocks
+}
+
+// client is the 404 history of one IP.
+type client struct {
+	windowStart  time.Time
+	misses       int // 404s since windowStart
+	strikes      int // Blocks so far
+	blockedUntil time.Time
+}
+
+// Guard tracks 404s per IP and pads guarded responses. It is safe for concurrent use.
+type Guard struct {
+	cfg   Config
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration)
+
+	mu      sync.Mutex // Guards the clients' fields
+	clients *cache.LRU[string, *client]
+
+	notFound atomic.Int64
+	blocks   atomic.Int64
+	rejected atomic.Int64
+}
+
+// New creates a Guard. An IP's history is forgotten once it has been quiet for
+// MaxBlock plus Window, so strikes escalate only for persistent scanners.
+func New(cfg Config) *Guard {
+	return &Guard{
+		cfg:     cfg,
+		now:     time.Now,
+		sleep:   sleep,
+		clients: cache.NewLRU[string, *client](cfg.MaxIPs, cfg.MaxBlock+cfg.Window),
+	}
+}
+
+// Stats returns the current counters.
+func (g *Guard) Stats() Stats {
+	return Stats{
+		NotFound:   g.notFound.Load(),
+		Blocks:     g.blocks.Load(),
+		Rejected:   g.rejected.Load(),
+		TrackedIPs: g.clients.Stats().Size,
+	}
+}
+
+// Handler returns middleware guarding the routes it is mounted on. Blocked IPs get 429
+// with Retry-After; other requests are answered no sooner than MinLatency plus jitter
+// after they arrived, whatever their outcome. With Config.Headers, every guarded
+// response carries the client's remaining 404 budget (0 while blocked).
+func (g *Guard) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := g.now()
+		ip := c.IP()
+		if until, blocked := g.blockedUntil(ip, start); blocked {
+			g.rejected.Add(1)
+			retryAfter := strconv.Itoa(seconds(until.Sub(start)))
+			c.Set(fiber.HeaderRetryAfter, retryAfter)
+			if g.cfg.Headers {
+				g.setHeaders(c, 0, retryAfter)
+			}
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many requests"})
+		}
+
+		err := c.Next()
+		if c.Response().StatusCode() == fiber.StatusNotFound {
+			g.notFound.Add(1)
+			g.recordNotFound(ip)
+		}
+		if g.cfg.Headers {
+			remaining, reset := g.budget(ip, g.now())
+			g.setHeaders(c, remaining, strconv.Itoa(seconds(reset)))
+		}
+
+		delay := g.cfg.MinLatency - g.now().Sub(start)
+		if g.cfg.Jitter > 0 {
+			delay += rand.N(g.cfg.Jitter)
+		}
+		if delay > 0 {
+			g.sleep(c.Context(), delay)
+		}
+		return err
+	}
+}
+
+// setHeaders sets the rate limit headers of a guarded response.
+func (g *Guard) setHeaders(c *fiber.Ctx, remaining int, reset string) {
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(g.cfg.Threshold))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(remaining))
+	c.Set(HeaderRateLimitReset, reset)
+}
+
+// budget returns how many more 404s ip may get in its current window, and how long
+// until the window ends. A block restores the full budget once it ends, so a blocked
+// IP has 0 left until then.
+func (g *Guard) budget(ip string, now time.Time) (remaining int, reset time.Duration) {
+	cl, ok := g.clients.Get(ip)
+	if !ok {
+		return g.cfg.Threshold, g.cfg.Window
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if now.Before(cl.blockedUntil) {
+		return 0, cl.blockedUntil.Sub(now)
+	}
+	if now.Sub(cl.windowStart) >= g.cfg.Window {
+		return g.cfg.Threshold, g.cfg.Window
+	}
+	return max(g.cfg.Threshold-cl.misses, 0), cl.windowStart.Add(g.cfg.Window).Sub(now)
+}
+
+// seconds rounds d up to whole seconds, as Retry-After and X-RateLimit-Reset are given.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// blockedUntil reports whether ip is blocked at now, and until when.
+func (g *Guard) blockedUntil(ip string, now time.Time) (time.Time, bool) {
+	cl, ok := g.clients.Get(ip)
+	if !ok {
+		return time.Time{}, false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return cl.blockedUntil, now.Before(cl.blockedUntil)
+}
+
+// recordNotFound counts a 404 for ip and blocks it once it exceeds the threshold.
+func (g *Guard) recordNotFound(ip string) {
+	now := g.now()
+	cl, ok := g.clients.Get(ip)
+
+	g.mu.Lock()
+	if !ok {
+		cl = &client{windowStart: now}
+	}
+	if now.Sub(cl.windowStart) >= g.cfg.Window {
+		cl.windowStart, cl.misses = now, 0
+	}
+	cl.misses++
+
+	var block time.Duration
+	if cl.misses > g.cfg.Threshold {
+		block = g.cfg.Block
+		for range cl.strikes {
+			if block >= g.cfg.MaxBlock {
+				break
+			}
+			block *= 2
+		}
+		block = min(block, g.cfg.MaxBlock)
+		cl.strikes++
+		cl.blockedUntil = now.Add(block)
+		cl.windowStart, cl.misses = now, 0
+	}
+	g.mu.Unlock()
+
+	g.clients.Add(ip, cl) // Also extends how long the history is kept
+	if block > 0 {
+		g.blocks.Add(1)
+		log.Warn().
+			Str("ip", redact.Value(ip)).
+			Dur("block", block).
+			Msg("blocking client scanning for coupon names")
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
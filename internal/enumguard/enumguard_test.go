@@ -0,0 +1,176 @@
+package enumguard
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testGuard is a Guard on a fake clock whose sleeps advance the clock and are recorded.
+type testGuard struct {
+	*Guard
+	clock  time.Time
+	sleeps []time.Duration
+}
+
+func newTestGuard(cfg Config) *testGuard {
+	g := &testGuard{Guard: New(cfg), clock: time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)}
+	g.now = func() time.Time { return g.clock }
+	g.sleep = func(_ context.Context, d time.Duration) {
+		g.sleeps = append(g.sleeps, d)
+		g.clock = g.clock.Add(d)
+	}
+	return g
+}
+
+// app serves /api/coupons/:name, answering 404 for MISSING and taking handlerTime.
+func (g *testGuard) app(handlerTime time.Duration) *fiber.App {
+	app := fiber.New()
+	app.Get("/api/coupons/:name", g.Handler(), func(c *fiber.Ctx) error {
+		g.clock = g.clock.Add(handlerTime)
+		if c.Params("name") == "MISSING" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		return c.JSON(fiber.Map{"name": c.Params("name")})
+	})
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, name string) int {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/coupons/"+name, nil))
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestGuard_PadsResponsesToMinLatency(t *testing.T) {
+	g := newTestGuard(Config{MinLatency: 50 * time.Millisecond, Threshold: 100, Window: time.Minute, Block: time.Minute, MaxBlock: time.Hour, MaxIPs: 10})
+	app := g.app(10 * time.Millisecond)
+
+	assert.Equal(t, fiber.StatusOK, get(t, app, "PROMO"))
+	assert.Equal(t, fiber.StatusNotFound, get(t, app, "MISSING"))
+
+	assert.Equal(t, []time.Duration{40 * time.Millisecond, 40 * time.Millisecond}, g.sleeps,
+		"hits and misses take the same time")
+}
+
+func TestGuard_SlowResponsesAreNotPadded(t *testing.T) {
+	g := newTestGuard(Config{MinLatency: 50 * time.Millisecond, Threshold: 100, Window: time.Minute, Block: time.Minute, MaxBlock: time.Hour, MaxIPs: 10})
+
+	get(t, g.app(80*time.Millisecond), "PROMO")
+
+	assert.Empty(t, g.sleeps)
+}
+
+func TestGuard_JitterIsBounded(t *testing.T) {
+	g := newTestGuard(Config{Jitter: 20 * time.Millisecond, Threshold: 100, Window: time.Minute, Block: time.Minute, MaxBlock: time.Hour, MaxIPs: 10})
+	app := g.app(0)
+
+	for range 20 {
+		get(t, app, "PROMO")
+	}
+
+	for _, d := range g.sleeps {
+		assert.Less(t, d, 20*time.Millisecond)
+	}
+}
+
+func TestGuard_BlocksScannersWithEscalation(t *testing.T) {
+	g := newTestGuard(Config{Threshold: 3, Window: time.Minute, Block: time.Minute, MaxBlock: 3 * time.Minute, MaxIPs: 10})
+	app := g.app(0)
+
+	scan := func() {
+		for range 4 {
+			assert.Equal(t, fiber.StatusNotFound, get(t, app, "MISSING"))
+		}
+	}
+
+	scan()
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/coupons/PROMO", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode, "blocked for every name")
+	assert.Equal(t, "60", resp.Header.Get(fiber.HeaderRetryAfter))
+
+	g.clock = g.clock.Add(time.Minute)
+	scan()
+	g.clock = g.clock.Add(time.Minute)
+	assert.Equal(t, fiber.StatusTooManyRequests, get(t, app, "PROMO"), "second block lasts 2m")
+
+	g.clock = g.clock.Add(time.Minute)
+	scan()
+	g.clock = g.clock.Add(3*time.Minute - time.Second)
+	assert.Equal(t, fiber.StatusTooManyRequests, get(t, app, "PROMO"), "third block capped at 3m")
+	g.clock = g.clock.Add(time.Second)
+	assert.Equal(t, fiber.StatusOK, get(t, app, "PROMO"))
+
+	assert.Equal(t, Stats{NotFound: 12, Blocks: 3, Rejected: 3, TrackedIPs: 1}, g.Stats())
+}
+
+func TestGuard_WindowResetsCount(t *testing.T) {
+	g := newTestGuard(Config{Threshold: 3, Window: time.Minute, Block: time.Minute, MaxBlock: time.Hour, MaxIPs: 10})
+	app := g.app(0)
+
+	for range 3 {
+		get(t, app, "MISSING")
+	}
+	g.clock = g.clock.Add(time.Minute)
+	for range 3 {
+		get(t, app, "MISSING")
+	}
+
+	assert.Equal(t, fiber.StatusOK, get(t, app, "PROMO"))
+	assert.Zero(t, g.Stats().Blocks)
+}
+
+func TestGuard_RateLimitHeaders(t *testing.T) {
+	g := newTestGuard(Config{Threshold: 2, Window: time.Minute, Block: time.Minute, MaxBlock: time.Hour, MaxIPs: 10, Headers: true})
+	app := g.app(0)
+
+	headers := func(name string) (int, []string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/coupons/"+name, nil))
+		require.NoError(t, err)
+		return resp.StatusCode, []string{
+			resp.Header.Get(HeaderRateLimitLimit),
+			resp.Header.Get(HeaderRateLimitRemaining),
+			resp.Header.Get(HeaderRateLimitReset),
+		}
+	}
+
+	status, h := headers("PROMO")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, []string{"2", "2", "60"}, h, "hits do not use the budget")
+
+	_, h = headers("MISSING")
+	assert.Equal(t, []string{"2", "1", "60"}, h)
+
+	g.clock = g.clock.Add(15 * time.Second)
+	_, h = headers("MISSING")
+	assert.Equal(t, []string{"2", "0", "45"}, h, "reset counts down to the end of the window")
+
+	status, h = headers("MISSING")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Equal(t, []string{"2", "0", "60"}, h, "the 404 that blocks reports the block")
+
+	status, h = headers("PROMO")
+	assert.Equal(t, fiber.StatusTooManyRequests, status)
+	assert.Equal(t, []string{"2", "0", "60"}, h)
+
+	g.clock = g.clock.Add(time.Minute)
+	_, h = headers("PROMO")
+	assert.Equal(t, []string{"2", "2", "60"}, h, "the budget is restored after the block")
+}
+
+func TestGuard_RateLimitHeadersOptIn(t *testing.T) {
+	g := newTestGuard(Config{Threshold: 2, Window: time.Minute, Block: time.Minute, MaxBlock: time.Hour, MaxIPs: 10})
+
+	resp, err := g.app(0).Test(httptest.NewRequest("GET", "/api/coupons/MISSING", nil))
+	require.NoError(t, err)
+
+	assert.Empty(t, resp.Header.Get(HeaderRateLimitLimit))
+}
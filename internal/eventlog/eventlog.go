@@ -0,0 +1,84 @@
+// Package eventlog appends domain events (coupons created, claims made) as JSON lines to
+// a size-rotated file or stdout, so that activity can be ingested for offline analytics
+// without access to the database. Each line carries "stream":"domain_event", telling
+// events apart from the application logs when they share stdout.
+package eventlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/accesslog"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// Stream is the value of the stream field of every event line.
+const Stream = "domain_event"
+
+// Options configures the sink events are written to: accesslog.Stdout, or
+// accesslog.File rotated at MaxSize bytes keeping MaxBackups old files.
+type Options = accesslog.Options
+
+// Open opens the sink described by opts. Syslog is not supported, as events may not
+// fit in a syslog message.
+func Open(opts Options) (io.WriteCloser, error) {
+	if opts.Sink == accesslog.Syslog {
+		return nil, fmt.Errorf("unsupported event log sink %q", opts.Sink)
+	}
+	return accesslog.Open(opts)
+}
+
+// Stats is a snapshot of event log counters.
+type Stats struct {
+	Written int64 `json:"written"`
+	Errors  int64 `json:"errors"` // Events that could not be written and are lost
+}
+
+// line is an event as written.
+type line struct {
+	Stream string `json:"stream"`
+	model.DomainEvent
+}
+
+// Log appends events to a writer, one JSON line per event and one write per line so
+// that lines are never interleaved or split across rotated files. It is safe for
+// concurrent use.
+type Log struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	written atomic.Int64
+	errors  atomic.Int64
+}
+
+// New creates a Log writing to w.
+func New(w io.Writer) *Log {
+	return &Log{w: w}
+}
+
+// Stats returns the current counters.
+func (l *Log) Stats() Stats {
+	return Stats{Written: l.written.Load(), Errors: l.errors.Load()}
+}
+
+// Append writes event. Failures are logged and counted rather than returned, as the
+// change the event records has already been committed.
+func (l *Log) Append(event model.DomainEvent) {
+	b, err := json.Marshal(line{Stream: Stream, DomainEvent: event})
+	if err == nil {
+		l.mu.Lock()
+		_, err = l.w.Write(append(b, '\n'))
+		l.mu.Unlock()
+	}
+	if err != nil {
+		l.errors.Add(1)
+		log.Error().Err(err).Str("event", event.Type).Msg("failed to write domain event")
+		return
+	}
+	l.written.Add(1)
+}
@@ -0,0 +1,70 @@
+package eventlog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/accesslog"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestLog_Append(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf)
+	at := time.Date(2024, 11, 29, 9, 0, 0, 0, time.UTC)
+
+	l.Append(model.DomainEvent{
+		Type:       model.CouponEventCreated,
+		OccurredAt: at,
+		Coupon:     &model.CouponSummary{Name: "PROMO", Amount: 10, RemainingAmount: 10, Tags: []string{}},
+	})
+	l.Append(model.DomainEvent{
+		Type:       model.CouponEventClaimed,
+		OccurredAt: at,
+		Claim:      &model.ClaimReceipt{UserID: "user_001", CouponName: "PROMO", ClaimSequence: 1},
+	})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"stream": "domain_event", "type": "coupon.created", "occurred_at": "2024-11-29T09:00:00Z",
+		"coupon": {"name": "PROMO", "amount": 10, "remaining_amount": 10, "tags": []}}`, lines[0])
+	assert.JSONEq(t, `{"stream": "domain_event", "type": "coupon.claimed", "occurred_at": "2024-11-29T09:00:00Z",
+		"claim": {"user_id": "user_001", "coupon_name": "PROMO", "claim_sequence": 1}}`, lines[1])
+	assert.Equal(t, Stats{Written: 2}, l.Stats())
+}
+
+func TestLog_AppendError(t *testing.T) {
+	l := New(failingWriter{})
+
+	l.Append(model.DomainEvent{Type: model.CouponEventClaimed})
+
+	assert.Equal(t, Stats{Errors: 1}, l.Stats())
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	w, err := Open(Options{Sink: accesslog.File, Path: path, MaxSize: 1 << 20})
+	require.NoError(t, err)
+	New(w).Append(model.DomainEvent{Type: model.CouponEventClaimed})
+	require.NoError(t, w.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"coupon.claimed"`)
+
+	_, err = Open(Options{Sink: accesslog.Syslog})
+	assert.ErrorContains(t, err, `unsupported event log sink "syslog"`)
+}
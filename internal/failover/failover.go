@@ -0,0 +1,167 @@
+// Package failover samples which database server answers and when it started, and
+// reports a failover when either changes: another server answering at the configured
+// host (a promoted replica, a DNS flip) or the same server restarted. Both drop every
+// pooled connection and show up as a burst of slow or failed requests, which the
+// "database failover detected" event logged here explains after the incident.
+package failover
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// Statuses, as reported by Status.
+const (
+	StatusOK       = "ok"
+	StatusDetected = "failover_detected" // A failover was detected within the hold
+)
+
+// Reasons of an Event.
+const (
+	ReasonServerChanged   = "server_changed"   // Another server answers
+	ReasonServerRestarted = "server_restarted" // The same server started again
+)
+
+// restartTolerance is how much later a server's start time may read before it counts
+// as a restart. MySQL only reports its uptime in seconds, so the start time derived
+// from it moves by a second or so between samples.
+const restartTolerance = 5 * time.Second
+
+// Event is a detected failover.
+type Event struct {
+	Reason     string                  `json:"reason"`
+	From       database.ServerIdentity `json:"from"`
+	To         database.ServerIdentity `json:"to"`
+	DetectedAt time.Time               `json:"detected_at"`
+}
+
+// Stats is a snapshot of the watcher.
+type Stats struct {
+	Status       string                  `json:"status"`
+	Server       database.ServerIdentity `json:"server"`        // Zero before the first sample
+	Failovers    int64                   `json:"failovers"`     // Server changes and restarts detected
+	SampleErrors int64                   `json:"sample_errors"` // Samples the server could not be identified in
+	LastEvent    *Event                  `json:"last_event"`    // nil before the first failover
+}
+
+// Watcher compares the identity of the database server between samples. It is safe
+// for concurrent use.
+type Watcher struct {
+	identify func(ctx context.Context) (database.ServerIdentity, error)
+	hold     time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	server    database.ServerIdentity
+	sampled   bool
+	lastEvent *Event
+
+	failovers    atomic.Int64
+	sampleErrors atomic.Int64
+}
+
+// New creates a Watcher of the server identify returns, reporting StatusDetected for
+// hold after each failover.
+func New(identify func(ctx context.Context) (database.ServerIdentity, error), hold time.Duration) *Watcher {
+	return &Watcher{identify: identify, hold: hold, now: time.Now}
+}
+
+// Status returns StatusDetected within the hold of the last failover, StatusOK otherwise.
+func (w *Watcher) Status() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.statusLocked()
+}
+
+// statusLocked is Status. The caller holds w.mu.
+func (w *Watcher) statusLocked() string {
+	if w.lastEvent != nil && w.now().Before(w.lastEvent.DetectedAt.Add(w.hold)) {
+		return StatusDetected
+	}
+	return StatusOK
+}
+
+// Stats returns the server last sampled and the failovers detected.
+func (w *Watcher) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := Stats{
+		Status:       w.statusLocked(),
+		Server:       w.server,
+		Failovers:    w.failovers.Load(),
+		SampleErrors: w.sampleErrors.Load(),
+	}
+	if w.lastEvent != nil {
+		event := *w.lastEvent
+		stats.LastEvent = &event
+	}
+	return stats
+}
+
+// Sample identifies the server, logging a "database failover detected" event when it
+// is not the one of the previous sample or has restarted since. A server that cannot
+// be identified (e.g. during the failover) is skipped: the next sample compares
+// against the last server identified.
+func (w *Watcher) Sample(ctx context.Context) {
+	server, err := w.identify(ctx)
+	if err != nil {
+		w.sampleErrors.Add(1)
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Msg("failed to identify the database server")
+		}
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	previous, sampled := w.server, w.sampled
+	w.server, w.sampled = server, true
+	if !sampled {
+		log.Info().
+			Str("db_server", server.Addr).
+			Time("db_server_started_at", server.StartedAt).
+			Msg("database server identified")
+		return
+	}
+
+	var reason string
+	switch {
+	case server.Addr != previous.Addr:
+		reason = ReasonServerChanged
+	case server.StartedAt.Sub(previous.StartedAt) > restartTolerance:
+		reason = ReasonServerRestarted
+	default:
+		return
+	}
+	w.lastEvent = &Event{Reason: reason, From: previous, To: server, DetectedAt: w.now()}
+	w.failovers.Add(1)
+	log.Warn().
+		Str("event", "database_failover").
+		Str("reason", reason).
+		Str("previous_db_server", previous.Addr).
+		Time("previous_db_server_started_at", previous.StartedAt).
+		Str("db_server", server.Addr).
+		Time("db_server_started_at", server.StartedAt).
+		Msg("database failover detected")
+}
+
+// Run samples the server now and then every interval until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.Sample(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
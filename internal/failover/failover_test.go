@@ -0,0 +1,111 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// fakeServer returns the identity or error set by the test.
+type fakeServer struct {
+	id  database.ServerIdentity
+	err error
+}
+
+func (f *fakeServer) identify(context.Context) (database.ServerIdentity, error) {
+	return f.id, f.err
+}
+
+func newWatcher(server *fakeServer, now *time.Time) *Watcher {
+	w := New(server.identify, 15*time.Minute)
+	w.now = func() time.Time { return *now }
+	return w
+}
+
+func TestWatcher_ServerChanged(t *testing.T) {
+	started := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := started.Add(24 * time.Hour)
+	server := &fakeServer{id: database.ServerIdentity{Addr: "10.0.0.1:5432", StartedAt: started}}
+	w := newWatcher(server, &now)
+
+	w.Sample(context.Background())
+	w.Sample(context.Background())
+	assert.Equal(t, StatusOK, w.Status())
+	assert.Equal(t, server.id, w.Stats().Server)
+	assert.Nil(t, w.Stats().LastEvent)
+
+	// The replica was promoted
+	previous := server.id
+	server.id = database.ServerIdentity{Addr: "10.0.0.2:5432", StartedAt: started.Add(-time.Hour)}
+	w.Sample(context.Background())
+	assert.Equal(t, StatusDetected, w.Status())
+	stats := w.Stats()
+	assert.Equal(t, int64(1), stats.Failovers)
+	require.NotNil(t, stats.LastEvent)
+	assert.Equal(t, Event{Reason: ReasonServerChanged, From: previous, To: server.id, DetectedAt: now}, *stats.LastEvent)
+
+	// Counted once, and reported until the hold has passed
+	w.Sample(context.Background())
+	assert.Equal(t, int64(1), w.Stats().Failovers)
+	now = now.Add(15 * time.Minute)
+	assert.Equal(t, StatusOK, w.Status())
+}
+
+func TestWatcher_ServerRestarted(t *testing.T) {
+	started := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := started.Add(time.Hour)
+	server := &fakeServer{id: database.ServerIdentity{Addr: "db:3306", StartedAt: started}}
+	w := newWatcher(server, &now)
+	w.Sample(context.Background())
+
+	// A start time derived from an uptime in seconds jitters: not a restart
+	server.id.StartedAt = started.Add(time.Second)
+	w.Sample(context.Background())
+	assert.Equal(t, StatusOK, w.Status())
+
+	server.id.StartedAt = now.Add(-30 * time.Second)
+	w.Sample(context.Background())
+	assert.Equal(t, StatusDetected, w.Status())
+	require.NotNil(t, w.Stats().LastEvent)
+	assert.Equal(t, ReasonServerRestarted, w.Stats().LastEvent.Reason)
+}
+
+func TestWatcher_SampleError(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	server := &fakeServer{id: database.ServerIdentity{Addr: "10.0.0.1:5432", StartedAt: now}}
+	w := newWatcher(server, &now)
+	w.Sample(context.Background())
+
+	// The server is unreachable mid-failover: compared with the last one identified after
+	server.err = errors.New("connection refused")
+	w.Sample(context.Background())
+	assert.Equal(t, int64(1), w.Stats().SampleErrors)
+	assert.Equal(t, StatusOK, w.Status())
+
+	server.err = nil
+	server.id.Addr = "10.0.0.2:5432"
+	w.Sample(context.Background())
+	assert.Equal(t, StatusDetected, w.Status())
+	assert.Equal(t, "10.0.0.1:5432", w.Stats().LastEvent.From.Addr)
+}
+
+func TestWatcher_Run(t *testing.T) {
+	server := &fakeServer{id: database.ServerIdentity{Addr: "10.0.0.1:5432"}}
+	w := New(server.identify, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return w.Stats().Server.Addr != "" }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
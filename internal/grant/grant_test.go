@@ -0,0 +1,74 @@
+package grant
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+
+func newTestSigner(secret string) *Signer {
+	s := New([]byte(secret))
+	s.now = func() time.Time { return testNow }
+	return s
+}
+
+func TestSigner_RoundTrip(t *testing.T) {
+	s := newTestSigner("0123456789abcdef0123456789abcdef")
+	want := Claims{UserID: "user_1", CouponName: "PROMO", Channel: "app", ExpiresAt: testNow.Add(time.Minute).Unix()}
+
+	token, err := s.Sign(want)
+	require.NoError(t, err)
+	got, err := s.Verify(token)
+
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, Stats{Verified: 1}, s.Stats())
+}
+
+func TestSigner_Expired(t *testing.T) {
+	s := newTestSigner("0123456789abcdef0123456789abcdef")
+	token, err := s.Sign(Claims{UserID: "user_1", CouponName: "PROMO", ExpiresAt: testNow.Unix()})
+	require.NoError(t, err)
+
+	_, err = s.Verify(token)
+
+	assert.ErrorIs(t, err, ErrExpired)
+	assert.Equal(t, Stats{Expired: 1}, s.Stats())
+}
+
+func TestSigner_Rejects(t *testing.T) {
+	s := newTestSigner("0123456789abcdef0123456789abcdef")
+	exp := testNow.Add(time.Minute).Unix()
+	valid, err := s.Sign(Claims{UserID: "user_1", CouponName: "PROMO", ExpiresAt: exp})
+	require.NoError(t, err)
+	otherKey, err := newTestSigner("another-secret-another-secret-xx").Sign(Claims{UserID: "user_1", CouponName: "PROMO", ExpiresAt: exp})
+	require.NoError(t, err)
+	noExpiry, err := s.Sign(Claims{UserID: "user_1", CouponName: "PROMO"})
+	require.NoError(t, err)
+
+	parts := strings.Split(valid, ".")
+	tampered := parts[0] + "." + encode([]byte(`{"sub":"user_2","coupon":"PROMO","exp":9999999999}`)) + "." + parts[2]
+	unsigned := encode([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + "."
+
+	for name, token := range map[string]string{
+		"empty":          "",
+		"not a jwt":      "abc",
+		"other key":      otherKey,
+		"tampered":       tampered,
+		"alg none":       unsigned,
+		"missing expiry": noExpiry,
+		"bad signature":  parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString([]byte("x")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := s.Verify(token)
+			assert.ErrorIs(t, err, ErrInvalid)
+		})
+	}
+	assert.Equal(t, int64(7), s.Stats().Rejected)
+}
@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// AdminServiceInterface defines the interface for administrative coupon operations.
+type AdminServiceInterface interface {
+	Apply(ctx context.Context, m *model.Manifest, dryRun bool) (*model.ApplyReport, error)
+	ImportClaims(ctx context.Context, req *model.ClaimImportRequest) (*model.ClaimImportReport, error)
+	StreamImportClaims(ctx context.Context, req *model.ClaimImportRequest, each func(model.ClaimImportResult) error) (*model.ClaimImportReport, error)
+	Terminate(ctx context.Context, name, reason string) (*model.CouponResponse, error)
+	AddNote(ctx context.Context, name string, req *model.CouponNoteRequest) (*model.CouponChangelogResponse, error)
+	Changelog(ctx context.Context, name string, after *model.AuditPosition, limit int) (*model.CouponChangelogResponse, error)
+}
+
+// AdminHandler handles HTTP requests for administrative operations.
+type AdminHandler struct {
+	service   AdminServiceInterface
+	validator *validator.Validate
+}
+
+// NewAdminHandler creates a new AdminHandler with the given service and validator.
+func NewAdminHandler(svc AdminServiceInterface, v *validator.Validate) *AdminHandler {
+	return &AdminHandler{service: svc, validator: v}
+}
+
+// mimeNDJSON is the media type of newline-delimited JSON: one JSON value per line.
+const mimeNDJSON = "application/x-ndjson"
+
+// isYAMLContentType reports whether the request body should be decoded as YAML.
+func isYAMLContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch strings.TrimSpace(mediaType) {
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return true
+	}
+	return false
+}
+
+// formatManifestValidationError converts validator errors on a manifest to AC-required messages.
+func formatManifestValidationError(err error) string {
+	var ve validator.ValidationErrors
+	if errors.As(err, &ve) && len(ve) > 0 && ve[0].Field() == "Coupons" && ve[0].Tag() == "max" {
+		return "invalid request: coupons exceeds maximum of 1000 entries"
+	}
+	return formatValidationError(err)
+}
+
+// ApplyManifest handles POST /api/admin/apply requests to reconcile coupons with a manifest.
+// Accepts JSON, or YAML when sent with a YAML content type. With ?dry_run=true the diff
+// report is returned without writing anything.
+func (h *AdminHandler) ApplyManifest(c *fiber.Ctx) error {
+	manifest, err := model.ParseManifest(c.Body(), isYAMLContentType(c.Get(fiber.HeaderContentType)))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(manifest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatManifestValidationError(err)})
+	}
+
+	if name := manifest.DuplicateName(); name != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: duplicate coupon name in manifest: " + name,
+		})
+	}
+
+	report, err := h.service.Apply(c.UserContext(), manifest, c.QueryBool("dry_run"))
+	if err != nil {
+		if errors.Is(err, apperr.ErrManifestConflict) && report != nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   "manifest cannot be applied",
+				"changes": report.Changes,
+			})
+		}
+		if errors.Is(err, apperr.ErrCouponExists) {
+			// Coupons being created are not locked by the apply: one was created
+			// concurrently, or is deleted and not yet purged
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "manifest cannot be applied: a coupon it creates already exists or is deleted",
+			})
+		}
+		if msg, ok := couponConfigErrorMessage(err); ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+		}
+		names := make([]string, 0, len(manifest.Coupons))
+		for _, coupon := range manifest.Coupons {
+			names = append(names, coupon.Name)
+		}
+		log.Error().
+			Str("error", redact.Error(err, names...)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("method", c.Method()).
+			Str("path", logPath(c)).
+			Int("coupons", len(manifest.Coupons)).
+			Msg("failed to apply manifest")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	return c.JSON(report)
+}
+
+// formatImportValidationError converts validator errors on a claim import to messages
+// naming the offending entry, e.g. "invalid request: claims[3]: user_id is required".
+func formatImportValidationError(err error) string {
+	var ve validator.ValidationErrors
+	if !errors.As(err, &ve) || len(ve) == 0 {
+		return "invalid request"
+	}
+	if ve[0].Field() == "Claims" {
+		return "invalid request: claims must contain between 1 and 5000 entries"
+	}
+
+	// Entry errors are reported under a Claims[i] namespace
+	namespace := ve[0].Namespace()
+	entry := namespace[strings.Index(namespace, "Claims["):strings.LastIndex(namespace, ".")]
+	msg := strings.TrimPrefix(formatClaimValidationError(err), "invalid request: ")
+	return "invalid request: " + strings.ToLower(entry[:1]) + entry[1:] + ": " + msg
+}
+
+// ImportClaims handles POST /api/admin/claims/import requests to backfill historical
+// claims, e.g. from a legacy promo platform. Claims are committed in chunks; if the
+// import fails part way, the response reports how many claims were processed and the
+// same request can be sent again to resume (claims already imported are skipped).
+//
+// With Accept: application/x-ndjson the response streams the result of each claim as a
+// line once its chunk is committed, instead of a report when the import finishes,
+// followed by a summary line, or an error line if the import is interrupted.
+func (h *AdminHandler) ImportClaims(c *fiber.Ctx) error {
+	var req model.ClaimImportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatImportValidationError(err)})
+	}
+	now := time.Now()
+	for i, claim := range req.Claims {
+		if claim.ClaimedAt != nil && claim.ClaimedAt.After(now) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("invalid request: claims[%d]: claimed_at cannot be in the future", i),
+			})
+		}
+	}
+
+	if c.Accepts(fiber.MIMEApplicationJSON, mimeNDJSON) == mimeNDJSON {
+		return h.streamImportClaims(c, &req)
+	}
+
+	logged := newImportLog(c, len(req.Claims))
+	report, err := h.service.ImportClaims(c.UserContext(), &req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(logged.failed(report, err))
+	}
+	logged.finished(report)
+	return c.JSON(report)
+}
+
+// streamImportClaims streams the results of an import as NDJSON. The body is written
+// after the handler returns, when route timeouts and request deadlines have already
+// cancelled the request context, so the import runs on a context keeping its values and
+// deadline that is cancelled only once the import ends.
+func (h *AdminHandler) streamImportClaims(c *fiber.Ctx, req *model.ClaimImportRequest) error {
+	base := context.WithoutCancel(c.UserContext())
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if deadline, ok := c.UserContext().Deadline(); ok {
+		ctx, cancel = context.WithDeadline(base, deadline)
+	} else {
+		ctx, cancel = context.WithCancel(base)
+	}
+	logged := newImportLog(c, len(req.Claims))
+
+	c.Set(fiber.HeaderContentType, mimeNDJSON)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		enc := json.NewEncoder(w)
+		report, err := h.service.StreamImportClaims(ctx, req, func(result model.ClaimImportResult) error {
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+			return w.Flush()
+		})
+		if err != nil {
+			_ = enc.Encode(logged.failed(report, err))
+		} else {
+			logged.finished(report)
+			_ = enc.Encode(fiber.Map{"summary": fiber.Map{
+				"total":     report.Total,
+				"processed": report.Processed,
+				"imported":  report.Imported,
+				"skipped":   report.Skipped,
+				"rejected":  len(report.Rejected),
+			}})
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
+
+// importLog logs the outcome of a claim import. It copies what it needs from the
+// request, so it can be used after the handler returned.
+type importLog struct {
+	requestID, method, path string
+	claims                  int
+}
+
+func newImportLog(c *fiber.Ctx, claims int) importLog {
+	return importLog{
+		requestID: strings.Clone(c.GetRespHeader("X-Request-ID")),
+		method:    strings.Clone(c.Method()),
+		path:      strings.Clone(logPath(c)),
+		claims:    claims,
+	}
+}
+
+// failed logs a failed import and returns the error response body.
+func (l importLog) failed(report *model.ClaimImportReport, err error) fiber.Map {
+	processed := 0
+	if report != nil {
+		processed = report.Processed
+	}
+	log.Error().
+		Str("error", redact.Error(err)).
+		Str("request_id", l.requestID).
+		Str("method", l.method).
+		Str("path", l.path).
+		Int("claims", l.claims).
+		Int("processed", processed).
+		Msg("failed to import claims")
+	return fiber.Map{"error": "claim import interrupted", "processed": processed}
+}
+
+func (l importLog) finished(report *model.ClaimImportReport) {
+	log.Info().
+		Str("request_id", l.requestID).
+		Int("imported", report.Imported).
+		Int("skipped", report.Skipped).
+		Int("rejected", len(report.Rejected)).
+		Msg("claims imported")
+}
+
+// TerminateCoupon handles POST /api/admin/coupons/:name/terminate requests to stop all
+// claims of a coupon at once. The JSON body is optional; its reason is recorded in the
+// audit log.
+func (h *AdminHandler) TerminateCoupon(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: name is required",
+		})
+	}
+
+	var req model.TerminateCouponRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+		if err := h.validator.Struct(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatValidationError(err)})
+		}
+	}
+
+	coupon, err := h.service.Terminate(c.UserContext(), name, req.Reason)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		log.Error().Str("error", redact.Error(err, name)).Str("coupon_name", redact.Value(name)).Msg("failed to terminate coupon")
+		return internalError(c, err)
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("coupon_name", redact.Value(name)).
+		Int("remaining_amount", coupon.RemainingAmount).
+		Msg("coupon terminated by admin")
+	return c.JSON(coupon)
+}
+
+// formatNoteValidationError converts validator errors on a coupon note request.
+func formatNoteValidationError(err error) string {
+	var ve validator.ValidationErrors
+	if !errors.As(err, &ve) || len(ve) == 0 {
+		return "invalid request"
+	}
+	if ve[0].Field() == "Author" {
+		return "invalid request: author cannot be whitespace only and must be at most 255 characters"
+	}
+	if ve[0].Tag() == "max" {
+		return "invalid request: note exceeds maximum length of 2000"
+	}
+	return "invalid request: note is required"
+}
+
+// AddCouponNote handles POST /api/coupons/:name/notes requests recording an operator's
+// note on a coupon, e.g. what was changed during an incident and why. Responds 201 with
+// the coupon's changelog.
+func (h *AdminHandler) AddCouponNote(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: name is required",
+		})
+	}
+
+	var req model.CouponNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatNoteValidationError(err)})
+	}
+
+	changelog, err := h.service.AddNote(c.UserContext(), name, &req)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		log.Error().Str("error", redact.Error(err, name)).Str("coupon_name", redact.Value(name)).Msg("failed to add coupon note")
+		return internalError(c, err)
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("coupon_name", redact.Value(name)).
+		Msg("coupon note added")
+	return c.Status(fiber.StatusCreated).JSON(changelog)
+}
+
+// CouponChangelog handles GET /api/admin/coupons/:name/changelog requests. It returns
+// a page of the coupon's notes and terminations, newest first, of up to ?limit= entries
+// after ?cursor=.
+func (h *AdminHandler) CouponChangelog(c *fiber.Ctx) error {
+	name := c.Params("name")
+	limit, key, msg := pageQuery(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	var after *model.AuditPosition
+	if key != "" {
+		pos, err := model.ParseAuditPosition(key)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msgInvalidCursor})
+		}
+		after = &pos
+	}
+
+	changelog, err := h.service.Changelog(c.UserContext(), name, after, limit)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		log.Error().Str("error", redact.Error(err, name)).Str("coupon_name", redact.Value(name)).Msg("failed to get coupon changelog")
+		return internalError(c, err)
+	}
+	return c.JSON(changelog)
+}
@@ -0,0 +1,583 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+)
+
+// mockAdminService is a mock implementation of AdminServiceInterface.
+type mockAdminService struct {
+	applyFn     func(ctx context.Context, m *model.Manifest, dryRun bool) (*model.ApplyReport, error)
+	importFn    func(ctx context.Context, req *model.ClaimImportRequest) (*model.ClaimImportReport, error)
+	streamFn    func(ctx context.Context, req *model.ClaimImportRequest, each func(model.ClaimImportResult) error) (*model.ClaimImportReport, error)
+	terminateFn func(ctx context.Context, name, reason string) (*model.CouponResponse, error)
+	addNoteFn   func(ctx context.Context, name string, req *model.CouponNoteRequest) (*model.CouponChangelogResponse, error)
+	changelogFn func(ctx context.Context, name string, after *model.AuditPosition, limit int) (*model.CouponChangelogResponse, error)
+}
+
+func (m *mockAdminService) Apply(ctx context.Context, manifest *model.Manifest, dryRun bool) (*model.ApplyReport, error) {
+	if m.applyFn != nil {
+		return m.applyFn(ctx, manifest, dryRun)
+	}
+	return &model.ApplyReport{DryRun: dryRun, Changes: []model.ApplyChange{}}, nil
+}
+
+func (m *mockAdminService) ImportClaims(ctx context.Context, req *model.ClaimImportRequest) (*model.ClaimImportReport, error) {
+	if m.importFn != nil {
+		return m.importFn(ctx, req)
+	}
+	n := len(req.Claims)
+	return &model.ClaimImportReport{Total: n, Processed: n, Imported: n, Rejected: []model.ClaimImportRejection{}}, nil
+}
+
+func (m *mockAdminService) StreamImportClaims(ctx context.Context, req *model.ClaimImportRequest, each func(model.ClaimImportResult) error) (*model.ClaimImportReport, error) {
+	if m.streamFn != nil {
+		return m.streamFn(ctx, req, each)
+	}
+	for i, claim := range req.Claims {
+		if err := each(model.ClaimImportResult{Index: i, UserID: claim.UserID, CouponName: claim.CouponName, Status: model.ClaimImportImported}); err != nil {
+			return nil, err
+		}
+	}
+	return m.ImportClaims(ctx, req)
+}
+
+func (m *mockAdminService) Terminate(ctx context.Context, name, reason string) (*model.CouponResponse, error) {
+	if m.terminateFn != nil {
+		return m.terminateFn(ctx, name, reason)
+	}
+	return &model.CouponResponse{Name: name, Disabled: true}, nil
+}
+
+func (m *mockAdminService) AddNote(ctx context.Context, name string, req *model.CouponNoteRequest) (*model.CouponChangelogResponse, error) {
+	if m.addNoteFn != nil {
+		return m.addNoteFn(ctx, name, req)
+	}
+	return &model.CouponChangelogResponse{CouponName: name, Page: model.Page[model.ChangelogEntry]{Items: []model.ChangelogEntry{}}}, nil
+}
+
+func (m *mockAdminService) Changelog(ctx context.Context, name string, after *model.AuditPosition, limit int) (*model.CouponChangelogResponse, error) {
+	if m.changelogFn != nil {
+		return m.changelogFn(ctx, name, after, limit)
+	}
+	return &model.CouponChangelogResponse{CouponName: name, Page: model.Page[model.ChangelogEntry]{Items: []model.ChangelogEntry{}}}, nil
+}
+
+func setupAdminTestApp(mockSvc *mockAdminService) *fiber.App {
+	app := fiber.New()
+	h := NewAdminHandler(mockSvc, validator.New())
+	app.Post("/api/admin/apply", h.ApplyManifest)
+	app.Post("/api/admin/claims/import", h.ImportClaims)
+	app.Post("/api/admin/coupons/:name/terminate", h.TerminateCoupon)
+	app.Post("/api/coupons/:name/notes", h.AddCouponNote)
+	app.Get("/api/admin/coupons/:name/changelog", h.CouponChangelog)
+	return app
+}
+
+func TestApplyManifest_JSON(t *testing.T) {
+	var received *model.Manifest
+	mockSvc := &mockAdminService{
+		applyFn: func(ctx context.Context, m *model.Manifest, dryRun bool) (*model.ApplyReport, error) {
+			received = m
+			assert.False(t, dryRun)
+			return &model.ApplyReport{Changes: []model.ApplyChange{
+				{Name: "BF_APP", Action: model.ApplyActionCreate},
+			}}, nil
+		},
+	}
+	app := setupAdminTestApp(mockSvc)
+
+	body := `{"coupons": [{"name": "BF_APP", "amount": 100, "tags": ["blackfriday"]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/apply", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NotNil(t, received)
+	require.Len(t, received.Coupons, 1)
+	assert.Equal(t, 100, *received.Coupons[0].Amount)
+	assert.Equal(t, []string{"blackfriday"}, received.Coupons[0].Tags)
+
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"dry_run": false, "changes": [{"name": "BF_APP", "action": "create"}]}`, string(respBody))
+}
+
+func TestApplyManifest_YAMLDryRun(t *testing.T) {
+	var received *model.Manifest
+	mockSvc := &mockAdminService{
+		applyFn: func(ctx context.Context, m *model.Manifest, dryRun bool) (*model.ApplyReport, error) {
+			received = m
+			assert.True(t, dryRun)
+			return &model.ApplyReport{DryRun: true, Changes: []model.ApplyChange{}}, nil
+		},
+	}
+	app := setupAdminTestApp(mockSvc)
+
+	body := strings.Join([]string{
+		"coupons:",
+		"  - name: BF_APP",
+		"    amount: 100",
+		"    channels:",
+		"      app: 60",
+		"      web: 40",
+		"    tiers:",
+		"      - name: gold",
+		"        size: 10",
+	}, "\n")
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/apply?dry_run=true", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/yaml; charset=utf-8")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NotNil(t, received)
+	require.Len(t, received.Coupons, 1)
+	assert.Equal(t, map[string]int{"app": 60, "web": 40}, received.Coupons[0].Channels)
+	assert.Equal(t, []model.Tier{{Name: "gold", Size: 10}}, received.Coupons[0].Tiers)
+}
+
+func TestApplyManifest_Conflict(t *testing.T) {
+	mockSvc := &mockAdminService{
+		applyFn: func(ctx context.Context, m *model.Manifest, dryRun bool) (*model.ApplyReport, error) {
+			return &model.ApplyReport{Changes: []model.ApplyChange{
+				{Name: "BF_APP", Action: model.ApplyActionConflict, Reason: "amount cannot be decreased"},
+			}}, apperr.ErrManifestConflict
+		},
+	}
+	app := setupAdminTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/apply", bytes.NewBufferString(`{"coupons": [{"name": "BF_APP", "amount": 1}]}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{
+		"error": "manifest cannot be applied",
+		"changes": [{"name": "BF_APP", "action": "conflict", "reason": "amount cannot be decreased"}]
+	}`, string(respBody))
+}
+
+func TestApplyManifest_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		svcErr      error
+		wantStatus  int
+		wantError   string
+	}{
+		{"malformed json", "application/json", `{invalid`, nil, fiber.StatusBadRequest, "invalid request body"},
+		{"malformed yaml", "application/yaml", "coupons: [", nil, fiber.StatusBadRequest, "invalid request body"},
+		{"missing amount", "application/json", `{"coupons": [{"name": "A"}]}`, nil, fiber.StatusBadRequest, "invalid request: amount is required"},
+		{"invalid tier", "application/json", `{"coupons": [{"name": "A", "amount": 5, "tiers": [{"name": "gold"}]}]}`, nil, fiber.StatusBadRequest, "invalid request: tier size must be at least 1"},
+		{"duplicate name", "application/json", `{"coupons": [{"name": "A", "amount": 1}, {"name": "A", "amount": 2}]}`, nil, fiber.StatusBadRequest, "invalid request: duplicate coupon name in manifest: A"},
+		{"bad channel split", "application/json", `{"coupons": [{"name": "A", "amount": 10, "channels": {"app": 50}}]}`, apperr.ErrInvalidChannelQuotas, fiber.StatusBadRequest, "invalid request: channel percentages must sum to 100"},
+		{"coupon exists", "application/json", `{"coupons": [{"name": "A", "amount": 1}]}`, apperr.ErrCouponExists, fiber.StatusConflict, "manifest cannot be applied: a coupon it creates already exists or is deleted"},
+		{"internal error", "application/json", `{"coupons": []}`, errors.New("database connection failed"), fiber.StatusInternalServerError, "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockAdminService{
+				applyFn: func(ctx context.Context, m *model.Manifest, dryRun bool) (*model.ApplyReport, error) {
+					return nil, tt.svcErr
+				},
+			}
+			app := setupAdminTestApp(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/apply", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.wantError, result["error"])
+		})
+	}
+}
+
+func TestApplyManifest_TooManyCoupons(t *testing.T) {
+	coupons := make([]string, 1001)
+	for i := range coupons {
+		coupons[i] = `{"name": "C` + strings.Repeat("x", i%5) + `", "amount": 1}`
+	}
+	app := setupAdminTestApp(&mockAdminService{})
+
+	body := `{"coupons": [` + strings.Join(coupons, ",") + `]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/apply", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "invalid request: coupons exceeds maximum of 1000 entries", result["error"])
+}
+
+func postImport(t *testing.T, app *fiber.App, body string) (*http.Response, string) {
+	t.Helper()
+	return postImportAccept(t, app, body, "")
+}
+
+func postImportAccept(t *testing.T, app *fiber.App, body, accept string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/claims/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp, string(respBody)
+}
+
+func TestImportClaims_Success(t *testing.T) {
+	var received *model.ClaimImportRequest
+	mockSvc := &mockAdminService{
+		importFn: func(ctx context.Context, req *model.ClaimImportRequest) (*model.ClaimImportReport, error) {
+			received = req
+			return &model.ClaimImportReport{
+				Total: 2, Processed: 2, Imported: 1,
+				Rejected: []model.ClaimImportRejection{{Index: 1, UserID: "u2", CouponName: "GONE", Reason: "coupon not found"}},
+			}, nil
+		},
+	}
+	app := setupAdminTestApp(mockSvc)
+
+	resp, body := postImport(t, app, `{"claims": [
+		{"user_id": "u1", "coupon_name": "LEGACY", "channel": "app", "claimed_at": "2024-11-29T10:00:00Z"},
+		{"user_id": "u2", "coupon_name": "GONE"}
+	]}`)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NotNil(t, received)
+	require.Len(t, received.Claims, 2)
+	assert.Equal(t, "app", received.Claims[0].Channel)
+	require.NotNil(t, received.Claims[0].ClaimedAt)
+	assert.Equal(t, 2024, received.Claims[0].ClaimedAt.Year())
+	assert.Nil(t, received.Claims[1].ClaimedAt)
+	assert.JSONEq(t, `{"total": 2, "processed": 2, "imported": 1, "skipped": 0,
+		"rejected": [{"index": 1, "user_id": "u2", "coupon_name": "GONE", "reason": "coupon not found"}]}`, body)
+}
+
+func TestImportClaims_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"malformed", `{"claims": [`, "invalid request body"},
+		{"empty", `{"claims": []}`, "invalid request: claims must contain between 1 and 5000 entries"},
+		{"missing_user", `{"claims": [{"user_id": "u1", "coupon_name": "A"}, {"coupon_name": "A"}]}`,
+			"invalid request: claims[1]: user_id is required"},
+		{"blank_channel", `{"claims": [{"user_id": "u1", "coupon_name": "A", "channel": " "}]}`,
+			"invalid request: claims[0]: channel cannot be whitespace only"},
+		{"future", `{"claims": [{"user_id": "u1", "coupon_name": "A", "claimed_at": "2999-01-01T00:00:00Z"}]}`,
+			"invalid request: claims[0]: claimed_at cannot be in the future"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockAdminService{
+				importFn: func(context.Context, *model.ClaimImportRequest) (*model.ClaimImportReport, error) {
+					t.Fatal("service must not be called")
+					return nil, nil
+				},
+			}
+			resp, body := postImport(t, setupAdminTestApp(mockSvc), tt.body)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			assert.JSONEq(t, `{"error": "`+tt.want+`"}`, body)
+		})
+	}
+}
+
+func TestImportClaims_TooMany(t *testing.T) {
+	claims := make([]model.ImportClaim, 5001)
+	for i := range claims {
+		claims[i] = model.ImportClaim{UserID: "u", CouponName: "A"}
+	}
+	body, err := json.Marshal(model.ClaimImportRequest{Claims: claims})
+	require.NoError(t, err)
+
+	resp, respBody := postImport(t, setupAdminTestApp(&mockAdminService{}), string(body))
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, respBody, "claims must contain between 1 and 5000 entries")
+}
+
+func TestImportClaims_Interrupted(t *testing.T) {
+	mockSvc := &mockAdminService{
+		importFn: func(context.Context, *model.ClaimImportRequest) (*model.ClaimImportReport, error) {
+			return &model.ClaimImportReport{Total: 2, Processed: 1}, errors.New("connection reset")
+		},
+	}
+
+	resp, body := postImport(t, setupAdminTestApp(mockSvc), `{"claims": [
+		{"user_id": "u1", "coupon_name": "A"}, {"user_id": "u2", "coupon_name": "A"}
+	]}`)
+
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	assert.JSONEq(t, `{"error": "claim import interrupted", "processed": 1}`, body)
+}
+
+func TestImportClaims_NDJSON(t *testing.T) {
+	var deadlineErr error
+	mockSvc := &mockAdminService{
+		importFn: func(context.Context, *model.ClaimImportRequest) (*model.ClaimImportReport, error) {
+			t.Fatal("streamed imports must not build a report response")
+			return nil, nil
+		},
+		streamFn: func(ctx context.Context, req *model.ClaimImportRequest, each func(model.ClaimImportResult) error) (*model.ClaimImportReport, error) {
+			deadlineErr = ctx.Err()
+			assert.NoError(t, each(model.ClaimImportResult{Index: 0, UserID: "u1", CouponName: "LEGACY", Status: model.ClaimImportImported}))
+			assert.NoError(t, each(model.ClaimImportResult{Index: 1, UserID: "u2", CouponName: "GONE", Status: model.ClaimImportRejected, Reason: "coupon not found"}))
+			return &model.ClaimImportReport{
+				Total: 2, Processed: 2, Imported: 1,
+				Rejected: []model.ClaimImportRejection{{Index: 1, UserID: "u2", CouponName: "GONE", Reason: "coupon not found"}},
+			}, nil
+		},
+	}
+	app := fiber.New()
+	app.Post("/api/admin/claims/import", RouteLimits(time.Minute, 0), NewAdminHandler(mockSvc, validator.New()).ImportClaims)
+
+	resp, body := postImportAccept(t, app, `{"claims": [
+		{"user_id": "u1", "coupon_name": "LEGACY"}, {"user_id": "u2", "coupon_name": "GONE"}
+	]}`, "application/x-ndjson")
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	assert.NoError(t, deadlineErr, "the import outlives the handler's request context")
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, `{"index": 0, "user_id": "u1", "coupon_name": "LEGACY", "status": "imported"}`, lines[0])
+	assert.JSONEq(t, `{"index": 1, "user_id": "u2", "coupon_name": "GONE", "status": "rejected", "reason": "coupon not found"}`, lines[1])
+	assert.JSONEq(t, `{"summary": {"total": 2, "processed": 2, "imported": 1, "skipped": 0, "rejected": 1}}`, lines[2])
+}
+
+func TestImportClaims_NDJSONInterrupted(t *testing.T) {
+	mockSvc := &mockAdminService{
+		streamFn: func(ctx context.Context, req *model.ClaimImportRequest, each func(model.ClaimImportResult) error) (*model.ClaimImportReport, error) {
+			assert.NoError(t, each(model.ClaimImportResult{Index: 0, UserID: "u1", CouponName: "A", Status: model.ClaimImportImported}))
+			return &model.ClaimImportReport{Total: 2, Processed: 1}, errors.New("connection reset")
+		},
+	}
+
+	resp, body := postImportAccept(t, setupAdminTestApp(mockSvc), `{"claims": [
+		{"user_id": "u1", "coupon_name": "A"}, {"user_id": "u2", "coupon_name": "A"}
+	]}`, "application/x-ndjson")
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode, "the status is sent before the import runs")
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"error": "claim import interrupted", "processed": 1}`, lines[1])
+}
+
+func TestImportClaims_NDJSONValidationError(t *testing.T) {
+	resp, body := postImportAccept(t, setupAdminTestApp(&mockAdminService{}), `{"claims": []}`, "application/x-ndjson")
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.JSONEq(t, `{"error": "invalid request: claims must contain between 1 and 5000 entries"}`, body)
+}
+
+func postTerminate(t *testing.T, app *fiber.App, name, body string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/coupons/"+name+"/terminate", bytes.NewBufferString(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp, string(respBody)
+}
+
+func TestTerminateCoupon_Success(t *testing.T) {
+	var gotName, gotReason string
+	mockSvc := &mockAdminService{
+		terminateFn: func(ctx context.Context, name, reason string) (*model.CouponResponse, error) {
+			gotName, gotReason = name, reason
+			return &model.CouponResponse{Name: name, Amount: 100, RemainingAmount: 40, Disabled: true}, nil
+		},
+	}
+
+	resp, body := postTerminate(t, setupAdminTestApp(mockSvc), "PROMO", `{"reason": "misconfigured amount"}`)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `"disabled":true`)
+	assert.Equal(t, "PROMO", gotName)
+	assert.Equal(t, "misconfigured amount", gotReason)
+}
+
+func TestTerminateCoupon_WithoutBody(t *testing.T) {
+	resp, _ := postTerminate(t, setupAdminTestApp(&mockAdminService{}), "PROMO", "")
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestTerminateCoupon_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+		wantError  string
+	}{
+		{"not found", "", apperr.ErrCouponNotFound, fiber.StatusNotFound, "coupon not found"},
+		{"malformed body", `{"reason":`, nil, fiber.StatusBadRequest, "invalid request body"},
+		{"reason too long", `{"reason": "` + strings.Repeat("x", 501) + `"}`, nil, fiber.StatusBadRequest, ""},
+		{"database error", "", errors.New("connection reset"), fiber.StatusInternalServerError, "internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockAdminService{
+				terminateFn: func(context.Context, string, string) (*model.CouponResponse, error) {
+					return nil, tt.err
+				},
+			}
+
+			resp, body := postTerminate(t, setupAdminTestApp(mockSvc), "PROMO", tt.body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantError != "" {
+				assert.JSONEq(t, `{"error": "`+tt.wantError+`"}`, body)
+			}
+		})
+	}
+}
+
+func TestAddCouponNote(t *testing.T) {
+	at := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	var gotName string
+	var gotReq *model.CouponNoteRequest
+	mockSvc := &mockAdminService{
+		addNoteFn: func(ctx context.Context, name string, req *model.CouponNoteRequest) (*model.CouponChangelogResponse, error) {
+			gotName, gotReq = name, req
+			return &model.CouponChangelogResponse{CouponName: name, Page: model.Page[model.ChangelogEntry]{Items: []model.ChangelogEntry{
+				{ID: 3, Action: model.AuditActionCouponNote, Details: map[string]any{"note": req.Note, "author": req.Author}, CreatedAt: at},
+			}}}, nil
+		},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/PROMO/notes", bytes.NewBufferString(`{"note": "raised stock to 2000", "author": "ops-alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := setupAdminTestApp(mockSvc).Test(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "PROMO", gotName)
+	assert.Equal(t, &model.CouponNoteRequest{Note: "raised stock to 2000", Author: "ops-alice"}, gotReq)
+	assert.JSONEq(t, `{"coupon_name": "PROMO", "items": [{"id": 3, "action": "coupon_note",
+		"details": {"note": "raised stock to 2000", "author": "ops-alice"}, "created_at": "2026-11-27T09:00:00Z"}]}`, string(body))
+}
+
+func TestAddCouponNote_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+		want   string
+	}{
+		{"malformed", `{"note": `, nil, fiber.StatusBadRequest, "invalid request body"},
+		{"missing_note", `{"author": "ops"}`, nil, fiber.StatusBadRequest, "invalid request: note is required"},
+		{"blank_note", `{"note": "  "}`, nil, fiber.StatusBadRequest, "invalid request: note is required"},
+		{"not_found", `{"note": "x"}`, apperr.ErrCouponNotFound, fiber.StatusNotFound, "coupon not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockAdminService{
+				addNoteFn: func(context.Context, string, *model.CouponNoteRequest) (*model.CouponChangelogResponse, error) {
+					return nil, tt.err
+				},
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons/PROMO/notes", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := setupAdminTestApp(mockSvc).Test(req)
+			require.NoError(t, err)
+			body, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.JSONEq(t, `{"error": "`+tt.want+`"}`, string(body))
+		})
+	}
+}
+
+func TestCouponChangelog(t *testing.T) {
+	var gotAfter *model.AuditPosition
+	var gotLimit int
+	mockSvc := &mockAdminService{
+		changelogFn: func(ctx context.Context, name string, after *model.AuditPosition, limit int) (*model.CouponChangelogResponse, error) {
+			gotAfter, gotLimit = after, limit
+			if name == "MISSING" {
+				return nil, apperr.ErrCouponNotFound
+			}
+			return &model.CouponChangelogResponse{CouponName: name, Page: model.Page[model.ChangelogEntry]{Items: []model.ChangelogEntry{}}}, nil
+		},
+	}
+	app := setupAdminTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/coupons/PROMO/changelog", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 100, gotLimit)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/coupons/PROMO/changelog?limit=5", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 5, gotLimit)
+
+	assert.Nil(t, gotAfter)
+
+	pos := model.AuditPosition{CreatedAt: time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC), ID: 3}
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/coupons/PROMO/changelog?cursor="+model.EncodeCursor(pos.Key()), nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NotNil(t, gotAfter)
+	assert.True(t, pos.CreatedAt.Equal(gotAfter.CreatedAt))
+	assert.Equal(t, int64(3), gotAfter.ID)
+
+	for _, query := range []string{"limit=1001", "cursor=" + model.EncodeCursor("PROMO")} {
+		resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/coupons/PROMO/changelog?"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/coupons/MISSING/changelog", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
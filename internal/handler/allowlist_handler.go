@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// AllowlistServiceInterface defines the interface for managing coupon allowlists.
+type AllowlistServiceInterface interface {
+	SetAllowlist(ctx context.Context, name string, entries []model.AllowlistEntry) (*model.AllowlistResponse, error)
+	Allowlist(ctx context.Context, name, afterUserID string, limit int) (*model.AllowlistResponse, error)
+	DeleteAllowlist(ctx context.Context, name string) error
+}
+
+// AllowlistHandler handles HTTP requests for coupon allowlists.
+type AllowlistHandler struct {
+	service   AllowlistServiceInterface
+	validator *validator.Validate
+}
+
+// NewAllowlistHandler creates a new AllowlistHandler with the given service and validator.
+func NewAllowlistHandler(svc AllowlistServiceInterface, v *validator.Validate) *AllowlistHandler {
+	return &AllowlistHandler{service: svc, validator: v}
+}
+
+// formatAllowlistValidationError converts validator errors on an allowlist request.
+func formatAllowlistValidationError(err error) string {
+	var ve validator.ValidationErrors
+	if !errors.As(err, &ve) || len(ve) == 0 {
+		return "invalid request"
+	}
+	if ve[0].Field() == "UserID" {
+		return "invalid request: user_id is required and must be at most 255 characters"
+	}
+	return "invalid request: entries must list 1 to 10000 users"
+}
+
+// allowlistNotFound responds 404 to a request for a coupon without an allowlist.
+func allowlistNotFound(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon allowlist not found"})
+}
+
+// SetAllowlist handles PUT /api/admin/coupons/:name/allowlist requests. The entries
+// replace the coupon's allowlist.
+func (h *AllowlistHandler) SetAllowlist(c *fiber.Ctx) error {
+	name := c.Params("name")
+	var req model.SetAllowlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatAllowlistValidationError(err)})
+	}
+
+	resp, err := h.service.SetAllowlist(c.UserContext(), name, req.Entries)
+	if err != nil {
+		if errors.Is(err, apperr.ErrInvalidRequest) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: entries must list each user_id once"})
+		}
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to set coupon allowlist")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("coupon_name", redact.Value(name)).
+		Int("entries", len(req.Entries)).
+		Msg("coupon allowlist set")
+
+	return c.JSON(resp)
+}
+
+// GetAllowlist handles GET /api/admin/coupons/:name/allowlist requests, a page of up to
+// ?limit= entries after ?cursor= at a time, in user ID order.
+func (h *AllowlistHandler) GetAllowlist(c *fiber.Ctx) error {
+	name := c.Params("name")
+	limit, after, msg := pageQuery(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+
+	resp, err := h.service.Allowlist(c.UserContext(), name, after, limit)
+	if err != nil {
+		if errors.Is(err, apperr.ErrAllowlistNotFound) {
+			return allowlistNotFound(c)
+		}
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to get coupon allowlist")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+	return c.JSON(resp)
+}
+
+// DeleteAllowlist handles DELETE /api/admin/coupons/:name/allowlist requests. The
+// coupon is open to every user again.
+func (h *AllowlistHandler) DeleteAllowlist(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if err := h.service.DeleteAllowlist(c.UserContext(), name); err != nil {
+		if errors.Is(err, apperr.ErrAllowlistNotFound) {
+			return allowlistNotFound(c)
+		}
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to delete coupon allowlist")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("coupon_name", redact.Value(name)).
+		Msg("coupon allowlist deleted")
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+)
+
+// mockAllowlistService is a mock implementation of AllowlistServiceInterface
+// knowing the coupon "PROMO" only, with an allowlist once set.
+type mockAllowlistService struct {
+	entries []model.AllowlistEntry
+	after   string
+	limit   int
+}
+
+func (m *mockAllowlistService) SetAllowlist(ctx context.Context, name string, entries []model.AllowlistEntry) (*model.AllowlistResponse, error) {
+	if name != "PROMO" {
+		return nil, apperr.ErrCouponNotFound
+	}
+	for i := range entries {
+		for _, e := range entries[:i] {
+			if e.UserID == entries[i].UserID {
+				return nil, apperr.ErrInvalidRequest
+			}
+		}
+	}
+	m.entries = entries
+	return &model.AllowlistResponse{CouponName: name, Page: model.Page[model.AllowlistEntry]{Items: entries}}, nil
+}
+
+func (m *mockAllowlistService) Allowlist(ctx context.Context, name, afterUserID string, limit int) (*model.AllowlistResponse, error) {
+	if name != "PROMO" || m.entries == nil {
+		return nil, apperr.ErrAllowlistNotFound
+	}
+	m.after, m.limit = afterUserID, limit
+	return &model.AllowlistResponse{CouponName: name, Page: model.Page[model.AllowlistEntry]{Items: m.entries}}, nil
+}
+
+func (m *mockAllowlistService) DeleteAllowlist(ctx context.Context, name string) error {
+	if name != "PROMO" || m.entries == nil {
+		return apperr.ErrAllowlistNotFound
+	}
+	m.entries = nil
+	return nil
+}
+
+func setupAllowlistTestApp(mockSvc *mockAllowlistService) *fiber.App {
+	app := fiber.New()
+	h := NewAllowlistHandler(mockSvc, validator.New())
+	app.Put("/api/admin/coupons/:name/allowlist", h.SetAllowlist)
+	app.Get("/api/admin/coupons/:name/allowlist", h.GetAllowlist)
+	app.Delete("/api/admin/coupons/:name/allowlist", h.DeleteAllowlist)
+	return app
+}
+
+func TestSetAllowlist(t *testing.T) {
+	mockSvc := &mockAllowlistService{}
+	app := setupAllowlistTestApp(mockSvc)
+
+	body := `{"entries": [{"user_id": "vip", "claim_by": "2024-11-29T10:00:00Z"}, {"user_id": "user"}]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/coupons/PROMO/allowlist", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Len(t, mockSvc.entries, 2)
+	assert.True(t, mockSvc.entries[0].ClaimBy.Equal(time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)))
+	assert.Nil(t, mockSvc.entries[1].ClaimBy)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"coupon_name": "PROMO", "items": [{"user_id": "vip", "claim_by": "2024-11-29T10:00:00Z"}, {"user_id": "user"}]}`,
+		string(respBody))
+}
+
+func TestSetAllowlist_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{"no entries", "/api/admin/coupons/PROMO/allowlist", `{"entries": []}`, fiber.StatusBadRequest,
+			"invalid request: entries must list 1 to 10000 users"},
+		{"too many entries", "/api/admin/coupons/PROMO/allowlist",
+			`{"entries": [` + strings.Repeat(`{"user_id": "u"},`, 10000) + `{"user_id": "u"}]}`, fiber.StatusBadRequest,
+			"invalid request: entries must list 1 to 10000 users"},
+		{"blank user", "/api/admin/coupons/PROMO/allowlist", `{"entries": [{"user_id": " "}]}`, fiber.StatusBadRequest,
+			"invalid request: user_id is required and must be at most 255 characters"},
+		{"repeated user", "/api/admin/coupons/PROMO/allowlist", `{"entries": [{"user_id": "a"}, {"user_id": "a"}]}`,
+			fiber.StatusBadRequest, "invalid request: entries must list each user_id once"},
+		{"bad deadline", "/api/admin/coupons/PROMO/allowlist", `{"entries": [{"user_id": "a", "claim_by": "tomorrow"}]}`,
+			fiber.StatusBadRequest, "invalid request body"},
+		{"unknown coupon", "/api/admin/coupons/OTHER/allowlist", `{"entries": [{"user_id": "a"}]}`, fiber.StatusNotFound,
+			"coupon not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockAllowlistService{}
+			app := setupAllowlistTestApp(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPut, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			respBody, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, `{"error": "`+tt.want+`"}`, string(respBody))
+			assert.Nil(t, mockSvc.entries)
+		})
+	}
+}
+
+func TestGetAndDeleteAllowlist(t *testing.T) {
+	mockSvc := &mockAllowlistService{entries: []model.AllowlistEntry{{UserID: "vip"}}}
+	app := setupAllowlistTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/coupons/PROMO/allowlist", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/api/admin/coupons/PROMO/allowlist", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		resp, err = app.Test(httptest.NewRequest(method, "/api/admin/coupons/PROMO/allowlist", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, method)
+	}
+}
+
+func TestGetAllowlist_Paging(t *testing.T) {
+	mockSvc := &mockAllowlistService{entries: []model.AllowlistEntry{{UserID: "vip"}}}
+	app := setupAllowlistTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/coupons/PROMO/allowlist?limit=10&cursor=dXNlcl8wMDE", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "user_001", mockSvc.after)
+	assert.Equal(t, 10, mockSvc.limit)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"coupon_name": "PROMO", "items": [{"user_id": "vip"}]}`, string(respBody))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/coupons/PROMO/allowlist?cursor=%25", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
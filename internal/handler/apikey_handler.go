@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apikey"
+)
+
+// APIKeyUsage defines the interface for reading the claim usage of API keys.
+type APIKeyUsage interface {
+	Usage(id string) (apikey.Usage, bool)
+}
+
+// APIKeyHandler handles HTTP requests for API keys.
+type APIKeyHandler struct {
+	keys APIKeyUsage
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler with the given keys.
+func NewAPIKeyHandler(keys APIKeyUsage) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+// GetUsage handles GET /api/admin/keys/:id/usage requests. It returns the claims the
+// key made this month and what is left of its monthly quota.
+func (h *APIKeyHandler) GetUsage(c *fiber.Ctx) error {
+	usage, ok := h.keys.Usage(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "API key not found"})
+	}
+	return c.JSON(usage)
+}
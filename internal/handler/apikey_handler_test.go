@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apikey"
+)
+
+// stubKeyUsage returns the usage of the keys it holds.
+type stubKeyUsage map[string]apikey.Usage
+
+func (s stubKeyUsage) Usage(id string) (apikey.Usage, bool) {
+	u, ok := s[id]
+	return u, ok
+}
+
+func TestGetAPIKeyUsage(t *testing.T) {
+	remaining := int64(58)
+	app := fiber.New()
+	app.Get("/api/admin/keys/:id/usage", NewAPIKeyHandler(stubKeyUsage{
+		"acme": {
+			ID: "acme", Tier: apikey.TierPremium, Month: "2026-10", Claims: 42, MonthlyQuota: 100,
+			Remaining: &remaining, ResetsAt: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}).GetUsage)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/keys/acme/usage", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"id":"acme","tier":"premium","month":"2026-10","claims":42,"monthly_quota":100,
+		"remaining":58,"resets_at":"2026-11-01T00:00:00Z"}`, string(body))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/keys/nobody/usage", nil))
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.JSONEq(t, `{"error":"API key not found"}`, string(body))
+}
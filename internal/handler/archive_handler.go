@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// CouponArchiveServiceInterface defines the interface for reading archived coupons.
+type CouponArchiveServiceInterface interface {
+	GetArchived(ctx context.Context, name string) (*model.ArchivedCoupon, error)
+}
+
+// ArchiveHandler handles HTTP requests for archived coupons.
+type ArchiveHandler struct {
+	service CouponArchiveServiceInterface
+	maxAge  time.Duration
+}
+
+// NewArchiveHandler creates a new ArchiveHandler with the given service, whose
+// responses may be cached for maxAge.
+func NewArchiveHandler(svc CouponArchiveServiceInterface, maxAge time.Duration) *ArchiveHandler {
+	return &ArchiveHandler{service: svc, maxAge: maxAge}
+}
+
+// GetArchivedCoupon handles GET /api/archive/coupons/:name requests. Unlike live
+// coupons, an archived coupon does not change, so it is served as immutable and
+// cacheable by shared caches for the configured max age, with an ETag of when it was
+// archived: a request with a matching If-None-Match gets 304.
+func (h *ArchiveHandler) GetArchivedCoupon(c *fiber.Ctx) error {
+	name := c.Params("name")
+	archived, err := h.service.GetArchived(c.UserContext(), name)
+	if err != nil {
+		if errors.Is(err, apperr.ErrArchivedCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "archived coupon not found"})
+		}
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to get archived coupon")
+		return internalError(c, err)
+	}
+
+	etag := fmt.Sprintf(`"%x"`, archived.ArchivedAt.UnixNano())
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d, immutable", int(h.maxAge.Seconds())))
+	c.Set(fiber.HeaderETag, etag)
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.JSON(archived)
+}
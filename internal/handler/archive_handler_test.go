@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// stubArchive serves the archived coupons it holds, failing for "BROKEN".
+type stubArchive map[string]*model.ArchivedCoupon
+
+func (s stubArchive) GetArchived(ctx context.Context, name string) (*model.ArchivedCoupon, error) {
+	if name == "BROKEN" {
+		return nil, errors.New("connection reset")
+	}
+	if a, ok := s[name]; ok {
+		return a, nil
+	}
+	return nil, apperr.ErrArchivedCouponNotFound
+}
+
+func setupArchiveTestApp() *fiber.App {
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	app := fiber.New()
+	h := NewArchiveHandler(stubArchive{
+		"SUMMER": {
+			Name: "SUMMER", Coupon: []byte(`{"name":"SUMMER","amount":10,"remaining_amount":0}`), Claims: 10,
+			CreatedAt: at.Add(-30 * 24 * time.Hour), DeletedAt: at.Add(-time.Hour), ArchivedAt: at,
+		},
+	}, 24*time.Hour)
+	app.Get("/api/archive/coupons/:name", h.GetArchivedCoupon)
+	return app
+}
+
+func TestGetArchivedCoupon(t *testing.T) {
+	app := setupArchiveTestApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/archive/coupons/SUMMER", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "public, max-age=86400, immutable", resp.Header.Get(fiber.HeaderCacheControl))
+	assert.JSONEq(t, `{"name":"SUMMER","coupon":{"name":"SUMMER","amount":10,"remaining_amount":0},"claims":10,
+		"created_at":"2026-09-01T12:00:00Z","deleted_at":"2026-10-01T11:00:00Z","archived_at":"2026-10-01T12:00:00Z"}`, string(body))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/archive/coupons/SUMMER", nil)
+	req.Header.Set(fiber.HeaderIfNoneMatch, resp.Header.Get(fiber.HeaderETag))
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotModified, resp.StatusCode)
+}
+
+func TestGetArchivedCoupon_NotFound(t *testing.T) {
+	resp, err := setupArchiveTestApp().Test(httptest.NewRequest(http.MethodGet, "/api/archive/coupons/NEVER", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(fiber.HeaderCacheControl), "the coupon may yet be archived")
+	assert.JSONEq(t, `{"error":"archived coupon not found"}`, string(body))
+}
+
+func TestGetArchivedCoupon_Error(t *testing.T) {
+	resp, err := setupArchiveTestApp().Test(httptest.NewRequest(http.MethodGet, "/api/archive/coupons/BROKEN", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+}
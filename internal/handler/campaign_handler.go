@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// CampaignCapServiceInterface defines the interface for managing campaign claim caps.
+type CampaignCapServiceInterface interface {
+	SetCampaignCap(ctx context.Context, campaign string, claimCap int) (*model.CampaignCap, error)
+	CampaignCap(ctx context.Context, campaign string) (*model.CampaignCap, error)
+	DeleteCampaignCap(ctx context.Context, campaign string) error
+}
+
+// CampaignHandler handles HTTP requests for campaign claim caps.
+type CampaignHandler struct {
+	service   CampaignCapServiceInterface
+	validator *validator.Validate
+}
+
+// NewCampaignHandler creates a new CampaignHandler with the given service and validator.
+func NewCampaignHandler(svc CampaignCapServiceInterface, v *validator.Validate) *CampaignHandler {
+	return &CampaignHandler{service: svc, validator: v}
+}
+
+// campaignParam returns the percent-decoded :id route parameter naming a campaign (a
+// coupon tag), so tags containing reserved characters match exactly. ok is false if it
+// cannot name a tag.
+func campaignParam(c *fiber.Ctx) (campaign string, ok bool) {
+	campaign, err := url.PathUnescape(c.Params("id"))
+	if err != nil || strings.TrimSpace(campaign) == "" || len(campaign) > 64 {
+		return "", false
+	}
+	return campaign, true
+}
+
+// invalidCampaign responds 400 to a request naming an invalid campaign.
+func invalidCampaign(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": "invalid request: campaign id must be a tag of at most 64 characters",
+	})
+}
+
+// SetCampaignCap handles PUT /api/admin/campaigns/:id/cap requests.
+func (h *CampaignHandler) SetCampaignCap(c *fiber.Ctx) error {
+	campaign, ok := campaignParam(c)
+	if !ok {
+		return invalidCampaign(c)
+	}
+	var req model.SetCampaignCapRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: claim_cap must be a non-negative integer",
+		})
+	}
+
+	cp, err := h.service.SetCampaignCap(c.UserContext(), campaign, *req.ClaimCap)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("campaign", campaign).
+			Msg("failed to set campaign cap")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("campaign", campaign).
+		Int("claim_cap", cp.ClaimCap).
+		Int("claimed", cp.Claimed).
+		Msg("campaign cap set")
+
+	return c.JSON(cp)
+}
+
+// GetCampaignCap handles GET /api/admin/campaigns/:id/cap requests.
+func (h *CampaignHandler) GetCampaignCap(c *fiber.Ctx) error {
+	campaign, ok := campaignParam(c)
+	if !ok {
+		return invalidCampaign(c)
+	}
+
+	cp, err := h.service.CampaignCap(c.UserContext(), campaign)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCampaignCapNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "campaign claim cap not found"})
+		}
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("campaign", campaign).
+			Msg("failed to get campaign cap")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+	return c.JSON(cp)
+}
+
+// DeleteCampaignCap handles DELETE /api/admin/campaigns/:id/cap requests. Claims are
+// no longer limited, and the claims counted so far are forgotten.
+func (h *CampaignHandler) DeleteCampaignCap(c *fiber.Ctx) error {
+	campaign, ok := campaignParam(c)
+	if !ok {
+		return invalidCampaign(c)
+	}
+
+	if err := h.service.DeleteCampaignCap(c.UserContext(), campaign); err != nil {
+		if errors.Is(err, apperr.ErrCampaignCapNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "campaign claim cap not found"})
+		}
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("campaign", campaign).
+			Msg("failed to delete campaign cap")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("campaign", campaign).
+		Msg("campaign cap deleted")
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
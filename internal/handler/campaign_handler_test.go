@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+)
+
+// mockCampaignCapService is a mock implementation of CampaignCapServiceInterface
+// knowing a cap for the "summer" campaign only.
+type mockCampaignCapService struct {
+	set map[string]int
+}
+
+func (m *mockCampaignCapService) SetCampaignCap(ctx context.Context, campaign string, claimCap int) (*model.CampaignCap, error) {
+	m.set = map[string]int{campaign: claimCap}
+	return &model.CampaignCap{Campaign: campaign, ClaimCap: claimCap, Claimed: 40}, nil
+}
+
+func (m *mockCampaignCapService) CampaignCap(ctx context.Context, campaign string) (*model.CampaignCap, error) {
+	if campaign != "summer" {
+		return nil, apperr.ErrCampaignCapNotFound
+	}
+	return &model.CampaignCap{Campaign: campaign, ClaimCap: 100, Claimed: 40}, nil
+}
+
+func (m *mockCampaignCapService) DeleteCampaignCap(ctx context.Context, campaign string) error {
+	if campaign != "summer" {
+		return apperr.ErrCampaignCapNotFound
+	}
+	return nil
+}
+
+func setupCampaignTestApp(mockSvc *mockCampaignCapService) *fiber.App {
+	app := fiber.New()
+	h := NewCampaignHandler(mockSvc, validator.New())
+	app.Put("/api/admin/campaigns/:id/cap", h.SetCampaignCap)
+	app.Get("/api/admin/campaigns/:id/cap", h.GetCampaignCap)
+	app.Delete("/api/admin/campaigns/:id/cap", h.DeleteCampaignCap)
+	return app
+}
+
+func TestSetCampaignCap(t *testing.T) {
+	mockSvc := &mockCampaignCapService{}
+	app := setupCampaignTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/campaigns/summer/cap", bytes.NewBufferString(`{"claim_cap": 0}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, map[string]int{"summer": 0}, mockSvc.set, "a zero cap stops all claims")
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"campaign": "summer", "claim_cap": 0, "claimed": 40, "updated_at": "0001-01-01T00:00:00Z"}`, string(respBody))
+}
+
+func TestSetCampaignCap_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"missing cap", `{}`, "invalid request: claim_cap must be a non-negative integer"},
+		{"negative cap", `{"claim_cap": -1}`, "invalid request: claim_cap must be a non-negative integer"},
+		{"malformed", `{"claim_cap":`, "invalid request body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockCampaignCapService{}
+			app := setupCampaignTestApp(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/campaigns/summer/cap", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			respBody, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, `{"error": "`+tt.want+`"}`, string(respBody))
+			assert.Nil(t, mockSvc.set)
+		})
+	}
+}
+
+func TestGetAndDeleteCampaignCap(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/api/admin/campaigns/summer/cap", fiber.StatusOK},
+		{http.MethodGet, "/api/admin/campaigns/winter/cap", fiber.StatusNotFound},
+		{http.MethodDelete, "/api/admin/campaigns/summer/cap", fiber.StatusNoContent},
+		{http.MethodDelete, "/api/admin/campaigns/winter/cap", fiber.StatusNotFound},
+		{http.MethodGet, "/api/admin/campaigns/sum%6Der/cap", fiber.StatusOK}, // Percent-decoded
+		{http.MethodGet, "/api/admin/campaigns/%20/cap", fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			app := setupCampaignTestApp(&mockCampaignCapService{})
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
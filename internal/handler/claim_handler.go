@@ -3,24 +3,39 @@ package handler
 import (
 	"context"
 	"errors"
+	"strconv"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/captcha"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/grant"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
-	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
 )
 
 // ClaimServiceInterface defines the interface for claim business logic.
 type ClaimServiceInterface interface {
-	ClaimCoupon(ctx context.Context, userID, couponName string) error
+	ClaimCoupon(ctx context.Context, req *model.ClaimCouponRequest) (*model.ClaimReceipt, error)
+	ListClaims(ctx context.Context, name string, afterSequence, limit int) (*model.ClaimListResponse, error)
+	SampleClaims(ctx context.Context, name string, n int) (*model.ClaimSampleResponse, error)
 }
 
+// Claim sample size bounds for ?n=.
+const (
+	defaultClaimSampleSize = 100
+	maxClaimSampleSize     = 1000
+)
+
 // ClaimHandler handles HTTP requests for claim operations.
 type ClaimHandler struct {
 	service   ClaimServiceInterface
 	validator *validator.Validate
+	captcha   captcha.Verifier // nil when captcha verification is not configured
+	grants    *grant.Signer    // nil when claim grants are not accepted
+	claimedBy claimedByPrivacy // How claims' user IDs are reported
 }
 
 // NewClaimHandler creates a new ClaimHandler with the given service and validator.
@@ -28,6 +43,19 @@ func NewClaimHandler(svc ClaimServiceInterface, v *validator.Validate) *ClaimHan
 	return &ClaimHandler{service: svc, validator: v}
 }
 
+// SetCaptchaVerifier verifies the captcha tokens of claims with v. The service decides
+// per coupon whether a verified token is required (see model.Coupon.CaptchaRequired);
+// without a verifier, claims of such coupons are rejected.
+func (h *ClaimHandler) SetCaptchaVerifier(v captcha.Verifier) {
+	h.captcha = v
+}
+
+// SetGrantSigner accepts claim grants verified by s: a claim carrying a grant claims
+// what the grant names, and may only repeat its fields, not override them.
+func (h *ClaimHandler) SetGrantSigner(s *grant.Signer) {
+	h.grants = s
+}
+
 // formatClaimValidationError converts validator errors to AC-required messages for claims.
 func formatClaimValidationError(err error) string {
 	var ve validator.ValidationErrors
@@ -59,6 +87,27 @@ func formatClaimValidationError(err error) string {
 					return "invalid request: coupon_name exceeds maximum length of 255"
 				}
 				return "invalid request: coupon_name is invalid"
+			case "Channel":
+				if tag == "notblank" {
+					return "invalid request: channel cannot be whitespace only"
+				}
+				if tag == "max" {
+					return "invalid request: channel exceeds maximum length of 64"
+				}
+				return "invalid request: channel is invalid"
+			case "Region":
+				if tag == "notblank" {
+					return "invalid request: region cannot be whitespace only"
+				}
+				if tag == "max" {
+					return "invalid request: region exceeds maximum length of 64"
+				}
+				return "invalid request: region is invalid"
+			case "CaptchaToken":
+				if tag == "max" {
+					return "invalid request: captcha_token exceeds maximum length of 4096"
+				}
+				return "invalid request: captcha_token is invalid"
 			default:
 				if tag == "required" {
 					return "invalid request: " + field + " is required"
@@ -85,40 +134,254 @@ func (h *ClaimHandler) ClaimCoupon(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
+	// Take the claim's fields from its grant, if any
+	if req.Grant != "" {
+		if status, msg := h.applyGrant(c, &req); status != 0 {
+			return c.Status(status).JSON(fiber.Map{"error": msg})
+		}
+	}
+
 	// Validate request
 	if err := h.validator.Struct(req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatClaimValidationError(err)})
 	}
 
+	// Verify the captcha token, if any, before the claim transaction (never while
+	// holding the coupon's row lock)
+	if req.CaptchaToken != "" && h.captcha != nil {
+		if err := h.captcha.Verify(c.UserContext(), req.CaptchaToken, c.IP()); err != nil {
+			if errors.Is(err, captcha.ErrInvalidToken) {
+				log.Debug().
+					Err(err).
+					Str("request_id", c.GetRespHeader("X-Request-ID")).
+					Str("coupon_name", redact.Value(req.CouponName)).
+					Msg("captcha rejected")
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "captcha verification failed"})
+			}
+			log.Error().
+				Err(err).
+				Str("request_id", c.GetRespHeader("X-Request-ID")).
+				Str("method", c.Method()).
+				Str("path", logPath(c)).
+				Msg("captcha provider unavailable")
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "captcha verification unavailable"})
+		}
+		req.CaptchaVerified = true
+	}
+
 	// Claim coupon via service
-	if err := h.service.ClaimCoupon(c.Context(), req.UserID, req.CouponName); err != nil {
-		if errors.Is(err, service.ErrCouponNotFound) {
+	receipt, err := h.service.ClaimCoupon(c.UserContext(), &req)
+	if err != nil {
+		if errors.Is(err, apperr.ErrClaimQueued) {
+			// Accepted for later processing, not granted (store-and-forward mode)
+			return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+				"status":      "queued",
+				"user_id":     req.UserID,
+				"coupon_name": req.CouponName,
+			})
+		}
+		if errors.Is(err, apperr.ErrCouponNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
 		}
-		if errors.Is(err, service.ErrAlreadyClaimed) {
+		var claimed *apperr.AlreadyClaimedError
+		if errors.As(err, &claimed) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":          "coupon already claimed by user",
+				"claimed_at":     claimed.ClaimedAt,
+				"claim_sequence": claimed.ClaimSequence,
+			})
+		}
+		if errors.Is(err, apperr.ErrAlreadyClaimed) {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "coupon already claimed by user"})
 		}
-		if errors.Is(err, service.ErrNoStock) {
+		if errors.Is(err, apperr.ErrExclusionGroupClaimed) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "user already claimed a coupon of this exclusion group"})
+		}
+		if errors.Is(err, apperr.ErrNoStock) {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "coupon out of stock"})
 		}
+		if errors.Is(err, apperr.ErrCouponBusy) {
+			return couponBusy(c)
+		}
+		if errors.Is(err, apperr.ErrDeadlineTooShort) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "not enough time left to claim"})
+		}
+		if errors.Is(err, apperr.ErrClaimPaced) {
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many claims on this coupon, retry later"})
+		}
+		if errors.Is(err, apperr.ErrCampaignCapReached) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "campaign claim cap reached"})
+		}
+		if errors.Is(err, apperr.ErrRegionQuotaReached) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "region quota reached"})
+		}
+		if errors.Is(err, apperr.ErrCouponDisabled) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "coupon is disabled"})
+		}
+		if errors.Is(err, apperr.ErrCaptchaRequired) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "captcha required"})
+		}
+		if errors.Is(err, apperr.ErrGrantRequired) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "claim grant required"})
+		}
+		if errors.Is(err, apperr.ErrNotAllowlisted) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "user is not allowlisted for this coupon"})
+		}
+		if errors.Is(err, apperr.ErrClaimDeadlinePassed) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "claim deadline has passed"})
+		}
+		if errors.Is(err, apperr.ErrPrerequisiteNotClaimed) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "prerequisite coupon not claimed"})
+		}
+		if errors.Is(err, apperr.ErrChannelRequired) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: channel is required for this coupon"})
+		}
+		if errors.Is(err, apperr.ErrUnknownChannel) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: unknown channel"})
+		}
 		log.Error().
-			Err(err).
+			Str("error", redact.Error(err, req.UserID, req.CouponName)).
 			Str("request_id", c.GetRespHeader("X-Request-ID")).
 			Str("method", c.Method()).
-			Str("path", c.Path()).
-			Str("user_id", req.UserID).
-			Str("coupon_name", req.CouponName).
+			Str("path", logPath(c)).
+			Str("user_id", redact.Value(req.UserID)).
+			Str("coupon_name", redact.Value(req.CouponName)).
 			Msg("failed to claim coupon")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+		return internalError(c, err)
 	}
 
 	log.Info().
 		Str("request_id", c.GetRespHeader("X-Request-ID")).
 		Str("method", c.Method()).
-		Str("path", c.Path()).
-		Str("user_id", req.UserID).
-		Str("coupon_name", req.CouponName).
+		Str("path", logPath(c)).
+		Str("user_id", redact.Value(req.UserID)).
+		Str("coupon_name", redact.Value(req.CouponName)).
+		Int("claim_sequence", receipt.ClaimSequence).
 		Msg("coupon claimed successfully")
 
-	return c.Status(fiber.StatusOK).Send(nil)
+	return c.Status(fiber.StatusOK).JSON(receipt)
+}
+
+// applyGrant verifies req.Grant and fills req from it. It returns the status and error
+// message to answer with when the grant cannot be honored, or 0.
+func (h *ClaimHandler) applyGrant(c *fiber.Ctx, req *model.ClaimCouponRequest) (int, string) {
+	if h.grants == nil {
+		return fiber.StatusBadRequest, "invalid request: claim grants are not enabled"
+	}
+	if len(req.Grant) > 4096 {
+		return fiber.StatusBadRequest, "invalid request: grant exceeds maximum length of 4096"
+	}
+
+	claims, err := h.grants.Verify(req.Grant)
+	if err != nil {
+		log.Debug().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Msg("claim grant rejected")
+		if errors.Is(err, grant.ErrExpired) {
+			return fiber.StatusForbidden, "claim grant expired"
+		}
+		return fiber.StatusForbidden, "invalid claim grant"
+	}
+
+	for _, f := range []struct {
+		name    string
+		field   *string
+		granted string
+	}{
+		{"user_id", &req.UserID, claims.UserID},
+		{"coupon_name", &req.CouponName, claims.CouponName},
+		{"channel", &req.Channel, claims.Channel},
+		{"region", &req.Region, claims.Region},
+	} {
+		if *f.field != "" && *f.field != f.granted {
+			return fiber.StatusBadRequest, "invalid request: " + f.name + " does not match grant"
+		}
+		*f.field = f.granted
+	}
+	req.GrantVerified = true
+	return 0, ""
+}
+
+// ListClaims handles GET /api/coupons/:name/claims requests to export a coupon's claims in
+// claim order, a page of up to ?limit= claims after ?cursor= at a time. User IDs may be
+// hashed or omitted (see SetClaimedByPrivacy).
+func (h *ClaimHandler) ListClaims(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: name is required"})
+	}
+	limit, key, msg := pageQuery(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	after := 0
+	if key != "" {
+		seq, err := strconv.Atoi(key)
+		if err != nil || seq < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msgInvalidCursor})
+		}
+		after = seq
+	}
+
+	claims, err := h.service.ListClaims(c.UserContext(), name, after, limit)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("method", c.Method()).
+			Str("path", logPath(c)).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to list claims")
+		return internalError(c, err)
+	}
+
+	if !h.claimedBy.full() {
+		public := *claims
+		public.Items = h.claimedBy.claimRecords(claims.Items)
+		claims = &public
+	}
+	return c.JSON(claims)
+}
+
+// SampleClaims handles GET /api/coupons/:name/claims/sample requests to read a random
+// sample of a coupon's claims; ?n= bounds the sample size. User IDs may be hashed or
+// omitted (see SetClaimedByPrivacy).
+func (h *ClaimHandler) SampleClaims(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: name is required"})
+	}
+	n := c.QueryInt("n", defaultClaimSampleSize)
+	if n < 1 || n > maxClaimSampleSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: n must be between 1 and 1000",
+		})
+	}
+
+	sample, err := h.service.SampleClaims(c.UserContext(), name, n)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("method", c.Method()).
+			Str("path", logPath(c)).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to sample claims")
+		return internalError(c, err)
+	}
+
+	if !h.claimedBy.full() {
+		public := *sample
+		public.Claims = h.claimedBy.claimRecords(sample.Claims)
+		sample = &public
+	}
+	return c.JSON(sample)
 }
@@ -9,25 +9,56 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/captcha"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/grant"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
 )
 
 // mockClaimService is a mock implementation of ClaimServiceInterface.
+// Successful claims get a receipt echoing the request with claim_sequence 1.
 type mockClaimService struct {
-	claimCouponFn func(ctx context.Context, userID, couponName string) error
+	claimCouponFn func(ctx context.Context, req *model.ClaimCouponRequest) error
+	listClaimsFn  func(ctx context.Context, name string, afterSequence, limit int) (*model.ClaimListResponse, error)
+	sampleFn      func(ctx context.Context, name string, n int) (*model.ClaimSampleResponse, error)
 }
 
-func (m *mockClaimService) ClaimCoupon(ctx context.Context, userID, couponName string) error {
+func (m *mockClaimService) ClaimCoupon(ctx context.Context, req *model.ClaimCouponRequest) (*model.ClaimReceipt, error) {
 	if m.claimCouponFn != nil {
-		return m.claimCouponFn(ctx, userID, couponName)
+		if err := m.claimCouponFn(ctx, req); err != nil {
+			return nil, err
+		}
 	}
-	return nil
+	return &model.ClaimReceipt{
+		UserID:        req.UserID,
+		CouponName:    req.CouponName,
+		Channel:       req.Channel,
+		ClaimSequence: 1,
+	}, nil
+}
+
+func (m *mockClaimService) ListClaims(ctx context.Context, name string, afterSequence, limit int) (*model.ClaimListResponse, error) {
+	if m.listClaimsFn != nil {
+		return m.listClaimsFn(ctx, name, afterSequence, limit)
+	}
+	return &model.ClaimListResponse{CouponName: name, Page: model.Page[model.ClaimRecord]{Items: []model.ClaimRecord{}}}, nil
+}
+
+func (m *mockClaimService) SampleClaims(ctx context.Context, name string, n int) (*model.ClaimSampleResponse, error) {
+	if m.sampleFn != nil {
+		return m.sampleFn(ctx, name, n)
+	}
+	return &model.ClaimSampleResponse{CouponName: name, Claims: []model.ClaimRecord{}}, nil
 }
 
 func setupClaimTestApp(mockSvc *mockClaimService) *fiber.App {
@@ -35,12 +66,14 @@ func setupClaimTestApp(mockSvc *mockClaimService) *fiber.App {
 	v := validator.New() // Uses shared validator with custom validations
 	h := NewClaimHandler(mockSvc, v)
 	app.Post("/api/coupons/claim", h.ClaimCoupon)
+	app.Get("/api/coupons/:name/claims", h.ListClaims)
+	app.Get("/api/coupons/:name/claims/sample", h.SampleClaims)
 	return app
 }
 
 func TestClaimCoupon_Success(t *testing.T) {
 	mockSvc := &mockClaimService{
-		claimCouponFn: func(ctx context.Context, userID, couponName string) error {
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
 			return nil
 		},
 	}
@@ -56,15 +89,15 @@ func TestClaimCoupon_Success(t *testing.T) {
 
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Expected 200 OK")
 
-	// Verify empty body
+	// Verify receipt body
 	respBody, _ := io.ReadAll(resp.Body)
-	assert.Empty(t, respBody, "Response body should be empty on success")
+	assert.JSONEq(t, `{"user_id": "user_001", "coupon_name": "PROMO_SUPER", "claim_sequence": 1}`, string(respBody))
 }
 
 func TestClaimCoupon_DuplicateClaim(t *testing.T) {
 	mockSvc := &mockClaimService{
-		claimCouponFn: func(ctx context.Context, userID, couponName string) error {
-			return service.ErrAlreadyClaimed
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrAlreadyClaimed
 		},
 	}
 	app := setupClaimTestApp(mockSvc)
@@ -85,10 +118,54 @@ func TestClaimCoupon_DuplicateClaim(t *testing.T) {
 	assert.Equal(t, "coupon already claimed by user", result["error"], "Exact error message required")
 }
 
+func TestClaimCoupon_DuplicateClaimHint(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return &apperr.AlreadyClaimedError{ClaimedAt: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC), ClaimSequence: 42}
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_001", "coupon_name": "PROMO_SUPER"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"error": "coupon already claimed by user", "claimed_at": "2025-03-03T10:00:00Z", "claim_sequence": 42}`, string(respBody))
+}
+
+func TestClaimCoupon_ExclusionGroupClaimed(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrExclusionGroupClaimed
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_001", "coupon_name": "WELCOME_B"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "user already claimed a coupon of this exclusion group", result["error"])
+}
+
 func TestClaimCoupon_OutOfStock(t *testing.T) {
 	mockSvc := &mockClaimService{
-		claimCouponFn: func(ctx context.Context, userID, couponName string) error {
-			return service.ErrNoStock
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrNoStock
 		},
 	}
 	app := setupClaimTestApp(mockSvc)
@@ -109,10 +186,405 @@ func TestClaimCoupon_OutOfStock(t *testing.T) {
 	assert.Equal(t, "coupon out of stock", result["error"], "Exact error message required")
 }
 
+func TestClaimCoupon_CouponBusy(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrCouponBusy
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_999", "coupon_name": "PROMO_SUPER"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	var result map[string]string
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	require.NoError(t, err)
+	assert.Equal(t, "coupon is busy", result["error"])
+}
+
+func TestClaimCoupon_DeadlineTooShort(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrDeadlineTooShort
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_999", "coupon_name": "PROMO_SUPER"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+
+	var result map[string]string
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	require.NoError(t, err)
+	assert.Equal(t, "not enough time left to claim", result["error"])
+}
+
+func TestClaimCoupon_Paced(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrClaimPaced
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_999", "coupon_name": "PROMO_SUPER"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	var result map[string]string
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	require.NoError(t, err)
+	assert.Equal(t, "too many claims on this coupon, retry later", result["error"])
+}
+
+func TestClaimCoupon_CampaignCapReached(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrCampaignCapReached
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_999", "coupon_name": "PROMO_SUPER"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Expected 400 Bad Request")
+
+	var result map[string]string
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	require.NoError(t, err)
+	assert.Equal(t, "campaign claim cap reached", result["error"], "Exact error message required")
+}
+
+func TestClaimCoupon_RegionQuotaReached(t *testing.T) {
+	var captured *model.ClaimCouponRequest
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			captured = req
+			return apperr.ErrRegionQuotaReached
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_999", "coupon_name": "GLOBAL", "region": "eu"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	require.NotNil(t, captured)
+	assert.Equal(t, "eu", captured.Region)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "region quota reached", result["error"])
+}
+
+func TestClaimCoupon_NotEligible(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{apperr.ErrNotAllowlisted, "user is not allowlisted for this coupon"},
+		{apperr.ErrClaimDeadlinePassed, "claim deadline has passed"},
+		{apperr.ErrPrerequisiteNotClaimed, "prerequisite coupon not claimed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			mockSvc := &mockClaimService{
+				claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+					return tt.err
+				},
+			}
+			app := setupClaimTestApp(mockSvc)
+
+			body := `{"user_id": "user_999", "coupon_name": "PROMO_SUPER"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.want, result["error"])
+		})
+	}
+}
+
+func TestClaimCoupon_CouponDisabled(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrCouponDisabled
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_999", "coupon_name": "PROMO_SUPER"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "coupon is disabled", result["error"])
+}
+
+// mockCaptcha accepts only the token "good"; err, when set, simulates an unreachable provider.
+type mockCaptcha struct {
+	err   error
+	calls int
+}
+
+func (m *mockCaptcha) Verify(ctx context.Context, token, remoteIP string) error {
+	m.calls++
+	if m.err != nil {
+		return m.err
+	}
+	if token != "good" {
+		return captcha.ErrInvalidToken
+	}
+	return nil
+}
+
+func TestClaimCoupon_Captcha(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		providerErr error
+		wantStatus  int
+		wantError   string
+		wantCalls   int
+	}{
+		{"verified", `{"user_id": "u1", "coupon_name": "DROP", "captcha_token": "good"}`, nil, fiber.StatusOK, "", 1},
+		{"rejected token", `{"user_id": "u1", "coupon_name": "DROP", "captcha_token": "forged"}`, nil, fiber.StatusForbidden, "captcha verification failed", 1},
+		{"missing token", `{"user_id": "u1", "coupon_name": "DROP"}`, nil, fiber.StatusForbidden, "captcha required", 0},
+		{"provider down", `{"user_id": "u1", "coupon_name": "DROP", "captcha_token": "good"}`, errors.New("timeout"), fiber.StatusServiceUnavailable, "captcha verification unavailable", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// DROP requires a captcha
+			mockSvc := &mockClaimService{
+				claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+					if !req.CaptchaVerified {
+						return apperr.ErrCaptchaRequired
+					}
+					return nil
+				},
+			}
+			verifier := &mockCaptcha{err: tt.providerErr}
+			app := fiber.New()
+			h := NewClaimHandler(mockSvc, validator.New())
+			h.SetCaptchaVerifier(verifier)
+			app.Post("/api/coupons/claim", h.ClaimCoupon)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantCalls, verifier.calls)
+			if tt.wantError != "" {
+				var result map[string]string
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+				assert.Equal(t, tt.wantError, result["error"])
+			}
+		})
+	}
+}
+
+func TestClaimCoupon_CaptchaTokenIsNotTrustedWithoutVerifier(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			assert.False(t, req.CaptchaVerified)
+			return apperr.ErrCaptchaRequired
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "u1", "coupon_name": "DROP", "captcha_token": "good"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestClaimCoupon_Grant(t *testing.T) {
+	signer := grant.New([]byte("0123456789abcdef0123456789abcdef"))
+	sign := func(c grant.Claims) string {
+		token, err := signer.Sign(c)
+		require.NoError(t, err)
+		return token
+	}
+	exp := time.Now().Add(time.Minute).Unix()
+	valid := sign(grant.Claims{UserID: "u1", CouponName: "PROMO", Channel: "app", ExpiresAt: exp})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{"grant only", `{"grant": "` + valid + `"}`, fiber.StatusOK, ""},
+		{"repeated fields", `{"grant": "` + valid + `", "user_id": "u1", "coupon_name": "PROMO"}`, fiber.StatusOK, ""},
+		{"other user", `{"grant": "` + valid + `", "user_id": "u2"}`, fiber.StatusBadRequest, "invalid request: user_id does not match grant"},
+		{"other coupon", `{"grant": "` + valid + `", "coupon_name": "OTHER"}`, fiber.StatusBadRequest, "invalid request: coupon_name does not match grant"},
+		{"other channel", `{"grant": "` + valid + `", "channel": "web"}`, fiber.StatusBadRequest, "invalid request: channel does not match grant"},
+		{"forged", `{"grant": "` + valid + `x"}`, fiber.StatusForbidden, "invalid claim grant"},
+		{"expired", `{"grant": "` + sign(grant.Claims{UserID: "u1", CouponName: "PROMO", ExpiresAt: 1}) + `"}`, fiber.StatusForbidden, "claim grant expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claimed *model.ClaimCouponRequest
+			mockSvc := &mockClaimService{
+				claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+					claimed = req
+					return nil
+				},
+			}
+			app := fiber.New()
+			h := NewClaimHandler(mockSvc, validator.New())
+			h.SetGrantSigner(signer)
+			app.Post("/api/coupons/claim", h.ClaimCoupon)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantError != "" {
+				var result map[string]string
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+				assert.Equal(t, tt.wantError, result["error"])
+				assert.Nil(t, claimed)
+				return
+			}
+			require.NotNil(t, claimed)
+			assert.Equal(t, "u1", claimed.UserID)
+			assert.Equal(t, "PROMO", claimed.CouponName)
+			assert.Equal(t, "app", claimed.Channel)
+			assert.True(t, claimed.GrantVerified)
+		})
+	}
+}
+
+func TestClaimCoupon_GrantRequired(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			assert.False(t, req.GrantVerified)
+			return apperr.ErrGrantRequired
+		},
+	}
+	app := fiber.New()
+	app.Post("/api/coupons/claim", NewClaimHandler(mockSvc, validator.New()).ClaimCoupon)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(`{"user_id": "u1", "coupon_name": "VIP"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "claim grant required", result["error"])
+}
+
+func TestClaimCoupon_GrantWithoutSigner(t *testing.T) {
+	app := setupClaimTestApp(&mockClaimService{})
+
+	body := `{"grant": "a.b.c"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "invalid request: claim grants are not enabled", result["error"])
+}
+
+func TestClaimCoupon_Queued(t *testing.T) {
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrClaimQueued
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_001", "coupon_name": "PROMO_SUPER"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "queued", result["status"])
+	assert.Equal(t, "user_001", result["user_id"])
+	assert.Equal(t, "PROMO_SUPER", result["coupon_name"])
+}
+
 func TestClaimCoupon_CouponNotFound(t *testing.T) {
 	mockSvc := &mockClaimService{
-		claimCouponFn: func(ctx context.Context, userID, couponName string) error {
-			return service.ErrCouponNotFound
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return apperr.ErrCouponNotFound
 		},
 	}
 	app := setupClaimTestApp(mockSvc)
@@ -195,7 +667,7 @@ func TestClaimCoupon_MalformedJSON(t *testing.T) {
 
 func TestClaimCoupon_InternalServerError(t *testing.T) {
 	mockSvc := &mockClaimService{
-		claimCouponFn: func(ctx context.Context, userID, couponName string) error {
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
 			return errors.New("database connection failed")
 		},
 	}
@@ -241,9 +713,9 @@ func TestClaimCoupon_EmptyBody(t *testing.T) {
 func TestClaimCoupon_RequestFieldsSnakeCase(t *testing.T) {
 	var capturedUserID, capturedCouponName string
 	mockSvc := &mockClaimService{
-		claimCouponFn: func(ctx context.Context, userID, couponName string) error {
-			capturedUserID = userID
-			capturedCouponName = couponName
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			capturedUserID = req.UserID
+			capturedCouponName = req.CouponName
 			return nil
 		},
 	}
@@ -267,8 +739,8 @@ func TestClaimCoupon_RequestFieldsSnakeCase(t *testing.T) {
 func TestClaimCoupon_UnicodeUserID(t *testing.T) {
 	var capturedUserID string
 	mockSvc := &mockClaimService{
-		claimCouponFn: func(ctx context.Context, userID, couponName string) error {
-			capturedUserID = userID
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			capturedUserID = req.UserID
 			return nil
 		},
 	}
@@ -290,8 +762,8 @@ func TestClaimCoupon_UnicodeUserID(t *testing.T) {
 func TestClaimCoupon_SpecialCharactersInCouponName(t *testing.T) {
 	var capturedCouponName string
 	mockSvc := &mockClaimService{
-		claimCouponFn: func(ctx context.Context, userID, couponName string) error {
-			capturedCouponName = couponName
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			capturedCouponName = req.CouponName
 			return nil
 		},
 	}
@@ -309,3 +781,282 @@ func TestClaimCoupon_SpecialCharactersInCouponName(t *testing.T) {
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 	assert.Equal(t, "PROMO-100%_OFF!", capturedCouponName, "Special characters should be preserved")
 }
+
+func TestClaimCoupon_PassesChannel(t *testing.T) {
+	var captured *model.ClaimCouponRequest
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			captured = req
+			return nil
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "user_001", "coupon_name": "BF_SPLIT", "channel": "app"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NotNil(t, captured)
+	assert.Equal(t, "app", captured.Channel)
+}
+
+func TestClaimCoupon_ChannelErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		svcErr   error
+		expected string
+	}{
+		{"channel required", `{"user_id": "u1", "coupon_name": "BF_SPLIT"}`, apperr.ErrChannelRequired, "invalid request: channel is required for this coupon"},
+		{"unknown channel", `{"user_id": "u1", "coupon_name": "BF_SPLIT", "channel": "kiosk"}`, apperr.ErrUnknownChannel, "invalid request: unknown channel"},
+		{"blank channel", `{"user_id": "u1", "coupon_name": "BF_SPLIT", "channel": "   "}`, nil, "invalid request: channel cannot be whitespace only"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockClaimService{
+				claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+					return tt.svcErr
+				},
+			}
+			app := setupClaimTestApp(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.expected, result["error"])
+		})
+	}
+}
+
+func TestListClaims_Success(t *testing.T) {
+	claimedAt := time.Date(2026, 11, 27, 0, 0, 1, 0, time.UTC)
+	var capturedName string
+	var capturedAfter, capturedLimit int
+	total := 3
+	mockSvc := &mockClaimService{
+		listClaimsFn: func(ctx context.Context, name string, afterSequence, limit int) (*model.ClaimListResponse, error) {
+			capturedName, capturedAfter, capturedLimit = name, afterSequence, limit
+			return &model.ClaimListResponse{
+				CouponName: name,
+				Page: model.Page[model.ClaimRecord]{
+					Items: []model.ClaimRecord{
+						{UserID: "user_001", ClaimSequence: 1, ClaimedAt: claimedAt},
+						{UserID: "user_002", Channel: "app", ClaimSequence: 2, ClaimedAt: claimedAt},
+					},
+					NextCursor: model.EncodeCursor("2"),
+					Total:      &total,
+				},
+			}, nil
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER/claims", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "PROMO_SUPER", capturedName)
+	assert.Equal(t, 0, capturedAfter)
+	assert.Equal(t, 100, capturedLimit)
+
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{
+		"coupon_name": "PROMO_SUPER",
+		"items": [
+			{"user_id": "user_001", "claim_sequence": 1, "claimed_at": "2026-11-27T00:00:01Z"},
+			{"user_id": "user_002", "channel": "app", "claim_sequence": 2, "claimed_at": "2026-11-27T00:00:01Z"}
+		],
+		"next_cursor": "Mg",
+		"total": 3
+	}`, string(respBody))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER/claims?limit=2&cursor=Mg", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, capturedAfter)
+	assert.Equal(t, 2, capturedLimit)
+}
+
+func TestListClaims_InvalidPage(t *testing.T) {
+	for _, query := range []string{"limit=0", "cursor=" + model.EncodeCursor("BF_APP"), "cursor=" + model.EncodeCursor("0"), "cursor=%%%"} {
+		t.Run(query, func(t *testing.T) {
+			app := setupClaimTestApp(&mockClaimService{})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER/claims?"+query, nil))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestListClaims_CouponNotFound(t *testing.T) {
+	mockSvc := &mockClaimService{
+		listClaimsFn: func(ctx context.Context, name string, afterSequence, limit int) (*model.ClaimListResponse, error) {
+			return nil, apperr.ErrCouponNotFound
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/NONEXISTENT/claims", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "coupon not found", result["error"])
+}
+
+func TestListClaims_InternalServerError(t *testing.T) {
+	mockSvc := &mockClaimService{
+		listClaimsFn: func(ctx context.Context, name string, afterSequence, limit int) (*model.ClaimListResponse, error) {
+			return nil, errors.New("database connection failed")
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER/claims", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "internal server error", result["error"])
+}
+
+func TestClaimCoupon_RedactsLogs(t *testing.T) {
+	var logs bytes.Buffer
+	original := log.Logger
+	log.Logger = zerolog.New(&logs)
+	redactor, err := redact.New(redact.ModeTruncate, "")
+	require.NoError(t, err)
+	redact.SetDefault(redactor)
+	t.Cleanup(func() {
+		log.Logger = original
+		off, _ := redact.New(redact.ModeOff, "")
+		redact.SetDefault(off)
+	})
+
+	mockSvc := &mockClaimService{
+		claimCouponFn: func(ctx context.Context, req *model.ClaimCouponRequest) error {
+			return errors.New("insert claim for jane_doe on PROMO_SUPER: connection reset")
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	body := `{"user_id": "jane_doe", "coupon_name": "PROMO_SUPER"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	assert.NotContains(t, logs.String(), "jane_doe")
+	assert.NotContains(t, logs.String(), "PROMO_SUPER")
+	assert.Contains(t, logs.String(), `"user_id":"jane***"`)
+	assert.Contains(t, logs.String(), "insert claim for jane*** on PROM***")
+}
+
+func TestSampleClaims_Success(t *testing.T) {
+	var gotName string
+	var gotN int
+	mockSvc := &mockClaimService{
+		sampleFn: func(ctx context.Context, name string, n int) (*model.ClaimSampleResponse, error) {
+			gotName, gotN = name, n
+			return &model.ClaimSampleResponse{
+				CouponName:  name,
+				TotalClaims: 5000,
+				Claims:      []model.ClaimRecord{{UserID: "user_042", ClaimSequence: 42}},
+			}, nil
+		},
+	}
+	app := setupClaimTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER/claims/sample?n=10", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "PROMO_SUPER", gotName)
+	assert.Equal(t, 10, gotN)
+	var sample model.ClaimSampleResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sample))
+	assert.Equal(t, 5000, sample.TotalClaims)
+	assert.Len(t, sample.Claims, 1)
+}
+
+func TestSampleClaims_DefaultSize(t *testing.T) {
+	var gotN int
+	mockSvc := &mockClaimService{
+		sampleFn: func(ctx context.Context, name string, n int) (*model.ClaimSampleResponse, error) {
+			gotN = n
+			return &model.ClaimSampleResponse{CouponName: name, Claims: []model.ClaimRecord{}}, nil
+		},
+	}
+
+	resp, err := setupClaimTestApp(mockSvc).Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER/claims/sample", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 100, gotN)
+}
+
+func TestSampleClaims_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		wantError  string
+	}{
+		{"n too small", "?n=0", nil, fiber.StatusBadRequest, "invalid request: n must be between 1 and 1000"},
+		{"n too large", "?n=1001", nil, fiber.StatusBadRequest, "invalid request: n must be between 1 and 1000"},
+		{"not found", "", apperr.ErrCouponNotFound, fiber.StatusNotFound, "coupon not found"},
+		{"database error", "", errors.New("connection reset"), fiber.StatusInternalServerError, "internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockClaimService{
+				sampleFn: func(context.Context, string, int) (*model.ClaimSampleResponse, error) {
+					return nil, tt.err
+				},
+			}
+
+			resp, err := setupClaimTestApp(mockSvc).Test(
+				httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER/claims/sample"+tt.query, nil))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, `{"error": "`+tt.wantError+`"}`, string(body))
+		})
+	}
+}
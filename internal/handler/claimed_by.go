@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// How public reads report who claimed a coupon (see CouponHandler.SetClaimedByPrivacy).
+const (
+	ClaimedByFull = "full" // User IDs as claimed
+	ClaimedByHash = "hash" // A keyed hash of each user ID
+	ClaimedByOmit = "omit" // No user IDs at all
+)
+
+// couponWithoutClaimedBy encodes a coupon response without claimed_by: the outer field
+// shadows the embedded one and is always empty.
+type couponWithoutClaimedBy struct {
+	*model.CouponResponse
+	ClaimedBy []string `json:"claimed_by,omitempty"`
+}
+
+// claimedByPrivacy is a claimed_by mode with the hasher of ClaimedByHash mode. The zero
+// value reports user IDs in full.
+type claimedByPrivacy struct {
+	mode   string           // ClaimedByFull when empty
+	hasher *redact.Redactor // Set in ClaimedByHash mode
+}
+
+// newClaimedByPrivacy returns the claimedByPrivacy of mode, hashing with hashKey (at
+// least redact.MinKeyLength bytes) in ClaimedByHash mode.
+func newClaimedByPrivacy(mode, hashKey string) (claimedByPrivacy, error) {
+	switch mode {
+	case ClaimedByFull, ClaimedByOmit:
+		return claimedByPrivacy{mode: mode}, nil
+	case ClaimedByHash:
+		hasher, err := redact.New(redact.ModeHash, hashKey)
+		if err != nil {
+			return claimedByPrivacy{}, err
+		}
+		return claimedByPrivacy{mode: mode, hasher: hasher}, nil
+	default:
+		return claimedByPrivacy{}, fmt.Errorf("unknown claimed_by mode %q", mode)
+	}
+}
+
+// full reports whether user IDs are served as claimed.
+func (p claimedByPrivacy) full() bool {
+	return p.mode == "" || p.mode == ClaimedByFull
+}
+
+// claimRecords returns records with their user IDs as the mode reports them: hashed, or
+// left out. The records may be cached, so they are copied rather than modified.
+func (p claimedByPrivacy) claimRecords(records []model.ClaimRecord) []model.ClaimRecord {
+	if p.full() {
+		return records
+	}
+	out := make([]model.ClaimRecord, len(records))
+	for i, r := range records {
+		if p.mode == ClaimedByHash {
+			r.UserID = p.hasher.Value(r.UserID)
+		} else {
+			r.UserID = ""
+		}
+		out[i] = r
+	}
+	return out
+}
+
+// SetClaimedByPrivacy sets how GetCoupon reports claimed_by, which the endpoint would
+// otherwise serve to anyone as raw user IDs. In ClaimedByHash mode each user ID is
+// replaced with the first 16 hex characters of its HMAC-SHA256 under hashKey (at least
+// redact.MinKeyLength bytes), so a user's claims still match across coupons; in
+// ClaimedByOmit mode claimed_by is left out. stats.total_claims still counts the claims.
+// Outside ClaimedByFull mode ?claimed_by_contains= is refused, as it would tell whether
+// raw user IDs claimed the coupon.
+func (h *CouponHandler) SetClaimedByPrivacy(mode, hashKey string) error {
+	privacy, err := newClaimedByPrivacy(mode, hashKey)
+	if err != nil {
+		return err
+	}
+	h.claimedBy = privacy
+	return nil
+}
+
+// leaders returns leaders with their user IDs as the mode reports them: hashed, or left
+// out. The leaders are copied rather than modified, like claimRecords.
+func (p claimedByPrivacy) leaders(leaders []model.LeaderboardEntry) []model.LeaderboardEntry {
+	if p.full() {
+		return leaders
+	}
+	out := make([]model.LeaderboardEntry, len(leaders))
+	for i, e := range leaders {
+		if p.mode == ClaimedByHash {
+			e.UserID = p.hasher.Value(e.UserID)
+		} else {
+			e.UserID = ""
+		}
+		out[i] = e
+	}
+	return out
+}
+
+// SetClaimedByPrivacy sets how ListClaims and SampleClaims report the user IDs of
+// claims, in the same modes and with the same hashKey as
+// CouponHandler.SetClaimedByPrivacy, so a hashed user matches across both. In
+// ClaimedByOmit mode user_id is left out of the claims.
+func (h *ClaimHandler) SetClaimedByPrivacy(mode, hashKey string) error {
+	privacy, err := newClaimedByPrivacy(mode, hashKey)
+	if err != nil {
+		return err
+	}
+	h.claimedBy = privacy
+	return nil
+}
+
+// SetClaimedByPrivacy sets how GetLeaderboard reports the user IDs of leaders, in the
+// same modes and with the same hashKey as CouponHandler.SetClaimedByPrivacy, so a hashed
+// user matches across both. In ClaimedByOmit mode user_id is left out of the leaders.
+func (h *LeaderboardHandler) SetClaimedByPrivacy(mode, hashKey string) error {
+	privacy, err := newClaimedByPrivacy(mode, hashKey)
+	if err != nil {
+		return err
+	}
+	h.claimedBy = privacy
+	return nil
+}
+
+// publicCoupon returns coupon with claimed_by as the claimed_by mode reports it. The
+// coupon may be cached, so it is copied rather than modified.
+func (h *CouponHandler) publicCoupon(coupon *model.CouponResponse) any {
+	switch h.claimedBy.mode {
+	case ClaimedByHash:
+		hashed := *coupon
+		hashed.ClaimedBy = make([]string, 0, len(coupon.ClaimedBy))
+		for _, userID := range coupon.ClaimedBy {
+			hashed.ClaimedBy = append(hashed.ClaimedBy, h.claimedBy.hasher.Value(userID))
+		}
+		return &hashed
+	case ClaimedByOmit:
+		return couponWithoutClaimedBy{CouponResponse: coupon}
+	}
+	return coupon
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+)
+
+const claimedByHashKey = "0123456789abcdef"
+
+// getCouponWithClaimedBy serves GET /api/coupons/:name in the given claimed_by mode and
+// returns the decoded response and the response the service returned.
+func getCouponWithClaimedBy(t *testing.T, mode string) (map[string]any, *model.CouponResponse) {
+	t.Helper()
+	served := &model.CouponResponse{
+		Name:      "PROMO",
+		ClaimedBy: []string{"user_1", "user_2"},
+		Tags:      []string{},
+		Stats:     model.ClaimStats{TotalClaims: 2},
+	}
+	mockSvc := &mockCouponService{
+		getByNameFn: func(ctx context.Context, name string) (*model.CouponResponse, error) {
+			return served, nil
+		},
+	}
+	h := NewCouponHandler(mockSvc, validator.New())
+	require.NoError(t, h.SetClaimedByPrivacy(mode, claimedByHashKey))
+	app := fiber.New()
+	app.Get("/api/coupons/:name", h.GetCoupon)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var result map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return result, served
+}
+
+func TestGetCoupon_ClaimedByFull(t *testing.T) {
+	result, _ := getCouponWithClaimedBy(t, ClaimedByFull)
+
+	assert.Equal(t, []any{"user_1", "user_2"}, result["claimed_by"])
+}
+
+func TestGetCoupon_ClaimedByHash(t *testing.T) {
+	result, served := getCouponWithClaimedBy(t, ClaimedByHash)
+
+	hasher, err := redact.New(redact.ModeHash, claimedByHashKey)
+	require.NoError(t, err)
+	assert.Equal(t, []any{hasher.Value("user_1"), hasher.Value("user_2")}, result["claimed_by"])
+	assert.Equal(t, "PROMO", result["name"])
+	assert.Equal(t, []string{"user_1", "user_2"}, served.ClaimedBy, "the served (possibly cached) response is not modified")
+}
+
+func TestGetCoupon_ClaimedByOmit(t *testing.T) {
+	result, _ := getCouponWithClaimedBy(t, ClaimedByOmit)
+
+	assert.NotContains(t, result, "claimed_by")
+	assert.Equal(t, "PROMO", result["name"])
+	assert.Equal(t, float64(2), result["stats"].(map[string]any)["total_claims"], "claims are still counted")
+}
+
+func TestCouponHandler_SetClaimedByPrivacy_Invalid(t *testing.T) {
+	h := NewCouponHandler(&mockCouponService{}, validator.New())
+
+	assert.Error(t, h.SetClaimedByPrivacy("hidden", ""))
+	assert.Error(t, h.SetClaimedByPrivacy(ClaimedByHash, "short"))
+}
+
+func TestGetCoupon_ClaimedByContainsRefusedUnlessFull(t *testing.T) {
+	for _, mode := range []string{ClaimedByHash, ClaimedByOmit} {
+		t.Run(mode, func(t *testing.T) {
+			h := NewCouponHandler(&mockCouponService{}, validator.New())
+			require.NoError(t, h.SetClaimedByPrivacy(mode, claimedByHashKey))
+			app := fiber.New()
+			app.Get("/api/coupons/:name", h.GetCoupon)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO?claimed_by_contains=user_1", nil))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+// getClaimsWithClaimedBy serves the claim export and sample in the given claimed_by mode
+// and returns the user_id of each claim in both responses ("" when left out), and the
+// records the service returned.
+func getClaimsWithClaimedBy(t *testing.T, mode string) (listed, sampled []string, served []model.ClaimRecord) {
+	t.Helper()
+	served = []model.ClaimRecord{{UserID: "user_1", ClaimSequence: 1}, {UserID: "user_2", ClaimSequence: 2}}
+	mockSvc := &mockClaimService{
+		listClaimsFn: func(ctx context.Context, name string, afterSequence, limit int) (*model.ClaimListResponse, error) {
+			return &model.ClaimListResponse{CouponName: name, Page: model.Page[model.ClaimRecord]{Items: served}}, nil
+		},
+		sampleFn: func(ctx context.Context, name string, n int) (*model.ClaimSampleResponse, error) {
+			return &model.ClaimSampleResponse{CouponName: name, TotalClaims: 2, Claims: served}, nil
+		},
+	}
+	h := NewClaimHandler(mockSvc, validator.New())
+	require.NoError(t, h.SetClaimedByPrivacy(mode, claimedByHashKey))
+	app := fiber.New()
+	app.Get("/api/coupons/:name/claims", h.ListClaims)
+	app.Get("/api/coupons/:name/claims/sample", h.SampleClaims)
+
+	userIDs := func(path, field string) []string {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		var claims []map[string]any
+		require.NoError(t, json.Unmarshal(result[field], &claims))
+		var ids []string
+		for _, claim := range claims {
+			id, _ := claim["user_id"].(string)
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	return userIDs("/api/coupons/PROMO/claims", "items"), userIDs("/api/coupons/PROMO/claims/sample", "claims"), served
+}
+
+func TestClaimHandler_ClaimedByPrivacy(t *testing.T) {
+	hasher, err := redact.New(redact.ModeHash, claimedByHashKey)
+	require.NoError(t, err)
+	hashed := []string{hasher.Value("user_1"), hasher.Value("user_2")}
+
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{ClaimedByFull, []string{"user_1", "user_2"}},
+		{ClaimedByHash, hashed},
+		{ClaimedByOmit, []string{"", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			listed, sampled, served := getClaimsWithClaimedBy(t, tt.mode)
+
+			assert.Equal(t, tt.want, listed)
+			assert.Equal(t, tt.want, sampled)
+			assert.Equal(t, "user_1", served[0].UserID, "the served records are not modified")
+		})
+	}
+}
+
+func TestLeaderboardHandler_ClaimedByPrivacy(t *testing.T) {
+	hasher, err := redact.New(redact.ModeHash, claimedByHashKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		mode string
+		want []any
+	}{
+		{ClaimedByFull, []any{"alice", "bob"}},
+		{ClaimedByHash, []any{hasher.Value("alice"), hasher.Value("bob")}},
+		{ClaimedByOmit, []any{nil, nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			h := NewLeaderboardHandler(&mockLeaderboardService{})
+			require.NoError(t, h.SetClaimedByPrivacy(tt.mode, claimedByHashKey))
+			app := fiber.New()
+			app.Get("/api/campaigns/:id/leaderboard", h.GetLeaderboard)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/campaigns/summer/leaderboard", nil))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+			var result struct {
+				Leaders []map[string]any `json:"leaders"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			var userIDs []any
+			for _, leader := range result.Leaders {
+				userIDs = append(userIDs, leader["user_id"])
+			}
+			assert.Equal(t, tt.want, userIDs)
+		})
+	}
+}
+
+func TestCouponWrites_ClaimedByPrivacy(t *testing.T) {
+	coupon := func(name string) *model.CouponResponse {
+		return &model.CouponResponse{Name: name, ClaimedBy: []string{"user_1"}, Tags: []string{}}
+	}
+	mockSvc := &mockCouponService{
+		updateFn: func(ctx context.Context, name string, req *model.UpdateCouponRequest) (*model.CouponResponse, error) {
+			return coupon(name), nil
+		},
+		topUpFn: func(ctx context.Context, name string, amount int) (*model.CouponResponse, error) {
+			return coupon(name), nil
+		},
+		putFn: func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+			return coupon(req.Name), false, nil
+		},
+	}
+	h := NewCouponHandler(mockSvc, validator.New())
+	require.NoError(t, h.SetClaimedByPrivacy(ClaimedByOmit, ""))
+	app := fiber.New()
+	app.Patch("/api/coupons/:name", h.UpdateCoupon)
+	app.Put("/api/coupons/:name", h.PutCoupon)
+	app.Post("/api/coupons/:name/top-up", h.TopUpCoupon)
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{http.MethodPatch, "/api/coupons/PROMO", `{"high_profile": true}`},
+		{http.MethodPut, "/api/coupons/PROMO", `{"amount": 10}`},
+		{http.MethodPost, "/api/coupons/PROMO/top-up", `{"amount": 5}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode, tt.method)
+
+		var result map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		resp.Body.Close()
+		assert.Equal(t, "PROMO", result["name"], tt.method)
+		assert.NotContains(t, result, "claimed_by", "%s answers claimed_by as GetCoupon does", tt.method)
+	}
+}
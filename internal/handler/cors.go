@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// corsExposeHeaders are the response headers browser clients on other origins may read.
+var corsExposeHeaders = strings.Join([]string{
+	fiber.HeaderXRequestID,
+	fiber.HeaderRetryAfter,
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+}, ", ")
+
+// methodOrder orders the methods listed in Allow and Access-Control-Allow-Methods.
+var methodOrder = []string{
+	fiber.MethodGet, fiber.MethodHead, fiber.MethodPost, fiber.MethodPut,
+	fiber.MethodPatch, fiber.MethodDelete, fiber.MethodOptions,
+}
+
+// CORSConfig configures CORS. An empty AllowOrigins disables cross-origin access;
+// "*" allows any origin. MaxAge is how long browsers may cache a preflight answer.
+type CORSConfig struct {
+	AllowOrigins []string
+	AllowHeaders []string
+	MaxAge       time.Duration
+}
+
+// CORS returns middleware answering OPTIONS requests for every route with 204 and the
+// methods registered on the request's path in Allow, or passing them on to 404 when no
+// route matches. Preflights from allowed origins additionally get the CORS headers,
+// with the same methods in Access-Control-Allow-Methods, and other requests from
+// allowed origins get Access-Control-Allow-Origin. Methods are derived from the app's
+// routes on first use, so the middleware must be mounted before any route is served.
+func CORS(cfg CORSConfig) fiber.Handler {
+	var (
+		once   sync.Once
+		routes []fiber.Route
+	)
+	anyOrigin := slices.Contains(cfg.AllowOrigins, "*")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	// allowOrigin returns the Access-Control-Allow-Origin value for origin, or "".
+	allowOrigin := func(origin string) string {
+		switch {
+		case origin == "":
+			return ""
+		case anyOrigin:
+			return "*"
+		case slices.Contains(cfg.AllowOrigins, origin):
+			return origin
+		}
+		return ""
+	}
+
+	return func(c *fiber.Ctx) error {
+		origin := allowOrigin(c.Get(fiber.HeaderOrigin))
+		if origin != "" && origin != "*" {
+			c.Vary(fiber.HeaderOrigin)
+		}
+
+		if c.Method() != fiber.MethodOptions {
+			if origin != "" {
+				c.Set(fiber.HeaderAccessControlAllowOrigin, origin)
+				c.Set(fiber.HeaderAccessControlExposeHeaders, corsExposeHeaders)
+			}
+			return c.Next()
+		}
+
+		once.Do(func() { routes = c.App().GetRoutes(true) })
+		methods := routeMethods(routes, c.Path())
+		if len(methods) == 0 {
+			return c.Next()
+		}
+		allow := strings.Join(methods, ", ")
+		c.Set(fiber.HeaderAllow, allow)
+
+		if origin != "" && c.Get(fiber.HeaderAccessControlRequestMethod) != "" {
+			c.Set(fiber.HeaderAccessControlAllowOrigin, origin)
+			c.Set(fiber.HeaderAccessControlAllowMethods, allow)
+			if allowHeaders != "" {
+				c.Set(fiber.HeaderAccessControlAllowHeaders, allowHeaders)
+			}
+			c.Set(fiber.HeaderAccessControlMaxAge, maxAge)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// routeMethods returns the methods of the routes matching path, plus OPTIONS, in
+// methodOrder; nil if no route matches.
+func routeMethods(routes []fiber.Route, path string) []string {
+	found := map[string]bool{}
+	for _, r := range routes {
+		if matchRoutePath(r.Path, path) {
+			found[r.Method] = true
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	found[fiber.MethodOptions] = true
+
+	methods := make([]string, 0, len(found))
+	for _, m := range methodOrder {
+		if found[m] {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// matchRoutePath reports whether path matches a route pattern whose segments are
+// literals, :params or a trailing *.
+func matchRoutePath(pattern, path string) bool {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range want {
+		if seg == "*" {
+			return true
+		}
+		if i >= len(got) {
+			return false
+		}
+		if strings.HasPrefix(seg, ":") {
+			if got[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != got[i] {
+			return false
+		}
+	}
+	return len(want) == len(got)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCORSTestApp(cfg CORSConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(CORS(cfg))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/api/coupons/:name", ok)
+	app.Patch("/api/coupons/:name", ok)
+	app.Post("/api/coupons/claim", ok)
+	app.Get("/api/coupons/:name/claims", ok)
+	return app
+}
+
+func preflight(t *testing.T, app *fiber.App, path, origin string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	if origin != "" {
+		req.Header.Set(fiber.HeaderOrigin, origin)
+		req.Header.Set(fiber.HeaderAccessControlRequestMethod, http.MethodPatch)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	app := setupCORSTestApp(CORSConfig{
+		AllowOrigins: []string{"https://shop.example.com"},
+		AllowHeaders: []string{"Content-Type", "X-Request-ID"},
+		MaxAge:       10 * time.Minute,
+	})
+
+	resp := preflight(t, app, "/api/coupons/PROMO", "https://shop.example.com")
+
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "https://shop.example.com", resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "GET, HEAD, PATCH, OPTIONS", resp.Header.Get(fiber.HeaderAccessControlAllowMethods))
+	assert.Equal(t, "Content-Type, X-Request-ID", resp.Header.Get(fiber.HeaderAccessControlAllowHeaders))
+	assert.Equal(t, "600", resp.Header.Get(fiber.HeaderAccessControlMaxAge))
+	assert.Equal(t, fiber.HeaderOrigin, resp.Header.Get(fiber.HeaderVary))
+}
+
+func TestCORS_MethodsPerRoute(t *testing.T) {
+	app := setupCORSTestApp(CORSConfig{AllowOrigins: []string{"*"}})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/coupons/PROMO/claims", "GET, HEAD, OPTIONS"},
+		{"/api/coupons/claim", "GET, HEAD, POST, PATCH, OPTIONS"}, // Also matches /api/coupons/:name
+	}
+	for _, tt := range tests {
+		resp := preflight(t, app, tt.path, "https://any.example.com")
+
+		assert.Equal(t, fiber.StatusNoContent, resp.StatusCode, tt.path)
+		assert.Equal(t, tt.want, resp.Header.Get(fiber.HeaderAllow), tt.path)
+		assert.Equal(t, tt.want, resp.Header.Get(fiber.HeaderAccessControlAllowMethods), tt.path)
+		assert.Equal(t, "*", resp.Header.Get(fiber.HeaderAccessControlAllowOrigin), tt.path)
+	}
+}
+
+func TestCORS_OriginNotAllowed(t *testing.T) {
+	app := setupCORSTestApp(CORSConfig{AllowOrigins: []string{"https://shop.example.com"}})
+
+	resp := preflight(t, app, "/api/coupons/PROMO", "https://evil.example.com")
+
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "GET, HEAD, PATCH, OPTIONS", resp.Header.Get(fiber.HeaderAllow))
+	assert.Empty(t, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+	assert.Empty(t, resp.Header.Get(fiber.HeaderAccessControlAllowMethods))
+}
+
+func TestCORS_Disabled(t *testing.T) {
+	app := setupCORSTestApp(CORSConfig{})
+
+	resp := preflight(t, app, "/api/coupons/PROMO", "")
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode, "OPTIONS is answered without CORS too")
+	assert.Equal(t, "GET, HEAD, PATCH, OPTIONS", resp.Header.Get(fiber.HeaderAllow))
+
+	resp = preflight(t, app, "/api/coupons/PROMO", "https://shop.example.com")
+	assert.Empty(t, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+}
+
+func TestCORS_UnknownPath(t *testing.T) {
+	app := setupCORSTestApp(CORSConfig{AllowOrigins: []string{"*"}})
+
+	resp := preflight(t, app, "/api/unknown", "https://shop.example.com")
+
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestCORS_SimpleRequest(t *testing.T) {
+	app := setupCORSTestApp(CORSConfig{AllowOrigins: []string{"https://shop.example.com"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO", nil)
+	req.Header.Set(fiber.HeaderOrigin, "https://shop.example.com")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "https://shop.example.com", resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+	assert.Contains(t, resp.Header.Get(fiber.HeaderAccessControlExposeHeaders), "X-Request-ID")
+}
+
+func TestMatchRoutePath(t *testing.T) {
+	assert.True(t, matchRoutePath("/api/coupons/:name", "/api/coupons/PROMO"))
+	assert.True(t, matchRoutePath("/api/coupons/:name", "/api/coupons/PROMO/"))
+	assert.False(t, matchRoutePath("/api/coupons/:name", "/api/coupons"))
+	assert.False(t, matchRoutePath("/api/coupons/:name", "/api/coupons/PROMO/claims"))
+	assert.True(t, matchRoutePath("/admin/*", "/admin/assets/app.js"))
+}
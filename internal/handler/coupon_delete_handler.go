@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// CouponDeleteServiceInterface defines the interface for deleting and restoring coupons.
+type CouponDeleteServiceInterface interface {
+	Delete(ctx context.Context, name string) (*model.DeletedCoupon, error)
+	Restore(ctx context.Context, name string) (*model.CouponResponse, error)
+}
+
+// CouponDeleteHandler handles HTTP requests deleting and restoring coupons.
+type CouponDeleteHandler struct {
+	service CouponDeleteServiceInterface
+}
+
+// NewCouponDeleteHandler creates a new CouponDeleteHandler with the given service.
+func NewCouponDeleteHandler(svc CouponDeleteServiceInterface) *CouponDeleteHandler {
+	return &CouponDeleteHandler{service: svc}
+}
+
+// DeleteCoupon handles DELETE /api/coupons/:name requests. The coupon can be restored
+// until restorable_until in the response.
+func (h *CouponDeleteHandler) DeleteCoupon(c *fiber.Ctx) error {
+	name := c.Params("name")
+	deleted, err := h.service.Delete(c.UserContext(), name)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to delete coupon")
+		return internalError(c, err)
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("coupon_name", redact.Value(name)).
+		Time("restorable_until", deleted.RestorableUntil).
+		Msg("coupon deleted")
+
+	return c.JSON(deleted)
+}
+
+// RestoreCoupon handles POST /api/coupons/:name/restore requests.
+func (h *CouponDeleteHandler) RestoreCoupon(c *fiber.Ctx) error {
+	name := c.Params("name")
+	coupon, err := h.service.Restore(c.UserContext(), name)
+	if err != nil {
+		if errors.Is(err, apperr.ErrDeletedCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "deleted coupon not found"})
+		}
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to restore coupon")
+		return internalError(c, err)
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("coupon_name", redact.Value(name)).
+		Msg("coupon restored")
+
+	return c.JSON(coupon)
+}
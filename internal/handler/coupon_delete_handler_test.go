@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// mockCouponDeleteService is a mock implementation of CouponDeleteServiceInterface
+// knowing the coupon "PROMO" only.
+type mockCouponDeleteService struct {
+	deleted bool
+	err     error
+}
+
+func (m *mockCouponDeleteService) Delete(ctx context.Context, name string) (*model.DeletedCoupon, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if name != "PROMO" || m.deleted {
+		return nil, apperr.ErrCouponNotFound
+	}
+	m.deleted = true
+	deletedAt := time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+	return &model.DeletedCoupon{Name: name, DeletedAt: deletedAt, RestorableUntil: deletedAt.Add(24 * time.Hour)}, nil
+}
+
+func (m *mockCouponDeleteService) Restore(ctx context.Context, name string) (*model.CouponResponse, error) {
+	if name != "PROMO" || !m.deleted {
+		return nil, apperr.ErrDeletedCouponNotFound
+	}
+	m.deleted = false
+	return &model.CouponResponse{Name: name, Amount: 10, RemainingAmount: 7}, nil
+}
+
+func setupCouponDeleteTestApp(mockSvc *mockCouponDeleteService) *fiber.App {
+	app := fiber.New()
+	h := NewCouponDeleteHandler(mockSvc)
+	app.Delete("/api/coupons/:name", h.DeleteCoupon)
+	app.Post("/api/coupons/:name/restore", h.RestoreCoupon)
+	return app
+}
+
+func TestDeleteCoupon(t *testing.T) {
+	mockSvc := &mockCouponDeleteService{}
+	app := setupCouponDeleteTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/api/coupons/PROMO", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{
+		"name": "PROMO",
+		"deleted_at": "2024-11-29T10:00:00Z",
+		"restorable_until": "2024-11-30T10:00:00Z"
+	}`, string(body))
+	assert.True(t, mockSvc.deleted)
+}
+
+func TestDeleteCoupon_NotFound(t *testing.T) {
+	app := setupCouponDeleteTestApp(&mockCouponDeleteService{deleted: true})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/api/coupons/PROMO", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"error": "coupon not found"}`, string(body))
+}
+
+func TestDeleteCoupon_InternalError(t *testing.T) {
+	app := setupCouponDeleteTestApp(&mockCouponDeleteService{err: errors.New("connection refused")})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/api/coupons/PROMO", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestRestoreCoupon(t *testing.T) {
+	mockSvc := &mockCouponDeleteService{deleted: true}
+	app := setupCouponDeleteTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/api/coupons/PROMO/restore", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var coupon model.CouponResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&coupon))
+	assert.Equal(t, "PROMO", coupon.Name)
+	assert.Equal(t, 7, coupon.RemainingAmount)
+	assert.False(t, mockSvc.deleted)
+}
+
+func TestRestoreCoupon_NotFound(t *testing.T) {
+	app := setupCouponDeleteTestApp(&mockCouponDeleteService{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/api/coupons/PROMO/restore", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"error": "deleted coupon not found"}`, string(body))
+}
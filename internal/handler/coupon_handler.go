@@ -3,25 +3,52 @@ package handler
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
-	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
 )
 
 // CouponServiceInterface defines the interface for coupon business logic.
 type CouponServiceInterface interface {
 	Create(ctx context.Context, req *model.CreateCouponRequest) error
 	GetByName(ctx context.Context, name string) (*model.CouponResponse, error)
+	GetByNameClaimedBy(ctx context.Context, name string, userIDs []string) (*model.CouponResponse, error)
+	List(ctx context.Context, filter model.CouponFilter) (*model.CouponListResponse, error)
+	Update(ctx context.Context, name string, req *model.UpdateCouponRequest) (*model.CouponResponse, error)
+	Put(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error)
+	TopUp(ctx context.Context, name string, amount int) (*model.CouponResponse, error)
+}
+
+// maxClaimedByContains bounds the users checked by GET /api/coupons/:name?claimed_by_contains=.
+const maxClaimedByContains = 100
+
+// parseClaimedByContains splits a claimed_by_contains value into user IDs.
+// Returns false unless it names 1 to maxClaimedByContains non-blank IDs of at most 255 characters.
+func parseClaimedByContains(value string) ([]string, bool) {
+	userIDs := strings.Split(value, ",")
+	if len(userIDs) > maxClaimedByContains {
+		return nil, false
+	}
+	for _, userID := range userIDs {
+		if strings.TrimSpace(userID) == "" || len(userID) > 255 {
+			return nil, false
+		}
+	}
+	return userIDs, true
 }
 
 // CouponHandler handles HTTP requests for coupon operations.
 type CouponHandler struct {
 	service   CouponServiceInterface
 	validator *validator.Validate
+
+	claimedBy claimedByPrivacy
 }
 
 // NewCouponHandler creates a new CouponHandler with the given service and validator.
@@ -38,6 +65,23 @@ func formatValidationError(err error) string {
 			field := fe.Field()
 			tag := fe.Tag()
 
+			if field == "Tags" || strings.HasPrefix(field, "Tags[") {
+				return formatTagsValidationError(field, tag)
+			}
+			if field == "Channels" || strings.HasPrefix(field, "Channels[") {
+				return formatChannelsValidationError(field, tag)
+			}
+			if field == "Regions" || strings.HasPrefix(field, "Regions[") {
+				return formatRegionsValidationError(field, tag)
+			}
+			// Tier entries are structs, so their errors report Name/Size fields under a Tiers[i] namespace.
+			if field == "Tiers" || strings.Contains(fe.StructNamespace(), ".Tiers[") {
+				return formatTiersValidationError(field, tag)
+			}
+			if strings.Contains(fe.StructNamespace(), ".ClaimRetention.") {
+				return formatClaimRetentionValidationError(field)
+			}
+
 			switch field {
 			case "Name":
 				if tag == "required" {
@@ -51,14 +95,44 @@ func formatValidationError(err error) string {
 				}
 				return "invalid request: name is invalid"
 			case "Amount":
-				if tag == "required" {
+				if tag == "required" || tag == "required_unless" {
 					return "invalid request: amount is required"
 				}
+				if tag == "excluded_if" {
+					return "invalid request: unlimited coupons cannot have an amount"
+				}
 				if tag == "gte" {
 					return "invalid request: amount must be at least 1"
 				}
 				// Defensive: handle other amount validation tags
 				return "invalid request: amount is invalid"
+			case "Metadata":
+				if tag == "max" {
+					return "invalid request: metadata exceeds maximum size of 16384 bytes"
+				}
+				return "invalid request: metadata is invalid"
+			case "LowStockPercent":
+				if tag == "excluded_if" {
+					return "invalid request: unlimited coupons cannot have a low_stock_percent"
+				}
+				return "invalid request: low_stock_percent must be between 1 and 99"
+			case "Parent":
+				if tag == "notblank" {
+					return "invalid request: parent cannot be whitespace only"
+				}
+				return "invalid request: parent exceeds maximum length of 255"
+			case "Prerequisite":
+				if tag == "notblank" {
+					return "invalid request: prerequisite cannot be whitespace only"
+				}
+				return "invalid request: prerequisite exceeds maximum length of 255"
+			case "ExclusionGroup":
+				if tag == "notblank" {
+					return "invalid request: exclusion_group cannot be whitespace only"
+				}
+				return "invalid request: exclusion_group exceeds maximum length of 255"
+			case "Visibility":
+				return "invalid request: visibility must be public, unlisted or private"
 			default:
 				// Defensive: handle unknown fields with descriptive message
 				if tag == "required" {
@@ -74,7 +148,110 @@ func formatValidationError(err error) string {
 	return "invalid request"
 }
 
-// CreateCoupon handles POST /api/coupons requests to create a new coupon.
+// formatTagsValidationError converts validator errors on the tags array or its entries.
+func formatTagsValidationError(field, tag string) string {
+	if field == "Tags" {
+		if tag == "max" {
+			return "invalid request: tags exceeds maximum of 20 entries"
+		}
+		return "invalid request: tags is invalid"
+	}
+	if tag == "notblank" {
+		return "invalid request: tags cannot contain blank values"
+	}
+	if tag == "max" {
+		return "invalid request: tag exceeds maximum length of 64"
+	}
+	return "invalid request: tags is invalid"
+}
+
+// formatChannelsValidationError converts validator errors on the channels map, its keys or its percentages.
+func formatChannelsValidationError(field, tag string) string {
+	switch {
+	case tag == "excluded_if":
+		return "invalid request: unlimited coupons cannot have channels"
+	case field == "Channels" && tag == "max":
+		return "invalid request: channels exceeds maximum of 10 entries"
+	case tag == "notblank":
+		return "invalid request: channel names cannot be blank"
+	case tag == "max" && strings.HasPrefix(field, "Channels["):
+		// Percentages are bounded by lte, so a max failure on an element is always a key.
+		return "invalid request: channel name exceeds maximum length of 64"
+	case tag == "gte" || tag == "lte":
+		return "invalid request: channel percentage must be between 1 and 100"
+	}
+	return "invalid request: channels is invalid"
+}
+
+// formatRegionsValidationError converts validator errors on the regions map, its keys or its quotas.
+func formatRegionsValidationError(field, tag string) string {
+	switch {
+	case field == "Regions" && tag == "max":
+		return "invalid request: regions exceeds maximum of 50 entries"
+	case tag == "notblank":
+		return "invalid request: region names cannot be blank"
+	case tag == "max":
+		// Quotas have no upper bound, so a max failure on an element is always a key.
+		return "invalid request: region name exceeds maximum length of 64"
+	case tag == "gte":
+		return "invalid request: region quota must be at least 1"
+	}
+	return "invalid request: regions is invalid"
+}
+
+// formatTiersValidationError converts validator errors on the tiers array or its entries.
+func formatTiersValidationError(field, tag string) string {
+	switch {
+	case field == "Tiers" && tag == "max":
+		return "invalid request: tiers exceeds maximum of 10 entries"
+	case field == "Name" && tag == "required":
+		return "invalid request: tier name is required"
+	case field == "Name" && tag == "notblank":
+		return "invalid request: tier name cannot be whitespace only"
+	case field == "Name" && tag == "max":
+		return "invalid request: tier name exceeds maximum length of 32"
+	case field == "Size":
+		return "invalid request: tier size must be at least 1"
+	}
+	return "invalid request: tiers is invalid"
+}
+
+// formatClaimRetentionValidationError converts validator errors on the claim_retention object.
+func formatClaimRetentionValidationError(field string) string {
+	if field == "Days" {
+		return "invalid request: claim_retention days must be between 1 and 3650"
+	}
+	return "invalid request: claim_retention action must be purge or anonymize"
+}
+
+// couponConfigErrorMessage maps service errors for an invalid coupon configuration
+// to their 400 response message.
+func couponConfigErrorMessage(err error) (string, bool) {
+	switch {
+	case errors.Is(err, apperr.ErrInvalidRequest):
+		return "invalid request", true
+	case errors.Is(err, apperr.ErrInvalidChannelQuotas):
+		return "invalid request: channel percentages must sum to 100", true
+	case errors.Is(err, apperr.ErrInvalidTiers):
+		return "invalid request: tier sizes exceed amount", true
+	case errors.Is(err, apperr.ErrInvalidParent):
+		return "invalid request: parent must be an existing coupon without a parent", true
+	case errors.Is(err, apperr.ErrInvalidPrerequisite):
+		return "invalid request: prerequisite must be an existing coupon", true
+	case errors.Is(err, apperr.ErrClaimRetentionDisabled):
+		return "invalid request: claim retention is not enabled", true
+	}
+	var metadataErr *apperr.MetadataError
+	if errors.As(err, &metadataErr) {
+		return "invalid request: metadata " + metadataErr.Reason, true
+	}
+	return "", false
+}
+
+// CreateCoupon handles POST /api/coupons requests to create a new coupon. With
+// ?idempotent=true it answers as PutCoupon, so creators retrying a create, or racing
+// each other to create the same coupon, get the existing coupon instead of 409 when its
+// configuration matches.
 func (h *CouponHandler) CreateCoupon(c *fiber.Ctx) error {
 	var req model.CreateCouponRequest
 
@@ -88,22 +265,31 @@ func (h *CouponHandler) CreateCoupon(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatValidationError(err)})
 	}
 
+	if c.QueryBool("idempotent") {
+		coupon, created, err := h.service.Put(c.UserContext(), &req)
+		return h.putResponse(c, &req, coupon, created, err)
+	}
+
 	// Create coupon via service
-	if err := h.service.Create(c.Context(), &req); err != nil {
-		if errors.Is(err, service.ErrCouponExists) {
+	if err := h.service.Create(c.UserContext(), &req); err != nil {
+		if errors.Is(err, apperr.ErrCouponExists) {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "coupon already exists"})
 		}
-		if errors.Is(err, service.ErrInvalidRequest) {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		if msg, ok := couponConfigErrorMessage(err); ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
 		}
-		log.Error().Err(err).Str("coupon_name", req.Name).Msg("failed to create coupon")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+		log.Error().Str("error", redact.Error(err, req.Name)).Str("coupon_name", redact.Value(req.Name)).Msg("failed to create coupon")
+		return internalError(c, err)
 	}
 
 	return c.Status(fiber.StatusCreated).Send(nil)
 }
 
 // GetCoupon handles GET /api/coupons/:name requests to retrieve coupon details.
+// With ?claimed_by_contains=user_1,user_2 claimed_by lists only which of those users
+// claimed the coupon, checked in the database rather than by shipping every claimer.
+// Unlisted and private coupons are not found. claimed_by may be hashed or omitted, and
+// claimed_by_contains is then refused (see SetClaimedByPrivacy).
 func (h *CouponHandler) GetCoupon(c *fiber.Ctx) error {
 	name := c.Params("name")
 	if name == "" {
@@ -112,24 +298,220 @@ func (h *CouponHandler) GetCoupon(c *fiber.Ctx) error {
 		})
 	}
 
-	coupon, err := h.service.GetByName(c.Context(), name)
+	var coupon *model.CouponResponse
+	var err error
+	if contains, ok := c.Queries()["claimed_by_contains"]; ok {
+		if !h.claimedBy.full() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request: claimed_by_contains is not available while claimed_by is hashed or omitted",
+			})
+		}
+		userIDs, valid := parseClaimedByContains(contains)
+		if !valid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request: claimed_by_contains must list 1 to 100 comma-separated user IDs",
+			})
+		}
+		coupon, err = h.service.GetByNameClaimedBy(c.UserContext(), name, userIDs)
+	} else {
+		coupon, err = h.service.GetByName(c.UserContext(), name)
+	}
 	if err != nil {
-		if errors.Is(err, service.ErrCouponNotFound) {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": "coupon not found",
 			})
 		}
-		log.Error().Err(err).Str("coupon_name", name).Msg("failed to get coupon")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "internal server error",
+		log.Error().Str("error", redact.Error(err, name)).Str("coupon_name", redact.Value(name)).Msg("failed to get coupon")
+		return internalError(c, err)
+	}
+	if !model.Listed(coupon.Visibility) {
+		// Unlisted and private coupons read as missing, even to callers knowing the name
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "coupon not found",
 		})
 	}
 
 	log.Info().
-		Str("coupon_name", coupon.Name).
+		Str("coupon_name", redact.Value(coupon.Name)).
 		Int("remaining_amount", coupon.RemainingAmount).
 		Int("claims_count", len(coupon.ClaimedBy)).
 		Msg("coupon retrieved")
 
-	return c.JSON(coupon)
+	return c.JSON(h.publicCoupon(coupon))
+}
+
+// writtenCoupon returns the response to a write of coupon: the coupon as GetCoupon
+// serves it, claimed_by as the claimed_by mode reports it, or written, the fields the
+// write set, if the coupon is unlisted or private. GetCoupon answers 404 for those, so a
+// write must not reveal their stock or claimers to callers who only know the name.
+func (h *CouponHandler) writtenCoupon(coupon *model.CouponResponse, written any) any {
+	if !model.Listed(coupon.Visibility) {
+		return written
+	}
+	return h.publicCoupon(coupon)
+}
+
+// ListCoupons handles GET /api/coupons requests to list coupons, a page at a time.
+// Supports ?tag= to filter by a single tag, ?limit= to bound the page size and ?cursor=
+// to read the page after the previous one.
+func (h *CouponHandler) ListCoupons(c *fiber.Ctx) error {
+	limit, after, msg := pageQuery(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+
+	filter := model.CouponFilter{Tag: c.Query("tag"), Status: c.Query("status"), After: after, Limit: limit}
+	switch filter.Status {
+	case "", model.CouponStatusActive, model.CouponStatusExhausted, model.CouponStatusDisabled:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: status must be active, exhausted or disabled",
+		})
+	}
+	coupons, err := h.service.List(c.UserContext(), filter)
+	if err != nil {
+		log.Error().Err(err).Str("tag", filter.Tag).Msg("failed to list coupons")
+		return internalError(c, err)
+	}
+
+	return c.JSON(coupons)
+}
+
+// UpdateCoupon handles PATCH /api/coupons/:name requests to update mutable coupon fields.
+func (h *CouponHandler) UpdateCoupon(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: name is required",
+		})
+	}
+
+	var req model.UpdateCouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatValidationError(err)})
+	}
+
+	coupon, err := h.service.Update(c.UserContext(), name, &req)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		if errors.Is(err, apperr.ErrInvalidRequest) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+		log.Error().Str("error", redact.Error(err, name)).Str("coupon_name", redact.Value(name)).Msg("failed to update coupon")
+		return internalError(c, err)
+	}
+
+	return c.JSON(h.writtenCoupon(coupon, fiber.Map{
+		"name":         coupon.Name,
+		"tags":         coupon.Tags,
+		"high_profile": coupon.HighProfile,
+	}))
+}
+
+// TopUpCoupon handles POST /api/coupons/:name/top-up requests to add stock to a coupon.
+func (h *CouponHandler) TopUpCoupon(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: name is required",
+		})
+	}
+
+	var req model.TopUpRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatValidationError(err)})
+	}
+
+	coupon, err := h.service.TopUp(c.UserContext(), name, req.Amount)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		if errors.Is(err, apperr.ErrPartitionedTopUp) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "channel-partitioned coupons cannot be topped up"})
+		}
+		if errors.Is(err, apperr.ErrUnlimitedTopUp) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "unlimited coupons cannot be topped up"})
+		}
+		if errors.Is(err, apperr.ErrInvalidRequest) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+		log.Error().Str("error", redact.Error(err, name)).Str("coupon_name", redact.Value(name)).Msg("failed to top up coupon")
+		return internalError(c, err)
+	}
+
+	log.Info().
+		Str("coupon_name", redact.Value(name)).
+		Int("added", req.Amount).
+		Int("remaining_amount", coupon.RemainingAmount).
+		Msg("coupon topped up")
+
+	return c.JSON(h.writtenCoupon(coupon, fiber.Map{"name": coupon.Name, "added": req.Amount}))
+}
+
+// PutCoupon handles PUT /api/coupons/:name requests to create a coupon idempotently.
+// Responds 201 when the coupon was created, 200 when an identical coupon already exists,
+// and 409 with a field diff when the existing coupon has a different configuration.
+func (h *CouponHandler) PutCoupon(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: name is required",
+		})
+	}
+
+	var req model.CreateCouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Name != "" && req.Name != name {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: name does not match path"})
+	}
+	req.Name = name
+	if err := h.validator.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatValidationError(err)})
+	}
+
+	coupon, created, err := h.service.Put(c.UserContext(), &req)
+	return h.putResponse(c, &req, coupon, created, err)
+}
+
+// putResponse answers the idempotent create req with the result of CouponService.Put.
+func (h *CouponHandler) putResponse(c *fiber.Ctx, req *model.CreateCouponRequest, coupon *model.CouponResponse, created bool, err error) error {
+	name := req.Name
+	if err != nil {
+		var conflict *apperr.ConflictError
+		if errors.As(err, &conflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "coupon already exists with different configuration",
+				"diff":  conflict.Diffs,
+			})
+		}
+		if errors.Is(err, apperr.ErrCouponDeleted) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "coupon is deleted: restore it, or recreate it once it is purged",
+			})
+		}
+		if msg, ok := couponConfigErrorMessage(err); ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+		}
+		log.Error().Str("error", redact.Error(err, name)).Str("coupon_name", redact.Value(name)).Msg("failed to put coupon")
+		return internalError(c, err)
+	}
+
+	// Put created the coupon as req, or found it configured so: req is what was written
+	resp := h.writtenCoupon(coupon, req)
+	if created {
+		return c.Status(fiber.StatusCreated).JSON(resp)
+	}
+	return c.JSON(resp)
 }
@@ -5,24 +5,33 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
-	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
 // mockCouponService is a mock implementation of CouponServiceInterface.
 type mockCouponService struct {
 	createFn    func(ctx context.Context, req *model.CreateCouponRequest) error
 	getByNameFn func(ctx context.Context, name string) (*model.CouponResponse, error)
+	claimedByFn func(ctx context.Context, name string, userIDs []string) (*model.CouponResponse, error)
+	listFn      func(ctx context.Context, filter model.CouponFilter) (*model.CouponListResponse, error)
+	updateFn    func(ctx context.Context, name string, req *model.UpdateCouponRequest) (*model.CouponResponse, error)
+	putFn       func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error)
+	topUpFn     func(ctx context.Context, name string, amount int) (*model.CouponResponse, error)
 }
 
 func (m *mockCouponService) Create(ctx context.Context, req *model.CreateCouponRequest) error {
@@ -39,12 +48,51 @@ func (m *mockCouponService) GetByName(ctx context.Context, name string) (*model.
 	return nil, nil
 }
 
+func (m *mockCouponService) GetByNameClaimedBy(ctx context.Context, name string, userIDs []string) (*model.CouponResponse, error) {
+	if m.claimedByFn != nil {
+		return m.claimedByFn(ctx, name, userIDs)
+	}
+	return nil, nil
+}
+
+func (m *mockCouponService) List(ctx context.Context, filter model.CouponFilter) (*model.CouponListResponse, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx, filter)
+	}
+	return &model.CouponListResponse{Items: []model.CouponSummary{}}, nil
+}
+
+func (m *mockCouponService) Update(ctx context.Context, name string, req *model.UpdateCouponRequest) (*model.CouponResponse, error) {
+	if m.updateFn != nil {
+		return m.updateFn(ctx, name, req)
+	}
+	return nil, nil
+}
+
+func (m *mockCouponService) Put(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+	if m.putFn != nil {
+		return m.putFn(ctx, req)
+	}
+	return nil, false, nil
+}
+
+func (m *mockCouponService) TopUp(ctx context.Context, name string, amount int) (*model.CouponResponse, error) {
+	if m.topUpFn != nil {
+		return m.topUpFn(ctx, name, amount)
+	}
+	return nil, nil
+}
+
 func setupTestApp(mockSvc *mockCouponService) *fiber.App {
 	app := fiber.New()
 	v := validator.New() // Uses shared validator with custom validations
 	h := NewCouponHandler(mockSvc, v)
 	app.Post("/api/coupons", h.CreateCoupon)
+	app.Get("/api/coupons", h.ListCoupons)
 	app.Get("/api/coupons/:name", h.GetCoupon)
+	app.Patch("/api/coupons/:name", h.UpdateCoupon)
+	app.Put("/api/coupons/:name", h.PutCoupon)
+	app.Post("/api/coupons/:name/top-up", h.TopUpCoupon)
 	return app
 }
 
@@ -70,6 +118,44 @@ func TestCreateCoupon_Success(t *testing.T) {
 	assert.Empty(t, respBody, "Response body should be empty on success")
 }
 
+func TestCreateCoupon_Idempotent(t *testing.T) {
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			t.Fatal("idempotent creates must not fail on an existing coupon")
+			return nil
+		},
+		putFn: func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+			if req.Name == "PROMO_BIG" {
+				return nil, false, &apperr.ConflictError{Diffs: []model.FieldDiff{{Field: "amount", Current: 100, Requested: 200}}}
+			}
+			return &model.CouponResponse{Name: req.Name, Amount: 100, RemainingAmount: 40, ClaimedBy: []string{}, Tags: []string{}}, false, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	post := func(body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons?idempotent=true", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := post(`{"name": "PROMO_SUPER", "amount": 100}`)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode, "an existing coupon of the same configuration is returned")
+	var result model.CouponResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "PROMO_SUPER", result.Name)
+	assert.Equal(t, 40, result.RemainingAmount)
+
+	resp = post(`{"name": "PROMO_BIG", "amount": 200}`)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	var conflict map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&conflict))
+	assert.Equal(t, "coupon already exists with different configuration", conflict["error"])
+	assert.Len(t, conflict["diff"], 1)
+}
+
 func TestCreateCoupon_MissingName(t *testing.T) {
 	mockSvc := &mockCouponService{}
 	app := setupTestApp(mockSvc)
@@ -108,6 +194,253 @@ func TestCreateCoupon_MissingAmount(t *testing.T) {
 	assert.Equal(t, "invalid request: amount is required", result["error"], "Exact error message required")
 }
 
+func TestCreateCoupon_Unlimited(t *testing.T) {
+	var captured *model.CreateCouponRequest
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			captured = req
+			return nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	for _, body := range []string{`{"name": "WELCOME", "unlimited": true}`, `{"name": "WELCOME", "unlimited": true, "amount": null}`} {
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode, body)
+		require.NotNil(t, captured)
+		assert.True(t, captured.Unlimited)
+		assert.Nil(t, captured.Amount)
+	}
+}
+
+func TestCreateCoupon_Unlimited_Invalid(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"name": "WELCOME", "unlimited": true, "amount": 10}`, "invalid request: unlimited coupons cannot have an amount"},
+		{`{"name": "WELCOME", "unlimited": true, "channels": {"app": 100}}`, "invalid request: unlimited coupons cannot have channels"},
+		{`{"name": "WELCOME", "unlimited": true, "low_stock_percent": 10}`, "invalid request: unlimited coupons cannot have a low_stock_percent"},
+		{`{"name": "WELCOME", "unlimited": false}`, "invalid request: amount is required"},
+	}
+	for _, tt := range tests {
+		app := setupTestApp(&mockCouponService{})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, tt.body)
+		var result map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, tt.want, result["error"])
+	}
+}
+
+func TestGetCoupon_Unlimited(t *testing.T) {
+	claimed := 42
+	mockSvc := &mockCouponService{
+		getByNameFn: func(ctx context.Context, name string) (*model.CouponResponse, error) {
+			return &model.CouponResponse{Name: name, ClaimedBy: []string{}, Tags: []string{}, Unlimited: true, Claimed: &claimed}, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/WELCOME", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var result map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, true, result["unlimited"])
+	assert.Equal(t, float64(42), result["claimed"])
+}
+
+func TestGetCoupon_Unlisted(t *testing.T) {
+	for _, visibility := range []string{model.VisibilityUnlisted, model.VisibilityPrivate} {
+		mockSvc := &mockCouponService{
+			getByNameFn: func(ctx context.Context, name string) (*model.CouponResponse, error) {
+				return &model.CouponResponse{Name: name, ClaimedBy: []string{"u1"}, Tags: []string{}, Visibility: visibility}, nil
+			},
+		}
+		app := setupTestApp(mockSvc)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/SECRET", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, visibility)
+		var result map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, "coupon not found", result["error"])
+	}
+}
+
+func TestCouponWrites_Unlisted(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   map[string]any
+	}{
+		{"update", http.MethodPatch, "/api/coupons/SECRET", `{"high_profile": true}`,
+			map[string]any{"name": "SECRET", "tags": []any{"vip"}, "high_profile": false}},
+		{"top-up", http.MethodPost, "/api/coupons/SECRET/top-up", `{"amount": 5}`,
+			map[string]any{"name": "SECRET", "added": float64(5)}},
+	}
+	for _, visibility := range []string{model.VisibilityUnlisted, model.VisibilityPrivate} {
+		coupon := func(name string) *model.CouponResponse {
+			return &model.CouponResponse{Name: name, Amount: 10, RemainingAmount: 7, ClaimedBy: []string{"u1"}, Tags: []string{"vip"}, Visibility: visibility}
+		}
+		mockSvc := &mockCouponService{
+			updateFn: func(ctx context.Context, name string, req *model.UpdateCouponRequest) (*model.CouponResponse, error) {
+				return coupon(name), nil
+			},
+			topUpFn: func(ctx context.Context, name string, amount int) (*model.CouponResponse, error) {
+				return coupon(name), nil
+			},
+			putFn: func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+				return coupon(req.Name), false, nil
+			},
+		}
+		app := setupTestApp(mockSvc)
+
+		for _, tt := range tests {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode, tt.name)
+			var result map[string]any
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.want, result, "%s of a %s coupon answers only with the fields written", tt.name, visibility)
+		}
+
+		body := fmt.Sprintf(`{"amount": 10, "tags": ["vip"], "visibility": %q}`, visibility)
+		req := httptest.NewRequest(http.MethodPut, "/api/coupons/SECRET", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var result map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, visibility, result["visibility"])
+		assert.NotContains(t, result, "remaining_amount", "put of a %s coupon", visibility)
+		assert.NotContains(t, result, "claimed_by", "put of a %s coupon", visibility)
+	}
+}
+
+func TestCreateCoupon_Visibility(t *testing.T) {
+	var captured *model.CreateCouponRequest
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			captured = req
+			return nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	post := func(body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := post(`{"name": "SECRET", "amount": 10, "visibility": "unlisted"}`)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotNil(t, captured)
+	assert.Equal(t, model.VisibilityUnlisted, captured.Visibility)
+
+	resp = post(`{"name": "SECRET", "amount": 10, "visibility": "hidden"}`)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "invalid request: visibility must be public, unlisted or private", result["error"])
+}
+
+func TestCreateCoupon_ClaimRetention(t *testing.T) {
+	var captured *model.CreateCouponRequest
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			captured = req
+			return nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	body := `{"name": "GDPR_PROMO", "amount": 10, "claim_retention": {"days": 90, "action": "anonymize"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotNil(t, captured)
+	assert.Equal(t, &model.ClaimRetention{Days: 90, Action: model.RetentionAnonymize}, captured.ClaimRetention)
+}
+
+func TestCreateCoupon_ClaimRetention_Invalid(t *testing.T) {
+	tests := []struct {
+		body string
+		err  error
+		want string
+	}{
+		{body: `{"name": "GDPR_PROMO", "amount": 10, "claim_retention": {"days": 0, "action": "purge"}}`,
+			want: "invalid request: claim_retention days must be between 1 and 3650"},
+		{body: `{"name": "GDPR_PROMO", "amount": 10, "claim_retention": {"days": 3651, "action": "purge"}}`,
+			want: "invalid request: claim_retention days must be between 1 and 3650"},
+		{body: `{"name": "GDPR_PROMO", "amount": 10, "claim_retention": {"days": 30, "action": "archive"}}`,
+			want: "invalid request: claim_retention action must be purge or anonymize"},
+		{body: `{"name": "GDPR_PROMO", "amount": 10, "claim_retention": {"days": 30}}`,
+			want: "invalid request: claim_retention action must be purge or anonymize"},
+		{body: `{"name": "GDPR_PROMO", "amount": 10, "claim_retention": {"days": 30, "action": "purge"}}`,
+			err: apperr.ErrClaimRetentionDisabled, want: "invalid request: claim retention is not enabled"},
+	}
+	for _, tt := range tests {
+		app := setupTestApp(&mockCouponService{
+			createFn: func(ctx context.Context, req *model.CreateCouponRequest) error { return tt.err },
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, tt.body)
+		var result map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, tt.want, result["error"])
+	}
+}
+
+func TestGetCoupon_ClaimRetention(t *testing.T) {
+	mockSvc := &mockCouponService{
+		getByNameFn: func(ctx context.Context, name string) (*model.CouponResponse, error) {
+			return &model.CouponResponse{Name: name, ClaimedBy: []string{}, Tags: []string{},
+				ClaimRetention: &model.ClaimRetention{Days: 30, Action: model.RetentionPurge}}, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/GDPR_PROMO", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var result map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, map[string]any{"days": float64(30), "action": "purge"}, result["claim_retention"])
+}
+
 func TestCreateCoupon_AmountZero(t *testing.T) {
 	mockSvc := &mockCouponService{}
 	app := setupTestApp(mockSvc)
@@ -149,7 +482,7 @@ func TestCreateCoupon_AmountNegative(t *testing.T) {
 func TestCreateCoupon_DuplicateCoupon(t *testing.T) {
 	mockSvc := &mockCouponService{
 		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
-			return service.ErrCouponExists
+			return apperr.ErrCouponExists
 		},
 	}
 	app := setupTestApp(mockSvc)
@@ -336,7 +669,7 @@ func TestGetCoupon_JSONSnakeCase(t *testing.T) {
 func TestGetCoupon_NotFound(t *testing.T) {
 	mockSvc := &mockCouponService{
 		getByNameFn: func(ctx context.Context, name string) (*model.CouponResponse, error) {
-			return nil, service.ErrCouponNotFound
+			return nil, apperr.ErrCouponNotFound
 		},
 	}
 	app := setupTestApp(mockSvc)
@@ -375,6 +708,25 @@ func TestGetCoupon_InternalServerError(t *testing.T) {
 	assert.Equal(t, "internal server error", result["error"])
 }
 
+func TestGetCoupon_QueryTimeout(t *testing.T) {
+	mockSvc := &mockCouponService{
+		getByNameFn: func(ctx context.Context, name string) (*model.CouponResponse, error) {
+			return nil, fmt.Errorf("get coupon by name %s: %w", name, &database.QueryTimeoutError{
+				Query: database.QueryGetCoupon, Timeout: 500 * time.Millisecond, Err: context.DeadlineExceeded,
+			})
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode, "a slow database is not the client's timeout")
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "database timed out", result["error"])
+}
+
 func TestGetCoupon_EmptyName(t *testing.T) {
 	mockSvc := &mockCouponService{}
 	app := fiber.New()
@@ -397,10 +749,60 @@ func TestGetCoupon_EmptyName(t *testing.T) {
 	assert.Equal(t, "invalid request: name is required", result["error"])
 }
 
+func TestGetCoupon_ClaimedByContains(t *testing.T) {
+	var gotUsers []string
+	mockSvc := &mockCouponService{
+		getByNameFn: func(ctx context.Context, name string) (*model.CouponResponse, error) {
+			t.Fatal("the full claim list must not be loaded")
+			return nil, nil
+		},
+		claimedByFn: func(ctx context.Context, name string, userIDs []string) (*model.CouponResponse, error) {
+			gotUsers = userIDs
+			return &model.CouponResponse{Name: name, Amount: 100, RemainingAmount: 95, ClaimedBy: []string{"user_123"}}, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER?claimed_by_contains=user_123,user_456", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"user_123", "user_456"}, gotUsers)
+	var result model.CouponResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, []string{"user_123"}, result.ClaimedBy)
+}
+
+func TestGetCoupon_ClaimedByContains_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"empty", "claimed_by_contains="},
+		{"blank entry", "claimed_by_contains=user_1,,user_2"},
+		{"too long", "claimed_by_contains=" + strings.Repeat("u", 256)},
+		{"too many", "claimed_by_contains=" + strings.Repeat("user,", 100) + "user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupTestApp(&mockCouponService{})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO_SUPER?"+tt.query, nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, "invalid request: claimed_by_contains must list 1 to 100 comma-separated user IDs", result["error"])
+		})
+	}
+}
+
 func TestCreateCoupon_InvalidRequest(t *testing.T) {
 	mockSvc := &mockCouponService{
 		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
-			return service.ErrInvalidRequest
+			return apperr.ErrInvalidRequest
 		},
 	}
 	app := setupTestApp(mockSvc)
@@ -420,6 +822,58 @@ func TestCreateCoupon_InvalidRequest(t *testing.T) {
 	assert.Equal(t, "invalid request", result["error"])
 }
 
+func TestCreateCoupon_InvalidMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  error
+		want string
+	}{
+		{"schema violation", `{"name": "PROMO", "amount": 1, "metadata": {"owner": "growth"}}`,
+			&apperr.MetadataError{Reason: "violates the metadata schema: (root): campaign is required"},
+			"invalid request: metadata violates the metadata schema: (root): campaign is required"},
+		{"not an object", `{"name": "PROMO", "amount": 1, "metadata": [1]}`,
+			&apperr.MetadataError{Reason: "must be a JSON object"}, "invalid request: metadata must be a JSON object"},
+		{"too large", `{"name": "PROMO", "amount": 1, "metadata": {"note": "` + strings.Repeat("x", 16384) + `"}}`,
+			nil, "invalid request: metadata exceeds maximum size of 16384 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupTestApp(&mockCouponService{
+				createFn: func(ctx context.Context, req *model.CreateCouponRequest) error { return tt.err },
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.want, result["error"])
+		})
+	}
+}
+
+func TestCreateCoupon_InvalidLowStockPercent(t *testing.T) {
+	for _, percent := range []string{"-1", "100"} {
+		app := setupTestApp(&mockCouponService{})
+
+		body := `{"name": "PROMO", "amount": 10, "low_stock_percent": ` + percent + `}`
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		var result map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, "invalid request: low_stock_percent must be between 1 and 99", result["error"])
+	}
+}
+
 // Edge case tests for validation
 func TestCreateCoupon_UnicodeCharactersInName(t *testing.T) {
 	var capturedName string
@@ -482,3 +936,756 @@ func TestCreateCoupon_VeryLargeAmount(t *testing.T) {
 
 	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
 }
+
+func TestCreateCoupon_WithTags(t *testing.T) {
+	var captured *model.CreateCouponRequest
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			captured = req
+			return nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	body := `{"name": "PROMO_SUPER", "amount": 100, "tags": ["blackfriday", "app"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotNil(t, captured)
+	assert.Equal(t, []string{"blackfriday", "app"}, captured.Tags)
+}
+
+func TestCreateCoupon_InvalidTags(t *testing.T) {
+	tooMany := make([]string, 21)
+	for i := range tooMany {
+		tooMany[i] = "t"
+	}
+	tooManyJSON, _ := json.Marshal(tooMany)
+
+	tests := []struct {
+		name     string
+		tags     string
+		expected string
+	}{
+		{"blank tag", `["ok", "   "]`, "invalid request: tags cannot contain blank values"},
+		{"tag too long", `["` + string(bytes.Repeat([]byte("a"), 65)) + `"]`, "invalid request: tag exceeds maximum length of 64"},
+		{"too many tags", string(tooManyJSON), "invalid request: tags exceeds maximum of 20 entries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupTestApp(&mockCouponService{})
+
+			body := `{"name": "PROMO_SUPER", "amount": 100, "tags": ` + tt.tags + `}`
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.expected, result["error"])
+		})
+	}
+}
+
+func TestListCoupons_FilterByTag(t *testing.T) {
+	var captured model.CouponFilter
+	mockSvc := &mockCouponService{
+		listFn: func(ctx context.Context, filter model.CouponFilter) (*model.CouponListResponse, error) {
+			captured = filter
+			return &model.CouponListResponse{Items: []model.CouponSummary{
+				{Name: "BF_APP", Amount: 10, RemainingAmount: 7, Tags: []string{"blackfriday"}},
+			}}, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons?tag=blackfriday&limit=50", nil)
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "blackfriday", captured.Tag)
+	assert.Equal(t, 50, captured.Limit)
+
+	var result model.CouponListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "BF_APP", result.Items[0].Name)
+	assert.Equal(t, []string{"blackfriday"}, result.Items[0].Tags)
+}
+
+func TestListCoupons_DefaultLimit(t *testing.T) {
+	var captured model.CouponFilter
+	mockSvc := &mockCouponService{
+		listFn: func(ctx context.Context, filter model.CouponFilter) (*model.CouponListResponse, error) {
+			captured = filter
+			return &model.CouponListResponse{Items: []model.CouponSummary{}}, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "", captured.Tag)
+	assert.Equal(t, 100, captured.Limit)
+
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"items": []}`, string(respBody))
+}
+
+func TestListCoupons_InvalidLimit(t *testing.T) {
+	for _, limit := range []string{"0", "-1", "1001"} {
+		t.Run(limit, func(t *testing.T) {
+			app := setupTestApp(&mockCouponService{})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons?limit="+limit, nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, "invalid request: limit must be between 1 and 1000", result["error"])
+		})
+	}
+}
+
+func TestListCoupons_Cursor(t *testing.T) {
+	var captured model.CouponFilter
+	mockSvc := &mockCouponService{
+		listFn: func(ctx context.Context, filter model.CouponFilter) (*model.CouponListResponse, error) {
+			captured = filter
+			return &model.CouponListResponse{
+				Items:      []model.CouponSummary{{Name: "BF_WEB", Amount: 10, Tags: []string{}}},
+				NextCursor: model.EncodeCursor("BF_WEB"),
+			}, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons?limit=1&cursor="+model.EncodeCursor("BF_APP"), nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "BF_APP", captured.After)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"items": [{"name": "BF_WEB", "amount": 10, "remaining_amount": 0, "tags": []}], "next_cursor": "`+model.EncodeCursor("BF_WEB")+`"}`, string(respBody))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons?cursor=not*base64", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	respBody, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"error": "invalid request: cursor is invalid"}`, string(respBody))
+}
+
+func TestListCoupons_FilterByStatus(t *testing.T) {
+	var captured model.CouponFilter
+	mockSvc := &mockCouponService{
+		listFn: func(ctx context.Context, filter model.CouponFilter) (*model.CouponListResponse, error) {
+			captured = filter
+			return &model.CouponListResponse{Items: []model.CouponSummary{
+				{Name: "BF_APP", Amount: 10, Tags: []string{}, Status: model.CouponStatusExhausted},
+			}}, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons?status=exhausted", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, model.CouponStatusExhausted, captured.Status)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"items": [{"name": "BF_APP", "amount": 10, "remaining_amount": 0, "tags": [], "status": "exhausted"}]}`, string(respBody))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons?status=expired", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	respBody, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"error": "invalid request: status must be active, exhausted or disabled"}`, string(respBody))
+}
+
+func TestListCoupons_InternalServerError(t *testing.T) {
+	mockSvc := &mockCouponService{
+		listFn: func(ctx context.Context, filter model.CouponFilter) (*model.CouponListResponse, error) {
+			return nil, errors.New("database connection failed")
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestUpdateCoupon_SetTags(t *testing.T) {
+	var capturedName string
+	var capturedReq *model.UpdateCouponRequest
+	mockSvc := &mockCouponService{
+		updateFn: func(ctx context.Context, name string, req *model.UpdateCouponRequest) (*model.CouponResponse, error) {
+			capturedName = name
+			capturedReq = req
+			return &model.CouponResponse{
+				Name:            name,
+				Amount:          100,
+				RemainingAmount: 100,
+				ClaimedBy:       []string{},
+				Tags:            req.Tags,
+			}, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	body := `{"tags": ["cybermonday"]}`
+	req := httptest.NewRequest(http.MethodPatch, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "PROMO_SUPER", capturedName)
+	require.NotNil(t, capturedReq)
+	assert.Equal(t, []string{"cybermonday"}, capturedReq.Tags)
+
+	var result model.CouponResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, []string{"cybermonday"}, result.Tags)
+}
+
+func TestUpdateCoupon_NotFound(t *testing.T) {
+	mockSvc := &mockCouponService{
+		updateFn: func(ctx context.Context, name string, req *model.UpdateCouponRequest) (*model.CouponResponse, error) {
+			return nil, apperr.ErrCouponNotFound
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/coupons/NONEXISTENT", bytes.NewBufferString(`{"tags": []}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "coupon not found", result["error"])
+}
+
+func TestUpdateCoupon_InvalidTags(t *testing.T) {
+	app := setupTestApp(&mockCouponService{})
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(`{"tags": [""]}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "invalid request: tags cannot contain blank values", result["error"])
+}
+
+func TestUpdateCoupon_MalformedJSON(t *testing.T) {
+	app := setupTestApp(&mockCouponService{})
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(`{"tags": [`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUpdateCoupon_InternalServerError(t *testing.T) {
+	mockSvc := &mockCouponService{
+		updateFn: func(ctx context.Context, name string, req *model.UpdateCouponRequest) (*model.CouponResponse, error) {
+			return nil, errors.New("database connection failed")
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(`{"tags": ["a"]}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "internal server error", result["error"])
+}
+
+func TestCreateCoupon_WithChannels(t *testing.T) {
+	var captured *model.CreateCouponRequest
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			captured = req
+			return nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	body := `{"name": "BF_SPLIT", "amount": 100, "channels": {"app": 70, "web": 30}, "overflow_at": "2026-11-30T23:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotNil(t, captured)
+	assert.Equal(t, map[string]int{"app": 70, "web": 30}, captured.Channels)
+	require.NotNil(t, captured.OverflowAt)
+	assert.Equal(t, "2026-11-30T23:00:00Z", captured.OverflowAt.UTC().Format(time.RFC3339))
+}
+
+func TestCreateCoupon_InvalidChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels string
+		expected string
+	}{
+		{"blank channel name", `{" ": 100}`, "invalid request: channel names cannot be blank"},
+		{"channel name too long", `{"` + strings.Repeat("a", 65) + `": 100}`, "invalid request: channel name exceeds maximum length of 64"},
+		{"zero percentage", `{"app": 0, "web": 100}`, "invalid request: channel percentage must be between 1 and 100"},
+		{"percentage over 100", `{"app": 101}`, "invalid request: channel percentage must be between 1 and 100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupTestApp(&mockCouponService{})
+
+			body := `{"name": "BF_SPLIT", "amount": 100, "channels": ` + tt.channels + `}`
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.expected, result["error"])
+		})
+	}
+}
+
+func TestCreateCoupon_ChannelPercentagesDoNotSum(t *testing.T) {
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			return apperr.ErrInvalidChannelQuotas
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	body := `{"name": "BF_SPLIT", "amount": 100, "channels": {"app": 70, "web": 20}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "invalid request: channel percentages must sum to 100", result["error"])
+}
+
+func TestCreateCoupon_WithTiers(t *testing.T) {
+	var captured *model.CreateCouponRequest
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			captured = req
+			return nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	body := `{"name": "BF_TIERS", "amount": 1000, "tiers": [{"name": "gold", "size": 100}, {"name": "silver", "size": 900}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotNil(t, captured)
+	assert.Equal(t, []model.Tier{{Name: "gold", Size: 100}, {Name: "silver", Size: 900}}, captured.Tiers)
+}
+
+func TestCreateCoupon_InvalidTiers(t *testing.T) {
+	tooMany := make([]model.Tier, 11)
+	for i := range tooMany {
+		tooMany[i] = model.Tier{Name: "t", Size: 1}
+	}
+	tooManyJSON, _ := json.Marshal(tooMany)
+
+	tests := []struct {
+		name     string
+		tiers    string
+		expected string
+	}{
+		{"missing tier name", `[{"size": 10}]`, "invalid request: tier name is required"},
+		{"blank tier name", `[{"name": "  ", "size": 10}]`, "invalid request: tier name cannot be whitespace only"},
+		{"tier name too long", `[{"name": "` + strings.Repeat("a", 33) + `", "size": 10}]`, "invalid request: tier name exceeds maximum length of 32"},
+		{"zero size", `[{"name": "gold", "size": 0}]`, "invalid request: tier size must be at least 1"},
+		{"negative size", `[{"name": "gold", "size": -5}]`, "invalid request: tier size must be at least 1"},
+		{"too many tiers", string(tooManyJSON), "invalid request: tiers exceeds maximum of 10 entries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupTestApp(&mockCouponService{})
+
+			body := `{"name": "BF_TIERS", "amount": 100, "tiers": ` + tt.tiers + `}`
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.expected, result["error"])
+		})
+	}
+}
+
+func TestCreateCoupon_TiersExceedAmount(t *testing.T) {
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			return apperr.ErrInvalidTiers
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	body := `{"name": "BF_TIERS", "amount": 10, "tiers": [{"name": "gold", "size": 100}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "invalid request: tier sizes exceed amount", result["error"])
+}
+
+func TestCreateCoupon_InvalidRegions(t *testing.T) {
+	tests := []struct {
+		regions  string
+		expected string
+	}{
+		{`{"eu": 0}`, "invalid request: region quota must be at least 1"},
+		{`{" ": 10}`, "invalid request: region names cannot be blank"},
+		{`{"` + strings.Repeat("r", 65) + `": 10}`, "invalid request: region name exceeds maximum length of 64"},
+	}
+	for _, tt := range tests {
+		app := setupTestApp(&mockCouponService{})
+
+		body := `{"name": "GLOBAL", "amount": 100, "regions": ` + tt.regions + `}`
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		var result map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, tt.expected, result["error"], tt.regions)
+	}
+}
+
+func TestCreateCoupon_InvalidParent(t *testing.T) {
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			assert.Equal(t, "BF_TOTAL", req.Parent)
+			return apperr.ErrInvalidParent
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	body := `{"name": "BF_EU", "amount": 10, "parent": "BF_TOTAL"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "invalid request: parent must be an existing coupon without a parent", result["error"])
+}
+
+func TestCreateCoupon_InvalidPrerequisite(t *testing.T) {
+	mockSvc := &mockCouponService{
+		createFn: func(ctx context.Context, req *model.CreateCouponRequest) error {
+			assert.Equal(t, "SILVER", req.Prerequisite)
+			return apperr.ErrInvalidPrerequisite
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	body := `{"name": "GOLD", "amount": 10, "prerequisite": "SILVER"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "invalid request: prerequisite must be an existing coupon", result["error"])
+}
+
+func TestPutCoupon_Created(t *testing.T) {
+	var captured *model.CreateCouponRequest
+	mockSvc := &mockCouponService{
+		putFn: func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+			captured = req
+			return &model.CouponResponse{Name: req.Name, Amount: *req.Amount, RemainingAmount: *req.Amount, ClaimedBy: []string{}, Tags: []string{}}, true, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(`{"amount": 100}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotNil(t, captured)
+	assert.Equal(t, "PROMO_SUPER", captured.Name, "name should come from the path")
+
+	var result model.CouponResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "PROMO_SUPER", result.Name)
+	assert.Equal(t, 100, result.Amount)
+}
+
+func TestPutCoupon_ExistingMatches(t *testing.T) {
+	mockSvc := &mockCouponService{
+		putFn: func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+			return &model.CouponResponse{Name: req.Name, Amount: 100, RemainingAmount: 40, ClaimedBy: []string{}, Tags: []string{}}, false, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(`{"name": "PROMO_SUPER", "amount": 100}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var result model.CouponResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, 40, result.RemainingAmount)
+}
+
+func TestPutCoupon_ConflictReturnsDiff(t *testing.T) {
+	mockSvc := &mockCouponService{
+		putFn: func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+			return nil, false, &apperr.ConflictError{Diffs: []model.FieldDiff{
+				{Field: "amount", Current: 100, Requested: 200},
+			}}
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(`{"amount": 200}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{
+		"error": "coupon already exists with different configuration",
+		"diff": [{"field": "amount", "current": 100, "requested": 200}]
+	}`, string(respBody))
+}
+
+func TestPutCoupon_Deleted(t *testing.T) {
+	mockSvc := &mockCouponService{
+		putFn: func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+			return nil, false, apperr.ErrCouponDeleted
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(`{"amount": 100}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"error": "coupon is deleted: restore it, or recreate it once it is purged"}`, string(respBody))
+}
+
+func TestPutCoupon_NameMismatch(t *testing.T) {
+	putCalled := false
+	mockSvc := &mockCouponService{
+		putFn: func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+			putCalled = true
+			return nil, false, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(`{"name": "OTHER", "amount": 100}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.False(t, putCalled)
+
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "invalid request: name does not match path", result["error"])
+}
+
+func TestPutCoupon_ValidationAndServiceErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantError  string
+	}{
+		{"missing amount", `{}`, nil, fiber.StatusBadRequest, "invalid request: amount is required"},
+		{"malformed json", `{invalid`, nil, fiber.StatusBadRequest, "invalid request body"},
+		{"bad channel split", `{"amount": 10, "channels": {"app": 50}}`, apperr.ErrInvalidChannelQuotas, fiber.StatusBadRequest, "invalid request: channel percentages must sum to 100"},
+		{"internal error", `{"amount": 10}`, errors.New("database connection failed"), fiber.StatusInternalServerError, "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockCouponService{
+				putFn: func(ctx context.Context, req *model.CreateCouponRequest) (*model.CouponResponse, bool, error) {
+					return nil, false, tt.svcErr
+				},
+			}
+			app := setupTestApp(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/coupons/PROMO_SUPER", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.wantError, result["error"])
+		})
+	}
+}
+
+func TestTopUpCoupon_Success(t *testing.T) {
+	mockSvc := &mockCouponService{
+		topUpFn: func(ctx context.Context, name string, amount int) (*model.CouponResponse, error) {
+			assert.Equal(t, "PROMO_SUPER", name)
+			assert.Equal(t, 50, amount)
+			return &model.CouponResponse{Name: name, Amount: 150, RemainingAmount: 60, ClaimedBy: []string{}, Tags: []string{}}, nil
+		},
+	}
+	app := setupTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/PROMO_SUPER/top-up", bytes.NewBufferString(`{"amount": 50}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var result model.CouponResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, 150, result.Amount)
+	assert.Equal(t, 60, result.RemainingAmount)
+}
+
+func TestTopUpCoupon_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantError  string
+	}{
+		{"missing amount", `{}`, nil, fiber.StatusBadRequest, "invalid request: amount is required"},
+		{"negative amount", `{"amount": -5}`, nil, fiber.StatusBadRequest, "invalid request: amount must be at least 1"},
+		{"malformed json", `{invalid`, nil, fiber.StatusBadRequest, "invalid request body"},
+		{"not found", `{"amount": 5}`, apperr.ErrCouponNotFound, fiber.StatusNotFound, "coupon not found"},
+		{"partitioned", `{"amount": 5}`, apperr.ErrPartitionedTopUp, fiber.StatusConflict, "channel-partitioned coupons cannot be topped up"},
+		{"unlimited", `{"amount": 5}`, apperr.ErrUnlimitedTopUp, fiber.StatusConflict, "unlimited coupons cannot be topped up"},
+		{"internal error", `{"amount": 5}`, errors.New("database connection failed"), fiber.StatusInternalServerError, "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockCouponService{
+				topUpFn: func(ctx context.Context, name string, amount int) (*model.CouponResponse, error) {
+					return nil, tt.svcErr
+				},
+			}
+			app := setupTestApp(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons/PROMO_SUPER/top-up", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.wantError, result["error"])
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderRequestDeadline carries the absolute time, in RFC 3339, by which the caller
+// (usually a gateway enforcing its own SLA) stops waiting for the response.
+const HeaderRequestDeadline = "X-Request-Deadline"
+
+// RequestDeadline returns middleware giving requests that send X-Request-Deadline that
+// deadline, but no later than maxWait from now, through the request's user context.
+// Route timeouts (see RouteLimits) still apply beneath it, so the earlier one wins.
+// Requests whose deadline has already passed get 504 without being handled, as do
+// requests failing because it passed; a malformed header gets 400.
+func RequestDeadline(maxWait time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(HeaderRequestDeadline)
+		if header == "" {
+			return c.Next()
+		}
+		deadline, err := time.Parse(time.RFC3339Nano, header)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid " + HeaderRequestDeadline + " header: must be an RFC 3339 time"})
+		}
+		now := time.Now()
+		if !deadline.After(now) {
+			return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"error": "request deadline exceeded"})
+		}
+		if latest := now.Add(maxWait); deadline.After(latest) {
+			deadline = latest
+		}
+
+		ctx, cancel := context.WithDeadline(c.UserContext(), deadline)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err = c.Next()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"error": "request timed out"})
+		}
+		return err
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deadlineRequest returns a request with X-Request-Deadline set to deadline, if any.
+func deadlineRequest(deadline string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/coupon", nil)
+	if deadline != "" {
+		req.Header.Set(HeaderRequestDeadline, deadline)
+	}
+	return req
+}
+
+func TestRequestDeadline(t *testing.T) {
+	var got time.Time
+	var hasDeadline bool
+	app := fiber.New()
+	app.Use(RequestDeadline(time.Minute))
+	app.Get("/coupon", RouteLimits(10*time.Second, 0), func(c *fiber.Ctx) error {
+		got, hasDeadline = c.UserContext().Deadline()
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	t.Run("caller deadline", func(t *testing.T) {
+		deadline := time.Now().Add(2 * time.Second).Truncate(time.Millisecond)
+		resp, err := app.Test(deadlineRequest(deadline.Format(time.RFC3339Nano)))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.True(t, hasDeadline)
+		assert.True(t, deadline.Equal(got), "got %s", got)
+	})
+
+	t.Run("route timeout is earlier", func(t *testing.T) {
+		resp, err := app.Test(deadlineRequest(time.Now().Add(30 * time.Second).Format(time.RFC3339)))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.WithinDuration(t, time.Now().Add(10*time.Second), got, time.Second)
+	})
+
+	t.Run("no header", func(t *testing.T) {
+		resp, err := app.Test(deadlineRequest(""))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.WithinDuration(t, time.Now().Add(10*time.Second), got, time.Second)
+	})
+}
+
+func TestRequestDeadline_BoundedByMax(t *testing.T) {
+	var got time.Time
+	app := fiber.New()
+	app.Use(RequestDeadline(time.Second))
+	app.Get("/coupon", func(c *fiber.Ctx) error {
+		got, _ = c.UserContext().Deadline()
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(deadlineRequest(time.Now().Add(time.Hour).Format(time.RFC3339)))
+
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.WithinDuration(t, time.Now().Add(time.Second), got, 500*time.Millisecond)
+}
+
+func TestRequestDeadline_Rejected(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestDeadline(time.Minute))
+	app.Get("/coupon", func(c *fiber.Ctx) error {
+		<-c.UserContext().Done() // A database call honoring the deadline
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "service unavailable"})
+	})
+
+	tests := []struct {
+		name       string
+		deadline   string
+		wantStatus int
+		wantError  string
+	}{
+		{"malformed", "in 5 seconds", fiber.StatusBadRequest, "invalid X-Request-Deadline header: must be an RFC 3339 time"},
+		{"already passed", time.Now().Add(-time.Second).Format(time.RFC3339), fiber.StatusGatewayTimeout, "request deadline exceeded"},
+		{"passes while handling", time.Now().Add(50 * time.Millisecond).Format(time.RFC3339Nano), fiber.StatusGatewayTimeout, "request timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(deadlineRequest(tt.deadline))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.wantError, result["error"])
+		})
+	}
+}
@@ -2,3 +2,45 @@ package handler
 
 // Package handler provides HTTP handlers for the Fiber web framework.
 // This file will contain the coupon handlers in Epic 2.
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// logPath returns the request path for logging. With redaction enabled the route
+// pattern (e.g. /api/coupons/:name) is logged instead, since paths embed coupon names
+// and user IDs.
+func logPath(c *fiber.Ctx) string {
+	if redact.Enabled() {
+		return c.Route().Path
+	}
+	return c.Path()
+}
+
+// internalError responds to a request that failed on err: 503 if a database query ran
+// past its own timeout (DB_QUERY_TIMEOUTS) or a coupon's row lock was not acquired
+// (DB_COUPON_LOCK_POLICY), 500 otherwise. Requests failing because their route timeout
+// passed get 504 from RouteLimits instead.
+func internalError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, apperr.ErrCouponBusy) {
+		return couponBusy(c)
+	}
+	var timeout *database.QueryTimeoutError
+	if errors.As(err, &timeout) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "database timed out"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+}
+
+// couponBusy responds 503 to a request whose coupon was locked by another transaction,
+// asking the client to retry.
+func couponBusy(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderRetryAfter, "1")
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "coupon is busy"})
+}
@@ -2,9 +2,15 @@ package handler
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
 // Pinger is an interface for health check ping operations.
@@ -12,9 +18,38 @@ type Pinger interface {
 	Ping(ctx context.Context) error
 }
 
+// PoolStatuser reports whether the connection pools are nearing exhaustion.
+type PoolStatuser interface {
+	Status() string
+}
+
+// FailoverStatuser reports whether a database failover was detected recently.
+type FailoverStatuser interface {
+	Status() string
+}
+
+// DataStatser reports sanity figures of the stored data.
+type DataStatser interface {
+	DataStats(ctx context.Context) (database.DataStats, error)
+}
+
 // HealthHandler handles health check requests.
 type HealthHandler struct {
-	pool Pinger
+	pool       Pinger
+	pools      PoolStatuser     // nil without the pool watchdog
+	failover   FailoverStatuser // nil without failover detection
+	data       DataStatser      // nil without readiness detail
+	migrations map[string]string
+	draining   atomic.Bool
+}
+
+// readinessDetail is the body of GET /readyz?detail=true.
+type readinessDetail struct {
+	Status      string            `json:"status"`           // "ok" when every check passed, "failed" otherwise
+	Checks      map[string]string `json:"checks"`           // "ok", "failed" or "excluded" by check
+	Coupons     *int64            `json:"coupons"`          // nil when the data check did not pass
+	LastClaimAt *time.Time        `json:"last_claim_at"`    // nil before the first claim
+	Migrations  map[string]string `json:"migration_phases"` // Phase of each rename and table move
 }
 
 // NewHealthHandler creates a new HealthHandler with the given database pool.
@@ -22,18 +57,169 @@ func NewHealthHandler(pool Pinger) *HealthHandler {
 	return &HealthHandler{pool: pool}
 }
 
+// SetPoolStatus makes Check report the status of the connection pools as "pools": "ok"
+// or "warning". A warning does not make the instance unhealthy.
+func (h *HealthHandler) SetPoolStatus(pools PoolStatuser) {
+	h.pools = pools
+}
+
+// SetFailoverStatus makes Check report whether a database failover was detected
+// recently as "database_server": "ok" or "failover_detected". A detected failover does
+// not make the instance unhealthy: it explains the errors and latency around it.
+func (h *HealthHandler) SetFailoverStatus(failover FailoverStatuser) {
+	h.failover = failover
+}
+
+// SetReadinessDetail makes GET /readyz?detail=true answer in JSON with a "data" check
+// reading stats, the figures it read and the phase of each rename and table move in
+// migrations, so that a deployment smoke test can check the data plane in one call.
+func (h *HealthHandler) SetReadinessDetail(stats DataStatser, migrations map[string]string) {
+	h.data = stats
+	h.migrations = migrations
+}
+
 // Check performs a health check by pinging the database.
 // Returns 200 OK with {"status": "healthy"} when database is reachable.
 // Returns 503 Service Unavailable with {"status": "unhealthy", "error": "..."} when database is unreachable.
+// With SetPoolStatus and SetFailoverStatus, both include the status of the connection
+// pools and of the database server.
 func (h *HealthHandler) Check(c *fiber.Ctx) error {
-	if err := h.pool.Ping(c.Context()); err != nil {
+	if err := h.pool.Ping(c.UserContext()); err != nil {
 		log.Error().Err(err).Msg("health check failed: database unreachable")
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		return c.Status(fiber.StatusServiceUnavailable).JSON(h.withStatuses(fiber.Map{
 			"status": "unhealthy",
 			"error":  "database connection failed",
-		})
+		}))
 	}
-	return c.JSON(fiber.Map{
+	return c.JSON(h.withStatuses(fiber.Map{
 		"status": "healthy",
+	}))
+}
+
+func (h *HealthHandler) withStatuses(body fiber.Map) fiber.Map {
+	if h.pools != nil {
+		body["pools"] = h.pools.Status()
+	}
+	if h.failover != nil {
+		body["database_server"] = h.failover.Status()
+	}
+	return body
+}
+
+// Drain makes readiness checks fail from now on, so that load balancers and service
+// meshes stop routing requests to an instance that is shutting down.
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// healthCheck is one named check of a Kubernetes-style health endpoint.
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) bool
+}
+
+// Livez handles GET /livez: the process is up and serving. It checks no dependencies,
+// so a database outage does not get the instance restarted.
+func (h *HealthHandler) Livez(c *fiber.Ctx) error {
+	return h.probe(c, "livez", []healthCheck{
+		{"ping", func(context.Context) bool { return true }},
 	})
 }
+
+// Readyz handles GET /readyz: the instance can serve requests. It fails while the
+// database is unreachable and once shutdown has begun (see Drain). With
+// SetReadinessDetail, ?detail=true also reads the data stats and answers in JSON.
+func (h *HealthHandler) Readyz(c *fiber.Ctx) error {
+	if h.data == nil || !c.QueryBool("detail") {
+		return h.probe(c, "readyz", h.readinessChecks())
+	}
+
+	detail := &readinessDetail{Checks: map[string]string{}, Migrations: h.migrations}
+	checks := append(h.readinessChecks(), healthCheck{"data", func(ctx context.Context) bool {
+		stats, err := h.data.DataStats(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("readiness check failed: data stats unreadable")
+			return false
+		}
+		detail.Coupons, detail.LastClaimAt = &stats.Coupons, stats.LastClaimAt
+		return true
+	}})
+	passed := h.runChecks(c, checks, func(name, result string) { detail.Checks[name] = result })
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	detail.Status = "ok"
+	if !passed {
+		detail.Status = "failed"
+		c.Status(fiber.StatusServiceUnavailable)
+	}
+	return c.JSON(detail)
+}
+
+// Healthz handles GET /healthz, Kubernetes' older combined endpoint, with the checks of Readyz.
+func (h *HealthHandler) Healthz(c *fiber.Ctx) error {
+	return h.probe(c, "healthz", h.readinessChecks())
+}
+
+func (h *HealthHandler) readinessChecks() []healthCheck {
+	return []healthCheck{
+		{"ping", func(context.Context) bool { return true }},
+		{"database", func(ctx context.Context) bool {
+			if err := h.pool.Ping(ctx); err != nil {
+				log.Error().Err(err).Msg("readiness check failed: database unreachable")
+				return false
+			}
+			return true
+		}},
+		{"shutdown", func(context.Context) bool { return !h.draining.Load() }},
+	}
+}
+
+// probe runs checks the way Kubernetes health endpoints do: 200 "ok" when all pass,
+// 503 otherwise. ?verbose lists each check as [+]name ok or [-]name failed, and
+// ?exclude=name (repeatable) skips a check. Failure reasons are only logged.
+func (h *HealthHandler) probe(c *fiber.Ctx, endpoint string, checks []healthCheck) error {
+	var report strings.Builder
+	passed := h.runChecks(c, checks, func(name, result string) {
+		switch result {
+		case "excluded":
+			report.WriteString("[+]" + name + " excluded: ok\n")
+		case "ok":
+			report.WriteString("[+]" + name + " ok\n")
+		default:
+			report.WriteString("[-]" + name + " failed: reason withheld\n")
+		}
+	})
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if !passed {
+		return c.Status(fiber.StatusServiceUnavailable).SendString(report.String() + endpoint + " check failed\n")
+	}
+	if c.Context().QueryArgs().Has("verbose") {
+		return c.SendString(report.String() + endpoint + " check passed\n")
+	}
+	return c.SendString("ok")
+}
+
+// runChecks runs the checks not excluded by ?exclude=, reporting each as "ok", "failed"
+// or "excluded", and returns whether none failed.
+func (h *HealthHandler) runChecks(c *fiber.Ctx, checks []healthCheck, report func(name, result string)) bool {
+	var excluded []string
+	for _, v := range c.Context().QueryArgs().PeekMulti("exclude") {
+		excluded = append(excluded, string(v))
+	}
+
+	passed := true
+	for _, hc := range checks {
+		switch {
+		case slices.Contains(excluded, hc.name):
+			report(hc.name, "excluded")
+		case hc.check(c.UserContext()):
+			report(hc.name, "ok")
+		default:
+			report(hc.name, "failed")
+			passed = false
+		}
+	}
+	return passed
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
 // mockPool implements a minimal interface for testing health checks
@@ -73,6 +75,54 @@ func TestHealthHandler_Check_Unhealthy(t *testing.T) {
 	assert.Contains(t, string(body), `"error":"database connection failed"`)
 }
 
+// poolStatus reports a fixed connection pool or database server status.
+type poolStatus string
+
+func (s poolStatus) Status() string { return string(s) }
+
+func TestHealthHandler_Check_PoolStatus(t *testing.T) {
+	app := fiber.New()
+	handler := NewHealthHandler(&mockPool{})
+	app.Get("/health", handler.Check)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "pools")
+
+	handler.SetPoolStatus(poolStatus("warning"))
+	resp, err = app.Test(httptest.NewRequest("GET", "/health", nil))
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode, "a pool warning leaves the instance healthy")
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"healthy","pools":"warning"}`, string(body))
+}
+
+func TestHealthHandler_Check_FailoverStatus(t *testing.T) {
+	app := fiber.New()
+	handler := NewHealthHandler(&mockPool{})
+	handler.SetPoolStatus(poolStatus("ok"))
+	handler.SetFailoverStatus(poolStatus("failover_detected"))
+	app.Get("/health", handler.Check)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode, "a detected failover leaves the instance healthy")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"healthy","pools":"ok","database_server":"failover_detected"}`, string(body))
+}
+
 func TestHealthHandler_Check_SlowResponse(t *testing.T) {
 	// Test that slow database responses are handled correctly
 	// Fiber's default test timeout is 1 second, so we use a shorter delay
@@ -153,3 +203,108 @@ func TestHealthHandler_Check_DeadlineExceeded(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, string(body), `"status":"unhealthy"`)
 }
+
+func setupProbeTestApp(h *HealthHandler) *fiber.App {
+	app := fiber.New()
+	app.Get("/livez", h.Livez)
+	app.Get("/readyz", h.Readyz)
+	app.Get("/healthz", h.Healthz)
+	return app
+}
+
+func TestHealthHandler_Probes(t *testing.T) {
+	tests := []struct {
+		name     string
+		pingErr  error
+		draining bool
+		path     string
+		status   int
+		body     string
+	}{
+		{"ready", nil, false, "/readyz", fiber.StatusOK, "ok"},
+		{"ready verbose", nil, false, "/readyz?verbose", fiber.StatusOK,
+			"[+]ping ok\n[+]database ok\n[+]shutdown ok\nreadyz check passed\n"},
+		{"database down", errors.New("connection refused"), false, "/readyz", fiber.StatusServiceUnavailable,
+			"[+]ping ok\n[-]database failed: reason withheld\n[+]shutdown ok\nreadyz check failed\n"},
+		{"database excluded", errors.New("connection refused"), false, "/readyz?exclude=database", fiber.StatusOK, "ok"},
+		{"draining", nil, true, "/healthz", fiber.StatusServiceUnavailable,
+			"[+]ping ok\n[+]database ok\n[-]shutdown failed: reason withheld\nhealthz check failed\n"},
+		{"live without database", errors.New("connection refused"), true, "/livez", fiber.StatusOK, "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(&mockPool{pingErr: tt.pingErr})
+			if tt.draining {
+				h.Drain()
+			}
+			app := setupProbeTestApp(h)
+
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			require.NoError(t, err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+			assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+		})
+	}
+}
+
+// dataStats returns fixed data stats.
+type dataStats struct {
+	stats database.DataStats
+	err   error
+}
+
+func (d dataStats) DataStats(context.Context) (database.DataStats, error) { return d.stats, d.err }
+
+func TestHealthHandler_ReadyzDetail(t *testing.T) {
+	lastClaim := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	migrations := map[string]string{"claims.claimed_at": "dual_write"}
+	tests := []struct {
+		name   string
+		stats  dataStats
+		path   string
+		status int
+		body   string
+	}{
+		{"ok", dataStats{stats: database.DataStats{Coupons: 42, LastClaimAt: &lastClaim}}, "/readyz?detail=true", fiber.StatusOK,
+			`{"status":"ok","checks":{"data":"ok","database":"ok","ping":"ok","shutdown":"ok"},"coupons":42,` +
+				`"last_claim_at":"2026-01-15T10:00:00Z","migration_phases":{"claims.claimed_at":"dual_write"}}`},
+		{"no claims yet", dataStats{}, "/readyz?detail=true", fiber.StatusOK,
+			`{"status":"ok","checks":{"data":"ok","database":"ok","ping":"ok","shutdown":"ok"},"coupons":0,` +
+				`"last_claim_at":null,"migration_phases":{"claims.claimed_at":"dual_write"}}`},
+		{"data unreadable", dataStats{err: errors.New("relation does not exist")}, "/readyz?detail=true", fiber.StatusServiceUnavailable,
+			`{"status":"failed","checks":{"data":"failed","database":"ok","ping":"ok","shutdown":"ok"},"coupons":null,` +
+				`"last_claim_at":null,"migration_phases":{"claims.claimed_at":"dual_write"}}`},
+		{"data excluded", dataStats{err: errors.New("relation does not exist")}, "/readyz?detail=true&exclude=data", fiber.StatusOK,
+			`{"status":"ok","checks":{"data":"excluded","database":"ok","ping":"ok","shutdown":"ok"},"coupons":null,` +
+				`"last_claim_at":null,"migration_phases":{"claims.claimed_at":"dual_write"}}`},
+		{"without detail", dataStats{}, "/readyz", fiber.StatusOK, "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(&mockPool{})
+			h.SetReadinessDetail(tt.stats, migrations)
+			app := setupProbeTestApp(h)
+
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			require.NoError(t, err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+			assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+		})
+	}
+}
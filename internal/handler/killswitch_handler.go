@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// KillSwitchInterface defines the interface for the global kill switch.
+type KillSwitchInterface interface {
+	State() model.KillSwitch
+	Set(ctx context.Context, engaged bool, message string) (model.KillSwitch, error)
+}
+
+// KillSwitchHandler handles HTTP requests for the global kill switch.
+type KillSwitchHandler struct {
+	killSwitch KillSwitchInterface
+	validator  *validator.Validate
+}
+
+// NewKillSwitchHandler creates a new KillSwitchHandler with the given switch and validator.
+func NewKillSwitchHandler(ks KillSwitchInterface, v *validator.Validate) *KillSwitchHandler {
+	return &KillSwitchHandler{killSwitch: ks, validator: v}
+}
+
+// GetKillSwitch handles GET /api/admin/killswitch requests.
+func (h *KillSwitchHandler) GetKillSwitch(c *fiber.Ctx) error {
+	return c.JSON(h.killSwitch.State())
+}
+
+// SetKillSwitch handles PUT /api/admin/killswitch requests. While engaged, writes are
+// refused with 503 and the message; reads, health checks and this route keep working.
+func (h *KillSwitchHandler) SetKillSwitch(c *fiber.Ctx) error {
+	var req model.SetKillSwitchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: engaged is required and message must be at most 255 characters",
+		})
+	}
+
+	state, err := h.killSwitch.Set(c.UserContext(), *req.Engaged, req.Message)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Bool("engaged", *req.Engaged).
+			Msg("failed to set kill switch")
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "kill switch store unavailable"})
+	}
+
+	log.Warn().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Bool("engaged", state.Engaged).
+		Str("message", state.Message).
+		Msg("kill switch set")
+
+	return c.JSON(state)
+}
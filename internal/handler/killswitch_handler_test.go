@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+)
+
+// mockKillSwitch is a mock implementation of KillSwitchInterface failing Set with err.
+type mockKillSwitch struct {
+	state model.KillSwitch
+	err   error
+}
+
+func (m *mockKillSwitch) State() model.KillSwitch {
+	return m.state
+}
+
+func (m *mockKillSwitch) Set(ctx context.Context, engaged bool, message string) (model.KillSwitch, error) {
+	if m.err != nil {
+		return model.KillSwitch{}, m.err
+	}
+	since := time.Date(2024, 11, 29, 9, 0, 0, 0, time.UTC)
+	m.state = model.KillSwitch{Engaged: engaged, Message: message, Since: &since}
+	return m.state, nil
+}
+
+func setupKillSwitchTestApp(ks *mockKillSwitch) *fiber.App {
+	app := fiber.New()
+	h := NewKillSwitchHandler(ks, validator.New())
+	app.Get("/api/admin/killswitch", h.GetKillSwitch)
+	app.Put("/api/admin/killswitch", h.SetKillSwitch)
+	return app
+}
+
+func TestSetKillSwitch(t *testing.T) {
+	ks := &mockKillSwitch{}
+	app := setupKillSwitchTestApp(ks)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/killswitch", bytes.NewBufferString(`{"engaged": true, "message": "back at 10:00 UTC"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"engaged": true, "message": "back at 10:00 UTC", "since": "2024-11-29T09:00:00Z"}`, string(body))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/killswitch", nil))
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"engaged": true, "message": "back at 10:00 UTC", "since": "2024-11-29T09:00:00Z"}`, string(body))
+}
+
+func TestSetKillSwitch_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing engaged": `{"message": "paused"}`,
+		"long message":    `{"engaged": true, "message": "` + string(bytes.Repeat([]byte("x"), 256)) + `"}`,
+		"malformed":       `{"engaged": `,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := setupKillSwitchTestApp(&mockKillSwitch{})
+
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/killswitch", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestSetKillSwitch_StoreUnavailable(t *testing.T) {
+	app := setupKillSwitchTestApp(&mockKillSwitch{err: errors.New("connection refused")})
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/killswitch", bytes.NewBufferString(`{"engaged": false}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
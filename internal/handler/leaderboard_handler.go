@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// Leaderboard size bounds for ?limit=.
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+// LeaderboardServiceInterface defines the interface for reading campaign leaderboards.
+type LeaderboardServiceInterface interface {
+	Leaderboard(ctx context.Context, campaign string, limit int) (*model.LeaderboardResponse, error)
+}
+
+// LeaderboardHandler handles HTTP requests for campaign leaderboards.
+type LeaderboardHandler struct {
+	service   LeaderboardServiceInterface
+	claimedBy claimedByPrivacy // How leaders' user IDs are reported
+}
+
+// NewLeaderboardHandler creates a new LeaderboardHandler with the given service.
+func NewLeaderboardHandler(svc LeaderboardServiceInterface) *LeaderboardHandler {
+	return &LeaderboardHandler{service: svc}
+}
+
+// GetLeaderboard handles GET /api/campaigns/:id/leaderboard requests. A campaign is a
+// coupon tag; ?limit= bounds the number of leaders returned. Leaders' user IDs may be
+// hashed or omitted (see SetClaimedByPrivacy).
+func (h *LeaderboardHandler) GetLeaderboard(c *fiber.Ctx) error {
+	campaign, ok := campaignParam(c)
+	if !ok {
+		return invalidCampaign(c)
+	}
+	limit := c.QueryInt("limit", defaultLeaderboardLimit)
+	if limit < 1 || limit > maxLeaderboardLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: limit must be between 1 and 100",
+		})
+	}
+
+	resp, err := h.service.Leaderboard(c.UserContext(), campaign, limit)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("campaign", campaign).
+			Msg("failed to get campaign leaderboard")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+	if !h.claimedBy.full() {
+		public := *resp
+		public.Leaders = h.claimedBy.leaders(resp.Leaders)
+		resp = &public
+	}
+	return c.JSON(resp)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// mockLeaderboardService is a mock implementation of LeaderboardServiceInterface.
+type mockLeaderboardService struct {
+	campaign string
+	limit    int
+	err      error
+}
+
+func (m *mockLeaderboardService) Leaderboard(ctx context.Context, campaign string, limit int) (*model.LeaderboardResponse, error) {
+	m.campaign, m.limit = campaign, limit
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &model.LeaderboardResponse{
+		Campaign:    campaign,
+		TotalClaims: 3,
+		Claimers:    2,
+		Leaders:     []model.LeaderboardEntry{{Rank: 1, UserID: "alice", Claims: 2}, {Rank: 2, UserID: "bob", Claims: 1}},
+	}, nil
+}
+
+func setupLeaderboardTestApp(mockSvc *mockLeaderboardService) *fiber.App {
+	app := fiber.New()
+	app.Get("/api/campaigns/:id/leaderboard", NewLeaderboardHandler(mockSvc).GetLeaderboard)
+	return app
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	mockSvc := &mockLeaderboardService{}
+	app := setupLeaderboardTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/campaigns/summer/leaderboard", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "summer", mockSvc.campaign)
+	assert.Equal(t, defaultLeaderboardLimit, mockSvc.limit)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"campaign": "summer", "total_claims": 3, "claimers": 2, "leaders": [
+		{"rank": 1, "user_id": "alice", "claims": 2}, {"rank": 2, "user_id": "bob", "claims": 1}]}`, string(respBody))
+}
+
+func TestGetLeaderboard_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		err    error
+		status int
+	}{
+		{"limit too large", "/api/campaigns/summer/leaderboard?limit=101", nil, fiber.StatusBadRequest},
+		{"limit zero", "/api/campaigns/summer/leaderboard?limit=0", nil, fiber.StatusBadRequest},
+		{"id too long", "/api/campaigns/" + strings.Repeat("a", 65) + "/leaderboard", nil, fiber.StatusBadRequest},
+		{"service error", "/api/campaigns/summer/leaderboard", errors.New("database connection failed"), fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockLeaderboardService{err: tt.err}
+			app := setupLeaderboardTestApp(mockSvc)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RouteLimits returns middleware bounding the route it is mounted on: bodies larger
+// than bodyLimit bytes get 413, and handling is given a deadline of timeout (0 for
+// none) through the request's user context. A request that fails because the deadline
+// passed gets 504 instead of the handler's 5xx.
+func RouteLimits(timeout time.Duration, bodyLimit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if bodyLimit > 0 && len(c.Body()) > bodyLimit {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "request body too large"})
+		}
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"error": "request timed out"})
+		}
+		return err
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteLimits_BodyLimit(t *testing.T) {
+	app := fiber.New()
+	app.Post("/claim", RouteLimits(0, 16), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/claim", strings.NewReader(`{"user_id":"u1"}`)))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPost, "/claim", strings.NewReader(`{"user_id":"u12"}`)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusRequestEntityTooLarge, resp.StatusCode)
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "request body too large", result["error"])
+}
+
+func TestRouteLimits_Timeout(t *testing.T) {
+	app := fiber.New()
+	app.Post("/import", RouteLimits(20*time.Millisecond, 0), func(c *fiber.Ctx) error {
+		<-c.UserContext().Done() // A database call honoring the deadline
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	})
+	app.Post("/claim", RouteLimits(time.Second, 0), func(c *fiber.Ctx) error {
+		deadline, ok := c.UserContext().Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/import", bytes.NewBufferString("{}")))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "request timed out", result["error"])
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPost, "/claim", bytes.NewBufferString("{}")))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestRouteLimits_NoTimeoutKeepsContext(t *testing.T) {
+	app := fiber.New()
+	app.Get("/coupon", RouteLimits(0, 1024), func(c *fiber.Ctx) error {
+		_, ok := c.UserContext().Deadline()
+		assert.False(t, ok)
+		assert.Equal(t, context.Background(), c.UserContext())
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/coupon", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// LoadTestServiceInterface defines the interface for seeding and tearing down load tests.
+type LoadTestServiceInterface interface {
+	PrewarmLoadTest(ctx context.Context, req *model.LoadTestPrewarmRequest) (*model.LoadTestNamespace, error)
+	CleanupLoadTest(ctx context.Context, ns string) (*model.LoadTestCleanup, error)
+}
+
+// LoadTestHandler handles HTTP requests for load test namespaces.
+type LoadTestHandler struct {
+	service   LoadTestServiceInterface
+	validator *validator.Validate
+}
+
+// NewLoadTestHandler creates a new LoadTestHandler with the given service and validator.
+func NewLoadTestHandler(svc LoadTestServiceInterface, v *validator.Validate) *LoadTestHandler {
+	return &LoadTestHandler{service: svc, validator: v}
+}
+
+// PrewarmLoadTest handles POST /api/admin/loadtest/prewarm requests, creating the
+// coupons and fake claims of a new load test namespace.
+func (h *LoadTestHandler) PrewarmLoadTest(c *fiber.Ctx) error {
+	var req model.LoadTestPrewarmRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: namespace must be lowercase alphanumeric, coupons 1-1000, amount 1-1000000, " +
+				"claims_per_coupon at most amount and 10000, and ttl_minutes 1-10080",
+		})
+	}
+
+	ns, err := h.service.PrewarmLoadTest(c.UserContext(), &req)
+	if err != nil {
+		if errors.Is(err, apperr.ErrLoadTestNamespaceExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "load test namespace already exists"})
+		}
+		if errors.Is(err, apperr.ErrLoadTestTooLarge) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: " + err.Error()})
+		}
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("namespace", req.Namespace).
+			Msg("failed to prewarm load test")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("namespace", ns.Namespace).
+		Int("coupons", ns.Coupons).
+		Int("claims", ns.Claims).
+		Time("expires_at", ns.ExpiresAt).
+		Msg("load test prewarmed")
+
+	return c.Status(fiber.StatusCreated).JSON(ns)
+}
+
+// CleanupLoadTest handles DELETE /api/admin/loadtest/:namespace requests, removing the
+// coupons of a load test namespace with their claims before it expires. The namespace
+// is checked like a prewarmed one, so it cannot carry LIKE wildcards matching other
+// coupons.
+func (h *LoadTestHandler) CleanupLoadTest(c *fiber.Ctx) error {
+	namespace := c.Params("namespace")
+	if err := h.validator.Var(namespace, "required,alphanum,lowercase,max=32"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: namespace must be lowercase alphanumeric",
+		})
+	}
+
+	cleanup, err := h.service.CleanupLoadTest(c.UserContext(), namespace)
+	if err != nil {
+		if errors.Is(err, apperr.ErrLoadTestNamespaceNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "load test namespace not found"})
+		}
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("namespace", namespace).
+			Msg("failed to clean up load test")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("namespace", namespace).
+		Int("coupons", cleanup.Coupons).
+		Msg("load test cleaned up")
+
+	return c.JSON(cleanup)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+)
+
+// mockLoadTestService is a mock implementation of LoadTestServiceInterface knowing the
+// "run42" namespace only.
+type mockLoadTestService struct {
+	prewarmed *model.LoadTestPrewarmRequest
+	cleanedUp []string
+}
+
+func (m *mockLoadTestService) PrewarmLoadTest(ctx context.Context, req *model.LoadTestPrewarmRequest) (*model.LoadTestNamespace, error) {
+	if req.Namespace == "run42" {
+		return nil, apperr.ErrLoadTestNamespaceExists
+	}
+	if req.Coupons*req.ClaimsPerCoupon > 100000 {
+		return nil, apperr.ErrLoadTestTooLarge
+	}
+	m.prewarmed = req
+	return &model.LoadTestNamespace{
+		Namespace:    req.Namespace,
+		CouponPrefix: "loadtest-" + req.Namespace + "-",
+		UserPrefix:   "loadtest-" + req.Namespace + "-user-",
+		Coupons:      req.Coupons,
+		Claims:       req.Coupons * req.ClaimsPerCoupon,
+		ExpiresAt:    time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func (m *mockLoadTestService) CleanupLoadTest(ctx context.Context, ns string) (*model.LoadTestCleanup, error) {
+	m.cleanedUp = append(m.cleanedUp, strings.Clone(ns)) // fiber reuses the buffer
+	if ns != "run42" {
+		return &model.LoadTestCleanup{Namespace: ns}, apperr.ErrLoadTestNamespaceNotFound
+	}
+	return &model.LoadTestCleanup{Namespace: ns, Coupons: 10}, nil
+}
+
+func setupLoadTestTestApp(mockSvc *mockLoadTestService) *fiber.App {
+	app := fiber.New()
+	h := NewLoadTestHandler(mockSvc, validator.New())
+	app.Post("/api/admin/loadtest/prewarm", h.PrewarmLoadTest)
+	app.Delete("/api/admin/loadtest/:namespace", h.CleanupLoadTest)
+	return app
+}
+
+func TestPrewarmLoadTest(t *testing.T) {
+	mockSvc := &mockLoadTestService{}
+	app := setupLoadTestTestApp(mockSvc)
+
+	body := `{"namespace": "run43", "coupons": 10, "amount": 100, "claims_per_coupon": 5, "ttl_minutes": 60}`
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/loadtest/prewarm", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	assert.Equal(t, &model.LoadTestPrewarmRequest{Namespace: "run43", Coupons: 10, Amount: 100, ClaimsPerCoupon: 5, TTLMinutes: 60}, mockSvc.prewarmed)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"namespace": "run43", "coupon_prefix": "loadtest-run43-", "user_prefix": "loadtest-run43-user-",
+		"coupons": 10, "claims": 50, "expires_at": "2024-12-01T00:00:00Z"}`, string(respBody))
+}
+
+func TestPrewarmLoadTest_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"namespace with wildcard", `{"namespace": "run_%", "coupons": 1, "amount": 1, "ttl_minutes": 60}`, fiber.StatusBadRequest},
+		{"more claims than amount", `{"namespace": "run43", "coupons": 1, "amount": 1, "claims_per_coupon": 2, "ttl_minutes": 60}`, fiber.StatusBadRequest},
+		{"missing ttl", `{"namespace": "run43", "coupons": 1, "amount": 1}`, fiber.StatusBadRequest},
+		{"too many claims", `{"namespace": "run43", "coupons": 1000, "amount": 1000, "claims_per_coupon": 1000, "ttl_minutes": 60}`, fiber.StatusBadRequest},
+		{"namespace exists", `{"namespace": "run42", "coupons": 1, "amount": 1, "ttl_minutes": 60}`, fiber.StatusConflict},
+		{"malformed", `{"namespace":`, fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupLoadTestTestApp(&mockLoadTestService{})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/loadtest/prewarm", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestCleanupLoadTest(t *testing.T) {
+	mockSvc := &mockLoadTestService{}
+	app := setupLoadTestTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/api/admin/loadtest/run42", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"namespace": "run42", "coupons": 10}`, string(respBody))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/api/admin/loadtest/run43", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/api/admin/loadtest/run_4", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, []string{"run42", "run43"}, mockSvc.cleanedUp, "a namespace with LIKE wildcards is never cleaned up")
+}
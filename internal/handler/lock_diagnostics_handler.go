@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/lockdiag"
+)
+
+// LockDumper defines the interface for reading the lock state dumps of long coupon
+// lock waits.
+type LockDumper interface {
+	Dumps() []lockdiag.Dump
+}
+
+// LockDiagnosticsHandler handles HTTP requests for lock state dumps.
+type LockDiagnosticsHandler struct {
+	dumper LockDumper
+}
+
+// NewLockDiagnosticsHandler creates a new LockDiagnosticsHandler with the given dumper.
+func NewLockDiagnosticsHandler(d LockDumper) *LockDiagnosticsHandler {
+	return &LockDiagnosticsHandler{dumper: d}
+}
+
+// ListLockDumps handles GET /api/admin/diagnostics/locks requests. It returns the
+// pg_stat_activity and pg_locks snapshots taken for claims that waited longer than
+// DB_LOCK_DIAGNOSTICS_THRESHOLD for a coupon row lock, newest first.
+func (h *LockDiagnosticsHandler) ListLockDumps(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"dumps": h.dumper.Dumps()})
+}
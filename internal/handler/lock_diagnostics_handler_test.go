@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/lockdiag"
+)
+
+type stubLockDumper []lockdiag.Dump
+
+func (s stubLockDumper) Dumps() []lockdiag.Dump { return s }
+
+func TestListLockDumps(t *testing.T) {
+	dumps := stubLockDumper{{
+		Time:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		RequestID: "req-1",
+		Coupon:    "PROMO",
+		PID:       42,
+		WaitedMs:  250,
+		Snapshot: lockdiag.Snapshot{
+			Backends: []lockdiag.Backend{{PID: 42, BlockedBy: []uint32{7}, State: "active"}},
+			Locks:    []lockdiag.Lock{{PID: 42, LockType: "transactionid", TransactionID: "812", Mode: "ShareLock"}},
+		},
+	}}
+	app := fiber.New()
+	app.Get("/api/admin/diagnostics/locks", NewLockDiagnosticsHandler(dumps).ListLockDumps)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/diagnostics/locks", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `"request_id":"req-1"`)
+	assert.Contains(t, string(body), `"blocked_by":[7]`)
+	assert.Contains(t, string(body), `"locks":[{"pid":42,"locktype":"transactionid","transaction_id":"812","mode":"ShareLock","granted":false}]`)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/repository/memory"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+)
+
+// setupMemoryStoreApp returns an app serving the coupon and claim handlers with a real
+// service on the memory repositories, which store what the handlers pass them: unlike
+// service tests' string literals, path parameters share buffers fiber reuses.
+func setupMemoryStoreApp() *fiber.App {
+	db := memory.NewDB()
+	svc := service.NewCouponServiceWithTransactor(db, memory.NewCouponRepository(db), memory.NewClaimRepository(db))
+	v := validator.New()
+	coupons := NewCouponHandler(svc, v)
+	claims := NewClaimHandler(svc, v)
+
+	app := fiber.New()
+	app.Post("/api/coupons", coupons.CreateCoupon)
+	app.Post("/api/coupons/claim", claims.ClaimCoupon)
+	app.Get("/api/coupons/:name", coupons.GetCoupon)
+	app.Patch("/api/coupons/:name", coupons.UpdateCoupon)
+	app.Post("/api/coupons/:name/top-up", coupons.TopUpCoupon)
+	return app
+}
+
+func doJSON(t *testing.T, app *fiber.App, method, path, body string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestMemoryStore_ClaimAfterWriteByPathName(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"top-up", http.MethodPost, "/api/coupons/PROMO/top-up", `{"amount": 5}`},
+		{"update", http.MethodPatch, "/api/coupons/PROMO", `{"tags": ["spring"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupMemoryStoreApp()
+			require.Equal(t, fiber.StatusCreated, doJSON(t, app, http.MethodPost, "/api/coupons", `{"name": "PROMO", "amount": 5}`))
+			require.Equal(t, fiber.StatusOK, doJSON(t, app, tt.method, tt.path, tt.body))
+
+			// Requests reusing the buffer the written name was parsed from must not rename
+			// the coupon.
+			for i := range 10 {
+				doJSON(t, app, http.MethodGet, fmt.Sprintf("/api/coupons/XYZ%02d", i), "")
+			}
+
+			assert.Equal(t, fiber.StatusOK, doJSON(t, app, http.MethodGet, "/api/coupons/PROMO", ""))
+			assert.Equal(t, fiber.StatusOK, doJSON(t, app, http.MethodPost, "/api/coupons/claim", `{"user_id": "user_001", "coupon_name": "PROMO"}`))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// Claim move verification batch bounds for ?limit=.
+const (
+	defaultVerifyLimit = 100
+	maxVerifyLimit     = 1000
+)
+
+// ClaimMoveServiceInterface defines the interface for verifying the move of claims to
+// claims_v2.
+type ClaimMoveServiceInterface interface {
+	Verify(ctx context.Context, after string, limit int) (*model.ClaimMoveReport, error)
+}
+
+// MigrationHandler handles HTTP requests for table migrations.
+type MigrationHandler struct {
+	service ClaimMoveServiceInterface
+}
+
+// NewMigrationHandler creates a new MigrationHandler with the given service.
+func NewMigrationHandler(svc ClaimMoveServiceInterface) *MigrationHandler {
+	return &MigrationHandler{service: svc}
+}
+
+// VerifyClaimsV2 handles GET /api/admin/migrations/claims_v2/verify requests. It
+// compares the claims of a batch of coupons (?limit=, by name after ?after=) in claims
+// and claims_v2 and reports those that differ; next names the following batch.
+func (h *MigrationHandler) VerifyClaimsV2(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", defaultVerifyLimit)
+	if limit < 1 || limit > maxVerifyLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: limit must be between 1 and 1000",
+		})
+	}
+
+	report, err := h.service.Verify(c.UserContext(), c.Query("after"), limit)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("method", c.Method()).
+			Str("path", logPath(c)).
+			Msg("failed to verify claims_v2")
+		return internalError(c, err)
+	}
+	if len(report.Mismatches) > 0 {
+		log.Warn().
+			Int("checked", report.Checked).
+			Int("mismatches", len(report.Mismatches)).
+			Msg("claims_v2 differs from claims")
+	}
+	return c.JSON(report)
+}
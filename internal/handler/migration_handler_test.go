@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// mockClaimMoveService is a mock implementation of ClaimMoveServiceInterface.
+type mockClaimMoveService struct {
+	after string
+	limit int
+	err   error
+}
+
+func (m *mockClaimMoveService) Verify(ctx context.Context, after string, limit int) (*model.ClaimMoveReport, error) {
+	m.after, m.limit = after, limit
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &model.ClaimMoveReport{
+		Phase:      "dual_write",
+		Checked:    2,
+		Mismatches: []model.ClaimComparison{{CouponName: "PROMO", OldClaims: 5, NewClaims: 4}},
+		Next:       "SUMMER",
+	}, nil
+}
+
+func setupMigrationTestApp(mockSvc *mockClaimMoveService) *fiber.App {
+	app := fiber.New()
+	app.Get("/api/admin/migrations/claims_v2/verify", NewMigrationHandler(mockSvc).VerifyClaimsV2)
+	return app
+}
+
+func TestVerifyClaimsV2(t *testing.T) {
+	mockSvc := &mockClaimMoveService{}
+	app := setupMigrationTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/migrations/claims_v2/verify?after=BLACK_FRIDAY&limit=2", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "BLACK_FRIDAY", mockSvc.after)
+	assert.Equal(t, 2, mockSvc.limit)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"phase": "dual_write", "checked": 2, "next": "SUMMER",
+		"mismatches": [{"coupon_name": "PROMO", "old_claims": 5, "new_claims": 4}]}`, string(respBody))
+}
+
+func TestVerifyClaimsV2_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		err    error
+		status int
+	}{
+		{"limit too large", "/api/admin/migrations/claims_v2/verify?limit=1001", nil, fiber.StatusBadRequest},
+		{"limit zero", "/api/admin/migrations/claims_v2/verify?limit=0", nil, fiber.StatusBadRequest},
+		{"service error", "/api/admin/migrations/claims_v2/verify", errors.New("relation \"claims_v2\" does not exist"), fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupMigrationTestApp(&mockClaimMoveService{err: tt.err})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.url, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
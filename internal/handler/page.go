@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// Page size bounds for ?limit= of every list endpoint, SERVER_DEFAULT_PAGE_SIZE and
+// SERVER_MAX_PAGE_SIZE. Configured once at startup, like the process-wide Redactor.
+var (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// SetPageSizes sets the page size of list requests without ?limit= and the largest one
+// they may ask for. Not safe for concurrent use with the handlers; call it during
+// startup only.
+func SetPageSizes(defaultSize, maxSize int) {
+	defaultPageSize, maxPageSize = defaultSize, maxSize
+}
+
+// msgInvalidCursor is the 400 response message for a ?cursor= the API did not hand out.
+const msgInvalidCursor = "invalid request: cursor is invalid"
+
+// pageQuery reads ?limit= and ?cursor= of a list request: the page size and the key of
+// the item the page starts after, "" for the first page. On invalid input, returns the
+// message of a 400 response. Every list endpoint reads its page through it, so none
+// can be asked for more than the maximum page size.
+func pageQuery(c *fiber.Ctx) (limit int, key, msg string) {
+	limit = c.QueryInt("limit", defaultPageSize)
+	if limit < 1 || limit > maxPageSize {
+		return 0, "", fmt.Sprintf("invalid request: limit must be between 1 and %d", maxPageSize)
+	}
+	key, err := model.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		return 0, "", msgInvalidCursor
+	}
+	return limit, key, ""
+}
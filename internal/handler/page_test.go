@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestPageQuery_PageSizes(t *testing.T) {
+	SetPageSizes(20, 200)
+	defer SetPageSizes(100, 1000)
+
+	var gotLimit int
+	var gotKey string
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		limit, key, msg := pageQuery(c)
+		if msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+		}
+		gotLimit, gotKey = limit, key
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/items", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 20, gotLimit, "default page size")
+	assert.Empty(t, gotKey)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/items?limit=200&cursor="+model.EncodeCursor("PROMO"), nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 200, gotLimit)
+	assert.Equal(t, "PROMO", gotKey)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/items?limit=10000000", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.JSONEq(t, `{"error": "invalid request: limit must be between 1 and 200"}`, string(body))
+}
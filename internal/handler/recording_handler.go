@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/claimrecord"
+)
+
+// ClaimRecorder defines the interface for reading and clearing recorded claims.
+type ClaimRecorder interface {
+	Recordings() []claimrecord.Recording
+	Clear()
+}
+
+// RecordingHandler handles HTTP requests for recorded failing claims.
+type RecordingHandler struct {
+	recorder ClaimRecorder
+}
+
+// NewRecordingHandler creates a new RecordingHandler with the given recorder.
+func NewRecordingHandler(r ClaimRecorder) *RecordingHandler {
+	return &RecordingHandler{recorder: r}
+}
+
+// ListRecordings handles GET /api/admin/claims/recordings requests. It returns the
+// failing claims sent with X-Debug-Record: true, newest first.
+func (h *RecordingHandler) ListRecordings(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"recordings": h.recorder.Recordings()})
+}
+
+// ClearRecordings handles DELETE /api/admin/claims/recordings requests.
+func (h *RecordingHandler) ClearRecordings(c *fiber.Ctx) error {
+	h.recorder.Clear()
+	log.Info().Str("request_id", c.GetRespHeader("X-Request-ID")).Msg("claim recordings cleared")
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/claimrecord"
+)
+
+func setupRecordingTestApp(recorder *claimrecord.Recorder) *fiber.App {
+	app := fiber.New()
+	h := NewRecordingHandler(recorder)
+	app.Post("/api/coupons/claim", recorder.Handler(), func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: user_id is required"})
+	})
+	app.Get("/api/admin/claims/recordings", h.ListRecordings)
+	app.Delete("/api/admin/claims/recordings", h.ClearRecordings)
+	return app
+}
+
+func TestRecordings(t *testing.T) {
+	recorder := claimrecord.New(10)
+	app := setupRecordingTestApp(recorder)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", strings.NewReader(`{"coupon_name": "PROMO"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(claimrecord.Header, "true")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/claims/recordings", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `"request":{"coupon_name":"PROMO"}`)
+	assert.Contains(t, string(body), `"response":{"error":"invalid request: user_id is required"}`)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/api/admin/claims/recordings", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/claims/recordings", nil))
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"recordings": []}`, string(body))
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/claimsim"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// ClaimSimulator defines the interface for simulating coupon launches.
+type ClaimSimulator interface {
+	Simulate(req *model.ClaimSimulationRequest) (*model.ClaimSimulationResult, error)
+}
+
+// SimulationHandler handles HTTP requests for claim simulations.
+type SimulationHandler struct {
+	simulator ClaimSimulator
+	validator *validator.Validate
+}
+
+// NewSimulationHandler creates a new SimulationHandler with the given simulator and validator.
+func NewSimulationHandler(s ClaimSimulator, v *validator.Validate) *SimulationHandler {
+	return &SimulationHandler{simulator: s, validator: v}
+}
+
+// Simulate handles POST /api/admin/simulate requests. It simulates claiming a coupon
+// of the given stock at the given rate, with the claim latencies this instance
+// recorded, and returns when the stock would run out and the claims' latencies.
+func (h *SimulationHandler) Simulate(c *fiber.Ctx) error {
+	var req model.ClaimSimulationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: stock must be 1-10000000, arrival_rate above 0 and at most 100000, duration_seconds 1-3600",
+		})
+	}
+
+	res, err := h.simulator.Simulate(&req)
+	switch {
+	case errors.Is(err, claimsim.ErrTooManyClaims):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: " + err.Error()})
+	case errors.Is(err, claimsim.ErrNoSamples):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "no claims recorded on this instance yet"})
+	case err != nil:
+		return internalError(c, err)
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Int("stock", req.Stock).
+		Float64("arrival_rate", req.ArrivalRate).
+		Int("duration_seconds", req.DurationSeconds).
+		Int("claims", res.Claims).
+		Float64("p99_latency_ms", res.P99LatencyMs).
+		Msg("claims simulated")
+	return c.JSON(res)
+}
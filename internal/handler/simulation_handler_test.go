@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/claimsim"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// stubSimulator returns res and err for every simulation.
+type stubSimulator struct {
+	res *model.ClaimSimulationResult
+	err error
+}
+
+func (s stubSimulator) Simulate(*model.ClaimSimulationRequest) (*model.ClaimSimulationResult, error) {
+	return s.res, s.err
+}
+
+func simulate(t *testing.T, sim ClaimSimulator, body string) (int, string) {
+	t.Helper()
+	app := fiber.New()
+	app.Post("/api/admin/simulate", NewSimulationHandler(sim, validator.New()).Simulate)
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/simulate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestSimulate(t *testing.T) {
+	exhausted := 5000.0
+	sim := stubSimulator{res: &model.ClaimSimulationResult{LockPolicy: "wait", Claims: 1000, Granted: 500, ExhaustedAfterMs: &exhausted}}
+
+	status, body := simulate(t, sim, `{"stock": 500, "arrival_rate": 100, "duration_seconds": 10}`)
+
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Contains(t, body, `"exhausted_after_ms":5000`)
+	assert.Contains(t, body, `"granted":500`)
+}
+
+func TestSimulate_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		sim    stubSimulator
+		body   string
+		status int
+		error  string
+	}{
+		{"invalid body", stubSimulator{}, `{`, fiber.StatusBadRequest, "invalid request body"},
+		{"missing stock", stubSimulator{}, `{"arrival_rate": 1, "duration_seconds": 1}`, fiber.StatusBadRequest, "invalid request: stock must be"},
+		{"too many claims", stubSimulator{err: claimsim.ErrTooManyClaims}, `{"stock": 1, "arrival_rate": 100000, "duration_seconds": 3600}`, fiber.StatusBadRequest, "exceeds the simulated claims limit"},
+		{"no samples", stubSimulator{err: claimsim.ErrNoSamples}, `{"stock": 1, "arrival_rate": 1, "duration_seconds": 1}`, fiber.StatusConflict, "no claims recorded on this instance yet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := simulate(t, tt.sim, tt.body)
+
+			assert.Equal(t, tt.status, status)
+			assert.Contains(t, body, tt.error)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// defaultStockWaitTimeout is the wait of GET /api/coupons/:name/wait-for-stock without
+// ?timeout=, if the maximum allows it.
+const defaultStockWaitTimeout = 30 * time.Second
+
+// StockWaitServiceInterface defines the interface for waiting for a coupon's stock.
+type StockWaitServiceInterface interface {
+	WaitForStock(ctx context.Context, name string, timeout time.Duration) (*model.StockWaitResponse, error)
+}
+
+// StockWaitHandler handles HTTP requests waiting for coupon stock.
+type StockWaitHandler struct {
+	service    StockWaitServiceInterface
+	maxTimeout time.Duration
+}
+
+// NewStockWaitHandler creates a new StockWaitHandler with the given service, waiting
+// at most maxTimeout per request.
+func NewStockWaitHandler(svc StockWaitServiceInterface, maxTimeout time.Duration) *StockWaitHandler {
+	return &StockWaitHandler{service: svc, maxTimeout: maxTimeout}
+}
+
+// WaitForStock handles GET /api/coupons/:name/wait-for-stock requests. It answers once
+// the coupon is claimable or ?timeout= (a duration such as 30s) passes, with available
+// telling which; clients waiting longer send the request again.
+func (h *StockWaitHandler) WaitForStock(c *fiber.Ctx) error {
+	timeout := min(defaultStockWaitTimeout, h.maxTimeout)
+	if raw := c.Query("timeout"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 || d > h.maxTimeout {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request: timeout must be a duration between 0s and " + h.maxTimeout.String(),
+			})
+		}
+		timeout = d
+	}
+
+	name := c.Params("name")
+	resp, err := h.service.WaitForStock(c.UserContext(), name, timeout)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "coupon not found"})
+		}
+		if errors.Is(err, apperr.ErrStockWaitUnavailable) {
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "cannot wait for stock right now, retry later"})
+		}
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to wait for coupon stock")
+		return internalError(c, err)
+	}
+	return c.JSON(resp)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// mockStockWaitService is a mock implementation of StockWaitServiceInterface
+// recording the timeout asked for.
+type mockStockWaitService struct {
+	timeout time.Duration
+	err     error
+}
+
+func (m *mockStockWaitService) WaitForStock(ctx context.Context, name string, timeout time.Duration) (*model.StockWaitResponse, error) {
+	m.timeout = timeout
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &model.StockWaitResponse{Name: name, Available: true, RemainingAmount: 40}, nil
+}
+
+func setupStockWaitTestApp(mockSvc *mockStockWaitService) *fiber.App {
+	app := fiber.New()
+	h := NewStockWaitHandler(mockSvc, time.Minute)
+	app.Get("/api/coupons/:name/wait-for-stock", h.WaitForStock)
+	return app
+}
+
+func TestWaitForStock(t *testing.T) {
+	mockSvc := &mockStockWaitService{}
+	app := setupStockWaitTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO/wait-for-stock?timeout=45s", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"name": "PROMO", "available": true, "remaining_amount": 40}`, string(body))
+	assert.Equal(t, 45*time.Second, mockSvc.timeout)
+}
+
+func TestWaitForStock_DefaultTimeout(t *testing.T) {
+	mockSvc := &mockStockWaitService{}
+	app := setupStockWaitTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO/wait-for-stock", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 30*time.Second, mockSvc.timeout)
+}
+
+func TestWaitForStock_InvalidTimeout(t *testing.T) {
+	app := setupStockWaitTestApp(&mockStockWaitService{})
+
+	for _, timeout := range []string{"30", "0s", "-1s", "2m"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO/wait-for-stock?timeout="+timeout, nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, timeout)
+		body, _ := io.ReadAll(resp.Body)
+		assert.JSONEq(t, `{"error": "invalid request: timeout must be a duration between 0s and 1m0s"}`, string(body))
+	}
+}
+
+func TestWaitForStock_Errors(t *testing.T) {
+	tests := []struct {
+		err        error
+		status     int
+		retryAfter string
+	}{
+		{err: apperr.ErrCouponNotFound, status: fiber.StatusNotFound},
+		{err: apperr.ErrStockWaitUnavailable, status: fiber.StatusServiceUnavailable, retryAfter: "1"},
+		{err: errors.New("database connection failed"), status: fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			app := setupStockWaitTestApp(&mockStockWaitService{err: tt.err})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/coupons/PROMO/wait-for-stock", nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.retryAfter, resp.Header.Get(fiber.HeaderRetryAfter))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// UserServiceInterface defines the interface for per-user data operations.
+type UserServiceInterface interface {
+	EraseUserData(ctx context.Context, userID string) (*model.UserErasureResponse, error)
+}
+
+// UserHandler handles HTTP requests for per-user data operations.
+type UserHandler struct {
+	service UserServiceInterface
+}
+
+// NewUserHandler creates a new UserHandler with the given service.
+func NewUserHandler(svc UserServiceInterface) *UserHandler {
+	return &UserHandler{service: svc}
+}
+
+// EraseUserData handles DELETE /api/users/:user_id/data requests (data-deletion requests).
+// The user's claims are pseudonymized rather than deleted so coupon counts are preserved.
+// user_id is percent-decoded so IDs containing reserved characters match their claims exactly.
+func (h *UserHandler) EraseUserData(c *fiber.Ctx) error {
+	userID, err := url.PathUnescape(c.Params("user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: user_id is invalid"})
+	}
+	if strings.TrimSpace(userID) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: user_id is required"})
+	}
+	if len(userID) > 255 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: user_id exceeds maximum length of 255"})
+	}
+
+	result, err := h.service.EraseUserData(c.UserContext(), userID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrInvalidRequest) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+		// user_id is deliberately not logged: it is the personal data being erased.
+		log.Error().
+			Str("error", redact.Error(err, userID)).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("method", c.Method()).
+			Msg("failed to erase user data")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Str("pseudonym", result.Pseudonym).
+		Int64("claims_anonymized", result.ClaimsAnonymized).
+		Msg("user data erased")
+
+	return c.JSON(result)
+}
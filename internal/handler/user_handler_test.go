@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// mockUserService is a mock implementation of UserServiceInterface.
+type mockUserService struct {
+	eraseUserDataFn func(ctx context.Context, userID string) (*model.UserErasureResponse, error)
+}
+
+func (m *mockUserService) EraseUserData(ctx context.Context, userID string) (*model.UserErasureResponse, error) {
+	if m.eraseUserDataFn != nil {
+		return m.eraseUserDataFn(ctx, userID)
+	}
+	return &model.UserErasureResponse{}, nil
+}
+
+func setupUserTestApp(mockSvc *mockUserService) *fiber.App {
+	app := fiber.New()
+	h := NewUserHandler(mockSvc)
+	app.Delete("/api/users/:user_id/data", h.EraseUserData)
+	return app
+}
+
+func TestEraseUserData_Success(t *testing.T) {
+	var received string
+	mockSvc := &mockUserService{
+		eraseUserDataFn: func(ctx context.Context, userID string) (*model.UserErasureResponse, error) {
+			received = userID
+			return &model.UserErasureResponse{Pseudonym: "erased-abc", ClaimsAnonymized: 2}, nil
+		},
+	}
+	app := setupUserTestApp(mockSvc)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/users/user_001/data", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "user_001", received)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"pseudonym": "erased-abc", "claims_anonymized": 2}`, string(body))
+}
+
+func TestEraseUserData_DecodesUserID(t *testing.T) {
+	var received string
+	mockSvc := &mockUserService{
+		eraseUserDataFn: func(ctx context.Context, userID string) (*model.UserErasureResponse, error) {
+			received = userID
+			return &model.UserErasureResponse{}, nil
+		},
+	}
+	app := setupUserTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/api/users/jane%40example.com/data", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "jane@example.com", received)
+}
+
+func TestEraseUserData_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		svcErr     error
+		wantStatus int
+		wantError  string
+	}{
+		{"blank user id", "/api/users/%20/data", nil, fiber.StatusBadRequest, "invalid request: user_id is required"},
+		{"user id too long", "/api/users/" + strings.Repeat("u", 256) + "/data", nil, fiber.StatusBadRequest, "invalid request: user_id exceeds maximum length of 255"},
+		{"internal error", "/api/users/user_001/data", errors.New("database connection failed"), fiber.StatusInternalServerError, "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			mockSvc := &mockUserService{
+				eraseUserDataFn: func(ctx context.Context, userID string) (*model.UserErasureResponse, error) {
+					called = true
+					return nil, tt.svcErr
+				},
+			}
+			app := setupUserTestApp(mockSvc)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodDelete, tt.path, nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.svcErr != nil, called)
+
+			var result map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.wantError, result["error"])
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"runtime/debug"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/runtimelimits"
+)
+
+// VersionInfo is the body of GET /api/version.
+type VersionInfo struct {
+	GoVersion string               `json:"go_version"`
+	Revision  string               `json:"revision,omitempty"` // VCS revision built from
+	Modified  bool                 `json:"modified,omitempty"` // Built with uncommitted changes
+	Runtime   runtimelimits.Limits `json:"runtime"`
+}
+
+// Version returns the handler of GET /api/version: the build, and the runtime limits
+// in effect, read on every request since GOMAXPROCS follows the cgroup CPU limit.
+func Version() fiber.Handler {
+	var build VersionInfo
+	if info, ok := debug.ReadBuildInfo(); ok {
+		build.GoVersion = info.GoVersion
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				build.Revision = setting.Value
+			case "vcs.modified":
+				build.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		info := build
+		info.Runtime = runtimelimits.Current()
+		return c.JSON(info)
+	}
+}
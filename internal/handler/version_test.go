@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/version", Version())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/version", nil))
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var info VersionInfo
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, runtime.GOMAXPROCS(0), info.Runtime.GOMAXPROCS)
+}
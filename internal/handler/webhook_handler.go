@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// WebhookServiceInterface defines the interface for webhook subscription management.
+type WebhookServiceInterface interface {
+	Subscribe(ctx context.Context, req *model.CreateWebhookRequest) (*model.WebhookSubscription, error)
+	List(ctx context.Context, afterID int64, limit int) (*model.WebhookListResponse, error)
+	Unsubscribe(ctx context.Context, id int64) error
+}
+
+// WebhookHandler handles HTTP requests for webhook subscriptions.
+type WebhookHandler struct {
+	service   WebhookServiceInterface
+	validator *validator.Validate
+}
+
+// NewWebhookHandler creates a new WebhookHandler with the given service and validator.
+func NewWebhookHandler(svc WebhookServiceInterface, v *validator.Validate) *WebhookHandler {
+	return &WebhookHandler{service: svc, validator: v}
+}
+
+// formatWebhookValidationError converts validator errors on a webhook subscription request.
+func formatWebhookValidationError(err error) string {
+	var ve validator.ValidationErrors
+	if !errors.As(err, &ve) || len(ve) == 0 {
+		return "invalid request"
+	}
+	fe := ve[0]
+	switch fe.Field() {
+	case "URL":
+		if fe.Tag() == "required" {
+			return "invalid request: url is required"
+		}
+		return "invalid request: url must be an absolute http or https URL of at most 2048 characters"
+	case "Events":
+		if fe.Tag() == "max" {
+			return "invalid request: events exceeds maximum of 5 entries"
+		}
+		return "invalid request: events is required"
+	case "Secret":
+		return "invalid request: secret must be between 16 and 255 characters"
+	case "PayloadTemplate":
+		return "invalid request: payload_template must be at most 8192 characters"
+	}
+	if fe.Tag() == "oneof" { // An Events[i] entry
+		return "invalid request: events must be coupon.created, coupon.updated, coupon.disabled, coupon.low_stock or coupon.claimed"
+	}
+	return "invalid request"
+}
+
+// CreateWebhook handles POST /api/admin/webhooks requests. The response carries the
+// signing secret, which is not shown again.
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	var req model.CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": formatWebhookValidationError(err)})
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request: url must be an absolute http or https URL of at most 2048 characters",
+		})
+	}
+
+	sub, err := h.service.Subscribe(c.UserContext(), &req)
+	if err != nil {
+		if errors.Is(err, apperr.ErrInvalidWebhookTemplate) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: " + err.Error()})
+		}
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("method", c.Method()).
+			Msg("failed to create webhook subscription")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Int64("webhook_id", sub.ID).
+		Strs("events", sub.Events).
+		Msg("webhook subscription created")
+
+	return c.Status(fiber.StatusCreated).JSON(sub)
+}
+
+// ListWebhooks handles GET /api/admin/webhooks requests, a page of up to ?limit=
+// subscriptions after ?cursor= at a time, in ID order. Secrets are omitted.
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	limit, key, msg := pageQuery(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	var after int64
+	if key != "" {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || id < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msgInvalidCursor})
+		}
+		after = id
+	}
+
+	resp, err := h.service.List(c.UserContext(), after, limit)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("method", c.Method()).
+			Msg("failed to list webhook subscriptions")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+	return c.JSON(resp)
+}
+
+// DeleteWebhook handles DELETE /api/admin/webhooks/:id requests.
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request: id must be a positive integer"})
+	}
+
+	if err := h.service.Unsubscribe(c.UserContext(), id); err != nil {
+		if errors.Is(err, apperr.ErrWebhookNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "webhook subscription not found"})
+		}
+		log.Error().
+			Err(err).
+			Str("request_id", c.GetRespHeader("X-Request-ID")).
+			Str("method", c.Method()).
+			Int64("webhook_id", id).
+			Msg("failed to delete webhook subscription")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	log.Info().
+		Str("request_id", c.GetRespHeader("X-Request-ID")).
+		Int64("webhook_id", id).
+		Msg("webhook subscription deleted")
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+)
+
+// mockWebhookService is a mock implementation of WebhookServiceInterface.
+type mockWebhookService struct {
+	subscribed *model.CreateWebhookRequest
+	deleted    []int64
+	after      int64
+	limit      int
+}
+
+func (m *mockWebhookService) Subscribe(ctx context.Context, req *model.CreateWebhookRequest) (*model.WebhookSubscription, error) {
+	if req.PayloadTemplate == "{{" {
+		return nil, fmt.Errorf("%w: unclosed action", apperr.ErrInvalidWebhookTemplate)
+	}
+	m.subscribed = req
+	return &model.WebhookSubscription{ID: 1, URL: req.URL, Events: req.Events, Secret: "whsec_generated"}, nil
+}
+
+func (m *mockWebhookService) List(ctx context.Context, afterID int64, limit int) (*model.WebhookListResponse, error) {
+	m.after, m.limit = afterID, limit
+	return &model.WebhookListResponse{Items: []model.WebhookSubscription{{ID: 1, URL: "https://erp.example.com"}}}, nil
+}
+
+func (m *mockWebhookService) Unsubscribe(ctx context.Context, id int64) error {
+	if id != 1 {
+		return apperr.ErrWebhookNotFound
+	}
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func setupWebhookTestApp(mockSvc *mockWebhookService) *fiber.App {
+	app := fiber.New()
+	h := NewWebhookHandler(mockSvc, validator.New())
+	app.Post("/api/admin/webhooks", h.CreateWebhook)
+	app.Get("/api/admin/webhooks", h.ListWebhooks)
+	app.Delete("/api/admin/webhooks/:id", h.DeleteWebhook)
+	return app
+}
+
+func TestCreateWebhook(t *testing.T) {
+	mockSvc := &mockWebhookService{}
+	app := setupWebhookTestApp(mockSvc)
+
+	body := `{"url": "https://erp.example.com/hooks", "events": ["coupon.created", "coupon.disabled"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/webhooks", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotNil(t, mockSvc.subscribed)
+	assert.Equal(t, []string{"coupon.created", "coupon.disabled"}, mockSvc.subscribed.Events)
+
+	var sub model.WebhookSubscription
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sub))
+	assert.Equal(t, "whsec_generated", sub.Secret, "the secret is returned on creation")
+}
+
+func TestCreateWebhook_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"missing url", `{"events": ["coupon.created"]}`, "invalid request: url is required"},
+		{"not http", `{"url": "ftp://erp.example.com", "events": ["coupon.created"]}`,
+			"invalid request: url must be an absolute http or https URL of at most 2048 characters"},
+		{"no events", `{"url": "https://erp.example.com", "events": []}`, "invalid request: events is required"},
+		{"unknown event", `{"url": "https://erp.example.com", "events": ["coupon.deleted"]}`,
+			"invalid request: events must be coupon.created, coupon.updated, coupon.disabled, coupon.low_stock or coupon.claimed"},
+		{"short secret", `{"url": "https://erp.example.com", "events": ["coupon.created"], "secret": "short"}`,
+			"invalid request: secret must be between 16 and 255 characters"},
+		{"invalid template", `{"url": "https://erp.example.com", "events": ["coupon.created"], "payload_template": "{{"}`,
+			"invalid request: invalid payload template: unclosed action"},
+		{"malformed", `{"url":`, "invalid request body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockWebhookService{}
+			app := setupWebhookTestApp(mockSvc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/webhooks", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			respBody, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, `{"error": "`+tt.want+`"}`, string(respBody))
+			assert.Nil(t, mockSvc.subscribed)
+		})
+	}
+}
+
+func TestListWebhooks(t *testing.T) {
+	mockSvc := &mockWebhookService{}
+	app := setupWebhookTestApp(mockSvc)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/webhooks", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Zero(t, mockSvc.after)
+	assert.Equal(t, defaultPageSize, mockSvc.limit)
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"items": [{"id": 1, "url": "https://erp.example.com", "events": null, "created_at": "0001-01-01T00:00:00Z"}]}`,
+		string(respBody))
+}
+
+func TestListWebhooks_Cursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		after  int64
+	}{
+		{"after id", "?cursor=NQ&limit=2", fiber.StatusOK, 5},
+		{"not an id", "?cursor=YWJj", fiber.StatusBadRequest, 0},
+		{"zero id", "?cursor=MA", fiber.StatusBadRequest, 0},
+		{"limit too large", "?limit=1001", fiber.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockWebhookService{}
+			app := setupWebhookTestApp(mockSvc)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/webhooks"+tt.query, nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.after, mockSvc.after)
+		})
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/api/admin/webhooks/1", fiber.StatusNoContent},
+		{"/api/admin/webhooks/2", fiber.StatusNotFound},
+		{"/api/admin/webhooks/abc", fiber.StatusBadRequest},
+		{"/api/admin/webhooks/0", fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			app := setupWebhookTestApp(&mockWebhookService{})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodDelete, tt.path, nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package hedge issues a second attempt of a slow read once it has taken longer than
+// the read's recent p95 latency, and returns whichever attempt succeeds first.
+// Only use it for reads that are safe to run twice.
+package hedge
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// sampleSize is how many recent latencies each operation keeps.
+	sampleSize = 1000
+	// minSamples is how many latencies an operation needs before it is hedged.
+	minSamples = 20
+	// refreshEvery is how often (in samples) the p95 is recomputed.
+	refreshEvery = 50
+)
+
+// Stats is a snapshot of one operation's hedging counters.
+type Stats struct {
+	Requests int64   `json:"requests"`
+	Hedged   int64   `json:"hedged"`    // Requests that issued a second attempt
+	HedgeWon int64   `json:"hedge_won"` // Hedged requests answered by the second attempt
+	DelayMs  float64 `json:"delay_ms"`  // Current hedge delay (0 until enough samples)
+}
+
+// Hedger tracks read latencies per operation and hedges reads slower than their p95.
+// A nil *Hedger runs reads once. It is safe for concurrent use.
+type Hedger struct {
+	minDelay time.Duration
+
+	mu  sync.Mutex
+	ops map[string]*operation
+}
+
+// New creates a Hedger that never hedges sooner than minDelay, so that fast reads
+// with a tight latency distribution are not doubled.
+func New(minDelay time.Duration) *Hedger {
+	return &Hedger{minDelay: minDelay, ops: make(map[string]*operation)}
+}
+
+// Stats returns the counters of every operation seen so far.
+func (h *Hedger) Stats() map[string]Stats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := make(map[string]Stats, len(h.ops))
+	for name, op := range h.ops {
+		stats[name] = Stats{
+			Requests: op.requests.Load(),
+			Hedged:   op.hedged.Load(),
+			HedgeWon: op.hedgeWon.Load(),
+			DelayMs:  float64(op.delay(h.minDelay)) / float64(time.Millisecond),
+		}
+	}
+	return stats
+}
+
+func (h *Hedger) operation(name string) *operation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	op, ok := h.ops[name]
+	if !ok {
+		op = &operation{}
+		h.ops[name] = op
+	}
+	return op
+}
+
+// operation holds the latency samples and counters of one kind of read.
+type operation struct {
+	mu      sync.Mutex
+	samples []time.Duration // Ring buffer of recent latencies
+	next    int
+	pending int // Samples since p95 was last computed
+
+	p95      atomic.Int64 // time.Duration; 0 until minSamples were recorded
+	requests atomic.Int64
+	hedged   atomic.Int64
+	hedgeWon atomic.Int64
+}
+
+// record adds a latency sample, recomputing p95 every refreshEvery samples.
+func (op *operation) record(d time.Duration) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	if len(op.samples) < sampleSize {
+		op.samples = append(op.samples, d)
+	} else {
+		op.samples[op.next] = d
+		op.next = (op.next + 1) % sampleSize
+	}
+	op.pending++
+	if len(op.samples) < minSamples || (op.pending < refreshEvery && op.p95.Load() != 0) {
+		return
+	}
+
+	op.pending = 0
+	sorted := slices.Clone(op.samples)
+	slices.Sort(sorted)
+	op.p95.Store(int64(sorted[len(sorted)*95/100]))
+}
+
+// delay returns how long to wait before hedging, or 0 if the operation is not hedged yet.
+func (op *operation) delay(minDelay time.Duration) time.Duration {
+	p95 := time.Duration(op.p95.Load())
+	if p95 == 0 {
+		return 0
+	}
+	return max(p95, minDelay)
+}
+
+type result[T any] struct {
+	value T
+	err   error
+	hedge bool
+}
+
+// Do runs read, running it a second time if it has not returned after the operation's
+// hedge delay. The first successful result is returned and the other attempt's context
+// is canceled; if both fail, the first error is returned. The latency recorded for a
+// hedged request is that of the answer the caller received.
+func Do[T any](ctx context.Context, h *Hedger, name string, read func(context.Context) (T, error)) (T, error) {
+	if h == nil {
+		return read(ctx)
+	}
+	op := h.operation(name)
+	op.requests.Add(1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	results := make(chan result[T], 2) // Buffered: the losing attempt must not block
+	attempt := func(hedge bool) {
+		v, err := read(ctx)
+		results <- result[T]{value: v, err: err, hedge: hedge}
+	}
+	go attempt(false)
+
+	attempts := 1
+	var timer <-chan time.Time
+	if d := op.delay(h.minDelay); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	var firstErr error
+	for {
+		select {
+		case <-timer:
+			timer = nil
+			attempts++
+			op.hedged.Add(1)
+			go attempt(true)
+		case r := <-results:
+			if r.err == nil {
+				op.record(time.Since(start))
+				if r.hedge {
+					op.hedgeWon.Add(1)
+				}
+				return r.value, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			// Wait for an attempt still running; a primary failing before the hedge
+			// delay fails the read (hedging is not a retry).
+			if attempts--; attempts == 0 {
+				var zero T
+				return zero, firstErr
+			}
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package hedge
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warmUp records enough samples of d for op name to be hedged after d.
+func warmUp(h *Hedger, name string, d time.Duration) {
+	op := h.operation(name)
+	for range minSamples {
+		op.record(d)
+	}
+}
+
+func TestDo_NilHedgerRunsOnce(t *testing.T) {
+	calls := 0
+	v, err := Do(context.Background(), nil, "get", func(context.Context) (int, error) {
+		calls++
+		return 42, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 42, v)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_NoHedgeUntilEnoughSamples(t *testing.T) {
+	h := New(time.Millisecond)
+	var calls atomic.Int32
+
+	_, err := Do(context.Background(), h, "get", func(context.Context) (int, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, Stats{Requests: 1}, h.Stats()["get"])
+}
+
+func TestDo_HedgesSlowRead(t *testing.T) {
+	h := New(time.Millisecond)
+	warmUp(h, "get", 5*time.Millisecond)
+
+	var calls atomic.Int32
+	primaryCanceled := make(chan struct{})
+	v, err := Do(context.Background(), h, "get", func(ctx context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			<-ctx.Done() // The primary is stuck until the hedge wins
+			close(primaryCanceled)
+			return "", ctx.Err()
+		}
+		return "hedge", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "hedge", v)
+	<-primaryCanceled
+	stats := h.Stats()["get"]
+	assert.Equal(t, int64(1), stats.Hedged)
+	assert.Equal(t, int64(1), stats.HedgeWon)
+	assert.Equal(t, 5.0, stats.DelayMs)
+}
+
+func TestDo_FastReadIsNotHedged(t *testing.T) {
+	h := New(time.Millisecond)
+	warmUp(h, "get", time.Second)
+
+	var calls atomic.Int32
+	_, err := Do(context.Background(), h, "get", func(context.Context) (int, error) {
+		calls.Add(1)
+		return 1, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Zero(t, h.Stats()["get"].Hedged)
+}
+
+func TestDo_MinDelayFloorsP95(t *testing.T) {
+	h := New(50 * time.Millisecond)
+	warmUp(h, "get", time.Microsecond)
+
+	assert.Equal(t, 50.0, h.Stats()["get"].DelayMs)
+}
+
+func TestDo_WaitsForHedgeWhenPrimaryFails(t *testing.T) {
+	h := New(time.Millisecond)
+	warmUp(h, "get", time.Millisecond)
+
+	var calls atomic.Int32
+	hedgeStarted := make(chan struct{})
+	v, err := Do(context.Background(), h, "get", func(ctx context.Context) (int, error) {
+		if calls.Add(1) == 1 {
+			<-hedgeStarted
+			return 0, errors.New("connection reset")
+		}
+		close(hedgeStarted)
+		time.Sleep(5 * time.Millisecond)
+		return 7, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 7, v)
+}
+
+func TestDo_BothFail(t *testing.T) {
+	h := New(time.Millisecond)
+	warmUp(h, "get", time.Millisecond)
+
+	errPrimary := errors.New("primary")
+	var calls atomic.Int32
+	_, err := Do(context.Background(), h, "get", func(ctx context.Context) (int, error) {
+		if calls.Add(1) == 1 {
+			time.Sleep(10 * time.Millisecond)
+			return 0, errPrimary
+		}
+		time.Sleep(20 * time.Millisecond)
+		return 0, errors.New("hedge")
+	})
+
+	assert.ErrorIs(t, err, errPrimary)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestDo_PrimaryFailsBeforeDelay(t *testing.T) {
+	h := New(time.Millisecond)
+	warmUp(h, "get", time.Second)
+
+	var calls atomic.Int32
+	_, err := Do(context.Background(), h, "get", func(context.Context) (int, error) {
+		calls.Add(1)
+		return 0, errors.New("not found")
+	})
+
+	assert.EqualError(t, err, "not found")
+	assert.Equal(t, int32(1), calls.Load(), "hedging is not a retry")
+}
+
+func TestDo_CallerCanceled(t *testing.T) {
+	h := New(time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := Do(ctx, h, "get", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestOperation_P95(t *testing.T) {
+	op := &operation{}
+	for i := 1; i <= minSamples+2*refreshEvery; i++ { // p95 is recomputed on the last sample
+		op.record(time.Duration(i) * time.Millisecond)
+	}
+
+	assert.Equal(t, 115*time.Millisecond, op.delay(0))
+}
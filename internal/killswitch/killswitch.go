@@ -0,0 +1,172 @@
+// Package killswitch pauses every write of the service at once for maintenance or an
+// incident: while the switch is engaged, requests other than reads are refused with 503
+// and a maintenance message, and reads and health checks keep working. The switch is
+// held in process, or in Redis so that toggling it on one replica pauses them all.
+package killswitch
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// Store persists the switch state shared by every replica.
+type Store interface {
+	// Load returns the stored state, or nil if the switch was never toggled.
+	Load(ctx context.Context) (*model.KillSwitch, error)
+	// Save stores state.
+	Save(ctx context.Context, state model.KillSwitch) error
+}
+
+// Stats is a snapshot of the switch state and counters.
+type Stats struct {
+	Engaged     bool  `json:"engaged"`
+	Rejected    int64 `json:"rejected"`     // Writes refused while engaged
+	StoreErrors int64 `json:"store_errors"` // Failed loads and saves; the last known state stays in force
+}
+
+// Switch is the global kill switch. It is safe for concurrent use.
+type Switch struct {
+	message string // Default maintenance message
+	store   Store
+	now     func() time.Time
+
+	state atomic.Pointer[model.KillSwitch]
+
+	rejected    atomic.Int64
+	storeErrors atomic.Int64
+}
+
+// New creates a Switch, engaged with message if engaged is set. With a store, the
+// stored state replaces it once Run first loads it.
+func New(engaged bool, message string, store Store) *Switch {
+	s := &Switch{message: message, store: store, now: time.Now}
+	s.state.Store(&model.KillSwitch{Engaged: engaged, Message: s.messageFor(engaged, "")})
+	return s
+}
+
+// Stats returns the switch state and counters.
+func (s *Switch) Stats() Stats {
+	return Stats{
+		Engaged:     s.state.Load().Engaged,
+		Rejected:    s.rejected.Load(),
+		StoreErrors: s.storeErrors.Load(),
+	}
+}
+
+// State returns the switch state.
+func (s *Switch) State() model.KillSwitch {
+	return *s.state.Load()
+}
+
+// Set engages or disengages the switch, refusing writes with message (or the default
+// one if empty) while engaged, and returns the new state. With a store the state is
+// saved first, so a failed save leaves the switch as it was.
+func (s *Switch) Set(ctx context.Context, engaged bool, message string) (model.KillSwitch, error) {
+	now := s.now().UTC()
+	state := model.KillSwitch{Engaged: engaged, Message: s.messageFor(engaged, message), Since: &now}
+	if s.store != nil {
+		if err := s.store.Save(ctx, state); err != nil {
+			s.storeErrors.Add(1)
+			return model.KillSwitch{}, err
+		}
+	}
+	s.state.Store(&state)
+	return state, nil
+}
+
+// messageFor returns the message to refuse writes with: none while disengaged.
+func (s *Switch) messageFor(engaged bool, message string) string {
+	switch {
+	case !engaged:
+		return ""
+	case message == "":
+		return s.message
+	}
+	return message
+}
+
+// Refresh loads the state from the store, keeping the current one if the store fails
+// or was never written to. It does nothing without a store.
+func (s *Switch) Refresh(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	state, err := s.store.Load(ctx)
+	if err != nil {
+		s.storeErrors.Add(1)
+		return err
+	}
+	if state != nil {
+		if state.Engaged && state.Message == "" {
+			state.Message = s.message
+		}
+		s.state.Store(state)
+	}
+	return nil
+}
+
+// Run refreshes the state now and then every interval until ctx is cancelled, so a
+// switch toggled on another replica takes effect here within interval.
+func (s *Switch) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failing := false
+	for {
+		engaged := s.state.Load().Engaged
+		err := s.Refresh(ctx)
+		switch {
+		case err != nil && !failing && ctx.Err() == nil:
+			log.Warn().Err(err).Bool("engaged", engaged).Msg("kill switch store unavailable, keeping last state")
+		case err == nil && failing:
+			log.Info().Msg("kill switch store available again")
+		}
+		failing = err != nil
+		if now := s.state.Load().Engaged; now != engaged {
+			log.Warn().Bool("engaged", now).Msg("kill switch toggled")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Handler returns middleware refusing writes (requests other than GET, HEAD and
+// OPTIONS) with 503 and the maintenance message while the switch is engaged, except to
+// the exempt paths so the switch can still be turned off.
+func (s *Switch) Handler(exempt ...string) fiber.Handler {
+	exempted := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		exempted[normalizePath(path)] = true
+	}
+	return func(c *fiber.Ctx) error {
+		state := s.state.Load()
+		if !state.Engaged {
+			return c.Next()
+		}
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+		if exempted[normalizePath(c.Path())] {
+			return c.Next()
+		}
+		s.rejected.Add(1)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": state.Message})
+	}
+}
+
+// normalizePath makes path match the way routes do: case-insensitively and with or
+// without a trailing slash.
+func normalizePath(path string) string {
+	return strings.TrimSuffix(strings.ToLower(path), "/")
+}
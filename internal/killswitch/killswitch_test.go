@@ -0,0 +1,121 @@
+package killswitch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// memoryStore is a Store holding the state in memory, failing while err is set.
+type memoryStore struct {
+	state *model.KillSwitch
+	err   error
+}
+
+func (m *memoryStore) Load(ctx context.Context) (*model.KillSwitch, error) {
+	return m.state, m.err
+}
+
+func (m *memoryStore) Save(ctx context.Context, state model.KillSwitch) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.state = &state
+	return nil
+}
+
+func testApp(s *Switch) *fiber.App {
+	app := fiber.New()
+	app.Use(s.Handler("/api/admin/killswitch"))
+	ok := func(c *fiber.Ctx) error { return c.SendString("ok") }
+	app.Get("/api/coupons/:name", ok)
+	app.Post("/api/coupons/claim", ok)
+	app.Put("/api/admin/killswitch", ok)
+	return app
+}
+
+func request(t *testing.T, app *fiber.App, method, path string) (int, string) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(method, path, nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestSwitch_Handler(t *testing.T) {
+	s := New(false, "writes are paused for maintenance", nil)
+	app := testApp(s)
+
+	status, _ := request(t, app, http.MethodPost, "/api/coupons/claim")
+	assert.Equal(t, http.StatusOK, status, "writes pass while disengaged")
+
+	_, err := s.Set(context.Background(), true, "")
+	require.NoError(t, err)
+
+	status, body := request(t, app, http.MethodPost, "/api/coupons/claim")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.JSONEq(t, `{"error": "writes are paused for maintenance"}`, body)
+	status, _ = request(t, app, http.MethodGet, "/api/coupons/PROMO")
+	assert.Equal(t, http.StatusOK, status, "reads pass")
+	status, _ = request(t, app, http.MethodPut, "/API/admin/killswitch/")
+	assert.Equal(t, http.StatusOK, status, "the switch can be turned off")
+	assert.Equal(t, Stats{Engaged: true, Rejected: 1}, s.Stats())
+}
+
+func TestSwitch_Set(t *testing.T) {
+	s := New(true, "writes are paused for maintenance", nil)
+	assert.Equal(t, model.KillSwitch{Engaged: true, Message: "writes are paused for maintenance"}, s.State())
+
+	state, err := s.Set(context.Background(), true, "back at 10:00 UTC")
+	require.NoError(t, err)
+	assert.Equal(t, "back at 10:00 UTC", state.Message)
+	assert.NotNil(t, state.Since)
+
+	state, err = s.Set(context.Background(), false, "ignored")
+	require.NoError(t, err)
+	assert.False(t, state.Engaged)
+	assert.Empty(t, state.Message)
+	assert.Equal(t, state, s.State())
+}
+
+func TestSwitch_Store(t *testing.T) {
+	store := &memoryStore{}
+	s := New(false, "paused", store)
+
+	require.NoError(t, s.Refresh(context.Background()))
+	assert.False(t, s.State().Engaged, "an empty store keeps the initial state")
+
+	_, err := s.Set(context.Background(), true, "")
+	require.NoError(t, err)
+	assert.Equal(t, &model.KillSwitch{Engaged: true, Message: "paused", Since: s.State().Since}, store.state)
+
+	// Toggled on another replica
+	store.state = &model.KillSwitch{Engaged: false}
+	require.NoError(t, s.Refresh(context.Background()))
+	assert.False(t, s.State().Engaged)
+}
+
+func TestSwitch_StoreErrors(t *testing.T) {
+	store := &memoryStore{state: &model.KillSwitch{Engaged: true}}
+	s := New(false, "paused", store)
+	require.NoError(t, s.Refresh(context.Background()))
+	assert.Equal(t, "paused", s.State().Message, "a stored state without a message gets the default one")
+
+	store.err = errors.New("connection refused")
+	assert.Error(t, s.Refresh(context.Background()))
+	assert.True(t, s.State().Engaged, "the last state stays in force")
+
+	_, err := s.Set(context.Background(), false, "")
+	assert.ErrorIs(t, err, store.err)
+	assert.True(t, s.State().Engaged, "a failed save leaves the switch as it was")
+	assert.Equal(t, int64(2), s.Stats().StoreErrors)
+}
@@ -0,0 +1,68 @@
+package killswitch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redis"
+)
+
+// Key is the Redis key holding the switch state as JSON, e.g.
+// {"engaged":true,"message":"back at 10:00 UTC"}.
+const Key = "kill_switch"
+
+// RedisStore keeps the switch state in Redis. It is safe for concurrent use.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a RedisStore for the server at rawURL
+// (redis://[user:password@]host:port[/db]) whose commands time out after timeout.
+// It does not connect until first used.
+func NewRedisStore(rawURL string, timeout time.Duration) (*RedisStore, error) {
+	client, err := redis.New(rawURL, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Close closes idle connections.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// Load implements Store.
+func (s *RedisStore) Load(ctx context.Context) (*model.KillSwitch, error) {
+	reply, err := s.client.Do(ctx, "GET", Key)
+	if err != nil {
+		return nil, fmt.Errorf("load kill switch: %w", err)
+	}
+	if reply == nil {
+		return nil, nil
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("load kill switch: unexpected reply %v", reply)
+	}
+	var state model.KillSwitch
+	if err := json.Unmarshal([]byte(value), &state); err != nil {
+		return nil, fmt.Errorf("load kill switch: %w", err)
+	}
+	return &state, nil
+}
+
+// Save implements Store.
+func (s *RedisStore) Save(ctx context.Context, state model.KillSwitch) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("save kill switch: %w", err)
+	}
+	if _, err := s.client.Do(ctx, "SET", Key, string(value)); err != nil {
+		return fmt.Errorf("save kill switch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package killswitch
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redis/redistest"
+)
+
+func TestRedisStore(t *testing.T) {
+	var stored string
+	f := redistest.NewServer(t, func(args []string) string {
+		switch {
+		case args[0] == "SET":
+			stored = args[2]
+			return "+OK\r\n"
+		case stored == "":
+			return "$-1\r\n"
+		}
+		return "$" + strconv.Itoa(len(stored)) + "\r\n" + stored + "\r\n"
+	})
+	s, err := NewRedisStore(f.URL(), time.Second)
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	state, err := s.Load(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, state, "never toggled")
+
+	since := time.Date(2024, 11, 29, 9, 0, 0, 0, time.UTC)
+	want := model.KillSwitch{Engaged: true, Message: "back at 10:00 UTC", Since: &since}
+	require.NoError(t, s.Save(context.Background(), want))
+	state, err = s.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &want, state)
+	assert.Equal(t, []string{"GET", Key}, f.Commands()[0])
+}
+
+func TestRedisStore_InvalidValue(t *testing.T) {
+	f := redistest.NewServer(t, func([]string) string { return "$2\r\non\r\n" })
+	s, err := NewRedisStore(f.URL(), time.Second)
+	require.NoError(t, err)
+
+	_, err = s.Load(context.Background())
+	assert.ErrorContains(t, err, "load kill switch")
+}
@@ -0,0 +1,248 @@
+// Package lockdiag captures what a claim waiting long for its coupon's row lock waits
+// on. When a wait passes a threshold, the pg_stat_activity and pg_locks rows of the
+// waiting backend and of the backends blocking it, directly or through others, are
+// read on another connection, logged and kept for GET /api/admin/diagnostics/locks, so
+// convoys on a hot coupon can be debugged during an incident without psql access.
+// Dumps carry the request ID of the waiting claim, and mark the other backends running
+// a claim of this process that waits for a lock, to find them in the access log and
+// the claim recordings.
+package lockdiag
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// snapshotTimeout bounds taking a snapshot, which needs a connection of a pool the
+// convoy may have exhausted.
+const snapshotTimeout = 2 * time.Second
+
+// Backend is the pg_stat_activity row of a backend in a snapshot.
+type Backend struct {
+	PID             uint32     `json:"pid"`
+	BlockedBy       []uint32   `json:"blocked_by"`           // Backends it waits for, from pg_blocking_pids
+	RequestID       string     `json:"request_id,omitempty"` // Claim of this process waiting for a lock in it
+	ApplicationName string     `json:"application_name"`
+	State           string     `json:"state"`
+	WaitEventType   string     `json:"wait_event_type,omitempty"`
+	WaitEvent       string     `json:"wait_event,omitempty"`
+	XactStart       *time.Time `json:"xact_start"`
+	QueryStart      *time.Time `json:"query_start"`
+	Query           string     `json:"query"` // Running statement, or the last one when idle in transaction
+}
+
+// Lock is a pg_locks row of a backend in a snapshot.
+type Lock struct {
+	PID           uint32 `json:"pid"`
+	LockType      string `json:"locktype"`
+	Relation      string `json:"relation,omitempty"`
+	Tuple         string `json:"tuple,omitempty"`          // page,tuple of tuple locks
+	TransactionID string `json:"transaction_id,omitempty"` // Of transactionid locks
+	Mode          string `json:"mode"`
+	Granted       bool   `json:"granted"`
+}
+
+// Snapshot is the state of a waiting backend and of those blocking it.
+type Snapshot struct {
+	Backends []Backend `json:"backends"`
+	Locks    []Lock    `json:"locks"`
+}
+
+// Dump is the snapshot taken for one claim waiting long for a coupon row lock.
+type Dump struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	Coupon    string    `json:"coupon"` // Redacted like in logs
+	PID       uint32    `json:"pid"`
+	WaitedMs  int64     `json:"waited_ms"` // How long the claim had waited when the snapshot was taken
+	Snapshot
+	Error string `json:"error,omitempty"` // Set when no snapshot could be taken
+}
+
+// Stats is a snapshot of Diagnoser counters.
+type Stats struct {
+	Size     int   `json:"size"` // Dumps held
+	Capacity int   `json:"capacity"`
+	Waits    int64 `json:"waits"`   // Lock waits that passed the threshold
+	Dumps    int64 `json:"dumps"`   // Snapshots taken, including overwritten ones
+	Skipped  int64 `json:"skipped"` // Waits passing the threshold while a snapshot was being taken
+	Failed   int64 `json:"failed"`  // Snapshots that could not be taken
+}
+
+// Diagnoser dumps the lock state of claims whose coupon row lock wait passes a
+// threshold, keeping the most recent dumps. One snapshot is taken at a time: waits
+// passing the threshold meanwhile, usually of the same convoy, are only counted. It is
+// safe for concurrent use.
+type Diagnoser struct {
+	snapshot  func(ctx context.Context, pid uint32) (Snapshot, error)
+	threshold time.Duration
+	capturing atomic.Bool
+
+	mu      sync.Mutex
+	waiting map[uint32]string // Request IDs of the claims waiting for a lock, by backend
+	entries []Dump            // Ring buffer; next is the oldest once full
+	next    int
+	full    bool
+
+	waits   atomic.Int64
+	dumps   atomic.Int64
+	skipped atomic.Int64
+	failed  atomic.Int64
+}
+
+// New creates a Diagnoser taking a snapshot of a waiting backend with snapshot once its
+// wait passes threshold, and keeping the size most recent dumps.
+func New(snapshot func(ctx context.Context, pid uint32) (Snapshot, error), threshold time.Duration, size int) *Diagnoser {
+	return &Diagnoser{
+		snapshot:  snapshot,
+		threshold: threshold,
+		waiting:   make(map[uint32]string),
+		entries:   make([]Dump, size),
+	}
+}
+
+// Stats returns the diagnoser's counters.
+func (d *Diagnoser) Stats() Stats {
+	d.mu.Lock()
+	size := d.next
+	if d.full {
+		size = len(d.entries)
+	}
+	d.mu.Unlock()
+	return Stats{
+		Size:     size,
+		Capacity: len(d.entries),
+		Waits:    d.waits.Load(),
+		Dumps:    d.dumps.Load(),
+		Skipped:  d.skipped.Load(),
+		Failed:   d.failed.Load(),
+	}
+}
+
+// Dumps returns the dumps held, newest first.
+func (d *Diagnoser) Dumps() []Dump {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := d.next
+	if d.full {
+		n = len(d.entries)
+	}
+	out := make([]Dump, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, d.entries[(d.next-i+len(d.entries))%len(d.entries)])
+	}
+	return out
+}
+
+// Watch is called as the claim in ctx starts waiting for the row lock of coupon in
+// backend pid; it returns the function to call once the claim has the lock or gave up.
+// A snapshot is taken if that takes longer than the threshold.
+func (d *Diagnoser) Watch(ctx context.Context, pid uint32, coupon string) (stop func()) {
+	requestID := requestIDFrom(ctx)
+	start := time.Now()
+	d.mu.Lock()
+	d.waiting[pid] = requestID
+	d.mu.Unlock()
+
+	timer := time.AfterFunc(d.threshold, func() { d.capture(pid, requestID, coupon, start) })
+	return func() {
+		timer.Stop()
+		d.mu.Lock()
+		delete(d.waiting, pid)
+		d.mu.Unlock()
+	}
+}
+
+// capture takes, logs and keeps the snapshot of a claim whose wait passed the threshold.
+func (d *Diagnoser) capture(pid uint32, requestID, coupon string, start time.Time) {
+	d.waits.Add(1)
+	if !d.capturing.CompareAndSwap(false, true) {
+		d.skipped.Add(1)
+		return
+	}
+	defer d.capturing.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+	snapshot, err := d.snapshot(ctx, pid)
+	dump := Dump{
+		Time:      time.Now().UTC(),
+		RequestID: requestID,
+		Coupon:    redact.Value(coupon),
+		PID:       pid,
+		WaitedMs:  time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		d.failed.Add(1)
+		dump.Snapshot = Snapshot{Backends: []Backend{}, Locks: []Lock{}}
+		dump.Error = redact.Error(err, coupon)
+		d.add(dump)
+		log.Warn().
+			Str("error", dump.Error).
+			Str("request_id", requestID).
+			Str("coupon_name", dump.Coupon).
+			Uint32("pid", pid).
+			Int64("waited_ms", dump.WaitedMs).
+			Msg("claim waiting long for coupon lock, failed to take lock snapshot")
+		return
+	}
+
+	d.dumps.Add(1)
+	d.mu.Lock()
+	for i, b := range snapshot.Backends {
+		snapshot.Backends[i].RequestID = d.waiting[b.PID]
+	}
+	d.mu.Unlock()
+	dump.Snapshot = snapshot
+	d.add(dump)
+	log.Warn().
+		Str("request_id", requestID).
+		Str("coupon_name", dump.Coupon).
+		Uint32("pid", pid).
+		Int64("waited_ms", dump.WaitedMs).
+		Interface("backends", snapshot.Backends).
+		Interface("locks", snapshot.Locks).
+		Msg("claim waiting long for coupon lock")
+}
+
+func (d *Diagnoser) add(dump Dump) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries[d.next] = dump
+	d.next = (d.next + 1) % len(d.entries)
+	if d.next == 0 {
+		d.full = true
+	}
+}
+
+// requestIDKey is the context key of the request ID passed on by Handler.
+type requestIDKey struct{}
+
+// withRequestID returns ctx carrying the request ID id.
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFrom returns the request ID in ctx, or "" if there is none.
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Handler returns middleware passing the request ID (X-Request-ID, set by the requestid
+// middleware) on in the request's context, so that dumps of its lock waits carry it.
+func (d *Diagnoser) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := strings.Clone(c.GetRespHeader(fiber.HeaderXRequestID))
+		c.SetUserContext(withRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
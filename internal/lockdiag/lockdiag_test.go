@@ -0,0 +1,126 @@
+package lockdiag
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockedSnapshot reports pid blocked by backend 7, which runs another waiting claim.
+func blockedSnapshot(_ context.Context, pid uint32) (Snapshot, error) {
+	return Snapshot{
+		Backends: []Backend{
+			{PID: 7, BlockedBy: []uint32{}, State: "idle in transaction"},
+			{PID: pid, BlockedBy: []uint32{7}, State: "active", WaitEventType: "Lock", WaitEvent: "transactionid"},
+		},
+		Locks: []Lock{{PID: pid, LockType: "transactionid", TransactionID: "812", Mode: "ShareLock"}},
+	}, nil
+}
+
+func TestDiagnoser_DumpsLongWaits(t *testing.T) {
+	d := New(blockedSnapshot, 10*time.Millisecond, 5)
+	defer d.Watch(withRequestID(context.Background(), "req-holder"), 7, "PROMO")()
+	require.Eventually(t, func() bool { return d.Stats().Dumps == 1 }, time.Second, time.Millisecond)
+
+	stop := d.Watch(withRequestID(context.Background(), "req-waiter"), 42, "PROMO")
+	require.Eventually(t, func() bool { return d.Stats().Dumps == 2 }, time.Second, time.Millisecond)
+	stop()
+
+	dumps := d.Dumps()
+	require.Len(t, dumps, 2)
+	dump := dumps[0]
+	assert.Equal(t, "req-waiter", dump.RequestID)
+	assert.Equal(t, "PROMO", dump.Coupon)
+	assert.Equal(t, uint32(42), dump.PID)
+	assert.GreaterOrEqual(t, dump.WaitedMs, int64(10))
+	assert.Empty(t, dump.Error)
+	require.Len(t, dump.Backends, 2)
+	assert.Equal(t, "req-holder", dump.Backends[0].RequestID, "other waiting claims are marked")
+	assert.Equal(t, "req-waiter", dump.Backends[1].RequestID)
+	assert.Len(t, dump.Locks, 1)
+}
+
+func TestDiagnoser_IgnoresShortWaits(t *testing.T) {
+	d := New(blockedSnapshot, 50*time.Millisecond, 5)
+
+	d.Watch(context.Background(), 42, "PROMO")()
+	time.Sleep(80 * time.Millisecond)
+
+	assert.Empty(t, d.Dumps())
+	assert.Equal(t, Stats{Capacity: 5}, d.Stats())
+}
+
+func TestDiagnoser_OneSnapshotAtATime(t *testing.T) {
+	release := make(chan struct{})
+	snapshot := func(ctx context.Context, pid uint32) (Snapshot, error) {
+		<-release
+		return blockedSnapshot(ctx, pid)
+	}
+	d := New(snapshot, time.Millisecond, 5)
+
+	defer d.Watch(context.Background(), 1, "PROMO")()
+	require.Eventually(t, func() bool { return d.Stats().Waits == 1 }, time.Second, time.Millisecond)
+	defer d.Watch(context.Background(), 2, "PROMO")()
+	require.Eventually(t, func() bool { return d.Stats().Skipped == 1 }, time.Second, time.Millisecond)
+	close(release)
+
+	require.Eventually(t, func() bool { return d.Stats().Dumps == 1 }, time.Second, time.Millisecond)
+	assert.Len(t, d.Dumps(), 1)
+}
+
+func TestDiagnoser_SnapshotError(t *testing.T) {
+	snapshot := func(context.Context, uint32) (Snapshot, error) {
+		return Snapshot{}, errors.New("acquire connection: context deadline exceeded")
+	}
+	d := New(snapshot, time.Millisecond, 5)
+
+	defer d.Watch(context.Background(), 42, "PROMO")()
+	require.Eventually(t, func() bool { return d.Stats().Failed == 1 }, time.Second, time.Millisecond)
+
+	dumps := d.Dumps()
+	require.Len(t, dumps, 1)
+	assert.Equal(t, "acquire connection: context deadline exceeded", dumps[0].Error)
+	assert.Empty(t, dumps[0].Backends)
+}
+
+func TestDiagnoser_KeepsMostRecent(t *testing.T) {
+	d := New(blockedSnapshot, time.Millisecond, 2)
+
+	for pid := uint32(1); pid <= 3; pid++ {
+		stop := d.Watch(context.Background(), pid, "PROMO")
+		require.Eventually(t, func() bool { return d.Stats().Dumps == int64(pid) }, time.Second, time.Millisecond)
+		stop()
+	}
+
+	dumps := d.Dumps()
+	require.Len(t, dumps, 2)
+	assert.Equal(t, uint32(3), dumps[0].PID, "newest first")
+	assert.Equal(t, uint32(2), dumps[1].PID)
+	assert.Equal(t, 2, d.Stats().Size)
+}
+
+func TestDiagnoser_Handler(t *testing.T) {
+	d := New(blockedSnapshot, time.Second, 1)
+	app := fiber.New()
+	var got string
+	app.Post("/claim", requestid.New(), d.Handler(), func(c *fiber.Ctx) error {
+		got = requestIDFrom(c.UserContext())
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/claim", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "req-1")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-1", got)
+}
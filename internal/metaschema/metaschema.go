@@ -0,0 +1,61 @@
+// Package metaschema validates coupon metadata documents against a JSON Schema
+// (drafts 4, 6 and 7) loaded at startup.
+package metaschema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// maxReported bounds the violations listed in a validation error.
+const maxReported = 3
+
+// Schema is a compiled JSON Schema. It is safe for concurrent use.
+type Schema struct {
+	schema *gojsonschema.Schema
+}
+
+// Load compiles the JSON Schema in the file at path.
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read metadata schema: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse compiles the JSON Schema document data.
+func Parse(data []byte) (*Schema, error) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return nil, fmt.Errorf("compile metadata schema: %w", err)
+	}
+	return &Schema{schema: schema}, nil
+}
+
+// Validate checks the JSON document doc against the schema. The error lists the first
+// violations, e.g. "(root): campaign is required; budget: must be >= 0".
+func (s *Schema) Validate(doc []byte) error {
+	result, err := s.schema.Validate(gojsonschema.NewBytesLoader(doc))
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	violations := result.Errors()
+	reported := violations[:min(len(violations), maxReported)]
+	msgs := make([]string, 0, len(reported)+1)
+	for _, v := range reported {
+		msgs = append(msgs, v.Field()+": "+v.Description())
+	}
+	if len(violations) > maxReported {
+		msgs = append(msgs, fmt.Sprintf("and %d more", len(violations)-maxReported))
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
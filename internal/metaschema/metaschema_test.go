@@ -0,0 +1,78 @@
+package metaschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["campaign"],
+	"properties": {
+		"campaign": {"type": "string"},
+		"budget": {"type": "integer", "minimum": 0}
+	}
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		doc  string
+		want []string // Substrings of the error; none when doc is valid
+	}{
+		{"valid", `{"campaign": "summer", "budget": 100}`, nil},
+		{"extra properties", `{"campaign": "summer", "owner": "growth"}`, nil},
+		{"missing required", `{"budget": 100}`, []string{"(root): campaign is required"}},
+		{"several violations", `{"campaign": 1, "budget": -1}`,
+			[]string{"campaign: Invalid type", "budget: Must be greater than or equal to 0"}},
+		{"malformed", `{"campaign":`, []string{"invalid JSON"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tt.doc))
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tt.want {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestSchema_Validate_BoundsReportedViolations(t *testing.T) {
+	schema, err := Parse([]byte(`{"type": "object", "required": ["a", "b", "c", "d", "e"]}`))
+	require.NoError(t, err)
+
+	err = schema.Validate([]byte(`{}`))
+
+	require.Error(t, err)
+	assert.Equal(t, "(root): a is required; (root): b is required; (root): c is required; and 2 more", err.Error())
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(valid, []byte(testSchema), 0o600))
+	broken := filepath.Join(dir, "broken.json")
+	require.NoError(t, os.WriteFile(broken, []byte(`{"type": 5}`), 0o600))
+
+	_, err := Load(valid)
+	assert.NoError(t, err)
+
+	_, err = Load(broken)
+	assert.ErrorContains(t, err, "compile metadata schema")
+
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "read metadata schema")
+}
@@ -1,31 +1,660 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
 
 // Coupon represents a coupon in the system
 type Coupon struct {
-	Name            string    `json:"name"`
-	Amount          int       `json:"amount"`
-	RemainingAmount int       `json:"remaining_amount"`
-	CreatedAt       time.Time `json:"-"` // Not exposed in API
+	Name            string          `json:"name"`
+	Amount          int             `json:"amount"`
+	RemainingAmount int             `json:"remaining_amount"`
+	Tags            []string        `json:"tags"`
+	CreatedAt       time.Time       `json:"-"`                         // Not exposed in API
+	Channels        []ChannelQuota  `json:"channels,omitempty"`        // Empty when the coupon is not partitioned
+	OverflowAt      *time.Time      `json:"overflow_at,omitempty"`     // When partitions may borrow leftover stock
+	ClaimSequence   int             `json:"-"`                         // Sequence assigned to the most recent claim
+	Tiers           []Tier          `json:"tiers,omitempty"`           // Bonus tiers in claim order
+	Disabled        bool            `json:"disabled"`                  // Disabled coupons reject claims
+	CaptchaRequired bool            `json:"captcha_required"`          // Claims must pass a captcha check
+	Metadata        json.RawMessage `json:"metadata,omitempty"`        // JSON object; nil when the coupon has none
+	LowStockPercent int             `json:"low_stock_percent"`         // Low stock watermark (% of amount remaining); 0 for none
+	Parent          string          `json:"parent,omitempty"`          // Coupon whose stock is the pooled budget of this one; empty for none
+	Regions         []RegionQuota   `json:"regions,omitempty"`         // Claims per region, with the region's quota if it has one
+	Stats           *CouponStats    `json:"-"`                         // Claim rollup; nil until the first claim
+	Unlimited       bool            `json:"unlimited"`                 // No stock cap; Amount and RemainingAmount are 0
+	ClaimRetention  *ClaimRetention `json:"claim_retention,omitempty"` // nil when claims are kept indefinitely
+	Visibility      string          `json:"visibility,omitempty"`      // One of the Visibility constants
+	HighProfile     bool            `json:"high_profile,omitempty"`    // Claims share the high-profile pool quota
+	Prerequisite    string          `json:"prerequisite,omitempty"`    // Coupon users must have claimed first; empty for none
+	ExclusionGroup  string          `json:"exclusion_group,omitempty"` // Users claim at most one coupon of the group; empty for none
+}
+
+// Coupon visibilities. Coupons other than public ones are left out of listings and read
+// as not found, so the names and claims of secret campaigns cannot be harvested; they
+// are still claimed by exact name.
+const (
+	VisibilityPublic   = "public"   // Listed and readable
+	VisibilityUnlisted = "unlisted" // Claimable by anyone knowing the name
+	VisibilityPrivate  = "private"  // Claimable only through a signed claim grant
+)
+
+// Listed reports whether a coupon of the given visibility is listed and readable.
+func Listed(visibility string) bool {
+	return visibility != VisibilityUnlisted && visibility != VisibilityPrivate
+}
+
+// Claim retention actions, applied to a coupon's claims once older than its retention.
+const (
+	RetentionPurge     = "purge"     // Delete the claims
+	RetentionAnonymize = "anonymize" // Replace their user IDs with per-claim pseudonyms
+)
+
+// ClaimRetention is how long a coupon's claims are kept before the claim retention job
+// applies Action to them.
+type ClaimRetention struct {
+	Days   int    `json:"days" validate:"required,gte=1,lte=3650"`
+	Action string `json:"action" validate:"required,oneof=purge anonymize"`
+}
+
+// CouponClaimRetention is the claim retention of one coupon.
+type CouponClaimRetention struct {
+	CouponName string
+	ClaimRetention
+}
+
+// Tier is a bonus tier covering the next Size claims after the preceding tiers,
+// e.g. [{gold 100} {silver 900}] gives claims 1-100 gold and 101-1000 silver.
+type Tier struct {
+	Name string `json:"name" validate:"required,notblank,max=32"`
+	Size int    `json:"size" validate:"required,gte=1"`
+}
+
+// ChannelQuota is one channel's share of a partitioned coupon's stock
+type ChannelQuota struct {
+	Channel   string `json:"channel"`
+	Quota     int    `json:"quota"`
+	Remaining int    `json:"remaining"`
+}
+
+// RegionQuota counts a coupon's claims from one region. Quota caps them; regions
+// without a quota are only counted.
+type RegionQuota struct {
+	Region  string `json:"region"`
+	Quota   int    `json:"quota,omitempty"` // 0 when the region has no quota
+	Claimed int    `json:"claimed"`
+}
+
+// CouponStats is the claim rollup of a coupon, maintained in the claim transaction so
+// reads never count claims. Claims are bucketed by clock hour: ClaimsThisHour counts
+// those in the hour starting at HourStart, ClaimsPrevHour those in the hour before.
+type CouponStats struct {
+	TotalClaims    int        `json:"total_claims"`
+	LastClaimAt    *time.Time `json:"last_claim_at"`
+	HourStart      time.Time  `json:"hour_start"`
+	ClaimsThisHour int        `json:"claims_this_hour"`
+	ClaimsPrevHour int        `json:"claims_prev_hour"`
+}
+
+// ClaimStats is the claim rollup of a coupon in API responses.
+type ClaimStats struct {
+	TotalClaims    int        `json:"total_claims"`
+	LastClaimAt    *time.Time `json:"last_claim_at,omitempty"`
+	ClaimsLastHour int        `json:"claims_last_hour"` // Estimated from hourly buckets
+}
+
+// Claim represents a single user's claim of a coupon
+type Claim struct {
+	UserID     string
+	CouponName string
+	Channel    string // Empty when the claim did not specify a channel
+	Region     string // Empty when the claim did not specify a region
+	Sequence   int    // 1-based claim order within the coupon
+	Tier       string // Empty when the claim fell outside all tiers
+	CreatedAt  time.Time
 }
 
 // CouponResponse is the API response DTO for GET /api/coupons/:name
 type CouponResponse struct {
+	Name            string          `json:"name"`
+	Amount          int             `json:"amount"`
+	RemainingAmount int             `json:"remaining_amount"`
+	ClaimedBy       []string        `json:"claimed_by"`
+	ClaimedCount    int             `json:"claimed_count"` // Users claimed_by would list without truncation
+	Truncated       bool            `json:"truncated"`     // claimed_by lists only the first claimants (COUPON_CLAIMED_BY_MAX)
+	Tags            []string        `json:"tags"`
+	Channels        []ChannelQuota  `json:"channels,omitempty"`
+	OverflowAt      *time.Time      `json:"overflow_at,omitempty"`
+	Tiers           []Tier          `json:"tiers,omitempty"`
+	Disabled        bool            `json:"disabled,omitempty"`
+	CaptchaRequired bool            `json:"captcha_required,omitempty"`
+	Metadata        json.RawMessage `json:"metadata,omitempty"`
+	LowStockPercent int             `json:"low_stock_percent,omitempty"`
+	LowStock        bool            `json:"low_stock,omitempty"` // Remaining stock is at or below LowStockPercent
+	Parent          string          `json:"parent,omitempty"`
+	Regions         []RegionQuota   `json:"regions,omitempty"` // Claims by region
+	Stats           ClaimStats      `json:"stats"`
+	Unlimited       bool            `json:"unlimited,omitempty"` // Amount and remaining_amount are 0; see Claimed
+	Claimed         *int            `json:"claimed,omitempty"`   // Claims of an unlimited coupon; nil for others
+	ClaimRetention  *ClaimRetention `json:"claim_retention,omitempty"`
+	Status          string          `json:"status"` // CouponStatusActive, CouponStatusExhausted or CouponStatusDisabled
+	Visibility      string          `json:"visibility,omitempty"`
+	HighProfile     bool            `json:"high_profile,omitempty"`
+	Prerequisite    string          `json:"prerequisite,omitempty"`
+	ExclusionGroup  string          `json:"exclusion_group,omitempty"`
+}
+
+// CouponSummary is a single entry in the GET /api/coupons listing.
+// It omits claimed_by to keep listings cheap regardless of claim volume.
+type CouponSummary struct {
 	Name            string   `json:"name"`
 	Amount          int      `json:"amount"`
 	RemainingAmount int      `json:"remaining_amount"`
-	ClaimedBy       []string `json:"claimed_by"`
+	Tags            []string `json:"tags"`
+	Disabled        bool     `json:"disabled,omitempty"`
+	LowStock        bool     `json:"low_stock,omitempty"`
+	Unlimited       bool     `json:"unlimited,omitempty"`
+	Claimed         *int     `json:"claimed,omitempty"` // Claims of an unlimited coupon; nil for others
+	Status          string   `json:"status,omitempty"`  // As in CouponResponse
+}
+
+// Coupon statuses, derived from a coupon's state. A disabled coupon is disabled
+// whatever its stock; an unlimited one is never exhausted.
+const (
+	CouponStatusActive    = "active"    // Claimable
+	CouponStatusExhausted = "exhausted" // No remaining stock
+	CouponStatusDisabled  = "disabled"  // Claims rejected until re-enabled
+)
+
+// CouponListResponse is the API response DTO for GET /api/coupons, ordered by name
+type CouponListResponse = Page[CouponSummary]
+
+// CouponFilter holds optional filters for listing coupons
+type CouponFilter struct {
+	Tag    string
+	Status string // One of the CouponStatus constants; empty for any
+	After  string // Name the page starts after; empty for the first page
+	Limit  int
 }
 
 // CreateCouponRequest is the DTO for creating a coupon
 type CreateCouponRequest struct {
-	Name   string `json:"name" validate:"required,notblank,max=255"`
-	Amount *int   `json:"amount" validate:"required,gte=1"`
+	Name   string   `json:"name" validate:"required,notblank,max=255"`
+	Amount *int     `json:"amount" validate:"required_unless=Unlimited true,excluded_if=Unlimited true,omitempty,gte=1"`
+	Tags   []string `json:"tags" validate:"omitempty,max=20,dive,notblank,max=64"`
+
+	// Unlimited creates a coupon without a stock cap, whose amount must be omitted (or
+	// null). Each user may still claim it once; claims are counted instead of taking stock.
+	Unlimited bool `json:"unlimited"`
+
+	// Channels maps channel name to its percentage of stock (must sum to 100).
+	Channels   map[string]int `json:"channels" validate:"excluded_if=Unlimited true,omitempty,max=10,dive,keys,notblank,max=64,endkeys,gte=1,lte=100"`
+	OverflowAt *time.Time     `json:"overflow_at"`
+
+	// Tiers assigns bonus tiers by claim order; sizes may not exceed amount in total.
+	Tiers []Tier `json:"tiers" validate:"omitempty,max=10,dive"`
+
+	// CaptchaRequired makes claims carry a captcha token that passes verification.
+	CaptchaRequired bool `json:"captcha_required"`
+
+	// Metadata is an arbitrary JSON object stored with the coupon, validated against
+	// the metadata schema (COUPON_METADATA_SCHEMA) when one is configured.
+	Metadata json.RawMessage `json:"metadata" validate:"omitempty,max=16384"`
+
+	// LowStockPercent is the low stock watermark: once a claim leaves this percentage of
+	// the amount or less, the coupon reports low_stock and a coupon.low_stock event fires.
+	LowStockPercent int `json:"low_stock_percent" validate:"excluded_if=Unlimited true,omitempty,gte=1,lte=99"`
+
+	// Parent names an existing coupon whose remaining stock is a budget shared with
+	// its other children: claims of this coupon also take one unit of the parent's.
+	Parent string `json:"parent" validate:"omitempty,notblank,max=255"`
+
+	// Regions maps region name to the most claims accepted from that region.
+	// Claims from other regions are limited by the coupon's stock only.
+	Regions map[string]int `json:"regions" validate:"omitempty,max=50,dive,keys,notblank,max=64,endkeys,gte=1"`
+
+	// ClaimRetention purges or anonymizes the coupon's claims once they are older than
+	// its days, for campaigns with a legal retention limit (CLAIM_RETENTION_ENABLED).
+	ClaimRetention *ClaimRetention `json:"claim_retention"`
+
+	// Visibility hides unlisted and private coupons from listings and reads; private
+	// coupons are only claimable through a signed claim grant. Defaults to public.
+	Visibility string `json:"visibility" validate:"omitempty,oneof=public unlisted private"`
+
+	// HighProfile limits the coupon's claims to the connections reserved for high-profile
+	// coupons (COUPON_HIGH_PROFILE_POOL_SHARE), so a viral coupon cannot starve the rest.
+	HighProfile bool `json:"high_profile"`
+
+	// Prerequisite names an existing coupon users must have claimed before they may
+	// claim this one, e.g. the coupon of the loyalty tier below.
+	Prerequisite string `json:"prerequisite" validate:"omitempty,notblank,max=255"`
+
+	// ExclusionGroup names a group of coupons users may claim only one of, e.g. a choice
+	// between welcome offers.
+	ExclusionGroup string `json:"exclusion_group" validate:"omitempty,notblank,max=255"`
+}
+
+// UpdateCouponRequest is the DTO for PATCH /api/coupons/:name.
+// Nil fields are left unchanged; an empty tags array clears all tags.
+type UpdateCouponRequest struct {
+	Tags        []string `json:"tags" validate:"omitempty,max=20,dive,notblank,max=64"`
+	HighProfile *bool    `json:"high_profile"` // nil leaves it unchanged
+}
+
+// TopUpRequest is the DTO for POST /api/coupons/:name/top-up.
+type TopUpRequest struct {
+	Amount int `json:"amount" validate:"required,gte=1,lte=1000000"`
 }
 
 // ClaimCouponRequest is the DTO for claiming a coupon
 type ClaimCouponRequest struct {
 	UserID     string `json:"user_id" validate:"required,notblank,max=255"`
 	CouponName string `json:"coupon_name" validate:"required,notblank,max=255"`
+	Channel    string `json:"channel" validate:"omitempty,notblank,max=64"`
+	Region     string `json:"region" validate:"omitempty,notblank,max=64"`
+
+	// CaptchaToken is the captcha widget's response, required by coupons with captcha_required.
+	CaptchaToken string `json:"captcha_token,omitempty" validate:"omitempty,max=4096"`
+	// CaptchaVerified is set by the handler once CaptchaToken passed verification.
+	CaptchaVerified bool `json:"-"`
+
+	// Grant is a signed claim grant; the handler fills the fields above from it.
+	Grant string `json:"grant,omitempty"`
+	// GrantVerified is set by the handler once Grant passed verification.
+	GrantVerified bool `json:"-"`
+}
+
+// ClaimReceipt is the API response DTO for POST /api/coupons/claim
+type ClaimReceipt struct {
+	UserID        string `json:"user_id"`
+	CouponName    string `json:"coupon_name"`
+	Channel       string `json:"channel,omitempty"`
+	Region        string `json:"region,omitempty"`
+	ClaimSequence int    `json:"claim_sequence"`
+	Tier          string `json:"tier,omitempty"`
+}
+
+// ClaimRecord is a single claim in the GET /api/coupons/:name/claims export.
+type ClaimRecord struct {
+	UserID        string    `json:"user_id,omitempty"` // Hashed or omitted by COUPON_CLAIMED_BY
+	Channel       string    `json:"channel,omitempty"`
+	Region        string    `json:"region,omitempty"`
+	ClaimSequence int       `json:"claim_sequence"`
+	Tier          string    `json:"tier,omitempty"`
+	ClaimedAt     time.Time `json:"claimed_at"`
+}
+
+// ClaimListResponse is the API response DTO for GET /api/coupons/:name/claims, in
+// claim order. Its total is the coupon's claim count.
+type ClaimListResponse struct {
+	CouponName string `json:"coupon_name"`
+	Page[ClaimRecord]
+}
+
+// ClaimSampleResponse is the API response DTO for GET /api/coupons/:name/claims/sample
+type ClaimSampleResponse struct {
+	CouponName  string        `json:"coupon_name"`
+	TotalClaims int           `json:"total_claims"` // Claims the sample was drawn from
+	Claims      []ClaimRecord `json:"claims"`       // In claim order
+}
+
+// FieldDiff describes one field whose current value differs from the requested one.
+type FieldDiff struct {
+	Field     string `json:"field"`
+	Current   any    `json:"current"`
+	Requested any    `json:"requested"`
+}
+
+// Manifest is the desired state of a set of coupons for POST /api/admin/apply and the
+// startup seed (SEED_FILE).
+type Manifest struct {
+	Coupons []CreateCouponRequest `json:"coupons" validate:"max=1000,dive"`
+}
+
+// ParseManifest decodes a JSON or YAML manifest. YAML is converted to JSON first so
+// both formats share the json tags of Manifest.
+func ParseManifest(data []byte, yamlData bool) (*Manifest, error) {
+	if yamlData {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		data = converted
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// DuplicateName returns the first coupon name listed more than once in m, or "" if
+// the names are unique.
+func (m *Manifest) DuplicateName() string {
+	seen := make(map[string]struct{}, len(m.Coupons))
+	for _, coupon := range m.Coupons {
+		if _, ok := seen[coupon.Name]; ok {
+			return coupon.Name
+		}
+		seen[coupon.Name] = struct{}{}
+	}
+	return ""
+}
+
+// Manifest apply actions.
+const (
+	ApplyActionCreate    = "create"
+	ApplyActionUpdate    = "update"
+	ApplyActionDisable   = "disable"
+	ApplyActionUnchanged = "unchanged"
+	ApplyActionConflict  = "conflict"
+)
+
+// ApplyChange is the planned (or applied) change for one coupon.
+type ApplyChange struct {
+	Name   string      `json:"name"`
+	Action string      `json:"action"`
+	Diff   []FieldDiff `json:"diff,omitempty"`
+	Reason string      `json:"reason,omitempty"` // Why a conflict cannot be reconciled
+}
+
+// ApplyReport is the API response DTO for POST /api/admin/apply
+type ApplyReport struct {
+	DryRun  bool          `json:"dry_run"`
+	Changes []ApplyChange `json:"changes"`
+}
+
+// ImportClaim is one historical claim in a POST /api/admin/claims/import request.
+type ImportClaim struct {
+	UserID     string     `json:"user_id" validate:"required,notblank,max=255"`
+	CouponName string     `json:"coupon_name" validate:"required,notblank,max=255"`
+	Channel    string     `json:"channel" validate:"omitempty,notblank,max=64"`
+	Region     string     `json:"region" validate:"omitempty,notblank,max=64"`
+	ClaimedAt  *time.Time `json:"claimed_at"` // Stored as the claim's creation time; defaults to now
+}
+
+// ClaimImportRequest is the request body for POST /api/admin/claims/import.
+// Claims receive claim sequences in request order within each coupon.
+type ClaimImportRequest struct {
+	Claims []ImportClaim `json:"claims" validate:"required,min=1,max=5000,dive"`
+}
+
+// ClaimImportRejection is a claim the import could not grant.
+type ClaimImportRejection struct {
+	Index      int    `json:"index"` // Position in the request's claims
+	UserID     string `json:"user_id"`
+	CouponName string `json:"coupon_name"`
+	Reason     string `json:"reason"`
+}
+
+// Claim import result statuses.
+const (
+	ClaimImportImported = "imported"
+	ClaimImportSkipped  = "skipped"  // Already claimed
+	ClaimImportRejected = "rejected" // See Reason
+)
+
+// ClaimImportResult is the outcome of one claim of an import, streamed as a line of
+// POST /api/admin/claims/import with Accept: application/x-ndjson once its chunk is committed.
+type ClaimImportResult struct {
+	Index      int    `json:"index"` // Position in the request's claims
+	UserID     string `json:"user_id"`
+	CouponName string `json:"coupon_name"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// ClaimImportReport is the API response DTO for POST /api/admin/claims/import
+type ClaimImportReport struct {
+	Total     int                    `json:"total"`
+	Processed int                    `json:"processed"` // Claims in committed chunks
+	Imported  int                    `json:"imported"`
+	Skipped   int                    `json:"skipped"` // Already claimed, e.g. by an earlier run of the import
+	Rejected  []ClaimImportRejection `json:"rejected"`
+}
+
+// ClaimComparison compares one coupon's claims in claims and claims_v2 while claims
+// move to claims_v2 (see database.ClaimsV2).
+type ClaimComparison struct {
+	CouponName string `json:"coupon_name"`
+	OldClaims  int    `json:"old_claims"` // Claims in claims
+	NewClaims  int    `json:"new_claims"` // Claims in claims_v2
+	Match      bool   `json:"-"`          // Whether both tables hold the same claims, field for field
+}
+
+// ClaimMoveReport is the API response DTO for GET /api/admin/migrations/claims_v2/verify
+type ClaimMoveReport struct {
+	Phase      string            `json:"phase"`
+	Checked    int               `json:"checked"`        // Coupons compared
+	Mismatches []ClaimComparison `json:"mismatches"`     // Coupons whose claims differ
+	Next       string            `json:"next,omitempty"` // Pass as ?after= to verify the next batch; empty after the last
+}
+
+// TerminateCouponRequest is the optional request body for
+// POST /api/admin/coupons/:name/terminate.
+type TerminateCouponRequest struct {
+	Reason string `json:"reason" validate:"omitempty,max=500"` // Recorded in the audit log
+}
+
+// CouponNoteRequest is the request body for POST /api/coupons/:name/notes.
+type CouponNoteRequest struct {
+	Note   string `json:"note" validate:"required,notblank,max=2000"`
+	Author string `json:"author" validate:"omitempty,notblank,max=255"` // Who made the change, e.g. an operator's handle
+}
+
+// Audit log actions.
+const (
+	AuditActionUserDataErased   = "user_data_erased"
+	AuditActionCouponTerminated = "coupon_terminated"
+	AuditActionCouponNote       = "coupon_note"
+)
+
+// CouponChangelogActions are the audit log actions whose subject is a coupon name,
+// listed in its changelog.
+var CouponChangelogActions = []string{AuditActionCouponTerminated, AuditActionCouponNote}
+
+// AuditEntry is a row of the audit log.
+type AuditEntry struct {
+	ID        int64 // Set when read
+	Action    string
+	Subject   string         // Never raw personal data
+	Details   map[string]any // Stored as JSONB
+	CreatedAt time.Time      // Set when read
+}
+
+// ChangelogEntry is an audit log entry of a coupon: a note or a change.
+type ChangelogEntry struct {
+	ID        int64          `json:"id"`
+	Action    string         `json:"action"`  // One of CouponChangelogActions
+	Details   map[string]any `json:"details"` // note and author for notes; reason and stock for terminations
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// CouponChangelogResponse is the API response DTO for GET /api/admin/coupons/:name/changelog,
+// newest first
+type CouponChangelogResponse struct {
+	CouponName string `json:"coupon_name"`
+	Page[ChangelogEntry]
+}
+
+// UserErasureResponse is the API response DTO for DELETE /api/users/:user_id/data
+type UserErasureResponse struct {
+	Pseudonym        string `json:"pseudonym"`
+	ClaimsAnonymized int64  `json:"claims_anonymized"`
+}
+
+// Coupon lifecycle event types delivered to webhook subscriptions.
+const (
+	CouponEventCreated  = "coupon.created"
+	CouponEventUpdated  = "coupon.updated" // Tags, stock top-ups or re-enabling
+	CouponEventDisabled = "coupon.disabled"
+	CouponEventLowStock = "coupon.low_stock" // A claim took stock to the low stock watermark
+	CouponEventClaimed  = "coupon.claimed"   // Also a domain event
+)
+
+// CouponEvent is the body of a webhook delivery. Coupon is the coupon's state right
+// after the change.
+type CouponEvent struct {
+	ID         string        `json:"id"` // Unique per event; repeated on redelivery
+	Type       string        `json:"type"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	Coupon     CouponSummary `json:"coupon"`
+	Claim      *ClaimReceipt `json:"claim,omitempty"` // coupon.claimed: the claim made
+}
+
+// DomainEvent is a coupon created or claimed, as appended to the domain event log.
+type DomainEvent struct {
+	Type       string         `json:"type"` // CouponEventCreated or CouponEventClaimed
+	OccurredAt time.Time      `json:"occurred_at"`
+	Coupon     *CouponSummary `json:"coupon,omitempty"` // coupon.created: the coupon as created
+	Claim      *ClaimReceipt  `json:"claim,omitempty"`  // coupon.claimed: the claim made
+}
+
+// WebhookSubscription is an endpoint receiving coupon lifecycle events.
+// Secret signs the deliveries and is only returned when the subscription is created.
+// PayloadTemplate, if set, shapes the delivery bodies instead of CouponEvent's JSON.
+type WebhookSubscription struct {
+	ID              int64     `json:"id"`
+	URL             string    `json:"url"`
+	Events          []string  `json:"events"`
+	Secret          string    `json:"secret,omitempty"`
+	PayloadTemplate string    `json:"payload_template,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CreateWebhookRequest is the DTO for POST /api/admin/webhooks.
+// A secret is generated when none is given. PayloadTemplate is a Go text/template
+// rendering the delivery body (see webhook.Template), checked when subscribing.
+type CreateWebhookRequest struct {
+	URL             string   `json:"url" validate:"required,url,max=2048"`
+	Events          []string `json:"events" validate:"required,min=1,max=5,dive,oneof=coupon.created coupon.updated coupon.disabled coupon.low_stock coupon.claimed"`
+	Secret          string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	PayloadTemplate string   `json:"payload_template" validate:"max=8192"`
+}
+
+// WebhookListResponse is the API response DTO for GET /api/admin/webhooks, ordered by ID
+type WebhookListResponse = Page[WebhookSubscription]
+
+// LeaderboardEntry is one claimer on a campaign leaderboard. Users with equal claims
+// share a rank (1, 2, 2, 4).
+type LeaderboardEntry struct {
+	Rank   int    `json:"rank"`
+	UserID string `json:"user_id,omitempty"` // Hashed or omitted by COUPON_CLAIMED_BY
+	Claims int    `json:"claims"`
+}
+
+// LeaderboardResponse is the API response DTO for GET /api/campaigns/:id/leaderboard.
+// A campaign is a coupon tag; claims of all coupons carrying it count.
+type LeaderboardResponse struct {
+	Campaign    string             `json:"campaign"`
+	TotalClaims int64              `json:"total_claims"`
+	Claimers    int64              `json:"claimers"`
+	Leaders     []LeaderboardEntry `json:"leaders"`
+}
+
+// CampaignCap is a campaign's claim budget across the coupons tagged with it, and the
+// claims counted against it since it was created.
+type CampaignCap struct {
+	Campaign  string    `json:"campaign"`
+	ClaimCap  int       `json:"claim_cap"`
+	Claimed   int       `json:"claimed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetCampaignCapRequest is the DTO for PUT /api/admin/campaigns/:id/cap.
+type SetCampaignCapRequest struct {
+	ClaimCap *int `json:"claim_cap" validate:"required,min=0"`
+}
+
+// AllowlistEntry is a user allowed to claim an allowlisted coupon, until ClaimBy if set.
+type AllowlistEntry struct {
+	UserID  string     `json:"user_id" validate:"required,notblank,max=255"`
+	ClaimBy *time.Time `json:"claim_by,omitempty"` // Claims after it are rejected; nil for no deadline
+}
+
+// SetAllowlistRequest is the DTO for PUT /api/admin/coupons/:name/allowlist.
+type SetAllowlistRequest struct {
+	Entries []AllowlistEntry `json:"entries" validate:"required,min=1,max=10000,dive"`
+}
+
+// AllowlistResponse is the API response DTO for a coupon's allowlist, in user ID order.
+type AllowlistResponse struct {
+	CouponName string `json:"coupon_name"`
+	Page[AllowlistEntry]
+}
+
+// DeletedCoupon is the API response DTO for DELETE /api/coupons/:name.
+type DeletedCoupon struct {
+	Name            string    `json:"name"`
+	DeletedAt       time.Time `json:"deleted_at"`
+	RestorableUntil time.Time `json:"restorable_until"` // POST /api/coupons/:name/restore works until then
+}
+
+// ArchivedCoupon is the API response DTO for GET /api/archive/coupons/:name: a deleted
+// coupon as it was when purged, kept in the coupon archive after its rows are removed.
+type ArchivedCoupon struct {
+	Name        string          `json:"name"`
+	Coupon      json.RawMessage `json:"coupon"`                  // The coupon's fields and stock when purged, as in Coupon
+	Claims      int             `json:"claims"`                  // Claims made on it
+	LastClaimAt *time.Time      `json:"last_claim_at,omitempty"` // nil if it was never claimed
+	CreatedAt   time.Time       `json:"created_at"`
+	DeletedAt   time.Time       `json:"deleted_at"`
+	ArchivedAt  time.Time       `json:"archived_at"`
+	Visibility  string          `json:"-"` // The coupon's visibility; only listed ones are served
+}
+
+// StockWaitResponse is the API response DTO for GET /api/coupons/:name/wait-for-stock.
+type StockWaitResponse struct {
+	Name            string `json:"name"`
+	Available       bool   `json:"available"`        // False when the wait timed out first
+	RemainingAmount int    `json:"remaining_amount"` // As last read
+}
+
+// KillSwitch is the state of the global kill switch: while Engaged, every write but
+// toggling the switch is refused with 503 and Message.
+type KillSwitch struct {
+	Engaged bool       `json:"engaged"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"` // When it was last toggled; nil if never
+}
+
+// SetKillSwitchRequest is the DTO for PUT /api/admin/killswitch.
+type SetKillSwitchRequest struct {
+	Engaged *bool  `json:"engaged" validate:"required"`
+	Message string `json:"message" validate:"max=255"` // Empty for the default message
+}
+
+// LoadTestPrewarmRequest is the DTO for POST /api/admin/loadtest/prewarm.
+type LoadTestPrewarmRequest struct {
+	Namespace       string `json:"namespace" validate:"required,alphanum,lowercase,max=32"`
+	Coupons         int    `json:"coupons" validate:"required,min=1,max=1000"`
+	Amount          int    `json:"amount" validate:"required,min=1,max=1000000"`                 // Stock of each coupon
+	ClaimsPerCoupon int    `json:"claims_per_coupon" validate:"min=0,max=10000,ltefield=Amount"` // Fake claims made on each coupon
+	TTLMinutes      int    `json:"ttl_minutes" validate:"required,min=1,max=10080"`              // Cleaned up automatically after this
+}
+
+// LoadTestNamespace is a set of disposable coupons created for a load test, named
+// CouponPrefix followed by a 4-digit number, with fake claims by users named
+// UserPrefix followed by a number.
+type LoadTestNamespace struct {
+	Namespace    string    `json:"namespace"`
+	CouponPrefix string    `json:"coupon_prefix"`
+	UserPrefix   string    `json:"user_prefix"`
+	Coupons      int       `json:"coupons"`
+	Claims       int       `json:"claims"` // Across all its coupons
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// LoadTestCleanup is the API response DTO for DELETE /api/admin/loadtest/:namespace.
+type LoadTestCleanup struct {
+	Namespace string `json:"namespace"`
+	Coupons   int    `json:"coupons"` // Coupons removed with their claims
 }
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when decoding a cursor the API did not hand out.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is the envelope of every list response: a page of items and the cursor of the
+// next one. Clients page by passing next_cursor back as ?cursor= until it is omitted.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"` // Omitted on the last page
+	Total      *int   `json:"total,omitempty"`       // Items across all pages, where cheap to count
+}
+
+// NewPage returns the page of items, fetched with one item beyond limit to tell whether
+// a next page exists. Its next cursor is the key of its last item when one does.
+func NewPage[T any](items []T, limit int, key func(T) string) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	if len(items) <= limit {
+		return Page[T]{Items: items}
+	}
+	items = items[:limit]
+	return Page[T]{Items: items, NextCursor: EncodeCursor(key(items[len(items)-1]))}
+}
+
+// EncodeCursor returns the opaque cursor of the page after the item with key.
+func EncodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// DecodeCursor returns the key of cursor, or "" for an empty cursor (the first page).
+// Returns ErrInvalidCursor if cursor was not made by EncodeCursor.
+func DecodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(key) == 0 {
+		return "", ErrInvalidCursor
+	}
+	return string(key), nil
+}
+
+// AuditPosition is an audit entry's place in a newest-first listing; entries are
+// ordered by creation time, then id.
+type AuditPosition struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Key returns the cursor key of p, which ParseAuditPosition reads back.
+func (p AuditPosition) Key() string {
+	return p.CreatedAt.UTC().Format(time.RFC3339Nano) + "/" + strconv.FormatInt(p.ID, 10)
+}
+
+// ParseAuditPosition returns the position of a cursor key made by AuditPosition.Key.
+// Returns ErrInvalidCursor if key is malformed.
+func ParseAuditPosition(key string) (AuditPosition, error) {
+	at, id, ok := strings.Cut(key, "/")
+	if !ok {
+		return AuditPosition{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return AuditPosition{}, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n < 1 {
+		return AuditPosition{}, ErrInvalidCursor
+	}
+	return AuditPosition{CreatedAt: createdAt, ID: n}, nil
+}
@@ -0,0 +1,27 @@
+package model
+
+// ClaimSimulationRequest is the DTO for POST /api/admin/simulate: a coupon launch to
+// simulate, with Stock claimable units claimed at ArrivalRate claims per second for
+// DurationSeconds.
+type ClaimSimulationRequest struct {
+	Stock           int     `json:"stock" validate:"required,gte=1,lte=10000000"`
+	ArrivalRate     float64 `json:"arrival_rate" validate:"required,gt=0,lte=100000"`
+	DurationSeconds int     `json:"duration_seconds" validate:"required,gte=1,lte=3600"`
+	Seed            uint64  `json:"seed"` // Seeds arrivals and latencies; the same seed gives the same result
+}
+
+// ClaimSimulationResult is the outcome of a simulated coupon launch.
+type ClaimSimulationResult struct {
+	LockPolicy       string   `json:"lock_policy"`        // DB_COUPON_LOCK_POLICY simulated
+	Claims           int      `json:"claims"`             // Claims arriving over the duration
+	Granted          int      `json:"granted"`            // Claims taking a unit of stock
+	OutOfStock       int      `json:"out_of_stock"`       // Claims arriving once the stock ran out
+	Busy             int      `json:"busy"`               // Claims refused the row lock by the lock policy
+	ExhaustedAfterMs *float64 `json:"exhausted_after_ms"` // When the last unit was claimed; null if stock lasted
+	P50LatencyMs     float64  `json:"p50_latency_ms"`
+	P99LatencyMs     float64  `json:"p99_latency_ms"`
+	MaxLatencyMs     float64  `json:"max_latency_ms"`
+	PeakConnections  int      `json:"peak_connections"` // Most claims holding a database connection at once
+	LockHoldMs       float64  `json:"lock_hold_ms"`     // Mean time a granted claim held the coupon's row lock
+	Samples          int64    `json:"samples"`          // Recorded statement runs the latencies are drawn from
+}
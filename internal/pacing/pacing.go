@@ -0,0 +1,206 @@
+// Package pacing smooths claims on each coupon to a steady rate with a leaky bucket:
+// claims arriving faster than the rate are queued and released one interval apart,
+// and claims that would queue for longer than the bucket holds are shed. The bucket
+// state lives in a Store, in process or in Redis so that pacing is shared by every
+// replica and survives restarts.
+package pacing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned by Wait when a claim would queue longer than the bucket allows.
+var ErrQueueFull = errors.New("pacing queue full")
+
+// Store schedules claims in per-key leaky buckets.
+type Store interface {
+	// Schedule reserves the next slot of key's bucket, one interval after the previous
+	// one, and returns how long until it. When the slot is more than maxDelay away it
+	// reserves nothing and returns ok false.
+	Schedule(ctx context.Context, key string, interval, maxDelay time.Duration) (delay time.Duration, ok bool, err error)
+}
+
+// Config configures a Pacer.
+type Config struct {
+	Rate     float64 // Claims per second released per coupon
+	MaxQueue int     // Claims queued per coupon before further claims are shed
+	// PriorityMaxQueue is how deep priority claims may queue, at least MaxQueue, so they
+	// are still admitted once the others are shed.
+	PriorityMaxQueue int
+	// Priority reports whether the claim of a context is a priority one; nil for none.
+	Priority func(context.Context) bool
+}
+
+// Stats is a snapshot of one coupon's pacing counters.
+type Stats struct {
+	Admitted   int64 `json:"admitted"`    // Claims released, with or without queueing
+	Delayed    int64 `json:"delayed"`     // Claims that queued before release
+	Shed       int64 `json:"shed"`        // Claims rejected with the queue full
+	QueueDepth int64 `json:"queue_depth"` // Claims queued on this instance right now
+	Backlog    int   `json:"backlog"`     // Claims queued on all instances, as last seen in the store
+}
+
+// coupon holds one coupon's counters.
+type coupon struct {
+	admitted atomic.Int64
+	delayed  atomic.Int64
+	shed     atomic.Int64
+	queued   atomic.Int64
+	backlog  atomic.Int64
+}
+
+// Pacer paces claims per coupon. It is safe for concurrent use.
+type Pacer struct {
+	store            Store
+	interval         time.Duration
+	maxDelay         time.Duration
+	priorityMaxDelay time.Duration
+	priority         func(context.Context) bool
+
+	mu      sync.Mutex
+	coupons map[string]*coupon
+
+	errors atomic.Int64
+}
+
+// New creates a Pacer keeping its buckets in store.
+func New(store Store, cfg Config) *Pacer {
+	interval := time.Duration(float64(time.Second) / cfg.Rate)
+	return &Pacer{
+		store:            store,
+		interval:         interval,
+		maxDelay:         time.Duration(cfg.MaxQueue) * interval,
+		priorityMaxDelay: time.Duration(max(cfg.MaxQueue, cfg.PriorityMaxQueue)) * interval,
+		priority:         cfg.Priority,
+		coupons:          make(map[string]*coupon),
+	}
+}
+
+// Stats returns the counters of every coupon claimed so far.
+func (p *Pacer) Stats() map[string]Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make(map[string]Stats, len(p.coupons))
+	for name, c := range p.coupons {
+		stats[name] = Stats{
+			Admitted:   c.admitted.Load(),
+			Delayed:    c.delayed.Load(),
+			Shed:       c.shed.Load(),
+			QueueDepth: c.queued.Load(),
+			Backlog:    int(c.backlog.Load()),
+		}
+	}
+	return stats
+}
+
+// Errors returns how many claims were let through unpaced because the store failed.
+func (p *Pacer) Errors() int64 {
+	return p.errors.Load()
+}
+
+// Wait blocks until a claim on name may proceed. It returns ErrQueueFull when the
+// coupon's queue is full (for a priority claim, its deeper queue) or the claim's slot
+// is past ctx's deadline, and ctx's error
+// when ctx ends while queued. A failing store does not block claims: they proceed
+// unpaced, since the claim transaction still enforces stock.
+func (p *Pacer) Wait(ctx context.Context, name string) error {
+	c := p.coupon(name)
+
+	maxDelay := p.maxDelay
+	if p.priority != nil && p.priority(ctx) {
+		maxDelay = p.priorityMaxDelay
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		maxDelay = min(maxDelay, time.Until(deadline))
+	}
+	delay, ok, err := p.store.Schedule(ctx, name, p.interval, maxDelay)
+	if err != nil {
+		p.errors.Add(1)
+		c.admitted.Add(1)
+		return nil
+	}
+	c.backlog.Store(int64(delay / p.interval))
+	if !ok {
+		c.shed.Add(1)
+		return ErrQueueFull
+	}
+	if delay > 0 {
+		c.delayed.Add(1)
+		c.queued.Add(1)
+		defer c.queued.Add(-1)
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c.admitted.Add(1)
+	return nil
+}
+
+// coupon returns name's counters, creating them on first use.
+func (p *Pacer) coupon(name string) *coupon {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := p.coupons[name]
+	if !ok {
+		c = &coupon{}
+		p.coupons[name] = c
+	}
+	return c
+}
+
+// MemoryStore keeps buckets in process, so each instance paces on its own. It is safe
+// for concurrent use.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu   sync.Mutex
+	next map[string]time.Time // When each bucket's next slot is free
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{now: time.Now, next: make(map[string]time.Time)}
+}
+
+// Schedule implements Store.
+func (s *MemoryStore) Schedule(_ context.Context, key string, interval, maxDelay time.Duration) (time.Duration, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	next, ok := s.next[key]
+	if !ok || next.Before(now) {
+		next = now
+	}
+	delay := next.Sub(now)
+	if delay > maxDelay {
+		return delay, false, nil
+	}
+	s.next[key] = next.Add(interval)
+	if len(s.next) > maxIdleBuckets {
+		s.evictIdle(now)
+	}
+	return delay, true, nil
+}
+
+// maxIdleBuckets is how many buckets a MemoryStore holds before dropping drained ones.
+const maxIdleBuckets = 1024
+
+// evictIdle drops buckets with no slot reserved past now; they hold no state.
+func (s *MemoryStore) evictIdle(now time.Time) {
+	for key, next := range s.next {
+		if !next.After(now) {
+			delete(s.next, key)
+		}
+	}
+}
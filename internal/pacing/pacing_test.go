@@ -0,0 +1,145 @@
+package pacing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Schedule(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	for i, want := range []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		delay, ok, err := s.Schedule(context.Background(), "PROMO", 100*time.Millisecond, 250*time.Millisecond)
+		require.NoError(t, err)
+		assert.True(t, ok, "claim %d", i)
+		assert.Equal(t, want, delay, "claim %d", i)
+	}
+
+	delay, ok, err := s.Schedule(context.Background(), "PROMO", 100*time.Millisecond, 250*time.Millisecond)
+	require.NoError(t, err)
+	assert.False(t, ok, "the queue is full")
+	assert.Equal(t, 300*time.Millisecond, delay)
+
+	delay, ok, _ = s.Schedule(context.Background(), "OTHER", 100*time.Millisecond, 250*time.Millisecond)
+	assert.True(t, ok, "coupons have their own buckets")
+	assert.Zero(t, delay)
+
+	now = now.Add(time.Second)
+	delay, ok, _ = s.Schedule(context.Background(), "PROMO", 100*time.Millisecond, 250*time.Millisecond)
+	assert.True(t, ok, "the bucket drained")
+	assert.Zero(t, delay)
+}
+
+func TestMemoryStore_EvictsDrainedBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	for i := range maxIdleBuckets {
+		_, _, _ = s.Schedule(context.Background(), fmt.Sprintf("COUPON_%d", i), time.Millisecond, time.Second)
+	}
+	now = now.Add(time.Second)
+	_, _, _ = s.Schedule(context.Background(), "PROMO", time.Millisecond, time.Second)
+
+	assert.Len(t, s.next, 1)
+}
+
+// stubStore returns fixed Schedule results and records the maximum delays asked for.
+type stubStore struct {
+	delay time.Duration
+	ok    bool
+	err   error
+
+	mu        sync.Mutex
+	maxDelays []time.Duration
+}
+
+func (s *stubStore) Schedule(_ context.Context, _ string, _, maxDelay time.Duration) (time.Duration, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxDelays = append(s.maxDelays, maxDelay)
+	return s.delay, s.ok, s.err
+}
+
+// priorityKey marks priority claims in tests.
+type priorityKey struct{}
+
+func TestPacer_Wait(t *testing.T) {
+	t.Run("admits a claim with a free slot", func(t *testing.T) {
+		p := New(&stubStore{ok: true}, Config{Rate: 10, MaxQueue: 5})
+
+		require.NoError(t, p.Wait(context.Background(), "PROMO"))
+		assert.Equal(t, Stats{Admitted: 1}, p.Stats()["PROMO"])
+	})
+
+	t.Run("queues a claim until its slot", func(t *testing.T) {
+		p := New(&stubStore{ok: true, delay: 30 * time.Millisecond}, Config{Rate: 100, MaxQueue: 5})
+
+		done := make(chan error)
+		start := time.Now()
+		go func() { done <- p.Wait(context.Background(), "PROMO") }()
+		require.Eventually(t, func() bool { return p.Stats()["PROMO"].QueueDepth == 1 }, time.Second, time.Millisecond)
+		require.NoError(t, <-done)
+
+		assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+		assert.Equal(t, Stats{Admitted: 1, Delayed: 1, Backlog: 3}, p.Stats()["PROMO"])
+	})
+
+	t.Run("sheds a claim when the queue is full", func(t *testing.T) {
+		p := New(&stubStore{ok: false, delay: time.Second}, Config{Rate: 10, MaxQueue: 5})
+
+		assert.ErrorIs(t, p.Wait(context.Background(), "PROMO"), ErrQueueFull)
+		assert.Equal(t, Stats{Shed: 1, Backlog: 10}, p.Stats()["PROMO"])
+	})
+
+	t.Run("does not queue past the context deadline", func(t *testing.T) {
+		store := &stubStore{ok: true}
+		p := New(store, Config{Rate: 10, MaxQueue: 5})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		require.NoError(t, p.Wait(ctx, "PROMO"))
+		require.NoError(t, p.Wait(context.Background(), "PROMO"))
+
+		assert.LessOrEqual(t, store.maxDelays[0], 200*time.Millisecond)
+		assert.Equal(t, 500*time.Millisecond, store.maxDelays[1])
+	})
+
+	t.Run("queues priority claims deeper", func(t *testing.T) {
+		store := &stubStore{ok: true}
+		p := New(store, Config{Rate: 10, MaxQueue: 5, PriorityMaxQueue: 20, Priority: func(ctx context.Context) bool {
+			return ctx.Value(priorityKey{}) != nil
+		}})
+
+		require.NoError(t, p.Wait(context.Background(), "PROMO"))
+		require.NoError(t, p.Wait(context.WithValue(context.Background(), priorityKey{}, true), "PROMO"))
+
+		assert.Equal(t, []time.Duration{500 * time.Millisecond, 2 * time.Second}, store.maxDelays)
+	})
+
+	t.Run("returns when the context ends while queued", func(t *testing.T) {
+		p := New(&stubStore{ok: true, delay: time.Minute}, Config{Rate: 1, MaxQueue: 100})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, p.Wait(ctx, "PROMO"), context.Canceled)
+		assert.Equal(t, int64(0), p.Stats()["PROMO"].QueueDepth)
+	})
+
+	t.Run("lets claims through when the store fails", func(t *testing.T) {
+		p := New(&stubStore{err: errors.New("connection refused")}, Config{Rate: 10, MaxQueue: 5})
+
+		require.NoError(t, p.Wait(context.Background(), "PROMO"))
+		assert.Equal(t, int64(1), p.Errors())
+		assert.Equal(t, Stats{Admitted: 1}, p.Stats()["PROMO"])
+	})
+}
@@ -0,0 +1,71 @@
+package pacing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redis"
+)
+
+// KeyPrefix prefixes the Redis keys of coupon buckets.
+const KeyPrefix = "claim_pacing:"
+
+// scheduleScript reserves a slot in the bucket at KEYS[1] atomically. The key holds the
+// time its next slot is free, in microseconds of the Redis server clock, so replicas
+// with skewed clocks share one schedule. It expires once the bucket drains. ARGV are
+// the interval and the maximum delay in microseconds; the reply is the delay, or -1
+// with the delay when it exceeds the maximum.
+const scheduleScript = `
+if redis.replicate_commands then redis.replicate_commands() end
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local next = tonumber(redis.call('GET', KEYS[1]) or '0')
+if next < now then next = now end
+local delay = next - now
+if delay > tonumber(ARGV[2]) then return {-1, delay} end
+redis.call('SET', KEYS[1], string.format('%d', next + interval), 'PX', math.floor((delay + interval) / 1000) + 1000)
+return {0, delay}
+`
+
+// RedisStore keeps buckets in Redis, shared by every instance pointing at it. It is
+// safe for concurrent use.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a RedisStore for a redis://[user:password@]host:port[/db] URL.
+// Connections are dialed on first use.
+func NewRedisStore(rawURL string, timeout time.Duration) (*RedisStore, error) {
+	client, err := redis.New(rawURL, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Close closes the idle connections.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// Schedule implements Store.
+func (s *RedisStore) Schedule(ctx context.Context, key string, interval, maxDelay time.Duration) (time.Duration, bool, error) {
+	reply, err := s.client.Do(ctx, "EVAL", scheduleScript, "1", KeyPrefix+key,
+		strconv.FormatInt(interval.Microseconds(), 10), strconv.FormatInt(max(maxDelay, 0).Microseconds(), 10))
+	if err != nil {
+		return 0, false, err
+	}
+	values, ok := reply.([]any)
+	if !ok || len(values) != 2 {
+		return 0, false, fmt.Errorf("unexpected schedule reply %v", reply)
+	}
+	status, ok1 := values[0].(int64)
+	delay, ok2 := values[1].(int64)
+	if !ok1 || !ok2 {
+		return 0, false, fmt.Errorf("unexpected schedule reply %v", reply)
+	}
+	return time.Duration(delay) * time.Microsecond, status == 0, nil
+}
@@ -0,0 +1,82 @@
+package pacing
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redis/redistest"
+)
+
+func TestRedisStore_Schedule(t *testing.T) {
+	f := redistest.NewServer(t, func(args []string) string {
+		if args[0] == "EVAL" {
+			return "*2\r\n:0\r\n:150000\r\n"
+		}
+		return "+OK\r\n"
+	})
+	s, err := NewRedisStore("redis://app:secret@"+f.Addr().String()+"/2", time.Second)
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	for range 2 {
+		delay, ok, err := s.Schedule(context.Background(), "PROMO", 100*time.Millisecond, time.Second)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 150*time.Millisecond, delay)
+	}
+
+	commands := f.Commands()
+	require.Len(t, commands, 4)
+	assert.Equal(t, []string{"AUTH", "app", "secret"}, commands[0])
+	assert.Equal(t, []string{"SELECT", "2"}, commands[1])
+	assert.Equal(t, []string{"EVAL", scheduleScript, "1", "claim_pacing:PROMO", "100000", "1000000"}, commands[2])
+	assert.Equal(t, 1, f.Conns(), "the connection is reused")
+}
+
+func TestRedisStore_ScheduleQueueFull(t *testing.T) {
+	f := redistest.NewServer(t, func([]string) string { return "*2\r\n:-1\r\n:2500000\r\n" })
+	s, err := NewRedisStore(f.URL(), time.Second)
+	require.NoError(t, err)
+
+	delay, ok, err := s.Schedule(context.Background(), "PROMO", 100*time.Millisecond, time.Second)
+
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 2500*time.Millisecond, delay)
+}
+
+func TestRedisStore_ScheduleErrorReply(t *testing.T) {
+	f := redistest.NewServer(t, func([]string) string { return "-NOSCRIPT no scripting\r\n" })
+	s, err := NewRedisStore(f.URL(), time.Second)
+	require.NoError(t, err)
+
+	_, _, err = s.Schedule(context.Background(), "PROMO", 100*time.Millisecond, time.Second)
+	assert.ErrorContains(t, err, "NOSCRIPT")
+
+	_, _, err = s.Schedule(context.Background(), "PROMO", 100*time.Millisecond, time.Second)
+	assert.ErrorContains(t, err, "NOSCRIPT")
+	assert.Equal(t, 1, f.Conns(), "an error reply leaves the connection usable")
+}
+
+func TestRedisStore_ScheduleUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	s, err := NewRedisStore("redis://"+addr, time.Second)
+	require.NoError(t, err)
+
+	_, _, err = s.Schedule(context.Background(), "PROMO", 100*time.Millisecond, time.Second)
+	assert.ErrorContains(t, err, "connect to redis")
+}
+
+func TestNewRedisStore_InvalidURL(t *testing.T) {
+	_, err := NewRedisStore("http://localhost:6379", time.Second)
+	assert.Error(t, err)
+}
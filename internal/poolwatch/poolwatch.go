@@ -0,0 +1,185 @@
+// Package poolwatch samples connection pool usage and warns before a pool runs out of
+// connections: when the pool is nearly fully in use, or when acquiring a connection has
+// started to wait long, which happen before acquires time out and requests fail.
+package poolwatch
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// Pool statuses, as reported by Status.
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+)
+
+// windowSize is how many samples of each pool the acquire wait p95 is computed over.
+const windowSize = 60
+
+// Thresholds are the levels a pool is warned about at. A zero threshold is not checked.
+type Thresholds struct {
+	Saturation     float64       // Share of the pool's connections in use, 0 to 1
+	AcquireWaitP95 time.Duration // p95 of the mean acquire wait per sample
+}
+
+// PoolStats is a snapshot of one watched pool.
+type PoolStats struct {
+	Saturation       float64 `json:"saturation"`
+	AcquireWaitP95Ms float64 `json:"acquire_wait_p95_ms"`
+	Status           string  `json:"status"`
+	Warnings         int64   `json:"warnings"` // Times the pool went from ok to warning
+}
+
+// Stats is a snapshot of the watchdog.
+type Stats struct {
+	Status string               `json:"status"`
+	Pools  map[string]PoolStats `json:"pools"`
+}
+
+// Watchdog compares the usage of connection pools against Thresholds. Pools only count
+// how long acquires waited in total, so the acquire wait p95 is taken over the mean wait
+// of the acquires that waited between two samples, across the last 60 samples. It is
+// safe for concurrent use.
+type Watchdog struct {
+	stats      func() map[string]database.PoolStats
+	thresholds Thresholds
+
+	mu    sync.Mutex
+	pools map[string]*pool
+
+	warning atomic.Bool // Some pool is over a threshold
+}
+
+// pool holds the recent samples of one pool.
+type pool struct {
+	last     database.PoolStats
+	sampled  bool
+	waits    []time.Duration // Ring buffer of mean acquire waits per sample
+	next     int
+	current  PoolStats
+	warnings int64
+}
+
+// New creates a Watchdog of the pools whose usage stats returns, keyed by pool name.
+func New(stats func() map[string]database.PoolStats, thresholds Thresholds) *Watchdog {
+	return &Watchdog{stats: stats, thresholds: thresholds, pools: make(map[string]*pool)}
+}
+
+// Status returns StatusWarning while some pool is over a threshold, StatusOK otherwise.
+func (w *Watchdog) Status() string {
+	if w.warning.Load() {
+		return StatusWarning
+	}
+	return StatusOK
+}
+
+// Stats returns the latest sample of every pool.
+func (w *Watchdog) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pools := make(map[string]PoolStats, len(w.pools))
+	for name, p := range w.pools {
+		stats := p.current
+		stats.Warnings = p.warnings
+		pools[name] = stats
+	}
+	return Stats{Status: w.Status(), Pools: pools}
+}
+
+// Sample checks the current usage of every pool, logging a warning when a pool goes over
+// a threshold and when it is back under all of them.
+func (w *Watchdog) Sample() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	warning := false
+	for name, stats := range w.stats() {
+		p, ok := w.pools[name]
+		if !ok {
+			p = &pool{current: PoolStats{Status: StatusOK}}
+			w.pools[name] = p
+		}
+		p.record(stats)
+
+		p.current.Saturation = 0
+		if stats.MaxConns > 0 {
+			p.current.Saturation = float64(stats.AcquiredConns) / float64(stats.MaxConns)
+		}
+		waitP95 := p.waitP95()
+		p.current.AcquireWaitP95Ms = float64(waitP95) / float64(time.Millisecond)
+
+		saturated := w.thresholds.Saturation > 0 && p.current.Saturation >= w.thresholds.Saturation
+		waiting := w.thresholds.AcquireWaitP95 > 0 && waitP95 >= w.thresholds.AcquireWaitP95
+		switch {
+		case (saturated || waiting) && p.current.Status == StatusOK:
+			p.current.Status = StatusWarning
+			p.warnings++
+			log.Warn().
+				Str("pool", name).
+				Float64("saturation", p.current.Saturation).
+				Float64("saturation_threshold", w.thresholds.Saturation).
+				Dur("acquire_wait_p95", waitP95).
+				Dur("acquire_wait_p95_threshold", w.thresholds.AcquireWaitP95).
+				Int32("acquired_conns", stats.AcquiredConns).
+				Int32("max_conns", stats.MaxConns).
+				Msg("connection pool nearing exhaustion")
+		case !saturated && !waiting && p.current.Status == StatusWarning:
+			p.current.Status = StatusOK
+			log.Info().
+				Str("pool", name).
+				Float64("saturation", p.current.Saturation).
+				Dur("acquire_wait_p95", waitP95).
+				Msg("connection pool back under thresholds")
+		}
+		warning = warning || p.current.Status == StatusWarning
+	}
+	w.warning.Store(warning)
+}
+
+// record adds the mean wait of the acquires that waited since the previous sample.
+func (p *pool) record(stats database.PoolStats) {
+	var wait time.Duration
+	if p.sampled && stats.WaitCount > p.last.WaitCount {
+		waited := time.Duration(stats.WaitMillis-p.last.WaitMillis) * time.Millisecond
+		wait = waited / time.Duration(stats.WaitCount-p.last.WaitCount)
+	}
+	p.last, p.sampled = stats, true
+
+	if len(p.waits) < windowSize {
+		p.waits = append(p.waits, wait)
+	} else {
+		p.waits[p.next] = wait
+		p.next = (p.next + 1) % windowSize
+	}
+}
+
+// waitP95 returns the p95 of the recorded acquire waits.
+func (p *pool) waitP95() time.Duration {
+	sorted := slices.Clone(p.waits)
+	slices.Sort(sorted)
+	return sorted[len(sorted)*95/100]
+}
+
+// Run samples the pools now and then every interval until ctx is cancelled.
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.Sample()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package poolwatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// fakePools returns the stats set by the test.
+type fakePools struct {
+	stats map[string]database.PoolStats
+}
+
+func (f *fakePools) PoolStats() map[string]database.PoolStats {
+	return f.stats
+}
+
+func TestWatchdog_Saturation(t *testing.T) {
+	pools := &fakePools{stats: map[string]database.PoolStats{
+		"claim": {MaxConns: 10, AcquiredConns: 5},
+		"read":  {MaxConns: 10, AcquiredConns: 2},
+	}}
+	w := New(pools.PoolStats, Thresholds{Saturation: 0.9})
+
+	w.Sample()
+	assert.Equal(t, StatusOK, w.Status())
+	assert.Equal(t, 0.5, w.Stats().Pools["claim"].Saturation)
+
+	pools.stats["claim"] = database.PoolStats{MaxConns: 10, AcquiredConns: 9}
+	w.Sample()
+	w.Sample() // Still over: counted once
+	assert.Equal(t, StatusWarning, w.Status())
+	stats := w.Stats()
+	assert.Equal(t, StatusWarning, stats.Status)
+	assert.Equal(t, PoolStats{Saturation: 0.9, Status: StatusWarning, Warnings: 1}, stats.Pools["claim"])
+	assert.Equal(t, StatusOK, stats.Pools["read"].Status)
+
+	pools.stats["claim"] = database.PoolStats{MaxConns: 10, AcquiredConns: 3}
+	w.Sample()
+	assert.Equal(t, StatusOK, w.Status())
+	assert.Equal(t, int64(1), w.Stats().Pools["claim"].Warnings)
+}
+
+func TestWatchdog_AcquireWaitP95(t *testing.T) {
+	pools := &fakePools{stats: map[string]database.PoolStats{"claim": {MaxConns: 10}}}
+	w := New(pools.PoolStats, Thresholds{AcquireWaitP95: 50 * time.Millisecond})
+	w.Sample()
+
+	// Four acquires waited 40ms on average: under the threshold
+	pools.stats["claim"] = database.PoolStats{MaxConns: 10, WaitCount: 4, WaitMillis: 160}
+	w.Sample()
+	assert.Equal(t, StatusOK, w.Status())
+	assert.Equal(t, 40.0, w.Stats().Pools["claim"].AcquireWaitP95Ms)
+
+	// Two more waited 100ms on average
+	pools.stats["claim"] = database.PoolStats{MaxConns: 10, WaitCount: 6, WaitMillis: 360}
+	w.Sample()
+	assert.Equal(t, StatusWarning, w.Status())
+	assert.Equal(t, 100.0, w.Stats().Pools["claim"].AcquireWaitP95Ms)
+
+	// The slow sample leaves the window once enough quiet samples follow it
+	for range windowSize {
+		w.Sample()
+	}
+	assert.Equal(t, StatusOK, w.Status())
+	assert.Zero(t, w.Stats().Pools["claim"].AcquireWaitP95Ms)
+}
+
+func TestWatchdog_ZeroThresholdsNeverWarn(t *testing.T) {
+	pools := &fakePools{stats: map[string]database.PoolStats{"claim": {MaxConns: 10, AcquiredConns: 10}}}
+	w := New(pools.PoolStats, Thresholds{})
+
+	w.Sample()
+
+	assert.Equal(t, StatusOK, w.Status())
+}
+
+func TestWatchdog_Run(t *testing.T) {
+	pools := &fakePools{stats: map[string]database.PoolStats{"claim": {MaxConns: 1, AcquiredConns: 1}}}
+	w := New(pools.PoolStats, Thresholds{Saturation: 0.5})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		w.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return w.Status() == StatusWarning }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
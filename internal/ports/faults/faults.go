@@ -0,0 +1,376 @@
+// Package faults wraps ports implementations so tests can make chosen calls fail.
+//
+// Wrappers forward every call to a real (or mock) implementation unless the Injector
+// has a fault for that method, in which case the call fails without reaching it:
+//
+//	inj := faults.NewInjector()
+//	inj.Fail(faults.CouponDecrementStock, faults.ErrInjected, faults.After(2))
+//	couponRepo := faults.CouponRepository(repository.NewCouponRepository(pool), inj)
+//	svc := service.NewCouponServiceWithTxBeginner(faults.TxBeginner(pool, inj), couponRepo, claimRepo)
+//
+// The wrappers are the generated mocks from ports/mocks, so calls can also be inspected
+// (e.g. couponRepo.DecrementStockCalls()). Wrappers and Injector are safe for concurrent use.
+package faults
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// ErrInjected is a ready-made error for injected faults.
+var ErrInjected = errors.New("injected fault")
+
+// Method identifies a wrapped port method.
+type Method string
+
+// Wrapped methods.
+const (
+	CouponInsert                Method = "CouponRepository.Insert"
+	CouponGetByName             Method = "CouponRepository.GetByName"
+	CouponList                  Method = "CouponRepository.List"
+	CouponNames                 Method = "CouponRepository.Names"
+	CouponUpdateTags            Method = "CouponRepository.UpdateTags"
+	CouponGetForUpdate          Method = "CouponRepository.GetCouponForUpdate"
+	CouponDecrementStock        Method = "CouponRepository.DecrementStock"
+	CouponDecrementChannelStock Method = "CouponRepository.DecrementChannelStock"
+	CouponInsertTx              Method = "CouponRepository.InsertTx"
+	CouponUpdateTagsTx          Method = "CouponRepository.UpdateTagsTx"
+	CouponListForUpdate         Method = "CouponRepository.ListForUpdate"
+	CouponTopUp                 Method = "CouponRepository.TopUp"
+	CouponSetDisabled           Method = "CouponRepository.SetDisabled"
+	CouponSetHighProfile        Method = "CouponRepository.SetHighProfile"
+
+	ClaimGetUsersByCoupon Method = "ClaimRepository.GetUsersByCoupon"
+	ClaimCountByCoupon    Method = "ClaimRepository.CountByCoupon"
+	ClaimListByCoupon     Method = "ClaimRepository.ListByCoupon"
+	ClaimListBySequences  Method = "ClaimRepository.ListBySequences"
+	ClaimInsert           Method = "ClaimRepository.Insert"
+	ClaimInsertExclusion  Method = "ClaimRepository.InsertExclusionClaim"
+	ClaimClaimedUsers     Method = "ClaimRepository.ClaimedUsers"
+	ClaimGetClaimedUsers  Method = "ClaimRepository.GetClaimedUsers"
+	ClaimHasClaimed       Method = "ClaimRepository.HasClaimed"
+	ClaimGetClaim         Method = "ClaimRepository.GetClaim"
+
+	UserClaimPseudonymize Method = "UserClaimRepository.PseudonymizeUser"
+	AuditInsert           Method = "AuditRepository.Insert"
+	AuditListBySubject    Method = "AuditRepository.ListBySubject"
+
+	TxBegin  Method = "TxBeginner.Begin"
+	TxCommit Method = "Tx.Commit"
+)
+
+// Option adjusts when a fault fires.
+type Option func(*fault)
+
+// After lets the first n calls through before the fault starts firing.
+func After(n int) Option {
+	return func(f *fault) { f.skip = n }
+}
+
+// Times stops the fault after n failures; later calls pass through again.
+func Times(n int) Option {
+	return func(f *fault) { f.remaining = n }
+}
+
+type fault struct {
+	err       error
+	skip      int // Calls to let through before failing
+	remaining int // Failures left; negative means unlimited
+}
+
+// Injector decides which wrapped calls fail and counts calls per method.
+type Injector struct {
+	mu     sync.Mutex
+	faults map[Method]*fault
+	calls  map[Method]int
+}
+
+// NewInjector returns an Injector without faults: every call passes through.
+func NewInjector() *Injector {
+	return &Injector{faults: make(map[Method]*fault), calls: make(map[Method]int)}
+}
+
+// Fail makes calls to method return err, on every call unless limited by opts.
+// It replaces any earlier fault for the same method.
+func (i *Injector) Fail(method Method, err error, opts ...Option) {
+	f := &fault{err: err, remaining: -1}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults[method] = f
+}
+
+// Clear removes all faults. Call counts are kept.
+func (i *Injector) Clear() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	clear(i.faults)
+}
+
+// Calls returns how often method was called through a wrapper, including failed calls.
+func (i *Injector) Calls(method Method) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.calls[method]
+}
+
+// check records a call to method and returns the error it must fail with, if any.
+func (i *Injector) check(method Method) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.calls[method]++
+	f, ok := i.faults[method]
+	if !ok || f.remaining == 0 {
+		return nil
+	}
+	if f.skip > 0 {
+		f.skip--
+		return nil
+	}
+	if f.remaining > 0 {
+		f.remaining--
+	}
+	return f.err
+}
+
+// CouponRepository wraps next so its calls can fail through inj.
+func CouponRepository(next ports.CouponRepository, inj *Injector) *mocks.CouponRepositoryMock {
+	return &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			if err := inj.check(CouponInsert); err != nil {
+				return err
+			}
+			return next.Insert(ctx, coupon)
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			if err := inj.check(CouponGetByName); err != nil {
+				return nil, err
+			}
+			return next.GetByName(ctx, name)
+		},
+		ListFunc: func(ctx context.Context, filter model.CouponFilter) ([]model.Coupon, error) {
+			if err := inj.check(CouponList); err != nil {
+				return nil, err
+			}
+			return next.List(ctx, filter)
+		},
+		NamesFunc: func(ctx context.Context) ([]string, error) {
+			if err := inj.check(CouponNames); err != nil {
+				return nil, err
+			}
+			return next.Names(ctx)
+		},
+		UpdateTagsFunc: func(ctx context.Context, name string, tags []string) error {
+			if err := inj.check(CouponUpdateTags); err != nil {
+				return err
+			}
+			return next.UpdateTags(ctx, name, tags)
+		},
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			if err := inj.check(CouponGetForUpdate); err != nil {
+				return nil, err
+			}
+			return next.GetCouponForUpdate(ctx, tx, name)
+		},
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			if err := inj.check(CouponDecrementStock); err != nil {
+				return err
+			}
+			return next.DecrementStock(ctx, tx, name)
+		},
+		DecrementChannelStockFunc: func(ctx context.Context, tx database.TxQuerier, name, channel string) error {
+			if err := inj.check(CouponDecrementChannelStock); err != nil {
+				return err
+			}
+			return next.DecrementChannelStock(ctx, tx, name, channel)
+		},
+		InsertTxFunc: func(ctx context.Context, tx database.TxQuerier, coupon *model.Coupon) error {
+			if err := inj.check(CouponInsertTx); err != nil {
+				return err
+			}
+			return next.InsertTx(ctx, tx, coupon)
+		},
+		UpdateTagsTxFunc: func(ctx context.Context, tx database.TxQuerier, name string, tags []string) error {
+			if err := inj.check(CouponUpdateTagsTx); err != nil {
+				return err
+			}
+			return next.UpdateTagsTx(ctx, tx, name, tags)
+		},
+		ListForUpdateFunc: func(ctx context.Context, tx database.TxQuerier) ([]model.Coupon, error) {
+			if err := inj.check(CouponListForUpdate); err != nil {
+				return nil, err
+			}
+			return next.ListForUpdate(ctx, tx)
+		},
+		TopUpFunc: func(ctx context.Context, tx database.TxQuerier, name string, delta int) error {
+			if err := inj.check(CouponTopUp); err != nil {
+				return err
+			}
+			return next.TopUp(ctx, tx, name, delta)
+		},
+		SetDisabledFunc: func(ctx context.Context, tx database.TxQuerier, name string, disabled bool) error {
+			if err := inj.check(CouponSetDisabled); err != nil {
+				return err
+			}
+			return next.SetDisabled(ctx, tx, name, disabled)
+		},
+		SetHighProfileFunc: func(ctx context.Context, tx database.TxQuerier, name string, highProfile bool) error {
+			if err := inj.check(CouponSetHighProfile); err != nil {
+				return err
+			}
+			return next.SetHighProfile(ctx, tx, name, highProfile)
+		},
+	}
+}
+
+// ClaimRepository wraps next so its calls can fail through inj.
+func ClaimRepository(next ports.ClaimRepository, inj *Injector) *mocks.ClaimRepositoryMock {
+	return &mocks.ClaimRepositoryMock{
+		GetUsersByCouponFunc: func(ctx context.Context, couponName string, limit int) ([]string, error) {
+			if err := inj.check(ClaimGetUsersByCoupon); err != nil {
+				return nil, err
+			}
+			return next.GetUsersByCoupon(ctx, couponName, limit)
+		},
+		CountByCouponFunc: func(ctx context.Context, couponName string) (int, error) {
+			if err := inj.check(ClaimCountByCoupon); err != nil {
+				return 0, err
+			}
+			return next.CountByCoupon(ctx, couponName)
+		},
+		ListByCouponFunc: func(ctx context.Context, couponName string, afterSequence, limit int) ([]model.Claim, error) {
+			if err := inj.check(ClaimListByCoupon); err != nil {
+				return nil, err
+			}
+			return next.ListByCoupon(ctx, couponName, afterSequence, limit)
+		},
+		ListBySequencesFunc: func(ctx context.Context, couponName string, sequences []int) ([]model.Claim, error) {
+			if err := inj.check(ClaimListBySequences); err != nil {
+				return nil, err
+			}
+			return next.ListBySequences(ctx, couponName, sequences)
+		},
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			if err := inj.check(ClaimInsert); err != nil {
+				return err
+			}
+			return next.Insert(ctx, tx, claim)
+		},
+		InsertExclusionClaimFunc: func(ctx context.Context, tx database.TxQuerier, group, userID, couponName string) error {
+			if err := inj.check(ClaimInsertExclusion); err != nil {
+				return err
+			}
+			return next.InsertExclusionClaim(ctx, tx, group, userID, couponName)
+		},
+		ClaimedUsersFunc: func(ctx context.Context, tx database.TxQuerier, couponName string, userIDs []string) ([]string, error) {
+			if err := inj.check(ClaimClaimedUsers); err != nil {
+				return nil, err
+			}
+			return next.ClaimedUsers(ctx, tx, couponName, userIDs)
+		},
+		GetClaimedUsersFunc: func(ctx context.Context, couponName string, userIDs []string) ([]string, error) {
+			if err := inj.check(ClaimGetClaimedUsers); err != nil {
+				return nil, err
+			}
+			return next.GetClaimedUsers(ctx, couponName, userIDs)
+		},
+		HasClaimedFunc: func(ctx context.Context, userID, couponName string) (bool, error) {
+			if err := inj.check(ClaimHasClaimed); err != nil {
+				return false, err
+			}
+			return next.HasClaimed(ctx, userID, couponName)
+		},
+		GetClaimFunc: func(ctx context.Context, userID, couponName string) (*model.Claim, error) {
+			if err := inj.check(ClaimGetClaim); err != nil {
+				return nil, err
+			}
+			return next.GetClaim(ctx, userID, couponName)
+		},
+	}
+}
+
+// UserClaimRepository wraps next so its calls can fail through inj.
+func UserClaimRepository(next ports.UserClaimRepository, inj *Injector) *mocks.UserClaimRepositoryMock {
+	return &mocks.UserClaimRepositoryMock{
+		PseudonymizeUserFunc: func(ctx context.Context, tx database.TxQuerier, userID, pseudonym string) (int64, error) {
+			if err := inj.check(UserClaimPseudonymize); err != nil {
+				return 0, err
+			}
+			return next.PseudonymizeUser(ctx, tx, userID, pseudonym)
+		},
+	}
+}
+
+// AuditRepository wraps next so its calls can fail through inj.
+func AuditRepository(next ports.AuditRepository, inj *Injector) *mocks.AuditRepositoryMock {
+	return &mocks.AuditRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, entry *model.AuditEntry) error {
+			if err := inj.check(AuditInsert); err != nil {
+				return err
+			}
+			return next.Insert(ctx, tx, entry)
+		},
+		ListBySubjectFunc: func(ctx context.Context, subject string, actions []string, after *model.AuditPosition, limit int) ([]model.AuditEntry, error) {
+			if err := inj.check(AuditListBySubject); err != nil {
+				return nil, err
+			}
+			return next.ListBySubject(ctx, subject, actions, after, limit)
+		},
+	}
+}
+
+// TxBeginner wraps next so Begin, and Commit on the transactions it returns, can fail
+// through inj. A failed Commit rolls the transaction back, so the database ends up as
+// if the commit had been lost.
+func TxBeginner(next ports.TxBeginner, inj *Injector) *mocks.TxBeginnerMock {
+	return &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			if err := inj.check(TxBegin); err != nil {
+				return nil, err
+			}
+			tx, err := next.Begin(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return &faultyTx{Tx: tx, inj: inj}, nil
+		},
+	}
+}
+
+// TxHooks returns Transactor hooks making Begin and Commit fail through inj, like
+// TxBeginner but for any backend (e.g. MySQL, whose transactions are not pgx.Tx):
+//
+//	tr := database.NewSQLTransactor(db, database.MySQL)
+//	tr.SetHooks(faults.TxHooks(inj))
+func TxHooks(inj *Injector) database.TxHooks {
+	return database.TxHooks{
+		Begin:  func(context.Context) error { return inj.check(TxBegin) },
+		Commit: func(context.Context) error { return inj.check(TxCommit) },
+	}
+}
+
+// faultyTx is a pgx.Tx whose Commit can fail through inj.
+type faultyTx struct {
+	pgx.Tx
+	inj *Injector
+}
+
+func (t *faultyTx) Commit(ctx context.Context) error {
+	if err := t.inj.check(TxCommit); err != nil {
+		_ = t.Tx.Rollback(ctx)
+		return err
+	}
+	return t.Tx.Commit(ctx)
+}
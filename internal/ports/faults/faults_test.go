@@ -0,0 +1,180 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// nextCouponRepository returns a mock standing in for the real repository.
+func nextCouponRepository() *mocks.CouponRepositoryMock {
+	return &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			return nil
+		},
+	}
+}
+
+func TestInjector_NoFaultPassesThrough(t *testing.T) {
+	inj := NewInjector()
+	next := nextCouponRepository()
+	repo := CouponRepository(next, inj)
+
+	coupon, err := repo.GetByName(context.Background(), "PROMO_SUPER")
+
+	require.NoError(t, err)
+	assert.Equal(t, "PROMO_SUPER", coupon.Name)
+	assert.Len(t, next.GetByNameCalls(), 1)
+	assert.Equal(t, 1, inj.Calls(CouponGetByName))
+}
+
+func TestInjector_Fail(t *testing.T) {
+	inj := NewInjector()
+	inj.Fail(CouponDecrementStock, ErrInjected)
+	next := nextCouponRepository()
+	repo := CouponRepository(next, inj)
+
+	for range 3 {
+		err := repo.DecrementStock(context.Background(), nil, "PROMO_SUPER")
+		assert.ErrorIs(t, err, ErrInjected)
+	}
+	assert.Empty(t, next.DecrementStockCalls(), "failed calls must not reach the wrapped repository")
+	assert.Len(t, repo.DecrementStockCalls(), 3)
+	assert.Equal(t, 3, inj.Calls(CouponDecrementStock))
+
+	// Other methods are unaffected
+	_, err := repo.GetByName(context.Background(), "PROMO_SUPER")
+	assert.NoError(t, err)
+}
+
+func TestInjector_AfterAndTimes(t *testing.T) {
+	inj := NewInjector()
+	inj.Fail(CouponDecrementStock, ErrInjected, After(2), Times(1))
+	repo := CouponRepository(nextCouponRepository(), inj)
+
+	var results []bool
+	for range 5 {
+		results = append(results, repo.DecrementStock(context.Background(), nil, "PROMO_SUPER") == nil)
+	}
+
+	assert.Equal(t, []bool{true, true, false, true, true}, results)
+}
+
+func TestInjector_Clear(t *testing.T) {
+	inj := NewInjector()
+	inj.Fail(CouponGetByName, ErrInjected)
+	repo := CouponRepository(nextCouponRepository(), inj)
+
+	_, err := repo.GetByName(context.Background(), "PROMO_SUPER")
+	require.ErrorIs(t, err, ErrInjected)
+
+	inj.Clear()
+	_, err = repo.GetByName(context.Background(), "PROMO_SUPER")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, inj.Calls(CouponGetByName))
+}
+
+func TestInjector_Concurrent(t *testing.T) {
+	inj := NewInjector()
+	inj.Fail(CouponDecrementStock, ErrInjected, Times(10))
+	repo := CouponRepository(nextCouponRepository(), inj)
+
+	var mu sync.Mutex
+	failures := 0
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			if err := repo.DecrementStock(context.Background(), nil, "PROMO_SUPER"); err != nil {
+				mu.Lock()
+				failures++
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, failures)
+	assert.Equal(t, 50, inj.Calls(CouponDecrementStock))
+}
+
+func TestWrappers_ForwardErrorsFromNext(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	next := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			return dbErr
+		},
+	}
+	repo := ClaimRepository(next, NewInjector())
+
+	err := repo.Insert(context.Background(), nil, &model.Claim{})
+
+	assert.ErrorIs(t, err, dbErr)
+}
+
+func TestTxBeginner(t *testing.T) {
+	var committed, rolledBack bool
+	tx := &mocks.TxMock{
+		CommitFunc:   func(ctx context.Context) error { committed = true; return nil },
+		RollbackFunc: func(ctx context.Context) error { rolledBack = true; return nil },
+	}
+	next := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return tx, nil },
+	}
+
+	t.Run("begin fails", func(t *testing.T) {
+		inj := NewInjector()
+		inj.Fail(TxBegin, ErrInjected, Times(1))
+		pool := TxBeginner(next, inj)
+
+		_, err := pool.Begin(context.Background())
+		assert.ErrorIs(t, err, ErrInjected)
+		assert.Empty(t, next.BeginCalls())
+	})
+
+	t.Run("commit fails and rolls back", func(t *testing.T) {
+		inj := NewInjector()
+		inj.Fail(TxCommit, ErrInjected)
+		pool := TxBeginner(next, inj)
+
+		got, err := pool.Begin(context.Background())
+		require.NoError(t, err)
+		assert.ErrorIs(t, got.Commit(context.Background()), ErrInjected)
+		assert.False(t, committed)
+		assert.True(t, rolledBack)
+	})
+
+	t.Run("commit passes through", func(t *testing.T) {
+		pool := TxBeginner(next, NewInjector())
+
+		got, err := pool.Begin(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, got.Commit(context.Background()))
+		assert.True(t, committed)
+	})
+}
+
+func TestTxHooks(t *testing.T) {
+	inj := NewInjector()
+	inj.Fail(TxBegin, ErrInjected, Times(1))
+	inj.Fail(TxCommit, ErrInjected, Times(1))
+	hooks := TxHooks(inj)
+
+	assert.ErrorIs(t, hooks.Begin(context.Background()), ErrInjected)
+	assert.NoError(t, hooks.Begin(context.Background()))
+	assert.ErrorIs(t, hooks.Commit(context.Background()), ErrInjected)
+	assert.NoError(t, hooks.Commit(context.Background()))
+}
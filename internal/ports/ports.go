@@ -0,0 +1,241 @@
+// Package ports declares the interfaces the service layer depends on. Keeping them in
+// one place lets the repositories, the generated test doubles in ports/mocks and the
+// error-injection wrappers in ports/faults all implement the same contracts.
+//
+// After changing an interface, regenerate the mocks with `make mocks`.
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/claimbuffer"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+)
+
+// CouponRepository defines coupon data access.
+type CouponRepository interface {
+	Insert(ctx context.Context, coupon *model.Coupon) error
+	GetByName(ctx context.Context, name string) (*model.Coupon, error)
+	List(ctx context.Context, filter model.CouponFilter) ([]model.Coupon, error)
+	Names(ctx context.Context) ([]string, error)
+	UpdateTags(ctx context.Context, name string, tags []string) error
+	GetCouponForUpdate(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error)
+	DecrementStock(ctx context.Context, tx database.TxQuerier, name string) error
+	AdvanceClaimSequence(ctx context.Context, tx database.TxQuerier, name string) error
+	DecrementBudget(ctx context.Context, tx database.TxQuerier, name string) error
+	DecrementChannelStock(ctx context.Context, tx database.TxQuerier, name, channel string) error
+	CountRegionClaim(ctx context.Context, tx database.TxQuerier, name, region string) error
+	RecordClaimStats(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error
+	InsertTx(ctx context.Context, tx database.TxQuerier, coupon *model.Coupon) error
+	UpdateTagsTx(ctx context.Context, tx database.TxQuerier, name string, tags []string) error
+	ListForUpdate(ctx context.Context, tx database.TxQuerier) ([]model.Coupon, error)
+	TopUp(ctx context.Context, tx database.TxQuerier, name string, delta int) error
+	SetDisabled(ctx context.Context, tx database.TxQuerier, name string, disabled bool) error
+	SetHighProfile(ctx context.Context, tx database.TxQuerier, name string, highProfile bool) error
+}
+
+// ClaimRepository defines claim data access for coupon operations.
+type ClaimRepository interface {
+	GetUsersByCoupon(ctx context.Context, couponName string, limit int) ([]string, error)
+	CountByCoupon(ctx context.Context, couponName string) (int, error)
+	// ListByCoupon returns up to limit claims of couponName after claim sequence
+	// afterSequence, in claim order.
+	ListByCoupon(ctx context.Context, couponName string, afterSequence, limit int) ([]model.Claim, error)
+	ListBySequences(ctx context.Context, couponName string, sequences []int) ([]model.Claim, error)
+	Insert(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error
+	// InsertExclusionClaim records userID's claim of couponName as their one claim of
+	// the exclusion group. Returns apperr.ErrExclusionGroupClaimed if they have one;
+	// the transaction stays usable.
+	InsertExclusionClaim(ctx context.Context, tx database.TxQuerier, group, userID, couponName string) error
+	ClaimedUsers(ctx context.Context, tx database.TxQuerier, couponName string, userIDs []string) ([]string, error)
+	GetClaimedUsers(ctx context.Context, couponName string, userIDs []string) ([]string, error)
+	HasClaimed(ctx context.Context, userID, couponName string) (bool, error)
+	GetClaim(ctx context.Context, userID, couponName string) (*model.Claim, error)
+}
+
+// UserClaimRepository defines the claim data access needed for user data requests.
+type UserClaimRepository interface {
+	PseudonymizeUser(ctx context.Context, tx database.TxQuerier, userID, pseudonym string) (int64, error)
+}
+
+// AuditRepository defines audit log data access.
+type AuditRepository interface {
+	Insert(ctx context.Context, tx database.TxQuerier, entry *model.AuditEntry) error
+	// ListBySubject returns up to limit entries about subject with one of actions, newest
+	// first, starting after the entry at after (nil for the newest).
+	ListBySubject(ctx context.Context, subject string, actions []string, after *model.AuditPosition, limit int) ([]model.AuditEntry, error)
+}
+
+// WebhookRepository defines webhook subscription data access.
+type WebhookRepository interface {
+	Insert(ctx context.Context, sub *model.WebhookSubscription) error
+	// List returns up to limit subscriptions with IDs above afterID, ordered by ID, with
+	// their secrets.
+	List(ctx context.Context, afterID int64, limit int) ([]model.WebhookSubscription, error)
+	ListForEvent(ctx context.Context, eventType string) ([]model.WebhookSubscription, error)
+	Get(ctx context.Context, id int64) (*model.WebhookSubscription, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// LeaderboardRepository defines campaign leaderboard data access.
+type LeaderboardRepository interface {
+	// PendingCoupons returns up to limit coupons with claims not yet counted, by name.
+	PendingCoupons(ctx context.Context, limit int) ([]string, error)
+	// CountClaims counts up to maxClaims uncounted claims of a coupon into the
+	// leaderboards of its tags within tx and returns how many it counted.
+	CountClaims(ctx context.Context, tx database.TxQuerier, couponName string, maxClaims int) (int, error)
+	// Top returns the limit users with the most claims in campaign, most first.
+	Top(ctx context.Context, campaign string, limit int) ([]model.LeaderboardEntry, error)
+	// Totals returns the claims and distinct claimers counted for campaign.
+	Totals(ctx context.Context, campaign string) (claims, claimers int64, err error)
+	// PseudonymizeUser replaces userID with pseudonym on the user's leaderboard entries
+	// within tx and returns how many it changed.
+	PseudonymizeUser(ctx context.Context, tx database.TxQuerier, userID, pseudonym string) (int64, error)
+}
+
+// CampaignCapRepository defines campaign claim cap data access.
+type CampaignCapRepository interface {
+	// Set creates campaign's cap or changes its claim_cap, keeping what was claimed.
+	Set(ctx context.Context, campaign string, claimCap int) (*model.CampaignCap, error)
+	// Get returns campaign's cap, or nil if it has none.
+	Get(ctx context.Context, campaign string) (*model.CampaignCap, error)
+	// Delete removes campaign's cap. Returns apperr.ErrCampaignCapNotFound if it has none.
+	Delete(ctx context.Context, campaign string) error
+	// LockCaps locks the caps of those campaigns that have one within tx, in campaign
+	// order, and returns them.
+	LockCaps(ctx context.Context, tx database.TxQuerier, campaigns []string) ([]model.CampaignCap, error)
+	// AddClaims counts n claims against each of campaigns' caps within tx.
+	AddClaims(ctx context.Context, tx database.TxQuerier, campaigns []string, n int) error
+}
+
+// AllowlistRepository defines coupon allowlist data access.
+type AllowlistRepository interface {
+	// Replace replaces couponName's allowlist with entries within tx.
+	// Returns apperr.ErrCouponNotFound if the coupon does not exist.
+	Replace(ctx context.Context, tx database.TxQuerier, couponName string, entries []model.AllowlistEntry) error
+	// List returns up to limit entries of couponName's allowlist with user IDs after
+	// afterUserID ("" for the first), in user ID order; empty if it has none.
+	List(ctx context.Context, couponName, afterUserID string, limit int) ([]model.AllowlistEntry, error)
+	// Delete removes couponName's allowlist. Returns apperr.ErrAllowlistNotFound if it has none.
+	Delete(ctx context.Context, couponName string) error
+	// Lookup returns userID's entry on couponName's allowlist within tx, or nil, and
+	// whether the coupon has an allowlist at all.
+	Lookup(ctx context.Context, tx database.TxQuerier, couponName, userID string) (entry *model.AllowlistEntry, restricted bool, err error)
+	// PseudonymizeUser replaces userID with pseudonym on the user's allowlist entries
+	// within tx and returns how many it changed.
+	PseudonymizeUser(ctx context.Context, tx database.TxQuerier, userID, pseudonym string) (int64, error)
+}
+
+// CouponTombstoneRepository defines data access for deleted coupons, which are kept as
+// tombstones hidden from CouponRepository until purged.
+type CouponTombstoneRepository interface {
+	// Tombstone marks the coupon name deleted and returns when.
+	// Returns apperr.ErrCouponNotFound if it does not exist or is already deleted.
+	Tombstone(ctx context.Context, name string) (time.Time, error)
+	// Restore clears the deletion of the coupon name if it was deleted within window.
+	// Returns apperr.ErrDeletedCouponNotFound otherwise.
+	Restore(ctx context.Context, name string, window time.Duration) error
+	// Expired returns up to limit coupons deleted longer than window ago, oldest first.
+	Expired(ctx context.Context, window time.Duration, limit int) ([]string, error)
+	// Purge removes the coupon name and the rows referencing it within tx if it was
+	// deleted longer than window ago, and reports whether it did.
+	Purge(ctx context.Context, tx database.TxQuerier, name string, window time.Duration) (bool, error)
+}
+
+// CouponArchiveRepository defines data access for the coupon archive, which keeps
+// deleted coupons once they are purged.
+type CouponArchiveRepository interface {
+	// Archive locks the coupon name within tx if it was deleted longer than window ago,
+	// copies it to the archive (replacing an earlier coupon of the name) and reports
+	// whether it did.
+	Archive(ctx context.Context, tx database.TxQuerier, name string, window time.Duration) (bool, error)
+	// GetArchived returns the archived coupon name, or nil if there is none.
+	GetArchived(ctx context.Context, name string) (*model.ArchivedCoupon, error)
+}
+
+// LoadTestRepository defines data access for disposable load test coupons.
+type LoadTestRepository interface {
+	// CreateNamespace records the load test namespace ns, to be cleaned up by its ExpiresAt.
+	// Returns apperr.ErrLoadTestNamespaceExists if it is already recorded.
+	CreateNamespace(ctx context.Context, ns *model.LoadTestNamespace) error
+	// ExpiredNamespaces returns up to limit namespaces past their expiry, oldest first.
+	ExpiredNamespaces(ctx context.Context, limit int) ([]string, error)
+	// PurgeCoupons removes up to limit coupons named with prefix, their claims and the
+	// other rows referencing them within tx, and returns their names.
+	PurgeCoupons(ctx context.Context, tx database.TxQuerier, prefix string, limit int) ([]string, error)
+	// DeleteNamespace forgets the namespace ns.
+	// Returns apperr.ErrLoadTestNamespaceNotFound if it is not recorded.
+	DeleteNamespace(ctx context.Context, ns string) error
+}
+
+// ClaimRetentionRepository defines data access for enforcing coupons' claim retention.
+type ClaimRetentionRepository interface {
+	// RetentionPolicies returns the claim retention of every coupon that has one, in
+	// coupon name order.
+	RetentionPolicies(ctx context.Context) ([]model.CouponClaimRetention, error)
+	// ExpireClaims applies action (model.RetentionPurge or model.RetentionAnonymize) to
+	// up to limit claims of couponName made before cutoff within tx, skipping claims
+	// already anonymized, and returns how many it changed.
+	ExpireClaims(ctx context.Context, tx database.TxQuerier, couponName, action string, cutoff time.Time, limit int) (int, error)
+}
+
+// AnalyticsExportRepository defines the reads of the analytics export.
+type AnalyticsExportRepository interface {
+	// ExportCoupons calls fn with every coupon, deleted ones excepted, in name order,
+	// stopping at the first error fn returns.
+	ExportCoupons(ctx context.Context, fn func(*model.Coupon) error) error
+	// ExportClaims calls fn with every claim made in [from, to), in claim time order,
+	// stopping at the first error fn returns.
+	ExportClaims(ctx context.Context, from, to time.Time, fn func(*model.Claim) error) error
+}
+
+// ClaimMoveRepository defines data access for verifying the move of claims to claims_v2.
+type ClaimMoveRepository interface {
+	// CompareClaims compares the claims in claims and claims_v2 of up to limit coupons
+	// named after after, in name order.
+	CompareClaims(ctx context.Context, after string, limit int) ([]model.ClaimComparison, error)
+}
+
+// APIKeyUsageRepository defines data access for the monthly claim counts of API keys
+// (satisfies apikey.UsageStore).
+type APIKeyUsageRepository interface {
+	// AddUsage adds the claims of deltas, by key ID, to the keys' counts for month
+	// ("2006-01", UTC) and returns the count of every key with claims in month.
+	AddUsage(ctx context.Context, month string, deltas map[string]int64) (map[string]int64, error)
+}
+
+// JobQueue enqueues background jobs (satisfied by *jobs.Queue).
+type JobQueue interface {
+	Enqueue(ctx context.Context, queue string, payload any, opts ...jobs.EnqueueOption) (int64, error)
+}
+
+// WebhookSender delivers a signed event to a subscriber endpoint (satisfied by *webhook.Sender).
+type WebhookSender interface {
+	Send(ctx context.Context, sub *model.WebhookSubscription, event *model.CouponEvent) error
+}
+
+// TxBeginner begins database transactions (satisfied by *pgxpool.Pool).
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Transactor runs fn in a database transaction, committing it if fn returns nil
+// (satisfied by *database.Transactor). Depending on the backend fn may run more than
+// once, so it must only have effects through tx.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(tx database.TxQuerier) error) error
+}
+
+// ClaimBuffer is the durable claim queue used in store-and-forward mode
+// (satisfied by *claimbuffer.Buffer).
+type ClaimBuffer interface {
+	Append(req *model.ClaimCouponRequest) error
+	Pending() ([]claimbuffer.Entry, error)
+	Ack(n int) error
+	Len() int
+}
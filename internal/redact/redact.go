@@ -0,0 +1,121 @@
+// Package redact hides personal data (user IDs, coupon names) in logs and error messages.
+//
+// In hash mode values are replaced by a keyed HMAC-SHA256, so the same value always
+// redacts to the same token and log lines can still be correlated without exposing it.
+// In truncate mode only a short prefix of the value is kept.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Mode selects how values are redacted.
+type Mode string
+
+// Redaction modes.
+const (
+	ModeOff      Mode = "off"
+	ModeHash     Mode = "hash"
+	ModeTruncate Mode = "truncate"
+)
+
+const (
+	hashLength   = 16 // Hex characters of the HMAC kept in hash mode
+	truncateKeep = 4  // Leading characters kept in truncate mode
+	mask         = "***"
+)
+
+// MinKeyLength is the minimum HMAC key length accepted for hash mode.
+const MinKeyLength = 16
+
+// Redactor redacts values according to its mode. The zero value does not redact.
+type Redactor struct {
+	mode Mode
+	key  []byte
+}
+
+// New creates a Redactor. Hash mode requires a key of at least MinKeyLength bytes.
+func New(mode Mode, key string) (*Redactor, error) {
+	switch mode {
+	case ModeOff, ModeTruncate:
+		return &Redactor{mode: mode}, nil
+	case ModeHash:
+		if len(key) < MinKeyLength {
+			return nil, fmt.Errorf("hash redaction requires a key of at least %d bytes", MinKeyLength)
+		}
+		return &Redactor{mode: mode, key: []byte(key)}, nil
+	}
+	return nil, fmt.Errorf("unknown redaction mode %q", mode)
+}
+
+// Enabled reports whether the Redactor changes values.
+func (r *Redactor) Enabled() bool {
+	return r.mode == ModeHash || r.mode == ModeTruncate
+}
+
+// Value returns the redacted form of s. Empty values are returned unchanged.
+func (r *Redactor) Value(s string) string {
+	if s == "" {
+		return s
+	}
+	switch r.mode {
+	case ModeHash:
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write([]byte(s))
+		return hex.EncodeToString(mac.Sum(nil))[:hashLength]
+	case ModeTruncate:
+		runes := []rune(s)
+		if len(runes) <= truncateKeep {
+			return mask
+		}
+		return string(runes[:truncateKeep]) + mask
+	}
+	return s
+}
+
+// Error returns err's message with every occurrence of values replaced by its redacted form.
+// Errors often embed the values they concern (e.g. "update tags for PROMO: ..."), so callers
+// pass the user IDs and coupon names of the request being logged.
+func (r *Redactor) Error(err error, values ...string) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	if !r.Enabled() {
+		return msg
+	}
+
+	// Replace longer values first so a value containing another is not partially redacted.
+	sorted := append([]string(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, v := range sorted {
+		if v != "" {
+			msg = strings.ReplaceAll(msg, v, r.Value(v))
+		}
+	}
+	return msg
+}
+
+// std is the process-wide Redactor used by the package-level functions.
+// Configured once at startup, like the global zerolog logger.
+var std = &Redactor{mode: ModeOff}
+
+// SetDefault replaces the process-wide Redactor. Not safe for concurrent use with
+// the package-level functions; call it during startup only.
+func SetDefault(r *Redactor) {
+	std = r
+}
+
+// Enabled reports whether the process-wide Redactor changes values.
+func Enabled() bool { return std.Enabled() }
+
+// Value redacts s with the process-wide Redactor.
+func Value(s string) string { return std.Value(s) }
+
+// Error redacts values in err's message with the process-wide Redactor.
+func Error(err error, values ...string) string { return std.Error(err, values...) }
@@ -0,0 +1,79 @@
+package redact
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func TestNew(t *testing.T) {
+	for _, mode := range []Mode{ModeOff, ModeTruncate, ModeHash} {
+		_, err := New(mode, testKey)
+		assert.NoError(t, err, mode)
+	}
+
+	_, err := New(ModeHash, "short")
+	assert.ErrorContains(t, err, "at least 16 bytes")
+
+	_, err = New("mask", testKey)
+	assert.ErrorContains(t, err, `unknown redaction mode "mask"`)
+}
+
+func TestRedactor_Value(t *testing.T) {
+	hash, err := New(ModeHash, testKey)
+	require.NoError(t, err)
+	otherKey, err := New(ModeHash, testKey+"x")
+	require.NoError(t, err)
+	truncate, err := New(ModeTruncate, "")
+	require.NoError(t, err)
+
+	t.Run("hash is stable and keyed", func(t *testing.T) {
+		v := hash.Value("user_001")
+		assert.Len(t, v, hashLength)
+		assert.NotContains(t, v, "user")
+		assert.Equal(t, v, hash.Value("user_001"), "same value must correlate")
+		assert.NotEqual(t, v, hash.Value("user_002"))
+		assert.NotEqual(t, v, otherKey.Value("user_001"))
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		assert.Equal(t, "user***", truncate.Value("user_001"))
+		assert.Equal(t, "***", truncate.Value("abcd"), "short values are fully masked")
+		assert.Equal(t, "café***", truncate.Value("cafébar"), "truncates on characters, not bytes")
+	})
+
+	t.Run("off and empty", func(t *testing.T) {
+		assert.Equal(t, "user_001", (&Redactor{}).Value("user_001"))
+		assert.Equal(t, "", hash.Value(""))
+	})
+}
+
+func TestRedactor_Error(t *testing.T) {
+	truncate, err := New(ModeTruncate, "")
+	require.NoError(t, err)
+	err = errors.New("insert claim for user_001 on PROMO_SUPER_XL: PROMO_SUPER_XL locked")
+
+	assert.Equal(t, "insert claim for user*** on PROM***: PROM*** locked",
+		truncate.Error(err, "user_001", "PROMO", "PROMO_SUPER_XL", ""))
+	assert.Equal(t, err.Error(), (&Redactor{}).Error(err, "user_001"))
+	assert.Equal(t, "", truncate.Error(nil, "user_001"))
+}
+
+func TestSetDefault(t *testing.T) {
+	t.Cleanup(func() { SetDefault(&Redactor{mode: ModeOff}) })
+
+	assert.False(t, Enabled())
+	assert.Equal(t, "user_001", Value("user_001"))
+
+	truncate, err := New(ModeTruncate, "")
+	require.NoError(t, err)
+	SetDefault(truncate)
+
+	assert.True(t, Enabled())
+	assert.Equal(t, "user***", Value("user_001"))
+	assert.Equal(t, "failed for user***", Error(errors.New("failed for user_001"), "user_001"))
+}
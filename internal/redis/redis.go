@@ -0,0 +1,223 @@
+// Package redis is a minimal Redis client for the state the service shares between
+// instances through Redis. It speaks just enough of the protocol (RESP2) to run
+// commands and read their replies, with a small pool of idle connections.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxIdleConns is how many idle connections a Client keeps.
+const maxIdleConns = 16
+
+// Client runs commands on one Redis server over a small pool of connections. It is
+// safe for concurrent use.
+type Client struct {
+	addr     string
+	username string
+	password string
+	db       int
+	timeout  time.Duration // Dial and command timeout when the context has no earlier deadline
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+// New creates a Client for a redis://[user:password@]host:port[/db] URL. Commands
+// time out after timeout unless their context ends earlier. Connections are dialed on
+// first use.
+func New(rawURL string, timeout time.Duration) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis URL: %w", err)
+	}
+	if u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("redis URL must look like redis://host:port/db, got %q", u.Redacted())
+	}
+	s := &Client{addr: u.Host, timeout: timeout}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if s.db, err = strconv.Atoi(db); err != nil || s.db < 0 {
+			return nil, fmt.Errorf("redis URL database must be a non-negative number, got %q", db)
+		}
+	}
+	return s, nil
+}
+
+// Close closes the idle connections.
+func (s *Client) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.idle {
+		_ = c.Close()
+	}
+	s.idle = nil
+	return nil
+}
+
+// Do runs a command on an idle or new connection and returns its reply: a string,
+// int64, []any or nil. An error reply is returned as an Error, leaving the connection
+// usable; connections that fail otherwise are closed.
+func (s *Client) Do(ctx context.Context, args ...string) (any, error) {
+	c, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.do(s.deadline(ctx), args...)
+	var redisErr Error
+	if err != nil && !errors.As(err, &redisErr) {
+		_ = c.Close()
+		return nil, err
+	}
+	s.put(c)
+	return reply, err
+}
+
+// deadline is the deadline of a command run under ctx.
+func (s *Client) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+// conn returns an idle connection, or dials, authenticates and selects the database.
+func (s *Client) conn(ctx context.Context) (*redisConn, error) {
+	s.mu.Lock()
+	if n := len(s.idle); n > 0 {
+		c := s.idle[n-1]
+		s.idle = s.idle[:n-1]
+		s.mu.Unlock()
+		return c, nil
+	}
+	s.mu.Unlock()
+
+	dialer := net.Dialer{Deadline: s.deadline(ctx)}
+	nc, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect to redis: %w", err)
+	}
+	c := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if s.password != "" {
+		args := []string{"AUTH", s.password}
+		if s.username != "" {
+			args = []string{"AUTH", s.username, s.password}
+		}
+		if _, err := c.do(s.deadline(ctx), args...); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("authenticate to redis: %w", err)
+		}
+	}
+	if s.db != 0 {
+		if _, err := c.do(s.deadline(ctx), "SELECT", strconv.Itoa(s.db)); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("select redis database: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// put returns c to the idle connections, or closes it when there are enough.
+func (s *Client) put(c *redisConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.idle) >= maxIdleConns {
+		_ = c.Close()
+		return
+	}
+	s.idle = append(s.idle, c)
+}
+
+// Error is an error reply from Redis.
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// redisConn is a connection speaking RESP2.
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// do sends a command and reads its reply: a string, int64, []any, nil or Error.
+func (c *redisConn) do(deadline time.Time, args ...string) (any, error) {
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		return nil, fmt.Errorf("write redis command: %w", err)
+	}
+	reply, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := reply.(Error); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+// read reads one reply. Error replies nested in arrays are returned as values.
+func (c *redisConn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read redis reply: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("read redis reply: empty line")
+	}
+	switch kind, rest := line[0], line[1:]; kind {
+	case '+':
+		return rest, nil
+	case '-':
+		return Error(rest), nil
+	case ':':
+		return strconv.ParseInt(rest, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, fmt.Errorf("read redis reply: %w", err)
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]any, n)
+		for i := range values {
+			if values[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("read redis reply: unexpected %q", line)
+}
@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redis/redistest"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		url     string
+		addr    string
+		db      int
+		wantErr bool
+	}{
+		{url: "redis://localhost", addr: "localhost:6379"},
+		{url: "redis://cache:6380/3", addr: "cache:6380", db: 3},
+		{url: "http://localhost:6379", wantErr: true},
+		{url: "redis:///0", wantErr: true},
+		{url: "redis://localhost/x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			c, err := New(tt.url, time.Second)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.addr, c.addr)
+			assert.Equal(t, tt.db, c.db)
+		})
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	f := redistest.NewServer(t, func(args []string) string {
+		switch args[0] {
+		case "GET":
+			return "$-1\r\n"
+		case "MGET":
+			return "*2\r\n$5\r\nhello\r\n$-1\r\n"
+		}
+		return "+OK\r\n"
+	})
+	c, err := New(f.URL(), time.Second)
+	require.NoError(t, err)
+	defer func() { _ = c.Close() }()
+
+	tests := []struct {
+		args []string
+		want any
+	}{
+		{args: []string{"SET", "k", "hello"}, want: "OK"},
+		{args: []string{"GET", "missing"}, want: nil},
+		{args: []string{"MGET", "k", "missing"}, want: []any{"hello", nil}},
+	}
+	for _, tt := range tests {
+		reply, err := c.Do(context.Background(), tt.args...)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, reply, tt.args)
+	}
+	assert.Equal(t, 1, f.Conns(), "the connection is reused")
+}
+
+func TestClient_DoErrorReply(t *testing.T) {
+	f := redistest.NewServer(t, func([]string) string { return "-WRONGTYPE wrong kind of value\r\n" })
+	c, err := New(f.URL(), time.Second)
+	require.NoError(t, err)
+
+	_, err = c.Do(context.Background(), "GET", "k")
+
+	var redisErr Error
+	require.ErrorAs(t, err, &redisErr)
+	assert.Equal(t, "redis: WRONGTYPE wrong kind of value", err.Error())
+}
@@ -0,0 +1,94 @@
+// Package redistest provides a fake Redis server for tests of Redis-backed stores.
+package redistest
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Server is a Redis server answering each command with reply(command), a raw RESP2
+// reply such as "+OK\r\n". It records the commands it receives.
+type Server struct {
+	net.Listener
+	reply func(args []string) string
+
+	mu       sync.Mutex
+	commands [][]string
+	conns    int
+}
+
+// NewServer starts a Server on a local port, closed when the test ends.
+func NewServer(t testing.TB, reply func(args []string) string) *Server {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &Server{Listener: l, reply: reply}
+	t.Cleanup(func() { _ = l.Close() })
+	go s.serve()
+	return s
+}
+
+// URL returns the redis:// URL of the server.
+func (s *Server) URL() string {
+	return "redis://" + s.Addr().String()
+}
+
+// Commands returns the commands received so far.
+func (s *Server) Commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands
+}
+
+// Conns returns how many connections were accepted.
+func (s *Server) Conns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+func (s *Server) serve() {
+	for {
+		c, err := s.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.mu.Unlock()
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer func() { _ = c.Close() }()
+	r := bufio.NewReader(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, n)
+		for i := range args {
+			line, _ = r.ReadString('\n')
+			size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			buf := make([]byte, size+2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			args[i] = string(buf[:size])
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		s.mu.Unlock()
+		_, _ = io.WriteString(c, s.reply(args))
+	}
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// AllowlistRepository provides data access for coupon allowlists: the users allowed to
+// claim a coupon, each until an optional claim-by deadline.
+type AllowlistRepository struct {
+	pool PoolInterface
+}
+
+var _ ports.AllowlistRepository = (*AllowlistRepository)(nil)
+
+// NewAllowlistRepository creates a new AllowlistRepository.
+func NewAllowlistRepository(pool *pgxpool.Pool) *AllowlistRepository {
+	return NewAllowlistRepositoryWithPool(pool)
+}
+
+// NewAllowlistRepositoryWithPool creates a new AllowlistRepository with a custom pool interface.
+// This is primarily used for testing.
+func NewAllowlistRepositoryWithPool(pool PoolInterface) *AllowlistRepository {
+	return &AllowlistRepository{pool: pool}
+}
+
+// Replace replaces couponName's allowlist with entries within tx.
+// Returns apperr.ErrCouponNotFound if the coupon does not exist.
+func (r *AllowlistRepository) Replace(ctx context.Context, tx database.TxQuerier, couponName string, entries []model.AllowlistEntry) error {
+	_, err := tx.Exec(ctx, `DELETE FROM coupon_allowlist WHERE coupon_name = $1`, couponName)
+	if err != nil {
+		return fmt.Errorf("clear allowlist %s: %w", couponName, err)
+	}
+
+	userIDs := make([]string, len(entries))
+	claimBy := make([]*time.Time, len(entries))
+	for i, e := range entries {
+		userIDs[i], claimBy[i] = e.UserID, e.ClaimBy
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO coupon_allowlist (coupon_name, user_id, claim_by)
+		SELECT $1, user_id, claim_by FROM unnest($2::text[], $3::timestamptz[]) AS e(user_id, claim_by)
+	`, couponName, userIDs, claimBy)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return apperr.ErrCouponNotFound
+		}
+		return fmt.Errorf("insert allowlist %s: %w", couponName, err)
+	}
+	return nil
+}
+
+// List returns up to limit entries of couponName's allowlist with user IDs after
+// afterUserID ("" for the first), in user ID order; empty if it has none.
+func (r *AllowlistRepository) List(ctx context.Context, couponName, afterUserID string, limit int) ([]model.AllowlistEntry, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT user_id, claim_by FROM coupon_allowlist
+		WHERE coupon_name = $1 AND user_id > $2
+		ORDER BY user_id
+		LIMIT $3
+	`, couponName, afterUserID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list allowlist %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	entries := []model.AllowlistEntry{}
+	for rows.Next() {
+		var e model.AllowlistEntry
+		if err := rows.Scan(&e.UserID, &e.ClaimBy); err != nil {
+			return nil, fmt.Errorf("scan allowlist entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate allowlist: %w", err)
+	}
+	return entries, nil
+}
+
+// Delete removes couponName's allowlist.
+// Returns apperr.ErrAllowlistNotFound if it has none.
+func (r *AllowlistRepository) Delete(ctx context.Context, couponName string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM coupon_allowlist WHERE coupon_name = $1`, couponName)
+	if err != nil {
+		return fmt.Errorf("delete allowlist %s: %w", couponName, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrAllowlistNotFound
+	}
+	return nil
+}
+
+// Lookup returns userID's entry on couponName's allowlist within tx, or nil, and
+// whether the coupon has an allowlist at all. It takes one query either way.
+func (r *AllowlistRepository) Lookup(ctx context.Context, tx database.TxQuerier, couponName, userID string) (*model.AllowlistEntry, bool, error) {
+	var restricted, listed bool
+	var claimBy *time.Time
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM coupon_allowlist WHERE coupon_name = $1), e.user_id IS NOT NULL, e.claim_by
+		FROM (SELECT 1) AS one
+		LEFT JOIN coupon_allowlist e ON e.coupon_name = $1 AND e.user_id = $2
+	`, couponName, userID).Scan(&restricted, &listed, &claimBy)
+	if err != nil {
+		return nil, false, fmt.Errorf("look up allowlist entry: %w", err)
+	}
+	if !listed {
+		return nil, restricted, nil
+	}
+	return &model.AllowlistEntry{UserID: userID, ClaimBy: claimBy}, restricted, nil
+}
+
+// PseudonymizeUser replaces userID with pseudonym on the user's allowlist entries
+// within tx. Returns the number of entries changed.
+func (r *AllowlistRepository) PseudonymizeUser(ctx context.Context, tx database.TxQuerier, userID, pseudonym string) (int64, error) {
+	tag, err := tx.Exec(ctx, `UPDATE coupon_allowlist SET user_id = $2 WHERE user_id = $1`, userID, pseudonym)
+	if err != nil {
+		return 0, fmt.Errorf("pseudonymize allowlist entries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
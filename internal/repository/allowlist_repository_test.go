@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestAllowlistRepository_Replace(t *testing.T) {
+	var statements []string
+	var insertArgs []any
+	tx := &mockTxQuerier{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		statements = append(statements, sql)
+		insertArgs = arguments
+		return pgconn.NewCommandTag("INSERT 0 2"), nil
+	}}
+	deadline := time.Unix(1700000000, 0)
+
+	err := NewAllowlistRepositoryWithPool(&mockPool{}).Replace(context.Background(), tx, "PROMO", []model.AllowlistEntry{
+		{UserID: "vip", ClaimBy: &deadline},
+		{UserID: "user"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, statements, 2)
+	assert.Contains(t, statements[0], "DELETE FROM coupon_allowlist", "the previous allowlist is replaced")
+	assert.Equal(t, []any{"PROMO", []string{"vip", "user"}, []*time.Time{&deadline, nil}}, insertArgs)
+}
+
+func TestAllowlistRepository_Replace_CouponNotFound(t *testing.T) {
+	tx := &mockTxQuerier{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		if len(arguments) > 1 {
+			return pgconn.CommandTag{}, &pgconn.PgError{Code: "23503"}
+		}
+		return pgconn.NewCommandTag("DELETE 0"), nil
+	}}
+
+	err := NewAllowlistRepositoryWithPool(&mockPool{}).Replace(context.Background(), tx, "MISSING",
+		[]model.AllowlistEntry{{UserID: "user"}})
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+}
+
+func TestAllowlistRepository_Delete_NotFound(t *testing.T) {
+	pool := &mockPool{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		return pgconn.NewCommandTag("DELETE 0"), nil
+	}}
+
+	err := NewAllowlistRepositoryWithPool(pool).Delete(context.Background(), "PROMO")
+
+	assert.ErrorIs(t, err, apperr.ErrAllowlistNotFound)
+}
+
+func TestAllowlistRepository_Lookup(t *testing.T) {
+	deadline := time.Unix(1700000000, 0)
+	tests := []struct {
+		name       string
+		restricted bool
+		listed     bool
+		want       *model.AllowlistEntry
+	}{
+		{"no allowlist", false, false, nil},
+		{"not listed", true, false, nil},
+		{"listed", true, true, &model.AllowlistEntry{UserID: "user", ClaimBy: &deadline}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedArgs []any
+			tx := &mockTxQuerier{queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+				capturedArgs = args
+				return &mockRow{scanFn: func(dest ...any) error {
+					*(dest[0].(*bool)) = tt.restricted
+					*(dest[1].(*bool)) = tt.listed
+					if tt.listed {
+						*(dest[2].(**time.Time)) = &deadline
+					}
+					return nil
+				}}
+			}}
+
+			entry, restricted, err := NewAllowlistRepositoryWithPool(&mockPool{}).Lookup(context.Background(), tx, "PROMO", "user")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.restricted, restricted)
+			assert.Equal(t, tt.want, entry)
+			assert.Equal(t, []any{"PROMO", "user"}, capturedArgs)
+		})
+	}
+}
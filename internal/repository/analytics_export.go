@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// ExportCoupons calls fn with every coupon, deleted ones excepted, in name order. It
+// reads from the read pool, so a replica serves the export when one is configured.
+func (r *ClaimRepository) ExportCoupons(ctx context.Context, fn func(*model.Coupon) error) error {
+	rows, err := r.reads.Query(ctx, `SELECT `+couponColumns+` FROM coupons WHERE deleted_at IS NULL ORDER BY name`)
+	if err != nil {
+		return fmt.Errorf("export coupons: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return fmt.Errorf("scan coupon: %w", err)
+		}
+		if err := fn(coupon); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate coupon rows: %w", err)
+	}
+	return nil
+}
+
+// ExportClaims calls fn with every claim made in [from, to), in claim time order, from
+// the read pool.
+func (r *ClaimRepository) ExportClaims(ctx context.Context, from, to time.Time, fn func(*model.Claim) error) error {
+	claimedAt := r.claimedAtExpr()
+	rows, err := r.reads.Query(ctx, `SELECT user_id, COALESCE(channel, ''), COALESCE(region, ''), claim_sequence, COALESCE(tier, ''), `+
+		claimedAt+`, coupon_name FROM `+r.table.ReadTable()+` WHERE `+claimedAt+` >= $1 AND `+claimedAt+` < $2 ORDER BY `+claimedAt, from, to)
+	if err != nil {
+		return fmt.Errorf("export claims: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var claim model.Claim
+		if err := rows.Scan(&claim.UserID, &claim.Channel, &claim.Region, &claim.Sequence, &claim.Tier, &claim.CreatedAt, &claim.CouponName); err != nil {
+			return fmt.Errorf("scan claim: %w", err)
+		}
+		if err := fn(&claim); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate claims rows: %w", err)
+	}
+	return nil
+}
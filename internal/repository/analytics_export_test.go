@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestClaimRepository_ExportCoupons(t *testing.T) {
+	var gotSQL string
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			gotSQL = sql
+			return &mockCouponRows{data: []model.Coupon{{Name: "PROMO", Amount: 10}, {Name: "SALE", Amount: 5}}}, nil
+		},
+	})
+
+	var names []string
+	err := repo.ExportCoupons(context.Background(), func(c *model.Coupon) error {
+		names = append(names, c.Name)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PROMO", "SALE"}, names)
+	assert.Contains(t, gotSQL, "FROM coupons WHERE deleted_at IS NULL ORDER BY name")
+}
+
+func TestClaimRepository_ExportCoupons_CallbackError(t *testing.T) {
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return &mockCouponRows{data: []model.Coupon{{Name: "PROMO"}, {Name: "SALE"}}}, nil
+		},
+	})
+	stop := errors.New("disk full")
+
+	calls := 0
+	err := repo.ExportCoupons(context.Background(), func(c *model.Coupon) error {
+		calls++
+		return stop
+	})
+
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestClaimRepository_ExportClaims(t *testing.T) {
+	from := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	at := from.Add(time.Hour)
+	tests := []struct {
+		name  string
+		phase database.MigrationPhase
+		want  string
+	}{
+		{
+			name:  "claims",
+			phase: database.PhaseOld,
+			want:  "created_at, coupon_name FROM claims WHERE created_at >= $1 AND created_at < $2 ORDER BY created_at",
+		},
+		{
+			name:  "claims_v2",
+			phase: database.PhaseNew,
+			want:  "claimed_at, coupon_name FROM claims_v2 WHERE claimed_at >= $1 AND claimed_at < $2 ORDER BY claimed_at",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSQL string
+			var gotArgs []any
+			repo := NewClaimRepositoryWithPool(&mockClaimPool{
+				queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					gotSQL, gotArgs = sql, args
+					return &mockClaimListRows{claims: []model.Claim{{UserID: "u1", Sequence: 1, CreatedAt: at}}}, nil
+				},
+			})
+			move := database.ClaimsV2
+			move.Phase = tt.phase
+			repo.SetTableMoves(map[string]database.TableMove{move.Name(): move})
+
+			var claims []model.Claim
+			err := repo.ExportClaims(context.Background(), from, to, func(c *model.Claim) error {
+				claims = append(claims, *c)
+				return nil
+			})
+
+			require.NoError(t, err)
+			assert.Contains(t, gotSQL, tt.want)
+			assert.Equal(t, []any{from, to}, gotArgs)
+			require.Len(t, claims, 1)
+			assert.Equal(t, "u1", claims[0].UserID)
+			assert.Equal(t, at, claims[0].CreatedAt)
+		})
+	}
+}
+
+func TestClaimRepository_ExportClaims_QueryError(t *testing.T) {
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return nil, errors.New("connection refused")
+		},
+	})
+
+	err := repo.ExportClaims(context.Background(), time.Now(), time.Now(), func(c *model.Claim) error { return nil })
+
+	assert.ErrorContains(t, err, "export claims: connection refused")
+}
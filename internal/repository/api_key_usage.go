@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+)
+
+// APIKeyUsageRepository provides data access for the monthly claim counts of API keys.
+type APIKeyUsageRepository struct {
+	pool PoolInterface
+}
+
+var _ ports.APIKeyUsageRepository = (*APIKeyUsageRepository)(nil)
+
+// NewAPIKeyUsageRepository creates a new APIKeyUsageRepository.
+func NewAPIKeyUsageRepository(pool *pgxpool.Pool) *APIKeyUsageRepository {
+	return NewAPIKeyUsageRepositoryWithPool(pool)
+}
+
+// NewAPIKeyUsageRepositoryWithPool creates a new APIKeyUsageRepository with a custom pool interface.
+// This is primarily used for testing.
+func NewAPIKeyUsageRepositoryWithPool(pool PoolInterface) *APIKeyUsageRepository {
+	return &APIKeyUsageRepository{pool: pool}
+}
+
+// AddUsage adds deltas to the keys' counts for month in one statement, so that the
+// replicas flushing concurrently add up, and returns the month's counts.
+func (r *APIKeyUsageRepository) AddUsage(ctx context.Context, month string, deltas map[string]int64) (map[string]int64, error) {
+	ids := make([]string, 0, len(deltas))
+	claims := make([]int64, 0, len(deltas))
+	for id, n := range deltas {
+		ids = append(ids, id)
+		claims = append(claims, n)
+	}
+	// The upserted rows are not visible to the SELECT of the same statement, so their
+	// counts come from RETURNING and the other keys' from the table
+	rows, err := r.pool.Query(ctx, `
+		WITH added AS (
+			INSERT INTO api_key_usage (key_id, month, claims)
+			SELECT key_id, $1, claims FROM unnest($2::text[], $3::bigint[]) AS d(key_id, claims)
+			ORDER BY key_id
+			ON CONFLICT (key_id, month) DO UPDATE
+				SET claims = api_key_usage.claims + EXCLUDED.claims, updated_at = NOW()
+			RETURNING key_id, claims
+		)
+		SELECT key_id, claims FROM added
+		UNION ALL
+		SELECT key_id, claims FROM api_key_usage
+		WHERE month = $1 AND key_id NOT IN (SELECT key_id FROM added)`,
+		month, ids, claims)
+	if err != nil {
+		return nil, fmt.Errorf("add API key usage for %s: %w", month, err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]int64)
+	for rows.Next() {
+		var id string
+		var n int64
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, fmt.Errorf("scan API key usage: %w", err)
+		}
+		totals[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("add API key usage for %s: %w", month, err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUsageRows returns the key IDs of data with a count of 1 each.
+type mockUsageRows struct {
+	mockClaimRows
+}
+
+func (m *mockUsageRows) Scan(dest ...any) error {
+	*(dest[0].(*string)) = m.data[m.index-1]
+	*(dest[1].(*int64)) = int64(m.index)
+	return nil
+}
+
+func TestAPIKeyUsageRepository_AddUsage(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	pool := &mockPool{queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		capturedSQL, capturedArgs = sql, args
+		return &mockUsageRows{mockClaimRows{data: []string{"acme", "shop"}}}, nil
+	}}
+
+	totals, err := NewAPIKeyUsageRepositoryWithPool(pool).AddUsage(context.Background(), "2026-10", map[string]int64{"acme": 3})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"acme": 1, "shop": 2}, totals)
+	assert.Contains(t, capturedSQL, "ON CONFLICT (key_id, month) DO UPDATE")
+	assert.Contains(t, capturedSQL, "SET claims = api_key_usage.claims + EXCLUDED.claims")
+	assert.Equal(t, []any{"2026-10", []string{"acme"}, []int64{3}}, capturedArgs)
+}
+
+func TestAPIKeyUsageRepository_AddUsage_Error(t *testing.T) {
+	pool := &mockPool{queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		return nil, errors.New("connection refused")
+	}}
+
+	_, err := NewAPIKeyUsageRepositoryWithPool(pool).AddUsage(context.Background(), "2026-10", nil)
+
+	assert.ErrorContains(t, err, "add API key usage for 2026-10")
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// AuditRepository provides data access for the audit log.
+// Entries are always written inside the transaction of the operation they record.
+type AuditRepository struct {
+	pool PoolInterface
+}
+
+var _ ports.AuditRepository = (*AuditRepository)(nil)
+
+// NewAuditRepository creates a new AuditRepository.
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return NewAuditRepositoryWithPool(pool)
+}
+
+// NewAuditRepositoryWithPool creates a new AuditRepository with a custom pool interface.
+// This is primarily used for testing.
+func NewAuditRepositoryWithPool(pool PoolInterface) *AuditRepository {
+	return &AuditRepository{pool: pool}
+}
+
+// Insert appends an entry to the audit log within a transaction.
+// Nil details are stored as an empty object.
+func (r *AuditRepository) Insert(ctx context.Context, tx database.TxQuerier, entry *model.AuditEntry) error {
+	details := entry.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+
+	_, err := tx.Exec(ctx, `INSERT INTO audit_log (action, subject, details) VALUES ($1, $2, $3)`,
+		entry.Action, entry.Subject, details)
+	if err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListBySubject returns up to limit entries about subject with one of actions, newest
+// first, starting after the entry at after (nil for the newest).
+func (r *AuditRepository) ListBySubject(ctx context.Context, subject string, actions []string, after *model.AuditPosition, limit int) ([]model.AuditEntry, error) {
+	query := `SELECT id, action, subject, details, created_at FROM audit_log WHERE subject = $1 AND action = ANY($2)`
+	args := []any{subject, actions}
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		query += ` AND (created_at, id) < ($3, $4)`
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries of %s: %w", subject, err)
+	}
+	defer rows.Close()
+
+	entries := []model.AuditEntry{}
+	for rows.Next() {
+		var e model.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Action, &e.Subject, &e.Details, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list audit entries of %s: %w", subject, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestAuditRepository_Insert(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mockTx := &mockTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+
+	repo := NewAuditRepositoryWithPool(nil)
+	err := repo.Insert(context.Background(), mockTx, &model.AuditEntry{
+		Action:  model.AuditActionUserDataErased,
+		Subject: "erased-abc",
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "INSERT INTO audit_log")
+	assert.Equal(t, []any{"user_data_erased", "erased-abc", map[string]any{}}, capturedArgs, "nil details stored as empty object")
+}
+
+func TestAuditRepository_Insert_DatabaseError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	mockTx := &mockTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			return pgconn.CommandTag{}, dbErr
+		},
+	}
+
+	err := NewAuditRepositoryWithPool(nil).Insert(context.Background(), mockTx, &model.AuditEntry{Action: "x", Subject: "y"})
+
+	assert.True(t, errors.Is(err, dbErr))
+	assert.Contains(t, err.Error(), "insert audit entry")
+}
+
+// mockAuditRows implements pgx.Rows for testing ListBySubject.
+type mockAuditRows struct {
+	mockClaimRows
+	entries []model.AuditEntry
+}
+
+func (m *mockAuditRows) Next() bool {
+	if m.index < len(m.entries) {
+		m.index++
+		return true
+	}
+	return false
+}
+
+func (m *mockAuditRows) Scan(dest ...any) error {
+	e := m.entries[m.index-1]
+	*(dest[0].(*int64)) = e.ID
+	*(dest[1].(*string)) = e.Action
+	*(dest[2].(*string)) = e.Subject
+	*(dest[3].(*map[string]any)) = e.Details
+	*(dest[4].(*time.Time)) = e.CreatedAt
+	return nil
+}
+
+func TestAuditRepository_ListBySubject(t *testing.T) {
+	at := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	note := model.AuditEntry{ID: 7, Action: model.AuditActionCouponNote, Subject: "PROMO", Details: map[string]any{"note": "raised stock"}, CreatedAt: at}
+	var capturedSQL string
+	var capturedArgs []any
+	pool := &mockTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL, capturedArgs = sql, args
+			return &mockAuditRows{entries: []model.AuditEntry{note}}, nil
+		},
+	}
+
+	entries, err := NewAuditRepositoryWithPool(pool).ListBySubject(context.Background(), "PROMO", model.CouponChangelogActions, nil, 50)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.AuditEntry{note}, entries)
+	assert.Contains(t, capturedSQL, "action = ANY($2)")
+	assert.Contains(t, capturedSQL, "ORDER BY created_at DESC, id DESC")
+	assert.Equal(t, []any{"PROMO", model.CouponChangelogActions, 50}, capturedArgs)
+}
+
+func TestAuditRepository_ListBySubject_After(t *testing.T) {
+	after := &model.AuditPosition{CreatedAt: time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC), ID: 7}
+	var capturedSQL string
+	var capturedArgs []any
+	pool := &mockTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL, capturedArgs = sql, args
+			return &mockAuditRows{}, nil
+		},
+	}
+
+	entries, err := NewAuditRepositoryWithPool(pool).ListBySubject(context.Background(), "PROMO", model.CouponChangelogActions, after, 50)
+
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Contains(t, capturedSQL, "(created_at, id) < ($3, $4)")
+	assert.Contains(t, capturedSQL, "LIMIT $5")
+	assert.Equal(t, []any{"PROMO", model.CouponChangelogActions, after.CreatedAt, int64(7), 50}, capturedArgs)
+}
+
+func TestAuditRepository_ListBySubject_DatabaseError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	pool := &mockTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) { return nil, dbErr },
+	}
+
+	_, err := NewAuditRepositoryWithPool(pool).ListBySubject(context.Background(), "PROMO", model.CouponChangelogActions, nil, 50)
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.Contains(t, err.Error(), "list audit entries of PROMO")
+}
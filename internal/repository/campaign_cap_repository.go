@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// campaignCapColumns is the column list shared by all campaign cap SELECTs.
+const campaignCapColumns = `campaign, claim_cap, claimed, updated_at`
+
+// CampaignCapRepository provides data access for campaign claim caps.
+type CampaignCapRepository struct {
+	pool PoolInterface
+}
+
+var _ ports.CampaignCapRepository = (*CampaignCapRepository)(nil)
+
+// NewCampaignCapRepository creates a new CampaignCapRepository.
+func NewCampaignCapRepository(pool *pgxpool.Pool) *CampaignCapRepository {
+	return NewCampaignCapRepositoryWithPool(pool)
+}
+
+// NewCampaignCapRepositoryWithPool creates a new CampaignCapRepository with a custom pool interface.
+// This is primarily used for testing.
+func NewCampaignCapRepositoryWithPool(pool PoolInterface) *CampaignCapRepository {
+	return &CampaignCapRepository{pool: pool}
+}
+
+// Set creates campaign's cap or changes its claim_cap, keeping what was claimed.
+func (r *CampaignCapRepository) Set(ctx context.Context, campaign string, claimCap int) (*model.CampaignCap, error) {
+	var c model.CampaignCap
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO campaign_caps (campaign, claim_cap) VALUES ($1, $2)
+		ON CONFLICT (campaign) DO UPDATE SET claim_cap = EXCLUDED.claim_cap, updated_at = NOW()
+		RETURNING `+campaignCapColumns,
+		campaign, claimCap).Scan(&c.Campaign, &c.ClaimCap, &c.Claimed, &c.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("set campaign cap %s: %w", campaign, err)
+	}
+	return &c, nil
+}
+
+// Get returns campaign's cap, or nil if it has none.
+func (r *CampaignCapRepository) Get(ctx context.Context, campaign string) (*model.CampaignCap, error) {
+	var c model.CampaignCap
+	err := r.pool.QueryRow(ctx, `SELECT `+campaignCapColumns+` FROM campaign_caps WHERE campaign = $1`, campaign).
+		Scan(&c.Campaign, &c.ClaimCap, &c.Claimed, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get campaign cap %s: %w", campaign, err)
+	}
+	return &c, nil
+}
+
+// Delete removes campaign's cap.
+// Returns apperr.ErrCampaignCapNotFound if it has none.
+func (r *CampaignCapRepository) Delete(ctx context.Context, campaign string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM campaign_caps WHERE campaign = $1`, campaign)
+	if err != nil {
+		return fmt.Errorf("delete campaign cap %s: %w", campaign, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrCampaignCapNotFound
+	}
+	return nil
+}
+
+// LockCaps locks (SELECT FOR UPDATE) the caps of those campaigns that have one, in
+// campaign order so that concurrent claims sharing campaigns cannot deadlock.
+// Must be called within a transaction after locking the coupon rows claimed.
+func (r *CampaignCapRepository) LockCaps(ctx context.Context, tx database.TxQuerier, campaigns []string) ([]model.CampaignCap, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+campaignCapColumns+` FROM campaign_caps
+		WHERE campaign = ANY($1)
+		ORDER BY campaign
+		FOR UPDATE
+	`, campaigns)
+	if err != nil {
+		return nil, fmt.Errorf("lock campaign caps: %w", err)
+	}
+	defer rows.Close()
+
+	caps := []model.CampaignCap{}
+	for rows.Next() {
+		var c model.CampaignCap
+		if err := rows.Scan(&c.Campaign, &c.ClaimCap, &c.Claimed, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan campaign cap: %w", err)
+		}
+		caps = append(caps, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate campaign caps: %w", err)
+	}
+	return caps, nil
+}
+
+// AddClaims counts n claims against each of campaigns' caps.
+// Must be called within a transaction after LockCaps.
+func (r *CampaignCapRepository) AddClaims(ctx context.Context, tx database.TxQuerier, campaigns []string, n int) error {
+	_, err := tx.Exec(ctx, `UPDATE campaign_caps SET claimed = claimed + $2 WHERE campaign = ANY($1)`, campaigns, n)
+	if err != nil {
+		return fmt.Errorf("count campaign claims: %w", err)
+	}
+	return nil
+}
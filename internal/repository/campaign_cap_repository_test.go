@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+)
+
+func TestCampaignCapRepository_Set(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	pool := &mockPool{queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+		capturedSQL, capturedArgs = sql, args
+		return &mockRow{scanFn: func(dest ...any) error {
+			*(dest[0].(*string)) = "summer"
+			*(dest[1].(*int)) = 100
+			*(dest[2].(*int)) = 40
+			*(dest[3].(*time.Time)) = time.Unix(1700000000, 0)
+			return nil
+		}}
+	}}
+
+	c, err := NewCampaignCapRepositoryWithPool(pool).Set(context.Background(), "summer", 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, 40, c.Claimed)
+	assert.Contains(t, capturedSQL, "DO UPDATE SET claim_cap = EXCLUDED.claim_cap", "what was claimed is kept")
+	assert.Equal(t, []any{"summer", 100}, capturedArgs)
+}
+
+func TestCampaignCapRepository_Get_NotFound(t *testing.T) {
+	pool := &mockPool{queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+		return &mockRow{scanFn: func(dest ...any) error { return pgx.ErrNoRows }}
+	}}
+
+	c, err := NewCampaignCapRepositoryWithPool(pool).Get(context.Background(), "summer")
+
+	require.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+func TestCampaignCapRepository_Delete_NotFound(t *testing.T) {
+	pool := &mockPool{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		return pgconn.NewCommandTag("DELETE 0"), nil
+	}}
+
+	err := NewCampaignCapRepositoryWithPool(pool).Delete(context.Background(), "summer")
+
+	assert.ErrorIs(t, err, apperr.ErrCampaignCapNotFound)
+}
+
+func TestCampaignCapRepository_LockCaps(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	tx := &mockTxQuerier{queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		capturedSQL, capturedArgs = sql, args
+		return &mockCouponRows{}, nil
+	}}
+
+	caps, err := NewCampaignCapRepositoryWithPool(&mockPool{}).LockCaps(context.Background(), tx, []string{"vip", "summer"})
+
+	require.NoError(t, err)
+	assert.NotNil(t, caps)
+	assert.Contains(t, capturedSQL, "ORDER BY campaign\n\t\tFOR UPDATE", "caps are locked in campaign order")
+	assert.Equal(t, []any{[]string{"vip", "summer"}}, capturedArgs)
+}
+
+func TestCampaignCapRepository_AddClaims(t *testing.T) {
+	var capturedArgs []any
+	tx := &mockTxQuerier{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		capturedArgs = arguments
+		return pgconn.NewCommandTag("UPDATE 1"), nil
+	}}
+
+	err := NewCampaignCapRepositoryWithPool(&mockPool{}).AddClaims(context.Background(), tx, []string{"summer"}, 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, []any{[]string{"summer"}, 3}, capturedArgs)
+}
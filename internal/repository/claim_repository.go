@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
-	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
 	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
@@ -18,29 +22,101 @@ type ClaimPoolInterface interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 }
 
-// ClaimRepository provides data access for claims using pgx.
+// ClaimRepository provides data access for claims using pgx. Claim lists and
+// GetClaimedUsers use the read pool (see SetReadPool); HasClaimed, on the claim path,
+// uses pool.
 type ClaimRepository struct {
-	pool ClaimPoolInterface
+	pool      ClaimPoolInterface
+	reads     ClaimPoolInterface
+	claimedAt database.Rename    // claims.created_at, being renamed to claimed_at
+	table     database.TableMove // claims, being moved to claims_v2
+	retry     *database.ReadRetrier
+	metrics   *database.StatementMetrics
 }
 
+var (
+	_ ports.ClaimRepository           = (*ClaimRepository)(nil)
+	_ ports.UserClaimRepository       = (*ClaimRepository)(nil)
+	_ ports.ClaimMoveRepository       = (*ClaimRepository)(nil)
+	_ ports.ClaimRetentionRepository  = (*ClaimRepository)(nil)
+	_ ports.AnalyticsExportRepository = (*ClaimRepository)(nil)
+)
+
 // NewClaimRepository creates a new ClaimRepository with the given pool.
 func NewClaimRepository(pool *pgxpool.Pool) *ClaimRepository {
-	return &ClaimRepository{pool: pool}
+	return NewClaimRepositoryWithPool(pool)
 }
 
 // NewClaimRepositoryWithPool creates a new ClaimRepository with a custom pool interface.
 // This is primarily used for testing.
 func NewClaimRepositoryWithPool(pool ClaimPoolInterface) *ClaimRepository {
-	return &ClaimRepository{pool: pool}
+	return &ClaimRepository{pool: pool, reads: pool, claimedAt: database.ClaimsClaimedAt, table: database.ClaimsV2}
+}
+
+// SetReadPool sets the pool used by GetUsersByCoupon, CountByCoupon, ListByCoupon,
+// ListBySequences and GetClaimedUsers.
+func (r *ClaimRepository) SetReadPool(pool ClaimPoolInterface) {
+	r.reads = pool
+}
+
+// SetStatementMetrics sets the metrics timing the statements named in database.Statements.
+func (r *ClaimRepository) SetStatementMetrics(metrics *database.StatementMetrics) {
+	r.metrics = metrics
+}
+
+// SetReadRetrier sets the retrier rerunning GetUsersByCoupon when its connection is lost.
+func (r *ClaimRepository) SetReadRetrier(retrier *database.ReadRetrier) {
+	r.retry = retrier
 }
 
-// GetUsersByCoupon retrieves all user IDs who have claimed a specific coupon.
+// SetRenames sets the migration phase of the column renames on the claims table
+// (see database.Rename); other renames are ignored.
+func (r *ClaimRepository) SetRenames(renames map[string]database.Rename) {
+	if rename, ok := renames[database.ClaimsClaimedAt.Name()]; ok {
+		r.claimedAt = rename
+	}
+}
+
+// SetTableMoves sets the migration phase of the move of claims to claims_v2 (see
+// database.TableMove); other moves are ignored.
+func (r *ClaimRepository) SetTableMoves(moves map[string]database.TableMove) {
+	if move, ok := moves[database.ClaimsV2.Name()]; ok {
+		r.table = move
+	}
+}
+
+// claimedAtExpr returns the SQL expression reading a claim's time from the table reads
+// use. claims_v2 was created with claimed_at.
+func (r *ClaimRepository) claimedAtExpr() string {
+	if r.table.ReadsNew() {
+		return "claimed_at"
+	}
+	return r.claimedAt.ReadExpr()
+}
+
+// GetUsersByCoupon retrieves the user IDs who have claimed a specific coupon, in claim
+// order: the first limit of them, or all when limit is 0.
 // On success, returns an empty slice (not nil) when no claims exist.
 // On error, returns nil and the wrapped error.
-func (r *ClaimRepository) GetUsersByCoupon(ctx context.Context, couponName string) ([]string, error) {
-	query := `SELECT user_id FROM claims WHERE coupon_name = $1 ORDER BY created_at`
+func (r *ClaimRepository) GetUsersByCoupon(ctx context.Context, couponName string, limit int) ([]string, error) {
+	var users []string
+	err := r.retry.Run(ctx, func(ctx context.Context) error {
+		var err error
+		users, err = r.getUsersByCoupon(ctx, couponName, limit)
+		return err
+	})
+	return users, err
+}
+
+func (r *ClaimRepository) getUsersByCoupon(ctx context.Context, couponName string, limit int) ([]string, error) {
+	query := `SELECT user_id FROM ` + r.table.ReadTable() + ` WHERE coupon_name = $1 ORDER BY ` + r.claimedAtExpr()
+	args := []any{couponName}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
 
-	rows, err := r.pool.Query(ctx, query, couponName)
+	rows, err := r.reads.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("get claims for coupon %s: %w", couponName, err)
 	}
@@ -67,18 +143,271 @@ func (r *ClaimRepository) GetUsersByCoupon(ctx context.Context, couponName strin
 	return users, nil
 }
 
-// Insert inserts a new claim record within a transaction.
-// Returns service.ErrAlreadyClaimed if the user has already claimed this coupon.
-func (r *ClaimRepository) Insert(ctx context.Context, tx database.TxQuerier, userID, couponName string) error {
-	query := `INSERT INTO claims (user_id, coupon_name) VALUES ($1, $2)`
+// CountByCoupon returns how many claims a coupon has.
+func (r *ClaimRepository) CountByCoupon(ctx context.Context, couponName string) (int, error) {
+	rows, err := r.reads.Query(ctx, `SELECT COUNT(*) FROM `+r.table.ReadTable()+` WHERE coupon_name = $1`, couponName)
+	if err != nil {
+		return 0, fmt.Errorf("count claims of coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	var count int
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, fmt.Errorf("scan claim count: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate claims rows: %w", err)
+	}
+	return count, nil
+}
 
-	_, err := tx.Exec(ctx, query, userID, couponName)
+// ListByCoupon retrieves up to limit claims of a coupon after claim sequence
+// afterSequence, ordered by claim sequence, through idx_claims_coupon_sequence.
+// On success, returns an empty slice (not nil) when no claims exist.
+func (r *ClaimRepository) ListByCoupon(ctx context.Context, couponName string, afterSequence, limit int) ([]model.Claim, error) {
+	query := `SELECT user_id, COALESCE(channel, ''), COALESCE(region, ''), claim_sequence, COALESCE(tier, ''), ` +
+		r.claimedAtExpr() + ` FROM ` + r.table.ReadTable() + ` WHERE coupon_name = $1 AND claim_sequence > $2 ORDER BY claim_sequence LIMIT $3`
+	return r.listClaims(ctx, couponName, query, couponName, afterSequence, limit)
+}
+
+// ListBySequences retrieves the claims of a coupon with the given claim sequences,
+// ordered by claim sequence, through idx_claims_coupon_sequence. Sequences without a
+// claim are skipped. On success, returns an empty slice (not nil) when none match.
+func (r *ClaimRepository) ListBySequences(ctx context.Context, couponName string, sequences []int) ([]model.Claim, error) {
+	query := `SELECT user_id, COALESCE(channel, ''), COALESCE(region, ''), claim_sequence, COALESCE(tier, ''), ` +
+		r.claimedAtExpr() + ` FROM ` + r.table.ReadTable() + ` WHERE coupon_name = $1 AND claim_sequence = ANY($2) ORDER BY claim_sequence`
+	return r.listClaims(ctx, couponName, query, couponName, sequences)
+}
+
+// listClaims runs a query selecting claims of couponName in ListByCoupon's column order.
+func (r *ClaimRepository) listClaims(ctx context.Context, couponName, query string, args ...any) ([]model.Claim, error) {
+	rows, err := r.reads.Query(ctx, query, args...)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return service.ErrAlreadyClaimed
+		return nil, fmt.Errorf("list claims for coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	claims := []model.Claim{}
+	for rows.Next() {
+		claim := model.Claim{CouponName: couponName}
+		if err := rows.Scan(&claim.UserID, &claim.Channel, &claim.Region, &claim.Sequence, &claim.Tier, &claim.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan claim: %w", err)
+		}
+		claims = append(claims, claim)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate claims rows: %w", err)
+	}
+	return claims, nil
+}
+
+// Insert inserts a new claim record within a transaction.
+// An empty channel, region or tier is stored as NULL, and a zero CreatedAt as the current time.
+// While claims moves to claims_v2, the claim is written to both tables, with the
+// claims.id it got as its claim_id.
+// Returns apperr.ErrAlreadyClaimed if the user has already claimed this coupon.
+func (r *ClaimRepository) Insert(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+	return r.metrics.Run(database.StatementInsertClaim, func() error {
+		return r.insert(ctx, tx, claim)
+	})
+}
+
+func (r *ClaimRepository) insert(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+	args := []any{claim.UserID, claim.CouponName, claim.Channel, claim.Sequence, claim.Tier,
+		nullTime(claim.CreatedAt), claim.Region}
+
+	var claimID any // claims_v2 takes the next claims id when claims is not written
+	if r.table.WritesOld() {
+		columns := r.claimedAt.WriteColumns()
+		query := `INSERT INTO claims (user_id, coupon_name, channel, claim_sequence, tier, region, ` + strings.Join(columns, ", ") + `)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), NULLIF($7, ''), ` +
+			strings.Repeat("COALESCE($6::timestamptz, NOW()), ", len(columns)-1) + `COALESCE($6::timestamptz, NOW()))`
+		if !r.table.WritesNew() {
+			_, err := tx.Exec(ctx, query, args...)
+			return insertClaimError(err)
 		}
-		return fmt.Errorf("insert claim: %w", err)
+		var id int64
+		if err := tx.QueryRow(ctx, query+` RETURNING id`, args...).Scan(&id); err != nil {
+			return insertClaimError(err)
+		}
+		claimID = id
+	}
+
+	_, err := tx.Exec(ctx, `INSERT INTO claims_v2 (claim_id, user_id, coupon_name, channel, claim_sequence, tier, region, claimed_at)
+		VALUES (COALESCE($8::bigint, nextval('claims_id_seq')), $1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), NULLIF($7, ''),
+			COALESCE($6::timestamptz, NOW()))`, append(args, claimID)...)
+	return insertClaimError(err)
+}
+
+// insertClaimError maps a failed claim insert to apperr.ErrAlreadyClaimed on a unique
+// violation.
+func insertClaimError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return apperr.ErrAlreadyClaimed
+	}
+	return fmt.Errorf("insert claim: %w", err)
+}
+
+// InsertExclusionClaim records userID's claim of couponName as their one claim of the
+// exclusion group within tx. Returns apperr.ErrExclusionGroupClaimed if they have one;
+// a conflict does not abort the transaction.
+func (r *ClaimRepository) InsertExclusionClaim(ctx context.Context, tx database.TxQuerier, group, userID, couponName string) error {
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO coupon_exclusion_claims (exclusion_group, user_id, coupon_name) VALUES ($1, $2, $3)
+		ON CONFLICT (exclusion_group, user_id) DO NOTHING
+	`, group, userID, couponName)
+	if err != nil {
+		return fmt.Errorf("insert exclusion claim: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrExclusionGroupClaimed
 	}
 	return nil
 }
+
+// ClaimedUsers returns which of userIDs have claimed couponName, reading within tx.
+func (r *ClaimRepository) ClaimedUsers(ctx context.Context, tx database.TxQuerier, couponName string, userIDs []string) ([]string, error) {
+	return claimedUsers(ctx, tx, r.table.ReadTable(), couponName, userIDs)
+}
+
+// GetClaimedUsers returns which of userIDs have claimed couponName, reading outside any
+// transaction. Only the named users' claims are read, however many the coupon has.
+func (r *ClaimRepository) GetClaimedUsers(ctx context.Context, couponName string, userIDs []string) ([]string, error) {
+	return claimedUsers(ctx, r.reads, r.table.ReadTable(), couponName, userIDs)
+}
+
+func claimedUsers(ctx context.Context, q ClaimPoolInterface, table, couponName string, userIDs []string) ([]string, error) {
+	rows, err := q.Query(ctx, `SELECT user_id FROM `+table+` WHERE coupon_name = $1 AND user_id = ANY($2)`,
+		couponName, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get claimed users for coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	users := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan claim user_id: %w", err)
+		}
+		users = append(users, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate claims rows: %w", err)
+	}
+	return users, nil
+}
+
+// HasClaimed reports whether userID has claimed couponName. It reads outside any
+// transaction and takes no locks.
+func (r *ClaimRepository) HasClaimed(ctx context.Context, userID, couponName string) (bool, error) {
+	rows, err := r.pool.Query(ctx, `SELECT user_id FROM `+r.table.ReadTable()+` WHERE user_id = $1 AND coupon_name = $2`,
+		userID, couponName)
+	if err != nil {
+		return false, fmt.Errorf("check claim of coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	claimed := rows.Next()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("iterate claims rows: %w", err)
+	}
+	return claimed, nil
+}
+
+// GetClaim returns userID's claim of couponName, or nil if there is none. Like
+// HasClaimed, it reads outside any transaction and takes no locks.
+func (r *ClaimRepository) GetClaim(ctx context.Context, userID, couponName string) (*model.Claim, error) {
+	rows, err := r.pool.Query(ctx, `SELECT user_id, COALESCE(channel, ''), COALESCE(region, ''), claim_sequence, COALESCE(tier, ''), `+
+		r.claimedAtExpr()+` FROM `+r.table.ReadTable()+` WHERE user_id = $1 AND coupon_name = $2`, userID, couponName)
+	if err != nil {
+		return nil, fmt.Errorf("get claim of coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("iterate claims rows: %w", err)
+		}
+		return nil, nil
+	}
+	claim := &model.Claim{CouponName: couponName}
+	if err := rows.Scan(&claim.UserID, &claim.Channel, &claim.Region, &claim.Sequence, &claim.Tier, &claim.CreatedAt); err != nil {
+		return nil, fmt.Errorf("scan claim: %w", err)
+	}
+	return claim, nil
+}
+
+// PseudonymizeUser replaces userID with pseudonym on all of the user's claims within a
+// transaction, leaving claim counts and sequences untouched. Returns the number of claims
+// changed in the table reads use.
+func (r *ClaimRepository) PseudonymizeUser(ctx context.Context, tx database.TxQuerier, userID, pseudonym string) (int64, error) {
+	var changed int64
+	for _, table := range r.table.WriteTables() {
+		tag, err := tx.Exec(ctx, `UPDATE `+table+` SET user_id = $2 WHERE user_id = $1`, userID, pseudonym)
+		if err != nil {
+			return 0, fmt.Errorf("pseudonymize %s: %w", table, err)
+		}
+		if table == r.table.ReadTable() {
+			changed = tag.RowsAffected()
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE coupon_exclusion_claims SET user_id = $2 WHERE user_id = $1`, userID, pseudonym); err != nil {
+		return 0, fmt.Errorf("pseudonymize coupon_exclusion_claims: %w", err)
+	}
+	return changed, nil
+}
+
+// CompareClaims compares the claims in claims and claims_v2 of up to limit coupons
+// named after after, in name order, including deleted coupons. Each table's claims of a
+// coupon are hashed as rows in id order, so any differing field (a claim_id that is not
+// the claims id, say) is a mismatch. Both tables must exist.
+func (r *ClaimRepository) CompareClaims(ctx context.Context, after string, limit int) ([]model.ClaimComparison, error) {
+	rows, err := r.reads.Query(ctx, `
+		SELECT c.name, o.claims, n.claims, o.digest = n.digest
+		FROM (SELECT name FROM coupons WHERE name > $1 ORDER BY name LIMIT $2) c
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*), md5(COALESCE(string_agg(
+				ROW(id, user_id, claim_sequence, channel, region, tier, `+r.claimedAt.ReadExpr()+`)::text, ',' ORDER BY id), ''))
+			FROM claims WHERE coupon_name = c.name
+		) o(claims, digest)
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*), md5(COALESCE(string_agg(
+				ROW(claim_id, user_id, claim_sequence, channel, region, tier, claimed_at)::text, ',' ORDER BY claim_id), ''))
+			FROM claims_v2 WHERE coupon_name = c.name
+		) n(claims, digest)
+		ORDER BY c.name
+	`, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("compare claims: %w", err)
+	}
+	defer rows.Close()
+
+	comparisons := []model.ClaimComparison{}
+	for rows.Next() {
+		var cmp model.ClaimComparison
+		if err := rows.Scan(&cmp.CouponName, &cmp.OldClaims, &cmp.NewClaims, &cmp.Match); err != nil {
+			return nil, fmt.Errorf("scan claim comparison: %w", err)
+		}
+		comparisons = append(comparisons, cmp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate claim comparisons: %w", err)
+	}
+	return comparisons, nil
+}
+
+// nullTime returns nil for the zero time so the column default applies.
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
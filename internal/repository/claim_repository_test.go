@@ -3,14 +3,20 @@ package repository
 import (
 	"context"
 	"errors"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
 // mockClaimRows implements pgx.Rows for testing.
@@ -51,6 +57,34 @@ func (m *mockClaimRows) RawValues() [][]byte                          { return n
 func (m *mockClaimRows) Values() ([]any, error)                       { return nil, nil }
 func (m *mockClaimRows) Conn() *pgx.Conn                              { return nil }
 
+// mockClaimListRows implements pgx.Rows for testing ListByCoupon.
+type mockClaimListRows struct {
+	mockClaimRows
+	claims []model.Claim
+}
+
+func (m *mockClaimListRows) Next() bool {
+	if m.index < len(m.claims) {
+		m.index++
+		return true
+	}
+	return false
+}
+
+func (m *mockClaimListRows) Scan(dest ...any) error {
+	if m.errOnScan != nil {
+		return m.errOnScan
+	}
+	c := m.claims[m.index-1]
+	*(dest[0].(*string)) = c.UserID
+	*(dest[1].(*string)) = c.Channel
+	*(dest[2].(*string)) = c.Region
+	*(dest[3].(*int)) = c.Sequence
+	*(dest[4].(*string)) = c.Tier
+	*(dest[5].(*time.Time)) = c.CreatedAt
+	return nil
+}
+
 // mockClaimPool implements ClaimPoolInterface for testing.
 type mockClaimPool struct {
 	queryFn func(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
@@ -73,7 +107,7 @@ func TestClaimRepository_GetUsersByCoupon_Success(t *testing.T) {
 	}
 
 	repo := NewClaimRepositoryWithPool(mock)
-	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER")
+	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER", 0)
 
 	require.NoError(t, err)
 	assert.Equal(t, []string{"user_001", "user_002", "user_003"}, users)
@@ -89,7 +123,7 @@ func TestClaimRepository_GetUsersByCoupon_Empty(t *testing.T) {
 	}
 
 	repo := NewClaimRepositoryWithPool(mock)
-	users, err := repo.GetUsersByCoupon(context.Background(), "NEW_PROMO")
+	users, err := repo.GetUsersByCoupon(context.Background(), "NEW_PROMO", 0)
 
 	require.NoError(t, err)
 	require.NotNil(t, users, "Should return empty slice, not nil")
@@ -105,7 +139,7 @@ func TestClaimRepository_GetUsersByCoupon_QueryError(t *testing.T) {
 	}
 
 	repo := NewClaimRepositoryWithPool(mock)
-	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER")
+	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER", 0)
 
 	require.Error(t, err)
 	assert.Nil(t, users)
@@ -125,7 +159,7 @@ func TestClaimRepository_GetUsersByCoupon_ScanError(t *testing.T) {
 	}
 
 	repo := NewClaimRepositoryWithPool(mock)
-	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER")
+	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER", 0)
 
 	require.Error(t, err)
 	assert.Nil(t, users)
@@ -144,13 +178,36 @@ func TestClaimRepository_GetUsersByCoupon_RowsError(t *testing.T) {
 	}
 
 	repo := NewClaimRepositoryWithPool(mock)
-	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER")
+	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER", 0)
 
 	require.Error(t, err)
 	assert.Nil(t, users)
 	assert.Contains(t, err.Error(), "iterate claims rows")
 }
 
+func TestClaimRepository_GetUsersByCoupon_RetriesLostConnection(t *testing.T) {
+	var calls int
+	mock := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			calls++
+			if calls == 1 {
+				return nil, &pgconn.PgError{Code: "57P01"}
+			}
+			return &mockClaimRows{data: []string{"user_001"}}, nil
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(mock)
+	retrier := database.NewReadRetrier()
+	repo.SetReadRetrier(retrier)
+	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER", 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_001"}, users)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, database.ReadRetryStats{Retries: 1, Recovered: 1}, retrier.Stats())
+}
+
 func TestClaimRepository_GetUsersByCoupon_VerifiesParameterizedQuery(t *testing.T) {
 	var capturedSQL string
 	var capturedArgs []any
@@ -165,7 +222,7 @@ func TestClaimRepository_GetUsersByCoupon_VerifiesParameterizedQuery(t *testing.
 	repo := NewClaimRepositoryWithPool(mock)
 
 	// Test with SQL injection attempt
-	_, _ = repo.GetUsersByCoupon(context.Background(), "'; DROP TABLE claims;--")
+	_, _ = repo.GetUsersByCoupon(context.Background(), "'; DROP TABLE claims;--", 0)
 
 	// Verify parameterized query
 	assert.Contains(t, capturedSQL, "$1")
@@ -173,6 +230,77 @@ func TestClaimRepository_GetUsersByCoupon_VerifiesParameterizedQuery(t *testing.
 	assert.Equal(t, "'; DROP TABLE claims;--", capturedArgs[0], "Name should be passed as parameter")
 }
 
+func TestClaimRepository_GetUsersByCoupon_Limit(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			capturedArgs = args
+			return &mockClaimRows{data: []string{"user_001"}}, nil
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(mock)
+	users, err := repo.GetUsersByCoupon(context.Background(), "PROMO_SUPER", 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_001"}, users)
+	assert.Contains(t, capturedSQL, "LIMIT $2")
+	assert.Equal(t, []any{"PROMO_SUPER", 1}, capturedArgs)
+}
+
+// mockClaimCountRows returns a single claim count.
+type mockClaimCountRows struct {
+	mockClaimRows
+	count int
+}
+
+func (m *mockClaimCountRows) Next() bool {
+	m.index++
+	return m.index == 1
+}
+
+func (m *mockClaimCountRows) Scan(dest ...any) error {
+	*(dest[0].(*int)) = m.count
+	return nil
+}
+
+func TestClaimRepository_CountByCoupon(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			capturedArgs = args
+			return &mockClaimCountRows{count: 10050}, nil
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(mock)
+	count, err := repo.CountByCoupon(context.Background(), "HUGE")
+
+	require.NoError(t, err)
+	assert.Equal(t, 10050, count)
+	assert.Equal(t, "SELECT COUNT(*) FROM claims WHERE coupon_name = $1", capturedSQL)
+	assert.Equal(t, []any{"HUGE"}, capturedArgs)
+}
+
+func TestClaimRepository_CountByCoupon_QueryError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	mock := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return nil, dbErr
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(mock)
+	_, err := repo.CountByCoupon(context.Background(), "HUGE")
+
+	require.ErrorIs(t, err, dbErr)
+	assert.Contains(t, err.Error(), "count claims of coupon HUGE")
+}
+
 // mockTxQuerier implements database.TxQuerier for testing Insert method.
 type mockTxQuerier struct {
 	execFn     func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
@@ -213,7 +341,7 @@ func TestClaimRepository_Insert_Success(t *testing.T) {
 	}
 
 	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
-	err := repo.Insert(context.Background(), mockTx, "user_001", "PROMO_SUPER")
+	err := repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "PROMO_SUPER"})
 
 	require.NoError(t, err)
 	assert.Contains(t, capturedSQL, "INSERT INTO claims")
@@ -222,6 +350,144 @@ func TestClaimRepository_Insert_Success(t *testing.T) {
 	assert.Equal(t, "PROMO_SUPER", capturedArgs[1])
 }
 
+func TestClaimRepository_Insert_RecordsChannel(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mockTx := &mockTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+	err := repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "BF_SPLIT", Channel: "app"})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "NULLIF($3, '')", "empty channel should be stored as NULL")
+	assert.Equal(t, "app", capturedArgs[2])
+}
+
+func TestClaimRepository_Insert_RecordsSequence(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mockTx := &mockTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+	err := repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_042", CouponName: "PROMO_SUPER", Sequence: 42, Tier: "gold"})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "claim_sequence")
+	assert.Contains(t, capturedSQL, "NULLIF($5, '')", "empty tier should be stored as NULL")
+	assert.Equal(t, 42, capturedArgs[3])
+	assert.Equal(t, "gold", capturedArgs[4])
+}
+
+func TestClaimRepository_ListByCoupon_Success(t *testing.T) {
+	claimedAt := time.Date(2026, 11, 27, 0, 0, 1, 0, time.UTC)
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			capturedArgs = args
+			return &mockClaimListRows{claims: []model.Claim{
+				{UserID: "user_001", Sequence: 1, CreatedAt: claimedAt},
+				{UserID: "user_002", Channel: "app", Sequence: 2, Tier: "gold", CreatedAt: claimedAt},
+			}}, nil
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(mock)
+	claims, err := repo.ListByCoupon(context.Background(), "PROMO_SUPER", 0, 100)
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "claim_sequence > $2 ORDER BY claim_sequence LIMIT $3")
+	assert.Equal(t, []any{"PROMO_SUPER", 0, 100}, capturedArgs)
+	assert.Equal(t, []model.Claim{
+		{UserID: "user_001", CouponName: "PROMO_SUPER", Sequence: 1, CreatedAt: claimedAt},
+		{UserID: "user_002", CouponName: "PROMO_SUPER", Channel: "app", Sequence: 2, Tier: "gold", CreatedAt: claimedAt},
+	}, claims)
+}
+
+func TestClaimRepository_ListBySequences(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			capturedArgs = args
+			return &mockClaimListRows{claims: []model.Claim{{UserID: "user_007", Sequence: 7}}}, nil
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(mock)
+	claims, err := repo.ListBySequences(context.Background(), "PROMO_SUPER", []int{3, 7})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "claim_sequence = ANY($2) ORDER BY claim_sequence")
+	assert.Equal(t, []any{"PROMO_SUPER", []int{3, 7}}, capturedArgs)
+	assert.Equal(t, []model.Claim{{UserID: "user_007", CouponName: "PROMO_SUPER", Sequence: 7}}, claims)
+}
+
+func TestClaimRepository_ListByCoupon_Empty(t *testing.T) {
+	mock := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return &mockClaimListRows{}, nil
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(mock)
+	claims, err := repo.ListByCoupon(context.Background(), "NEW_PROMO", 0, 100)
+
+	require.NoError(t, err)
+	require.NotNil(t, claims, "Should return empty slice, not nil")
+	assert.Len(t, claims, 0)
+}
+
+func TestClaimRepository_ListByCoupon_Errors(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	scanErr := errors.New("scan error")
+	rowsErr := errors.New("rows iteration error")
+
+	tests := []struct {
+		name    string
+		rows    pgx.Rows
+		err     error
+		wantErr error
+		wantMsg string
+	}{
+		{"query error", nil, dbErr, dbErr, "list claims for coupon"},
+		{"scan error", &mockClaimListRows{mockClaimRows: mockClaimRows{errOnScan: scanErr}, claims: []model.Claim{{UserID: "u"}}}, nil, scanErr, "scan claim"},
+		{"rows error", &mockClaimListRows{mockClaimRows: mockClaimRows{errOnRows: rowsErr}}, nil, rowsErr, "iterate claims rows"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockClaimPool{
+				queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					return tt.rows, tt.err
+				},
+			}
+
+			repo := NewClaimRepositoryWithPool(mock)
+			claims, err := repo.ListByCoupon(context.Background(), "PROMO_SUPER", 0, 100)
+
+			require.Error(t, err)
+			assert.Nil(t, claims)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Contains(t, err.Error(), tt.wantMsg)
+		})
+	}
+}
+
 func TestClaimRepository_Insert_DuplicateClaim(t *testing.T) {
 	mockTx := &mockTxQuerier{
 		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
@@ -235,10 +501,10 @@ func TestClaimRepository_Insert_DuplicateClaim(t *testing.T) {
 	}
 
 	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
-	err := repo.Insert(context.Background(), mockTx, "user_001", "PROMO_SUPER")
+	err := repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "PROMO_SUPER"})
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, service.ErrAlreadyClaimed), "should return ErrAlreadyClaimed for duplicate")
+	assert.True(t, errors.Is(err, apperr.ErrAlreadyClaimed), "should return ErrAlreadyClaimed for duplicate")
 }
 
 func TestClaimRepository_Insert_DatabaseError(t *testing.T) {
@@ -250,10 +516,10 @@ func TestClaimRepository_Insert_DatabaseError(t *testing.T) {
 	}
 
 	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
-	err := repo.Insert(context.Background(), mockTx, "user_001", "PROMO_SUPER")
+	err := repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "PROMO_SUPER"})
 
 	require.Error(t, err)
-	assert.False(t, errors.Is(err, service.ErrAlreadyClaimed), "should not return ErrAlreadyClaimed for generic error")
+	assert.False(t, errors.Is(err, apperr.ErrAlreadyClaimed), "should not return ErrAlreadyClaimed for generic error")
 	assert.Contains(t, err.Error(), "insert claim")
 	assert.True(t, errors.Is(err, dbErr), "should wrap original error")
 }
@@ -271,10 +537,10 @@ func TestClaimRepository_Insert_OtherPgError(t *testing.T) {
 	}
 
 	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
-	err := repo.Insert(context.Background(), mockTx, "user_001", "NONEXISTENT")
+	err := repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "NONEXISTENT"})
 
 	require.Error(t, err)
-	assert.False(t, errors.Is(err, service.ErrAlreadyClaimed), "should not return ErrAlreadyClaimed for non-23505 error")
+	assert.False(t, errors.Is(err, apperr.ErrAlreadyClaimed), "should not return ErrAlreadyClaimed for non-23505 error")
 	assert.Contains(t, err.Error(), "insert claim")
 }
 
@@ -292,7 +558,7 @@ func TestClaimRepository_Insert_VerifiesParameterizedQuery(t *testing.T) {
 	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
 
 	// Test with SQL injection attempt
-	_ = repo.Insert(context.Background(), mockTx, "'; DROP TABLE claims;--", "PROMO_SUPER")
+	_ = repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "'; DROP TABLE claims;--", CouponName: "PROMO_SUPER"})
 
 	// Verify parameterized query
 	assert.Contains(t, capturedSQL, "$1")
@@ -310,3 +576,403 @@ func TestNewClaimRepository_Production(t *testing.T) {
 	repo := NewClaimRepository(nil)
 	require.NotNil(t, repo, "NewClaimRepository should return a non-nil repository")
 }
+
+func TestClaimRepository_PseudonymizeUser(t *testing.T) {
+	var capturedSQL []string
+	mockTx := &mockTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = append(capturedSQL, sql)
+			assert.Equal(t, []any{"user_001", "erased-abc"}, arguments)
+			return pgconn.NewCommandTag("UPDATE 2"), nil
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+	count, err := repo.PseudonymizeUser(context.Background(), mockTx, "user_001", "erased-abc")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	require.Len(t, capturedSQL, 2)
+	assert.Contains(t, capturedSQL[0], "UPDATE claims SET user_id = $2 WHERE user_id = $1")
+	assert.Contains(t, capturedSQL[1], "UPDATE coupon_exclusion_claims SET user_id = $2 WHERE user_id = $1",
+		"the user's exclusion group claims are renamed too")
+}
+
+func TestClaimRepository_InsertExclusionClaim(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	affected := "INSERT 0 1"
+	mockTx := &mockTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return pgconn.NewCommandTag(affected), nil
+		},
+	}
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+
+	require.NoError(t, repo.InsertExclusionClaim(context.Background(), mockTx, "welcome", "user_001", "WELCOME_A"))
+	assert.Contains(t, capturedSQL, "ON CONFLICT (exclusion_group, user_id) DO NOTHING", "a conflict does not abort the transaction")
+	assert.Equal(t, []any{"welcome", "user_001", "WELCOME_A"}, capturedArgs)
+
+	affected = "INSERT 0 0"
+	err := repo.InsertExclusionClaim(context.Background(), mockTx, "welcome", "user_001", "WELCOME_B")
+	assert.ErrorIs(t, err, apperr.ErrExclusionGroupClaimed)
+}
+
+func TestClaimRepository_PseudonymizeUser_DatabaseError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	mockTx := &mockTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			return pgconn.CommandTag{}, dbErr
+		},
+	}
+
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+	count, err := repo.PseudonymizeUser(context.Background(), mockTx, "user_001", "erased-abc")
+
+	assert.Zero(t, count)
+	assert.True(t, errors.Is(err, dbErr))
+	assert.Contains(t, err.Error(), "pseudonymize claims")
+}
+
+func TestClaimRepository_Insert_RecordsCreatedAt(t *testing.T) {
+	var capturedArgs []any
+	mockTx := &mockTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedArgs = arguments
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+
+	require.NoError(t, repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "PROMO"}))
+	assert.Nil(t, capturedArgs[5], "zero CreatedAt should use the column default")
+
+	claimedAt := time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "PROMO", CreatedAt: claimedAt}))
+	assert.Equal(t, claimedAt, capturedArgs[5])
+}
+
+func TestClaimRepository_ClaimedUsers(t *testing.T) {
+	var capturedArgs []any
+	mockTx := &mockTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedArgs = args
+			return &mockClaimRows{data: []string{"user_002"}}, nil
+		},
+	}
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+
+	users, err := repo.ClaimedUsers(context.Background(), mockTx, "PROMO", []string{"user_001", "user_002"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_002"}, users)
+	assert.Equal(t, []any{"PROMO", []string{"user_001", "user_002"}}, capturedArgs)
+}
+
+func TestClaimRepository_ClaimedUsers_QueryError(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	mockTx := &mockTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return nil, dbErr
+		},
+	}
+
+	_, err := NewClaimRepositoryWithPool(&mockClaimPool{}).ClaimedUsers(context.Background(), mockTx, "PROMO", []string{"user_001"})
+
+	assert.ErrorIs(t, err, dbErr)
+}
+
+func TestClaimRepository_GetClaimedUsers_UsesReadPool(t *testing.T) {
+	claimPool := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			t.Fatal("GetClaimedUsers must not use the claim pool")
+			return nil, nil
+		},
+	}
+	readPool := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return &mockClaimRows{data: []string{"user_002"}}, nil
+		},
+	}
+	repo := NewClaimRepositoryWithPool(claimPool)
+	repo.SetReadPool(readPool)
+
+	users, err := repo.GetClaimedUsers(context.Background(), "PROMO", []string{"user_001", "user_002"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_002"}, users)
+}
+
+func TestClaimRepository_HasClaimed(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		want bool
+	}{
+		{"claimed", []string{"user_001"}, true},
+		{"not claimed", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedArgs []any
+			pool := &mockClaimPool{
+				queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					capturedArgs = args
+					return &mockClaimRows{data: tt.rows}, nil
+				},
+			}
+
+			claimed, err := NewClaimRepositoryWithPool(pool).HasClaimed(context.Background(), "user_001", "PROMO")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, claimed)
+			assert.Equal(t, []any{"user_001", "PROMO"}, capturedArgs)
+		})
+	}
+}
+
+func TestClaimRepository_GetClaim(t *testing.T) {
+	claimedAt := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rows []model.Claim
+		want *model.Claim
+	}{
+		{"claimed", []model.Claim{{UserID: "user_001", Sequence: 42, Tier: "gold", CreatedAt: claimedAt}},
+			&model.Claim{UserID: "user_001", CouponName: "PROMO", Sequence: 42, Tier: "gold", CreatedAt: claimedAt}},
+		{"not claimed", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedSQL string
+			var capturedArgs []any
+			pool := &mockClaimPool{
+				queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					capturedSQL, capturedArgs = sql, args
+					return &mockClaimListRows{claims: tt.rows}, nil
+				},
+			}
+
+			claim, err := NewClaimRepositoryWithPool(pool).GetClaim(context.Background(), "user_001", "PROMO")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, claim)
+			assert.Contains(t, capturedSQL, "FROM claims WHERE user_id = $1 AND coupon_name = $2")
+			assert.Equal(t, []any{"user_001", "PROMO"}, capturedArgs)
+		})
+	}
+}
+
+func TestClaimRepository_HasClaimed_QueryError(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	pool := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return nil, dbErr
+		},
+	}
+
+	_, err := NewClaimRepositoryWithPool(pool).HasClaimed(context.Background(), "user_001", "PROMO")
+
+	assert.ErrorIs(t, err, dbErr)
+}
+
+func TestClaimRepository_Renames(t *testing.T) {
+	tests := []struct {
+		phase  database.MigrationPhase
+		insert string
+		list   string
+	}{
+		{database.PhaseOld, "tier, region, created_at)", "COALESCE(tier, ''), created_at FROM"},
+		{database.PhaseDualWrite, "tier, region, created_at, claimed_at)", "COALESCE(tier, ''), created_at FROM"},
+		{database.PhaseReadNew, "tier, region, created_at, claimed_at)", "COALESCE(claimed_at, created_at) FROM"},
+		{database.PhaseNew, "tier, region, claimed_at)", "COALESCE(tier, ''), claimed_at FROM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			var insertSQL, listSQL string
+			var insertArgs []any
+			mockTx := &mockTxQuerier{
+				execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+					insertSQL, insertArgs = sql, arguments
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+			}
+			pool := &mockClaimPool{
+				queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					listSQL = sql
+					return &mockClaimListRows{}, nil
+				},
+			}
+			rename := database.ClaimsClaimedAt
+			rename.Phase = tt.phase
+			repo := NewClaimRepositoryWithPool(pool)
+			repo.SetRenames(map[string]database.Rename{rename.Name(): rename})
+
+			require.NoError(t, repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "PROMO"}))
+			_, err := repo.ListByCoupon(context.Background(), "PROMO", 0, 100)
+			require.NoError(t, err)
+
+			assert.Contains(t, insertSQL, tt.insert)
+			assert.Len(t, insertArgs, 7, "dual writes reuse the timestamp parameter")
+			assert.Equal(t, len(rename.WriteColumns()), strings.Count(insertSQL, "COALESCE($6::timestamptz, NOW())"))
+			assert.Contains(t, listSQL, tt.list)
+		})
+	}
+}
+
+func TestClaimRepository_TableMoves(t *testing.T) {
+	tests := []struct {
+		phase   database.MigrationPhase
+		inserts []string // Tables inserted into, in order
+		list    string
+		updates []string // Tables PseudonymizeUser updates
+	}{
+		{database.PhaseOld, []string{"claims"}, "created_at FROM claims WHERE", []string{"claims", "coupon_exclusion_claims"}},
+		{database.PhaseDualWrite, []string{"claims", "claims_v2"}, "created_at FROM claims WHERE", []string{"claims", "claims_v2", "coupon_exclusion_claims"}},
+		{database.PhaseReadNew, []string{"claims", "claims_v2"}, "claimed_at FROM claims_v2 WHERE", []string{"claims", "claims_v2", "coupon_exclusion_claims"}},
+		{database.PhaseNew, []string{"claims_v2"}, "claimed_at FROM claims_v2 WHERE", []string{"claims_v2", "coupon_exclusion_claims"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			var inserts, updates []string
+			var v2Args []any
+			table := func(sql, prefix string) string {
+				rest := sql[strings.Index(sql, prefix)+len(prefix):]
+				return strings.Fields(rest)[0]
+			}
+			mockTx := &mockTxQuerier{
+				execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+					if strings.HasPrefix(sql, "UPDATE") {
+						updates = append(updates, table(sql, "UPDATE "))
+						return pgconn.NewCommandTag("UPDATE " + strconv.Itoa(len(updates))), nil
+					}
+					inserts = append(inserts, table(sql, "INSERT INTO "))
+					if strings.Contains(sql, "claims_v2") {
+						v2Args = arguments
+					}
+					return pgconn.NewCommandTag("INSERT 0 1"), nil
+				},
+				queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					inserts = append(inserts, table(sql, "INSERT INTO "))
+					assert.Contains(t, sql, "RETURNING id")
+					return &mockRow{scanFn: func(dest ...any) error {
+						*dest[0].(*int64) = 42
+						return nil
+					}}
+				},
+			}
+			var listSQL string
+			pool := &mockClaimPool{
+				queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					listSQL = sql
+					return &mockClaimListRows{}, nil
+				},
+			}
+			move := database.ClaimsV2
+			move.Phase = tt.phase
+			repo := NewClaimRepositoryWithPool(pool)
+			repo.SetTableMoves(map[string]database.TableMove{move.Name(): move})
+
+			require.NoError(t, repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "PROMO"}))
+			_, err := repo.ListByCoupon(context.Background(), "PROMO", 0, 100)
+			require.NoError(t, err)
+			changed, err := repo.PseudonymizeUser(context.Background(), mockTx, "user_001", "erased-abc")
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.inserts, inserts)
+			assert.Contains(t, listSQL, tt.list)
+			assert.Equal(t, tt.updates, updates)
+			assert.Equal(t, int64(slices.Index(tt.updates, move.ReadTable())+1), changed,
+				"the count is from the table reads use")
+			switch tt.phase {
+			case database.PhaseDualWrite, database.PhaseReadNew:
+				assert.Equal(t, int64(42), v2Args[7], "claims_v2 keeps the claims id")
+			case database.PhaseNew:
+				assert.Nil(t, v2Args[7], "claims_v2 takes the next id itself")
+			}
+		})
+	}
+}
+
+func TestClaimRepository_Insert_DualWriteDuplicate(t *testing.T) {
+	mockTx := &mockTxQuerier{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFn: func(dest ...any) error { return &pgconn.PgError{Code: "23505"} }}
+		},
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			t.Fatal("claims_v2 must not be written after claims rejected the claim")
+			return pgconn.CommandTag{}, nil
+		},
+	}
+	move := database.ClaimsV2
+	move.Phase = database.PhaseDualWrite
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+	repo.SetTableMoves(map[string]database.TableMove{move.Name(): move})
+
+	err := repo.Insert(context.Background(), mockTx, &model.Claim{UserID: "user_001", CouponName: "PROMO"})
+
+	assert.ErrorIs(t, err, apperr.ErrAlreadyClaimed)
+}
+
+// mockClaimComparisonRows implements pgx.Rows for testing CompareClaims.
+type mockClaimComparisonRows struct {
+	mockClaimRows
+	comparisons []model.ClaimComparison
+}
+
+func (m *mockClaimComparisonRows) Next() bool {
+	if m.index < len(m.comparisons) {
+		m.index++
+		return true
+	}
+	return false
+}
+
+func (m *mockClaimComparisonRows) Scan(dest ...any) error {
+	c := m.comparisons[m.index-1]
+	*(dest[0].(*string)) = c.CouponName
+	*(dest[1].(*int)) = c.OldClaims
+	*(dest[2].(*int)) = c.NewClaims
+	*(dest[3].(*bool)) = c.Match
+	return nil
+}
+
+func TestClaimRepository_CompareClaims(t *testing.T) {
+	want := []model.ClaimComparison{{CouponName: "PROMO", OldClaims: 5, NewClaims: 4}}
+	var capturedSQL string
+	var capturedArgs []any
+	readPool := &mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL, capturedArgs = sql, args
+			return &mockClaimComparisonRows{comparisons: want}, nil
+		},
+	}
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+	repo.SetReadPool(readPool)
+
+	comparisons, err := repo.CompareClaims(context.Background(), "AUTUMN", 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, want, comparisons)
+	assert.Equal(t, []any{"AUTUMN", 100}, capturedArgs)
+	assert.Contains(t, capturedSQL, "FROM claims WHERE coupon_name = c.name")
+	assert.Contains(t, capturedSQL, "FROM claims_v2 WHERE coupon_name = c.name")
+	assert.Contains(t, capturedSQL, "tier, created_at)::text", "claims is hashed with its claim time column")
+}
+
+func TestClaimRepository_CompareClaims_QueryError(t *testing.T) {
+	dbErr := errors.New(`relation "claims_v2" does not exist`)
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) { return nil, dbErr },
+	})
+
+	_, err := repo.CompareClaims(context.Background(), "", 100)
+
+	assert.ErrorIs(t, err, dbErr)
+}
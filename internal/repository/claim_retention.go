@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// anonymizedClaimPrefix starts the user ID given to a claim anonymized by ExpireClaims,
+// followed by its claim ID: unique per coupon, the same in claims and claims_v2, and
+// unrelated to the user.
+const anonymizedClaimPrefix = "anonymized-"
+
+// RetentionPolicies returns the claim retention of every coupon that has one, in
+// coupon name order. Deleted coupons are skipped: their claims go when they are purged.
+func (r *ClaimRepository) RetentionPolicies(ctx context.Context) ([]model.CouponClaimRetention, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT name, claim_retention FROM coupons
+		WHERE claim_retention IS NOT NULL AND deleted_at IS NULL
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list claim retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []model.CouponClaimRetention{}
+	for rows.Next() {
+		var policy model.CouponClaimRetention
+		if err := rows.Scan(&policy.CouponName, &policy.ClaimRetention); err != nil {
+			return nil, fmt.Errorf("scan claim retention policy: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate claim retention policies: %w", err)
+	}
+	return policies, nil
+}
+
+// ExpireClaims purges or anonymizes up to limit claims of couponName made before
+// cutoff within tx, skipping claims already anonymized, and returns how many. The
+// claims are chosen and locked in the table reads use, then changed by claim ID in every
+// table writes go to, so both stay equal while claims moves to claims_v2. Anonymized claims keep their sequence, tier,
+// channel and region; only the user ID is replaced.
+func (r *ClaimRepository) ExpireClaims(ctx context.Context, tx database.TxQuerier, couponName, action string, cutoff time.Time, limit int) (int, error) {
+	var change func(table, idColumn string) string
+	var pending string // Claims the action has not been applied to
+	switch action {
+	case model.RetentionPurge:
+		change = func(table, idColumn string) string {
+			return `DELETE FROM ` + table + ` WHERE coupon_name = $1 AND ` + idColumn + ` = ANY($2)`
+		}
+	case model.RetentionAnonymize:
+		change = func(table, idColumn string) string {
+			return `UPDATE ` + table + ` SET user_id = '` + anonymizedClaimPrefix + `' || ` + idColumn + `::TEXT
+				WHERE coupon_name = $1 AND ` + idColumn + ` = ANY($2)`
+		}
+		pending = ` AND user_id NOT LIKE '` + anonymizedClaimPrefix + `%'`
+	default:
+		return 0, fmt.Errorf("unknown claim retention action %q", action)
+	}
+
+	idColumn := claimIDColumn(r.table.ReadTable())
+	rows, err := tx.Query(ctx, `
+		SELECT `+idColumn+` FROM `+r.table.ReadTable()+`
+		WHERE coupon_name = $1 AND `+r.claimedAtExpr()+` < $2`+pending+`
+		ORDER BY `+idColumn+`
+		LIMIT $3
+		FOR UPDATE
+	`, couponName, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("list expired claims of coupon %s: %w", couponName, err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return 0, fmt.Errorf("scan expired claims of coupon %s: %w", couponName, err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// Expired claims give up their users' exclusion group claims, which would otherwise
+	// keep the user ID an anonymized claim drops
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM coupon_exclusion_claims e USING `+r.table.ReadTable()+` c
+		WHERE c.coupon_name = $1 AND c.`+idColumn+` = ANY($2) AND e.coupon_name = c.coupon_name AND e.user_id = c.user_id
+	`, couponName, ids); err != nil {
+		return 0, fmt.Errorf("release exclusion claims of expired claims of coupon %s: %w", couponName, err)
+	}
+	for _, table := range r.table.WriteTables() {
+		if _, err := tx.Exec(ctx, change(table, claimIDColumn(table)), couponName, ids); err != nil {
+			return 0, fmt.Errorf("%s expired claims in %s of coupon %s: %w", action, table, couponName, err)
+		}
+	}
+	return len(ids), nil
+}
+
+// claimIDColumn returns the column of table holding the claim ID: claims.id, which
+// claims_v2 keeps as claim_id.
+func claimIDColumn(table string) string {
+	if table == database.ClaimsV2.New {
+		return "claim_id"
+	}
+	return "id"
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// mockClaimIDRows implements pgx.Rows for testing ExpireClaims.
+type mockClaimIDRows struct {
+	mockClaimRows
+	ids []int64
+}
+
+func (m *mockClaimIDRows) Next() bool {
+	if m.index < len(m.ids) {
+		m.index++
+		return true
+	}
+	return false
+}
+
+func (m *mockClaimIDRows) Scan(dest ...any) error {
+	*(dest[0].(*int64)) = m.ids[m.index-1]
+	return nil
+}
+
+func TestClaimRepository_ExpireClaims(t *testing.T) {
+	cutoff := time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		action  string
+		phase   database.MigrationPhase
+		list    string
+		changes []string
+	}{
+		{
+			name:   "purge",
+			action: model.RetentionPurge,
+			phase:  database.PhaseOld,
+			list:   "SELECT id FROM claims",
+			changes: []string{
+				"DELETE FROM coupon_exclusion_claims e USING claims c",
+				"DELETE FROM claims WHERE coupon_name = $1 AND id = ANY($2)",
+			},
+		},
+		{
+			name:   "anonymize while dual writing",
+			action: model.RetentionAnonymize,
+			phase:  database.PhaseDualWrite,
+			list:   "SELECT id FROM claims",
+			changes: []string{
+				"DELETE FROM coupon_exclusion_claims e USING claims c",
+				"UPDATE claims SET user_id = 'anonymized-' || id::TEXT",
+				"UPDATE claims_v2 SET user_id = 'anonymized-' || claim_id::TEXT",
+			},
+		},
+		{
+			name:   "purge from claims_v2",
+			action: model.RetentionPurge,
+			phase:  database.PhaseNew,
+			list:   "SELECT claim_id FROM claims_v2",
+			changes: []string{
+				"c.claim_id = ANY($2)",
+				"DELETE FROM claims_v2 WHERE coupon_name = $1 AND claim_id = ANY($2)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var listSQL string
+			var listArgs []any
+			var changes []string
+			mockTx := &mockTxQuerier{
+				queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+					listSQL, listArgs = sql, args
+					return &mockClaimIDRows{ids: []int64{7, 9}}, nil
+				},
+				execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+					changes = append(changes, sql)
+					assert.Equal(t, []any{"PROMO", []int64{7, 9}}, arguments)
+					return pgconn.NewCommandTag("UPDATE 2"), nil
+				},
+			}
+			move := database.ClaimsV2
+			move.Phase = tt.phase
+			repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+			repo.SetTableMoves(map[string]database.TableMove{move.Name(): move})
+
+			expired, err := repo.ExpireClaims(context.Background(), mockTx, "PROMO", tt.action, cutoff, 100)
+
+			require.NoError(t, err)
+			assert.Equal(t, 2, expired)
+			assert.Contains(t, listSQL, tt.list)
+			assert.Contains(t, listSQL, "FOR UPDATE")
+			assert.Equal(t, []any{"PROMO", cutoff, 100}, listArgs)
+			assert.Equal(t, tt.action == model.RetentionAnonymize, strings.Contains(listSQL, "NOT LIKE 'anonymized-%'"),
+				"only anonymizing skips anonymized claims")
+			require.Len(t, changes, len(tt.changes))
+			for i, want := range tt.changes {
+				assert.Contains(t, changes[i], want)
+			}
+		})
+	}
+}
+
+func TestClaimRepository_ExpireClaims_NoneExpired(t *testing.T) {
+	mockTx := &mockTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return &mockClaimIDRows{}, nil
+		},
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			t.Fatal("nothing to change")
+			return pgconn.CommandTag{}, nil
+		},
+	}
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+
+	expired, err := repo.ExpireClaims(context.Background(), mockTx, "PROMO", model.RetentionPurge, time.Now(), 100)
+
+	require.NoError(t, err)
+	assert.Zero(t, expired)
+}
+
+func TestClaimRepository_ExpireClaims_Errors(t *testing.T) {
+	repo := NewClaimRepositoryWithPool(&mockClaimPool{})
+
+	_, err := repo.ExpireClaims(context.Background(), &mockTxQuerier{}, "PROMO", "archive", time.Now(), 100)
+	assert.ErrorContains(t, err, `unknown claim retention action "archive"`)
+
+	dbErr := errors.New("database connection failed")
+	mockTx := &mockTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return &mockClaimIDRows{ids: []int64{7}}, nil
+		},
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			if strings.Contains(sql, "coupon_exclusion_claims") {
+				return pgconn.NewCommandTag("DELETE 0"), nil
+			}
+			return pgconn.CommandTag{}, dbErr
+		},
+	}
+	expired, err := repo.ExpireClaims(context.Background(), mockTx, "PROMO", model.RetentionAnonymize, time.Now(), 100)
+	assert.Zero(t, expired)
+	assert.ErrorIs(t, err, dbErr)
+	assert.ErrorContains(t, err, "anonymize expired claims in claims of coupon PROMO")
+}
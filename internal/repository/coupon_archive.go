@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// archivedCouponColumns is the column list of coupon_archive SELECTs.
+const archivedCouponColumns = `name, coupon, claims, last_claim_at, created_at, deleted_at, archived_at, visibility`
+
+// Archive locks the coupon name within tx if it was deleted longer than window ago,
+// copies it to coupon_archive and reports whether it did. A coupon of the same name
+// archived earlier (the name was reused after a purge) is replaced.
+func (r *CouponRepository) Archive(ctx context.Context, tx database.TxQuerier, name string, window time.Duration) (bool, error) {
+	coupon, err := scanCoupon(tx.QueryRow(ctx, `
+		SELECT `+couponColumns+` FROM coupons WHERE name = $1 AND deleted_at <= NOW() - $2::interval FOR UPDATE
+	`, name, window))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil // Restored, or purged by another instance
+		}
+		return false, fmt.Errorf("lock deleted coupon %s: %w", name, err)
+	}
+
+	coupon.Tags = nonNilTags(coupon.Tags)
+	snapshot, err := json.Marshal(coupon)
+	if err != nil {
+		return false, fmt.Errorf("encode coupon %s for the archive: %w", name, err)
+	}
+	var lastClaimAt *time.Time
+	if coupon.Stats != nil {
+		lastClaimAt = coupon.Stats.LastClaimAt
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO coupon_archive (name, coupon, claims, last_claim_at, created_at, deleted_at, visibility)
+		SELECT name, $2, $3, $4, created_at, deleted_at, visibility FROM coupons WHERE name = $1
+		ON CONFLICT (name) DO UPDATE SET
+			coupon = EXCLUDED.coupon, claims = EXCLUDED.claims, last_claim_at = EXCLUDED.last_claim_at,
+			created_at = EXCLUDED.created_at, deleted_at = EXCLUDED.deleted_at,
+			visibility = EXCLUDED.visibility, archived_at = NOW()
+	`, name, snapshot, coupon.ClaimSequence, lastClaimAt)
+	if err != nil {
+		return false, fmt.Errorf("archive coupon %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// GetArchived returns the archived coupon name, or nil if there is none. It reads from
+// the read pool when reads have their own.
+func (r *CouponRepository) GetArchived(ctx context.Context, name string) (*model.ArchivedCoupon, error) {
+	var a model.ArchivedCoupon
+	err := r.reads.QueryRow(ctx, `SELECT `+archivedCouponColumns+` FROM coupon_archive WHERE name = $1`, name).
+		Scan(&a.Name, &a.Coupon, &a.Claims, &a.LastClaimAt, &a.CreatedAt, &a.DeletedAt, &a.ArchivedAt, &a.Visibility)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get archived coupon %s: %w", name, err)
+	}
+	return &a, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestCouponRepository_Archive(t *testing.T) {
+	lastClaim := time.Date(2026, 9, 30, 18, 0, 0, 0, time.UTC)
+	var lockSQL, archiveSQL string
+	var archiveArgs []any
+	tx := &mockTxQuerier{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			lockSQL = sql
+			assert.Equal(t, []any{"PROMO", time.Hour}, args)
+			return &mockRow{scanFn: func(dest ...any) error {
+				*(dest[0].(*string)) = "PROMO"
+				*(dest[1].(*int)) = 10
+				*(dest[7].(*int)) = 4
+				*(dest[15].(**model.CouponStats)) = &model.CouponStats{TotalClaims: 4, LastClaimAt: &lastClaim}
+				return nil
+			}}
+		},
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			archiveSQL, archiveArgs = sql, arguments
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+
+	archived, err := NewCouponRepositoryWithPool(&mockPool{}).Archive(context.Background(), tx, "PROMO", time.Hour)
+
+	require.NoError(t, err)
+	assert.True(t, archived)
+	assert.Contains(t, lockSQL, "deleted_at <= NOW() - $2::interval FOR UPDATE")
+	assert.Contains(t, archiveSQL, "INSERT INTO coupon_archive")
+	assert.Contains(t, archiveSQL, "ON CONFLICT (name) DO UPDATE")
+	require.Len(t, archiveArgs, 4)
+	var snapshot map[string]any
+	require.NoError(t, json.Unmarshal(archiveArgs[1].([]byte), &snapshot))
+	assert.Equal(t, "PROMO", snapshot["name"])
+	assert.Equal(t, []any{}, snapshot["tags"])
+	assert.Equal(t, 4, archiveArgs[2], "claims are the coupon's claim sequence")
+	assert.Equal(t, &lastClaim, archiveArgs[3])
+}
+
+func TestCouponRepository_Archive_Restored(t *testing.T) {
+	execCalled := false
+	tx := &mockTxQuerier{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFn: func(dest ...any) error { return pgx.ErrNoRows }}
+		},
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			execCalled = true
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+
+	archived, err := NewCouponRepositoryWithPool(&mockPool{}).Archive(context.Background(), tx, "PROMO", time.Hour)
+
+	require.NoError(t, err)
+	assert.False(t, archived)
+	assert.False(t, execCalled)
+}
+
+func TestCouponRepository_GetArchived(t *testing.T) {
+	pool := &mockPool{queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+		assert.Contains(t, sql, "FROM coupon_archive WHERE name = $1")
+		if args[0] != "PROMO" {
+			return &mockRow{scanFn: func(dest ...any) error { return pgx.ErrNoRows }}
+		}
+		return &mockRow{scanFn: func(dest ...any) error {
+			*(dest[0].(*string)) = "PROMO"
+			*(dest[2].(*int)) = 4
+			*(dest[7].(*string)) = model.VisibilityPublic
+			return nil
+		}}
+	}}
+	repo := NewCouponRepositoryWithPool(pool)
+
+	archived, err := repo.GetArchived(context.Background(), "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, &model.ArchivedCoupon{Name: "PROMO", Claims: 4, Visibility: model.VisibilityPublic}, archived)
+
+	archived, err = repo.GetArchived(context.Background(), "OTHER")
+	require.NoError(t, err)
+	assert.Nil(t, archived)
+}
+
+func TestCouponRepository_GetArchived_Error(t *testing.T) {
+	pool := &mockPool{queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+		return &mockRow{scanFn: func(dest ...any) error { return errors.New("connection reset") }}
+	}}
+
+	_, err := NewCouponRepositoryWithPool(pool).GetArchived(context.Background(), "PROMO")
+
+	assert.ErrorContains(t, err, "get archived coupon PROMO")
+}
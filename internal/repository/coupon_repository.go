@@ -2,108 +2,587 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
-	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
 	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
+// couponColumns is the column list shared by all coupon SELECTs.
+// New columns are appended at the end so scanCoupon stays positional.
+// Channel partitions, region claims and the claim stats rollup are aggregated inline so
+// a single round trip (and a single FOR UPDATE) returns the full coupon state.
+const couponColumns = `name, amount, remaining_amount, created_at, tags, overflow_at,
+	(SELECT COALESCE(jsonb_agg(jsonb_build_object(
+			'channel', q.channel, 'quota', q.quota, 'remaining', q.remaining) ORDER BY q.channel), '[]'::jsonb)
+		FROM coupon_channel_quotas q WHERE q.coupon_name = coupons.name),
+	claim_sequence, tiers, disabled, captcha_required, metadata, low_stock_percent,
+	COALESCE(parent, ''),
+	(SELECT COALESCE(jsonb_agg(jsonb_build_object(
+			'region', r.region, 'quota', COALESCE(r.quota, 0), 'claimed', r.claimed) ORDER BY r.region), '[]'::jsonb)
+		FROM coupon_region_claims r WHERE r.coupon_name = coupons.name),
+	(SELECT jsonb_build_object('total_claims', s.total_claims, 'last_claim_at', s.last_claim_at,
+			'hour_start', s.hour_start, 'claims_this_hour', s.claims_this_hour, 'claims_prev_hour', s.claims_prev_hour)
+		FROM coupon_stats s WHERE s.coupon_name = coupons.name),
+	unlimited, claim_retention, visibility, high_profile,
+	COALESCE(prerequisite, ''), COALESCE(exclusion_group, '')`
+
 // PoolInterface defines the database operations needed by repositories.
 // This allows for easier testing with mocks.
 type PoolInterface interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// nonNilTiers returns tiers or an empty slice, since a nil slice would encode as JSON null.
+func nonNilTiers(tiers []model.Tier) []model.Tier {
+	if tiers == nil {
+		return []model.Tier{}
+	}
+	return tiers
 }
 
-// CouponRepository provides data access for coupons using pgx.
+// nonNilMetadata returns metadata or an empty JSON object, the column's default.
+func nonNilMetadata(metadata json.RawMessage) json.RawMessage {
+	if len(metadata) == 0 {
+		return json.RawMessage("{}")
+	}
+	return metadata
+}
+
+// CouponRepository provides data access for coupons using pgx. Reads outside a
+// transaction use the read pool (see SetReadPool); everything else uses pool.
+// Deleted coupons (see Tombstone) are hidden from every method but Names and those
+// of ports.CouponTombstoneRepository.
 type CouponRepository struct {
-	pool PoolInterface
+	pool     PoolInterface
+	reads    PoolInterface
+	timeouts database.QueryTimeouts
+	lock     database.LockPolicy
+	waits    LockWaitWatcher // nil when lock waits are not watched
+	retry    *database.ReadRetrier
+	metrics  *database.StatementMetrics
+	claims   database.TableMove // claims, being moved to claims_v2
+	stock    string             // Channel notified when stock may have become claimable; empty for none
 }
 
+var (
+	_ ports.CouponRepository          = (*CouponRepository)(nil)
+	_ ports.CouponTombstoneRepository = (*CouponRepository)(nil)
+)
+
 // NewCouponRepository creates a new CouponRepository with the given pool.
 func NewCouponRepository(pool *pgxpool.Pool) *CouponRepository {
-	return &CouponRepository{pool: pool}
+	return NewCouponRepositoryWithPool(pool)
 }
 
 // NewCouponRepositoryWithPool creates a new CouponRepository with a custom pool interface.
 // This is primarily used for testing.
 func NewCouponRepositoryWithPool(pool PoolInterface) *CouponRepository {
-	return &CouponRepository{pool: pool}
+	return &CouponRepository{pool: pool, reads: pool, claims: database.ClaimsV2}
+}
+
+// SetTableMoves sets the migration phase of the move of claims to claims_v2 (see
+// database.TableMove), which decides the claim tables Purge empties.
+func (r *CouponRepository) SetTableMoves(moves map[string]database.TableMove) {
+	if move, ok := moves[database.ClaimsV2.Name()]; ok {
+		r.claims = move
+	}
+}
+
+// StockChannel is the PostgreSQL channel SetStockNotifications notifies, with the coupon
+// name as payload.
+const StockChannel = "coupon_stock"
+
+// SetStockNotifications makes TopUp, re-enabling SetDisabled and Restore notify channel
+// (see database.Listener) with the coupon's name when their change commits, so waiters
+// for stock recheck the coupon. Requires PostgreSQL; an empty channel sends none.
+func (r *CouponRepository) SetStockNotifications(channel string) {
+	r.stock = channel
+}
+
+// notifyStock notifies the stock channel, if set, that name may have claimable stock.
+// Within a transaction the notification is sent when it commits.
+func (r *CouponRepository) notifyStock(ctx context.Context, tx database.TxQuerier, name string) error {
+	if r.stock == "" {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, r.stock, name); err != nil {
+		return fmt.Errorf("notify stock of %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetReadPool sets the pool used by GetByName, List and Names, so that read traffic
+// cannot take the connections claims need.
+func (r *CouponRepository) SetReadPool(pool PoolInterface) {
+	r.reads = pool
+}
+
+// SetQueryTimeouts sets the timeouts of the queries named in database.Queries.
+func (r *CouponRepository) SetQueryTimeouts(timeouts database.QueryTimeouts) {
+	r.timeouts = timeouts
+}
+
+// SetStatementMetrics sets the metrics timing the statements named in database.Statements.
+func (r *CouponRepository) SetStatementMetrics(metrics *database.StatementMetrics) {
+	r.metrics = metrics
+}
+
+// SetReadRetrier sets the retrier rerunning GetByName when its connection is lost.
+func (r *CouponRepository) SetReadRetrier(retrier *database.ReadRetrier) {
+	r.retry = retrier
+}
+
+// SetLockPolicy sets how GetCouponForUpdate acquires a coupon's row lock.
+func (r *CouponRepository) SetLockPolicy(policy database.LockPolicy) {
+	r.lock = policy
+}
+
+// LockWaitWatcher is told of every coupon row lock GetCouponForUpdate waits for, e.g. to
+// dump the lock state of long waits (see lockdiag.Diagnoser).
+type LockWaitWatcher interface {
+	// Watch is called as the transaction in backend pid starts waiting for the row lock
+	// of coupon; stop is called once it has the lock or gave up.
+	Watch(ctx context.Context, pid uint32, coupon string) (stop func())
+}
+
+// SetLockWaitWatcher sets the watcher of GetCouponForUpdate's row lock waits. It sees
+// the waits of transactions on pgx connections only.
+func (r *CouponRepository) SetLockWaitWatcher(w LockWaitWatcher) {
+	r.waits = w
+}
+
+// scanCoupon scans a row selected with couponColumns into a Coupon.
+func scanCoupon(row pgx.Row) (*model.Coupon, error) {
+	var coupon model.Coupon
+	if err := row.Scan(
+		&coupon.Name,
+		&coupon.Amount,
+		&coupon.RemainingAmount,
+		&coupon.CreatedAt,
+		&coupon.Tags,
+		&coupon.OverflowAt,
+		&coupon.Channels,
+		&coupon.ClaimSequence,
+		&coupon.Tiers,
+		&coupon.Disabled,
+		&coupon.CaptchaRequired,
+		&coupon.Metadata,
+		&coupon.LowStockPercent,
+		&coupon.Parent,
+		&coupon.Regions,
+		&coupon.Stats,
+		&coupon.Unlimited,
+		&coupon.ClaimRetention,
+		&coupon.Visibility,
+		&coupon.HighProfile,
+		&coupon.Prerequisite,
+		&coupon.ExclusionGroup,
+	); err != nil {
+		return nil, err
+	}
+	if string(coupon.Metadata) == "{}" {
+		coupon.Metadata = nil
+	}
+	return &coupon, nil
+}
+
+// nonNilTags returns tags or an empty slice, since a nil slice would encode as JSON null.
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
 }
 
-// Insert inserts a new coupon into the database.
-// Returns service.ErrCouponExists if a coupon with the same name already exists.
+// Insert inserts a new coupon and its channel partitions (if any) into the database.
+// Both are written by a single statement, so no transaction is required.
+// Returns apperr.ErrCouponExists if a coupon with the same name already exists: the
+// statement skips existing names (ON CONFLICT DO NOTHING) and counts the coupons it
+// returned, so a concurrent creation of the same name is no error for PostgreSQL and
+// leaves a surrounding transaction (InsertTx) usable.
 func (r *CouponRepository) Insert(ctx context.Context, coupon *model.Coupon) error {
-	_, err := r.pool.Exec(ctx,
-		`INSERT INTO coupons (name, amount, remaining_amount) VALUES ($1, $2, $3)`,
-		coupon.Name, coupon.Amount, coupon.Amount) // remaining_amount = amount
+	return r.metrics.Run(database.StatementInsertCoupon, func() error {
+		return insertCoupon(ctx, r.pool, coupon)
+	})
+}
+
+// InsertTx is Insert within a caller-managed transaction.
+func (r *CouponRepository) InsertTx(ctx context.Context, tx database.TxQuerier, coupon *model.Coupon) error {
+	return r.metrics.Run(database.StatementInsertCoupon, func() error {
+		return insertCoupon(ctx, tx, coupon)
+	})
+}
+
+func insertCoupon(ctx context.Context, q database.TxQuerier, coupon *model.Coupon) error {
+	channels := make([]string, 0, len(coupon.Channels))
+	quotas := make([]int, 0, len(coupon.Channels))
+	for _, q := range coupon.Channels {
+		channels = append(channels, q.Channel)
+		quotas = append(quotas, q.Quota)
+	}
+	regions := make([]string, 0, len(coupon.Regions))
+	regionQuotas := make([]int, 0, len(coupon.Regions))
+	for _, r := range coupon.Regions {
+		regions = append(regions, r.Region)
+		regionQuotas = append(regionQuotas, r.Quota)
+	}
+
+	// Partitions are only inserted along with a new coupon: they join c, which is empty
+	// when the name exists
+	var inserted int
+	err := q.QueryRow(ctx,
+		`WITH c AS (
+			INSERT INTO coupons (name, amount, remaining_amount, tags, overflow_at, tiers, captcha_required, metadata, low_stock_percent, parent, unlimited, claim_retention, visibility, high_profile, prerequisite, exclusion_group)
+			VALUES ($1, $2, $3, $4, $5, $8, $9, $10, $11, NULLIF($12, ''), $15, $16, COALESCE(NULLIF($17, ''), 'public'), $18, NULLIF($19, ''), NULLIF($20, ''))
+			ON CONFLICT (name) DO NOTHING
+			RETURNING name
+		), r AS (
+			INSERT INTO coupon_region_claims (coupon_name, region, quota)
+			SELECT c.name, r.region, r.quota FROM c, unnest($13::text[], $14::int[]) AS r(region, quota)
+		), q AS (
+			INSERT INTO coupon_channel_quotas (coupon_name, channel, quota, remaining)
+			SELECT c.name, q.channel, q.quota, q.quota FROM c, unnest($6::text[], $7::int[]) AS q(channel, quota)
+		)
+		SELECT count(*) FROM c`,
+		coupon.Name, coupon.Amount, coupon.Amount, nonNilTags(coupon.Tags), coupon.OverflowAt, // remaining_amount = amount
+		channels, quotas, nonNilTiers(coupon.Tiers), coupon.CaptchaRequired,
+		nonNilMetadata(coupon.Metadata), coupon.LowStockPercent, coupon.Parent,
+		regions, regionQuotas, coupon.Unlimited, coupon.ClaimRetention, coupon.Visibility, coupon.HighProfile, coupon.Prerequisite, coupon.ExclusionGroup).Scan(&inserted)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return service.ErrCouponExists
-		}
 		return fmt.Errorf("insert coupon: %w", err)
 	}
+	if inserted == 0 {
+		return apperr.ErrCouponExists
+	}
 	return nil
 }
 
 // GetByName retrieves a coupon by its name.
 // Returns nil, nil if the coupon is not found (service layer handles this).
 func (r *CouponRepository) GetByName(ctx context.Context, name string) (*model.Coupon, error) {
-	query := `SELECT name, amount, remaining_amount, created_at FROM coupons WHERE name = $1`
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE name = $1 AND deleted_at IS NULL`
 
-	var coupon model.Coupon
-	err := r.pool.QueryRow(ctx, query, name).Scan(
-		&coupon.Name,
-		&coupon.Amount,
-		&coupon.RemainingAmount,
-		&coupon.CreatedAt,
-	)
+	var coupon *model.Coupon
+	err := r.retry.Run(ctx, func(ctx context.Context) error {
+		return r.timeouts.Run(ctx, database.QueryGetCoupon, func(ctx context.Context) error {
+			var err error
+			coupon, err = scanCoupon(r.reads.QueryRow(ctx, query, name))
+			return err
+		})
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil // Not found - let service handle
 		}
 		return nil, fmt.Errorf("get coupon by name %s: %w", name, err)
 	}
-	return &coupon, nil
+	return coupon, nil
+}
+
+// couponStatusConditions are the SQL conditions selecting coupons of each status.
+var couponStatusConditions = map[string]string{
+	model.CouponStatusActive:    `NOT disabled AND (unlimited OR remaining_amount > 0)`,
+	model.CouponStatusExhausted: `NOT disabled AND NOT unlimited AND remaining_amount <= 0`,
+	model.CouponStatusDisabled:  `disabled`,
+}
+
+// List retrieves public coupons ordered by name, optionally filtered by tag and status
+// and starting after the name filter.After.
+// On success, returns an empty slice (not nil) when nothing matches.
+func (r *CouponRepository) List(ctx context.Context, filter model.CouponFilter) ([]model.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE deleted_at IS NULL AND visibility = 'public'`
+	args := []any{}
+	if filter.Tag != "" {
+		args = append(args, []string{filter.Tag})
+		query += fmt.Sprintf(` AND tags @> $%d::jsonb`, len(args))
+	}
+	if filter.Status != "" {
+		cond, ok := couponStatusConditions[filter.Status]
+		if !ok {
+			return nil, fmt.Errorf("list coupons: unknown status %q", filter.Status)
+		}
+		query += ` AND ` + cond
+	}
+	if filter.After != "" {
+		args = append(args, filter.After)
+		query += fmt.Sprintf(` AND name > $%d`, len(args))
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(` ORDER BY name LIMIT $%d`, len(args))
+
+	rows, err := r.reads.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list coupons: %w", err)
+	}
+	defer rows.Close()
+
+	coupons := []model.Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan coupon: %w", err)
+		}
+		coupons = append(coupons, *coupon)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate coupon rows: %w", err)
+	}
+	return coupons, nil
+}
+
+// Names returns the names of all coupons, deleted ones included until purged.
+// On success, returns an empty slice (not nil) when there are none.
+func (r *CouponRepository) Names(ctx context.Context) ([]string, error) {
+	rows, err := r.reads.Query(ctx, `SELECT name FROM coupons`)
+	if err != nil {
+		return nil, fmt.Errorf("list coupon names: %w", err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan coupon name: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate coupon rows: %w", err)
+	}
+	return names, nil
+}
+
+// UpdateTags replaces the tags of a coupon.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (r *CouponRepository) UpdateTags(ctx context.Context, name string, tags []string) error {
+	return updateTags(ctx, r.pool, name, tags)
+}
+
+// UpdateTagsTx is UpdateTags within a caller-managed transaction.
+func (r *CouponRepository) UpdateTagsTx(ctx context.Context, tx database.TxQuerier, name string, tags []string) error {
+	return updateTags(ctx, tx, name, tags)
+}
+
+func updateTags(ctx context.Context, q database.TxQuerier, name string, tags []string) error {
+	tag, err := q.Exec(ctx, `UPDATE coupons SET tags = $2 WHERE name = $1 AND deleted_at IS NULL`, name, nonNilTags(tags))
+	if err != nil {
+		return fmt.Errorf("update tags for %s: %w", name, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrCouponNotFound
+	}
+	return nil
+}
+
+// ListForUpdate retrieves every coupon, children (coupons with a parent) first and then
+// by name, locking all of their rows in that order until the transaction completes.
+// Claims lock a child's row before its parent's, so the two cannot deadlock.
+// Used by bulk reconciliation (manifest apply).
+func (r *CouponRepository) ListForUpdate(ctx context.Context, tx database.TxQuerier) ([]model.Coupon, error) {
+	rows, err := tx.Query(ctx, `SELECT `+couponColumns+` FROM coupons WHERE deleted_at IS NULL ORDER BY parent IS NULL, name FOR UPDATE`)
+	if err != nil {
+		return nil, fmt.Errorf("list coupons for update: %w", err)
+	}
+	defer rows.Close()
+
+	coupons := []model.Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan coupon: %w", err)
+		}
+		coupons = append(coupons, *coupon)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate coupon rows: %w", err)
+	}
+	return coupons, nil
+}
+
+// TopUp increases a coupon's amount and remaining stock by delta.
+// Must be called within a transaction after locking the row.
+func (r *CouponRepository) TopUp(ctx context.Context, tx database.TxQuerier, name string, delta int) error {
+	query := `UPDATE coupons SET amount = amount + $2, remaining_amount = remaining_amount + $2 WHERE name = $1`
+
+	_, err := tx.Exec(ctx, query, name, delta)
+	if err != nil {
+		return fmt.Errorf("top up %s: %w", name, err)
+	}
+	return r.notifyStock(ctx, tx, name)
+}
+
+// SetDisabled disables or re-enables claims on a coupon.
+// Must be called within a transaction after locking the row.
+func (r *CouponRepository) SetDisabled(ctx context.Context, tx database.TxQuerier, name string, disabled bool) error {
+	_, err := tx.Exec(ctx, `UPDATE coupons SET disabled = $2 WHERE name = $1`, name, disabled)
+	if err != nil {
+		return fmt.Errorf("set disabled for %s: %w", name, err)
+	}
+	if disabled {
+		return nil
+	}
+	return r.notifyStock(ctx, tx, name)
+}
+
+// SetHighProfile marks a coupon as high-profile or not.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (r *CouponRepository) SetHighProfile(ctx context.Context, tx database.TxQuerier, name string, highProfile bool) error {
+	tag, err := tx.Exec(ctx, `UPDATE coupons SET high_profile = $2 WHERE name = $1 AND deleted_at IS NULL`, name, highProfile)
+	if err != nil {
+		return fmt.Errorf("set high profile for %s: %w", name, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrCouponNotFound
+	}
+	return nil
 }
 
 // GetCouponForUpdate retrieves a coupon with a row lock (SELECT FOR UPDATE).
 // This locks the row until the transaction completes.
-// Returns service.ErrCouponNotFound if the coupon doesn't exist.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist, and
+// apperr.ErrCouponBusy if the lock policy gave up on a lock held by another transaction.
+// The timeout policy sets lock_timeout for the rest of the transaction.
 func (r *CouponRepository) GetCouponForUpdate(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
-	query := `SELECT name, amount, remaining_amount, created_at FROM coupons WHERE name = $1 FOR UPDATE`
+	var coupon *model.Coupon
+	err := r.metrics.Run(database.StatementGetForUpdate, func() error {
+		var err error
+		coupon, err = r.getCouponForUpdate(ctx, tx, name)
+		return err
+	})
+	return coupon, err
+}
 
-	var coupon model.Coupon
-	err := tx.QueryRow(ctx, query, name).Scan(
-		&coupon.Name,
-		&coupon.Amount,
-		&coupon.RemainingAmount,
-		&coupon.CreatedAt,
-	)
+func (r *CouponRepository) getCouponForUpdate(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE name = $1 AND deleted_at IS NULL FOR UPDATE`
+	switch r.lock.Mode {
+	case database.LockNoWait:
+		query += ` NOWAIT`
+	case database.LockTimeout:
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`SET LOCAL lock_timeout = '%dms'`, r.lock.Timeout.Milliseconds())); err != nil {
+			return nil, fmt.Errorf("set lock timeout: %w", err)
+		}
+	}
+
+	if conn, ok := tx.(interface{ Conn() *pgx.Conn }); ok && r.waits != nil && r.lock.Mode != database.LockNoWait {
+		defer r.waits.Watch(ctx, conn.Conn().PgConn().PID(), name)()
+	}
+
+	var coupon *model.Coupon
+	err := r.timeouts.Run(ctx, database.QueryLockCoupon, func(ctx context.Context) error {
+		var err error
+		coupon, err = scanCoupon(tx.QueryRow(ctx, query, name))
+		return err
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, service.ErrCouponNotFound
+			return nil, apperr.ErrCouponNotFound
+		}
+		if database.IsLockNotAvailable(err) {
+			return nil, apperr.ErrCouponBusy
 		}
 		return nil, fmt.Errorf("get coupon for update %s: %w", name, err)
 	}
-	return &coupon, nil
+	return coupon, nil
+}
+
+// DecrementChannelStock decrements the remaining stock of one channel partition by 1.
+// Must be called within a transaction after locking the parent coupon row.
+func (r *CouponRepository) DecrementChannelStock(ctx context.Context, tx database.TxQuerier, name, channel string) error {
+	query := `UPDATE coupon_channel_quotas SET remaining = remaining - 1 WHERE coupon_name = $1 AND channel = $2`
+
+	_, err := tx.Exec(ctx, query, name, channel)
+	if err != nil {
+		return fmt.Errorf("decrement channel stock for %s/%s: %w", name, channel, err)
+	}
+	return nil
 }
 
-// DecrementStock decrements the remaining_amount of a coupon by 1.
+// DecrementStock decrements the remaining_amount of a coupon by 1 and advances its claim_sequence.
 // Must be called within a transaction after locking the row.
 func (r *CouponRepository) DecrementStock(ctx context.Context, tx database.TxQuerier, name string) error {
-	query := `UPDATE coupons SET remaining_amount = remaining_amount - 1 WHERE name = $1`
+	query := `UPDATE coupons SET remaining_amount = remaining_amount - 1, claim_sequence = claim_sequence + 1 WHERE name = $1`
 
-	_, err := tx.Exec(ctx, query, name)
+	err := r.metrics.Run(database.StatementDecrementStock, func() error {
+		_, err := tx.Exec(ctx, query, name)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("decrement stock for %s: %w", name, err)
 	}
 	return nil
 }
+
+// AdvanceClaimSequence advances the claim_sequence of an unlimited coupon, which has no
+// stock to decrement. Must be called within a transaction after locking the row.
+func (r *CouponRepository) AdvanceClaimSequence(ctx context.Context, tx database.TxQuerier, name string) error {
+	_, err := tx.Exec(ctx, `UPDATE coupons SET claim_sequence = claim_sequence + 1 WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("advance claim sequence for %s: %w", name, err)
+	}
+	return nil
+}
+
+// CountRegionClaim counts a claim of the coupon name from region.
+// Must be called within a transaction after locking the coupon row.
+func (r *CouponRepository) CountRegionClaim(ctx context.Context, tx database.TxQuerier, name, region string) error {
+	query := `INSERT INTO coupon_region_claims (coupon_name, region, claimed) VALUES ($1, $2, 1)
+		ON CONFLICT (coupon_name, region) DO UPDATE SET claimed = coupon_region_claims.claimed + 1`
+
+	_, err := tx.Exec(ctx, query, name, region)
+	if err != nil {
+		return fmt.Errorf("count region claim for %s/%s: %w", name, region, err)
+	}
+	return nil
+}
+
+// RecordClaimStats counts a claim of the coupon name made at at in its stats rollup.
+// Claims are bucketed by clock hour; a claim in the hour after the current bucket
+// shifts the buckets, and claims older than the previous bucket only count towards
+// the total. Must be called within a transaction after locking the coupon row.
+func (r *CouponRepository) RecordClaimStats(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error {
+	query := `INSERT INTO coupon_stats (coupon_name, total_claims, last_claim_at, hour_start, claims_this_hour, claims_prev_hour)
+		VALUES ($1, 1, $2, $3, 1, 0)
+		ON CONFLICT (coupon_name) DO UPDATE SET
+			total_claims = coupon_stats.total_claims + 1,
+			last_claim_at = GREATEST(coupon_stats.last_claim_at, EXCLUDED.last_claim_at),
+			claims_prev_hour = CASE
+				WHEN EXCLUDED.hour_start = coupon_stats.hour_start + INTERVAL '1 hour' THEN coupon_stats.claims_this_hour
+				WHEN EXCLUDED.hour_start > coupon_stats.hour_start THEN 0
+				WHEN EXCLUDED.hour_start = coupon_stats.hour_start - INTERVAL '1 hour' THEN coupon_stats.claims_prev_hour + 1
+				ELSE coupon_stats.claims_prev_hour END,
+			claims_this_hour = CASE
+				WHEN EXCLUDED.hour_start = coupon_stats.hour_start THEN coupon_stats.claims_this_hour + 1
+				WHEN EXCLUDED.hour_start > coupon_stats.hour_start THEN 1
+				ELSE coupon_stats.claims_this_hour END,
+			hour_start = GREATEST(coupon_stats.hour_start, EXCLUDED.hour_start)`
+
+	_, err := tx.Exec(ctx, query, name, at, at.Truncate(time.Hour))
+	if err != nil {
+		return fmt.Errorf("record claim stats for %s: %w", name, err)
+	}
+	return nil
+}
+
+// DecrementBudget decrements the remaining_amount of a parent coupon by 1 for a claim
+// of one of its children; its claim_sequence only counts its own claims.
+// Must be called within a transaction after locking the row.
+func (r *CouponRepository) DecrementBudget(ctx context.Context, tx database.TxQuerier, name string) error {
+	_, err := tx.Exec(ctx, `UPDATE coupons SET remaining_amount = remaining_amount - 1 WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("decrement budget for %s: %w", name, err)
+	}
+	return nil
+}
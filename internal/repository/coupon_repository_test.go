@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,8 +12,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
-	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
 // mockRow implements pgx.Row for testing GetByName.
@@ -27,10 +29,49 @@ func (m *mockRow) Scan(dest ...any) error {
 	return nil
 }
 
+// mockCouponRows implements pgx.Rows for testing coupon listings.
+type mockCouponRows struct {
+	data      []model.Coupon
+	index     int
+	errOnScan error
+	errOnRows error
+}
+
+func (m *mockCouponRows) Close()     {}
+func (m *mockCouponRows) Err() error { return m.errOnRows }
+
+func (m *mockCouponRows) Next() bool {
+	if m.index < len(m.data) {
+		m.index++
+		return true
+	}
+	return false
+}
+
+func (m *mockCouponRows) Scan(dest ...any) error {
+	if m.errOnScan != nil {
+		return m.errOnScan
+	}
+	c := m.data[m.index-1]
+	*(dest[0].(*string)) = c.Name
+	*(dest[1].(*int)) = c.Amount
+	*(dest[2].(*int)) = c.RemainingAmount
+	*(dest[3].(*time.Time)) = c.CreatedAt
+	*(dest[4].(*[]string)) = c.Tags
+	return nil
+}
+
+func (m *mockCouponRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (m *mockCouponRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (m *mockCouponRows) RawValues() [][]byte                          { return nil }
+func (m *mockCouponRows) Values() ([]any, error)                       { return nil, nil }
+func (m *mockCouponRows) Conn() *pgx.Conn                              { return nil }
+
 // mockPool implements PoolInterface for testing.
 type mockPool struct {
 	execFn     func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	queryRowFn func(ctx context.Context, sql string, args ...any) pgx.Row
+	queryFn    func(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 }
 
 func (m *mockPool) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
@@ -47,15 +88,30 @@ func (m *mockPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Ro
 	return &mockRow{}
 }
 
+func (m *mockPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if m.queryFn != nil {
+		return m.queryFn(ctx, sql, args...)
+	}
+	return &mockCouponRows{}, nil
+}
+
+// insertedRow returns the row of the coupon insert statement, counting n inserted coupons.
+func insertedRow(n int) pgx.Row {
+	return &mockRow{scanFn: func(dest ...any) error {
+		*dest[0].(*int) = n
+		return nil
+	}}
+}
+
 func TestCouponRepository_Insert_Success(t *testing.T) {
 	var capturedSQL string
 	var capturedArgs []any
 
 	mock := &mockPool{
-		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
 			capturedSQL = sql
 			capturedArgs = arguments
-			return pgconn.NewCommandTag("INSERT 0 1"), nil
+			return insertedRow(1)
 		},
 	}
 
@@ -75,15 +131,101 @@ func TestCouponRepository_Insert_Success(t *testing.T) {
 	assert.Equal(t, 100, capturedArgs[2]) // remaining_amount = amount
 }
 
+func TestCouponRepository_Insert_Unlimited(t *testing.T) {
+	var capturedArgs []any
+	mock := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			capturedArgs = arguments
+			return insertedRow(1)
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	err := repo.Insert(context.Background(), &model.Coupon{Name: "WELCOME", Unlimited: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, capturedArgs[1])
+	assert.Equal(t, true, capturedArgs[14]) // $15 unlimited
+}
+
+func TestCouponRepository_Insert_Visibility(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return insertedRow(1)
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	err := repo.Insert(context.Background(), &model.Coupon{Name: "SECRET", Amount: 10, Visibility: model.VisibilityUnlisted})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "COALESCE(NULLIF($17, ''), 'public')")
+	assert.Equal(t, model.VisibilityUnlisted, capturedArgs[16])
+}
+
+func TestCouponRepository_Insert_Prerequisite(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return insertedRow(1)
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	err := repo.Insert(context.Background(), &model.Coupon{Name: "GOLD", Amount: 10, Prerequisite: "SILVER"})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "NULLIF($19, '')")
+	assert.Equal(t, "SILVER", capturedArgs[18])
+}
+
+func TestCouponRepository_Insert_ExclusionGroup(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return insertedRow(1)
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	err := repo.Insert(context.Background(), &model.Coupon{Name: "WELCOME_A", Amount: 10, ExclusionGroup: "welcome"})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "NULLIF($20, '')")
+	assert.Equal(t, "welcome", capturedArgs[19])
+}
+
+func TestCouponRepository_List_PublicOnly(t *testing.T) {
+	var capturedSQL string
+	mock := &mockPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			return &mockCouponRows{}, nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	_, err := repo.List(context.Background(), model.CouponFilter{Limit: 10})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "WHERE deleted_at IS NULL AND visibility = 'public'")
+}
+
 func TestCouponRepository_Insert_DuplicateCoupon(t *testing.T) {
 	mock := &mockPool{
-		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
-			// Simulate PostgreSQL unique violation error (code 23505)
-			pgErr := &pgconn.PgError{
-				Code:    "23505",
-				Message: "duplicate key value violates unique constraint",
-			}
-			return pgconn.CommandTag{}, pgErr
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			assert.Contains(t, sql, "ON CONFLICT (name) DO NOTHING")
+			return insertedRow(0) // The name exists: nothing inserted, no error raised
 		},
 	}
 
@@ -96,14 +238,14 @@ func TestCouponRepository_Insert_DuplicateCoupon(t *testing.T) {
 	err := repo.Insert(context.Background(), coupon)
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, service.ErrCouponExists), "should return ErrCouponExists for duplicate")
+	assert.True(t, errors.Is(err, apperr.ErrCouponExists), "should return ErrCouponExists for duplicate")
 }
 
 func TestCouponRepository_Insert_DatabaseError(t *testing.T) {
 	dbErr := errors.New("connection refused")
 	mock := &mockPool{
-		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
-			return pgconn.CommandTag{}, dbErr
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			return &mockRow{scanFn: func(dest ...any) error { return dbErr }}
 		},
 	}
 
@@ -116,20 +258,20 @@ func TestCouponRepository_Insert_DatabaseError(t *testing.T) {
 	err := repo.Insert(context.Background(), coupon)
 
 	require.Error(t, err)
-	assert.False(t, errors.Is(err, service.ErrCouponExists), "should not return ErrCouponExists for generic error")
+	assert.False(t, errors.Is(err, apperr.ErrCouponExists), "should not return ErrCouponExists for generic error")
 	assert.Contains(t, err.Error(), "insert coupon")
 	assert.True(t, errors.Is(err, dbErr), "should wrap original error")
 }
 
 func TestCouponRepository_Insert_OtherPgError(t *testing.T) {
 	mock := &mockPool{
-		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
-			// Simulate a different PostgreSQL error (not 23505)
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			// Simulate a PostgreSQL error
 			pgErr := &pgconn.PgError{
 				Code:    "23502", // not_null_violation
 				Message: "null value in column violates not-null constraint",
 			}
-			return pgconn.CommandTag{}, pgErr
+			return &mockRow{scanFn: func(dest ...any) error { return pgErr }}
 		},
 	}
 
@@ -142,16 +284,16 @@ func TestCouponRepository_Insert_OtherPgError(t *testing.T) {
 	err := repo.Insert(context.Background(), coupon)
 
 	require.Error(t, err)
-	assert.False(t, errors.Is(err, service.ErrCouponExists), "should not return ErrCouponExists for non-23505 error")
+	assert.False(t, errors.Is(err, apperr.ErrCouponExists), "should not return ErrCouponExists for other errors")
 	assert.Contains(t, err.Error(), "insert coupon")
 }
 
 func TestCouponRepository_Insert_VerifiesParameterizedQuery(t *testing.T) {
 	var capturedSQL string
 	mock := &mockPool{
-		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
 			capturedSQL = sql
-			return pgconn.NewCommandTag("INSERT 0 1"), nil
+			return insertedRow(1)
 		},
 	}
 
@@ -240,6 +382,77 @@ func TestCouponRepository_GetByName_DatabaseError(t *testing.T) {
 	assert.True(t, errors.Is(err, dbErr), "should wrap original error")
 }
 
+func TestCouponRepository_GetByName_RetriesLostConnection(t *testing.T) {
+	var calls int
+	mock := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			calls++
+			return &mockRow{
+				scanFn: func(dest ...any) error {
+					if calls == 1 {
+						return &pgconn.PgError{Code: "57P01"}
+					}
+					*(dest[0].(*string)) = "PROMO_SUPER"
+					return nil
+				},
+			}
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	retrier := database.NewReadRetrier()
+	repo.SetReadRetrier(retrier)
+	coupon, err := repo.GetByName(context.Background(), "PROMO_SUPER")
+
+	require.NoError(t, err)
+	require.NotNil(t, coupon)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, database.ReadRetryStats{Retries: 1, Recovered: 1}, retrier.Stats())
+}
+
+func TestCouponRepository_GetByName_NoRetryWithoutRetrier(t *testing.T) {
+	var calls int
+	mock := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			calls++
+			return &mockRow{
+				scanFn: func(dest ...any) error {
+					return &pgconn.PgError{Code: "57P01"}
+				},
+			}
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	_, err := repo.GetByName(context.Background(), "PROMO_SUPER")
+
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCouponRepository_SetReadPool(t *testing.T) {
+	claimPool := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			t.Error("reads outside transactions must not use the claim pool")
+			return &mockRow{}
+		},
+	}
+	var reads int
+	readPool := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			reads++
+			return &mockRow{scanFn: func(dest ...any) error { return pgx.ErrNoRows }}
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(claimPool)
+	repo.SetReadPool(readPool)
+	_, err := repo.GetByName(context.Background(), "PROMO_SUPER")
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, reads)
+}
+
 func TestCouponRepository_GetByName_VerifiesParameterizedQuery(t *testing.T) {
 	var capturedSQL string
 	var capturedArgs []any
@@ -337,7 +550,7 @@ func TestCouponRepository_GetCouponForUpdate_NotFound(t *testing.T) {
 	coupon, err := repo.GetCouponForUpdate(context.Background(), mockTx, "NONEXISTENT")
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, service.ErrCouponNotFound), "should return ErrCouponNotFound")
+	assert.True(t, errors.Is(err, apperr.ErrCouponNotFound), "should return ErrCouponNotFound")
 	assert.Nil(t, coupon)
 }
 
@@ -386,6 +599,46 @@ func TestCouponRepository_GetCouponForUpdate_VerifiesParameterizedQuery(t *testi
 	assert.Equal(t, "'; DROP TABLE coupons;--", capturedArgs[0])
 }
 
+func TestCouponRepository_GetCouponForUpdate_LockPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    database.LockPolicy
+		wantExec  string
+		wantQuery string
+	}{
+		{"wait", database.LockPolicy{}, "", "FOR UPDATE"},
+		{"nowait", database.LockPolicy{Mode: database.LockNoWait}, "", "FOR UPDATE NOWAIT"},
+		{"timeout", database.LockPolicy{Mode: database.LockTimeout, Timeout: 250 * time.Millisecond},
+			"SET LOCAL lock_timeout = '250ms'", "FOR UPDATE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var execSQL, querySQL string
+			mockTx := &mockCouponTxQuerier{
+				execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+					execSQL = sql
+					return pgconn.NewCommandTag("SET"), nil
+				},
+				queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+					querySQL = sql
+					return &mockRow{scanFn: func(dest ...any) error {
+						return &pgconn.PgError{Code: "55P03", Message: "could not obtain lock on row"}
+					}}
+				},
+			}
+
+			repo := NewCouponRepositoryWithPool(&mockPool{})
+			repo.SetLockPolicy(tt.policy)
+			_, err := repo.GetCouponForUpdate(context.Background(), mockTx, "PROMO_SUPER")
+
+			assert.ErrorIs(t, err, apperr.ErrCouponBusy)
+			assert.Equal(t, tt.wantExec, execSQL)
+			assert.True(t, strings.HasSuffix(querySQL, tt.wantQuery), querySQL)
+		})
+	}
+}
+
 func TestCouponRepository_DecrementStock_Success(t *testing.T) {
 	var capturedSQL string
 	var capturedArgs []any
@@ -403,9 +656,84 @@ func TestCouponRepository_DecrementStock_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, capturedSQL, "UPDATE coupons")
 	assert.Contains(t, capturedSQL, "remaining_amount = remaining_amount - 1")
+	assert.Contains(t, capturedSQL, "claim_sequence = claim_sequence + 1")
 	assert.Equal(t, "PROMO_SUPER", capturedArgs[0])
 }
 
+func TestCouponRepository_AdvanceClaimSequence(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL, capturedArgs = sql, arguments
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	err := repo.AdvanceClaimSequence(context.Background(), mockTx, "WELCOME")
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "claim_sequence = claim_sequence + 1")
+	assert.NotContains(t, capturedSQL, "remaining_amount")
+	assert.Equal(t, []any{"WELCOME"}, capturedArgs)
+}
+
+func TestCouponRepository_CountRegionClaim(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL, capturedArgs = sql, arguments
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	err := repo.CountRegionClaim(context.Background(), mockTx, "GLOBAL", "eu")
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "ON CONFLICT (coupon_name, region) DO UPDATE SET claimed = coupon_region_claims.claimed + 1")
+	assert.Equal(t, []any{"GLOBAL", "eu"}, capturedArgs)
+}
+
+func TestCouponRepository_RecordClaimStats(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL, capturedArgs = sql, arguments
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+	at := time.Date(2026, 1, 1, 10, 15, 30, 0, time.UTC)
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	err := repo.RecordClaimStats(context.Background(), mockTx, "GLOBAL", at)
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "ON CONFLICT (coupon_name) DO UPDATE SET")
+	assert.Contains(t, capturedSQL, "total_claims = coupon_stats.total_claims + 1")
+	assert.Equal(t, []any{"GLOBAL", at, time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)}, capturedArgs)
+}
+
+func TestCouponRepository_DecrementBudget(t *testing.T) {
+	var capturedSQL string
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = sql
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	err := repo.DecrementBudget(context.Background(), mockTx, "BF_TOTAL")
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "remaining_amount = remaining_amount - 1")
+	assert.NotContains(t, capturedSQL, "claim_sequence", "a parent's sequence only counts its own claims")
+}
+
 func TestCouponRepository_DecrementStock_DatabaseError(t *testing.T) {
 	dbErr := errors.New("database connection failed")
 	mockTx := &mockCouponTxQuerier{
@@ -422,6 +750,28 @@ func TestCouponRepository_DecrementStock_DatabaseError(t *testing.T) {
 	assert.True(t, errors.Is(err, dbErr), "should wrap original error")
 }
 
+func TestCouponRepository_StatementMetrics(t *testing.T) {
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			if arguments[0] == "BROKEN" {
+				return pgconn.CommandTag{}, errors.New("database connection failed")
+			}
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+	metrics := database.NewStatementMetrics()
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	repo.SetStatementMetrics(metrics)
+
+	require.NoError(t, repo.DecrementStock(context.Background(), mockTx, "PROMO_SUPER"))
+	require.Error(t, repo.DecrementStock(context.Background(), mockTx, "BROKEN"))
+
+	stats := metrics.Stats()
+	assert.Equal(t, int64(2), stats[database.StatementDecrementStock].Count)
+	assert.Equal(t, int64(1), stats[database.StatementDecrementStock].Errors)
+	assert.Zero(t, stats[database.StatementInsertClaim].Count)
+}
+
 func TestCouponRepository_DecrementStock_VerifiesParameterizedQuery(t *testing.T) {
 	var capturedSQL string
 	var capturedArgs []any
@@ -450,3 +800,470 @@ func TestNewCouponRepository_Production(t *testing.T) {
 	repo := NewCouponRepository(nil)
 	require.NotNil(t, repo, "NewCouponRepository should return a non-nil repository")
 }
+
+func TestCouponRepository_Insert_NilTagsStoredAsEmptyArray(t *testing.T) {
+	var capturedArgs []any
+	mock := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			capturedArgs = arguments
+			return insertedRow(1)
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	err := repo.Insert(context.Background(), &model.Coupon{Name: "PROMO_SUPER", Amount: 1})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, capturedArgs[3], "nil tags must not be encoded as JSON null")
+	assert.Equal(t, []model.Tier{}, capturedArgs[7], "nil tiers must not be encoded as JSON null")
+}
+
+func TestCouponRepository_GetByName_ScansTags(t *testing.T) {
+	mock := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			assert.Contains(t, sql, "tags")
+			return &mockRow{
+				scanFn: func(dest ...any) error {
+					*(dest[0].(*string)) = "PROMO_SUPER"
+					*(dest[4].(*[]string)) = []string{"blackfriday"}
+					return nil
+				},
+			}
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	coupon, err := repo.GetByName(context.Background(), "PROMO_SUPER")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"blackfriday"}, coupon.Tags)
+}
+
+func TestCouponRepository_List_WithTagFilter(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			capturedArgs = args
+			return &mockCouponRows{data: []model.Coupon{
+				{Name: "BF_APP", Amount: 10, RemainingAmount: 3, Tags: []string{"blackfriday"}},
+			}}, nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	coupons, err := repo.List(context.Background(), model.CouponFilter{Tag: "blackfriday", Limit: 25})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "tags @> $1::jsonb")
+	assert.Contains(t, capturedSQL, "ORDER BY name LIMIT $2")
+	assert.Equal(t, []any{[]string{"blackfriday"}, 25}, capturedArgs)
+	require.Len(t, coupons, 1)
+	assert.Equal(t, "BF_APP", coupons[0].Name)
+	assert.Equal(t, []string{"blackfriday"}, coupons[0].Tags)
+}
+
+func TestCouponRepository_List_WithoutFilter(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			capturedArgs = args
+			return &mockCouponRows{}, nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	coupons, err := repo.List(context.Background(), model.CouponFilter{Limit: 100})
+
+	require.NoError(t, err)
+	assert.NotContains(t, capturedSQL, "tags @>")
+	assert.Contains(t, capturedSQL, "LIMIT $1")
+	assert.Equal(t, []any{100}, capturedArgs)
+	assert.NotNil(t, coupons, "should return empty slice, not nil")
+	assert.Len(t, coupons, 0)
+}
+
+func TestCouponRepository_List_WithStatusFilter(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			capturedArgs = args
+			return &mockCouponRows{}, nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	_, err := repo.List(context.Background(), model.CouponFilter{Tag: "blackfriday", Status: model.CouponStatusExhausted, Limit: 25})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "AND NOT disabled AND NOT unlimited AND remaining_amount <= 0 ORDER BY name LIMIT $2")
+	assert.Equal(t, []any{[]string{"blackfriday"}, 25}, capturedArgs)
+
+	_, err = repo.List(context.Background(), model.CouponFilter{Status: "expired", Limit: 25})
+	assert.ErrorContains(t, err, `unknown status "expired"`)
+}
+
+func TestCouponRepository_List_After(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			capturedArgs = args
+			return &mockCouponRows{}, nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	_, err := repo.List(context.Background(), model.CouponFilter{Tag: "blackfriday", After: "BF_APP", Limit: 25})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "AND name > $2 ORDER BY name LIMIT $3")
+	assert.Equal(t, []any{[]string{"blackfriday"}, "BF_APP", 25}, capturedArgs)
+}
+
+func TestCouponRepository_List_Errors(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+
+	tests := []struct {
+		name     string
+		queryFn  func(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+		contains string
+	}{
+		{
+			name: "query error",
+			queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+				return nil, dbErr
+			},
+			contains: "list coupons",
+		},
+		{
+			name: "scan error",
+			queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+				return &mockCouponRows{data: []model.Coupon{{Name: "X"}}, errOnScan: dbErr}, nil
+			},
+			contains: "scan coupon",
+		},
+		{
+			name: "rows error",
+			queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+				return &mockCouponRows{errOnRows: dbErr}, nil
+			},
+			contains: "iterate coupon rows",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewCouponRepositoryWithPool(&mockPool{queryFn: tt.queryFn})
+			coupons, err := repo.List(context.Background(), model.CouponFilter{Limit: 10})
+
+			require.Error(t, err)
+			assert.Nil(t, coupons)
+			assert.Contains(t, err.Error(), tt.contains)
+			assert.True(t, errors.Is(err, dbErr), "should wrap original error")
+		})
+	}
+}
+
+func TestCouponRepository_Names(t *testing.T) {
+	var capturedSQL string
+	pool := &mockPool{queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		capturedSQL = sql
+		return &mockClaimRows{data: []string{"PROMO_A", "PROMO_B"}}, nil
+	}}
+
+	names, err := NewCouponRepositoryWithPool(pool).Names(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PROMO_A", "PROMO_B"}, names)
+	assert.Equal(t, "SELECT name FROM coupons", capturedSQL)
+}
+
+func TestCouponRepository_Names_QueryError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	pool := &mockPool{queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		return nil, dbErr
+	}}
+
+	_, err := NewCouponRepositoryWithPool(pool).Names(context.Background())
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.ErrorContains(t, err, "list coupon names")
+}
+
+func TestCouponRepository_UpdateTags_Success(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockPool{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	err := repo.UpdateTags(context.Background(), "PROMO_SUPER", nil)
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "UPDATE coupons SET tags = $2 WHERE name = $1")
+	assert.Equal(t, "PROMO_SUPER", capturedArgs[0])
+	assert.Equal(t, []string{}, capturedArgs[1])
+}
+
+func TestCouponRepository_UpdateTags_NotFound(t *testing.T) {
+	mock := &mockPool{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			return pgconn.NewCommandTag("UPDATE 0"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	err := repo.UpdateTags(context.Background(), "NONEXISTENT", []string{"a"})
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, apperr.ErrCouponNotFound))
+}
+
+func TestCouponRepository_UpdateTags_DatabaseError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	mock := &mockPool{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			return pgconn.CommandTag{}, dbErr
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(mock)
+	err := repo.UpdateTags(context.Background(), "PROMO_SUPER", []string{"a"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "update tags")
+	assert.True(t, errors.Is(err, dbErr))
+}
+
+func TestCouponRepository_Insert_WithChannels(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mock := &mockPool{
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return insertedRow(1)
+		},
+	}
+	overflowAt := time.Date(2026, 11, 30, 23, 0, 0, 0, time.UTC)
+
+	repo := NewCouponRepositoryWithPool(mock)
+	err := repo.Insert(context.Background(), &model.Coupon{
+		Name:       "BF_SPLIT",
+		Amount:     100,
+		OverflowAt: &overflowAt,
+		Channels: []model.ChannelQuota{
+			{Channel: "app", Quota: 70, Remaining: 70},
+			{Channel: "web", Quota: 30, Remaining: 30},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "INSERT INTO coupon_channel_quotas")
+	assert.Equal(t, &overflowAt, capturedArgs[4])
+	assert.Equal(t, []string{"app", "web"}, capturedArgs[5])
+	assert.Equal(t, []int{70, 30}, capturedArgs[6])
+}
+
+func TestCouponRepository_DecrementChannelStock_Success(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	err := repo.DecrementChannelStock(context.Background(), mockTx, "BF_SPLIT", "app")
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "UPDATE coupon_channel_quotas")
+	assert.Contains(t, capturedSQL, "remaining = remaining - 1")
+	assert.Equal(t, []any{"BF_SPLIT", "app"}, capturedArgs)
+}
+
+func TestCouponRepository_DecrementChannelStock_DatabaseError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			return pgconn.CommandTag{}, dbErr
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	err := repo.DecrementChannelStock(context.Background(), mockTx, "BF_SPLIT", "app")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "decrement channel stock")
+	assert.ErrorIs(t, err, dbErr)
+}
+
+func TestCouponRepository_InsertTx_UsesTransaction(t *testing.T) {
+	var capturedSQL []string
+	mockTx := &mockCouponTxQuerier{
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			capturedSQL = append(capturedSQL, sql)
+			return insertedRow(1)
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{
+		queryRowFn: func(ctx context.Context, sql string, arguments ...any) pgx.Row {
+			t.Fatal("InsertTx must not use the pool")
+			return nil
+		},
+	})
+	err := repo.InsertTx(context.Background(), mockTx, &model.Coupon{Name: "NEW", Amount: 10, RemainingAmount: 10})
+
+	require.NoError(t, err)
+	require.NotEmpty(t, capturedSQL)
+	assert.Contains(t, capturedSQL[0], "INSERT INTO coupons")
+}
+
+func TestCouponRepository_UpdateTagsTx_NotFound(t *testing.T) {
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			return pgconn.NewCommandTag("UPDATE 0"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	err := repo.UpdateTagsTx(context.Background(), mockTx, "NONEXISTENT", []string{"a"})
+
+	assert.True(t, errors.Is(err, apperr.ErrCouponNotFound))
+}
+
+func TestCouponRepository_SetHighProfile(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return pgconn.NewCommandTag("UPDATE 0"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	err := repo.SetHighProfile(context.Background(), mockTx, "NONEXISTENT", true)
+
+	assert.True(t, errors.Is(err, apperr.ErrCouponNotFound))
+	assert.Contains(t, capturedSQL, "deleted_at IS NULL")
+	assert.Equal(t, []any{"NONEXISTENT", true}, capturedArgs)
+}
+
+func TestCouponRepository_ListForUpdate(t *testing.T) {
+	var capturedSQL string
+	mockTx := &mockCouponTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedSQL = sql
+			return &mockCouponRows{data: []model.Coupon{{Name: "A"}, {Name: "B"}}}, nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	coupons, err := repo.ListForUpdate(context.Background(), mockTx)
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "ORDER BY parent IS NULL, name FOR UPDATE", "children are locked before their parents")
+	require.Len(t, coupons, 2)
+	assert.Equal(t, "B", coupons[1].Name)
+}
+
+func TestCouponRepository_ListForUpdate_Error(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	mockTx := &mockCouponTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return nil, dbErr
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	coupons, err := repo.ListForUpdate(context.Background(), mockTx)
+
+	assert.Nil(t, coupons)
+	assert.Contains(t, err.Error(), "list coupons for update")
+	assert.True(t, errors.Is(err, dbErr))
+}
+
+func TestCouponRepository_TopUp(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedSQL = sql
+			capturedArgs = arguments
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	err := repo.TopUp(context.Background(), mockTx, "PROMO", 40)
+
+	require.NoError(t, err)
+	assert.Contains(t, capturedSQL, "amount = amount + $2, remaining_amount = remaining_amount + $2")
+	assert.Equal(t, []any{"PROMO", 40}, capturedArgs)
+}
+
+func TestCouponRepository_StockNotifications(t *testing.T) {
+	var statements []string
+	var notified [][]any
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			statements = append(statements, sql)
+			if strings.Contains(sql, "pg_notify") {
+				notified = append(notified, arguments)
+			}
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	require.NoError(t, repo.TopUp(context.Background(), mockTx, "PROMO", 40))
+	assert.Len(t, statements, 1, "no notification without a channel")
+
+	repo.SetStockNotifications(StockChannel)
+	require.NoError(t, repo.TopUp(context.Background(), mockTx, "PROMO", 40))
+	require.NoError(t, repo.SetDisabled(context.Background(), mockTx, "PROMO", true))
+	require.NoError(t, repo.SetDisabled(context.Background(), mockTx, "SALE", false))
+
+	assert.Equal(t, [][]any{{StockChannel, "PROMO"}, {StockChannel, "SALE"}}, notified,
+		"top-ups and re-enabling notify, disabling does not")
+}
+
+func TestCouponRepository_SetDisabled(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	var capturedArgs []any
+	mockTx := &mockCouponTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			capturedArgs = arguments
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+	}
+
+	repo := NewCouponRepositoryWithPool(&mockPool{})
+	require.NoError(t, repo.SetDisabled(context.Background(), mockTx, "PROMO", true))
+	assert.Equal(t, []any{"PROMO", true}, capturedArgs)
+
+	mockTx.execFn = func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		return pgconn.CommandTag{}, dbErr
+	}
+	err := repo.SetDisabled(context.Background(), mockTx, "PROMO", false)
+	assert.True(t, errors.Is(err, dbErr))
+	assert.Contains(t, err.Error(), "set disabled for PROMO")
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// couponReferences are the tables besides the claim tables referencing coupons(name),
+// emptied of a coupon's rows before it is purged.
+var couponReferences = []string{"coupon_channel_quotas", "coupon_region_claims", "coupon_stats", "campaign_leaderboard_progress", "coupon_allowlist", "coupon_exclusion_claims"}
+
+// Tombstone marks the coupon name deleted and returns when, by the database clock.
+// A claim waiting on the coupon's row lock finds it deleted once the lock is released.
+// Returns apperr.ErrCouponNotFound if it does not exist or is already deleted.
+func (r *CouponRepository) Tombstone(ctx context.Context, name string) (time.Time, error) {
+	var deletedAt time.Time
+	err := r.pool.QueryRow(ctx, `
+		UPDATE coupons SET deleted_at = NOW() WHERE name = $1 AND deleted_at IS NULL
+		RETURNING deleted_at
+	`, name).Scan(&deletedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, apperr.ErrCouponNotFound
+		}
+		return time.Time{}, fmt.Errorf("delete coupon %s: %w", name, err)
+	}
+	return deletedAt, nil
+}
+
+// Restore clears the deletion of the coupon name if it was deleted within window.
+// Returns apperr.ErrDeletedCouponNotFound otherwise.
+func (r *CouponRepository) Restore(ctx context.Context, name string, window time.Duration) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE coupons SET deleted_at = NULL WHERE name = $1 AND deleted_at > NOW() - $2::interval
+	`, name, window)
+	if err != nil {
+		return fmt.Errorf("restore coupon %s: %w", name, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrDeletedCouponNotFound
+	}
+	return r.notifyStock(ctx, r.pool, name)
+}
+
+// Expired returns up to limit coupons deleted longer than window ago, oldest first.
+func (r *CouponRepository) Expired(ctx context.Context, window time.Duration, limit int) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT name FROM coupons
+		WHERE deleted_at <= NOW() - $1::interval
+		ORDER BY deleted_at
+		LIMIT $2
+	`, window, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list expired coupons: %w", err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan expired coupon: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate expired coupons: %w", err)
+	}
+	return names, nil
+}
+
+// Purge removes the coupon name, its claims and the other rows referencing it within
+// tx if it was deleted longer than window ago, and reports whether it did. The coupon
+// row is locked first, so a concurrent restore either wins or finds it gone.
+func (r *CouponRepository) Purge(ctx context.Context, tx database.TxQuerier, name string, window time.Duration) (bool, error) {
+	var locked string
+	err := tx.QueryRow(ctx, `
+		SELECT name FROM coupons WHERE name = $1 AND deleted_at <= NOW() - $2::interval FOR UPDATE
+	`, name, window).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil // Restored, or purged by another instance
+		}
+		return false, fmt.Errorf("lock deleted coupon %s: %w", name, err)
+	}
+
+	if err := r.purge(ctx, tx, name); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// purge removes the coupon name, its claims and the other rows referencing it within
+// tx. The caller holds the coupon's row lock.
+func (r *CouponRepository) purge(ctx context.Context, tx database.TxQuerier, name string) error {
+	for _, table := range append(r.claims.WriteTables(), couponReferences...) {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE coupon_name = $1`, name); err != nil {
+			return fmt.Errorf("purge %s of coupon %s: %w", table, name, err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM coupons WHERE name = $1`, name); err != nil {
+		return fmt.Errorf("purge coupon %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponRepository_Tombstone_NotFound(t *testing.T) {
+	pool := &mockPool{queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+		return &mockRow{scanFn: func(dest ...any) error { return pgx.ErrNoRows }}
+	}}
+
+	_, err := NewCouponRepositoryWithPool(pool).Tombstone(context.Background(), "PROMO")
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+}
+
+func TestCouponRepository_Restore_NotFound(t *testing.T) {
+	var capturedArgs []any
+	pool := &mockPool{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		capturedArgs = arguments
+		return pgconn.NewCommandTag("UPDATE 0"), nil
+	}}
+
+	err := NewCouponRepositoryWithPool(pool).Restore(context.Background(), "PROMO", time.Hour)
+
+	assert.ErrorIs(t, err, apperr.ErrDeletedCouponNotFound)
+	assert.Equal(t, []any{"PROMO", time.Hour}, capturedArgs)
+}
+
+func TestCouponRepository_Restore_NotifiesStock(t *testing.T) {
+	var statements []string
+	pool := &mockPool{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		statements = append(statements, sql)
+		return pgconn.NewCommandTag("UPDATE 1"), nil
+	}}
+	repo := NewCouponRepositoryWithPool(pool)
+	repo.SetStockNotifications(StockChannel)
+
+	require.NoError(t, repo.Restore(context.Background(), "PROMO", time.Hour))
+
+	require.Len(t, statements, 2)
+	assert.Contains(t, statements[1], "pg_notify")
+}
+
+func TestCouponRepository_Purge(t *testing.T) {
+	var statements []string
+	tx := &mockTxQuerier{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{}
+		},
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			statements = append(statements, sql)
+			return pgconn.NewCommandTag("DELETE 1"), nil
+		},
+	}
+
+	purged, err := NewCouponRepositoryWithPool(&mockPool{}).Purge(context.Background(), tx, "PROMO", time.Hour)
+
+	require.NoError(t, err)
+	assert.True(t, purged)
+	tables := append([]string{"claims"}, couponReferences...)
+	require.Len(t, statements, len(tables)+1)
+	for i, table := range tables {
+		assert.Contains(t, statements[i], "DELETE FROM "+table+" ")
+	}
+	assert.Contains(t, statements[len(tables)], "DELETE FROM coupons", "the coupon goes after the rows referencing it")
+}
+
+func TestCouponRepository_Purge_ClaimTables(t *testing.T) {
+	for phase, want := range map[database.MigrationPhase][]string{
+		database.PhaseDualWrite: {"claims", "claims_v2"},
+		database.PhaseNew:       {"claims_v2"},
+	} {
+		t.Run(string(phase), func(t *testing.T) {
+			var claimTables []string
+			tx := &mockTxQuerier{
+				queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row { return &mockRow{} },
+				execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+					if table := strings.Fields(sql)[2]; strings.HasPrefix(table, "claims") {
+						claimTables = append(claimTables, table)
+					}
+					return pgconn.NewCommandTag("DELETE 1"), nil
+				},
+			}
+			move := database.ClaimsV2
+			move.Phase = phase
+			repo := NewCouponRepositoryWithPool(&mockPool{})
+			repo.SetTableMoves(map[string]database.TableMove{move.Name(): move})
+
+			_, err := repo.Purge(context.Background(), tx, "PROMO", time.Hour)
+
+			require.NoError(t, err)
+			assert.Equal(t, want, claimTables)
+		})
+	}
+}
+
+func TestCouponRepository_Purge_Restored(t *testing.T) {
+	execCalled := false
+	tx := &mockTxQuerier{
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return &mockRow{scanFn: func(dest ...any) error { return pgx.ErrNoRows }}
+		},
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			execCalled = true
+			return pgconn.NewCommandTag("DELETE 1"), nil
+		},
+	}
+
+	purged, err := NewCouponRepositoryWithPool(&mockPool{}).Purge(context.Background(), tx, "PROMO", time.Hour)
+
+	require.NoError(t, err)
+	assert.False(t, purged)
+	assert.False(t, execCalled, "a restored coupon keeps its claims")
+}
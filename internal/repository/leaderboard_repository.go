@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// LeaderboardRepository provides data access for campaign leaderboards: claims per user
+// across the coupons tagged with a campaign, counted incrementally from the claims table.
+// Top and Totals use the read pool (see SetReadPool).
+type LeaderboardRepository struct {
+	pool   PoolInterface
+	reads  PoolInterface
+	claims database.TableMove // claims, being moved to claims_v2
+}
+
+var _ ports.LeaderboardRepository = (*LeaderboardRepository)(nil)
+
+// NewLeaderboardRepository creates a new LeaderboardRepository.
+func NewLeaderboardRepository(pool *pgxpool.Pool) *LeaderboardRepository {
+	return NewLeaderboardRepositoryWithPool(pool)
+}
+
+// NewLeaderboardRepositoryWithPool creates a new LeaderboardRepository with a custom pool interface.
+// This is primarily used for testing.
+func NewLeaderboardRepositoryWithPool(pool PoolInterface) *LeaderboardRepository {
+	return &LeaderboardRepository{pool: pool, reads: pool, claims: database.ClaimsV2}
+}
+
+// SetReadPool sets the pool used by Top and Totals.
+func (r *LeaderboardRepository) SetReadPool(pool PoolInterface) {
+	r.reads = pool
+}
+
+// SetTableMoves sets the migration phase of the move of claims to claims_v2 (see
+// database.TableMove), which decides the table claims are counted from.
+func (r *LeaderboardRepository) SetTableMoves(moves map[string]database.TableMove) {
+	if move, ok := moves[database.ClaimsV2.Name()]; ok {
+		r.claims = move
+	}
+}
+
+// PendingCoupons returns up to limit coupons whose claim_sequence is past their
+// counted watermark, ordered by name.
+func (r *LeaderboardRepository) PendingCoupons(ctx context.Context, limit int) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT c.name FROM coupons c
+		LEFT JOIN campaign_leaderboard_progress p ON p.coupon_name = c.name
+		WHERE c.claim_sequence > COALESCE(p.claim_sequence, 0)
+		ORDER BY c.name
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list pending coupons: %w", err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan pending coupon: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pending coupons: %w", err)
+	}
+	return names, nil
+}
+
+// CountClaims adds the next claims of couponName, up to maxClaims of them past its
+// watermark, to the leaderboards of the coupon's current tags and advances the watermark.
+// Claims of unlisted and private coupons are skipped rather than counted.
+// The watermark row is locked, so concurrent refreshes of a coupon count each claim
+// once. Claims are read FOR SHARE, so a concurrent erasure either renames them before
+// they are read or waits, and then also renames the entries counted here.
+func (r *LeaderboardRepository) CountClaims(ctx context.Context, tx database.TxQuerier, couponName string, maxClaims int) (int, error) {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO campaign_leaderboard_progress (coupon_name, claim_sequence) VALUES ($1, 0)
+		ON CONFLICT (coupon_name) DO NOTHING
+	`, couponName)
+	if err != nil {
+		return 0, fmt.Errorf("create leaderboard watermark: %w", err)
+	}
+	var counted int
+	err = tx.QueryRow(ctx, `
+		SELECT claim_sequence FROM campaign_leaderboard_progress WHERE coupon_name = $1 FOR UPDATE
+	`, couponName).Scan(&counted)
+	if err != nil {
+		return 0, fmt.Errorf("lock leaderboard watermark: %w", err)
+	}
+
+	// Claims up to the coupon's claim_sequence have committed: they are numbered under
+	// the coupon row lock, in the transaction that advances it
+	var latest int
+	var tags []string
+	var visibility string
+	err = tx.QueryRow(ctx, `SELECT claim_sequence, tags, visibility FROM coupons WHERE name = $1`, couponName).Scan(&latest, &tags, &visibility)
+	if err != nil {
+		return 0, fmt.Errorf("get coupon claim sequence: %w", err)
+	}
+	upTo := min(latest, counted+maxClaims)
+	if upTo <= counted {
+		return 0, nil
+	}
+	if !model.Listed(visibility) {
+		// The public leaderboards would reveal who claimed unlisted and private coupons
+		return 0, r.advance(ctx, tx, couponName, upTo)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT user_id FROM `+r.claims.ReadTable()+`
+		WHERE coupon_name = $1 AND claim_sequence > $2 AND claim_sequence <= $3
+		FOR SHARE
+	`, couponName, counted, upTo)
+	if err != nil {
+		return 0, fmt.Errorf("list claims to count: %w", err)
+	}
+	users := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan claim to count: %w", err)
+		}
+		users = append(users, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate claims to count: %w", err)
+	}
+
+	// A user claims a coupon at most once, so each user adds one claim per tag
+	if len(users) > 0 && len(tags) > 0 {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO campaign_leaderboard (campaign, user_id, claims)
+			SELECT campaign, user_id, 1 FROM unnest($1::text[]) AS campaign, unnest($2::text[]) AS user_id
+			ON CONFLICT (campaign, user_id) DO UPDATE SET claims = campaign_leaderboard.claims + 1
+		`, tags, users)
+		if err != nil {
+			return 0, fmt.Errorf("count claims: %w", err)
+		}
+	}
+
+	if err := r.advance(ctx, tx, couponName, upTo); err != nil {
+		return 0, err
+	}
+	return len(users), nil
+}
+
+// advance moves the watermark of couponName to claimSequence within tx.
+func (r *LeaderboardRepository) advance(ctx context.Context, tx database.TxQuerier, couponName string, claimSequence int) error {
+	_, err := tx.Exec(ctx, `UPDATE campaign_leaderboard_progress SET claim_sequence = $2 WHERE coupon_name = $1`, couponName, claimSequence)
+	if err != nil {
+		return fmt.Errorf("advance leaderboard watermark: %w", err)
+	}
+	return nil
+}
+
+// Top returns the limit users with the most claims in campaign, most first and then by
+// user ID. Ranks are left zero.
+func (r *LeaderboardRepository) Top(ctx context.Context, campaign string, limit int) ([]model.LeaderboardEntry, error) {
+	rows, err := r.reads.Query(ctx, `
+		SELECT user_id, claims FROM campaign_leaderboard
+		WHERE campaign = $1
+		ORDER BY claims DESC, user_id
+		LIMIT $2
+	`, campaign, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []model.LeaderboardEntry{}
+	for rows.Next() {
+		var e model.LeaderboardEntry
+		if err := rows.Scan(&e.UserID, &e.Claims); err != nil {
+			return nil, fmt.Errorf("scan leaderboard entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate leaderboard: %w", err)
+	}
+	return entries, nil
+}
+
+// Totals returns the claims and distinct claimers counted for campaign.
+func (r *LeaderboardRepository) Totals(ctx context.Context, campaign string) (claims, claimers int64, err error) {
+	err = r.reads.QueryRow(ctx, `
+		SELECT COALESCE(SUM(claims), 0)::BIGINT, COUNT(*) FROM campaign_leaderboard WHERE campaign = $1
+	`, campaign).Scan(&claims, &claimers)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get leaderboard totals: %w", err)
+	}
+	return claims, claimers, nil
+}
+
+// PseudonymizeUser replaces userID with pseudonym on the user's leaderboard entries
+// within tx. Returns the number of entries changed.
+func (r *LeaderboardRepository) PseudonymizeUser(ctx context.Context, tx database.TxQuerier, userID, pseudonym string) (int64, error) {
+	tag, err := tx.Exec(ctx, `UPDATE campaign_leaderboard SET user_id = $2 WHERE user_id = $1`, userID, pseudonym)
+	if err != nil {
+		return 0, fmt.Errorf("pseudonymize leaderboard entries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
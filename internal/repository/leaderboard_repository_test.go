@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// leaderboardTx returns a TxQuerier whose watermark is counted, whose coupon has
+// claimed up to latest with tags, and whose claims in range are users. Exec
+// statements are appended to execs. The coupon is public.
+func leaderboardTx(counted, latest int, tags, users []string, execs *[]string, execArgs *[][]any) *mockTxQuerier {
+	return leaderboardTxVisibility(counted, latest, tags, users, model.VisibilityPublic, execs, execArgs)
+}
+
+// leaderboardTxVisibility is leaderboardTx for a coupon of visibility.
+func leaderboardTxVisibility(counted, latest int, tags, users []string, visibility string, execs *[]string, execArgs *[][]any) *mockTxQuerier {
+	return &mockTxQuerier{
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			*execs = append(*execs, strings.TrimSpace(sql))
+			*execArgs = append(*execArgs, arguments)
+			return pgconn.NewCommandTag("UPDATE 1"), nil
+		},
+		queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+			if strings.Contains(sql, "campaign_leaderboard_progress") {
+				return &mockRow{scanFn: func(dest ...any) error {
+					*(dest[0].(*int)) = counted
+					return nil
+				}}
+			}
+			return &mockRow{scanFn: func(dest ...any) error {
+				*(dest[0].(*int)) = latest
+				*(dest[1].(*[]string)) = tags
+				*(dest[2].(*string)) = visibility
+				return nil
+			}}
+		},
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			return &mockClaimRows{data: users}, nil
+		},
+	}
+}
+
+func TestLeaderboardRepository_CountClaims(t *testing.T) {
+	var execs []string
+	var execArgs [][]any
+	tx := leaderboardTx(10, 15, []string{"summer", "vip"}, []string{"alice", "bob"}, &execs, &execArgs)
+
+	n, err := NewLeaderboardRepositoryWithPool(&mockPool{}).CountClaims(context.Background(), tx, "PROMO", 1000)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.Len(t, execs, 3)
+	assert.True(t, strings.HasPrefix(execs[0], "INSERT INTO campaign_leaderboard_progress"), "the watermark row is created")
+	assert.Contains(t, execs[1], "ON CONFLICT (campaign, user_id) DO UPDATE SET claims = campaign_leaderboard.claims + 1")
+	assert.Equal(t, []any{[]string{"summer", "vip"}, []string{"alice", "bob"}}, execArgs[1])
+	assert.Equal(t, []any{"PROMO", 15}, execArgs[2], "the watermark advances to the coupon's claim sequence")
+}
+
+func TestLeaderboardRepository_CountClaims_Unlisted(t *testing.T) {
+	for _, visibility := range []string{model.VisibilityUnlisted, model.VisibilityPrivate} {
+		var execs []string
+		var execArgs [][]any
+		tx := leaderboardTxVisibility(10, 15, []string{"summer"}, []string{"alice"}, visibility, &execs, &execArgs)
+		tx.queryFn = func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			t.Fatal("claims must not be read")
+			return nil, nil
+		}
+
+		n, err := NewLeaderboardRepositoryWithPool(&mockPool{}).CountClaims(context.Background(), tx, "SECRET", 1000)
+
+		require.NoError(t, err)
+		assert.Zero(t, n, visibility)
+		require.Len(t, execs, 2, "%s coupons only advance the watermark", visibility)
+		assert.Equal(t, []any{"SECRET", 15}, execArgs[1])
+	}
+}
+
+func TestLeaderboardRepository_CountClaims_Batch(t *testing.T) {
+	var execs []string
+	var execArgs [][]any
+	var claimArgs []any
+	tx := leaderboardTx(10, 5000, nil, []string{"alice"}, &execs, &execArgs)
+	tx.queryFn = func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		claimArgs = args
+		return &mockClaimRows{data: []string{"alice"}}, nil
+	}
+
+	_, err := NewLeaderboardRepositoryWithPool(&mockPool{}).CountClaims(context.Background(), tx, "PROMO", 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, []any{"PROMO", 10, 110}, claimArgs)
+	require.Len(t, execs, 2, "untagged coupons only advance the watermark")
+	assert.Equal(t, []any{"PROMO", 110}, execArgs[1])
+}
+
+func TestLeaderboardRepository_CountClaims_ReadsMovedClaims(t *testing.T) {
+	var execs []string
+	var execArgs [][]any
+	var claimSQL string
+	tx := leaderboardTx(10, 15, nil, []string{"alice"}, &execs, &execArgs)
+	tx.queryFn = func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		claimSQL = sql
+		return &mockClaimRows{data: []string{"alice"}}, nil
+	}
+	move := database.ClaimsV2
+	move.Phase = database.PhaseReadNew
+	repo := NewLeaderboardRepositoryWithPool(&mockPool{})
+	repo.SetTableMoves(map[string]database.TableMove{move.Name(): move})
+
+	_, err := repo.CountClaims(context.Background(), tx, "PROMO", 1000)
+
+	require.NoError(t, err)
+	assert.Contains(t, claimSQL, "SELECT user_id FROM claims_v2")
+}
+
+func TestLeaderboardRepository_CountClaims_UpToDate(t *testing.T) {
+	var execs []string
+	var execArgs [][]any
+	tx := leaderboardTx(15, 15, []string{"summer"}, nil, &execs, &execArgs)
+	tx.queryFn = func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		t.Fatal("claims must not be read")
+		return nil, nil
+	}
+
+	n, err := NewLeaderboardRepositoryWithPool(&mockPool{}).CountClaims(context.Background(), tx, "PROMO", 1000)
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Len(t, execs, 1)
+}
+
+func TestLeaderboardRepository_Top(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	pool := &mockPool{queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		capturedSQL, capturedArgs = sql, args
+		return &mockCouponRows{}, nil
+	}}
+
+	entries, err := NewLeaderboardRepositoryWithPool(pool).Top(context.Background(), "summer", 10)
+
+	require.NoError(t, err)
+	assert.NotNil(t, entries)
+	assert.Contains(t, capturedSQL, "ORDER BY claims DESC, user_id")
+	assert.Equal(t, []any{"summer", 10}, capturedArgs)
+}
+
+func TestLeaderboardRepository_PseudonymizeUser(t *testing.T) {
+	var capturedArgs []any
+	tx := &mockTxQuerier{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		capturedArgs = arguments
+		return pgconn.NewCommandTag("UPDATE 3"), nil
+	}}
+
+	n, err := NewLeaderboardRepositoryWithPool(&mockPool{}).PseudonymizeUser(context.Background(), tx, "user_001", "erased_abc")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, []any{"user_001", "erased_abc"}, capturedArgs)
+}
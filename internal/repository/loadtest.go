@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// CreateNamespace records the load test namespace ns, to be cleaned up by its ExpiresAt.
+// Returns apperr.ErrLoadTestNamespaceExists if it is already recorded.
+func (r *CouponRepository) CreateNamespace(ctx context.Context, ns *model.LoadTestNamespace) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO loadtest_namespaces (namespace, coupons, claims, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, ns.Namespace, ns.Coupons, ns.Claims, ns.ExpiresAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return apperr.ErrLoadTestNamespaceExists
+		}
+		return fmt.Errorf("create load test namespace %s: %w", ns.Namespace, err)
+	}
+	return nil
+}
+
+// ExpiredNamespaces returns up to limit namespaces past their expiry, oldest first.
+func (r *CouponRepository) ExpiredNamespaces(ctx context.Context, limit int) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT namespace FROM loadtest_namespaces
+		WHERE expires_at <= NOW()
+		ORDER BY expires_at
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list expired load test namespaces: %w", err)
+	}
+	namespaces, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scan expired load test namespaces: %w", err)
+	}
+	return namespaces, nil
+}
+
+// PurgeCoupons removes up to limit coupons named with prefix, their claims and the
+// other rows referencing them within tx, and returns their names. The coupons are
+// locked first, skipping those another instance is purging, so claims on them finish
+// or find them gone.
+func (r *CouponRepository) PurgeCoupons(ctx context.Context, tx database.TxQuerier, prefix string, limit int) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT name FROM coupons
+		WHERE name LIKE $1 || '%'
+		ORDER BY name
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("lock coupons named %s*: %w", prefix, err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scan coupons named %s*: %w", prefix, err)
+	}
+	for _, name := range names {
+		if err := r.purge(ctx, tx, name); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// DeleteNamespace forgets the namespace ns.
+// Returns apperr.ErrLoadTestNamespaceNotFound if it is not recorded.
+func (r *CouponRepository) DeleteNamespace(ctx context.Context, ns string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM loadtest_namespaces WHERE namespace = $1`, ns)
+	if err != nil {
+		return fmt.Errorf("delete load test namespace %s: %w", ns, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrLoadTestNamespaceNotFound
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// mockNameRows implements pgx.Rows for testing PurgeCoupons.
+type mockNameRows struct {
+	mockClaimRows
+	names []string
+}
+
+func (m *mockNameRows) Next() bool {
+	if m.index < len(m.names) {
+		m.index++
+		return true
+	}
+	return false
+}
+
+func (m *mockNameRows) Scan(dest ...any) error {
+	*(dest[0].(*string)) = m.names[m.index-1]
+	return nil
+}
+
+func TestCouponRepository_CreateNamespace_Exists(t *testing.T) {
+	pool := &mockPool{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		return pgconn.CommandTag{}, &pgconn.PgError{Code: "23505"}
+	}}
+
+	err := NewCouponRepositoryWithPool(pool).CreateNamespace(context.Background(), &model.LoadTestNamespace{Namespace: "run42"})
+
+	assert.ErrorIs(t, err, apperr.ErrLoadTestNamespaceExists)
+}
+
+func TestCouponRepository_PurgeCoupons(t *testing.T) {
+	var lockSQL string
+	var lockArgs []any
+	var deleted []any
+	tx := &mockTxQuerier{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			lockSQL, lockArgs = sql, args
+			return &mockNameRows{names: []string{"loadtest-run42-0001", "loadtest-run42-0002"}}, nil
+		},
+		execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+			if strings.Contains(sql, "DELETE FROM coupons ") {
+				deleted = append(deleted, arguments[0])
+			}
+			return pgconn.NewCommandTag("DELETE 1"), nil
+		},
+	}
+
+	names, err := NewCouponRepositoryWithPool(&mockPool{}).PurgeCoupons(context.Background(), tx, "loadtest-run42-", 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"loadtest-run42-0001", "loadtest-run42-0002"}, names)
+	assert.Contains(t, lockSQL, "FOR UPDATE SKIP LOCKED")
+	assert.Equal(t, []any{"loadtest-run42-", 100}, lockArgs)
+	assert.Equal(t, []any{"loadtest-run42-0001", "loadtest-run42-0002"}, deleted)
+}
+
+func TestCouponRepository_DeleteNamespace_NotFound(t *testing.T) {
+	pool := &mockPool{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+		return pgconn.NewCommandTag("DELETE 0"), nil
+	}}
+
+	err := NewCouponRepositoryWithPool(pool).DeleteNamespace(context.Background(), "run42")
+
+	assert.ErrorIs(t, err, apperr.ErrLoadTestNamespaceNotFound)
+}
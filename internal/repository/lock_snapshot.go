@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/lockdiag"
+)
+
+// maxBlockingDepth bounds how far LockSnapshot follows backends blocking each other.
+const maxBlockingDepth = 5
+
+// lockSnapshotBackends selects the pg_stat_activity rows of backend $1 and of the
+// backends blocking it, directly or through up to maxBlockingDepth others.
+const lockSnapshotBackends = `WITH RECURSIVE chain(pid, depth) AS (
+		SELECT $1::int, 0
+		UNION
+		SELECT blocker, chain.depth + 1
+		FROM chain, unnest(pg_blocking_pids(chain.pid)) AS blocker
+		WHERE chain.depth < $2
+	)
+	SELECT a.pid, pg_blocking_pids(a.pid), COALESCE(a.application_name, ''), COALESCE(a.state, ''),
+		COALESCE(a.wait_event_type, ''), COALESCE(a.wait_event, ''), a.xact_start, a.query_start,
+		left(COALESCE(a.query, ''), 1024)
+	FROM pg_stat_activity a
+	WHERE a.pid IN (SELECT pid FROM chain)
+	ORDER BY a.pid`
+
+// lockSnapshotLocks selects the pg_locks rows of the backends $1 that can block row
+// locks: every lock but the access share locks of plain reads and virtual transaction IDs.
+const lockSnapshotLocks = `SELECT l.pid, l.locktype, COALESCE(l.relation::regclass::text, ''),
+		COALESCE(l.page || ',' || l.tuple, ''), COALESCE(l.transactionid::text, ''), l.mode, l.granted
+	FROM pg_locks l
+	WHERE l.pid = ANY($1) AND l.locktype <> 'virtualxid'
+		AND NOT (l.locktype = 'relation' AND l.mode = 'AccessShareLock')
+	ORDER BY l.pid, l.granted, l.locktype
+	LIMIT 200`
+
+// LockSnapshot reads the lock state of backend pid and of the backends blocking it from
+// pg_stat_activity and pg_locks (see lockdiag.Snapshot). PostgreSQL only.
+func LockSnapshot(ctx context.Context, pool PoolInterface, pid uint32) (lockdiag.Snapshot, error) {
+	snapshot := lockdiag.Snapshot{Backends: []lockdiag.Backend{}, Locks: []lockdiag.Lock{}}
+
+	rows, err := pool.Query(ctx, lockSnapshotBackends, pid, maxBlockingDepth)
+	if err != nil {
+		return snapshot, fmt.Errorf("read pg_stat_activity: %w", err)
+	}
+	snapshot.Backends, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (lockdiag.Backend, error) {
+		var b lockdiag.Backend
+		err := row.Scan(&b.PID, &b.BlockedBy, &b.ApplicationName, &b.State,
+			&b.WaitEventType, &b.WaitEvent, &b.XactStart, &b.QueryStart, &b.Query)
+		return b, err
+	})
+	if err != nil {
+		return snapshot, fmt.Errorf("read pg_stat_activity: %w", err)
+	}
+
+	pids := make([]uint32, 0, len(snapshot.Backends))
+	for _, b := range snapshot.Backends {
+		pids = append(pids, b.PID)
+	}
+	rows, err = pool.Query(ctx, lockSnapshotLocks, pids)
+	if err != nil {
+		return snapshot, fmt.Errorf("read pg_locks: %w", err)
+	}
+	snapshot.Locks, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (lockdiag.Lock, error) {
+		var l lockdiag.Lock
+		err := row.Scan(&l.PID, &l.LockType, &l.Relation, &l.Tuple, &l.TransactionID, &l.Mode, &l.Granted)
+		return l, err
+	})
+	if err != nil {
+		return snapshot, fmt.Errorf("read pg_locks: %w", err)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockSnapshot_QueryError(t *testing.T) {
+	dbErr := errors.New("acquire connection: context deadline exceeded")
+	var capturedArgs []any
+	mock := &mockPool{
+		queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			capturedArgs = args
+			return nil, dbErr
+		},
+	}
+
+	snapshot, err := LockSnapshot(context.Background(), mock, 4242)
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.Contains(t, err.Error(), "read pg_stat_activity")
+	assert.Equal(t, []any{uint32(4242), maxBlockingDepth}, capturedArgs)
+	assert.Empty(t, snapshot.Backends)
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// AllowlistRepository provides data access for coupon allowlists in memory.
+type AllowlistRepository struct {
+	db *DB
+}
+
+var _ ports.AllowlistRepository = (*AllowlistRepository)(nil)
+
+// NewAllowlistRepository creates a new AllowlistRepository on db.
+func NewAllowlistRepository(db *DB) *AllowlistRepository {
+	return &AllowlistRepository{db: db}
+}
+
+// Replace replaces couponName's allowlist with entries within tx.
+// Returns apperr.ErrCouponNotFound if the coupon does not exist.
+func (r *AllowlistRepository) Replace(_ context.Context, q database.TxQuerier, couponName string, entries []model.AllowlistEntry) error {
+	tx, err := txOf(q)
+	if err != nil {
+		return fmt.Errorf("replace allowlist %s: %w", couponName, err)
+	}
+	stored := cloneEntries(entries)
+	slices.SortFunc(stored, compareEntries)
+	for i := 1; i < len(stored); i++ {
+		if stored[i].UserID == stored[i-1].UserID {
+			return fmt.Errorf("replace allowlist %s: %s listed twice: %w", couponName, stored[i].UserID, ErrConstraint)
+		}
+	}
+	return tx.write(func() error {
+		db := tx.db
+		if !db.exists(couponName) {
+			return apperr.ErrCouponNotFound
+		}
+		db.setAllowlist(couponName, stored)
+		return nil
+	})
+}
+
+// setAllowlist replaces couponName's allowlist with entries. Must be called within
+// write.
+func (db *DB) setAllowlist(couponName string, entries []model.AllowlistEntry) {
+	if len(entries) == 0 {
+		db.allowlists.del(couponName)
+	} else {
+		db.allowlists.set(couponName, entries)
+	}
+}
+
+// List returns up to limit entries of couponName's allowlist with user IDs after
+// afterUserID ("" for the first), in user ID order; empty if it has none.
+func (r *AllowlistRepository) List(_ context.Context, couponName, afterUserID string, limit int) ([]model.AllowlistEntry, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	committed, _ := r.db.allowlists.committed(couponName)
+	start, _ := slices.BinarySearchFunc(committed, afterUserID, func(e model.AllowlistEntry, userID string) int {
+		return strings.Compare(e.UserID, userID)
+	})
+	if start < len(committed) && committed[start].UserID == afterUserID {
+		start++
+	}
+	entries := cloneEntries(committed[start:min(len(committed), start+limit)])
+	if entries == nil {
+		entries = []model.AllowlistEntry{}
+	}
+	return entries, nil
+}
+
+// Delete removes couponName's allowlist.
+// Returns apperr.ErrAllowlistNotFound if it has none.
+func (r *AllowlistRepository) Delete(ctx context.Context, couponName string) error {
+	return r.db.InTx(ctx, func(q database.TxQuerier) error {
+		tx, err := txOf(q)
+		if err != nil {
+			return fmt.Errorf("delete allowlist %s: %w", couponName, err)
+		}
+		return tx.write(func() error {
+			if _, ok := tx.db.allowlists.get(couponName); !ok {
+				return apperr.ErrAllowlistNotFound
+			}
+			tx.db.allowlists.del(couponName)
+			return nil
+		})
+	})
+}
+
+// Lookup returns userID's entry on couponName's allowlist within tx, or nil, and
+// whether the coupon has an allowlist at all.
+func (r *AllowlistRepository) Lookup(_ context.Context, q database.TxQuerier, couponName, userID string) (*model.AllowlistEntry, bool, error) {
+	tx, err := txOf(q)
+	if err != nil {
+		return nil, false, fmt.Errorf("look up allowlist entry: %w", err)
+	}
+	var entries []model.AllowlistEntry
+	tx.read(func() { entries, _ = r.db.allowlists.get(couponName) })
+	i, found := slices.BinarySearchFunc(entries, userID, func(e model.AllowlistEntry, userID string) int {
+		return strings.Compare(e.UserID, userID)
+	})
+	if !found {
+		return nil, len(entries) > 0, nil
+	}
+	return &cloneEntries(entries[i : i+1])[0], true, nil
+}
+
+// PseudonymizeUser replaces userID with pseudonym on the user's allowlist entries
+// within tx. Returns the number of entries changed.
+func (r *AllowlistRepository) PseudonymizeUser(_ context.Context, q database.TxQuerier, userID, pseudonym string) (int64, error) {
+	tx, err := txOf(q)
+	if err != nil {
+		return 0, fmt.Errorf("pseudonymize allowlist entries: %w", err)
+	}
+	var changed int64
+	err = tx.write(func() error {
+		db := tx.db
+		next := map[string][]model.AllowlistEntry{}
+		var conflict error
+		db.allowlists.all(func(coupon string, entries []model.AllowlistEntry) {
+			i := slices.IndexFunc(entries, func(e model.AllowlistEntry) bool { return e.UserID == userID })
+			if i < 0 {
+				return
+			}
+			if slices.ContainsFunc(entries, func(e model.AllowlistEntry) bool { return e.UserID == pseudonym }) {
+				conflict = fmt.Errorf("pseudonymize allowlist entries: %s already listed for %s: %w", pseudonym, coupon, ErrConstraint)
+			}
+			renamed := slices.Clone(entries)
+			renamed[i].UserID = strings.Clone(pseudonym)
+			slices.SortFunc(renamed, compareEntries)
+			next[coupon] = renamed
+		})
+		if conflict != nil {
+			return conflict
+		}
+		for coupon, entries := range next {
+			db.setAllowlist(coupon, entries)
+		}
+		changed = int64(len(next))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+// compareEntries orders allowlist entries by user ID.
+func compareEntries(a, b model.AllowlistEntry) int {
+	return strings.Compare(a.UserID, b.UserID)
+}
+
+// cloneEntries returns a copy of entries sharing none of their strings or claim-by
+// deadlines.
+func cloneEntries(entries []model.AllowlistEntry) []model.AllowlistEntry {
+	clone := slices.Clone(entries)
+	for i, e := range clone {
+		clone[i].UserID = strings.Clone(e.UserID)
+		if e.ClaimBy != nil {
+			claimBy := *e.ClaimBy
+			clone[i].ClaimBy = &claimBy
+		}
+	}
+	return clone
+}
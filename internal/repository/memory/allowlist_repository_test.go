@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestAllowlistRepository(t *testing.T) {
+	db := NewDB()
+	repo := NewAllowlistRepository(db)
+	ctx := context.Background()
+	require.NoError(t, NewCouponRepository(db).Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 10}))
+	replace := func(coupon string, entries ...model.AllowlistEntry) error {
+		return db.InTx(ctx, func(tx database.TxQuerier) error { return repo.Replace(ctx, tx, coupon, entries) })
+	}
+	lookup := func(userID string) (entry *model.AllowlistEntry, restricted bool) {
+		require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+			var err error
+			entry, restricted, err = repo.Lookup(ctx, tx, "PROMO", userID)
+			return err
+		}))
+		return entry, restricted
+	}
+
+	entry, restricted := lookup("user_001")
+	assert.Nil(t, entry)
+	assert.False(t, restricted, "no allowlist")
+
+	deadline := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, replace("PROMO", model.AllowlistEntry{UserID: "user_002", ClaimBy: &deadline}, model.AllowlistEntry{UserID: "user_001"}))
+	assert.ErrorIs(t, replace("GONE", model.AllowlistEntry{UserID: "user_001"}), apperr.ErrCouponNotFound)
+	assert.ErrorIs(t, replace("PROMO", model.AllowlistEntry{UserID: "user_001"}, model.AllowlistEntry{UserID: "user_001"}), ErrConstraint)
+
+	entries, err := repo.List(ctx, "PROMO", "", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []model.AllowlistEntry{{UserID: "user_001"}, {UserID: "user_002", ClaimBy: &deadline}}, entries)
+	entries, err = repo.List(ctx, "PROMO", "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []model.AllowlistEntry{{UserID: "user_001"}}, entries)
+	entries, err = repo.List(ctx, "PROMO", "user_001", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []model.AllowlistEntry{{UserID: "user_002", ClaimBy: &deadline}}, entries)
+	entries, err = repo.List(ctx, "PROMO", "user_0015", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []model.AllowlistEntry{{UserID: "user_002", ClaimBy: &deadline}}, entries, "the cursor need not be listed")
+	entry, restricted = lookup("user_002")
+	assert.Equal(t, &model.AllowlistEntry{UserID: "user_002", ClaimBy: &deadline}, entry)
+	assert.True(t, restricted)
+	entry, restricted = lookup("user_003")
+	assert.Nil(t, entry)
+	assert.True(t, restricted)
+
+	require.NoError(t, repo.Delete(ctx, "PROMO"))
+	assert.ErrorIs(t, repo.Delete(ctx, "PROMO"), apperr.ErrAllowlistNotFound)
+	entries, err = repo.List(ctx, "PROMO", "", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []model.AllowlistEntry{}, entries)
+}
+
+func TestAllowlistRepository_PseudonymizeUser(t *testing.T) {
+	db := NewDB()
+	repo := NewAllowlistRepository(db)
+	ctx := context.Background()
+	for _, name := range []string{"A", "B", "C"} {
+		require.NoError(t, NewCouponRepository(db).Insert(ctx, &model.Coupon{Name: name, Amount: 10}))
+	}
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		for _, name := range []string{"A", "B"} {
+			if err := repo.Replace(ctx, tx, name, []model.AllowlistEntry{{UserID: "user_001"}, {UserID: "user_002"}}); err != nil {
+				return err
+			}
+		}
+		return repo.Replace(ctx, tx, "C", []model.AllowlistEntry{{UserID: "user_002"}})
+	}))
+
+	var changed int64
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		var err error
+		changed, err = repo.PseudonymizeUser(ctx, tx, "user_001", "erased_001")
+		return err
+	}))
+	assert.Equal(t, int64(2), changed)
+	entries, err := repo.List(ctx, "A", "", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []model.AllowlistEntry{{UserID: "erased_001"}, {UserID: "user_002"}}, entries, "still in user ID order")
+
+	err = db.InTx(ctx, func(tx database.TxQuerier) error {
+		_, err := repo.PseudonymizeUser(ctx, tx, "user_002", "erased_001")
+		return err
+	})
+	assert.ErrorIs(t, err, ErrConstraint, "one entry per user")
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+)
+
+// APIKeyUsageRepository provides data access for the monthly claim counts of API keys
+// in memory.
+type APIKeyUsageRepository struct {
+	db *DB
+}
+
+var _ ports.APIKeyUsageRepository = (*APIKeyUsageRepository)(nil)
+
+// NewAPIKeyUsageRepository creates a new APIKeyUsageRepository on db.
+func NewAPIKeyUsageRepository(db *DB) *APIKeyUsageRepository {
+	return &APIKeyUsageRepository{db: db}
+}
+
+// AddUsage adds deltas to the keys' counts for month and returns the month's counts.
+// No transaction writes the counts, so it takes the lock without one.
+func (r *APIKeyUsageRepository) AddUsage(_ context.Context, month string, deltas map[string]int64) (map[string]int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for id, n := range deltas {
+		key := usageKey{id, month}
+		if _, ok := r.db.usage[key]; !ok {
+			key = usageKey{strings.Clone(id), strings.Clone(month)}
+		}
+		r.db.usage[key] += n
+	}
+	totals := make(map[string]int64)
+	for key, n := range r.db.usage {
+		if key.month == month {
+			totals[key.keyID] = n
+		}
+	}
+	return totals, nil
+}
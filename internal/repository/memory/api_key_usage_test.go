@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyUsageRepository_AddUsage(t *testing.T) {
+	repo := NewAPIKeyUsageRepository(NewDB())
+	ctx := context.Background()
+
+	totals, err := repo.AddUsage(ctx, "2026-05", map[string]int64{"a": 2, "b": 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"a": 2, "b": 1}, totals)
+
+	totals, err = repo.AddUsage(ctx, "2026-05", map[string]int64{"a": 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"a": 5, "b": 1}, totals, "with the keys not flushed")
+
+	totals, err = repo.AddUsage(ctx, "2026-06", map[string]int64{})
+	require.NoError(t, err)
+	assert.Empty(t, totals, "counts are per month")
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// AuditRepository provides data access for the audit log in memory.
+// Entries are always written inside the transaction of the operation they record.
+type AuditRepository struct {
+	db *DB
+}
+
+var _ ports.AuditRepository = (*AuditRepository)(nil)
+
+// NewAuditRepository creates a new AuditRepository on db.
+func NewAuditRepository(db *DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Insert appends an entry to the audit log within a transaction.
+// Details are stored as their JSON round trip, as the SQL repositories store them.
+func (r *AuditRepository) Insert(_ context.Context, q database.TxQuerier, entry *model.AuditEntry) error {
+	tx, err := txOf(q)
+	if err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	details := entry.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("encode audit details: %w", err)
+	}
+	stored := model.AuditEntry{Action: strings.Clone(entry.Action), Subject: strings.Clone(entry.Subject), CreatedAt: time.Now()}
+	if err := json.Unmarshal(encoded, &stored.Details); err != nil {
+		return fmt.Errorf("decode audit details: %w", err)
+	}
+
+	return tx.write(func() error {
+		stored.ID = int64(len(tx.db.audit)) + 1
+		tx.db.audit = append(tx.db.audit, stored)
+		return nil
+	})
+}
+
+// ListBySubject returns up to limit entries about subject with one of actions, newest
+// first, starting after the entry at after (nil for the newest).
+func (r *AuditRepository) ListBySubject(_ context.Context, subject string, actions []string, after *model.AuditPosition, limit int) ([]model.AuditEntry, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	entries := []model.AuditEntry{}
+	// IDs grow with the creation time, so the log is already in (created_at, id) order
+	for _, e := range slices.Backward(r.db.audit[:r.db.audited]) {
+		if len(entries) == limit {
+			break
+		}
+		if e.Subject != subject || !slices.Contains(actions, e.Action) {
+			continue
+		}
+		if after != nil && (e.CreatedAt.After(after.CreatedAt) ||
+			(e.CreatedAt.Equal(after.CreatedAt) && e.ID >= after.ID)) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
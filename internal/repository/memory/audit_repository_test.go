@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestAuditRepository_ListBySubject(t *testing.T) {
+	db := NewDB()
+	repo := NewAuditRepository(db)
+	ctx := context.Background()
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		for _, e := range []model.AuditEntry{
+			{Action: "note", Subject: "PROMO", Details: map[string]any{"n": 1}},
+			{Action: "top_up", Subject: "PROMO"},
+			{Action: "note", Subject: "OTHER"},
+			{Action: "note", Subject: "PROMO", Details: map[string]any{"n": 2}},
+		} {
+			require.NoError(t, repo.Insert(ctx, tx, &e))
+		}
+		return nil
+	}))
+
+	entries, err := repo.ListBySubject(ctx, "PROMO", []string{"note"}, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]any{"n": float64(2)}, entries[0].Details, "details are stored as JSON")
+
+	after := model.AuditPosition{CreatedAt: entries[0].CreatedAt, ID: entries[0].ID}
+	entries, err = repo.ListBySubject(ctx, "PROMO", []string{"note", "top_up"}, &after, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "top_up", entries[0].Action)
+	assert.Equal(t, map[string]any{}, entries[0].Details)
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// CampaignCapRepository provides data access for campaign claim caps in memory.
+type CampaignCapRepository struct {
+	db *DB
+}
+
+var _ ports.CampaignCapRepository = (*CampaignCapRepository)(nil)
+
+// NewCampaignCapRepository creates a new CampaignCapRepository on db.
+func NewCampaignCapRepository(db *DB) *CampaignCapRepository {
+	return &CampaignCapRepository{db: db}
+}
+
+// Set creates campaign's cap or changes its claim_cap, keeping what was claimed.
+func (r *CampaignCapRepository) Set(ctx context.Context, campaign string, claimCap int) (*model.CampaignCap, error) {
+	var c model.CampaignCap
+	err := r.db.InTx(ctx, func(q database.TxQuerier) error {
+		tx, err := txOf(q)
+		if err != nil {
+			return fmt.Errorf("set campaign cap %s: %w", campaign, err)
+		}
+		return tx.write(func() error {
+			c, _ = tx.db.caps.get(campaign)
+			c.Campaign, c.ClaimCap, c.UpdatedAt = strings.Clone(campaign), claimCap, time.Now()
+			tx.db.caps.set(campaign, c)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Get returns campaign's cap, or nil if it has none.
+func (r *CampaignCapRepository) Get(_ context.Context, campaign string) (*model.CampaignCap, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	c, ok := r.db.caps.committed(campaign)
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// Delete removes campaign's cap.
+// Returns apperr.ErrCampaignCapNotFound if it has none.
+func (r *CampaignCapRepository) Delete(ctx context.Context, campaign string) error {
+	return r.db.InTx(ctx, func(q database.TxQuerier) error {
+		tx, err := txOf(q)
+		if err != nil {
+			return fmt.Errorf("delete campaign cap %s: %w", campaign, err)
+		}
+		return tx.write(func() error {
+			if _, ok := tx.db.caps.get(campaign); !ok {
+				return apperr.ErrCampaignCapNotFound
+			}
+			tx.db.caps.del(campaign)
+			return nil
+		})
+	})
+}
+
+// LockCaps returns the caps of those campaigns that have one within tx, in campaign
+// order. The transaction already excludes all others.
+func (r *CampaignCapRepository) LockCaps(_ context.Context, q database.TxQuerier, campaigns []string) ([]model.CampaignCap, error) {
+	tx, err := txOf(q)
+	if err != nil {
+		return nil, fmt.Errorf("lock campaign caps: %w", err)
+	}
+	caps := []model.CampaignCap{}
+	tx.read(func() {
+		for _, campaign := range slices.Compact(slices.Sorted(slices.Values(campaigns))) {
+			if c, ok := r.db.caps.get(campaign); ok {
+				caps = append(caps, c)
+			}
+		}
+	})
+	return caps, nil
+}
+
+// AddClaims counts n claims against each of campaigns' caps.
+// Must be called within a transaction after LockCaps.
+func (r *CampaignCapRepository) AddClaims(_ context.Context, q database.TxQuerier, campaigns []string, n int) error {
+	tx, err := txOf(q)
+	if err != nil {
+		return fmt.Errorf("count campaign claims: %w", err)
+	}
+	return tx.write(func() error {
+		for _, campaign := range slices.Compact(slices.Sorted(slices.Values(campaigns))) {
+			if c, ok := tx.db.caps.get(campaign); ok {
+				c.Claimed += n
+				tx.db.caps.set(campaign, c)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCampaignCapRepository(t *testing.T) {
+	db := NewDB()
+	repo := NewCampaignCapRepository(db)
+	ctx := context.Background()
+
+	got, err := repo.Get(ctx, "summer")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	set, err := repo.Set(ctx, "summer", 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10, set.ClaimCap)
+	_, err = repo.Set(ctx, "winter", 5)
+	require.NoError(t, err)
+
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		caps, err := repo.LockCaps(ctx, tx, []string{"winter", "spring", "summer", "winter"})
+		require.NoError(t, err)
+		require.Len(t, caps, 2)
+		assert.Equal(t, "summer", caps[0].Campaign, "in campaign order")
+		assert.Equal(t, "winter", caps[1].Campaign)
+		return repo.AddClaims(ctx, tx, []string{"spring", "summer"}, 3)
+	}))
+	err = db.InTx(ctx, func(tx database.TxQuerier) error {
+		require.NoError(t, repo.AddClaims(ctx, tx, []string{"summer"}, 1))
+		return context.Canceled
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	set, err = repo.Set(ctx, "summer", 20)
+	require.NoError(t, err)
+	assert.Equal(t, model.CampaignCap{Campaign: "summer", ClaimCap: 20, Claimed: 3, UpdatedAt: set.UpdatedAt}, *set,
+		"the count is kept, without the rolled back claim")
+	got, err = repo.Get(ctx, "spring")
+	require.NoError(t, err)
+	assert.Nil(t, got, "claims of campaigns without a cap are not counted")
+
+	require.NoError(t, repo.Delete(ctx, "summer"))
+	assert.ErrorIs(t, repo.Delete(ctx, "summer"), apperr.ErrCampaignCapNotFound)
+}
@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// ClaimRepository provides data access for claims in memory.
+type ClaimRepository struct {
+	db      *DB
+	metrics *database.StatementMetrics
+}
+
+var (
+	_ ports.ClaimRepository     = (*ClaimRepository)(nil)
+	_ ports.UserClaimRepository = (*ClaimRepository)(nil)
+)
+
+// NewClaimRepository creates a new ClaimRepository on db.
+func NewClaimRepository(db *DB) *ClaimRepository {
+	return &ClaimRepository{db: db}
+}
+
+// SetStatementMetrics sets the metrics timing the statements named in database.Statements.
+func (r *ClaimRepository) SetStatementMetrics(metrics *database.StatementMetrics) {
+	r.metrics = metrics
+}
+
+// GetUsersByCoupon retrieves the user IDs who have claimed a specific coupon, in claim
+// order: the first limit of them, or all when limit is 0.
+// On success, returns an empty slice (not nil) when no claims exist.
+func (r *ClaimRepository) GetUsersByCoupon(_ context.Context, couponName string, limit int) ([]string, error) {
+	r.db.mu.RLock()
+	committed, _ := r.db.claims.committed(couponName)
+	claims := slices.Clone(committed)
+	r.db.mu.RUnlock()
+
+	// Imported claims may be older than the ones inserted before them
+	slices.SortStableFunc(claims, func(a, b model.Claim) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	if limit > 0 && len(claims) > limit {
+		claims = claims[:limit]
+	}
+	users := make([]string, 0, len(claims))
+	for _, claim := range claims {
+		users = append(users, claim.UserID)
+	}
+	return users, nil
+}
+
+// CountByCoupon returns how many claims a coupon has.
+func (r *ClaimRepository) CountByCoupon(_ context.Context, couponName string) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	claims, _ := r.db.claims.committed(couponName)
+	return len(claims), nil
+}
+
+// ListByCoupon retrieves up to limit claims of a coupon after claim sequence
+// afterSequence, ordered by claim sequence.
+// On success, returns an empty slice (not nil) when no claims exist.
+func (r *ClaimRepository) ListByCoupon(_ context.Context, couponName string, afterSequence, limit int) ([]model.Claim, error) {
+	claims := r.selectClaims(couponName, func(c model.Claim) bool { return c.Sequence > afterSequence })
+	if len(claims) > limit {
+		claims = claims[:limit]
+	}
+	return claims, nil
+}
+
+// ListBySequences retrieves the claims of a coupon with the given claim sequences,
+// ordered by claim sequence. Sequences without a claim are skipped.
+// On success, returns an empty slice (not nil) when none match.
+func (r *ClaimRepository) ListBySequences(_ context.Context, couponName string, sequences []int) ([]model.Claim, error) {
+	return r.selectClaims(couponName, func(c model.Claim) bool { return slices.Contains(sequences, c.Sequence) }), nil
+}
+
+// selectClaims returns the claims of couponName matching keep, ordered by claim sequence.
+func (r *ClaimRepository) selectClaims(couponName string, keep func(c model.Claim) bool) []model.Claim {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	claims := []model.Claim{}
+	committed, _ := r.db.claims.committed(couponName)
+	for _, claim := range committed {
+		if keep(claim) {
+			claims = append(claims, claim)
+		}
+	}
+	slices.SortFunc(claims, func(a, b model.Claim) int { return cmp.Compare(a.Sequence, b.Sequence) })
+	return claims
+}
+
+// Insert inserts a new claim record within a transaction.
+// A zero CreatedAt is stored as the current time.
+// Returns apperr.ErrAlreadyClaimed if the user has already claimed this coupon.
+func (r *ClaimRepository) Insert(_ context.Context, q database.TxQuerier, claim *model.Claim) error {
+	tx, err := txOf(q)
+	if err != nil {
+		return fmt.Errorf("insert claim: %w", err)
+	}
+	return r.metrics.Run(database.StatementInsertClaim, func() error {
+		return tx.write(func() error {
+			db := tx.db
+			if !db.exists(claim.CouponName) {
+				return fmt.Errorf("insert claim: unknown coupon %s: %w", claim.CouponName, ErrConstraint)
+			}
+			key := claimKey{claim.UserID, claim.CouponName}
+			if _, ok := db.claimed.get(key); ok {
+				return apperr.ErrAlreadyClaimed
+			}
+
+			stored := *claim
+			stored.UserID, stored.CouponName = strings.Clone(claim.UserID), strings.Clone(claim.CouponName)
+			stored.Channel, stored.Region, stored.Tier = strings.Clone(claim.Channel), strings.Clone(claim.Region), strings.Clone(claim.Tier)
+			if stored.CreatedAt.IsZero() {
+				stored.CreatedAt = time.Now()
+			}
+			// Appending leaves the committed claims, a prefix of the slice, unchanged
+			claims, _ := db.claims.get(claim.CouponName)
+			db.claims.set(claim.CouponName, append(claims, stored))
+			db.claimed.set(key, len(claims))
+			return nil
+		})
+	})
+}
+
+// InsertExclusionClaim records userID's claim of couponName as their one claim of the
+// exclusion group within tx. Returns apperr.ErrExclusionGroupClaimed if they have one;
+// the transaction stays usable.
+func (r *ClaimRepository) InsertExclusionClaim(_ context.Context, q database.TxQuerier, group, userID, couponName string) error {
+	tx, err := txOf(q)
+	if err != nil {
+		return fmt.Errorf("insert exclusion claim: %w", err)
+	}
+	return tx.write(func() error {
+		key := exclusionKey{group, userID}
+		if _, ok := tx.db.exclusions.get(key); ok {
+			return apperr.ErrExclusionGroupClaimed
+		}
+		tx.db.exclusions.set(key, strings.Clone(couponName))
+		return nil
+	})
+}
+
+// ClaimedUsers returns which of userIDs have claimed couponName, reading within tx.
+func (r *ClaimRepository) ClaimedUsers(_ context.Context, q database.TxQuerier, couponName string, userIDs []string) ([]string, error) {
+	tx, err := txOf(q)
+	if err != nil {
+		return nil, fmt.Errorf("get claimed users for coupon %s: %w", couponName, err)
+	}
+	var users []string
+	tx.read(func() { users = r.claimedUsers(r.db.claimed.get, couponName, userIDs) })
+	return users, nil
+}
+
+// GetClaimedUsers returns which of userIDs have claimed couponName, reading outside any
+// transaction.
+func (r *ClaimRepository) GetClaimedUsers(_ context.Context, couponName string, userIDs []string) ([]string, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return r.claimedUsers(r.db.claimed.committed, couponName, userIDs), nil
+}
+
+// claimedUsers returns which of userIDs have claimed couponName, looking claims up with
+// get. Must be called with db.mu held.
+func (r *ClaimRepository) claimedUsers(get func(claimKey) (int, bool), couponName string, userIDs []string) []string {
+	users := []string{}
+	for _, id := range userIDs {
+		if _, ok := get(claimKey{id, couponName}); ok {
+			users = append(users, id)
+		}
+	}
+	return users
+}
+
+// HasClaimed reports whether userID has claimed couponName. It reads outside any
+// transaction.
+func (r *ClaimRepository) HasClaimed(_ context.Context, userID, couponName string) (bool, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	_, ok := r.db.claimed.committed(claimKey{userID, couponName})
+	return ok, nil
+}
+
+// GetClaim returns userID's claim of couponName, or nil if there is none. Like
+// HasClaimed, it reads outside any transaction.
+func (r *ClaimRepository) GetClaim(_ context.Context, userID, couponName string) (*model.Claim, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	i, ok := r.db.claimed.committed(claimKey{userID, couponName})
+	if !ok {
+		return nil, nil
+	}
+	claims, _ := r.db.claims.committed(couponName)
+	claim := claims[i]
+	return &claim, nil
+}
+
+// PseudonymizeUser replaces userID with pseudonym on all of the user's claims within a
+// transaction, leaving claim counts and sequences untouched. Returns the number of claims changed.
+func (r *ClaimRepository) PseudonymizeUser(_ context.Context, q database.TxQuerier, userID, pseudonym string) (int64, error) {
+	tx, err := txOf(q)
+	if err != nil {
+		return 0, fmt.Errorf("pseudonymize claims: %w", err)
+	}
+	var changed int64
+	err = tx.write(func() error {
+		db := tx.db
+		var coupons []string
+		db.claims.all(func(coupon string, _ []model.Claim) {
+			if _, ok := db.claimed.get(claimKey{userID, coupon}); ok {
+				coupons = append(coupons, coupon)
+			}
+		})
+		for _, coupon := range coupons {
+			if _, ok := db.claimed.get(claimKey{pseudonym, coupon}); ok {
+				return fmt.Errorf("pseudonymize claims: %s already claimed %s: %w", pseudonym, coupon, ErrConstraint)
+			}
+		}
+		var groups []string
+		db.exclusions.all(func(key exclusionKey, _ string) {
+			if key.userID == userID {
+				groups = append(groups, key.group)
+			}
+		})
+		for _, group := range groups {
+			if _, ok := db.exclusions.get(exclusionKey{group, pseudonym}); ok {
+				return fmt.Errorf("pseudonymize coupon_exclusion_claims: %w", ErrConstraint)
+			}
+		}
+
+		pseudonym := strings.Clone(pseudonym)
+		for _, coupon := range coupons {
+			// Replaced, not modified: the committed claims may share the slice
+			claims, _ := db.claims.get(coupon)
+			claims = slices.Clone(claims)
+			i, _ := db.claimed.get(claimKey{userID, coupon})
+			claims[i].UserID = pseudonym
+			db.claims.set(coupon, claims)
+			db.claimed.del(claimKey{userID, coupon})
+			db.claimed.set(claimKey{pseudonym, coupon}, i)
+		}
+		for _, group := range groups {
+			coupon, _ := db.exclusions.get(exclusionKey{group, userID})
+			db.exclusions.del(exclusionKey{group, userID})
+			db.exclusions.set(exclusionKey{group, pseudonym}, coupon)
+		}
+		changed = int64(len(coupons))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
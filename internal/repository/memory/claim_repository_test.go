@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// newClaimFixture returns a DB with the coupon PROMO claimed by the given users in order.
+func newClaimFixture(t *testing.T, users ...string) (*DB, *ClaimRepository) {
+	t.Helper()
+	db := NewDB()
+	ctx := context.Background()
+	require.NoError(t, NewCouponRepository(db).Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 100}))
+	claims := NewClaimRepository(db)
+	for i, user := range users {
+		require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+			return claims.Insert(ctx, tx, &model.Claim{UserID: user, CouponName: "PROMO", Sequence: i + 1})
+		}))
+	}
+	return db, claims
+}
+
+func TestClaimRepository_Insert(t *testing.T) {
+	db, claims := newClaimFixture(t, "user_001")
+	ctx := context.Background()
+
+	err := db.InTx(ctx, func(tx database.TxQuerier) error {
+		return claims.Insert(ctx, tx, &model.Claim{UserID: "user_001", CouponName: "PROMO", Sequence: 2})
+	})
+	assert.ErrorIs(t, err, apperr.ErrAlreadyClaimed)
+
+	err = db.InTx(ctx, func(tx database.TxQuerier) error {
+		return claims.Insert(ctx, tx, &model.Claim{UserID: "user_001", CouponName: "GONE", Sequence: 1})
+	})
+	assert.ErrorIs(t, err, ErrConstraint, "claims reference their coupon")
+
+	claim, err := claims.GetClaim(ctx, "user_001", "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 1, claim.Sequence)
+	assert.False(t, claim.CreatedAt.IsZero(), "a zero CreatedAt is stored as the current time")
+	claim, err = claims.GetClaim(ctx, "user_002", "PROMO")
+	require.NoError(t, err)
+	assert.Nil(t, claim)
+}
+
+func TestClaimRepository_Reads(t *testing.T) {
+	_, claims := newClaimFixture(t, "user_001", "user_002", "user_003")
+	ctx := context.Background()
+
+	users, err := claims.GetUsersByCoupon(ctx, "PROMO", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_001", "user_002"}, users)
+
+	list, err := claims.ListByCoupon(ctx, "PROMO", 1, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "user_002", list[0].UserID)
+
+	list, err = claims.ListBySequences(ctx, "PROMO", []int{3, 1, 9})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, []int{1, 3}, []int{list[0].Sequence, list[1].Sequence})
+
+	claimed, err := claims.GetClaimedUsers(ctx, "PROMO", []string{"user_003", "user_009"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_003"}, claimed)
+
+	users, err = claims.GetUsersByCoupon(ctx, "NONE", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, users)
+}
+
+func TestClaimRepository_GetUsersByCoupon_ClaimTimeOrder(t *testing.T) {
+	db, claims := newClaimFixture(t, "user_001")
+	ctx := context.Background()
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		return claims.Insert(ctx, tx, &model.Claim{UserID: "imported", CouponName: "PROMO", Sequence: 2,
+			CreatedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)})
+	}))
+
+	users, err := claims.GetUsersByCoupon(ctx, "PROMO", 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"imported", "user_001"}, users)
+}
+
+func TestClaimRepository_PseudonymizeUser(t *testing.T) {
+	db, claims := newClaimFixture(t, "user_001", "user_002")
+	ctx := context.Background()
+
+	var n int64
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		require.NoError(t, claims.InsertExclusionClaim(ctx, tx, "welcome", "user_001", "PROMO"))
+		var err error
+		n, err = claims.PseudonymizeUser(ctx, tx, "user_001", "erased-1")
+		return err
+	}))
+
+	assert.Equal(t, int64(1), n)
+	claimed, err := claims.HasClaimed(ctx, "user_001", "PROMO")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	claim, err := claims.GetClaim(ctx, "erased-1", "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 1, claim.Sequence)
+	err = db.InTx(ctx, func(tx database.TxQuerier) error {
+		return claims.InsertExclusionClaim(ctx, tx, "welcome", "erased-1", "PROMO")
+	})
+	assert.ErrorIs(t, err, apperr.ErrExclusionGroupClaimed, "the group claim moved to the pseudonym")
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// anonymizedClaimPrefix starts the user ID given to a claim anonymized by ExpireClaims,
+// followed by a number unique within the DB and unrelated to the user. Like a SQL
+// sequence, the numbering is not rolled back.
+const anonymizedClaimPrefix = "anonymized-"
+
+var _ ports.ClaimRetentionRepository = (*ClaimRepository)(nil)
+
+// RetentionPolicies returns the claim retention of every coupon that has one, in
+// coupon name order. Deleted coupons are skipped: their claims go when they are purged.
+func (r *ClaimRepository) RetentionPolicies(context.Context) ([]model.CouponClaimRetention, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	policies := []model.CouponClaimRetention{}
+	r.db.coupons.allCommitted(func(name string, coupon *model.Coupon) {
+		if coupon.ClaimRetention != nil {
+			policies = append(policies, model.CouponClaimRetention{CouponName: name, ClaimRetention: *coupon.ClaimRetention})
+		}
+	})
+	slices.SortFunc(policies, func(a, b model.CouponClaimRetention) int { return strings.Compare(a.CouponName, b.CouponName) })
+	return policies, nil
+}
+
+// ExpireClaims purges or anonymizes up to limit claims of couponName made before
+// cutoff within tx, oldest inserted first, skipping claims already anonymized, and
+// returns how many. Anonymized claims keep their sequence, tier, channel and region;
+// only the user ID is replaced.
+func (r *ClaimRepository) ExpireClaims(_ context.Context, q database.TxQuerier, couponName, action string, cutoff time.Time, limit int) (int, error) {
+	if action != model.RetentionPurge && action != model.RetentionAnonymize {
+		return 0, fmt.Errorf("unknown claim retention action %q", action)
+	}
+	tx, err := txOf(q)
+	if err != nil {
+		return 0, fmt.Errorf("expire claims of coupon %s: %w", couponName, err)
+	}
+	var expired int
+	err = tx.write(func() error {
+		db := tx.db
+		claims, _ := db.claims.get(couponName)
+		users := map[string]bool{} // Of the expired claims
+		next := make([]model.Claim, 0, len(claims))
+		for _, claim := range claims {
+			if len(users) == limit || !claim.CreatedAt.Before(cutoff) ||
+				(action == model.RetentionAnonymize && strings.HasPrefix(claim.UserID, anonymizedClaimPrefix)) {
+				next = append(next, claim)
+				continue
+			}
+			users[claim.UserID] = true
+			if action == model.RetentionAnonymize {
+				db.anonymized++
+				claim.UserID = anonymizedClaimPrefix + strconv.FormatInt(db.anonymized, 10)
+				next = append(next, claim)
+			}
+		}
+		expired = len(users)
+		if expired == 0 {
+			return nil
+		}
+
+		// Expired claims give up their users' exclusion group claims, which would
+		// otherwise keep the user ID an anonymized claim drops
+		db.releaseExclusions(couponName, func(userID string) bool { return users[userID] })
+		db.setClaims(couponName, next)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
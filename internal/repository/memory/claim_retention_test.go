@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestClaimRepository_RetentionPolicies(t *testing.T) {
+	db := NewDB()
+	coupons := NewCouponRepository(db)
+	ctx := context.Background()
+	retention := &model.ClaimRetention{Days: 30, Action: model.RetentionPurge}
+	for _, c := range []*model.Coupon{
+		{Name: "B", Amount: 1, ClaimRetention: retention},
+		{Name: "A", Amount: 1, ClaimRetention: retention},
+		{Name: "KEPT", Amount: 1},
+		{Name: "DELETED", Amount: 1, ClaimRetention: retention},
+	} {
+		require.NoError(t, coupons.Insert(ctx, c))
+	}
+	_, err := coupons.Tombstone(ctx, "DELETED")
+	require.NoError(t, err)
+
+	policies, err := NewClaimRepository(db).RetentionPolicies(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []model.CouponClaimRetention{
+		{CouponName: "A", ClaimRetention: *retention},
+		{CouponName: "B", ClaimRetention: *retention},
+	}, policies)
+}
+
+// newRetentionFixture returns a DB with the coupon PROMO claimed by user_001 and
+// user_002 two days ago and by user_003 now, user_001 also in the exclusion group launch.
+func newRetentionFixture(t *testing.T) (*DB, *ClaimRepository) {
+	t.Helper()
+	db := NewDB()
+	ctx := context.Background()
+	require.NoError(t, NewCouponRepository(db).Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 100}))
+	claims := NewClaimRepository(db)
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		for i, c := range []model.Claim{{UserID: "user_001", CreatedAt: old}, {UserID: "user_002", CreatedAt: old}, {UserID: "user_003"}} {
+			c.CouponName, c.Sequence = "PROMO", i+1
+			if err := claims.Insert(ctx, tx, &c); err != nil {
+				return err
+			}
+		}
+		return claims.InsertExclusionClaim(ctx, tx, "launch", "user_001", "PROMO")
+	}))
+	return db, claims
+}
+
+func TestClaimRepository_ExpireClaims(t *testing.T) {
+	ctx := context.Background()
+	cutoff := time.Now().Add(-24 * time.Hour)
+	expire := func(t *testing.T, db *DB, claims *ClaimRepository, action string, limit int) int {
+		t.Helper()
+		var n int
+		require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+			var err error
+			n, err = claims.ExpireClaims(ctx, tx, "PROMO", action, cutoff, limit)
+			return err
+		}))
+		return n
+	}
+
+	t.Run("purge", func(t *testing.T) {
+		db, claims := newRetentionFixture(t)
+		assert.Equal(t, 1, expire(t, db, claims, model.RetentionPurge, 1))
+		assert.Equal(t, 1, expire(t, db, claims, model.RetentionPurge, 10))
+		assert.Zero(t, expire(t, db, claims, model.RetentionPurge, 10))
+
+		users, err := claims.GetUsersByCoupon(ctx, "PROMO", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user_003"}, users)
+		claim, err := claims.GetClaim(ctx, "user_003", "PROMO")
+		require.NoError(t, err)
+		assert.Equal(t, 3, claim.Sequence, "the remaining claims are reindexed")
+		require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+			return claims.InsertExclusionClaim(ctx, tx, "launch", "user_001", "OTHER")
+		}), "purged claims give up their exclusion group claims")
+	})
+
+	t.Run("anonymize", func(t *testing.T) {
+		db, claims := newRetentionFixture(t)
+		assert.Equal(t, 2, expire(t, db, claims, model.RetentionAnonymize, 10))
+		assert.Zero(t, expire(t, db, claims, model.RetentionAnonymize, 10), "already anonymized")
+
+		users, err := claims.GetUsersByCoupon(ctx, "PROMO", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"anonymized-1", "anonymized-2", "user_003"}, users)
+		claim, err := claims.GetClaim(ctx, "anonymized-2", "PROMO")
+		require.NoError(t, err)
+		assert.Equal(t, 2, claim.Sequence, "anonymized claims keep their sequence")
+		claimed, err := claims.HasClaimed(ctx, "user_001", "PROMO")
+		require.NoError(t, err)
+		assert.False(t, claimed)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		db, claims := newRetentionFixture(t)
+		err := db.InTx(ctx, func(tx database.TxQuerier) error {
+			_, err := claims.ExpireClaims(ctx, tx, "PROMO", model.RetentionAnonymize, cutoff, 10)
+			require.NoError(t, err)
+			return context.Canceled
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		users, err := claims.GetUsersByCoupon(ctx, "PROMO", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user_001", "user_002", "user_003"}, users)
+		err = db.InTx(ctx, func(tx database.TxQuerier) error {
+			return claims.InsertExclusionClaim(ctx, tx, "launch", "user_001", "OTHER")
+		})
+		assert.ErrorIs(t, err, apperr.ErrExclusionGroupClaimed, "the exclusion group claim is back")
+		assert.Equal(t, 2, expire(t, db, claims, model.RetentionAnonymize, 10))
+		users, err = claims.GetUsersByCoupon(ctx, "PROMO", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"anonymized-3", "anonymized-4", "user_003"}, users, "like a sequence, the numbering is not rolled back")
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		db, claims := newRetentionFixture(t)
+		err := db.InTx(ctx, func(tx database.TxQuerier) error {
+			_, err := claims.ExpireClaims(ctx, tx, "PROMO", "archive", cutoff, 10)
+			return err
+		})
+		assert.ErrorContains(t, err, `unknown claim retention action "archive"`)
+	})
+}
@@ -0,0 +1,407 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// CouponRepository provides data access for coupons in memory.
+type CouponRepository struct {
+	db      *DB
+	metrics *database.StatementMetrics
+}
+
+var _ ports.CouponRepository = (*CouponRepository)(nil)
+
+// NewCouponRepository creates a new CouponRepository on db.
+func NewCouponRepository(db *DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+// SetStatementMetrics sets the metrics timing the statements named in database.Statements.
+func (r *CouponRepository) SetStatementMetrics(metrics *database.StatementMetrics) {
+	r.metrics = metrics
+}
+
+// Insert inserts a new coupon with its channel partitions and region quotas.
+// Returns apperr.ErrCouponExists if a coupon with the same name already exists.
+func (r *CouponRepository) Insert(ctx context.Context, coupon *model.Coupon) error {
+	return r.db.InTx(ctx, func(tx database.TxQuerier) error {
+		return r.InsertTx(ctx, tx, coupon)
+	})
+}
+
+// InsertTx is Insert within a caller-managed transaction.
+func (r *CouponRepository) InsertTx(_ context.Context, q database.TxQuerier, coupon *model.Coupon) error {
+	tx, err := txOf(q)
+	if err != nil {
+		return fmt.Errorf("insert coupon: %w", err)
+	}
+	return r.metrics.Run(database.StatementInsertCoupon, func() error {
+		return tx.write(func() error {
+			if tx.db.exists(coupon.Name) {
+				return apperr.ErrCouponExists // Deleted coupons keep their names until purged
+			}
+			if coupon.Amount <= 0 && !coupon.Unlimited {
+				return fmt.Errorf("insert coupon: amount must be positive: %w", ErrConstraint)
+			}
+			tx.db.coupons.set(coupon.Name, newCoupon(coupon))
+			return nil
+		})
+	})
+}
+
+// newCoupon returns the stored form of a coupon being inserted: full stock, no claims
+// and the column defaults of the SQL schemas, with strings of its own.
+func newCoupon(coupon *model.Coupon) *model.Coupon {
+	c := cloneCoupon(coupon)
+	c.Name, c.Parent, c.Prerequisite = strings.Clone(c.Name), strings.Clone(c.Parent), strings.Clone(c.Prerequisite)
+	c.ExclusionGroup, c.Visibility = strings.Clone(c.ExclusionGroup), strings.Clone(c.Visibility)
+	c.Tags = cloneStrings(c.Tags)
+	for i := range c.Channels {
+		c.Channels[i].Channel = strings.Clone(c.Channels[i].Channel)
+	}
+	for i := range c.Tiers {
+		c.Tiers[i].Name = strings.Clone(c.Tiers[i].Name)
+	}
+	for i := range c.Regions {
+		c.Regions[i].Region = strings.Clone(c.Regions[i].Region)
+	}
+	c.RemainingAmount = c.Amount
+	c.CreatedAt = time.Now()
+	c.ClaimSequence = 0
+	c.Disabled = false
+	c.Stats = nil
+	if c.Tags == nil {
+		c.Tags = []string{}
+	}
+	if string(c.Metadata) == "{}" {
+		c.Metadata = nil
+	}
+	if c.Visibility == "" {
+		c.Visibility = model.VisibilityPublic
+	}
+	for i := range c.Channels {
+		c.Channels[i].Remaining = c.Channels[i].Quota
+	}
+	slices.SortFunc(c.Channels, func(a, b model.ChannelQuota) int { return cmp.Compare(a.Channel, b.Channel) })
+	for i := range c.Regions {
+		c.Regions[i].Claimed = 0
+	}
+	slices.SortFunc(c.Regions, func(a, b model.RegionQuota) int { return cmp.Compare(a.Region, b.Region) })
+	return c
+}
+
+// GetByName retrieves a coupon by its name, as last committed.
+// Returns nil, nil if the coupon is not found (service layer handles this).
+func (r *CouponRepository) GetByName(_ context.Context, name string) (*model.Coupon, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	coupon, ok := r.db.coupons.committed(name)
+	if !ok {
+		return nil, nil // Not found - let service handle
+	}
+	return cloneCoupon(coupon), nil
+}
+
+// couponStatusMatchers select the coupons of each status, as the SQL repositories'
+// status conditions do.
+var couponStatusMatchers = map[string]func(c *model.Coupon) bool{
+	model.CouponStatusActive: func(c *model.Coupon) bool {
+		return !c.Disabled && (c.Unlimited || c.RemainingAmount > 0)
+	},
+	model.CouponStatusExhausted: func(c *model.Coupon) bool {
+		return !c.Disabled && !c.Unlimited && c.RemainingAmount <= 0
+	},
+	model.CouponStatusDisabled: func(c *model.Coupon) bool { return c.Disabled },
+}
+
+// List retrieves public coupons ordered by name, optionally filtered by tag and status
+// and starting after the name filter.After.
+// On success, returns an empty slice (not nil) when nothing matches.
+func (r *CouponRepository) List(_ context.Context, filter model.CouponFilter) ([]model.Coupon, error) {
+	matches := func(*model.Coupon) bool { return true }
+	if filter.Status != "" {
+		var ok bool
+		if matches, ok = couponStatusMatchers[filter.Status]; !ok {
+			return nil, fmt.Errorf("list coupons: unknown status %q", filter.Status)
+		}
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	coupons := []model.Coupon{}
+	committed := r.committedCoupons()
+	for _, name := range sortedKeys(committed) {
+		c := committed[name]
+		if c.Visibility != model.VisibilityPublic || name <= filter.After ||
+			(filter.Tag != "" && !slices.Contains(c.Tags, filter.Tag)) || !matches(c) {
+			continue
+		}
+		if len(coupons) == filter.Limit {
+			break
+		}
+		coupons = append(coupons, *cloneCoupon(c))
+	}
+	return coupons, nil
+}
+
+// committedCoupons returns the committed coupons by name. Must be called with db.mu held.
+func (r *CouponRepository) committedCoupons() map[string]*model.Coupon {
+	coupons := map[string]*model.Coupon{}
+	r.db.coupons.allCommitted(func(name string, c *model.Coupon) { coupons[name] = c })
+	return coupons
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// Names returns the names of all coupons.
+// On success, returns an empty slice (not nil) when there are none.
+func (r *CouponRepository) Names(context.Context) ([]string, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	names := sortedKeys(r.committedCoupons())
+	if names == nil {
+		names = []string{}
+	}
+	return names, nil
+}
+
+// UpdateTags replaces the tags of a coupon.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (r *CouponRepository) UpdateTags(ctx context.Context, name string, tags []string) error {
+	return r.db.InTx(ctx, func(tx database.TxQuerier) error {
+		return r.UpdateTagsTx(ctx, tx, name, tags)
+	})
+}
+
+// UpdateTagsTx is UpdateTags within a caller-managed transaction.
+func (r *CouponRepository) UpdateTagsTx(_ context.Context, tx database.TxQuerier, name string, tags []string) error {
+	if tags == nil {
+		tags = []string{}
+	}
+	return r.update(tx, name, "update tags for "+name, func(c *model.Coupon) error {
+		c.Tags = cloneStrings(tags)
+		return nil
+	})
+}
+
+// update modifies coupon name within tx; what describes the write in errors.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (r *CouponRepository) update(q database.TxQuerier, name, what string, fn func(c *model.Coupon) error) error {
+	tx, err := txOf(q)
+	if err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	return tx.write(func() error {
+		err := tx.updateCoupon(name, fn)
+		if err != nil && !errors.Is(err, apperr.ErrCouponNotFound) {
+			err = fmt.Errorf("%s: %w", what, err)
+		}
+		return err
+	})
+}
+
+// ListForUpdate retrieves every coupon, children (coupons with a parent) first and then
+// by name. The transaction already excludes all others. Used by bulk reconciliation
+// (manifest apply).
+func (r *CouponRepository) ListForUpdate(_ context.Context, q database.TxQuerier) ([]model.Coupon, error) {
+	tx, err := txOf(q)
+	if err != nil {
+		return nil, fmt.Errorf("list coupons for update: %w", err)
+	}
+	coupons := []model.Coupon{}
+	tx.read(func() {
+		live := map[string]*model.Coupon{}
+		r.db.coupons.all(func(name string, c *model.Coupon) { live[name] = c })
+		for _, name := range sortedKeys(live) {
+			coupons = append(coupons, *cloneCoupon(live[name]))
+		}
+	})
+	slices.SortStableFunc(coupons, func(a, b model.Coupon) int {
+		return cmp.Compare(boolRank(a.Parent == ""), boolRank(b.Parent == ""))
+	})
+	return coupons, nil
+}
+
+// boolRank orders false before true.
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// TopUp increases a coupon's amount and remaining stock by delta.
+// Must be called within a transaction.
+func (r *CouponRepository) TopUp(_ context.Context, tx database.TxQuerier, name string, delta int) error {
+	return r.update(tx, name, "top up "+name, func(c *model.Coupon) error {
+		c.Amount += delta
+		c.RemainingAmount += delta
+		return checkStock(c)
+	})
+}
+
+// SetDisabled disables or re-enables claims on a coupon.
+// Must be called within a transaction.
+func (r *CouponRepository) SetDisabled(_ context.Context, tx database.TxQuerier, name string, disabled bool) error {
+	return r.update(tx, name, "set disabled for "+name, func(c *model.Coupon) error {
+		c.Disabled = disabled
+		return nil
+	})
+}
+
+// SetHighProfile marks a coupon as high-profile or not.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (r *CouponRepository) SetHighProfile(_ context.Context, tx database.TxQuerier, name string, highProfile bool) error {
+	return r.update(tx, name, "set high profile for "+name, func(c *model.Coupon) error {
+		c.HighProfile = highProfile
+		return nil
+	})
+}
+
+// GetCouponForUpdate returns a coupon within a transaction, which holds it (like every
+// other coupon) until it completes.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (r *CouponRepository) GetCouponForUpdate(_ context.Context, q database.TxQuerier, name string) (*model.Coupon, error) {
+	var coupon *model.Coupon
+	err := r.metrics.Run(database.StatementGetForUpdate, func() error {
+		tx, err := txOf(q)
+		if err != nil {
+			return fmt.Errorf("get coupon for update %s: %w", name, err)
+		}
+		tx.read(func() {
+			if c, ok := r.db.coupons.get(name); ok {
+				coupon = cloneCoupon(c)
+			}
+		})
+		if coupon == nil {
+			return apperr.ErrCouponNotFound
+		}
+		return nil
+	})
+	return coupon, err
+}
+
+// DecrementChannelStock decrements the remaining stock of one channel partition by 1.
+// Must be called within a transaction.
+func (r *CouponRepository) DecrementChannelStock(_ context.Context, tx database.TxQuerier, name, channel string) error {
+	return r.update(tx, name, "decrement channel stock for "+name+"/"+channel, func(c *model.Coupon) error {
+		for i := range c.Channels {
+			if c.Channels[i].Channel == channel {
+				c.Channels[i].Remaining--
+				if c.Channels[i].Remaining < 0 {
+					return fmt.Errorf("channel stock below zero: %w", ErrConstraint)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DecrementStock decrements the remaining_amount of a coupon by 1 and advances its claim_sequence.
+// Must be called within a transaction.
+func (r *CouponRepository) DecrementStock(_ context.Context, tx database.TxQuerier, name string) error {
+	return r.metrics.Run(database.StatementDecrementStock, func() error {
+		return r.update(tx, name, "decrement stock for "+name, func(c *model.Coupon) error {
+			c.RemainingAmount--
+			c.ClaimSequence++
+			return checkStock(c)
+		})
+	})
+}
+
+// AdvanceClaimSequence advances the claim_sequence of an unlimited coupon, which has no
+// stock to decrement. Must be called within a transaction.
+func (r *CouponRepository) AdvanceClaimSequence(_ context.Context, tx database.TxQuerier, name string) error {
+	return r.update(tx, name, "advance claim sequence for "+name, func(c *model.Coupon) error {
+		c.ClaimSequence++
+		return nil
+	})
+}
+
+// DecrementBudget decrements the remaining_amount of a parent coupon by 1 for a claim
+// of one of its children; its claim_sequence only counts its own claims.
+// Must be called within a transaction.
+func (r *CouponRepository) DecrementBudget(_ context.Context, tx database.TxQuerier, name string) error {
+	return r.update(tx, name, "decrement budget for "+name, func(c *model.Coupon) error {
+		c.RemainingAmount--
+		return checkStock(c)
+	})
+}
+
+// checkStock enforces the stock floor of the remaining_amount CHECK constraint.
+func checkStock(c *model.Coupon) error {
+	if c.RemainingAmount < 0 {
+		return fmt.Errorf("stock below zero: %w", ErrConstraint)
+	}
+	return nil
+}
+
+// CountRegionClaim counts a claim of the coupon name from region.
+// Must be called within a transaction.
+func (r *CouponRepository) CountRegionClaim(_ context.Context, tx database.TxQuerier, name, region string) error {
+	return r.update(tx, name, "count region claim for "+name+"/"+region, func(c *model.Coupon) error {
+		i, found := slices.BinarySearchFunc(c.Regions, region, func(q model.RegionQuota, region string) int {
+			return cmp.Compare(q.Region, region)
+		})
+		if !found {
+			c.Regions = slices.Insert(c.Regions, i, model.RegionQuota{Region: strings.Clone(region)})
+		}
+		c.Regions[i].Claimed++
+		return nil
+	})
+}
+
+// RecordClaimStats counts a claim of the coupon name made at at in its stats rollup,
+// bucketed by clock hour like the SQL repositories.
+// Must be called within a transaction.
+func (r *CouponRepository) RecordClaimStats(_ context.Context, tx database.TxQuerier, name string, at time.Time) error {
+	at = at.UTC()
+	hour := at.Truncate(time.Hour)
+	return r.update(tx, name, "record claim stats for "+name, func(c *model.Coupon) error {
+		s := c.Stats
+		if s == nil {
+			c.Stats = &model.CouponStats{TotalClaims: 1, LastClaimAt: &at, HourStart: hour, ClaimsThisHour: 1}
+			return nil
+		}
+		s.TotalClaims++
+		if s.LastClaimAt == nil || at.After(*s.LastClaimAt) {
+			s.LastClaimAt = &at
+		}
+		switch {
+		case hour.Equal(s.HourStart):
+			s.ClaimsThisHour++
+		case hour.Equal(s.HourStart.Add(time.Hour)):
+			s.ClaimsPrevHour, s.ClaimsThisHour, s.HourStart = s.ClaimsThisHour, 1, hour
+		case hour.After(s.HourStart):
+			s.ClaimsPrevHour, s.ClaimsThisHour, s.HourStart = 0, 1, hour
+		case hour.Equal(s.HourStart.Add(-time.Hour)):
+			s.ClaimsPrevHour++
+		}
+		return nil
+	})
+}
+
+// cloneStrings returns a copy of ss sharing no memory with it.
+func cloneStrings(ss []string) []string {
+	clone := make([]string, len(ss))
+	for i, s := range ss {
+		clone[i] = strings.Clone(s)
+	}
+	return clone
+}
@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponRepository_Insert(t *testing.T) {
+	repo := NewCouponRepository(NewDB())
+	ctx := context.Background()
+
+	coupon := &model.Coupon{
+		Name: "PROMO", Amount: 10, RemainingAmount: 3, Metadata: []byte("{}"),
+		Channels: []model.ChannelQuota{{Channel: "web", Quota: 3}, {Channel: "app", Quota: 7}},
+	}
+	require.NoError(t, repo.Insert(ctx, coupon))
+	assert.ErrorIs(t, repo.Insert(ctx, coupon), apperr.ErrCouponExists)
+
+	got, err := repo.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 10, got.RemainingAmount, "remaining_amount = amount")
+	assert.Equal(t, []string{}, got.Tags)
+	assert.Nil(t, got.Metadata)
+	assert.Equal(t, model.VisibilityPublic, got.Visibility)
+	assert.Equal(t, []model.ChannelQuota{{Channel: "app", Quota: 7, Remaining: 7}, {Channel: "web", Quota: 3, Remaining: 3}}, got.Channels)
+
+	got.Channels[0].Remaining = 0
+	again, err := repo.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 7, again.Channels[0].Remaining, "callers get copies")
+
+	assert.ErrorIs(t, repo.Insert(ctx, &model.Coupon{Name: "EMPTY"}), ErrConstraint)
+	require.NoError(t, repo.Insert(ctx, &model.Coupon{Name: "FREE", Unlimited: true}))
+}
+
+func TestCouponRepository_StockFloor(t *testing.T) {
+	db := NewDB()
+	repo := NewCouponRepository(db)
+	ctx := context.Background()
+	require.NoError(t, repo.Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 1,
+		Channels: []model.ChannelQuota{{Channel: "web", Quota: 1}}}))
+
+	err := db.InTx(ctx, func(tx database.TxQuerier) error {
+		require.NoError(t, repo.DecrementStock(ctx, tx, "PROMO"))
+		require.NoError(t, repo.DecrementChannelStock(ctx, tx, "PROMO", "web"))
+		assert.ErrorIs(t, repo.DecrementChannelStock(ctx, tx, "PROMO", "web"), ErrConstraint)
+		return repo.DecrementStock(ctx, tx, "PROMO")
+	})
+
+	assert.ErrorIs(t, err, ErrConstraint)
+	got, err := repo.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.RemainingAmount, "the transaction rolled back")
+	assert.Equal(t, 1, got.Channels[0].Remaining)
+}
+
+func TestCouponRepository_List(t *testing.T) {
+	db := NewDB()
+	repo := NewCouponRepository(db)
+	ctx := context.Background()
+	for _, c := range []*model.Coupon{
+		{Name: "C", Amount: 1, Tags: []string{"summer"}},
+		{Name: "A", Amount: 1, Tags: []string{"summer"}},
+		{Name: "B", Amount: 1, Tags: []string{"winter"}},
+		{Name: "D", Amount: 1, Tags: []string{"summer"}, Visibility: model.VisibilityUnlisted},
+		{Name: "E", Amount: 1, Tags: []string{"summer"}},
+	} {
+		require.NoError(t, repo.Insert(ctx, c))
+	}
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		return repo.SetDisabled(ctx, tx, "E", true)
+	}))
+
+	names := func(filter model.CouponFilter) []string {
+		coupons, err := repo.List(ctx, filter)
+		require.NoError(t, err)
+		names := []string{}
+		for _, c := range coupons {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"A", "B"}, names(model.CouponFilter{Limit: 2}))
+	assert.Equal(t, []string{"C", "E"}, names(model.CouponFilter{Tag: "summer", After: "A", Limit: 10}))
+	assert.Equal(t, []string{"A", "C"}, names(model.CouponFilter{Tag: "summer", Status: model.CouponStatusActive, Limit: 10}))
+	assert.Equal(t, []string{"E"}, names(model.CouponFilter{Status: model.CouponStatusDisabled, Limit: 10}))
+
+	_, err := repo.List(ctx, model.CouponFilter{Status: "gone", Limit: 10})
+	assert.ErrorContains(t, err, `unknown status "gone"`)
+}
+
+func TestCouponRepository_ListForUpdate_ChildrenFirst(t *testing.T) {
+	db := NewDB()
+	repo := NewCouponRepository(db)
+	ctx := context.Background()
+	for _, c := range []*model.Coupon{
+		{Name: "A", Amount: 1}, {Name: "Z", Amount: 1, Parent: "A"}, {Name: "B", Amount: 1}, {Name: "Y", Amount: 1, Parent: "A"},
+	} {
+		require.NoError(t, repo.Insert(ctx, c))
+	}
+
+	var names []string
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		coupons, err := repo.ListForUpdate(ctx, tx)
+		for _, c := range coupons {
+			names = append(names, c.Name)
+		}
+		return err
+	}))
+
+	assert.Equal(t, []string{"Y", "Z", "A", "B"}, names)
+}
+
+func TestCouponRepository_UpdatesOfMissingCoupon(t *testing.T) {
+	db := NewDB()
+	repo := NewCouponRepository(db)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, repo.UpdateTags(ctx, "GONE", []string{"x"}), apperr.ErrCouponNotFound)
+	err := db.InTx(ctx, func(tx database.TxQuerier) error {
+		_, err := repo.GetCouponForUpdate(ctx, tx, "GONE")
+		return err
+	})
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+}
+
+func TestCouponRepository_RegionsAndStats(t *testing.T) {
+	db := NewDB()
+	repo := NewCouponRepository(db)
+	ctx := context.Background()
+	require.NoError(t, repo.Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 10,
+		Regions: []model.RegionQuota{{Region: "SG", Quota: 5}}}))
+	hour := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		for _, claim := range []struct {
+			region string
+			at     time.Time
+		}{
+			{"SG", hour.Add(5 * time.Minute)},
+			{"ID", hour.Add(10 * time.Minute)},
+			{"ID", hour.Add(70 * time.Minute)},
+			{"SG", hour.Add(20 * time.Minute)}, // Late: counts towards the previous hour
+		} {
+			require.NoError(t, repo.CountRegionClaim(ctx, tx, "PROMO", claim.region))
+			require.NoError(t, repo.RecordClaimStats(ctx, tx, "PROMO", claim.at))
+		}
+		return nil
+	}))
+
+	got, err := repo.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, []model.RegionQuota{{Region: "ID", Claimed: 2}, {Region: "SG", Quota: 5, Claimed: 2}}, got.Regions)
+	last := hour.Add(70 * time.Minute)
+	assert.Equal(t, &model.CouponStats{
+		TotalClaims: 4, LastClaimAt: &last, HourStart: hour.Add(time.Hour), ClaimsThisHour: 1, ClaimsPrevHour: 3,
+	}, got.Stats)
+}
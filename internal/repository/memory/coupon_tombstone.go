@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+var _ ports.CouponTombstoneRepository = (*CouponRepository)(nil)
+
+// Tombstone marks the coupon name deleted and returns when. The coupon is hidden from
+// the other methods of CouponRepository until it is restored or purged.
+// Returns apperr.ErrCouponNotFound if it does not exist or is already deleted.
+func (r *CouponRepository) Tombstone(ctx context.Context, name string) (time.Time, error) {
+	var deletedAt time.Time
+	err := r.db.InTx(ctx, func(q database.TxQuerier) error {
+		tx, err := txOf(q)
+		if err != nil {
+			return fmt.Errorf("delete coupon %s: %w", name, err)
+		}
+		return tx.write(func() error {
+			db := tx.db
+			coupon, ok := db.coupons.get(name)
+			if !ok {
+				return apperr.ErrCouponNotFound
+			}
+			deletedAt = time.Now()
+			db.coupons.del(name)
+			db.deleted.set(name, tombstone{coupon: coupon, deletedAt: deletedAt})
+			return nil
+		})
+	})
+	return deletedAt, err
+}
+
+// Restore clears the deletion of the coupon name if it was deleted within window.
+// Returns apperr.ErrDeletedCouponNotFound otherwise.
+func (r *CouponRepository) Restore(ctx context.Context, name string, window time.Duration) error {
+	return r.db.InTx(ctx, func(q database.TxQuerier) error {
+		tx, err := txOf(q)
+		if err != nil {
+			return fmt.Errorf("restore coupon %s: %w", name, err)
+		}
+		return tx.write(func() error {
+			db := tx.db
+			deleted, ok := db.deleted.get(name)
+			if !ok || !deleted.deletedAt.After(time.Now().Add(-window)) {
+				return apperr.ErrDeletedCouponNotFound
+			}
+			db.deleted.del(name)
+			db.coupons.set(name, deleted.coupon)
+			return nil
+		})
+	})
+}
+
+// Expired returns up to limit coupons deleted longer than window ago, oldest first.
+func (r *CouponRepository) Expired(_ context.Context, window time.Duration, limit int) ([]string, error) {
+	cutoff := time.Now().Add(-window)
+	r.db.mu.RLock()
+	expired := []tombstone{}
+	r.db.deleted.allCommitted(func(_ string, deleted tombstone) {
+		if !deleted.deletedAt.After(cutoff) {
+			expired = append(expired, deleted)
+		}
+	})
+	r.db.mu.RUnlock()
+
+	slices.SortFunc(expired, func(a, b tombstone) int {
+		return cmp.Or(a.deletedAt.Compare(b.deletedAt), cmp.Compare(a.coupon.Name, b.coupon.Name))
+	})
+	names := []string{}
+	for _, deleted := range expired[:min(limit, len(expired))] {
+		names = append(names, deleted.coupon.Name)
+	}
+	return names, nil
+}
+
+// Purge removes the coupon name, its claims and the other data referencing it within
+// tx if it was deleted longer than window ago, and reports whether it did.
+func (r *CouponRepository) Purge(_ context.Context, q database.TxQuerier, name string, window time.Duration) (bool, error) {
+	tx, err := txOf(q)
+	if err != nil {
+		return false, fmt.Errorf("purge coupon %s: %w", name, err)
+	}
+	var purged bool
+	err = tx.write(func() error {
+		db := tx.db
+		deleted, ok := db.deleted.get(name)
+		if !ok || deleted.deletedAt.After(time.Now().Add(-window)) {
+			return nil // Restored, or already purged
+		}
+		purged = true
+		db.deleted.del(name)
+		db.allowlists.del(name)
+		db.setClaims(name, nil)
+		db.releaseExclusions(name, func(string) bool { return true })
+		return nil
+	})
+	return purged, err
+}
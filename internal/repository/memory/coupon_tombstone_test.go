@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponRepository_TombstoneRestore(t *testing.T) {
+	db, _ := newClaimFixture(t, "user_001")
+	repo := NewCouponRepository(db)
+	ctx := context.Background()
+
+	deletedAt, err := repo.Tombstone(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), deletedAt, time.Second)
+	_, err = repo.Tombstone(ctx, "PROMO")
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound, "already deleted")
+	_, err = repo.Tombstone(ctx, "GONE")
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+
+	got, err := repo.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Nil(t, got, "deleted coupons are hidden")
+	assert.ErrorIs(t, repo.Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 1}), apperr.ErrCouponExists,
+		"the name stays taken")
+
+	assert.ErrorIs(t, repo.Restore(ctx, "PROMO", 0), apperr.ErrDeletedCouponNotFound, "outside the window")
+	require.NoError(t, repo.Restore(ctx, "PROMO", time.Hour))
+	got, err = repo.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 100, got.Amount)
+	assert.ErrorIs(t, repo.Restore(ctx, "PROMO", time.Hour), apperr.ErrDeletedCouponNotFound, "not deleted")
+}
+
+func TestCouponRepository_Purge(t *testing.T) {
+	db, claims := newClaimFixture(t, "user_001", "user_002")
+	repo := NewCouponRepository(db)
+	allowlists := NewAllowlistRepository(db)
+	ctx := context.Background()
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		if err := claims.InsertExclusionClaim(ctx, tx, "launch", "user_001", "PROMO"); err != nil {
+			return err
+		}
+		return allowlists.Replace(ctx, tx, "PROMO", []model.AllowlistEntry{{UserID: "user_001"}})
+	}))
+	_, err := repo.Tombstone(ctx, "PROMO")
+	require.NoError(t, err)
+
+	expired, err := repo.Expired(ctx, time.Hour, 10)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	expired, err = repo.Expired(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PROMO"}, expired)
+
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		purged, err := repo.Purge(ctx, tx, "PROMO", time.Hour)
+		assert.False(t, purged, "deleted within the window")
+		return err
+	}))
+	failed := errors.New("failed")
+	err = db.InTx(ctx, func(tx database.TxQuerier) error {
+		purged, err := repo.Purge(ctx, tx, "PROMO", 0)
+		require.NoError(t, err)
+		assert.True(t, purged)
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	n, err := claims.CountByCoupon(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "the failed purge rolled back")
+
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		_, err := repo.Purge(ctx, tx, "PROMO", 0)
+		return err
+	}))
+	n, err = claims.CountByCoupon(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	entries, err := allowlists.List(ctx, "PROMO", "", 100)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	expired, err = repo.Expired(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	require.NoError(t, repo.Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 1}), "the name is free again")
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		if err := claims.Insert(ctx, tx, &model.Claim{UserID: "user_001", CouponName: "PROMO", Sequence: 1}); err != nil {
+			return err
+		}
+		return claims.InsertExclusionClaim(ctx, tx, "launch", "user_001", "PROMO")
+	}), "the purged claims are gone")
+}
@@ -0,0 +1,376 @@
+// Package memory implements the repository interfaces in internal/ports in process
+// memory, for demos and tests selecting DB_DRIVER=memory: `go run ./cmd/api` then needs
+// no database. Nothing is persisted; the data is lost when the process exits.
+//
+// The repositories share a DB, which keeps the invariants the SQL schemas enforce:
+// one claim per user and coupon, one claim per user and exclusion group, and stock
+// that never drops below zero. Transactions run one at a time, so a claim's coupon
+// "row lock" is the transaction itself. Their writes are visible to reads outside
+// them only once they commit, and are rolled back when they fail: the running
+// transaction keeps the committed version of every row it changes until then.
+//
+// Stored strings never share memory with the caller's: handlers may pass strings
+// backed by request buffers that are reused for later requests.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// ErrConstraint is returned by writes that would break an invariant the SQL schemas
+// enforce with a CHECK or foreign key constraint, e.g. stock below zero.
+var ErrConstraint = errors.New("memory: constraint violated")
+
+// errNoSQL is returned when a memory transaction is used to run SQL.
+var errNoSQL = errors.New("memory: transactions do not run SQL")
+
+// claimKey identifies a user's claim of a coupon.
+type claimKey struct {
+	userID, coupon string
+}
+
+func (k claimKey) clone() claimKey {
+	return claimKey{strings.Clone(k.userID), strings.Clone(k.coupon)}
+}
+
+// exclusionKey identifies a user's claim within an exclusion group.
+type exclusionKey struct {
+	group, userID string
+}
+
+func (k exclusionKey) clone() exclusionKey {
+	return exclusionKey{strings.Clone(k.group), strings.Clone(k.userID)}
+}
+
+// usageKey identifies an API key's claim count for a month.
+type usageKey struct {
+	keyID, month string
+}
+
+// tombstone is a deleted coupon, kept out of DB.coupons until it is purged.
+type tombstone struct {
+	coupon    *model.Coupon
+	deletedAt time.Time
+}
+
+// version is a row as it was committed; ok is false if it did not exist.
+type version[V any] struct {
+	row V
+	ok  bool
+}
+
+// table is a map of rows written by transactions. The running transaction changes
+// rows in place and keeps the committed version of each in before, which reads
+// outside transactions see instead and a rollback restores. Rows are replaced, never
+// modified in place, so a kept version shares nothing the transaction changes
+// (appending to a slice row writes past the kept version's end).
+// Must be accessed with DB.mu held.
+type table[K comparable, V any] struct {
+	rows     map[K]V
+	before   map[K]version[V] // Of the rows the running transaction changed
+	cloneKey func(K) K        // Copies a key before it is stored
+}
+
+func newTable[K comparable, V any](cloneKey func(K) K) *table[K, V] {
+	return &table[K, V]{rows: map[K]V{}, before: map[K]version[V]{}, cloneKey: cloneKey}
+}
+
+// get returns row k as the running transaction sees it.
+func (t *table[K, V]) get(k K) (V, bool) {
+	row, ok := t.rows[k]
+	return row, ok
+}
+
+// committed returns row k as last committed.
+func (t *table[K, V]) committed(k K) (V, bool) {
+	if v, ok := t.before[k]; ok {
+		return v.row, v.ok
+	}
+	return t.get(k)
+}
+
+// set stores row k. Must be called by the running transaction.
+func (t *table[K, V]) set(k K, row V) {
+	t.keep(k)
+	t.rows[t.cloneKey(k)] = row // Assigning replaces the stored key too
+}
+
+// del removes row k. Must be called by the running transaction.
+func (t *table[K, V]) del(k K) {
+	t.keep(k)
+	delete(t.rows, k)
+}
+
+// keep records the committed version of row k before the running transaction first
+// changes it.
+func (t *table[K, V]) keep(k K) {
+	if _, ok := t.before[k]; ok {
+		return
+	}
+	row, ok := t.rows[k]
+	t.before[t.cloneKey(k)] = version[V]{row, ok}
+}
+
+// all calls fn with every row as the running transaction sees it.
+func (t *table[K, V]) all(fn func(k K, row V)) {
+	for k, row := range t.rows {
+		fn(k, row)
+	}
+}
+
+// allCommitted calls fn with every row as last committed.
+func (t *table[K, V]) allCommitted(fn func(k K, row V)) {
+	for k, row := range t.rows {
+		if _, changed := t.before[k]; !changed {
+			fn(k, row)
+		}
+	}
+	for k, v := range t.before {
+		if v.ok {
+			fn(k, v.row)
+		}
+	}
+}
+
+// end ends the running transaction, keeping its changes if commit is set and
+// restoring the committed rows otherwise.
+func (t *table[K, V]) end(commit bool) {
+	if !commit {
+		for k, v := range t.before {
+			if v.ok {
+				t.rows[k] = v.row
+			} else {
+				delete(t.rows, k)
+			}
+		}
+	}
+	clear(t.before)
+}
+
+// DB holds the data of the memory repositories. Stored coupons are never modified:
+// writes replace them with modified copies, so readers may keep what they read once
+// the lock is released.
+type DB struct {
+	sem chan struct{} // Held by the running transaction
+
+	mu         sync.RWMutex
+	coupons    *table[string, *model.Coupon]
+	deleted    *table[string, tombstone]              // Deleted coupons, hidden from coupons
+	claims     *table[string, []model.Claim]          // By coupon, in claim order
+	claimed    *table[claimKey, int]                  // Index of each claim in claims
+	exclusions *table[exclusionKey, string]           // Coupon claimed in each group
+	allowlists *table[string, []model.AllowlistEntry] // By coupon, in user ID order
+	caps       *table[string, model.CampaignCap]      // By campaign
+	usage      map[usageKey]int64                     // Not written by transactions
+	anonymized int64                                  // Claims anonymized so far, numbering their user IDs
+	audit      []model.AuditEntry
+	audited    int // Committed entries of audit
+	tables     []interface{ end(commit bool) }
+}
+
+// NewDB creates an empty DB.
+func NewDB() *DB {
+	db := &DB{
+		sem:        make(chan struct{}, 1),
+		coupons:    newTable[string, *model.Coupon](strings.Clone),
+		deleted:    newTable[string, tombstone](strings.Clone),
+		claims:     newTable[string, []model.Claim](strings.Clone),
+		claimed:    newTable[claimKey, int](claimKey.clone),
+		exclusions: newTable[exclusionKey, string](exclusionKey.clone),
+		allowlists: newTable[string, []model.AllowlistEntry](strings.Clone),
+		caps:       newTable[string, model.CampaignCap](strings.Clone),
+		usage:      map[usageKey]int64{},
+	}
+	db.tables = []interface{ end(commit bool) }{db.coupons, db.deleted, db.claims, db.claimed, db.exclusions, db.allowlists, db.caps}
+	return db
+}
+
+// exists reports whether the coupon name exists, deleted or not, as the rows
+// referencing coupons require. Must be called with db.mu held.
+func (db *DB) exists(name string) bool {
+	_, live := db.coupons.get(name)
+	_, deleted := db.deleted.get(name)
+	return live || deleted
+}
+
+// InTx runs fn in a transaction and commits its writes if fn returns nil; otherwise they
+// are rolled back and fn's error returned. They are also rolled back if fn panics, and
+// if ctx is done by the time fn returns. Transactions wait for the running one to
+// finish, or for ctx to be done. InTx satisfies ports.Transactor.
+func (db *DB) InTx(ctx context.Context, fn func(tx database.TxQuerier) error) error {
+	select {
+	case db.sem <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("begin tx: %w", ctx.Err())
+	}
+	defer func() { <-db.sem }()
+
+	tx := &Tx{db: db}
+	defer tx.end(false) // No-op if committed; also runs on panic
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	tx.end(true)
+	return nil
+}
+
+// Tx is a memory transaction. It satisfies database.TxQuerier so the service layer can
+// pass it to the repositories, but it runs no SQL: its methods fail.
+type Tx struct {
+	db   *DB
+	done bool
+}
+
+var _ database.TxQuerier = (*Tx)(nil)
+
+func (t *Tx) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errNoSQL
+}
+
+func (t *Tx) QueryRow(context.Context, string, ...any) pgx.Row {
+	return errRow{}
+}
+
+func (t *Tx) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errNoSQL
+}
+
+// errRow is the row of a query a memory transaction cannot run.
+type errRow struct{}
+
+func (errRow) Scan(...any) error { return errNoSQL }
+
+// end commits or rolls back the writes of t, unless it already ended.
+func (t *Tx) end(commit bool) {
+	if t.done {
+		return
+	}
+	t.done = true
+	db := t.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, table := range db.tables {
+		table.end(commit)
+	}
+	if commit {
+		db.audited = len(db.audit)
+	} else {
+		db.audit = db.audit[:db.audited]
+	}
+}
+
+// txOf returns the memory transaction q, which the repositories' callers got from InTx.
+func txOf(q database.TxQuerier) (*Tx, error) {
+	tx, ok := q.(*Tx)
+	if !ok || tx.done {
+		return nil, fmt.Errorf("memory: %T is not a running memory transaction", q)
+	}
+	return tx, nil
+}
+
+// write runs fn with the write lock held. fn must leave the data unchanged when it
+// fails, so that the transaction stays usable like one whose statement failed on a
+// constraint the caller expects.
+func (t *Tx) write(fn func() error) error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+	return fn()
+}
+
+// read runs fn with the read lock held, for reads within t.
+func (t *Tx) read(fn func()) {
+	t.db.mu.RLock()
+	defer t.db.mu.RUnlock()
+	fn()
+}
+
+// updateCoupon replaces coupon name with a copy modified by fn; if fn fails, the
+// coupon is left unchanged. Must be called within write.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (t *Tx) updateCoupon(name string, fn func(c *model.Coupon) error) error {
+	prev, ok := t.db.coupons.get(name)
+	if !ok {
+		return apperr.ErrCouponNotFound
+	}
+	next := cloneCoupon(prev)
+	if err := fn(next); err != nil {
+		return err
+	}
+	t.db.coupons.set(name, next)
+	return nil
+}
+
+// cloneCoupon returns a copy of c sharing none of its slices or pointers.
+func cloneCoupon(c *model.Coupon) *model.Coupon {
+	clone := *c
+	clone.Tags = slices.Clone(c.Tags)
+	clone.Channels = slices.Clone(c.Channels)
+	clone.Tiers = slices.Clone(c.Tiers)
+	clone.Metadata = slices.Clone(c.Metadata)
+	clone.Regions = slices.Clone(c.Regions)
+	if c.OverflowAt != nil {
+		at := *c.OverflowAt
+		clone.OverflowAt = &at
+	}
+	if c.Stats != nil {
+		stats := *c.Stats
+		if c.Stats.LastClaimAt != nil {
+			at := *c.Stats.LastClaimAt
+			stats.LastClaimAt = &at
+		}
+		clone.Stats = &stats
+	}
+	if c.ClaimRetention != nil {
+		retention := *c.ClaimRetention
+		clone.ClaimRetention = &retention
+	}
+	return &clone
+}
+
+// setClaims replaces the claims of coupon with claims, reindexing them. Must be
+// called within write.
+func (db *DB) setClaims(coupon string, claims []model.Claim) {
+	prev, _ := db.claims.get(coupon)
+	for _, claim := range prev {
+		db.claimed.del(claimKey{claim.UserID, coupon})
+	}
+	for i, claim := range claims {
+		db.claimed.set(claimKey{claim.UserID, coupon}, i)
+	}
+	if len(claims) == 0 {
+		db.claims.del(coupon)
+	} else {
+		db.claims.set(coupon, claims)
+	}
+}
+
+// releaseExclusions removes the exclusion group claims made with coupon by the users
+// release reports. Must be called within write.
+func (db *DB) releaseExclusions(coupon string, release func(userID string) bool) {
+	var released []exclusionKey
+	db.exclusions.all(func(key exclusionKey, claimed string) {
+		if claimed == coupon && release(key.userID) {
+			released = append(released, key)
+		}
+	})
+	for _, key := range released {
+		db.exclusions.del(key)
+	}
+}
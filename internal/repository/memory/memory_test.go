@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestDB_InTx_RollsBackOnError(t *testing.T) {
+	db := NewDB()
+	coupons, claims := NewCouponRepository(db), NewClaimRepository(db)
+	ctx := context.Background()
+	require.NoError(t, coupons.Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 5}))
+
+	failed := errors.New("failed")
+	err := db.InTx(ctx, func(tx database.TxQuerier) error {
+		require.NoError(t, claims.Insert(ctx, tx, &model.Claim{UserID: "user_001", CouponName: "PROMO", Sequence: 1}))
+		require.NoError(t, coupons.DecrementStock(ctx, tx, "PROMO"))
+		require.NoError(t, claims.InsertExclusionClaim(ctx, tx, "welcome", "user_001", "PROMO"))
+		return failed
+	})
+
+	require.ErrorIs(t, err, failed)
+	coupon, err := coupons.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 5, coupon.RemainingAmount)
+	assert.Zero(t, coupon.ClaimSequence)
+	claimed, err := claims.HasClaimed(ctx, "user_001", "PROMO")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		return claims.InsertExclusionClaim(ctx, tx, "welcome", "user_001", "PROMO")
+	}), "the group claim was rolled back")
+}
+
+func TestDB_InTx_RollsBackOnPanic(t *testing.T) {
+	db := NewDB()
+	coupons := NewCouponRepository(db)
+	ctx := context.Background()
+
+	assert.Panics(t, func() {
+		_ = db.InTx(ctx, func(tx database.TxQuerier) error {
+			require.NoError(t, coupons.InsertTx(ctx, tx, &model.Coupon{Name: "PROMO", Amount: 5}))
+			panic("boom")
+		})
+	})
+
+	coupon, err := coupons.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Nil(t, coupon)
+	require.NoError(t, coupons.Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 5}), "the next transaction can begin")
+}
+
+func TestDB_InTx_HidesUncommittedWrites(t *testing.T) {
+	db := NewDB()
+	coupons, claims := NewCouponRepository(db), NewClaimRepository(db)
+	ctx := context.Background()
+	require.NoError(t, coupons.Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 5}))
+
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error {
+		require.NoError(t, claims.Insert(ctx, tx, &model.Claim{UserID: "user_001", CouponName: "PROMO", Sequence: 1}))
+		require.NoError(t, coupons.DecrementStock(ctx, tx, "PROMO"))
+		require.NoError(t, coupons.InsertTx(ctx, tx, &model.Coupon{Name: "NEW", Amount: 1}))
+
+		own, err := coupons.GetCouponForUpdate(ctx, tx, "PROMO")
+		require.NoError(t, err)
+		assert.Equal(t, 4, own.RemainingAmount, "the transaction sees its writes")
+
+		// Reads outside the transaction see what was last committed.
+		coupon, err := coupons.GetByName(ctx, "PROMO")
+		require.NoError(t, err)
+		assert.Equal(t, 5, coupon.RemainingAmount)
+		claimed, err := claims.HasClaimed(ctx, "user_001", "PROMO")
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		added, err := coupons.GetByName(ctx, "NEW")
+		require.NoError(t, err)
+		assert.Nil(t, added)
+		return nil
+	}))
+
+	coupon, err := coupons.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 4, coupon.RemainingAmount)
+	claimed, err := claims.HasClaimed(ctx, "user_001", "PROMO")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	added, err := coupons.GetByName(ctx, "NEW")
+	require.NoError(t, err)
+	assert.NotNil(t, added)
+}
+
+func TestDB_InTx_WaitsForRunningTransaction(t *testing.T) {
+	db := NewDB()
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = db.InTx(context.Background(), func(database.TxQuerier) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := db.InTx(ctx, func(database.TxQuerier) error { return nil })
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRepositories_RejectOtherTransactions(t *testing.T) {
+	db := NewDB()
+	ctx := context.Background()
+
+	err := NewCouponRepository(db).DecrementStock(ctx, nil, "PROMO")
+	assert.ErrorContains(t, err, "not a running memory transaction")
+
+	var leaked database.TxQuerier
+	require.NoError(t, db.InTx(ctx, func(tx database.TxQuerier) error { leaked = tx; return nil }))
+	err = NewClaimRepository(db).Insert(ctx, leaked, &model.Claim{UserID: "user_001", CouponName: "PROMO"})
+	assert.ErrorContains(t, err, "not a running memory transaction", "a committed transaction cannot be reused")
+
+	_, err = leaked.Exec(ctx, "SELECT 1")
+	assert.ErrorIs(t, err, errNoSQL)
+}
+
+// TestConcurrentClaims claims a coupon as the service does from more goroutines than it
+// has stock: each user gets at most one claim and the stock ends at zero.
+func TestConcurrentClaims(t *testing.T) {
+	db := NewDB()
+	coupons, claims := NewCouponRepository(db), NewClaimRepository(db)
+	ctx := context.Background()
+	require.NoError(t, coupons.Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 10}))
+
+	claim := func(userID string) error {
+		return db.InTx(ctx, func(tx database.TxQuerier) error {
+			coupon, err := coupons.GetCouponForUpdate(ctx, tx, "PROMO")
+			if err != nil {
+				return err
+			}
+			if coupon.RemainingAmount <= 0 {
+				return apperr.ErrNoStock
+			}
+			err = claims.Insert(ctx, tx, &model.Claim{UserID: userID, CouponName: "PROMO", Sequence: coupon.ClaimSequence + 1})
+			if err != nil {
+				return err
+			}
+			return coupons.DecrementStock(ctx, tx, "PROMO")
+		})
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	granted := 0
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if claim([]string{"user_a", "user_b", "user_c", "user_d", "user_e",
+				"user_f", "user_g", "user_h", "user_i", "user_j", "user_k", "user_l"}[i%12]) == nil {
+				mu.Lock()
+				granted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	coupon, err := coupons.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 10, granted)
+	assert.Equal(t, 0, coupon.RemainingAmount)
+	assert.Equal(t, 10, coupon.ClaimSequence)
+	count, err := claims.CountByCoupon(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 10, count)
+}
@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// AuditRepository provides data access for the audit log on MySQL.
+// Entries are always written inside the transaction of the operation they record.
+type AuditRepository struct {
+	pool database.TxQuerier
+}
+
+var _ ports.AuditRepository = (*AuditRepository)(nil)
+
+// NewAuditRepository creates a new AuditRepository.
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return NewAuditRepositoryWithPool(database.SQLQuerier(db))
+}
+
+// NewAuditRepositoryWithPool creates a new AuditRepository with a custom pool interface.
+// This is primarily used for testing.
+func NewAuditRepositoryWithPool(pool database.TxQuerier) *AuditRepository {
+	return &AuditRepository{pool: pool}
+}
+
+// Insert appends an entry to the audit log within a transaction.
+// Nil details are stored as an empty object.
+func (r *AuditRepository) Insert(ctx context.Context, tx database.TxQuerier, entry *model.AuditEntry) error {
+	details := entry.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("encode audit details: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO audit_log (action, subject, details) VALUES (?, ?, ?)`,
+		entry.Action, entry.Subject, string(encoded))
+	if err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListBySubject returns up to limit entries about subject with one of actions, newest
+// first, starting after the entry at after (nil for the newest).
+func (r *AuditRepository) ListBySubject(ctx context.Context, subject string, actions []string, after *model.AuditPosition, limit int) ([]model.AuditEntry, error) {
+	entries := []model.AuditEntry{}
+	if len(actions) == 0 {
+		return entries, nil // IN () is a syntax error
+	}
+	args := make([]any, 0, len(actions)+4)
+	args = append(args, subject)
+	for _, action := range actions {
+		args = append(args, action)
+	}
+	query := `SELECT id, action, subject, details, created_at FROM audit_log
+		WHERE subject = ? AND action IN (?` + strings.Repeat(", ?", len(actions)-1) + `)`
+	if after != nil {
+		query += ` AND (created_at, id) < (?, ?)`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries of %s: %w", subject, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e model.AuditEntry
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.Action, &e.Subject, &details, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		if err := json.Unmarshal(details, &e.Details); err != nil {
+			return nil, fmt.Errorf("decode audit details: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list audit entries of %s: %w", subject, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestAuditRepository_Insert_EncodesDetails(t *testing.T) {
+	var args []any
+	q := &mockQuerier{execFn: func(_ string, a ...any) (pgconn.CommandTag, error) {
+		args = a
+		return pgconn.NewCommandTag("EXEC 1"), nil
+	}}
+
+	err := NewAuditRepositoryWithPool(nil).Insert(context.Background(), q, &model.AuditEntry{Action: "erase", Subject: "erased-1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []any{"erase", "erased-1", "{}"}, args)
+}
+
+func TestAuditRepository_ListBySubject_Query(t *testing.T) {
+	q := &mockQuerier{}
+
+	_, err := NewAuditRepositoryWithPool(q).ListBySubject(context.Background(), "PROMO", model.CouponChangelogActions, &model.AuditPosition{ID: 7}, 50)
+
+	require.Error(t, err, "the mock does not implement Query")
+	require.Len(t, q.statements, 1)
+	assert.Contains(t, q.statements[0], "action IN (?, ?)")
+	assert.Contains(t, q.statements[0], "(created_at, id) < (?, ?)")
+	assert.Contains(t, q.statements[0], "ORDER BY created_at DESC, id DESC")
+}
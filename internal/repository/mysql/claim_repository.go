@@ -0,0 +1,278 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// ClaimRepository provides data access for claims on MySQL.
+type ClaimRepository struct {
+	pool      database.TxQuerier
+	claimedAt database.Rename // claims.created_at, being renamed to claimed_at
+	metrics   *database.StatementMetrics
+}
+
+var (
+	_ ports.ClaimRepository     = (*ClaimRepository)(nil)
+	_ ports.UserClaimRepository = (*ClaimRepository)(nil)
+)
+
+// NewClaimRepository creates a new ClaimRepository with the given pool.
+func NewClaimRepository(db *sql.DB) *ClaimRepository {
+	return NewClaimRepositoryWithPool(database.SQLQuerier(db))
+}
+
+// NewClaimRepositoryWithPool creates a new ClaimRepository with a custom pool interface.
+// This is primarily used for testing.
+func NewClaimRepositoryWithPool(pool database.TxQuerier) *ClaimRepository {
+	return &ClaimRepository{pool: pool, claimedAt: database.ClaimsClaimedAt}
+}
+
+// SetStatementMetrics sets the metrics timing the statements named in database.Statements.
+func (r *ClaimRepository) SetStatementMetrics(metrics *database.StatementMetrics) {
+	r.metrics = metrics
+}
+
+// SetRenames sets the migration phase of the column renames on the claims table
+// (see database.Rename); other renames are ignored.
+func (r *ClaimRepository) SetRenames(renames map[string]database.Rename) {
+	if rename, ok := renames[database.ClaimsClaimedAt.Name()]; ok {
+		r.claimedAt = rename
+	}
+}
+
+// GetUsersByCoupon retrieves the user IDs who have claimed a specific coupon, in claim
+// order: the first limit of them, or all when limit is 0.
+// On success, returns an empty slice (not nil) when no claims exist.
+func (r *ClaimRepository) GetUsersByCoupon(ctx context.Context, couponName string, limit int) ([]string, error) {
+	query := `SELECT user_id FROM claims WHERE coupon_name = ? ORDER BY ` + r.claimedAt.ReadExpr() + `, id`
+	args := []any{couponName}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get claims for coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	users := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan claim user_id: %w", err)
+		}
+		users = append(users, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate claims rows: %w", err)
+	}
+	return users, nil
+}
+
+// CountByCoupon returns how many claims a coupon has.
+func (r *ClaimRepository) CountByCoupon(ctx context.Context, couponName string) (int, error) {
+	var count int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM claims WHERE coupon_name = ?`, couponName).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count claims of coupon %s: %w", couponName, err)
+	}
+	return count, nil
+}
+
+// ListByCoupon retrieves up to limit claims of a coupon after claim sequence
+// afterSequence, ordered by claim sequence.
+// On success, returns an empty slice (not nil) when no claims exist.
+func (r *ClaimRepository) ListByCoupon(ctx context.Context, couponName string, afterSequence, limit int) ([]model.Claim, error) {
+	query := `SELECT user_id, COALESCE(channel, ''), COALESCE(region, ''), claim_sequence, COALESCE(tier, ''), ` +
+		r.claimedAt.ReadExpr() + ` FROM claims WHERE coupon_name = ? AND claim_sequence > ? ORDER BY claim_sequence LIMIT ?`
+	return r.listClaims(ctx, couponName, query, couponName, afterSequence, limit)
+}
+
+// ListBySequences retrieves the claims of a coupon with the given claim sequences,
+// ordered by claim sequence. Sequences without a claim are skipped.
+// On success, returns an empty slice (not nil) when none match.
+func (r *ClaimRepository) ListBySequences(ctx context.Context, couponName string, sequences []int) ([]model.Claim, error) {
+	if len(sequences) == 0 {
+		return []model.Claim{}, nil // IN () is a syntax error
+	}
+	args := make([]any, 0, len(sequences)+1)
+	args = append(args, couponName)
+	for _, seq := range sequences {
+		args = append(args, seq)
+	}
+	query := `SELECT user_id, COALESCE(channel, ''), COALESCE(region, ''), claim_sequence, COALESCE(tier, ''), ` +
+		r.claimedAt.ReadExpr() + ` FROM claims WHERE coupon_name = ? AND claim_sequence IN (?` +
+		strings.Repeat(", ?", len(sequences)-1) + `) ORDER BY claim_sequence`
+	return r.listClaims(ctx, couponName, query, args...)
+}
+
+// listClaims runs a query selecting claims of couponName in ListByCoupon's column order.
+func (r *ClaimRepository) listClaims(ctx context.Context, couponName, query string, args ...any) ([]model.Claim, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list claims for coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	claims := []model.Claim{}
+	for rows.Next() {
+		claim := model.Claim{CouponName: couponName}
+		if err := rows.Scan(&claim.UserID, &claim.Channel, &claim.Region, &claim.Sequence, &claim.Tier, &claim.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan claim: %w", err)
+		}
+		claims = append(claims, claim)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate claims rows: %w", err)
+	}
+	return claims, nil
+}
+
+// Insert inserts a new claim record within a transaction.
+// An empty channel, region or tier is stored as NULL, and a zero CreatedAt as the current time.
+// Returns apperr.ErrAlreadyClaimed if the user has already claimed this coupon.
+func (r *ClaimRepository) Insert(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+	columns := r.claimedAt.WriteColumns()
+	query := `INSERT INTO claims (user_id, coupon_name, channel, claim_sequence, tier, region, ` + strings.Join(columns, ", ") + `)
+		VALUES (?, ?, NULLIF(?, ''), ?, NULLIF(?, ''), NULLIF(?, ''), ` +
+		strings.Repeat("COALESCE(?, CURRENT_TIMESTAMP(6)), ", len(columns)-1) + `COALESCE(?, CURRENT_TIMESTAMP(6)))`
+
+	var createdAt any
+	if !claim.CreatedAt.IsZero() {
+		createdAt = claim.CreatedAt
+	}
+	args := []any{claim.UserID, claim.CouponName, claim.Channel, claim.Sequence, claim.Tier, claim.Region}
+	for range columns {
+		args = append(args, createdAt) // Positional: one per timestamp column
+	}
+	err := r.metrics.Run(database.StatementInsertClaim, func() error {
+		_, err := tx.Exec(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		if database.IsDuplicateEntry(err) {
+			return apperr.ErrAlreadyClaimed
+		}
+		return fmt.Errorf("insert claim: %w", err)
+	}
+	return nil
+}
+
+// InsertExclusionClaim records userID's claim of couponName as their one claim of the
+// exclusion group within tx. Returns apperr.ErrExclusionGroupClaimed if they have one;
+// MySQL keeps the transaction usable after the duplicate key error.
+func (r *ClaimRepository) InsertExclusionClaim(ctx context.Context, tx database.TxQuerier, group, userID, couponName string) error {
+	_, err := tx.Exec(ctx, `INSERT INTO coupon_exclusion_claims (exclusion_group, user_id, coupon_name) VALUES (?, ?, ?)`,
+		group, userID, couponName)
+	if err != nil {
+		if database.IsDuplicateEntry(err) {
+			return apperr.ErrExclusionGroupClaimed
+		}
+		return fmt.Errorf("insert exclusion claim: %w", err)
+	}
+	return nil
+}
+
+// ClaimedUsers returns which of userIDs have claimed couponName, reading within tx.
+func (r *ClaimRepository) ClaimedUsers(ctx context.Context, tx database.TxQuerier, couponName string, userIDs []string) ([]string, error) {
+	return claimedUsers(ctx, tx, couponName, userIDs)
+}
+
+// GetClaimedUsers returns which of userIDs have claimed couponName, reading outside any
+// transaction. Only the named users' claims are read, however many the coupon has.
+func (r *ClaimRepository) GetClaimedUsers(ctx context.Context, couponName string, userIDs []string) ([]string, error) {
+	return claimedUsers(ctx, r.pool, couponName, userIDs)
+}
+
+func claimedUsers(ctx context.Context, q database.TxQuerier, couponName string, userIDs []string) ([]string, error) {
+	users := []string{}
+	if len(userIDs) == 0 {
+		return users, nil // IN () is a syntax error
+	}
+	args := make([]any, 0, len(userIDs)+1)
+	args = append(args, couponName)
+	for _, id := range userIDs {
+		args = append(args, id)
+	}
+	query := `SELECT user_id FROM claims WHERE coupon_name = ? AND user_id IN (?` +
+		strings.Repeat(", ?", len(userIDs)-1) + `)`
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get claimed users for coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan claim user_id: %w", err)
+		}
+		users = append(users, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate claims rows: %w", err)
+	}
+	return users, nil
+}
+
+// HasClaimed reports whether userID has claimed couponName. It reads outside any
+// transaction and takes no locks.
+func (r *ClaimRepository) HasClaimed(ctx context.Context, userID, couponName string) (bool, error) {
+	rows, err := r.pool.Query(ctx, `SELECT user_id FROM claims WHERE user_id = ? AND coupon_name = ?`,
+		userID, couponName)
+	if err != nil {
+		return false, fmt.Errorf("check claim of coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	claimed := rows.Next()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("iterate claims rows: %w", err)
+	}
+	return claimed, nil
+}
+
+// GetClaim returns userID's claim of couponName, or nil if there is none. Like
+// HasClaimed, it reads outside any transaction and takes no locks.
+func (r *ClaimRepository) GetClaim(ctx context.Context, userID, couponName string) (*model.Claim, error) {
+	rows, err := r.pool.Query(ctx, `SELECT user_id, COALESCE(channel, ''), COALESCE(region, ''), claim_sequence, COALESCE(tier, ''), `+
+		r.claimedAt.ReadExpr()+` FROM claims WHERE user_id = ? AND coupon_name = ?`, userID, couponName)
+	if err != nil {
+		return nil, fmt.Errorf("get claim of coupon %s: %w", couponName, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("iterate claims rows: %w", err)
+		}
+		return nil, nil
+	}
+	claim := &model.Claim{CouponName: couponName}
+	if err := rows.Scan(&claim.UserID, &claim.Channel, &claim.Region, &claim.Sequence, &claim.Tier, &claim.CreatedAt); err != nil {
+		return nil, fmt.Errorf("scan claim: %w", err)
+	}
+	return claim, nil
+}
+
+// PseudonymizeUser replaces userID with pseudonym on all of the user's claims within a
+// transaction, leaving claim counts and sequences untouched. Returns the number of claims changed.
+func (r *ClaimRepository) PseudonymizeUser(ctx context.Context, tx database.TxQuerier, userID, pseudonym string) (int64, error) {
+	tag, err := tx.Exec(ctx, `UPDATE claims SET user_id = ? WHERE user_id = ?`, pseudonym, userID)
+	if err != nil {
+		return 0, fmt.Errorf("pseudonymize claims: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE coupon_exclusion_claims SET user_id = ? WHERE user_id = ?`, pseudonym, userID); err != nil {
+		return 0, fmt.Errorf("pseudonymize coupon_exclusion_claims: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
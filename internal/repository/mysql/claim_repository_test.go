@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestClaimRepository_Insert_AlreadyClaimed(t *testing.T) {
+	q := &mockQuerier{execFn: func(string, ...any) (pgconn.CommandTag, error) {
+		return pgconn.CommandTag{}, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	}}
+	repo := NewClaimRepositoryWithPool(nil)
+
+	err := repo.Insert(context.Background(), q, &model.Claim{UserID: "user_001", CouponName: "PROMO", Sequence: 1})
+
+	assert.ErrorIs(t, err, apperr.ErrAlreadyClaimed)
+}
+
+func TestClaimRepository_InsertExclusionClaim_Claimed(t *testing.T) {
+	q := &mockQuerier{execFn: func(string, ...any) (pgconn.CommandTag, error) {
+		return pgconn.CommandTag{}, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	}}
+	repo := NewClaimRepositoryWithPool(nil)
+
+	err := repo.InsertExclusionClaim(context.Background(), q, "welcome", "user_001", "WELCOME_B")
+
+	assert.ErrorIs(t, err, apperr.ErrExclusionGroupClaimed)
+}
+
+func TestClaimRepository_PseudonymizeUser(t *testing.T) {
+	var args []any
+	q := &mockQuerier{execFn: func(_ string, a ...any) (pgconn.CommandTag, error) {
+		args = a
+		return pgconn.NewCommandTag("EXEC 2"), nil
+	}}
+	repo := NewClaimRepositoryWithPool(nil)
+
+	n, err := repo.PseudonymizeUser(context.Background(), q, "user_001", "erased-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, []any{"erased-1", "user_001"}, args, "placeholders are positional")
+	require.Len(t, q.statements, 2)
+	assert.Contains(t, q.statements[1], "UPDATE coupon_exclusion_claims")
+}
+
+func TestClaimRepository_ClaimedUsers_NoUsers(t *testing.T) {
+	q := &mockQuerier{}
+	repo := NewClaimRepositoryWithPool(nil)
+
+	users, err := repo.ClaimedUsers(context.Background(), q, "PROMO", nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Empty(t, q.statements, "an empty IN list is not sent")
+}
+
+func TestClaimRepository_ClaimedUsers_Placeholders(t *testing.T) {
+	q := &mockQuerier{}
+	repo := NewClaimRepositoryWithPool(nil)
+
+	_, err := repo.ClaimedUsers(context.Background(), q, "PROMO", []string{"user_001", "user_002"})
+
+	require.Error(t, err, "the mock does not implement Query")
+	require.Len(t, q.statements, 1)
+	assert.Contains(t, q.statements[0], "user_id IN (?, ?)")
+}
+
+func TestClaimRepository_ListBySequences(t *testing.T) {
+	q := &mockQuerier{}
+	repo := NewClaimRepositoryWithPool(q)
+
+	claims, err := repo.ListBySequences(context.Background(), "PROMO", nil)
+	require.NoError(t, err)
+	assert.NotNil(t, claims)
+	assert.Empty(t, q.statements, "an empty IN list is not sent")
+
+	_, err = repo.ListBySequences(context.Background(), "PROMO", []int{3, 7, 9})
+	require.Error(t, err, "the mock does not implement Query")
+	require.Len(t, q.statements, 1)
+	assert.Contains(t, q.statements[0], "claim_sequence IN (?, ?, ?) ORDER BY claim_sequence")
+}
+
+func TestClaimRepository_GetUsersByCoupon_Limit(t *testing.T) {
+	q := &mockQuerier{}
+	repo := NewClaimRepositoryWithPool(q)
+
+	_, err := repo.GetUsersByCoupon(context.Background(), "PROMO", 0)
+	require.Error(t, err, "the mock does not implement Query")
+	_, err = repo.GetUsersByCoupon(context.Background(), "PROMO", 100)
+	require.Error(t, err, "the mock does not implement Query")
+
+	require.Len(t, q.statements, 2)
+	assert.NotContains(t, q.statements[0], "LIMIT")
+	assert.Contains(t, q.statements[1], "ORDER BY created_at, id LIMIT ?")
+}
+
+func TestClaimRepository_Insert_DualWrite(t *testing.T) {
+	var query string
+	var args []any
+	q := &mockQuerier{execFn: func(sql string, a ...any) (pgconn.CommandTag, error) {
+		query, args = sql, a
+		return pgconn.NewCommandTag("EXEC 1"), nil
+	}}
+	rename := database.ClaimsClaimedAt
+	rename.Phase = database.PhaseDualWrite
+	repo := NewClaimRepositoryWithPool(nil)
+	repo.SetRenames(map[string]database.Rename{rename.Name(): rename})
+	claimedAt := time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+
+	err := repo.Insert(context.Background(), q, &model.Claim{UserID: "user_001", CouponName: "PROMO", CreatedAt: claimedAt})
+
+	require.NoError(t, err)
+	assert.Contains(t, query, "tier, region, created_at, claimed_at)")
+	assert.Equal(t, []any{"user_001", "PROMO", "", 0, "", "", claimedAt, claimedAt}, args, "one parameter per placeholder")
+}
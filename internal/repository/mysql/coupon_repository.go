@@ -0,0 +1,568 @@
+// Package mysql implements the repository interfaces in internal/ports for MySQL and
+// MariaDB (InnoDB), for deployments selecting DB_DRIVER=mysql. Queries run through
+// database.SQLQuerier, so the service layer and its transactions are shared with the
+// PostgreSQL repositories; the schema is scripts/mysql/init.sql.
+//
+// Compared to the PostgreSQL repositories, MySQL has no RETURNING, no writable CTEs and
+// no JSONB operators: multi-table inserts are separate statements in one transaction,
+// JSON columns are (un)marshaled here, and tag filters use JSON_CONTAINS.
+package mysql
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// couponColumns is the column list shared by all coupon SELECTs (same order as the
+// PostgreSQL repository). Channel partitions are aggregated inline; JSON_ARRAYAGG does
+// not order its input portably, so scanCoupon sorts them. JSON_OBJECT would render
+// DATETIMEs in MySQL's own format, so the stats timestamps are formatted as RFC 3339
+// (the session time zone is UTC).
+const couponColumns = `name, amount, remaining_amount, created_at, tags, overflow_at,
+	(SELECT JSON_ARRAYAGG(JSON_OBJECT('channel', q.channel, 'quota', q.quota, 'remaining', q.remaining))
+		FROM coupon_channel_quotas q WHERE q.coupon_name = coupons.name),
+	claim_sequence, tiers, disabled, captcha_required, metadata, low_stock_percent,
+	COALESCE(parent, ''),
+	(SELECT JSON_ARRAYAGG(JSON_OBJECT('region', r.region, 'quota', COALESCE(r.quota, 0), 'claimed', r.claimed))
+		FROM coupon_region_claims r WHERE r.coupon_name = coupons.name),
+	(SELECT JSON_OBJECT('total_claims', s.total_claims,
+			'last_claim_at', DATE_FORMAT(s.last_claim_at, '%Y-%m-%dT%H:%i:%s.%fZ'),
+			'hour_start', DATE_FORMAT(s.hour_start, '%Y-%m-%dT%H:%i:%s.%fZ'),
+			'claims_this_hour', s.claims_this_hour, 'claims_prev_hour', s.claims_prev_hour)
+		FROM coupon_stats s WHERE s.coupon_name = coupons.name),
+	unlimited, visibility, high_profile, COALESCE(prerequisite, ''), COALESCE(exclusion_group, '')`
+
+// CouponRepository provides data access for coupons on MySQL.
+type CouponRepository struct {
+	pool     database.TxQuerier
+	tx       ports.Transactor
+	timeouts database.QueryTimeouts
+	lock     database.LockPolicy
+	metrics  *database.StatementMetrics
+}
+
+var _ ports.CouponRepository = (*CouponRepository)(nil)
+
+// NewCouponRepository creates a new CouponRepository. tx runs Insert, which writes
+// more than one statement, in a transaction.
+func NewCouponRepository(db *sql.DB, tx ports.Transactor) *CouponRepository {
+	return &CouponRepository{pool: database.SQLQuerier(db), tx: tx}
+}
+
+// NewCouponRepositoryWithPool creates a new CouponRepository with a custom pool interface.
+// This is primarily used for testing.
+func NewCouponRepositoryWithPool(pool database.TxQuerier, tx ports.Transactor) *CouponRepository {
+	return &CouponRepository{pool: pool, tx: tx}
+}
+
+// SetQueryTimeouts sets the timeouts of the queries named in database.Queries.
+func (r *CouponRepository) SetQueryTimeouts(timeouts database.QueryTimeouts) {
+	r.timeouts = timeouts
+}
+
+// SetStatementMetrics sets the metrics timing the statements named in database.Statements.
+func (r *CouponRepository) SetStatementMetrics(metrics *database.StatementMetrics) {
+	r.metrics = metrics
+}
+
+// SetLockPolicy sets how GetCouponForUpdate acquires a coupon's row lock. Only the
+// wait and nowait modes are supported (see config.CouponLockConfig).
+func (r *CouponRepository) SetLockPolicy(policy database.LockPolicy) {
+	r.lock = policy
+}
+
+// scanCoupon scans a row selected with couponColumns into a Coupon.
+func scanCoupon(row pgx.Row) (*model.Coupon, error) {
+	var coupon model.Coupon
+	var tags, channels, tiers, metadata, regions, stats []byte
+	if err := row.Scan(
+		&coupon.Name,
+		&coupon.Amount,
+		&coupon.RemainingAmount,
+		&coupon.CreatedAt,
+		&tags,
+		&coupon.OverflowAt,
+		&channels,
+		&coupon.ClaimSequence,
+		&tiers,
+		&coupon.Disabled,
+		&coupon.CaptchaRequired,
+		&metadata,
+		&coupon.LowStockPercent,
+		&coupon.Parent,
+		&regions,
+		&stats,
+		&coupon.Unlimited,
+		&coupon.Visibility,
+		&coupon.HighProfile,
+		&coupon.Prerequisite,
+		&coupon.ExclusionGroup,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalJSON(tags, &coupon.Tags); err != nil {
+		return nil, fmt.Errorf("decode tags: %w", err)
+	}
+	if err := unmarshalJSON(channels, &coupon.Channels); err != nil {
+		return nil, fmt.Errorf("decode channels: %w", err)
+	}
+	if err := unmarshalJSON(tiers, &coupon.Tiers); err != nil {
+		return nil, fmt.Errorf("decode tiers: %w", err)
+	}
+	if err := unmarshalJSON(regions, &coupon.Regions); err != nil {
+		return nil, fmt.Errorf("decode regions: %w", err)
+	}
+	if err := unmarshalJSON(stats, &coupon.Stats); err != nil {
+		return nil, fmt.Errorf("decode stats: %w", err)
+	}
+	if coupon.Tags == nil {
+		coupon.Tags = []string{}
+	}
+	if len(metadata) > 0 && string(metadata) != "{}" {
+		coupon.Metadata = metadata
+	}
+	slices.SortFunc(coupon.Channels, func(a, b model.ChannelQuota) int {
+		return cmp.Compare(a.Channel, b.Channel)
+	})
+	slices.SortFunc(coupon.Regions, func(a, b model.RegionQuota) int {
+		return cmp.Compare(a.Region, b.Region)
+	})
+	return &coupon, nil
+}
+
+// unmarshalJSON decodes a JSON column; NULL leaves v unchanged.
+func unmarshalJSON(data []byte, v any) error {
+	if data == nil {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// marshalJSON encodes v for a JSON column; nil slices are stored as [].
+func marshalJSON[T any](v []T) (string, error) {
+	if v == nil {
+		v = []T{}
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// marshalMetadata returns the metadata column value: metadata, or an empty object.
+func marshalMetadata(metadata json.RawMessage) string {
+	if len(metadata) == 0 {
+		return "{}"
+	}
+	return string(metadata)
+}
+
+// queryCoupons runs a query selecting couponColumns and scans every row.
+func queryCoupons(ctx context.Context, q database.TxQuerier, query string, args ...any) ([]model.Coupon, error) {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := []model.Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan coupon: %w", err)
+		}
+		coupons = append(coupons, *coupon)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate coupon rows: %w", err)
+	}
+	return coupons, nil
+}
+
+// Insert inserts a new coupon and its channel partitions (if any) in one transaction.
+// Returns apperr.ErrCouponExists if a coupon with the same name already exists.
+func (r *CouponRepository) Insert(ctx context.Context, coupon *model.Coupon) error {
+	return r.tx.InTx(ctx, func(tx database.TxQuerier) error {
+		return r.metrics.Run(database.StatementInsertCoupon, func() error {
+			return insertCoupon(ctx, tx, coupon)
+		})
+	})
+}
+
+// InsertTx is Insert within a caller-managed transaction.
+func (r *CouponRepository) InsertTx(ctx context.Context, tx database.TxQuerier, coupon *model.Coupon) error {
+	return r.metrics.Run(database.StatementInsertCoupon, func() error {
+		return insertCoupon(ctx, tx, coupon)
+	})
+}
+
+func insertCoupon(ctx context.Context, q database.TxQuerier, coupon *model.Coupon) error {
+	tags, err := marshalJSON(coupon.Tags)
+	if err != nil {
+		return fmt.Errorf("encode tags: %w", err)
+	}
+	tiers, err := marshalJSON(coupon.Tiers)
+	if err != nil {
+		return fmt.Errorf("encode tiers: %w", err)
+	}
+
+	_, err = q.Exec(ctx,
+		`INSERT INTO coupons (name, amount, remaining_amount, tags, overflow_at, tiers, captcha_required, metadata, low_stock_percent, parent, unlimited, visibility, high_profile, prerequisite, exclusion_group)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, COALESCE(NULLIF(?, ''), 'public'), ?, NULLIF(?, ''), NULLIF(?, ''))`,
+		coupon.Name, coupon.Amount, coupon.Amount, tags, coupon.OverflowAt, tiers, // remaining_amount = amount
+		coupon.CaptchaRequired, marshalMetadata(coupon.Metadata), coupon.LowStockPercent, coupon.Parent, coupon.Unlimited, coupon.Visibility, coupon.HighProfile,
+		coupon.Prerequisite, coupon.ExclusionGroup)
+	if err != nil {
+		if database.IsDuplicateEntry(err) {
+			return apperr.ErrCouponExists
+		}
+		return fmt.Errorf("insert coupon: %w", err)
+	}
+
+	if len(coupon.Channels) > 0 {
+		values := make([]string, 0, len(coupon.Channels))
+		args := make([]any, 0, 4*len(coupon.Channels))
+		for _, q := range coupon.Channels {
+			values = append(values, "(?, ?, ?, ?)")
+			args = append(args, coupon.Name, q.Channel, q.Quota, q.Quota)
+		}
+		_, err = q.Exec(ctx,
+			`INSERT INTO coupon_channel_quotas (coupon_name, channel, quota, remaining) VALUES `+strings.Join(values, ", "),
+			args...)
+		if err != nil {
+			return fmt.Errorf("insert channel quotas: %w", err)
+		}
+	}
+
+	if len(coupon.Regions) > 0 {
+		values := make([]string, 0, len(coupon.Regions))
+		args := make([]any, 0, 3*len(coupon.Regions))
+		for _, r := range coupon.Regions {
+			values = append(values, "(?, ?, ?)")
+			args = append(args, coupon.Name, r.Region, r.Quota)
+		}
+		_, err = q.Exec(ctx,
+			`INSERT INTO coupon_region_claims (coupon_name, region, quota) VALUES `+strings.Join(values, ", "),
+			args...)
+		if err != nil {
+			return fmt.Errorf("insert region quotas: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetByName retrieves a coupon by its name.
+// Returns nil, nil if the coupon is not found (service layer handles this).
+func (r *CouponRepository) GetByName(ctx context.Context, name string) (*model.Coupon, error) {
+	var coupon *model.Coupon
+	err := r.timeouts.Run(ctx, database.QueryGetCoupon, func(ctx context.Context) error {
+		var err error
+		coupon, err = scanCoupon(r.pool.QueryRow(ctx, `SELECT `+couponColumns+` FROM coupons WHERE name = ?`, name))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil // Not found - let service handle
+		}
+		return nil, fmt.Errorf("get coupon by name %s: %w", name, err)
+	}
+	return coupon, nil
+}
+
+// couponStatusConditions are the SQL conditions selecting coupons of each status.
+var couponStatusConditions = map[string]string{
+	model.CouponStatusActive:    `NOT disabled AND (unlimited OR remaining_amount > 0)`,
+	model.CouponStatusExhausted: `NOT disabled AND NOT unlimited AND remaining_amount <= 0`,
+	model.CouponStatusDisabled:  `disabled`,
+}
+
+// List retrieves public coupons ordered by name, optionally filtered by tag and status
+// and starting after the name filter.After.
+// On success, returns an empty slice (not nil) when nothing matches.
+func (r *CouponRepository) List(ctx context.Context, filter model.CouponFilter) ([]model.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE visibility = 'public'`
+	args := []any{}
+	if filter.Tag != "" {
+		query += ` AND JSON_CONTAINS(tags, JSON_QUOTE(?))`
+		args = append(args, filter.Tag)
+	}
+	if filter.Status != "" {
+		cond, ok := couponStatusConditions[filter.Status]
+		if !ok {
+			return nil, fmt.Errorf("list coupons: unknown status %q", filter.Status)
+		}
+		query += ` AND ` + cond
+	}
+	if filter.After != "" {
+		query += ` AND name > ?`
+		args = append(args, filter.After)
+	}
+	query += ` ORDER BY name LIMIT ?`
+	args = append(args, filter.Limit)
+
+	coupons, err := queryCoupons(ctx, r.pool, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list coupons: %w", err)
+	}
+	return coupons, nil
+}
+
+// Names returns the names of all coupons.
+// On success, returns an empty slice (not nil) when there are none.
+func (r *CouponRepository) Names(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT name FROM coupons`)
+	if err != nil {
+		return nil, fmt.Errorf("list coupon names: %w", err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan coupon name: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate coupon rows: %w", err)
+	}
+	return names, nil
+}
+
+// UpdateTags replaces the tags of a coupon.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (r *CouponRepository) UpdateTags(ctx context.Context, name string, tags []string) error {
+	return updateTags(ctx, r.pool, name, tags)
+}
+
+// UpdateTagsTx is UpdateTags within a caller-managed transaction.
+func (r *CouponRepository) UpdateTagsTx(ctx context.Context, tx database.TxQuerier, name string, tags []string) error {
+	return updateTags(ctx, tx, name, tags)
+}
+
+// updateTags relies on clientFoundRows=true in the DSN: without it MySQL reports rows
+// changed rather than matched, and setting the current tags would look like a missing coupon.
+func updateTags(ctx context.Context, q database.TxQuerier, name string, tags []string) error {
+	encoded, err := marshalJSON(tags)
+	if err != nil {
+		return fmt.Errorf("encode tags: %w", err)
+	}
+	tag, err := q.Exec(ctx, `UPDATE coupons SET tags = ? WHERE name = ?`, encoded, name)
+	if err != nil {
+		return fmt.Errorf("update tags for %s: %w", name, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrCouponNotFound
+	}
+	return nil
+}
+
+// ListForUpdate retrieves every coupon, children (coupons with a parent) first and then
+// by name, locking all of their rows until the transaction completes. Children are
+// locked before the other coupons because claims lock a child's row before its
+// parent's. Used by bulk reconciliation (manifest apply).
+// Locks are taken first and the coupons read by another statement; see GetCouponForUpdate.
+func (r *CouponRepository) ListForUpdate(ctx context.Context, tx database.TxQuerier) ([]model.Coupon, error) {
+	for _, query := range []string{
+		`SELECT name FROM coupons WHERE parent IS NOT NULL ORDER BY name FOR UPDATE`,
+		`SELECT name FROM coupons ORDER BY name FOR UPDATE`,
+	} {
+		rows, err := tx.Query(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("lock coupons: %w", err)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("lock coupons: %w", err)
+		}
+	}
+
+	coupons, err := queryCoupons(ctx, tx, `SELECT `+couponColumns+` FROM coupons ORDER BY parent IS NULL, name`)
+	if err != nil {
+		return nil, fmt.Errorf("list coupons for update: %w", err)
+	}
+	return coupons, nil
+}
+
+// TopUp increases a coupon's amount and remaining stock by delta.
+// Must be called within a transaction after locking the row.
+func (r *CouponRepository) TopUp(ctx context.Context, tx database.TxQuerier, name string, delta int) error {
+	query := `UPDATE coupons SET amount = amount + ?, remaining_amount = remaining_amount + ? WHERE name = ?`
+
+	_, err := tx.Exec(ctx, query, delta, delta, name)
+	if err != nil {
+		return fmt.Errorf("top up %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetDisabled disables or re-enables claims on a coupon.
+// Must be called within a transaction after locking the row.
+func (r *CouponRepository) SetDisabled(ctx context.Context, tx database.TxQuerier, name string, disabled bool) error {
+	_, err := tx.Exec(ctx, `UPDATE coupons SET disabled = ? WHERE name = ?`, disabled, name)
+	if err != nil {
+		return fmt.Errorf("set disabled for %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetHighProfile marks a coupon as high-profile or not. Relies on clientFoundRows=true,
+// as updateTags does.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (r *CouponRepository) SetHighProfile(ctx context.Context, tx database.TxQuerier, name string, highProfile bool) error {
+	tag, err := tx.Exec(ctx, `UPDATE coupons SET high_profile = ? WHERE name = ?`, highProfile, name)
+	if err != nil {
+		return fmt.Errorf("set high profile for %s: %w", name, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrCouponNotFound
+	}
+	return nil
+}
+
+// GetCouponForUpdate locks a coupon row until the transaction completes and returns it.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist, and
+// apperr.ErrCouponBusy if the nowait lock policy found it locked by another transaction.
+//
+// In InnoDB only the locked row of a SELECT ... FOR UPDATE is read current; the channel
+// subquery would read the statement's snapshot, taken before waiting for the lock, and
+// could miss the previous holder's decrement. So the lock is taken by one statement and
+// the coupon read by the next, whose READ COMMITTED snapshot includes that decrement.
+func (r *CouponRepository) GetCouponForUpdate(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+	var coupon *model.Coupon
+	err := r.metrics.Run(database.StatementGetForUpdate, func() error {
+		var err error
+		coupon, err = r.getCouponForUpdate(ctx, tx, name)
+		return err
+	})
+	return coupon, err
+}
+
+func (r *CouponRepository) getCouponForUpdate(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+	lock := `SELECT name FROM coupons WHERE name = ? FOR UPDATE`
+	if r.lock.Mode == database.LockNoWait {
+		lock += ` NOWAIT`
+	}
+	err := r.timeouts.Run(ctx, database.QueryLockCoupon, func(ctx context.Context) error {
+		var locked string
+		return tx.QueryRow(ctx, lock, name).Scan(&locked)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.ErrCouponNotFound
+		}
+		if database.IsLockNotAvailable(err) {
+			return nil, apperr.ErrCouponBusy
+		}
+		return nil, fmt.Errorf("lock coupon %s: %w", name, err)
+	}
+
+	coupon, err := scanCoupon(tx.QueryRow(ctx, `SELECT `+couponColumns+` FROM coupons WHERE name = ?`, name))
+	if err != nil {
+		return nil, fmt.Errorf("get coupon for update %s: %w", name, err)
+	}
+	return coupon, nil
+}
+
+// DecrementChannelStock decrements the remaining stock of one channel partition by 1.
+// Must be called within a transaction after locking the parent coupon row.
+func (r *CouponRepository) DecrementChannelStock(ctx context.Context, tx database.TxQuerier, name, channel string) error {
+	query := `UPDATE coupon_channel_quotas SET remaining = remaining - 1 WHERE coupon_name = ? AND channel = ?`
+
+	_, err := tx.Exec(ctx, query, name, channel)
+	if err != nil {
+		return fmt.Errorf("decrement channel stock for %s/%s: %w", name, channel, err)
+	}
+	return nil
+}
+
+// DecrementStock decrements the remaining_amount of a coupon by 1 and advances its claim_sequence.
+// Must be called within a transaction after locking the row.
+func (r *CouponRepository) DecrementStock(ctx context.Context, tx database.TxQuerier, name string) error {
+	query := `UPDATE coupons SET remaining_amount = remaining_amount - 1, claim_sequence = claim_sequence + 1 WHERE name = ?`
+
+	err := r.metrics.Run(database.StatementDecrementStock, func() error {
+		_, err := tx.Exec(ctx, query, name)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("decrement stock for %s: %w", name, err)
+	}
+	return nil
+}
+
+// AdvanceClaimSequence advances the claim_sequence of an unlimited coupon, which has no
+// stock to decrement. Must be called within a transaction after locking the row.
+func (r *CouponRepository) AdvanceClaimSequence(ctx context.Context, tx database.TxQuerier, name string) error {
+	_, err := tx.Exec(ctx, `UPDATE coupons SET claim_sequence = claim_sequence + 1 WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("advance claim sequence for %s: %w", name, err)
+	}
+	return nil
+}
+
+// CountRegionClaim counts a claim of the coupon name from region.
+// Must be called within a transaction after locking the coupon row.
+func (r *CouponRepository) CountRegionClaim(ctx context.Context, tx database.TxQuerier, name, region string) error {
+	query := `INSERT INTO coupon_region_claims (coupon_name, region, claimed) VALUES (?, ?, 1)
+		ON DUPLICATE KEY UPDATE claimed = claimed + 1`
+
+	_, err := tx.Exec(ctx, query, name, region)
+	if err != nil {
+		return fmt.Errorf("count region claim for %s/%s: %w", name, region, err)
+	}
+	return nil
+}
+
+// RecordClaimStats counts a claim of the coupon name made at at in its stats rollup,
+// bucketed by clock hour like the PostgreSQL repository. MySQL applies the assignments
+// left to right, so the buckets are shifted before hour_start moves.
+// Must be called within a transaction after locking the coupon row.
+func (r *CouponRepository) RecordClaimStats(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error {
+	query := `INSERT INTO coupon_stats (coupon_name, total_claims, last_claim_at, hour_start, claims_this_hour, claims_prev_hour)
+		VALUES (?, 1, ?, ?, 1, 0)
+		ON DUPLICATE KEY UPDATE
+			total_claims = total_claims + 1,
+			last_claim_at = GREATEST(last_claim_at, VALUES(last_claim_at)),
+			claims_prev_hour = CASE
+				WHEN VALUES(hour_start) = hour_start + INTERVAL 1 HOUR THEN claims_this_hour
+				WHEN VALUES(hour_start) > hour_start THEN 0
+				WHEN VALUES(hour_start) = hour_start - INTERVAL 1 HOUR THEN claims_prev_hour + 1
+				ELSE claims_prev_hour END,
+			claims_this_hour = CASE
+				WHEN VALUES(hour_start) = hour_start THEN claims_this_hour + 1
+				WHEN VALUES(hour_start) > hour_start THEN 1
+				ELSE claims_this_hour END,
+			hour_start = GREATEST(hour_start, VALUES(hour_start))`
+
+	_, err := tx.Exec(ctx, query, name, at.UTC(), at.UTC().Truncate(time.Hour))
+	if err != nil {
+		return fmt.Errorf("record claim stats for %s: %w", name, err)
+	}
+	return nil
+}
+
+// DecrementBudget decrements the remaining_amount of a parent coupon by 1 for a claim
+// of one of its children; its claim_sequence only counts its own claims.
+// Must be called within a transaction after locking the row.
+func (r *CouponRepository) DecrementBudget(ctx context.Context, tx database.TxQuerier, name string) error {
+	_, err := tx.Exec(ctx, `UPDATE coupons SET remaining_amount = remaining_amount - 1 WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("decrement budget for %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// mockRow implements pgx.Row.
+type mockRow struct {
+	scanFn func(dest ...any) error
+}
+
+func (m *mockRow) Scan(dest ...any) error { return m.scanFn(dest...) }
+
+// mockQuerier implements database.TxQuerier, recording every statement.
+type mockQuerier struct {
+	execFn     func(sql string, args ...any) (pgconn.CommandTag, error)
+	queryRowFn func(sql string, args ...any) pgx.Row
+	statements []string
+}
+
+func (m *mockQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	m.statements = append(m.statements, sql)
+	if m.execFn != nil {
+		return m.execFn(sql, args...)
+	}
+	return pgconn.NewCommandTag("EXEC 1"), nil
+}
+
+func (m *mockQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	m.statements = append(m.statements, sql)
+	return m.queryRowFn(sql, args...)
+}
+
+func (m *mockQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	m.statements = append(m.statements, sql)
+	return nil, errors.New("not implemented")
+}
+
+// inlineTx runs fn directly on a mockQuerier.
+type inlineTx struct {
+	q *mockQuerier
+}
+
+func (t inlineTx) InTx(ctx context.Context, fn func(tx database.TxQuerier) error) error {
+	return fn(t.q)
+}
+
+// couponRow scans a coupon as MySQL returns it: JSON columns as bytes.
+func couponRow(name string, channels string) *mockRow {
+	return &mockRow{scanFn: func(dest ...any) error {
+		*(dest[0].(*string)) = name
+		*(dest[1].(*int)) = 100
+		*(dest[2].(*int)) = 70
+		*(dest[3].(*time.Time)) = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		*(dest[4].(*[]byte)) = []byte(`["summer"]`)
+		if channels != "" {
+			*(dest[6].(*[]byte)) = []byte(channels)
+		}
+		*(dest[7].(*int)) = 30
+		*(dest[8].(*[]byte)) = []byte(`[{"name":"gold","size":10}]`)
+		*(dest[9].(*bool)) = true
+		return nil
+	}}
+}
+
+func TestCouponRepository_GetByName_DecodesJSONColumns(t *testing.T) {
+	q := &mockQuerier{queryRowFn: func(string, ...any) pgx.Row {
+		return couponRow("PROMO", `[{"channel":"web","quota":30,"remaining":10},{"channel":"app","quota":70,"remaining":60}]`)
+	}}
+	repo := NewCouponRepositoryWithPool(q, inlineTx{q})
+
+	coupon, err := repo.GetByName(context.Background(), "PROMO")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"summer"}, coupon.Tags)
+	assert.Equal(t, []model.Tier{{Name: "gold", Size: 10}}, coupon.Tiers)
+	assert.Equal(t, []model.ChannelQuota{
+		{Channel: "app", Quota: 70, Remaining: 60},
+		{Channel: "web", Quota: 30, Remaining: 10},
+	}, coupon.Channels, "channels are sorted like the PostgreSQL repository's")
+	assert.Equal(t, 30, coupon.ClaimSequence)
+	assert.True(t, coupon.Disabled)
+}
+
+func TestCouponRepository_GetByName_DecodesStats(t *testing.T) {
+	q := &mockQuerier{queryRowFn: func(string, ...any) pgx.Row {
+		row := couponRow("PROMO", "")
+		scan := row.scanFn
+		row.scanFn = func(dest ...any) error {
+			*(dest[15].(*[]byte)) = []byte(`{"total_claims": 30, "last_claim_at": "2026-01-01T10:15:00.250000Z",
+				"hour_start": "2026-01-01T10:00:00.000000Z", "claims_this_hour": 4, "claims_prev_hour": 9}`)
+			return scan(dest...)
+		}
+		return row
+	}}
+	repo := NewCouponRepositoryWithPool(q, inlineTx{q})
+
+	coupon, err := repo.GetByName(context.Background(), "PROMO")
+
+	require.NoError(t, err)
+	lastClaimAt := time.Date(2026, 1, 1, 10, 15, 0, 250_000_000, time.UTC)
+	assert.Equal(t, &model.CouponStats{
+		TotalClaims:    30,
+		LastClaimAt:    &lastClaimAt,
+		HourStart:      time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+		ClaimsThisHour: 4,
+		ClaimsPrevHour: 9,
+	}, coupon.Stats)
+}
+
+func TestCouponRepository_GetByName_NotFound(t *testing.T) {
+	q := &mockQuerier{queryRowFn: func(string, ...any) pgx.Row {
+		return &mockRow{scanFn: func(...any) error { return pgx.ErrNoRows }}
+	}}
+	repo := NewCouponRepositoryWithPool(q, inlineTx{q})
+
+	coupon, err := repo.GetByName(context.Background(), "MISSING")
+
+	require.NoError(t, err)
+	assert.Nil(t, coupon)
+}
+
+func TestCouponRepository_GetCouponForUpdate_LocksBeforeReading(t *testing.T) {
+	q := &mockQuerier{queryRowFn: func(sql string, _ ...any) pgx.Row {
+		if sql == `SELECT name FROM coupons WHERE name = ? FOR UPDATE` {
+			return &mockRow{scanFn: func(dest ...any) error {
+				*(dest[0].(*string)) = "PROMO"
+				return nil
+			}}
+		}
+		return couponRow("PROMO", "")
+	}}
+	repo := NewCouponRepositoryWithPool(nil, nil)
+
+	coupon, err := repo.GetCouponForUpdate(context.Background(), q, "PROMO")
+
+	require.NoError(t, err)
+	assert.Equal(t, 70, coupon.RemainingAmount)
+	require.Len(t, q.statements, 2)
+	assert.Contains(t, q.statements[0], "FOR UPDATE")
+	assert.NotContains(t, q.statements[1], "FOR UPDATE", "the read must start after the lock is held")
+}
+
+func TestCouponRepository_GetCouponForUpdate_NotFound(t *testing.T) {
+	q := &mockQuerier{queryRowFn: func(string, ...any) pgx.Row {
+		return &mockRow{scanFn: func(...any) error { return pgx.ErrNoRows }}
+	}}
+	repo := NewCouponRepositoryWithPool(nil, nil)
+
+	_, err := repo.GetCouponForUpdate(context.Background(), q, "MISSING")
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	assert.Len(t, q.statements, 1)
+}
+
+func TestCouponRepository_Insert_WithChannels(t *testing.T) {
+	var quotaArgs []any
+	q := &mockQuerier{execFn: func(sql string, args ...any) (pgconn.CommandTag, error) {
+		if len(args) == 8 {
+			quotaArgs = args
+		}
+		return pgconn.NewCommandTag("EXEC 1"), nil
+	}}
+	repo := NewCouponRepositoryWithPool(q, inlineTx{q})
+
+	err := repo.Insert(context.Background(), &model.Coupon{
+		Name:     "PROMO",
+		Amount:   100,
+		Channels: []model.ChannelQuota{{Channel: "app", Quota: 70}, {Channel: "web", Quota: 30}},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, q.statements, 2, "coupon and quotas are separate statements (no writable CTEs)")
+	assert.Contains(t, q.statements[1], "VALUES (?, ?, ?, ?), (?, ?, ?, ?)")
+	assert.Equal(t, []any{"PROMO", "app", 70, 70, "PROMO", "web", 30, 30}, quotaArgs)
+}
+
+func TestCouponRepository_Insert_Duplicate(t *testing.T) {
+	q := &mockQuerier{execFn: func(string, ...any) (pgconn.CommandTag, error) {
+		return pgconn.CommandTag{}, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'PROMO' for key 'PRIMARY'"}
+	}}
+	repo := NewCouponRepositoryWithPool(q, inlineTx{q})
+
+	err := repo.Insert(context.Background(), &model.Coupon{Name: "PROMO", Amount: 100})
+
+	assert.ErrorIs(t, err, apperr.ErrCouponExists)
+}
+
+func TestCouponRepository_UpdateTags(t *testing.T) {
+	var args []any
+	q := &mockQuerier{execFn: func(_ string, a ...any) (pgconn.CommandTag, error) {
+		args = a
+		return pgconn.NewCommandTag("EXEC 0"), nil
+	}}
+	repo := NewCouponRepositoryWithPool(q, inlineTx{q})
+
+	err := repo.UpdateTags(context.Background(), "MISSING", nil)
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	assert.Equal(t, []any{"[]", "MISSING"}, args, "nil tags are stored as an empty JSON array")
+}
+
+func TestCouponRepository_RecordClaimStats(t *testing.T) {
+	var args []any
+	q := &mockQuerier{execFn: func(sql string, a ...any) (pgconn.CommandTag, error) {
+		args = a
+		return pgconn.NewCommandTag("EXEC 1"), nil
+	}}
+	repo := NewCouponRepositoryWithPool(q, inlineTx{q})
+	at := time.Date(2026, 1, 1, 10, 15, 0, 0, time.FixedZone("WIB", 7*60*60))
+
+	err := repo.RecordClaimStats(context.Background(), q, "PROMO", at)
+
+	require.NoError(t, err)
+	require.Len(t, q.statements, 1)
+	assert.Contains(t, q.statements[0], "ON DUPLICATE KEY UPDATE")
+	assert.Less(t, strings.Index(q.statements[0], "claims_prev_hour ="), strings.Index(q.statements[0], "hour_start = GREATEST"),
+		"the buckets are shifted before hour_start moves")
+	assert.Equal(t, []any{"PROMO", at.UTC(), time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)}, args)
+}
+
+func TestCouponRepository_List_WithStatusFilter(t *testing.T) {
+	q := &mockQuerier{}
+	repo := NewCouponRepositoryWithPool(q, inlineTx{q})
+
+	_, err := repo.List(context.Background(), model.CouponFilter{Tag: "blackfriday", Status: model.CouponStatusActive, After: "BF_APP", Limit: 25})
+
+	require.Error(t, err) // mockQuerier does not implement Query
+	require.Len(t, q.statements, 1)
+	assert.Contains(t, q.statements[0], "WHERE visibility = 'public' AND JSON_CONTAINS(tags, JSON_QUOTE(?)) AND NOT disabled AND (unlimited OR remaining_amount > 0) AND name > ? ORDER BY name")
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+)
+
+// webhookColumns is the column list shared by all webhook subscription SELECTs.
+const webhookColumns = `id, url, events, secret, payload_template, created_at`
+
+// WebhookRepository provides data access for webhook subscriptions.
+type WebhookRepository struct {
+	pool PoolInterface
+}
+
+var _ ports.WebhookRepository = (*WebhookRepository)(nil)
+
+// NewWebhookRepository creates a new WebhookRepository.
+func NewWebhookRepository(pool *pgxpool.Pool) *WebhookRepository {
+	return NewWebhookRepositoryWithPool(pool)
+}
+
+// NewWebhookRepositoryWithPool creates a new WebhookRepository with a custom pool interface.
+// This is primarily used for testing.
+func NewWebhookRepositoryWithPool(pool PoolInterface) *WebhookRepository {
+	return &WebhookRepository{pool: pool}
+}
+
+// Insert stores sub and sets its ID and CreatedAt.
+func (r *WebhookRepository) Insert(ctx context.Context, sub *model.WebhookSubscription) error {
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO webhook_subscriptions (url, events, secret, payload_template) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, sub.URL, sub.Events, sub.Secret, sub.PayloadTemplate).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit subscriptions with IDs above afterID, ordered by ID, with
+// their secrets.
+func (r *WebhookRepository) List(ctx context.Context, afterID int64, limit int) ([]model.WebhookSubscription, error) {
+	return r.query(ctx, `SELECT `+webhookColumns+` FROM webhook_subscriptions WHERE id > $1 ORDER BY id LIMIT $2`, afterID, limit)
+}
+
+// ListForEvent returns the subscriptions receiving eventType, ordered by ID.
+func (r *WebhookRepository) ListForEvent(ctx context.Context, eventType string) ([]model.WebhookSubscription, error) {
+	return r.query(ctx, `SELECT `+webhookColumns+` FROM webhook_subscriptions WHERE $1 = ANY(events) ORDER BY id`, eventType)
+}
+
+// Get returns the subscription with id, or nil if there is none.
+func (r *WebhookRepository) Get(ctx context.Context, id int64) (*model.WebhookSubscription, error) {
+	var sub model.WebhookSubscription
+	err := r.pool.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhook_subscriptions WHERE id = $1`, id).
+		Scan(&sub.ID, &sub.URL, &sub.Events, &sub.Secret, &sub.PayloadTemplate, &sub.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get webhook subscription %d: %w", id, err)
+	}
+	return &sub, nil
+}
+
+// Delete removes the subscription with id.
+// Returns apperr.ErrWebhookNotFound if there is none.
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription %d: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) query(ctx context.Context, sql string, args ...any) ([]model.WebhookSubscription, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []model.WebhookSubscription{}
+	for rows.Next() {
+		var sub model.WebhookSubscription
+		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Events, &sub.Secret, &sub.PayloadTemplate, &sub.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan webhook subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestWebhookRepository_Insert(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var capturedArgs []any
+	pool := &mockPool{queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+		capturedArgs = args
+		return &mockRow{scanFn: func(dest ...any) error {
+			*(dest[0].(*int64)) = 7
+			*(dest[1].(*time.Time)) = created
+			return nil
+		}}
+	}}
+	sub := &model.WebhookSubscription{
+		URL:             "https://erp.example.com",
+		Events:          []string{model.CouponEventCreated},
+		Secret:          "whsec_0123456789abcdef",
+		PayloadTemplate: `{"sku": {{json .coupon.name}}}`,
+	}
+
+	require.NoError(t, NewWebhookRepositoryWithPool(pool).Insert(context.Background(), sub))
+
+	assert.Equal(t, []any{"https://erp.example.com", []string{model.CouponEventCreated}, "whsec_0123456789abcdef",
+		`{"sku": {{json .coupon.name}}}`}, capturedArgs)
+	assert.Equal(t, int64(7), sub.ID)
+	assert.Equal(t, created, sub.CreatedAt)
+}
+
+func TestWebhookRepository_ListForEvent(t *testing.T) {
+	var capturedSQL string
+	var capturedArgs []any
+	pool := &mockPool{queryFn: func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+		capturedSQL, capturedArgs = sql, args
+		return &mockCouponRows{}, nil
+	}}
+
+	subs, err := NewWebhookRepositoryWithPool(pool).ListForEvent(context.Background(), model.CouponEventDisabled)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.WebhookSubscription{}, subs)
+	assert.Contains(t, capturedSQL, "WHERE $1 = ANY(events)")
+	assert.Equal(t, []any{model.CouponEventDisabled}, capturedArgs)
+}
+
+func TestWebhookRepository_Get_NotFound(t *testing.T) {
+	pool := &mockPool{queryRowFn: func(ctx context.Context, sql string, args ...any) pgx.Row {
+		return &mockRow{scanFn: func(dest ...any) error { return pgx.ErrNoRows }}
+	}}
+
+	sub, err := NewWebhookRepositoryWithPool(pool).Get(context.Background(), 7)
+
+	require.NoError(t, err)
+	assert.Nil(t, sub)
+}
+
+func TestWebhookRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		err  error
+		want error
+	}{
+		{"deleted", "DELETE 1", nil, nil},
+		{"not found", "DELETE 0", nil, apperr.ErrWebhookNotFound},
+		{"database error", "", errors.New("connection reset"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &mockPool{execFn: func(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+				return pgconn.NewCommandTag(tt.tag), tt.err
+			}}
+
+			err := NewWebhookRepositoryWithPool(pool).Delete(context.Background(), 7)
+
+			switch {
+			case tt.err != nil:
+				assert.ErrorIs(t, err, tt.err)
+			case tt.want != nil:
+				assert.ErrorIs(t, err, tt.want)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
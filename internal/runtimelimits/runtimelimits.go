@@ -0,0 +1,97 @@
+// Package runtimelimits fits the Go runtime to the CPU and memory limits of the
+// container it runs in. Since Go 1.25 the runtime already caps GOMAXPROCS at the
+// cgroup CPU limit; the memory limit is left unset, so the garbage collector lets the
+// heap grow until the container is OOM-killed. Apply sets it from the cgroup memory
+// limit instead.
+package runtimelimits
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Where the effective limits come from, as reported in Limits.
+const (
+	SourceEnv     = "env"     // GOMAXPROCS or GOMEMLIMIT is set
+	SourceRuntime = "runtime" // The Go runtime's default: the CPU count, capped at the cgroup CPU limit
+	SourceCgroup  = "cgroup"  // Apply derived the memory limit from the cgroup memory limit
+	SourceNone    = "none"    // No memory limit
+)
+
+// cgroupRoot is where cgroup file systems are mounted.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// Limits are the effective runtime limits.
+type Limits struct {
+	GOMAXPROCS        int    `json:"gomaxprocs"`
+	GOMAXPROCSSource  string `json:"gomaxprocs_source"` // SourceEnv or SourceRuntime
+	NumCPU            int    `json:"num_cpu"`           // CPUs the process may run on, ignoring CPU quotas
+	MemoryLimit       int64  `json:"memory_limit"`      // Bytes; 0 for none
+	MemoryLimitSource string `json:"memory_limit_source"`
+}
+
+// Apply sets the runtime memory limit to ratio of the cgroup memory limit, unless
+// GOMEMLIMIT is set, ratio is 0 or there is no cgroup memory limit, and returns the
+// effective limits.
+func Apply(ratio float64) Limits {
+	return apply(cgroupRoot, ratio)
+}
+
+func apply(root string, ratio float64) Limits {
+	if _, ok := os.LookupEnv("GOMEMLIMIT"); !ok && ratio > 0 {
+		if limit, ok := cgroupMemoryLimit(root); ok {
+			debug.SetMemoryLimit(int64(float64(limit) * ratio))
+		}
+	}
+	return Current()
+}
+
+// Current returns the effective limits. GOMAXPROCS may change while the process runs, as
+// the runtime follows changes of the cgroup CPU limit. A memory limit not set by
+// GOMEMLIMIT is reported as set by Apply from the cgroup.
+func Current() Limits {
+	limits := Limits{
+		GOMAXPROCS:        runtime.GOMAXPROCS(0),
+		GOMAXPROCSSource:  SourceRuntime,
+		NumCPU:            runtime.NumCPU(),
+		MemoryLimitSource: SourceNone,
+	}
+	if _, ok := os.LookupEnv("GOMAXPROCS"); ok {
+		limits.GOMAXPROCSSource = SourceEnv
+	}
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		limits.MemoryLimit = limit
+		limits.MemoryLimitSource = SourceCgroup
+		if _, ok := os.LookupEnv("GOMEMLIMIT"); ok {
+			limits.MemoryLimitSource = SourceEnv
+		}
+	}
+	return limits
+}
+
+// cgroupMemoryLimit returns the memory limit of the process's cgroup under root, from
+// memory.max on cgroup v2 or memory/memory.limit_in_bytes on v1, and whether it has one.
+func cgroupMemoryLimit(root string) (int64, bool) {
+	for _, path := range []string{"memory.max", filepath.Join("memory", "memory.limit_in_bytes")} {
+		b, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			continue
+		}
+		value := strings.TrimSpace(string(b))
+		if value == "max" {
+			return 0, false
+		}
+		limit, err := strconv.ParseInt(value, 10, 64)
+		// v1 reports no limit as a huge page-aligned number
+		if err != nil || limit <= 0 || limit >= math.MaxInt64/2 {
+			return 0, false
+		}
+		return limit, true
+	}
+	return 0, false
+}
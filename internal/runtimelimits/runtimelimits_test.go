@@ -0,0 +1,78 @@
+package runtimelimits
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cgroupDir returns a cgroup root holding files, by path.
+func cgroupDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return root
+}
+
+func TestCgroupMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		limit int64
+		ok    bool
+	}{
+		{"v2", map[string]string{"memory.max": "536870912\n"}, 536870912, true},
+		{"v2 unlimited", map[string]string{"memory.max": "max\n"}, 0, false},
+		{"v1", map[string]string{"memory/memory.limit_in_bytes": "268435456\n"}, 268435456, true},
+		{"v1 unlimited", map[string]string{"memory/memory.limit_in_bytes": "9223372036854771712\n"}, 0, false},
+		{"no cgroup", nil, 0, false},
+		{"malformed", map[string]string{"memory.max": "lots"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, ok := cgroupMemoryLimit(cgroupDir(t, tt.files))
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.limit, limit)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	previous := debug.SetMemoryLimit(math.MaxInt64)
+	t.Cleanup(func() { debug.SetMemoryLimit(previous) })
+	root := cgroupDir(t, map[string]string{"memory.max": "1000000000"})
+
+	limits := apply(root, 0)
+	assert.Equal(t, SourceNone, limits.MemoryLimitSource, "a ratio of 0 leaves the limit unset")
+	assert.Zero(t, limits.MemoryLimit)
+
+	limits = apply(root, 0.9)
+	assert.Equal(t, SourceCgroup, limits.MemoryLimitSource)
+	assert.Equal(t, int64(900000000), limits.MemoryLimit)
+	assert.Equal(t, int64(900000000), debug.SetMemoryLimit(-1))
+	assert.Positive(t, limits.GOMAXPROCS)
+	assert.Positive(t, limits.NumCPU)
+}
+
+func TestApply_GOMEMLIMIT(t *testing.T) {
+	previous := debug.SetMemoryLimit(math.MaxInt64)
+	t.Cleanup(func() { debug.SetMemoryLimit(previous) })
+	t.Setenv("GOMEMLIMIT", "off")
+	root := cgroupDir(t, map[string]string{"memory.max": "1000000000"})
+
+	limits := apply(root, 0.9)
+
+	assert.Equal(t, SourceNone, limits.MemoryLimitSource, "GOMEMLIMIT=off is not overridden")
+	assert.Equal(t, int64(math.MaxInt64), debug.SetMemoryLimit(-1))
+}
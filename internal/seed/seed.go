@@ -0,0 +1,48 @@
+// Package seed loads the coupon manifest named by SEED_FILE, which is applied when the
+// server starts so demo environments and preview deployments boot with their coupons.
+package seed
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/validator"
+)
+
+// isYAML reports whether path names a YAML manifest (.yaml or .yml); any other file
+// is read as JSON.
+func isYAML(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// Load reads the manifest in the file at path, as YAML or JSON by its extension, and
+// validates it like POST /api/admin/apply does.
+func Load(path string) (*model.Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read seed manifest: %w", err)
+	}
+	return Parse(data, isYAML(path))
+}
+
+// Parse decodes and validates a JSON or YAML seed manifest.
+func Parse(data []byte, yamlData bool) (*model.Manifest, error) {
+	m, err := model.ParseManifest(data, yamlData)
+	if err != nil {
+		return nil, fmt.Errorf("decode seed manifest: %w", err)
+	}
+	if err := validator.New().Struct(m); err != nil {
+		return nil, fmt.Errorf("invalid seed manifest: %w", err)
+	}
+	if name := m.DuplicateName(); name != "" {
+		return nil, fmt.Errorf("invalid seed manifest: duplicate coupon name %s", name)
+	}
+	return m, nil
+}
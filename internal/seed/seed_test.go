@@ -0,0 +1,60 @@
+package seed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"coupons.json": `{"coupons": [{"name": "WELCOME", "amount": 100, "tags": ["demo"]}]}`,
+		"coupons.yaml": "coupons:\n  - name: WELCOME\n    amount: 100\n    tags: [demo]\n",
+		"coupons.YML":  "coupons:\n  - {name: WELCOME, amount: 100, tags: [demo]}\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			m, err := Load(path)
+
+			require.NoError(t, err)
+			require.Len(t, m.Coupons, 1)
+			assert.Equal(t, "WELCOME", m.Coupons[0].Name)
+			assert.Equal(t, 100, *m.Coupons[0].Amount)
+			assert.Equal(t, []string{"demo"}, m.Coupons[0].Tags)
+		})
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "read seed manifest")
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		yamlData bool
+		want     string
+	}{
+		{"malformed json", `{"coupons": [`, false, "decode seed manifest"},
+		{"malformed yaml", "coupons: [", true, "decode seed manifest"},
+		{"yaml read as json", "coupons: []", false, "decode seed manifest"},
+		{"invalid coupon", `{"coupons": [{"name": "", "amount": 1}]}`, false, "invalid seed manifest"},
+		{"duplicate name", `{"coupons": [{"name": "A", "amount": 1}, {"name": "A", "amount": 2}]}`, false,
+			"invalid seed manifest: duplicate coupon name A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data), tt.yamlData)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
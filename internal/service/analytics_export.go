@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/objstore"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/parquet"
+)
+
+// analyticsExportDelay is how long after a day ends its claims are exported, so claims
+// whose transactions began before midnight have committed.
+const analyticsExportDelay = 10 * time.Minute
+
+// Columns of the analytics export's Parquet files.
+var (
+	analyticsCouponColumns = []parquet.Column{
+		{Name: "name", Type: parquet.String},
+		{Name: "amount", Type: parquet.Int64},
+		{Name: "remaining_amount", Type: parquet.Int64},
+		{Name: "claims", Type: parquet.Int64},
+		{Name: "unlimited", Type: parquet.Bool},
+		{Name: "disabled", Type: parquet.Bool},
+		{Name: "visibility", Type: parquet.String},
+		{Name: "parent", Type: parquet.String, Optional: true},
+		{Name: "created_at", Type: parquet.Timestamp},
+		{Name: "snapshot_at", Type: parquet.Timestamp},
+	}
+	analyticsClaimColumns = []parquet.Column{
+		{Name: "coupon_name", Type: parquet.String},
+		{Name: "user_id", Type: parquet.String},
+		{Name: "claim_sequence", Type: parquet.Int64},
+		{Name: "channel", Type: parquet.String, Optional: true},
+		{Name: "region", Type: parquet.String, Optional: true},
+		{Name: "tier", Type: parquet.String, Optional: true},
+		{Name: "claimed_at", Type: parquet.Timestamp},
+	}
+)
+
+// AnalyticsExportStats is a snapshot of analytics export counters.
+type AnalyticsExportStats struct {
+	Passes     int64 `json:"passes"`      // Completed export passes
+	PassErrors int64 `json:"pass_errors"` // Failed passes (retried by the next one)
+	Days       int64 `json:"days"`        // Days exported
+	Coupons    int64 `json:"coupons"`     // Coupon rows written
+	Claims     int64 `json:"claims"`      // Claim rows written
+	LastPass   int64 `json:"last_pass"`   // Unix time of the last completed pass; 0 before the first
+}
+
+// AnalyticsExporter writes daily snapshots of coupons and claims as Parquet files to an
+// object store, for the analytics warehouse to load instead of querying the production
+// database. Each UTC day, once over, gets two objects under the exporter's prefix:
+//
+//	<prefix>/coupons/date=2026-10-15/coupons.parquet  every coupon, as of the export
+//	<prefix>/claims/date=2026-10-15/claims.parquet    the claims made that day
+//
+// The claims object is written last and marks the day as exported. Days missed while no
+// instance ran are exported by the next pass, up to its lookback; instances may export
+// the same day concurrently, the last upload winning.
+type AnalyticsExporter struct {
+	repo     ports.AnalyticsExportRepository
+	store    objstore.Store
+	prefix   string
+	lookback int // Days before today checked by each pass
+	now      func() time.Time
+	jobGate  JobGate // nil when every tick exports
+
+	passes     atomic.Int64
+	passErrors atomic.Int64
+	days       atomic.Int64
+	coupons    atomic.Int64
+	claims     atomic.Int64
+	lastPass   atomic.Int64
+}
+
+// NewAnalyticsExporter creates an AnalyticsExporter reading from repo and writing to
+// store under prefix (e.g. "production"), which checks the last lookback days.
+func NewAnalyticsExporter(repo ports.AnalyticsExportRepository, store objstore.Store, prefix string, lookback int) *AnalyticsExporter {
+	return &AnalyticsExporter{repo: repo, store: store, prefix: prefix, lookback: lookback, now: time.Now}
+}
+
+// Stats returns the export counters.
+func (e *AnalyticsExporter) Stats() AnalyticsExportStats {
+	return AnalyticsExportStats{
+		Passes:     e.passes.Load(),
+		PassErrors: e.passErrors.Load(),
+		Days:       e.days.Load(),
+		Coupons:    e.coupons.Load(),
+		Claims:     e.claims.Load(),
+		LastPass:   e.lastPass.Load(),
+	}
+}
+
+// analyticsKey returns the key of the object of table for day.
+func (e *AnalyticsExporter) analyticsKey(table string, day time.Time) string {
+	return path.Join(e.prefix, table, "date="+day.Format(time.DateOnly), table+".parquet")
+}
+
+// Export exports every day of the lookback not exported yet, oldest first.
+func (e *AnalyticsExporter) Export(ctx context.Context) error {
+	if err := e.export(ctx); err != nil {
+		e.passErrors.Add(1)
+		return err
+	}
+	e.passes.Add(1)
+	e.lastPass.Store(time.Now().Unix())
+	return nil
+}
+
+func (e *AnalyticsExporter) export(ctx context.Context) error {
+	now := e.now().UTC().Add(-analyticsExportDelay)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for i := e.lookback; i >= 1; i-- {
+		day := today.AddDate(0, 0, -i)
+		done, err := e.store.Exists(ctx, e.analyticsKey("claims", day))
+		if err != nil {
+			return err
+		}
+		if done {
+			continue
+		}
+		if err := e.ExportDay(ctx, day); err != nil {
+			return fmt.Errorf("export %s: %w", day.Format(time.DateOnly), err)
+		}
+	}
+	return nil
+}
+
+// ExportDay writes the coupons and the claims of the UTC day starting at day, replacing
+// any objects of the day already written.
+func (e *AnalyticsExporter) ExportDay(ctx context.Context, day time.Time) error {
+	snapshotAt := e.now()
+	coupons, err := e.upload(ctx, e.analyticsKey("coupons", day), analyticsCouponColumns, func(w *parquet.Writer) error {
+		return e.repo.ExportCoupons(ctx, func(c *model.Coupon) error {
+			return w.Write(c.Name, c.Amount, c.RemainingAmount, c.ClaimSequence, c.Unlimited, c.Disabled,
+				c.Visibility, optionalString(c.Parent), c.CreatedAt, snapshotAt)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	claims, err := e.upload(ctx, e.analyticsKey("claims", day), analyticsClaimColumns, func(w *parquet.Writer) error {
+		return e.repo.ExportClaims(ctx, day, day.AddDate(0, 0, 1), func(c *model.Claim) error {
+			return w.Write(c.CouponName, c.UserID, c.Sequence, optionalString(c.Channel),
+				optionalString(c.Region), optionalString(c.Tier), c.CreatedAt)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	e.days.Add(1)
+	e.coupons.Add(coupons)
+	e.claims.Add(claims)
+	log.Info().Str("day", day.Format(time.DateOnly)).Int64("coupons", coupons).Int64("claims", claims).
+		Msg("analytics exported")
+	return nil
+}
+
+// upload writes the rows written by fill as a Parquet file of columns to a temporary
+// file, then puts it at key, and returns how many rows it has.
+func (e *AnalyticsExporter) upload(ctx context.Context, key string, columns []parquet.Column, fill func(*parquet.Writer) error) (int64, error) {
+	file, err := os.CreateTemp("", "analytics-*.parquet")
+	if err != nil {
+		return 0, fmt.Errorf("create %s: %w", key, err)
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	w, err := parquet.NewWriter(file, columns)
+	if err != nil {
+		return 0, err
+	}
+	if err := fill(w); err != nil {
+		return 0, fmt.Errorf("write %s: %w", key, err)
+	}
+	if err := w.Close(); err != nil {
+		return 0, fmt.Errorf("write %s: %w", key, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("write %s: %w", key, err)
+	}
+	if err := e.store.Put(ctx, key, file); err != nil {
+		return 0, err
+	}
+	return w.Rows(), nil
+}
+
+// optionalString returns nil for an empty s, written as null.
+func optionalString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Run exports now and then every interval until ctx is cancelled, skipping the passes
+// the job gate holds back. A skipped day is exported by a later pass within the lookback.
+func (e *AnalyticsExporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if allowHeavyJob(ctx, e.jobGate, JobAnalyticsExport) {
+			if err := e.Export(ctx); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Msg("analytics export failed")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
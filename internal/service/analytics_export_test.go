@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/objstore"
+)
+
+// analyticsRepo returns a repository of coupons, and of claims made at claimedAt.
+func analyticsRepo(coupons []model.Coupon, claimedAt ...time.Time) *mocks.AnalyticsExportRepositoryMock {
+	return &mocks.AnalyticsExportRepositoryMock{
+		ExportCouponsFunc: func(ctx context.Context, fn func(*model.Coupon) error) error {
+			for i := range coupons {
+				if err := fn(&coupons[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		ExportClaimsFunc: func(ctx context.Context, from, to time.Time, fn func(*model.Claim) error) error {
+			for i, at := range claimedAt {
+				if !at.Before(from) && at.Before(to) {
+					claim := &model.Claim{CouponName: "PROMO", UserID: "user", Sequence: i + 1, CreatedAt: at}
+					if err := fn(claim); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func TestAnalyticsExporter_Export(t *testing.T) {
+	dir := t.TempDir()
+	repo := analyticsRepo(
+		[]model.Coupon{{Name: "PROMO", Amount: 10, Visibility: model.VisibilityPublic}},
+		time.Date(2026, 10, 14, 23, 59, 0, 0, time.UTC),
+		time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 16, 0, 5, 0, 0, time.UTC),
+	)
+	exporter := NewAnalyticsExporter(repo, objstore.NewLocal(dir), "staging", 2)
+	exporter.now = func() time.Time { return time.Date(2026, 10, 16, 1, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, exporter.Export(context.Background()))
+
+	for _, key := range []string{
+		"staging/coupons/date=2026-10-14/coupons.parquet",
+		"staging/claims/date=2026-10-14/claims.parquet",
+		"staging/coupons/date=2026-10-15/coupons.parquet",
+		"staging/claims/date=2026-10-15/claims.parquet",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(key)))
+		require.NoError(t, err, key)
+		assert.True(t, strings.HasPrefix(string(data), "PAR1"), key)
+	}
+	stats := exporter.Stats()
+	assert.Equal(t, int64(1), stats.Passes)
+	assert.Equal(t, int64(2), stats.Days)
+	assert.Equal(t, int64(2), stats.Coupons)
+	assert.Equal(t, int64(3), stats.Claims, "claims of today are not exported yet")
+
+	require.NoError(t, exporter.Export(context.Background()))
+	assert.Equal(t, int64(2), exporter.Stats().Days, "exported days are skipped")
+	assert.Len(t, repo.ExportClaimsCalls(), 2)
+}
+
+func TestAnalyticsExporter_Export_WaitsForDayToSettle(t *testing.T) {
+	repo := analyticsRepo(nil)
+	exporter := NewAnalyticsExporter(repo, objstore.NewLocal(t.TempDir()), "", 1)
+	exporter.now = func() time.Time { return time.Date(2026, 10, 16, 0, 5, 0, 0, time.UTC) }
+
+	require.NoError(t, exporter.Export(context.Background()))
+
+	require.Len(t, repo.ExportClaimsCalls(), 1)
+	assert.Equal(t, time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), repo.ExportClaimsCalls()[0].From,
+		"2026-10-15 ended less than analyticsExportDelay ago")
+}
+
+// failingStore is an object store whose uploads fail.
+type failingStore struct{ objstore.Store }
+
+func (failingStore) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	return errors.New("access denied")
+}
+
+func TestAnalyticsExporter_Export_UploadFails(t *testing.T) {
+	exporter := NewAnalyticsExporter(analyticsRepo(nil), failingStore{objstore.NewLocal(t.TempDir())}, "prod", 1)
+
+	err := exporter.Export(context.Background())
+
+	assert.ErrorContains(t, err, "access denied")
+	assert.Equal(t, int64(1), exporter.Stats().PassErrors)
+	assert.Zero(t, exporter.Stats().Days)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// SetCampaignCaps enables campaign claim caps: budgets of claims across the coupons
+// tagged with a campaign. A claim locks the caps of its coupon's tags after the coupon
+// row, in campaign order, and is rejected with apperr.ErrCampaignCapReached when one of them
+// is used up, however much stock the coupon has left. Claims of coupons without capped
+// tags take one extra read. A nil repo disables them.
+func (s *CouponService) SetCampaignCaps(repo ports.CampaignCapRepository) {
+	s.caps = repo
+}
+
+// SetCampaignCap creates campaign's claim cap or changes it, keeping the claims already
+// counted against it. A cap below them stops further claims.
+// Returns apperr.ErrInvalidRequest if claimCap is negative.
+func (s *CouponService) SetCampaignCap(ctx context.Context, campaign string, claimCap int) (*model.CampaignCap, error) {
+	if claimCap < 0 {
+		return nil, apperr.ErrInvalidRequest
+	}
+	return s.caps.Set(ctx, campaign, claimCap)
+}
+
+// CampaignCap returns campaign's claim cap.
+// Returns apperr.ErrCampaignCapNotFound if it has none.
+func (s *CouponService) CampaignCap(ctx context.Context, campaign string) (*model.CampaignCap, error) {
+	c, err := s.caps.Get(ctx, campaign)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, apperr.ErrCampaignCapNotFound
+	}
+	return c, nil
+}
+
+// DeleteCampaignCap removes campaign's claim cap.
+// Returns apperr.ErrCampaignCapNotFound if it has none.
+func (s *CouponService) DeleteCampaignCap(ctx context.Context, campaign string) error {
+	return s.caps.Delete(ctx, campaign)
+}
+
+// checkCampaignCaps locks the caps of tags within tx and returns the capped ones.
+// Returns apperr.ErrCampaignCapReached if one of them has no claims left.
+func (s *CouponService) checkCampaignCaps(ctx context.Context, tx database.TxQuerier, tags []string) ([]string, error) {
+	if s.caps == nil || len(tags) == 0 {
+		return nil, nil
+	}
+	caps, err := s.caps.LockCaps(ctx, tx, tags)
+	if err != nil {
+		return nil, fmt.Errorf("lock campaign caps: %w", err)
+	}
+	capped := make([]string, 0, len(caps))
+	for _, c := range caps {
+		if c.Claimed >= c.ClaimCap {
+			return nil, apperr.ErrCampaignCapReached
+		}
+		capped = append(capped, c.Campaign)
+	}
+	return capped, nil
+}
+
+// countImportedClaims counts imported claims per campaign against the campaigns' caps
+// within tx, in campaign order. Historical claims are trusted, so caps do not reject
+// them; they are counted once every coupon of the chunk is locked, keeping the lock
+// order of live claims (coupons, then campaigns).
+func (s *CouponService) countImportedClaims(ctx context.Context, tx database.TxQuerier, counts map[string]int) error {
+	if s.caps == nil {
+		return nil
+	}
+	campaigns := make([]string, 0, len(counts))
+	for campaign, n := range counts {
+		if n > 0 {
+			campaigns = append(campaigns, campaign)
+		}
+	}
+	slices.Sort(campaigns)
+	for _, campaign := range campaigns {
+		if err := s.caps.AddClaims(ctx, tx, []string{campaign}, counts[campaign]); err != nil {
+			return fmt.Errorf("count imported claims: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// cappedClaimService returns a service claiming a coupon tagged summer and vip, where
+// summer's cap has claimed of claimCap claims used and vip is uncapped.
+func cappedClaimService(claimCap, claimed int, inserts *int, counted *[]string) *CouponService {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 50, Tags: []string{"vip", "summer"}}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			*inserts++
+			return nil
+		},
+	}
+	caps := &mocks.CampaignCapRepositoryMock{
+		LockCapsFunc: func(ctx context.Context, tx database.TxQuerier, campaigns []string) ([]model.CampaignCap, error) {
+			return []model.CampaignCap{{Campaign: "summer", ClaimCap: claimCap, Claimed: claimed}}, nil
+		},
+		AddClaimsFunc: func(ctx context.Context, tx database.TxQuerier, campaigns []string, n int) error {
+			*counted = append(*counted, fmt.Sprintf("%v+%d", campaigns, n))
+			return nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+	svc.SetCampaignCaps(caps)
+	return svc
+}
+
+func TestCouponService_ClaimCoupon_CampaignCaps(t *testing.T) {
+	var inserts int
+	var counted []string
+	svc := cappedClaimService(10, 9, &inserts, &counted)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserts)
+	assert.Equal(t, []string{"[summer]+1"}, counted, "the claim counts against capped campaigns only")
+}
+
+func TestCouponService_ClaimCoupon_CampaignCapReached(t *testing.T) {
+	var inserts int
+	var counted []string
+	svc := cappedClaimService(10, 10, &inserts, &counted)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+
+	assert.ErrorIs(t, err, apperr.ErrCampaignCapReached)
+	assert.Zero(t, inserts, "the coupon's stock is not used")
+	assert.Empty(t, counted)
+}
+
+func TestCouponService_ImportClaims_CountsCampaignCaps(t *testing.T) {
+	f := newImportFixture(
+		&model.Coupon{Name: "A", Amount: 10, RemainingAmount: 10, Tags: []string{"summer", "vip"}},
+		&model.Coupon{Name: "B", Amount: 10, RemainingAmount: 10, Tags: []string{"summer"}},
+	)
+	var counted []string
+	caps := &mocks.CampaignCapRepositoryMock{
+		AddClaimsFunc: func(ctx context.Context, tx database.TxQuerier, campaigns []string, n int) error {
+			counted = append(counted, fmt.Sprintf("%v+%d", campaigns, n))
+			return nil
+		},
+	}
+	svc := f.service()
+	svc.SetCampaignCaps(caps) // LockCaps is unset: imports are not limited by caps
+
+	report, err := svc.ImportClaims(context.Background(), &model.ClaimImportRequest{Claims: []model.ImportClaim{
+		{UserID: "u1", CouponName: "A"},
+		{UserID: "u1", CouponName: "B"},
+		{UserID: "u2", CouponName: "B"},
+	}})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Imported)
+	assert.Equal(t, []string{"[summer]+3", "[vip]+1"}, counted, "counted per campaign, in campaign order")
+}
+
+func TestCouponService_CampaignCap(t *testing.T) {
+	caps := &mocks.CampaignCapRepositoryMock{
+		GetFunc: func(ctx context.Context, campaign string) (*model.CampaignCap, error) { return nil, nil },
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetCampaignCaps(caps)
+
+	_, err := svc.CampaignCap(context.Background(), "summer")
+	assert.ErrorIs(t, err, apperr.ErrCampaignCapNotFound)
+
+	_, err = svc.SetCampaignCap(context.Background(), "summer", -1)
+	assert.ErrorIs(t, err, apperr.ErrInvalidRequest)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// allocateChannelQuotas splits amount across channels by percentage.
+// Each channel receives floor(amount*pct/100); the rounding remainder is handed out
+// one unit at a time to the largest shares first (ties broken by name), so the
+// quotas always sum to amount. Returns apperr.ErrInvalidChannelQuotas unless the
+// percentages sum to exactly 100.
+func allocateChannelQuotas(amount int, percentages map[string]int) ([]model.ChannelQuota, error) {
+	if len(percentages) == 0 {
+		return nil, nil
+	}
+
+	total := 0
+	for _, pct := range percentages {
+		total += pct
+	}
+	if total != 100 {
+		return nil, apperr.ErrInvalidChannelQuotas
+	}
+
+	names := make([]string, 0, len(percentages))
+	for name := range percentages {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if percentages[names[i]] != percentages[names[j]] {
+			return percentages[names[i]] > percentages[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	quotas := make([]model.ChannelQuota, len(names))
+	allocated := 0
+	for i, name := range names {
+		share := amount * percentages[name] / 100
+		quotas[i] = model.ChannelQuota{Channel: name, Quota: share, Remaining: share}
+		allocated += share
+	}
+	for i := 0; allocated < amount; i = (i + 1) % len(quotas) {
+		quotas[i].Quota++
+		quotas[i].Remaining++
+		allocated++
+	}
+
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].Channel < quotas[j].Channel })
+	return quotas, nil
+}
+
+// pickChannelPartition decides which channel partition a claim decrements.
+// Returns "" for coupons without partitions. For partitioned coupons the claim's own
+// channel is used while it has stock; once now reaches OverflowAt, an exhausted
+// channel borrows from the partition with the most leftover stock.
+// Must be evaluated against coupon state read under the coupon row lock.
+func pickChannelPartition(coupon *model.Coupon, channel string, now time.Time) (string, error) {
+	if len(coupon.Channels) == 0 {
+		return "", nil
+	}
+	if channel == "" {
+		return "", apperr.ErrChannelRequired
+	}
+
+	var own *model.ChannelQuota
+	for i := range coupon.Channels {
+		if coupon.Channels[i].Channel == channel {
+			own = &coupon.Channels[i]
+			break
+		}
+	}
+	if own == nil {
+		return "", apperr.ErrUnknownChannel
+	}
+	if own.Remaining > 0 {
+		return own.Channel, nil
+	}
+
+	if coupon.OverflowAt == nil || now.Before(*coupon.OverflowAt) {
+		return "", apperr.ErrNoStock
+	}
+
+	var donor *model.ChannelQuota
+	for i := range coupon.Channels {
+		q := &coupon.Channels[i]
+		if q.Remaining > 0 && (donor == nil || q.Remaining > donor.Remaining) {
+			donor = q
+		}
+	}
+	if donor == nil {
+		return "", apperr.ErrNoStock
+	}
+	return donor.Channel, nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestAllocateChannelQuotas_ExactSplit(t *testing.T) {
+	quotas, err := allocateChannelQuotas(100, map[string]int{"app": 70, "web": 30})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ChannelQuota{
+		{Channel: "app", Quota: 70, Remaining: 70},
+		{Channel: "web", Quota: 30, Remaining: 30},
+	}, quotas)
+}
+
+func TestAllocateChannelQuotas_RemainderGoesToLargestShare(t *testing.T) {
+	// 10 * 0.34 = 3.4, 10 * 0.33 = 3.3 (x2) -> 3+3+3 = 9, remainder 1 goes to "a" (34%)
+	quotas, err := allocateChannelQuotas(10, map[string]int{"a": 34, "b": 33, "c": 33})
+
+	require.NoError(t, err)
+	total := 0
+	for _, q := range quotas {
+		total += q.Quota
+	}
+	assert.Equal(t, 10, total, "quotas must always sum to amount")
+	assert.Equal(t, 4, quotas[0].Quota)
+	assert.Equal(t, 3, quotas[1].Quota)
+	assert.Equal(t, 3, quotas[2].Quota)
+}
+
+func TestAllocateChannelQuotas_SmallAmount(t *testing.T) {
+	quotas, err := allocateChannelQuotas(1, map[string]int{"app": 50, "web": 50})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ChannelQuota{
+		{Channel: "app", Quota: 1, Remaining: 1},
+		{Channel: "web", Quota: 0, Remaining: 0},
+	}, quotas, "ties are broken by channel name")
+}
+
+func TestAllocateChannelQuotas_NoChannels(t *testing.T) {
+	quotas, err := allocateChannelQuotas(100, nil)
+
+	require.NoError(t, err)
+	assert.Nil(t, quotas)
+}
+
+func TestAllocateChannelQuotas_InvalidSum(t *testing.T) {
+	for _, pct := range []map[string]int{
+		{"app": 70, "web": 20},
+		{"app": 70, "web": 40},
+	} {
+		_, err := allocateChannelQuotas(100, pct)
+		assert.ErrorIs(t, err, apperr.ErrInvalidChannelQuotas)
+	}
+}
+
+func partitionedCoupon(overflowAt *time.Time, quotas ...model.ChannelQuota) *model.Coupon {
+	return &model.Coupon{Name: "PROMO", Amount: 10, RemainingAmount: 10, Channels: quotas, OverflowAt: overflowAt}
+}
+
+func TestPickChannelPartition_Unpartitioned(t *testing.T) {
+	partition, err := pickChannelPartition(&model.Coupon{Name: "PROMO"}, "app", time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, "", partition, "unpartitioned coupons ignore the channel")
+}
+
+func TestPickChannelPartition_OwnPartition(t *testing.T) {
+	coupon := partitionedCoupon(nil,
+		model.ChannelQuota{Channel: "app", Quota: 7, Remaining: 2},
+		model.ChannelQuota{Channel: "web", Quota: 3, Remaining: 3},
+	)
+
+	partition, err := pickChannelPartition(coupon, "app", time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, "app", partition)
+}
+
+func TestPickChannelPartition_ChannelErrors(t *testing.T) {
+	coupon := partitionedCoupon(nil, model.ChannelQuota{Channel: "app", Quota: 10, Remaining: 10})
+
+	_, err := pickChannelPartition(coupon, "", time.Now())
+	assert.ErrorIs(t, err, apperr.ErrChannelRequired)
+
+	_, err = pickChannelPartition(coupon, "kiosk", time.Now())
+	assert.ErrorIs(t, err, apperr.ErrUnknownChannel)
+}
+
+func TestPickChannelPartition_ExhaustedBeforeOverflow(t *testing.T) {
+	overflowAt := time.Now().Add(time.Hour)
+	coupon := partitionedCoupon(&overflowAt,
+		model.ChannelQuota{Channel: "app", Quota: 7, Remaining: 0},
+		model.ChannelQuota{Channel: "web", Quota: 3, Remaining: 3},
+	)
+
+	_, err := pickChannelPartition(coupon, "app", time.Now())
+
+	assert.ErrorIs(t, err, apperr.ErrNoStock)
+}
+
+func TestPickChannelPartition_ExhaustedWithoutOverflowPolicy(t *testing.T) {
+	coupon := partitionedCoupon(nil,
+		model.ChannelQuota{Channel: "app", Quota: 7, Remaining: 0},
+		model.ChannelQuota{Channel: "web", Quota: 3, Remaining: 3},
+	)
+
+	_, err := pickChannelPartition(coupon, "app", time.Now())
+
+	assert.ErrorIs(t, err, apperr.ErrNoStock)
+}
+
+func TestPickChannelPartition_BorrowsAfterOverflow(t *testing.T) {
+	overflowAt := time.Now().Add(-time.Minute)
+	coupon := partitionedCoupon(&overflowAt,
+		model.ChannelQuota{Channel: "app", Quota: 6, Remaining: 0},
+		model.ChannelQuota{Channel: "kiosk", Quota: 1, Remaining: 1},
+		model.ChannelQuota{Channel: "web", Quota: 3, Remaining: 2},
+	)
+
+	partition, err := pickChannelPartition(coupon, "app", time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, "web", partition, "borrows from the partition with the most leftover stock")
+}
+
+func TestPickChannelPartition_NothingToBorrow(t *testing.T) {
+	overflowAt := time.Now().Add(-time.Minute)
+	coupon := partitionedCoupon(&overflowAt,
+		model.ChannelQuota{Channel: "app", Quota: 5, Remaining: 0},
+		model.ChannelQuota{Channel: "web", Quota: 5, Remaining: 0},
+	)
+
+	_, err := pickChannelPartition(coupon, "app", time.Now())
+
+	assert.ErrorIs(t, err, apperr.ErrNoStock)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// ClaimBudgetStats is a snapshot of claim budget counters.
+type ClaimBudgetStats struct {
+	Aborted int64 `json:"aborted"` // Claims rejected before their transaction began
+}
+
+// SetMinClaimBudget makes claims whose context has less than budget left before its
+// deadline fail with apperr.ErrDeadlineTooShort instead of beginning a transaction. Such a
+// transaction would likely be cancelled mid-flight, after queueing on the coupon's
+// row lock and holding a connection for nothing. Contexts without a deadline are not
+// affected.
+func (s *CouponService) SetMinClaimBudget(budget time.Duration) {
+	s.minClaimBudget = budget
+}
+
+// ClaimBudgetStats returns claim budget counters.
+func (s *CouponService) ClaimBudgetStats() ClaimBudgetStats {
+	return ClaimBudgetStats{Aborted: s.budgetAborts.Load()}
+}
+
+// claimBudgetExceeded reports whether ctx has too little time left to begin a claim.
+func (s *CouponService) claimBudgetExceeded(ctx context.Context) bool {
+	if s.minClaimBudget <= 0 {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) >= s.minClaimBudget {
+		return false
+	}
+	s.budgetAborts.Add(1)
+	return true
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponService_ClaimCoupon_MinBudget(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+	pool := newPool(newTx())
+	svc := NewCouponServiceWithTxBeginner(pool, couponRepo, claimRepo)
+	svc.SetMinClaimBudget(100 * time.Millisecond)
+
+	short, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := svc.ClaimCoupon(short, claimRequest("user_1", "PROMO"))
+	assert.ErrorIs(t, err, apperr.ErrDeadlineTooShort)
+	assert.Empty(t, pool.BeginCalls(), "no transaction is begun")
+
+	long, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = svc.ClaimCoupon(long, claimRequest("user_1", "PROMO"))
+	require.NoError(t, err)
+
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_2", "PROMO"))
+	require.NoError(t, err, "claims without a deadline are not affected")
+
+	assert.Equal(t, ClaimBudgetStats{Aborted: 1}, svc.ClaimBudgetStats())
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// claimConflict returns the error for a repeat claim of req: an
+// *apperr.AlreadyClaimedError telling when the user claimed, or apperr.ErrAlreadyClaimed
+// if their earlier claim cannot be read (the lookup failed, or the claim was since
+// anonymized or erased).
+func (s *CouponService) claimConflict(ctx context.Context, req *model.ClaimCouponRequest) error {
+	claim, err := s.claimRepo.GetClaim(ctx, req.UserID, req.CouponName)
+	if err != nil {
+		log.Warn().
+			Str("error", redact.Error(err, req.UserID, req.CouponName)).
+			Str("coupon_name", redact.Value(req.CouponName)).
+			Msg("failed to read earlier claim of a repeat claim")
+		return apperr.ErrAlreadyClaimed
+	}
+	if claim == nil {
+		return apperr.ErrAlreadyClaimed
+	}
+	return &apperr.AlreadyClaimedError{ClaimedAt: claim.CreatedAt, ClaimSequence: claim.Sequence}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// newDedupService returns a service with deduplication enabled whose claims succeed
+// after waiting for release (closed immediately when nil). inserts counts transactions
+// that reached the claim insert.
+func newDedupService(release <-chan struct{}, inserts *atomic.Int64) *CouponService {
+	sequence := 0
+	var mu sync.Mutex
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			if release != nil {
+				<-release
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 100 - sequence, ClaimSequence: sequence}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sequence++
+			return nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			inserts.Add(1)
+			return nil
+		},
+	}
+	tx := &mocks.TransactorMock{
+		InTxFunc: func(ctx context.Context, fn func(tx database.TxQuerier) error) error { return fn(nil) },
+	}
+	svc := NewCouponServiceWithTransactor(tx, couponRepo, claimRepo)
+	svc.SetClaimDedup(time.Minute, 100)
+	return svc
+}
+
+func TestClaimDedup_CoalescesConcurrentDuplicates(t *testing.T) {
+	release := make(chan struct{})
+	var inserts atomic.Int64
+	svc := newDedupService(release, &inserts)
+
+	const n = 20
+	receipts := make([]*model.ClaimReceipt, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			receipt, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+			assert.NoError(t, err)
+			receipts[i] = receipt
+		}()
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), inserts.Load(), "duplicates must share one transaction")
+	for _, r := range receipts {
+		require.NotNil(t, r)
+		assert.Equal(t, 1, r.ClaimSequence)
+	}
+	// Duplicates that arrived before the claim finished were coalesced, later ones replayed.
+	stats := svc.ClaimDedupStats()
+	assert.Equal(t, int64(n-1), stats.Coalesced+stats.Replayed)
+}
+
+func TestClaimDedup_ReplaysRecentReceipt(t *testing.T) {
+	var inserts atomic.Int64
+	svc := newDedupService(nil, &inserts)
+
+	first, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+	require.NoError(t, err)
+	retry, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+	require.NoError(t, err, "a retry within the window gets the receipt instead of 409")
+
+	assert.Equal(t, first, retry)
+	assert.NotSame(t, first, retry)
+	assert.Equal(t, int64(1), inserts.Load())
+	assert.Equal(t, ClaimDedupStats{Replayed: 1}, svc.ClaimDedupStats())
+}
+
+func TestClaimDedup_KeysOnUserCouponAndChannel(t *testing.T) {
+	var inserts atomic.Int64
+	svc := newDedupService(nil, &inserts)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+	require.NoError(t, err)
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_002", "PROMO_SUPER"))
+	require.NoError(t, err)
+	_, err = svc.ClaimCoupon(context.Background(), &model.ClaimCouponRequest{UserID: "user_001", CouponName: "PROMO_SUPER", Channel: "web"})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), inserts.Load())
+}
+
+func TestClaimDedup_DoesNotRememberFailures(t *testing.T) {
+	calls := 0
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			calls++
+			return &model.Coupon{Name: name, Amount: 1, RemainingAmount: 0}, nil
+		},
+	}
+	tx := &mocks.TransactorMock{
+		InTxFunc: func(ctx context.Context, fn func(tx database.TxQuerier) error) error { return fn(nil) },
+	}
+	svc := NewCouponServiceWithTransactor(tx, couponRepo, &mocks.ClaimRepositoryMock{})
+	svc.SetClaimDedup(time.Minute, 100)
+
+	for range 2 {
+		_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+		assert.ErrorIs(t, err, apperr.ErrNoStock)
+	}
+	assert.Equal(t, 2, calls, "a failed claim is retried, e.g. after a top-up")
+}
+
+func TestClaimDedup_WaiterStopsOnOwnContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var inserts atomic.Int64
+	svc := newDedupService(release, &inserts)
+
+	go func() { _, _ = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER")) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := svc.ClaimCoupon(ctx, claimRequest("user_001", "PROMO_SUPER"))
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClaimDedup_Disabled(t *testing.T) {
+	var inserts atomic.Int64
+	svc := newDedupService(nil, &inserts)
+	svc.SetClaimDedup(0, 100)
+
+	for range 2 {
+		_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(2), inserts.Load(), "the repository mock does not enforce uniqueness")
+	assert.Equal(t, ClaimDedupStats{}, svc.ClaimDedupStats())
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// filterFixture is an in-memory claim store for claimed-filter tests. txs counts
+// claim transactions; checkErr fails HasClaimed.
+type filterFixture struct {
+	claimed  map[string]bool
+	txs      int
+	checks   int
+	checkErr error
+}
+
+func (f *filterFixture) service() *CouponService {
+	f.claimed = map[string]bool{}
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 100}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			key := claimKey(claim.UserID, claim.CouponName)
+			if f.claimed[key] {
+				return apperr.ErrAlreadyClaimed
+			}
+			f.claimed[key] = true
+			return nil
+		},
+		GetClaimFunc: func(ctx context.Context, userID, couponName string) (*model.Claim, error) { return nil, nil },
+		HasClaimedFunc: func(ctx context.Context, userID, couponName string) (bool, error) {
+			f.checks++
+			return f.claimed[claimKey(userID, couponName)], f.checkErr
+		},
+	}
+	tx := &mocks.TransactorMock{
+		InTxFunc: func(ctx context.Context, fn func(tx database.TxQuerier) error) error {
+			f.txs++
+			return fn(nil)
+		},
+	}
+	svc := NewCouponServiceWithTransactor(tx, couponRepo, claimRepo)
+	svc.SetClaimFilter(1000, 0.01)
+	return svc
+}
+
+func TestClaimFilter_RejectsRepeatClaimWithoutTransaction(t *testing.T) {
+	f := &filterFixture{}
+	svc := f.service()
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	require.NoError(t, err)
+	assert.Zero(t, f.checks, "a first claim misses the filter")
+
+	for range 3 {
+		_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+		assert.ErrorIs(t, err, apperr.ErrAlreadyClaimed)
+	}
+
+	assert.Equal(t, 1, f.txs)
+	assert.Equal(t, ClaimFilterStats{Rejected: 3, Entries: 1}, svc.ClaimFilterStats())
+}
+
+func TestClaimFilter_RemembersClaimsRejectedByDatabase(t *testing.T) {
+	f := &filterFixture{}
+	svc := f.service()
+	f.claimed[claimKey("user_001", "PROMO")] = true // Claimed through another instance
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	assert.ErrorIs(t, err, apperr.ErrAlreadyClaimed)
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	assert.ErrorIs(t, err, apperr.ErrAlreadyClaimed)
+
+	assert.Equal(t, 1, f.txs)
+	assert.Equal(t, int64(1), svc.ClaimFilterStats().Rejected)
+}
+
+func TestClaimFilter_FalsePositiveFallsThrough(t *testing.T) {
+	f := &filterFixture{}
+	svc := f.service()
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	require.NoError(t, err)
+	delete(f.claimed, claimKey("user_001", "PROMO")) // E.g. the user's claims were erased
+
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.txs)
+	assert.Equal(t, int64(1), svc.ClaimFilterStats().FalsePositives)
+}
+
+func TestClaimFilter_CheckErrorFallsThrough(t *testing.T) {
+	f := &filterFixture{}
+	svc := f.service()
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	require.NoError(t, err)
+	f.checkErr = errors.New("connection refused")
+
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+
+	assert.ErrorIs(t, err, apperr.ErrAlreadyClaimed, "the transaction still rejects the duplicate")
+	assert.Equal(t, 2, f.txs)
+	assert.Equal(t, int64(1), svc.ClaimFilterStats().Errors)
+}
+
+func TestClaimFilter_ResetsWhenFull(t *testing.T) {
+	f := &filterFixture{}
+	svc := f.service()
+	svc.SetClaimFilter(1, 0.01)
+
+	for _, user := range []string{"user_001", "user_002", "user_003"} {
+		_, err := svc.ClaimCoupon(context.Background(), claimRequest(user, "PROMO"))
+		require.NoError(t, err)
+	}
+
+	stats := svc.ClaimFilterStats()
+	assert.Equal(t, int64(1), stats.Resets)
+	assert.Equal(t, 1, stats.Entries)
+}
+
+func TestClaimFilter_Disabled(t *testing.T) {
+	f := &filterFixture{}
+	svc := f.service()
+	svc.SetClaimFilter(0, 0)
+
+	for range 2 {
+		_, _ = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	}
+
+	assert.Equal(t, 2, f.txs)
+	assert.Zero(t, f.checks)
+	assert.Equal(t, ClaimFilterStats{}, svc.ClaimFilterStats())
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// HighProfileStats is a snapshot of high-profile quota state and counters.
+type HighProfileStats struct {
+	Slots     int   `json:"slots"`     // Connections high-profile claims may hold at once
+	InUse     int   `json:"in_use"`    // Slots taken right now
+	Waiting   int64 `json:"waiting"`   // Claims waiting for a slot right now
+	Coupons   int   `json:"coupons"`   // High-profile coupons known to this instance
+	Admitted  int64 `json:"admitted"`  // Claims that took a slot
+	Waited    int64 `json:"waited"`    // Claims among Admitted that waited for theirs
+	Abandoned int64 `json:"abandoned"` // Claims whose context ended while waiting
+}
+
+// highProfileQuota bounds the claims of high-profile coupons to a share of the claim
+// pool's connections. Which coupons are high-profile is learned from the coupons the
+// claims read (and from changes made through this instance), so no lookup precedes a
+// claim; the first claim an instance sees of a newly marked coupon is not bounded.
+type highProfileQuota struct {
+	slots chan struct{}
+
+	mu      sync.RWMutex
+	coupons map[string]bool // Only high-profile coupons
+
+	waiting   atomic.Int64
+	admitted  atomic.Int64
+	waited    atomic.Int64
+	abandoned atomic.Int64
+}
+
+// SetHighProfileQuota makes claims on high-profile coupons hold at most slots database
+// connections at once, together: the others wait for a slot before beginning their
+// transaction, so a viral coupon cannot take every connection and starve the rest of
+// the catalog. Claims whose context ends while waiting fail with its error.
+func (s *CouponService) SetHighProfileQuota(slots int) {
+	s.quota = &highProfileQuota{slots: make(chan struct{}, max(slots, 1)), coupons: make(map[string]bool)}
+}
+
+// HighProfileStats returns the high-profile quota state and counters.
+func (s *CouponService) HighProfileStats() HighProfileStats {
+	q := s.quota
+	if q == nil {
+		return HighProfileStats{}
+	}
+	q.mu.RLock()
+	coupons := len(q.coupons)
+	q.mu.RUnlock()
+	return HighProfileStats{
+		Slots:     cap(q.slots),
+		InUse:     len(q.slots),
+		Waiting:   q.waiting.Load(),
+		Coupons:   coupons,
+		Admitted:  q.admitted.Load(),
+		Waited:    q.waited.Load(),
+		Abandoned: q.abandoned.Load(),
+	}
+}
+
+// markHighProfile records whether the coupon name is high-profile.
+func (s *CouponService) markHighProfile(name string, highProfile bool) {
+	q := s.quota
+	if q == nil {
+		return
+	}
+	q.mu.RLock()
+	known := q.coupons[name]
+	q.mu.RUnlock()
+	if known == highProfile {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if highProfile {
+		q.coupons[name] = true
+	} else {
+		delete(q.coupons, name)
+	}
+}
+
+// acquireHighProfileSlot waits for a slot when the coupon name is high-profile, and
+// returns the function releasing it.
+func (s *CouponService) acquireHighProfileSlot(ctx context.Context, name string) (func(), error) {
+	q := s.quota
+	if q == nil {
+		return func() {}, nil
+	}
+	q.mu.RLock()
+	highProfile := q.coupons[name]
+	q.mu.RUnlock()
+	if !highProfile {
+		return func() {}, nil
+	}
+
+	select {
+	case q.slots <- struct{}{}:
+	default:
+		q.waiting.Add(1)
+		defer q.waiting.Add(-1)
+		select {
+		case q.slots <- struct{}{}:
+			q.waited.Add(1)
+		case <-ctx.Done():
+			q.abandoned.Add(1)
+			return nil, ctx.Err()
+		}
+	}
+	q.admitted.Add(1)
+	return func() { <-q.slots }, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponService_ClaimCoupon_HighProfileQuota(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10, HighProfile: name == "VIRAL"}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+	pool := newPool(newTx())
+	svc := NewCouponServiceWithTxBeginner(pool, couponRepo, claimRepo)
+	svc.SetHighProfileQuota(1)
+
+	// The first claim learns the coupon is high-profile
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_1", "VIRAL"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, svc.HighProfileStats().Coupons)
+
+	// With the only slot taken, claims on the coupon wait, and the others pass
+	release, err := svc.acquireHighProfileSlot(context.Background(), "VIRAL")
+	require.NoError(t, err)
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = svc.ClaimCoupon(short, claimRequest("user_2", "VIRAL"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_2", "OTHER"))
+	require.NoError(t, err)
+	assert.Len(t, pool.BeginCalls(), 2, "the waiting claim took no connection")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_3", "VIRAL"))
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return svc.HighProfileStats().Waiting == 1 }, time.Second, time.Millisecond)
+	release()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, HighProfileStats{Slots: 1, Coupons: 1, Admitted: 2, Waited: 1, Abandoned: 1}, svc.HighProfileStats())
+}
+
+func TestCouponService_Update_HighProfile(t *testing.T) {
+	var marked *bool
+	couponRepo := &mocks.CouponRepositoryMock{
+		SetHighProfileFunc: func(ctx context.Context, tx database.TxQuerier, name string, highProfile bool) error {
+			marked = &highProfile
+			return nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10, HighProfile: *marked}, nil
+		},
+	}
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), couponRepo, noClaims())
+	svc.SetHighProfileQuota(4)
+
+	highProfile := true
+	resp, err := svc.Update(context.Background(), "VIRAL", &model.UpdateCouponRequest{HighProfile: &highProfile})
+
+	require.NoError(t, err)
+	assert.True(t, resp.HighProfile)
+	assert.Equal(t, 1, svc.HighProfileStats().Coupons, "claims are bounded without waiting to learn it")
+	assert.Empty(t, couponRepo.UpdateTagsCalls())
+
+	highProfile = false
+	_, err = svc.Update(context.Background(), "VIRAL", &model.UpdateCouponRequest{HighProfile: &highProfile})
+	require.NoError(t, err)
+	assert.Zero(t, svc.HighProfileStats().Coupons)
+}
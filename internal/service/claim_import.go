@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// DefaultImportChunkSize is how many claims ImportClaims commits per transaction
+// unless SetImportChunkSize is called.
+const DefaultImportChunkSize = 500
+
+// ClaimImportStats is a snapshot of claim import counters, summed over all imports
+// since startup. Processed/Total of a running import is its progress.
+type ClaimImportStats struct {
+	Running   int64 `json:"running"`
+	Total     int64 `json:"total"`
+	Processed int64 `json:"processed"`
+	Imported  int64 `json:"imported"`
+	Skipped   int64 `json:"skipped"`
+	Rejected  int64 `json:"rejected"`
+}
+
+// claimImportCounters backs ClaimImportStats.
+type claimImportCounters struct {
+	running, total, processed, imported, skipped, rejected atomic.Int64
+}
+
+// SetImportChunkSize sets how many claims ImportClaims commits per transaction.
+func (s *CouponService) SetImportChunkSize(n int) {
+	s.importChunkSize = n
+}
+
+// ClaimImportStats returns claim import counters.
+func (s *CouponService) ClaimImportStats() ClaimImportStats {
+	c := &s.imports
+	return ClaimImportStats{
+		Running:   c.running.Load(),
+		Total:     c.total.Load(),
+		Processed: c.processed.Load(),
+		Imported:  c.imported.Load(),
+		Skipped:   c.skipped.Load(),
+		Rejected:  c.rejected.Load(),
+	}
+}
+
+// ImportClaims inserts historical claims, e.g. when migrating from another promo
+// platform. Each claim goes through the same steps as ClaimCoupon, so remaining stock,
+// channel partitions, claim sequences and tiers stay consistent with live claims, which
+// may continue during the import.
+//
+// Claims are committed in chunks, each in its own transaction. Claims of users who
+// already claimed the coupon are skipped, so an import interrupted by an error can be
+// resumed by sending it again. On error the report covers the chunks committed so far.
+// Claims rejected by business rules (unknown or disabled coupon, no stock, ...) are
+// listed in the report and do not stop the import.
+func (s *CouponService) ImportClaims(ctx context.Context, req *model.ClaimImportRequest) (*model.ClaimImportReport, error) {
+	return s.StreamImportClaims(ctx, req, nil)
+}
+
+// StreamImportClaims is ImportClaims, calling each with the result of every claim, in
+// request order, once its chunk is committed. If each returns an error, e.g. because
+// the client went away, the import stops with that error after the current chunk.
+func (s *CouponService) StreamImportClaims(ctx context.Context, req *model.ClaimImportRequest, each func(model.ClaimImportResult) error) (*model.ClaimImportReport, error) {
+	if req == nil {
+		return nil, apperr.ErrInvalidRequest
+	}
+
+	size := s.importChunkSize
+	if size <= 0 {
+		size = DefaultImportChunkSize
+	}
+
+	report := &model.ClaimImportReport{Total: len(req.Claims), Rejected: []model.ClaimImportRejection{}}
+	s.imports.running.Add(1)
+	defer s.imports.running.Add(-1)
+	s.imports.total.Add(int64(report.Total))
+
+	for start := 0; start < len(req.Claims); start += size {
+		end := min(start+size, len(req.Claims))
+
+		var chunk *model.ClaimImportReport
+		var results []model.ClaimImportResult
+		err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+			var err error
+			chunk, results, err = s.importChunk(ctx, tx, req.Claims[start:end], start)
+			return err
+		})
+		if err != nil {
+			return report, fmt.Errorf("import claims %d-%d: %w", start, end-1, err)
+		}
+
+		report.Processed = end
+		report.Imported += chunk.Imported
+		report.Skipped += chunk.Skipped
+		report.Rejected = append(report.Rejected, chunk.Rejected...)
+		s.imports.processed.Add(int64(end - start))
+		s.imports.imported.Add(int64(chunk.Imported))
+		s.imports.skipped.Add(int64(chunk.Skipped))
+		s.imports.rejected.Add(int64(len(chunk.Rejected)))
+		for _, c := range req.Claims[start:end] {
+			s.invalidate(c.CouponName)
+		}
+
+		log.Info().
+			Int("processed", report.Processed).
+			Int("total", report.Total).
+			Int("imported", report.Imported).
+			Int("skipped", report.Skipped).
+			Int("rejected", len(report.Rejected)).
+			Msg("claim import progress")
+
+		if each != nil {
+			for _, result := range results {
+				if err := each(result); err != nil {
+					return report, fmt.Errorf("stream claim import results: %w", err)
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// importChunk imports claims (starting at index offset of the request) within tx and
+// returns the chunk's report and the result of each claim, in request order. Coupons
+// are locked in name order, so concurrent imports cannot deadlock each other.
+func (s *CouponService) importChunk(ctx context.Context, tx database.TxQuerier, claims []model.ImportClaim, offset int) (*model.ClaimImportReport, []model.ClaimImportResult, error) {
+	byCoupon := make(map[string][]int)
+	for i := range claims {
+		name := claims[i].CouponName
+		byCoupon[name] = append(byCoupon[name], i)
+	}
+	names := make([]string, 0, len(byCoupon))
+	for name := range byCoupon {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	report := &model.ClaimImportReport{}
+	results := make([]model.ClaimImportResult, len(claims))
+	for i := range claims {
+		results[i] = model.ClaimImportResult{Index: offset + i, UserID: claims[i].UserID, CouponName: claims[i].CouponName}
+	}
+	reject := func(i int, reason error) {
+		report.Rejected = append(report.Rejected, model.ClaimImportRejection{
+			Index:      offset + i,
+			UserID:     claims[i].UserID,
+			CouponName: claims[i].CouponName,
+			Reason:     reason.Error(),
+		})
+		results[i].Status = model.ClaimImportRejected
+		results[i].Reason = reason.Error()
+	}
+
+	campaignClaims := map[string]int{}
+	for _, name := range names {
+		indexes := byCoupon[name]
+
+		// Lock the coupon before checking for existing claims, so a live claim cannot
+		// insert one in between (which would abort the transaction on the unique constraint).
+		coupon, err := s.couponRepo.GetCouponForUpdate(ctx, tx, name)
+		if err != nil {
+			if !errors.Is(err, apperr.ErrCouponNotFound) {
+				return nil, nil, fmt.Errorf("get coupon for update: %w", err)
+			}
+			for _, i := range indexes {
+				reject(i, apperr.ErrCouponNotFound)
+			}
+			continue
+		}
+
+		userIDs := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			userIDs = append(userIDs, claims[i].UserID)
+		}
+		claimed, err := s.claimRepo.ClaimedUsers(ctx, tx, name, userIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get claimed users: %w", err)
+		}
+		done := make(map[string]bool, len(indexes))
+		for _, userID := range claimed {
+			done[userID] = true
+		}
+
+		for _, i := range indexes {
+			c := &claims[i]
+			if done[c.UserID] {
+				report.Skipped++
+				results[i].Status = model.ClaimImportSkipped
+				continue
+			}
+
+			var claimedAt time.Time
+			if c.ClaimedAt != nil {
+				claimedAt = *c.ClaimedAt
+			}
+			// Historical claims are trusted: captcha requirements apply to live claims only.
+			req := &model.ClaimCouponRequest{UserID: c.UserID, CouponName: name, Channel: c.Channel, Region: c.Region, CaptchaVerified: true, GrantVerified: true}
+			_, _, err := s.claim(ctx, tx, req, claimedAt, true)
+			switch {
+			case err == nil:
+				report.Imported++
+				results[i].Status = model.ClaimImportImported
+				for _, tag := range coupon.Tags {
+					campaignClaims[tag]++
+				}
+				done[c.UserID] = true
+				if s.claimed != nil {
+					s.claimed.add(c.UserID, name)
+				}
+			case isClaimRejection(err):
+				reject(i, err)
+			default:
+				return nil, nil, err
+			}
+		}
+	}
+	if err := s.countImportedClaims(ctx, tx, campaignClaims); err != nil {
+		return nil, nil, err
+	}
+	return report, results, nil
+}
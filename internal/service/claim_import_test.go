@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// importFixture is an in-memory coupon store for import tests. Each InTx call is a
+// committed chunk unless failChunk names it (1-based).
+type importFixture struct {
+	coupons   map[string]*model.Coupon
+	claims    []model.Claim
+	chunks    int
+	failChunk int
+}
+
+func newImportFixture(coupons ...*model.Coupon) *importFixture {
+	f := &importFixture{coupons: map[string]*model.Coupon{}}
+	for _, c := range coupons {
+		f.coupons[c.Name] = c
+	}
+	return f
+}
+
+func (f *importFixture) service() *CouponService {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			c, ok := f.coupons[name]
+			if !ok {
+				return nil, apperr.ErrCouponNotFound
+			}
+			copied := *c
+			return &copied, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			f.coupons[name].RemainingAmount--
+			f.coupons[name].ClaimSequence++
+			return nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		ClaimedUsersFunc: func(ctx context.Context, tx database.TxQuerier, couponName string, userIDs []string) ([]string, error) {
+			var claimed []string
+			for _, c := range f.claims {
+				if c.CouponName == couponName && slices.Contains(userIDs, c.UserID) {
+					claimed = append(claimed, c.UserID)
+				}
+			}
+			return claimed, nil
+		},
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			f.claims = append(f.claims, *claim)
+			return nil
+		},
+	}
+	tx := &mocks.TransactorMock{
+		InTxFunc: func(ctx context.Context, fn func(tx database.TxQuerier) error) error {
+			f.chunks++
+			if f.chunks == f.failChunk {
+				return errors.New("connection reset")
+			}
+			return fn(nil)
+		},
+	}
+	return NewCouponServiceWithTransactor(tx, couponRepo, claimRepo)
+}
+
+func TestCouponService_ImportClaims(t *testing.T) {
+	f := newImportFixture(&model.Coupon{Name: "LEGACY", Amount: 10, RemainingAmount: 9, ClaimSequence: 1})
+	f.claims = []model.Claim{{UserID: "u0", CouponName: "LEGACY", Sequence: 1}}
+	svc := f.service()
+	svc.SetImportChunkSize(2)
+	claimedAt := time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+
+	report, err := svc.ImportClaims(context.Background(), &model.ClaimImportRequest{Claims: []model.ImportClaim{
+		{UserID: "u1", CouponName: "LEGACY", ClaimedAt: &claimedAt},
+		{UserID: "u0", CouponName: "LEGACY"}, // Claimed before the import
+		{UserID: "u2", CouponName: "MISSING"},
+		{UserID: "u2", CouponName: "LEGACY"},
+		{UserID: "u2", CouponName: "LEGACY"}, // Duplicate within the request
+	}})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.ClaimImportReport{
+		Total: 5, Processed: 5, Imported: 2, Skipped: 2,
+		Rejected: []model.ClaimImportRejection{{Index: 2, UserID: "u2", CouponName: "MISSING", Reason: "coupon not found"}},
+	}, report)
+	assert.Equal(t, 3, f.chunks)
+	assert.Equal(t, 7, f.coupons["LEGACY"].RemainingAmount)
+
+	require.Len(t, f.claims, 3)
+	assert.Equal(t, 2, f.claims[1].Sequence, "sequences continue after existing claims")
+	assert.Equal(t, claimedAt, f.claims[1].CreatedAt)
+	assert.Equal(t, 3, f.claims[2].Sequence)
+	assert.True(t, f.claims[2].CreatedAt.IsZero(), "no claimed_at means now")
+
+	assert.Equal(t, ClaimImportStats{Total: 5, Processed: 5, Imported: 2, Skipped: 2, Rejected: 1}, svc.ClaimImportStats())
+}
+
+func TestCouponService_ImportClaims_RejectsBusinessRuleFailures(t *testing.T) {
+	f := newImportFixture(
+		&model.Coupon{Name: "SOLD_OUT", Amount: 1, RemainingAmount: 0},
+		&model.Coupon{Name: "OFF", Amount: 1, RemainingAmount: 1, Disabled: true},
+	)
+
+	report, err := f.service().ImportClaims(context.Background(), &model.ClaimImportRequest{Claims: []model.ImportClaim{
+		{UserID: "u1", CouponName: "SOLD_OUT"},
+		{UserID: "u1", CouponName: "OFF"},
+	}})
+
+	require.NoError(t, err)
+	assert.Zero(t, report.Imported)
+	assert.ElementsMatch(t, []model.ClaimImportRejection{
+		{Index: 0, UserID: "u1", CouponName: "SOLD_OUT", Reason: apperr.ErrNoStock.Error()},
+		{Index: 1, UserID: "u1", CouponName: "OFF", Reason: apperr.ErrCouponDisabled.Error()},
+	}, report.Rejected)
+}
+
+func TestCouponService_ImportClaims_ResumesAfterFailure(t *testing.T) {
+	f := newImportFixture(&model.Coupon{Name: "LEGACY", Amount: 10, RemainingAmount: 10})
+	f.failChunk = 2
+	svc := f.service()
+	svc.SetImportChunkSize(2)
+	req := &model.ClaimImportRequest{Claims: []model.ImportClaim{
+		{UserID: "u1", CouponName: "LEGACY"},
+		{UserID: "u2", CouponName: "LEGACY"},
+		{UserID: "u3", CouponName: "LEGACY"},
+	}}
+
+	report, err := svc.ImportClaims(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, 2, report.Processed, "only the first chunk was committed")
+
+	report, err = svc.ImportClaims(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 7, f.coupons["LEGACY"].RemainingAmount)
+}
+
+func TestCouponService_StreamImportClaims(t *testing.T) {
+	f := newImportFixture(&model.Coupon{Name: "LEGACY", Amount: 10, RemainingAmount: 10})
+	f.claims = []model.Claim{{UserID: "u0", CouponName: "LEGACY"}}
+	svc := f.service()
+	svc.SetImportChunkSize(2)
+
+	var results []model.ClaimImportResult
+	var chunksAtResult []int
+	report, err := svc.StreamImportClaims(context.Background(), &model.ClaimImportRequest{Claims: []model.ImportClaim{
+		{UserID: "u1", CouponName: "MISSING"},
+		{UserID: "u1", CouponName: "LEGACY"},
+		{UserID: "u0", CouponName: "LEGACY"},
+	}}, func(result model.ClaimImportResult) error {
+		results = append(results, result)
+		chunksAtResult = append(chunksAtResult, f.chunks)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, []model.ClaimImportResult{
+		{Index: 0, UserID: "u1", CouponName: "MISSING", Status: model.ClaimImportRejected, Reason: "coupon not found"},
+		{Index: 1, UserID: "u1", CouponName: "LEGACY", Status: model.ClaimImportImported},
+		{Index: 2, UserID: "u0", CouponName: "LEGACY", Status: model.ClaimImportSkipped},
+	}, results)
+	assert.Equal(t, []int{1, 1, 2}, chunksAtResult, "results are streamed after their chunk")
+}
+
+func TestCouponService_StreamImportClaims_StopsWhenStreamFails(t *testing.T) {
+	f := newImportFixture(&model.Coupon{Name: "LEGACY", Amount: 10, RemainingAmount: 10})
+	svc := f.service()
+	svc.SetImportChunkSize(1)
+	gone := errors.New("broken pipe")
+
+	report, err := svc.StreamImportClaims(context.Background(), &model.ClaimImportRequest{Claims: []model.ImportClaim{
+		{UserID: "u1", CouponName: "LEGACY"},
+		{UserID: "u2", CouponName: "LEGACY"},
+	}}, func(model.ClaimImportResult) error { return gone })
+
+	require.ErrorIs(t, err, gone)
+	assert.Equal(t, 1, report.Processed)
+	assert.Equal(t, 1, f.chunks, "no chunk is imported after the stream fails")
+}
+
+func TestCouponService_ImportClaims_NilRequest(t *testing.T) {
+	_, err := newImportFixture().service().ImportClaims(context.Background(), nil)
+
+	assert.ErrorIs(t, err, apperr.ErrInvalidRequest)
+}
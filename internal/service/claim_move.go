@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// ClaimMoveService verifies the move of claims to claims_v2 (see database.ClaimsV2):
+// after the backfill, every coupon must have the same claims in both tables before
+// replicas cut over to reading claims_v2.
+type ClaimMoveService struct {
+	repo  ports.ClaimMoveRepository
+	phase database.MigrationPhase
+}
+
+// NewClaimMoveService creates a ClaimMoveService for a move in phase.
+func NewClaimMoveService(repo ports.ClaimMoveRepository, phase database.MigrationPhase) *ClaimMoveService {
+	return &ClaimMoveService{repo: repo, phase: phase}
+}
+
+// Verify compares the claims of up to limit coupons named after after and reports the
+// coupons whose claims differ. Next is set while coupons remain, so a verification of
+// every coupon is a series of calls each passing the previous Next.
+func (s *ClaimMoveService) Verify(ctx context.Context, after string, limit int) (*model.ClaimMoveReport, error) {
+	comparisons, err := s.repo.CompareClaims(ctx, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.ClaimMoveReport{
+		Phase:      string(s.phase),
+		Checked:    len(comparisons),
+		Mismatches: []model.ClaimComparison{},
+	}
+	for _, cmp := range comparisons {
+		if !cmp.Match {
+			report.Mismatches = append(report.Mismatches, cmp)
+		}
+	}
+	if len(comparisons) == limit {
+		report.Next = comparisons[len(comparisons)-1].CouponName
+	}
+	return report, nil
+}
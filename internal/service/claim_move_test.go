@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestClaimMoveService_Verify(t *testing.T) {
+	repo := &mocks.ClaimMoveRepositoryMock{
+		CompareClaimsFunc: func(ctx context.Context, after string, limit int) ([]model.ClaimComparison, error) {
+			return []model.ClaimComparison{
+				{CouponName: "AUTUMN", OldClaims: 3, NewClaims: 3, Match: true},
+				{CouponName: "PROMO", OldClaims: 5, NewClaims: 4},
+			}, nil
+		},
+	}
+	svc := NewClaimMoveService(repo, database.PhaseDualWrite)
+
+	report, err := svc.Verify(context.Background(), "", 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.ClaimMoveReport{
+		Phase:      "dual_write",
+		Checked:    2,
+		Mismatches: []model.ClaimComparison{{CouponName: "PROMO", OldClaims: 5, NewClaims: 4}},
+		Next:       "PROMO",
+	}, report)
+
+	report, err = svc.Verify(context.Background(), "PROMO", 10)
+	require.NoError(t, err)
+	assert.Empty(t, report.Next, "a short batch is the last")
+	assert.Equal(t, "PROMO", repo.CompareClaimsCalls()[1].After)
+}
+
+func TestClaimMoveService_Verify_Error(t *testing.T) {
+	dbErr := errors.New("relation \"claims_v2\" does not exist")
+	repo := &mocks.ClaimMoveRepositoryMock{
+		CompareClaimsFunc: func(ctx context.Context, after string, limit int) ([]model.ClaimComparison, error) {
+			return nil, dbErr
+		},
+	}
+
+	_, err := NewClaimMoveService(repo, database.PhaseReadNew).Verify(context.Background(), "", 100)
+
+	assert.ErrorIs(t, err, dbErr)
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/pacing"
+)
+
+// SetClaimPacer makes claims wait their turn in p before beginning a transaction, so
+// each coupon's claims reach the database at a steady rate. Claims p sheds fail with
+// apperr.ErrClaimPaced. Pacing runs after deduplication and the claimed filter, so
+// claims those answer take no turn.
+func (s *CouponService) SetClaimPacer(p *pacing.Pacer) {
+	s.pacer = p
+}
+
+// pace waits for a claim's turn on name.
+func (s *CouponService) pace(ctx context.Context, name string) error {
+	if s.pacer == nil {
+		return nil
+	}
+	err := s.pacer.Wait(ctx, name)
+	if errors.Is(err, pacing.ErrQueueFull) {
+		return apperr.ErrClaimPaced
+	}
+	return err
+}
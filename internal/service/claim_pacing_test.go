@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/pacing"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponService_ClaimCoupon_Paced(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+	pool := newPool(newTx())
+	svc := NewCouponServiceWithTxBeginner(pool, couponRepo, claimRepo)
+	// One claim per 50ms with one more queued: the second claim waits, the third is shed
+	pacer := pacing.New(pacing.NewMemoryStore(), pacing.Config{Rate: 20, MaxQueue: 1})
+	svc.SetClaimPacer(pacer)
+
+	start := time.Now()
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_1", "PROMO"))
+	require.NoError(t, err)
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_2", "PROMO"))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "the second claim waited its turn")
+
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_3", "PROMO"))
+	require.NoError(t, err, "the queue had room again once the second claim ran")
+
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = svc.ClaimCoupon(short, claimRequest("user_4", "PROMO"))
+	assert.ErrorIs(t, err, apperr.ErrClaimPaced, "the claim's turn is after its deadline")
+
+	assert.Len(t, pool.BeginCalls(), 3)
+	assert.Equal(t, int64(1), pacer.Stats()["PROMO"].Shed)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// sampleSequences returns up to n distinct claim sequences drawn uniformly from
+// 1..total, in ascending order. All of them are returned when total <= n.
+func sampleSequences(total, n int) []int {
+	if total <= n {
+		seqs := make([]int, total)
+		for i := range seqs {
+			seqs[i] = i + 1
+		}
+		return seqs
+	}
+	picked := make(map[int]struct{}, n)
+	for len(picked) < n {
+		picked[rand.IntN(total)+1] = struct{}{}
+	}
+	seqs := make([]int, 0, n)
+	for seq := range picked {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	return seqs
+}
+
+// SampleClaims returns up to n claims of a coupon picked at random, in claim order,
+// e.g. for QA to spot-check a live campaign without exporting all of its claims.
+// Claims are looked up by random claim sequence, so the sample costs n index lookups
+// however many claims the coupon has. Sequences without a claim are skipped, so the
+// sample may be smaller than n.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist or is not listed.
+func (s *CouponService) SampleClaims(ctx context.Context, name string, n int) (*model.ClaimSampleResponse, error) {
+	if !s.couponMayExist(name) {
+		return nil, apperr.ErrCouponNotFound
+	}
+	coupon, err := s.couponRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("get coupon: %w", err)
+	}
+	if coupon == nil || !model.Listed(coupon.Visibility) {
+		return nil, apperr.ErrCouponNotFound
+	}
+
+	resp := &model.ClaimSampleResponse{
+		CouponName:  coupon.Name,
+		TotalClaims: coupon.ClaimSequence,
+		Claims:      []model.ClaimRecord{},
+	}
+	if coupon.ClaimSequence == 0 {
+		return resp, nil
+	}
+	claims, err := s.claimRepo.ListBySequences(ctx, name, sampleSequences(coupon.ClaimSequence, n))
+	if err != nil {
+		return nil, fmt.Errorf("sample claims: %w", err)
+	}
+	for _, c := range claims {
+		resp.Claims = append(resp.Claims, model.ClaimRecord{
+			UserID:        c.UserID,
+			Channel:       c.Channel,
+			Region:        c.Region,
+			ClaimSequence: c.Sequence,
+			Tier:          c.Tier,
+			ClaimedAt:     c.CreatedAt,
+		})
+	}
+	return resp, nil
+}
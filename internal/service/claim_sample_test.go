@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+)
+
+func TestSampleSequences(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, sampleSequences(3, 100), "small coupons are returned whole")
+
+	seqs := sampleSequences(1_000_000, 100)
+	assert.Len(t, seqs, 100)
+	assert.True(t, sort.IntsAreSorted(seqs))
+	seen := map[int]bool{}
+	for _, seq := range seqs {
+		assert.False(t, seen[seq], "sequence %d drawn twice", seq)
+		assert.True(t, seq >= 1 && seq <= 1_000_000)
+		seen[seq] = true
+	}
+}
+
+func TestCouponService_SampleClaims(t *testing.T) {
+	var gotSequences []int
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10000, ClaimSequence: 5000}, nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		ListBySequencesFunc: func(ctx context.Context, couponName string, sequences []int) ([]model.Claim, error) {
+			gotSequences = sequences
+			return []model.Claim{{UserID: "user_042", CouponName: couponName, Sequence: 42, Region: "eu"}}, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+
+	sample, err := svc.SampleClaims(context.Background(), "PROMO", 20)
+
+	require.NoError(t, err)
+	assert.Len(t, gotSequences, 20)
+	assert.Equal(t, 5000, sample.TotalClaims)
+	assert.Equal(t, []model.ClaimRecord{{UserID: "user_042", ClaimSequence: 42, Region: "eu"}}, sample.Claims)
+}
+
+func TestCouponService_SampleClaims_NoClaims(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100}, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, &mocks.ClaimRepositoryMock{})
+
+	sample, err := svc.SampleClaims(context.Background(), "PROMO", 100)
+
+	require.NoError(t, err)
+	assert.NotNil(t, sample.Claims)
+	assert.Empty(t, sample.Claims)
+}
+
+func TestCouponService_SampleClaims_NotFound(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) { return nil, nil },
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, &mocks.ClaimRepositoryMock{})
+
+	_, err := svc.SampleClaims(context.Background(), "MISSING", 100)
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// shadowTimeout bounds a shadow run, which outlives the request it shadows.
+const shadowTimeout = 5 * time.Second
+
+// ClaimStrategy is a candidate way of deciding claims, run in shadow mode next to the
+// current one (the row-locked transaction of ClaimCoupon) before it replaces it.
+type ClaimStrategy interface {
+	// Name identifies the strategy in logs.
+	Name() string
+	// DryRun predicts the outcome of req made at now, the service clock's time when the
+	// claim started, without writing anything: nil when the claim would be granted,
+	// otherwise the error ClaimCoupon would return.
+	DryRun(ctx context.Context, req *model.ClaimCouponRequest, now time.Time) error
+}
+
+// ClaimShadowStats is a snapshot of shadow mode counters.
+type ClaimShadowStats struct {
+	Compared    int64 `json:"compared"`    // Claims both strategies decided
+	Divergences int64 `json:"divergences"` // Compared claims with different outcomes
+	Skipped     int64 `json:"skipped"`     // Claims not shadowed: sampled out or too many in flight
+	Errors      int64 `json:"errors"`      // Claims either strategy failed to decide (e.g. database errors)
+}
+
+// claimShadow runs a candidate strategy's dry run alongside sampled claims and
+// compares its prediction with the actual outcome once both are known, off the
+// request path. Divergences are logged and counted.
+type claimShadow struct {
+	strategy   ClaimStrategy
+	sampleRate float64
+	slots      chan struct{} // One per shadow run in flight
+
+	compared    atomic.Int64
+	divergences atomic.Int64
+	skipped     atomic.Int64
+	errors      atomic.Int64
+}
+
+// SetClaimShadow runs strategy in shadow mode for a sampleRate fraction (0-1] of
+// claims, with at most maxInFlight dry runs at once; claims beyond that are not
+// shadowed. A nil strategy disables it.
+// The dry run reads the state the claim is racing against, so claims competing for a
+// coupon's last units, or repeated by the same user, may diverge spuriously; look for
+// divergences that persist on quiet coupons.
+func (s *CouponService) SetClaimShadow(strategy ClaimStrategy, sampleRate float64, maxInFlight int) {
+	if strategy == nil {
+		s.shadow = nil
+		return
+	}
+	s.shadow = &claimShadow{strategy: strategy, sampleRate: sampleRate, slots: make(chan struct{}, maxInFlight)}
+}
+
+// ClaimShadowStats returns shadow mode counters (zero when disabled).
+func (s *CouponService) ClaimShadowStats() ClaimShadowStats {
+	if s.shadow == nil {
+		return ClaimShadowStats{}
+	}
+	return ClaimShadowStats{
+		Compared:    s.shadow.compared.Load(),
+		Divergences: s.shadow.divergences.Load(),
+		Skipped:     s.shadow.skipped.Load(),
+		Errors:      s.shadow.errors.Load(),
+	}
+}
+
+// DrainClaimShadow waits until no shadow run is in flight, or ctx ends. New runs are
+// not started afterwards, so call it once claims have stopped.
+func (s *CouponService) DrainClaimShadow(ctx context.Context) error {
+	if s.shadow == nil {
+		return nil
+	}
+	for range cap(s.shadow.slots) {
+		select {
+		case s.shadow.slots <- struct{}{}:
+		case <-ctx.Done():
+			return fmt.Errorf("drain claim shadow: %w", ctx.Err())
+		}
+	}
+	return nil
+}
+
+// start begins a dry run of req made at now, unless it is sampled out or too many are
+// in flight. The returned function must be called with the claim's actual outcome.
+func (sh *claimShadow) start(ctx context.Context, req *model.ClaimCouponRequest, now time.Time) func(actual error) {
+	if sh.sampleRate < 1 && rand.Float64() >= sh.sampleRate {
+		sh.skipped.Add(1)
+		return func(error) {}
+	}
+	select {
+	case sh.slots <- struct{}{}:
+	default:
+		sh.skipped.Add(1)
+		return func(error) {}
+	}
+
+	shadowReq := *req
+	actual := make(chan error, 1)
+	go func() {
+		defer func() { <-sh.slots }()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shadowTimeout)
+		defer cancel()
+		predicted := sh.strategy.DryRun(ctx, &shadowReq, now)
+		sh.compare(&shadowReq, <-actual, predicted)
+	}()
+	return func(err error) { actual <- err }
+}
+
+// compare counts and logs whether the predicted outcome of req matches the actual one.
+func (sh *claimShadow) compare(req *model.ClaimCouponRequest, actual, predicted error) {
+	if !isClaimOutcome(actual) || !isClaimOutcome(predicted) {
+		sh.errors.Add(1)
+		return
+	}
+	sh.compared.Add(1)
+	if claimOutcome(actual) == claimOutcome(predicted) {
+		return
+	}
+	sh.divergences.Add(1)
+	log.Warn().
+		Str("strategy", sh.strategy.Name()).
+		Str("user_id", redact.Value(req.UserID)).
+		Str("coupon_name", redact.Value(req.CouponName)).
+		Str("actual", claimOutcome(actual)).
+		Str("predicted", claimOutcome(predicted)).
+		Msg("claim strategies diverged")
+}
+
+// isClaimOutcome reports whether err is a decision on a claim (a grant or a rejection)
+// rather than a failure to decide.
+func isClaimOutcome(err error) bool {
+	return err == nil || isClaimRejection(err) || errors.Is(err, apperr.ErrAlreadyClaimed)
+}
+
+// claimOutcome describes a claim decision for comparison and logs: "granted", or the
+// rejection's apperr code, which unlike its message does not depend on how it was wrapped.
+func claimOutcome(err error) string {
+	if err == nil {
+		return "granted"
+	}
+	return apperr.Code(err)
+}
+
+// optimisticStrategy decides claims from lock-free reads, the way a strategy replacing
+// the row lock with a conditional update (UPDATE ... WHERE remaining_amount > 0) would
+// before writing. Its checks run in the order of ClaimCoupon's, so rejections match.
+type optimisticStrategy struct {
+	couponRepo ports.CouponRepository
+	claimRepo  ports.ClaimRepository
+}
+
+// NewOptimisticClaimStrategy returns the optimistic claim strategy, for shadow mode.
+func NewOptimisticClaimStrategy(couponRepo ports.CouponRepository, claimRepo ports.ClaimRepository) ClaimStrategy {
+	return &optimisticStrategy{couponRepo: couponRepo, claimRepo: claimRepo}
+}
+
+func (o *optimisticStrategy) Name() string { return "optimistic" }
+
+func (o *optimisticStrategy) DryRun(ctx context.Context, req *model.ClaimCouponRequest, now time.Time) error {
+	coupon, err := o.couponRepo.GetByName(ctx, req.CouponName)
+	if err != nil {
+		return fmt.Errorf("get coupon: %w", err)
+	}
+	if coupon == nil {
+		return apperr.ErrCouponNotFound
+	}
+	if coupon.Disabled {
+		return apperr.ErrCouponDisabled
+	}
+	if coupon.CaptchaRequired && !req.CaptchaVerified {
+		return apperr.ErrCaptchaRequired
+	}
+	if coupon.Visibility == model.VisibilityPrivate && !req.GrantVerified {
+		return apperr.ErrGrantRequired
+	}
+	if !hasStock(coupon) {
+		return apperr.ErrNoStock
+	}
+	if _, err := pickChannelPartition(coupon, req.Channel, now); err != nil {
+		return err
+	}
+	if err := checkRegionQuota(coupon, req.Region); err != nil {
+		return err
+	}
+
+	claimed, err := o.claimRepo.HasClaimed(ctx, req.UserID, req.CouponName)
+	if err != nil {
+		return fmt.Errorf("check claim: %w", err)
+	}
+	if claimed {
+		return apperr.ErrAlreadyClaimed
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// fakeStrategy predicts every claim's outcome as err, after release is closed (if set),
+// and records the time of the last claim it was asked about.
+type fakeStrategy struct {
+	err     error
+	release chan struct{}
+	now     time.Time
+}
+
+func (f *fakeStrategy) Name() string { return "fake" }
+
+func (f *fakeStrategy) DryRun(ctx context.Context, req *model.ClaimCouponRequest, now time.Time) error {
+	if f.release != nil {
+		<-f.release
+	}
+	f.now = now
+	return f.err
+}
+
+// shadowedService returns a service granting every claim, except with claimErr.
+func shadowedService(strategy ClaimStrategy, claimErr error, maxInFlight int) *CouponService {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 100}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc:   func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return claimErr },
+		GetClaimFunc: func(ctx context.Context, userID, couponName string) (*model.Claim, error) { return nil, nil },
+	}
+	tx := &mocks.TransactorMock{
+		InTxFunc: func(ctx context.Context, fn func(tx database.TxQuerier) error) error { return fn(nil) },
+	}
+	svc := NewCouponServiceWithTransactor(tx, couponRepo, claimRepo)
+	svc.SetClaimShadow(strategy, 1, maxInFlight)
+	return svc
+}
+
+func TestClaimShadow_ComparesOutcomes(t *testing.T) {
+	tests := []struct {
+		name      string
+		claimErr  error
+		predicted error
+		want      ClaimShadowStats
+	}{
+		{"both grant", nil, nil, ClaimShadowStats{Compared: 1}},
+		{"both reject", apperr.ErrAlreadyClaimed, apperr.ErrAlreadyClaimed, ClaimShadowStats{Compared: 1}},
+		{"shadow rejects", nil, apperr.ErrNoStock, ClaimShadowStats{Compared: 1, Divergences: 1}},
+		{"shadow rejects region", nil, apperr.ErrRegionQuotaReached, ClaimShadowStats{Compared: 1, Divergences: 1}},
+		{"both reject group", apperr.ErrExclusionGroupClaimed, apperr.ErrExclusionGroupClaimed, ClaimShadowStats{Compared: 1}},
+		{"shadow grants", apperr.ErrAlreadyClaimed, nil, ClaimShadowStats{Compared: 1, Divergences: 1}},
+		{"shadow fails", nil, errors.New("connection reset"), ClaimShadowStats{Errors: 1}},
+		{"claim fails", errors.New("connection reset"), nil, ClaimShadowStats{Errors: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := shadowedService(&fakeStrategy{err: tt.predicted}, tt.claimErr, 4)
+
+			_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+			if tt.claimErr == nil {
+				require.NoError(t, err, "the shadow never changes the outcome")
+			}
+			require.NoError(t, svc.DrainClaimShadow(context.Background()))
+
+			assert.Equal(t, tt.want, svc.ClaimShadowStats())
+		})
+	}
+}
+
+func TestClaimShadow_SkipsWhenTooManyInFlight(t *testing.T) {
+	strategy := &fakeStrategy{release: make(chan struct{})}
+	svc := shadowedService(strategy, nil, 1)
+
+	for range 3 {
+		_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+		require.NoError(t, err, "claims do not wait for the shadow")
+	}
+	close(strategy.release)
+	require.NoError(t, svc.DrainClaimShadow(context.Background()))
+
+	assert.Equal(t, ClaimShadowStats{Compared: 1, Skipped: 2}, svc.ClaimShadowStats())
+}
+
+func TestClaimShadow_UsesServiceClock(t *testing.T) {
+	t0 := time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+	strategy := &fakeStrategy{}
+	svc := shadowedService(strategy, nil, 1)
+	svc.SetClock(clock.NewFake(t0))
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	require.NoError(t, err)
+	require.NoError(t, svc.DrainClaimShadow(context.Background()))
+
+	assert.Equal(t, t0, strategy.now)
+}
+
+func TestOptimisticClaimStrategy_DryRun(t *testing.T) {
+	overflowAt := time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+	overflowing := func() *model.Coupon {
+		return &model.Coupon{Name: "PROMO", RemainingAmount: 1, OverflowAt: &overflowAt, Channels: []model.ChannelQuota{
+			{Channel: "app", Quota: 1}, {Channel: "web", Quota: 1, Remaining: 1},
+		}}
+	}
+	onApp := func() *model.ClaimCouponRequest {
+		req := claimRequest("u1", "PROMO")
+		req.Channel = "app"
+		return req
+	}
+	tests := []struct {
+		name    string
+		coupon  *model.Coupon
+		claimed bool
+		req     *model.ClaimCouponRequest
+		now     time.Time
+		want    error
+	}{
+		{"granted", &model.Coupon{Name: "PROMO", RemainingAmount: 1}, false, claimRequest("u1", "PROMO"), time.Time{}, nil},
+		{"not found", nil, false, claimRequest("u1", "PROMO"), time.Time{}, apperr.ErrCouponNotFound},
+		{"disabled", &model.Coupon{Name: "PROMO", RemainingAmount: 1, Disabled: true}, false, claimRequest("u1", "PROMO"), time.Time{}, apperr.ErrCouponDisabled},
+		{"captcha", &model.Coupon{Name: "PROMO", RemainingAmount: 1, CaptchaRequired: true}, false, claimRequest("u1", "PROMO"), time.Time{}, apperr.ErrCaptchaRequired},
+		{"already claimed", &model.Coupon{Name: "PROMO", RemainingAmount: 1}, true, claimRequest("u1", "PROMO"), time.Time{}, apperr.ErrAlreadyClaimed},
+		{"sold out before duplicate", &model.Coupon{Name: "PROMO"}, true, claimRequest("u1", "PROMO"), time.Time{}, apperr.ErrNoStock},
+		{
+			"channel required",
+			&model.Coupon{Name: "PROMO", RemainingAmount: 1, Channels: []model.ChannelQuota{{Channel: "app", Quota: 1, Remaining: 1}}},
+			false, claimRequest("u1", "PROMO"), time.Time{}, apperr.ErrChannelRequired,
+		},
+		{"channel exhausted before overflow", overflowing(), false, onApp(), overflowAt.Add(-time.Second), apperr.ErrNoStock},
+		{"channel borrows at overflow", overflowing(), false, onApp(), overflowAt, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			couponRepo := &mocks.CouponRepositoryMock{
+				GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) { return tt.coupon, nil },
+			}
+			claimRepo := &mocks.ClaimRepositoryMock{
+				HasClaimedFunc: func(ctx context.Context, userID, couponName string) (bool, error) { return tt.claimed, nil },
+			}
+
+			err := NewOptimisticClaimStrategy(couponRepo, claimRepo).DryRun(context.Background(), tt.req, tt.now)
+
+			if tt.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+}
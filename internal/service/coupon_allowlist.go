@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// SetAllowlists enables coupon allowlists: a coupon with an allowlist accepts claims
+// only from the users on it, each until the claim-by deadline of their entry if it has
+// one. Deadlines are checked in the claim transaction against the claim's time, so
+// entries with different deadlines give tiered access windows. Claims take one extra
+// read. A nil repo disables them.
+func (s *CouponService) SetAllowlists(repo ports.AllowlistRepository) {
+	s.allowlists = repo
+}
+
+// SetAllowlist replaces the allowlist of the coupon name with entries, in one transaction.
+// Returns apperr.ErrInvalidRequest if entries is empty or lists a user twice, and
+// apperr.ErrCouponNotFound if the coupon does not exist.
+func (s *CouponService) SetAllowlist(ctx context.Context, name string, entries []model.AllowlistEntry) (*model.AllowlistResponse, error) {
+	if len(entries) == 0 {
+		return nil, apperr.ErrInvalidRequest
+	}
+	seen := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		if _, ok := seen[e.UserID]; ok {
+			return nil, apperr.ErrInvalidRequest
+		}
+		seen[e.UserID] = struct{}{}
+	}
+	if !s.couponMayExist(name) {
+		return nil, apperr.ErrCouponNotFound
+	}
+
+	err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+		return s.allowlists.Replace(ctx, tx, name, entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Allowlist(ctx, name, "", len(entries))
+}
+
+// Allowlist returns a page of up to limit entries of the allowlist of the coupon name
+// with user IDs after afterUserID ("" for the first page), in user ID order.
+// Returns apperr.ErrAllowlistNotFound if it has none.
+func (s *CouponService) Allowlist(ctx context.Context, name, afterUserID string, limit int) (*model.AllowlistResponse, error) {
+	entries, err := s.allowlists.List(ctx, name, afterUserID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 && afterUserID == "" {
+		return nil, apperr.ErrAllowlistNotFound
+	}
+	page := model.NewPage(entries, limit, func(e model.AllowlistEntry) string { return e.UserID })
+	return &model.AllowlistResponse{CouponName: name, Page: page}, nil
+}
+
+// DeleteAllowlist removes the allowlist of the coupon name, opening it to every user.
+// Returns apperr.ErrAllowlistNotFound if it has none.
+func (s *CouponService) DeleteAllowlist(ctx context.Context, name string) error {
+	return s.allowlists.Delete(ctx, name)
+}
+
+// checkAllowlist checks within tx that userID may claim couponName at now.
+// Returns apperr.ErrNotAllowlisted if the coupon has an allowlist the user is not on, and
+// apperr.ErrClaimDeadlinePassed if the user's claim-by deadline is before now.
+func (s *CouponService) checkAllowlist(ctx context.Context, tx database.TxQuerier, couponName, userID string, now time.Time) error {
+	if s.allowlists == nil {
+		return nil
+	}
+	entry, restricted, err := s.allowlists.Lookup(ctx, tx, couponName, userID)
+	if err != nil {
+		return fmt.Errorf("look up allowlist: %w", err)
+	}
+	if !restricted {
+		return nil
+	}
+	if entry == nil {
+		return apperr.ErrNotAllowlisted
+	}
+	if entry.ClaimBy != nil && now.After(*entry.ClaimBy) {
+		return apperr.ErrClaimDeadlinePassed
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// allowlistedClaimService returns a service claiming a coupon with stock whose
+// allowlist holds entries (none when nil), counting claims inserted.
+func allowlistedClaimService(entries map[string]*time.Time, inserts *int) *CouponService {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 50}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			*inserts++
+			return nil
+		},
+	}
+	allowlists := &mocks.AllowlistRepositoryMock{
+		LookupFunc: func(ctx context.Context, tx database.TxQuerier, couponName, userID string) (*model.AllowlistEntry, bool, error) {
+			claimBy, ok := entries[userID]
+			if !ok {
+				return nil, entries != nil, nil
+			}
+			return &model.AllowlistEntry{UserID: userID, ClaimBy: claimBy}, true, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+	svc.SetAllowlists(allowlists)
+	return svc
+}
+
+func TestCouponService_ClaimCoupon_Allowlist(t *testing.T) {
+	passed := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	entries := map[string]*time.Time{"vip": &future, "early": &passed, "anytime": nil}
+
+	tests := []struct {
+		name    string
+		entries map[string]*time.Time
+		userID  string
+		want    error
+	}{
+		{"no allowlist", nil, "user_001", nil},
+		{"before deadline", entries, "vip", nil},
+		{"no deadline", entries, "anytime", nil},
+		{"after deadline", entries, "early", apperr.ErrClaimDeadlinePassed},
+		{"not listed", entries, "user_001", apperr.ErrNotAllowlisted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inserts int
+			svc := allowlistedClaimService(tt.entries, &inserts)
+
+			_, err := svc.ClaimCoupon(context.Background(), claimRequest(tt.userID, "PROMO"))
+
+			assert.ErrorIs(t, err, tt.want)
+			if tt.want != nil {
+				assert.Zero(t, inserts)
+			}
+		})
+	}
+}
+
+func TestCouponService_SetAllowlist(t *testing.T) {
+	var replaced []model.AllowlistEntry
+	allowlists := &mocks.AllowlistRepositoryMock{
+		ReplaceFunc: func(ctx context.Context, tx database.TxQuerier, couponName string, entries []model.AllowlistEntry) error {
+			replaced = entries
+			return nil
+		},
+		ListFunc: func(ctx context.Context, couponName, afterUserID string, limit int) ([]model.AllowlistEntry, error) {
+			return replaced, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetAllowlists(allowlists)
+
+	resp, err := svc.SetAllowlist(context.Background(), "PROMO", []model.AllowlistEntry{{UserID: "vip"}, {UserID: "user"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.AllowlistResponse{CouponName: "PROMO", Page: model.Page[model.AllowlistEntry]{Items: replaced}}, resp)
+	assert.Equal(t, 2+1, allowlists.ListCalls()[0].Limit, "the response lists every entry set")
+
+	_, err = svc.SetAllowlist(context.Background(), "PROMO", []model.AllowlistEntry{{UserID: "vip"}, {UserID: "vip"}})
+	assert.ErrorIs(t, err, apperr.ErrInvalidRequest, "users are listed once")
+	assert.Len(t, allowlists.ReplaceCalls(), 1)
+}
+
+func TestCouponService_Allowlist_NotFound(t *testing.T) {
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetAllowlists(&mocks.AllowlistRepositoryMock{
+		ListFunc: func(ctx context.Context, couponName, afterUserID string, limit int) ([]model.AllowlistEntry, error) {
+			return []model.AllowlistEntry{}, nil
+		},
+	})
+
+	_, err := svc.Allowlist(context.Background(), "PROMO", "", 10)
+	assert.ErrorIs(t, err, apperr.ErrAllowlistNotFound)
+
+	resp, err := svc.Allowlist(context.Background(), "PROMO", "user_009", 10)
+	require.NoError(t, err, "a page past the last entry is empty")
+	assert.Empty(t, resp.Items)
+}
+
+func TestCouponService_Allowlist_Pages(t *testing.T) {
+	allowlists := &mocks.AllowlistRepositoryMock{
+		ListFunc: func(ctx context.Context, couponName, afterUserID string, limit int) ([]model.AllowlistEntry, error) {
+			return []model.AllowlistEntry{{UserID: "user_002"}, {UserID: "user_003"}, {UserID: "user_004"}}, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetAllowlists(allowlists)
+
+	resp, err := svc.Allowlist(context.Background(), "PROMO", "user_001", 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.AllowlistEntry{{UserID: "user_002"}, {UserID: "user_003"}}, resp.Items)
+	assert.Equal(t, model.EncodeCursor("user_003"), resp.NextCursor)
+	require.Len(t, allowlists.ListCalls(), 1)
+	assert.Equal(t, "user_001", allowlists.ListCalls()[0].AfterUserID)
+	assert.Equal(t, 3, allowlists.ListCalls()[0].Limit, "one more than the page to tell if there is a next")
+}
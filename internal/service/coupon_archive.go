@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+)
+
+// SetCouponArchive copies deleted coupons to repo as PurgeDeleted purges them, so that
+// GetArchived serves them once their rows are gone. Requires SetCouponDeletion.
+func (s *CouponService) SetCouponArchive(repo ports.CouponArchiveRepository) {
+	s.archive = repo
+}
+
+// GetArchived returns the archived coupon name. Archived coupons never change (until
+// a coupon created later under the name is purged in turn), so they bypass the coupon
+// cache and name filter, which only know live coupons.
+// Returns apperr.ErrArchivedCouponNotFound if none is archived, or it was not listed.
+func (s *CouponService) GetArchived(ctx context.Context, name string) (*model.ArchivedCoupon, error) {
+	archived, err := s.archive.GetArchived(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if archived == nil || !model.Listed(archived.Visibility) {
+		return nil, apperr.ErrArchivedCouponNotFound
+	}
+	return archived, nil
+}
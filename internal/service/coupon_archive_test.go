@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponService_PurgeDeleted_Archives(t *testing.T) {
+	var archivedNames, purgedNames []string
+	tombstones := &mocks.CouponTombstoneRepositoryMock{
+		ExpiredFunc: func(ctx context.Context, undoWindow time.Duration, limit int) ([]string, error) {
+			return []string{"OLD", "RESTORED"}, nil
+		},
+		PurgeFunc: func(ctx context.Context, tx database.TxQuerier, name string, undoWindow time.Duration) (bool, error) {
+			purgedNames = append(purgedNames, name)
+			return true, nil
+		},
+	}
+	archive := &mocks.CouponArchiveRepositoryMock{
+		ArchiveFunc: func(ctx context.Context, tx database.TxQuerier, name string, window time.Duration) (bool, error) {
+			assert.Equal(t, time.Hour, window)
+			archivedNames = append(archivedNames, name)
+			return name != "RESTORED", nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetCouponDeletion(tombstones, time.Hour)
+	svc.SetCouponArchive(archive)
+
+	require.NoError(t, svc.PurgeDeleted(context.Background()))
+
+	assert.Equal(t, []string{"OLD", "RESTORED"}, archivedNames)
+	assert.Equal(t, []string{"OLD"}, purgedNames, "a coupon restored meanwhile is not purged")
+	stats := svc.CouponPurgeStats()
+	assert.Equal(t, int64(1), stats.Purged)
+	assert.Equal(t, int64(1), stats.Archived)
+}
+
+func TestCouponService_GetArchived(t *testing.T) {
+	archive := &mocks.CouponArchiveRepositoryMock{
+		GetArchivedFunc: func(ctx context.Context, name string) (*model.ArchivedCoupon, error) {
+			switch name {
+			case "SUMMER":
+				return &model.ArchivedCoupon{Name: name, Claims: 3, Visibility: model.VisibilityPublic}, nil
+			case "SECRET":
+				return &model.ArchivedCoupon{Name: name, Visibility: model.VisibilityPrivate}, nil
+			}
+			return nil, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetCouponArchive(archive)
+
+	archived, err := svc.GetArchived(context.Background(), "SUMMER")
+	require.NoError(t, err)
+	assert.Equal(t, 3, archived.Claims)
+
+	_, err = svc.GetArchived(context.Background(), "SECRET")
+	assert.ErrorIs(t, err, apperr.ErrArchivedCouponNotFound, "unlisted coupons stay hidden")
+	_, err = svc.GetArchived(context.Background(), "NEVER")
+	assert.ErrorIs(t, err, apperr.ErrArchivedCouponNotFound)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// claimRetentionBatch is how many expired claims of a coupon are changed per transaction.
+const claimRetentionBatch = 1000
+
+// ClaimRetentionStats is a snapshot of claim retention counters.
+type ClaimRetentionStats struct {
+	Passes     int64 `json:"passes"`      // Completed retention passes
+	PassErrors int64 `json:"pass_errors"` // Failed passes (resumed by the next one)
+	Purged     int64 `json:"purged"`      // Claims deleted
+	Anonymized int64 `json:"anonymized"`  // Claims whose user ID was replaced
+	LastPass   int64 `json:"last_pass"`   // Unix time of the last completed pass; 0 before the first
+}
+
+// claimRetentionCounters counts retention passes.
+type claimRetentionCounters struct {
+	passes     atomic.Int64
+	passErrors atomic.Int64
+	purged     atomic.Int64
+	anonymized atomic.Int64
+	lastPass   atomic.Int64
+}
+
+// SetClaimRetention enables coupons with a claim retention, whose claims
+// EnforceClaimRetention purges or anonymizes once older than the retention.
+func (s *CouponService) SetClaimRetention(repo ports.ClaimRetentionRepository) {
+	s.retention = repo
+}
+
+// ClaimRetentionStats returns the claim retention counters.
+func (s *CouponService) ClaimRetentionStats() ClaimRetentionStats {
+	return ClaimRetentionStats{
+		Passes:     s.retentions.passes.Load(),
+		PassErrors: s.retentions.passErrors.Load(),
+		Purged:     s.retentions.purged.Load(),
+		Anonymized: s.retentions.anonymized.Load(),
+		LastPass:   s.retentions.lastPass.Load(),
+	}
+}
+
+// EnforceClaimRetention purges or anonymizes the claims of every coupon with a claim
+// retention that are older than its days, claimRetentionBatch claims per transaction.
+// An interrupted pass is resumed by the next one; instances may enforce concurrently.
+func (s *CouponService) EnforceClaimRetention(ctx context.Context) error {
+	if err := s.enforceClaimRetention(ctx); err != nil {
+		s.retentions.passErrors.Add(1)
+		return err
+	}
+	s.retentions.passes.Add(1)
+	s.retentions.lastPass.Store(s.clock.Now().Unix())
+	return nil
+}
+
+func (s *CouponService) enforceClaimRetention(ctx context.Context) error {
+	policies, err := s.retention.RetentionPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	now := s.clock.Now()
+	for _, policy := range policies {
+		cutoff := now.AddDate(0, 0, -policy.Days)
+		counter := &s.retentions.purged
+		if policy.Action == model.RetentionAnonymize {
+			counter = &s.retentions.anonymized
+		}
+		for {
+			var expired int
+			err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+				var err error
+				expired, err = s.retention.ExpireClaims(ctx, tx, policy.CouponName, policy.Action, cutoff, claimRetentionBatch)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("coupon %s: %w", policy.CouponName, err)
+			}
+			if expired > 0 {
+				counter.Add(int64(expired))
+				s.invalidate(policy.CouponName) // claimed_by changed
+			}
+			if expired < claimRetentionBatch {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// RunClaimRetention enforces claim retention now and then every interval until ctx is
+// cancelled, skipping the passes the job gate holds back.
+func (s *CouponService) RunClaimRetention(ctx context.Context, interval time.Duration) {
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if allowHeavyJob(ctx, s.jobGate, JobClaimRetention) {
+			if err := s.EnforceClaimRetention(ctx); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Msg("claim retention pass failed")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
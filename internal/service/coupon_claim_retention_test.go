@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponService_Create_ClaimRetention(t *testing.T) {
+	req := &model.CreateCouponRequest{
+		Name:           "GDPR_PROMO",
+		Amount:         intPtr(10),
+		ClaimRetention: &model.ClaimRetention{Days: 90, Action: model.RetentionPurge},
+	}
+	couponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error { return nil },
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, &mocks.ClaimRepositoryMock{})
+
+	assert.ErrorIs(t, svc.Create(context.Background(), req), apperr.ErrClaimRetentionDisabled)
+	assert.Empty(t, couponRepo.InsertCalls())
+
+	svc.SetClaimRetention(&mocks.ClaimRetentionRepositoryMock{})
+	require.NoError(t, svc.Create(context.Background(), req))
+	require.Len(t, couponRepo.InsertCalls(), 1)
+	assert.Equal(t, req.ClaimRetention, couponRepo.InsertCalls()[0].Coupon.ClaimRetention)
+}
+
+func TestCouponService_EnforceClaimRetention(t *testing.T) {
+	// PURGE_ME has a full batch and then a short one; ANON_ME a short one
+	expired := map[string][]int{"PURGE_ME": {claimRetentionBatch, 3}, "ANON_ME": {2}}
+	retention := &mocks.ClaimRetentionRepositoryMock{
+		RetentionPoliciesFunc: func(ctx context.Context) ([]model.CouponClaimRetention, error) {
+			return []model.CouponClaimRetention{
+				{CouponName: "ANON_ME", ClaimRetention: model.ClaimRetention{Days: 30, Action: model.RetentionAnonymize}},
+				{CouponName: "PURGE_ME", ClaimRetention: model.ClaimRetention{Days: 90, Action: model.RetentionPurge}},
+			}, nil
+		},
+		ExpireClaimsFunc: func(ctx context.Context, tx database.TxQuerier, couponName, action string, cutoff time.Time, limit int) (int, error) {
+			n := expired[couponName][0]
+			expired[couponName] = expired[couponName][1:]
+			return n, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetClaimRetention(retention)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.SetClock(clock.NewFake(now))
+
+	require.NoError(t, svc.EnforceClaimRetention(context.Background()))
+
+	calls := retention.ExpireClaimsCalls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, "ANON_ME", calls[0].CouponName)
+	assert.Equal(t, model.RetentionAnonymize, calls[0].Action)
+	assert.Equal(t, now.AddDate(0, 0, -30), calls[0].Cutoff)
+	assert.Equal(t, claimRetentionBatch, calls[0].Limit)
+	assert.Equal(t, model.RetentionPurge, calls[2].Action)
+	assert.Equal(t, now.AddDate(0, 0, -90), calls[2].Cutoff)
+
+	stats := svc.ClaimRetentionStats()
+	assert.Equal(t, int64(1), stats.Passes)
+	assert.Zero(t, stats.PassErrors)
+	assert.Equal(t, int64(claimRetentionBatch+3), stats.Purged)
+	assert.Equal(t, int64(2), stats.Anonymized)
+	assert.Equal(t, now.Unix(), stats.LastPass)
+}
+
+func TestCouponService_RunClaimRetention(t *testing.T) {
+	passes := make(chan struct{})
+	retention := &mocks.ClaimRetentionRepositoryMock{
+		RetentionPoliciesFunc: func(ctx context.Context) ([]model.CouponClaimRetention, error) {
+			passes <- struct{}{}
+			return nil, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetClaimRetention(retention)
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc.SetClock(clk)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunClaimRetention(ctx, time.Hour)
+		close(done)
+	}()
+
+	<-passes // Right away
+	clk.BlockUntil(1)
+	clk.Advance(59 * time.Minute)
+	select {
+	case <-passes:
+		t.Fatal("pass before the interval")
+	default:
+	}
+	clk.Advance(time.Minute)
+	<-passes
+	cancel()
+	<-done
+
+	assert.Equal(t, int64(2), svc.ClaimRetentionStats().Passes)
+}
+
+func TestCouponService_EnforceClaimRetention_Error(t *testing.T) {
+	retention := &mocks.ClaimRetentionRepositoryMock{
+		RetentionPoliciesFunc: func(ctx context.Context) ([]model.CouponClaimRetention, error) {
+			return []model.CouponClaimRetention{
+				{CouponName: "A", ClaimRetention: model.ClaimRetention{Days: 1, Action: model.RetentionPurge}},
+				{CouponName: "B", ClaimRetention: model.ClaimRetention{Days: 1, Action: model.RetentionPurge}},
+			}, nil
+		},
+		ExpireClaimsFunc: func(ctx context.Context, tx database.TxQuerier, couponName, action string, cutoff time.Time, limit int) (int, error) {
+			if couponName == "B" {
+				return 0, errors.New("connection reset")
+			}
+			return 4, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetClaimRetention(retention)
+
+	err := svc.EnforceClaimRetention(context.Background())
+
+	assert.ErrorContains(t, err, "coupon B")
+	stats := svc.ClaimRetentionStats()
+	assert.Zero(t, stats.Passes)
+	assert.Equal(t, int64(1), stats.PassErrors)
+	assert.Equal(t, int64(4), stats.Purged)
+}
+
+func TestDiffCoupon_ClaimRetention(t *testing.T) {
+	existing := &model.Coupon{Name: "GDPR_PROMO", Amount: 10}
+	desired := &model.Coupon{Name: "GDPR_PROMO", Amount: 10, ClaimRetention: &model.ClaimRetention{Days: 30, Action: model.RetentionPurge}}
+
+	diffs := diffCoupon(existing, desired)
+
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "claim_retention", diffs[0].Field)
+	assert.Empty(t, diffCoupon(desired, &model.Coupon{Name: "GDPR_PROMO", Amount: 10,
+		ClaimRetention: &model.ClaimRetention{Days: 30, Action: model.RetentionPurge}}))
+}
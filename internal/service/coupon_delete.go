@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// couponPurgeBatch is how many expired coupons are listed per query when purging.
+const couponPurgeBatch = 100
+
+// CouponPurgeStats is a snapshot of deleted coupon purge counters.
+type CouponPurgeStats struct {
+	Purges      int64 `json:"purges"`       // Completed purge passes
+	PurgeErrors int64 `json:"purge_errors"` // Failed passes (resumed by the next one)
+	Purged      int64 `json:"purged"`       // Coupons purged with their claims
+	Archived    int64 `json:"archived"`     // Purged coupons copied to the archive
+	LastPurge   int64 `json:"last_purge"`   // Unix time of the last completed pass; 0 before the first
+}
+
+// couponPurgeCounters counts purge passes.
+type couponPurgeCounters struct {
+	purges      atomic.Int64
+	purgeErrors atomic.Int64
+	purged      atomic.Int64
+	archived    atomic.Int64
+	lastPurge   atomic.Int64
+}
+
+// SetCouponDeletion enables deleting coupons. A deleted coupon is kept as a tombstone
+// for undoWindow: it is hidden from reads, claims and updates, its name stays taken,
+// and Restore brings it back as it was. PurgeDeleted then removes it with its claims.
+func (s *CouponService) SetCouponDeletion(repo ports.CouponTombstoneRepository, undoWindow time.Duration) {
+	s.tombstones = repo
+	s.undoWindow = undoWindow
+}
+
+// CouponPurgeStats returns the purge counters.
+func (s *CouponService) CouponPurgeStats() CouponPurgeStats {
+	return CouponPurgeStats{
+		Purges:      s.purges.purges.Load(),
+		PurgeErrors: s.purges.purgeErrors.Load(),
+		Purged:      s.purges.purged.Load(),
+		Archived:    s.purges.archived.Load(),
+		LastPurge:   s.purges.lastPurge.Load(),
+	}
+}
+
+// Delete deletes the coupon name, keeping it restorable for the undo window.
+// Returns apperr.ErrCouponNotFound if it does not exist or is already deleted.
+func (s *CouponService) Delete(ctx context.Context, name string) (*model.DeletedCoupon, error) {
+	if !s.couponMayExist(name) {
+		return nil, apperr.ErrCouponNotFound
+	}
+	deletedAt, err := s.tombstones.Tombstone(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(name)
+	return &model.DeletedCoupon{
+		Name:            name,
+		DeletedAt:       deletedAt,
+		RestorableUntil: deletedAt.Add(s.undoWindow),
+	}, nil
+}
+
+// Restore undeletes the coupon name, with its stock and claims, and returns it.
+// Returns apperr.ErrDeletedCouponNotFound if it was not deleted within the undo window.
+func (s *CouponService) Restore(ctx context.Context, name string) (*model.CouponResponse, error) {
+	if err := s.tombstones.Restore(ctx, name, s.undoWindow); err != nil {
+		return nil, err
+	}
+	s.addCouponName(name) // Possibly deleted before the name filter was built
+	return s.GetByName(ctx, name)
+}
+
+// PurgeDeleted removes every coupon deleted longer than the undo window ago, with its
+// claims, one transaction per coupon. An interrupted pass is resumed by the next one;
+// instances may purge concurrently.
+func (s *CouponService) PurgeDeleted(ctx context.Context) error {
+	if err := s.purgeDeleted(ctx); err != nil {
+		s.purges.purgeErrors.Add(1)
+		return err
+	}
+	s.purges.purges.Add(1)
+	s.purges.lastPurge.Store(s.clock.Now().Unix())
+	return nil
+}
+
+func (s *CouponService) purgeDeleted(ctx context.Context) error {
+	for {
+		names, err := s.tombstones.Expired(ctx, s.undoWindow, couponPurgeBatch)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			var archived, purged bool
+			err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+				if s.archive != nil {
+					// Copied under the row lock Purge then takes, so that a coupon
+					// restored meanwhile is neither archived nor purged
+					var err error
+					if archived, err = s.archive.Archive(ctx, tx, name, s.undoWindow); err != nil || !archived {
+						return err
+					}
+				}
+				var err error
+				purged, err = s.tombstones.Purge(ctx, tx, name, s.undoWindow)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("coupon %s: %w", name, err)
+			}
+			if purged {
+				s.purges.purged.Add(1)
+				if archived {
+					s.purges.archived.Add(1)
+				}
+			}
+		}
+		if len(names) < couponPurgeBatch {
+			return nil
+		}
+	}
+}
+
+// RunCouponPurge purges deleted coupons now and then every interval until ctx is
+// cancelled, skipping the passes the job gate holds back.
+func (s *CouponService) RunCouponPurge(ctx context.Context, interval time.Duration) {
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if allowHeavyJob(ctx, s.jobGate, JobCouponPurge) {
+			if err := s.PurgeDeleted(ctx); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Msg("deleted coupon purge failed")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
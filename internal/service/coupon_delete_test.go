@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponService_Delete(t *testing.T) {
+	deletedAt := time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+	tombstones := &mocks.CouponTombstoneRepositoryMock{
+		TombstoneFunc: func(ctx context.Context, name string) (time.Time, error) {
+			if name != "PROMO" {
+				return time.Time{}, apperr.ErrCouponNotFound
+			}
+			return deletedAt, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetCouponDeletion(tombstones, 24*time.Hour)
+
+	deleted, err := svc.Delete(context.Background(), "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, &model.DeletedCoupon{
+		Name:            "PROMO",
+		DeletedAt:       deletedAt,
+		RestorableUntil: deletedAt.Add(24 * time.Hour),
+	}, deleted)
+
+	_, err = svc.Delete(context.Background(), "OTHER")
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+}
+
+func TestCouponService_Restore(t *testing.T) {
+	var window time.Duration
+	tombstones := &mocks.CouponTombstoneRepositoryMock{
+		RestoreFunc: func(ctx context.Context, name string, undoWindow time.Duration) error {
+			window = undoWindow
+			if name != "PROMO" {
+				return apperr.ErrDeletedCouponNotFound
+			}
+			return nil
+		},
+	}
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 9}, nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		GetUsersByCouponFunc: func(ctx context.Context, couponName string, limit int) ([]string, error) {
+			return []string{"user_001"}, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+	svc.SetCouponDeletion(tombstones, time.Hour)
+
+	coupon, err := svc.Restore(context.Background(), "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, window)
+	assert.Equal(t, 9, coupon.RemainingAmount)
+	assert.Equal(t, []string{"user_001"}, coupon.ClaimedBy)
+
+	_, err = svc.Restore(context.Background(), "OTHER")
+	assert.ErrorIs(t, err, apperr.ErrDeletedCouponNotFound)
+}
+
+func TestCouponService_PurgeDeleted(t *testing.T) {
+	// Two full batches, then a short one; every third coupon was restored meanwhile
+	var batches [][]string
+	for b := range 3 {
+		n := couponPurgeBatch
+		if b == 2 {
+			n = 5
+		}
+		batch := make([]string, n)
+		for i := range batch {
+			batch[i] = fmt.Sprintf("C%d_%d", b, i)
+		}
+		batches = append(batches, batch)
+	}
+	var calls, purges int
+	tombstones := &mocks.CouponTombstoneRepositoryMock{
+		ExpiredFunc: func(ctx context.Context, undoWindow time.Duration, limit int) ([]string, error) {
+			calls++
+			return batches[calls-1], nil
+		},
+		PurgeFunc: func(ctx context.Context, tx database.TxQuerier, name string, undoWindow time.Duration) (bool, error) {
+			purges++
+			return purges%3 != 0, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetCouponDeletion(tombstones, time.Hour)
+
+	require.NoError(t, svc.PurgeDeleted(context.Background()))
+
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 2*couponPurgeBatch+5, purges)
+	stats := svc.CouponPurgeStats()
+	assert.Equal(t, int64(1), stats.Purges)
+	assert.Equal(t, int64(0), stats.PurgeErrors)
+	assert.Equal(t, int64(purges-purges/3), stats.Purged)
+	assert.NotZero(t, stats.LastPurge)
+}
+
+func TestCouponService_PurgeDeleted_Error(t *testing.T) {
+	tombstones := &mocks.CouponTombstoneRepositoryMock{
+		ExpiredFunc: func(ctx context.Context, undoWindow time.Duration, limit int) ([]string, error) {
+			return []string{"A", "B"}, nil
+		},
+		PurgeFunc: func(ctx context.Context, tx database.TxQuerier, name string, undoWindow time.Duration) (bool, error) {
+			if name == "B" {
+				return false, errors.New("connection reset")
+			}
+			return true, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetCouponDeletion(tombstones, time.Hour)
+
+	err := svc.PurgeDeleted(context.Background())
+
+	assert.ErrorContains(t, err, "coupon B")
+	stats := svc.CouponPurgeStats()
+	assert.Equal(t, int64(0), stats.Purges)
+	assert.Equal(t, int64(1), stats.PurgeErrors)
+	assert.Equal(t, int64(1), stats.Purged)
+}
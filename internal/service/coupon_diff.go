@@ -0,0 +1,133 @@
+package service
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// diffCoupon lists the configuration fields of desired that differ from existing.
+// Runtime state (remaining stock, claims) is not configuration and is ignored.
+// Channels are compared by allocated quota, since percentages are not stored.
+func diffCoupon(existing, desired *model.Coupon) []model.FieldDiff {
+	var diffs []model.FieldDiff
+
+	if existing.Amount != desired.Amount {
+		diffs = append(diffs, model.FieldDiff{Field: "amount", Current: existing.Amount, Requested: desired.Amount})
+	}
+
+	if existing.Unlimited != desired.Unlimited {
+		diffs = append(diffs, model.FieldDiff{Field: "unlimited", Current: existing.Unlimited, Requested: desired.Unlimited})
+	}
+
+	currentTags, requestedTags := sortedTags(existing.Tags), sortedTags(desired.Tags)
+	if !slices.Equal(currentTags, requestedTags) {
+		diffs = append(diffs, model.FieldDiff{Field: "tags", Current: currentTags, Requested: requestedTags})
+	}
+
+	currentQuotas, requestedQuotas := channelQuotaMap(existing.Channels), channelQuotaMap(desired.Channels)
+	if !maps.Equal(currentQuotas, requestedQuotas) {
+		diffs = append(diffs, model.FieldDiff{Field: "channels", Current: currentQuotas, Requested: requestedQuotas})
+	}
+
+	if !timePtrEqual(existing.OverflowAt, desired.OverflowAt) {
+		diffs = append(diffs, model.FieldDiff{Field: "overflow_at", Current: existing.OverflowAt, Requested: desired.OverflowAt})
+	}
+
+	currentTiers, requestedTiers := nonNilTiers(existing.Tiers), nonNilTiers(desired.Tiers)
+	if !slices.Equal(currentTiers, requestedTiers) {
+		diffs = append(diffs, model.FieldDiff{Field: "tiers", Current: currentTiers, Requested: requestedTiers})
+	}
+
+	if existing.CaptchaRequired != desired.CaptchaRequired {
+		diffs = append(diffs, model.FieldDiff{Field: "captcha_required", Current: existing.CaptchaRequired, Requested: desired.CaptchaRequired})
+	}
+
+	if !metadataEqual(existing.Metadata, desired.Metadata) {
+		diffs = append(diffs, model.FieldDiff{Field: "metadata", Current: existing.Metadata, Requested: desired.Metadata})
+	}
+
+	if existing.LowStockPercent != desired.LowStockPercent {
+		diffs = append(diffs, model.FieldDiff{Field: "low_stock_percent", Current: existing.LowStockPercent, Requested: desired.LowStockPercent})
+	}
+
+	currentRegions, requestedRegions := regionQuotaMap(existing.Regions), regionQuotaMap(desired.Regions)
+	if !maps.Equal(currentRegions, requestedRegions) {
+		diffs = append(diffs, model.FieldDiff{Field: "regions", Current: currentRegions, Requested: requestedRegions})
+	}
+
+	if existing.Parent != desired.Parent {
+		diffs = append(diffs, model.FieldDiff{Field: "parent", Current: existing.Parent, Requested: desired.Parent})
+	}
+
+	if !claimRetentionEqual(existing.ClaimRetention, desired.ClaimRetention) {
+		diffs = append(diffs, model.FieldDiff{Field: "claim_retention", Current: existing.ClaimRetention, Requested: desired.ClaimRetention})
+	}
+
+	if currentVisibility, requestedVisibility := couponVisibility(existing), couponVisibility(desired); currentVisibility != requestedVisibility {
+		diffs = append(diffs, model.FieldDiff{Field: "visibility", Current: currentVisibility, Requested: requestedVisibility})
+	}
+
+	if existing.HighProfile != desired.HighProfile {
+		diffs = append(diffs, model.FieldDiff{Field: "high_profile", Current: existing.HighProfile, Requested: desired.HighProfile})
+	}
+
+	if existing.Prerequisite != desired.Prerequisite {
+		diffs = append(diffs, model.FieldDiff{Field: "prerequisite", Current: existing.Prerequisite, Requested: desired.Prerequisite})
+	}
+
+	if existing.ExclusionGroup != desired.ExclusionGroup {
+		diffs = append(diffs, model.FieldDiff{Field: "exclusion_group", Current: existing.ExclusionGroup, Requested: desired.ExclusionGroup})
+	}
+
+	return diffs
+}
+
+// couponVisibility returns the visibility of c, public when unset.
+func couponVisibility(c *model.Coupon) string {
+	if c.Visibility == "" {
+		return model.VisibilityPublic
+	}
+	return c.Visibility
+}
+
+// sortedTags returns a sorted copy of the deduplicated tags, so tag order is not a difference.
+func sortedTags(tags []string) []string {
+	out := normalizeTags(tags)
+	slices.Sort(out)
+	return out
+}
+
+// channelQuotaMap maps each channel to its allocated quota.
+func channelQuotaMap(channels []model.ChannelQuota) map[string]int {
+	out := make(map[string]int, len(channels))
+	for _, q := range channels {
+		out[q.Channel] = q.Quota
+	}
+	return out
+}
+
+func timePtrEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// claimRetentionEqual reports whether two optional claim retentions are the same.
+func claimRetentionEqual(a, b *model.ClaimRetention) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// nonNilTiers returns tiers or an empty slice, so a coupon without tiers reports [] rather than null.
+func nonNilTiers(tiers []model.Tier) []model.Tier {
+	if tiers == nil {
+		return []model.Tier{}
+	}
+	return tiers
+}
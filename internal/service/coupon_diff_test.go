@@ -0,0 +1,117 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestDiffCoupon_IgnoresRuntimeStateAndTagOrder(t *testing.T) {
+	existing := &model.Coupon{Name: "PROMO", Amount: 100, RemainingAmount: 3, ClaimSequence: 97, Tags: []string{"b", "a"}}
+	desired := &model.Coupon{Name: "PROMO", Amount: 100, RemainingAmount: 100, Tags: []string{"a", "b", "a"}}
+
+	assert.Empty(t, diffCoupon(existing, desired))
+}
+
+func TestDiffCoupon_ReportsEachDifferingField(t *testing.T) {
+	t1 := time.Date(2026, 11, 30, 23, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	existing := &model.Coupon{
+		Amount:     100,
+		Tags:       []string{"a"},
+		Channels:   []model.ChannelQuota{{Channel: "app", Quota: 70}, {Channel: "web", Quota: 30}},
+		OverflowAt: &t1,
+		Tiers:      []model.Tier{{Name: "gold", Size: 10}},
+	}
+	desired := &model.Coupon{
+		Amount:          200,
+		Tags:            []string{"b"},
+		Channels:        []model.ChannelQuota{{Channel: "app", Quota: 100}, {Channel: "web", Quota: 100}},
+		OverflowAt:      &t2,
+		LowStockPercent: 10,
+		Parent:          "BF_TOTAL",
+	}
+
+	diffs := diffCoupon(existing, desired)
+
+	fields := make([]string, 0, len(diffs))
+	for _, d := range diffs {
+		fields = append(fields, d.Field)
+	}
+	assert.Equal(t, []string{"amount", "tags", "channels", "overflow_at", "tiers", "low_stock_percent", "parent"}, fields)
+	assert.Equal(t, model.FieldDiff{Field: "amount", Current: 100, Requested: 200}, diffs[0])
+	assert.Equal(t, model.FieldDiff{Field: "tiers", Current: existing.Tiers, Requested: []model.Tier{}}, diffs[4])
+}
+
+func TestDiffCoupon_OverflowAtComparesInstants(t *testing.T) {
+	utc := time.Date(2026, 11, 30, 23, 0, 0, 0, time.UTC)
+	local := utc.In(time.FixedZone("WIB", 7*60*60))
+	channels := []model.ChannelQuota{{Channel: "app", Quota: 10}}
+
+	assert.Empty(t, diffCoupon(
+		&model.Coupon{Amount: 10, Channels: channels, OverflowAt: &utc},
+		&model.Coupon{Amount: 10, Channels: channels, OverflowAt: &local},
+	))
+}
+
+func TestDiffCoupon_Metadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  string
+		requested string
+		differ    bool
+	}{
+		{"both absent", ``, ``, false},
+		{"absent and empty", ``, `{}`, false},
+		{"key order", `{"a": 1, "b": [true]}`, `{"b": [true], "a": 1}`, false},
+		{"changed value", `{"a": 1}`, `{"a": 2}`, true},
+		{"added", ``, `{"a": 1}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := &model.Coupon{Metadata: json.RawMessage(tt.existing)}
+			desired := &model.Coupon{Metadata: json.RawMessage(tt.requested)}
+
+			diffs := diffCoupon(existing, desired)
+
+			if tt.differ {
+				require.Len(t, diffs, 1)
+				assert.Equal(t, "metadata", diffs[0].Field)
+			} else {
+				assert.Empty(t, diffs)
+			}
+		})
+	}
+}
+
+func TestDiffCoupon_Visibility(t *testing.T) {
+	assert.Empty(t, diffCoupon(&model.Coupon{}, &model.Coupon{Visibility: model.VisibilityPublic}), "unset is public")
+
+	diffs := diffCoupon(&model.Coupon{Visibility: model.VisibilityPublic}, &model.Coupon{Visibility: model.VisibilityUnlisted})
+
+	assert.Equal(t, []model.FieldDiff{{Field: "visibility", Current: "public", Requested: "unlisted"}}, diffs)
+}
+
+func TestDiffCoupon_Prerequisite(t *testing.T) {
+	diffs := diffCoupon(&model.Coupon{Prerequisite: "SILVER"}, &model.Coupon{})
+
+	assert.Equal(t, []model.FieldDiff{{Field: "prerequisite", Current: "SILVER", Requested: ""}}, diffs)
+}
+
+func TestDiffCoupon_ExclusionGroup(t *testing.T) {
+	diffs := diffCoupon(&model.Coupon{ExclusionGroup: "welcome"}, &model.Coupon{ExclusionGroup: "spring"})
+
+	assert.Equal(t, []model.FieldDiff{{Field: "exclusion_group", Current: "welcome", Requested: "spring"}}, diffs)
+}
+
+func TestDiffCoupon_HighProfile(t *testing.T) {
+	diffs := diffCoupon(&model.Coupon{}, &model.Coupon{HighProfile: true})
+
+	assert.Equal(t, []model.FieldDiff{{Field: "high_profile", Current: false, Requested: true}}, diffs)
+}
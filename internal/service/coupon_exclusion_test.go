@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// groupClaims returns a claim repository mock keeping exclusion group claims in claimed,
+// keyed by group and user.
+func groupClaims(claimed map[[2]string]string) *mocks.ClaimRepositoryMock {
+	return &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+		InsertExclusionClaimFunc: func(ctx context.Context, tx database.TxQuerier, group, userID, couponName string) error {
+			key := [2]string{group, userID}
+			if _, ok := claimed[key]; ok {
+				return apperr.ErrExclusionGroupClaimed
+			}
+			claimed[key] = couponName
+			return nil
+		},
+	}
+}
+
+func TestCouponService_ClaimCoupon_ExclusionGroup(t *testing.T) {
+	coupons := map[string]*model.Coupon{
+		"WELCOME_A": {Name: "WELCOME_A", Amount: 10, RemainingAmount: 10, ExclusionGroup: "welcome"},
+		"WELCOME_B": {Name: "WELCOME_B", Amount: 10, RemainingAmount: 10, ExclusionGroup: "welcome"},
+		"PROMO":     {Name: "PROMO", Amount: 10, RemainingAmount: 10},
+	}
+	var calls []string
+	claimed := map[[2]string]string{}
+	claims := groupClaims(claimed)
+	svc := NewCouponServiceWithTransactor(passThroughTx(), familyRepo(coupons, &calls), claims)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "WELCOME_A"))
+	require.NoError(t, err)
+	assert.Equal(t, map[[2]string]string{{"welcome", "user_001"}: "WELCOME_A"}, claimed)
+
+	calls = nil
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "WELCOME_B"))
+	assert.ErrorIs(t, err, apperr.ErrExclusionGroupClaimed)
+	assert.Equal(t, []string{"lock WELCOME_B"}, calls, "no stock is taken")
+
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_002", "WELCOME_B"))
+	require.NoError(t, err, "the group is per user")
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	require.NoError(t, err)
+	assert.Len(t, claims.InsertExclusionClaimCalls(), 3, "coupons without a group record none")
+}
+
+func TestCouponService_ImportClaims_ExclusionGroupClaimed(t *testing.T) {
+	coupons := map[string]*model.Coupon{
+		"WELCOME_B": {Name: "WELCOME_B", Amount: 10, RemainingAmount: 10, ExclusionGroup: "welcome"},
+	}
+	var calls []string
+	claimed := map[[2]string]string{{"welcome", "user_001"}: "WELCOME_A"}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), familyRepo(coupons, &calls), groupClaims(claimed))
+
+	_, _, err := svc.claim(context.Background(), nil, claimRequest("user_001", "WELCOME_B"), time.Time{}, true)
+
+	require.NoError(t, err, "historical claims are kept")
+	assert.Equal(t, []string{"lock WELCOME_B", "stock WELCOME_B"}, calls)
+}
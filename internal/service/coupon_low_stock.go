@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// isLowStock reports whether remaining of amount is at or below the low stock
+// watermark of percent (0 for none).
+func isLowStock(amount, remaining, percent int) bool {
+	return percent > 0 && remaining*100 <= amount*percent
+}
+
+// couponLowStock reports whether the coupon's remaining stock is at or below its low
+// stock watermark.
+func couponLowStock(c *model.Coupon) bool {
+	return isLowStock(c.Amount, c.RemainingAmount, c.LowStockPercent)
+}
+
+// claimReachesLowStock reports whether claiming one unit of the locked coupon c takes
+// its stock across the low stock watermark. A coupon topped up above the watermark
+// crosses it again.
+func claimReachesLowStock(c *model.Coupon) bool {
+	return !couponLowStock(c) && isLowStock(c.Amount, c.RemainingAmount-1, c.LowStockPercent)
+}
+
+// announceLowStock logs that a claim took the coupon to its low stock watermark and
+// publishes a coupon.low_stock event, once the claim has committed.
+func (s *CouponService) announceLowStock(ctx context.Context, name string) {
+	log.Info().
+		Str("coupon_name", redact.Value(name)).
+		Msg("coupon stock low")
+	s.publishCoupon(ctx, model.CouponEventLowStock, name)
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestIsLowStock(t *testing.T) {
+	tests := []struct {
+		amount, remaining, percent int
+		want                       bool
+	}{
+		{100, 11, 10, false},
+		{100, 10, 10, true},
+		{100, 0, 10, true},
+		{100, 0, 0, false}, // No watermark
+		{7, 1, 10, false},  // 14% left
+		{7, 0, 10, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isLowStock(tt.amount, tt.remaining, tt.percent),
+			"%d of %d at %d%%", tt.remaining, tt.amount, tt.percent)
+	}
+}
+
+// lowStockClaimService returns a service claiming a coupon of 100 with remaining units
+// left and a 10% low stock watermark, publishing events to events.
+func lowStockClaimService(remaining int, events *recordingPublisher) *CouponService {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: remaining, LowStockPercent: 10}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: remaining - 1, LowStockPercent: 10}, nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+	svc.SetEventPublisher(events)
+	return svc
+}
+
+func TestCouponService_ClaimCoupon_PublishesLowStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining int
+		want      []string
+	}{
+		{"crosses the watermark", 11, []string{"coupon.low_stock PROMO"}},
+		{"above the watermark", 12, nil},
+		{"already low", 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &recordingPublisher{}
+			svc := lowStockClaimService(tt.remaining, events)
+
+			_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, events.events)
+			assert.Equal(t, []string{"user_001 PROMO"}, events.claims)
+		})
+	}
+}
+
+func TestCouponService_GetByName_LowStock(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 5, LowStockPercent: 10}, nil
+		},
+	}
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), couponRepo, noClaims())
+
+	resp, err := svc.GetByName(context.Background(), "PROMO")
+
+	require.NoError(t, err)
+	assert.Equal(t, 10, resp.LowStockPercent)
+	assert.True(t, resp.LowStock)
+}
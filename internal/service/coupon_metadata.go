@@ -0,0 +1,71 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// MetadataSchema validates coupon metadata documents, e.g. against a JSON Schema
+// (see package metaschema).
+type MetadataSchema interface {
+	// Validate returns an error describing how doc violates the schema.
+	Validate(doc []byte) error
+}
+
+// SetMetadataSchema validates the metadata of coupons created through this service
+// against schema. Coupons stored before the schema was set are not revalidated.
+// A nil schema only requires metadata to be a JSON object.
+func (s *CouponService) SetMetadataSchema(schema MetadataSchema) {
+	s.metadataSchema = schema
+}
+
+// normalizeMetadata returns the metadata of a create request, nil when absent or null.
+// Returns a *apperr.MetadataError unless it is a JSON object.
+func normalizeMetadata(metadata json.RawMessage) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(metadata)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(trimmed, &doc); err != nil {
+		return nil, &apperr.MetadataError{Reason: "must be a JSON object"}
+	}
+	return trimmed, nil
+}
+
+// validateMetadata checks coupon's metadata against the metadata schema, if set.
+// Coupons without metadata are validated as an empty object, so schemas with required
+// properties make metadata mandatory.
+func (s *CouponService) validateMetadata(coupon *model.Coupon) error {
+	if s.metadataSchema == nil {
+		return nil
+	}
+	doc := coupon.Metadata
+	if doc == nil {
+		doc = json.RawMessage("{}")
+	}
+	if err := s.metadataSchema.Validate(doc); err != nil {
+		return &apperr.MetadataError{Reason: "violates the metadata schema: " + err.Error()}
+	}
+	return nil
+}
+
+// metadataEqual reports whether a and b hold the same JSON object, ignoring key order
+// and formatting. Absent metadata equals an empty object.
+func metadataEqual(a, b json.RawMessage) bool {
+	var docA, docB map[string]any
+	if len(a) > 0 && json.Unmarshal(a, &docA) != nil {
+		return false
+	}
+	if len(b) > 0 && json.Unmarshal(b, &docB) != nil {
+		return false
+	}
+	if len(docA) == 0 && len(docB) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(docA, docB)
+}
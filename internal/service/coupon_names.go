@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/bloom"
+)
+
+// CouponNameFilterStats is a snapshot of coupon name filter counters.
+type CouponNameFilterStats struct {
+	Rejected      int64 `json:"rejected"`       // Lookups answered 404 without a database read
+	Names         int   `json:"names"`          // Names in the filter
+	Rebuilds      int64 `json:"rebuilds"`       // Successful rebuilds
+	RebuildErrors int64 `json:"rebuild_errors"` // Failed rebuilds (the previous filter stays in use)
+}
+
+// couponNameFilter is a Bloom filter of coupon names, so claims and reads of coupons
+// that do not exist (typos, enumeration scans) are answered 404 without a database
+// read. Until the first rebuild every name passes. Coupons created through this
+// instance are added as they are created; coupons created through other instances are
+// 404 here until the next rebuild.
+type couponNameFilter struct {
+	capacity int
+	fpRate   float64
+	current  atomic.Pointer[bloom.Filter] // nil until the first rebuild
+	pending  atomic.Pointer[bloom.Filter] // The filter being rebuilt, if any
+
+	rejected      atomic.Int64
+	rebuilds      atomic.Int64
+	rebuildErrors atomic.Int64
+}
+
+// SetCouponNameFilter enables the coupon name filter, sized for at least capacity
+// names at false-positive rate fpRate. It is empty, letting every name pass, until
+// RebuildCouponNameFilter (or RunCouponNameFilter) loads the names.
+// A capacity of 0 disables it.
+func (s *CouponService) SetCouponNameFilter(capacity int, fpRate float64) {
+	if capacity <= 0 {
+		s.names = nil
+		return
+	}
+	s.names = &couponNameFilter{capacity: capacity, fpRate: fpRate}
+}
+
+// CouponNameFilterStats returns coupon name filter counters (zero when disabled).
+func (s *CouponService) CouponNameFilterStats() CouponNameFilterStats {
+	if s.names == nil {
+		return CouponNameFilterStats{}
+	}
+	stats := CouponNameFilterStats{
+		Rejected:      s.names.rejected.Load(),
+		Rebuilds:      s.names.rebuilds.Load(),
+		RebuildErrors: s.names.rebuildErrors.Load(),
+	}
+	if f := s.names.current.Load(); f != nil {
+		stats.Names = f.Len()
+	}
+	return stats
+}
+
+// RebuildCouponNameFilter reloads the coupon name filter from the coupon repository.
+// Coupons created while it runs are added to both the old and the new filter.
+// It must not run concurrently with itself.
+func (s *CouponService) RebuildCouponNameFilter(ctx context.Context) error {
+	f := s.names
+	if f == nil {
+		return nil
+	}
+
+	next := bloom.New(f.capacity, f.fpRate)
+	f.pending.Store(next) // Before reading, so coupons created during the read are kept
+	defer f.pending.Store(nil)
+
+	names, err := s.couponRepo.Names(ctx)
+	if err != nil {
+		f.rebuildErrors.Add(1)
+		return fmt.Errorf("list coupon names: %w", err)
+	}
+	if len(names) > f.capacity {
+		// Outgrown: this filter still has no false negatives, but more false positives.
+		// Size the next one for the names we have plus room to grow.
+		f.capacity = 2 * len(names)
+	}
+	for _, name := range names {
+		next.Add(name)
+	}
+	f.current.Store(next)
+	f.rebuilds.Add(1)
+	return nil
+}
+
+// RunCouponNameFilter rebuilds the coupon name filter now and then every interval
+// until ctx is cancelled.
+func (s *CouponService) RunCouponNameFilter(ctx context.Context, interval time.Duration) {
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RebuildCouponNameFilter(ctx); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("coupon name filter rebuild failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// couponMayExist reports whether the coupon name filter lets name pass, counting
+// rejections. It is always true when the filter is disabled or not yet built.
+func (s *CouponService) couponMayExist(name string) bool {
+	if s.names == nil {
+		return true
+	}
+	f := s.names.current.Load()
+	if f == nil || f.MayContain(name) {
+		return true
+	}
+	s.names.rejected.Add(1)
+	return false
+}
+
+// addCouponName records that a coupon named name exists.
+func (s *CouponService) addCouponName(name string) {
+	if s.names == nil {
+		return
+	}
+	if f := s.names.current.Load(); f != nil {
+		f.Add(name)
+	}
+	if f := s.names.pending.Load(); f != nil {
+		f.Add(name)
+	}
+}
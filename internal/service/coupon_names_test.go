@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+)
+
+// newNameFilterService returns a service with the coupon name filter enabled over a
+// repository holding names. Reads of any coupon reach GetByName, recorded in reads.
+func newNameFilterService(names []string, reads *[]string) (*CouponService, *mocks.CouponRepositoryMock) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		NamesFunc: func(ctx context.Context) ([]string, error) {
+			return names, nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			*reads = append(*reads, name)
+			return nil, nil
+		},
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error { return nil },
+	}
+	svc := NewCouponServiceWithTransactor(&mocks.TransactorMock{}, couponRepo, &mocks.ClaimRepositoryMock{})
+	svc.SetCouponNameFilter(100, 0.01)
+	return svc, couponRepo
+}
+
+func TestCouponNameFilter_RejectsUnknownNames(t *testing.T) {
+	var reads []string
+	svc, _ := newNameFilterService([]string{"PROMO"}, &reads)
+	require.NoError(t, svc.RebuildCouponNameFilter(context.Background()))
+
+	_, err := svc.GetByName(context.Background(), "PROMOO")
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	_, err = svc.ListClaims(context.Background(), "PROMOO", 0, 100)
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMOO"))
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound, "rejected before starting a transaction")
+	assert.Empty(t, reads)
+
+	_, err = svc.GetByName(context.Background(), "PROMO")
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound, "known names are read from the repository")
+	assert.Equal(t, []string{"PROMO"}, reads)
+
+	assert.Equal(t, CouponNameFilterStats{Rejected: 3, Names: 1, Rebuilds: 1}, svc.CouponNameFilterStats())
+}
+
+func TestCouponNameFilter_PassesEverythingUntilBuilt(t *testing.T) {
+	var reads []string
+	svc, _ := newNameFilterService(nil, &reads)
+
+	_, err := svc.GetByName(context.Background(), "PROMO")
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	assert.Equal(t, []string{"PROMO"}, reads)
+	assert.Zero(t, svc.CouponNameFilterStats().Rejected)
+}
+
+func TestCouponNameFilter_AddsCreatedCoupons(t *testing.T) {
+	var reads []string
+	svc, _ := newNameFilterService(nil, &reads)
+	require.NoError(t, svc.RebuildCouponNameFilter(context.Background()))
+
+	require.NoError(t, svc.Create(context.Background(), &model.CreateCouponRequest{Name: "NEW", Amount: intPtr(10)}))
+	_, _ = svc.GetByName(context.Background(), "NEW")
+
+	assert.Equal(t, []string{"NEW"}, reads)
+}
+
+func TestCouponNameFilter_KeepsCouponsCreatedDuringRebuild(t *testing.T) {
+	var reads []string
+	svc, couponRepo := newNameFilterService(nil, &reads)
+	couponRepo.NamesFunc = func(ctx context.Context) ([]string, error) {
+		// Created after the names were read
+		defer func() {
+			require.NoError(t, svc.Create(ctx, &model.CreateCouponRequest{Name: "NEW", Amount: intPtr(10)}))
+		}()
+		return []string{"PROMO"}, nil
+	}
+
+	require.NoError(t, svc.RebuildCouponNameFilter(context.Background()))
+	_, _ = svc.GetByName(context.Background(), "NEW")
+
+	assert.Equal(t, []string{"NEW"}, reads)
+}
+
+func TestCouponNameFilter_RebuildErrorKeepsPreviousFilter(t *testing.T) {
+	var reads []string
+	svc, couponRepo := newNameFilterService([]string{"PROMO"}, &reads)
+	require.NoError(t, svc.RebuildCouponNameFilter(context.Background()))
+	dbErr := errors.New("connection refused")
+	couponRepo.NamesFunc = func(ctx context.Context) ([]string, error) { return nil, dbErr }
+
+	err := svc.RebuildCouponNameFilter(context.Background())
+
+	assert.ErrorIs(t, err, dbErr)
+	_, _ = svc.GetByName(context.Background(), "PROMO")
+	assert.Equal(t, []string{"PROMO"}, reads)
+	assert.Equal(t, int64(1), svc.CouponNameFilterStats().RebuildErrors)
+}
+
+func TestCouponNameFilter_Disabled(t *testing.T) {
+	var reads []string
+	svc, _ := newNameFilterService(nil, &reads)
+	svc.SetCouponNameFilter(0, 0)
+
+	require.NoError(t, svc.RebuildCouponNameFilter(context.Background()))
+	_, _ = svc.GetByName(context.Background(), "PROMO")
+
+	assert.Equal(t, []string{"PROMO"}, reads)
+	assert.Equal(t, CouponNameFilterStats{}, svc.CouponNameFilterStats())
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// DefaultChangelogLimit is how many entries AddNote returns with the note: the first
+// page of the changelog.
+const DefaultChangelogLimit = 100
+
+// errNoAuditLog is returned by notes and changelogs when SetAuditLog was not called.
+var errNoAuditLog = errors.New("audit log not configured")
+
+// AddNote records an operator's note on the coupon name in the audit log, e.g. why its
+// stock was raised during an incident, and returns the coupon's changelog with it.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (s *CouponService) AddNote(ctx context.Context, name string, req *model.CouponNoteRequest) (*model.CouponChangelogResponse, error) {
+	if req == nil {
+		return nil, apperr.ErrInvalidRequest
+	}
+	if s.audit == nil {
+		return nil, errNoAuditLog
+	}
+	if !s.couponMayExist(name) {
+		return nil, apperr.ErrCouponNotFound
+	}
+	coupon, err := s.couponRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("get coupon: %w", err)
+	}
+	if coupon == nil {
+		return nil, apperr.ErrCouponNotFound
+	}
+
+	details := map[string]any{"note": req.Note}
+	if req.Author != "" {
+		details["author"] = req.Author
+	}
+	err = s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+		return s.audit.Insert(ctx, tx, &model.AuditEntry{
+			Action:  model.AuditActionCouponNote,
+			Subject: name,
+			Details: details,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("coupon_name", redact.Value(name)).Msg("coupon note added")
+
+	return s.Changelog(ctx, name, nil, DefaultChangelogLimit)
+}
+
+// Changelog returns a page of up to limit of the coupon name's notes and terminations,
+// newest first, starting after the entry at after (nil for the newest). The changelog
+// of a deleted coupon stays readable, e.g. for an incident retro.
+// Returns apperr.ErrCouponNotFound if the coupon has no changelog and doesn't exist.
+func (s *CouponService) Changelog(ctx context.Context, name string, after *model.AuditPosition, limit int) (*model.CouponChangelogResponse, error) {
+	if s.audit == nil {
+		return nil, errNoAuditLog
+	}
+	entries, err := s.audit.ListBySubject(ctx, name, model.CouponChangelogActions, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 && after == nil {
+		coupon, err := s.couponRepo.GetByName(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("get coupon: %w", err)
+		}
+		if coupon == nil {
+			return nil, apperr.ErrCouponNotFound
+		}
+	}
+
+	changes := make([]model.ChangelogEntry, 0, len(entries))
+	for _, e := range entries {
+		changes = append(changes, model.ChangelogEntry{
+			ID:        e.ID,
+			Action:    e.Action,
+			Details:   e.Details,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	page := model.NewPage(changes, limit, func(e model.ChangelogEntry) string {
+		return model.AuditPosition{CreatedAt: e.CreatedAt, ID: e.ID}.Key()
+	})
+	return &model.CouponChangelogResponse{CouponName: name, Page: page}, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// notesService returns a service whose coupons are names, keeping audit entries in
+// memory.
+func notesService(entries *[]model.AuditEntry, names ...string) *CouponService {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			for _, n := range names {
+				if n == name {
+					return &model.Coupon{Name: name}, nil
+				}
+			}
+			return nil, nil
+		},
+	}
+	audit := &mocks.AuditRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, entry *model.AuditEntry) error {
+			e := *entry
+			e.ID = int64(len(*entries) + 1)
+			e.CreatedAt = time.Date(2026, 11, 27, 9, len(*entries), 0, 0, time.UTC)
+			*entries = append(*entries, e)
+			return nil
+		},
+		ListBySubjectFunc: func(ctx context.Context, subject string, actions []string, after *model.AuditPosition, limit int) ([]model.AuditEntry, error) {
+			out := []model.AuditEntry{}
+			for i := len(*entries) - 1; i >= 0 && len(out) < limit; i-- {
+				if e := (*entries)[i]; e.Subject == subject && (after == nil || e.ID < after.ID) {
+					out = append(out, e)
+				}
+			}
+			return out, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, &mocks.ClaimRepositoryMock{})
+	svc.SetAuditLog(audit)
+	return svc
+}
+
+func TestCouponService_AddNote(t *testing.T) {
+	entries := []model.AuditEntry{{ID: 1, Action: model.AuditActionCouponTerminated, Subject: "PROMO", Details: map[string]any{"reason": "wrong amount"}}}
+	svc := notesService(&entries, "PROMO")
+
+	resp, err := svc.AddNote(context.Background(), "PROMO", &model.CouponNoteRequest{Note: "re-created with amount 1000", Author: "ops-alice"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "PROMO", resp.CouponName)
+	require.Len(t, resp.Items, 2)
+	assert.Equal(t, model.AuditActionCouponNote, resp.Items[0].Action, "newest first")
+	assert.Equal(t, map[string]any{"note": "re-created with amount 1000", "author": "ops-alice"}, resp.Items[0].Details)
+	assert.Equal(t, model.AuditActionCouponTerminated, resp.Items[1].Action)
+}
+
+func TestCouponService_AddNote_CouponNotFound(t *testing.T) {
+	var entries []model.AuditEntry
+	svc := notesService(&entries)
+
+	_, err := svc.AddNote(context.Background(), "MISSING", &model.CouponNoteRequest{Note: "x"})
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	assert.Empty(t, entries)
+}
+
+func TestCouponService_Changelog(t *testing.T) {
+	entries := []model.AuditEntry{{ID: 1, Action: model.AuditActionCouponNote, Subject: "GONE", Details: map[string]any{"note": "deleting"}}}
+	svc := notesService(&entries, "PROMO")
+
+	resp, err := svc.Changelog(context.Background(), "GONE", nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, resp.Items, 1, "deleted coupons keep their changelog")
+
+	resp, err = svc.Changelog(context.Background(), "PROMO", nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Items)
+	assert.NotNil(t, resp.Items)
+
+	_, err = svc.Changelog(context.Background(), "MISSING", nil, 10)
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+}
+
+func TestCouponService_Changelog_Pages(t *testing.T) {
+	var entries []model.AuditEntry
+	svc := notesService(&entries, "PROMO")
+	for _, note := range []string{"first", "second", "third"} {
+		_, err := svc.AddNote(context.Background(), "PROMO", &model.CouponNoteRequest{Note: note})
+		require.NoError(t, err)
+	}
+
+	var notes []any
+	var after *model.AuditPosition
+	for range 3 {
+		resp, err := svc.Changelog(context.Background(), "PROMO", after, 2)
+		require.NoError(t, err)
+		for _, e := range resp.Items {
+			notes = append(notes, e.Details["note"])
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		key, err := model.DecodeCursor(resp.NextCursor)
+		require.NoError(t, err)
+		pos, err := model.ParseAuditPosition(key)
+		require.NoError(t, err)
+		after = &pos
+	}
+	assert.Equal(t, []any{"third", "second", "first"}, notes)
+}
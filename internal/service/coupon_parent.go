@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// validParent reports whether parent may be the parent of the coupon named child:
+// hierarchies are one level deep, so the parent must not have a parent itself, and
+// it must have a budget to share, so it must not be unlimited.
+func validParent(child string, parent *model.Coupon) bool {
+	return parent != nil && parent.Name != child && parent.Parent == "" && !parent.Unlimited
+}
+
+// checkParent checks that the parent of coupon, if it has one, exists and is not
+// itself a child or unlimited. Returns apperr.ErrInvalidParent otherwise.
+func (s *CouponService) checkParent(ctx context.Context, coupon *model.Coupon) error {
+	if coupon.Parent == "" {
+		return nil
+	}
+	parent, err := s.couponRepo.GetByName(ctx, coupon.Parent)
+	if err != nil {
+		return fmt.Errorf("get parent coupon: %w", err)
+	}
+	if !validParent(coupon.Name, parent) {
+		return apperr.ErrInvalidParent
+	}
+	return nil
+}
+
+// lockParent locks the row of the locked coupon's parent within tx and checks that it
+// is enabled and has budget left. Returns nil for coupons without a parent.
+// Coupon rows are locked child first, the order ListForUpdate locks them in too.
+// A deleted parent has no budget left.
+func (s *CouponService) lockParent(ctx context.Context, tx database.TxQuerier, coupon *model.Coupon) (*model.Coupon, error) {
+	if coupon.Parent == "" {
+		return nil, nil
+	}
+	parent, err := s.couponRepo.GetCouponForUpdate(ctx, tx, coupon.Parent)
+	if err != nil {
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return nil, apperr.ErrNoStock
+		}
+		return nil, fmt.Errorf("get parent coupon for update: %w", err)
+	}
+	if parent.Disabled {
+		return nil, apperr.ErrCouponDisabled
+	}
+	if parent.RemainingAmount <= 0 {
+		return nil, apperr.ErrNoStock
+	}
+	return parent, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// familyRepo returns a coupon repository holding coupons, recording the rows it locks
+// and the stock it decrements in calls.
+func familyRepo(coupons map[string]*model.Coupon, calls *[]string) *mocks.CouponRepositoryMock {
+	return &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			*calls = append(*calls, "lock "+name)
+			c, ok := coupons[name]
+			if !ok {
+				return nil, apperr.ErrCouponNotFound
+			}
+			return c, nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return coupons[name], nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			*calls = append(*calls, "stock "+name)
+			return nil
+		},
+		DecrementBudgetFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			*calls = append(*calls, "budget "+name)
+			return nil
+		},
+	}
+}
+
+func TestCouponService_ClaimCoupon_ChildTakesParentBudget(t *testing.T) {
+	var calls []string
+	coupons := map[string]*model.Coupon{
+		"A_TOTAL": {Name: "A_TOTAL", Amount: 100, RemainingAmount: 11, LowStockPercent: 10},
+		"EU":      {Name: "EU", Amount: 50, RemainingAmount: 50, Parent: "A_TOTAL"},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+	events := &recordingPublisher{}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), familyRepo(coupons, &calls), claimRepo)
+	svc.SetEventPublisher(events)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "EU"))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lock EU", "lock A_TOTAL", "stock EU", "budget A_TOTAL"}, calls,
+		"the child's row is locked before its parent's")
+	assert.Equal(t, []string{"coupon.low_stock A_TOTAL"}, events.events)
+}
+
+func TestCouponService_ClaimCoupon_ParentRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent *model.Coupon
+		want   error
+	}{
+		{"no budget", &model.Coupon{Name: "A_TOTAL", Amount: 100, RemainingAmount: 0}, apperr.ErrNoStock},
+		{"disabled", &model.Coupon{Name: "A_TOTAL", Amount: 100, RemainingAmount: 10, Disabled: true}, apperr.ErrCouponDisabled},
+		{"deleted", nil, apperr.ErrNoStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			coupons := map[string]*model.Coupon{
+				"EU": {Name: "EU", Amount: 50, RemainingAmount: 50, Parent: "A_TOTAL"},
+			}
+			if tt.parent != nil {
+				coupons["A_TOTAL"] = tt.parent
+			}
+			svc := NewCouponServiceWithTransactor(passThroughTx(), familyRepo(coupons, &calls), &mocks.ClaimRepositoryMock{})
+
+			_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "EU"))
+
+			assert.ErrorIs(t, err, tt.want)
+			assert.Equal(t, []string{"lock EU", "lock A_TOTAL"}, calls, "nothing is claimed")
+		})
+	}
+}
+
+func TestCouponService_Create_InvalidParent(t *testing.T) {
+	coupons := map[string]*model.Coupon{
+		"A_TOTAL": {Name: "A_TOTAL", Amount: 100, RemainingAmount: 100},
+		"EU":      {Name: "EU", Amount: 50, RemainingAmount: 50, Parent: "A_TOTAL"},
+	}
+	for _, parent := range []string{"MISSING", "EU", "EU_PARIS"} {
+		var calls []string
+		svc := NewCouponServiceWithTransactor(passThroughTx(), familyRepo(coupons, &calls), &mocks.ClaimRepositoryMock{})
+
+		err := svc.Create(context.Background(), &model.CreateCouponRequest{Name: "EU_PARIS", Amount: intPtr(10), Parent: parent})
+
+		assert.ErrorIs(t, err, apperr.ErrInvalidParent, "parent %s", parent)
+	}
+}
+
+func TestPlanManifest_Parents(t *testing.T) {
+	existing := []model.Coupon{{Name: "A_TOTAL", Amount: 100}}
+	desired := []*model.Coupon{
+		{Name: "A_TOTAL", Amount: 100},
+		{Name: "EU", Amount: 50, Parent: "A_TOTAL"},
+		{Name: "US", Amount: 50, Parent: "B_TOTAL"},
+		{Name: "B_TOTAL", Amount: 100},
+		{Name: "US_NY", Amount: 10, Parent: "US"},
+	}
+
+	steps := planManifest(existing, desired)
+
+	actions := map[string]string{}
+	for _, step := range steps {
+		actions[step.change.Name] = step.change.Action
+	}
+	assert.Equal(t, map[string]string{
+		"A_TOTAL": model.ApplyActionUnchanged,
+		"B_TOTAL": model.ApplyActionCreate,
+		"EU":      model.ApplyActionCreate,
+		"US":      model.ApplyActionCreate, // Parent created by the same manifest
+		"US_NY":   model.ApplyActionConflict,
+	}, actions)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// checkPrerequisite checks that the prerequisite of coupon, if it has one, exists and
+// is not the coupon itself. Coupons are created one at a time after their
+// prerequisites, so prerequisites cannot form a cycle. Returns
+// apperr.ErrInvalidPrerequisite otherwise.
+func (s *CouponService) checkPrerequisite(ctx context.Context, coupon *model.Coupon) error {
+	if coupon.Prerequisite == "" {
+		return nil
+	}
+	if coupon.Prerequisite == coupon.Name {
+		return apperr.ErrInvalidPrerequisite
+	}
+	prerequisite, err := s.couponRepo.GetByName(ctx, coupon.Prerequisite)
+	if err != nil {
+		return fmt.Errorf("get prerequisite coupon: %w", err)
+	}
+	if prerequisite == nil {
+		return apperr.ErrInvalidPrerequisite
+	}
+	return nil
+}
+
+// validPrerequisites reports whether following prerequisites from the coupon named
+// name through coupons ends at a coupon without one, rather than at a coupon not among
+// coupons or in a cycle no user could ever enter.
+func validPrerequisites(name string, coupons map[string]*model.Coupon) bool {
+	next := coupons[name].Prerequisite
+	for range len(coupons) {
+		if next == "" {
+			return true
+		}
+		c, ok := coupons[next]
+		if !ok {
+			return false
+		}
+		next = c.Prerequisite
+	}
+	return false
+}
+
+// checkPrerequisiteClaimed checks within tx that userID has claimed the prerequisite
+// of coupon, if it has one. The lookup reads the claims' (user, coupon) unique index
+// without locking the prerequisite's row, so claims of the two coupons do not contend.
+// Returns apperr.ErrPrerequisiteNotClaimed otherwise.
+func (s *CouponService) checkPrerequisiteClaimed(ctx context.Context, tx database.TxQuerier, coupon *model.Coupon, userID string) error {
+	if coupon.Prerequisite == "" {
+		return nil
+	}
+	claimed, err := s.claimRepo.ClaimedUsers(ctx, tx, coupon.Prerequisite, []string{userID})
+	if err != nil {
+		return fmt.Errorf("check prerequisite claim: %w", err)
+	}
+	if len(claimed) == 0 {
+		return apperr.ErrPrerequisiteNotClaimed
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// loyaltyClaims returns a claim repository mock where only the users in claimed have
+// claimed SILVER.
+func loyaltyClaims(claimed ...string) *mocks.ClaimRepositoryMock {
+	return &mocks.ClaimRepositoryMock{
+		ClaimedUsersFunc: func(ctx context.Context, tx database.TxQuerier, couponName string, userIDs []string) ([]string, error) {
+			users := []string{}
+			if couponName != "SILVER" {
+				return users, nil
+			}
+			for _, u := range userIDs {
+				for _, c := range claimed {
+					if u == c {
+						users = append(users, u)
+					}
+				}
+			}
+			return users, nil
+		},
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+}
+
+func TestCouponService_ClaimCoupon_Prerequisite(t *testing.T) {
+	coupons := map[string]*model.Coupon{
+		"SILVER": {Name: "SILVER", Amount: 100, RemainingAmount: 100},
+		"GOLD":   {Name: "GOLD", Amount: 10, RemainingAmount: 10, Prerequisite: "SILVER"},
+	}
+	var calls []string
+	claims := loyaltyClaims("user_silver")
+	svc := NewCouponServiceWithTransactor(passThroughTx(), familyRepo(coupons, &calls), claims)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_new", "GOLD"))
+	assert.ErrorIs(t, err, apperr.ErrPrerequisiteNotClaimed)
+	assert.Empty(t, claims.InsertCalls())
+
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_silver", "GOLD"))
+	require.NoError(t, err)
+	require.Len(t, claims.ClaimedUsersCalls(), 2)
+	assert.Equal(t, "SILVER", claims.ClaimedUsersCalls()[1].CouponName)
+	assert.Equal(t, []string{"lock GOLD", "lock GOLD", "stock GOLD"}, calls, "the prerequisite's row is not locked")
+}
+
+func TestCouponService_Create_InvalidPrerequisite(t *testing.T) {
+	coupons := map[string]*model.Coupon{
+		"SILVER": {Name: "SILVER", Amount: 100, RemainingAmount: 100},
+	}
+	for _, prerequisite := range []string{"MISSING", "GOLD"} {
+		var calls []string
+		repo := familyRepo(coupons, &calls)
+		svc := NewCouponServiceWithTransactor(passThroughTx(), repo, &mocks.ClaimRepositoryMock{})
+
+		err := svc.Create(context.Background(), &model.CreateCouponRequest{Name: "GOLD", Amount: intPtr(10), Prerequisite: prerequisite})
+
+		assert.ErrorIs(t, err, apperr.ErrInvalidPrerequisite, "prerequisite %s", prerequisite)
+		assert.Empty(t, repo.InsertCalls())
+	}
+}
+
+func TestPlanManifest_Prerequisites(t *testing.T) {
+	existing := []model.Coupon{{Name: "SILVER", Amount: 100}}
+	desired := []*model.Coupon{
+		{Name: "SILVER", Amount: 100},
+		{Name: "GOLD", Amount: 10, Prerequisite: "SILVER"},
+		{Name: "PLATINUM", Amount: 5, Prerequisite: "GOLD"}, // Created by the same manifest
+		{Name: "ORPHAN", Amount: 5, Prerequisite: "MISSING"},
+		{Name: "LOOP_A", Amount: 5, Prerequisite: "LOOP_B"},
+		{Name: "LOOP_B", Amount: 5, Prerequisite: "LOOP_A"},
+	}
+
+	steps := planManifest(existing, desired)
+
+	actions := map[string]string{}
+	for _, step := range steps {
+		actions[step.change.Name] = step.change.Action
+	}
+	assert.Equal(t, map[string]string{
+		"SILVER":   model.ApplyActionUnchanged,
+		"GOLD":     model.ApplyActionCreate,
+		"PLATINUM": model.ApplyActionCreate,
+		"ORPHAN":   model.ApplyActionConflict,
+		"LOOP_A":   model.ApplyActionConflict,
+		"LOOP_B":   model.ApplyActionConflict,
+	}, actions)
+}
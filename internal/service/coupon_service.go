@@ -4,141 +4,815 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/cache"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/hedge"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/pacing"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/stockwait"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
 	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
-// CouponRepositoryInterface defines the interface for coupon data access.
-type CouponRepositoryInterface interface {
-	Insert(ctx context.Context, coupon *model.Coupon) error
-	GetByName(ctx context.Context, name string) (*model.Coupon, error)
-	GetCouponForUpdate(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error)
-	DecrementStock(ctx context.Context, tx database.TxQuerier, name string) error
-}
+// CouponService provides business logic for coupon operations.
+type CouponService struct {
+	tx         ports.Transactor
+	couponRepo ports.CouponRepository
+	claimRepo  ports.ClaimRepository
+	cache      *cache.LRU[string, *model.CouponResponse] // nil when caching is disabled
+	dedup      *claimDedup                               // nil when claim deduplication is disabled
+	claimed    *claimFilter                              // nil when the claimed filter is disabled
+	names      *couponNameFilter                         // nil when the coupon name filter is disabled
+	hedger     *hedge.Hedger                             // nil when read hedging is disabled
+	shadow     *claimShadow                              // nil when no claim strategy is shadowed
+	pacer      *pacing.Pacer                             // nil when claims are not paced
+	quota      *highProfileQuota                         // nil when high-profile coupons share the pool
+	events     EventPublisher                            // nil when lifecycle events are not published
+	eventLog   DomainEventLog                            // nil when domain events are not recorded
+	caps       ports.CampaignCapRepository               // nil when campaign claim caps are disabled
+	allowlists ports.AllowlistRepository                 // nil when coupon allowlists are disabled
+	tombstones ports.CouponTombstoneRepository           // nil when coupons cannot be deleted
+	archive    ports.CouponArchiveRepository             // nil when purged coupons are not archived
+	audit      ports.AuditRepository                     // nil when terminations are not audited
+	waiters    *stockwait.Hub                            // nil when waiting for stock is disabled
+	retention  ports.ClaimRetentionRepository            // nil when claim retention is disabled
+	loadTests  ports.LoadTestRepository                  // nil when load test namespaces are disabled
+	jobGate    JobGate                                   // nil when heavy jobs run on every tick
+	clock      clock.Clock                               // clock.Real unless SetClock is called
+
+	metadataSchema MetadataSchema // nil when metadata only has to be a JSON object
+
+	importChunkSize int // Claims per ImportClaims transaction; 0 means DefaultImportChunkSize
+	imports         claimImportCounters
+
+	minClaimBudget time.Duration // 0 when claims begin transactions whatever their deadline
+	budgetAborts   atomic.Int64
 
-// ClaimRepositoryInterface defines the interface for claim data access.
-type ClaimRepositoryInterface interface {
-	GetUsersByCoupon(ctx context.Context, couponName string) ([]string, error)
-	Insert(ctx context.Context, tx database.TxQuerier, userID, couponName string) error
+	undoWindow time.Duration // How long deleted coupons can be restored
+	purges     couponPurgeCounters
+
+	retentions claimRetentionCounters
+
+	loadTestCounts loadTestCounters
+
+	claimedByLimit int // Most user IDs in claimed_by; 0 for all
 }
 
-// TxBeginner defines the interface for beginning transactions.
-type TxBeginner interface {
-	Begin(ctx context.Context) (pgx.Tx, error)
+// NewCouponService creates a new CouponService with the given PostgreSQL pool and repositories.
+func NewCouponService(pool *pgxpool.Pool, couponRepo ports.CouponRepository, claimRepo ports.ClaimRepository) *CouponService {
+	return NewCouponServiceWithTxBeginner(pool, couponRepo, claimRepo)
 }
 
-// CouponService provides business logic for coupon operations.
-type CouponService struct {
-	pool       TxBeginner
-	couponRepo CouponRepositoryInterface
-	claimRepo  ClaimRepositoryInterface
+// NewCouponServiceWithTxBeginner creates a CouponService with a custom TxBeginner.
+// Transactions are run once, as on PostgreSQL. Primarily used for testing.
+func NewCouponServiceWithTxBeginner(pool ports.TxBeginner, couponRepo ports.CouponRepository, claimRepo ports.ClaimRepository) *CouponService {
+	return NewCouponServiceWithTransactor(database.NewTransactor(pool, database.Postgres), couponRepo, claimRepo)
 }
 
-// NewCouponService creates a new CouponService with the given pool and repositories.
-func NewCouponService(pool *pgxpool.Pool, couponRepo CouponRepositoryInterface, claimRepo ClaimRepositoryInterface) *CouponService {
+// NewCouponServiceWithTransactor creates a CouponService that runs its transactions
+// through tx, e.g. a store.Store with the retry behavior of its database.
+func NewCouponServiceWithTransactor(tx ports.Transactor, couponRepo ports.CouponRepository, claimRepo ports.ClaimRepository) *CouponService {
 	return &CouponService{
-		pool:       pool,
+		tx:         tx,
 		couponRepo: couponRepo,
 		claimRepo:  claimRepo,
+		clock:      clock.Real,
 	}
 }
 
-// NewCouponServiceWithTxBeginner creates a CouponService with a custom TxBeginner.
-// Primarily used for testing.
-func NewCouponServiceWithTxBeginner(pool TxBeginner, couponRepo CouponRepositoryInterface, claimRepo ClaimRepositoryInterface) *CouponService {
-	return &CouponService{
-		pool:       pool,
-		couponRepo: couponRepo,
-		claimRepo:  claimRepo,
+// SetClock makes the service read the time from c instead of the wall clock: claim
+// windows, claim statistics, retention cutoffs and the periodic passes (Run*) follow c,
+// so tests can freeze and advance time with a clock.Fake instead of sleeping. Checks
+// made in SQL, such as tombstone and load test expiry, still use the database's clock.
+func (s *CouponService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetCache enables caching of GetByName results in c. Intended for short TTLs that absorb
+// read stampedes on hot coupons. Configuration writes made through this service
+// (PATCH, top-up, manifest apply) invalidate cached entries; claims deliberately do not,
+// since that would empty the cache exactly when reads spike, so remaining_amount and
+// claimed_by may lag by up to the TTL. Writes from other instances are not seen until expiry.
+func (s *CouponService) SetCache(c *cache.LRU[string, *model.CouponResponse]) {
+	s.cache = c
+}
+
+// SetClaimedByLimit caps claimed_by in GetByName responses (and those of the writes
+// returning it) at the first n claimants, so a coupon with millions of claims cannot
+// produce a multi-megabyte response. Truncated responses report truncated and count all
+// claimants in claimed_count, at the cost of counting them. 0 lists every claimant.
+func (s *CouponService) SetClaimedByLimit(n int) {
+	s.claimedByLimit = n
+}
+
+// SetHedger enables hedged reads for GetByName, List and ListClaims: a read still running
+// after its recent p95 latency is issued again and the first answer wins (see package
+// hedge). Both attempts go to the same pool, so hedging trades extra read load for tail
+// latency. A nil h disables it.
+func (s *CouponService) SetHedger(h *hedge.Hedger) {
+	s.hedger = h
+}
+
+// invalidate drops name from the GetByName cache, if enabled.
+func (s *CouponService) invalidate(name string) {
+	if s.cache != nil {
+		s.cache.Remove(name)
 	}
 }
 
 // Create creates a new coupon from the request.
-// Returns ErrCouponExists if a coupon with the same name already exists.
-// Returns ErrInvalidRequest if request data is nil or incomplete.
+// Returns apperr.ErrCouponExists if a coupon with the same name already exists.
+// Returns apperr.ErrInvalidRequest if request data is nil or incomplete.
+// Returns apperr.ErrInvalidChannelQuotas if channel percentages do not sum to 100.
+// Returns apperr.ErrInvalidTiers if tier sizes add up to more than the amount.
+// Returns a *apperr.MetadataError (which matches apperr.ErrInvalidMetadata) if the metadata is rejected.
+// Returns apperr.ErrInvalidParent if the parent does not exist or has a parent itself.
+// Returns apperr.ErrInvalidPrerequisite if the prerequisite does not exist or is the coupon itself.
+// Returns apperr.ErrClaimRetentionDisabled if it has a claim retention and claim retention is disabled.
 func (s *CouponService) Create(ctx context.Context, req *model.CreateCouponRequest) error {
-	// Defense-in-depth: check for nil pointer even though handler validates
-	if req == nil || req.Amount == nil {
-		return ErrInvalidRequest
+	coupon, err := s.newCoupon(req)
+	if err != nil {
+		return err
+	}
+	if err := s.checkParent(ctx, coupon); err != nil {
+		return err
+	}
+	if err := s.checkPrerequisite(ctx, coupon); err != nil {
+		return err
+	}
+	if err := s.couponRepo.Insert(ctx, coupon); err != nil {
+		return err
+	}
+	s.addCouponName(coupon.Name)
+	s.markHighProfile(coupon.Name, coupon.HighProfile)
+	s.logCouponCreated(coupon)
+	s.publishCoupon(ctx, model.CouponEventCreated, coupon.Name)
+	return nil
+}
+
+// Put creates the coupon described by req, or accepts an existing coupon of the same
+// name when its configuration matches req. created reports whether a coupon was inserted.
+// Returns a *apperr.ConflictError (which matches apperr.ErrCouponExists) listing the differing fields
+// when the existing coupon does not match, apperr.ErrCouponDeleted when it is deleted but not
+// yet purged, plus the same errors as Create.
+func (s *CouponService) Put(ctx context.Context, req *model.CreateCouponRequest) (resp *model.CouponResponse, created bool, err error) {
+	desired, err := s.newCoupon(req)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.checkParent(ctx, desired); err != nil {
+		return nil, false, err
+	}
+	if err := s.checkPrerequisite(ctx, desired); err != nil {
+		return nil, false, err
+	}
+
+	err = s.couponRepo.Insert(ctx, desired)
+	if err == nil {
+		s.addCouponName(desired.Name)
+		s.markHighProfile(desired.Name, desired.HighProfile)
+		s.logCouponCreated(desired)
+		s.publishCoupon(ctx, model.CouponEventCreated, desired.Name)
+		resp, err = s.GetByName(ctx, req.Name)
+		return resp, true, err
+	}
+	if !errors.Is(err, apperr.ErrCouponExists) {
+		return nil, false, err
+	}
+	s.addCouponName(desired.Name) // Possibly created through another instance
+
+	existing, err := s.couponRepo.GetByName(ctx, req.Name)
+	if err != nil {
+		return nil, false, fmt.Errorf("get coupon: %w", err)
+	}
+	if existing == nil {
+		return nil, false, apperr.ErrCouponDeleted // Kept as a tombstone until purged
+	}
+	if diffs := diffCoupon(existing, desired); len(diffs) > 0 {
+		return nil, false, &apperr.ConflictError{Diffs: diffs}
+	}
+
+	resp, err = s.GetByName(ctx, req.Name)
+	return resp, false, err
+}
+
+// newCoupon builds the coupon described by a create request and validates its
+// metadata against the metadata schema. A claim retention is only accepted when it is
+// enforced.
+func (s *CouponService) newCoupon(req *model.CreateCouponRequest) (*model.Coupon, error) {
+	coupon, err := newCoupon(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateMetadata(coupon); err != nil {
+		return nil, err
+	}
+	if coupon.ClaimRetention != nil && s.retention == nil {
+		return nil, apperr.ErrClaimRetentionDisabled
+	}
+	return coupon, nil
+}
+
+// newCoupon builds the coupon described by a create request.
+func newCoupon(req *model.CreateCouponRequest) (*model.Coupon, error) {
+	// Defense-in-depth: check for nil pointer even though handler validates.
+	// Unlimited coupons have no amount; all others must have one
+	if req == nil || (req.Amount == nil) != req.Unlimited {
+		return nil, apperr.ErrInvalidRequest
+	}
+	amount := 0
+	if !req.Unlimited {
+		amount = *req.Amount
+	} else if len(req.Channels) > 0 || req.LowStockPercent > 0 {
+		return nil, apperr.ErrInvalidRequest // Both are shares of the amount
+	}
+
+	channels, err := allocateChannelQuotas(amount, req.Channels)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Unlimited {
+		if err := validateTiers(amount, req.Tiers); err != nil {
+			return nil, err
+		}
+	}
+	metadata, err := normalizeMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
 	}
 
 	coupon := &model.Coupon{
 		Name:            req.Name,
-		Amount:          *req.Amount,
-		RemainingAmount: *req.Amount,
+		Amount:          amount,
+		RemainingAmount: amount,
+		Tags:            normalizeTags(req.Tags),
+		Channels:        channels,
+		Tiers:           req.Tiers,
+		CaptchaRequired: req.CaptchaRequired,
+		Metadata:        metadata,
+		LowStockPercent: req.LowStockPercent,
+		Parent:          req.Parent,
+		Regions:         regionQuotas(req.Regions),
+		Unlimited:       req.Unlimited,
+		ClaimRetention:  req.ClaimRetention,
+		Visibility:      req.Visibility,
+		HighProfile:     req.HighProfile,
+		Prerequisite:    req.Prerequisite,
+		ExclusionGroup:  req.ExclusionGroup,
+	}
+	if coupon.Visibility == "" {
+		coupon.Visibility = model.VisibilityPublic
+	}
+	if len(channels) > 0 {
+		coupon.OverflowAt = req.OverflowAt // Only meaningful for partitioned coupons
+	}
+	return coupon, nil
+}
+
+// List returns a page of up to filter.Limit coupons matching the filter, ordered by name.
+// A status filter matches the statuses couponStatus derives.
+func (s *CouponService) List(ctx context.Context, filter model.CouponFilter) (*model.CouponListResponse, error) {
+	limit := filter.Limit
+	filter.Limit++ // One more tells whether there is a next page
+	coupons, err := hedge.Do(ctx, s.hedger, "list_coupons", func(ctx context.Context) ([]model.Coupon, error) {
+		return s.couponRepo.List(ctx, filter)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list coupons: %w", err)
+	}
+
+	summaries := make([]model.CouponSummary, 0, len(coupons))
+	for i := range coupons {
+		summaries = append(summaries, summarizeCoupon(&coupons[i]))
+	}
+	page := model.NewPage(summaries, limit, func(c model.CouponSummary) string { return c.Name })
+	return &page, nil
+}
+
+// summarizeCoupon returns the listing entry for c.
+func summarizeCoupon(c *model.Coupon) model.CouponSummary {
+	return model.CouponSummary{
+		Name:            c.Name,
+		Amount:          c.Amount,
+		RemainingAmount: c.RemainingAmount,
+		Tags:            normalizeTags(c.Tags),
+		Disabled:        c.Disabled,
+		LowStock:        couponLowStock(c),
+		Unlimited:       c.Unlimited,
+		Claimed:         claimedCount(c),
+		Status:          couponStatus(c),
+	}
+}
+
+// Update applies a partial update to a coupon and returns its new state.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+// Returns apperr.ErrInvalidRequest if the request is nil.
+func (s *CouponService) Update(ctx context.Context, name string, req *model.UpdateCouponRequest) (*model.CouponResponse, error) {
+	if req == nil {
+		return nil, apperr.ErrInvalidRequest
+	}
+
+	if req.Tags != nil {
+		if err := s.couponRepo.UpdateTags(ctx, name, normalizeTags(req.Tags)); err != nil {
+			if errors.Is(err, apperr.ErrCouponNotFound) {
+				return nil, apperr.ErrCouponNotFound
+			}
+			return nil, fmt.Errorf("update tags: %w", err)
+		}
+		s.invalidate(name)
+		s.publishCoupon(ctx, model.CouponEventUpdated, name)
+	}
+	if req.HighProfile != nil {
+		err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+			return s.couponRepo.SetHighProfile(ctx, tx, name, *req.HighProfile)
+		})
+		if err != nil {
+			if errors.Is(err, apperr.ErrCouponNotFound) {
+				return nil, apperr.ErrCouponNotFound
+			}
+			return nil, fmt.Errorf("set high profile: %w", err)
+		}
+		s.markHighProfile(name, *req.HighProfile)
+		s.invalidate(name)
+		s.publishCoupon(ctx, model.CouponEventUpdated, name)
+	}
+
+	return s.GetByName(ctx, name)
+}
+
+// TopUp adds amount to a coupon's stock (both amount and remaining_amount) under a
+// row lock and returns its new state.
+// Returns apperr.ErrInvalidRequest if amount is not positive.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+// Returns apperr.ErrPartitionedTopUp if the coupon is partitioned by channel.
+// Returns apperr.ErrUnlimitedTopUp if the coupon is unlimited.
+func (s *CouponService) TopUp(ctx context.Context, name string, amount int) (*model.CouponResponse, error) {
+	if amount < 1 {
+		return nil, apperr.ErrInvalidRequest
+	}
+
+	err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+		coupon, err := s.couponRepo.GetCouponForUpdate(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+		if len(coupon.Channels) > 0 {
+			return apperr.ErrPartitionedTopUp
+		}
+		if coupon.Unlimited {
+			return apperr.ErrUnlimitedTopUp
+		}
+		return s.couponRepo.TopUp(ctx, tx, name, amount)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.couponRepo.Insert(ctx, coupon)
+	s.invalidate(name)
+	s.addCouponName(name) // Possibly created through another instance
+	s.publishCoupon(ctx, model.CouponEventUpdated, name)
+
+	return s.GetByName(ctx, name)
 }
 
 // GetByName retrieves a coupon by name with its claim list.
-// Returns ErrCouponNotFound if the coupon doesn't exist.
+// When a cache is set, results are served from it until they expire (see SetCache);
+// not-found results are never cached. Callers must not modify the returned value.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
 func (s *CouponService) GetByName(ctx context.Context, name string) (*model.CouponResponse, error) {
+	if !s.couponMayExist(name) {
+		return nil, apperr.ErrCouponNotFound
+	}
+	if s.cache == nil {
+		return s.hedgedLoadCoupon(ctx, name)
+	}
+	if resp, ok := s.cache.Get(name); ok {
+		return resp, nil
+	}
+	resp, err := s.hedgedLoadCoupon(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Add(name, resp)
+	return resp, nil
+}
+
+// hedgedLoadCoupon is loadCoupon, hedged when a Hedger is set.
+func (s *CouponService) hedgedLoadCoupon(ctx context.Context, name string) (*model.CouponResponse, error) {
+	return hedge.Do(ctx, s.hedger, "get_coupon", func(ctx context.Context) (*model.CouponResponse, error) {
+		return s.loadCoupon(ctx, name)
+	})
+}
+
+// loadCoupon reads a coupon and its claim list from the repositories.
+func (s *CouponService) loadCoupon(ctx context.Context, name string) (*model.CouponResponse, error) {
+	coupon, err := s.couponRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("get coupon: %w", err)
+	}
+	if coupon == nil {
+		return nil, apperr.ErrCouponNotFound
+	}
+
+	// Reading one user past the limit tells whether claimed_by is truncated
+	limit := 0
+	if s.claimedByLimit > 0 {
+		limit = s.claimedByLimit + 1
+	}
+	claimedBy, err := s.claimRepo.GetUsersByCoupon(ctx, name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get claims: %w", err)
+	}
+	if limit == 0 || len(claimedBy) < limit {
+		return couponResponse(coupon, claimedBy, s.clock.Now()), nil
+	}
+
+	count, err := s.claimRepo.CountByCoupon(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("count claims: %w", err)
+	}
+	resp := couponResponse(coupon, claimedBy[:s.claimedByLimit], s.clock.Now())
+	resp.ClaimedCount, resp.Truncated = count, true
+	return resp, nil
+}
+
+// GetByNameClaimedBy is GetByName with claimed_by narrowed to the users of userIDs that
+// have claimed the coupon, in the order given. Only their claims are read, so checking
+// a few users stays cheap however many claims the coupon has; claimed_count counts just
+// them, and claimed_by is never truncated. The GetByName cache is bypassed, so the
+// answer is never stale.
+// Returns apperr.ErrInvalidRequest if userIDs is empty.
+func (s *CouponService) GetByNameClaimedBy(ctx context.Context, name string, userIDs []string) (*model.CouponResponse, error) {
+	if len(userIDs) == 0 {
+		return nil, apperr.ErrInvalidRequest
+	}
+	if !s.couponMayExist(name) {
+		return nil, apperr.ErrCouponNotFound
+	}
+
 	coupon, err := s.couponRepo.GetByName(ctx, name)
 	if err != nil {
 		return nil, fmt.Errorf("get coupon: %w", err)
 	}
 	if coupon == nil {
-		return nil, ErrCouponNotFound
+		return nil, apperr.ErrCouponNotFound
 	}
 
-	claimedBy, err := s.claimRepo.GetUsersByCoupon(ctx, name)
+	claimed, err := s.claimRepo.GetClaimedUsers(ctx, name, userIDs)
 	if err != nil {
 		return nil, fmt.Errorf("get claims: %w", err)
 	}
+	claimedBy := make([]string, 0, len(claimed))
+	for _, userID := range userIDs {
+		if slices.Contains(claimed, userID) && !slices.Contains(claimedBy, userID) {
+			claimedBy = append(claimedBy, userID)
+		}
+	}
+	return couponResponse(coupon, claimedBy, s.clock.Now()), nil
+}
 
+// couponResponse returns the API response for coupon claimed by claimedBy, as of now.
+func couponResponse(coupon *model.Coupon, claimedBy []string, now time.Time) *model.CouponResponse {
 	return &model.CouponResponse{
 		Name:            coupon.Name,
 		Amount:          coupon.Amount,
 		RemainingAmount: coupon.RemainingAmount,
 		ClaimedBy:       claimedBy,
-	}, nil
+		ClaimedCount:    len(claimedBy),
+		Tags:            normalizeTags(coupon.Tags),
+		Channels:        coupon.Channels,
+		OverflowAt:      coupon.OverflowAt,
+		Tiers:           coupon.Tiers,
+		Disabled:        coupon.Disabled,
+		CaptchaRequired: coupon.CaptchaRequired,
+		Metadata:        coupon.Metadata,
+		LowStockPercent: coupon.LowStockPercent,
+		LowStock:        couponLowStock(coupon),
+		Parent:          coupon.Parent,
+		Regions:         coupon.Regions,
+		Stats:           claimStats(coupon.Stats, now),
+		Unlimited:       coupon.Unlimited,
+		Claimed:         claimedCount(coupon),
+		ClaimRetention:  coupon.ClaimRetention,
+		Status:          couponStatus(coupon),
+		Visibility:      coupon.Visibility,
+		HighProfile:     coupon.HighProfile,
+		Prerequisite:    coupon.Prerequisite,
+		ExclusionGroup:  coupon.ExclusionGroup,
+	}
 }
 
-// ClaimCoupon atomically claims a coupon for a user.
+// ClaimCoupon atomically claims a coupon for a user and returns the claim receipt.
 // Uses SELECT FOR UPDATE to lock the coupon row during the transaction.
+// The claim's sequence (its 1-based position among the coupon's claims) is assigned
+// under that lock, so sequences are gap-free and strictly increasing per coupon,
+// and the bonus tier derived from it is race-free.
+// For channel-partitioned coupons the claim also decrements its channel's partition.
+// Unlimited coupons never run out of stock; their claims are only counted.
+// For children of a parent coupon it also takes one unit of the parent's remaining
+// stock, locking the parent's row after the child's.
 // Returns:
-//   - ErrCouponNotFound if the coupon doesn't exist
-//   - ErrNoStock if the coupon has no remaining stock
-//   - ErrAlreadyClaimed if the user has already claimed this coupon
-func (s *CouponService) ClaimCoupon(ctx context.Context, userID, couponName string) error {
-	tx, err := s.pool.Begin(ctx)
+//   - apperr.ErrInvalidRequest if the request is nil
+//   - apperr.ErrCouponNotFound if the coupon doesn't exist
+//   - apperr.ErrCouponDisabled if the coupon (or its parent) has been disabled
+//   - apperr.ErrCaptchaRequired if the coupon requires a captcha and req.CaptchaVerified is false
+//   - apperr.ErrGrantRequired if the coupon is private and req.GrantVerified is false
+//   - apperr.ErrNotAllowlisted / apperr.ErrClaimDeadlinePassed if the coupon has an allowlist the user
+//     is not on, or whose claim-by deadline for the user has passed (SetAllowlists)
+//   - apperr.ErrPrerequisiteNotClaimed if the user has not claimed the coupon's prerequisite
+//   - apperr.ErrNoStock if the coupon (or the claim's channel partition, or its parent) has no
+//     remaining stock
+//   - apperr.ErrCampaignCapReached if a campaign cap of the coupon's tags is used up (SetCampaignCaps)
+//   - apperr.ErrRegionQuotaReached if the coupon's quota for the claim's region is used up
+//   - apperr.ErrChannelRequired / apperr.ErrUnknownChannel for invalid channels on partitioned coupons
+//   - apperr.ErrAlreadyClaimed if the user has already claimed this coupon; an
+//     *apperr.AlreadyClaimedError when the earlier claim could be read
+//   - apperr.ErrExclusionGroupClaimed if the user has claimed another coupon of the coupon's
+//     exclusion group
+//
+// With deduplication enabled (SetClaimDedup), an identical request in flight or
+// recently successful is answered with that request's outcome instead.
+// With the claimed filter enabled (SetClaimFilter), repeat claims are rejected with
+// apperr.ErrAlreadyClaimed before starting a transaction.
+// With shadow mode enabled (SetClaimShadow), a candidate strategy predicts the outcome
+// alongside and is compared with it in the background.
+// A successful claim publishes coupon.claimed, and one taking the coupon to its low stock
+// watermark also coupon.low_stock.
+func (s *CouponService) ClaimCoupon(ctx context.Context, req *model.ClaimCouponRequest) (*model.ClaimReceipt, error) {
+	if req == nil {
+		return nil, apperr.ErrInvalidRequest
+	}
+	if !s.couponMayExist(req.CouponName) {
+		return nil, apperr.ErrCouponNotFound
+	}
+	var receipt *model.ClaimReceipt
+	var err error
+	if s.dedup != nil {
+		receipt, err = s.dedup.do(ctx, req, s.claimCoupon)
+	} else {
+		receipt, err = s.claimCoupon(ctx, req)
+	}
+	if errors.Is(err, apperr.ErrAlreadyClaimed) {
+		return nil, s.claimConflict(ctx, req)
+	}
+	return receipt, err
+}
+
+// claimCoupon runs a claim in its own transaction and returns its receipt.
+func (s *CouponService) claimCoupon(ctx context.Context, req *model.ClaimCouponRequest) (_ *model.ClaimReceipt, err error) {
+	if s.shadow != nil {
+		compare := s.shadow.start(ctx, req, s.clock.Now())
+		defer func() { compare(err) }()
+	}
+	if s.claimed != nil && s.claimed.rejects(ctx, s.claimRepo, req) {
+		return nil, apperr.ErrAlreadyClaimed
+	}
+	if err := s.pace(ctx, req.CouponName); err != nil {
+		return nil, err
+	}
+	release, err := s.acquireHighProfileSlot(ctx, req.CouponName)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return nil, err
+	}
+	defer release()
+	if s.claimBudgetExceeded(ctx) {
+		return nil, apperr.ErrDeadlineTooShort
+	}
+
+	var claim *model.Claim
+	var lowStock []string
+	err = s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+		var err error
+		claim, lowStock, err = s.claim(ctx, tx, req, time.Time{}, false)
+		return err
+	})
+	if s.claimed != nil && (err == nil || errors.Is(err, apperr.ErrAlreadyClaimed)) {
+		s.claimed.add(req.UserID, req.CouponName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range lowStock {
+		s.announceLowStock(ctx, name)
+	}
+	receipt := &model.ClaimReceipt{
+		UserID:        claim.UserID,
+		CouponName:    claim.CouponName,
+		Channel:       claim.Channel,
+		Region:        claim.Region,
+		ClaimSequence: claim.Sequence,
+		Tier:          claim.Tier,
+	}
+	s.logClaim(receipt)
+	s.publishClaim(ctx, receipt)
+	return receipt, nil
+}
+
+// claim runs the claim steps of ClaimCoupon within tx and returns the inserted claim,
+// and the coupons (the claimed one or its parent) it took to their low stock watermark. imported marks a
+// historical claim (see ImportClaims), made at claimedAt if that is non-zero; campaign
+// caps, allowlists and prerequisites do not apply to it.
+func (s *CouponService) claim(ctx context.Context, tx database.TxQuerier, req *model.ClaimCouponRequest, claimedAt time.Time, imported bool) (*model.Claim, []string, error) {
+	couponName := req.CouponName
+	now := claimedAt
+	if now.IsZero() {
+		now = s.clock.Now()
 	}
-	defer func() { _ = tx.Rollback(ctx) }() // Safe: no-op if committed
 
 	// 1. Lock the coupon row (SELECT FOR UPDATE)
 	coupon, err := s.couponRepo.GetCouponForUpdate(ctx, tx, couponName)
 	if err != nil {
-		if errors.Is(err, ErrCouponNotFound) {
-			return ErrCouponNotFound
+		if errors.Is(err, apperr.ErrCouponNotFound) {
+			return nil, nil, apperr.ErrCouponNotFound
+		}
+		return nil, nil, fmt.Errorf("get coupon for update: %w", err)
+	}
+	s.markHighProfile(couponName, coupon.HighProfile)
+
+	// 2. Check the coupon is enabled, the user may claim it (allowlist, prerequisite) and
+	// it has stock (overall, then the channel partition if partitioned, then the region's
+	// quota, then the parent's budget if it has a parent, whose row is locked after the
+	// coupon's). Imports exceed region quotas rather than lose historical claims
+	if coupon.Disabled {
+		return nil, nil, apperr.ErrCouponDisabled
+	}
+	if coupon.CaptchaRequired && !req.CaptchaVerified {
+		return nil, nil, apperr.ErrCaptchaRequired
+	}
+	if coupon.Visibility == model.VisibilityPrivate && !req.GrantVerified {
+		return nil, nil, apperr.ErrGrantRequired
+	}
+	if !imported {
+		if err := s.checkAllowlist(ctx, tx, couponName, req.UserID, now); err != nil {
+			return nil, nil, err
+		}
+		if err := s.checkPrerequisiteClaimed(ctx, tx, coupon, req.UserID); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !hasStock(coupon) {
+		return nil, nil, apperr.ErrNoStock
+	}
+	partition, err := pickChannelPartition(coupon, req.Channel, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !imported {
+		if err := checkRegionQuota(coupon, req.Region); err != nil {
+			return nil, nil, err
+		}
+	}
+	parent, err := s.lockParent(ctx, tx, coupon)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 3. Lock the caps of the coupon's campaigns and check they have claims left.
+	// Historical claims (imports) are counted by the import instead.
+	var capped []string
+	if !imported {
+		capped, err = s.checkCampaignCaps(ctx, tx, coupon.Tags)
+		if err != nil {
+			return nil, nil, err
 		}
-		return fmt.Errorf("get coupon for update: %w", err)
 	}
 
-	// 2. Check stock
-	if coupon.RemainingAmount <= 0 {
-		return ErrNoStock
+	// 4. Insert claim (UNIQUE constraint catches duplicates) and, for coupons of an
+	// exclusion group, the user's claim of the group (its primary key catches a second)
+	sequence := coupon.ClaimSequence + 1
+	claim := &model.Claim{
+		UserID:     req.UserID,
+		CouponName: couponName,
+		Channel:    req.Channel,
+		Region:     req.Region,
+		Sequence:   sequence,
+		Tier:       tierForSequence(coupon.Tiers, sequence),
+		CreatedAt:  claimedAt,
+	}
+	err = s.claimRepo.Insert(ctx, tx, claim)
+	if err != nil {
+		if errors.Is(err, apperr.ErrAlreadyClaimed) {
+			return nil, nil, apperr.ErrAlreadyClaimed
+		}
+		return nil, nil, fmt.Errorf("insert claim: %w", err)
+	}
+	if coupon.ExclusionGroup != "" {
+		err := s.claimRepo.InsertExclusionClaim(ctx, tx, coupon.ExclusionGroup, req.UserID, couponName)
+		switch {
+		case errors.Is(err, apperr.ErrExclusionGroupClaimed) && imported:
+			// Historical claims are kept even if the user claimed the group before
+		case errors.Is(err, apperr.ErrExclusionGroupClaimed):
+			return nil, nil, err
+		case err != nil:
+			return nil, nil, fmt.Errorf("insert exclusion claim: %w", err)
+		}
 	}
 
-	// 3. Insert claim (UNIQUE constraint catches duplicates)
-	err = s.claimRepo.Insert(ctx, tx, userID, couponName)
+	// 5. Decrement stock (also advances the coupon's claim_sequence; unlimited coupons
+	// only advance it) and the parent's budget, and count the claim in the coupon's stats
+	// rollup, its region and against its campaigns' caps
+	err = s.takeStock(ctx, tx, coupon)
 	if err != nil {
-		if errors.Is(err, ErrAlreadyClaimed) {
-			return ErrAlreadyClaimed
+		return nil, nil, fmt.Errorf("decrement stock: %w", err)
+	}
+	if partition != "" {
+		if err := s.couponRepo.DecrementChannelStock(ctx, tx, couponName, partition); err != nil {
+			return nil, nil, fmt.Errorf("decrement channel stock: %w", err)
+		}
+	}
+	if err := s.couponRepo.RecordClaimStats(ctx, tx, couponName, now); err != nil {
+		return nil, nil, fmt.Errorf("record claim stats: %w", err)
+	}
+	if req.Region != "" {
+		if err := s.couponRepo.CountRegionClaim(ctx, tx, couponName, req.Region); err != nil {
+			return nil, nil, fmt.Errorf("count region claim: %w", err)
 		}
-		return fmt.Errorf("insert claim: %w", err)
+	}
+	if parent != nil {
+		if err := s.couponRepo.DecrementBudget(ctx, tx, parent.Name); err != nil {
+			return nil, nil, fmt.Errorf("decrement parent budget: %w", err)
+		}
+	}
+	if len(capped) > 0 {
+		if err := s.caps.AddClaims(ctx, tx, capped, 1); err != nil {
+			return nil, nil, fmt.Errorf("count campaign claim: %w", err)
+		}
+	}
+
+	var lowStock []string
+	for _, c := range []*model.Coupon{coupon, parent} {
+		if c != nil && claimReachesLowStock(c) {
+			lowStock = append(lowStock, c.Name)
+		}
+	}
+	return claim, lowStock, nil
+}
+
+// ListClaims returns a page of up to limit claims of a coupon after claim sequence
+// afterSequence, in claim order.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist or is not listed.
+func (s *CouponService) ListClaims(ctx context.Context, name string, afterSequence, limit int) (*model.ClaimListResponse, error) {
+	if !s.couponMayExist(name) {
+		return nil, apperr.ErrCouponNotFound
+	}
+	return hedge.Do(ctx, s.hedger, "list_claims", func(ctx context.Context) (*model.ClaimListResponse, error) {
+		return s.listClaims(ctx, name, afterSequence, limit)
+	})
+}
+
+// listClaims reads a page of the claims of a coupon from the repositories.
+func (s *CouponService) listClaims(ctx context.Context, name string, afterSequence, limit int) (*model.ClaimListResponse, error) {
+	coupon, err := s.couponRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("get coupon: %w", err)
+	}
+	if coupon == nil || !model.Listed(coupon.Visibility) {
+		return nil, apperr.ErrCouponNotFound
 	}
 
-	// 4. Decrement stock
-	err = s.couponRepo.DecrementStock(ctx, tx, couponName)
+	claims, err := s.claimRepo.ListByCoupon(ctx, name, afterSequence, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("list claims: %w", err)
+	}
+	total, err := s.claimRepo.CountByCoupon(ctx, name)
 	if err != nil {
-		return fmt.Errorf("decrement stock: %w", err)
+		return nil, fmt.Errorf("count claims: %w", err)
+	}
+
+	records := make([]model.ClaimRecord, 0, len(claims))
+	for _, c := range claims {
+		records = append(records, model.ClaimRecord{
+			UserID:        c.UserID,
+			Channel:       c.Channel,
+			Region:        c.Region,
+			ClaimSequence: c.Sequence,
+			Tier:          c.Tier,
+			ClaimedAt:     c.CreatedAt,
+		})
 	}
+	page := model.NewPage(records, limit, func(r model.ClaimRecord) string { return strconv.Itoa(r.ClaimSequence) })
+	page.Total = &total
+	return &model.ClaimListResponse{CouponName: coupon.Name, Page: page}, nil
+}
 
-	return tx.Commit(ctx)
+// normalizeTags removes duplicate tags while preserving order.
+// Always returns a non-nil slice so responses encode tags as [] rather than null.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		out = append(out, tag)
+	}
+	return out
 }
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,79 +14,62 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/cache"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/hedge"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
 	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
 )
 
-// mockCouponRepository is a mock implementation of CouponRepositoryInterface.
-type mockCouponRepository struct {
-	insertFn             func(ctx context.Context, coupon *model.Coupon) error
-	getByNameFn          func(ctx context.Context, name string) (*model.Coupon, error)
-	getCouponForUpdateFn func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error)
-	decrementStockFn     func(ctx context.Context, tx database.TxQuerier, name string) error
-}
-
-func (m *mockCouponRepository) Insert(ctx context.Context, coupon *model.Coupon) error {
-	if m.insertFn != nil {
-		return m.insertFn(ctx, coupon)
-	}
-	return nil
-}
-
-func (m *mockCouponRepository) GetByName(ctx context.Context, name string) (*model.Coupon, error) {
-	if m.getByNameFn != nil {
-		return m.getByNameFn(ctx, name)
-	}
-	return nil, nil
-}
-
-func (m *mockCouponRepository) GetCouponForUpdate(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
-	if m.getCouponForUpdateFn != nil {
-		return m.getCouponForUpdateFn(ctx, tx, name)
+// newTx returns a transaction mock whose Commit and Rollback succeed.
+func newTx() *mocks.TxMock {
+	return &mocks.TxMock{
+		CommitFunc:   func(ctx context.Context) error { return nil },
+		RollbackFunc: func(ctx context.Context) error { return nil },
 	}
-	return nil, nil
 }
 
-func (m *mockCouponRepository) DecrementStock(ctx context.Context, tx database.TxQuerier, name string) error {
-	if m.decrementStockFn != nil {
-		return m.decrementStockFn(ctx, tx, name)
+// newPool returns a TxBeginner mock that begins tx.
+func newPool(tx pgx.Tx) *mocks.TxBeginnerMock {
+	return &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return tx, nil },
 	}
-	return nil
 }
 
-// mockClaimRepository is a mock implementation of ClaimRepositoryInterface.
-type mockClaimRepository struct {
-	getUsersByCouponFn func(ctx context.Context, couponName string) ([]string, error)
-	insertFn           func(ctx context.Context, tx database.TxQuerier, userID, couponName string) error
-}
-
-func (m *mockClaimRepository) GetUsersByCoupon(ctx context.Context, couponName string) ([]string, error) {
-	if m.getUsersByCouponFn != nil {
-		return m.getUsersByCouponFn(ctx, couponName)
-	}
-	return []string{}, nil
-}
-
-func (m *mockClaimRepository) Insert(ctx context.Context, tx database.TxQuerier, userID, couponName string) error {
-	if m.insertFn != nil {
-		return m.insertFn(ctx, tx, userID, couponName)
+// noClaims returns a claim repository mock for coupons nobody has claimed yet.
+func noClaims() *mocks.ClaimRepositoryMock {
+	return &mocks.ClaimRepositoryMock{
+		GetUsersByCouponFunc: func(ctx context.Context, couponName string, limit int) ([]string, error) {
+			return []string{}, nil
+		},
+		CountByCouponFunc: func(ctx context.Context, couponName string) (int, error) {
+			return 0, nil
+		},
+		ListByCouponFunc: func(ctx context.Context, couponName string, afterSequence, limit int) ([]model.Claim, error) {
+			return []model.Claim{}, nil
+		},
 	}
-	return nil
 }
 
 func intPtr(i int) *int {
 	return &i
 }
 
+func claimRequest(userID, couponName string) *model.ClaimCouponRequest {
+	return &model.ClaimCouponRequest{UserID: userID, CouponName: couponName}
+}
+
 func TestCouponService_Create_Success(t *testing.T) {
 	var capturedCoupon *model.Coupon
-	mockCouponRepo := &mockCouponRepository{
-		insertFn: func(ctx context.Context, coupon *model.Coupon) error {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
 			capturedCoupon = coupon
 			return nil
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
 	req := &model.CreateCouponRequest{
@@ -97,15 +83,16 @@ func TestCouponService_Create_Success(t *testing.T) {
 	assert.Equal(t, "PROMO_SUPER", capturedCoupon.Name)
 	assert.Equal(t, 100, capturedCoupon.Amount)
 	assert.Equal(t, 100, capturedCoupon.RemainingAmount, "RemainingAmount should equal Amount on creation")
+	assert.Equal(t, model.VisibilityPublic, capturedCoupon.Visibility, "coupons are public by default")
 }
 
 func TestCouponService_Create_DuplicateCoupon(t *testing.T) {
-	mockCouponRepo := &mockCouponRepository{
-		insertFn: func(ctx context.Context, coupon *model.Coupon) error {
-			return ErrCouponExists
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			return apperr.ErrCouponExists
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
 	req := &model.CreateCouponRequest{
@@ -116,17 +103,17 @@ func TestCouponService_Create_DuplicateCoupon(t *testing.T) {
 	err := svc.Create(context.Background(), req)
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, ErrCouponExists), "error should be ErrCouponExists")
+	assert.True(t, errors.Is(err, apperr.ErrCouponExists), "error should be apperr.ErrCouponExists")
 }
 
 func TestCouponService_Create_RepositoryError(t *testing.T) {
 	repoErr := errors.New("database connection failed")
-	mockCouponRepo := &mockCouponRepository{
-		insertFn: func(ctx context.Context, coupon *model.Coupon) error {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
 			return repoErr
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
 	req := &model.CreateCouponRequest{
@@ -137,23 +124,23 @@ func TestCouponService_Create_RepositoryError(t *testing.T) {
 	err := svc.Create(context.Background(), req)
 
 	require.Error(t, err)
-	assert.False(t, errors.Is(err, ErrCouponExists), "error should not be ErrCouponExists")
+	assert.False(t, errors.Is(err, apperr.ErrCouponExists), "error should not be apperr.ErrCouponExists")
 }
 
 func TestCouponService_Create_NilRequest(t *testing.T) {
-	mockCouponRepo := &mockCouponRepository{}
-	mockClaimRepo := &mockClaimRepository{}
+	mockCouponRepo := &mocks.CouponRepositoryMock{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
 
 	err := svc.Create(context.Background(), nil)
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, ErrInvalidRequest), "should return ErrInvalidRequest for nil request")
+	assert.True(t, errors.Is(err, apperr.ErrInvalidRequest), "should return apperr.ErrInvalidRequest for nil request")
 }
 
 func TestCouponService_Create_NilAmount(t *testing.T) {
-	mockCouponRepo := &mockCouponRepository{}
-	mockClaimRepo := &mockClaimRepository{}
+	mockCouponRepo := &mocks.CouponRepositoryMock{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
 
 	req := &model.CreateCouponRequest{
@@ -164,12 +151,12 @@ func TestCouponService_Create_NilAmount(t *testing.T) {
 	err := svc.Create(context.Background(), req)
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, ErrInvalidRequest), "should return ErrInvalidRequest for nil amount")
+	assert.True(t, errors.Is(err, apperr.ErrInvalidRequest), "should return apperr.ErrInvalidRequest for nil amount")
 }
 
 func TestCouponService_GetByName_WithClaims(t *testing.T) {
-	mockCouponRepo := &mockCouponRepository{
-		getByNameFn: func(ctx context.Context, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
 			return &model.Coupon{
 				Name:            "PROMO_SUPER",
 				Amount:          100,
@@ -178,8 +165,8 @@ func TestCouponService_GetByName_WithClaims(t *testing.T) {
 			}, nil
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{
-		getUsersByCouponFn: func(ctx context.Context, couponName string) ([]string, error) {
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		GetUsersByCouponFunc: func(ctx context.Context, couponName string, limit int) ([]string, error) {
 			return []string{"user_001", "user_002", "user_003", "user_004", "user_005"}, nil
 		},
 	}
@@ -193,11 +180,50 @@ func TestCouponService_GetByName_WithClaims(t *testing.T) {
 	assert.Equal(t, 100, resp.Amount)
 	assert.Equal(t, 95, resp.RemainingAmount)
 	assert.Equal(t, []string{"user_001", "user_002", "user_003", "user_004", "user_005"}, resp.ClaimedBy)
+	assert.Equal(t, 5, resp.ClaimedCount)
+	assert.False(t, resp.Truncated)
+}
+
+func TestCouponService_GetByName_ClaimedByLimit(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: "HUGE", Amount: 100, RemainingAmount: 50}, nil
+		},
+	}
+	claims := []string{"user_001", "user_002", "user_003", "user_004"}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		GetUsersByCouponFunc: func(ctx context.Context, couponName string, limit int) ([]string, error) {
+			return claims[:min(limit, len(claims))], nil
+		},
+		CountByCouponFunc: func(ctx context.Context, couponName string) (int, error) {
+			return 50, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
+	svc.SetClaimedByLimit(3)
+	resp, err := svc.GetByName(context.Background(), "HUGE")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_001", "user_002", "user_003"}, resp.ClaimedBy)
+	assert.Equal(t, 50, resp.ClaimedCount)
+	assert.True(t, resp.Truncated)
+	assert.Equal(t, 4, mockClaimRepo.GetUsersByCouponCalls()[0].Limit, "one user past the limit is read")
+
+	// At the limit nothing is left out, and claims are not counted
+	claims = claims[:3]
+	resp, err = svc.GetByName(context.Background(), "HUGE")
+
+	require.NoError(t, err)
+	assert.Equal(t, claims, resp.ClaimedBy)
+	assert.Equal(t, 3, resp.ClaimedCount)
+	assert.False(t, resp.Truncated)
+	assert.Len(t, mockClaimRepo.CountByCouponCalls(), 1)
 }
 
 func TestCouponService_GetByName_EmptyClaims(t *testing.T) {
-	mockCouponRepo := &mockCouponRepository{
-		getByNameFn: func(ctx context.Context, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
 			return &model.Coupon{
 				Name:            "NEW_PROMO",
 				Amount:          100,
@@ -206,8 +232,8 @@ func TestCouponService_GetByName_EmptyClaims(t *testing.T) {
 			}, nil
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{
-		getUsersByCouponFn: func(ctx context.Context, couponName string) ([]string, error) {
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		GetUsersByCouponFunc: func(ctx context.Context, couponName string, limit int) ([]string, error) {
 			return []string{}, nil // Empty slice, not nil
 		},
 	}
@@ -225,41 +251,41 @@ func TestCouponService_GetByName_EmptyClaims(t *testing.T) {
 }
 
 func TestCouponService_GetByName_NotFound(t *testing.T) {
-	mockCouponRepo := &mockCouponRepository{
-		getByNameFn: func(ctx context.Context, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
 			return nil, nil // Not found
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
 	resp, err := svc.GetByName(context.Background(), "NONEXISTENT")
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, ErrCouponNotFound), "error should be ErrCouponNotFound")
+	assert.True(t, errors.Is(err, apperr.ErrCouponNotFound), "error should be apperr.ErrCouponNotFound")
 	assert.Nil(t, resp)
 }
 
 func TestCouponService_GetByName_CouponRepoError(t *testing.T) {
 	dbErr := errors.New("database connection failed")
-	mockCouponRepo := &mockCouponRepository{
-		getByNameFn: func(ctx context.Context, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
 			return nil, dbErr
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
 	resp, err := svc.GetByName(context.Background(), "PROMO_SUPER")
 
 	require.Error(t, err)
 	assert.Nil(t, resp)
-	assert.False(t, errors.Is(err, ErrCouponNotFound), "error should not be ErrCouponNotFound")
+	assert.False(t, errors.Is(err, apperr.ErrCouponNotFound), "error should not be apperr.ErrCouponNotFound")
 }
 
 func TestCouponService_GetByName_ClaimRepoError(t *testing.T) {
-	mockCouponRepo := &mockCouponRepository{
-		getByNameFn: func(ctx context.Context, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
 			return &model.Coupon{
 				Name:            "PROMO_SUPER",
 				Amount:          100,
@@ -269,8 +295,8 @@ func TestCouponService_GetByName_ClaimRepoError(t *testing.T) {
 		},
 	}
 	dbErr := errors.New("database connection failed")
-	mockClaimRepo := &mockClaimRepository{
-		getUsersByCouponFn: func(ctx context.Context, couponName string) ([]string, error) {
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		GetUsersByCouponFunc: func(ctx context.Context, couponName string, limit int) ([]string, error) {
 			return nil, dbErr
 		},
 	}
@@ -282,83 +308,15 @@ func TestCouponService_GetByName_ClaimRepoError(t *testing.T) {
 	assert.Nil(t, resp)
 }
 
-// mockTx is a mock implementation of pgx.Tx for testing transactions.
-type mockTx struct {
-	commitFn   func(ctx context.Context) error
-	rollbackFn func(ctx context.Context) error
-}
-
-func (m *mockTx) Begin(ctx context.Context) (pgx.Tx, error) {
-	return nil, errors.New("nested transactions not supported")
-}
-
-func (m *mockTx) Commit(ctx context.Context) error {
-	if m.commitFn != nil {
-		return m.commitFn(ctx)
-	}
-	return nil
-}
-
-func (m *mockTx) Rollback(ctx context.Context) error {
-	if m.rollbackFn != nil {
-		return m.rollbackFn(ctx)
-	}
-	return nil
-}
-
-func (m *mockTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
-	return 0, nil
-}
-
-func (m *mockTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
-	return nil
-}
-
-func (m *mockTx) LargeObjects() pgx.LargeObjects {
-	return pgx.LargeObjects{}
-}
-
-func (m *mockTx) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
-	return nil, nil
-}
-
-func (m *mockTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
-	return pgconn.CommandTag{}, nil
-}
-
-func (m *mockTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
-	return nil, nil
-}
-
-func (m *mockTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
-	return nil
-}
-
-func (m *mockTx) Conn() *pgx.Conn {
-	return nil
-}
-
-// mockTxBeginner is a mock implementation of TxBeginner.
-type mockTxBeginner struct {
-	beginFn func(ctx context.Context) (pgx.Tx, error)
-}
-
-func (m *mockTxBeginner) Begin(ctx context.Context) (pgx.Tx, error) {
-	if m.beginFn != nil {
-		return m.beginFn(ctx)
-	}
-	return &mockTx{}, nil
-}
-
 func TestCouponService_ClaimCoupon_Success(t *testing.T) {
-	tx := &mockTx{}
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	tx := newTx()
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return tx, nil
 		},
 	}
-	mockCouponRepo := &mockCouponRepository{
-		getCouponForUpdateFn: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
 			return &model.Coupon{
 				Name:            "PROMO_SUPER",
 				Amount:          100,
@@ -366,31 +324,32 @@ func TestCouponService_ClaimCoupon_Success(t *testing.T) {
 				CreatedAt:       time.Now(),
 			}, nil
 		},
-		decrementStockFn: func(ctx context.Context, tx database.TxQuerier, name string) error {
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
 			return nil
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{
-		insertFn: func(ctx context.Context, tx database.TxQuerier, userID, couponName string) error {
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
 			return nil
 		},
 	}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_001", "PROMO_SUPER")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
 
 	require.NoError(t, err)
 }
 
 func TestCouponService_ClaimCoupon_DuplicateClaim(t *testing.T) {
-	tx := &mockTx{}
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	tx := newTx()
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return tx, nil
 		},
 	}
-	mockCouponRepo := &mockCouponRepository{
-		getCouponForUpdateFn: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
 			return &model.Coupon{
 				Name:            "PROMO_SUPER",
 				Amount:          100,
@@ -399,28 +358,62 @@ func TestCouponService_ClaimCoupon_DuplicateClaim(t *testing.T) {
 			}, nil
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{
-		insertFn: func(ctx context.Context, tx database.TxQuerier, userID, couponName string) error {
-			return ErrAlreadyClaimed
+	claimedAt := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			return apperr.ErrAlreadyClaimed
+		},
+		GetClaimFunc: func(ctx context.Context, userID, couponName string) (*model.Claim, error) {
+			return &model.Claim{UserID: userID, CouponName: couponName, Sequence: 42, CreatedAt: claimedAt}, nil
 		},
 	}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_001", "PROMO_SUPER")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, ErrAlreadyClaimed), "error should be ErrAlreadyClaimed")
+	assert.True(t, errors.Is(err, apperr.ErrAlreadyClaimed), "error should be apperr.ErrAlreadyClaimed")
+	var conflict *apperr.AlreadyClaimedError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, &apperr.AlreadyClaimedError{ClaimedAt: claimedAt, ClaimSequence: 42}, conflict)
+	require.Len(t, mockClaimRepo.GetClaimCalls(), 1)
+	assert.Equal(t, "user_001", mockClaimRepo.GetClaimCalls()[0].UserID)
+}
+
+func TestCouponService_ClaimCoupon_DuplicateClaimWithoutEarlierClaim(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 5}, nil
+		},
+	}
+	for name, lookupErr := range map[string]error{"anonymized": nil, "lookup failed": errors.New("connection refused")} {
+		t.Run(name, func(t *testing.T) {
+			claimRepo := &mocks.ClaimRepositoryMock{
+				InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+					return apperr.ErrAlreadyClaimed
+				},
+				GetClaimFunc: func(ctx context.Context, userID, couponName string) (*model.Claim, error) {
+					return nil, lookupErr
+				},
+			}
+			svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+
+			_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+
+			assert.Same(t, apperr.ErrAlreadyClaimed, err)
+		})
+	}
 }
 
 func TestCouponService_ClaimCoupon_NoStock(t *testing.T) {
-	tx := &mockTx{}
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	tx := newTx()
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return tx, nil
 		},
 	}
-	mockCouponRepo := &mockCouponRepository{
-		getCouponForUpdateFn: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
 			return &model.Coupon{
 				Name:            "PROMO_SUPER",
 				Amount:          100,
@@ -429,58 +422,58 @@ func TestCouponService_ClaimCoupon_NoStock(t *testing.T) {
 			}, nil
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_999", "PROMO_SUPER")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_999", "PROMO_SUPER"))
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, ErrNoStock), "error should be ErrNoStock")
+	assert.True(t, errors.Is(err, apperr.ErrNoStock), "error should be apperr.ErrNoStock")
 }
 
 func TestCouponService_ClaimCoupon_CouponNotFound(t *testing.T) {
-	tx := &mockTx{}
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	tx := newTx()
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return tx, nil
 		},
 	}
-	mockCouponRepo := &mockCouponRepository{
-		getCouponForUpdateFn: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
-			return nil, ErrCouponNotFound
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return nil, apperr.ErrCouponNotFound
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_001", "NONEXISTENT")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "NONEXISTENT"))
 
 	require.Error(t, err)
-	assert.True(t, errors.Is(err, ErrCouponNotFound), "error should be ErrCouponNotFound")
+	assert.True(t, errors.Is(err, apperr.ErrCouponNotFound), "error should be apperr.ErrCouponNotFound")
 }
 
 func TestCouponService_ClaimCoupon_TransactionRollbackOnFailure(t *testing.T) {
 	rollbackCalled := false
-	tx := &mockTx{
-		rollbackFn: func(ctx context.Context) error {
+	tx := &mocks.TxMock{
+		RollbackFunc: func(ctx context.Context) error {
 			rollbackCalled = true
 			return nil
 		},
 	}
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return tx, nil
 		},
 	}
-	mockCouponRepo := &mockCouponRepository{
-		getCouponForUpdateFn: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
-			return nil, ErrCouponNotFound
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return nil, apperr.ErrCouponNotFound
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_001", "NONEXISTENT")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "NONEXISTENT"))
 
 	require.Error(t, err)
 	assert.True(t, rollbackCalled, "rollback should be called on failure")
@@ -488,53 +481,53 @@ func TestCouponService_ClaimCoupon_TransactionRollbackOnFailure(t *testing.T) {
 
 func TestCouponService_ClaimCoupon_BeginTxError(t *testing.T) {
 	txErr := errors.New("database connection pool exhausted")
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return nil, txErr
 		},
 	}
-	mockCouponRepo := &mockCouponRepository{}
-	mockClaimRepo := &mockClaimRepository{}
+	mockCouponRepo := &mocks.CouponRepositoryMock{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_001", "PROMO_SUPER")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "begin tx", "error should mention transaction begin")
 }
 
 func TestCouponService_ClaimCoupon_GetCouponForUpdateError(t *testing.T) {
-	tx := &mockTx{}
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	tx := newTx()
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return tx, nil
 		},
 	}
 	dbErr := errors.New("database query timeout")
-	mockCouponRepo := &mockCouponRepository{
-		getCouponForUpdateFn: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
-			return nil, dbErr // Non-ErrCouponNotFound error
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return nil, dbErr // Non-apperr.ErrCouponNotFound error
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_001", "PROMO_SUPER")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "get coupon for update", "error should mention get coupon for update")
-	assert.False(t, errors.Is(err, ErrCouponNotFound), "error should not be ErrCouponNotFound")
+	assert.False(t, errors.Is(err, apperr.ErrCouponNotFound), "error should not be apperr.ErrCouponNotFound")
 }
 
 func TestCouponService_ClaimCoupon_ClaimInsertError(t *testing.T) {
-	tx := &mockTx{}
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	tx := newTx()
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return tx, nil
 		},
 	}
-	mockCouponRepo := &mockCouponRepository{
-		getCouponForUpdateFn: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
 			return &model.Coupon{
 				Name:            "PROMO_SUPER",
 				Amount:          100,
@@ -543,47 +536,48 @@ func TestCouponService_ClaimCoupon_ClaimInsertError(t *testing.T) {
 		},
 	}
 	dbErr := errors.New("database insert timeout")
-	mockClaimRepo := &mockClaimRepository{
-		insertFn: func(ctx context.Context, tx database.TxQuerier, userID, couponName string) error {
-			return dbErr // Non-ErrAlreadyClaimed error
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			return dbErr // Non-apperr.ErrAlreadyClaimed error
 		},
 	}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_001", "PROMO_SUPER")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "insert claim", "error should mention insert claim")
-	assert.False(t, errors.Is(err, ErrAlreadyClaimed), "error should not be ErrAlreadyClaimed")
+	assert.False(t, errors.Is(err, apperr.ErrAlreadyClaimed), "error should not be apperr.ErrAlreadyClaimed")
 }
 
 func TestCouponService_ClaimCoupon_DecrementStockError(t *testing.T) {
-	tx := &mockTx{}
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	tx := newTx()
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return tx, nil
 		},
 	}
-	mockCouponRepo := &mockCouponRepository{
-		getCouponForUpdateFn: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
 			return &model.Coupon{
 				Name:            "PROMO_SUPER",
 				Amount:          100,
 				RemainingAmount: 5,
 			}, nil
 		},
-		decrementStockFn: func(ctx context.Context, tx database.TxQuerier, name string) error {
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
 			return errors.New("database update timeout")
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{
-		insertFn: func(ctx context.Context, tx database.TxQuerier, userID, couponName string) error {
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
 			return nil
 		},
 	}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_001", "PROMO_SUPER")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "decrement stock", "error should mention decrement stock")
@@ -591,37 +585,1148 @@ func TestCouponService_ClaimCoupon_DecrementStockError(t *testing.T) {
 
 func TestCouponService_ClaimCoupon_CommitError(t *testing.T) {
 	commitErr := errors.New("database commit timeout")
-	tx := &mockTx{
-		commitFn: func(ctx context.Context) error {
+	tx := &mocks.TxMock{
+		CommitFunc: func(ctx context.Context) error {
 			return commitErr
 		},
+		RollbackFunc: func(ctx context.Context) error { return nil },
 	}
-	mockPool := &mockTxBeginner{
-		beginFn: func(ctx context.Context) (pgx.Tx, error) {
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
 			return tx, nil
 		},
 	}
-	mockCouponRepo := &mockCouponRepository{
-		getCouponForUpdateFn: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
 			return &model.Coupon{
 				Name:            "PROMO_SUPER",
 				Amount:          100,
 				RemainingAmount: 5,
 			}, nil
 		},
-		decrementStockFn: func(ctx context.Context, tx database.TxQuerier, name string) error {
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
 			return nil
 		},
 	}
-	mockClaimRepo := &mockClaimRepository{
-		insertFn: func(ctx context.Context, tx database.TxQuerier, userID, couponName string) error {
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
 			return nil
 		},
 	}
 
 	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
-	err := svc.ClaimCoupon(context.Background(), "user_001", "PROMO_SUPER")
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
 
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, commitErr), "error should wrap commit error")
 }
+
+func TestCouponService_Create_DeduplicatesTags(t *testing.T) {
+	var capturedCoupon *model.Coupon
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			capturedCoupon = coupon
+			return nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	err := svc.Create(context.Background(), &model.CreateCouponRequest{
+		Name:   "PROMO_SUPER",
+		Amount: intPtr(100),
+		Tags:   []string{"blackfriday", "app", "blackfriday"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"blackfriday", "app"}, capturedCoupon.Tags)
+}
+
+func TestCouponService_GetByNameClaimedBy(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 95}, nil
+		},
+	}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		GetClaimedUsersFunc: func(ctx context.Context, couponName string, userIDs []string) ([]string, error) {
+			return []string{"user_3", "user_1"}, nil // In no particular order
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
+	resp, err := svc.GetByNameClaimedBy(context.Background(), "PROMO_SUPER", []string{"user_1", "user_2", "user_3", "user_1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 95, resp.RemainingAmount)
+	assert.Equal(t, []string{"user_1", "user_3"}, resp.ClaimedBy, "claimers in request order, once each")
+	assert.Empty(t, mockClaimRepo.GetUsersByCouponCalls(), "the full claim list is not read")
+}
+
+func TestCouponService_GetByNameClaimedBy_NoneClaimed(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 100}, nil
+		},
+	}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		GetClaimedUsersFunc: func(ctx context.Context, couponName string, userIDs []string) ([]string, error) {
+			return []string{}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
+	resp, err := svc.GetByNameClaimedBy(context.Background(), "PROMO_SUPER", []string{"user_1"})
+
+	require.NoError(t, err)
+	assert.NotNil(t, resp.ClaimedBy, "ClaimedBy should be empty slice, not nil")
+	assert.Empty(t, resp.ClaimedBy)
+
+	_, err = svc.GetByNameClaimedBy(context.Background(), "PROMO_SUPER", nil)
+	assert.ErrorIs(t, err, apperr.ErrInvalidRequest)
+}
+
+func TestCouponService_GetByName_NilTagsBecomeEmpty(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, noClaims())
+	resp, err := svc.GetByName(context.Background(), "PROMO_SUPER")
+
+	require.NoError(t, err)
+	assert.NotNil(t, resp.Tags, "Tags should be empty array, not null")
+	assert.Len(t, resp.Tags, 0)
+}
+
+func TestCouponService_List_Success(t *testing.T) {
+	var capturedFilter model.CouponFilter
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		ListFunc: func(ctx context.Context, filter model.CouponFilter) ([]model.Coupon, error) {
+			capturedFilter = filter
+			return []model.Coupon{
+				{Name: "BF_APP", Amount: 10, RemainingAmount: 4, Tags: []string{"blackfriday"}},
+				{Name: "BF_WEB", Amount: 20, RemainingAmount: 20},
+			}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	resp, err := svc.List(context.Background(), model.CouponFilter{Tag: "blackfriday", Limit: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, model.CouponFilter{Tag: "blackfriday", Limit: 11}, capturedFilter, "one more coupon tells whether there is a next page")
+	require.Len(t, resp.Items, 2)
+	assert.Equal(t, model.CouponSummary{Name: "BF_APP", Amount: 10, RemainingAmount: 4, Tags: []string{"blackfriday"}, Status: model.CouponStatusActive}, resp.Items[0])
+	assert.Equal(t, []string{}, resp.Items[1].Tags)
+	assert.Empty(t, resp.NextCursor, "last page")
+}
+
+func TestCouponService_List_NextPage(t *testing.T) {
+	var capturedFilter model.CouponFilter
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		ListFunc: func(ctx context.Context, filter model.CouponFilter) ([]model.Coupon, error) {
+			capturedFilter = filter
+			return []model.Coupon{{Name: "BF_APP", Amount: 10}, {Name: "BF_WEB", Amount: 10}, {Name: "BF_X", Amount: 10}}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	resp, err := svc.List(context.Background(), model.CouponFilter{After: "BF_A", Limit: 2})
+
+	require.NoError(t, err)
+	assert.Equal(t, "BF_A", capturedFilter.After)
+	require.Len(t, resp.Items, 2)
+	assert.Equal(t, "BF_WEB", resp.Items[1].Name)
+	key, err := model.DecodeCursor(resp.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, "BF_WEB", key, "the next page starts after the last coupon")
+}
+
+func TestCouponService_List_RepositoryError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		ListFunc: func(ctx context.Context, filter model.CouponFilter) ([]model.Coupon, error) {
+			return nil, dbErr
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	resp, err := svc.List(context.Background(), model.CouponFilter{Limit: 10})
+
+	require.Error(t, err)
+	assert.Nil(t, resp)
+	assert.True(t, errors.Is(err, dbErr), "should wrap original error")
+}
+
+func TestCouponService_Update_Tags(t *testing.T) {
+	var capturedTags []string
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		UpdateTagsFunc: func(ctx context.Context, name string, tags []string) error {
+			capturedTags = tags
+			return nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10, Tags: capturedTags}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, noClaims())
+	resp, err := svc.Update(context.Background(), "PROMO_SUPER", &model.UpdateCouponRequest{
+		Tags: []string{"a", "b", "a"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, capturedTags)
+	assert.Equal(t, []string{"a", "b"}, resp.Tags)
+}
+
+func TestCouponService_Update_NilTagsLeavesTagsUnchanged(t *testing.T) {
+	updateCalled := false
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		UpdateTagsFunc: func(ctx context.Context, name string, tags []string) error {
+			updateCalled = true
+			return nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10, Tags: []string{"keep"}}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, noClaims())
+	resp, err := svc.Update(context.Background(), "PROMO_SUPER", &model.UpdateCouponRequest{})
+
+	require.NoError(t, err)
+	assert.False(t, updateCalled, "UpdateTags should not be called when tags are omitted")
+	assert.Equal(t, []string{"keep"}, resp.Tags)
+}
+
+func TestCouponService_Update_NotFound(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		UpdateTagsFunc: func(ctx context.Context, name string, tags []string) error {
+			return apperr.ErrCouponNotFound
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	resp, err := svc.Update(context.Background(), "NONEXISTENT", &model.UpdateCouponRequest{Tags: []string{}})
+
+	require.Error(t, err)
+	assert.Nil(t, resp)
+	assert.True(t, errors.Is(err, apperr.ErrCouponNotFound))
+}
+
+func TestCouponService_Update_NilRequest(t *testing.T) {
+	svc := NewCouponService(nil, &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	resp, err := svc.Update(context.Background(), "PROMO_SUPER", nil)
+
+	require.Error(t, err)
+	assert.Nil(t, resp)
+	assert.True(t, errors.Is(err, apperr.ErrInvalidRequest))
+}
+
+func TestCouponService_Create_WithChannels(t *testing.T) {
+	var capturedCoupon *model.Coupon
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			capturedCoupon = coupon
+			return nil
+		},
+	}
+	overflowAt := time.Date(2026, 11, 30, 23, 0, 0, 0, time.UTC)
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	err := svc.Create(context.Background(), &model.CreateCouponRequest{
+		Name:       "BF_SPLIT",
+		Amount:     intPtr(100),
+		Channels:   map[string]int{"app": 70, "web": 30},
+		OverflowAt: &overflowAt,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ChannelQuota{
+		{Channel: "app", Quota: 70, Remaining: 70},
+		{Channel: "web", Quota: 30, Remaining: 30},
+	}, capturedCoupon.Channels)
+	assert.Equal(t, &overflowAt, capturedCoupon.OverflowAt)
+}
+
+func TestCouponService_Create_OverflowIgnoredWithoutChannels(t *testing.T) {
+	var capturedCoupon *model.Coupon
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			capturedCoupon = coupon
+			return nil
+		},
+	}
+	overflowAt := time.Now()
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	err := svc.Create(context.Background(), &model.CreateCouponRequest{
+		Name:       "PLAIN",
+		Amount:     intPtr(10),
+		OverflowAt: &overflowAt,
+	})
+
+	require.NoError(t, err)
+	assert.Nil(t, capturedCoupon.OverflowAt)
+}
+
+func TestCouponService_Create_InvalidChannelPercentages(t *testing.T) {
+	insertCalled := false
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			insertCalled = true
+			return nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	err := svc.Create(context.Background(), &model.CreateCouponRequest{
+		Name:     "BF_SPLIT",
+		Amount:   intPtr(100),
+		Channels: map[string]int{"app": 70, "web": 10},
+	})
+
+	assert.ErrorIs(t, err, apperr.ErrInvalidChannelQuotas)
+	assert.False(t, insertCalled)
+}
+
+func TestCouponService_ClaimCoupon_NilRequest(t *testing.T) {
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+
+	_, err := svc.ClaimCoupon(context.Background(), nil)
+
+	assert.ErrorIs(t, err, apperr.ErrInvalidRequest)
+}
+
+func TestCouponService_ClaimCoupon_ChannelPartition(t *testing.T) {
+	var decrementedChannel string
+	var insertedClaim *model.Claim
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{
+				Name:            name,
+				Amount:          10,
+				RemainingAmount: 5,
+				Channels: []model.ChannelQuota{
+					{Channel: "app", Quota: 7, Remaining: 4},
+					{Channel: "web", Quota: 3, Remaining: 1},
+				},
+			}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			return nil
+		},
+		DecrementChannelStockFunc: func(ctx context.Context, tx database.TxQuerier, name, channel string) error {
+			decrementedChannel = channel
+			return nil
+		},
+	}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			insertedClaim = claim
+			return nil
+		},
+	}
+
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, mockClaimRepo)
+	_, err := svc.ClaimCoupon(context.Background(), &model.ClaimCouponRequest{
+		UserID: "user_001", CouponName: "BF_SPLIT", Channel: "web",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "web", decrementedChannel)
+	assert.Equal(t, &model.Claim{UserID: "user_001", CouponName: "BF_SPLIT", Channel: "web", Sequence: 1}, insertedClaim)
+}
+
+func TestCouponService_ClaimCoupon_OverflowFollowsClock(t *testing.T) {
+	overflowAt := time.Date(2026, 11, 27, 12, 0, 0, 0, time.UTC)
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{
+				Name:            name,
+				Amount:          10,
+				RemainingAmount: 3,
+				OverflowAt:      &overflowAt,
+				Channels: []model.ChannelQuota{
+					{Channel: "app", Quota: 7, Remaining: 0},
+					{Channel: "web", Quota: 3, Remaining: 3},
+				},
+			}, nil
+		},
+		RecordClaimStatsFunc:      func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:        func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+		DecrementChannelStockFunc: func(ctx context.Context, tx database.TxQuerier, name, channel string) error { return nil },
+	}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+	clk := clock.NewFake(overflowAt.Add(-time.Second))
+	svc := NewCouponServiceWithTransactor(passThroughTx(), mockCouponRepo, mockClaimRepo)
+	svc.SetClock(clk)
+	req := &model.ClaimCouponRequest{UserID: "user_001", CouponName: "BF_SPLIT", Channel: "app"}
+
+	_, err := svc.ClaimCoupon(context.Background(), req)
+	assert.ErrorIs(t, err, apperr.ErrNoStock, "no borrowing before overflow_at")
+
+	clk.Advance(time.Second)
+	_, err = svc.ClaimCoupon(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, mockCouponRepo.DecrementChannelStockCalls(), 1)
+	assert.Equal(t, "web", mockCouponRepo.DecrementChannelStockCalls()[0].Channel)
+	assert.Equal(t, overflowAt, mockCouponRepo.RecordClaimStatsCalls()[0].At)
+}
+
+func TestCouponService_ClaimCoupon_ChannelErrors(t *testing.T) {
+	overflowAt := time.Now().Add(time.Hour)
+	coupon := &model.Coupon{
+		Name:            "BF_SPLIT",
+		Amount:          10,
+		RemainingAmount: 3,
+		OverflowAt:      &overflowAt,
+		Channels: []model.ChannelQuota{
+			{Channel: "app", Quota: 7, Remaining: 0},
+			{Channel: "web", Quota: 3, Remaining: 3},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		channel string
+		wantErr error
+	}{
+		{"missing channel", "", apperr.ErrChannelRequired},
+		{"unknown channel", "kiosk", apperr.ErrUnknownChannel},
+		{"exhausted partition before overflow", "app", apperr.ErrNoStock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claimInserted := false
+			mockCouponRepo := &mocks.CouponRepositoryMock{
+				GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+					return coupon, nil
+				},
+			}
+			mockClaimRepo := &mocks.ClaimRepositoryMock{
+				InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+					claimInserted = true
+					return nil
+				},
+			}
+
+			svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, mockClaimRepo)
+			_, err := svc.ClaimCoupon(context.Background(), &model.ClaimCouponRequest{
+				UserID: "user_001", CouponName: "BF_SPLIT", Channel: tt.channel,
+			})
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, claimInserted, "no claim should be inserted when the partition check fails")
+		})
+	}
+}
+
+func TestCouponService_ClaimCoupon_DecrementChannelStockError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{
+				Name:            name,
+				RemainingAmount: 1,
+				Channels:        []model.ChannelQuota{{Channel: "app", Quota: 1, Remaining: 1}},
+			}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			return nil
+		},
+		DecrementChannelStockFunc: func(ctx context.Context, tx database.TxQuerier, name, channel string) error {
+			return dbErr
+		},
+	}
+
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			return nil
+		},
+	}
+
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, mockClaimRepo)
+	_, err := svc.ClaimCoupon(context.Background(), &model.ClaimCouponRequest{
+		UserID: "user_001", CouponName: "BF_SPLIT", Channel: "app",
+	})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, dbErr)
+	assert.Contains(t, err.Error(), "decrement channel stock")
+}
+
+func TestCouponService_ClaimCoupon_AssignsNextSequence(t *testing.T) {
+	var insertedClaim *model.Claim
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 59, ClaimSequence: 41}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			return nil
+		},
+	}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			insertedClaim = claim
+			return nil
+		},
+	}
+
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, mockClaimRepo)
+	receipt, err := svc.ClaimCoupon(context.Background(), claimRequest("user_042", "PROMO_SUPER"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 42, insertedClaim.Sequence)
+	assert.Equal(t, &model.ClaimReceipt{UserID: "user_042", CouponName: "PROMO_SUPER", ClaimSequence: 42}, receipt)
+}
+
+func TestCouponService_ClaimCoupon_RetriesSerializationFailure(t *testing.T) {
+	// On CockroachDB a contended claim fails with 40001 and is rerun from the start,
+	// seeing the state left by the transaction it conflicted with.
+	attempts := 0
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			attempts++
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 100 - attempts, ClaimSequence: 40 + attempts}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			if attempts == 1 {
+				return &pgconn.PgError{Code: "40001", Message: "restart transaction"}
+			}
+			return nil
+		},
+	}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			return nil
+		},
+	}
+	pool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return newTx(), nil },
+	}
+
+	tx := database.NewTransactor(pool, database.CockroachDB)
+	svc := NewCouponServiceWithTransactor(tx, mockCouponRepo, mockClaimRepo)
+	receipt, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+
+	require.NoError(t, err)
+	assert.Len(t, pool.BeginCalls(), 2)
+	assert.Len(t, mockClaimRepo.InsertCalls(), 2)
+	assert.Equal(t, 43, receipt.ClaimSequence, "receipt must come from the committed attempt")
+}
+
+func TestCouponService_ClaimCoupon_NoReceiptOnCommitError(t *testing.T) {
+	tx := &mocks.TxMock{
+		CommitFunc: func(ctx context.Context) error {
+			return errors.New("database commit timeout")
+		},
+		RollbackFunc: func(ctx context.Context) error { return nil },
+	}
+	mockPool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			return tx, nil
+		},
+	}
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 5}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			return nil
+		},
+	}
+
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			return nil
+		},
+	}
+
+	svc := NewCouponServiceWithTxBeginner(mockPool, mockCouponRepo, mockClaimRepo)
+	receipt, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+
+	require.Error(t, err)
+	assert.Nil(t, receipt, "a receipt must only be issued for committed claims")
+}
+
+func TestCouponService_ListClaims_Success(t *testing.T) {
+	claimedAt := time.Now()
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 7}, nil
+		},
+	}
+	var capturedAfter, capturedLimit int
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		CountByCouponFunc: func(ctx context.Context, couponName string) (int, error) {
+			return 3, nil
+		},
+		ListByCouponFunc: func(ctx context.Context, couponName string, afterSequence, limit int) ([]model.Claim, error) {
+			capturedAfter, capturedLimit = afterSequence, limit
+			return []model.Claim{
+				{UserID: "user_002", CouponName: couponName, Channel: "app", Sequence: 2, CreatedAt: claimedAt},
+				{UserID: "user_003", CouponName: couponName, Sequence: 3, CreatedAt: claimedAt},
+			}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
+	result, err := svc.ListClaims(context.Background(), "PROMO_SUPER", 1, 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, capturedAfter)
+	assert.Equal(t, 2, capturedLimit, "one more claim tells whether there is a next page")
+	assert.Equal(t, &model.ClaimListResponse{
+		CouponName: "PROMO_SUPER",
+		Page: model.Page[model.ClaimRecord]{
+			Items:      []model.ClaimRecord{{UserID: "user_002", Channel: "app", ClaimSequence: 2, ClaimedAt: claimedAt}},
+			NextCursor: model.EncodeCursor("2"),
+			Total:      intPtr(3),
+		},
+	}, result)
+}
+
+func TestCouponService_ListClaims_Empty(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, noClaims())
+	result, err := svc.ListClaims(context.Background(), "NEW_PROMO", 0, 100)
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Items, "claims should encode as [] rather than null")
+	assert.Empty(t, result.Items)
+	assert.Empty(t, result.NextCursor)
+	assert.Equal(t, 0, *result.Total)
+}
+
+func TestCouponService_ListClaims_NotFound(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return nil, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	_, err := svc.ListClaims(context.Background(), "NONEXISTENT", 0, 100)
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+}
+
+func TestCouponService_ListClaims_Unlisted(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, Visibility: model.VisibilityUnlisted}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	_, err := svc.ListClaims(context.Background(), "SECRET", 0, 100)
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+}
+
+func TestCouponService_ListClaims_RepositoryError(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name}, nil
+		},
+	}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		ListByCouponFunc: func(ctx context.Context, couponName string, afterSequence, limit int) ([]model.Claim, error) {
+			return nil, dbErr
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, mockClaimRepo)
+	_, err := svc.ListClaims(context.Background(), "PROMO_SUPER", 0, 100)
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.Contains(t, err.Error(), "list claims")
+}
+
+func TestCouponService_Create_WithTiers(t *testing.T) {
+	var capturedCoupon *model.Coupon
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			capturedCoupon = coupon
+			return nil
+		},
+	}
+	tiers := []model.Tier{{Name: "gold", Size: 100}, {Name: "silver", Size: 900}}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	err := svc.Create(context.Background(), &model.CreateCouponRequest{Name: "BF_TIERS", Amount: intPtr(1000), Tiers: tiers})
+
+	require.NoError(t, err)
+	assert.Equal(t, tiers, capturedCoupon.Tiers)
+}
+
+func TestCouponService_Create_TiersExceedAmount(t *testing.T) {
+	insertCalled := false
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			insertCalled = true
+			return nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	err := svc.Create(context.Background(), &model.CreateCouponRequest{
+		Name:   "BF_TIERS",
+		Amount: intPtr(500),
+		Tiers:  []model.Tier{{Name: "gold", Size: 100}, {Name: "silver", Size: 900}},
+	})
+
+	assert.ErrorIs(t, err, apperr.ErrInvalidTiers)
+	assert.False(t, insertCalled)
+}
+
+// schemaFunc adapts a function to MetadataSchema.
+type schemaFunc func(doc []byte) error
+
+func (f schemaFunc) Validate(doc []byte) error { return f(doc) }
+
+func TestCouponService_Create_Metadata(t *testing.T) {
+	requireCampaign := schemaFunc(func(doc []byte) error {
+		if !strings.Contains(string(doc), `"campaign"`) {
+			return errors.New("(root): campaign is required")
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name     string
+		schema   MetadataSchema
+		metadata string
+		stored   string // Empty when no metadata is stored
+		reason   string // Empty when the coupon is created
+	}{
+		{"absent", nil, ``, "", ""},
+		{"null", nil, `null`, "", ""},
+		{"object", nil, ` {"campaign": "summer"} `, `{"campaign": "summer"}`, ""},
+		{"array", nil, `[1, 2]`, "", "must be a JSON object"},
+		{"scalar", nil, `"summer"`, "", "must be a JSON object"},
+		{"conforms to schema", requireCampaign, `{"campaign": "summer"}`, `{"campaign": "summer"}`, ""},
+		{"violates schema", requireCampaign, `{"owner": "growth"}`, "",
+			"violates the metadata schema: (root): campaign is required"},
+		{"absent with schema", requireCampaign, ``, "",
+			"violates the metadata schema: (root): campaign is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured *model.Coupon
+			mockCouponRepo := &mocks.CouponRepositoryMock{
+				InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+					captured = coupon
+					return nil
+				},
+			}
+			svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+			svc.SetMetadataSchema(tt.schema)
+
+			err := svc.Create(context.Background(), &model.CreateCouponRequest{
+				Name: "META", Amount: intPtr(10), Metadata: json.RawMessage(tt.metadata),
+			})
+
+			if tt.reason != "" {
+				var metadataErr *apperr.MetadataError
+				require.ErrorAs(t, err, &metadataErr)
+				assert.ErrorIs(t, err, apperr.ErrInvalidMetadata)
+				assert.Equal(t, tt.reason, metadataErr.Reason)
+				assert.Nil(t, captured, "rejected coupons are not inserted")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.stored, string(captured.Metadata))
+		})
+	}
+}
+
+func TestCouponService_ClaimCoupon_AssignsTier(t *testing.T) {
+	tiers := []model.Tier{{Name: "gold", Size: 100}, {Name: "silver", Size: 900}}
+
+	tests := []struct {
+		name         string
+		lastSequence int
+		wantTier     string
+	}{
+		{"first claim", 0, "gold"},
+		{"last gold claim", 99, "gold"},
+		{"first silver claim", 100, "silver"},
+		{"beyond all tiers", 1000, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var insertedClaim *model.Claim
+			mockCouponRepo := &mocks.CouponRepositoryMock{
+				GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+					return &model.Coupon{Name: name, Amount: 2000, RemainingAmount: 2000 - tt.lastSequence, ClaimSequence: tt.lastSequence, Tiers: tiers}, nil
+				},
+				RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+				DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+					return nil
+				},
+			}
+			mockClaimRepo := &mocks.ClaimRepositoryMock{
+				InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+					insertedClaim = claim
+					return nil
+				},
+			}
+
+			svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, mockClaimRepo)
+			receipt, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "BF_TIERS"))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTier, insertedClaim.Tier)
+			assert.Equal(t, tt.wantTier, receipt.Tier)
+		})
+	}
+}
+
+func TestCouponService_Put_Created(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 100}, nil
+		},
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			return nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, noClaims())
+	resp, created, err := svc.Put(context.Background(), &model.CreateCouponRequest{Name: "PROMO_SUPER", Amount: intPtr(100)})
+
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "PROMO_SUPER", resp.Name)
+}
+
+func TestCouponService_Put_ExistingMatches(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			return apperr.ErrCouponExists
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 42, Tags: []string{"app", "blackfriday"}}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, noClaims())
+	resp, created, err := svc.Put(context.Background(), &model.CreateCouponRequest{
+		Name:   "PROMO_SUPER",
+		Amount: intPtr(100),
+		Tags:   []string{"blackfriday", "app"},
+	})
+
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, 42, resp.RemainingAmount)
+}
+
+func TestCouponService_Put_Conflict(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error {
+			return apperr.ErrCouponExists
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 100}, nil
+		},
+	}
+
+	svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+	_, _, err := svc.Put(context.Background(), &model.CreateCouponRequest{Name: "PROMO_SUPER", Amount: intPtr(200)})
+
+	var conflict *apperr.ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.ErrorIs(t, err, apperr.ErrCouponExists)
+	assert.Equal(t, []model.FieldDiff{{Field: "amount", Current: 100, Requested: 200}}, conflict.Diffs)
+}
+
+func TestCouponService_Put_Errors(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+
+	t.Run("invalid request", func(t *testing.T) {
+		svc := NewCouponService(nil, &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+		_, _, err := svc.Put(context.Background(), &model.CreateCouponRequest{Name: "PROMO_SUPER"})
+		assert.ErrorIs(t, err, apperr.ErrInvalidRequest)
+	})
+
+	t.Run("insert error", func(t *testing.T) {
+		mockCouponRepo := &mocks.CouponRepositoryMock{
+			InsertFunc: func(ctx context.Context, coupon *model.Coupon) error { return dbErr },
+		}
+		svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+		_, _, err := svc.Put(context.Background(), &model.CreateCouponRequest{Name: "PROMO_SUPER", Amount: intPtr(1)})
+		assert.ErrorIs(t, err, dbErr)
+	})
+
+	t.Run("deleted and not yet purged", func(t *testing.T) {
+		mockCouponRepo := &mocks.CouponRepositoryMock{
+			InsertFunc:    func(ctx context.Context, coupon *model.Coupon) error { return apperr.ErrCouponExists },
+			GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) { return nil, nil },
+		}
+		svc := NewCouponService(nil, mockCouponRepo, &mocks.ClaimRepositoryMock{})
+		_, _, err := svc.Put(context.Background(), &model.CreateCouponRequest{Name: "PROMO_SUPER", Amount: intPtr(1)})
+		assert.ErrorIs(t, err, apperr.ErrCouponDeleted)
+	})
+}
+
+func TestCouponService_ClaimCoupon_Disabled(t *testing.T) {
+	claimInserted := false
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10, Disabled: true}, nil
+		},
+	}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			claimInserted = true
+			return nil
+		},
+	}
+
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, mockClaimRepo)
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "OLD_PROMO"))
+
+	assert.ErrorIs(t, err, apperr.ErrCouponDisabled)
+	assert.False(t, claimInserted)
+}
+
+func TestCouponService_ClaimCoupon_CaptchaRequired(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10, CaptchaRequired: true}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	inserts := 0
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			inserts++
+			return nil
+		},
+	}
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, mockClaimRepo)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "DROP"))
+	assert.ErrorIs(t, err, apperr.ErrCaptchaRequired)
+	assert.Zero(t, inserts)
+
+	req := claimRequest("user_001", "DROP")
+	req.CaptchaVerified = true
+	_, err = svc.ClaimCoupon(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserts)
+}
+
+func TestCouponService_ClaimCoupon_PrivateRequiresGrant(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10, Visibility: model.VisibilityPrivate}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	inserts := 0
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			inserts++
+			return nil
+		},
+	}
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, mockClaimRepo)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "VIP"))
+	assert.ErrorIs(t, err, apperr.ErrGrantRequired)
+	assert.Zero(t, inserts)
+
+	req := claimRequest("user_001", "VIP")
+	req.GrantVerified = true
+	_, err = svc.ClaimCoupon(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserts)
+}
+
+func TestCouponService_ClaimCoupon_UnlistedClaimableByName(t *testing.T) {
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10, Visibility: model.VisibilityUnlisted}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+	}
+	mockClaimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, mockClaimRepo)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "SECRET"))
+	assert.NoError(t, err)
+}
+
+func TestCouponService_TopUp(t *testing.T) {
+	committed := false
+	tx := newTx()
+	tx.CommitFunc = func(ctx context.Context) error { committed = true; return nil }
+	pool := &mocks.TxBeginnerMock{BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return tx, nil }}
+
+	stored := &model.Coupon{Name: "PROMO_SUPER", Amount: 10, RemainingAmount: 2}
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, q database.TxQuerier, name string) (*model.Coupon, error) {
+			return stored, nil
+		},
+		TopUpFunc: func(ctx context.Context, q database.TxQuerier, name string, delta int) error {
+			assert.Same(t, tx, q)
+			stored.Amount += delta
+			stored.RemainingAmount += delta
+			return nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return stored, nil
+		},
+	}
+
+	svc := NewCouponServiceWithTxBeginner(pool, mockCouponRepo, noClaims())
+	resp, err := svc.TopUp(context.Background(), "PROMO_SUPER", 5)
+
+	require.NoError(t, err)
+	assert.True(t, committed)
+	assert.Equal(t, 15, resp.Amount)
+	assert.Equal(t, 7, resp.RemainingAmount)
+}
+
+func TestCouponService_TopUp_Errors(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+
+	tests := []struct {
+		name    string
+		amount  int
+		coupon  *model.Coupon
+		lockErr error
+		topUp   error
+		wantErr error
+	}{
+		{name: "non-positive amount", amount: 0, wantErr: apperr.ErrInvalidRequest},
+		{name: "not found", amount: 1, lockErr: apperr.ErrCouponNotFound, wantErr: apperr.ErrCouponNotFound},
+		{name: "partitioned", amount: 1, coupon: &model.Coupon{Channels: []model.ChannelQuota{{Channel: "app"}}}, wantErr: apperr.ErrPartitionedTopUp},
+		{name: "unlimited", amount: 1, coupon: &model.Coupon{Unlimited: true}, wantErr: apperr.ErrUnlimitedTopUp},
+		{name: "write error", amount: 1, coupon: &model.Coupon{}, topUp: dbErr, wantErr: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topUpCalled := false
+			mockCouponRepo := &mocks.CouponRepositoryMock{
+				GetCouponForUpdateFunc: func(ctx context.Context, q database.TxQuerier, name string) (*model.Coupon, error) {
+					return tt.coupon, tt.lockErr
+				},
+				TopUpFunc: func(ctx context.Context, q database.TxQuerier, name string, delta int) error {
+					topUpCalled = true
+					return tt.topUp
+				},
+			}
+
+			svc := NewCouponServiceWithTxBeginner(newPool(newTx()), mockCouponRepo, &mocks.ClaimRepositoryMock{})
+			resp, err := svc.TopUp(context.Background(), "PROMO_SUPER", tt.amount)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Nil(t, resp)
+			assert.Equal(t, tt.topUp != nil, topUpCalled)
+		})
+	}
+}
+
+func TestCouponService_GetByName_Cache(t *testing.T) {
+	loads := 0
+	tags := []string{"old"}
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			loads++
+			if name == "MISSING" {
+				return nil, nil
+			}
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10, Tags: tags}, nil
+		},
+		UpdateTagsFunc: func(ctx context.Context, name string, newTags []string) error {
+			tags = newTags
+			return nil
+		},
+	}
+	svc := NewCouponService(nil, mockCouponRepo, noClaims())
+	svc.SetCache(cache.NewLRU[string, *model.CouponResponse](10, time.Minute))
+	ctx := context.Background()
+
+	_, err := svc.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	resp, err := svc.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, 1, loads, "second read is served from the cache")
+	assert.Equal(t, []string{"old"}, resp.Tags)
+
+	_, err = svc.Update(ctx, "PROMO", &model.UpdateCouponRequest{Tags: []string{"new"}})
+	require.NoError(t, err)
+	resp, err = svc.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new"}, resp.Tags, "writes through the service invalidate the entry")
+
+	_, err = svc.GetByName(ctx, "MISSING")
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	loadsBefore := loads
+	_, err = svc.GetByName(ctx, "MISSING")
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	assert.Equal(t, loadsBefore+1, loads, "not-found results are not cached")
+}
+
+func TestCouponService_GetByName_Hedged(t *testing.T) {
+	var loads atomic.Int32
+	stuck := make(chan struct{})
+	mockCouponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			if loads.Add(1) == 21 { // First read after warm-up stalls, e.g. on a busy connection
+				<-ctx.Done()
+				close(stuck)
+				return nil, ctx.Err()
+			}
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10}, nil
+		},
+	}
+	h := hedge.New(time.Millisecond)
+	svc := NewCouponService(nil, mockCouponRepo, noClaims())
+	svc.SetHedger(h)
+	ctx := context.Background()
+
+	for range 20 { // Establish the p95 latency
+		_, err := svc.GetByName(ctx, "PROMO")
+		require.NoError(t, err)
+	}
+	resp, err := svc.GetByName(ctx, "PROMO")
+	require.NoError(t, err)
+	assert.Equal(t, "PROMO", resp.Name)
+	<-stuck // The stalled attempt is canceled once the hedge answers
+
+	stats := h.Stats()["get_coupon"]
+	assert.Equal(t, int64(21), stats.Requests)
+	assert.Equal(t, int64(1), stats.HedgeWon)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// claimStats returns the API view of a coupon's claim rollup at now; zero when the
+// coupon has no claims yet.
+func claimStats(stats *model.CouponStats, now time.Time) model.ClaimStats {
+	if stats == nil {
+		return model.ClaimStats{}
+	}
+	return model.ClaimStats{
+		TotalClaims:    stats.TotalClaims,
+		LastClaimAt:    stats.LastClaimAt,
+		ClaimsLastHour: claimsLastHour(stats, now),
+	}
+}
+
+// claimsLastHour estimates the claims in the hour before now from the rollup's two
+// clock-hour buckets, weighting the older bucket by the part of it still inside the
+// window.
+func claimsLastHour(stats *model.CouponStats, now time.Time) int {
+	current := now.Truncate(time.Hour)
+	left := 1 - float64(now.Sub(current))/float64(time.Hour)
+	switch {
+	case stats.HourStart.Equal(current):
+		return stats.ClaimsThisHour + int(math.Round(float64(stats.ClaimsPrevHour)*left))
+	case stats.HourStart.Equal(current.Add(-time.Hour)):
+		return int(math.Round(float64(stats.ClaimsThisHour) * left))
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestClaimsLastHour(t *testing.T) {
+	hour := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	stats := &model.CouponStats{HourStart: hour, ClaimsThisHour: 40, ClaimsPrevHour: 100}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{"start of the bucket", hour, 140},
+		{"quarter into the bucket", hour.Add(15 * time.Minute), 115},
+		{"end of the bucket", hour.Add(time.Hour - time.Nanosecond), 40},
+		{"bucket is the previous hour", hour.Add(90 * time.Minute), 20},
+		{"bucket is older", hour.Add(2 * time.Hour), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, claimsLastHour(stats, tt.now))
+		})
+	}
+}
+
+func TestClaimStats(t *testing.T) {
+	assert.Equal(t, model.ClaimStats{}, claimStats(nil, time.Now()), "a coupon without claims has empty stats")
+
+	lastClaimAt := time.Date(2026, 1, 1, 10, 5, 0, 0, time.UTC)
+	stats := &model.CouponStats{
+		TotalClaims: 500, LastClaimAt: &lastClaimAt,
+		HourStart: lastClaimAt.Truncate(time.Hour), ClaimsThisHour: 7,
+	}
+	assert.Equal(t, model.ClaimStats{TotalClaims: 500, LastClaimAt: &lastClaimAt, ClaimsLastHour: 7},
+		claimStats(stats, lastClaimAt.Add(time.Minute)))
+}
+
+func TestCouponService_ClaimCoupon_RecordsStats(t *testing.T) {
+	var recorded []time.Time
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 50}, nil
+		},
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error {
+			recorded = append(recorded, at)
+			return nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+
+	before := time.Now()
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	assert.False(t, recorded[0].Before(before))
+	assert.Equal(t, "PROMO", couponRepo.RecordClaimStatsCalls()[0].Name)
+}
@@ -0,0 +1,16 @@
+package service
+
+import "github.com/fairyhunter13/scalable-coupon-system/internal/model"
+
+// couponStatus returns the status of coupon c. It reflects c's own stock only: a child
+// whose parent ran out stays active, as the parent reports exhausted.
+func couponStatus(c *model.Coupon) string {
+	switch {
+	case c.Disabled:
+		return model.CouponStatusDisabled
+	case !c.Unlimited && c.RemainingAmount <= 0:
+		return model.CouponStatusExhausted
+	default:
+		return model.CouponStatusActive
+	}
+}
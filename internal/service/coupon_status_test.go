@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestCouponStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		coupon model.Coupon
+		want   string
+	}{
+		{"in stock", model.Coupon{Amount: 10, RemainingAmount: 1}, model.CouponStatusActive},
+		{"out of stock", model.Coupon{Amount: 10}, model.CouponStatusExhausted},
+		{"unlimited", model.Coupon{Unlimited: true}, model.CouponStatusActive},
+		{"disabled in stock", model.Coupon{Amount: 10, RemainingAmount: 10, Disabled: true}, model.CouponStatusDisabled},
+		{"disabled out of stock", model.Coupon{Amount: 10, Disabled: true}, model.CouponStatusDisabled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, couponStatus(&tt.coupon))
+			assert.Equal(t, tt.want, summarizeCoupon(&tt.coupon).Status)
+			assert.Equal(t, tt.want, couponResponse(&tt.coupon, nil, time.Now()).Status)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/stockwait"
+)
+
+// stockRecheckInterval bounds how long WaitForStock goes without reading the coupon,
+// in case a notification was missed (while the listener reconnects) or its read beat
+// the change to a lagging read replica.
+const stockRecheckInterval = 5 * time.Second
+
+// SetStockWaiter enables WaitForStock, whose waiters h wakes when a coupon's stock may
+// have changed.
+func (s *CouponService) SetStockWaiter(h *stockwait.Hub) {
+	s.waiters = h
+}
+
+// WaitForStock waits up to timeout for the coupon name to be claimable: not disabled,
+// with stock left, and with stock left in its parent's budget if it has one. It returns
+// the coupon's remaining stock as last read, with Available false when timeout passed
+// first or the waiter was closed. Returns apperr.ErrCouponNotFound if the coupon does
+// not exist, and apperr.ErrStockWaitUnavailable when too many requests are waiting or
+// the waiter is closed before the first read.
+func (s *CouponService) WaitForStock(ctx context.Context, name string, timeout time.Duration) (*model.StockWaitResponse, error) {
+	if !s.couponMayExist(name) {
+		return nil, apperr.ErrCouponNotFound
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp := &model.StockWaitResponse{Name: name}
+	names := []string{name}
+	for read := false; ; read = true {
+		// Subscribe before reading, so changes committed after the read wake this waiter
+		woken, unsubscribe, err := s.waiters.Subscribe(names...)
+		if err != nil {
+			if read && errors.Is(err, stockwait.ErrClosed) {
+				return resp, nil // Shutting down
+			}
+			return nil, apperr.ErrStockWaitUnavailable
+		}
+		parent, err := s.readStock(ctx, resp)
+		if err != nil {
+			unsubscribe()
+			if read && ctx.Err() != nil {
+				return resp, nil // Timed out during a recheck
+			}
+			return nil, err
+		}
+		if resp.Available {
+			unsubscribe()
+			return resp, nil
+		}
+		if parent != "" {
+			names = []string{name, parent}
+		}
+
+		timer := time.NewTimer(stockRecheckInterval)
+		select {
+		case <-woken:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+		unsubscribe()
+		if ctx.Err() != nil {
+			return resp, nil
+		}
+	}
+}
+
+// readStock reads resp's coupon, bypassing the cache, and sets resp's stock. It returns
+// the coupon's parent, if it has one.
+func (s *CouponService) readStock(ctx context.Context, resp *model.StockWaitResponse) (string, error) {
+	coupon, err := s.couponRepo.GetByName(ctx, resp.Name)
+	if err != nil {
+		return "", fmt.Errorf("get coupon: %w", err)
+	}
+	resp.RemainingAmount = coupon.RemainingAmount
+	resp.Available = !coupon.Disabled && hasStock(coupon)
+	if coupon.Parent == "" || !resp.Available {
+		return coupon.Parent, nil
+	}
+
+	parent, err := s.couponRepo.GetByName(ctx, coupon.Parent)
+	if err != nil {
+		return "", fmt.Errorf("get parent coupon: %w", err)
+	}
+	resp.Available = !parent.Disabled && parent.RemainingAmount > 0
+	return coupon.Parent, nil
+}
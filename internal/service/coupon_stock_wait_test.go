@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/stockwait"
+)
+
+// stockWaitService returns a CouponService waiting on hub, reading coupons from coupons.
+func stockWaitService(hub *stockwait.Hub, coupons func(name string) (*model.Coupon, error)) *CouponService {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return coupons(name)
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, &mocks.ClaimRepositoryMock{})
+	svc.SetStockWaiter(hub)
+	return svc
+}
+
+// notifyOnceWaiting notifies name on hub once a request is waiting.
+func notifyOnceWaiting(t *testing.T, hub *stockwait.Hub, name string) {
+	t.Helper()
+	go func() {
+		assert.Eventually(t, func() bool { return hub.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+		hub.Notify(name)
+	}()
+}
+
+func TestCouponService_WaitForStock(t *testing.T) {
+	t.Run("returns at once with stock left", func(t *testing.T) {
+		svc := stockWaitService(stockwait.New(10), func(name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, RemainingAmount: 3}, nil
+		})
+
+		resp, err := svc.WaitForStock(context.Background(), "PROMO", time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, &model.StockWaitResponse{Name: "PROMO", Available: true, RemainingAmount: 3}, resp)
+	})
+
+	t.Run("returns when a top-up is notified", func(t *testing.T) {
+		hub := stockwait.New(10)
+		var remaining atomic.Int64
+		svc := stockWaitService(hub, func(name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, RemainingAmount: int(remaining.Load())}, nil
+		})
+		go func() {
+			assert.Eventually(t, func() bool { return hub.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+			remaining.Store(50)
+			hub.Notify("PROMO")
+		}()
+
+		resp, err := svc.WaitForStock(context.Background(), "PROMO", time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, &model.StockWaitResponse{Name: "PROMO", Available: true, RemainingAmount: 50}, resp)
+		assert.Zero(t, hub.Stats().Waiting)
+	})
+
+	t.Run("keeps waiting when notified without stock", func(t *testing.T) {
+		hub := stockwait.New(10)
+		svc := stockWaitService(hub, func(name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Disabled: true, RemainingAmount: 5}, nil
+		})
+		notifyOnceWaiting(t, hub, "PROMO")
+
+		resp, err := svc.WaitForStock(context.Background(), "PROMO", 100*time.Millisecond)
+
+		require.NoError(t, err)
+		assert.Equal(t, &model.StockWaitResponse{Name: "PROMO", RemainingAmount: 5}, resp, "disabled coupons are not claimable")
+		assert.Equal(t, int64(1), hub.Stats().Woken)
+		assert.Zero(t, hub.Stats().Waiting)
+	})
+
+	t.Run("waits for the parent's budget", func(t *testing.T) {
+		hub := stockwait.New(10)
+		var budget atomic.Int64
+		svc := stockWaitService(hub, func(name string) (*model.Coupon, error) {
+			if name == "BUDGET" {
+				return &model.Coupon{Name: name, RemainingAmount: int(budget.Load())}, nil
+			}
+			return &model.Coupon{Name: name, RemainingAmount: 10, Parent: "BUDGET"}, nil
+		})
+		go func() {
+			// The first read finds the parent, the second subscribes to it
+			assert.Eventually(t, func() bool { return hub.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+			hub.Notify("CHILD")
+			assert.Eventually(t, func() bool { return hub.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+			budget.Store(1)
+			hub.Notify("BUDGET")
+		}()
+
+		resp, err := svc.WaitForStock(context.Background(), "CHILD", time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, &model.StockWaitResponse{Name: "CHILD", Available: true, RemainingAmount: 10}, resp)
+	})
+
+	t.Run("returns the last read when closed", func(t *testing.T) {
+		hub := stockwait.New(10)
+		svc := stockWaitService(hub, func(name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name}, nil
+		})
+		go func() {
+			assert.Eventually(t, func() bool { return hub.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+			hub.Close()
+		}()
+
+		resp, err := svc.WaitForStock(context.Background(), "PROMO", time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, &model.StockWaitResponse{Name: "PROMO"}, resp)
+	})
+
+	t.Run("unknown coupon", func(t *testing.T) {
+		svc := stockWaitService(stockwait.New(10), func(name string) (*model.Coupon, error) {
+			return nil, apperr.ErrCouponNotFound
+		})
+
+		_, err := svc.WaitForStock(context.Background(), "NOPE", time.Minute)
+
+		assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	})
+
+	t.Run("too many waiters", func(t *testing.T) {
+		svc := stockWaitService(stockwait.New(0), func(name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name}, nil
+		})
+
+		_, err := svc.WaitForStock(context.Background(), "PROMO", time.Minute)
+
+		assert.ErrorIs(t, err, apperr.ErrStockWaitUnavailable)
+	})
+}
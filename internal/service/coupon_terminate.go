@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// SetAuditLog records coupon terminations in repo.
+func (s *CouponService) SetAuditLog(repo ports.AuditRepository) {
+	s.audit = repo
+}
+
+// Terminate disables claims of the coupon name at once, e.g. to kill a misconfigured
+// promo, and returns its new state. Claims already holding the coupon's row lock
+// commit first; later ones get apperr.ErrCouponDisabled. The termination is recorded in the
+// audit log with reason and the stock left, in the same transaction, and announced
+// with a coupon.disabled event unless the coupon was already disabled. A manifest
+// apply listing the coupon re-enables it.
+// Returns apperr.ErrCouponNotFound if the coupon doesn't exist.
+func (s *CouponService) Terminate(ctx context.Context, name, reason string) (*model.CouponResponse, error) {
+	if !s.couponMayExist(name) {
+		return nil, apperr.ErrCouponNotFound
+	}
+
+	var coupon *model.Coupon
+	err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+		var err error
+		coupon, err = s.couponRepo.GetCouponForUpdate(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+		if !coupon.Disabled {
+			if err := s.couponRepo.SetDisabled(ctx, tx, name, true); err != nil {
+				return err
+			}
+		}
+		if s.audit == nil {
+			return nil
+		}
+		return s.audit.Insert(ctx, tx, &model.AuditEntry{
+			Action:  model.AuditActionCouponTerminated,
+			Subject: name,
+			Details: map[string]any{
+				"reason":           reason,
+				"remaining_amount": coupon.RemainingAmount,
+				"claims":           coupon.ClaimSequence,
+				"already_disabled": coupon.Disabled,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(name)
+	if !coupon.Disabled {
+		s.publishCoupon(ctx, model.CouponEventDisabled, name)
+	}
+	log.Warn().
+		Str("coupon_name", redact.Value(name)).
+		Int("remaining_amount", coupon.RemainingAmount).
+		Bool("already_disabled", coupon.Disabled).
+		Msg("coupon terminated")
+
+	return s.GetByName(ctx, name)
+}
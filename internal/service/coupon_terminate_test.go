@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// terminateService returns a service terminating coupon, recording whether it was
+// disabled, the audit entries written and the events published.
+func terminateService(coupon *model.Coupon, disabled *bool, entries *[]*model.AuditEntry, events *recordingPublisher) *CouponService {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			if name != coupon.Name {
+				return nil, apperr.ErrCouponNotFound
+			}
+			return coupon, nil
+		},
+		SetDisabledFunc: func(ctx context.Context, tx database.TxQuerier, name string, d bool) error {
+			*disabled = d
+			return nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: coupon.Amount, RemainingAmount: coupon.RemainingAmount, Disabled: true}, nil
+		},
+	}
+	audit := &mocks.AuditRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, entry *model.AuditEntry) error {
+			*entries = append(*entries, entry)
+			return nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		GetUsersByCouponFunc: func(ctx context.Context, name string, limit int) ([]string, error) { return nil, nil },
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+	svc.SetAuditLog(audit)
+	svc.SetEventPublisher(events)
+	return svc
+}
+
+func TestCouponService_Terminate(t *testing.T) {
+	var disabled bool
+	var entries []*model.AuditEntry
+	events := &recordingPublisher{}
+	svc := terminateService(&model.Coupon{Name: "PROMO", Amount: 100, RemainingAmount: 40, ClaimSequence: 60},
+		&disabled, &entries, events)
+
+	resp, err := svc.Terminate(context.Background(), "PROMO", "amount misconfigured")
+
+	require.NoError(t, err)
+	assert.True(t, resp.Disabled)
+	assert.True(t, disabled)
+	require.Len(t, entries, 1)
+	assert.Equal(t, model.AuditActionCouponTerminated, entries[0].Action)
+	assert.Equal(t, "PROMO", entries[0].Subject)
+	assert.Equal(t, "amount misconfigured", entries[0].Details["reason"])
+	assert.Equal(t, 40, entries[0].Details["remaining_amount"])
+	assert.Equal(t, []string{"coupon.disabled PROMO"}, events.events)
+}
+
+func TestCouponService_Terminate_AlreadyDisabled(t *testing.T) {
+	var disabled bool
+	var entries []*model.AuditEntry
+	events := &recordingPublisher{}
+	svc := terminateService(&model.Coupon{Name: "PROMO", Amount: 100, RemainingAmount: 40, Disabled: true},
+		&disabled, &entries, events)
+
+	_, err := svc.Terminate(context.Background(), "PROMO", "")
+
+	require.NoError(t, err)
+	assert.False(t, disabled, "the coupon is not written again")
+	require.Len(t, entries, 1, "the termination is still audited")
+	assert.Equal(t, true, entries[0].Details["already_disabled"])
+	assert.Empty(t, events.events)
+}
+
+func TestCouponService_Terminate_NotFound(t *testing.T) {
+	var disabled bool
+	var entries []*model.AuditEntry
+	svc := terminateService(&model.Coupon{Name: "PROMO"}, &disabled, &entries, &recordingPublisher{})
+
+	_, err := svc.Terminate(context.Background(), "MISSING", "")
+
+	assert.ErrorIs(t, err, apperr.ErrCouponNotFound)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// hasStock reports whether the coupon c has stock left for a claim. Unlimited coupons
+// always do.
+func hasStock(c *model.Coupon) bool {
+	return c.Unlimited || c.RemainingAmount > 0
+}
+
+// claimedCount returns the number of claims of c for API responses when it is
+// unlimited, whose amount and remaining stock say nothing, and nil otherwise.
+// Claim sequences are gap-free, so the latest is the count.
+func claimedCount(c *model.Coupon) *int {
+	if !c.Unlimited {
+		return nil
+	}
+	claimed := c.ClaimSequence
+	return &claimed
+}
+
+// takeStock takes one unit of the locked coupon's stock and advances its claim
+// sequence; unlimited coupons only advance the sequence.
+func (s *CouponService) takeStock(ctx context.Context, tx database.TxQuerier, coupon *model.Coupon) error {
+	if coupon.Unlimited {
+		return s.couponRepo.AdvanceClaimSequence(ctx, tx, coupon.Name)
+	}
+	return s.couponRepo.DecrementStock(ctx, tx, coupon.Name)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestNewCoupon_Unlimited(t *testing.T) {
+	coupon, err := newCoupon(&model.CreateCouponRequest{
+		Name:      "WELCOME",
+		Unlimited: true,
+		Tiers:     []model.Tier{{Name: "early", Size: 1000}},
+	})
+
+	require.NoError(t, err)
+	assert.True(t, coupon.Unlimited)
+	assert.Zero(t, coupon.Amount)
+	assert.Zero(t, coupon.RemainingAmount)
+	assert.Equal(t, []model.Tier{{Name: "early", Size: 1000}}, coupon.Tiers, "tiers are not bounded by an amount")
+}
+
+func TestNewCoupon_Unlimited_Invalid(t *testing.T) {
+	tests := map[string]*model.CreateCouponRequest{
+		"with amount":            {Name: "WELCOME", Unlimited: true, Amount: intPtr(10)},
+		"with channels":          {Name: "WELCOME", Unlimited: true, Channels: map[string]int{"app": 100}},
+		"with low stock percent": {Name: "WELCOME", Unlimited: true, LowStockPercent: 10},
+	}
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := newCoupon(req)
+
+			assert.ErrorIs(t, err, apperr.ErrInvalidRequest)
+		})
+	}
+}
+
+func TestCouponService_ClaimCoupon_Unlimited(t *testing.T) {
+	var advanced []string
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Unlimited: true, ClaimSequence: 41}, nil
+		},
+		AdvanceClaimSequenceFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			advanced = append(advanced, name)
+			return nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error { return nil },
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+
+	receipt, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "WELCOME"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 42, receipt.ClaimSequence)
+	assert.Equal(t, []string{"WELCOME"}, advanced)
+	assert.Empty(t, couponRepo.DecrementStockCalls(), "unlimited coupons have no stock to take")
+}
+
+func TestCouponService_ClaimCoupon_Unlimited_AlreadyClaimed(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Unlimited: true}, nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			return apperr.ErrAlreadyClaimed
+		},
+		GetClaimFunc: func(ctx context.Context, userID, couponName string) (*model.Claim, error) { return nil, nil },
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "WELCOME"))
+
+	assert.ErrorIs(t, err, apperr.ErrAlreadyClaimed)
+	assert.Empty(t, couponRepo.AdvanceClaimSequenceCalls())
+}
+
+func TestCouponService_GetByName_Unlimited(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Unlimited: true, ClaimSequence: 7}, nil
+		},
+	}
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), couponRepo, noClaims())
+
+	resp, err := svc.GetByName(context.Background(), "WELCOME")
+
+	require.NoError(t, err)
+	assert.True(t, resp.Unlimited)
+	assert.Equal(t, intPtr(7), resp.Claimed)
+	assert.Nil(t, summarizeCoupon(&model.Coupon{Amount: 10, RemainingAmount: 3}).Claimed, "limited coupons report remaining stock")
+}
+
+func TestValidParent_Unlimited(t *testing.T) {
+	assert.False(t, validParent("CHILD", &model.Coupon{Name: "WELCOME", Unlimited: true}))
+}
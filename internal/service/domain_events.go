@@ -0,0 +1,45 @@
+package service
+
+import (
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+)
+
+// DomainEventLog records domain events for offline analytics (implemented by eventlog.Log).
+type DomainEventLog interface {
+	Append(event model.DomainEvent)
+}
+
+// SetDomainEventLog records the coupons created (through Create, Put and manifests) and
+// the claims made through this service in l, once committed. Imported claims are not
+// recorded. User IDs are redacted as in logs. A nil l disables it.
+func (s *CouponService) SetDomainEventLog(l DomainEventLog) {
+	s.eventLog = l
+}
+
+// logCouponCreated records the creation of coupon, if a domain event log is set.
+func (s *CouponService) logCouponCreated(coupon *model.Coupon) {
+	if s.eventLog == nil {
+		return
+	}
+	summary := summarizeCoupon(coupon)
+	s.eventLog.Append(model.DomainEvent{
+		Type:       model.CouponEventCreated,
+		OccurredAt: s.clock.Now().UTC(),
+		Coupon:     &summary,
+	})
+}
+
+// logClaim records the claim receipt describes, if a domain event log is set.
+func (s *CouponService) logClaim(receipt *model.ClaimReceipt) {
+	if s.eventLog == nil {
+		return
+	}
+	claim := *receipt
+	claim.UserID = redact.Value(claim.UserID)
+	s.eventLog.Append(model.DomainEvent{
+		Type:       model.CouponEventClaimed,
+		OccurredAt: s.clock.Now().UTC(),
+		Claim:      &claim,
+	})
+}
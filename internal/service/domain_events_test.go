@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// recordingEventLog is a DomainEventLog keeping the events appended to it.
+type recordingEventLog struct {
+	events []model.DomainEvent
+}
+
+func (r *recordingEventLog) Append(event model.DomainEvent) {
+	r.events = append(r.events, event)
+}
+
+func TestCouponService_Create_LogsDomainEvent(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error { return nil },
+	}
+	eventLog := &recordingEventLog{}
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), couponRepo, noClaims())
+	svc.SetDomainEventLog(eventLog)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.SetClock(clock.NewFake(now))
+
+	require.NoError(t, svc.Create(context.Background(), &model.CreateCouponRequest{Name: "PROMO", Amount: intPtr(10), Tags: []string{"summer"}}))
+
+	require.Len(t, eventLog.events, 1)
+	event := eventLog.events[0]
+	assert.Equal(t, model.CouponEventCreated, event.Type)
+	assert.Equal(t, now, event.OccurredAt)
+	assert.Equal(t, &model.CouponSummary{Name: "PROMO", Amount: 10, RemainingAmount: 10, Tags: []string{"summer"}, Status: model.CouponStatusActive}, event.Coupon)
+	assert.Nil(t, event.Claim)
+}
+
+func TestCouponService_ClaimCoupon_LogsDomainEvent(t *testing.T) {
+	claimed := false
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 5, ClaimSequence: 5}, nil
+		},
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error {
+			return nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			if claimed {
+				return apperr.ErrAlreadyClaimed
+			}
+			claimed = true
+			return nil
+		},
+		GetClaimFunc: func(ctx context.Context, userID, couponName string) (*model.Claim, error) { return nil, nil },
+	}
+	eventLog := &recordingEventLog{}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+	svc.SetDomainEventLog(eventLog)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	require.NoError(t, err)
+	_, err = svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO"))
+	require.ErrorIs(t, err, apperr.ErrAlreadyClaimed)
+
+	require.Len(t, eventLog.events, 1, "failed claims are not recorded")
+	assert.Equal(t, model.CouponEventClaimed, eventLog.events[0].Type)
+	assert.Equal(t, &model.ClaimReceipt{UserID: "user_001", CouponName: "PROMO", ClaimSequence: 6}, eventLog.events[0].Claim)
+}
+
+func TestCouponService_Apply_LogsDomainEvents(t *testing.T) {
+	manifest := &model.Manifest{Coupons: []model.CreateCouponRequest{
+		{Name: "NEW", Amount: intPtr(100)},
+		{Name: "KEEP", Amount: intPtr(10)},
+	}}
+	var calls []string
+	couponRepo := recordingCouponRepository([]model.Coupon{{Name: "KEEP", Amount: 10, RemainingAmount: 4}}, &calls)
+	eventLog := &recordingEventLog{}
+	pool := &mocks.TxBeginnerMock{BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return newTx(), nil }}
+	svc := NewCouponServiceWithTxBeginner(pool, couponRepo, &mocks.ClaimRepositoryMock{})
+	svc.SetDomainEventLog(eventLog)
+
+	_, err := svc.Apply(context.Background(), manifest, true)
+	require.NoError(t, err)
+	assert.Empty(t, eventLog.events, "dry runs record nothing")
+
+	_, err = svc.Apply(context.Background(), manifest, false)
+	require.NoError(t, err)
+	require.Len(t, eventLog.events, 1)
+	assert.Equal(t, "NEW", eventLog.events[0].Coupon.Name)
+}
@@ -0,0 +1,33 @@
+package service
+
+import "context"
+
+// Heavy jobs, as named to a JobGate.
+const (
+	JobCouponPurge     = "coupon_purge"     // Purging deleted coupons, archiving them first
+	JobClaimRetention  = "claim_retention"  // Deleting claims past retention
+	JobAnalyticsExport = "analytics_export" // Exporting daily analytics files
+)
+
+// JobGate decides whether a heavy periodic job may run now (implemented by
+// jobs.Schedule).
+type JobGate interface {
+	Allow(ctx context.Context, job string) bool
+}
+
+// SetJobGate makes the coupon purge and claim retention passes run only when gate
+// allows them. A nil gate runs them on every tick.
+func (s *CouponService) SetJobGate(gate JobGate) {
+	s.jobGate = gate
+}
+
+// SetJobGate makes export passes run only when gate allows them. A nil gate runs them
+// on every tick.
+func (e *AnalyticsExporter) SetJobGate(gate JobGate) {
+	e.jobGate = gate
+}
+
+// allowHeavyJob reports whether gate, if any, lets job run now.
+func allowHeavyJob(ctx context.Context, gate JobGate, job string) bool {
+	return gate == nil || gate.Allow(ctx, job)
+}
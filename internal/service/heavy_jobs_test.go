@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
+)
+
+// fakeJobGate allows the jobs set by the test and records the jobs asked about.
+type fakeJobGate struct {
+	mu     sync.Mutex
+	allow  bool
+	asked  []string
+	answer chan struct{}
+}
+
+func (g *fakeJobGate) Allow(ctx context.Context, job string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.asked = append(g.asked, job)
+	if g.answer != nil {
+		defer func() { g.answer <- struct{}{} }()
+	}
+	return g.allow
+}
+
+func (g *fakeJobGate) set(allow bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.allow = allow
+}
+
+func TestCouponService_RunClaimRetention_JobGate(t *testing.T) {
+	passes := make(chan struct{}, 1)
+	retention := &mocks.ClaimRetentionRepositoryMock{
+		RetentionPoliciesFunc: func(ctx context.Context) ([]model.CouponClaimRetention, error) {
+			passes <- struct{}{}
+			return nil, nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetClaimRetention(retention)
+	gate := &fakeJobGate{answer: make(chan struct{}, 1)}
+	svc.SetJobGate(gate)
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc.SetClock(clk)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunClaimRetention(ctx, time.Hour)
+		close(done)
+	}()
+
+	// Held back right away: no pass until the next tick
+	<-gate.answer
+	clk.BlockUntil(1)
+	assert.Empty(t, passes)
+
+	gate.set(true)
+	clk.Advance(time.Hour)
+	<-gate.answer
+	<-passes
+	cancel()
+	<-done
+	assert.Equal(t, []string{JobClaimRetention, JobClaimRetention}, gate.asked)
+}
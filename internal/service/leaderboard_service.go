@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// Leaderboard refresh batches: coupons listed per query, and claims of one coupon
+// counted per transaction (bounding how long its watermark row stays locked).
+const (
+	leaderboardCouponBatch = 100
+	leaderboardClaimBatch  = 1000
+)
+
+// LeaderboardStats is a snapshot of leaderboard refresh counters.
+type LeaderboardStats struct {
+	Refreshes     int64 `json:"refreshes"`      // Completed refreshes
+	RefreshErrors int64 `json:"refresh_errors"` // Failed refreshes (resumed by the next one)
+	Counted       int64 `json:"counted"`        // Claims counted into leaderboards
+	LastRefresh   int64 `json:"last_refresh"`   // Unix time of the last completed refresh; 0 before the first
+}
+
+// LeaderboardService maintains campaign leaderboards: claims per user across the
+// coupons tagged with a campaign. They are refreshed incrementally, counting only the
+// claims made since the previous refresh, so leaderboards lag claims by up to the
+// refresh interval. A coupon's claims count towards the tags it has when they are
+// counted; retagging a coupon does not move claims already counted.
+type LeaderboardService struct {
+	tx   ports.Transactor
+	repo ports.LeaderboardRepository
+
+	refreshes     atomic.Int64
+	refreshErrors atomic.Int64
+	counted       atomic.Int64
+	lastRefresh   atomic.Int64
+}
+
+// NewLeaderboardService creates a LeaderboardService that runs its transactions through tx.
+func NewLeaderboardService(tx ports.Transactor, repo ports.LeaderboardRepository) *LeaderboardService {
+	return &LeaderboardService{tx: tx, repo: repo}
+}
+
+// Stats returns the refresh counters.
+func (s *LeaderboardService) Stats() LeaderboardStats {
+	return LeaderboardStats{
+		Refreshes:     s.refreshes.Load(),
+		RefreshErrors: s.refreshErrors.Load(),
+		Counted:       s.counted.Load(),
+		LastRefresh:   s.lastRefresh.Load(),
+	}
+}
+
+// Refresh counts every claim not yet counted into the leaderboards, one transaction
+// per coupon and batch of claims. Progress is kept per coupon, so an interrupted
+// refresh is resumed by the next one; instances may refresh concurrently.
+func (s *LeaderboardService) Refresh(ctx context.Context) error {
+	if err := s.refresh(ctx); err != nil {
+		s.refreshErrors.Add(1)
+		return err
+	}
+	s.refreshes.Add(1)
+	s.lastRefresh.Store(time.Now().Unix())
+	return nil
+}
+
+func (s *LeaderboardService) refresh(ctx context.Context) error {
+	for {
+		names, err := s.repo.PendingCoupons(ctx, leaderboardCouponBatch)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+		for _, name := range names {
+			if err := s.countCoupon(ctx, name); err != nil {
+				return fmt.Errorf("coupon %s: %w", name, err)
+			}
+		}
+	}
+}
+
+// countCoupon counts all of a coupon's uncounted claims, in batches.
+func (s *LeaderboardService) countCoupon(ctx context.Context, name string) error {
+	for {
+		var n int
+		err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+			var err error
+			n, err = s.repo.CountClaims(ctx, tx, name, leaderboardClaimBatch)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		s.counted.Add(int64(n))
+		if n < leaderboardClaimBatch {
+			return nil
+		}
+	}
+}
+
+// Run refreshes the leaderboards now and then every interval until ctx is cancelled.
+func (s *LeaderboardService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("leaderboard refresh failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Leaderboard returns the limit top claimers of campaign (a coupon tag) with the
+// campaign's totals. Campaigns without counted claims have an empty leaderboard.
+// Returns apperr.ErrInvalidRequest if limit is not positive.
+func (s *LeaderboardService) Leaderboard(ctx context.Context, campaign string, limit int) (*model.LeaderboardResponse, error) {
+	if limit < 1 {
+		return nil, apperr.ErrInvalidRequest
+	}
+	leaders, err := s.repo.Top(ctx, campaign, limit)
+	if err != nil {
+		return nil, err
+	}
+	claims, claimers, err := s.repo.Totals(ctx, campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range leaders {
+		if i > 0 && leaders[i].Claims == leaders[i-1].Claims {
+			leaders[i].Rank = leaders[i-1].Rank
+		} else {
+			leaders[i].Rank = i + 1
+		}
+	}
+	return &model.LeaderboardResponse{Campaign: campaign, TotalClaims: claims, Claimers: claimers, Leaders: leaders}, nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// passThroughTx returns a transactor mock that runs fn without a transaction.
+func passThroughTx() *mocks.TransactorMock {
+	return &mocks.TransactorMock{
+		InTxFunc: func(ctx context.Context, fn func(tx database.TxQuerier) error) error { return fn(nil) },
+	}
+}
+
+func TestLeaderboardService_Leaderboard_Ranks(t *testing.T) {
+	repo := &mocks.LeaderboardRepositoryMock{
+		TopFunc: func(ctx context.Context, campaign string, limit int) ([]model.LeaderboardEntry, error) {
+			assert.Equal(t, "summer", campaign)
+			assert.Equal(t, 4, limit)
+			return []model.LeaderboardEntry{
+				{UserID: "alice", Claims: 5},
+				{UserID: "bob", Claims: 3},
+				{UserID: "carol", Claims: 3},
+				{UserID: "dave", Claims: 1},
+			}, nil
+		},
+		TotalsFunc: func(ctx context.Context, campaign string) (int64, int64, error) { return 14, 6, nil },
+	}
+
+	resp, err := NewLeaderboardService(passThroughTx(), repo).Leaderboard(context.Background(), "summer", 4)
+
+	require.NoError(t, err)
+	assert.Equal(t, "summer", resp.Campaign)
+	assert.Equal(t, int64(14), resp.TotalClaims)
+	assert.Equal(t, int64(6), resp.Claimers)
+	ranks := make([]int, len(resp.Leaders))
+	for i, e := range resp.Leaders {
+		ranks[i] = e.Rank
+	}
+	assert.Equal(t, []int{1, 2, 2, 4}, ranks, "tied users share a rank")
+}
+
+func TestLeaderboardService_Leaderboard_Errors(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+
+	t.Run("invalid limit", func(t *testing.T) {
+		svc := NewLeaderboardService(passThroughTx(), &mocks.LeaderboardRepositoryMock{})
+		_, err := svc.Leaderboard(context.Background(), "summer", 0)
+		assert.ErrorIs(t, err, apperr.ErrInvalidRequest)
+	})
+
+	t.Run("top error", func(t *testing.T) {
+		repo := &mocks.LeaderboardRepositoryMock{
+			TopFunc: func(ctx context.Context, campaign string, limit int) ([]model.LeaderboardEntry, error) {
+				return nil, dbErr
+			},
+		}
+		_, err := NewLeaderboardService(passThroughTx(), repo).Leaderboard(context.Background(), "summer", 10)
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
+
+func TestLeaderboardService_Refresh_CountsInBatches(t *testing.T) {
+	pending := [][]string{{"A", "B"}, {"C"}, {}}
+	remaining := map[string]int{"A": leaderboardClaimBatch + 5, "B": 0, "C": 7}
+	repo := &mocks.LeaderboardRepositoryMock{
+		PendingCouponsFunc: func(ctx context.Context, limit int) ([]string, error) {
+			assert.Equal(t, leaderboardCouponBatch, limit)
+			names := pending[0]
+			pending = pending[1:]
+			return names, nil
+		},
+		CountClaimsFunc: func(ctx context.Context, tx database.TxQuerier, couponName string, maxClaims int) (int, error) {
+			n := min(remaining[couponName], maxClaims)
+			remaining[couponName] -= n
+			return n, nil
+		},
+	}
+	svc := NewLeaderboardService(passThroughTx(), repo)
+
+	require.NoError(t, svc.Refresh(context.Background()))
+
+	assert.Len(t, repo.CountClaimsCalls(), 4, "A takes two batches")
+	stats := svc.Stats()
+	assert.Equal(t, int64(leaderboardClaimBatch+12), stats.Counted)
+	assert.Equal(t, int64(1), stats.Refreshes)
+	assert.NotZero(t, stats.LastRefresh)
+}
+
+func TestLeaderboardService_Refresh_Error(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+	repo := &mocks.LeaderboardRepositoryMock{
+		PendingCouponsFunc: func(ctx context.Context, limit int) ([]string, error) { return []string{"A"}, nil },
+		CountClaimsFunc: func(ctx context.Context, tx database.TxQuerier, couponName string, maxClaims int) (int, error) {
+			return 0, dbErr
+		},
+	}
+	svc := NewLeaderboardService(passThroughTx(), repo)
+
+	err := svc.Refresh(context.Background())
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.Equal(t, int64(1), svc.Stats().RefreshErrors)
+	assert.Zero(t, svc.Stats().LastRefresh)
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+const (
+	// MaxLoadTestClaims is the most fake claims one prewarm may make across its coupons.
+	MaxLoadTestClaims = 100000
+	// loadTestTag tags every load test coupon, so they can be filtered out of coupon lists.
+	loadTestTag = "loadtest"
+	// loadTestPurgeBatch is how many load test coupons are removed per transaction.
+	loadTestPurgeBatch = 100
+)
+
+// LoadTestStats is a snapshot of load test namespace counters.
+type LoadTestStats struct {
+	Prewarmed      int64 `json:"prewarmed"`       // Namespaces created
+	CleanedUp      int64 `json:"cleaned_up"`      // Namespaces removed, on request or expiry
+	Expired        int64 `json:"expired"`         // Of which by expiry
+	CleanupErrors  int64 `json:"cleanup_errors"`  // Failed expiry passes (resumed by the next one)
+	CouponsCreated int64 `json:"coupons_created"` // Load test coupons created
+	CouponsRemoved int64 `json:"coupons_removed"` // Load test coupons removed with their claims
+}
+
+// loadTestCounters counts load test namespaces.
+type loadTestCounters struct {
+	prewarmed      atomic.Int64
+	cleanedUp      atomic.Int64
+	expired        atomic.Int64
+	cleanupErrors  atomic.Int64
+	couponsCreated atomic.Int64
+	couponsRemoved atomic.Int64
+}
+
+// SetLoadTests enables seeding load test environments through PrewarmLoadTest and
+// tearing them down through CleanupLoadTest or, once expired, CleanupExpiredLoadTests.
+func (s *CouponService) SetLoadTests(repo ports.LoadTestRepository) {
+	s.loadTests = repo
+}
+
+// LoadTestStats returns the load test counters.
+func (s *CouponService) LoadTestStats() LoadTestStats {
+	return LoadTestStats{
+		Prewarmed:      s.loadTestCounts.prewarmed.Load(),
+		CleanedUp:      s.loadTestCounts.cleanedUp.Load(),
+		Expired:        s.loadTestCounts.expired.Load(),
+		CleanupErrors:  s.loadTestCounts.cleanupErrors.Load(),
+		CouponsCreated: s.loadTestCounts.couponsCreated.Load(),
+		CouponsRemoved: s.loadTestCounts.couponsRemoved.Load(),
+	}
+}
+
+// loadTestCouponPrefix returns the name prefix of the coupons of namespace ns.
+func loadTestCouponPrefix(ns string) string {
+	return "loadtest-" + ns + "-"
+}
+
+// PrewarmLoadTest creates req.Coupons disposable coupons of req.Amount stock in the
+// namespace req.Namespace, named loadtest-<namespace>-0001 and so on and tagged
+// loadtest, each with req.ClaimsPerCoupon fake claims by users loadtest-<namespace>-user-1
+// and so on. The namespace is recorded first, so coupons left behind by a failed prewarm
+// are still removed when it expires. Load test coupons and claims publish no events.
+// Returns apperr.ErrLoadTestNamespaceExists if the namespace was not cleaned up yet, and
+// apperr.ErrLoadTestTooLarge if it would make more than MaxLoadTestClaims claims.
+func (s *CouponService) PrewarmLoadTest(ctx context.Context, req *model.LoadTestPrewarmRequest) (*model.LoadTestNamespace, error) {
+	if req == nil {
+		return nil, apperr.ErrInvalidRequest
+	}
+	if req.Coupons*req.ClaimsPerCoupon > MaxLoadTestClaims {
+		return nil, apperr.ErrLoadTestTooLarge
+	}
+
+	prefix := loadTestCouponPrefix(req.Namespace)
+	ns := &model.LoadTestNamespace{
+		Namespace:    req.Namespace,
+		CouponPrefix: prefix,
+		UserPrefix:   prefix + "user-",
+		Coupons:      req.Coupons,
+		Claims:       req.Coupons * req.ClaimsPerCoupon,
+		ExpiresAt:    s.clock.Now().UTC().Add(time.Duration(req.TTLMinutes) * time.Minute).Truncate(time.Second),
+	}
+	if err := s.loadTests.CreateNamespace(ctx, ns); err != nil {
+		return nil, err
+	}
+	s.loadTestCounts.prewarmed.Add(1)
+
+	amount := req.Amount
+	for i := 1; i <= req.Coupons; i++ {
+		coupon, err := s.newCoupon(&model.CreateCouponRequest{
+			Name:   fmt.Sprintf("%s%04d", prefix, i),
+			Amount: &amount,
+			Tags:   []string{loadTestTag},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := s.couponRepo.Insert(ctx, coupon); err != nil {
+			return nil, fmt.Errorf("create coupon %s: %w", coupon.Name, err)
+		}
+		s.addCouponName(coupon.Name)
+		s.loadTestCounts.couponsCreated.Add(1)
+		if err := s.prewarmClaims(ctx, coupon.Name, ns.UserPrefix, req.ClaimsPerCoupon); err != nil {
+			return nil, fmt.Errorf("claim coupon %s: %w", coupon.Name, err)
+		}
+	}
+	return ns, nil
+}
+
+// prewarmClaims makes n fake claims on the coupon name as imported claims, one import
+// chunk per transaction.
+func (s *CouponService) prewarmClaims(ctx context.Context, name, userPrefix string, n int) error {
+	size := s.importChunkSize
+	if size <= 0 {
+		size = DefaultImportChunkSize
+	}
+	claims := make([]model.ImportClaim, n)
+	for j := range claims {
+		claims[j] = model.ImportClaim{UserID: userPrefix + strconv.Itoa(j+1), CouponName: name}
+	}
+	for start := 0; start < n; start += size {
+		end := min(start+size, n)
+		err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+			report, _, err := s.importChunk(ctx, tx, claims[start:end], start)
+			if err == nil && len(report.Rejected) > 0 {
+				err = fmt.Errorf("claim %d rejected: %s", report.Rejected[0].Index, report.Rejected[0].Reason)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CleanupLoadTest removes the coupons of the load test namespace ns with their claims,
+// loadTestPurgeBatch coupons per transaction, then forgets the namespace, and returns
+// how many coupons it removed. Returns apperr.ErrLoadTestNamespaceNotFound if the
+// namespace does not exist or was already cleaned up.
+func (s *CouponService) CleanupLoadTest(ctx context.Context, ns string) (*model.LoadTestCleanup, error) {
+	cleanup := &model.LoadTestCleanup{Namespace: ns}
+	for {
+		var names []string
+		err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+			var err error
+			names, err = s.loadTests.PurgeCoupons(ctx, tx, loadTestCouponPrefix(ns), loadTestPurgeBatch)
+			return err
+		})
+		if err != nil {
+			return cleanup, err
+		}
+		for _, name := range names {
+			s.invalidate(name)
+		}
+		cleanup.Coupons += len(names)
+		s.loadTestCounts.couponsRemoved.Add(int64(len(names)))
+		if len(names) < loadTestPurgeBatch {
+			break
+		}
+	}
+	if err := s.loadTests.DeleteNamespace(ctx, ns); err != nil {
+		return cleanup, err
+	}
+	s.loadTestCounts.cleanedUp.Add(1)
+	return cleanup, nil
+}
+
+// CleanupExpiredLoadTests cleans up every load test namespace past its expiry.
+// Instances may clean up concurrently.
+func (s *CouponService) CleanupExpiredLoadTests(ctx context.Context) error {
+	for {
+		namespaces, err := s.loadTests.ExpiredNamespaces(ctx, loadTestPurgeBatch)
+		if err != nil {
+			s.loadTestCounts.cleanupErrors.Add(1)
+			return err
+		}
+		for _, ns := range namespaces {
+			_, err := s.CleanupLoadTest(ctx, ns)
+			switch {
+			case err == nil:
+				s.loadTestCounts.expired.Add(1)
+			case errors.Is(err, apperr.ErrLoadTestNamespaceNotFound):
+				// Cleaned up by another instance
+			default:
+				s.loadTestCounts.cleanupErrors.Add(1)
+				return fmt.Errorf("load test namespace %s: %w", ns, err)
+			}
+		}
+		if len(namespaces) < loadTestPurgeBatch {
+			return nil
+		}
+	}
+}
+
+// RunLoadTestCleanup cleans up expired load test namespaces now and then every interval
+// until ctx is cancelled.
+func (s *CouponService) RunLoadTestCleanup(ctx context.Context, interval time.Duration) {
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.CleanupExpiredLoadTests(ctx); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("load test cleanup failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestCouponService_PrewarmLoadTest(t *testing.T) {
+	f := newImportFixture()
+	svc := f.service()
+	svc.couponRepo.(*mocks.CouponRepositoryMock).InsertFunc = func(ctx context.Context, coupon *model.Coupon) error {
+		f.coupons[coupon.Name] = coupon
+		return nil
+	}
+	svc.SetImportChunkSize(2)
+	loadTests := &mocks.LoadTestRepositoryMock{
+		CreateNamespaceFunc: func(ctx context.Context, ns *model.LoadTestNamespace) error { return nil },
+	}
+	svc.SetLoadTests(loadTests)
+
+	ns, err := svc.PrewarmLoadTest(context.Background(), &model.LoadTestPrewarmRequest{
+		Namespace: "run42", Coupons: 2, Amount: 10, ClaimsPerCoupon: 3, TTLMinutes: 60,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "loadtest-run42-", ns.CouponPrefix)
+	assert.Equal(t, "loadtest-run42-user-", ns.UserPrefix)
+	assert.Equal(t, 6, ns.Claims)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), ns.ExpiresAt, 2*time.Second)
+	require.Len(t, loadTests.CreateNamespaceCalls(), 1)
+	assert.Equal(t, ns, loadTests.CreateNamespaceCalls()[0].Ns)
+
+	require.Len(t, f.coupons, 2)
+	coupon := f.coupons["loadtest-run42-0002"]
+	require.NotNil(t, coupon)
+	assert.Equal(t, []string{"loadtest"}, coupon.Tags)
+	assert.Equal(t, 7, coupon.RemainingAmount)
+	require.Len(t, f.claims, 6)
+	assert.Equal(t, "loadtest-run42-user-3", f.claims[5].UserID)
+	assert.Equal(t, 4, f.chunks, "two chunks of claims per coupon")
+}
+
+func TestCouponService_PrewarmLoadTest_Errors(t *testing.T) {
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetLoadTests(&mocks.LoadTestRepositoryMock{
+		CreateNamespaceFunc: func(ctx context.Context, ns *model.LoadTestNamespace) error {
+			return apperr.ErrLoadTestNamespaceExists
+		},
+	})
+
+	_, err := svc.PrewarmLoadTest(context.Background(), &model.LoadTestPrewarmRequest{
+		Namespace: "run42", Coupons: 1000, Amount: 1000, ClaimsPerCoupon: 101, TTLMinutes: 60,
+	})
+	assert.ErrorIs(t, err, apperr.ErrLoadTestTooLarge)
+
+	_, err = svc.PrewarmLoadTest(context.Background(), &model.LoadTestPrewarmRequest{
+		Namespace: "run42", Coupons: 1, Amount: 10, TTLMinutes: 60,
+	})
+	assert.ErrorIs(t, err, apperr.ErrLoadTestNamespaceExists)
+}
+
+func TestCouponService_CleanupLoadTest(t *testing.T) {
+	batches := []int{loadTestPurgeBatch, 5}
+	loadTests := &mocks.LoadTestRepositoryMock{
+		PurgeCouponsFunc: func(ctx context.Context, tx database.TxQuerier, prefix string, limit int) ([]string, error) {
+			names := make([]string, batches[0])
+			for i := range names {
+				names[i] = fmt.Sprintf("%s%04d", prefix, i)
+			}
+			batches = batches[1:]
+			return names, nil
+		},
+		DeleteNamespaceFunc: func(ctx context.Context, ns string) error { return nil },
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetLoadTests(loadTests)
+
+	cleanup, err := svc.CleanupLoadTest(context.Background(), "run42")
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.LoadTestCleanup{Namespace: "run42", Coupons: loadTestPurgeBatch + 5}, cleanup)
+	assert.Equal(t, "loadtest-run42-", loadTests.PurgeCouponsCalls()[0].Prefix)
+	assert.Len(t, loadTests.DeleteNamespaceCalls(), 1)
+	assert.Equal(t, int64(loadTestPurgeBatch+5), svc.LoadTestStats().CouponsRemoved)
+}
+
+func TestCouponService_CleanupExpiredLoadTests(t *testing.T) {
+	loadTests := &mocks.LoadTestRepositoryMock{
+		ExpiredNamespacesFunc: func(ctx context.Context, limit int) ([]string, error) {
+			return []string{"gone", "run42"}, nil
+		},
+		PurgeCouponsFunc: func(ctx context.Context, tx database.TxQuerier, prefix string, limit int) ([]string, error) {
+			return nil, nil
+		},
+		DeleteNamespaceFunc: func(ctx context.Context, ns string) error {
+			if ns == "gone" {
+				return apperr.ErrLoadTestNamespaceNotFound
+			}
+			return nil
+		},
+	}
+	svc := NewCouponServiceWithTransactor(passThroughTx(), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+	svc.SetLoadTests(loadTests)
+
+	require.NoError(t, svc.CleanupExpiredLoadTests(context.Background()))
+
+	stats := svc.LoadTestStats()
+	assert.Equal(t, int64(1), stats.Expired, "a namespace cleaned up by another instance is skipped")
+	assert.Zero(t, stats.CleanupErrors)
+}
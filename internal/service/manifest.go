@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// manifestStep is one planned change together with what is needed to carry it out.
+type manifestStep struct {
+	change      model.ApplyChange
+	desired     *model.Coupon // Coupon to create (create only)
+	topUp       int           // Stock to add; 0 when unchanged
+	tags        []string      // Replacement tags; nil when unchanged
+	highProfile *bool         // New high-profile flag; nil when unchanged
+	enable      bool
+	disable     bool
+}
+
+// Apply reconciles the stored coupons with the desired state in m inside one transaction:
+// missing coupons are created, existing ones are topped up, retagged, marked high-profile
+// or not and re-enabled, and coupons absent from the manifest are disabled. Decreasing an amount or changing unlimited,
+// channels, overflow_at or tiers cannot be reconciled; if the manifest asks for any of
+// these nothing is written and the report is returned with apperr.ErrManifestConflict.
+// With dryRun the report is computed against locked rows but nothing is written.
+func (s *CouponService) Apply(ctx context.Context, m *model.Manifest, dryRun bool) (*model.ApplyReport, error) {
+	return s.apply(ctx, m, dryRun, true)
+}
+
+// Seed applies the manifest m like Apply, except that coupons absent from it are left
+// alone rather than disabled. It seeds coupons at startup (SEED_FILE): applying the same
+// manifest again reports them unchanged and writes nothing.
+func (s *CouponService) Seed(ctx context.Context, m *model.Manifest) (*model.ApplyReport, error) {
+	return s.apply(ctx, m, false, false)
+}
+
+// apply is Apply, disabling the coupons absent from m only with prune.
+func (s *CouponService) apply(ctx context.Context, m *model.Manifest, dryRun, prune bool) (*model.ApplyReport, error) {
+	if m == nil {
+		return nil, apperr.ErrInvalidRequest
+	}
+
+	desired := make([]*model.Coupon, 0, len(m.Coupons))
+	for i := range m.Coupons {
+		coupon, err := s.newCoupon(&m.Coupons[i])
+		if err != nil {
+			return nil, fmt.Errorf("coupon %s: %w", m.Coupons[i].Name, err)
+		}
+		desired = append(desired, coupon)
+	}
+
+	var report *model.ApplyReport
+	err := s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+		var err error
+		report, err = s.applyManifest(ctx, tx, desired, dryRun, prune)
+		return err
+	})
+	if errors.Is(err, errDryRun) {
+		return report, nil
+	}
+	if err != nil {
+		return report, err // The report explains apperr.ErrManifestConflict
+	}
+	if s.cache != nil {
+		s.cache.Purge()
+	}
+	for _, change := range report.Changes {
+		if change.Action == model.ApplyActionCreate && s.eventLog != nil {
+			i := slices.IndexFunc(desired, func(c *model.Coupon) bool { return c.Name == change.Name })
+			s.logCouponCreated(desired[i])
+		}
+		if eventType, ok := manifestEvents[change.Action]; ok {
+			s.publishCoupon(ctx, eventType, change.Name)
+		}
+	}
+	return report, nil
+}
+
+// manifestEvents maps the applied manifest actions to the lifecycle events they announce.
+var manifestEvents = map[string]string{
+	model.ApplyActionCreate:  model.CouponEventCreated,
+	model.ApplyActionUpdate:  model.CouponEventUpdated,
+	model.ApplyActionDisable: model.CouponEventDisabled,
+}
+
+// errDryRun makes applyManifest roll back a dry run; apply does not return it.
+var errDryRun = errors.New("dry run")
+
+// applyManifest plans the manifest against the locked coupons and, unless dryRun or the
+// plan has conflicts, applies it within tx. Without prune, coupons absent from the
+// manifest are not disabled. Dry runs return errDryRun.
+func (s *CouponService) applyManifest(ctx context.Context, tx database.TxQuerier, desired []*model.Coupon, dryRun, prune bool) (*model.ApplyReport, error) {
+	existing, err := s.couponRepo.ListForUpdate(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("list coupons: %w", err)
+	}
+
+	steps := planManifest(existing, desired)
+	if !prune {
+		steps = slices.DeleteFunc(steps, func(step manifestStep) bool { return step.disable })
+	}
+	report := &model.ApplyReport{DryRun: dryRun, Changes: make([]model.ApplyChange, 0, len(steps))}
+	conflict := false
+	for _, step := range steps {
+		report.Changes = append(report.Changes, step.change)
+		conflict = conflict || step.change.Action == model.ApplyActionConflict
+	}
+	if conflict {
+		return report, apperr.ErrManifestConflict
+	}
+	if dryRun {
+		return report, errDryRun
+	}
+
+	for _, step := range steps {
+		if err := s.applyStep(ctx, tx, step); err != nil {
+			return nil, fmt.Errorf("apply %s: %w", step.change.Name, err)
+		}
+	}
+	return report, nil
+}
+
+func (s *CouponService) applyStep(ctx context.Context, tx database.TxQuerier, step manifestStep) error {
+	name := step.change.Name
+	if step.desired != nil {
+		if err := s.couponRepo.InsertTx(ctx, tx, step.desired); err != nil {
+			return err
+		}
+		s.addCouponName(name) // Harmless if the apply is rolled back: a false positive
+		s.markHighProfile(name, step.desired.HighProfile)
+		return nil
+	}
+	if step.topUp > 0 {
+		if err := s.couponRepo.TopUp(ctx, tx, name, step.topUp); err != nil {
+			return err
+		}
+	}
+	if step.tags != nil {
+		if err := s.couponRepo.UpdateTagsTx(ctx, tx, name, step.tags); err != nil {
+			return err
+		}
+	}
+	if step.highProfile != nil {
+		if err := s.couponRepo.SetHighProfile(ctx, tx, name, *step.highProfile); err != nil {
+			return err
+		}
+		s.markHighProfile(name, *step.highProfile) // Harmless if the apply is rolled back: corrected by the next claim
+	}
+	if step.enable || step.disable {
+		return s.couponRepo.SetDisabled(ctx, tx, name, step.disable)
+	}
+	return nil
+}
+
+// planManifest compares the stored coupons with the desired ones and returns the steps
+// that reconcile them, ordered by coupon name.
+func planManifest(existing []model.Coupon, desired []*model.Coupon) []manifestStep {
+	byName := make(map[string]*model.Coupon, len(existing))
+	for i := range existing {
+		byName[existing[i].Name] = &existing[i]
+	}
+	wanted := make(map[string]bool, len(desired))
+	// Parents and prerequisites of created coupons may be created by the manifest too
+	coupons := maps.Clone(byName)
+	for _, d := range desired {
+		coupons[d.Name] = d
+	}
+
+	steps := make([]manifestStep, 0, len(desired))
+	for _, d := range desired {
+		wanted[d.Name] = true
+		current, ok := byName[d.Name]
+		if !ok {
+			steps = append(steps, planCreate(d, coupons))
+			continue
+		}
+		steps = append(steps, planUpdate(current, d))
+	}
+
+	for i := range existing {
+		c := &existing[i]
+		if wanted[c.Name] || c.Disabled {
+			continue
+		}
+		steps = append(steps, manifestStep{
+			change: model.ApplyChange{
+				Name:   c.Name,
+				Action: model.ApplyActionDisable,
+				Diff:   []model.FieldDiff{{Field: "disabled", Current: false, Requested: true}},
+			},
+			disable: true,
+		})
+	}
+
+	sort.Slice(steps, func(i, j int) bool { return steps[i].change.Name < steps[j].change.Name })
+	return steps
+}
+
+// planCreate plans the creation of a coupon, which conflicts if its parent is not among
+// coupons or has a parent itself, or if its prerequisites do not lead to coupons
+// without one.
+func planCreate(desired *model.Coupon, coupons map[string]*model.Coupon) manifestStep {
+	conflict := func(err error) manifestStep {
+		return manifestStep{change: model.ApplyChange{
+			Name:   desired.Name,
+			Action: model.ApplyActionConflict,
+			Reason: err.Error(),
+		}}
+	}
+	if desired.Parent != "" && !validParent(desired.Name, coupons[desired.Parent]) {
+		return conflict(apperr.ErrInvalidParent)
+	}
+	if !validPrerequisites(desired.Name, coupons) {
+		return conflict(apperr.ErrInvalidPrerequisite)
+	}
+	return manifestStep{
+		change:  model.ApplyChange{Name: desired.Name, Action: model.ApplyActionCreate},
+		desired: desired,
+	}
+}
+
+// planUpdate plans the reconciliation of one existing coupon towards its desired state.
+func planUpdate(current, desired *model.Coupon) manifestStep {
+	step := manifestStep{change: model.ApplyChange{Name: current.Name}}
+	var reasons []string
+
+	diffs := diffCoupon(current, desired)
+	for _, d := range diffs {
+		switch d.Field {
+		case "amount":
+			switch {
+			case current.Unlimited != desired.Unlimited:
+				// Conflicts on unlimited
+			case desired.Amount < current.Amount:
+				reasons = append(reasons, "amount cannot be decreased")
+			case len(current.Channels) > 0:
+				reasons = append(reasons, apperr.ErrPartitionedTopUp.Error())
+			default:
+				step.topUp = desired.Amount - current.Amount
+			}
+		case "tags":
+			step.tags = desired.Tags
+		case "high_profile":
+			step.highProfile = &desired.HighProfile
+		case "channels":
+			// A partitioned coupon whose amount changes already conflicts on amount
+			if len(current.Channels) == 0 || current.Amount == desired.Amount {
+				reasons = append(reasons, "channels cannot be changed")
+			}
+		default:
+			reasons = append(reasons, d.Field+" cannot be changed")
+		}
+	}
+	if current.Disabled {
+		diffs = append(diffs, model.FieldDiff{Field: "disabled", Current: true, Requested: false})
+		step.enable = true
+	}
+
+	step.change.Diff = diffs
+	switch {
+	case len(reasons) > 0:
+		step.change.Action = model.ApplyActionConflict
+		step.change.Reason = strings.Join(reasons, "; ")
+	case len(diffs) > 0:
+		step.change.Action = model.ApplyActionUpdate
+	default:
+		step.change.Action = model.ApplyActionUnchanged
+	}
+	return step
+}
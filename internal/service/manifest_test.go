@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// recordingCouponRepository returns a mock that serves existing from ListForUpdate
+// and records every write made by Apply.
+func recordingCouponRepository(existing []model.Coupon, calls *[]string) *mocks.CouponRepositoryMock {
+	return &mocks.CouponRepositoryMock{
+		ListForUpdateFunc: func(ctx context.Context, tx database.TxQuerier) ([]model.Coupon, error) {
+			return existing, nil
+		},
+		InsertTxFunc: func(ctx context.Context, tx database.TxQuerier, coupon *model.Coupon) error {
+			*calls = append(*calls, "insert "+coupon.Name)
+			return nil
+		},
+		TopUpFunc: func(ctx context.Context, tx database.TxQuerier, name string, delta int) error {
+			*calls = append(*calls, "top_up "+name)
+			return nil
+		},
+		UpdateTagsTxFunc: func(ctx context.Context, tx database.TxQuerier, name string, tags []string) error {
+			*calls = append(*calls, "tags "+name)
+			return nil
+		},
+		SetDisabledFunc: func(ctx context.Context, tx database.TxQuerier, name string, disabled bool) error {
+			if disabled {
+				*calls = append(*calls, "disable "+name)
+			} else {
+				*calls = append(*calls, "enable "+name)
+			}
+			return nil
+		},
+	}
+}
+
+func TestCouponService_Apply_Reconciles(t *testing.T) {
+	existing := []model.Coupon{
+		{Name: "KEEP", Amount: 10, RemainingAmount: 4},
+		{Name: "OLD", Amount: 5, RemainingAmount: 5},
+		{Name: "REVIVE", Amount: 10, RemainingAmount: 10, Disabled: true},
+		{Name: "TOPUP", Amount: 10, RemainingAmount: 2, Tags: []string{"a"}},
+	}
+	manifest := &model.Manifest{Coupons: []model.CreateCouponRequest{
+		{Name: "TOPUP", Amount: intPtr(50), Tags: []string{"a", "b"}},
+		{Name: "NEW", Amount: intPtr(100)},
+		{Name: "KEEP", Amount: intPtr(10)},
+		{Name: "REVIVE", Amount: intPtr(10)},
+	}}
+	var calls []string
+	committed := false
+	tx := newTx()
+	tx.CommitFunc = func(ctx context.Context) error { committed = true; return nil }
+	pool := &mocks.TxBeginnerMock{BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return tx, nil }}
+
+	svc := NewCouponServiceWithTxBeginner(pool, recordingCouponRepository(existing, &calls), &mocks.ClaimRepositoryMock{})
+	report, err := svc.Apply(context.Background(), manifest, false)
+
+	require.NoError(t, err)
+	assert.True(t, committed)
+	assert.False(t, report.DryRun)
+
+	actions := map[string]string{}
+	for _, c := range report.Changes {
+		actions[c.Name] = c.Action
+	}
+	assert.Equal(t, map[string]string{
+		"KEEP":   model.ApplyActionUnchanged,
+		"NEW":    model.ApplyActionCreate,
+		"OLD":    model.ApplyActionDisable,
+		"REVIVE": model.ApplyActionUpdate,
+		"TOPUP":  model.ApplyActionUpdate,
+	}, actions)
+	assert.Equal(t, "KEEP", report.Changes[0].Name, "changes are ordered by name")
+	assert.Equal(t, []string{"insert NEW", "disable OLD", "enable REVIVE", "top_up TOPUP", "tags TOPUP"}, calls)
+}
+
+func TestCouponService_Seed_LeavesUnlistedCoupons(t *testing.T) {
+	existing := []model.Coupon{
+		{Name: "OLD", Amount: 5, RemainingAmount: 5},
+		{Name: "SEEDED", Amount: 10, RemainingAmount: 3},
+	}
+	manifest := &model.Manifest{Coupons: []model.CreateCouponRequest{
+		{Name: "SEEDED", Amount: intPtr(10)},
+		{Name: "NEW", Amount: intPtr(100)},
+	}}
+	var calls []string
+
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), recordingCouponRepository(existing, &calls), &mocks.ClaimRepositoryMock{})
+	report, err := svc.Seed(context.Background(), manifest)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ApplyChange{
+		{Name: "NEW", Action: model.ApplyActionCreate},
+		{Name: "SEEDED", Action: model.ApplyActionUnchanged},
+	}, report.Changes)
+	assert.Equal(t, []string{"insert NEW"}, calls, "OLD is not in the seed but stays enabled")
+}
+
+func TestCouponService_Apply_DryRunWritesNothing(t *testing.T) {
+	var calls []string
+	committed := false
+	tx := newTx()
+	tx.CommitFunc = func(ctx context.Context) error { committed = true; return nil }
+	pool := &mocks.TxBeginnerMock{BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return tx, nil }}
+	repo := recordingCouponRepository([]model.Coupon{{Name: "OLD", Amount: 5}}, &calls)
+
+	svc := NewCouponServiceWithTxBeginner(pool, repo, &mocks.ClaimRepositoryMock{})
+	report, err := svc.Apply(context.Background(), &model.Manifest{Coupons: []model.CreateCouponRequest{
+		{Name: "NEW", Amount: intPtr(1)},
+	}}, true)
+
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Len(t, report.Changes, 2)
+	assert.Empty(t, calls)
+	assert.False(t, committed)
+}
+
+func TestCouponService_Apply_ConflictsWriteNothing(t *testing.T) {
+	existing := []model.Coupon{
+		{Name: "SHRINK", Amount: 10},
+		{Name: "SPLIT", Amount: 10, Channels: []model.ChannelQuota{{Channel: "app", Quota: 10}}},
+		{Name: "TIERED", Amount: 10},
+		{Name: "UNCAPPED", Amount: 10},
+	}
+	manifest := &model.Manifest{Coupons: []model.CreateCouponRequest{
+		{Name: "SHRINK", Amount: intPtr(5)},
+		{Name: "SPLIT", Amount: intPtr(20), Channels: map[string]int{"app": 100}},
+		{Name: "TIERED", Amount: intPtr(10), Tiers: []model.Tier{{Name: "gold", Size: 1}}},
+		{Name: "UNCAPPED", Unlimited: true},
+		{Name: "NEW", Amount: intPtr(1)},
+	}}
+	var calls []string
+
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), recordingCouponRepository(existing, &calls), &mocks.ClaimRepositoryMock{})
+	report, err := svc.Apply(context.Background(), manifest, false)
+
+	assert.ErrorIs(t, err, apperr.ErrManifestConflict)
+	require.NotNil(t, report)
+	assert.Empty(t, calls, "nothing may be written when any change conflicts")
+
+	reasons := map[string]string{}
+	for _, c := range report.Changes {
+		if c.Action == model.ApplyActionConflict {
+			reasons[c.Name] = c.Reason
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"SHRINK":   "amount cannot be decreased",
+		"SPLIT":    "channel-partitioned coupons cannot be topped up",
+		"TIERED":   "tiers cannot be changed",
+		"UNCAPPED": "unlimited cannot be changed",
+	}, reasons)
+}
+
+func TestCouponService_Apply_Errors(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+
+	t.Run("nil manifest", func(t *testing.T) {
+		svc := NewCouponServiceWithTxBeginner(newPool(newTx()), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+		_, err := svc.Apply(context.Background(), nil, false)
+		assert.ErrorIs(t, err, apperr.ErrInvalidRequest)
+	})
+
+	t.Run("invalid coupon", func(t *testing.T) {
+		svc := NewCouponServiceWithTxBeginner(newPool(newTx()), &mocks.CouponRepositoryMock{}, &mocks.ClaimRepositoryMock{})
+		_, err := svc.Apply(context.Background(), &model.Manifest{Coupons: []model.CreateCouponRequest{
+			{Name: "BAD", Amount: intPtr(10), Channels: map[string]int{"app": 10}},
+		}}, false)
+		assert.ErrorIs(t, err, apperr.ErrInvalidChannelQuotas)
+		assert.Contains(t, err.Error(), "BAD")
+	})
+
+	t.Run("list error", func(t *testing.T) {
+		repo := &mocks.CouponRepositoryMock{
+			ListForUpdateFunc: func(ctx context.Context, tx database.TxQuerier) ([]model.Coupon, error) { return nil, dbErr },
+		}
+		svc := NewCouponServiceWithTxBeginner(newPool(newTx()), repo, &mocks.ClaimRepositoryMock{})
+		_, err := svc.Apply(context.Background(), &model.Manifest{}, false)
+		assert.ErrorIs(t, err, dbErr)
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		repo := &mocks.CouponRepositoryMock{
+			ListForUpdateFunc: func(ctx context.Context, tx database.TxQuerier) ([]model.Coupon, error) { return nil, nil },
+			InsertTxFunc:      func(ctx context.Context, tx database.TxQuerier, coupon *model.Coupon) error { return dbErr },
+		}
+		svc := NewCouponServiceWithTxBeginner(newPool(newTx()), repo, &mocks.ClaimRepositoryMock{})
+		report, err := svc.Apply(context.Background(), &model.Manifest{Coupons: []model.CreateCouponRequest{
+			{Name: "NEW", Amount: intPtr(1)},
+		}}, false)
+		assert.ErrorIs(t, err, dbErr)
+		assert.Contains(t, err.Error(), "apply NEW")
+		assert.Nil(t, report)
+	})
+}
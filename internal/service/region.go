@@ -0,0 +1,50 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// regionQuotas returns the region quotas of a create request, ordered by region.
+func regionQuotas(quotas map[string]int) []model.RegionQuota {
+	if len(quotas) == 0 {
+		return nil
+	}
+	out := make([]model.RegionQuota, 0, len(quotas))
+	for region, quota := range quotas {
+		out = append(out, model.RegionQuota{Region: region, Quota: quota})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Region < out[j].Region })
+	return out
+}
+
+// checkRegionQuota returns apperr.ErrRegionQuotaReached if the coupon's quota for region, if
+// it has one, is used up. Claims without a region are not limited by region.
+// Must be evaluated against coupon state read under the coupon row lock.
+func checkRegionQuota(coupon *model.Coupon, region string) error {
+	if region == "" {
+		return nil
+	}
+	for _, r := range coupon.Regions {
+		if r.Region == region {
+			if r.Quota > 0 && r.Claimed >= r.Quota {
+				return apperr.ErrRegionQuotaReached
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// regionQuotaMap maps each region with a quota to its quota.
+func regionQuotaMap(regions []model.RegionQuota) map[string]int {
+	out := make(map[string]int, len(regions))
+	for _, r := range regions {
+		if r.Quota > 0 {
+			out[r.Region] = r.Quota
+		}
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestRegionQuotas(t *testing.T) {
+	assert.Nil(t, regionQuotas(nil))
+	assert.Equal(t, []model.RegionQuota{{Region: "eu", Quota: 300}, {Region: "us", Quota: 500}},
+		regionQuotas(map[string]int{"us": 500, "eu": 300}))
+}
+
+func TestCheckRegionQuota(t *testing.T) {
+	coupon := &model.Coupon{Regions: []model.RegionQuota{
+		{Region: "eu", Quota: 2, Claimed: 1},
+		{Region: "us", Quota: 2, Claimed: 2},
+		{Region: "br", Claimed: 40}, // Counted only
+	}}
+
+	assert.NoError(t, checkRegionQuota(coupon, ""))
+	assert.NoError(t, checkRegionQuota(coupon, "eu"))
+	assert.ErrorIs(t, checkRegionQuota(coupon, "us"), apperr.ErrRegionQuotaReached)
+	assert.NoError(t, checkRegionQuota(coupon, "br"))
+	assert.NoError(t, checkRegionQuota(coupon, "jp"), "regions without a quota are not limited")
+}
+
+// regionClaimService returns a service claiming a coupon with regions, recording the
+// regions it counts claims in and the claims it inserts.
+func regionClaimService(regions []model.RegionQuota, counted *[]string, inserted **model.Claim) *CouponService {
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 100, RemainingAmount: 50, Regions: regions}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc:   func(ctx context.Context, tx database.TxQuerier, name string) error { return nil },
+		CountRegionClaimFunc: func(ctx context.Context, tx database.TxQuerier, name, region string) error {
+			*counted = append(*counted, region)
+			return nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			*inserted = claim
+			return nil
+		},
+	}
+	return NewCouponServiceWithTransactor(passThroughTx(), couponRepo, claimRepo)
+}
+
+func TestCouponService_ClaimCoupon_CountsRegion(t *testing.T) {
+	var counted []string
+	var inserted *model.Claim
+	svc := regionClaimService([]model.RegionQuota{{Region: "eu", Quota: 10, Claimed: 9}}, &counted, &inserted)
+
+	receipt, err := svc.ClaimCoupon(context.Background(), &model.ClaimCouponRequest{
+		UserID: "user_001", CouponName: "GLOBAL", Region: "eu",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "eu", receipt.Region)
+	assert.Equal(t, "eu", inserted.Region)
+	assert.Equal(t, []string{"eu"}, counted)
+}
+
+func TestCouponService_ClaimCoupon_WithoutRegion(t *testing.T) {
+	var counted []string
+	var inserted *model.Claim
+	svc := regionClaimService([]model.RegionQuota{{Region: "eu", Quota: 10, Claimed: 10}}, &counted, &inserted)
+
+	_, err := svc.ClaimCoupon(context.Background(), claimRequest("user_001", "GLOBAL"))
+
+	require.NoError(t, err)
+	assert.Empty(t, counted)
+}
+
+func TestCouponService_ClaimCoupon_RegionQuotaReached(t *testing.T) {
+	var counted []string
+	var inserted *model.Claim
+	svc := regionClaimService([]model.RegionQuota{{Region: "eu", Quota: 10, Claimed: 10}}, &counted, &inserted)
+
+	_, err := svc.ClaimCoupon(context.Background(), &model.ClaimCouponRequest{
+		UserID: "user_001", CouponName: "GLOBAL", Region: "eu",
+	})
+
+	assert.ErrorIs(t, err, apperr.ErrRegionQuotaReached)
+	assert.Nil(t, inserted)
+	assert.Empty(t, counted)
+}
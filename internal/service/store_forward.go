@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// ReplayResult counts the outcome of one replay pass over the claim buffer.
+type ReplayResult struct {
+	Claimed   int // Claims granted on replay
+	Duplicate int // Already claimed, e.g. by a client retry; nothing to do (idempotent)
+	Rejected  int // Rejected by business rules (no stock, disabled, ...; see isClaimRejection); the claim is dropped
+	Expired   int // Older than the maximum age; dropped without being attempted
+}
+
+// StoreAndForward is an opt-in CouponService wrapper that keeps accepting claims
+// while Postgres is briefly unreachable.
+//
+// Trade-offs, read before enabling:
+//   - Only connection failures are buffered. They happen before any statement is sent,
+//     so the claim certainly had no effect. Failures after the transaction started
+//     (where the outcome is unknown) still return an error to the caller.
+//   - A buffered claim is accepted (202), not granted. On replay it may still be
+//     rejected because stock ran out, the coupon was disabled, and so on; the caller
+//     is not notified and must check the claims export. Rejections are logged.
+//   - Buffered claims lose their place in line: once the database is back, new claims
+//     are served immediately and may take stock before the buffer drains.
+//   - Duplicate detection is deferred to replay, so a user can be told 202 twice for
+//     the same coupon; replay grants at most one claim (apperr.ErrAlreadyClaimed is treated
+//     as already done, which also makes replay safe to repeat after a crash).
+//   - The buffer is a local file: claims are only as durable as this instance's disk,
+//     and are replayed only by this instance.
+//   - Limits: the buffer holds at most its configured number of entries (further
+//     claims fail as if store-and-forward were off) and entries older than maxAge are
+//     dropped on replay, so a long outage cannot grant claims long after the sale.
+type StoreAndForward struct {
+	*CouponService
+	buffer ports.ClaimBuffer
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// NewStoreAndForward wraps svc so claims failing with a connection error are buffered.
+func NewStoreAndForward(svc *CouponService, buffer ports.ClaimBuffer, maxAge time.Duration) *StoreAndForward {
+	return &StoreAndForward{CouponService: svc, buffer: buffer, maxAge: maxAge, now: time.Now}
+}
+
+// ClaimCoupon claims directly and falls back to the buffer when the database is
+// unreachable. Returns apperr.ErrClaimQueued when the claim was buffered. If the buffer is
+// full or cannot be written, the original error is returned.
+func (s *StoreAndForward) ClaimCoupon(ctx context.Context, req *model.ClaimCouponRequest) (*model.ClaimReceipt, error) {
+	receipt, err := s.CouponService.ClaimCoupon(ctx, req)
+	if err == nil || !database.IsConnectError(err) {
+		return receipt, err
+	}
+
+	if bufErr := s.buffer.Append(req); bufErr != nil {
+		log.Warn().Err(bufErr).Msg("claim buffer unavailable, rejecting claim")
+		return nil, err
+	}
+	return nil, apperr.ErrClaimQueued
+}
+
+// Replay claims buffered entries in acceptance order until the buffer is empty or
+// the database is unreachable again. Entries are acknowledged only once their outcome
+// is final, so entries left by a failed pass are retried by the next one.
+func (s *StoreAndForward) Replay(ctx context.Context) (ReplayResult, error) {
+	var result ReplayResult
+	entries, err := s.buffer.Pending()
+	if err != nil {
+		return result, err
+	}
+
+	done := 0
+	for i := range entries {
+		entry := &entries[i]
+		if s.now().Sub(entry.AcceptedAt) > s.maxAge {
+			result.Expired++
+			done++
+			continue
+		}
+
+		_, err := s.CouponService.ClaimCoupon(ctx, &entry.Request)
+		switch {
+		case err == nil:
+			result.Claimed++
+		case errors.Is(err, apperr.ErrAlreadyClaimed):
+			result.Duplicate++
+		case isClaimRejection(err):
+			result.Rejected++
+			log.Warn().
+				Err(err).
+				Time("accepted_at", entry.AcceptedAt).
+				Msg("buffered claim rejected on replay")
+		default:
+			// Outage or unexpected failure: keep this and later entries for the next pass.
+			return result, errors.Join(s.buffer.Ack(done), err)
+		}
+		done++
+	}
+	return result, s.buffer.Ack(done)
+}
+
+// Run replays the buffer every interval until ctx is cancelled.
+func (s *StoreAndForward) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.buffer.Len() == 0 {
+			continue
+		}
+
+		result, err := s.Replay(ctx)
+		event := log.Info()
+		if err != nil {
+			event = log.Warn().Err(err)
+		}
+		event.
+			Int("claimed", result.Claimed).
+			Int("duplicate", result.Duplicate).
+			Int("rejected", result.Rejected).
+			Int("expired", result.Expired).
+			Int("pending", s.buffer.Len()).
+			Msg("claim buffer replayed")
+	}
+}
+
+// isClaimRejection reports whether err is a final rejection of a claim: an apperr error
+// mapping to a 4xx status, which retrying the claim would get again. Excluded are
+// apperr.ErrAlreadyClaimed, a duplicate rather than a rejection, and 429s such as
+// apperr.ErrClaimPaced, which ask to retry later.
+func isClaimRejection(err error) bool {
+	e, ok := apperr.As(err)
+	if !ok || errors.Is(err, apperr.ErrAlreadyClaimed) {
+		return false
+	}
+	return e.Status >= http.StatusBadRequest && e.Status < http.StatusInternalServerError && e.Status != http.StatusTooManyRequests
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/claimbuffer"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// connectError returns a real *pgconn.ConnectError (it cannot be built by hand).
+func connectError(t *testing.T) error {
+	t.Helper()
+	_, err := pgconn.Connect(context.Background(), "postgres://user@127.0.0.1:1/db?connect_timeout=1")
+	require.True(t, database.IsConnectError(err), "expected connect error, got %v", err)
+	return err
+}
+
+// storeForwardFixture wires a StoreAndForward to a real buffer and a switchable outage.
+type storeForwardFixture struct {
+	svc     *StoreAndForward
+	buffer  *claimbuffer.Buffer
+	down    bool
+	claims  map[string]bool // user_id -> claimed
+	stock   int
+	regions []model.RegionQuota
+}
+
+func newStoreForwardFixture(t *testing.T, maxEntries, stock int) *storeForwardFixture {
+	t.Helper()
+	f := &storeForwardFixture{claims: map[string]bool{}, stock: stock}
+	outage := connectError(t)
+
+	pool := &mocks.TxBeginnerMock{
+		BeginFunc: func(ctx context.Context) (pgx.Tx, error) {
+			if f.down {
+				return nil, outage
+			}
+			return newTx(), nil
+		},
+	}
+	couponRepo := &mocks.CouponRepositoryMock{
+		GetCouponForUpdateFunc: func(ctx context.Context, tx database.TxQuerier, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: f.stock, Regions: f.regions}, nil
+		},
+		RecordClaimStatsFunc: func(ctx context.Context, tx database.TxQuerier, name string, at time.Time) error { return nil },
+		DecrementStockFunc: func(ctx context.Context, tx database.TxQuerier, name string) error {
+			f.stock--
+			return nil
+		},
+	}
+	claimRepo := &mocks.ClaimRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, claim *model.Claim) error {
+			if f.claims[claim.UserID] {
+				return apperr.ErrAlreadyClaimed
+			}
+			f.claims[claim.UserID] = true
+			return nil
+		},
+		GetClaimFunc: func(ctx context.Context, userID, couponName string) (*model.Claim, error) { return nil, nil },
+	}
+
+	buffer, err := claimbuffer.Open(filepath.Join(t.TempDir(), "claims.log"), maxEntries)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = buffer.Close() })
+
+	f.buffer = buffer
+	f.svc = NewStoreAndForward(NewCouponServiceWithTxBeginner(pool, couponRepo, claimRepo), buffer, time.Minute)
+	return f
+}
+
+func TestStoreAndForward_ClaimCoupon_DatabaseUp(t *testing.T) {
+	f := newStoreForwardFixture(t, 10, 5)
+
+	receipt, err := f.svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "user_001", receipt.UserID)
+	assert.Equal(t, 0, f.buffer.Len())
+}
+
+func TestStoreAndForward_ClaimCoupon_QueuesOnOutage(t *testing.T) {
+	f := newStoreForwardFixture(t, 10, 5)
+	f.down = true
+
+	receipt, err := f.svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+
+	assert.Nil(t, receipt)
+	assert.ErrorIs(t, err, apperr.ErrClaimQueued)
+	assert.Equal(t, 1, f.buffer.Len())
+	assert.Empty(t, f.claims)
+}
+
+func TestStoreAndForward_ClaimCoupon_BufferFull(t *testing.T) {
+	f := newStoreForwardFixture(t, 1, 5)
+	f.down = true
+
+	_, err := f.svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+	require.ErrorIs(t, err, apperr.ErrClaimQueued)
+
+	_, err = f.svc.ClaimCoupon(context.Background(), claimRequest("user_002", "PROMO_SUPER"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, apperr.ErrClaimQueued)
+	assert.True(t, database.IsConnectError(err), "original error should be returned")
+	assert.Equal(t, 1, f.buffer.Len())
+}
+
+func TestStoreAndForward_ClaimCoupon_BusinessErrorsNotQueued(t *testing.T) {
+	f := newStoreForwardFixture(t, 10, 0)
+
+	_, err := f.svc.ClaimCoupon(context.Background(), claimRequest("user_001", "PROMO_SUPER"))
+
+	assert.ErrorIs(t, err, apperr.ErrNoStock)
+	assert.Equal(t, 0, f.buffer.Len())
+}
+
+func TestStoreAndForward_Replay(t *testing.T) {
+	f := newStoreForwardFixture(t, 10, 2)
+	f.claims["user_dup"] = true // Claimed directly before the outage
+	ctx := context.Background()
+
+	f.down = true
+	for _, user := range []string{"user_001", "user_dup", "user_001", "user_002", "user_003"} {
+		_, err := f.svc.ClaimCoupon(ctx, claimRequest(user, "PROMO_SUPER"))
+		require.ErrorIs(t, err, apperr.ErrClaimQueued)
+	}
+	f.down = false
+
+	result, err := f.svc.Replay(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Claimed: 2, Duplicate: 2, Rejected: 1}, result)
+	assert.True(t, f.claims["user_001"])
+	assert.True(t, f.claims["user_002"])
+	assert.False(t, f.claims["user_003"], "user_003 was queued after stock ran out")
+	assert.Equal(t, 0, f.buffer.Len())
+}
+
+func TestStoreAndForward_Replay_RejectionDoesNotBlock(t *testing.T) {
+	f := newStoreForwardFixture(t, 10, 5)
+	f.regions = []model.RegionQuota{{Region: "EU", Quota: 1, Claimed: 1}}
+	ctx := context.Background()
+
+	f.down = true
+	eu := claimRequest("user_001", "PROMO_SUPER")
+	eu.Region = "EU"
+	for _, req := range []*model.ClaimCouponRequest{eu, claimRequest("user_002", "PROMO_SUPER")} {
+		_, err := f.svc.ClaimCoupon(ctx, req)
+		require.ErrorIs(t, err, apperr.ErrClaimQueued)
+	}
+	f.down = false
+
+	result, err := f.svc.Replay(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Claimed: 1, Rejected: 1}, result)
+	assert.False(t, f.claims["user_001"], "the EU quota was used up")
+	assert.True(t, f.claims["user_002"], "the entry after the rejection is replayed")
+	assert.Equal(t, 0, f.buffer.Len())
+}
+
+func TestStoreAndForward_Replay_StopsOnOutage(t *testing.T) {
+	f := newStoreForwardFixture(t, 10, 5)
+	ctx := context.Background()
+
+	f.down = true
+	for _, user := range []string{"user_001", "user_002"} {
+		_, err := f.svc.ClaimCoupon(ctx, claimRequest(user, "PROMO_SUPER"))
+		require.ErrorIs(t, err, apperr.ErrClaimQueued)
+	}
+
+	result, err := f.svc.Replay(ctx)
+
+	require.Error(t, err)
+	assert.True(t, database.IsConnectError(err))
+	assert.Equal(t, ReplayResult{}, result)
+	assert.Equal(t, 2, f.buffer.Len(), "entries must be kept for the next pass")
+
+	f.down = false
+	result, err = f.svc.Replay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Claimed)
+	assert.Equal(t, 0, f.buffer.Len())
+}
+
+func TestStoreAndForward_Replay_DropsExpired(t *testing.T) {
+	f := newStoreForwardFixture(t, 10, 5)
+	ctx := context.Background()
+
+	f.down = true
+	_, err := f.svc.ClaimCoupon(ctx, claimRequest("user_001", "PROMO_SUPER"))
+	require.ErrorIs(t, err, apperr.ErrClaimQueued)
+	f.down = false
+
+	f.svc.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	result, err := f.svc.Replay(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Expired: 1}, result)
+	assert.Empty(t, f.claims)
+	assert.Equal(t, 0, f.buffer.Len())
+}
+
+func TestStoreAndForward_Run(t *testing.T) {
+	f := newStoreForwardFixture(t, 10, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f.down = true
+	_, err := f.svc.ClaimCoupon(ctx, claimRequest("user_001", "PROMO_SUPER"))
+	require.ErrorIs(t, err, apperr.ErrClaimQueued)
+	f.down = false
+
+	done := make(chan struct{})
+	go func() {
+		f.svc.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return f.buffer.Len() == 0 }, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	assert.True(t, f.claims["user_001"])
+}
+
+func TestIsClaimRejection(t *testing.T) {
+	assert.True(t, isClaimRejection(apperr.ErrNoStock))
+	assert.True(t, isClaimRejection(apperr.ErrCouponDisabled))
+	assert.True(t, isClaimRejection(apperr.ErrCampaignCapReached))
+	assert.True(t, isClaimRejection(apperr.ErrRegionQuotaReached))
+	assert.True(t, isClaimRejection(apperr.ErrNotAllowlisted))
+	assert.True(t, isClaimRejection(apperr.ErrClaimDeadlinePassed))
+	assert.True(t, isClaimRejection(apperr.ErrPrerequisiteNotClaimed))
+	assert.True(t, isClaimRejection(apperr.ErrExclusionGroupClaimed))
+	assert.True(t, isClaimRejection(fmt.Errorf("claim: %w", apperr.ErrNoStock)))
+	assert.False(t, isClaimRejection(apperr.ErrAlreadyClaimed))
+	assert.False(t, isClaimRejection(&apperr.AlreadyClaimedError{}))
+	assert.False(t, isClaimRejection(apperr.ErrClaimPaced), "429: retry later")
+	assert.False(t, isClaimRejection(apperr.ErrCouponBusy), "503: retry later")
+	assert.False(t, isClaimRejection(errors.New("connection reset")))
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+)
+
+// validateTiers checks that the tiers fit within the coupon's stock.
+// Tiers may cover fewer claims than amount; the remaining claims get no tier.
+func validateTiers(amount int, tiers []model.Tier) error {
+	total := 0
+	for _, t := range tiers {
+		total += t.Size
+	}
+	if total > amount {
+		return apperr.ErrInvalidTiers
+	}
+	return nil
+}
+
+// tierForSequence returns the tier covering the given 1-based claim sequence,
+// or "" when the sequence falls after the last tier.
+func tierForSequence(tiers []model.Tier, sequence int) string {
+	upper := 0
+	for _, t := range tiers {
+		upper += t.Size
+		if sequence <= upper {
+			return t.Name
+		}
+	}
+	return ""
+}
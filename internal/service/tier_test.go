@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestValidateTiers(t *testing.T) {
+	tiers := []model.Tier{{Name: "gold", Size: 100}, {Name: "silver", Size: 900}}
+
+	assert.NoError(t, validateTiers(1000, tiers), "tiers may cover the whole stock")
+	assert.NoError(t, validateTiers(5000, tiers), "tiers may cover part of the stock")
+	assert.NoError(t, validateTiers(10, nil))
+	assert.ErrorIs(t, validateTiers(999, tiers), apperr.ErrInvalidTiers)
+}
+
+func TestTierForSequence(t *testing.T) {
+	tiers := []model.Tier{{Name: "gold", Size: 100}, {Name: "silver", Size: 900}}
+
+	tests := []struct {
+		sequence int
+		want     string
+	}{
+		{1, "gold"},
+		{100, "gold"},
+		{101, "silver"},
+		{1000, "silver"},
+		{1001, ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tierForSequence(tiers, tt.sequence), "sequence %d", tt.sequence)
+	}
+	assert.Equal(t, "", tierForSequence(nil, 1), "coupons without tiers assign no tier")
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// pseudonymPrefix marks user IDs that replaced an erased user's ID.
+const pseudonymPrefix = "erased-"
+
+// UserService provides business logic for per-user data operations.
+type UserService struct {
+	tx        ports.Transactor
+	claimRepo ports.UserClaimRepository
+	auditRepo ports.AuditRepository
+	derived   []ports.UserClaimRepository // Other data holding user IDs, renamed along with the claims
+}
+
+// NewUserService creates a new UserService with the given PostgreSQL pool and repositories.
+func NewUserService(pool *pgxpool.Pool, claimRepo ports.UserClaimRepository, auditRepo ports.AuditRepository) *UserService {
+	return NewUserServiceWithTxBeginner(pool, claimRepo, auditRepo)
+}
+
+// NewUserServiceWithTxBeginner creates a UserService with a custom TxBeginner.
+// Transactions are run once, as on PostgreSQL. Primarily used for testing.
+func NewUserServiceWithTxBeginner(pool ports.TxBeginner, claimRepo ports.UserClaimRepository, auditRepo ports.AuditRepository) *UserService {
+	return NewUserServiceWithTransactor(database.NewTransactor(pool, database.Postgres), claimRepo, auditRepo)
+}
+
+// NewUserServiceWithTransactor creates a UserService that runs its transactions through tx.
+func NewUserServiceWithTransactor(tx ports.Transactor, claimRepo ports.UserClaimRepository, auditRepo ports.AuditRepository) *UserService {
+	return &UserService{
+		tx:        tx,
+		claimRepo: claimRepo,
+		auditRepo: auditRepo,
+	}
+}
+
+// AddDerivedData registers data derived from claims (e.g. leaderboards) or otherwise
+// holding user IDs (e.g. allowlists) whose user IDs EraseUserData must replace too.
+// They are renamed after the claims, in the same transaction.
+func (s *UserService) AddDerivedData(repo ports.UserClaimRepository) {
+	s.derived = append(s.derived, repo)
+}
+
+// EraseUserData replaces userID on all of the user's claims (and data derived from them,
+// see AddDerivedData) with a random pseudonym and records the erasure in the audit log, in one transaction. Claim counts, sequences and
+// remaining stock are unchanged. The pseudonym is not derived from userID, so the erased
+// claims can no longer be linked to the user; as a consequence the user may claim those
+// coupons again. Users without claims still get a pseudonym and an audit entry.
+// Returns apperr.ErrInvalidRequest if userID is empty.
+func (s *UserService) EraseUserData(ctx context.Context, userID string) (*model.UserErasureResponse, error) {
+	if userID == "" {
+		return nil, apperr.ErrInvalidRequest
+	}
+
+	pseudonym, err := newPseudonym()
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	err = s.tx.InTx(ctx, func(tx database.TxQuerier) error {
+		var err error
+		count, err = s.claimRepo.PseudonymizeUser(ctx, tx, userID, pseudonym)
+		if err != nil {
+			return err
+		}
+		for _, repo := range s.derived {
+			if _, err := repo.PseudonymizeUser(ctx, tx, userID, pseudonym); err != nil {
+				return err
+			}
+		}
+		return s.auditRepo.Insert(ctx, tx, &model.AuditEntry{
+			Action:  model.AuditActionUserDataErased,
+			Subject: pseudonym,
+			Details: map[string]any{"claims_anonymized": count},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.UserErasureResponse{Pseudonym: pseudonym, ClaimsAnonymized: count}, nil
+}
+
+// newPseudonym returns a random user ID for erased claims.
+func newPseudonym() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate pseudonym: %w", err)
+	}
+	return pseudonymPrefix + hex.EncodeToString(b), nil
+}
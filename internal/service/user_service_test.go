@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// userClaims returns a claim repository mock that pseudonymizes n claims.
+func userClaims(n int64) *mocks.UserClaimRepositoryMock {
+	return &mocks.UserClaimRepositoryMock{
+		PseudonymizeUserFunc: func(ctx context.Context, tx database.TxQuerier, userID, pseudonym string) (int64, error) {
+			return n, nil
+		},
+	}
+}
+
+// auditLog returns an audit repository mock that accepts every entry.
+func auditLog() *mocks.AuditRepositoryMock {
+	return &mocks.AuditRepositoryMock{
+		InsertFunc: func(ctx context.Context, tx database.TxQuerier, entry *model.AuditEntry) error {
+			return nil
+		},
+	}
+}
+
+func TestUserService_EraseUserData_Success(t *testing.T) {
+	tx := newTx()
+	committed := false
+	tx.CommitFunc = func(ctx context.Context) error { committed = true; return nil }
+	pool := &mocks.TxBeginnerMock{BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return tx, nil }}
+
+	var usedPseudonym string
+	claimRepo := &mocks.UserClaimRepositoryMock{
+		PseudonymizeUserFunc: func(ctx context.Context, q database.TxQuerier, userID, pseudonym string) (int64, error) {
+			assert.Same(t, tx, q, "claims must be updated in the erasure transaction")
+			assert.Equal(t, "user_001", userID)
+			usedPseudonym = pseudonym
+			return 3, nil
+		},
+	}
+	var audited *model.AuditEntry
+	auditRepo := &mocks.AuditRepositoryMock{
+		InsertFunc: func(ctx context.Context, q database.TxQuerier, entry *model.AuditEntry) error {
+			assert.Same(t, tx, q, "audit entry must be written in the erasure transaction")
+			audited = entry
+			return nil
+		},
+	}
+
+	svc := NewUserServiceWithTxBeginner(pool, claimRepo, auditRepo)
+	result, err := svc.EraseUserData(context.Background(), "user_001")
+
+	require.NoError(t, err)
+	assert.True(t, committed)
+	assert.True(t, strings.HasPrefix(result.Pseudonym, pseudonymPrefix))
+	assert.Equal(t, usedPseudonym, result.Pseudonym)
+	assert.Equal(t, int64(3), result.ClaimsAnonymized)
+
+	require.NotNil(t, audited)
+	assert.Equal(t, model.AuditActionUserDataErased, audited.Action)
+	assert.Equal(t, result.Pseudonym, audited.Subject)
+	assert.NotContains(t, audited.Subject, "user_001", "audit log must not keep the erased user_id")
+	assert.Equal(t, int64(3), audited.Details["claims_anonymized"])
+}
+
+func TestUserService_EraseUserData_PseudonymsAreUnique(t *testing.T) {
+	svc := NewUserServiceWithTxBeginner(newPool(newTx()), userClaims(0), auditLog())
+
+	first, err := svc.EraseUserData(context.Background(), "user_001")
+	require.NoError(t, err)
+	second, err := svc.EraseUserData(context.Background(), "user_001")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.Pseudonym, second.Pseudonym)
+}
+
+func TestUserService_EraseUserData_PseudonymizesDerivedData(t *testing.T) {
+	tx := newTx()
+	var pseudonyms []string
+	record := func(ctx context.Context, q database.TxQuerier, userID, pseudonym string) (int64, error) {
+		assert.Same(t, tx, q, "derived data must be updated in the erasure transaction")
+		assert.Equal(t, "user_001", userID)
+		pseudonyms = append(pseudonyms, pseudonym)
+		return 2, nil
+	}
+	svc := NewUserServiceWithTxBeginner(newPool(tx), &mocks.UserClaimRepositoryMock{PseudonymizeUserFunc: record}, auditLog())
+	svc.AddDerivedData(&mocks.UserClaimRepositoryMock{PseudonymizeUserFunc: record})
+
+	result, err := svc.EraseUserData(context.Background(), "user_001")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{result.Pseudonym, result.Pseudonym}, pseudonyms, "claims first, then derived data")
+	assert.Equal(t, int64(2), result.ClaimsAnonymized, "derived entries are not counted as claims")
+}
+
+func TestUserService_EraseUserData_Errors(t *testing.T) {
+	dbErr := errors.New("database connection failed")
+
+	t.Run("empty user id", func(t *testing.T) {
+		svc := NewUserServiceWithTxBeginner(newPool(newTx()), userClaims(0), auditLog())
+		_, err := svc.EraseUserData(context.Background(), "")
+		assert.ErrorIs(t, err, apperr.ErrInvalidRequest)
+	})
+
+	t.Run("begin error", func(t *testing.T) {
+		pool := &mocks.TxBeginnerMock{BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return nil, dbErr }}
+		svc := NewUserServiceWithTxBeginner(pool, userClaims(0), auditLog())
+		_, err := svc.EraseUserData(context.Background(), "user_001")
+		assert.ErrorIs(t, err, dbErr)
+	})
+
+	t.Run("audit error rolls back", func(t *testing.T) {
+		rolledBack := false
+		tx := &mocks.TxMock{
+			CommitFunc:   func(ctx context.Context) error { t.Fatal("must not commit"); return nil },
+			RollbackFunc: func(ctx context.Context) error { rolledBack = true; return nil },
+		}
+		pool := &mocks.TxBeginnerMock{BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return tx, nil }}
+		auditRepo := &mocks.AuditRepositoryMock{
+			InsertFunc: func(ctx context.Context, q database.TxQuerier, entry *model.AuditEntry) error { return dbErr },
+		}
+		svc := NewUserServiceWithTxBeginner(pool, userClaims(0), auditRepo)
+
+		result, err := svc.EraseUserData(context.Background(), "user_001")
+
+		assert.ErrorIs(t, err, dbErr)
+		assert.Nil(t, result)
+		assert.True(t, rolledBack)
+	})
+
+	t.Run("commit error", func(t *testing.T) {
+		tx := newTx()
+		tx.CommitFunc = func(ctx context.Context) error { return dbErr }
+		pool := &mocks.TxBeginnerMock{BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return tx, nil }}
+		svc := NewUserServiceWithTxBeginner(pool, userClaims(0), auditLog())
+
+		result, err := svc.EraseUserData(context.Background(), "user_001")
+
+		assert.ErrorIs(t, err, dbErr)
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/redact"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/webhook"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+)
+
+// WebhookQueue is the job queue carrying webhook deliveries.
+const WebhookQueue = "webhooks"
+
+// webhookSecretPrefix marks generated webhook secrets.
+const webhookSecretPrefix = "whsec_"
+
+// EventPublisher announces coupon lifecycle events (implemented by WebhookService).
+type EventPublisher interface {
+	Publish(ctx context.Context, eventType string, coupon model.CouponSummary) error
+	// PublishClaim announces coupon.claimed for claim. coupon reads the claimed coupon's
+	// state; it is only called when some subscription receives the event.
+	PublishClaim(ctx context.Context, claim model.ClaimReceipt, coupon func(context.Context) (model.CouponSummary, error)) error
+}
+
+// webhookDelivery is the payload of a job on WebhookQueue: one event for one subscription.
+type webhookDelivery struct {
+	SubscriptionID int64             `json:"subscription_id"`
+	Event          model.CouponEvent `json:"event"`
+}
+
+// WebhookService manages webhook subscriptions and fans coupon lifecycle events out to
+// them. Each subscriber gets its own delivery job, so a slow or failing endpoint is
+// retried on its own without holding up the others.
+type WebhookService struct {
+	repo   ports.WebhookRepository
+	queue  ports.JobQueue
+	sender ports.WebhookSender
+	now    func() time.Time
+}
+
+var _ EventPublisher = (*WebhookService)(nil)
+
+// NewWebhookService creates a WebhookService enqueueing deliveries on queue and
+// sending them with sender.
+func NewWebhookService(repo ports.WebhookRepository, queue ports.JobQueue, sender ports.WebhookSender) *WebhookService {
+	return &WebhookService{repo: repo, queue: queue, sender: sender, now: time.Now}
+}
+
+// Subscribe stores a subscription for req. A secret is generated when req has none;
+// the returned subscription is the only place it is shown. A payload template must
+// render valid JSON for sample events of each subscribed type.
+// Returns apperr.ErrInvalidRequest if req is nil, and apperr.ErrInvalidWebhookTemplate
+// wrapping the reason if its payload template is invalid.
+func (s *WebhookService) Subscribe(ctx context.Context, req *model.CreateWebhookRequest) (*model.WebhookSubscription, error) {
+	if req == nil {
+		return nil, apperr.ErrInvalidRequest
+	}
+	events := dedupeEvents(req.Events)
+	if req.PayloadTemplate != "" {
+		if _, err := webhook.ParseTemplate(req.PayloadTemplate, events); err != nil {
+			return nil, fmt.Errorf("%w: %v", apperr.ErrInvalidWebhookTemplate, err)
+		}
+	}
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	sub := &model.WebhookSubscription{URL: req.URL, Events: events, Secret: secret, PayloadTemplate: req.PayloadTemplate}
+	if err := s.repo.Insert(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// List returns a page of up to limit subscriptions with IDs above afterID, ordered by
+// ID, without their secrets.
+func (s *WebhookService) List(ctx context.Context, afterID int64, limit int) (*model.WebhookListResponse, error) {
+	subs, err := s.repo.List(ctx, afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	page := model.NewPage(subs, limit, func(sub model.WebhookSubscription) string { return strconv.FormatInt(sub.ID, 10) })
+	return &page, nil
+}
+
+// Unsubscribe deletes the subscription with id. Deliveries already enqueued for it are
+// dropped when they come up.
+// Returns apperr.ErrWebhookNotFound if there is none.
+func (s *WebhookService) Unsubscribe(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Publish enqueues a delivery of the event to every subscription receiving eventType.
+// It is called after the change commits, so an instance crashing in between loses the
+// event; subscribers needing exact state should reconcile periodically with GET /api/coupons.
+func (s *WebhookService) Publish(ctx context.Context, eventType string, coupon model.CouponSummary) error {
+	subs, err := s.repo.ListForEvent(ctx, eventType)
+	if err != nil {
+		return fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+	return s.enqueue(ctx, subs, model.CouponEvent{Type: eventType, Coupon: coupon})
+}
+
+// PublishClaim enqueues a coupon.claimed delivery of claim to every subscription
+// receiving it, with the coupon's state read by coupon. Without such subscriptions the
+// coupon is not read, so claims only pay for listing them. Like Publish, it is called
+// after the claim commits.
+func (s *WebhookService) PublishClaim(ctx context.Context, claim model.ClaimReceipt, coupon func(context.Context) (model.CouponSummary, error)) error {
+	subs, err := s.repo.ListForEvent(ctx, model.CouponEventClaimed)
+	if err != nil {
+		return fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+	summary, err := coupon(ctx)
+	if err != nil {
+		return err
+	}
+	return s.enqueue(ctx, subs, model.CouponEvent{Type: model.CouponEventClaimed, Coupon: summary, Claim: &claim})
+}
+
+// enqueue gives event an ID and time and enqueues a delivery of it to each of subs.
+func (s *WebhookService) enqueue(ctx context.Context, subs []model.WebhookSubscription, event model.CouponEvent) error {
+	id, err := newEventID()
+	if err != nil {
+		return err
+	}
+	event.ID, event.OccurredAt = id, s.now().UTC()
+	for _, sub := range subs {
+		if _, err := s.queue.Enqueue(ctx, WebhookQueue, webhookDelivery{SubscriptionID: sub.ID, Event: event}); err != nil {
+			return fmt.Errorf("enqueue webhook delivery for subscription %d: %w", sub.ID, err)
+		}
+	}
+	return nil
+}
+
+// Deliver is the jobs.Handler for WebhookQueue: it sends one event to its subscription.
+// Deliveries for deleted subscriptions are dropped.
+func (s *WebhookService) Deliver(ctx context.Context, job *jobs.Job) error {
+	var d webhookDelivery
+	if err := json.Unmarshal(job.Payload, &d); err != nil {
+		return jobs.Permanent(fmt.Errorf("decode webhook delivery: %w", err))
+	}
+	sub, err := s.repo.Get(ctx, d.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if sub == nil {
+		return nil
+	}
+	return s.sender.Send(ctx, sub, &d.Event)
+}
+
+// dedupeEvents drops repeated event types, keeping the first occurrence.
+func dedupeEvents(events []string) []string {
+	seen := make(map[string]bool, len(events))
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		if !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// newWebhookSecret returns a random secret for signing deliveries.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + hex.EncodeToString(b), nil
+}
+
+// newEventID returns a random event ID, shared by all deliveries of the event.
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate event ID: %w", err)
+	}
+	return "evt_" + hex.EncodeToString(b), nil
+}
+
+// SetEventPublisher announces coupon lifecycle changes made through this service with
+// p: creations, updates (tags, top-ups, re-enabling), manifest disables and claims
+// (imported claims excepted). A nil p disables it.
+func (s *CouponService) SetEventPublisher(p EventPublisher) {
+	s.events = p
+}
+
+// publishCoupon announces eventType for the coupon name with its current state, if an
+// event publisher is set. Failures are logged rather than returned, as the change has
+// already been committed.
+func (s *CouponService) publishCoupon(ctx context.Context, eventType, name string) {
+	if s.events == nil {
+		return
+	}
+	coupon, err := s.couponRepo.GetByName(ctx, name)
+	if err == nil && coupon != nil {
+		err = s.events.Publish(ctx, eventType, summarizeCoupon(coupon))
+	}
+	if err != nil {
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("event", eventType).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to publish coupon event")
+	}
+}
+
+// publishClaim announces coupon.claimed for the claim receipt describes, if an event
+// publisher is set. Failures are logged rather than returned, as the claim has already
+// been committed.
+func (s *CouponService) publishClaim(ctx context.Context, receipt *model.ClaimReceipt) {
+	if s.events == nil {
+		return
+	}
+	name := receipt.CouponName
+	err := s.events.PublishClaim(ctx, *receipt, func(ctx context.Context) (model.CouponSummary, error) {
+		coupon, err := s.couponRepo.GetByName(ctx, name)
+		if err != nil {
+			return model.CouponSummary{}, fmt.Errorf("get coupon: %w", err)
+		}
+		if coupon == nil {
+			return model.CouponSummary{}, apperr.ErrCouponNotFound
+		}
+		return summarizeCoupon(coupon), nil
+	})
+	if err != nil {
+		log.Error().
+			Str("error", redact.Error(err, name)).
+			Str("event", model.CouponEventClaimed).
+			Str("coupon_name", redact.Value(name)).
+			Msg("failed to publish coupon event")
+	}
+}
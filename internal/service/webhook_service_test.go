@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/mocks"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+)
+
+// recordingPublisher records published events as "<type> <coupon>", and claims apart
+// from them as "<user> <coupon>".
+type recordingPublisher struct {
+	events []string
+	claims []string
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, eventType string, coupon model.CouponSummary) error {
+	p.events = append(p.events, eventType+" "+coupon.Name)
+	return nil
+}
+
+func (p *recordingPublisher) PublishClaim(ctx context.Context, claim model.ClaimReceipt, coupon func(context.Context) (model.CouponSummary, error)) error {
+	p.claims = append(p.claims, claim.UserID+" "+claim.CouponName)
+	return nil
+}
+
+func TestWebhookService_Subscribe(t *testing.T) {
+	var stored *model.WebhookSubscription
+	repo := &mocks.WebhookRepositoryMock{
+		InsertFunc: func(ctx context.Context, sub *model.WebhookSubscription) error {
+			sub.ID = 7
+			stored = sub
+			return nil
+		},
+	}
+	svc := NewWebhookService(repo, &mocks.JobQueueMock{}, &mocks.WebhookSenderMock{})
+
+	sub, err := svc.Subscribe(context.Background(), &model.CreateWebhookRequest{
+		URL:    "https://erp.example.com/hooks",
+		Events: []string{model.CouponEventCreated, model.CouponEventDisabled, model.CouponEventCreated},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), sub.ID)
+	assert.Equal(t, []string{model.CouponEventCreated, model.CouponEventDisabled}, stored.Events)
+	assert.True(t, strings.HasPrefix(sub.Secret, webhookSecretPrefix), "a secret is generated")
+	assert.Len(t, sub.Secret, len(webhookSecretPrefix)+48)
+}
+
+func TestWebhookService_Subscribe_PayloadTemplate(t *testing.T) {
+	repo := &mocks.WebhookRepositoryMock{
+		InsertFunc: func(ctx context.Context, sub *model.WebhookSubscription) error { return nil },
+	}
+	svc := NewWebhookService(repo, &mocks.JobQueueMock{}, &mocks.WebhookSenderMock{})
+	req := &model.CreateWebhookRequest{
+		URL:             "https://erp.example.com/hooks",
+		Events:          []string{model.CouponEventLowStock},
+		PayloadTemplate: `{"sku": {{json .coupon.name}}, "left": {{.coupon.remaining_amount}}}`,
+	}
+
+	sub, err := svc.Subscribe(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, req.PayloadTemplate, sub.PayloadTemplate)
+
+	req.PayloadTemplate = `{"sku": {{.coupon.name}}}` // Unquoted, so not JSON
+	_, err = svc.Subscribe(context.Background(), req)
+
+	assert.ErrorIs(t, err, apperr.ErrInvalidWebhookTemplate)
+	assert.ErrorContains(t, err, "coupon.low_stock event: template did not render valid JSON")
+	assert.Len(t, repo.InsertCalls(), 1, "invalid templates are not stored")
+}
+
+func TestWebhookService_List_HidesSecrets(t *testing.T) {
+	repo := &mocks.WebhookRepositoryMock{
+		ListFunc: func(ctx context.Context, afterID int64, limit int) ([]model.WebhookSubscription, error) {
+			return []model.WebhookSubscription{{ID: 1, Secret: "whsec_0123456789abcdef"}}, nil
+		},
+	}
+
+	resp, err := NewWebhookService(repo, &mocks.JobQueueMock{}, &mocks.WebhookSenderMock{}).List(context.Background(), 0, 10)
+
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 1)
+	assert.Empty(t, resp.Items[0].Secret)
+	assert.Empty(t, resp.NextCursor)
+}
+
+func TestWebhookService_List_Pages(t *testing.T) {
+	repo := &mocks.WebhookRepositoryMock{
+		ListFunc: func(ctx context.Context, afterID int64, limit int) ([]model.WebhookSubscription, error) {
+			return []model.WebhookSubscription{{ID: 6}, {ID: 9}, {ID: 12}}, nil
+		},
+	}
+
+	resp, err := NewWebhookService(repo, &mocks.JobQueueMock{}, &mocks.WebhookSenderMock{}).List(context.Background(), 5, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.WebhookSubscription{{ID: 6}, {ID: 9}}, resp.Items)
+	assert.Equal(t, model.EncodeCursor("9"), resp.NextCursor)
+	require.Len(t, repo.ListCalls(), 1)
+	assert.Equal(t, int64(5), repo.ListCalls()[0].AfterID)
+	assert.Equal(t, 3, repo.ListCalls()[0].Limit)
+}
+
+func TestWebhookService_Publish_EnqueuesPerSubscription(t *testing.T) {
+	repo := &mocks.WebhookRepositoryMock{
+		ListForEventFunc: func(ctx context.Context, eventType string) ([]model.WebhookSubscription, error) {
+			return []model.WebhookSubscription{{ID: 1}, {ID: 2}}, nil
+		},
+	}
+	var deliveries []webhookDelivery
+	queue := &mocks.JobQueueMock{
+		EnqueueFunc: func(ctx context.Context, queue string, payload any, opts ...jobs.EnqueueOption) (int64, error) {
+			assert.Equal(t, WebhookQueue, queue)
+			deliveries = append(deliveries, payload.(webhookDelivery))
+			return int64(len(deliveries)), nil
+		},
+	}
+	svc := NewWebhookService(repo, queue, &mocks.WebhookSenderMock{})
+	svc.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	err := svc.Publish(context.Background(), model.CouponEventDisabled, model.CouponSummary{Name: "PROMO"})
+
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, []int64{1, 2}, []int64{deliveries[0].SubscriptionID, deliveries[1].SubscriptionID})
+	assert.Equal(t, deliveries[0].Event, deliveries[1].Event, "subscribers share the event ID")
+	assert.Equal(t, model.CouponEventDisabled, deliveries[0].Event.Type)
+	assert.Equal(t, "PROMO", deliveries[0].Event.Coupon.Name)
+	assert.True(t, deliveries[0].Event.OccurredAt.Equal(time.Unix(1700000000, 0)))
+}
+
+func TestWebhookService_PublishClaim(t *testing.T) {
+	var subs []model.WebhookSubscription
+	repo := &mocks.WebhookRepositoryMock{
+		ListForEventFunc: func(ctx context.Context, eventType string) ([]model.WebhookSubscription, error) {
+			assert.Equal(t, model.CouponEventClaimed, eventType)
+			return subs, nil
+		},
+	}
+	var deliveries []webhookDelivery
+	queue := &mocks.JobQueueMock{
+		EnqueueFunc: func(ctx context.Context, queue string, payload any, opts ...jobs.EnqueueOption) (int64, error) {
+			deliveries = append(deliveries, payload.(webhookDelivery))
+			return int64(len(deliveries)), nil
+		},
+	}
+	reads := 0
+	coupon := func(ctx context.Context) (model.CouponSummary, error) {
+		reads++
+		return model.CouponSummary{Name: "PROMO", Amount: 10, RemainingAmount: 9}, nil
+	}
+	svc := NewWebhookService(repo, queue, &mocks.WebhookSenderMock{})
+	claim := model.ClaimReceipt{UserID: "user_001", CouponName: "PROMO", ClaimSequence: 1}
+
+	require.NoError(t, svc.PublishClaim(context.Background(), claim, coupon))
+	assert.Zero(t, reads, "the coupon is not read without subscribers")
+	assert.Empty(t, deliveries)
+
+	subs = []model.WebhookSubscription{{ID: 1}}
+	require.NoError(t, svc.PublishClaim(context.Background(), claim, coupon))
+
+	assert.Equal(t, 1, reads)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.CouponEventClaimed, deliveries[0].Event.Type)
+	assert.Equal(t, 9, deliveries[0].Event.Coupon.RemainingAmount)
+	assert.Equal(t, &claim, deliveries[0].Event.Claim)
+}
+
+func TestWebhookService_Deliver(t *testing.T) {
+	payload, err := json.Marshal(webhookDelivery{SubscriptionID: 3, Event: model.CouponEvent{ID: "evt_1"}})
+	require.NoError(t, err)
+	sendErr := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		sub     *model.WebhookSubscription
+		payload []byte
+		sent    int
+		check   func(t *testing.T, err error)
+	}{
+		{"sent", &model.WebhookSubscription{ID: 3, URL: "https://erp.example.com", Secret: "s"}, payload, 1,
+			func(t *testing.T, err error) { assert.ErrorIs(t, err, sendErr) }},
+		{"unsubscribed", nil, payload, 0,
+			func(t *testing.T, err error) {
+				assert.NoError(t, err, "deliveries to deleted subscriptions are dropped")
+			}},
+		{"corrupt payload", nil, []byte("{"), 0,
+			func(t *testing.T, err error) { assert.True(t, jobs.IsPermanent(err)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.WebhookRepositoryMock{
+				GetFunc: func(ctx context.Context, id int64) (*model.WebhookSubscription, error) { return tt.sub, nil },
+			}
+			sender := &mocks.WebhookSenderMock{
+				SendFunc: func(ctx context.Context, sub *model.WebhookSubscription, event *model.CouponEvent) error {
+					assert.Equal(t, tt.sub, sub)
+					assert.Equal(t, "evt_1", event.ID)
+					return sendErr
+				},
+			}
+
+			err := NewWebhookService(repo, &mocks.JobQueueMock{}, sender).Deliver(context.Background(), &jobs.Job{Payload: tt.payload})
+
+			tt.check(t, err)
+			assert.Len(t, sender.SendCalls(), tt.sent)
+		})
+	}
+}
+
+func TestCouponService_Create_PublishesEvent(t *testing.T) {
+	couponRepo := &mocks.CouponRepositoryMock{
+		InsertFunc: func(ctx context.Context, coupon *model.Coupon) error { return nil },
+		GetByNameFunc: func(ctx context.Context, name string) (*model.Coupon, error) {
+			return &model.Coupon{Name: name, Amount: 10, RemainingAmount: 10}, nil
+		},
+	}
+	events := &recordingPublisher{}
+	svc := NewCouponServiceWithTxBeginner(newPool(newTx()), couponRepo, noClaims())
+	svc.SetEventPublisher(events)
+
+	require.NoError(t, svc.Create(context.Background(), &model.CreateCouponRequest{Name: "PROMO", Amount: intPtr(10)}))
+
+	assert.Equal(t, []string{"coupon.created PROMO"}, events.events)
+}
+
+func TestCouponService_Apply_PublishesEvents(t *testing.T) {
+	existing := []model.Coupon{
+		{Name: "KEEP", Amount: 10, RemainingAmount: 4},
+		{Name: "OLD", Amount: 5, RemainingAmount: 5},
+		{Name: "REVIVE", Amount: 10, RemainingAmount: 10, Disabled: true},
+	}
+	manifest := &model.Manifest{Coupons: []model.CreateCouponRequest{
+		{Name: "NEW", Amount: intPtr(100)},
+		{Name: "KEEP", Amount: intPtr(10)},
+		{Name: "REVIVE", Amount: intPtr(10)},
+	}}
+	var calls []string
+	couponRepo := recordingCouponRepository(existing, &calls)
+	couponRepo.GetByNameFunc = func(ctx context.Context, name string) (*model.Coupon, error) {
+		return &model.Coupon{Name: name}, nil
+	}
+	events := &recordingPublisher{}
+	pool := &mocks.TxBeginnerMock{BeginFunc: func(ctx context.Context) (pgx.Tx, error) { return newTx(), nil }}
+	svc := NewCouponServiceWithTxBeginner(pool, couponRepo, &mocks.ClaimRepositoryMock{})
+	svc.SetEventPublisher(events)
+
+	_, err := svc.Apply(context.Background(), manifest, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"coupon.created NEW", "coupon.disabled OLD", "coupon.updated REVIVE"}, events.events)
+
+	events.events = nil
+	_, err = svc.Apply(context.Background(), manifest, true)
+	require.NoError(t, err)
+	assert.Empty(t, events.events, "dry runs publish nothing")
+}
@@ -0,0 +1,140 @@
+// Package stockwait lets requests wait for a coupon's stock without polling the
+// database: each waiter subscribes to a coupon name and is woken when Notify reports
+// that the coupon's stock may have changed, e.g. from a database.Listener. Waiters
+// recheck the coupon when woken, since a notification says nothing of what changed.
+package stockwait
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrTooManyWaiters is returned by Subscribe when the Hub already has its maximum
+	// of waiters.
+	ErrTooManyWaiters = errors.New("too many stock waiters")
+	// ErrClosed is returned by Subscribe once the Hub is closed.
+	ErrClosed = errors.New("stock waiters closed")
+)
+
+// Stats is a snapshot of a Hub's counters.
+type Stats struct {
+	Waiting       int   `json:"waiting"`       // Subscriptions open right now
+	Notifications int64 `json:"notifications"` // Notify calls
+	Woken         int64 `json:"woken"`         // Subscriptions woken by a notification
+	Rejected      int64 `json:"rejected"`      // Subscriptions refused with ErrTooManyWaiters
+}
+
+// Hub wakes the subscribers of coupon names. It is safe for concurrent use.
+type Hub struct {
+	maxWaiters int
+
+	mu      sync.Mutex
+	waiters map[string]map[*subscription]struct{}
+	waiting int
+	closed  bool
+
+	notifications atomic.Int64
+	woken         atomic.Int64
+	rejected      atomic.Int64
+}
+
+// subscription is one waiter, woken by a Notify of any of its names.
+type subscription struct {
+	woken   chan struct{}
+	names   []string
+	removed bool // Woken or cancelled; guarded by Hub.mu
+}
+
+// New creates a Hub holding at most maxWaiters subscriptions at once.
+func New(maxWaiters int) *Hub {
+	return &Hub{maxWaiters: maxWaiters, waiters: make(map[string]map[*subscription]struct{})}
+}
+
+// Subscribe returns a channel closed by the next Notify of any of names, and a
+// function cancelling the subscription that must be called once it is no longer
+// needed. Returns ErrTooManyWaiters when the Hub is full, and ErrClosed once it is
+// closed.
+func (h *Hub) Subscribe(names ...string) (<-chan struct{}, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, nil, ErrClosed
+	}
+	if h.waiting >= h.maxWaiters {
+		h.rejected.Add(1)
+		return nil, nil, ErrTooManyWaiters
+	}
+
+	sub := &subscription{woken: make(chan struct{}), names: names}
+	for _, name := range names {
+		if h.waiters[name] == nil {
+			h.waiters[name] = make(map[*subscription]struct{})
+		}
+		h.waiters[name][sub] = struct{}{}
+	}
+	h.waiting++
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(sub)
+	}
+	return sub.woken, cancel, nil
+}
+
+// Notify wakes every subscriber of name.
+func (h *Hub) Notify(name string) {
+	h.notifications.Add(1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.waiters[name] {
+		close(sub.woken)
+		h.remove(sub)
+		h.woken.Add(1)
+	}
+}
+
+// Close wakes every subscriber and refuses new ones, so waits end at shutdown instead
+// of holding it up.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.waiters {
+		for sub := range subs {
+			close(sub.woken)
+			h.remove(sub)
+		}
+	}
+}
+
+// remove drops sub from each of its names, unless it was removed already. h.mu must
+// be held.
+func (h *Hub) remove(sub *subscription) {
+	if sub.removed {
+		return
+	}
+	sub.removed = true
+	for _, name := range sub.names {
+		delete(h.waiters[name], sub)
+		if len(h.waiters[name]) == 0 {
+			delete(h.waiters, name)
+		}
+	}
+	h.waiting--
+}
+
+// Stats returns the hub's counters.
+func (h *Hub) Stats() Stats {
+	h.mu.Lock()
+	waiting := h.waiting
+	h.mu.Unlock()
+	return Stats{
+		Waiting:       waiting,
+		Notifications: h.notifications.Load(),
+		Woken:         h.woken.Load(),
+		Rejected:      h.rejected.Load(),
+	}
+}
@@ -0,0 +1,95 @@
+package stockwait
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func closed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestHub_Notify(t *testing.T) {
+	h := New(10)
+	promo1, cancel1, err := h.Subscribe("PROMO")
+	require.NoError(t, err)
+	defer cancel1()
+	promo2, cancel2, err := h.Subscribe("PROMO")
+	require.NoError(t, err)
+	defer cancel2()
+	sale, cancelSale, err := h.Subscribe("SALE")
+	require.NoError(t, err)
+	defer cancelSale()
+
+	h.Notify("PROMO")
+
+	assert.True(t, closed(promo1))
+	assert.True(t, closed(promo2))
+	assert.False(t, closed(sale), "other coupons are not woken")
+	assert.Equal(t, Stats{Waiting: 1, Notifications: 1, Woken: 2}, h.Stats())
+
+	h.Notify("PROMO")
+	assert.Equal(t, int64(2), h.Stats().Woken, "woken subscriptions are dropped")
+}
+
+func TestHub_SubscribeNames(t *testing.T) {
+	h := New(10)
+	ch, cancel, err := h.Subscribe("CHILD", "PARENT")
+	require.NoError(t, err)
+	defer cancel()
+
+	h.Notify("PARENT")
+	h.Notify("CHILD")
+
+	assert.True(t, closed(ch))
+	assert.Equal(t, Stats{Notifications: 2, Woken: 1}, h.Stats(), "a subscription is woken once")
+}
+
+func TestHub_Cancel(t *testing.T) {
+	h := New(10)
+	ch, cancel, err := h.Subscribe("PROMO")
+	require.NoError(t, err)
+
+	cancel()
+	cancel()
+	h.Notify("PROMO")
+
+	assert.False(t, closed(ch))
+	assert.Equal(t, Stats{Notifications: 1}, h.Stats())
+}
+
+func TestHub_MaxWaiters(t *testing.T) {
+	h := New(1)
+	_, cancel, err := h.Subscribe("PROMO")
+	require.NoError(t, err)
+
+	_, _, err = h.Subscribe("SALE")
+	assert.ErrorIs(t, err, ErrTooManyWaiters)
+	assert.Equal(t, int64(1), h.Stats().Rejected)
+
+	cancel()
+	_, cancel, err = h.Subscribe("SALE")
+	require.NoError(t, err)
+	cancel()
+}
+
+func TestHub_Close(t *testing.T) {
+	h := New(10)
+	ch, cancel, err := h.Subscribe("PROMO", "SALE")
+	require.NoError(t, err)
+	defer cancel()
+
+	h.Close()
+
+	assert.True(t, closed(ch))
+	assert.Zero(t, h.Stats().Waiting)
+	_, _, err = h.Subscribe("PROMO")
+	assert.ErrorIs(t, err, ErrClosed)
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/lockdiag"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/repository/memory"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+)
+
+// memoryStore is a Store keeping its data in process memory. It has no pools, and
+// supports what the MySQL store supports plus campaign caps, allowlists, coupon
+// deletion (without the archive), claim retention and API key usage.
+type memoryStore struct {
+	*memory.DB
+
+	coupons   *memory.CouponRepository
+	claims    *memory.ClaimRepository
+	audit     *memory.AuditRepository
+	caps      *memory.CampaignCapRepository
+	allow     *memory.AllowlistRepository
+	usage     *memory.APIKeyUsageRepository
+	metrics   *database.StatementMetrics
+	startedAt time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	db := memory.NewDB()
+	s := &memoryStore{
+		DB:        db,
+		coupons:   memory.NewCouponRepository(db),
+		claims:    memory.NewClaimRepository(db),
+		audit:     memory.NewAuditRepository(db),
+		caps:      memory.NewCampaignCapRepository(db),
+		allow:     memory.NewAllowlistRepository(db),
+		usage:     memory.NewAPIKeyUsageRepository(db),
+		metrics:   database.NewStatementMetrics(),
+		startedAt: time.Now().Truncate(time.Second),
+	}
+	s.coupons.SetStatementMetrics(s.metrics)
+	s.claims.SetStatementMetrics(s.metrics)
+	return s
+}
+
+func (s *memoryStore) Coupons() ports.CouponRepository                  { return s.coupons }
+func (s *memoryStore) Claims() ClaimRepository                          { return s.claims }
+func (s *memoryStore) Audit() ports.AuditRepository                     { return s.audit }
+func (s *memoryStore) Webhooks() ports.WebhookRepository                { return nil }
+func (s *memoryStore) Leaderboard() ports.LeaderboardRepository         { return nil }
+func (s *memoryStore) CampaignCaps() ports.CampaignCapRepository        { return s.caps }
+func (s *memoryStore) Allowlists() ports.AllowlistRepository            { return s.allow }
+func (s *memoryStore) Tombstones() ports.CouponTombstoneRepository      { return s.coupons }
+func (s *memoryStore) Archive() ports.CouponArchiveRepository           { return nil }
+func (s *memoryStore) ClaimMoves() ports.ClaimMoveRepository            { return nil }
+func (s *memoryStore) ClaimRetention() ports.ClaimRetentionRepository   { return s.claims }
+func (s *memoryStore) AnalyticsExport() ports.AnalyticsExportRepository { return nil }
+func (s *memoryStore) LoadTests() ports.LoadTestRepository              { return nil }
+func (s *memoryStore) APIKeyUsage() ports.APIKeyUsageRepository         { return s.usage }
+func (s *memoryStore) Jobs() *jobs.Queue                                { return nil }
+func (s *memoryStore) StockListener() *database.Listener                { return nil }
+func (s *memoryStore) LockDiagnostics() *lockdiag.Diagnoser             { return nil }
+func (s *memoryStore) Dialect() database.Dialect                        { return database.Memory }
+func (s *memoryStore) ReadRetrier() *database.ReadRetrier               { return nil }
+func (s *memoryStore) StatementMetrics() *database.StatementMetrics     { return s.metrics }
+
+func (s *memoryStore) Ping(context.Context) error { return nil }
+func (s *memoryStore) PoolStats() map[string]database.PoolStats {
+	return map[string]database.PoolStats{}
+}
+func (s *memoryStore) ServerVersion(context.Context) (string, error) { return "in-memory", nil }
+
+func (s *memoryStore) ServerIdentity(context.Context) (database.ServerIdentity, error) {
+	return database.ServerIdentity{Addr: "memory", StartedAt: s.startedAt}, nil
+}
+
+func (s *memoryStore) ServerLoad(context.Context) (database.ServerLoad, error) {
+	return database.ServerLoad{}, fmt.Errorf("server load is not supported on %s", database.Memory.Name)
+}
+
+func (s *memoryStore) DataStats(ctx context.Context) (database.DataStats, error) {
+	var stats database.DataStats
+	names, err := s.coupons.Names(ctx)
+	if err != nil {
+		return stats, err
+	}
+	stats.Coupons = int64(len(names))
+	for _, name := range names {
+		coupon, err := s.coupons.GetByName(ctx, name)
+		if err != nil {
+			return stats, err
+		}
+		if coupon == nil || coupon.Stats == nil || coupon.Stats.LastClaimAt == nil {
+			continue
+		}
+		if stats.LastClaimAt == nil || coupon.Stats.LastClaimAt.After(*stats.LastClaimAt) {
+			stats.LastClaimAt = coupon.Stats.LastClaimAt
+		}
+	}
+	return stats, nil
+}
+
+func (s *memoryStore) Close() {}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/lockdiag"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/repository/mysql"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+)
+
+// mysqlStore is a Store backed by a database/sql MySQL pool.
+type mysqlStore struct {
+	*database.Transactor
+
+	db      *sql.DB
+	coupons *mysql.CouponRepository
+	claims  *mysql.ClaimRepository
+	audit   *mysql.AuditRepository
+	metrics *database.StatementMetrics
+}
+
+func newMySQLStore(db *sql.DB) *mysqlStore {
+	tx := database.NewSQLTransactor(db, database.MySQL)
+	s := &mysqlStore{
+		Transactor: tx,
+		db:         db,
+		coupons:    mysql.NewCouponRepository(db, tx),
+		claims:     mysql.NewClaimRepository(db),
+		audit:      mysql.NewAuditRepository(db),
+		metrics:    database.NewStatementMetrics(),
+	}
+	s.coupons.SetStatementMetrics(s.metrics)
+	s.claims.SetStatementMetrics(s.metrics)
+	return s
+}
+
+func (s *mysqlStore) Coupons() ports.CouponRepository                  { return s.coupons }
+func (s *mysqlStore) Claims() ClaimRepository                          { return s.claims }
+func (s *mysqlStore) Audit() ports.AuditRepository                     { return s.audit }
+func (s *mysqlStore) Webhooks() ports.WebhookRepository                { return nil }
+func (s *mysqlStore) Leaderboard() ports.LeaderboardRepository         { return nil }
+func (s *mysqlStore) CampaignCaps() ports.CampaignCapRepository        { return nil }
+func (s *mysqlStore) Allowlists() ports.AllowlistRepository            { return nil }
+func (s *mysqlStore) Tombstones() ports.CouponTombstoneRepository      { return nil }
+func (s *mysqlStore) Archive() ports.CouponArchiveRepository           { return nil }
+func (s *mysqlStore) ClaimMoves() ports.ClaimMoveRepository            { return nil }
+func (s *mysqlStore) ClaimRetention() ports.ClaimRetentionRepository   { return nil }
+func (s *mysqlStore) AnalyticsExport() ports.AnalyticsExportRepository { return nil }
+func (s *mysqlStore) LoadTests() ports.LoadTestRepository              { return nil }
+func (s *mysqlStore) APIKeyUsage() ports.APIKeyUsageRepository         { return nil }
+func (s *mysqlStore) Jobs() *jobs.Queue                                { return nil }
+func (s *mysqlStore) StockListener() *database.Listener                { return nil }
+func (s *mysqlStore) LockDiagnostics() *lockdiag.Diagnoser             { return nil }
+func (s *mysqlStore) Dialect() database.Dialect                        { return database.MySQL }
+func (s *mysqlStore) ReadRetrier() *database.ReadRetrier               { return nil }
+func (s *mysqlStore) StatementMetrics() *database.StatementMetrics     { return s.metrics }
+
+func (s *mysqlStore) Ping(ctx context.Context) error { return s.db.PingContext(ctx) }
+
+func (s *mysqlStore) PoolStats() map[string]database.PoolStats {
+	return map[string]database.PoolStats{"claim": database.SQLPoolStats(s.db)}
+}
+
+func (s *mysqlStore) ServerVersion(ctx context.Context) (string, error) {
+	var version string
+	err := s.db.QueryRowContext(ctx, `SELECT VERSION()`).Scan(&version)
+	return version, err
+}
+
+func (s *mysqlStore) ServerIdentity(ctx context.Context) (database.ServerIdentity, error) {
+	var id database.ServerIdentity
+	var uptime int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT CONCAT(@@hostname, ':', @@port), VARIABLE_VALUE
+		FROM performance_schema.global_status WHERE VARIABLE_NAME = 'Uptime'`).Scan(&id.Addr, &uptime)
+	if err != nil {
+		return id, err
+	}
+	id.StartedAt = time.Now().Add(-time.Duration(uptime) * time.Second).Truncate(time.Second)
+	return id, nil
+}
+
+func (s *mysqlStore) ServerLoad(context.Context) (database.ServerLoad, error) {
+	return database.ServerLoad{}, fmt.Errorf("server load is not supported on %s", database.MySQL.Name)
+}
+
+func (s *mysqlStore) DataStats(ctx context.Context) (database.DataStats, error) {
+	var stats database.DataStats
+	err := s.db.QueryRowContext(ctx, `SELECT
+		(SELECT COUNT(*) FROM coupons),
+		(SELECT MAX(last_claim_at) FROM coupon_stats)`).Scan(&stats.Coupons, &stats.LastClaimAt)
+	return stats, err
+}
+
+func (s *mysqlStore) Close() { _ = s.db.Close() }
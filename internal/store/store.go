@@ -0,0 +1,351 @@
+// Package store bundles the repositories, transactions and connection lifecycle of one
+// storage backend behind the Store interface, selected by DB_DRIVER.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/config"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/lockdiag"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/repository"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+)
+
+// ClaimRepository combines the claim operations of the coupon and user data services.
+type ClaimRepository interface {
+	ports.ClaimRepository
+	ports.UserClaimRepository
+}
+
+// Store is a storage backend: repositories plus the transactions they run in.
+type Store interface {
+	ports.Transactor
+
+	Coupons() ports.CouponRepository
+	Claims() ClaimRepository
+	Audit() ports.AuditRepository
+	// Webhooks returns the webhook subscriptions, or nil when the backend has no job
+	// queue to deliver them (MySQL, memory).
+	Webhooks() ports.WebhookRepository
+	// Leaderboard returns the campaign leaderboards, or nil when the backend does not
+	// maintain them (MySQL, memory).
+	Leaderboard() ports.LeaderboardRepository
+	// CampaignCaps returns the campaign claim caps, or nil when the backend does not
+	// enforce them (MySQL).
+	CampaignCaps() ports.CampaignCapRepository
+	// Allowlists returns the coupon allowlists, or nil when the backend does not
+	// enforce them (MySQL).
+	Allowlists() ports.AllowlistRepository
+	// Tombstones returns the deleted coupons, or nil when the backend cannot delete
+	// coupons (MySQL).
+	Tombstones() ports.CouponTombstoneRepository
+	// Archive keeps deleted coupons once they are purged, or is nil when the backend
+	// does not archive them (MySQL, memory).
+	Archive() ports.CouponArchiveRepository
+	// ClaimMoves verifies the move of claims to claims_v2, or is nil when the backend
+	// cannot move them (MySQL, memory).
+	ClaimMoves() ports.ClaimMoveRepository
+	// ClaimRetention enforces the claim retention of coupons, or is nil when the
+	// backend does not store it (MySQL).
+	ClaimRetention() ports.ClaimRetentionRepository
+	// AnalyticsExport reads the coupons and claims of the analytics export, or is nil
+	// when the backend does not support it (MySQL, memory).
+	AnalyticsExport() ports.AnalyticsExportRepository
+	// LoadTests creates and removes disposable load test coupons, or is nil when the
+	// backend cannot purge coupons (MySQL, memory).
+	LoadTests() ports.LoadTestRepository
+	// APIKeyUsage counts the claims of API keys against their monthly quotas, or is
+	// nil when the backend does not store them (MySQL).
+	APIKeyUsage() ports.APIKeyUsageRepository
+	// Jobs returns the durable job queue, or nil when the backend has none (MySQL, memory).
+	Jobs() *jobs.Queue
+	// StockListener receives the names of coupons whose stock may have become
+	// claimable, or is nil when the backend cannot LISTEN (CockroachDB, MySQL, memory).
+	StockListener() *database.Listener
+	// LockDiagnostics dumps the lock state of claims waiting long for a coupon's row
+	// lock, or is nil unless enabled (see config.DBConfig.LockDiagnosticsThreshold).
+	LockDiagnostics() *lockdiag.Diagnoser
+
+	// Dialect reports the backend's database dialect.
+	Dialect() database.Dialect
+	// Ping checks connectivity of every pool (used by /health).
+	Ping(ctx context.Context) error
+	// ReadRetrier returns the retrier of coupon and claim reads, or nil when reads are
+	// not retried (see config.DBConfig.ReadRetry).
+	ReadRetrier() *database.ReadRetrier
+	// PoolStats returns the usage of each connection pool: "claim", and "read" when
+	// reads have their own pool; none for the memory backend.
+	PoolStats() map[string]database.PoolStats
+	// StatementMetrics returns the run counts and latencies of the statements named in
+	// database.Statements.
+	StatementMetrics() *database.StatementMetrics
+	// ServerVersion returns the database server's version string.
+	ServerVersion(ctx context.Context) (string, error)
+	// ServerIdentity returns the address and start time of the server answering on the
+	// claim pool. On CockroachDB the address is the gateway node's, which may differ
+	// between connections.
+	ServerIdentity(ctx context.Context) (database.ServerIdentity, error)
+	// ServerLoad returns the replication lag and active connections of the server.
+	// PostgreSQL only: it fails on other backends.
+	ServerLoad(ctx context.Context) (database.ServerLoad, error)
+	// DataStats counts the coupons and finds the latest claim, from coupon_stats rather
+	// than the claims table so that it stays cheap however many claims there are.
+	DataStats(ctx context.Context) (database.DataStats, error)
+	// Close releases all connections.
+	Close()
+}
+
+// Open connects to the backend selected by cfg.Driver (see database.Dialects).
+// PostgreSQL wire-compatible backends share the pgx repositories and differ only in how
+// transactions are retried; MySQL uses the repositories in internal/repository/mysql,
+// and Memory those in internal/repository/memory, which need no server.
+// The repositories use the column renames and table moves in the phases set by
+// cfg.MigrationPhases and the query timeouts and coupon lock policy set by cfg. With
+// cfg.ReadMaxConns set, reads outside transactions get a pool of their own. With cfg.DegradedStart set, Open does not
+// connect: the pools connect on first use (see database.AwaitConnection). With
+// cfg.ReadRetry set, coupon and claim reads are retried once on a lost connection.
+// With cfg.LockDiagnosticsThreshold set (PostgreSQL only), long coupon lock waits are
+// dumped.
+func Open(ctx context.Context, cfg config.DBConfig) (Store, error) {
+	dialect, err := database.DialectByName(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	renames, err := database.RenamesWithPhases(cfg.MigrationPhases)
+	if err != nil {
+		return nil, err
+	}
+	moves, err := database.TableMovesWithPhases(cfg.MigrationPhases)
+	if err != nil {
+		return nil, err
+	}
+
+	if dialect == database.Memory {
+		return newMemoryStore(), nil
+	}
+
+	if dialect == database.MySQL {
+		var db *sql.DB
+		if cfg.DegradedStart {
+			db, err = database.NewLazyMySQLPool(cfg.MySQLDSN(), cfg.MaxConns, cfg.MinConns)
+		} else {
+			db, err = database.NewMySQLPool(ctx, cfg.MySQLDSN(), cfg.MaxConns, cfg.MinConns, 5)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("connect to %s: %w", dialect.Name, err)
+		}
+		st := newMySQLStore(db)
+		st.claims.SetRenames(renames)
+		st.coupons.SetQueryTimeouts(cfg.QueryTimeouts)
+		st.coupons.SetLockPolicy(cfg.LockPolicy())
+		return st, nil
+	}
+
+	newPool := func(dsn string) (*pgxpool.Pool, error) {
+		if cfg.DegradedStart {
+			return database.NewLazyPool(ctx, dsn)
+		}
+		return database.NewPool(ctx, dsn, 5)
+	}
+
+	pool, err := newPool(cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", dialect.Name, err)
+	}
+	st := newPgStore(pool, dialect)
+	st.claims.SetRenames(renames)
+	st.setTableMoves(moves)
+	st.coupons.SetQueryTimeouts(cfg.QueryTimeouts)
+	st.coupons.SetLockPolicy(cfg.LockPolicy())
+	if cfg.ReadRetry {
+		st.setReadRetrier(database.NewReadRetrier())
+	}
+
+	if cfg.ReadMaxConns > 0 {
+		reads, err := newPool(cfg.ReadDSN())
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("connect read pool to %s: %w", dialect.Name, err)
+		}
+		st.setReadPool(reads)
+	}
+	if cfg.LockDiagnosticsThreshold > 0 {
+		st.setLockDiagnostics(cfg.LockDiagnosticsThreshold, cfg.LockDiagnosticsSize)
+	}
+	return st, nil
+}
+
+// pgStore is a Store backed by a pgx pool.
+type pgStore struct {
+	*database.Transactor
+
+	pool    *pgxpool.Pool
+	reads   *pgxpool.Pool // nil when reads share pool
+	dialect database.Dialect
+	coupons *repository.CouponRepository
+	claims  *repository.ClaimRepository
+	audit   *repository.AuditRepository
+	hooks   *repository.WebhookRepository
+	board   *repository.LeaderboardRepository
+	caps    *repository.CampaignCapRepository
+	allow   *repository.AllowlistRepository
+	usage   *repository.APIKeyUsageRepository
+	jobs    *jobs.Queue
+	stock   *database.Listener    // nil unless the dialect is PostgreSQL
+	locks   *lockdiag.Diagnoser   // nil unless lock waits are diagnosed
+	retry   *database.ReadRetrier // nil when reads are not retried
+	metrics *database.StatementMetrics
+}
+
+func newPgStore(pool *pgxpool.Pool, dialect database.Dialect) *pgStore {
+	s := &pgStore{
+		Transactor: database.NewTransactor(pool, dialect),
+		pool:       pool,
+		dialect:    dialect,
+		coupons:    repository.NewCouponRepository(pool),
+		claims:     repository.NewClaimRepository(pool),
+		audit:      repository.NewAuditRepository(pool),
+		hooks:      repository.NewWebhookRepository(pool),
+		board:      repository.NewLeaderboardRepository(pool),
+		caps:       repository.NewCampaignCapRepository(pool),
+		allow:      repository.NewAllowlistRepository(pool),
+		usage:      repository.NewAPIKeyUsageRepository(pool),
+		jobs:       jobs.NewQueue(pool),
+		metrics:    database.NewStatementMetrics(),
+	}
+	s.coupons.SetStatementMetrics(s.metrics)
+	s.claims.SetStatementMetrics(s.metrics)
+	if dialect == database.Postgres {
+		s.coupons.SetStockNotifications(repository.StockChannel)
+		s.stock = database.NewListener(pool, repository.StockChannel)
+	}
+	return s
+}
+
+// setReadPool moves the repositories' reads outside transactions to reads.
+func (s *pgStore) setReadPool(reads *pgxpool.Pool) {
+	s.reads = reads
+	s.coupons.SetReadPool(reads)
+	s.claims.SetReadPool(reads)
+	s.board.SetReadPool(reads)
+}
+
+// setLockDiagnostics dumps coupon lock waits longer than threshold, keeping the size
+// most recent dumps. Snapshots are read on the read pool when there is one, which claims
+// piling up behind a lock cannot exhaust.
+func (s *pgStore) setLockDiagnostics(threshold time.Duration, size int) {
+	var pool repository.PoolInterface = s.pool
+	if s.reads != nil {
+		pool = s.reads
+	}
+	s.locks = lockdiag.New(func(ctx context.Context, pid uint32) (lockdiag.Snapshot, error) {
+		return repository.LockSnapshot(ctx, pool, pid)
+	}, threshold, size)
+	s.coupons.SetLockWaitWatcher(s.locks)
+}
+
+// setTableMoves moves the repositories' claim reads and writes to the tables of the
+// phase in moves.
+func (s *pgStore) setTableMoves(moves map[string]database.TableMove) {
+	s.claims.SetTableMoves(moves)
+	s.coupons.SetTableMoves(moves)
+	s.board.SetTableMoves(moves)
+}
+
+// setReadRetrier retries the coupon and claim reads with retrier.
+func (s *pgStore) setReadRetrier(retrier *database.ReadRetrier) {
+	s.retry = retrier
+	s.coupons.SetReadRetrier(retrier)
+	s.claims.SetReadRetrier(retrier)
+}
+
+func (s *pgStore) Coupons() ports.CouponRepository                  { return s.coupons }
+func (s *pgStore) Claims() ClaimRepository                          { return s.claims }
+func (s *pgStore) Audit() ports.AuditRepository                     { return s.audit }
+func (s *pgStore) Webhooks() ports.WebhookRepository                { return s.hooks }
+func (s *pgStore) Leaderboard() ports.LeaderboardRepository         { return s.board }
+func (s *pgStore) CampaignCaps() ports.CampaignCapRepository        { return s.caps }
+func (s *pgStore) Allowlists() ports.AllowlistRepository            { return s.allow }
+func (s *pgStore) Tombstones() ports.CouponTombstoneRepository      { return s.coupons }
+func (s *pgStore) Archive() ports.CouponArchiveRepository           { return s.coupons }
+func (s *pgStore) ClaimMoves() ports.ClaimMoveRepository            { return s.claims }
+func (s *pgStore) ClaimRetention() ports.ClaimRetentionRepository   { return s.claims }
+func (s *pgStore) AnalyticsExport() ports.AnalyticsExportRepository { return s.claims }
+func (s *pgStore) LoadTests() ports.LoadTestRepository              { return s.coupons }
+func (s *pgStore) APIKeyUsage() ports.APIKeyUsageRepository         { return s.usage }
+func (s *pgStore) Jobs() *jobs.Queue                                { return s.jobs }
+func (s *pgStore) StockListener() *database.Listener                { return s.stock }
+func (s *pgStore) LockDiagnostics() *lockdiag.Diagnoser             { return s.locks }
+func (s *pgStore) Dialect() database.Dialect                        { return s.dialect }
+func (s *pgStore) ReadRetrier() *database.ReadRetrier               { return s.retry }
+func (s *pgStore) StatementMetrics() *database.StatementMetrics     { return s.metrics }
+
+func (s *pgStore) Ping(ctx context.Context) error {
+	if err := s.pool.Ping(ctx); err != nil {
+		return err
+	}
+	if s.reads != nil {
+		return s.reads.Ping(ctx)
+	}
+	return nil
+}
+
+func (s *pgStore) PoolStats() map[string]database.PoolStats {
+	stats := map[string]database.PoolStats{"claim": database.PgxPoolStats(s.pool)}
+	if s.reads != nil {
+		stats["read"] = database.PgxPoolStats(s.reads)
+	}
+	return stats
+}
+
+func (s *pgStore) ServerVersion(ctx context.Context) (string, error) {
+	var version string
+	err := s.pool.QueryRow(ctx, `SELECT version()`).Scan(&version)
+	return version, err
+}
+
+func (s *pgStore) ServerIdentity(ctx context.Context) (database.ServerIdentity, error) {
+	var id database.ServerIdentity
+	query := `SELECT COALESCE(host(inet_server_addr()) || ':' || inet_server_port(), 'local'), pg_postmaster_start_time()`
+	if s.dialect == database.CockroachDB {
+		query = `SELECT address, started_at FROM crdb_internal.gossip_nodes WHERE node_id = crdb_internal.node_id()`
+	}
+	err := s.pool.QueryRow(ctx, query).Scan(&id.Addr, &id.StartedAt)
+	return id, err
+}
+
+func (s *pgStore) ServerLoad(ctx context.Context) (database.ServerLoad, error) {
+	var load database.ServerLoad
+	if s.dialect != database.Postgres {
+		return load, fmt.Errorf("server load is not supported on %s", s.dialect.Name)
+	}
+	var lagSeconds float64
+	err := s.pool.QueryRow(ctx, `SELECT
+		(SELECT COALESCE(MAX(EXTRACT(EPOCH FROM replay_lag)), 0) FROM pg_stat_replication),
+		(SELECT COUNT(*) FROM pg_stat_activity WHERE state = 'active' AND backend_type = 'client backend')`).
+		Scan(&lagSeconds, &load.ActiveConns)
+	load.ReplicationLag = time.Duration(lagSeconds * float64(time.Second))
+	return load, err
+}
+
+func (s *pgStore) DataStats(ctx context.Context) (database.DataStats, error) {
+	var stats database.DataStats
+	err := s.pool.QueryRow(ctx, `SELECT
+		(SELECT COUNT(*) FROM coupons WHERE deleted_at IS NULL),
+		(SELECT MAX(last_claim_at) FROM coupon_stats)`).Scan(&stats.Coupons, &stats.LastClaimAt)
+	return stats, err
+}
+
+func (s *pgStore) Close() {
+	if s.reads != nil {
+		s.reads.Close()
+	}
+	s.pool.Close()
+}
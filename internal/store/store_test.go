@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/config"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+var (
+	_ Store = (*pgStore)(nil)
+	_ Store = (*mysqlStore)(nil)
+	_ Store = (*memoryStore)(nil)
+)
+
+// unreachable returns a config pointing at a closed port.
+func unreachable(driver string) config.DBConfig {
+	return config.DBConfig{
+		Driver: driver, Host: "localhost", Port: 9999, User: "invalid", Password: "invalid",
+		Name: "invalid", SSLMode: "disable", MaxConns: 1,
+	}
+}
+
+func TestOpen_UnknownDriver(t *testing.T) {
+	s, err := Open(context.Background(), unreachable("spanner"))
+
+	require.Error(t, err)
+	assert.Nil(t, s)
+	assert.Contains(t, err.Error(), `unknown database driver "spanner"`)
+}
+
+func TestOpen_ConnectFailureNamesDialect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, driver := range []string{"cockroachdb", "mysql"} {
+		s, err := Open(ctx, unreachable(driver))
+
+		require.Error(t, err)
+		assert.Nil(t, s)
+		assert.Contains(t, err.Error(), "connect to "+driver)
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
+
+func TestOpen_Memory(t *testing.T) {
+	// Nothing listens on the configured port: the memory store ignores it
+	st, err := Open(context.Background(), unreachable("memory"))
+	require.NoError(t, err)
+	defer st.Close()
+
+	ctx := context.Background()
+	assert.Equal(t, database.Memory, st.Dialect())
+	require.NoError(t, st.Ping(ctx))
+	assert.Nil(t, st.Webhooks())
+	require.NoError(t, st.Coupons().Insert(ctx, &model.Coupon{Name: "PROMO", Amount: 1}))
+	err = st.InTx(ctx, func(tx database.TxQuerier) error {
+		return st.Claims().Insert(ctx, tx, &model.Claim{UserID: "user_001", CouponName: "PROMO", Sequence: 1})
+	})
+	require.NoError(t, err)
+
+	stats, err := st.DataStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Coupons)
+	assert.Nil(t, stats.LastClaimAt, "claims without RecordClaimStats are not in the rollup")
+}
+
+func TestPgStore_PoolStats(t *testing.T) {
+	// Pools connect lazily, so no server is needed
+	cfg := unreachable("postgres")
+	cfg.MaxConns, cfg.ReadMaxConns = 3, 2
+	claims, err := pgxpool.New(context.Background(), cfg.DSN())
+	require.NoError(t, err)
+	reads, err := pgxpool.New(context.Background(), cfg.ReadDSN())
+	require.NoError(t, err)
+
+	st := newPgStore(claims, database.Postgres)
+	defer st.Close()
+	assert.Equal(t, map[string]database.PoolStats{"claim": {MaxConns: 3}}, st.PoolStats())
+
+	st.setReadPool(reads)
+	assert.Equal(t, map[string]database.PoolStats{"claim": {MaxConns: 3}, "read": {MaxConns: 2}}, st.PoolStats())
+}
+
+func TestOpen_ReadRetry(t *testing.T) {
+	// A degraded start does not connect, so no server is needed
+	cfg := unreachable("postgres")
+	cfg.DegradedStart = true
+
+	st, err := Open(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Nil(t, st.ReadRetrier())
+	st.Close()
+
+	cfg.ReadRetry = true
+	st, err = Open(context.Background(), cfg)
+	require.NoError(t, err)
+	defer st.Close()
+	assert.NotNil(t, st.ReadRetrier())
+}
+
+func TestOpen_LockDiagnostics(t *testing.T) {
+	// A degraded start does not connect, so no server is needed
+	cfg := unreachable("postgres")
+	cfg.DegradedStart = true
+
+	st, err := Open(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Nil(t, st.LockDiagnostics())
+	st.Close()
+
+	cfg.LockDiagnosticsThreshold = time.Second
+	cfg.LockDiagnosticsSize = 5
+	st, err = Open(context.Background(), cfg)
+	require.NoError(t, err)
+	defer st.Close()
+	require.NotNil(t, st.LockDiagnostics())
+	assert.Equal(t, 5, st.LockDiagnostics().Stats().Capacity)
+}
+
+func TestPgStore_StockListener(t *testing.T) {
+	// Pools connect lazily, so no server is needed
+	pool, err := pgxpool.New(context.Background(), unreachable("postgres").DSN())
+	require.NoError(t, err)
+	defer pool.Close()
+
+	assert.NotNil(t, newPgStore(pool, database.Postgres).StockListener())
+	assert.Nil(t, newPgStore(pool, database.CockroachDB).StockListener(), "CockroachDB has no LISTEN")
+}
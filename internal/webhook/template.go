@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+// MaxPayloadSize bounds the body a payload template may render.
+const MaxPayloadSize = 64 << 10
+
+// maxRangeDepth bounds how deeply range actions of a payload template nest, so a short
+// template cannot loop for long over the same data.
+const maxRangeDepth = 2
+
+// templateFuncs are the functions a payload template may call besides the text/template
+// builtins.
+var templateFuncs = template.FuncMap{
+	"json":  templateJSON,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// templateJSON encodes v as JSON, for inserting values into a payload safely.
+func templateJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// Template renders a webhook delivery body from an event instead of the default JSON
+// encoding. It is a Go text/template evaluated over the event as encoded by default, so
+// fields are named as in the JSON (.type, .coupon.name) and fields omitted from it are
+// null. Besides the builtins it may call json, which encodes a value (use it to insert
+// strings), lower and upper. It must render valid JSON of at most MaxPayloadSize bytes.
+type Template struct {
+	tmpl *template.Template
+}
+
+// ParseTemplate parses a payload template and checks it renders valid JSON for an event
+// of every type in events. Templates may not define or call other templates, may only
+// range over event fields, and may nest at most 2 range actions.
+func ParseTemplate(src string, events []string) (*Template, error) {
+	tmpl, err := template.New("payload").Funcs(templateFuncs).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tmpl.Templates()) > 1 {
+		return nil, errors.New("templates may not define other templates")
+	}
+	if err := checkNode(tmpl.Root, 0); err != nil {
+		return nil, err
+	}
+
+	t := &Template{tmpl: tmpl}
+	for _, eventType := range events {
+		for _, event := range sampleEvents(eventType) {
+			if _, err := t.Render(event); err != nil {
+				return nil, fmt.Errorf("%s event: %w", eventType, err)
+			}
+		}
+	}
+	return t, nil
+}
+
+// checkNode rejects the parts of a payload template that could run for long: calls of
+// other templates, ranges over anything but event fields, and deeply nested ranges.
+func checkNode(node parse.Node, ranges int) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkNode(child, ranges); err != nil {
+				return err
+			}
+		}
+	case *parse.TemplateNode:
+		return errors.New("templates may not call other templates")
+	case *parse.RangeNode:
+		if ranges == maxRangeDepth {
+			return fmt.Errorf("range actions may not nest more than %d deep", maxRangeDepth)
+		}
+		if !rangesOverField(n.Pipe) {
+			return fmt.Errorf("range may only iterate over an event field, not %s", n.Pipe)
+		}
+		if err := checkNode(n.List, ranges+1); err != nil {
+			return err
+		}
+		return checkNode(n.ElseList, ranges)
+	case *parse.IfNode:
+		if err := checkNode(n.List, ranges); err != nil {
+			return err
+		}
+		return checkNode(n.ElseList, ranges)
+	case *parse.WithNode:
+		if err := checkNode(n.List, ranges); err != nil {
+			return err
+		}
+		return checkNode(n.ElseList, ranges)
+	}
+	return nil
+}
+
+// rangesOverField reports whether pipe is a single event field (.coupon.tags), the
+// event itself, or a field of a variable ($.coupon.tags, $tag.name): never a number,
+// which range would count up to.
+func rangesOverField(pipe *parse.PipeNode) bool {
+	if len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode, *parse.DotNode:
+		return true
+	case *parse.VariableNode:
+		return len(arg.Ident) > 1 || arg.Ident[0] == "$"
+	}
+	return false
+}
+
+// Render renders the delivery body for event.
+func (t *Template) Render(event *model.CouponEvent) ([]byte, error) {
+	// Evaluating over the JSON decoding names fields as in the default body, and
+	// makes numbers float64, which range cannot iterate over
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]any
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&limitedWriter{w: &buf, n: MaxPayloadSize}, data); err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.New("template did not render valid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+// limitedWriter writes to w until n bytes were written, then fails.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > l.n {
+		return 0, fmt.Errorf("template rendered more than %d bytes", MaxPayloadSize)
+	}
+	l.n -= len(p)
+	return l.w.Write(p)
+}
+
+// sampleEvents returns events of eventType to check a payload template against: one
+// with every optional field set and one with none.
+func sampleEvents(eventType string) []*model.CouponEvent {
+	claimed := 1
+	events := []*model.CouponEvent{
+		{
+			ID:   "evt_sample",
+			Type: eventType,
+			Coupon: model.CouponSummary{
+				Name:      "SAMPLE",
+				Tags:      []string{"sample"},
+				Disabled:  true,
+				LowStock:  true,
+				Unlimited: true,
+				Claimed:   &claimed,
+				Status:    model.CouponStatusDisabled,
+			},
+		},
+		{
+			ID:     "evt_sample",
+			Type:   eventType,
+			Coupon: model.CouponSummary{Name: "SAMPLE", Amount: 10, RemainingAmount: 1, Tags: []string{}},
+		},
+	}
+	if eventType == model.CouponEventClaimed {
+		events[0].Claim = &model.ClaimReceipt{
+			UserID: "sample_user", CouponName: "SAMPLE", Channel: "app", Region: "eu", ClaimSequence: 1, Tier: "gold",
+		}
+		events[1].Claim = &model.ClaimReceipt{UserID: "sample_user", CouponName: "SAMPLE", ClaimSequence: 1}
+	}
+	return events
+}
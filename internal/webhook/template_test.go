@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate(`{
+		"kind": {{json (upper .type)}},
+		"item": {"code": {{json .coupon.name}}, "stock": {{.coupon.remaining_amount}}},
+		"labels": [{{range $i, $tag := .coupon.tags}}{{if $i}}, {{end}}{{json $tag}}{{end}}],
+		"paused": {{if .coupon.disabled}}true{{else}}false{{end}}
+	}`, []string{model.CouponEventCreated, model.CouponEventDisabled})
+	require.NoError(t, err)
+
+	ev := event()
+	ev.Coupon.Tags = []string{"bf", "vip"}
+	body, err := tmpl.Render(ev)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"kind": "COUPON.CREATED", "item": {"code": "PROMO", "stock": 10}, "labels": ["bf", "vip"], "paused": false}`, string(body))
+}
+
+func TestParseTemplate_Claimed(t *testing.T) {
+	src := `{"user": {{json .claim.user_id}}, "seq": {{.claim.claim_sequence}}, "tier": {{json .claim.tier}}}`
+	tmpl, err := ParseTemplate(src, []string{model.CouponEventClaimed})
+	require.NoError(t, err)
+
+	ev := event()
+	ev.Type = model.CouponEventClaimed
+	ev.Claim = &model.ClaimReceipt{UserID: "user_001", CouponName: "PROMO", ClaimSequence: 3}
+	body, err := tmpl.Render(ev)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user": "user_001", "seq": 3, "tier": null}`, string(body))
+
+	_, err = ParseTemplate(src, []string{model.CouponEventCreated})
+	assert.ErrorContains(t, err, "template did not render valid JSON")
+}
+
+func TestParseTemplate_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		src  string
+		want string
+	}{
+		"syntax":              {`{"a": {{.type}`, "bad character"},
+		"not json":            {`{"sku": {{.coupon.name}}}`, "template did not render valid JSON"},
+		"optional field":      {`{"claimed": {{.coupon.claimed}}}`, "template did not render valid JSON"},
+		"unknown function":    {`{{exec "ls"}}`, `function "exec" not defined`},
+		"define":              {`{{define "a"}}{}{{end}}{}`, "may not define other templates"},
+		"template call":       {`{{template "payload"}}`, "may not call other templates"},
+		"range over number":   {`{{range 1000000000}}{{end}}{}`, "range may only iterate over an event field"},
+		"range over variable": {`{{$n := 1000000000}}{{range $n}}{{end}}{}`, "range may only iterate over an event field"},
+		"deep ranges": {`{{range .coupon.tags}}{{range $.coupon.tags}}{{range $.coupon.tags}}{{end}}{{end}}{{end}}{}`,
+			"may not nest more than 2 deep"},
+		"too large": {`{"a": "{{range .coupon.tags}}` + strings.Repeat("x", MaxPayloadSize) + `{{end}}"}`, "rendered more than"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTemplate(tt.src, []string{model.CouponEventCreated})
+
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Package webhook delivers signed coupon lifecycle events to subscriber endpoints.
+//
+// Each delivery is a JSON POST carrying the event ID and type in the X-Webhook-Id and
+// X-Webhook-Event headers and a signature in X-Webhook-Signature:
+//
+//	t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed with the subscription secret>
+//
+// Receivers should recompute the signature, compare it in constant time and reject
+// stale timestamps to prevent replays. Deliveries are at least once, so receivers
+// should also deduplicate on X-Webhook-Id.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+)
+
+// Delivery headers.
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns the X-Webhook-Signature value for body sent at ts with secret.
+func Sign(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Stats is a snapshot of delivery counters.
+type Stats struct {
+	Delivered int64 `json:"delivered"`
+	Rejected  int64 `json:"rejected"` // Permanent 4xx answers and unrenderable payloads; not retried
+	Errors    int64 `json:"errors"`   // Unreachable endpoints and retryable answers
+}
+
+// Sender POSTs events to subscriber endpoints. It is safe for concurrent use.
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+
+	delivered atomic.Int64
+	rejected  atomic.Int64
+	errors    atomic.Int64
+}
+
+// NewSender returns a Sender giving up on an endpoint after timeout.
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{client: &http.Client{Timeout: timeout}, now: time.Now}
+}
+
+// Stats returns the current counters.
+func (s *Sender) Stats() Stats {
+	return Stats{Delivered: s.delivered.Load(), Rejected: s.rejected.Load(), Errors: s.errors.Load()}
+}
+
+// Send delivers event to the URL of sub, signed with its secret. The body is the event as
+// JSON, or rendered with the subscription's payload template if it has one. A 2xx answer
+// is a delivery. Other 4xx answers except 408 and 429 mean the endpoint will not accept
+// the event and are returned as jobs.Permanent errors, as are events the template cannot
+// render; everything else is worth retrying.
+func (s *Sender) Send(ctx context.Context, sub *model.WebhookSubscription, event *model.CouponEvent) error {
+	body, err := s.body(sub, event)
+	if err != nil {
+		s.rejected.Add(1)
+		return jobs.Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		s.rejected.Add(1)
+		return jobs.Permanent(fmt.Errorf("build webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, event.ID)
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, s.now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.errors.Add(1)
+		return fmt.Errorf("deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // Lets the connection be reused
+
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		s.delivered.Add(1)
+		return nil
+	case code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests:
+		s.rejected.Add(1)
+		return jobs.Permanent(fmt.Errorf("webhook endpoint answered %d", code))
+	default:
+		s.errors.Add(1)
+		return fmt.Errorf("webhook endpoint answered %d", code)
+	}
+}
+
+// body returns the delivery body of event for sub.
+func (s *Sender) body(sub *model.WebhookSubscription, event *model.CouponEvent) ([]byte, error) {
+	if sub.PayloadTemplate == "" {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("marshal event: %w", err)
+		}
+		return body, nil
+	}
+	tmpl, err := ParseTemplate(sub.PayloadTemplate, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parse payload template: %w", err)
+	}
+	body, err := tmpl.Render(event)
+	if err != nil {
+		return nil, fmt.Errorf("render payload template: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+)
+
+// endpoint answers every delivery with status and records the last one.
+func endpoint(t *testing.T, status int) (*httptest.Server, *http.Request, *[]byte) {
+	t.Helper()
+	var last http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = *r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &last, &body
+}
+
+func event() *model.CouponEvent {
+	return &model.CouponEvent{
+		ID:     "evt_1",
+		Type:   model.CouponEventCreated,
+		Coupon: model.CouponSummary{Name: "PROMO", Amount: 10, RemainingAmount: 10, Tags: []string{}},
+	}
+}
+
+func subscription(url string) *model.WebhookSubscription {
+	return &model.WebhookSubscription{ID: 1, URL: url, Secret: "whsec_0123456789abcdef"}
+}
+
+func TestSign(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+
+	sig := Sign("whsec_0123456789abcdef", ts, []byte(`{"id":"evt_1"}`))
+
+	assert.Equal(t, "t=1700000000,v1=dea1657bd5053cb0f8a75ebf0bd3d22e0cdeb79563b44db6b88864e522fb8bc4", sig)
+	assert.NotEqual(t, sig, Sign("whsec_another_secret_00", ts, []byte(`{"id":"evt_1"}`)))
+	assert.NotEqual(t, sig, Sign("whsec_0123456789abcdef", ts.Add(time.Second), []byte(`{"id":"evt_1"}`)))
+}
+
+func TestSender_Send(t *testing.T) {
+	srv, last, body := endpoint(t, http.StatusNoContent)
+	s := NewSender(time.Second)
+	s.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	require.NoError(t, s.Send(context.Background(), subscription(srv.URL), event()))
+
+	assert.Equal(t, http.MethodPost, last.Method)
+	assert.Equal(t, "evt_1", last.Header.Get(HeaderID))
+	assert.Equal(t, model.CouponEventCreated, last.Header.Get(HeaderEvent))
+	assert.Equal(t, Sign("whsec_0123456789abcdef", time.Unix(1700000000, 0), *body), last.Header.Get(HeaderSignature))
+	assert.JSONEq(t, `{"id":"evt_1","type":"coupon.created","occurred_at":"0001-01-01T00:00:00Z",
+		"coupon":{"name":"PROMO","amount":10,"remaining_amount":10,"tags":[]}}`, string(*body))
+	assert.Equal(t, Stats{Delivered: 1}, s.Stats())
+}
+
+func TestSender_Send_PayloadTemplate(t *testing.T) {
+	srv, _, body := endpoint(t, http.StatusOK)
+	s := NewSender(time.Second)
+	sub := subscription(srv.URL)
+	sub.PayloadTemplate = `{"event": {{json .type}}, "sku": {{json (lower .coupon.name)}}}`
+
+	require.NoError(t, s.Send(context.Background(), sub, event()))
+	assert.JSONEq(t, `{"event": "coupon.created", "sku": "promo"}`, string(*body))
+
+	sub.PayloadTemplate = `{"claimed": {{.coupon.claimed}}}` // Not JSON for limited coupons
+	err := s.Send(context.Background(), sub, event())
+
+	assert.True(t, jobs.IsPermanent(err), "unrenderable events are not retried")
+	assert.ErrorContains(t, err, "render payload template")
+	assert.Equal(t, Stats{Delivered: 1, Rejected: 1}, s.Stats())
+}
+
+func TestSender_Send_Failures(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusGone, true},
+		{http.StatusBadRequest, true},
+		{http.StatusRequestTimeout, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv, _, _ := endpoint(t, tt.status)
+
+			err := NewSender(time.Second).Send(context.Background(), subscription(srv.URL), event())
+
+			require.Error(t, err)
+			assert.Equal(t, tt.permanent, jobs.IsPermanent(err))
+		})
+	}
+}
+
+func TestSender_Send_Unreachable(t *testing.T) {
+	srv, _, _ := endpoint(t, http.StatusOK)
+	srv.Close()
+	s := NewSender(time.Second)
+
+	err := s.Send(context.Background(), subscription(srv.URL), event())
+
+	require.Error(t, err)
+	assert.False(t, jobs.IsPermanent(err), "unreachable endpoints are retried")
+	assert.Equal(t, Stats{Errors: 1}, s.Stats())
+}
@@ -0,0 +1,165 @@
+// Package client is the Go SDK for systems integrating with the coupon service.
+//
+// It currently covers webhook receivers: VerifyWebhookSignature checks the
+// X-Webhook-Signature header of a delivery, and ReadWebhookEvent verifies and decodes a
+// delivery request into a WebhookEvent:
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//		event, err := client.ReadWebhookEvent(r, secret)
+//		if err != nil {
+//			http.Error(w, err.Error(), http.StatusUnauthorized)
+//			return
+//		}
+//		// Deliveries are at least once: deduplicate on event.ID
+//	}
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook delivery headers.
+const (
+	HeaderWebhookID        = "X-Webhook-Id"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// Webhook event types.
+const (
+	EventCouponCreated  = "coupon.created"
+	EventCouponUpdated  = "coupon.updated" // Tags, stock top-ups or re-enabling
+	EventCouponDisabled = "coupon.disabled"
+	EventCouponLowStock = "coupon.low_stock" // A claim took stock to the coupon's low stock watermark
+	EventCouponClaimed  = "coupon.claimed"   // A user claimed the coupon; the event carries the claim
+)
+
+// DefaultWebhookTolerance is how far a delivery's signature timestamp may be from the
+// receiver's clock in ReadWebhookEvent. Older deliveries are rejected as replays.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// maxWebhookBody bounds the delivery bodies ReadWebhookEvent reads.
+const maxWebhookBody = 1 << 20
+
+var (
+	// ErrInvalidSignature is returned when a delivery's signature is missing, malformed
+	// or does not match its body and the subscription secret.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrSignatureExpired is returned when a delivery's signature timestamp is outside
+	// the tolerance, e.g. a replayed delivery.
+	ErrSignatureExpired = errors.New("webhook signature timestamp outside tolerance")
+)
+
+// WebhookCoupon is a coupon's state right after the change an event reports.
+type WebhookCoupon struct {
+	Name            string   `json:"name"`
+	Amount          int      `json:"amount"`
+	RemainingAmount int      `json:"remaining_amount"`
+	Tags            []string `json:"tags"`
+	Disabled        bool     `json:"disabled,omitempty"`
+	LowStock        bool     `json:"low_stock,omitempty"`
+	Unlimited       bool     `json:"unlimited,omitempty"` // Amount and RemainingAmount are 0
+	Claimed         int      `json:"claimed,omitempty"`   // Claims of an unlimited coupon
+	Status          string   `json:"status,omitempty"`    // active, exhausted or disabled
+}
+
+// WebhookClaim is the claim an EventCouponClaimed event reports.
+type WebhookClaim struct {
+	UserID        string `json:"user_id"`
+	CouponName    string `json:"coupon_name"`
+	Channel       string `json:"channel,omitempty"`
+	Region        string `json:"region,omitempty"`
+	ClaimSequence int    `json:"claim_sequence"` // 1-based claim order within the coupon
+	Tier          string `json:"tier,omitempty"`
+}
+
+// WebhookEvent is the body of a webhook delivery.
+type WebhookEvent struct {
+	ID         string        `json:"id"` // Unique per event; repeated on redelivery
+	Type       string        `json:"type"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	Coupon     WebhookCoupon `json:"coupon"`
+	Claim      *WebhookClaim `json:"claim,omitempty"` // Set for EventCouponClaimed only
+}
+
+// now is the receiver's clock, replaced in tests.
+var now = time.Now
+
+// VerifyWebhookSignature checks header, the X-Webhook-Signature of a delivery
+// ("t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">"), against body and the
+// subscription secret. The timestamp must be within tolerance of now; a tolerance of 0
+// skips that check. The comparison is constant time.
+// Returns ErrInvalidSignature or ErrSignatureExpired.
+func VerifyWebhookSignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var ts string
+	var signatures [][]byte
+	for part := range strings.SplitSeq(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	valid := false
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			valid = true
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		if age := now().Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return ErrSignatureExpired
+		}
+	}
+	return nil
+}
+
+// ReadWebhookEvent reads a delivery request, verifies its signature with secret and
+// DefaultWebhookTolerance, and decodes its event. Bodies over 1MB are rejected.
+func ReadWebhookEvent(r *http.Request, secret string) (*WebhookEvent, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	if err != nil {
+		return nil, fmt.Errorf("read webhook body: %w", err)
+	}
+	if len(body) > maxWebhookBody {
+		return nil, fmt.Errorf("webhook body exceeds %d bytes", maxWebhookBody)
+	}
+	if err := VerifyWebhookSignature(secret, r.Header.Get(HeaderWebhookSignature), body, DefaultWebhookTolerance); err != nil {
+		return nil, err
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("decode webhook event: %w", err)
+	}
+	return &event, nil
+}
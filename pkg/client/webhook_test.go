@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/webhook"
+)
+
+const testSecret = "whsec_0123456789abcdef"
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	sent := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"evt_1"}`)
+	valid := webhook.Sign(testSecret, sent, body)
+	_, validMAC, _ := strings.Cut(valid, ",")
+	t.Cleanup(func() { now = time.Now })
+
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		receiving time.Time
+		want      error
+	}{
+		{"valid", testSecret, valid, body, sent.Add(time.Minute), nil},
+		{"rotated secrets", testSecret, valid + ",v1=00ff", body, sent, nil},
+		{"wrong secret", "whsec_another_secret_00", valid, body, sent, ErrInvalidSignature},
+		{"tampered body", testSecret, valid, []byte(`{"id":"evt_2"}`), sent, ErrInvalidSignature},
+		{"tampered timestamp", testSecret, "t=1700000001," + validMAC, body, sent, ErrInvalidSignature},
+		{"missing", testSecret, "", body, sent, ErrInvalidSignature},
+		{"no signature", testSecret, "t=1700000000", body, sent, ErrInvalidSignature},
+		{"replayed", testSecret, valid, body, sent.Add(DefaultWebhookTolerance + time.Second), ErrSignatureExpired},
+		{"from the future", testSecret, valid, body, sent.Add(-DefaultWebhookTolerance - time.Second), ErrSignatureExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = func() time.Time { return tt.receiving }
+
+			err := VerifyWebhookSignature(tt.secret, tt.header, tt.body, DefaultWebhookTolerance)
+
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestVerifyWebhookSignature_ZeroToleranceSkipsAgeCheck(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+
+	err := VerifyWebhookSignature(testSecret, webhook.Sign(testSecret, time.Unix(1, 0), body), body, 0)
+
+	assert.NoError(t, err)
+}
+
+func TestReadWebhookEvent_DecodesDeliveries(t *testing.T) {
+	var event *WebhookEvent
+	var readErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event, readErr = ReadWebhookEvent(r, testSecret)
+		assert.Equal(t, EventCouponDisabled, r.Header.Get(HeaderWebhookEvent))
+		assert.Equal(t, "evt_1", r.Header.Get(HeaderWebhookID))
+	}))
+	t.Cleanup(srv.Close)
+
+	occurred := time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+	err := webhook.NewSender(time.Second).Send(context.Background(), &model.WebhookSubscription{URL: srv.URL, Secret: testSecret}, &model.CouponEvent{
+		ID:         "evt_1",
+		Type:       model.CouponEventDisabled,
+		OccurredAt: occurred,
+		Coupon:     model.CouponSummary{Name: "PROMO", Amount: 10, RemainingAmount: 4, Tags: []string{"bf"}, Disabled: true},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, readErr)
+	assert.Equal(t, &WebhookEvent{
+		ID:         "evt_1",
+		Type:       EventCouponDisabled,
+		OccurredAt: occurred,
+		Coupon:     WebhookCoupon{Name: "PROMO", Amount: 10, RemainingAmount: 4, Tags: []string{"bf"}, Disabled: true},
+	}, event)
+}
+
+func TestReadWebhookEvent_DecodesClaims(t *testing.T) {
+	var event *WebhookEvent
+	var readErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event, readErr = ReadWebhookEvent(r, testSecret)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := webhook.NewSender(time.Second).Send(context.Background(), &model.WebhookSubscription{URL: srv.URL, Secret: testSecret}, &model.CouponEvent{
+		ID:     "evt_2",
+		Type:   model.CouponEventClaimed,
+		Coupon: model.CouponSummary{Name: "PROMO", Amount: 10, RemainingAmount: 3, Tags: []string{}},
+		Claim:  &model.ClaimReceipt{UserID: "user_001", CouponName: "PROMO", Region: "eu", ClaimSequence: 7, Tier: "gold"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, readErr)
+	assert.Equal(t, EventCouponClaimed, event.Type)
+	assert.Equal(t, &WebhookClaim{UserID: "user_001", CouponName: "PROMO", Region: "eu", ClaimSequence: 7, Tier: "gold"}, event.Claim)
+	assert.Equal(t, 3, event.Coupon.RemainingAmount)
+}
+
+func TestReadWebhookEvent_RejectsUnsigned(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(`{"id":"evt_1"}`))
+
+	_, err := ReadWebhookEvent(req, testSecret)
+
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
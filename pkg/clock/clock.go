@@ -0,0 +1,178 @@
+// Package clock abstracts the wall clock for the code that schedules work or compares
+// against the current time (claim windows, retention cutoffs, purges, periodic passes),
+// so that its tests can freeze time and advance it at will with a Fake instead of
+// sleeping. Context deadlines are not covered: they always run on the real clock.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel receiving the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+	// NewTicker returns a Ticker ticking every d, which must be positive.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks at intervals, dropping ticks for slow receivers as time.Ticker does.
+type Ticker interface {
+	// C returns the channel the ticks are delivered on.
+	C() <-chan time.Time
+	// Stop turns the ticker off.
+	Stop()
+}
+
+// Real is the wall clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// Fake is a Clock standing still until it is moved with Set or Advance, which fire the
+// timers and tickers due by then. It is safe for concurrent use.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond // Broadcast when waiters changes
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a pending After or an active ticker.
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration // 0 for After
+	c      chan time.Time
+}
+
+// NewFake returns a Fake frozen at now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the time the clock was set to.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel receiving the time once the clock is moved d past now.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.add(d, 0).c
+}
+
+// NewTicker returns a Ticker ticking whenever the clock is moved past a multiple of d
+// from now; moving it several periods at once delivers one tick.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return &fakeTicker{f: f, w: f.add(d, d)}
+}
+
+func (f *Fake) add(d, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &fakeWaiter{at: f.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	f.fire()
+	f.changed.Broadcast()
+	return w
+}
+
+// Advance moves the clock d forward.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	f.fire()
+}
+
+// Set moves the clock to now, which may be in the past: timers do not fire again then.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+	f.fire()
+}
+
+// Waiters returns how many timers and tickers are pending.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until at least n timers and tickers are pending, e.g. until the
+// code under test waits on the clock before moving it.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.changed.Wait()
+	}
+}
+
+// fire delivers the timers and ticks due at f.now. The caller holds f.mu.
+func (f *Fake) fire() {
+	pending := f.waiters[:0]
+	fired := false
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			pending = append(pending, w)
+			continue
+		}
+		select {
+		case w.c <- f.now:
+		default: // A tick is still unread
+		}
+		if w.period == 0 {
+			fired = true
+			continue
+		}
+		for !w.at.After(f.now) {
+			w.at = w.at.Add(w.period)
+		}
+		pending = append(pending, w)
+	}
+	clear(f.waiters[len(pending):])
+	f.waiters = pending
+	if fired {
+		f.changed.Broadcast()
+	}
+}
+
+func (f *Fake) remove(w *fakeWaiter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.changed.Broadcast()
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	f *Fake
+	w *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.w.c }
+func (t *fakeTicker) Stop()               { t.f.remove(t.w) }
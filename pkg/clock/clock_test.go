@@ -0,0 +1,101 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// received returns the value waiting on c, or false if there is none.
+func received(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case at := <-c:
+		return at, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFake_Now(t *testing.T) {
+	c := NewFake(epoch)
+
+	assert.Equal(t, epoch, c.Now())
+	c.Advance(time.Minute)
+	assert.Equal(t, epoch.Add(time.Minute), c.Now())
+	c.Set(epoch.Add(-time.Hour))
+	assert.Equal(t, epoch.Add(-time.Hour), c.Now())
+}
+
+func TestFake_After(t *testing.T) {
+	c := NewFake(epoch)
+	after := c.After(time.Minute)
+
+	c.Advance(59 * time.Second)
+	_, ok := received(after)
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Waiters())
+
+	c.Advance(time.Second)
+	at, ok := received(after)
+	assert.True(t, ok)
+	assert.Equal(t, epoch.Add(time.Minute), at)
+	assert.Zero(t, c.Waiters(), "fired timers are no longer pending")
+
+	_, ok = received(c.After(0))
+	assert.True(t, ok, "timers already due fire at once")
+}
+
+func TestFake_Ticker(t *testing.T) {
+	c := NewFake(epoch)
+	ticker := c.NewTicker(time.Minute)
+
+	c.Advance(time.Minute)
+	at, ok := received(ticker.C())
+	assert.True(t, ok)
+	assert.Equal(t, epoch.Add(time.Minute), at)
+
+	c.Advance(3 * time.Minute)
+	_, ok = received(ticker.C())
+	assert.True(t, ok)
+	_, ok = received(ticker.C())
+	assert.False(t, ok, "missed ticks are dropped")
+
+	c.Advance(30 * time.Second)
+	_, ok = received(ticker.C())
+	assert.False(t, ok, "the next tick stays on the ticker's period")
+	c.Advance(30 * time.Second)
+	_, ok = received(ticker.C())
+	assert.True(t, ok)
+
+	ticker.Stop()
+	assert.Zero(t, c.Waiters())
+	c.Advance(time.Hour)
+	_, ok = received(ticker.C())
+	assert.False(t, ok)
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := NewFake(epoch)
+	done := make(chan struct{})
+	go func() {
+		<-c.After(time.Second)
+		close(done)
+	}()
+
+	c.BlockUntil(1) // Without it, Advance could run before After
+	c.Advance(time.Second)
+
+	<-done
+}
+
+func TestReal(t *testing.T) {
+	assert.WithinDuration(t, time.Now(), Real.Now(), time.Second)
+
+	ticker := Real.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	<-ticker.C()
+	<-Real.After(time.Millisecond)
+}
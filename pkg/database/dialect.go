@@ -0,0 +1,71 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// sqlStateSerializationFailure is returned when a transaction lost a conflict with a
+// concurrent one and must be retried from the start.
+const sqlStateSerializationFailure = "40001"
+
+// Dialect describes a supported database and how its transactions behave.
+// PostgreSQL wire-compatible dialects share the pgx repositories and their SQL; MySQL
+// and Memory have their own repositories (internal/repository/mysql and memory) behind
+// the same interfaces.
+type Dialect struct {
+	// Name is the DB_DRIVER value that selects the dialect.
+	Name string
+	// TxAttempts is how often a transaction failing with a serialization failure is run
+	// in total. 1 disables retries.
+	TxAttempts int
+}
+
+var (
+	// Postgres runs transactions once. Services use READ COMMITTED with row locks, which
+	// serializes claims without serialization failures.
+	Postgres = Dialect{Name: "postgres", TxAttempts: 1}
+
+	// CockroachDB always runs SERIALIZABLE. Contended transactions (e.g. claims on a hot
+	// coupon) fail with SQLSTATE 40001 and must be retried by the client; see
+	// https://www.cockroachlabs.com/docs/stable/transaction-retry-error-reference
+	CockroachDB = Dialect{Name: "cockroachdb", TxAttempts: 10}
+
+	// MySQL (and MariaDB) with InnoDB. Claims are serialized by row locks as on Postgres,
+	// but InnoDB resolves lock cycles (e.g. concurrent manifest applies) by rolling back
+	// one transaction with a deadlock error, which is safe to rerun.
+	MySQL = Dialect{Name: "mysql", TxAttempts: 3}
+
+	// Memory keeps the data in process memory (internal/repository/memory), for demos
+	// and tests without a database. Its transactions run one at a time, so they never
+	// conflict.
+	Memory = Dialect{Name: "memory", TxAttempts: 1}
+)
+
+// Dialects lists the supported dialects.
+var Dialects = []Dialect{Postgres, CockroachDB, MySQL, Memory}
+
+// DialectByName returns the dialect selected by a DB_DRIVER value.
+func DialectByName(name string) (Dialect, error) {
+	for _, d := range Dialects {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return Dialect{}, fmt.Errorf("unknown database driver %q", name)
+}
+
+// IsSerializationFailure reports whether err is a serialization failure (SQLSTATE 40001),
+// which MySQL also reports for deadlocks. The failed transaction had no effect and may
+// be retried from the start.
+func IsSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == sqlStateSerializationFailure
+	}
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && string(myErr.SQLState[:]) == sqlStateSerializationFailure
+}
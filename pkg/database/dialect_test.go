@@ -0,0 +1,51 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialectByName(t *testing.T) {
+	d, err := DialectByName("postgres")
+	require.NoError(t, err)
+	assert.Equal(t, Postgres, d)
+
+	d, err = DialectByName("cockroachdb")
+	require.NoError(t, err)
+	assert.Equal(t, CockroachDB, d)
+	assert.Greater(t, d.TxAttempts, 1, "CockroachDB requires client-side retries")
+
+	d, err = DialectByName("mysql")
+	require.NoError(t, err)
+	assert.Equal(t, MySQL, d)
+
+	d, err = DialectByName("memory")
+	require.NoError(t, err)
+	assert.Equal(t, Memory, d)
+
+	_, err = DialectByName("oracle")
+	assert.ErrorContains(t, err, `unknown database driver "oracle"`)
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	retryErr := &pgconn.PgError{Code: "40001", Message: "restart transaction"}
+
+	assert.True(t, IsSerializationFailure(retryErr))
+	assert.True(t, IsSerializationFailure(fmt.Errorf("decrement stock: %w", retryErr)))
+	assert.False(t, IsSerializationFailure(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsSerializationFailure(errors.New("40001")))
+	assert.False(t, IsSerializationFailure(nil))
+}
+
+func TestIsSerializationFailure_MySQL(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, SQLState: [5]byte{'4', '0', '0', '0', '1'}, Message: "Deadlock found"}
+
+	assert.True(t, IsSerializationFailure(fmt.Errorf("top up: %w", deadlock)))
+	assert.False(t, IsSerializationFailure(&mysql.MySQLError{Number: 1062, SQLState: [5]byte{'2', '3', '0', '0', '0'}}))
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// ListenerStats is a snapshot of a Listener's counters.
+type ListenerStats struct {
+	Connected     bool  `json:"connected"`
+	Notifications int64 `json:"notifications"` // Notifications received
+	Reconnects    int64 `json:"reconnects"`    // Connections lost and re-established
+}
+
+// Listener receives the notifications sent on one PostgreSQL channel with NOTIFY or
+// pg_notify. Notifications sent inside a transaction are delivered when it commits.
+// LISTEN needs a session of its own, so it does not work through a pooler in
+// transaction pooling mode, nor on CockroachDB and MySQL.
+type Listener struct {
+	pool    *pgxpool.Pool
+	channel string
+
+	connected     atomic.Bool
+	notifications atomic.Int64
+	reconnects    atomic.Int64
+}
+
+// NewListener creates a Listener for channel on connections of pool.
+func NewListener(pool *pgxpool.Pool, channel string) *Listener {
+	return &Listener{pool: pool, channel: channel}
+}
+
+// Stats returns the listener's counters.
+func (l *Listener) Stats() ListenerStats {
+	return ListenerStats{
+		Connected:     l.connected.Load(),
+		Notifications: l.notifications.Load(),
+		Reconnects:    l.reconnects.Load(),
+	}
+}
+
+// Run holds a connection of the pool listening on the channel until ctx is cancelled,
+// calling handle with the payload of each notification. A lost connection is replaced,
+// backing off 1s, 2s, 4s, ... up to 30s; notifications sent meanwhile are missed, so
+// callers must not rely on them alone. handle runs on Run's goroutine and should not
+// block.
+func (l *Listener) Run(ctx context.Context, handle func(payload string)) {
+	attempt := 0
+	for {
+		err := l.listen(ctx, handle, func() { attempt = 0 })
+		l.connected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		wait := reconnectBackoff(attempt)
+		attempt++
+		log.Warn().
+			Err(err).
+			Str("channel", l.channel).
+			Dur("next_retry_in", wait).
+			Msg("database listener disconnected, reconnecting")
+		l.reconnects.Add(1)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// listen runs one LISTEN session, calling connected once it is established, until its
+// connection fails or ctx is cancelled.
+func (l *Listener) listen(ctx context.Context, handle func(payload string), connected func()) error {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection may still be subscribed, so it is closed instead of reused
+	defer func() {
+		_ = conn.Conn().Close(context.WithoutCancel(ctx))
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen on %s: %w", l.channel, err)
+	}
+	l.connected.Store(true)
+	connected()
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		l.notifications.Add(1)
+		handle(n.Payload)
+	}
+}
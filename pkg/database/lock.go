@@ -0,0 +1,59 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// sqlStateLockNotAvailable is returned when a row lock could not be acquired without
+// waiting (NOWAIT) or within lock_timeout.
+const sqlStateLockNotAvailable = "55P03"
+
+// MySQL error numbers for a row lock that was not acquired: held by another
+// transaction under NOWAIT, or not released within the lock wait timeout (which
+// MariaDB also reports for NOWAIT).
+const (
+	mysqlErrLockNoWait      = 3572
+	mysqlErrLockWaitTimeout = 1205
+)
+
+// LockMode is how a transaction acquires a row lock held by another transaction.
+type LockMode string
+
+// Lock modes.
+const (
+	LockWait    LockMode = "wait"    // Wait until the lock is released
+	LockNoWait  LockMode = "nowait"  // Fail at once
+	LockTimeout LockMode = "timeout" // Wait up to LockPolicy.Timeout, set per transaction
+)
+
+// ParseLockMode returns the lock mode named s.
+func ParseLockMode(s string) (LockMode, error) {
+	switch m := LockMode(s); m {
+	case LockWait, LockNoWait, LockTimeout:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown lock mode %q", s)
+}
+
+// LockPolicy is how repositories acquire the row lock of a coupon. The zero value waits.
+type LockPolicy struct {
+	Mode    LockMode
+	Timeout time.Duration // For LockTimeout
+}
+
+// IsLockNotAvailable reports whether err is a row lock that was not acquired under a
+// NOWAIT or timeout LockPolicy. The statement had no effect; the transaction must be
+// rolled back, and may be retried once the lock holder is done.
+func IsLockNotAvailable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == sqlStateLockNotAvailable
+	}
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && (myErr.Number == mysqlErrLockNoWait || myErr.Number == mysqlErrLockWaitTimeout)
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLockMode(t *testing.T) {
+	for _, s := range []string{"wait", "nowait", "timeout"} {
+		m, err := ParseLockMode(s)
+		require.NoError(t, err)
+		assert.Equal(t, LockMode(s), m)
+	}
+
+	_, err := ParseLockMode("skip")
+	assert.EqualError(t, err, `unknown lock mode "skip"`)
+}
+
+func TestIsLockNotAvailable(t *testing.T) {
+	assert.True(t, IsLockNotAvailable(fmt.Errorf("lock coupon: %w", &pgconn.PgError{Code: "55P03"})))
+	assert.True(t, IsLockNotAvailable(&mysql.MySQLError{Number: 3572}))
+	assert.True(t, IsLockNotAvailable(&mysql.MySQLError{Number: 1205}))
+	assert.False(t, IsLockNotAvailable(&pgconn.PgError{Code: "40001"}))
+	assert.False(t, IsLockNotAvailable(&mysql.MySQLError{Number: 1213}))
+	assert.False(t, IsLockNotAvailable(errors.New("55P03")))
+	assert.False(t, IsLockNotAvailable(nil))
+}
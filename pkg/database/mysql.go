@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql" // Registers the "mysql" database/sql driver
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// mysqlErrDuplicateEntry is MySQL's error number for a unique key violation.
+const mysqlErrDuplicateEntry = 1062
+
+// NewMySQLPool opens a MySQL/MariaDB connection pool with the same retry logic as NewPool.
+// The DSN must set parseTime=true and clientFoundRows=true (see config.DBConfig.MySQLDSN).
+func NewMySQLPool(ctx context.Context, dsn string, maxConns, minConns, maxRetries int) (*sql.DB, error) {
+	return connectWithRetry(ctx, maxRetries, func(ctx context.Context) (*sql.DB, error) {
+		db, err := NewLazyMySQLPool(dsn, maxConns, minConns)
+		if err != nil {
+			return nil, err
+		}
+		if pingErr := db.PingContext(ctx); pingErr != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("ping failed: %w", pingErr)
+		}
+		return db, nil
+	})
+}
+
+// NewLazyMySQLPool is NewMySQLPool without connecting, like NewLazyPool: connections
+// are established on first use.
+func NewLazyMySQLPool(dsn string, maxConns, minConns int) (*sql.DB, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(maxConns)
+	db.SetMaxIdleConns(minConns)
+	return db, nil
+}
+
+// NewSQLTransactor creates a Transactor that begins READ COMMITTED transactions from db.
+// InnoDB defaults to REPEATABLE READ, where plain reads inside a transaction see a
+// snapshot taken before any row lock was acquired; READ COMMITTED lets reads issued
+// after a locking read see what the previous lock holder committed, as on PostgreSQL.
+func NewSQLTransactor(db *sql.DB, dialect Dialect) *Transactor {
+	begin := func(ctx context.Context) (transaction, error) {
+		tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+		return sqlTx{sqlQuerier{tx}, tx}, nil
+	}
+	return &Transactor{begin: begin, dialect: dialect, backoff: retryBackoff}
+}
+
+// IsDuplicateEntry reports whether err is a MySQL unique key violation.
+func IsDuplicateEntry(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == mysqlErrDuplicateEntry
+}
+
+// SQLExecutor is implemented by *sql.DB and *sql.Tx.
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SQLQuerier adapts a database/sql handle to TxQuerier, so repositories for database/sql
+// drivers can be used with the same service layer and Transactor as the pgx ones.
+// Rows are scanned with database/sql semantics: JSON and array columns must be scanned
+// into []byte. pgx-only row metadata (field descriptions, raw values, Conn) is empty.
+func SQLQuerier(q SQLExecutor) TxQuerier {
+	return sqlQuerier{q}
+}
+
+type sqlQuerier struct {
+	q SQLExecutor
+}
+
+func (s sqlQuerier) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	res, err := s.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return pgconn.NewCommandTag(fmt.Sprintf("EXEC %d", n)), nil
+}
+
+func (s sqlQuerier) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	return sqlRow{s.q.QueryRowContext(ctx, query, args...)}
+}
+
+func (s sqlQuerier) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlRows{rows: rows}, nil
+}
+
+// sqlTx is a database/sql transaction adapted to the transaction interface.
+type sqlTx struct {
+	sqlQuerier
+	tx *sql.Tx
+}
+
+func (t sqlTx) Commit(ctx context.Context) error { return t.tx.Commit() }
+
+// Rollback is a no-op after Commit, as with pgx.
+func (t sqlTx) Rollback(ctx context.Context) error {
+	if err := t.tx.Rollback(); !errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	return nil
+}
+
+// sqlRow maps sql.ErrNoRows to pgx.ErrNoRows, which repositories check for.
+type sqlRow struct {
+	row *sql.Row
+}
+
+func (r sqlRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+	return err
+}
+
+type sqlRows struct {
+	rows *sql.Rows
+}
+
+func (r *sqlRows) Close()                                       { _ = r.rows.Close() }
+func (r *sqlRows) Err() error                                   { return r.rows.Err() }
+func (r *sqlRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *sqlRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *sqlRows) Next() bool                                   { return r.rows.Next() }
+func (r *sqlRows) Scan(dest ...any) error                       { return r.rows.Scan(dest...) }
+func (r *sqlRows) RawValues() [][]byte                          { return nil }
+func (r *sqlRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *sqlRows) Values() ([]any, error) {
+	cols, err := r.rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := r.rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
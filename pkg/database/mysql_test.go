@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver is a minimal database/sql driver: Exec reports rowsAffected, Query returns
+// rows (none when empty) and transactions record whether they were committed.
+type fakeDriver struct {
+	mu           sync.Mutex
+	rowsAffected int64
+	rows         [][]driver.Value
+	begins       int
+	isolation    sql.IsolationLevel
+	commits      int
+	rollbacks    int
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.begins++
+	c.d.isolation = sql.IsolationLevel(opts.Isolation)
+	return fakeSQLTx{c.d}, nil
+}
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(c.d.rowsAffected), nil
+}
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{rows: c.d.rows}, nil
+}
+
+type fakeSQLTx struct{ d *fakeDriver }
+
+func (t fakeSQLTx) Commit() error {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+	t.d.commits++
+	return nil
+}
+
+func (t fakeSQLTx) Rollback() error {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+	t.d.rollbacks++
+	return nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+	i    int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"name", "amount"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
+
+func openFakeDB(t *testing.T, d *fakeDriver) *sql.DB {
+	t.Helper()
+	name := "fake-" + t.Name()
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestSQLQuerier_Exec(t *testing.T) {
+	db := openFakeDB(t, &fakeDriver{rowsAffected: 3})
+
+	tag, err := SQLQuerier(db).Exec(context.Background(), "UPDATE claims SET user_id = ?", "x")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), tag.RowsAffected())
+}
+
+func TestSQLQuerier_QueryRowNoRows(t *testing.T) {
+	db := openFakeDB(t, &fakeDriver{})
+
+	var name string
+	err := SQLQuerier(db).QueryRow(context.Background(), "SELECT name FROM coupons").Scan(&name)
+
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "repositories check for pgx.ErrNoRows")
+}
+
+func TestSQLQuerier_Query(t *testing.T) {
+	db := openFakeDB(t, &fakeDriver{rows: [][]driver.Value{{"A", int64(1)}, {"B", int64(2)}}})
+
+	rows, err := SQLQuerier(db).Query(context.Background(), "SELECT name, amount FROM coupons")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		var amount int
+		require.NoError(t, rows.Scan(&name, &amount))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"A", "B"}, names)
+}
+
+func TestSQLTransactor_CommitsAndRetriesDeadlocks(t *testing.T) {
+	d := &fakeDriver{}
+	tr := NewSQLTransactor(openFakeDB(t, d), MySQL)
+	tr.backoff = func(int) time.Duration { return 0 }
+
+	deadlock := &mysql.MySQLError{Number: 1213, SQLState: [5]byte{'4', '0', '0', '0', '1'}}
+	calls := 0
+	err := tr.InTx(context.Background(), func(tx TxQuerier) error {
+		calls++
+		if calls == 1 {
+			return deadlock
+		}
+		_, err := tx.Exec(context.Background(), "UPDATE coupons SET disabled = ?", true)
+		return err
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 2, d.begins)
+	assert.Equal(t, sql.LevelReadCommitted, d.isolation)
+	assert.Equal(t, 1, d.commits)
+	assert.Equal(t, 1, d.rollbacks, "Rollback after Commit must not reach the driver")
+}
+
+func TestIsDuplicateEntry(t *testing.T) {
+	assert.True(t, IsDuplicateEntry(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, IsDuplicateEntry(&mysql.MySQLError{Number: 1213}))
+	assert.False(t, IsDuplicateEntry(errors.New("Duplicate entry")))
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -19,11 +20,88 @@ type TxQuerier interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 }
 
+// IsConnectError reports whether err is a failure to connect to the database.
+// Such errors happen before any statement is sent, so the operation had no effect
+// and can safely be retried later.
+func IsConnectError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	return errors.As(err, &connectErr)
+}
+
 // NewPool creates a PostgreSQL connection pool with retry logic.
 // Retries with exponential backoff: 1s, 2s, 4s, 8s, 16s (total ~31s before failure).
 func NewPool(ctx context.Context, dsn string, maxRetries int) (*pgxpool.Pool, error) {
-	var pool *pgxpool.Pool
-	var err error
+	return connectWithRetry(ctx, maxRetries, func(ctx context.Context) (*pgxpool.Pool, error) {
+		pool, err := pgxpool.New(ctx, dsn)
+		if err != nil {
+			return nil, err
+		}
+		// Verify connection actually works
+		if pingErr := pool.Ping(ctx); pingErr != nil {
+			pool.Close()
+			return nil, fmt.Errorf("ping failed: %w", pingErr)
+		}
+		return pool, nil
+	})
+}
+
+// NewLazyPool creates a PostgreSQL connection pool without connecting: connections are
+// established on first use, so it succeeds while the database is down and only fails
+// on an invalid dsn. See AwaitConnection.
+func NewLazyPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	return pgxpool.New(ctx, dsn)
+}
+
+// maxReconnectBackoff caps the wait between AwaitConnection attempts.
+const maxReconnectBackoff = 30 * time.Second
+
+// reconnectBackoff returns the wait after a failed AwaitConnection attempt: 1s, 2s,
+// 4s, ... capped at maxReconnectBackoff.
+func reconnectBackoff(attempt int) time.Duration {
+	if attempt >= 5 { // 32s
+		return maxReconnectBackoff
+	}
+	return time.Duration(1<<attempt) * time.Second
+}
+
+// AwaitConnection calls ping until it succeeds, backing off exponentially (1s, 2s, 4s,
+// ... up to 30s) between attempts, and logs when the database becomes reachable. It is
+// the background counterpart of NewPool's retries for lazily created pools. Returns
+// ctx.Err() if ctx is cancelled first.
+func AwaitConnection(ctx context.Context, ping func(ctx context.Context) error) error {
+	return awaitConnection(ctx, ping, reconnectBackoff)
+}
+
+func awaitConnection(ctx context.Context, ping func(ctx context.Context) error, backoff func(attempt int) time.Duration) error {
+	for attempt := 0; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			log.Info().Int("attempts", attempt+1).Msg("database connection established")
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		wait := backoff(attempt)
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt+1).
+			Dur("next_retry_in", wait).
+			Msg("database unreachable, retrying in the background")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// connectWithRetry calls connect until it succeeds, backing off exponentially
+// (1s, 2s, 4s, ...) between at most maxRetries attempts.
+func connectWithRetry[T any](ctx context.Context, maxRetries int, connect func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
 
 	// Ensure at least one attempt even if maxRetries is 0
 	attempts := maxRetries
@@ -31,17 +109,13 @@ func NewPool(ctx context.Context, dsn string, maxRetries int) (*pgxpool.Pool, er
 		attempts = 1
 	}
 
+	var err error
 	for attempt := 0; attempt < attempts; attempt++ {
-		pool, err = pgxpool.New(ctx, dsn)
+		var conn T
+		conn, err = connect(ctx)
 		if err == nil {
-			// Verify connection actually works
-			if pingErr := pool.Ping(ctx); pingErr == nil {
-				log.Info().Msg("database connection established")
-				return pool, nil
-			} else {
-				pool.Close()
-				err = fmt.Errorf("ping failed: %w", pingErr)
-			}
+			log.Info().Msg("database connection established")
+			return conn, nil
 		}
 
 		backoff := time.Duration(1<<attempt) * time.Second
@@ -54,10 +128,10 @@ func NewPool(ctx context.Context, dsn string, maxRetries int) (*pgxpool.Pool, er
 
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return zero, ctx.Err()
 		case <-time.After(backoff):
 		}
 	}
 
-	return nil, fmt.Errorf("failed to connect after %d attempts: %w", attempts, err)
+	return zero, fmt.Errorf("failed to connect after %d attempts: %w", attempts, err)
 }
@@ -2,9 +2,12 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -67,3 +70,63 @@ func TestNewPool_ValidConnection(t *testing.T) {
 	err = pool.Ping(ctx)
 	assert.NoError(t, err)
 }
+
+func TestIsConnectError(t *testing.T) {
+	// Nothing listens on port 1, so connecting fails before any statement is sent
+	_, err := pgconn.Connect(context.Background(), "postgres://user@127.0.0.1:1/db?connect_timeout=1")
+	require.Error(t, err)
+
+	assert.True(t, IsConnectError(err))
+	assert.True(t, IsConnectError(fmt.Errorf("begin tx: %w", err)), "wrapped errors are recognized")
+	assert.False(t, IsConnectError(errors.New("deadlock detected")))
+	assert.False(t, IsConnectError(nil))
+}
+
+func TestNewLazyPool_DatabaseDown(t *testing.T) {
+	// Nothing listens on port 1, yet the pool is created
+	pool, err := NewLazyPool(context.Background(), "postgres://user@127.0.0.1:1/db?connect_timeout=1&pool_min_conns=0")
+	require.NoError(t, err)
+	defer pool.Close()
+
+	assert.True(t, IsConnectError(pool.Ping(context.Background())), "connections are attempted on use")
+}
+
+func TestNewLazyPool_InvalidDSN(t *testing.T) {
+	_, err := NewLazyPool(context.Background(), "postgres://user@127.0.0.1:1/db?pool_max_conns=zero")
+	assert.Error(t, err)
+}
+
+func TestAwaitConnection(t *testing.T) {
+	var pings int
+	ping := func(ctx context.Context) error {
+		pings++
+		if pings < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	err := awaitConnection(context.Background(), ping, func(int) time.Duration { return time.Millisecond })
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, pings)
+}
+
+func TestAwaitConnection_ContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ping := func(ctx context.Context) error {
+		cancel()
+		return errors.New("connection refused")
+	}
+
+	err := awaitConnection(ctx, ping, func(int) time.Duration { return time.Hour })
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, reconnectBackoff(0))
+	assert.Equal(t, 16*time.Second, reconnectBackoff(4))
+	assert.Equal(t, maxReconnectBackoff, reconnectBackoff(5))
+	assert.Equal(t, maxReconnectBackoff, reconnectBackoff(100), "large attempts do not overflow")
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MigrationPhase is how far the rollout of a column rename has progressed. Renames are
+// rolled out expand/contract style, one phase per deploy, so that replicas running
+// adjacent phases can share the database:
+//
+//  1. old: only the old column is used (the new one may not exist yet).
+//  2. dual_write: the new column was added; writes fill both, reads use the old one.
+//     Backfill the new column once every replica is in this phase.
+//  3. read_new: writes fill both, reads prefer the new column (falling back to the
+//     old one for rows the backfill has not reached).
+//  4. new: only the new column is used; once every replica is here, drop the old one.
+type MigrationPhase string
+
+// Migration phases, in rollout order.
+const (
+	PhaseOld       MigrationPhase = "old"
+	PhaseDualWrite MigrationPhase = "dual_write"
+	PhaseReadNew   MigrationPhase = "read_new"
+	PhaseNew       MigrationPhase = "new"
+)
+
+// ParseMigrationPhase returns the phase named s.
+func ParseMigrationPhase(s string) (MigrationPhase, error) {
+	switch p := MigrationPhase(s); p {
+	case PhaseOld, PhaseDualWrite, PhaseReadNew, PhaseNew:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown migration phase %q", s)
+}
+
+// Rename is a column rename and the phase its rollout is in. Repositories build the
+// SQL touching the column from ReadExpr and WriteColumns instead of naming it directly.
+type Rename struct {
+	Table string
+	Old   string
+	New   string
+	Phase MigrationPhase
+}
+
+// Name identifies the rename in DB_MIGRATION_PHASES, e.g. "claims.claimed_at".
+func (r Rename) Name() string {
+	return r.Table + "." + r.New
+}
+
+// ReadExpr returns the SQL expression that reads the column.
+func (r Rename) ReadExpr() string {
+	switch r.Phase {
+	case PhaseReadNew:
+		return "COALESCE(" + r.New + ", " + r.Old + ")"
+	case PhaseNew:
+		return r.New
+	default:
+		return r.Old
+	}
+}
+
+// WriteColumns returns the columns a write must set to the same value.
+func (r Rename) WriteColumns() []string {
+	switch r.Phase {
+	case PhaseDualWrite, PhaseReadNew:
+		return []string{r.Old, r.New}
+	case PhaseNew:
+		return []string{r.New}
+	default:
+		return []string{r.Old}
+	}
+}
+
+// ClaimsClaimedAt renames claims.created_at to claimed_at, matching the API field.
+// Migration scripts: scripts/migrations/claims_claimed_at*.sql.
+var ClaimsClaimedAt = Rename{Table: "claims", Old: "created_at", New: "claimed_at", Phase: PhaseOld}
+
+// Renames lists the column renames the repositories support, in their default phase.
+var Renames = []Rename{ClaimsClaimedAt}
+
+// RenamesWithPhases returns Renames keyed by name, with the phases given in phases
+// (keyed by rename name) applied. Renames missing from phases keep their default.
+// Names of table moves are skipped; see TableMovesWithPhases.
+func RenamesWithPhases(phases map[string]string) (map[string]Rename, error) {
+	renames := make(map[string]Rename, len(Renames))
+	for _, r := range Renames {
+		renames[r.Name()] = r
+	}
+
+	names := make([]string, 0, len(phases))
+	for name := range phases {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Deterministic errors
+
+	for _, name := range names {
+		if isTableMove(name) {
+			continue
+		}
+		r, ok := renames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column rename %q", name)
+		}
+		phase, err := ParseMigrationPhase(phases[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		r.Phase = phase
+		renames[name] = r
+	}
+	return renames, nil
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRename_Phases(t *testing.T) {
+	tests := []struct {
+		phase  MigrationPhase
+		read   string
+		writes []string
+	}{
+		{PhaseOld, "created_at", []string{"created_at"}},
+		{PhaseDualWrite, "created_at", []string{"created_at", "claimed_at"}},
+		{PhaseReadNew, "COALESCE(claimed_at, created_at)", []string{"created_at", "claimed_at"}},
+		{PhaseNew, "claimed_at", []string{"claimed_at"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			r := ClaimsClaimedAt
+			r.Phase = tt.phase
+
+			assert.Equal(t, tt.read, r.ReadExpr())
+			assert.Equal(t, tt.writes, r.WriteColumns())
+		})
+	}
+}
+
+func TestRenamesWithPhases(t *testing.T) {
+	renames, err := RenamesWithPhases(nil)
+	require.NoError(t, err)
+	assert.Equal(t, PhaseOld, renames["claims.claimed_at"].Phase)
+
+	renames, err = RenamesWithPhases(map[string]string{"claims.claimed_at": "read_new"})
+	require.NoError(t, err)
+	assert.Equal(t, PhaseReadNew, renames["claims.claimed_at"].Phase)
+	assert.Equal(t, PhaseOld, ClaimsClaimedAt.Phase, "the registry is not modified")
+}
+
+func TestRenamesWithPhases_Invalid(t *testing.T) {
+	_, err := RenamesWithPhases(map[string]string{"claims.claimed_at": "halfway"})
+	assert.EqualError(t, err, `claims.claimed_at: unknown migration phase "halfway"`)
+
+	_, err = RenamesWithPhases(map[string]string{"coupons.quantity": "new"})
+	assert.EqualError(t, err, `unknown column rename "coupons.quantity"`)
+}
+
+func TestRenamesWithPhases_SkipsTableMoves(t *testing.T) {
+	renames, err := RenamesWithPhases(map[string]string{"claims_v2": "dual_write"})
+	require.NoError(t, err)
+	assert.Len(t, renames, len(Renames))
+}
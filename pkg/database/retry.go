@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+)
+
+// ReadRetryStats is a snapshot of ReadRetrier counters.
+type ReadRetryStats struct {
+	Retries   int64 `json:"retries"`   // Reads retried after losing their connection
+	Recovered int64 `json:"recovered"` // Retries that succeeded
+}
+
+// ReadRetrier retries read-only queries once when they fail because their connection
+// was lost, e.g. a pooled connection the server closed while it was idle. Rerunning a
+// read has no effect, so the retry is safe; the pool discards the broken connection,
+// so the retry gets another one. A nil *ReadRetrier runs queries once.
+type ReadRetrier struct {
+	retries   atomic.Int64
+	recovered atomic.Int64
+}
+
+// NewReadRetrier creates a ReadRetrier.
+func NewReadRetrier() *ReadRetrier {
+	return &ReadRetrier{}
+}
+
+// Run runs the read fn, and runs it once more if it fails with a lost connection
+// (see IsConnectionLost) while ctx is still live.
+func (r *ReadRetrier) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	if r == nil || err == nil || ctx.Err() != nil || !IsConnectionLost(err) {
+		return err
+	}
+
+	r.retries.Add(1)
+	log.Warn().Err(err).Msg("read lost its database connection, retrying once")
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	r.recovered.Add(1)
+	return nil
+}
+
+// Stats returns the retry counters.
+func (r *ReadRetrier) Stats() ReadRetryStats {
+	if r == nil {
+		return ReadRetryStats{}
+	}
+	return ReadRetryStats{Retries: r.retries.Load(), Recovered: r.recovered.Load()}
+}
+
+// IsConnectionLost reports whether err means the connection a statement ran on failed,
+// rather than the statement: connecting failed, the server terminated the session
+// (SQLSTATE class 57P), or the connection was reset or closed mid-statement.
+func IsConnectionLost(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if IsConnectError(err) || pgconn.SafeToRetry(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "57P01", "57P02", "57P03": // admin_shutdown, crash_shutdown, cannot_connect_now
+			return true
+		}
+		return false
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, net.ErrClosed)
+}
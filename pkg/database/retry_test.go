@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsConnectionLost(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"terminated by administrator", &pgconn.PgError{Code: "57P01"}, true},
+		{"server restarting", &pgconn.PgError{Code: "57P03"}, true},
+		{"unexpected eof", fmt.Errorf("get coupon: %w", io.ErrUnexpectedEOF), true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"broken pipe", syscall.EPIPE, true},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"cancelled", context.Canceled, false},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{"other", errors.New("no rows"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsConnectionLost(tt.err))
+		})
+	}
+}
+
+func TestReadRetrier_RetriesOnce(t *testing.T) {
+	r := NewReadRetrier()
+	var calls int
+	err := r.Run(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return &pgconn.PgError{Code: "57P01"}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, ReadRetryStats{Retries: 1, Recovered: 1}, r.Stats())
+}
+
+func TestReadRetrier_GivesUpAfterOneRetry(t *testing.T) {
+	r := NewReadRetrier()
+	var calls int
+	err := r.Run(context.Background(), func(ctx context.Context) error {
+		calls++
+		return io.ErrUnexpectedEOF
+	})
+
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, ReadRetryStats{Retries: 1}, r.Stats())
+}
+
+func TestReadRetrier_OtherErrorsNotRetried(t *testing.T) {
+	r := NewReadRetrier()
+	var calls int
+	queryErr := &pgconn.PgError{Code: "42P01"}
+	err := r.Run(context.Background(), func(ctx context.Context) error {
+		calls++
+		return queryErr
+	})
+
+	assert.ErrorIs(t, err, queryErr)
+	assert.Equal(t, 1, calls)
+	assert.Zero(t, r.Stats().Retries)
+}
+
+func TestReadRetrier_Nil(t *testing.T) {
+	var r *ReadRetrier
+	var calls int
+	err := r.Run(context.Background(), func(ctx context.Context) error {
+		calls++
+		return io.ErrUnexpectedEOF
+	})
+
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, 1, calls, "a nil retrier runs reads once")
+	assert.Equal(t, ReadRetryStats{}, r.Stats())
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Statements timed by StatementMetrics, named as in the db_statements metrics.
+const (
+	StatementInsertCoupon   = "insert_coupon"   // Insert a coupon with its channel and region quotas
+	StatementGetForUpdate   = "get_for_update"  // Lock and read a coupon for a claim or an update
+	StatementInsertClaim    = "insert_claim"    // Insert a claim
+	StatementDecrementStock = "decrement_stock" // Decrement a coupon's remaining stock
+)
+
+// Statements lists the statements StatementMetrics times.
+var Statements = []string{StatementInsertCoupon, StatementGetForUpdate, StatementInsertClaim, StatementDecrementStock}
+
+// latencyBuckets are the upper bounds of the statement latency histogram buckets; the
+// last bucket, +Inf, counts the rest.
+var latencyBuckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond,
+}
+
+// LatencyBucket is a cumulative histogram bucket: how many statements ran in at most Le.
+type LatencyBucket struct {
+	Le    string `json:"le"` // Upper bound, e.g. "5ms", or "+Inf"
+	Count int64  `json:"count"`
+}
+
+// StatementStats is a snapshot of one statement's counters.
+type StatementStats struct {
+	Count   int64           `json:"count"`
+	Errors  int64           `json:"errors"` // Runs that failed, including constraint violations
+	TotalMs float64         `json:"total_ms"`
+	Latency []LatencyBucket `json:"latency"`
+}
+
+// StatementMetrics counts the runs, failures and latencies of the statements in
+// Statements, so that the one slowing down under load can be told apart. A nil
+// *StatementMetrics runs statements untimed. It is safe for concurrent use.
+type StatementMetrics struct {
+	statements map[string]*statementCounters // Not modified after NewStatementMetrics
+}
+
+// statementCounters holds the counters of one statement.
+type statementCounters struct {
+	count   atomic.Int64
+	errors  atomic.Int64
+	totalNs atomic.Int64
+	buckets []atomic.Int64 // Per latencyBuckets, plus +Inf; not cumulative
+}
+
+// NewStatementMetrics creates a StatementMetrics for the statements in Statements.
+func NewStatementMetrics() *StatementMetrics {
+	m := &StatementMetrics{statements: make(map[string]*statementCounters, len(Statements))}
+	for _, name := range Statements {
+		m.statements[name] = &statementCounters{buckets: make([]atomic.Int64, len(latencyBuckets)+1)}
+	}
+	return m
+}
+
+// Run runs fn, recording its latency and whether it failed under statement (one of
+// Statements), and returns its error.
+func (m *StatementMetrics) Run(statement string, fn func() error) error {
+	if m == nil {
+		return fn()
+	}
+	counters, ok := m.statements[statement]
+	if !ok {
+		return fn()
+	}
+
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+
+	counters.count.Add(1)
+	if err != nil {
+		counters.errors.Add(1)
+	}
+	counters.totalNs.Add(int64(elapsed))
+	bucket := len(latencyBuckets)
+	for i, le := range latencyBuckets {
+		if elapsed <= le {
+			bucket = i
+			break
+		}
+	}
+	counters.buckets[bucket].Add(1)
+	return err
+}
+
+// Stats returns the counters of every statement.
+func (m *StatementMetrics) Stats() map[string]StatementStats {
+	if m == nil {
+		return map[string]StatementStats{}
+	}
+	stats := make(map[string]StatementStats, len(m.statements))
+	for name, counters := range m.statements {
+		latency := make([]LatencyBucket, 0, len(counters.buckets))
+		var cumulative int64
+		for i := range counters.buckets {
+			cumulative += counters.buckets[i].Load()
+			le := "+Inf"
+			if i < len(latencyBuckets) {
+				le = latencyBuckets[i].String()
+			}
+			latency = append(latency, LatencyBucket{Le: le, Count: cumulative})
+		}
+		stats[name] = StatementStats{
+			Count:   counters.count.Load(),
+			Errors:  counters.errors.Load(),
+			TotalMs: float64(counters.totalNs.Load()) / float64(time.Millisecond),
+			Latency: latency,
+		}
+	}
+	return stats
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementMetrics_Run(t *testing.T) {
+	m := NewStatementMetrics()
+	dbErr := errors.New("connection reset")
+
+	require.NoError(t, m.Run(StatementInsertClaim, func() error { return nil }))
+	assert.ErrorIs(t, m.Run(StatementInsertClaim, func() error { return dbErr }), dbErr)
+	require.NoError(t, m.Run(StatementInsertClaim, func() error {
+		time.Sleep(3 * time.Millisecond)
+		return nil
+	}))
+
+	stats := m.Stats()
+	require.Len(t, stats, len(Statements))
+	claim := stats[StatementInsertClaim]
+	assert.Equal(t, int64(3), claim.Count)
+	assert.Equal(t, int64(1), claim.Errors)
+	assert.GreaterOrEqual(t, claim.TotalMs, 3.0)
+	require.Len(t, claim.Latency, len(latencyBuckets)+1)
+	assert.Equal(t, LatencyBucket{Le: "2ms", Count: 2}, claim.Latency[1], "the slow run is over 2ms")
+	assert.Equal(t, LatencyBucket{Le: "+Inf", Count: 3}, claim.Latency[len(latencyBuckets)])
+	assert.Zero(t, stats[StatementDecrementStock].Count)
+}
+
+func TestStatementMetrics_Untracked(t *testing.T) {
+	var m *StatementMetrics
+	calls := 0
+
+	require.NoError(t, m.Run(StatementInsertClaim, func() error { calls++; return nil }))
+	require.NoError(t, NewStatementMetrics().Run("vacuum", func() error { calls++; return nil }))
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, m.Stats())
+}
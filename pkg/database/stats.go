@@ -0,0 +1,66 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolStats is a snapshot of a connection pool's usage.
+type PoolStats struct {
+	MaxConns      int32 `json:"max_conns"`
+	TotalConns    int32 `json:"total_conns"`
+	IdleConns     int32 `json:"idle_conns"`
+	AcquiredConns int32 `json:"acquired_conns"` // Connections in use
+	WaitCount     int64 `json:"wait_count"`     // Acquires that had to wait for a free connection
+	WaitMillis    int64 `json:"wait_ms"`        // Total time those acquires waited
+}
+
+// DataStats are sanity figures of the stored data, cheap enough for a deployment smoke
+// check to read on every call.
+type DataStats struct {
+	Coupons     int64      `json:"coupons"`       // Coupons not deleted
+	LastClaimAt *time.Time `json:"last_claim_at"` // Latest claim of any coupon; nil before the first
+}
+
+// ServerIdentity identifies the database server a pool's connections reach, so that a
+// failover (another server answering at the configured host) or a restart of the same
+// server can be told from a latency blip.
+type ServerIdentity struct {
+	Addr      string    `json:"addr"`       // Server address and port as the server reports them; hostname on MySQL
+	StartedAt time.Time `json:"started_at"` // When the server process started; to the second on MySQL
+}
+
+// ServerLoad is how busy the database server is, read before heavy background jobs run
+// so they can stand back while it is under pressure.
+type ServerLoad struct {
+	ReplicationLag time.Duration // Replay lag of the slowest replica; zero without replicas
+	ActiveConns    int64         // Client connections running a statement
+}
+
+// PgxPoolStats returns the usage of a pgx pool.
+func PgxPoolStats(pool *pgxpool.Pool) PoolStats {
+	s := pool.Stat()
+	return PoolStats{
+		MaxConns:      s.MaxConns(),
+		TotalConns:    s.TotalConns(),
+		IdleConns:     s.IdleConns(),
+		AcquiredConns: s.AcquiredConns(),
+		WaitCount:     s.EmptyAcquireCount(),
+		WaitMillis:    s.EmptyAcquireWaitTime().Milliseconds(),
+	}
+}
+
+// SQLPoolStats returns the usage of a database/sql pool.
+func SQLPoolStats(db *sql.DB) PoolStats {
+	s := db.Stats()
+	return PoolStats{
+		MaxConns:      int32(s.MaxOpenConnections),
+		TotalConns:    int32(s.OpenConnections),
+		IdleConns:     int32(s.Idle),
+		AcquiredConns: int32(s.InUse),
+		WaitCount:     s.WaitCount,
+		WaitMillis:    s.WaitDuration.Milliseconds(),
+	}
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TableMove is the move of a table's rows to a new table with a different layout (keys,
+// partitioning) and the phase its rollout is in. It goes through the same phases as a
+// column rename, with tables in place of columns:
+//
+//  1. old: only the old table is used (the new one may not exist yet).
+//  2. dual_write: the new table was created; every write goes to both in one
+//     transaction, reads use the old one. Backfill the new table once every replica
+//     is in this phase, then verify the two hold the same rows.
+//  3. read_new: the cutover. Writes still go to both, so replicas can move back to
+//     dual_write; reads use the new table, which must be fully backfilled.
+//  4. new: only the new table is used; once every replica is here, drop the old one.
+type TableMove struct {
+	Old   string
+	New   string
+	Phase MigrationPhase
+}
+
+// Name identifies the move in DB_MIGRATION_PHASES: the new table's name.
+func (m TableMove) Name() string {
+	return m.New
+}
+
+// ReadTable returns the table reads use.
+func (m TableMove) ReadTable() string {
+	if m.ReadsNew() {
+		return m.New
+	}
+	return m.Old
+}
+
+// ReadsNew reports whether reads use the new table.
+func (m TableMove) ReadsNew() bool {
+	return m.Phase == PhaseReadNew || m.Phase == PhaseNew
+}
+
+// WritesOld reports whether writes go to the old table.
+func (m TableMove) WritesOld() bool {
+	return m.Phase != PhaseNew
+}
+
+// WritesNew reports whether writes go to the new table.
+func (m TableMove) WritesNew() bool {
+	return m.Phase != PhaseOld
+}
+
+// WriteTables returns the tables writes go to, old first.
+func (m TableMove) WriteTables() []string {
+	switch m.Phase {
+	case PhaseDualWrite, PhaseReadNew:
+		return []string{m.Old, m.New}
+	case PhaseNew:
+		return []string{m.New}
+	default:
+		return []string{m.Old}
+	}
+}
+
+// ClaimsV2 moves claims to claims_v2, keyed by (coupon_name, claim_id) and
+// hash-partitioned by coupon, so a coupon's claims share a partition. claim_id keeps
+// the claims.id of moved rows. Requires PostgreSQL. Migration script:
+// scripts/migrations/claims_v2.sql.
+var ClaimsV2 = TableMove{Old: "claims", New: "claims_v2", Phase: PhaseOld}
+
+// TableMoves lists the table moves the repositories support, in their default phase.
+var TableMoves = []TableMove{ClaimsV2}
+
+// isTableMove reports whether name identifies a table move.
+func isTableMove(name string) bool {
+	for _, m := range TableMoves {
+		if m.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TableMovesWithPhases returns TableMoves keyed by name, with the phases given in
+// phases (keyed by move name) applied. Names of column renames are skipped; see
+// RenamesWithPhases.
+func TableMovesWithPhases(phases map[string]string) (map[string]TableMove, error) {
+	moves := make(map[string]TableMove, len(TableMoves))
+	for _, m := range TableMoves {
+		moves[m.Name()] = m
+	}
+
+	names := make([]string, 0, len(phases))
+	for name := range phases {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Deterministic errors
+
+	for _, name := range names {
+		m, ok := moves[name]
+		if !ok {
+			continue
+		}
+		phase, err := ParseMigrationPhase(phases[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		m.Phase = phase
+		moves[name] = m
+	}
+	return moves, nil
+}
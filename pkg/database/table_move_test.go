@@ -0,0 +1,48 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableMove_Phases(t *testing.T) {
+	tests := []struct {
+		phase  MigrationPhase
+		read   string
+		writes []string
+	}{
+		{PhaseOld, "claims", []string{"claims"}},
+		{PhaseDualWrite, "claims", []string{"claims", "claims_v2"}},
+		{PhaseReadNew, "claims_v2", []string{"claims", "claims_v2"}},
+		{PhaseNew, "claims_v2", []string{"claims_v2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			m := ClaimsV2
+			m.Phase = tt.phase
+
+			assert.Equal(t, tt.read, m.ReadTable())
+			assert.Equal(t, tt.writes, m.WriteTables())
+			assert.Equal(t, tt.writes[0] == "claims", m.WritesOld())
+			assert.Equal(t, tt.writes[len(tt.writes)-1] == "claims_v2", m.WritesNew())
+		})
+	}
+}
+
+func TestTableMovesWithPhases(t *testing.T) {
+	moves, err := TableMovesWithPhases(nil)
+	require.NoError(t, err)
+	assert.Equal(t, PhaseOld, moves["claims_v2"].Phase)
+
+	moves, err = TableMovesWithPhases(map[string]string{"claims_v2": "read_new", "claims.claimed_at": "new"})
+	require.NoError(t, err)
+	assert.Equal(t, PhaseReadNew, moves["claims_v2"].Phase)
+	assert.Len(t, moves, 1, "column renames are skipped")
+	assert.Equal(t, PhaseOld, ClaimsV2.Phase, "the registry is not modified")
+
+	_, err = TableMovesWithPhases(map[string]string{"claims_v2": "cutover"})
+	assert.EqualError(t, err, `claims_v2: unknown migration phase "cutover"`)
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Queries with their own timeout, named as in DB_QUERY_TIMEOUTS.
+const (
+	QueryGetCoupon  = "get_coupon"  // Read a coupon by name
+	QueryLockCoupon = "lock_coupon" // Lock a coupon row for a claim or an update
+)
+
+// Queries lists the query names QueryTimeouts may set.
+var Queries = []string{QueryGetCoupon, QueryLockCoupon}
+
+// QueryTimeoutError is returned when a query is cut short by its own timeout while the
+// caller's deadline, if any, has not passed: the database is slow, not the caller.
+type QueryTimeoutError struct {
+	Query   string
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *QueryTimeoutError) Error() string {
+	return fmt.Sprintf("%s query timed out after %s: %v", e.Query, e.Timeout, e.Err)
+}
+
+func (e *QueryTimeoutError) Unwrap() error { return e.Err }
+
+// QueryTimeouts maps query names (see Queries) to timeouts. Queries without one are
+// only bounded by their caller's deadline.
+type QueryTimeouts map[string]time.Duration
+
+// Run runs fn with ctx bounded by the timeout of query, layered under ctx's own
+// deadline. If fn fails because the query's timeout passed, the error is wrapped in a
+// *QueryTimeoutError; failures from ctx itself are returned as is.
+func (t QueryTimeouts) Run(ctx context.Context, query string, fn func(ctx context.Context) error) error {
+	timeout := t[query]
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := fn(queryCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) {
+		return &QueryTimeoutError{Query: query, Timeout: timeout, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForCancel blocks until ctx is done, like a query cancelled by its context.
+func waitForCancel(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestQueryTimeouts_Run(t *testing.T) {
+	timeouts := QueryTimeouts{QueryGetCoupon: 10 * time.Millisecond}
+
+	t.Run("query timeout", func(t *testing.T) {
+		err := timeouts.Run(context.Background(), QueryGetCoupon, waitForCancel)
+
+		var timeoutErr *QueryTimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		assert.Equal(t, QueryGetCoupon, timeoutErr.Query)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("caller deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+
+		err := timeouts.Run(ctx, QueryGetCoupon, waitForCancel)
+
+		var timeoutErr *QueryTimeoutError
+		assert.False(t, errors.As(err, &timeoutErr), "the caller's deadline is not the database's fault")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		err := timeouts.Run(context.Background(), QueryLockCoupon, func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			assert.False(t, ok)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		queryErr := errors.New("syntax error")
+		err := timeouts.Run(context.Background(), QueryGetCoupon, func(ctx context.Context) error { return queryErr })
+		assert.Same(t, queryErr, err)
+	})
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// maxRetryBackoff caps the wait between transaction attempts.
+const maxRetryBackoff = time.Second
+
+// TxBeginner begins transactions (satisfied by *pgxpool.Pool).
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// transaction is a transaction as seen by Transactor (satisfied by pgx.Tx and sqlTx).
+type transaction interface {
+	TxQuerier
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// TxHooks let the layers around a Transactor observe or fail its transactions, e.g. to
+// count retries or to inject faults in tests. Nil hooks are skipped.
+type TxHooks struct {
+	// Begin runs before each transaction is begun; an error fails the attempt as a
+	// failed begin would.
+	Begin func(ctx context.Context) error
+	// Commit runs before each commit; an error rolls the transaction back and fails
+	// the attempt as a failed commit would.
+	Commit func(ctx context.Context) error
+	// Retry runs before a transaction that failed with err on attempt is retried.
+	Retry func(attempt int, err error)
+}
+
+// Transactor runs functions in transactions, retrying them as the dialect requires.
+type Transactor struct {
+	begin   func(ctx context.Context) (transaction, error)
+	dialect Dialect
+	backoff func(attempt int) time.Duration
+	hooks   TxHooks
+}
+
+// NewTransactor creates a Transactor that begins transactions from pool.
+func NewTransactor(pool TxBeginner, dialect Dialect) *Transactor {
+	begin := func(ctx context.Context) (transaction, error) { return pool.Begin(ctx) }
+	return &Transactor{begin: begin, dialect: dialect, backoff: retryBackoff}
+}
+
+// SetHooks sets the hooks run around each transaction attempt. Must be called before
+// the Transactor is used.
+func (t *Transactor) SetHooks(hooks TxHooks) {
+	t.hooks = hooks
+}
+
+// InTx runs fn in a transaction and commits it if fn returns nil; otherwise the
+// transaction is rolled back and fn's error returned. On dialects with retries, a
+// transaction failing with a serialization failure (in fn or on commit) is rolled back
+// and fn is run again in a new transaction, so fn must only have effects through tx
+// and must reset any results it assigns. Each attempt runs as WithTx does.
+func (t *Transactor) InTx(ctx context.Context, fn func(tx TxQuerier) error) error {
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, t.begin, t.hooks, fn)
+		if err == nil || attempt >= t.dialect.TxAttempts || !IsSerializationFailure(err) {
+			return err
+		}
+		if t.hooks.Retry != nil {
+			t.hooks.Retry(attempt, err)
+		}
+
+		wait := t.backoff(attempt)
+		log.Debug().
+			Err(err).
+			Str("dialect", t.dialect.Name).
+			Int("attempt", attempt).
+			Dur("next_retry_in", wait).
+			Msg("transaction serialization failure, retrying")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// WithTx runs fn in a transaction begun from pool and commits it if fn returns nil;
+// otherwise the transaction is rolled back and fn's error returned. It makes a single
+// attempt; use a Transactor for the dialect's retries. The transaction is also rolled
+// back if fn panics, before the panic continues, and if ctx is done by the time fn
+// returns, in which case ctx's error is returned. No transaction is begun if ctx is
+// already done.
+func WithTx(ctx context.Context, pool TxBeginner, fn func(tx TxQuerier) error) error {
+	begin := func(ctx context.Context) (transaction, error) { return pool.Begin(ctx) }
+	return runTx(ctx, begin, TxHooks{}, fn)
+}
+
+// runTx runs fn in a transaction begun by begin, as WithTx describes.
+func runTx(ctx context.Context, begin func(ctx context.Context) (transaction, error), hooks TxHooks, fn func(tx TxQuerier) error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	if hooks.Begin != nil {
+		if err := hooks.Begin(ctx); err != nil {
+			return fmt.Errorf("begin tx: %w", err)
+		}
+	}
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	// Rolling back with ctx done still ends the transaction: pgx closes the connection
+	defer func() { _ = tx.Rollback(ctx) }() // Safe: no-op if committed; also runs on panic
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	if hooks.Commit != nil {
+		if err := hooks.Commit(ctx); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// retryBackoff returns a jittered exponential wait: up to 10ms, 20ms, 40ms, ... capped at 1s.
+// Jitter keeps transactions that conflicted with each other from retrying in lockstep.
+func retryBackoff(attempt int) time.Duration {
+	limit := maxRetryBackoff
+	if attempt < 8 { // Larger shifts exceed the cap anyway
+		limit = min(10*time.Millisecond<<(attempt-1), maxRetryBackoff)
+	}
+	return limit/2 + rand.N(limit/2+1)
+}
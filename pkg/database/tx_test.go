@@ -0,0 +1,294 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx is a pgx.Tx recording Commit and Rollback; other methods are not used.
+type fakeTx struct {
+	pgx.Tx
+	commitErr  error
+	committed  bool
+	rolledBack bool
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	if t.commitErr != nil {
+		return t.commitErr
+	}
+	t.committed = true
+	return nil
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	if !t.committed {
+		t.rolledBack = true
+	}
+	return nil
+}
+
+// fakeBeginner hands out txs in order and counts Begin calls.
+type fakeBeginner struct {
+	txs      []*fakeTx
+	begins   int
+	beginErr error
+}
+
+func (b *fakeBeginner) Begin(ctx context.Context) (pgx.Tx, error) {
+	if b.beginErr != nil {
+		return nil, b.beginErr
+	}
+	tx := b.txs[b.begins]
+	b.begins++
+	return tx, nil
+}
+
+// newTestTransactor returns a Transactor that does not wait between attempts.
+func newTestTransactor(pool TxBeginner, dialect Dialect) *Transactor {
+	tr := NewTransactor(pool, dialect)
+	tr.backoff = func(int) time.Duration { return 0 }
+	return tr
+}
+
+var errSerialization = &pgconn.PgError{Code: "40001", Message: "restart transaction"}
+
+func TestTransactor_Commit(t *testing.T) {
+	tx := &fakeTx{}
+	pool := &fakeBeginner{txs: []*fakeTx{tx}}
+
+	err := newTestTransactor(pool, Postgres).InTx(context.Background(), func(q TxQuerier) error {
+		assert.Same(t, tx, q)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.True(t, tx.committed)
+	assert.False(t, tx.rolledBack)
+}
+
+func TestTransactor_RollbackOnError(t *testing.T) {
+	tx := &fakeTx{}
+	pool := &fakeBeginner{txs: []*fakeTx{tx}}
+	fnErr := errors.New("coupon out of stock")
+
+	err := newTestTransactor(pool, Postgres).InTx(context.Background(), func(q TxQuerier) error { return fnErr })
+
+	assert.ErrorIs(t, err, fnErr)
+	assert.False(t, tx.committed)
+	assert.True(t, tx.rolledBack)
+}
+
+func TestTransactor_BeginError(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	pool := &fakeBeginner{beginErr: dbErr}
+
+	err := newTestTransactor(pool, CockroachDB).InTx(context.Background(), func(q TxQuerier) error {
+		t.Fatal("fn must not run without a transaction")
+		return nil
+	})
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.Contains(t, err.Error(), "begin tx")
+}
+
+func TestTransactor_PostgresDoesNotRetry(t *testing.T) {
+	pool := &fakeBeginner{txs: []*fakeTx{{}, {}}}
+
+	err := newTestTransactor(pool, Postgres).InTx(context.Background(), func(q TxQuerier) error { return errSerialization })
+
+	assert.True(t, IsSerializationFailure(err))
+	assert.Equal(t, 1, pool.begins)
+}
+
+func TestTransactor_CockroachDBRetries(t *testing.T) {
+	t.Run("failure in fn", func(t *testing.T) {
+		pool := &fakeBeginner{txs: []*fakeTx{{}, {}, {}}}
+		runs := 0
+
+		err := newTestTransactor(pool, CockroachDB).InTx(context.Background(), func(q TxQuerier) error {
+			runs++
+			if runs < 3 {
+				return errSerialization
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, runs)
+		assert.True(t, pool.txs[0].rolledBack)
+		assert.True(t, pool.txs[1].rolledBack)
+		assert.True(t, pool.txs[2].committed)
+	})
+
+	t.Run("failure on commit", func(t *testing.T) {
+		pool := &fakeBeginner{txs: []*fakeTx{{commitErr: errSerialization}, {}}}
+
+		err := newTestTransactor(pool, CockroachDB).InTx(context.Background(), func(q TxQuerier) error { return nil })
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, pool.begins)
+		assert.True(t, pool.txs[1].committed)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		pool := &fakeBeginner{txs: []*fakeTx{{}, {}}}
+		uniqueErr := &pgconn.PgError{Code: "23505"}
+
+		err := newTestTransactor(pool, CockroachDB).InTx(context.Background(), func(q TxQuerier) error { return uniqueErr })
+
+		assert.ErrorIs(t, err, uniqueErr)
+		assert.Equal(t, 1, pool.begins)
+	})
+
+	t.Run("gives up after TxAttempts", func(t *testing.T) {
+		dialect := Dialect{Name: "cockroachdb", TxAttempts: 3}
+		pool := &fakeBeginner{txs: []*fakeTx{{}, {}, {}, {}}}
+
+		err := newTestTransactor(pool, dialect).InTx(context.Background(), func(q TxQuerier) error { return errSerialization })
+
+		assert.True(t, IsSerializationFailure(err))
+		assert.Equal(t, 3, pool.begins)
+	})
+
+	t.Run("stops when context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		pool := &fakeBeginner{txs: []*fakeTx{{}, {}}}
+		tr := NewTransactor(pool, CockroachDB) // Real backoff: cancellation must cut it short
+
+		err := tr.InTx(ctx, func(q TxQuerier) error {
+			cancel()
+			return errSerialization
+		})
+
+		assert.True(t, IsSerializationFailure(err))
+		assert.Equal(t, 1, pool.begins)
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 20; attempt++ {
+		limit := maxRetryBackoff
+		if attempt < 8 {
+			limit = min(10*time.Millisecond<<(attempt-1), maxRetryBackoff)
+		}
+		for range 20 {
+			wait := retryBackoff(attempt)
+			assert.GreaterOrEqual(t, wait, limit/2, "attempt %d", attempt)
+			assert.LessOrEqual(t, wait, limit, "attempt %d", attempt)
+		}
+	}
+}
+
+func TestWithTx_Commit(t *testing.T) {
+	tx := &fakeTx{}
+
+	err := WithTx(context.Background(), &fakeBeginner{txs: []*fakeTx{tx}}, func(q TxQuerier) error { return nil })
+
+	require.NoError(t, err)
+	assert.True(t, tx.committed)
+}
+
+func TestWithTx_DoesNotRetry(t *testing.T) {
+	pool := &fakeBeginner{txs: []*fakeTx{{}, {}}}
+
+	err := WithTx(context.Background(), pool, func(q TxQuerier) error { return errSerialization })
+
+	assert.True(t, IsSerializationFailure(err))
+	assert.Equal(t, 1, pool.begins)
+}
+
+func TestWithTx_RollbackOnPanic(t *testing.T) {
+	tx := &fakeTx{}
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = WithTx(context.Background(), &fakeBeginner{txs: []*fakeTx{tx}}, func(q TxQuerier) error { panic("boom") })
+	})
+	assert.True(t, tx.rolledBack)
+	assert.False(t, tx.committed)
+}
+
+func TestWithTx_ContextDone(t *testing.T) {
+	t.Run("before begin", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		pool := &fakeBeginner{txs: []*fakeTx{{}}}
+
+		err := WithTx(ctx, pool, func(q TxQuerier) error {
+			t.Fatal("fn must not run without a transaction")
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, pool.begins)
+	})
+
+	t.Run("before commit", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		tx := &fakeTx{}
+
+		err := WithTx(ctx, &fakeBeginner{txs: []*fakeTx{tx}}, func(q TxQuerier) error {
+			cancel()
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, tx.committed)
+		assert.True(t, tx.rolledBack)
+	})
+}
+
+func TestTransactor_Hooks(t *testing.T) {
+	hookErr := errors.New("injected fault")
+
+	t.Run("begin", func(t *testing.T) {
+		pool := &fakeBeginner{txs: []*fakeTx{{}}}
+		tr := newTestTransactor(pool, Postgres)
+		tr.SetHooks(TxHooks{Begin: func(context.Context) error { return hookErr }})
+
+		err := tr.InTx(context.Background(), func(q TxQuerier) error { return nil })
+
+		assert.ErrorIs(t, err, hookErr)
+		assert.Equal(t, 0, pool.begins)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		tx := &fakeTx{}
+		tr := newTestTransactor(&fakeBeginner{txs: []*fakeTx{tx}}, Postgres)
+		tr.SetHooks(TxHooks{Commit: func(context.Context) error { return hookErr }})
+
+		err := tr.InTx(context.Background(), func(q TxQuerier) error { return nil })
+
+		assert.ErrorIs(t, err, hookErr)
+		assert.False(t, tx.committed)
+		assert.True(t, tx.rolledBack)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		var retried []int
+		tr := newTestTransactor(&fakeBeginner{txs: []*fakeTx{{}, {}, {}}}, CockroachDB)
+		tr.SetHooks(TxHooks{Retry: func(attempt int, err error) {
+			assert.True(t, IsSerializationFailure(err))
+			retried = append(retried, attempt)
+		}})
+		runs := 0
+
+		err := tr.InTx(context.Background(), func(q TxQuerier) error {
+			runs++
+			if runs < 3 {
+				return errSerialization
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, retried)
+	})
+}
@@ -0,0 +1,103 @@
+// Package jobs is a minimal durable job queue. Jobs are rows in the jobs table; a
+// Worker dequeues them one at a time with FOR UPDATE SKIP LOCKED, so any number of
+// workers across instances can poll the same queue without handing a job to two of
+// them at once.
+//
+// Delivery is at least once: a dequeued job is hidden for a visibility timeout, and a
+// worker that crashes or overruns it lets the job be dequeued again. Handlers must
+// therefore be idempotent.
+//
+// A Schedule keeps heavy periodic jobs to maintenance windows and holds them back
+// while the database is under load.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// DefaultMaxAttempts is how many times a job is attempted unless WithMaxAttempts is given.
+const DefaultMaxAttempts = 10
+
+// ErrLeaseLost is returned when a job is acknowledged after its visibility timeout
+// expired and it was dequeued again; the acknowledgement is dropped.
+var ErrLeaseLost = errors.New("job lease lost")
+
+// Job is a dequeued job.
+type Job struct {
+	ID          int64
+	Queue       string
+	Payload     json.RawMessage
+	Attempt     int // 1-based number of this delivery
+	MaxAttempts int
+	CreatedAt   time.Time
+}
+
+// Handler processes one job. Returning nil completes the job; returning an error
+// retries it with backoff, unless the error is Permanent or the job has no attempts left.
+type Handler func(ctx context.Context, job *Job) error
+
+// Store is the queue backend a Worker consumes (implemented by Queue).
+type Store interface {
+	// Dequeue claims the next due job of queue and hides it for visibility.
+	// Returns nil, nil when no job is due.
+	Dequeue(ctx context.Context, queue string, visibility time.Duration) (*Job, error)
+	// Complete removes a processed job.
+	Complete(ctx context.Context, job *Job) error
+	// Retry makes the job due again after delay, recording cause.
+	Retry(ctx context.Context, job *Job, delay time.Duration, cause error) error
+	// Fail keeps the job for inspection without running it again, recording cause.
+	Fail(ctx context.Context, job *Job, cause error) error
+}
+
+// EnqueueOption customizes an enqueued job.
+type EnqueueOption func(*enqueueOptions)
+
+type enqueueOptions struct {
+	maxAttempts int
+	delay       time.Duration
+}
+
+// WithMaxAttempts sets how many times the job is attempted before it is failed.
+func WithMaxAttempts(n int) EnqueueOption {
+	return func(o *enqueueOptions) { o.maxAttempts = n }
+}
+
+// WithDelay makes the job due after d instead of immediately.
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) { o.delay = d }
+}
+
+func applyOptions(opts []EnqueueOption) enqueueOptions {
+	o := enqueueOptions{maxAttempts: DefaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxAttempts < 1 {
+		o.maxAttempts = 1
+	}
+	return o
+}
+
+// permanentError marks a handler error that retrying cannot fix.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job is failed instead of retried, e.g. for a payload
+// that cannot be decoded.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
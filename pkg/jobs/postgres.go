@@ -0,0 +1,126 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// Queue is the PostgreSQL (and CockroachDB) Store, backed by the jobs table.
+//
+// A job's attempt count doubles as its lease token: Dequeue increments it, and
+// Complete, Retry and Fail only apply while it is unchanged, so a worker that overran
+// the visibility timeout cannot acknowledge a job another worker has since dequeued.
+type Queue struct {
+	db database.TxQuerier
+}
+
+var _ Store = (*Queue)(nil)
+
+// NewQueue creates a Queue on db (typically the pool).
+func NewQueue(db database.TxQuerier) *Queue {
+	return &Queue{db: db}
+}
+
+// Enqueue adds a job with payload (marshaled to JSON) to queue and returns its ID.
+func (q *Queue) Enqueue(ctx context.Context, queue string, payload any, opts ...EnqueueOption) (int64, error) {
+	return q.EnqueueTx(ctx, q.db, queue, payload, opts...)
+}
+
+// EnqueueTx adds a job within tx, so it is only created if the transaction commits
+// (e.g. a notification enqueued together with the change it announces).
+func (q *Queue) EnqueueTx(ctx context.Context, tx database.TxQuerier, queue string, payload any, opts ...EnqueueOption) (int64, error) {
+	o := applyOptions(opts)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("marshal job payload: %w", err)
+	}
+
+	var id int64
+	err = tx.QueryRow(ctx, `
+		INSERT INTO jobs (queue, payload, max_attempts, run_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 millisecond')
+		RETURNING id
+	`, queue, string(body), o.maxAttempts, o.delay.Milliseconds()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue job: %w", err)
+	}
+	return id, nil
+}
+
+// Dequeue claims the oldest due job of queue. Locked rows are skipped rather than
+// waited for, and the claimed job's run_at is pushed past the visibility timeout, which
+// hides it from other workers once the statement commits.
+func (q *Queue) Dequeue(ctx context.Context, queue string, visibility time.Duration) (*Job, error) {
+	var job Job
+	var payload []byte
+	err := q.db.QueryRow(ctx, `
+		UPDATE jobs
+		SET attempts = attempts + 1, run_at = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE queue = $1 AND failed_at IS NULL AND run_at <= NOW()
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, queue, payload, attempts, max_attempts, created_at
+	`, queue, visibility.Milliseconds()).Scan(
+		&job.ID, &job.Queue, &payload, &job.Attempt, &job.MaxAttempts, &job.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dequeue job: %w", err)
+	}
+	job.Payload = payload
+	return &job, nil
+}
+
+// Complete deletes the job.
+func (q *Queue) Complete(ctx context.Context, job *Job) error {
+	tag, err := q.db.Exec(ctx, `DELETE FROM jobs WHERE id = $1 AND attempts = $2`, job.ID, job.Attempt)
+	return leaseResult("complete job", tag.RowsAffected(), err)
+}
+
+// Retry makes the job due again after delay.
+func (q *Queue) Retry(ctx context.Context, job *Job, delay time.Duration, cause error) error {
+	tag, err := q.db.Exec(ctx, `
+		UPDATE jobs SET run_at = NOW() + $3 * INTERVAL '1 millisecond', last_error = $4
+		WHERE id = $1 AND attempts = $2
+	`, job.ID, job.Attempt, delay.Milliseconds(), errorText(cause))
+	return leaseResult("retry job", tag.RowsAffected(), err)
+}
+
+// Fail marks the job failed; it stays in the table for inspection but is never dequeued.
+func (q *Queue) Fail(ctx context.Context, job *Job, cause error) error {
+	tag, err := q.db.Exec(ctx, `
+		UPDATE jobs SET failed_at = NOW(), last_error = $3
+		WHERE id = $1 AND attempts = $2
+	`, job.ID, job.Attempt, errorText(cause))
+	return leaseResult("fail job", tag.RowsAffected(), err)
+}
+
+func leaseResult(op string, rows int64, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", op, ErrLeaseLost)
+	}
+	return nil
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockQuerier implements database.TxQuerier for Queue tests.
+type mockQuerier struct {
+	execFn     func(sql string, args ...any) (pgconn.CommandTag, error)
+	queryRowFn func(sql string, args ...any) pgx.Row
+}
+
+func (m *mockQuerier) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return m.execFn(sql, args...)
+}
+
+func (m *mockQuerier) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	return m.queryRowFn(sql, args...)
+}
+
+func (m *mockQuerier) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+type mockRow struct {
+	scanFn func(dest ...any) error
+}
+
+func (r *mockRow) Scan(dest ...any) error { return r.scanFn(dest...) }
+
+func TestQueue_EnqueueTx(t *testing.T) {
+	var args []any
+	tx := &mockQuerier{queryRowFn: func(_ string, a ...any) pgx.Row {
+		args = a
+		return &mockRow{scanFn: func(dest ...any) error {
+			*dest[0].(*int64) = 7
+			return nil
+		}}
+	}}
+	q := NewQueue(nil)
+
+	id, err := q.EnqueueTx(context.Background(), tx, "webhooks", map[string]string{"event": "claimed"},
+		WithMaxAttempts(5), WithDelay(2*time.Second))
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), id)
+	assert.Equal(t, []any{"webhooks", `{"event":"claimed"}`, 5, int64(2000)}, args)
+}
+
+func TestQueue_Enqueue_Defaults(t *testing.T) {
+	var args []any
+	db := &mockQuerier{queryRowFn: func(_ string, a ...any) pgx.Row {
+		args = a
+		return &mockRow{scanFn: func(...any) error { return nil }}
+	}}
+
+	_, err := NewQueue(db).Enqueue(context.Background(), "archive", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, []any{"archive", "null", DefaultMaxAttempts, int64(0)}, args)
+}
+
+func TestQueue_Enqueue_UnmarshalablePayload(t *testing.T) {
+	_, err := NewQueue(&mockQuerier{}).Enqueue(context.Background(), "archive", make(chan int))
+
+	assert.ErrorContains(t, err, "marshal job payload")
+}
+
+func TestQueue_Dequeue(t *testing.T) {
+	var sql string
+	var args []any
+	created := time.Now()
+	db := &mockQuerier{queryRowFn: func(s string, a ...any) pgx.Row {
+		sql, args = s, a
+		return &mockRow{scanFn: func(dest ...any) error {
+			*dest[0].(*int64) = 7
+			*dest[1].(*string) = "webhooks"
+			*dest[2].(*[]byte) = []byte(`{}`)
+			*dest[3].(*int) = 2
+			*dest[4].(*int) = 10
+			*dest[5].(*time.Time) = created
+			return nil
+		}}
+	}}
+
+	job, err := NewQueue(db).Dequeue(context.Background(), "webhooks", 30*time.Second)
+
+	require.NoError(t, err)
+	assert.Equal(t, &Job{ID: 7, Queue: "webhooks", Payload: []byte(`{}`), Attempt: 2, MaxAttempts: 10, CreatedAt: created}, job)
+	assert.Contains(t, sql, "FOR UPDATE SKIP LOCKED")
+	assert.Equal(t, []any{"webhooks", int64(30000)}, args)
+}
+
+func TestQueue_Dequeue_Empty(t *testing.T) {
+	db := &mockQuerier{queryRowFn: func(string, ...any) pgx.Row {
+		return &mockRow{scanFn: func(...any) error { return pgx.ErrNoRows }}
+	}}
+
+	job, err := NewQueue(db).Dequeue(context.Background(), "webhooks", time.Second)
+
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestQueue_Acknowledge_LeaseToken(t *testing.T) {
+	job := &Job{ID: 7, Attempt: 3}
+	acks := map[string]func(q *Queue) error{
+		"complete": func(q *Queue) error { return q.Complete(context.Background(), job) },
+		"retry":    func(q *Queue) error { return q.Retry(context.Background(), job, time.Second, errors.New("boom")) },
+		"fail":     func(q *Queue) error { return q.Fail(context.Background(), job, errors.New("boom")) },
+	}
+
+	for name, ack := range acks {
+		t.Run(name, func(t *testing.T) {
+			var args []any
+			rows := "UPDATE 1"
+			db := &mockQuerier{execFn: func(_ string, a ...any) (pgconn.CommandTag, error) {
+				args = a
+				return pgconn.NewCommandTag(rows), nil
+			}}
+			q := NewQueue(db)
+
+			require.NoError(t, ack(q))
+			assert.Equal(t, []any{int64(7), 3}, args[:2], "acknowledged by ID and attempt")
+
+			rows = "UPDATE 0"
+			assert.ErrorIs(t, ack(q), ErrLeaseLost)
+		})
+	}
+}
+
+func TestQueue_Acknowledge_DatabaseError(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	db := &mockQuerier{execFn: func(string, ...any) (pgconn.CommandTag, error) {
+		return pgconn.CommandTag{}, dbErr
+	}}
+
+	err := NewQueue(db).Complete(context.Background(), &Job{ID: 7, Attempt: 1})
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.ErrorContains(t, err, "complete job")
+}
@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// Reasons a Schedule holds a heavy job back.
+const (
+	SkipOutsideWindow  = "outside_window"
+	SkipReplicationLag = "replication_lag"
+	SkipActiveConns    = "active_connections"
+	SkipLoadUnknown    = "load_unknown" // The load could not be read
+)
+
+// maxWindows bounds the windows ParseWindows accepts.
+const maxWindows = 24
+
+// Window is a daily span of UTC time, e.g. 01:00-05:00. A window whose end is not after
+// its start spans midnight.
+type Window struct {
+	Start time.Duration // Since midnight UTC
+	End   time.Duration // Since midnight UTC
+}
+
+// Contains reports whether t falls in the window.
+func (w Window) Contains(t time.Time) bool {
+	t = t.UTC()
+	since := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	if w.Start < w.End {
+		return since >= w.Start && since < w.End
+	}
+	return since >= w.Start || since < w.End
+}
+
+// String returns the window as ParseWindows reads it.
+func (w Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d",
+		int(w.Start.Hours()), int(w.Start.Minutes())%60, int(w.End.Hours()), int(w.End.Minutes())%60)
+}
+
+// ParseWindows parses comma-separated HH:MM-HH:MM windows of UTC time, e.g.
+// "01:00-05:00,22:30-23:30". An empty s has no windows.
+func ParseWindows(s string) ([]Window, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) > maxWindows {
+		return nil, fmt.Errorf("at most %d windows, got %d", maxWindows, len(parts))
+	}
+	windows := make([]Window, 0, len(parts))
+	for _, part := range parts {
+		start, end, ok := strings.Cut(strings.TrimSpace(part), "-")
+		if !ok {
+			return nil, fmt.Errorf("window %q is not HH:MM-HH:MM", part)
+		}
+		var w Window
+		var err error
+		if w.Start, err = parseTimeOfDay(start); err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		if w.End, err = parseTimeOfDay(end); err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		if w.Start == w.End {
+			return nil, fmt.Errorf("window %q is empty", part)
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// parseTimeOfDay parses HH:MM into the time since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ScheduleConfig tunes a Schedule. Zero fields are not checked.
+type ScheduleConfig struct {
+	// Windows are when heavy jobs may run; any time without windows.
+	Windows []Window
+	// MaxReplicationLag holds heavy jobs back while a replica lags further behind, e.g.
+	// while a base backup is streamed from it.
+	MaxReplicationLag time.Duration
+	// MaxActiveConns holds heavy jobs back while more connections run a statement.
+	MaxActiveConns int64
+	// Load reads the server load, required with MaxReplicationLag or MaxActiveConns.
+	Load func(ctx context.Context) (database.ServerLoad, error)
+	// Clock tells the time of day (default: clock.Real).
+	Clock clock.Clock
+}
+
+// ScheduleStats is a snapshot of a Schedule's decisions, by job.
+type ScheduleStats struct {
+	Runs    map[string]int64            `json:"runs"`    // Runs allowed
+	Skipped map[string]map[string]int64 `json:"skipped"` // Runs held back, by reason
+}
+
+// Schedule decides whether heavy background jobs (purges, exports, sweeps) may run
+// now, so that they keep to maintenance windows and stand back while the database is
+// under pressure. A job held back runs on its next tick instead. It is safe for
+// concurrent use.
+type Schedule struct {
+	cfg ScheduleConfig
+
+	mu      sync.Mutex
+	runs    map[string]int64
+	skipped map[string]map[string]int64
+}
+
+// NewSchedule creates a Schedule.
+func NewSchedule(cfg ScheduleConfig) *Schedule {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real
+	}
+	return &Schedule{cfg: cfg, runs: make(map[string]int64), skipped: make(map[string]map[string]int64)}
+}
+
+// Allow reports whether job may run now, counting the decision.
+func (s *Schedule) Allow(ctx context.Context, job string) bool {
+	reason := s.check(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reason == "" {
+		s.runs[job]++
+		return true
+	}
+	if s.skipped[job] == nil {
+		s.skipped[job] = make(map[string]int64)
+	}
+	s.skipped[job][reason]++
+	log.Debug().Str("job", job).Str("reason", reason).Msg("heavy job held back")
+	return false
+}
+
+// check returns why a heavy job may not run now, or "" if it may.
+func (s *Schedule) check(ctx context.Context) string {
+	if len(s.cfg.Windows) > 0 && !s.inWindow(s.cfg.Clock.Now()) {
+		return SkipOutsideWindow
+	}
+	if s.cfg.MaxReplicationLag == 0 && s.cfg.MaxActiveConns == 0 {
+		return ""
+	}
+	load, err := s.cfg.Load(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Msg("failed to read the database load, holding heavy jobs back")
+		}
+		return SkipLoadUnknown
+	}
+	if s.cfg.MaxReplicationLag > 0 && load.ReplicationLag > s.cfg.MaxReplicationLag {
+		return SkipReplicationLag
+	}
+	if s.cfg.MaxActiveConns > 0 && load.ActiveConns > s.cfg.MaxActiveConns {
+		return SkipActiveConns
+	}
+	return ""
+}
+
+func (s *Schedule) inWindow(t time.Time) bool {
+	for _, w := range s.cfg.Windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns the decisions taken so far.
+func (s *Schedule) Stats() ScheduleStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := ScheduleStats{Runs: maps.Clone(s.runs), Skipped: make(map[string]map[string]int64, len(s.skipped))}
+	for job, reasons := range s.skipped {
+		stats.Skipped[job] = maps.Clone(reasons)
+	}
+	return stats
+}
@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+func TestParseWindows(t *testing.T) {
+	windows, err := ParseWindows("01:00-05:30, 22:00-02:00")
+	require.NoError(t, err)
+	assert.Equal(t, []Window{
+		{Start: time.Hour, End: 5*time.Hour + 30*time.Minute},
+		{Start: 22 * time.Hour, End: 2 * time.Hour},
+	}, windows)
+	assert.Equal(t, "22:00-02:00", windows[1].String())
+
+	windows, err = ParseWindows("")
+	require.NoError(t, err)
+	assert.Empty(t, windows)
+
+	for _, s := range []string{"01:00", "1am-5am", "01:00-24:00", "03:00-03:00"} {
+		_, err := ParseWindows(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestWindow_Contains(t *testing.T) {
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	w := Window{Start: time.Hour, End: 5 * time.Hour}
+	assert.True(t, w.Contains(day.Add(time.Hour)))
+	assert.False(t, w.Contains(day.Add(5*time.Hour)))
+
+	// Across midnight, and in UTC whatever the location of t
+	w = Window{Start: 22 * time.Hour, End: 2 * time.Hour}
+	assert.True(t, w.Contains(day.Add(23*time.Hour)))
+	assert.True(t, w.Contains(day.Add(time.Hour)))
+	assert.False(t, w.Contains(day.Add(12*time.Hour)))
+	assert.True(t, w.Contains(day.Add(23*time.Hour).In(time.FixedZone("UTC+7", 7*60*60))))
+}
+
+func TestSchedule_Windows(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	s := NewSchedule(ScheduleConfig{
+		Windows: []Window{{Start: time.Hour, End: 5 * time.Hour}},
+		Clock:   clk,
+	})
+
+	assert.False(t, s.Allow(context.Background(), "coupon_purge"))
+	clk.Set(time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC))
+	assert.True(t, s.Allow(context.Background(), "coupon_purge"))
+	assert.Equal(t, ScheduleStats{
+		Runs:    map[string]int64{"coupon_purge": 1},
+		Skipped: map[string]map[string]int64{"coupon_purge": {SkipOutsideWindow: 1}},
+	}, s.Stats())
+}
+
+func TestSchedule_Load(t *testing.T) {
+	var load database.ServerLoad
+	var loadErr error
+	s := NewSchedule(ScheduleConfig{
+		MaxReplicationLag: 10 * time.Second,
+		MaxActiveConns:    50,
+		Load: func(context.Context) (database.ServerLoad, error) {
+			return load, loadErr
+		},
+	})
+
+	load = database.ServerLoad{ReplicationLag: time.Second, ActiveConns: 50}
+	assert.True(t, s.Allow(context.Background(), "analytics_export"))
+
+	// A base backup streamed from the replica
+	load.ReplicationLag = time.Minute
+	assert.False(t, s.Allow(context.Background(), "analytics_export"))
+
+	load = database.ServerLoad{ActiveConns: 51}
+	assert.False(t, s.Allow(context.Background(), "analytics_export"))
+
+	loadErr = errors.New("connection refused")
+	assert.False(t, s.Allow(context.Background(), "analytics_export"))
+
+	assert.Equal(t, map[string]int64{
+		SkipReplicationLag: 1,
+		SkipActiveConns:    1,
+		SkipLoadUnknown:    1,
+	}, s.Stats().Skipped["analytics_export"])
+}
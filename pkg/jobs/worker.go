@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/workpool"
+)
+
+// Worker defaults, used for zero WorkerConfig fields.
+const (
+	DefaultPollInterval = time.Second
+	DefaultVisibility   = 30 * time.Second
+	maxBackoff          = 5 * time.Minute
+)
+
+// WorkerConfig tunes a Worker.
+type WorkerConfig struct {
+	// PollInterval is how long the worker waits after finding the queue empty.
+	PollInterval time.Duration
+	// Visibility is how long a dequeued job stays hidden from other workers. The
+	// handler's context is canceled when it runs out, as the job may be redelivered.
+	Visibility time.Duration
+	// Backoff returns the delay before retrying a job whose attempt failed
+	// (default: 1s doubling per attempt, capped at 5m).
+	Backoff func(attempt int) time.Duration
+	// Pool, if set, runs the handler, so that jobs are processed concurrently up to
+	// the pool's size. Jobs are only dequeued while the pool has room, leaving the rest
+	// in the queue, and must still finish within Visibility of being dequeued.
+	Pool *workpool.Pool
+	// Clock times the waits between polls (default: clock.Real). Visibility deadlines
+	// are context deadlines, so they always follow the wall clock.
+	Clock clock.Clock
+}
+
+// WorkerStats is a snapshot of a Worker's counters.
+type WorkerStats struct {
+	Completed int64 `json:"completed"`
+	Retried   int64 `json:"retried"`
+	Failed    int64 `json:"failed"` // Jobs given up on (permanent error or no attempts left)
+}
+
+// Worker processes the jobs of one queue with a handler.
+type Worker struct {
+	store   Store
+	queue   string
+	handler Handler
+	cfg     WorkerConfig
+
+	completed atomic.Int64
+	retried   atomic.Int64
+	failed    atomic.Int64
+}
+
+// NewWorker creates a Worker that runs handler for the jobs of queue in store.
+func NewWorker(store Store, queue string, handler Handler, cfg WorkerConfig) *Worker {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.Visibility <= 0 {
+		cfg.Visibility = DefaultVisibility
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = defaultBackoff
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real
+	}
+	return &Worker{store: store, queue: queue, handler: handler, cfg: cfg}
+}
+
+// Stats returns the worker's counters.
+func (w *Worker) Stats() WorkerStats {
+	return WorkerStats{
+		Completed: w.completed.Load(),
+		Retried:   w.retried.Load(),
+		Failed:    w.failed.Load(),
+	}
+}
+
+// Run processes jobs until ctx is cancelled, draining the queue before each wait of
+// PollInterval. Store errors are logged and retried after PollInterval.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		found, err := w.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Str("queue", w.queue).Msg("job queue error")
+		}
+		if found && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.cfg.Clock.After(w.cfg.PollInterval):
+		}
+	}
+}
+
+// RunOnce processes at most one due job and reports whether there was one. With a
+// Pool, it returns once the job is submitted, or without dequeuing while the pool is
+// full.
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	if w.cfg.Pool != nil && !w.cfg.Pool.HasRoom() {
+		return false, nil
+	}
+	job, err := w.store.Dequeue(ctx, w.queue, w.cfg.Visibility)
+	if err != nil || job == nil {
+		return false, err
+	}
+	deadline := time.Now().Add(w.cfg.Visibility)
+
+	if w.cfg.Pool == nil {
+		return true, w.process(ctx, job, deadline)
+	}
+	err = w.cfg.Pool.Submit(ctx, func() {
+		if err := w.process(ctx, job, deadline); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Str("queue", w.queue).Msg("job queue error")
+		}
+	})
+	if err != nil {
+		// Another submitter of a shared pool took the room, or shutdown began: retry
+		// the job shortly, which costs it an attempt
+		return true, w.store.Retry(context.WithoutCancel(ctx), job, w.cfg.PollInterval, err)
+	}
+	return true, nil
+}
+
+// process runs the handler for job until deadline and acknowledges the job.
+func (w *Worker) process(ctx context.Context, job *Job, deadline time.Time) error {
+	handlerCtx, cancel := context.WithDeadline(ctx, deadline)
+	err := w.handler(handlerCtx, job)
+	cancel()
+
+	// Acknowledge even if ctx was canceled meanwhile, so shutdown does not redeliver
+	// jobs that already ran.
+	ctx = context.WithoutCancel(ctx)
+	switch {
+	case err == nil:
+		w.completed.Add(1)
+		return w.store.Complete(ctx, job)
+	case IsPermanent(err) || job.Attempt >= job.MaxAttempts:
+		w.failed.Add(1)
+		log.Warn().
+			Err(err).
+			Str("queue", w.queue).
+			Int64("job_id", job.ID).
+			Int("attempt", job.Attempt).
+			Msg("job failed")
+		return w.store.Fail(ctx, job, err)
+	default:
+		w.retried.Add(1)
+		return w.store.Retry(ctx, job, w.cfg.Backoff(job.Attempt), err)
+	}
+}
+
+// defaultBackoff doubles from 1s per attempt, capped at maxBackoff.
+func defaultBackoff(attempt int) time.Duration {
+	if attempt > 20 { // Avoid overflowing the shift
+		return maxBackoff
+	}
+	return min(time.Second<<max(attempt-1, 0), maxBackoff)
+}
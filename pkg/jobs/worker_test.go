@@ -0,0 +1,263 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/clock"
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/workpool"
+)
+
+// fakeStore hands out its jobs in order and records how they were acknowledged.
+type fakeStore struct {
+	jobs       []*Job
+	dequeueErr error
+
+	completed []int64
+	retried   map[int64]time.Duration
+	failed    map[int64]error
+}
+
+func newFakeStore(jobs ...*Job) *fakeStore {
+	return &fakeStore{jobs: jobs, retried: map[int64]time.Duration{}, failed: map[int64]error{}}
+}
+
+func (s *fakeStore) Dequeue(ctx context.Context, queue string, visibility time.Duration) (*Job, error) {
+	if s.dequeueErr != nil {
+		return nil, s.dequeueErr
+	}
+	if len(s.jobs) == 0 {
+		return nil, nil
+	}
+	job := s.jobs[0]
+	s.jobs = s.jobs[1:]
+	return job, nil
+}
+
+func (s *fakeStore) Complete(ctx context.Context, job *Job) error {
+	s.completed = append(s.completed, job.ID)
+	return nil
+}
+
+func (s *fakeStore) Retry(ctx context.Context, job *Job, delay time.Duration, cause error) error {
+	s.retried[job.ID] = delay
+	return nil
+}
+
+func (s *fakeStore) Fail(ctx context.Context, job *Job, cause error) error {
+	s.failed[job.ID] = cause
+	return nil
+}
+
+func TestWorker_RunOnce_Completes(t *testing.T) {
+	store := newFakeStore(&Job{ID: 1, Payload: []byte(`{"a":1}`), Attempt: 1, MaxAttempts: 3})
+	var got *Job
+	w := NewWorker(store, "test", func(ctx context.Context, job *Job) error {
+		got = job
+		return nil
+	}, WorkerConfig{})
+
+	found, err := w.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.JSONEq(t, `{"a":1}`, string(got.Payload))
+	assert.Equal(t, []int64{1}, store.completed)
+	assert.Equal(t, WorkerStats{Completed: 1}, w.Stats())
+}
+
+func TestWorker_RunOnce_Empty(t *testing.T) {
+	w := NewWorker(newFakeStore(), "test", func(context.Context, *Job) error {
+		t.Fatal("handler must not run")
+		return nil
+	}, WorkerConfig{})
+
+	found, err := w.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestWorker_RunOnce_RetriesWithBackoff(t *testing.T) {
+	store := newFakeStore(&Job{ID: 1, Attempt: 2, MaxAttempts: 3})
+	w := NewWorker(store, "test", func(context.Context, *Job) error {
+		return errors.New("endpoint unavailable")
+	}, WorkerConfig{})
+
+	_, err := w.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, store.retried[1])
+	assert.Empty(t, store.failed)
+	assert.Equal(t, WorkerStats{Retried: 1}, w.Stats())
+}
+
+func TestWorker_RunOnce_FailsWhenAttemptsExhausted(t *testing.T) {
+	store := newFakeStore(&Job{ID: 1, Attempt: 3, MaxAttempts: 3})
+	cause := errors.New("endpoint unavailable")
+	w := NewWorker(store, "test", func(context.Context, *Job) error { return cause }, WorkerConfig{})
+
+	_, err := w.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, cause, store.failed[1])
+	assert.Empty(t, store.retried)
+}
+
+func TestWorker_RunOnce_FailsPermanentError(t *testing.T) {
+	store := newFakeStore(&Job{ID: 1, Attempt: 1, MaxAttempts: 10})
+	w := NewWorker(store, "test", func(context.Context, *Job) error {
+		return Permanent(errors.New("bad payload"))
+	}, WorkerConfig{})
+
+	_, err := w.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Contains(t, store.failed, int64(1))
+	assert.Equal(t, WorkerStats{Failed: 1}, w.Stats())
+}
+
+func TestWorker_RunOnce_HandlerBoundedByVisibility(t *testing.T) {
+	store := newFakeStore(&Job{ID: 1, Attempt: 1, MaxAttempts: 3})
+	w := NewWorker(store, "test", func(ctx context.Context, _ *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WorkerConfig{Visibility: 10 * time.Millisecond})
+
+	_, err := w.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Contains(t, store.retried, int64(1), "an overrunning handler is retried")
+}
+
+func TestWorker_RunOnce_DequeueError(t *testing.T) {
+	store := newFakeStore()
+	store.dequeueErr = errors.New("connection refused")
+	w := NewWorker(store, "test", func(context.Context, *Job) error { return nil }, WorkerConfig{})
+
+	found, err := w.RunOnce(context.Background())
+
+	assert.False(t, found)
+	assert.ErrorIs(t, err, store.dequeueErr)
+}
+
+func TestWorker_Run_DrainsQueueUntilCanceled(t *testing.T) {
+	store := newFakeStore(&Job{ID: 1, Attempt: 1, MaxAttempts: 1}, &Job{ID: 2, Attempt: 1, MaxAttempts: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewWorker(store, "test", func(_ context.Context, job *Job) error {
+		if job.ID == 2 {
+			cancel()
+		}
+		return nil
+	}, WorkerConfig{PollInterval: time.Hour})
+
+	w.Run(ctx) // Returns once canceled, without waiting for the poll interval
+
+	assert.Equal(t, []int64{1, 2}, store.completed)
+}
+
+func TestWorker_Run_PollsEveryInterval(t *testing.T) {
+	store := newFakeStore()
+	clk := clock.NewFake(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	w := NewWorker(store, "test", func(context.Context, *Job) error { return nil },
+		WorkerConfig{PollInterval: time.Minute, Clock: clk})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	clk.BlockUntil(1) // Found the queue empty and waits
+	store.jobs = []*Job{{ID: 1, Attempt: 1, MaxAttempts: 1}}
+	clk.Advance(time.Minute)
+	clk.BlockUntil(1) // Drained the queue and waits again
+	cancel()
+	<-done
+
+	assert.Equal(t, []int64{1}, store.completed)
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, defaultBackoff(1))
+	assert.Equal(t, 4*time.Second, defaultBackoff(3))
+	assert.Equal(t, maxBackoff, defaultBackoff(10))
+	assert.Equal(t, maxBackoff, defaultBackoff(100))
+}
+
+func TestPermanent(t *testing.T) {
+	cause := errors.New("bad payload")
+
+	assert.Nil(t, Permanent(nil))
+	assert.True(t, IsPermanent(Permanent(cause)))
+	assert.ErrorIs(t, Permanent(cause), cause)
+	assert.False(t, IsPermanent(cause))
+}
+
+// lockedStore is a fakeStore safe for the goroutines of a work pool.
+type lockedStore struct {
+	mu sync.Mutex
+	*fakeStore
+}
+
+func (s *lockedStore) Dequeue(ctx context.Context, queue string, visibility time.Duration) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fakeStore.Dequeue(ctx, queue, visibility)
+}
+
+func (s *lockedStore) Complete(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fakeStore.Complete(ctx, job)
+}
+
+func TestWorker_RunOnce_Pool(t *testing.T) {
+	store := &lockedStore{fakeStore: newFakeStore(
+		&Job{ID: 1, Attempt: 1, MaxAttempts: 3},
+		&Job{ID: 2, Attempt: 1, MaxAttempts: 3},
+		&Job{ID: 3, Attempt: 1, MaxAttempts: 3},
+	)}
+	pool, err := workpool.New(workpool.Config{Size: 2, Overflow: workpool.Block})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	poolDone := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(poolDone)
+	}()
+	defer func() {
+		cancel()
+		<-poolDone
+	}()
+
+	release := make(chan struct{})
+	w := NewWorker(store, "test", func(context.Context, *Job) error {
+		<-release
+		return nil
+	}, WorkerConfig{Pool: pool})
+
+	// Two jobs run at once; the third stays queued while the pool is full
+	for range 2 {
+		found, err := w.RunOnce(ctx)
+		require.NoError(t, err)
+		require.True(t, found)
+	}
+	require.Eventually(t, func() bool { return pool.Stats().Busy == 2 }, time.Second, time.Millisecond)
+	found, err := w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.False(t, found, "nothing is dequeued while the pool is full")
+
+	close(release)
+	require.Eventually(t, func() bool { return w.Stats().Completed == 2 }, time.Second, time.Millisecond)
+	found, err = w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, found)
+	require.Eventually(t, func() bool { return w.Stats().Completed == 3 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,150 @@
+// Package lifecycle starts and stops the process's subsystems in dependency order.
+// Each component names the components it depends on; it is started after them and
+// stopped before them, so e.g. the HTTP server stops accepting requests before the
+// workers it feeds drain, and those drain before the database pool closes.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Component is one subsystem.
+type Component struct {
+	Name      string
+	DependsOn []string // Components that must outlive this one; they must be added first
+
+	// Run, if set, runs the component in the background from Start until its context
+	// is cancelled at shutdown (e.g. a polling loop).
+	Run func(ctx context.Context)
+	// Stop, if set, releases the component at shutdown, after Run's context is
+	// cancelled. Shutdown then waits for Run to return.
+	Stop func(ctx context.Context) error
+}
+
+// component is a Component with its shutdown state.
+type component struct {
+	Component
+	dependents []*component
+
+	cancel  context.CancelFunc
+	running chan struct{} // Closed when Run returns (or immediately without Run)
+	stopped chan struct{} // Closed when the component is stopped
+}
+
+// Manager holds the components of a process. It is not safe for concurrent use;
+// add components, Start, then Shutdown.
+type Manager struct {
+	components []*component
+	byName     map[string]*component
+}
+
+// New creates an empty Manager.
+func New() *Manager {
+	return &Manager{byName: map[string]*component{}}
+}
+
+// Add registers c. Its dependencies must already be registered, so registration order
+// is a valid start order and dependency cycles cannot be built.
+func (m *Manager) Add(c Component) error {
+	if _, ok := m.byName[c.Name]; ok {
+		return fmt.Errorf("component %q already added", c.Name)
+	}
+	comp := &component{Component: c}
+	for _, dep := range c.DependsOn {
+		d, ok := m.byName[dep]
+		if !ok {
+			return fmt.Errorf("component %q depends on unknown component %q", c.Name, dep)
+		}
+		d.dependents = append(d.dependents, comp)
+	}
+	m.components = append(m.components, comp)
+	m.byName[c.Name] = comp
+	return nil
+}
+
+// Names returns the names of the registered components in registration order, e.g.
+// as the dependencies of a component that must stop before all others.
+func (m *Manager) Names() []string {
+	names := make([]string, len(m.components))
+	for i, c := range m.components {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Start runs the components' Run functions in the background, in registration order.
+func (m *Manager) Start() {
+	for _, c := range m.components {
+		c.running = make(chan struct{})
+		c.stopped = make(chan struct{})
+		if c.Run == nil {
+			close(c.running)
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		c.cancel = cancel
+		go func() {
+			defer close(c.running)
+			c.Run(ctx)
+		}()
+	}
+}
+
+// Shutdown stops every component once all components depending on it have stopped;
+// independent components stop concurrently. A component is stopped by cancelling its
+// Run context, calling Stop and waiting for Run to return. When ctx ends first, the
+// remaining components are still stopped without waiting for their Run, so resources
+// such as the database pool are always released. It returns the Stop errors and
+// ctx's error if components were abandoned.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	errs := make(chan error, len(m.components))
+	for _, c := range m.components {
+		go func() {
+			for _, d := range c.dependents {
+				<-d.stopped
+			}
+			errs <- c.stop(ctx)
+			close(c.stopped)
+		}()
+	}
+
+	var all []error
+	for range m.components {
+		if err := <-errs; err != nil {
+			all = append(all, err)
+		}
+	}
+	return errors.Join(all...)
+}
+
+// stop stops c, giving up on waiting for Run once ctx ends.
+func (c *component) stop(ctx context.Context) error {
+	start := time.Now()
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	var err error
+	if c.Stop != nil {
+		if stopErr := c.Stop(ctx); stopErr != nil {
+			err = fmt.Errorf("stop %s: %w", c.Name, stopErr)
+		}
+	}
+	select {
+	case <-c.running:
+	case <-ctx.Done():
+		err = errors.Join(err, fmt.Errorf("stop %s: %w", c.Name, ctx.Err()))
+	}
+
+	event := log.Info()
+	if err != nil {
+		event = log.Error().Err(err)
+	}
+	event.Str("component", c.Name).Dur("took", time.Since(start)).Msg("component stopped")
+	return err
+}
@@ -0,0 +1,117 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the order in which components stop.
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *recorder) record(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order = append(r.order, name)
+}
+
+// loop is a Run function recording name once cancelled.
+func (r *recorder) loop(name string) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		<-ctx.Done()
+		r.record(name)
+	}
+}
+
+// closer is a Stop function recording name.
+func (r *recorder) closer(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.record(name)
+		return nil
+	}
+}
+
+func TestManager_StopsDependentsFirst(t *testing.T) {
+	var r recorder
+	m := New()
+	require.NoError(t, m.Add(Component{Name: "pool", Stop: r.closer("pool")}))
+	require.NoError(t, m.Add(Component{Name: "buffer", Stop: r.closer("buffer")}))
+	require.NoError(t, m.Add(Component{Name: "replay", DependsOn: []string{"pool", "buffer"}, Run: r.loop("replay")}))
+	require.NoError(t, m.Add(Component{Name: "http", DependsOn: []string{"pool", "buffer", "replay"}, Stop: r.closer("http")}))
+
+	m.Start()
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	require.Len(t, r.order, 4)
+	assert.Equal(t, []string{"http", "replay"}, r.order[:2])
+	assert.ElementsMatch(t, []string{"pool", "buffer"}, r.order[2:], "independent components stop in any order")
+}
+
+func TestManager_StopWaitsForRun(t *testing.T) {
+	var r recorder
+	m := New()
+	require.NoError(t, m.Add(Component{Name: "pool", Stop: r.closer("pool")}))
+	require.NoError(t, m.Add(Component{
+		Name:      "worker",
+		DependsOn: []string{"pool"},
+		Run: func(ctx context.Context) {
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond) // Finishing the current job
+			r.record("worker drained")
+		},
+	}))
+
+	m.Start()
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	assert.Equal(t, []string{"worker drained", "pool"}, r.order)
+}
+
+func TestManager_TimeoutStillReleasesDependencies(t *testing.T) {
+	var r recorder
+	m := New()
+	require.NoError(t, m.Add(Component{Name: "pool", Stop: r.closer("pool")}))
+	require.NoError(t, m.Add(Component{
+		Name:      "stuck",
+		DependsOn: []string{"pool"},
+		Run:       func(ctx context.Context) { select {} },
+	}))
+
+	m.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := m.Shutdown(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stop stuck")
+	assert.Equal(t, []string{"pool"}, r.order)
+}
+
+func TestManager_JoinsStopErrors(t *testing.T) {
+	m := New()
+	require.NoError(t, m.Add(Component{Name: "pool", Stop: func(ctx context.Context) error { return errors.New("close failed") }}))
+
+	m.Start()
+	err := m.Shutdown(context.Background())
+
+	assert.EqualError(t, err, "stop pool: close failed")
+}
+
+func TestManager_Add(t *testing.T) {
+	m := New()
+	require.NoError(t, m.Add(Component{Name: "pool"}))
+
+	assert.EqualError(t, m.Add(Component{Name: "pool"}), `component "pool" already added`)
+	require.NoError(t, m.Add(Component{Name: "replay", DependsOn: []string{"pool"}}))
+	assert.Equal(t, []string{"pool", "replay"}, m.Names())
+	assert.EqualError(t, m.Add(Component{Name: "http", DependsOn: []string{"outbox"}}),
+		`component "http" depends on unknown component "outbox"`)
+}
@@ -0,0 +1,97 @@
+// Package objstore writes objects to a bucket of an object store: a local directory
+// (Local) or any service speaking the S3 API with Signature Version 4 (S3), which covers
+// Amazon S3, MinIO and Google Cloud Storage through its XML API with HMAC keys.
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey is returned for a key that is empty, absolute or has empty, "." or ".."
+// segments.
+var ErrInvalidKey = errors.New("invalid object key")
+
+// Store is a bucket of an object store. Keys are slash-separated paths such as
+// "production/claims/date=2026-10-15/claims.parquet".
+type Store interface {
+	// Put writes body as the object at key, replacing any object there. The object is
+	// only visible once complete.
+	Put(ctx context.Context, key string, body io.ReadSeeker) error
+	// Exists reports whether an object exists at key.
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// checkKey returns ErrInvalidKey if key is not a relative path of non-empty segments.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+// Local is a Store keeping objects as files under a directory, e.g. a mounted volume
+// the warehouse loads from, or for development.
+type Local struct {
+	dir string
+}
+
+var _ Store = (*Local)(nil)
+
+// NewLocal returns a Store keeping objects under dir, which must exist.
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+// Put writes body to a temporary file beside the object's, then renames it into place.
+func (l *Local) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".put-*")
+	if err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	return nil
+}
+
+// Exists reports whether the object's file exists.
+func (l *Local) Exists(ctx context.Context, key string) (bool, error) {
+	if err := checkKey(key); err != nil {
+		return false, err
+	}
+	_, err := os.Stat(filepath.Join(l.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat %s: %w", key, err)
+	}
+	return true, nil
+}
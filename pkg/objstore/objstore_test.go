@@ -0,0 +1,45 @@
+package objstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocal(dir)
+	ctx := context.Background()
+
+	ok, err := store.Exists(ctx, "staging/claims/date=2026-10-15/claims.parquet")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.Put(ctx, "staging/claims/date=2026-10-15/claims.parquet", strings.NewReader("v1")))
+	require.NoError(t, store.Put(ctx, "staging/claims/date=2026-10-15/claims.parquet", strings.NewReader("v2")))
+
+	ok, err = store.Exists(ctx, "staging/claims/date=2026-10-15/claims.parquet")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	data, err := os.ReadFile(filepath.Join(dir, "staging", "claims", "date=2026-10-15", "claims.parquet"))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(data))
+
+	entries, err := os.ReadDir(filepath.Join(dir, "staging", "claims", "date=2026-10-15"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files left behind")
+}
+
+func TestLocal_InvalidKey(t *testing.T) {
+	store := NewLocal(t.TempDir())
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a//b", "a/./b", "a/"} {
+		assert.ErrorIs(t, store.Put(context.Background(), key, strings.NewReader("x")), ErrInvalidKey, key)
+		_, err := store.Exists(context.Background(), key)
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
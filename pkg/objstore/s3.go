@@ -0,0 +1,197 @@
+package objstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Config configures an S3 Store.
+type S3Config struct {
+	// Endpoint is the service's base URL, e.g. https://storage.googleapis.com or
+	// http://minio:9000. Empty for Amazon S3 in Region.
+	Endpoint        string
+	Region          string // e.g. us-east-1; "auto" for GCS
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket as the first path segment (endpoint/bucket/key)
+	// rather than as a subdomain (bucket.endpoint/key), as MinIO needs.
+	PathStyle bool
+}
+
+// S3 is a Store of a bucket of a service speaking the S3 API, signing its requests with
+// Signature Version 4.
+type S3 struct {
+	cfg    S3Config
+	base   *url.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+var _ Store = (*S3)(nil)
+
+// NewS3 returns a Store of the bucket in cfg, sending requests with client.
+func NewS3(cfg S3Config, client *http.Client) (*S3, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, fmt.Errorf("bucket and region are required")
+	}
+	return &S3{cfg: cfg, base: base, client: client, now: time.Now}, nil
+}
+
+// Put uploads body with a single PutObject request, which S3 caps at 5 GiB.
+func (s *S3) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return fmt.Errorf("put %s: hash body: %w", key, err)
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("put %s: rewind body: %w", key, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), io.NopCloser(body))
+	if err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	req.ContentLength = size
+	s.sign(req, hex.EncodeToString(hash.Sum(nil)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("put %s: %w", key, responseError(resp))
+	}
+	return nil
+}
+
+// Exists sends a HeadObject request.
+func (s *S3) Exists(ctx context.Context, key string) (bool, error) {
+	if err := checkKey(key); err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(key), nil)
+	if err != nil {
+		return false, fmt.Errorf("head %s: %w", key, err)
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("head %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("head %s: %w", key, responseError(resp))
+	}
+}
+
+// objectURL returns the URL of the object at key, its path escaped as signed.
+func (s *S3) objectURL(key string) string {
+	u := *s.base
+	path := strings.TrimSuffix(u.Path, "/") + "/"
+	if s.cfg.PathStyle {
+		path += s.cfg.Bucket + "/"
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	u.Path = path + key
+	u.RawPath = uriEncode(path) + uriEncode(key)
+	return u.String()
+}
+
+// sign adds the Signature Version 4 headers of req, whose body hashes to payloadHash.
+func (s *S3) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"", // No query
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.cfg.SecretAccessKey, date, s.cfg.Region, "s3"), toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// signingKey derives the Signature Version 4 key of secret for date, region and service.
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode percent-encodes the path s as Signature Version 4 does: every byte but "/"
+// and unreserved characters.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// responseError returns an error of an unexpected response, with the start of its body,
+// where the service explains the error.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
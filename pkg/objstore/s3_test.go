@@ -0,0 +1,97 @@
+package objstore
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKey(t *testing.T) {
+	// Example of the Signature Version 4 documentation.
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
+
+func TestS3_Put(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got, body = r, string(b)
+	}))
+	defer server.Close()
+
+	store, err := NewS3(S3Config{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "analytics",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+	}, server.Client())
+	require.NoError(t, err)
+	store.now = func() time.Time { return time.Date(2026, 10, 16, 1, 2, 3, 0, time.UTC) }
+
+	require.NoError(t, store.Put(context.Background(), "prod/claims/date=2026-10-15/claims.parquet", strings.NewReader("PAR1")))
+
+	require.NotNil(t, got)
+	assert.Equal(t, http.MethodPut, got.Method)
+	assert.Equal(t, "/analytics/prod/claims/date%3D2026-10-15/claims.parquet", got.URL.EscapedPath())
+	assert.Equal(t, "PAR1", body)
+	assert.Equal(t, int64(4), got.ContentLength)
+	assert.Equal(t, "20261016T010203Z", got.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "fbc62d3b511368ee275ddc74117d8689b430e1427220e25d30816201d89ca7b6", got.Header.Get("X-Amz-Content-Sha256"))
+	assert.True(t, strings.HasPrefix(got.Header.Get("Authorization"),
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20261016/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="))
+}
+
+func TestS3_Exists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/analytics/present":
+			w.WriteHeader(http.StatusOK)
+		case "/analytics/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewS3(S3Config{Endpoint: server.URL, Region: "auto", Bucket: "analytics", PathStyle: true}, server.Client())
+	require.NoError(t, err)
+
+	ok, err := store.Exists(context.Background(), "present")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.Exists(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = store.Exists(context.Background(), "forbidden")
+	assert.ErrorContains(t, err, "403 Forbidden")
+}
+
+func TestS3_ObjectURL(t *testing.T) {
+	store, err := NewS3(S3Config{Region: "eu-west-1", Bucket: "analytics"}, http.DefaultClient)
+	require.NoError(t, err)
+	assert.Equal(t, "https://analytics.s3.eu-west-1.amazonaws.com/a%20b/c.parquet", store.objectURL("a b/c.parquet"))
+
+	store, err = NewS3(S3Config{Endpoint: "http://minio:9000/", Region: "us-east-1", Bucket: "analytics", PathStyle: true}, http.DefaultClient)
+	require.NoError(t, err)
+	assert.Equal(t, "http://minio:9000/analytics/c.parquet", store.objectURL("c.parquet"))
+}
+
+func TestNewS3_Invalid(t *testing.T) {
+	_, err := NewS3(S3Config{Endpoint: "minio:9000", Region: "us-east-1", Bucket: "b"}, http.DefaultClient)
+	assert.Error(t, err)
+	_, err = NewS3(S3Config{Region: "us-east-1"}, http.DefaultClient)
+	assert.Error(t, err)
+}
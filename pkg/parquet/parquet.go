@@ -0,0 +1,391 @@
+// Package parquet writes flat Apache Parquet files: a fixed list of columns of int64,
+// string, timestamp and bool values, each required or optional. Values are PLAIN
+// encoded and uncompressed in one data page per column per row group, which every
+// Parquet reader supports; there is no nesting, dictionary encoding or compression.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DefaultRowGroupSize is how many rows a row group holds unless SetRowGroupSize is called.
+const DefaultRowGroupSize = 100_000
+
+// magic starts and ends every Parquet file.
+const magic = "PAR1"
+
+// createdBy is the writer recorded in the file footer.
+const createdBy = "scalable-coupon-system"
+
+// ErrClosed is returned when writing to a closed Writer.
+var ErrClosed = errors.New("parquet writer closed")
+
+// Type is the type of a column's values.
+type Type int
+
+// Column types.
+const (
+	Int64     Type = iota // int64 or int
+	String                // string, annotated UTF8
+	Timestamp             // time.Time, stored as microseconds since the Unix epoch (UTC)
+	Bool                  // bool
+)
+
+// Column describes a column of the file.
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool // Accepts nil values
+}
+
+// Parquet format enum values (parquet.thrift).
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	pageData          = 0
+)
+
+// Writer writes rows to a Parquet file. Rows are buffered until a row group is full;
+// the file is complete once Close returns. Not safe for concurrent use.
+type Writer struct {
+	w            io.Writer
+	offset       int64
+	columns      []Column
+	chunks       []columnChunk
+	rows         int // Rows buffered in chunks
+	rowGroupSize int
+	rowGroups    []rowGroup
+	numRows      int64
+	closed       bool
+}
+
+// columnChunk buffers a column's values for the current row group.
+type columnChunk struct {
+	values  bytes.Buffer // PLAIN encoded non-null values; booleans are in bools
+	bools   []bool
+	defined []bool // Whether each row has a value; only for optional columns
+}
+
+// rowGroup is a written row group, recorded in the footer.
+type rowGroup struct {
+	columns []columnMeta
+	size    int64
+	rows    int64
+}
+
+// columnMeta is a written column chunk.
+type columnMeta struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// NewWriter returns a Writer of a file with columns to w.
+func NewWriter(w io.Writer, columns []Column) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: no columns")
+	}
+	seen := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		if col.Name == "" || seen[col.Name] {
+			return nil, fmt.Errorf("parquet: column name %q is empty or repeated", col.Name)
+		}
+		if col.Type < Int64 || col.Type > Bool {
+			return nil, fmt.Errorf("parquet: column %s has unknown type %d", col.Name, col.Type)
+		}
+		seen[col.Name] = true
+	}
+	return &Writer{
+		w:            w,
+		columns:      columns,
+		chunks:       make([]columnChunk, len(columns)),
+		rowGroupSize: DefaultRowGroupSize,
+	}, nil
+}
+
+// SetRowGroupSize sets how many rows each row group holds. Larger row groups compress
+// and scan better but are buffered in memory until full.
+func (w *Writer) SetRowGroupSize(rows int) {
+	w.rowGroupSize = max(rows, 1)
+}
+
+// Write adds a row, with one value per column in column order. A row with a value of the
+// wrong type, or nil in a required column, is rejected without being added.
+func (w *Writer) Write(row ...any) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: row has %d values, want %d", len(row), len(w.columns))
+	}
+	for i, v := range row {
+		if err := check(w.columns[i], v); err != nil {
+			return err
+		}
+	}
+	for i, v := range row {
+		w.chunks[i].append(w.columns[i], v)
+	}
+	w.rows++
+	if w.rows >= w.rowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Rows returns how many rows were written.
+func (w *Writer) Rows() int64 {
+	return w.numRows + int64(w.rows)
+}
+
+// check returns an error if v is not a value of col.
+func check(col Column, v any) error {
+	if v == nil {
+		if col.Optional {
+			return nil
+		}
+		return fmt.Errorf("parquet: column %s is required", col.Name)
+	}
+	var ok bool
+	switch col.Type {
+	case Int64:
+		switch v.(type) {
+		case int64, int:
+			ok = true
+		}
+	case String:
+		_, ok = v.(string)
+	case Timestamp:
+		_, ok = v.(time.Time)
+	case Bool:
+		_, ok = v.(bool)
+	}
+	if !ok {
+		return fmt.Errorf("parquet: column %s does not accept %T", col.Name, v)
+	}
+	return nil
+}
+
+// append adds v, already checked against col.
+func (c *columnChunk) append(col Column, v any) {
+	if col.Optional {
+		c.defined = append(c.defined, v != nil)
+	}
+	switch v := v.(type) {
+	case nil:
+	case int:
+		c.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+	case int64:
+		c.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+	case string:
+		c.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+		c.values.WriteString(v)
+	case time.Time:
+		c.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v.UnixMicro())))
+	case bool:
+		c.bools = append(c.bools, v)
+	}
+}
+
+// page returns the data of the chunk's data page: definition levels, then values.
+func (c *columnChunk) page(col Column) []byte {
+	var page []byte
+	if col.Optional {
+		levels := rleLevels(c.defined)
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+		page = append(page, levels...)
+	}
+	if col.Type == Bool {
+		packed := make([]byte, (len(c.bools)+7)/8)
+		for i, b := range c.bools {
+			if b {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		return append(page, packed...)
+	}
+	return append(page, c.values.Bytes()...)
+}
+
+// rleLevels encodes definition levels of bit width 1 as RLE runs of the RLE/bit-packing
+// hybrid encoding.
+func rleLevels(defined []bool) []byte {
+	var out []byte
+	for i := 0; i < len(defined); {
+		j := i
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if defined[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+// write writes b to the file, starting it with the magic number if nothing was written.
+func (w *Writer) write(b []byte) error {
+	if w.offset == 0 {
+		n, err := io.WriteString(w.w, magic)
+		w.offset += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+// flush writes the buffered rows as a row group.
+func (w *Writer) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	group := rowGroup{rows: int64(w.rows)}
+	for i, col := range w.columns {
+		data := w.chunks[i].page(col)
+
+		var header thriftWriter
+		header.begin()
+		header.i32(1, pageData)
+		header.i32(2, int32(len(data)))
+		header.i32(3, int32(len(data)))
+		header.structField(5) // DataPageHeader
+		header.i32(1, int32(w.rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.end()
+		header.end()
+
+		offset := w.offset
+		if err := w.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if offset == 0 {
+			offset = int64(len(magic))
+		}
+		if err := w.write(data); err != nil {
+			return err
+		}
+		size := int64(header.buf.Len() + len(data))
+		group.columns = append(group.columns, columnMeta{offset: offset, size: size, values: int64(w.rows)})
+		group.size += size
+		w.chunks[i] = columnChunk{}
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.numRows += group.rows
+	w.rows = 0
+	return nil
+}
+
+// Close writes the buffered rows and the file footer. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err := w.flush(); err != nil {
+		return err
+	}
+	footer := w.footer()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	return w.write(append(footer, magic...))
+}
+
+// footer returns the file's FileMetaData.
+func (w *Writer) footer() []byte {
+	var t thriftWriter
+	t.begin()
+	t.i32(1, 1) // Format version
+	t.list(2, thriftStruct, len(w.columns)+1)
+	t.begin() // Root of the schema
+	t.string(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.end()
+	for _, col := range w.columns {
+		t.begin()
+		physical, converted := col.types()
+		t.i32(1, physical)
+		if col.Optional {
+			t.i32(3, repetitionOptional)
+		} else {
+			t.i32(3, repetitionRequired)
+		}
+		t.string(4, col.Name)
+		if converted >= 0 {
+			t.i32(6, converted)
+		}
+		t.end()
+	}
+	t.i64(3, w.numRows)
+	t.list(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		t.begin()
+		t.list(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			col := w.columns[i]
+			physical, _ := col.types()
+			t.begin()
+			t.i64(2, chunk.offset)
+			t.structField(3) // ColumnMetaData
+			t.i32(1, physical)
+			t.list(2, thriftI32, 2)
+			t.varint(encodingPlain)
+			t.varint(encodingRLE)
+			t.list(3, thriftBinary, 1)
+			t.binary([]byte(col.Name))
+			t.i32(4, codecUncompressed)
+			t.i64(5, chunk.values)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.rows)
+		t.end()
+	}
+	t.string(6, createdBy)
+	t.end()
+	return t.buf.Bytes()
+}
+
+// types returns the physical type of the column's values and their converted type, -1
+// for none.
+func (col Column) types() (physical, converted int32) {
+	switch col.Type {
+	case String:
+		return physicalByteArray, convertedUTF8
+	case Timestamp:
+		return physicalInt64, convertedTimestampMicros
+	case Bool:
+		return physicalBoolean, -1
+	default:
+		return physicalInt64, -1
+	}
+}
@@ -0,0 +1,316 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the golden files in testdata: go test ./pkg/parquet -update.
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// thriftReader decodes the Thrift compact protocol into structs of field ID to value:
+// int64 for integers, []byte for binaries, []any for lists and map[int16]any for structs.
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.b[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		r.pos += n
+		return r.b[r.pos-n : r.pos]
+	case thriftList:
+		header := r.byte()
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		fields := map[int16]any{}
+		var id int16
+		for {
+			header := r.byte()
+			if header == 0 {
+				return fields
+			}
+			if delta := int16(header >> 4); delta != 0 {
+				id += delta
+			} else {
+				id = int16(r.varint())
+			}
+			fields[id] = r.value(header & 0x0f)
+		}
+	}
+	panic("unsupported thrift type")
+}
+
+// readFile decodes a file written by Writer into its footer and its rows.
+func readFile(t *testing.T, file []byte) (map[int16]any, [][]any) {
+	t.Helper()
+	require.Equal(t, magic, string(file[:4]))
+	require.Equal(t, magic, string(file[len(file)-4:]))
+	size := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := (&thriftReader{b: file[len(file)-8-size : len(file)-8]}).value(thriftStruct).(map[int16]any)
+
+	schema := footer[2].([]any)[1:]
+	var rows [][]any
+	for _, g := range footer[4].([]any) {
+		group := g.(map[int16]any)
+		groupRows := make([][]any, group[3].(int64))
+		for i, c := range group[1].([]any) {
+			meta := c.(map[int16]any)[3].(map[int16]any)
+			element := schema[i].(map[int16]any)
+			r := &thriftReader{b: file, pos: int(meta[9].(int64))}
+			header := r.value(thriftStruct).(map[int16]any)
+			page := &thriftReader{b: file[r.pos : r.pos+int(header[3].(int64))]}
+
+			defined := make([]bool, len(groupRows))
+			for j := range defined {
+				defined[j] = true
+			}
+			if element[3].(int64) == repetitionOptional {
+				end := page.pos + 4 + int(binary.LittleEndian.Uint32(page.b[page.pos:]))
+				page.pos += 4
+				for j := 0; page.pos < end; {
+					run := int(page.uvarint() >> 1)
+					level := page.byte()
+					for ; run > 0; run-- {
+						defined[j] = level == 1
+						j++
+					}
+				}
+			}
+			bit := 0
+			for j := range groupRows {
+				var v any
+				if defined[j] {
+					switch element[1].(int64) {
+					case physicalInt64:
+						n := int64(binary.LittleEndian.Uint64(page.b[page.pos:]))
+						page.pos += 8
+						v = n
+						if element[6] != nil {
+							v = time.UnixMicro(n).UTC()
+						}
+					case physicalByteArray:
+						n := int(binary.LittleEndian.Uint32(page.b[page.pos:]))
+						page.pos += 4 + n
+						v = string(page.b[page.pos-n : page.pos])
+					case physicalBoolean:
+						v = page.b[bit/8]&(1<<(bit%8)) != 0
+						bit++
+					}
+				}
+				groupRows[j] = append(groupRows[j], v)
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+	return footer, rows
+}
+
+// sampleAt is the claimed_at of the sample rows.
+var sampleAt = time.Date(2026, 10, 15, 8, 30, 0, 123456000, time.UTC)
+
+// sampleColumns are the columns of the sample file, one of each type and an optional one.
+var sampleColumns = []Column{
+	{Name: "coupon_name", Type: String},
+	{Name: "claim_sequence", Type: Int64},
+	{Name: "claimed_at", Type: Timestamp},
+	{Name: "region", Type: String, Optional: true},
+	{Name: "unlimited", Type: Bool},
+}
+
+// sampleRows are the rows of the sample file as readers return them.
+var sampleRows = [][]any{
+	{"PROMO", int64(1), sampleAt, "eu", true},
+	{"PROMO", int64(2), sampleAt, nil, false},
+	{"SALE", int64(1), sampleAt, nil, true},
+}
+
+// writeSample writes sampleRows in row groups of 2 rows.
+func writeSample(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, sampleColumns)
+	require.NoError(t, err)
+	w.SetRowGroupSize(2)
+
+	require.NoError(t, w.Write("PROMO", 1, sampleAt, "eu", true))
+	require.NoError(t, w.Write("PROMO", int64(2), sampleAt, nil, false))
+	require.NoError(t, w.Write("SALE", 1, sampleAt, nil, true))
+	assert.Equal(t, int64(3), w.Rows())
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestWriter(t *testing.T) {
+	footer, rows := readFile(t, writeSample(t))
+	assert.Equal(t, int64(3), footer[3], "num_rows")
+	assert.Len(t, footer[4], 2, "row groups")
+	assert.Equal(t, sampleRows, rows)
+}
+
+// TestWriter_Golden pins the bytes Writer produces to testdata/sample.parquet, the file
+// TestWriter_Golden pins the bytes Writer produces to testdata/sample.parquet, the file
+// TestGolden_ReadByPyArrow checks with another implementation: a change to the output
+// shows up as a changed golden file, and is checked again.
+func TestWriter_Golden(t *testing.T) {
+	golden := filepath.Join("testdata", "sample.parquet")
+	file := writeSample(t)
+	if *update {
+		require.NoError(t, os.WriteFile(golden, file, 0o600))
+	}
+
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, want, file, "run go test ./pkg/parquet -update if the change is intended")
+}
+
+// readByPyArrow reads the rows of a Parquet file with pyarrow, Apache Arrow's
+// reader, as column types, nullability and JSON rows, timestamps in microseconds.
+const readByPyArrow = `
+import datetime, json, sys
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+f = pq.ParquetFile(sys.argv[1])
+table = f.read()
+epoch = datetime.datetime(1970, 1, 1, tzinfo=datetime.timezone.utc)
+
+def value(v):
+    if isinstance(v, datetime.datetime):
+        if v.tzinfo is None:
+            v = v.replace(tzinfo=datetime.timezone.utc)
+        return (v - epoch) // datetime.timedelta(microseconds=1)
+    return v
+
+def type_name(t):
+    return "timestamp[%s]" % t.unit if pa.types.is_timestamp(t) else str(t)
+
+json.dump({
+    "created_by": f.metadata.created_by,
+    "types": [type_name(field.type) for field in table.schema],
+    "nullable": [field.nullable for field in table.schema],
+    "rows": [[value(v) for v in row.values()] for row in table.to_pylist()],
+}, sys.stdout)
+`
+
+// TestGolden_ReadByPyArrow reads testdata/sample.parquet with pyarrow, so the file is
+// checked against another implementation of the format rather than readFile's reading
+// of it. Skipped without python3 and pyarrow unless TEST_PARQUET_INTEROP is set, as CI
+// does.
+func TestGolden_ReadByPyArrow(t *testing.T) {
+	if err := exec.Command("python3", "-c", "import pyarrow.parquet").Run(); err != nil && os.Getenv("TEST_PARQUET_INTEROP") == "" {
+		t.Skipf("pyarrow unavailable: %v", err)
+	}
+	out, err := exec.Command("python3", "-c", readByPyArrow, filepath.Join("testdata", "sample.parquet")).Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		t.Log(string(exit.Stderr))
+	}
+	require.NoError(t, err)
+
+	var got struct {
+		CreatedBy string   `json:"created_by"`
+		Types     []string `json:"types"`
+		Nullable  []bool   `json:"nullable"`
+		Rows      [][]any  `json:"rows"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&got))
+
+	assert.Equal(t, createdBy, got.CreatedBy)
+	assert.Equal(t, []string{"string", "int64", "timestamp[us]", "string", "bool"}, got.Types)
+	assert.Equal(t, []bool{false, false, false, true, false}, got.Nullable)
+	require.Len(t, got.Rows, len(sampleRows))
+	for _, row := range got.Rows {
+		require.Len(t, row, len(sampleColumns))
+		for j, v := range row {
+			if n, ok := v.(json.Number); ok {
+				micros, err := n.Int64()
+				require.NoError(t, err)
+				v = micros
+				if sampleColumns[j].Type == Timestamp {
+					v = time.UnixMicro(micros).UTC()
+				}
+			}
+			row[j] = v
+		}
+	}
+	assert.Equal(t, sampleRows, got.Rows)
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{{Name: "name", Type: String}})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	footer, rows := readFile(t, buf.Bytes())
+	assert.Equal(t, int64(0), footer[3])
+	assert.Empty(t, rows)
+	assert.ErrorIs(t, w.Write("late"), ErrClosed)
+}
+
+func TestWriter_RejectsRow(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{{Name: "name", Type: String}, {Name: "amount", Type: Int64}})
+	require.NoError(t, err)
+
+	assert.EqualError(t, w.Write("PROMO"), "parquet: row has 1 values, want 2")
+	assert.EqualError(t, w.Write(nil, 1), "parquet: column name is required")
+	assert.EqualError(t, w.Write("PROMO", "100"), "parquet: column amount does not accept string")
+	require.NoError(t, w.Write("PROMO", 100))
+	require.NoError(t, w.Close())
+
+	_, rows := readFile(t, buf.Bytes())
+	assert.Equal(t, [][]any{{"PROMO", int64(100)}}, rows, "rejected rows are not added")
+}
+
+func TestNewWriter_InvalidColumns(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, nil)
+	assert.Error(t, err)
+	_, err = NewWriter(&bytes.Buffer{}, []Column{{Name: "a"}, {Name: "a"}})
+	assert.Error(t, err)
+	_, err = NewWriter(&bytes.Buffer{}, []Column{{Name: "a", Type: Type(9)}})
+	assert.Error(t, err)
+}
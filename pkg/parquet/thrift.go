@@ -0,0 +1,91 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol field types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift compact protocol structs of Parquet's metadata: page
+// headers and the file footer. Only the types those structs use are supported.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // Last field ID written, per open struct
+}
+
+// begin opens a struct, nested in the current one if any.
+func (w *thriftWriter) begin() {
+	w.last = append(w.last, 0)
+}
+
+// end closes the innermost struct.
+func (w *thriftWriter) end() {
+	w.buf.WriteByte(0) // Stop field
+	w.last = w.last[:len(w.last)-1]
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	top := len(w.last) - 1
+	if delta := id - w.last[top]; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(int64(id))
+	}
+	w.last[top] = id
+}
+
+// varint writes v zigzag encoded, as compact protocol integers are.
+func (w *thriftWriter) varint(v int64) {
+	w.uvarint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (w *thriftWriter) uvarint(v uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (w *thriftWriter) binary(b []byte) {
+	w.uvarint(uint64(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *thriftWriter) string(id int16, v string) {
+	w.field(id, thriftBinary)
+	w.binary([]byte(v))
+}
+
+// list writes the header of a list field of n elements of type elem; the caller writes
+// the elements.
+func (w *thriftWriter) list(id int16, elem byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	w.buf.WriteByte(0xf0 | elem)
+	w.uvarint(uint64(n))
+}
+
+// structField opens a struct field, closed with end.
+func (w *thriftWriter) structField(id int16) {
+	w.field(id, thriftStruct)
+	w.begin()
+}
@@ -0,0 +1,162 @@
+// Package workpool runs tasks on a fixed number of goroutines fed by a bounded queue,
+// so that a burst of work, such as webhook deliveries after a bulk change, cannot
+// spawn unbounded goroutines or connections. One Pool may be shared by every
+// subsystem delivering to the outside world, bounding them together.
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Overflow policies: what Submit does when every worker is busy and the queue is full.
+const (
+	Block  = "block"  // Wait for room in the queue
+	Reject = "reject" // Fail with ErrFull
+)
+
+var (
+	// ErrFull is returned by Submit under the Reject policy when the queue is full.
+	ErrFull = errors.New("work pool full")
+	// ErrStopped is returned by Submit once the pool has stopped.
+	ErrStopped = errors.New("work pool stopped")
+)
+
+// Config sizes a Pool.
+type Config struct {
+	Size       int    // Workers, at least 1
+	QueueDepth int    // Tasks waiting for a worker; 0 hands tasks only to idle workers
+	Overflow   string // Block or Reject
+}
+
+// Stats is a snapshot of a Pool.
+type Stats struct {
+	Size       int     `json:"size"`
+	QueueDepth int     `json:"queue_depth"`
+	Busy       int64   `json:"busy"`       // Workers running a task
+	Queued     int     `json:"queued"`     // Tasks waiting for a worker
+	Saturation float64 `json:"saturation"` // Busy / Size
+	Submitted  int64   `json:"submitted"`
+	Completed  int64   `json:"completed"`
+	Waited     int64   `json:"waited"`   // Submits that found the queue full and waited (Block)
+	Rejected   int64   `json:"rejected"` // Submits that found the queue full and failed (Reject)
+	Dropped    int64   `json:"dropped"`  // Queued tasks never run because the pool stopped
+}
+
+// Pool runs submitted tasks on Config.Size workers once Run is called. It is safe for
+// concurrent use.
+type Pool struct {
+	cfg     Config
+	tasks   chan func()
+	stopped chan struct{} // Closed when Run returns
+
+	busy      atomic.Int64
+	submitted atomic.Int64
+	completed atomic.Int64
+	waited    atomic.Int64
+	rejected  atomic.Int64
+	dropped   atomic.Int64
+}
+
+// New creates a Pool. Returns an error if cfg is invalid.
+func New(cfg Config) (*Pool, error) {
+	if cfg.Size < 1 {
+		return nil, fmt.Errorf("work pool size must be at least 1, got %d", cfg.Size)
+	}
+	if cfg.QueueDepth < 0 {
+		return nil, fmt.Errorf("work pool queue depth must not be negative, got %d", cfg.QueueDepth)
+	}
+	if cfg.Overflow != Block && cfg.Overflow != Reject {
+		return nil, fmt.Errorf("work pool overflow must be %s or %s, got %q", Block, Reject, cfg.Overflow)
+	}
+	return &Pool{cfg: cfg, tasks: make(chan func(), cfg.QueueDepth), stopped: make(chan struct{})}, nil
+}
+
+// Submit queues task to run on a worker. When the queue is full it waits for room
+// under the Block policy, until ctx is cancelled, and returns ErrFull under Reject.
+func (p *Pool) Submit(ctx context.Context, task func()) error {
+	select {
+	case <-p.stopped:
+		return ErrStopped
+	default:
+	}
+	select {
+	case p.tasks <- task:
+		p.submitted.Add(1)
+		return nil
+	default:
+	}
+	if p.cfg.Overflow == Reject {
+		p.rejected.Add(1)
+		return ErrFull
+	}
+
+	p.waited.Add(1)
+	select {
+	case p.tasks <- task:
+		p.submitted.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.stopped:
+		return ErrStopped
+	}
+}
+
+// HasRoom reports whether a task submitted now would start or queue without waiting.
+// Submitters with work of their own to hold back, like a job queue, check it first.
+func (p *Pool) HasRoom() bool {
+	return int(p.busy.Load())+len(p.tasks) < p.cfg.Size+p.cfg.QueueDepth
+}
+
+// Run runs queued tasks until ctx is cancelled, then waits for the running ones to
+// finish. Tasks still queued are dropped.
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range p.cfg.Size {
+		wg.Go(func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case task := <-p.tasks:
+					p.busy.Add(1)
+					task()
+					p.busy.Add(-1)
+					p.completed.Add(1)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(p.stopped)
+
+	for {
+		select {
+		case <-p.tasks:
+			p.dropped.Add(1)
+		default:
+			return
+		}
+	}
+}
+
+// Stats returns the pool's counters.
+func (p *Pool) Stats() Stats {
+	busy := p.busy.Load()
+	return Stats{
+		Size:       p.cfg.Size,
+		QueueDepth: p.cfg.QueueDepth,
+		Busy:       busy,
+		Queued:     len(p.tasks),
+		Saturation: float64(busy) / float64(p.cfg.Size),
+		Submitted:  p.submitted.Load(),
+		Completed:  p.completed.Load(),
+		Waited:     p.waited.Load(),
+		Rejected:   p.rejected.Load(),
+		Dropped:    p.dropped.Load(),
+	}
+}
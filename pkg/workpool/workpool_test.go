@@ -0,0 +1,118 @@
+package workpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_InvalidConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Size: 0, Overflow: Block},
+		{Size: 1, QueueDepth: -1, Overflow: Block},
+		{Size: 1, Overflow: "drop"},
+	} {
+		_, err := New(cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+// runPool runs p until the test ends.
+func runPool(t *testing.T, p *Pool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	p, err := New(Config{Size: 2, QueueDepth: 10, Overflow: Block})
+	require.NoError(t, err)
+	runPool(t, p)
+
+	var running, peak atomic.Int64
+	release := make(chan struct{})
+	for range 6 {
+		require.NoError(t, p.Submit(context.Background(), func() {
+			n := running.Add(1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			<-release
+			running.Add(-1)
+		}))
+	}
+
+	require.Eventually(t, func() bool { return p.Stats().Busy == 2 }, time.Second, time.Millisecond)
+	stats := p.Stats()
+	assert.Equal(t, 4, stats.Queued)
+	assert.Equal(t, 1.0, stats.Saturation)
+
+	close(release)
+	require.Eventually(t, func() bool { return p.Stats().Completed == 6 }, time.Second, time.Millisecond)
+	assert.Equal(t, int64(2), peak.Load())
+	assert.Equal(t, int64(6), p.Stats().Submitted)
+}
+
+func TestPool_Reject(t *testing.T) {
+	p, err := New(Config{Size: 1, QueueDepth: 1, Overflow: Reject})
+	require.NoError(t, err)
+	runPool(t, p)
+
+	release := make(chan struct{})
+	defer close(release)
+	require.NoError(t, p.Submit(context.Background(), func() { <-release }))
+	require.Eventually(t, func() bool { return p.Stats().Busy == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, p.Submit(context.Background(), func() {}), "queued")
+	assert.False(t, p.HasRoom())
+
+	err = p.Submit(context.Background(), func() {})
+
+	assert.ErrorIs(t, err, ErrFull)
+	assert.Equal(t, int64(1), p.Stats().Rejected)
+}
+
+func TestPool_BlockWaitsForRoom(t *testing.T) {
+	p, err := New(Config{Size: 1, Overflow: Block})
+	require.NoError(t, err)
+
+	// Not running: nothing takes the task
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = p.Submit(ctx, func() {})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int64(1), p.Stats().Waited)
+
+	runPool(t, p)
+	ran := make(chan struct{})
+	require.NoError(t, p.Submit(context.Background(), func() { close(ran) }))
+	<-ran
+}
+
+func TestPool_StopDropsQueued(t *testing.T) {
+	p, err := New(Config{Size: 1, QueueDepth: 2, Overflow: Block})
+	require.NoError(t, err)
+	require.NoError(t, p.Submit(context.Background(), func() {}))
+	require.NoError(t, p.Submit(context.Background(), func() {}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.Run(ctx)
+
+	assert.Equal(t, int64(2), p.Stats().Dropped+p.Stats().Completed)
+	assert.ErrorIs(t, p.Submit(context.Background(), func() {}), ErrStopped)
+}
@@ -3,9 +3,64 @@
 -- Coupons table
 CREATE TABLE coupons (
     name VARCHAR(255) PRIMARY KEY,
-    amount INTEGER NOT NULL CHECK (amount > 0),
+    amount INTEGER NOT NULL, -- 0 for unlimited coupons
     remaining_amount INTEGER NOT NULL CHECK (remaining_amount >= 0),
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+    tags JSONB NOT NULL DEFAULT '[]'::jsonb,
+    overflow_at TIMESTAMP WITH TIME ZONE,
+    claim_sequence INTEGER NOT NULL DEFAULT 0, -- sequence of the most recent claim
+    tiers JSONB NOT NULL DEFAULT '[]'::jsonb, -- [{"name": "gold", "size": 100}, ...] in claim order
+    disabled BOOLEAN NOT NULL DEFAULT FALSE, -- disabled coupons reject claims
+    captcha_required BOOLEAN NOT NULL DEFAULT FALSE, -- claims must pass a captcha check
+    metadata JSONB NOT NULL DEFAULT '{}'::jsonb, -- arbitrary JSON object, validated against COUPON_METADATA_SCHEMA on write
+    low_stock_percent INTEGER NOT NULL DEFAULT 0 CHECK (low_stock_percent BETWEEN 0 AND 99), -- low stock watermark (% of amount remaining); 0 for none
+    parent VARCHAR(255), -- coupon whose remaining_amount is the budget shared by its children; NULL for none
+    unlimited BOOLEAN NOT NULL DEFAULT FALSE, -- no stock cap: claims only advance claim_sequence
+    claim_retention JSONB, -- {"days": 90, "action": "purge" | "anonymize"} applied to older claims (CLAIM_RETENTION_ENABLED); NULL keeps them
+    visibility VARCHAR(16) NOT NULL DEFAULT 'public', -- public, unlisted or private: only public coupons are listed and readable
+    high_profile BOOLEAN NOT NULL DEFAULT FALSE, -- claims share a reserved fraction of pool connections (COUPON_HIGH_PROFILE_POOL_SHARE)
+    prerequisite VARCHAR(255), -- coupon users must have claimed before claiming this one; NULL for none
+    exclusion_group VARCHAR(255), -- users claim at most one coupon of the group (coupon_exclusion_claims); NULL for none
+    deleted_at TIMESTAMP WITH TIME ZONE, -- set while a deleted coupon can be restored (COUPON_UNDO_WINDOW)
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT coupons_amount_check CHECK (amount > 0 OR unlimited)
+);
+
+-- Index for finding deleted coupons to purge
+CREATE INDEX idx_coupons_deleted_at ON coupons(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- GIN index for tag containment filters (GET /api/coupons?tag=...)
+CREATE INDEX idx_coupons_tags ON coupons USING GIN (tags);
+
+-- Channel stock partitions (e.g. 70% app, 30% web). Quotas of a coupon sum to its amount.
+-- Rows are only modified while the parent coupon row is locked, so they need no locks of their own.
+CREATE TABLE coupon_channel_quotas (
+    coupon_name VARCHAR(255) NOT NULL REFERENCES coupons(name),
+    channel VARCHAR(64) NOT NULL,
+    quota INTEGER NOT NULL CHECK (quota >= 0),
+    remaining INTEGER NOT NULL CHECK (remaining >= 0),
+    PRIMARY KEY (coupon_name, channel)
+);
+
+-- Claims per region, capped by quota where one is set (NULL counts claims only).
+-- Like channel quotas, rows are only modified while the coupon row is locked.
+CREATE TABLE coupon_region_claims (
+    coupon_name VARCHAR(255) NOT NULL REFERENCES coupons(name),
+    region VARCHAR(64) NOT NULL,
+    quota INTEGER CHECK (quota > 0),
+    claimed INTEGER NOT NULL DEFAULT 0 CHECK (claimed >= 0),
+    PRIMARY KEY (coupon_name, region)
+);
+
+-- Claim rollup per coupon, updated in the claim transaction (under the coupon row lock)
+-- so reads never count claims. Claims are bucketed by clock hour: claims_this_hour
+-- counts the hour starting at hour_start, claims_prev_hour the hour before.
+CREATE TABLE coupon_stats (
+    coupon_name VARCHAR(255) PRIMARY KEY REFERENCES coupons(name),
+    total_claims INTEGER NOT NULL DEFAULT 0 CHECK (total_claims >= 0),
+    last_claim_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    hour_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    claims_this_hour INTEGER NOT NULL DEFAULT 0,
+    claims_prev_hour INTEGER NOT NULL DEFAULT 0
 );
 
 -- Claims table (separate, no embedding per architecture)
@@ -13,6 +68,10 @@ CREATE TABLE claims (
     id SERIAL PRIMARY KEY,
     user_id VARCHAR(255) NOT NULL,
     coupon_name VARCHAR(255) NOT NULL REFERENCES coupons(name),
+    channel VARCHAR(64),
+    region VARCHAR(64),
+    claim_sequence INTEGER NOT NULL, -- 1-based claim order within the coupon
+    tier VARCHAR(32),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     UNIQUE(user_id, coupon_name)
 );
@@ -20,5 +79,152 @@ CREATE TABLE claims (
 -- Index for efficient claim lookups by coupon
 CREATE INDEX idx_claims_coupon_name ON claims(coupon_name);
 
+-- Index for claim exports ordered by position (GET /api/coupons/:name/claims)
+CREATE INDEX idx_claims_coupon_sequence ON claims(coupon_name, claim_sequence);
+
 -- Index for efficient claim lookups by user
 CREATE INDEX idx_claims_user_id ON claims(user_id);
+
+-- The one claim each user may make among the coupons of an exclusion group, inserted by
+-- the claim transaction: the primary key refuses a second.
+CREATE TABLE coupon_exclusion_claims (
+    exclusion_group VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    coupon_name VARCHAR(255) NOT NULL REFERENCES coupons(name),
+    PRIMARY KEY (exclusion_group, user_id)
+);
+
+-- Index for removing a coupon's rows on purge and claim retention
+CREATE INDEX idx_coupon_exclusion_claims_coupon ON coupon_exclusion_claims(coupon_name, user_id);
+
+-- Index for renaming a user's rows on erasure
+CREATE INDEX idx_coupon_exclusion_claims_user_id ON coupon_exclusion_claims(user_id);
+
+-- Audit trail for administrative data operations (e.g. GDPR erasure).
+-- subject never holds raw personal data; erasures record the pseudonym only.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Index for a coupon's changelog: its notes and terminations, newest first
+CREATE INDEX idx_audit_log_subject ON audit_log(subject, created_at);
+
+-- Durable job queue (pkg/jobs). Workers claim due rows with FOR UPDATE SKIP LOCKED and
+-- hide them by pushing run_at past a visibility timeout; attempts doubles as the lease token.
+-- Completed jobs are deleted; jobs out of attempts keep failed_at and last_error for inspection.
+CREATE TABLE jobs (
+    id BIGSERIAL PRIMARY KEY,
+    queue VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 10 CHECK (max_attempts > 0),
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    failed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Index for dequeueing the oldest due job of a queue
+CREATE INDEX idx_jobs_queue_run_at ON jobs(queue, run_at) WHERE failed_at IS NULL;
+
+-- Endpoints receiving signed coupon lifecycle events (WEBHOOKS_ENABLED). Deliveries are
+-- jobs on the "webhooks" queue; secret signs them, so it is stored as given.
+-- payload_template, if not empty, renders the delivery bodies (a Go text/template).
+CREATE TABLE webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    events TEXT[] NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    payload_template TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Campaign leaderboards (LEADERBOARD_REFRESH_INTERVAL): claims per user across the coupons
+-- tagged with a campaign, maintained incrementally from claims. A coupon's claims count
+-- towards the tags it has when they are counted.
+CREATE TABLE campaign_leaderboard (
+    campaign VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    claims INTEGER NOT NULL CHECK (claims > 0),
+    PRIMARY KEY (campaign, user_id)
+);
+
+-- Index for reading a campaign's top claimers
+CREATE INDEX idx_campaign_leaderboard_rank ON campaign_leaderboard(campaign, claims DESC, user_id);
+
+-- Index for renaming a user's entries on erasure
+CREATE INDEX idx_campaign_leaderboard_user_id ON campaign_leaderboard(user_id);
+
+-- Highest claim_sequence of each coupon counted into campaign_leaderboard. Claim sequences
+-- are assigned under the coupon row lock, so they commit in order and everything at or
+-- below the watermark has been counted. Refreshes lock the row, so instances never count
+-- a claim twice.
+CREATE TABLE campaign_leaderboard_progress (
+    coupon_name VARCHAR(255) PRIMARY KEY REFERENCES coupons(name),
+    claim_sequence INTEGER NOT NULL
+);
+
+-- Campaign claim caps (CAMPAIGN_CAPS_ENABLED): a budget of claims across the coupons
+-- tagged with a campaign. Claims lock the caps of their coupon's tags after the coupon
+-- row, in campaign order, and count towards them, so the budget holds however much
+-- stock the coupons have left. claimed counts claims made since the cap was created.
+CREATE TABLE campaign_caps (
+    campaign VARCHAR(64) PRIMARY KEY,
+    claim_cap INTEGER NOT NULL CHECK (claim_cap >= 0),
+    claimed INTEGER NOT NULL DEFAULT 0 CHECK (claimed >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- API key claim usage (API_KEY_USAGE_ENABLED): the claims each API key made in a UTC
+-- month ("2026-10"), counted against the key's monthly_quota. Replicas add their
+-- claims every API_KEY_USAGE_FLUSH_INTERVAL and read back every key's count.
+CREATE TABLE api_key_usage (
+    key_id VARCHAR(255) NOT NULL,
+    month CHAR(7) NOT NULL,
+    claims BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (key_id, month)
+);
+
+-- Coupon allowlists (COUPON_ALLOWLISTS_ENABLED): a coupon with entries here accepts
+-- claims only from the users listed, each until their claim_by deadline (NULL for none).
+-- Claims look up their entry after locking the coupon row.
+CREATE TABLE coupon_allowlist (
+    coupon_name VARCHAR(255) NOT NULL REFERENCES coupons(name),
+    user_id VARCHAR(255) NOT NULL,
+    claim_by TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (coupon_name, user_id)
+);
+
+-- Index for renaming a user's entries on erasure
+CREATE INDEX idx_coupon_allowlist_user_id ON coupon_allowlist(user_id);
+
+-- Coupon archive (COUPON_ARCHIVE_ENABLED): deleted coupons as they were when purged,
+-- copied in the purge's transaction and served by GET /api/archive/coupons/{name}.
+-- coupon holds the coupon's fields; it references no other table, so it outlives the
+-- coupon's rows and a coupon created later under the name replaces it once purged.
+CREATE TABLE coupon_archive (
+    name VARCHAR(255) PRIMARY KEY,
+    coupon JSONB NOT NULL,
+    claims INTEGER NOT NULL,
+    last_claim_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    visibility VARCHAR(16) NOT NULL DEFAULT 'public'
+);
+
+-- Load test namespaces (LOADTEST_ENABLED): disposable coupons named
+-- loadtest-<namespace>-<n> created by POST /api/admin/loadtest/prewarm, removed with
+-- their claims when the namespace is cleaned up or once expires_at passes.
+CREATE TABLE loadtest_namespaces (
+    namespace VARCHAR(32) PRIMARY KEY,
+    coupons INTEGER NOT NULL,
+    claims INTEGER NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
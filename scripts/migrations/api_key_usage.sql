@@ -0,0 +1,11 @@
+-- Add the monthly claim counts of API keys (PostgreSQL, CockroachDB).
+-- Run once before enabling API_KEY_USAGE_ENABLED on an existing database. See
+-- "API key quotas" in the README.
+
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    key_id VARCHAR(255) NOT NULL,
+    month CHAR(7) NOT NULL,
+    claims BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (key_id, month)
+);
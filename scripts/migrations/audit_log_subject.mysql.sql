@@ -0,0 +1,5 @@
+-- Index the audit log by subject (MySQL, MariaDB).
+-- Run once before upgrading to a version serving coupon changelogs; without it they
+-- scan the whole audit log. See "Coupon notes" in the README.
+
+CREATE INDEX idx_audit_log_subject ON audit_log(subject, created_at);
@@ -0,0 +1,5 @@
+-- Index the audit log by subject (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version serving coupon changelogs; without it they
+-- scan the whole audit log. See "Coupon notes" in the README.
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_subject ON audit_log(subject, created_at);
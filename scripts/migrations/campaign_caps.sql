@@ -0,0 +1,10 @@
+-- Add campaign claim caps (PostgreSQL, CockroachDB).
+-- Run once before enabling CAMPAIGN_CAPS_ENABLED on an existing database. See
+-- "Campaign claim caps" in the README.
+
+CREATE TABLE IF NOT EXISTS campaign_caps (
+    campaign VARCHAR(64) PRIMARY KEY,
+    claim_cap INTEGER NOT NULL CHECK (claim_cap >= 0),
+    claimed INTEGER NOT NULL DEFAULT 0 CHECK (claimed >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
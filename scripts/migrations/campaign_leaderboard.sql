@@ -0,0 +1,19 @@
+-- Add campaign leaderboards (PostgreSQL, CockroachDB).
+-- Run once before setting LEADERBOARD_REFRESH_INTERVAL on an existing database; the
+-- first refreshes count the claims already made. See "Campaign leaderboards" in the
+-- README.
+
+CREATE TABLE IF NOT EXISTS campaign_leaderboard (
+    campaign VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    claims INTEGER NOT NULL CHECK (claims > 0),
+    PRIMARY KEY (campaign, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaign_leaderboard_rank ON campaign_leaderboard(campaign, claims DESC, user_id);
+CREATE INDEX IF NOT EXISTS idx_campaign_leaderboard_user_id ON campaign_leaderboard(user_id);
+
+CREATE TABLE IF NOT EXISTS campaign_leaderboard_progress (
+    coupon_name VARCHAR(255) PRIMARY KEY REFERENCES coupons(name),
+    claim_sequence INTEGER NOT NULL
+);
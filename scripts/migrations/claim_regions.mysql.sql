@@ -0,0 +1,14 @@
+-- Add claim regions and per-region coupon quotas (MySQL, MariaDB).
+-- Run once before upgrading to a version that reads them; existing claims have no
+-- region and existing coupons no quotas. See "Regions" in the README.
+
+ALTER TABLE claims ADD COLUMN region VARCHAR(64);
+
+CREATE TABLE IF NOT EXISTS coupon_region_claims (
+    coupon_name VARCHAR(255) NOT NULL,
+    region VARCHAR(64) NOT NULL,
+    quota INT CHECK (quota > 0),
+    claimed INT NOT NULL DEFAULT 0 CHECK (claimed >= 0),
+    PRIMARY KEY (coupon_name, region),
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name)
+) ENGINE=InnoDB;
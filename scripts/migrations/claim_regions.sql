@@ -0,0 +1,13 @@
+-- Add claim regions and per-region coupon quotas (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads them; existing claims have no
+-- region and existing coupons no quotas. See "Regions" in the README.
+
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS region VARCHAR(64);
+
+CREATE TABLE IF NOT EXISTS coupon_region_claims (
+    coupon_name VARCHAR(255) NOT NULL REFERENCES coupons(name),
+    region VARCHAR(64) NOT NULL,
+    quota INTEGER CHECK (quota > 0),
+    claimed INTEGER NOT NULL DEFAULT 0 CHECK (claimed >= 0),
+    PRIMARY KEY (coupon_name, region)
+);
@@ -0,0 +1,5 @@
+-- Add per-coupon claim retention (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads it; existing coupons keep their
+-- claims. See "Claim retention" in the README.
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS claim_retention JSONB;
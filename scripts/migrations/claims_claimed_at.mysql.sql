@@ -0,0 +1,14 @@
+-- Rename claims.created_at to claimed_at (MySQL, MariaDB).
+-- Run each step only once every replica runs the phase named before it; see the
+-- "Column Renames" section of the README and database.MigrationPhase.
+
+-- Step 1 (replicas in phase old): add the new column.
+ALTER TABLE claims ADD COLUMN claimed_at DATETIME(6) NULL;
+
+-- Step 2 (replicas in phase dual_write): backfill rows written before dual writes.
+-- Repeat until it updates 0 rows; small batches keep row locks short.
+UPDATE claims SET claimed_at = created_at WHERE claimed_at IS NULL LIMIT 10000;
+
+-- Step 3 (replicas in phase new): every write fills claimed_at, drop the old column.
+-- ALTER TABLE claims MODIFY claimed_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6);
+-- ALTER TABLE claims DROP COLUMN created_at;
@@ -0,0 +1,16 @@
+-- Rename claims.created_at to claimed_at (PostgreSQL, CockroachDB).
+-- Run each step only once every replica runs the phase named before it; see the
+-- "Column Renames" section of the README and database.MigrationPhase.
+
+-- Step 1 (replicas in phase old): add the new column.
+ALTER TABLE claims ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP WITH TIME ZONE;
+
+-- Step 2 (replicas in phase dual_write): backfill rows written before dual writes.
+-- Repeat until it updates 0 rows; small batches keep row locks short.
+UPDATE claims SET claimed_at = created_at
+WHERE id IN (SELECT id FROM claims WHERE claimed_at IS NULL LIMIT 10000);
+
+-- Step 3 (replicas in phase new): every write fills claimed_at, drop the old column.
+-- ALTER TABLE claims ALTER COLUMN claimed_at SET DEFAULT NOW();
+-- ALTER TABLE claims ALTER COLUMN claimed_at SET NOT NULL;
+-- ALTER TABLE claims DROP COLUMN created_at;
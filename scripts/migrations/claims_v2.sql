@@ -0,0 +1,51 @@
+-- Move claims to claims_v2, keyed by (coupon_name, claim_id) and hash-partitioned by
+-- coupon (PostgreSQL only). Run each step only once every replica runs the phase named
+-- before it; see the "Table Moves" section of the README and database.TableMove.
+
+-- Step 1 (replicas in phase old): create the new table. claim_id keeps claims.id and
+-- shares its sequence, so ids stay unique across both tables during the move.
+CREATE TABLE IF NOT EXISTS claims_v2 (
+    claim_id BIGINT NOT NULL DEFAULT nextval('claims_id_seq'),
+    user_id VARCHAR(255) NOT NULL,
+    coupon_name VARCHAR(255) NOT NULL REFERENCES coupons(name),
+    channel VARCHAR(64),
+    region VARCHAR(64),
+    claim_sequence INTEGER NOT NULL,
+    tier VARCHAR(32),
+    claimed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (coupon_name, claim_id),
+    UNIQUE (coupon_name, user_id)
+) PARTITION BY HASH (coupon_name);
+
+CREATE TABLE IF NOT EXISTS claims_v2_p0 PARTITION OF claims_v2 FOR VALUES WITH (MODULUS 8, REMAINDER 0);
+CREATE TABLE IF NOT EXISTS claims_v2_p1 PARTITION OF claims_v2 FOR VALUES WITH (MODULUS 8, REMAINDER 1);
+CREATE TABLE IF NOT EXISTS claims_v2_p2 PARTITION OF claims_v2 FOR VALUES WITH (MODULUS 8, REMAINDER 2);
+CREATE TABLE IF NOT EXISTS claims_v2_p3 PARTITION OF claims_v2 FOR VALUES WITH (MODULUS 8, REMAINDER 3);
+CREATE TABLE IF NOT EXISTS claims_v2_p4 PARTITION OF claims_v2 FOR VALUES WITH (MODULUS 8, REMAINDER 4);
+CREATE TABLE IF NOT EXISTS claims_v2_p5 PARTITION OF claims_v2 FOR VALUES WITH (MODULUS 8, REMAINDER 5);
+CREATE TABLE IF NOT EXISTS claims_v2_p6 PARTITION OF claims_v2 FOR VALUES WITH (MODULUS 8, REMAINDER 6);
+CREATE TABLE IF NOT EXISTS claims_v2_p7 PARTITION OF claims_v2 FOR VALUES WITH (MODULUS 8, REMAINDER 7);
+
+CREATE INDEX IF NOT EXISTS idx_claims_v2_coupon_sequence ON claims_v2(coupon_name, claim_sequence);
+CREATE INDEX IF NOT EXISTS idx_claims_v2_user_id ON claims_v2(user_id);
+
+-- Step 2 (replicas in phase dual_write): backfill rows written before dual writes.
+-- Repeat with the last id copied until it inserts 0 rows; rows already dual-written
+-- are skipped. Use created_at in place of claimed_at while the claims.claimed_at
+-- rename is in phase old.
+INSERT INTO claims_v2 (claim_id, user_id, coupon_name, channel, region, claim_sequence, tier, claimed_at)
+SELECT id, user_id, coupon_name, channel, region, claim_sequence, tier, COALESCE(claimed_at, created_at, NOW())
+FROM claims
+WHERE id > 0 -- last id copied
+ORDER BY id
+LIMIT 10000
+ON CONFLICT DO NOTHING;
+
+-- Then check both tables hold the same claims before moving replicas to read_new:
+--   GET /api/admin/migrations/claims_v2/verify?after=<next>
+-- until it returns no next, with no mismatches.
+
+-- Step 3 (replicas in phase new): nothing writes claims any more, drop it. The
+-- sequence is kept for claims_v2.
+-- ALTER SEQUENCE claims_id_seq OWNED BY claims_v2.claim_id;
+-- DROP TABLE claims;
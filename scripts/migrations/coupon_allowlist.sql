@@ -0,0 +1,12 @@
+-- Add coupon allowlists (PostgreSQL, CockroachDB).
+-- Run once before enabling COUPON_ALLOWLISTS_ENABLED on an existing database; existing
+-- coupons have none and stay open to everyone. See "Coupon allowlists" in the README.
+
+CREATE TABLE IF NOT EXISTS coupon_allowlist (
+    coupon_name VARCHAR(255) NOT NULL REFERENCES coupons(name),
+    user_id VARCHAR(255) NOT NULL,
+    claim_by TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (coupon_name, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_allowlist_user_id ON coupon_allowlist(user_id);
@@ -0,0 +1,14 @@
+-- Add the coupon archive (PostgreSQL, CockroachDB).
+-- Run once before enabling COUPON_ARCHIVE_ENABLED on an existing database; coupons
+-- purged before then are not archived. See "Coupon archive" in the README.
+
+CREATE TABLE IF NOT EXISTS coupon_archive (
+    name VARCHAR(255) PRIMARY KEY,
+    coupon JSONB NOT NULL,
+    claims INTEGER NOT NULL,
+    last_claim_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    visibility VARCHAR(16) NOT NULL DEFAULT 'public'
+);
@@ -0,0 +1,5 @@
+-- Add captcha-protected coupons (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads it; existing coupons need no captcha.
+-- See "Captcha" in the README.
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS captcha_required BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,8 @@
+-- Add coupon deletion (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads it: every coupon read filters on
+-- the column, whether or not COUPON_UNDO_WINDOW is set. Existing coupons are not
+-- deleted. See "Coupon deletion" in the README.
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_coupons_deleted_at ON coupons(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Add coupon exclusion groups (MySQL, MariaDB).
+-- Run once before upgrading to a version that reads them; existing coupons are in none.
+-- See "Exclusion groups" in the README.
+
+ALTER TABLE coupons ADD COLUMN exclusion_group VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS coupon_exclusion_claims (
+    exclusion_group VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    coupon_name VARCHAR(255) NOT NULL,
+    PRIMARY KEY (exclusion_group, user_id),
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name),
+    INDEX idx_coupon_exclusion_claims_user_id (user_id)
+) ENGINE=InnoDB;
@@ -0,0 +1,15 @@
+-- Add coupon exclusion groups (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads them; existing coupons are in none.
+-- See "Exclusion groups" in the README.
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS exclusion_group VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS coupon_exclusion_claims (
+    exclusion_group VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    coupon_name VARCHAR(255) NOT NULL REFERENCES coupons(name),
+    PRIMARY KEY (exclusion_group, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_exclusion_claims_coupon ON coupon_exclusion_claims(coupon_name, user_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_exclusion_claims_user_id ON coupon_exclusion_claims(user_id);
@@ -0,0 +1,5 @@
+-- Add high-profile coupons (MySQL, MariaDB).
+-- Run once before upgrading to a version that reads it; existing coupons are not high-profile.
+-- See "High-profile coupons" in the README.
+
+ALTER TABLE coupons ADD COLUMN high_profile BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,6 @@
+-- Add low stock watermarks (MySQL, MariaDB).
+-- Run once before upgrading to a version that reads it; existing coupons have none.
+-- See "Low stock" in the README.
+
+ALTER TABLE coupons ADD COLUMN low_stock_percent INT NOT NULL DEFAULT 0
+    CHECK (low_stock_percent BETWEEN 0 AND 99);
@@ -0,0 +1,6 @@
+-- Add low stock watermarks (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads it; existing coupons have none.
+-- See "Low stock" in the README.
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS low_stock_percent INTEGER NOT NULL DEFAULT 0
+    CHECK (low_stock_percent BETWEEN 0 AND 99);
@@ -0,0 +1,5 @@
+-- Add coupon metadata (MySQL, MariaDB).
+-- Run once before upgrading to a version that reads it; existing coupons get an empty
+-- object. See "Coupon metadata" in the README.
+
+ALTER TABLE coupons ADD COLUMN metadata JSON NOT NULL DEFAULT (JSON_OBJECT());
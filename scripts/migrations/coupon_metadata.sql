@@ -0,0 +1,5 @@
+-- Add coupon metadata (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads it; existing coupons get an empty
+-- object. See "Coupon metadata" in the README.
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
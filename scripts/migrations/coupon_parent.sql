@@ -0,0 +1,5 @@
+-- Add hierarchical coupons (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads it; existing coupons have no parent.
+-- See "Hierarchical coupons" in the README.
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS parent VARCHAR(255);
@@ -0,0 +1,5 @@
+-- Add coupon prerequisites (MySQL, MariaDB).
+-- Run once before upgrading to a version that reads it; existing coupons have none.
+-- See "Prerequisite coupons" in the README.
+
+ALTER TABLE coupons ADD COLUMN prerequisite VARCHAR(255);
@@ -0,0 +1,5 @@
+-- Add coupon prerequisites (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads it; existing coupons have none.
+-- See "Prerequisite coupons" in the README.
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS prerequisite VARCHAR(255);
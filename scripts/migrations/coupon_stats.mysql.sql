@@ -0,0 +1,23 @@
+-- Create and backfill the coupon_stats claim rollup (MySQL, MariaDB).
+-- Run once before upgrading to a version that maintains it; claims made by replicas
+-- still on the old version after it runs are not counted. See "Claim stats" in the README.
+
+CREATE TABLE IF NOT EXISTS coupon_stats (
+    coupon_name VARCHAR(255) NOT NULL PRIMARY KEY,
+    total_claims INT NOT NULL DEFAULT 0 CHECK (total_claims >= 0),
+    last_claim_at DATETIME(6) NOT NULL,
+    hour_start DATETIME(6) NOT NULL,
+    claims_this_hour INT NOT NULL DEFAULT 0,
+    claims_prev_hour INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name)
+) ENGINE=InnoDB;
+
+-- Timestamps are stored in UTC; hours are truncated in UTC, like the repositories do.
+SET @hour_start = DATE_FORMAT(UTC_TIMESTAMP(), '%Y-%m-%d %H:00:00');
+
+INSERT IGNORE INTO coupon_stats (coupon_name, total_claims, last_claim_at, hour_start, claims_this_hour, claims_prev_hour)
+SELECT coupon_name, COUNT(*), MAX(created_at), @hour_start,
+    SUM(created_at >= @hour_start),
+    SUM(created_at >= @hour_start - INTERVAL 1 HOUR AND created_at < @hour_start)
+FROM claims
+GROUP BY coupon_name;
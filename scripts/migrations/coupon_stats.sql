@@ -0,0 +1,21 @@
+-- Create and backfill the coupon_stats claim rollup (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that maintains it; claims made by replicas
+-- still on the old version after it runs are not counted. See "Claim stats" in the README.
+
+CREATE TABLE IF NOT EXISTS coupon_stats (
+    coupon_name VARCHAR(255) PRIMARY KEY REFERENCES coupons(name),
+    total_claims INTEGER NOT NULL DEFAULT 0 CHECK (total_claims >= 0),
+    last_claim_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    hour_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    claims_this_hour INTEGER NOT NULL DEFAULT 0,
+    claims_prev_hour INTEGER NOT NULL DEFAULT 0
+);
+
+-- Hours are truncated in UTC, like the repositories do.
+INSERT INTO coupon_stats (coupon_name, total_claims, last_claim_at, hour_start, claims_this_hour, claims_prev_hour)
+SELECT coupon_name, COUNT(*), MAX(created_at), h.hour_start,
+    COUNT(*) FILTER (WHERE created_at >= h.hour_start),
+    COUNT(*) FILTER (WHERE created_at >= h.hour_start - INTERVAL '1 hour' AND created_at < h.hour_start)
+FROM claims, (SELECT date_trunc('hour', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS hour_start) h
+GROUP BY coupon_name, h.hour_start
+ON CONFLICT (coupon_name) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Add unlimited coupons (MySQL, MariaDB).
+-- Run once before upgrading to a version that creates them; existing coupons stay
+-- limited. See "Unlimited coupons" in the README.
+
+ALTER TABLE coupons ADD COLUMN unlimited BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Unlimited coupons are stored with an amount of 0. MySQL names the amount check
+-- coupons_chk_1 (the first check of the table); MariaDB names it amount.
+ALTER TABLE coupons DROP CHECK coupons_chk_1;
+ALTER TABLE coupons ADD CONSTRAINT coupons_amount_check CHECK (amount > 0 OR unlimited);
@@ -0,0 +1,9 @@
+-- Add unlimited coupons (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that creates them; existing coupons stay
+-- limited. See "Unlimited coupons" in the README.
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS unlimited BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Unlimited coupons are stored with an amount of 0.
+ALTER TABLE coupons DROP CONSTRAINT IF EXISTS coupons_amount_check;
+ALTER TABLE coupons ADD CONSTRAINT coupons_amount_check CHECK (amount > 0 OR unlimited);
@@ -0,0 +1,5 @@
+-- Add coupon visibility (MySQL, MariaDB).
+-- Run once before upgrading to a version that reads it; existing coupons stay public.
+-- See "Coupon visibility" in the README.
+
+ALTER TABLE coupons ADD COLUMN visibility VARCHAR(16) NOT NULL DEFAULT 'public';
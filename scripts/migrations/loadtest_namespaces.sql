@@ -0,0 +1,11 @@
+-- Add load test namespaces (PostgreSQL, CockroachDB).
+-- Run once before enabling LOADTEST_ENABLED on an existing database. See "Load test
+-- seeding" in the README.
+
+CREATE TABLE IF NOT EXISTS loadtest_namespaces (
+    namespace VARCHAR(32) PRIMARY KEY,
+    coupons INTEGER NOT NULL,
+    claims INTEGER NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
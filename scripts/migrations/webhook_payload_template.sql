@@ -0,0 +1,5 @@
+-- Add webhook payload templates (PostgreSQL, CockroachDB).
+-- Run once before upgrading to a version that reads them; existing subscriptions keep
+-- receiving the default JSON body. See "Webhooks" in the README.
+
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS payload_template TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,27 @@
+-- Add webhook subscriptions (PostgreSQL, CockroachDB).
+-- Run once before enabling WEBHOOKS_ENABLED on an existing database, then
+-- webhook_payload_template.sql. Deliveries are jobs on the "webhooks" queue, so the
+-- jobs table is created too if the job queue is not in use yet. See "Coupon webhooks"
+-- in the README.
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    events TEXT[] NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    queue VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 10 CHECK (max_attempts > 0),
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    failed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queue_run_at ON jobs(queue, run_at) WHERE failed_at IS NULL;
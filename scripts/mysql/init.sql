@@ -0,0 +1,101 @@
+-- MySQL 8.0.13+ / MariaDB 10.5+ schema for the Scalable Coupon System (DB_DRIVER=mysql).
+-- Mirrors scripts/init.sql; keep the two in sync. Timestamps are stored in UTC.
+
+-- Coupons table
+CREATE TABLE coupons (
+    name VARCHAR(255) PRIMARY KEY,
+    amount INT NOT NULL, -- 0 for unlimited coupons
+    remaining_amount INT NOT NULL CHECK (remaining_amount >= 0),
+    tags JSON NOT NULL DEFAULT (JSON_ARRAY()), -- filtered with JSON_CONTAINS (no GIN index equivalent)
+    overflow_at DATETIME(6) NULL,
+    claim_sequence INT NOT NULL DEFAULT 0, -- sequence of the most recent claim
+    tiers JSON NOT NULL DEFAULT (JSON_ARRAY()), -- [{"name": "gold", "size": 100}, ...] in claim order
+    disabled BOOLEAN NOT NULL DEFAULT FALSE, -- disabled coupons reject claims
+    captcha_required BOOLEAN NOT NULL DEFAULT FALSE, -- claims must pass a captcha check
+    metadata JSON NOT NULL DEFAULT (JSON_OBJECT()), -- arbitrary JSON object, validated against COUPON_METADATA_SCHEMA on write
+    low_stock_percent INT NOT NULL DEFAULT 0 CHECK (low_stock_percent BETWEEN 0 AND 99), -- low stock watermark (% of amount remaining); 0 for none
+    parent VARCHAR(255), -- coupon whose remaining_amount is the budget shared by its children; NULL for none
+    unlimited BOOLEAN NOT NULL DEFAULT FALSE, -- no stock cap: claims only advance claim_sequence
+    visibility VARCHAR(16) NOT NULL DEFAULT 'public', -- public, unlisted or private: only public coupons are listed and readable
+    high_profile BOOLEAN NOT NULL DEFAULT FALSE, -- claims share a reserved fraction of pool connections (COUPON_HIGH_PROFILE_POOL_SHARE)
+    prerequisite VARCHAR(255), -- coupon users must have claimed before claiming this one; NULL for none
+    exclusion_group VARCHAR(255), -- users claim at most one coupon of the group (coupon_exclusion_claims); NULL for none
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT coupons_amount_check CHECK (amount > 0 OR unlimited)
+) ENGINE=InnoDB;
+
+-- Channel stock partitions (e.g. 70% app, 30% web). Quotas of a coupon sum to its amount.
+-- Rows are only modified while the parent coupon row is locked, so they need no locks of their own.
+CREATE TABLE coupon_channel_quotas (
+    coupon_name VARCHAR(255) NOT NULL,
+    channel VARCHAR(64) NOT NULL,
+    quota INT NOT NULL CHECK (quota >= 0),
+    remaining INT NOT NULL CHECK (remaining >= 0),
+    PRIMARY KEY (coupon_name, channel),
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name)
+) ENGINE=InnoDB;
+
+-- Claims per region, capped by quota where one is set (NULL counts claims only).
+-- Like channel quotas, rows are only modified while the coupon row is locked.
+CREATE TABLE coupon_region_claims (
+    coupon_name VARCHAR(255) NOT NULL,
+    region VARCHAR(64) NOT NULL,
+    quota INT CHECK (quota > 0),
+    claimed INT NOT NULL DEFAULT 0 CHECK (claimed >= 0),
+    PRIMARY KEY (coupon_name, region),
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name)
+) ENGINE=InnoDB;
+
+-- Claim rollup per coupon, updated in the claim transaction (under the coupon row lock)
+-- so reads never count claims. Claims are bucketed by clock hour (UTC): claims_this_hour
+-- counts the hour starting at hour_start, claims_prev_hour the hour before.
+CREATE TABLE coupon_stats (
+    coupon_name VARCHAR(255) NOT NULL PRIMARY KEY,
+    total_claims INT NOT NULL DEFAULT 0 CHECK (total_claims >= 0),
+    last_claim_at DATETIME(6) NOT NULL,
+    hour_start DATETIME(6) NOT NULL,
+    claims_this_hour INT NOT NULL DEFAULT 0,
+    claims_prev_hour INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name)
+) ENGINE=InnoDB;
+
+-- Claims table (separate, no embedding per architecture)
+CREATE TABLE claims (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    coupon_name VARCHAR(255) NOT NULL,
+    channel VARCHAR(64),
+    region VARCHAR(64),
+    claim_sequence INT NOT NULL, -- 1-based claim order within the coupon
+    tier VARCHAR(32),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE KEY uq_claims_user_coupon (user_id, coupon_name), -- also serves lookups by user
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name),
+    -- Index for claim exports ordered by position (also serves lookups by coupon)
+    INDEX idx_claims_coupon_sequence (coupon_name, claim_sequence)
+) ENGINE=InnoDB;
+
+-- The one claim each user may make among the coupons of an exclusion group, inserted by
+-- the claim transaction: the primary key refuses a second.
+CREATE TABLE coupon_exclusion_claims (
+    exclusion_group VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    coupon_name VARCHAR(255) NOT NULL,
+    PRIMARY KEY (exclusion_group, user_id),
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name),
+    -- Index for renaming a user's rows on erasure
+    INDEX idx_coupon_exclusion_claims_user_id (user_id)
+) ENGINE=InnoDB;
+
+-- Audit trail for administrative data operations (e.g. GDPR erasure).
+-- subject never holds raw personal data; erasures record the pseudonym only.
+CREATE TABLE audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    action VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    details JSON NOT NULL DEFAULT (JSON_OBJECT()),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB;
+
+-- Index for a coupon's changelog: its notes and terminations, newest first
+CREATE INDEX idx_audit_log_subject ON audit_log(subject, created_at);
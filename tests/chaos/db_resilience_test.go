@@ -5,8 +5,11 @@
 // - Connection pool exhaustion (AC #1)
 // - Query timeouts (AC #2)
 // - Connection drops mid-transaction (AC #3)
+// - Latency and partitions between the API and PostgreSQL
 //
-// All tests use real HTTP requests to the docker-compose server.
+// All tests use real HTTP requests to the docker-compose server. Network faults are
+// injected by toxiproxy (docker-compose.chaos.yml, see faults_test.go); tests needing
+// it are skipped without TEST_TOXIPROXY_URL.
 package chaos
 
 import (
@@ -256,7 +259,7 @@ func TestQueryTimeout(t *testing.T) {
 	})
 }
 
-// TestConnectionDrop simulates a connection being terminated mid-transaction.
+// TestConnectionDrop simulates the connections to PostgreSQL being dropped mid-transaction.
 //
 // AC #3: Given the CI pipeline runs the database resilience test job
 //
@@ -265,8 +268,10 @@ func TestQueryTimeout(t *testing.T) {
 //	And the connection is removed from the pool
 //	And subsequent requests use healthy connections
 //
-// This test uses PostgreSQL's pg_terminate_backend to simulate connection drops.
+// This test drops every connection at the proxy between the API and PostgreSQL
+// (see faults_test.go), the API's included.
 func TestConnectionDrop(t *testing.T) {
+	faults := requireFaults(t)
 	cleanupTables(t)
 
 	const (
@@ -283,44 +288,29 @@ func TestConnectionDrop(t *testing.T) {
 		couponName, availableStock, availableStock)
 	require.NoError(t, err, "Failed to create test coupon")
 
-	// Test 1: Terminate connection mid-transaction (database-level test)
-	t.Run("Connection terminated mid-transaction", func(t *testing.T) {
+	// Test 1: Drop the connection mid-transaction (database-level test)
+	t.Run("Connection dropped mid-transaction", func(t *testing.T) {
 		testCtx, testCancel := context.WithTimeout(ctx, 30*time.Second)
 		defer testCancel()
 
-		// Start a transaction
-		tx, err := testPool.Begin(testCtx)
+		// Start a transaction through the proxy
+		tx, err := faults.Pool(t).Begin(testCtx)
 		require.NoError(t, err, "Failed to begin transaction")
 		defer tx.Rollback(context.Background())
 
-		// Get the backend PID for this connection
-		var backendPID int
-		err = tx.QueryRow(testCtx, "SELECT pg_backend_pid()").Scan(&backendPID)
-		require.NoError(t, err, "Failed to get backend PID")
-		t.Logf("Transaction backend PID: %d", backendPID)
-
 		// Do some work in the transaction (but don't commit yet)
 		_, err = tx.Exec(testCtx,
 			"UPDATE coupons SET remaining_amount = remaining_amount - 1 WHERE name = $1",
 			couponName)
 		require.NoError(t, err, "Failed to update in transaction")
 
-		// From a separate connection, terminate the transaction's connection
-		_, err = testPool.Exec(testCtx, "SELECT pg_terminate_backend($1)", backendPID)
-		if err != nil {
-			t.Logf("Note: pg_terminate_backend returned error (expected in some cases): %v", err)
-		}
-
-		// The transaction should now be broken
-		time.Sleep(50 * time.Millisecond) // Give time for termination to propagate
+		faults.DropConnections(t)
 
-		// Try to use the terminated connection
+		// The transaction's connection is gone, so it can neither continue nor commit
 		_, err = tx.Exec(testCtx, "SELECT 1")
-
-		// We expect an error - the connection was terminated
-		if err != nil {
-			t.Logf("Transaction correctly failed after connection termination: %v", err)
-		}
+		require.Error(t, err, "Transaction should fail after its connection dropped")
+		t.Logf("Transaction correctly failed after connection drop: %v", err)
+		require.Error(t, tx.Commit(testCtx), "Commit should fail after the connection dropped")
 
 		// Verify no partial commit occurred
 		verifyCtx, verifyCancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -383,6 +373,115 @@ func TestConnectionDrop(t *testing.T) {
 	})
 }
 
+// TestDatabaseLatency verifies claims stay correct, only slower, while every packet
+// between the API and PostgreSQL is delayed.
+func TestDatabaseLatency(t *testing.T) {
+	faults := requireFaults(t)
+	cleanupTables(t)
+
+	const (
+		couponName = "LATENCY_TEST"
+		latency    = 100 * time.Millisecond
+		claims     = 10
+	)
+	createTestCoupon(t, couponName, claims*2)
+
+	faults.AddLatency(t, latency, 20*time.Millisecond)
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, claims)
+	start := time.Now()
+	for i := 0; i < claims; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			resp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{
+				"user_id":     fmt.Sprintf("latency_user_%d", id),
+				"coupon_name": couponName,
+			})
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			resp.Body.Close()
+			statuses <- resp.StatusCode
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+	elapsed := time.Since(start)
+
+	for status := range statuses {
+		assert.Equal(t, http.StatusOK, status, "Claims should succeed despite latency")
+	}
+	assert.GreaterOrEqual(t, elapsed, latency, "Claims should have been delayed by the injected latency")
+	t.Logf("%d claims took %v with %v latency", claims, elapsed, latency)
+
+	remaining, claimCount := getCouponFromDB(t, couponName)
+	assert.Equal(t, claims, claimCount, "Every claim should be recorded once")
+	assert.Equal(t, claims, remaining, "Stock should be decremented once per claim")
+}
+
+// TestDatabasePartition verifies the API fails requests cleanly while cut off from
+// PostgreSQL, reports itself not ready, and recovers once the partition heals.
+func TestDatabasePartition(t *testing.T) {
+	faults := requireFaults(t)
+	cleanupTables(t)
+
+	const couponName = "PARTITION_TEST"
+	createTestCoupon(t, couponName, 10)
+
+	faults.Partition(t)
+
+	t.Run("Requests fail during partition", func(t *testing.T) {
+		resp, err := getJSON(formatURL("/readyz"))
+		require.NoError(t, err, "Readiness probe should respond")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "API should report not ready")
+
+		resp, err = getJSON(formatURL("/livez"))
+		require.NoError(t, err, "Liveness probe should respond")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "API process should stay live")
+
+		for i := 0; i < 5; i++ {
+			resp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{
+				"user_id":     fmt.Sprintf("partition_user_%d", i),
+				"coupon_name": couponName,
+			})
+			require.NoError(t, err, "Claim %d should get a response", i)
+			resp.Body.Close()
+			assert.GreaterOrEqual(t, resp.StatusCode, 500, "Claim %d should fail with a server error", i)
+		}
+	})
+
+	faults.Heal(t)
+
+	t.Run("API recovers after partition heals", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			resp, err := getJSON(formatURL("/readyz"))
+			if err != nil {
+				return false
+			}
+			resp.Body.Close()
+			return resp.StatusCode == http.StatusOK
+		}, 15*time.Second, 250*time.Millisecond, "API should become ready again")
+
+		resp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{
+			"user_id":     "partition_user_after",
+			"coupon_name": couponName,
+		})
+		require.NoError(t, err, "Claim should get a response")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "Claim should succeed after the partition heals")
+
+		// Claims that failed during the partition left nothing behind
+		remaining, claimCount := getCouponFromDB(t, couponName)
+		assert.Equal(t, 1, claimCount, "Only the claim after the partition should be recorded")
+		assert.Equal(t, 9, remaining, "Only the claim after the partition should take stock")
+	})
+}
+
 // TestGoroutineLeakDetection is a comprehensive test that runs multiple
 // chaos scenarios via HTTP and verifies no goroutine leaks occur.
 func TestGoroutineLeakDetection(t *testing.T) {
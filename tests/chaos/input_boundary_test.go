@@ -216,6 +216,38 @@ func TestGetCoupon_LongNameBoundary(t *testing.T) {
 	}
 }
 
+func TestGetCoupon_HugeClaimList(t *testing.T) {
+	cleanupTables(t)
+	ctx := context.Background()
+
+	const claims = 200000
+	createValidCoupon(t, "HUGE_CLAIMS", claims)
+	_, err := testPool.Exec(ctx, `INSERT INTO claims (user_id, coupon_name, claim_sequence)
+		SELECT 'user_' || g, 'HUGE_CLAIMS', g FROM generate_series(1, $1::int) g`, claims)
+	require.NoError(t, err)
+	_, err = testPool.Exec(ctx, `UPDATE coupons SET remaining_amount = 0 WHERE name = 'HUGE_CLAIMS'`)
+	require.NoError(t, err)
+
+	resp, err := httpClient.Get(formatURL("/api/coupons/HUGE_CLAIMS"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	// The default COUPON_CLAIMED_BY_MAX of 10000 keeps the response small
+	assert.Less(t, len(body), 1<<20, "response should stay under 1MB")
+	var coupon struct {
+		ClaimedBy    []string `json:"claimed_by"`
+		ClaimedCount int      `json:"claimed_count"`
+		Truncated    bool     `json:"truncated"`
+	}
+	require.NoError(t, json.Unmarshal(body, &coupon))
+	assert.Len(t, coupon.ClaimedBy, 10000)
+	assert.Equal(t, claims, coupon.ClaimedCount)
+	assert.True(t, coupon.Truncated)
+}
+
 func TestClaimCoupon_LongNameBoundary(t *testing.T) {
 	cleanupTables(t)
 
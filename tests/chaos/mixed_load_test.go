@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/repository"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
@@ -168,7 +169,7 @@ func TestMixedOperationLoad(t *testing.T) {
 				// Random coupon from base set
 				couponName := baseCoupons[targetCouponIdx]
 				userID := fmt.Sprintf("chaos_user_%d", opID)
-				err := svc.ClaimCoupon(opCtx, userID, couponName)
+				_, err := svc.ClaimCoupon(opCtx, &model.ClaimCouponRequest{UserID: userID, CouponName: couponName})
 				if err == nil {
 					atomic.AddInt32(&claimSuccess, 1)
 				} else {
@@ -274,7 +275,7 @@ func TestZeroStockStampede(t *testing.T) {
 		wg.Add(1)
 		go func(userID string) {
 			defer wg.Done()
-			err := svc.ClaimCoupon(ctx, userID, couponName)
+			_, err := svc.ClaimCoupon(ctx, &model.ClaimCouponRequest{UserID: userID, CouponName: couponName})
 			results <- err
 		}(fmt.Sprintf("stampede_user_%d", i))
 	}
@@ -288,7 +289,7 @@ func TestZeroStockStampede(t *testing.T) {
 		switch {
 		case err == nil:
 			successes++
-		case errors.Is(err, service.ErrNoStock):
+		case errors.Is(err, apperr.ErrNoStock):
 			noStock++
 		case isServerError(err):
 			serverErrors++
@@ -363,7 +364,7 @@ func TestConstraintViolationStorm(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err := svc.ClaimCoupon(ctx, userID, couponName)
+			_, err := svc.ClaimCoupon(ctx, &model.ClaimCouponRequest{UserID: userID, CouponName: couponName})
 			results <- err
 		}()
 	}
@@ -377,7 +378,7 @@ func TestConstraintViolationStorm(t *testing.T) {
 		switch {
 		case err == nil:
 			successes++
-		case errors.Is(err, service.ErrAlreadyClaimed):
+		case errors.Is(err, apperr.ErrAlreadyClaimed):
 			alreadyClaimed++
 		case isRawDatabaseError(err):
 			rawDBErrors++
@@ -468,15 +469,15 @@ func TestInterleavedCreateClaim(t *testing.T) {
 			// CLAIM operation
 			go func(userID string) {
 				defer wg.Done()
-				err := svc.ClaimCoupon(ctx, userID, couponName)
+				_, err := svc.ClaimCoupon(ctx, &model.ClaimCouponRequest{UserID: userID, CouponName: couponName})
 				switch {
 				case err == nil:
 					atomic.AddInt32(&claimSuccess, 1)
-				case errors.Is(err, service.ErrCouponNotFound):
+				case errors.Is(err, apperr.ErrCouponNotFound):
 					atomic.AddInt32(&claimNotFound, 1)
-				case errors.Is(err, service.ErrNoStock):
+				case errors.Is(err, apperr.ErrNoStock):
 					atomic.AddInt32(&claimNoStock, 1)
-				case errors.Is(err, service.ErrAlreadyClaimed):
+				case errors.Is(err, apperr.ErrAlreadyClaimed):
 					atomic.AddInt32(&claimAlreadyClaimed, 1)
 				default:
 					atomic.AddInt32(&claimOther, 1)
@@ -36,6 +36,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/fairyhunter13/scalable-coupon-system/internal/apperr"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/ports/faults"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/repository"
 	"github.com/fairyhunter13/scalable-coupon-system/internal/service"
 )
@@ -93,8 +96,8 @@ func TestPartialFailure_InsertSucceedsDecrementFails(t *testing.T) {
 
 	// Step 2: Insert claim (this would succeed in normal flow)
 	_, err = tx.Exec(ctx,
-		"INSERT INTO claims (user_id, coupon_name) VALUES ($1, $2)",
-		testUserID, couponName)
+		"INSERT INTO claims (user_id, coupon_name, claim_sequence) VALUES ($1, $2, $3)",
+		testUserID, couponName, 1)
 	require.NoError(t, err, "Claim INSERT should succeed within transaction")
 
 	// Step 3: Simulate failure BEFORE decrement - ROLLBACK instead of continuing
@@ -148,8 +151,8 @@ func TestPartialFailure_MultipleOperations(t *testing.T) {
 	for i := 0; i < 3; i++ {
 		userID := fmt.Sprintf("multi_user_%d", i)
 		_, err = tx.Exec(ctx,
-			"INSERT INTO claims (user_id, coupon_name) VALUES ($1, $2)",
-			userID, couponName)
+			"INSERT INTO claims (user_id, coupon_name, claim_sequence) VALUES ($1, $2, $3)",
+			userID, couponName, i+1)
 		require.NoError(t, err, "Claim %d INSERT should succeed", i)
 	}
 
@@ -179,6 +182,57 @@ func TestPartialFailure_MultipleOperations(t *testing.T) {
 	t.Logf("Multi-operation rollback verified: all 3 claims and stock decrement rolled back")
 }
 
+// TestPartialFailure_InjectedServiceFaults drives the real ClaimCoupon code path against
+// Postgres and injects a failure at each step after the claim INSERT. Every claim must
+// be rolled back completely, and the coupon must stay claimable afterwards.
+func TestPartialFailure_InjectedServiceFaults(t *testing.T) {
+	steps := []faults.Method{faults.CouponDecrementStock, faults.TxCommit}
+
+	for _, step := range steps {
+		t.Run(string(step), func(t *testing.T) {
+			cleanupTables(t)
+			ctx := context.Background()
+
+			const (
+				couponName   = "INJECTED_FAIL_TEST"
+				initialStock = 5
+				testUserID   = "user_injected_fail"
+			)
+			_, err := testPool.Exec(ctx,
+				"INSERT INTO coupons (name, amount, remaining_amount) VALUES ($1, $2, $2)",
+				couponName, initialStock)
+			require.NoError(t, err)
+
+			inj := faults.NewInjector()
+			inj.Fail(step, faults.ErrInjected, faults.Times(1))
+			claimRepo := faults.ClaimRepository(repository.NewClaimRepository(testPool), inj)
+			svc := service.NewCouponServiceWithTxBeginner(
+				faults.TxBeginner(testPool, inj),
+				faults.CouponRepository(repository.NewCouponRepository(testPool), inj),
+				claimRepo,
+			)
+
+			req := &model.ClaimCouponRequest{UserID: testUserID, CouponName: couponName}
+			_, err = svc.ClaimCoupon(ctx, req)
+			require.ErrorIs(t, err, faults.ErrInjected)
+			require.Len(t, claimRepo.InsertCalls(), 1, "failure must be injected after the claim INSERT")
+
+			remaining, claimCount := getCouponFromDB(t, couponName)
+			assert.Equal(t, 0, claimCount, "Claim should NOT exist after an injected %s failure", step)
+			assert.Equal(t, initialStock, remaining, "Stock should be unchanged after an injected %s failure", step)
+
+			// The fault fired once; the retry must succeed as if nothing happened
+			receipt, err := svc.ClaimCoupon(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, 1, receipt.ClaimSequence, "Rolled back claim must not consume a sequence number")
+
+			remaining, claimCount = getCouponFromDB(t, couponName)
+			assert.Equal(t, 1, claimCount)
+			assert.Equal(t, initialStock-1, remaining)
+		})
+	}
+}
+
 // =============================================================================
 // AC #2: Deadlock Recovery Test
 // =============================================================================
@@ -232,7 +286,7 @@ func TestDeadlockRecovery_ConcurrentSameCoupon(t *testing.T) {
 		go func(id int) {
 			defer wg.Done()
 			userID := fmt.Sprintf("deadlock_user_%d", id)
-			err := svc.ClaimCoupon(ctx, userID, couponName)
+			_, err := svc.ClaimCoupon(ctx, &model.ClaimCouponRequest{UserID: userID, CouponName: couponName})
 			results <- err
 		}(i)
 	}
@@ -246,7 +300,7 @@ func TestDeadlockRecovery_ConcurrentSameCoupon(t *testing.T) {
 		switch {
 		case err == nil:
 			successes++
-		case errors.Is(err, service.ErrNoStock):
+		case errors.Is(err, apperr.ErrNoStock):
 			noStock++
 		default:
 			otherErrors++
@@ -323,10 +377,10 @@ func TestDeadlockRecovery_HighContention(t *testing.T) {
 		go func(id int) {
 			defer wg.Done()
 			userID := fmt.Sprintf("contention_user_%d", id)
-			err := svc.ClaimCoupon(ctx, userID, couponName)
+			_, err := svc.ClaimCoupon(ctx, &model.ClaimCouponRequest{UserID: userID, CouponName: couponName})
 			if err == nil {
 				atomic.AddInt32(&successes, 1)
-			} else if errors.Is(err, service.ErrNoStock) {
+			} else if errors.Is(err, apperr.ErrNoStock) {
 				atomic.AddInt32(&noStock, 1)
 			}
 		}(i)
@@ -397,11 +451,11 @@ func TestNegativeStockPrevention_ConcurrentExhaustion(t *testing.T) {
 		go func(id int) {
 			defer wg.Done()
 			userID := fmt.Sprintf("negative_test_user_%d", id)
-			err := svc.ClaimCoupon(ctx, userID, couponName)
+			_, err := svc.ClaimCoupon(ctx, &model.ClaimCouponRequest{UserID: userID, CouponName: couponName})
 			switch {
 			case err == nil:
 				atomic.AddInt32(&successes, 1)
-			case errors.Is(err, service.ErrNoStock):
+			case errors.Is(err, apperr.ErrNoStock):
 				atomic.AddInt32(&noStock, 1)
 			default:
 				atomic.AddInt32(&otherErrors, 1)
@@ -502,7 +556,7 @@ func TestNegativeStockPrevention_RapidSuccession(t *testing.T) {
 	var successes int
 	for i := 0; i < numClaims; i++ {
 		userID := fmt.Sprintf("rapid_user_%d", i)
-		err := svc.ClaimCoupon(ctx, userID, couponName)
+		_, err := svc.ClaimCoupon(ctx, &model.ClaimCouponRequest{UserID: userID, CouponName: couponName})
 		if err == nil {
 			successes++
 		}
@@ -564,7 +618,8 @@ func TestContextCancellation_MidTransaction(t *testing.T) {
 	// Start claim in goroutine
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- svc.ClaimCoupon(ctx, "user_cancel", couponName)
+		_, err := svc.ClaimCoupon(ctx, &model.ClaimCouponRequest{UserID: "user_cancel", CouponName: couponName})
+		errCh <- err
 	}()
 
 	// Cancel context almost immediately
@@ -661,7 +716,8 @@ func TestContextCancellation_DuringLockWait(t *testing.T) {
 
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- svc.ClaimCoupon(waitCtx, "waiting_user", couponName)
+		_, err := svc.ClaimCoupon(waitCtx, &model.ClaimCouponRequest{UserID: "waiting_user", CouponName: couponName})
+		errCh <- err
 	}()
 
 	// Wait for the claim to time out
@@ -723,7 +779,7 @@ func TestContextCancellation_PoolRecovery(t *testing.T) {
 			cancel()
 		}(i)
 
-		_ = svc.ClaimCoupon(ctx, fmt.Sprintf("cancel_user_%d", i), couponName)
+		_, _ = svc.ClaimCoupon(ctx, &model.ClaimCouponRequest{UserID: fmt.Sprintf("cancel_user_%d", i), CouponName: couponName})
 	}
 
 	// Allow time for cleanup
@@ -739,7 +795,7 @@ func TestContextCancellation_PoolRecovery(t *testing.T) {
 	successCtx, successCancel := context.WithTimeout(bgCtx, 10*time.Second)
 	defer successCancel()
 
-	err = svc.ClaimCoupon(successCtx, "recovery_user", couponName)
+	_, err = svc.ClaimCoupon(successCtx, &model.ClaimCouponRequest{UserID: "recovery_user", CouponName: couponName})
 	assert.NoError(t, err, "Normal claim should succeed after cancellation stress")
 
 	// Verify pool metrics
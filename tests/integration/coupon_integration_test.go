@@ -11,10 +11,17 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/repository"
 )
 
 // TestCreateCoupon_Integration_Success tests POST /api/coupons success via real HTTP
@@ -303,10 +310,10 @@ func TestGetCoupon_Integration_WithClaims(t *testing.T) {
 
 	// Insert claims
 	claims := []string{"user_001", "user_002", "user_003", "user_004", "user_005"}
-	for _, userID := range claims {
+	for i, userID := range claims {
 		_, err := testPool.Exec(context.Background(),
-			"INSERT INTO claims (user_id, coupon_name) VALUES ($1, $2)",
-			userID, "PROMO_SUPER")
+			"INSERT INTO claims (user_id, coupon_name, claim_sequence) VALUES ($1, $2, $3)",
+			userID, "PROMO_SUPER", i+1)
 		require.NoError(t, err)
 	}
 
@@ -433,9 +440,12 @@ func TestClaimCoupon_Integration_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected 200 OK for successful claim")
 
-	// Verify empty response body per AC
-	respBody, _ := io.ReadAll(resp.Body)
-	assert.Empty(t, respBody, "Response body should be empty on success")
+	// Verify claim receipt
+	var receipt map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&receipt))
+	assert.Equal(t, "user_001", receipt["user_id"])
+	assert.Equal(t, "PROMO_CLAIM", receipt["coupon_name"])
+	assert.Equal(t, float64(1), receipt["claim_sequence"])
 
 	// Verify database state: claim record exists
 	var claimCount int
@@ -482,10 +492,12 @@ func TestClaimCoupon_Integration_DuplicateClaim(t *testing.T) {
 
 	assert.Equal(t, http.StatusConflict, resp.StatusCode, "Expected 409 Conflict for duplicate claim")
 
-	var result map[string]string
+	var result map[string]any
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	require.NoError(t, err)
 	assert.Equal(t, "coupon already claimed by user", result["error"], "Exact error message required per AC2")
+	assert.Equal(t, float64(1), result["claim_sequence"], "Conflict should point at the earlier claim")
+	assert.NotEmpty(t, result["claimed_at"])
 
 	// Verify remaining_amount only decremented once
 	var remainingAmount int
@@ -528,6 +540,126 @@ func TestClaimCoupon_Integration_OutOfStock(t *testing.T) {
 	assert.Equal(t, 0, claimCount, "No claim should be created for out of stock")
 }
 
+func TestClaimCoupon_Integration_Unlimited(t *testing.T) {
+	cleanupTables(t)
+
+	resp, err := postJSON(formatURL("/api/coupons"), map[string]interface{}{
+		"name":      "WELCOME",
+		"unlimited": true,
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// Users still claim unlimited coupons once
+	claims := []struct {
+		userID string
+		status int
+	}{
+		{"user_001", http.StatusOK},
+		{"user_002", http.StatusOK},
+		{"user_001", http.StatusConflict},
+	}
+	for _, c := range claims {
+		resp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{
+			"user_id":     c.userID,
+			"coupon_name": "WELCOME",
+		})
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, c.status, resp.StatusCode, c.userID)
+	}
+
+	resp, err = getJSON(formatURL("/api/coupons/WELCOME"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var coupon map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&coupon))
+	assert.Equal(t, true, coupon["unlimited"])
+	assert.Equal(t, float64(2), coupon["claimed"])
+	assert.Equal(t, float64(0), coupon["remaining_amount"])
+}
+
+func TestClaimCoupon_Integration_Unlisted(t *testing.T) {
+	cleanupTables(t)
+
+	resp, err := postJSON(formatURL("/api/coupons"), map[string]interface{}{
+		"name":       "SECRET_DROP",
+		"amount":     10,
+		"visibility": "unlisted",
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// Claimable by exact name
+	resp, err = postJSON(formatURL("/api/coupons/claim"), map[string]string{
+		"user_id":     "user_001",
+		"coupon_name": "SECRET_DROP",
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// But neither readable nor listed
+	for _, path := range []string{"/api/coupons/SECRET_DROP", "/api/coupons/SECRET_DROP/claims"} {
+		resp, err = getJSON(formatURL(path))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+
+	resp, err = getJSON(formatURL("/api/coupons"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var list model.CouponListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Empty(t, list.Items)
+}
+
+func TestGetCoupon_Integration_TruncatesClaimedBy(t *testing.T) {
+	cleanupTables(t)
+	ctx := context.Background()
+
+	resp, err := postJSON(formatURL("/api/coupons"), map[string]interface{}{
+		"name":   "HUGE",
+		"amount": 20000,
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// More claims than COUPON_CLAIMED_BY_MAX (10000 by default)
+	_, err = testPool.Exec(ctx, `INSERT INTO claims (user_id, coupon_name, claim_sequence)
+		SELECT 'user_' || g, 'HUGE', g FROM generate_series(1, 10050) g`)
+	require.NoError(t, err)
+	_, err = testPool.Exec(ctx, `UPDATE coupons SET remaining_amount = amount - 10050 WHERE name = 'HUGE'`)
+	require.NoError(t, err)
+
+	resp, err = getJSON(formatURL("/api/coupons/HUGE"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var coupon model.CouponResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&coupon))
+	assert.Len(t, coupon.ClaimedBy, 10000)
+	assert.Equal(t, 10050, coupon.ClaimedCount)
+	assert.True(t, coupon.Truncated)
+	assert.Equal(t, 9950, coupon.RemainingAmount)
+
+	// Checking single users is never truncated
+	resp, err = getJSON(formatURL("/api/coupons/HUGE?claimed_by_contains=user_10050"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var narrowed model.CouponResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&narrowed))
+	assert.Equal(t, []string{"user_10050"}, narrowed.ClaimedBy)
+	assert.Equal(t, 1, narrowed.ClaimedCount)
+	assert.False(t, narrowed.Truncated)
+}
+
 func TestClaimCoupon_Integration_CouponNotFound(t *testing.T) {
 	cleanupTables(t)
 
@@ -626,3 +758,429 @@ func TestClaimCoupon_Integration_AtomicTransaction(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 3, claimCount, "Exactly 3 claims should exist")
 }
+
+// TestListCoupons_Integration_FilterByTag tests tag assignment at create/update time and tag-filtered listing
+func TestListCoupons_Integration_FilterByTag(t *testing.T) {
+	cleanupTables(t)
+
+	for _, body := range []map[string]interface{}{
+		{"name": "BF_APP", "amount": 10, "tags": []string{"blackfriday", "app"}},
+		{"name": "BF_WEB", "amount": 10, "tags": []string{"blackfriday", "web"}},
+		{"name": "SUMMER", "amount": 10},
+	} {
+		resp, err := postJSON(formatURL("/api/coupons"), body)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	// Tag an existing coupon via PATCH
+	req, err := http.NewRequest(http.MethodPatch, formatURL("/api/coupons/SUMMER"),
+		strings.NewReader(`{"tags": ["blackfriday"]}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	require.NoError(t, err)
+	var updated map[string]interface{}
+	require.NoError(t, readJSONResponse(resp, &updated))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []interface{}{"blackfriday"}, updated["tags"])
+
+	resp, err = getJSON(formatURL("/api/coupons?tag=web"))
+	require.NoError(t, err)
+	var webOnly struct {
+		Items []struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		} `json:"items"`
+	}
+	require.NoError(t, readJSONResponse(resp, &webOnly))
+	require.Len(t, webOnly.Items, 1)
+	assert.Equal(t, "BF_WEB", webOnly.Items[0].Name)
+
+	resp, err = getJSON(formatURL("/api/coupons?tag=blackfriday"))
+	require.NoError(t, err)
+	var all struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+	}
+	require.NoError(t, readJSONResponse(resp, &all))
+	require.Len(t, all.Items, 3)
+	assert.Equal(t, "BF_APP", all.Items[0].Name, "listing is ordered by name")
+
+	// Paging through the same listing a coupon at a time reads it whole
+	var names []string
+	cursor := ""
+	for range 4 {
+		resp, err = getJSON(formatURL("/api/coupons?tag=blackfriday&limit=1&cursor=" + cursor))
+		require.NoError(t, err)
+		var page model.CouponListResponse
+		require.NoError(t, readJSONResponse(resp, &page))
+		for _, c := range page.Items {
+			names = append(names, c.Name)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, []string{all.Items[0].Name, all.Items[1].Name, all.Items[2].Name}, names)
+}
+
+func TestListClaims_Integration_SequenceOrder(t *testing.T) {
+	cleanupTables(t)
+
+	resp, err := postJSON(formatURL("/api/coupons"), map[string]interface{}{"name": "SEQ_TEST", "amount": 5})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	users := []string{"user_a", "user_b", "user_c"}
+	for i, userID := range users {
+		resp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{
+			"user_id":     userID,
+			"coupon_name": "SEQ_TEST",
+		})
+		require.NoError(t, err)
+
+		var receipt map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&receipt))
+		resp.Body.Close()
+		assert.Equal(t, float64(i+1), receipt["claim_sequence"], "claim %d should get sequence %d", i, i+1)
+	}
+
+	listResp, err := getJSON(formatURL("/api/coupons/SEQ_TEST/claims"))
+	require.NoError(t, err)
+	defer listResp.Body.Close()
+	require.Equal(t, http.StatusOK, listResp.StatusCode)
+
+	var result struct {
+		Items []struct {
+			UserID        string `json:"user_id"`
+			ClaimSequence int    `json:"claim_sequence"`
+		} `json:"items"`
+		Total int `json:"total"`
+	}
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&result))
+	require.Len(t, result.Items, len(users))
+	assert.Equal(t, len(users), result.Total)
+	for i, c := range result.Items {
+		assert.Equal(t, users[i], c.UserID)
+		assert.Equal(t, i+1, c.ClaimSequence)
+	}
+
+	pageResp, err := getJSON(formatURL("/api/coupons/SEQ_TEST/claims?limit=2"))
+	require.NoError(t, err)
+	var first model.ClaimListResponse
+	require.NoError(t, readJSONResponse(pageResp, &first))
+	require.Len(t, first.Items, 2)
+	require.NotEmpty(t, first.NextCursor)
+
+	pageResp, err = getJSON(formatURL("/api/coupons/SEQ_TEST/claims?limit=2&cursor=" + first.NextCursor))
+	require.NoError(t, err)
+	var second model.ClaimListResponse
+	require.NoError(t, readJSONResponse(pageResp, &second))
+	require.Len(t, second.Items, 1)
+	assert.Equal(t, "user_c", second.Items[0].UserID)
+	assert.Empty(t, second.NextCursor)
+}
+
+func TestClaimCoupon_Integration_AssignsTier(t *testing.T) {
+	cleanupTables(t)
+
+	resp, err := postJSON(formatURL("/api/coupons"), map[string]interface{}{
+		"name":   "TIER_TEST",
+		"amount": 3,
+		"tiers":  []map[string]interface{}{{"name": "gold", "size": 1}, {"name": "silver", "size": 1}},
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	for _, want := range []string{"gold", "silver", ""} {
+		resp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{
+			"user_id":     "user_" + want,
+			"coupon_name": "TIER_TEST",
+		})
+		require.NoError(t, err)
+
+		var receipt map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&receipt))
+		resp.Body.Close()
+
+		if want == "" {
+			assert.NotContains(t, receipt, "tier", "claims beyond the last tier get no tier")
+		} else {
+			assert.Equal(t, want, receipt["tier"])
+		}
+	}
+}
+
+func TestPutCoupon_Integration_Idempotent(t *testing.T) {
+	cleanupTables(t)
+
+	put := func(body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPut, formatURL("/api/coupons/PUT_TEST"), strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := put(`{"amount": 10, "tags": ["a", "b"]}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "first PUT creates")
+
+	resp = put(`{"amount": 10, "tags": ["b", "a"]}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "re-applying the same configuration succeeds")
+
+	resp = put(`{"amount": 20, "tags": ["a", "b"]}`)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var result struct {
+		Diff []struct {
+			Field string `json:"field"`
+		} `json:"diff"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Len(t, result.Diff, 1)
+	assert.Equal(t, "amount", result.Diff[0].Field)
+}
+
+func TestCreateCoupon_Integration_ConcurrentIdempotent(t *testing.T) {
+	cleanupTables(t)
+
+	// Creators racing on one name each get the coupon: one 201, the rest 200
+	const creators = 10
+	statuses := make(chan int, creators)
+	var wg sync.WaitGroup
+	for range creators {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := postJSON(formatURL("/api/coupons?idempotent=true"), map[string]interface{}{
+				"name":     "RACE_CREATE",
+				"amount":   10,
+				"channels": map[string]int{"app": 70, "web": 30},
+			})
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			resp.Body.Close()
+			statuses <- resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusOK: creators - 1}, counts)
+
+	var partitions int
+	require.NoError(t, testPool.QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM coupon_channel_quotas WHERE coupon_name = $1", "RACE_CREATE").Scan(&partitions))
+	assert.Equal(t, 2, partitions, "partitions are inserted once")
+}
+
+func TestApplyManifest_Integration_Reconciles(t *testing.T) {
+	cleanupTables(t)
+	createTestCoupon(t, "APPLY_KEEP", 10)
+	createTestCoupon(t, "APPLY_OLD", 5)
+
+	apply := func(query, body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, formatURL("/api/admin/apply"+query), strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/yaml")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+	manifest := strings.Join([]string{
+		"coupons:",
+		"  - name: APPLY_KEEP",
+		"    amount: 15",
+		"  - name: APPLY_NEW",
+		"    amount: 3",
+	}, "\n")
+
+	resp := apply("?dry_run=true", manifest)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	remaining, _ := getCouponFromDB(t, "APPLY_KEEP")
+	assert.Equal(t, 10, remaining, "dry run must not write")
+
+	resp = apply("", manifest)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report struct {
+		Changes []struct {
+			Name   string `json:"name"`
+			Action string `json:"action"`
+		} `json:"changes"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	actions := map[string]string{}
+	for _, c := range report.Changes {
+		actions[c.Name] = c.Action
+	}
+	assert.Equal(t, map[string]string{"APPLY_KEEP": "update", "APPLY_NEW": "create", "APPLY_OLD": "disable"}, actions)
+
+	remaining, _ = getCouponFromDB(t, "APPLY_KEEP")
+	assert.Equal(t, 15, remaining, "amount increase tops up stock")
+	remaining, _ = getCouponFromDB(t, "APPLY_NEW")
+	assert.Equal(t, 3, remaining)
+
+	claimResp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{"user_id": "u1", "coupon_name": "APPLY_OLD"})
+	require.NoError(t, err)
+	defer claimResp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, claimResp.StatusCode, "disabled coupons cannot be claimed")
+}
+
+func TestEraseUserData_Integration_PreservesCounts(t *testing.T) {
+	cleanupTables(t)
+	createTestCoupon(t, "ERASE_TEST", 5)
+
+	for _, user := range []string{"erase_me", "keep_me"} {
+		resp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{"user_id": user, "coupon_name": "ERASE_TEST"})
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, formatURL("/api/users/erase_me/data"), nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Pseudonym        string `json:"pseudonym"`
+		ClaimsAnonymized int64  `json:"claims_anonymized"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, int64(1), result.ClaimsAnonymized)
+
+	remaining, claimCount := getCouponFromDB(t, "ERASE_TEST")
+	assert.Equal(t, 3, remaining, "stock is unchanged by erasure")
+	assert.Equal(t, 2, claimCount, "claim count is unchanged by erasure")
+
+	var erased, audited int
+	ctx := context.Background()
+	require.NoError(t, testPool.QueryRow(ctx, `SELECT COUNT(*) FROM claims WHERE user_id = 'erase_me'`).Scan(&erased))
+	require.NoError(t, testPool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM audit_log WHERE action = 'user_data_erased' AND subject = $1`, result.Pseudonym).Scan(&audited))
+	assert.Zero(t, erased)
+	assert.Equal(t, 1, audited)
+}
+
+func TestCouponChangelog_Integration(t *testing.T) {
+	cleanupTables(t)
+	createTestCoupon(t, "NOTES_TEST", 5)
+	_, err := testPool.Exec(context.Background(), `DELETE FROM audit_log WHERE subject = 'NOTES_TEST'`)
+	require.NoError(t, err)
+
+	resp, err := postJSON(formatURL("/api/coupons/NOTES_TEST/notes"), map[string]string{"note": "stock too low for the launch", "author": "ops"})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp, err = postJSON(formatURL("/api/admin/coupons/NOTES_TEST/terminate"), map[string]string{"reason": "re-creating with amount 500"})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(formatURL("/api/admin/coupons/NOTES_TEST/changelog"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var changelog struct {
+		Items []struct {
+			Action  string         `json:"action"`
+			Details map[string]any `json:"details"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&changelog))
+	require.Len(t, changelog.Items, 2)
+	assert.Equal(t, "coupon_terminated", changelog.Items[0].Action, "newest first")
+	assert.Equal(t, "re-creating with amount 500", changelog.Items[0].Details["reason"])
+	assert.Equal(t, "coupon_note", changelog.Items[1].Action)
+	assert.Equal(t, map[string]any{"note": "stock too low for the launch", "author": "ops"}, changelog.Items[1].Details)
+}
+
+func TestExpireClaims_Integration(t *testing.T) {
+	cleanupTables(t)
+	ctx := context.Background()
+	_, err := testPool.Exec(ctx, `INSERT INTO coupons (name, amount, remaining_amount, claim_retention)
+		VALUES ('RETAIN_TEST', 10, 7, '{"days": 30, "action": "anonymize"}')`)
+	require.NoError(t, err)
+	_, err = testPool.Exec(ctx, `INSERT INTO claims (user_id, coupon_name, claim_sequence, created_at)
+		VALUES ('old_1', 'RETAIN_TEST', 1, NOW() - INTERVAL '40 days'),
+			('old_2', 'RETAIN_TEST', 2, NOW() - INTERVAL '31 days'),
+			('recent', 'RETAIN_TEST', 3, NOW() - INTERVAL '1 day')`)
+	require.NoError(t, err)
+
+	repo := repository.NewClaimRepository(testPool)
+	policies, err := repo.RetentionPolicies(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []model.CouponClaimRetention{{CouponName: "RETAIN_TEST",
+		ClaimRetention: model.ClaimRetention{Days: 30, Action: model.RetentionAnonymize}}}, policies)
+
+	expire := func() int {
+		tx, err := testPool.Begin(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+		expired, err := repo.ExpireClaims(ctx, tx, "RETAIN_TEST", model.RetentionAnonymize, time.Now().AddDate(0, 0, -30), 100)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit(ctx))
+		return expired
+	}
+	assert.Equal(t, 2, expire())
+	assert.Zero(t, expire(), "anonymized claims are not anonymized again")
+
+	rows, err := testPool.Query(ctx, `SELECT user_id FROM claims WHERE coupon_name = 'RETAIN_TEST' ORDER BY claim_sequence`)
+	require.NoError(t, err)
+	users, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	assert.True(t, strings.HasPrefix(users[0], "anonymized-"))
+	assert.True(t, strings.HasPrefix(users[1], "anonymized-"))
+	assert.NotEqual(t, users[0], users[1])
+	assert.Equal(t, "recent", users[2])
+}
+
+func TestTopUpCoupon_Integration(t *testing.T) {
+	cleanupTables(t)
+	createTestCoupon(t, "TOPUP_TEST", 1)
+
+	resp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{"user_id": "u1", "coupon_name": "TOPUP_TEST"})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = postJSON(formatURL("/api/coupons/TOPUP_TEST/top-up"), map[string]int{"amount": 2})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var coupon struct {
+		Amount          int `json:"amount"`
+		RemainingAmount int `json:"remaining_amount"`
+	}
+	require.NoError(t, readJSONResponse(resp, &coupon))
+	assert.Equal(t, 3, coupon.Amount)
+	assert.Equal(t, 2, coupon.RemainingAmount)
+
+	resp, err = postJSON(formatURL("/api/coupons/claim"), map[string]string{"user_id": "u2", "coupon_name": "TOPUP_TEST"})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "topped-up stock is claimable")
+}
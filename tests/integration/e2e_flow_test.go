@@ -387,3 +387,40 @@ func TestE2E_ValidationErrors(t *testing.T) {
 
 	t.Log("E2E validation errors verified!")
 }
+
+// TestE2E_ReadinessDetail tests the data sanity figures deployment smoke tests read:
+// the coupon count, and the latest claim once there is one.
+func TestE2E_ReadinessDetail(t *testing.T) {
+	cleanupTables(t)
+
+	readiness := func() map[string]interface{} {
+		resp, err := getJSON(formatURL("/readyz?detail=true"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var detail map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&detail))
+		return detail
+	}
+
+	detail := readiness()
+	assert.Equal(t, "ok", detail["status"])
+	assert.Equal(t, float64(0), detail["coupons"])
+	assert.Nil(t, detail["last_claim_at"], "no claim yet")
+	assert.Contains(t, detail["migration_phases"], "claims.claimed_at")
+
+	for _, name := range []string{"READY_A", "READY_B"} {
+		resp, err := postJSON(formatURL("/api/coupons"), map[string]interface{}{"name": name, "amount": 10})
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	resp, err := postJSON(formatURL("/api/coupons/claim"), map[string]string{"user_id": "user_001", "coupon_name": "READY_A"})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	detail = readiness()
+	assert.Equal(t, float64(2), detail["coupons"])
+	assert.NotNil(t, detail["last_claim_at"])
+}
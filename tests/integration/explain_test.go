@@ -0,0 +1,243 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/model"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/repository"
+)
+
+// errRecorded is returned to repositories by recorder instead of running statements.
+var errRecorded = errors.New("statement recorded, not run")
+
+// statement is a SQL statement a repository sent, with its arguments.
+type statement struct {
+	sql  string
+	args []any
+}
+
+// recorder implements the repositories' pool and transaction interfaces, recording
+// the statements they send instead of running them, so the test explains exactly the
+// SQL the repositories build.
+type recorder struct {
+	statements []statement
+}
+
+func (r *recorder) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	r.statements = append(r.statements, statement{sql, args})
+	return pgconn.CommandTag{}, errRecorded
+}
+
+func (r *recorder) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	r.statements = append(r.statements, statement{sql, args})
+	return recordedRow{}
+}
+
+func (r *recorder) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	r.statements = append(r.statements, statement{sql, args})
+	return nil, errRecorded
+}
+
+type recordedRow struct{}
+
+func (recordedRow) Scan(...any) error { return errRecorded }
+
+// plan is what the test checks of a statement's query plan.
+type plan struct {
+	indexes  []string // Indexes scanned or used as ON CONFLICT arbiters
+	seqScans []string // Relations read with a sequential scan
+	raw      string
+}
+
+// explain returns the plan of st with sequential scans disabled, so that even on the
+// near-empty test tables the planner picks an index whenever one can serve the
+// statement; a sequential scan then means no index can.
+func explain(t *testing.T, st statement) plan {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := testPool.Begin(ctx)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(ctx, "SET LOCAL enable_seqscan = off")
+	require.NoError(t, err)
+
+	var raw []byte
+	require.NoError(t, tx.QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+st.sql, st.args...).Scan(&raw))
+
+	var doc any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	p := plan{raw: string(raw)}
+	walkPlan(doc, &p)
+	return p
+}
+
+// walkPlan collects the indexes and sequential scans of every node below v.
+func walkPlan(v any, p *plan) {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			walkPlan(e, p)
+		}
+	case map[string]any:
+		if name, ok := v["Index Name"].(string); ok {
+			p.indexes = append(p.indexes, name)
+		}
+		if arbiters, ok := v["Conflict Arbiter Indexes"].([]any); ok {
+			for _, a := range arbiters {
+				p.indexes = append(p.indexes, a.(string))
+			}
+		}
+		if v["Node Type"] == "Seq Scan" {
+			p.seqScans = append(p.seqScans, v["Relation Name"].(string))
+		}
+		for _, e := range v {
+			walkPlan(e, p)
+		}
+	}
+}
+
+// anyOf lists indexes any one of which serves a statement equally well.
+type anyOf []string
+
+// TestExplain_HotQueriesUseIndexes guards the hot queries against schema changes that
+// drop or alter the indexes serving them. Each case records the statements a
+// repository method sends and requires their plans, taken together, to use one index of
+// each expected set and to read no table sequentially.
+func TestExplain_HotQueriesUseIndexes(t *testing.T) {
+	at := time.Now()
+
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, rec *recorder)
+		want []anyOf // Indexes the plans must use
+	}{
+		{
+			name: "HasClaimed (claim dedup check)",
+			run: func(ctx context.Context, rec *recorder) {
+				_, _ = repository.NewClaimRepositoryWithPool(rec).HasClaimed(ctx, "user_001", "PROMO")
+			},
+			want: []anyOf{{"claims_user_id_coupon_name_key", "idx_claims_user_id"}},
+		},
+		{
+			name: "GetCouponForUpdate (claim lock)",
+			run: func(ctx context.Context, rec *recorder) {
+				_, _ = repository.NewCouponRepositoryWithPool(rec).GetCouponForUpdate(ctx, rec, "PROMO")
+			},
+			want: []anyOf{{"coupons_pkey"}, {"coupon_channel_quotas_pkey"}, {"coupon_region_claims_pkey"}, {"coupon_stats_pkey"}},
+		},
+		{
+			name: "DecrementStock (claim)",
+			run: func(ctx context.Context, rec *recorder) {
+				_ = repository.NewCouponRepositoryWithPool(rec).DecrementStock(ctx, rec, "PROMO")
+			},
+			want: []anyOf{{"coupons_pkey"}},
+		},
+		{
+			name: "AdvanceClaimSequence (unlimited claim)",
+			run: func(ctx context.Context, rec *recorder) {
+				_ = repository.NewCouponRepositoryWithPool(rec).AdvanceClaimSequence(ctx, rec, "PROMO")
+			},
+			want: []anyOf{{"coupons_pkey"}},
+		},
+		{
+			name: "RecordClaimStats (claim)",
+			run: func(ctx context.Context, rec *recorder) {
+				_ = repository.NewCouponRepositoryWithPool(rec).RecordClaimStats(ctx, rec, "PROMO", at)
+			},
+			want: []anyOf{{"coupon_stats_pkey"}},
+		},
+		{
+			name: "Insert (claim)",
+			run: func(ctx context.Context, rec *recorder) {
+				_ = repository.NewClaimRepositoryWithPool(rec).Insert(ctx, rec, &model.Claim{
+					UserID: "user_001", CouponName: "PROMO", Sequence: 1,
+				})
+			},
+		},
+		{
+			name: "GetUsersByCoupon",
+			run: func(ctx context.Context, rec *recorder) {
+				_, _ = repository.NewClaimRepositoryWithPool(rec).GetUsersByCoupon(ctx, "PROMO", 0)
+			},
+			want: []anyOf{{"idx_claims_coupon_name", "idx_claims_coupon_sequence"}},
+		},
+		{
+			name: "CountByCoupon (truncated claimed_by)",
+			run: func(ctx context.Context, rec *recorder) {
+				_, _ = repository.NewClaimRepositoryWithPool(rec).CountByCoupon(ctx, "PROMO")
+			},
+			want: []anyOf{{"idx_claims_coupon_name", "idx_claims_coupon_sequence"}},
+		},
+		{
+			name: "ListBySubject (coupon changelog)",
+			run: func(ctx context.Context, rec *recorder) {
+				_, _ = repository.NewAuditRepositoryWithPool(rec).ListBySubject(ctx, "PROMO", model.CouponChangelogActions, nil, 100)
+			},
+			want: []anyOf{{"idx_audit_log_subject"}},
+		},
+		{
+			name: "ListBySubject (coupon changelog, next page)",
+			run: func(ctx context.Context, rec *recorder) {
+				after := &model.AuditPosition{CreatedAt: time.Now(), ID: 100}
+				_, _ = repository.NewAuditRepositoryWithPool(rec).ListBySubject(ctx, "PROMO", model.CouponChangelogActions, after, 100)
+			},
+			want: []anyOf{{"idx_audit_log_subject"}},
+		},
+		{
+			name: "ListByCoupon (claims page)",
+			run: func(ctx context.Context, rec *recorder) {
+				_, _ = repository.NewClaimRepositoryWithPool(rec).ListByCoupon(ctx, "PROMO", 100, 100)
+			},
+			want: []anyOf{{"idx_claims_coupon_sequence"}},
+		},
+		{
+			name: "ListBySequences (claim sample)",
+			run: func(ctx context.Context, rec *recorder) {
+				_, _ = repository.NewClaimRepositoryWithPool(rec).ListBySequences(ctx, "PROMO", []int{1, 5, 9})
+			},
+			want: []anyOf{{"idx_claims_coupon_sequence"}},
+		},
+		{
+			name: "GetByName (coupon and stats)",
+			run: func(ctx context.Context, rec *recorder) {
+				_, _ = repository.NewCouponRepositoryWithPool(rec).GetByName(ctx, "PROMO")
+			},
+			want: []anyOf{{"coupons_pkey"}, {"coupon_stats_pkey"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			tt.run(context.Background(), rec)
+			require.NotEmpty(t, rec.statements, "the repository sent no statement")
+
+			var used []string
+			for _, st := range rec.statements {
+				p := explain(t, st)
+				require.Empty(t, p.seqScans,
+					"no index serves %s; add one to scripts/init.sql or fix the query\nSQL: %s\nplan: %s",
+					p.seqScans, st.sql, p.raw)
+				used = append(used, p.indexes...)
+			}
+			for _, want := range tt.want {
+				require.True(t, slices.ContainsFunc(want, func(index string) bool { return slices.Contains(used, index) }),
+					"plans use none of %v (used %v); it may have been dropped or renamed", want, used)
+			}
+		})
+	}
+}
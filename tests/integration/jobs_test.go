@@ -0,0 +1,99 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/jobs"
+)
+
+func cleanupJobs(t *testing.T, queue string) {
+	t.Helper()
+	_, err := testPool.Exec(context.Background(), "DELETE FROM jobs WHERE queue = $1", queue)
+	require.NoError(t, err)
+}
+
+// TestJobs_ConcurrentWorkersRunEachJobOnce verifies that SKIP LOCKED hands every job to
+// exactly one of several workers polling the same queue.
+func TestJobs_ConcurrentWorkersRunEachJobOnce(t *testing.T) {
+	const queue = "integration_concurrent"
+	const total = 50
+	cleanupJobs(t, queue)
+	defer cleanupJobs(t, queue)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q := jobs.NewQueue(testPool)
+	for i := range total {
+		_, err := q.Enqueue(ctx, queue, map[string]int{"n": i})
+		require.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[int64]int)
+	var done atomic.Int64
+	handler := func(_ context.Context, job *jobs.Job) error {
+		mu.Lock()
+		seen[job.ID]++
+		mu.Unlock()
+		if done.Add(1) == total {
+			cancel()
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jobs.NewWorker(q, queue, handler, jobs.WorkerConfig{PollInterval: 10 * time.Millisecond}).Run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, total)
+	for id, n := range seen {
+		assert.Equal(t, 1, n, "job %d ran more than once", id)
+	}
+}
+
+// TestJobs_VisibilityTimeoutRedelivers verifies that an unacknowledged job is dequeued
+// again once its visibility timeout expires, and that the stale lease is rejected.
+func TestJobs_VisibilityTimeoutRedelivers(t *testing.T) {
+	const queue = "integration_visibility"
+	cleanupJobs(t, queue)
+	defer cleanupJobs(t, queue)
+
+	ctx := context.Background()
+	q := jobs.NewQueue(testPool)
+	_, err := q.Enqueue(ctx, queue, nil)
+	require.NoError(t, err)
+
+	first, err := q.Dequeue(ctx, queue, 100*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+
+	hidden, err := q.Dequeue(ctx, queue, 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.Nil(t, hidden, "a dequeued job is hidden until its visibility timeout")
+
+	time.Sleep(150 * time.Millisecond)
+	second, err := q.Dequeue(ctx, queue, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, 2, second.Attempt)
+
+	assert.ErrorIs(t, q.Complete(ctx, first), jobs.ErrLeaseLost)
+	assert.NoError(t, q.Complete(ctx, second))
+}
@@ -0,0 +1,50 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/pkg/database"
+)
+
+// TestListener_ReceivesCommittedNotifications verifies that a notification sent in a
+// transaction reaches the listener once the transaction commits, and never if it rolls
+// back.
+func TestListener_ReceivesCommittedNotifications(t *testing.T) {
+	const channel = "integration_listen"
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	listener := database.NewListener(testPool, channel)
+	payloads := make(chan string, 4)
+	go listener.Run(ctx, func(payload string) { payloads <- payload })
+	require.Eventually(t, func() bool { return listener.Stats().Connected }, 5*time.Second, 10*time.Millisecond)
+
+	notify := func(payload string, commit bool) {
+		tx, err := testPool.Begin(ctx)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, `SELECT pg_notify($1, $2)`, channel, payload)
+		require.NoError(t, err)
+		if commit {
+			require.NoError(t, tx.Commit(ctx))
+		} else {
+			require.NoError(t, tx.Rollback(ctx))
+		}
+	}
+	notify("ROLLED_BACK", false)
+	notify("PROMO", true)
+
+	select {
+	case payload := <-payloads:
+		assert.Equal(t, "PROMO", payload)
+	case <-ctx.Done():
+		t.Fatal("no notification received")
+	}
+	assert.Equal(t, int64(1), listener.Stats().Notifications)
+}
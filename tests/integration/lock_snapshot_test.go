@@ -0,0 +1,64 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fairyhunter13/scalable-coupon-system/internal/lockdiag"
+	"github.com/fairyhunter13/scalable-coupon-system/internal/repository"
+)
+
+// TestLockSnapshot_ShowsBlockingBackend verifies that a claim waiting for a coupon row
+// lock held by another transaction is dumped with the backend blocking it.
+func TestLockSnapshot_ShowsBlockingBackend(t *testing.T) {
+	cleanupTables(t)
+	createTestCoupon(t, "LOCK_SNAPSHOT", 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	holder, err := testPool.Begin(ctx)
+	require.NoError(t, err)
+	defer func() { _ = holder.Rollback(ctx) }()
+	_, err = holder.Exec(ctx, `SELECT name FROM coupons WHERE name = 'LOCK_SNAPSHOT' FOR UPDATE`)
+	require.NoError(t, err)
+	holderPID := holder.Conn().PgConn().PID()
+
+	diag := lockdiag.New(func(ctx context.Context, pid uint32) (lockdiag.Snapshot, error) {
+		return repository.LockSnapshot(ctx, testPool, pid)
+	}, 50*time.Millisecond, 5)
+	repo := repository.NewCouponRepository(testPool)
+	repo.SetLockWaitWatcher(diag)
+
+	waiter, err := testPool.Begin(ctx)
+	require.NoError(t, err)
+	defer func() { _ = waiter.Rollback(ctx) }()
+	waiterPID := waiter.Conn().PgConn().PID()
+	locked := make(chan error, 1)
+	go func() {
+		_, err := repo.GetCouponForUpdate(ctx, waiter, "LOCK_SNAPSHOT")
+		locked <- err
+	}()
+
+	require.Eventually(t, func() bool { return diag.Stats().Dumps == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, holder.Rollback(ctx))
+	require.NoError(t, <-locked)
+
+	dump := diag.Dumps()[0]
+	assert.Equal(t, waiterPID, dump.PID)
+	backends := map[uint32]lockdiag.Backend{}
+	for _, b := range dump.Backends {
+		backends[b.PID] = b
+	}
+	require.Contains(t, backends, waiterPID)
+	require.Contains(t, backends, holderPID)
+	assert.Equal(t, []uint32{holderPID}, backends[waiterPID].BlockedBy)
+	assert.Equal(t, "idle in transaction", backends[holderPID].State)
+	assert.Contains(t, backends[waiterPID].Query, "FOR UPDATE")
+	assert.NotEmpty(t, dump.Locks)
+}